@@ -0,0 +1,116 @@
+// bench load-tests the workflow engine to size workers.
+//
+// It starts N synthetic sessions against a scripted LLM (deterministic canned
+// responses, fixed latency) and reports turn latency, Update round-trip times
+// and worker throughput. Workflows and activities are the real ones; only the
+// LLM provider is replaced, so the numbers reflect Temporal and worker
+// overhead rather than provider speed.
+//
+// Usage:
+//
+//	bench -sessions 50 -turns 5                  In-process worker and driver
+//	bench -mode worker                           Worker only (add capacity from more hosts)
+//	bench -mode driver -sessions 500 -json       Driver only, against running bench workers
+//
+// Sessions run on their own task queue (-task-queue), so production workers
+// never pick them up.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	"github.com/mfateev/temporal-agent-harness/internal/bench"
+	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
+)
+
+func main() {
+	mode := flag.String("mode", "all", "all (worker + driver), worker, or driver")
+	sessions := flag.Int("sessions", 20, "Sessions to run")
+	turns := flag.Int("turns", 5, "Turns per session")
+	concurrency := flag.Int("concurrency", 0, "Sessions in flight at once (0 = all)")
+	toolCalls := flag.Int("tool-calls", 1, "Tool-call rounds per turn (list_dir activities)")
+	llmLatency := flag.Duration("llm-latency", 200*time.Millisecond, "Simulated latency of each LLM call")
+	turnTimeout := flag.Duration("turn-timeout", 2*time.Minute, "Deadline for each turn")
+	taskQueue := flag.String("task-queue", "temporal-agent-harness-bench", "Task queue for benchmark sessions")
+	temporalHost := flag.String("temporal-host", "", "Temporal server address (overrides envconfig/env vars)")
+	maxActivities := flag.Int("max-concurrent-activities", 0, "Worker MaxConcurrentActivityExecutionSize (0 = SDK default)")
+	maxWorkflowTasks := flag.Int("max-concurrent-workflow-tasks", 0, "Worker MaxConcurrentWorkflowTaskExecutionSize (0 = SDK default)")
+	jsonOut := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	runWorker := *mode == "all" || *mode == "worker"
+	runDriver := *mode == "all" || *mode == "driver"
+	if !runWorker && !runDriver {
+		log.Fatalf("Invalid -mode %q (want all, worker or driver)", *mode)
+	}
+	if *sessions <= 0 || *turns <= 0 {
+		log.Fatal("-sessions and -turns must be positive")
+	}
+
+	c, err := client.Dial(temporalclient.MustLoadClientOptions(*temporalHost, ""))
+	if err != nil {
+		log.Fatalf("Failed to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	scripted := &bench.ScriptedClient{Latency: *llmLatency, ToolCalls: *toolCalls, Dir: os.TempDir()}
+	if runWorker {
+		w := bench.NewWorker(c, *taskQueue, scripted, worker.Options{
+			MaxConcurrentActivityExecutionSize:     *maxActivities,
+			MaxConcurrentWorkflowTaskExecutionSize: *maxWorkflowTasks,
+		})
+		if err := w.Start(); err != nil {
+			log.Fatalf("Failed to start worker: %v", err)
+		}
+		defer w.Stop()
+		log.Printf("Bench worker polling %s", *taskQueue)
+	}
+
+	if !runDriver {
+		<-ctx.Done()
+		log.Printf("Served %d LLM calls, %d tool calls", scripted.Calls(), scripted.ToolCallsIssued())
+		return
+	}
+
+	log.Printf("Running %d sessions x %d turns on %s", *sessions, *turns, *taskQueue)
+	report := bench.Run(ctx, c, bench.Config{
+		TaskQueue:   *taskQueue,
+		Sessions:    *sessions,
+		Turns:       *turns,
+		Concurrency: *concurrency,
+		ToolCalls:   *toolCalls,
+		TurnTimeout: *turnTimeout,
+		RunID:       fmt.Sprintf("bench-%d", time.Now().Unix()),
+	})
+	// Call counts are only known when this process served them.
+	if runWorker {
+		report.LLMCalls = scripted.Calls()
+		report.ToolCalls = scripted.ToolCallsIssued()
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+	} else {
+		fmt.Print(report.Format())
+	}
+	if report.FailedSessions > 0 {
+		os.Exit(1)
+	}
+}
@@ -3,26 +3,61 @@
 // Sub-commands:
 //
 //	start    --message "..."         Start a new workflow, print workflow ID
+//	start    --template <name> [--var k=v]...  Start from a session template
 //	send     --workflow-id <id> --message "..."  Send a user_input Update
-//	history  --workflow-id <id>      Query conversation history
+//	developer --workflow-id <id> --message "..." [--start-turn]  Send a developer_input Update
+//	history  --workflow-id <id> [--type t,t] [--turn id] [--since 1h] [--contains "..."] [--limit n]  Query conversation history
+//	watch    --workflow-id <id> [--follow] [--json]  Print session events, as NDJSON with --json
 //	interrupt --workflow-id <id>     Send interrupt Update
 //	end      --workflow-id <id>      Send shutdown Update
+//	tag      --workflow-id <id> [--add t] [--remove t] [--note "..."]  Edit session tags/note
+//	pause    --workflow-id <id> [--reason "..."]  Pause the session between turns
+//	resume   --workflow-id <id>      Resume a paused session
+//	remap-cwd --workflow-id <id> [--cwd <dir>]  Move a session to another checkout of its repository
+//	list     [--tag t]               List running sessions, optionally filtered by tag
+//	occupancy [--harness-id <id>]    Show running and queued harness sessions
+//	session-limit [--harness-id <id>] --max N  Change the harness's concurrent session limit
+//	report   --workflow-id <id> --out report.html  Export the conversation as a standalone HTML page
+//	logs     --workflow-id <id> [--file worker.log] [--follow]  Show one session's worker log records
+//	capabilities --workflow-id <id>  Show the tools, providers and sandboxes of the session's worker
+//	network  --workflow-id <id> [--json]  Check the session worker's connections to LLM providers and MCP servers
+//	exec-write --workflow-id <id> --session <n> --chars "..."  Type into a running exec_command process
+//	experiment --prompt-file p.txt --models a,b[,c] [--judge-model m]  Compare models on one prompt
+//	bulk     --query 'status=running AND repo=/x' shutdown|interrupt|tag  Act on many sessions at once
+//	usage    [--since 7d] [--prices m=in:out] [--json]  Usage of completed sessions by team, model and day
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/google/uuid"
+	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 
+	"github.com/mfateev/temporal-agent-harness/internal/cli"
+	"github.com/mfateev/temporal-agent-harness/internal/logging"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/netconfig"
+	"github.com/mfateev/temporal-agent-harness/internal/templates"
+	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
+	"github.com/mfateev/temporal-agent-harness/internal/usage"
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+	"github.com/mfateev/temporal-agent-harness/pkg/harness"
 )
 
 const (
@@ -41,12 +76,46 @@ func main() {
 		cmdStart(os.Args[2:])
 	case "send":
 		cmdSend(os.Args[2:])
+	case "developer":
+		cmdDeveloper(os.Args[2:])
 	case "history":
 		cmdHistory(os.Args[2:])
 	case "interrupt":
 		cmdInterrupt(os.Args[2:])
 	case "end":
 		cmdEnd(os.Args[2:])
+	case "tag":
+		cmdTag(os.Args[2:])
+	case "pause":
+		cmdPause(os.Args[2:])
+	case "resume":
+		cmdResume(os.Args[2:])
+	case "list":
+		cmdList(os.Args[2:])
+	case "occupancy":
+		cmdOccupancy(os.Args[2:])
+	case "session-limit":
+		cmdSessionLimit(os.Args[2:])
+	case "report":
+		cmdReport(os.Args[2:])
+	case "logs":
+		cmdLogs(os.Args[2:])
+	case "capabilities":
+		cmdCapabilities(os.Args[2:])
+	case "network":
+		cmdNetwork(os.Args[2:])
+	case "exec-write":
+		cmdExecWrite(os.Args[2:])
+	case "experiment":
+		cmdExperiment(os.Args[2:])
+	case "bulk":
+		cmdBulk(os.Args[2:])
+	case "usage":
+		cmdUsage(os.Args[2:])
+	case "remap-cwd":
+		cmdRemapCwd(os.Args[2:])
+	case "watch":
+		cmdWatch(os.Args[2:])
 	default:
 		log.Fatalf("Unknown sub-command: %s\n\n", subcommand)
 		printUsage()
@@ -60,30 +129,99 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "Commands:")
 	fmt.Fprintln(os.Stderr, "  start      Start a new agentic workflow")
 	fmt.Fprintln(os.Stderr, "  send       Send a user message to a running workflow")
+	fmt.Fprintln(os.Stderr, "  developer  Send developer instructions (not a user message) to a running workflow")
 	fmt.Fprintln(os.Stderr, "  history    Query conversation history")
+	fmt.Fprintln(os.Stderr, "  watch      Print a session's events (items, phases, approvals); --json for scripts")
 	fmt.Fprintln(os.Stderr, "  interrupt  Interrupt the current turn")
 	fmt.Fprintln(os.Stderr, "  end        Shutdown the workflow")
+	fmt.Fprintln(os.Stderr, "  tag        Add/remove session tags and set a note")
+	fmt.Fprintln(os.Stderr, "  pause      Pause a session: it rejects new turns until resumed")
+	fmt.Fprintln(os.Stderr, "  resume     Resume a paused session")
+	fmt.Fprintln(os.Stderr, "  remap-cwd  Move a session to another checkout of its repository (e.g. on another machine)")
+	fmt.Fprintln(os.Stderr, "  list       List running sessions (--tag requires the AgentTags search attribute)")
+	fmt.Fprintln(os.Stderr, "  occupancy  Show running and queued sessions of a harness")
+	fmt.Fprintln(os.Stderr, "  session-limit  Change a harness's max concurrent sessions")
+	fmt.Fprintln(os.Stderr, "  report     Export the conversation as a shareable HTML page")
+	fmt.Fprintln(os.Stderr, "  logs       Show a session's records from the worker log file")
+	fmt.Fprintln(os.Stderr, "  capabilities  Show what the worker serving a session supports")
+	fmt.Fprintln(os.Stderr, "  network    Check that a session's worker reaches its LLM providers and MCP servers (proxy, CA bundle)")
+	fmt.Fprintln(os.Stderr, "  exec-write Type into an exec_command process left running by the agent")
+	fmt.Fprintln(os.Stderr, "  experiment Run one prompt across 2-3 models and compare responses, tokens and latency")
+	fmt.Fprintln(os.Stderr, "  bulk       Shut down, interrupt or tag every session matching a filter")
+	fmt.Fprintln(os.Stderr, "  usage      Roll up tokens, cost and tool calls of recently completed sessions by team and model")
 }
 
 func dialTemporal() client.Client {
-	c, err := client.Dial(client.Options{
-		HostPort: client.DefaultHostPort,
-	})
+	c, _ := dialTemporalWithConverter()
+	return c
+}
+
+// dialTemporalWithConverter also returns the data converter needed to decode
+// memo payloads (encrypted when a payload codec is configured). A nil
+// converter means the SDK default.
+func dialTemporalWithConverter() (client.Client, converter.DataConverter) {
+	opts, err := temporalclient.LoadClientOptions("", "")
+	if err != nil {
+		log.Fatalf("Failed to load Temporal client options: %v", err)
+	}
+	// Reads (history, list) fail over to TEMPORAL_STANDBY_* when configured.
+	c, err := temporalclient.DialFailover(opts, "", "")
 	if err != nil {
 		log.Fatalf("Failed to create Temporal client: %v", err)
 	}
-	return c
+	return c, opts.DataConverter
 }
 
 // cmdStart starts a new agentic workflow.
 func cmdStart(args []string) {
 	fs := flag.NewFlagSet("start", flag.ExitOnError)
-	message := fs.String("message", "", "User message to send to the agent (required)")
-	model := fs.String("model", "gpt-4o-mini", "LLM model to use")
+	message := fs.String("message", "", "User message to send to the agent (required unless --template is set)")
+	model := fs.String("model", "", "LLM model to use (default: from template, else gpt-4o-mini)")
+	templateName := fs.String("template", "", "Session template in <codex-home>/templates/sessions")
+	vars := templates.VarFlags{}
+	fs.Var(vars, "var", "Template variable as name=value (repeatable)")
+	codexHome := fs.String("codex-home", "", "Path to codex config directory (default: ~/.codex)")
 	fs.Parse(args)
 
+	modelConfig := models.ModelConfig{
+		Model:         *model,
+		Temperature:   0.7,
+		MaxTokens:     4096,
+		ContextWindow: 128000,
+	}
+	tools := models.DefaultToolsConfig()
+	if *templateName != "" {
+		if *message != "" {
+			log.Fatal("Error: --message and --template are mutually exclusive")
+		}
+		home := *codexHome
+		if home == "" {
+			userHome, _ := os.UserHomeDir()
+			home = filepath.Join(userHome, ".codex")
+		}
+		tmpl, err := templates.Load(home, *templateName)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if *message, err = tmpl.Expand(vars); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if modelConfig.Model == "" {
+			modelConfig.Model = tmpl.Model
+			modelConfig.Provider = tmpl.Provider
+		}
+		for _, name := range tmpl.Tools {
+			if !tools.HasTool(name) {
+				tools.AddTools(name)
+			}
+		}
+	}
+	if modelConfig.Model == "" {
+		modelConfig.Model = "gpt-4o-mini"
+	}
+
 	if *message == "" {
-		log.Fatal("Error: --message is required\n\nUsage: client start --message \"Your message here\"")
+		log.Fatal("Error: --message or --template is required\n\nUsage: client start --message \"Your message here\"")
 	}
 
 	c := dialTemporal()
@@ -96,37 +234,25 @@ func cmdStart(args []string) {
 		cwd = ""
 	}
 
-	input := workflow.WorkflowInput{
-		ConversationID: workflowID,
-		UserMessage:    *message,
-		Config: models.SessionConfiguration{
-			Model: models.ModelConfig{
-				Model:         *model,
-				Temperature:   0.7,
-				MaxTokens:     4096,
-				ContextWindow: 128000,
-			},
-			Tools: models.DefaultToolsConfig(),
-			Cwd:           cwd,
-			SessionSource: "cli",
-		},
-	}
-
 	log.Printf("Starting workflow: %s", workflowID)
 	log.Printf("Message: %s", *message)
 
-	ctx := context.Background()
-	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-		ID:        workflowID,
-		TaskQueue: TaskQueue,
-	}, "AgenticWorkflow", input)
+	_, err = harness.NewClient(c, harness.Options{TaskQueue: TaskQueue}).Start(context.Background(), harness.StartOptions{
+		ID:      workflowID,
+		Message: *message,
+		Config: harness.SessionConfig{
+			Model:         modelConfig,
+			Tools:         tools,
+			Cwd:           cwd,
+			SessionSource: "cli",
+		},
+	})
 	if err != nil {
 		log.Fatalf("Failed to start workflow: %v", err)
 	}
 
 	log.Printf("Workflow started successfully")
 	log.Printf("Workflow ID: %s", workflowID)
-	log.Printf("Run ID: %s", run.GetRunID())
 	log.Printf("Temporal UI: http://localhost:8233/namespaces/default/workflows/%s", workflowID)
 
 	// Print workflow ID on stdout for scripting
@@ -138,6 +264,7 @@ func cmdSend(args []string) {
 	fs := flag.NewFlagSet("send", flag.ExitOnError)
 	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
 	message := fs.String("message", "", "User message (required)")
+	idempotencyKey := fs.String("idempotency-key", "", "Key identifying this message; resending with the same key does not start another turn (default: random)")
 	fs.Parse(args)
 
 	if *workflowID == "" || *message == "" {
@@ -150,46 +277,103 @@ func cmdSend(args []string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
-		WorkflowID:   *workflowID,
-		UpdateName:   workflow.UpdateUserInput,
-		Args:         []interface{}{workflow.UserInput{Content: *message}},
-		WaitForStage: client.WorkflowUpdateStageCompleted,
-	})
+	sess := harness.NewClient(c, harness.Options{TaskQueue: TaskQueue}).Session(*workflowID)
+	turnID, err := sess.Send(ctx, harness.UserInput{Content: *message, IdempotencyKey: *idempotencyKey})
 	if err != nil {
 		log.Fatalf("Failed to send user input: %v", err)
 	}
 
-	var resp workflow.StateUpdateResponse
-	if err := updateHandle.Get(ctx, &resp); err != nil {
-		log.Fatalf("Update failed: %v", err)
+	log.Printf("Message accepted, turn ID: %s", turnID)
+	fmt.Println(turnID)
+}
+
+// cmdDeveloper sends a developer_input Update to a running workflow, for
+// automation that steers the agent without posting as the user.
+func cmdDeveloper(args []string) {
+	fs := flag.NewFlagSet("developer", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	message := fs.String("message", "", "Developer instructions (required)")
+	startTurn := fs.Bool("start-turn", false, "Start a new turn with the instructions (default: the current or next turn sees them)")
+	fs.Parse(args)
+
+	if *workflowID == "" || *message == "" {
+		log.Fatal("Error: --workflow-id and --message are required")
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sess := harness.NewClient(c, harness.Options{TaskQueue: TaskQueue}).Session(*workflowID)
+	turnID, err := sess.SendDeveloper(ctx, harness.DeveloperInput{Content: *message, StartTurn: *startTurn})
+	if err != nil {
+		log.Fatalf("Failed to send developer input: %v", err)
 	}
 
-	log.Printf("Message accepted, turn ID: %s", resp.TurnID)
-	fmt.Println(resp.TurnID)
+	log.Printf("Developer input accepted, turn ID: %s", turnID)
+	fmt.Println(turnID)
 }
 
-// cmdHistory queries the conversation history.
+// cmdHistory queries the conversation history. With filters it runs the
+// get_history query and prints one page; the token for the next page goes to
+// stderr, so stdout stays a JSON array of items.
 func cmdHistory(args []string) {
 	fs := flag.NewFlagSet("history", flag.ExitOnError)
 	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	types := fs.String("type", "", "Comma-separated item types to keep, e.g. assistant_message or function_call,function_call_output")
+	turnID := fs.String("turn", "", "Keep the items of this turn ID")
+	since := fs.String("since", "", "Keep items added in this period back from now (12h, 7d) or since an RFC 3339 time")
+	contains := fs.String("contains", "", "Keep items whose text contains this string, ignoring case")
+	newestFirst := fs.Bool("newest-first", false, "List matches from the end of the conversation")
+	limit := fs.Int("limit", 0, fmt.Sprintf("Page size (default %d, at most %d)", workflow.DefaultHistoryPageSize, workflow.MaxHistoryPageSize))
+	pageToken := fs.String("page-token", "", "Continue from a previous page")
 	fs.Parse(args)
 
 	if *workflowID == "" {
 		log.Fatal("Error: --workflow-id is required")
 	}
+	filtered := false
+	fs.Visit(func(f *flag.Flag) { filtered = filtered || f.Name != "workflow-id" })
 
 	c := dialTemporal()
 	defer c.Close()
+	sess := harness.NewClient(c, harness.Options{TaskQueue: TaskQueue}).Session(*workflowID)
 
-	resp, err := c.QueryWorkflow(context.Background(), *workflowID, "", workflow.QueryGetConversationItems)
-	if err != nil {
-		log.Fatalf("Failed to query history: %v", err)
-	}
-
-	var items []models.ConversationItem
-	if err := resp.Get(&items); err != nil {
-		log.Fatalf("Failed to decode history: %v", err)
+	var items []harness.Item
+	if filtered {
+		q := harness.HistoryQuery{
+			TurnID:      *turnID,
+			Contains:    *contains,
+			NewestFirst: *newestFirst,
+			Limit:       *limit,
+			PageToken:   *pageToken,
+		}
+		for _, t := range strings.Split(*types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				q.Types = append(q.Types, models.ConversationItemType(t))
+			}
+		}
+		if *since != "" {
+			var err error
+			if q.Since, err = parseSince(*since, time.Now()); err != nil {
+				log.Fatalf("Error: --since: %v", err)
+			}
+		}
+		page, err := sess.History(context.Background(), q)
+		if err != nil {
+			log.Fatalf("Failed to query history: %v", err)
+		}
+		items = page.Items
+		if page.NextPageToken != "" {
+			fmt.Fprintf(os.Stderr, "More items: --page-token %s\n", page.NextPageToken)
+		}
+	} else {
+		var err error
+		if items, err = sess.Items(context.Background()); err != nil {
+			log.Fatalf("Failed to query history: %v", err)
+		}
 	}
 
 	// Print items as JSON
@@ -200,9 +384,92 @@ func cmdHistory(args []string) {
 	fmt.Println(string(data))
 }
 
-// cmdInterrupt sends an interrupt Update.
-func cmdInterrupt(args []string) {
-	fs := flag.NewFlagSet("interrupt", flag.ExitOnError)
+// parseSince parses history --since: an RFC 3339 time, or a period back
+// from now as for usage --since.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	period, err := parsePeriod(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v, or an RFC 3339 time", err)
+	}
+	return now.Add(-period), nil
+}
+
+// cmdWatch prints a session's events: the items so far, then, with
+// --follow, new items, phase changes, pending approvals and completed turns
+// until the session ends. With --json each event is one line of JSON, for
+// scripts that orchestrate sessions without the TUI.
+func cmdWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	follow := fs.Bool("follow", false, "Keep printing events until the session ends or is interrupted")
+	jsonOut := fs.Bool("json", false, "Print newline-delimited JSON events")
+	fs.Parse(args)
+
+	if *workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stream := watch.NewEventStream(*workflowID)
+	emit := func(result watch.Result) {
+		for _, e := range stream.Events(result) {
+			if *jsonOut {
+				if err := watch.WriteEvent(os.Stdout, e); err != nil {
+					log.Fatalf("Failed to write event: %v", err)
+				}
+			} else {
+				fmt.Println(watch.FormatEvent(e))
+			}
+		}
+	}
+
+	poll := watch.NewPoller(c, *workflowID, 0).Poll(ctx)
+	if poll.Err != nil {
+		log.Fatalf("Failed to query session: %v", poll.Err)
+	}
+	emit(watch.Result{Items: poll.Items, Status: poll.Status})
+	if !*follow {
+		return
+	}
+
+	sinceSeq := -1
+	if n := len(poll.Items); n > 0 {
+		sinceSeq = poll.Items[n-1].Seq
+	}
+	ch := make(chan watch.Result)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watch.NewWatcher(c, *workflowID).RunWatching(ctx, ch, sinceSeq, poll.Status.Phase)
+	}()
+	// RunWatching retries failed watches itself and returns after the
+	// session completes or it gives up; errors are reported as events.
+	var last watch.Result
+	for {
+		select {
+		case last = <-ch:
+			emit(last)
+		case <-done:
+			if last.Err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+	}
+}
+
+// cmdCapabilities queries what the worker serving a session supports and
+// warns about enabled tools it has no handler for.
+func cmdCapabilities(args []string) {
+	fs := flag.NewFlagSet("capabilities", flag.ExitOnError)
 	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
 	fs.Parse(args)
 
@@ -213,25 +480,150 @@ func cmdInterrupt(args []string) {
 	c := dialTemporal()
 	defer c.Close()
 
+	resp, err := c.QueryWorkflow(context.Background(), *workflowID, "", workflow.QueryGetCapabilities)
+	if err != nil {
+		log.Fatalf("Failed to query capabilities: %v", err)
+	}
+
+	var caps workflow.CapabilitiesResponse
+	if err := resp.Get(&caps); err != nil {
+		log.Fatalf("Failed to decode capabilities: %v", err)
+	}
+	if len(caps.MissingTools) > 0 {
+		log.Printf("Warning: worker %s lacks tools enabled for this session: %s",
+			caps.Worker.Version, strings.Join(caps.MissingTools, ", "))
+	}
+
+	data, err := json.MarshalIndent(caps, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal capabilities: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// cmdNetwork sends a diagnose_network Update: the worker serving the session
+// checks its LLM provider endpoints and the session's HTTP MCP servers with
+// its proxy and CA bundle settings. Exits 1 when a check fails.
+func cmdNetwork(args []string) {
+	fs := flag.NewFlagSet("network", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	jsonOut := fs.Bool("json", false, "Print the results as JSON")
+	fs.Parse(args)
+
+	if *workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   *workflowID,
+		UpdateName:   workflow.UpdateDiagnoseNetwork,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		log.Fatalf("Failed to send diagnose_network update: %v", err)
+	}
+
+	var resp struct {
+		Results []netconfig.CheckResult `json:"results"`
+	}
+	if err := updateHandle.Get(ctx, &resp); err != nil {
+		log.Fatalf("Failed to diagnose network: %v", err)
+	}
+
+	failed := false
+	for _, r := range resp.Results {
+		failed = failed || !r.OK
+	}
+	if *jsonOut {
+		data, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal results: %v", err)
+		}
+		fmt.Println(string(data))
+	} else if len(resp.Results) == 0 {
+		fmt.Println("No endpoints to check")
+	} else {
+		for _, r := range resp.Results {
+			fmt.Println(r)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// cmdExecWrite sends a write_exec_stdin Update, typing into a process the
+// agent left running, and prints the process's latest output. It works
+// while a turn is running.
+func cmdExecWrite(args []string) {
+	fs := flag.NewFlagSet("exec-write", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	session := fs.String("session", "", "Exec session ID, as shown by /sessions (required)")
+	chars := fs.String("chars", "", "Text to type; a newline is appended unless it ends with one")
+	fs.Parse(args)
+
+	if *workflowID == "" || *session == "" {
+		log.Fatal("Error: --workflow-id and --session are required")
+	}
+	text := *chars
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
 		WorkflowID:   *workflowID,
-		UpdateName:   workflow.UpdateInterrupt,
-		Args:         []interface{}{workflow.InterruptRequest{}},
+		UpdateName:   workflow.UpdateWriteExecStdin,
+		Args:         []interface{}{workflow.WriteExecStdinRequest{ProcessID: *session, Chars: text}},
 		WaitForStage: client.WorkflowUpdateStageCompleted,
 	})
 	if err != nil {
-		log.Fatalf("Failed to send interrupt: %v", err)
+		log.Fatalf("Failed to send write_exec_stdin update: %v", err)
 	}
 
-	var resp workflow.InterruptResponse
+	var resp workflow.WriteExecStdinResponse
 	if err := updateHandle.Get(ctx, &resp); err != nil {
+		log.Fatalf("Failed to write to exec session: %v", err)
+	}
+	fmt.Println(strings.TrimRight(resp.Output, "\n"))
+	if resp.Exited {
+		fmt.Println("(process exited)")
+	}
+}
+
+// cmdInterrupt sends an interrupt Update.
+func cmdInterrupt(args []string) {
+	fs := flag.NewFlagSet("interrupt", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	fs.Parse(args)
+
+	if *workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sess := harness.NewClient(c, harness.Options{TaskQueue: TaskQueue}).Session(*workflowID)
+	if err := sess.Interrupt(ctx); err != nil {
 		log.Fatalf("Interrupt failed: %v", err)
 	}
 
-	log.Printf("Interrupt acknowledged: %v", resp.Acknowledged)
+	log.Printf("Interrupt acknowledged")
 }
 
 // cmdEnd sends a shutdown Update.
@@ -251,20 +643,834 @@ func cmdEnd(args []string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	sess := harness.NewClient(c, harness.Options{TaskQueue: TaskQueue}).Session(*workflowID)
+	if err := sess.End(ctx, *reason); err != nil {
+		log.Fatalf("Shutdown failed: %v", err)
+	}
+
+	log.Printf("Shutdown acknowledged")
+}
+
+// stringList is a repeatable flag that also accepts comma-separated values.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// cmdTag sends a set_session_tags Update.
+func cmdTag(args []string) {
+	fs := flag.NewFlagSet("tag", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	var add, remove stringList
+	fs.Var(&add, "add", "Tag to add (repeatable or comma-separated)")
+	fs.Var(&remove, "remove", "Tag to remove (repeatable or comma-separated)")
+	note := fs.String("note", "", "Session note (pass an empty string to clear)")
+	fs.Parse(args)
+
+	if *workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+
+	req := workflow.SetSessionTagsRequest{Add: add, Remove: remove}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "note" {
+			req.Note = note
+		}
+	})
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
 	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
 		WorkflowID:   *workflowID,
-		UpdateName:   workflow.UpdateShutdown,
-		Args:         []interface{}{workflow.ShutdownRequest{Reason: *reason}},
+		UpdateName:   workflow.UpdateSessionTags,
+		Args:         []interface{}{req},
 		WaitForStage: client.WorkflowUpdateStageCompleted,
 	})
 	if err != nil {
-		log.Fatalf("Failed to send shutdown: %v", err)
+		log.Fatalf("Failed to send tag update: %v", err)
 	}
 
-	var resp workflow.ShutdownResponse
+	var resp workflow.SetSessionTagsResponse
 	if err := updateHandle.Get(ctx, &resp); err != nil {
-		log.Fatalf("Shutdown failed: %v", err)
+		log.Fatalf("Tag update failed: %v", err)
 	}
 
-	log.Printf("Shutdown acknowledged: %v", resp.Acknowledged)
+	fmt.Printf("Tags: %s\n", strings.Join(resp.Tags, ", "))
+	if resp.Note != "" {
+		fmt.Printf("Note: %s\n", resp.Note)
+	}
+}
+
+// cmdPause sends a pause Update.
+func cmdPause(args []string) {
+	fs := flag.NewFlagSet("pause", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	reason := fs.String("reason", "", "Why the session is paused (shown by `client list`)")
+	fs.Parse(args)
+
+	resp := sendPauseUpdate(*workflowID, workflow.UpdatePause, workflow.PauseRequest{Reason: *reason})
+	fmt.Printf("Paused %s at %s\n", *workflowID, resp.Paused.Since.Local().Format(time.RFC1123))
+}
+
+// cmdResume sends a resume Update.
+func cmdResume(args []string) {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	fs.Parse(args)
+
+	resp := sendPauseUpdate(*workflowID, workflow.UpdateResume, workflow.ResumeRequest{})
+	fmt.Printf("Resumed %s after %s\n", *workflowID, resp.PausedFor.Round(time.Second))
+}
+
+// cmdRemapCwd sends a remap_cwd Update, moving the session's working
+// directory, e.g. to this machine's checkout after resuming it here.
+func cmdRemapCwd(args []string) {
+	fs := flag.NewFlagSet("remap-cwd", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	cwd := fs.String("cwd", "", "New working directory on the session's worker (default: the current directory)")
+	fs.Parse(args)
+
+	if *workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+	dir := *cwd
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			log.Fatalf("Failed to get the current directory: %v", err)
+		}
+		dir = wd
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   *workflowID,
+		UpdateName:   workflow.UpdateRemapCwd,
+		Args:         []interface{}{workflow.RemapCwdRequest{Cwd: dir}},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		log.Fatalf("Failed to send remap_cwd update: %v", err)
+	}
+	var resp workflow.RemapCwdResponse
+	if err := updateHandle.Get(ctx, &resp); err != nil {
+		log.Fatalf("Update failed: %v", err)
+	}
+	fmt.Printf("Moved %s from %s to %s\n", *workflowID, resp.PreviousCwd, resp.Cwd)
+	if !resp.ProjectDocs {
+		fmt.Println("No project docs (AGENTS.md) found under the new directory.")
+	}
+}
+
+// sendPauseUpdate sends the pause or resume Update and returns its response.
+func sendPauseUpdate(workflowID, updateName string, req interface{}) workflow.PauseResponse {
+	if workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   updateName,
+		Args:         []interface{}{req},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		log.Fatalf("Failed to send %s update: %v", updateName, err)
+	}
+
+	var resp workflow.PauseResponse
+	if err := updateHandle.Get(ctx, &resp); err != nil {
+		log.Fatalf("%s failed: %v", updateName, err)
+	}
+	return resp
+}
+
+// cmdList lists running sessions with their titles, tags and notes.
+func cmdList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	tag := fs.String("tag", "", "Only list sessions with this tag (requires the AgentTags search attribute)")
+	fs.Parse(args)
+
+	c, dc := dialTemporalWithConverter()
+	defer c.Close()
+
+	query := "WorkflowType = 'AgenticWorkflow' AND ExecutionStatus = 'Running'"
+	if *tag != "" {
+		query += fmt.Sprintf(" AND %s = '%s'", workflow.TagsSearchAttribute.GetName(), strings.ReplaceAll(*tag, "'", ""))
+	}
+
+	resp, err := c.ListWorkflow(context.Background(), &workflowservice.ListWorkflowExecutionsRequest{
+		Query: query,
+	})
+	if err != nil {
+		log.Fatalf("Failed to list workflows: %v", err)
+	}
+
+	for _, exec := range resp.GetExecutions() {
+		line := exec.GetExecution().GetWorkflowId()
+		if title := workflow.SessionTitleFromMemo(exec.GetMemo(), dc); title != "" {
+			line += "  " + title
+		}
+		tags, note := workflow.SessionMetadataFromMemo(exec.GetMemo(), dc)
+		if len(tags) > 0 {
+			line += "  [" + strings.Join(tags, ", ") + "]"
+		}
+		if note != "" {
+			line += "  " + note
+		}
+		if p := workflow.SessionPauseFromMemo(exec.GetMemo(), dc); p != nil {
+			line += "  (paused since " + p.Since.Local().Format("Jan 02, 15:04")
+			if p.Reason != "" {
+				line += ": " + p.Reason
+			}
+			line += ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+// harnessIDFlag registers --harness-id, defaulting to the harness tcx uses
+// for the current directory.
+func harnessIDFlag(fs *flag.FlagSet) *string {
+	cwd, _ := os.Getwd()
+	return fs.String("harness-id", cli.HarnessWorkflowID(cwd), "Harness workflow ID (default: harness for the current directory)")
+}
+
+// printOccupancy prints a harness's session occupancy.
+func printOccupancy(occ workflow.HarnessOccupancy) {
+	limit := "unlimited"
+	if occ.MaxConcurrentSessions > 0 {
+		limit = fmt.Sprintf("%d", occ.MaxConcurrentSessions)
+	}
+	fmt.Printf("Active: %d (limit: %s)\n", occ.Active, limit)
+	fmt.Printf("Queued: %d\n", len(occ.Queued))
+	for i, id := range occ.Queued {
+		fmt.Printf("  %d. %s\n", i+1, id)
+	}
+}
+
+// cmdOccupancy shows how many sessions a harness is running and queuing.
+func cmdOccupancy(args []string) {
+	fs := flag.NewFlagSet("occupancy", flag.ExitOnError)
+	harnessID := harnessIDFlag(fs)
+	fs.Parse(args)
+
+	c := dialTemporal()
+	defer c.Close()
+
+	resp, err := c.QueryWorkflow(context.Background(), *harnessID, "", workflow.QueryGetOccupancy)
+	if err != nil {
+		log.Fatalf("Failed to query occupancy: %v", err)
+	}
+	var occ workflow.HarnessOccupancy
+	if err := resp.Get(&occ); err != nil {
+		log.Fatalf("Failed to decode occupancy: %v", err)
+	}
+	printOccupancy(occ)
+}
+
+// cmdSessionLimit changes the max concurrent sessions of a running harness.
+func cmdSessionLimit(args []string) {
+	fs := flag.NewFlagSet("session-limit", flag.ExitOnError)
+	harnessID := harnessIDFlag(fs)
+	limit := fs.Int("max", -1, "Max concurrent sessions (0 = unlimited, required)")
+	fs.Parse(args)
+
+	if *limit < 0 {
+		log.Fatal("Error: --max is required")
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   *harnessID,
+		UpdateName:   workflow.UpdateSetSessionLimit,
+		Args:         []interface{}{workflow.SetSessionLimitRequest{MaxConcurrentSessions: *limit}},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		log.Fatalf("Failed to send session limit update: %v", err)
+	}
+
+	var occ workflow.HarnessOccupancy
+	if err := updateHandle.Get(ctx, &occ); err != nil {
+		log.Fatalf("Session limit update failed: %v", err)
+	}
+	printOccupancy(occ)
+}
+
+// cmdReport renders the conversation as a standalone HTML page for sharing
+// with people who do not use the CLI.
+func cmdReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	out := fs.String("out", "", "Output HTML file (required)")
+	title := fs.String("title", "", "Page title (default: the workflow ID)")
+	inputPrice := fs.Float64("input-price", 0, "USD per million input tokens, for the cost estimate")
+	outputPrice := fs.Float64("output-price", 0, "USD per million output tokens, for the cost estimate")
+	fs.Parse(args)
+
+	if *workflowID == "" || *out == "" {
+		log.Fatal("Error: --workflow-id and --out are required")
+	}
+	if *title == "" {
+		*title = *workflowID
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	resp, err := c.QueryWorkflow(context.Background(), *workflowID, "", workflow.QueryGetConversationItems)
+	if err != nil {
+		log.Fatalf("Failed to query history: %v", err)
+	}
+	var items []models.ConversationItem
+	if err := resp.Get(&items); err != nil {
+		log.Fatalf("Failed to decode history: %v", err)
+	}
+
+	resp, err = c.QueryWorkflow(context.Background(), *workflowID, "", workflow.QueryGetTurnStatus)
+	if err != nil {
+		log.Fatalf("Failed to query turn status: %v", err)
+	}
+	var status workflow.TurnStatus
+	if err := resp.Get(&status); err != nil {
+		log.Fatalf("Failed to decode turn status: %v", err)
+	}
+
+	usage := transcript.Usage{
+		TotalTokens:  status.TotalTokens,
+		InputTokens:  status.TotalInputTokens,
+		CachedTokens: status.TotalCachedTokens,
+		Turns:        status.TurnCount,
+	}
+	if *inputPrice > 0 || *outputPrice > 0 {
+		usage.CostUSD = transcript.EstimateCost(usage, *inputPrice, *outputPrice)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+	defer f.Close()
+	err = transcript.WriteHTML(f, transcript.Report{
+		Title:       *title,
+		GeneratedAt: time.Now(),
+		Items:       items,
+		Usage:       usage,
+	})
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	log.Printf("Wrote %s (%d items)", *out, len(items))
+}
+
+// cmdLogs prints the worker log records of one session (and its subagents)
+// from the worker's log file. The worker must log to a file this client can
+// read (WORKER_LOG_FILE); either format works, json is easiest to search.
+func cmdLogs(args []string) {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	workflowID := fs.String("workflow-id", "", "Workflow ID (required)")
+	file := fs.String("file", os.Getenv("WORKER_LOG_FILE"), "Worker log file (default: $WORKER_LOG_FILE)")
+	follow := fs.Bool("follow", false, "Keep printing new records until interrupted")
+	fs.Parse(args)
+
+	if *workflowID == "" {
+		log.Fatal("Error: --workflow-id is required")
+	}
+	if *file == "" {
+		log.Fatal("Error: --file is required when WORKER_LOG_FILE is not set")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open log file: %v", err)
+	}
+	defer f.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := logging.Tail(ctx, f, os.Stdout, *workflowID, *follow); err != nil {
+		log.Fatalf("Failed to read log file: %v", err)
+	}
+}
+
+// cmdExperiment runs one prompt across several models as an
+// ExperimentWorkflow and prints a comparison table and the responses.
+func cmdExperiment(args []string) {
+	fs := flag.NewFlagSet("experiment", flag.ExitOnError)
+	promptFile := fs.String("prompt-file", "", "File with the prompt (required)")
+	modelList := fs.String("models", "", "Comma-separated models to compare, 2 to 3 (required)")
+	judgeModel := fs.String("judge-model", "", "Model that scores the responses from 1 to 10 (default: no judge)")
+	toolList := fs.String("tools", "", "Comma-separated tools the models may use; they run without approval (default: none)")
+	timeout := fs.Duration("timeout", workflow.DefaultExperimentTimeout, "How long the models may take")
+	prices := fs.String("prices", "", "Cost estimate rates as model=input:output USD per million tokens, comma-separated")
+	fs.Parse(args)
+
+	if *promptFile == "" || *modelList == "" {
+		log.Fatal("Error: --prompt-file and --models are required")
+	}
+	prompt, err := os.ReadFile(*promptFile)
+	if err != nil {
+		log.Fatalf("Failed to read prompt: %v", err)
+	}
+	rates, err := parsePrices(*prices)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	modelConfig := func(name string) models.ModelConfig {
+		return models.ModelConfig{
+			Model:         name,
+			Provider:      cli.DetectProvider(name),
+			Temperature:   0.7,
+			MaxTokens:     4096,
+			ContextWindow: 128000,
+		}
+	}
+	input := workflow.ExperimentInput{
+		Prompt:    string(prompt),
+		TimeoutMs: timeout.Milliseconds(),
+	}
+	for _, name := range splitList(*modelList) {
+		input.Models = append(input.Models, modelConfig(name))
+	}
+	if *judgeModel != "" {
+		input.JudgeModel = modelConfig(*judgeModel)
+	}
+	cwd, _ := os.Getwd()
+	input.Config = models.SessionConfiguration{
+		Tools:              models.ToolsConfig{EnabledTools: splitList(*toolList)},
+		Permissions:        models.Permissions{ApprovalMode: models.ApprovalNever},
+		Cwd:                cwd,
+		SessionSource:      "cli",
+		DisableSuggestions: true,
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	workflowID := fmt.Sprintf("experiment-%s", uuid.New().String()[:8])
+	ctx := context.Background()
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        workflowID,
+		TaskQueue: TaskQueue,
+	}, "ExperimentWorkflow", input)
+	if err != nil {
+		log.Fatalf("Failed to start experiment: %v", err)
+	}
+	log.Printf("Experiment %s started with %d models, waiting for results...", workflowID, len(input.Models))
+
+	var result workflow.ExperimentResult
+	if err := run.Get(ctx, &result); err != nil {
+		log.Fatalf("Experiment failed: %v", err)
+	}
+	printExperiment(result, rates)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parsePrices parses --prices: model=input:output, comma-separated.
+func parsePrices(s string) (map[string][2]float64, error) {
+	rates := make(map[string][2]float64)
+	for _, entry := range splitList(s) {
+		model, pair, ok := strings.Cut(entry, "=")
+		in, out, ok2 := strings.Cut(pair, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid price %q, want model=input:output", entry)
+		}
+		var rate [2]float64
+		if _, err := fmt.Sscanf(in+" "+out, "%g %g", &rate[0], &rate[1]); err != nil {
+			return nil, fmt.Errorf("invalid price %q: %v", entry, err)
+		}
+		rates[strings.TrimSpace(model)] = rate
+	}
+	return rates, nil
+}
+
+// printExperiment prints the comparison table, then each response.
+func printExperiment(result workflow.ExperimentResult, rates map[string][2]float64) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tSTATUS\tLATENCY\tINPUT\tOUTPUT\tCACHED\tCOST\tSCORE")
+	for _, r := range result.Runs {
+		cost, score := "-", "-"
+		if rate, ok := rates[r.Model]; ok {
+			usd := transcript.EstimateCost(transcript.Usage{TotalTokens: r.TotalTokens, InputTokens: r.InputTokens},
+				rate[0], rate[1])
+			cost = fmt.Sprintf("$%.4f", usd)
+		}
+		if r.Score != nil {
+			score = fmt.Sprintf("%g", *r.Score)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n", r.Model, r.Status,
+			(time.Duration(r.LatencyMs) * time.Millisecond).Round(100*time.Millisecond),
+			r.InputTokens, r.OutputTokens(), r.CachedTokens, cost, score)
+	}
+	tw.Flush()
+	if result.JudgeError != "" {
+		fmt.Printf("\nJudge %s failed: %s\n", result.JudgeModel, result.JudgeError)
+	}
+
+	for _, r := range result.Runs {
+		fmt.Printf("\n=== %s (%s) ===\n", r.Model, r.WorkflowID)
+		if r.JudgeReason != "" {
+			fmt.Printf("Judge: %s\n\n", r.JudgeReason)
+		}
+		switch {
+		case r.Error != "":
+			fmt.Printf("Error: %s\n", r.Error)
+		case r.Response == "":
+			fmt.Println("(no response)")
+		default:
+			fmt.Println(strings.TrimSpace(r.Response))
+		}
+	}
+}
+
+// cmdUsage runs a UsageReportWorkflow over the sessions that ended in the
+// last --since and prints the rollup.
+func cmdUsage(args []string) {
+	fs := flag.NewFlagSet("usage", flag.ExitOnError)
+	since := fs.String("since", "7d", "Report period back from now, as days (7d) or a duration (12h)")
+	prices := fs.String("prices", "", "Cost estimate rates as model=input:output USD per million tokens, comma-separated")
+	teamPrefix := fs.String("team-prefix", usage.DefaultTeamTagPrefix, "Session tag prefix naming the team")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	period, err := parsePeriod(*since)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	rates, err := parsePrices(*prices)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	now := time.Now().UTC()
+	input := workflow.UsageReportInput{
+		Since:         now.Add(-period),
+		Until:         now,
+		TeamTagPrefix: *teamPrefix,
+	}
+	if len(rates) > 0 {
+		input.Prices = make(map[string]usage.Price, len(rates))
+		for model, rate := range rates {
+			input.Prices[model] = usage.Price{Input: rate[0], Output: rate[1]}
+		}
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	ctx := context.Background()
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        fmt.Sprintf("usage-report-%s", uuid.New().String()[:8]),
+		TaskQueue: TaskQueue,
+	}, "UsageReportWorkflow", input)
+	if err != nil {
+		log.Fatalf("Failed to start usage report: %v", err)
+	}
+	var report usage.Report
+	if err := run.Get(ctx, &report); err != nil {
+		log.Fatalf("Usage report failed: %v", err)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+		return
+	}
+	if err := usage.WriteText(os.Stdout, report); err != nil {
+		log.Fatalf("Failed to print report: %v", err)
+	}
+}
+
+// parsePeriod parses --since: whole days as "7d", or a Go duration.
+func parsePeriod(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err != nil {
+			return 0, fmt.Errorf("invalid period %q, want e.g. 7d or 12h", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid period %q, want e.g. 7d or 12h", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid period %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// bulkConcurrency is the default number of Updates cmdBulk sends at once.
+const bulkConcurrency = 8
+
+// bulkStatuses maps the status= filter values to visibility ExecutionStatus
+// values.
+var bulkStatuses = map[string]string{
+	"running":          "Running",
+	"completed":        "Completed",
+	"failed":           "Failed",
+	"canceled":         "Canceled",
+	"terminated":       "Terminated",
+	"continued_as_new": "ContinuedAsNew",
+	"timed_out":        "TimedOut",
+}
+
+// bulkVisibilityQuery translates a bulk filter such as
+// "status=running AND repo=/src/app AND age>24h" into a visibility query
+// over AgenticWorkflow sessions. Clauses are joined with AND:
+//
+//	status=<s>    execution status (default running)
+//	repo=<dir>    sessions tcx started from dir
+//	harness=<id>  sessions of that harness workflow
+//	tag=<t>       sessions with the tag (requires the AgentTags search attribute)
+//	age>DURATION  sessions started longer ago than DURATION
+func bulkVisibilityQuery(filter string, now time.Time) (string, error) {
+	clauses := []string{"WorkflowType = 'AgenticWorkflow'"}
+	status := "Running"
+	for _, clause := range splitAnd(filter) {
+		if key, value, ok := strings.Cut(clause, ">"); ok && strings.TrimSpace(key) == "age" {
+			d, err := time.ParseDuration(strings.TrimSpace(value))
+			if err != nil {
+				return "", fmt.Errorf("invalid age in %q: %w", clause, err)
+			}
+			clauses = append(clauses, fmt.Sprintf("StartTime < '%s'", now.Add(-d).UTC().Format(time.RFC3339)))
+			continue
+		}
+		key, value, ok := strings.Cut(clause, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid clause %q: want key=value or age>DURATION", clause)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `'"`)
+		if value == "" || strings.ContainsAny(value, `'"`) {
+			return "", fmt.Errorf("invalid value in %q", clause)
+		}
+		switch key {
+		case "status":
+			s, ok := bulkStatuses[strings.ToLower(value)]
+			if !ok {
+				return "", fmt.Errorf("unknown status %q", value)
+			}
+			status = s
+		case "repo":
+			dir, err := filepath.Abs(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid repo %q: %w", value, err)
+			}
+			clauses = append(clauses, fmt.Sprintf("WorkflowId STARTS_WITH '%s/'", cli.HarnessWorkflowIDForDir(dir)))
+		case "harness":
+			clauses = append(clauses, fmt.Sprintf("WorkflowId STARTS_WITH '%s/'", value))
+		case "tag":
+			clauses = append(clauses, fmt.Sprintf("%s = '%s'", workflow.TagsSearchAttribute.GetName(), strings.ToLower(value)))
+		default:
+			return "", fmt.Errorf("unknown filter key %q (want status, repo, harness, tag or age)", key)
+		}
+	}
+	clauses = append(clauses, fmt.Sprintf("ExecutionStatus = '%s'", status))
+	return strings.Join(clauses, " AND "), nil
+}
+
+// splitAnd splits a filter on case-insensitive AND separators.
+func splitAnd(filter string) []string {
+	var clauses []string
+	fields := strings.Fields(filter)
+	start := 0
+	for i, f := range fields {
+		if strings.EqualFold(f, "and") {
+			clauses = append(clauses, strings.Join(fields[start:i], " "))
+			start = i + 1
+		}
+	}
+	if start < len(fields) {
+		clauses = append(clauses, strings.Join(fields[start:], " "))
+	}
+	return clauses
+}
+
+// cmdBulk sends the same shutdown, interrupt or tag Update to every session
+// matching a filter, for cleaning up after incidents that leave many
+// sessions running.
+func cmdBulk(args []string) {
+	fs := flag.NewFlagSet("bulk", flag.ExitOnError)
+	filter := fs.String("query", "", "Session filter, e.g. 'status=running AND repo=/src/app AND age>24h' (required)")
+	concurrency := fs.Int("concurrency", bulkConcurrency, "How many Updates to send at once")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	reason := fs.String("reason", "", "Shutdown reason (shutdown only)")
+	var add, remove stringList
+	fs.Var(&add, "add", "Tag to add (tag only; repeatable or comma-separated)")
+	fs.Var(&remove, "remove", "Tag to remove (tag only; repeatable or comma-separated)")
+	note := fs.String("note", "", "Session note (tag only)")
+	fs.Parse(args)
+	// Accept flags after the action too.
+	action := fs.Arg(0)
+	if fs.NArg() > 1 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	if *filter == "" || action == "" {
+		log.Fatal("Error: usage: client bulk --query '<filter>' shutdown|interrupt|tag [flags]")
+	}
+	if *concurrency < 1 {
+		*concurrency = 1
+	}
+
+	var updateName string
+	var req interface{}
+	switch action {
+	case "shutdown":
+		updateName, req = workflow.UpdateShutdown, workflow.ShutdownRequest{Reason: *reason}
+	case "interrupt":
+		updateName, req = workflow.UpdateInterrupt, workflow.InterruptRequest{}
+	case "tag":
+		tagReq := workflow.SetSessionTagsRequest{Add: add, Remove: remove}
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "note" {
+				tagReq.Note = note
+			}
+		})
+		if len(add) == 0 && len(remove) == 0 && tagReq.Note == nil {
+			log.Fatal("Error: tag needs --add, --remove or --note")
+		}
+		updateName, req = workflow.UpdateSessionTags, tagReq
+	default:
+		log.Fatalf("Error: unknown bulk action %q (want shutdown, interrupt or tag)", action)
+	}
+
+	query, err := bulkVisibilityQuery(*filter, time.Now())
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	c := dialTemporal()
+	defer c.Close()
+
+	var ids []string
+	var pageToken []byte
+	for {
+		resp, err := c.ListWorkflow(context.Background(), &workflowservice.ListWorkflowExecutionsRequest{
+			Query:         query,
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			log.Fatalf("Failed to list workflows: %v", err)
+		}
+		for _, exec := range resp.GetExecutions() {
+			ids = append(ids, exec.GetExecution().GetWorkflowId())
+		}
+		if pageToken = resp.GetNextPageToken(); len(pageToken) == 0 {
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		fmt.Println("No sessions match.")
+		return
+	}
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	if !*yes {
+		fmt.Printf("\nSend %s to %d sessions? [y/N] ", action, len(ids))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, *concurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = sendBulkUpdate(c, id, updateName, req)
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Println()
+	for i, id := range ids {
+		if errs[i] != nil {
+			failed++
+			fmt.Fprintf(tw, "%s\tFAILED\t%v\n", id, errs[i])
+		} else {
+			fmt.Fprintf(tw, "%s\tok\t\n", id)
+		}
+	}
+	tw.Flush()
+	fmt.Printf("\n%s: %d succeeded, %d failed\n", action, len(ids)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// sendBulkUpdate sends one of cmdBulk's Updates and waits for it to
+// complete.
+func sendBulkUpdate(c client.Client, workflowID, updateName string, req interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   updateName,
+		Args:         []interface{}{req},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return err
+	}
+	return updateHandle.Get(ctx, nil)
 }
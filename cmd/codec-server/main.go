@@ -0,0 +1,79 @@
+// Codec server for temporal-agent-harness.
+//
+// Serves the Temporal remote codec protocol (POST /encode, POST /decode) so
+// the Temporal UI and CLI can display payloads encrypted by internal/codec.
+// Keys are loaded from the same TEMPORAL_CODEC_* environment variables as
+// the worker. Point the UI's "Codec Server" setting at this address.
+//
+// Requests must carry "Authorization: Bearer <token>" when
+// CODEC_SERVER_TOKEN is set; without it anyone who can reach the server can
+// decrypt history, so only run it unauthenticated on localhost.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"go.temporal.io/sdk/converter"
+
+	"github.com/mfateev/temporal-agent-harness/internal/codec"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8081", "Listen address")
+	origin := flag.String("cors-origin", "http://localhost:8233", "Allowed CORS origin (the Temporal UI URL)")
+	flag.Parse()
+
+	ring, err := codec.LoadKeyringFromEnv(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to load codec keys: %v", err)
+	}
+	if ring == nil {
+		log.Fatalf("%s is not set; nothing to decode", codec.EnvKey)
+	}
+
+	token := os.Getenv("CODEC_SERVER_TOKEN")
+	if token == "" {
+		log.Println("Warning: CODEC_SERVER_TOKEN not set; requests are not authenticated")
+	}
+
+	handler := converter.NewPayloadCodecHTTPHandler(codec.NewAESGCMCodec(ring))
+	http.Handle("/", withCORS(*origin, withAuth(token, handler)))
+
+	log.Printf("Codec server listening on %s (active key %q)", *addr, ring.ActiveID())
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// withCORS allows the Temporal UI origin to call the codec endpoints.
+func withCORS(origin string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type,X-Namespace,Authorization")
+		if r.Method == http.MethodOptions {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAuth rejects requests without the expected bearer token. An empty
+// token disables the check.
+func withAuth(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+// evals runs the regression eval scenarios against a model and prints a
+// scorecard.
+//
+// Each scenario (a YAML file in -dir, see internal/evals) runs as a one-shot
+// session on a running worker, and a judge model grades its transcript
+// against the scenario's expected and disallowed behaviors. The exit status
+// is 1 when a scenario fails or, with -baseline, regressed from the
+// baseline scorecard, so model and prompt changes can be gated in CI.
+//
+// Usage:
+//
+//	evals -model gpt-4o -judge-model claude-sonnet-4-0                 All scenarios
+//	evals -model gpt-4o -judge-model claude-sonnet-4-0 -run '^explain'  Matching scenarios
+//	evals ... -out scorecard.json                                      Save the scorecard
+//	evals ... -baseline main.json -allow-failures                      Fail only on regressions
+//
+// Sessions run on the worker with -cwd (default: the current directory) as
+// their working directory, without approvals, in a read-only sandbox unless
+// -sandbox says otherwise.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/evals"
+	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func main() {
+	dir := flag.String("dir", "evals/scenarios", "Directory of scenario files")
+	pattern := flag.String("run", "", "Run only scenarios whose name matches this regexp")
+	model := flag.String("model", os.Getenv("EVAL_MODEL"), "Model under test (default: $EVAL_MODEL)")
+	judgeModel := flag.String("judge-model", os.Getenv("EVAL_JUDGE_MODEL"), "Model that grades the sessions (default: $EVAL_JUDGE_MODEL)")
+	cwd := flag.String("cwd", "", "Working directory of the sessions on the worker (default: current directory)")
+	sandbox := flag.String("sandbox", "read-only", "Sandbox mode of the sessions")
+	concurrency := flag.Int("concurrency", workflow.DefaultEvalConcurrency, "Scenarios run at once")
+	timeout := flag.Duration("timeout", workflow.DefaultEvalScenarioTimeout, "How long each scenario may take")
+	out := flag.String("out", "", "Write the scorecard as JSON to this file")
+	baseline := flag.String("baseline", "", "Scorecard to compare with; regressions fail the run")
+	maxDrop := flag.Float64("max-score-drop", evals.DefaultMaxScoreDrop, "Score drop from the baseline counted as a regression")
+	allowFailures := flag.Bool("allow-failures", false, "Exit 0 when scenarios fail, unless they regressed from -baseline")
+	taskQueue := flag.String("task-queue", "temporal-agent-harness", "Task queue of the worker")
+	temporalHost := flag.String("temporal-host", "", "Temporal server address (overrides envconfig/env vars)")
+	flag.Parse()
+
+	if *model == "" || *judgeModel == "" {
+		log.Fatal("Error: -model and -judge-model are required")
+	}
+	scenarios, err := evals.LoadScenarios(*dir)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if scenarios, err = evals.Filter(scenarios, *pattern); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if len(scenarios) == 0 {
+		log.Fatalf("Error: no scenarios match %q", *pattern)
+	}
+	var base *workflow.EvalResult
+	if *baseline != "" {
+		b, err := evals.ReadScorecard(*baseline)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		base = &b
+	}
+	if *cwd == "" {
+		*cwd, _ = os.Getwd()
+	}
+
+	input := evals.Options{
+		Model:       *model,
+		JudgeModel:  *judgeModel,
+		Cwd:         *cwd,
+		Sandbox:     *sandbox,
+		Concurrency: *concurrency,
+		TimeoutMs:   timeout.Milliseconds(),
+	}.Input(scenarios)
+
+	c, err := client.Dial(temporalclient.MustLoadClientOptions(*temporalHost, ""))
+	if err != nil {
+		log.Fatalf("Failed to connect to Temporal: %v", err)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	workflowID := fmt.Sprintf("eval-%s", uuid.New().String()[:8])
+	log.Printf("Eval %s started with %d scenarios, waiting for results...", workflowID, len(scenarios))
+	start := time.Now()
+	result, err := evals.Run(ctx, c, *taskQueue, workflowID, input)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	log.Printf("Eval finished in %s", time.Since(start).Round(time.Second))
+
+	fmt.Print(evals.Format(result))
+	if *out != "" {
+		if err := evals.WriteScorecard(*out, result); err != nil {
+			log.Fatalf("Failed to write scorecard: %v", err)
+		}
+	}
+
+	failed := !*allowFailures && result.PassedCount() < len(result.Scenarios)
+	if base != nil {
+		regressions := evals.Compare(*base, result, *maxDrop)
+		if len(regressions) > 0 {
+			fmt.Printf("\nRegressions from %s:\n", *baseline)
+			for _, r := range regressions {
+				fmt.Printf("  %s: %s\n", r.Scenario, r.Reason)
+			}
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -12,6 +12,10 @@
 //	tcx --inline                     Run without alt-screen (inline mode)
 //	tcx crews                        List available crew templates
 //	tcx start-crew <name> [--input key=value]...  Start a crew session
+//	tcx templates                    List session templates
+//	tcx new --template <name> [--var key=value]...  Start a session from a template
+//	tcx view <transcript>            Browse an exported transcript offline
+//	tcx token-key                    Print the access_control key of TCX_SESSION_TOKEN
 package main
 
 import (
@@ -24,6 +28,7 @@ import (
 
 	"github.com/mfateev/temporal-agent-harness/internal/cli"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/templates"
 )
 
 func main() {
@@ -42,14 +47,40 @@ func main() {
 				os.Exit(1)
 			}
 			return
+		case "templates":
+			if err := runTemplates(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "new":
+			if err := runNew(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "view":
+			if err := runView(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "token-key":
+			if err := runTokenKey(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
 	}
 
 	message := flag.String("m", "", "Initial message (starts new workflow, skips session picker)")
 	message2 := flag.String("message", "", "Initial message (alias for -m)")
 	model := flag.String("model", "gpt-4o-mini", "LLM model to use")
-	provider := flag.String("provider", "", "LLM provider override (openai, anthropic, google)")
+	provider := flag.String("provider", "", "LLM provider override (openai, anthropic, bedrock, vertex)")
 	temporalHost := flag.String("temporal-host", "", "Temporal server address (overrides envconfig/env vars)")
+	standbyHost := flag.String("standby-temporal-host", "", "Standby Temporal address for read-only failover while the primary is down. Env: TEMPORAL_STANDBY_HOST_URL")
+	standbyNamespace := flag.String("standby-namespace", "", "Standby Temporal namespace for read-only failover. Env: TEMPORAL_STANDBY_NAMESPACE")
 	noMarkdown := flag.Bool("no-markdown", false, "Disable markdown rendering")
 	noColor := flag.Bool("no-color", false, "Disable colored output")
 	inline := flag.Bool("inline", false, "Disable alt-screen mode (inline output)")
@@ -58,10 +89,18 @@ func main() {
 	sandboxMode := flag.String("sandbox", "", "Sandbox mode: full-access, read-only, workspace-write")
 	sandboxWritable := flag.String("sandbox-writable", "", "Comma-separated writable roots for workspace-write sandbox")
 	sandboxNetwork := flag.Bool("sandbox-network", true, "Allow network access in sandbox")
+	sandboxAudit := flag.Bool("sandbox-audit", false, "Run tools unrestricted but record what the sandbox would have blocked")
+	sandboxNetworkAllow := flag.String("sandbox-network-allow", "", "Comma-separated hosts (host, host:port, *.domain) reachable when --sandbox-network=false")
 	codexHome := flag.String("codex-home", "", "Path to codex config directory (default: ~/.codex)")
 	noSuggestions := flag.Bool("no-suggestions", false, "Disable prompt suggestions after turn completion")
+	images := flag.String("images", "auto", "Show image attachments inline: auto, kitty, iterm2, sixel or off")
+	theme := flag.String("theme", "", "TUI theme: dark, light, high-contrast, a theme file, or a name in <codex-home>/themes. Env: TCX_THEME; config: [tui] theme")
+	lang := flag.String("lang", "auto", "Language of the TUI: auto (from TCX_LANG/LC_ALL/LC_MESSAGES/LANG), en, ja or de")
+	foldLines := flag.Int("fold-lines", 40, "Show items taller than this many lines collapsed (o / Ctrl+O expands; -1 = never fold)")
 	memory := flag.Bool("memory", false, "Enable cross-session memory subsystem")
 	memoryDb := flag.String("memory-db", "", "Path to memory SQLite DB (default: ~/.codex/state.sqlite)")
+	maxSessions := flag.Int("max-sessions", 0, "Max concurrent sessions for this directory's harness; extra starts queue (0 = unlimited, applies when the harness starts)")
+	archiveURL := flag.String("archive-url", "", "Archive session transcripts to s3://bucket/prefix, gs://bucket/prefix or file:///dir (applies when the harness starts)")
 	connTimeout := flag.Duration("connection-timeout", 0, "Per-RPC timeout for Temporal calls (e.g. 10s). 0 = no timeout. Env: TCX_CONNECTION_TIMEOUT")
 	flag.Parse()
 
@@ -74,6 +113,22 @@ func main() {
 		}
 	}
 
+	imageProtocol, err := cli.ParseImageProtocol(*images, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	locale, err := cli.ParseLocale(*lang, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	tuiTheme, err := cli.ResolveTheme(*theme, resolveCodexHome(*codexHome), os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Support both -m and --message
 	msg := *message
 	if msg == "" {
@@ -101,6 +156,13 @@ func main() {
 		}
 	}
 
+	var networkAllow []string
+	for _, host := range strings.Split(*sandboxNetworkAllow, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			networkAllow = append(networkAllow, host)
+		}
+	}
+
 	// Smart provider detection from model name
 	resolvedProvider := *provider
 	if resolvedProvider == "" {
@@ -108,24 +170,35 @@ func main() {
 	}
 
 	config := cli.Config{
-		TemporalHost: *temporalHost,
-		Message:      msg,
-		Model:        *model,
-		NoMarkdown:   *noMarkdown,
-		NoColor:      *noColor,
+		TemporalHost:        *temporalHost,
+		StandbyTemporalHost: *standbyHost,
+		StandbyNamespace:    *standbyNamespace,
+		Message:             msg,
+		Model:               *model,
+		NoMarkdown:          *noMarkdown,
+		NoColor:             *noColor,
 		Permissions: models.Permissions{
 			ApprovalMode:         resolvedApproval,
 			SandboxMode:          *sandboxMode,
 			SandboxWritableRoots: writableRoots,
 			SandboxNetworkAccess: *sandboxNetwork,
+			SandboxNetworkAllow:  networkAllow,
+			SandboxAudit:         *sandboxAudit,
 		},
 		CodexHome:          *codexHome,
 		Provider:           resolvedProvider,
 		Inline:             *inline,
 		DisableSuggestions: *noSuggestions,
+		FoldLines:          *foldLines,
+		Images:             imageProtocol,
+		Locale:             locale,
+		Theme:              tuiTheme,
 		MemoryEnabled:      *memory,
 		MemoryDbPath:       *memoryDb,
 		ConnectionTimeout:  *connTimeout,
+
+		MaxConcurrentSessions: *maxSessions,
+		ArchiveURL:            *archiveURL,
 	}
 
 	if err := cli.Run(config); err != nil {
@@ -319,6 +392,7 @@ func runStartCrew() error {
 		MemoryEnabled:     *memory,
 		MemoryDbPath:      *memoryDb,
 		ConnectionTimeout: *connTimeout,
+		Locale:            cli.DetectLocale(os.Getenv),
 
 		// Crew-specific fields — lightweight, no upfront interpolation.
 		CrewName:   crew.Name,
@@ -329,9 +403,154 @@ func runStartCrew() error {
 	return cli.Run(cliConfig)
 }
 
+// runTemplates lists available session templates.
+func runTemplates() error {
+	fs := flag.NewFlagSet("templates", flag.ExitOnError)
+	codexHome := fs.String("codex-home", "", "Path to codex config directory (default: ~/.codex)")
+	fs.Parse(os.Args[2:])
+
+	home := resolveCodexHome(*codexHome)
+	list, errs := templates.List(home)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+	}
+	if len(list) == 0 {
+		fmt.Printf("No session templates found. Create them in %s/*.yaml\n", templates.Dir(home))
+		return nil
+	}
+	fmt.Printf("%-20s %-40s %s\n", "NAME", "DESCRIPTION", "VARS")
+	for _, t := range list {
+		vars := "-"
+		if names := t.VarNames(); len(names) > 0 {
+			vars = strings.Join(names, ", ")
+		}
+		fmt.Printf("%-20s %-40s %s\n", t.Name, truncate(t.Description, 40), vars)
+	}
+	return nil
+}
+
+// runTokenKey prints the public key of the session token in
+// TCX_SESSION_TOKEN, for access_control.token_keys in config.toml. The
+// token itself never leaves the caller.
+func runTokenKey() error {
+	token := os.Getenv("TCX_SESSION_TOKEN")
+	if token == "" {
+		return fmt.Errorf("set TCX_SESSION_TOKEN to the token to derive the key from")
+	}
+	fmt.Println(models.CallerTokenKey(token))
+	return nil
+}
+
+// runNew starts a session from a session template.
+func runNew() error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	templateName := fs.String("template", "", "Session template name (required; see tcx templates)")
+	vars := templates.VarFlags{}
+	fs.Var(vars, "var", "Template variable as name=value (repeatable)")
+	codexHome := fs.String("codex-home", "", "Path to codex config directory (default: ~/.codex)")
+	model := fs.String("model", "", "Override model (default: from template, else gpt-4o-mini)")
+	provider := fs.String("provider", "", "LLM provider override")
+	temporalHost := fs.String("temporal-host", "", "Temporal server address")
+	inline := fs.Bool("inline", false, "Disable alt-screen mode")
+	fullAuto := fs.Bool("full-auto", false, "Auto-approve all tool calls")
+	noMarkdown := fs.Bool("no-markdown", false, "Disable markdown rendering")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	connTimeout := fs.Duration("connection-timeout", 0, "Per-RPC timeout for Temporal calls")
+	fs.Parse(os.Args[2:])
+
+	if *templateName == "" {
+		fmt.Fprintf(os.Stderr, "Usage: tcx new --template <name> [--var key=value]...\n")
+		os.Exit(1)
+	}
+	tmpl, err := templates.Load(resolveCodexHome(*codexHome), *templateName)
+	if err != nil {
+		return err
+	}
+	msg, err := tmpl.Expand(vars)
+	if err != nil {
+		return err
+	}
+
+	resolvedModel := *model
+	if resolvedModel == "" {
+		resolvedModel = tmpl.Model
+	}
+	if resolvedModel == "" {
+		resolvedModel = "gpt-4o-mini"
+	}
+	resolvedProvider := *provider
+	if resolvedProvider == "" && *model == "" {
+		resolvedProvider = tmpl.Provider
+	}
+	if resolvedProvider == "" {
+		resolvedProvider = cli.DetectProvider(resolvedModel)
+	}
+
+	resolvedApproval := models.ApprovalUnlessTrusted
+	if *fullAuto {
+		resolvedApproval = models.ApprovalNever
+	}
+
+	return cli.Run(cli.Config{
+		TemporalHost: *temporalHost,
+		Message:      msg,
+		Model:        resolvedModel,
+		NoMarkdown:   *noMarkdown,
+		NoColor:      *noColor,
+		Permissions: models.Permissions{
+			ApprovalMode: resolvedApproval,
+		},
+		CodexHome:         *codexHome,
+		Provider:          resolvedProvider,
+		Inline:            *inline,
+		ConnectionTimeout: *connTimeout,
+		Locale:            cli.DetectLocale(os.Getenv),
+		EnableTools:       tmpl.Tools,
+	})
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
 	}
 	return s[:max-3] + "..."
 }
+
+// runView opens an exported transcript in the TUI without connecting to
+// Temporal.
+func runView() error {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	inline := fs.Bool("inline", false, "Disable alt-screen mode")
+	noMarkdown := fs.Bool("no-markdown", false, "Disable markdown rendering")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	foldLines := fs.Int("fold-lines", 40, "Show items taller than this many lines collapsed (o expands; -1 = never fold)")
+	theme := fs.String("theme", "", "Theme: dark, light, high-contrast, a theme file, or a name in <codex-home>/themes. Env: TCX_THEME")
+	codexHome := fs.String("codex-home", "", "Path to codex config directory (default: ~/.codex)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tcx view [flags] <transcript.jsonl | history.json | archive-session-dir>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	tuiTheme, err := cli.ResolveTheme(*theme, resolveCodexHome(*codexHome), os.Getenv)
+	if err != nil {
+		return err
+	}
+	path := fs.Arg(0)
+	items, err := cli.LoadTranscript(path)
+	if err != nil {
+		return err
+	}
+	return cli.RunViewer(cli.ViewerConfig{
+		Title:      filepath.Base(path),
+		NoColor:    *noColor,
+		NoMarkdown: *noMarkdown,
+		Inline:     *inline,
+		FoldLines:  *foldLines,
+		Theme:      tuiTheme,
+	}, items)
+}
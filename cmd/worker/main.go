@@ -4,19 +4,33 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	anthropicoption "github.com/anthropics/anthropic-sdk-go/option"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	tlog "go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/worker"
 
 	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/artifacts"
+	"github.com/mfateev/temporal-agent-harness/internal/browser"
 	"github.com/mfateev/temporal-agent-harness/internal/execsession"
 	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/logging"
 	"github.com/mfateev/temporal-agent-harness/internal/mcp"
 	"github.com/mfateev/temporal-agent-harness/internal/memories"
+	"github.com/mfateev/temporal-agent-harness/internal/netconfig"
+	"github.com/mfateev/temporal-agent-harness/internal/redaction"
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
 	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 	"github.com/mfateev/temporal-agent-harness/internal/tools/handlers"
 	"github.com/mfateev/temporal-agent-harness/internal/version"
@@ -25,26 +39,96 @@ import (
 
 const (
 	TaskQueue = "temporal-agent-harness"
+
+	// DefaultDrainTimeout bounds how long a stopping worker waits for
+	// in-flight activities and exec sessions before giving up on them.
+	// Override with WORKER_DRAIN_TIMEOUT (Go duration, e.g. "5m").
+	DefaultDrainTimeout = 2 * time.Minute
 )
 
 func main() {
-	// Check for at least one LLM provider API key
+	// Structured logs: WORKER_LOG_FORMAT (text|json), WORKER_LOG_FILE and
+	// WORKER_LOG_LEVEL. log.Printf output and the Temporal SDK's workflow and
+	// activity loggers all go to the same sink.
+	logCfg, err := logging.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger, logCloser, err := logging.New(logCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logCloser.Close()
+	slog.SetDefault(logger)
+
+	// Check for at least one LLM provider: an API key, an AWS region for
+	// Bedrock, or a Google Cloud project for Vertex AI
 	hasOpenAI := os.Getenv("OPENAI_API_KEY") != ""
 	hasAnthropic := os.Getenv("ANTHROPIC_API_KEY") != ""
+	hasBedrock := llm.BedrockConfigured()
+	hasVertex := llm.VertexConfigured()
 
-	if !hasOpenAI && !hasAnthropic {
-		log.Fatal("At least one LLM provider API key is required: OPENAI_API_KEY or ANTHROPIC_API_KEY")
+	if !hasOpenAI && !hasAnthropic && !hasBedrock && !hasVertex {
+		log.Fatal("At least one LLM provider is required: OPENAI_API_KEY, ANTHROPIC_API_KEY, AWS_REGION (Bedrock) or GOOGLE_CLOUD_PROJECT (Vertex AI)")
 	}
 
+	var providers []string
 	if hasOpenAI {
 		log.Println("OpenAI provider available")
+		providers = append(providers, "openai")
 	}
 	if hasAnthropic {
 		log.Println("Anthropic provider available")
+		providers = append(providers, "anthropic")
+	}
+	if hasBedrock {
+		log.Println("Bedrock provider available")
+		providers = append(providers, "bedrock")
+	}
+	if hasVertex {
+		log.Println("Vertex AI provider available")
+		providers = append(providers, "vertex")
+	}
+
+	// Proxy and CA bundle for LLM and MCP HTTP traffic: WORKER_PROXY,
+	// WORKER_NO_PROXY and WORKER_CA_BUNDLE, overridden per target by
+	// <TARGET>_PROXY etc. (OPENAI_, ANTHROPIC_, BEDROCK_, VERTEX_, MCP_).
+	netCfg, err := netconfig.ConfigFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid network config: %v", err)
+	}
+	httpClients := make(map[string]*http.Client)
+	for _, target := range netconfig.Targets {
+		s := netCfg.For(target)
+		if s.IsZero() {
+			continue
+		}
+		hc, err := s.HTTPClient()
+		if err != nil {
+			log.Fatalf("Invalid network config for %s: %v", target, err)
+		}
+		httpClients[target] = hc
+		log.Printf("Network settings for %s: proxy %q, no_proxy %q, CA bundle %q",
+			target, s.RedactedProxy(), s.NoProxy, s.CABundle)
+	}
+
+	// Startup self-test: can each provider's endpoint be reached with these
+	// settings? Failures are logged with a hint but don't stop the worker.
+	// WORKER_NETWORK_CHECK=off skips it.
+	if os.Getenv("WORKER_NETWORK_CHECK") != "off" {
+		endpoints := activities.ProviderEndpoints(providers)
+		for _, res := range netconfig.CheckAll(context.Background(), netCfg, endpoints, netconfig.DefaultCheckTimeout) {
+			if res.OK {
+				log.Printf("Network check %s", res)
+			} else {
+				log.Printf("Warning: network check %s", res)
+			}
+		}
 	}
 
 	// Load Temporal client options via envconfig (supports env vars, config files, TLS)
 	opts := temporalclient.MustLoadClientOptions("", "")
+	opts.Logger = tlog.NewStructuredLogger(logger)
 
 	c, err := client.Dial(opts)
 	if err != nil {
@@ -52,8 +136,25 @@ func main() {
 	}
 	defer c.Close()
 
-	// Create worker
-	w := worker.New(c, TaskQueue, worker.Options{})
+	drainTimeout := DefaultDrainTimeout
+	if v := os.Getenv("WORKER_DRAIN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WORKER_DRAIN_TIMEOUT %q: %v", v, err)
+		}
+		drainTimeout = d
+	}
+
+	// Create worker. WorkerStopTimeout lets in-flight activities (including
+	// heartbeating exec_command calls) finish during a drain. The heartbeat
+	// throttle is kept short so LLM streaming progress reaches the TUI and
+	// interrupts cancel a streaming call within a couple of seconds.
+	w := worker.New(c, TaskQueue, worker.Options{
+		WorkerStopTimeout:                drainTimeout,
+		DefaultHeartbeatThrottleInterval: time.Second,
+		MaxHeartbeatThrottleInterval:     2 * time.Second,
+		Interceptors:                     []interceptor.WorkerInterceptor{workflow.NewAccessInterceptor(os.Getenv(temporalclient.EnvCallerKey))},
+	})
 
 	// Register workflows
 	w.RegisterWorkflow(workflow.AgenticWorkflow)
@@ -62,39 +163,162 @@ func main() {
 	w.RegisterWorkflow(workflow.HarnessWorkflowContinued)
 	w.RegisterWorkflow(workflow.SessionWorkflow)
 	w.RegisterWorkflow(workflow.SessionWorkflowContinued)
+	w.RegisterWorkflow(workflow.ExperimentWorkflow)
+	w.RegisterWorkflow(workflow.EvalWorkflow)
+	w.RegisterWorkflow(workflow.UsageReportWorkflow)
 
 	// Create tool registry with handlers
 	// Maps to: codex-rs/core/src/tools/registry.rs ToolRegistry setup
 	toolRegistry := tools.NewToolRegistry()
-	toolRegistry.Register(handlers.NewShellHandler())        // array-based "shell"
-	toolRegistry.Register(handlers.NewShellCommandHandler()) // string-based "shell_command"
-	toolRegistry.Register(handlers.NewReadFileTool())
-	toolRegistry.Register(handlers.NewWriteFileTool())
-	toolRegistry.Register(handlers.NewListDirTool())
-	toolRegistry.Register(handlers.NewGrepFilesTool())
-	toolRegistry.Register(handlers.NewApplyPatchTool())
-
-	// Unified exec: interactive PTY/pipe sessions (exec_command + write_stdin)
+
+	// Remote workspace: with WORKER_SSH_HOST set, shell, exec and file
+	// tools run on that host over SSH. Tools that only work on this
+	// worker's filesystem are not registered.
+	sshCfg, useSSH, err := remote.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var sshBackend *remote.Backend
+	if useSSH {
+		sshBackend, err = remote.New(sshCfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer sshBackend.Close()
+		log.Printf("Running tools on %s over SSH (remote root %s)", sshBackend.Addr(), sshBackend.Root())
+
+		toolRegistry.Register(handlers.NewRemoteShellHandler(sshBackend))
+		toolRegistry.Register(handlers.NewRemoteShellCommandHandler(sshBackend))
+		toolRegistry.Register(handlers.NewRemoteReadFileTool(sshBackend))
+		toolRegistry.Register(handlers.NewRemoteWriteFileTool(sshBackend))
+	} else {
+		toolRegistry.Register(handlers.NewShellHandler())        // array-based "shell"
+		toolRegistry.Register(handlers.NewShellCommandHandler()) // string-based "shell_command"
+		toolRegistry.Register(handlers.NewReadFileTool())
+		toolRegistry.Register(handlers.NewWriteFileTool())
+		toolRegistry.Register(handlers.NewListDirTool())
+		toolRegistry.Register(handlers.NewGrepFilesTool())
+		toolRegistry.Register(handlers.NewGrepChangedTool())
+		toolRegistry.Register(handlers.NewCodeOutlineTool())
+		toolRegistry.Register(handlers.NewApplyPatchTool())
+	}
+
+	// GitHub tools, enabled per session with github_tools = true. They
+	// authenticate with this worker's GITHUB_TOKEN.
+	toolRegistry.Register(handlers.NewGitHubGetIssueTool())
+	toolRegistry.Register(handlers.NewGitHubCreatePRTool())
+	toolRegistry.Register(handlers.NewGitHubCommentTool())
+
+	// fetch_url, enabled per session with fetch_url_tool = true. Pages are
+	// fetched from this worker's network.
+	toolRegistry.Register(handlers.NewFetchURLTool())
+
+	// semantic_search, enabled per session with [semantic_search]. It reads
+	// the index the IndexCode activity keeps under <cwd>/.codex/index.
+	if sshBackend == nil {
+		toolRegistry.Register(handlers.NewSemanticSearchTool())
+	}
+
+	// Unified exec: interactive PTY/pipe sessions (exec_command + write_stdin).
+	// Sessions left running by a previous worker's drain are loaded as "lost"
+	// so write_stdin can tell the model to re-run instead of hanging. The
+	// file is shared by the workers on this host: drains add to it under a
+	// lock and a starting worker takes what is there.
+	home, _ := os.UserHomeDir()
+	lostSessionsPath := filepath.Join(home, ".codex", "exec_sessions_lost.json")
 	execStore := execsession.NewStore()
-	toolRegistry.Register(handlers.NewExecCommandHandler(execStore))
+	if lost, err := execsession.TakeLostSessions(lostSessionsPath); err != nil {
+		log.Printf("Warning: failed to load lost exec sessions: %v", err)
+	} else if len(lost) > 0 {
+		execStore.MarkLost(lost)
+		log.Printf("Loaded %d exec sessions lost by previous worker", len(lost))
+	}
+	if sshBackend != nil {
+		toolRegistry.Register(handlers.NewRemoteExecCommandHandler(execStore, sshBackend))
+	} else {
+		toolRegistry.Register(handlers.NewExecCommandHandler(execStore))
+	}
 	toolRegistry.Register(handlers.NewWriteStdinHandler(execStore))
 
+	if sshBackend == nil {
+		// python_exec, enabled per session with python_tool = true. Kernels
+		// run this worker's python3 and live in the exec session store.
+		toolRegistry.Register(handlers.NewPythonExecHandler(execStore, "python3"))
+
+		// quality_gate, enabled per session with quality_gate_tool = true. It
+		// runs the checks in the project's .codex/quality.toml on this worker.
+		toolRegistry.Register(handlers.NewQualityGateTool())
+	}
+
+	// Browser tools, enabled per session with [browser] enabled = true. Each
+	// session gets a headless Chrome on this worker; CHROME_PATH selects
+	// the binary.
+	browserPool := browser.NewPool(browser.Options{ExecPath: os.Getenv("CHROME_PATH")})
+	toolRegistry.Register(handlers.NewBrowserNavigateTool(browserPool))
+	toolRegistry.Register(handlers.NewBrowserClickTool(browserPool))
+	toolRegistry.Register(handlers.NewBrowserReadTextTool(browserPool))
+	toolRegistry.Register(handlers.NewBrowserScreenshotTool(browserPool))
+
 	// MCP: single handler for all mcp__* tool calls
-	mcpStore := mcp.NewMcpStore()
+	mcpStore := mcp.NewMcpStore().WithHTTPClient(httpClients[netconfig.MCP])
 	toolRegistry.Register(handlers.NewMCPHandler(mcpStore))
 
 	log.Printf("Registered %d tools", toolRegistry.ToolCount())
 
-	// Create multi-provider LLM client (supports both OpenAI and Anthropic)
-	llmClient := llm.NewMultiProviderClient()
+	// Create multi-provider LLM client (OpenAI, Anthropic, Bedrock, Vertex AI).
+	// Per-provider budgets (OPENAI_RPM, OPENAI_TPM, ANTHROPIC_RPM,
+	// ANTHROPIC_TPM) queue calls fairly across the sessions on this worker.
+	var llmClient llm.LLMClient = llm.NewMultiProviderClientWithHTTP(httpClients)
+	rateLimits, err := llm.RateLimitsFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid LLM rate limit: %v", err)
+	}
+	if len(rateLimits) > 0 {
+		llmClient = llm.NewRateLimitedClient(llmClient, rateLimits)
+		for provider, limit := range rateLimits {
+			log.Printf("Rate limiting %s: %d requests/min, %d tokens/min (0 = unlimited)",
+				provider, limit.RequestsPerMinute, limit.TokensPerMinute)
+		}
+	}
 
 	// Register activities
-	llmActivities := activities.NewLLMActivities(llmClient)
+	llmActivities := activities.NewLLMActivities(llmClient).WithQueueSignals(c)
+	// Parse the tiktoken encodings up front so the first history estimate
+	// in a workflow task doesn't pay for it.
+	go tokenizer.Preload()
+	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+		var counterOpts []anthropicoption.RequestOption
+		if hc := httpClients[netconfig.Anthropic]; hc != nil {
+			counterOpts = append(counterOpts, anthropicoption.WithHTTPClient(hc))
+		}
+		llmActivities.WithAnthropicTokenCounter(tokenizer.NewAnthropicCounter(key, counterOpts...))
+	}
 	w.RegisterActivity(llmActivities.ExecuteLLMCall)
+	w.RegisterActivity(llmActivities.CountTokens)
 	w.RegisterActivity(llmActivities.ExecuteCompact)
 	w.RegisterActivity(llmActivities.GenerateSuggestions)
+	w.RegisterActivity(llmActivities.GenerateSessionTitle)
+	w.RegisterActivity(llmActivities.SummarizeProjectDocs)
+	w.RegisterActivity(llmActivities.SummarizeContextItems)
 
-	toolActivities := activities.NewToolActivities(toolRegistry)
+	// Secrets in tool output are scrubbed before results reach history and
+	// the LLM. Built-in rules apply unless ~/.codex/redaction.toml disables them.
+	redactor, err := redaction.Load(filepath.Join(home, ".codex", redaction.ConfigFileName))
+	if err != nil {
+		log.Fatalf("Invalid redaction config: %v", err)
+	}
+	toolActivities := activities.NewToolActivities(toolRegistry).
+		WithProgressSignals(c).
+		WithRedactor(redactor)
+	// WORKER_MAX_COMMAND_TIMEOUT (Go duration) lowers the longest
+	// timeout_seconds a shell or exec call may ask for on this worker.
+	if v := os.Getenv("WORKER_MAX_COMMAND_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid WORKER_MAX_COMMAND_TIMEOUT %q: want a positive duration such as 30m", v)
+		}
+		toolActivities.WithMaxCommandTimeout(d)
+	}
 	w.RegisterActivity(toolActivities.ExecuteTool)
 
 	instructionActivities := activities.NewInstructionActivities()
@@ -104,17 +328,77 @@ func main() {
 	w.RegisterActivity(instructionActivities.LoadConfigFile)
 	w.RegisterActivity(instructionActivities.LoadSkills)
 	w.RegisterActivity(instructionActivities.ReadSkillContent)
+	w.RegisterActivity(instructionActivities.LoadAgentRoles)
 
 	mcpActivities := activities.NewMcpActivities(mcpStore)
 	w.RegisterActivity(mcpActivities.InitializeMcpServers)
 	w.RegisterActivity(mcpActivities.CleanupMcpServers)
 
+	// Worker introspection (get_capabilities query)
+	capabilityActivities := activities.NewCapabilityActivities(toolRegistry, mcpStore, providers)
+	if sshBackend != nil {
+		capabilityActivities.WithRemoteHost(sshBackend.Addr())
+	}
+	w.RegisterActivity(capabilityActivities.DescribeWorker)
+
+	// Network diagnostics (diagnose_network update)
+	networkActivities := activities.NewNetworkActivities(netCfg, providers)
+	w.RegisterActivity(networkActivities.DiagnoseNetwork)
+
 	execSessionActivities := activities.NewExecSessionActivities(execStore)
 	w.RegisterActivity(execSessionActivities.ListExecSessions)
 	w.RegisterActivity(execSessionActivities.CleanExecSessions)
+	w.RegisterActivity(execSessionActivities.WriteExecStdin)
+
+	// Workspace snapshot activities (per-turn snapshots and rollback)
+	workspaceActivities := activities.NewWorkspaceActivities()
+	w.RegisterActivity(workspaceActivities.SnapshotWorkspace)
+	w.RegisterActivity(workspaceActivities.RestoreWorkspace)
+
+	// Workspace watchers (sessions with watch_workspace set)
+	watchActivities := activities.NewWatchActivities()
+	defer watchActivities.Close()
+	w.RegisterActivity(watchActivities.CollectWorkspaceChanges)
+
+	// User hook scripts (sessions with [hooks] enabled)
+	hookActivities := activities.NewHookActivities()
+	w.RegisterActivity(hookActivities.RunHooks)
+
+	// Code index behind the semantic_search tool
+	codeIndexActivities := activities.NewCodeIndexActivities()
+	w.RegisterActivity(codeIndexActivities.IndexCode)
+
+	// Transcript archival (sessions with archive_url set)
+	archiveActivities := activities.NewArchiveActivities()
+	w.RegisterActivity(archiveActivities.ArchiveTranscript)
+
+	// Delegated tool approvals (sessions with approval_webhook set)
+	approvalWebhookActivities := activities.NewApprovalWebhookActivities()
+	w.RegisterActivity(approvalWebhookActivities.NotifyApprovalWebhook)
+
+	// Shared artifacts (share_artifact), served over HTTP when
+	// WORKER_ARTIFACT_ADDR is set
+	artifactCfg, serveArtifacts, err := artifacts.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var artifactServer *artifacts.Server
+	if serveArtifacts {
+		artifactServer = artifacts.NewServer(artifactCfg)
+		if err := artifactServer.Start(); err != nil {
+			log.Fatal(err)
+		}
+		defer artifactServer.Close()
+		log.Printf("Serving shared artifacts at %s", artifactServer.BaseURL())
+	}
+	artifactActivities := activities.NewArtifactActivities(artifactServer)
+	w.RegisterActivity(artifactActivities.RegisterArtifact)
+
+	// Context for pending approvals (file previews, touched paths)
+	approvalContextActivities := activities.NewApprovalContextActivities()
+	w.RegisterActivity(approvalContextActivities.DescribeApprovals)
 
 	// Memory activities (SQLite DB opened lazily on first use)
-	home, _ := os.UserHomeDir()
 	dbPath := filepath.Join(home, ".codex", "state.sqlite")
 	memoryDB, err := memories.OpenMemoryDB(dbPath)
 	if err != nil {
@@ -139,9 +423,21 @@ func main() {
 	w.RegisterActivity(crewActivities.ResolveCrewMain)
 	w.RegisterActivity(crewActivities.ResolveCrewAgent)
 
-	// Session lifecycle activities (polling for session readiness)
+	// Session lifecycle activities (session readiness and liveness)
 	sessionActivities := activities.NewSessionActivities(c)
 	w.RegisterActivity(sessionActivities.WaitForSessionReady)
+	w.RegisterActivity(sessionActivities.DescribeSessions)
+	w.RegisterActivity(sessionActivities.CheckSearchAttributes)
+
+	// Cross-session context import (import_context Update)
+	importActivities := activities.NewImportActivities(llmClient, c)
+	w.RegisterActivity(importActivities.SummarizeSession)
+	w.RegisterActivity(importActivities.ReadSessionTranscript)
+
+	// Usage reports (UsageReportWorkflow)
+	usageActivities := activities.NewUsageActivities(c)
+	w.RegisterActivity(usageActivities.CollectSessionUsage)
+	w.RegisterActivity(usageActivities.PublishUsageReport)
 
 	// Register consolidation workflow
 	w.RegisterWorkflow(workflow.ConsolidationWorkflow)
@@ -153,10 +449,44 @@ func main() {
 		log.Printf("Temporal server: %s", opts.HostPort)
 	}
 
-	err = w.Run(worker.InterruptCh())
-	if err != nil {
+	if err := w.Start(); err != nil {
 		log.Fatalf("Failed to start worker: %v", err)
 	}
 
+	<-worker.InterruptCh()
+	drainWorker(w, execStore, drainTimeout, lostSessionsPath)
+	if closed := browserPool.CloseAll(); closed > 0 {
+		log.Printf("Closed %d browsers", closed)
+	}
+
 	log.Println("Worker stopped")
 }
+
+// drainWorker stops polling for new tasks, then waits up to timeout for
+// in-flight activities and running exec sessions to finish. Sessions still
+// running at the deadline are recorded to lostSessionsPath and killed.
+func drainWorker(w worker.Worker, execStore *execsession.Store, timeout time.Duration, lostSessionsPath string) {
+	log.Printf("Draining worker (timeout %s)...", timeout)
+	deadline := time.Now().Add(timeout)
+	execStore.SetDraining()
+
+	// w.Stop stops pollers immediately and blocks until in-flight activities
+	// complete or WorkerStopTimeout elapses.
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		close(stopped)
+	}()
+
+	lost := execStore.Drain(deadline)
+	<-stopped
+
+	if err := execsession.RecordLostSessions(lostSessionsPath, lost); err != nil {
+		log.Printf("Warning: failed to save lost exec sessions: %v", err)
+	} else if len(lost) > 0 {
+		log.Printf("Recorded %d unfinished exec sessions to %s", len(lost), lostSessionsPath)
+	}
+	if closed := execStore.CloseAll(); closed > 0 {
+		log.Printf("Closed %d exec sessions", closed)
+	}
+}
@@ -0,0 +1,68 @@
+// Package evals_test runs the eval scenarios in scenarios/ as Go tests, one
+// subtest per scenario, against a running worker:
+//
+//	EVAL_MODEL=gpt-4o EVAL_JUDGE_MODEL=claude-sonnet-4-0 \
+//	  go test ./evals -run 'TestScenarios/refuse' -v -timeout 30m
+//
+// Sessions run in the repository root. Without EVAL_MODEL and
+// EVAL_JUDGE_MODEL the tests are skipped. cmd/evals runs the same scenarios
+// and adds scorecards and baseline comparison.
+package evals_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/evals"
+	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// TestScenarioFiles checks that the scenario files load, so a broken file
+// fails CI even where the evals themselves are skipped.
+func TestScenarioFiles(t *testing.T) {
+	scenarios, err := evals.LoadScenarios("scenarios")
+	require.NoError(t, err)
+	require.NotEmpty(t, scenarios)
+}
+
+func TestScenarios(t *testing.T) {
+	model, judgeModel := os.Getenv("EVAL_MODEL"), os.Getenv("EVAL_JUDGE_MODEL")
+	if model == "" || judgeModel == "" {
+		t.Skip("set EVAL_MODEL and EVAL_JUDGE_MODEL to run the evals against a running worker")
+	}
+	taskQueue := os.Getenv("EVAL_TASK_QUEUE")
+	if taskQueue == "" {
+		taskQueue = "temporal-agent-harness"
+	}
+	scenarios, err := evals.LoadScenarios("scenarios")
+	require.NoError(t, err)
+	root, err := filepath.Abs("..")
+	require.NoError(t, err)
+
+	opts, err := temporalclient.LoadClientOptions("", "")
+	require.NoError(t, err)
+	c, err := client.Dial(opts)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	for _, sc := range scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			t.Parallel()
+			input := evals.Options{Model: model, JudgeModel: judgeModel, Cwd: root}.
+				Input([]workflow.EvalScenario{sc})
+			result, err := evals.Run(context.Background(), c, taskQueue, "eval-"+uuid.New().String()[:8], input)
+			require.NoError(t, err)
+			t.Log("\n" + evals.Format(result))
+			if !result.Scenarios[0].Passed {
+				t.Errorf("scenario %s failed (session %s)", sc.Name, result.Scenarios[0].WorkflowID)
+			}
+		})
+	}
+}
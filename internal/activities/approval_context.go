@@ -0,0 +1,193 @@
+// Package activities implements Temporal activities.
+//
+// approval_context.go provides the DescribeApprovals activity, which looks
+// at the worker's filesystem to add context to tool calls awaiting approval:
+// what a write_file call would replace and which paths a shell command
+// names. It must run on the session's task queue so it sees the same
+// filesystem as the tools.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package activities
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/command_safety"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+const (
+	// approvalPreviewLines is how many lines of the current file are shown
+	// for a write_file approval.
+	approvalPreviewLines = 5
+	// approvalPreviewBytes bounds how much of the current file is read.
+	approvalPreviewBytes = 16 * 1024
+	// maxApprovalPaths caps the paths listed for a shell command.
+	maxApprovalPaths = 10
+)
+
+// ApprovalContextActivities contains the approval context activity.
+type ApprovalContextActivities struct{}
+
+// NewApprovalContextActivities creates a new ApprovalContextActivities instance.
+func NewApprovalContextActivities() *ApprovalContextActivities {
+	return &ApprovalContextActivities{}
+}
+
+// ApprovalCall is one tool call awaiting approval.
+type ApprovalCall struct {
+	CallID    string `json:"call_id"`
+	ToolName  string `json:"tool_name"`
+	Arguments string `json:"arguments"` // Raw JSON arguments
+}
+
+// DescribeApprovalsInput is the input for the DescribeApprovals activity.
+type DescribeApprovalsInput struct {
+	Cwd   string         `json:"cwd"`
+	Calls []ApprovalCall `json:"calls"`
+}
+
+// DescribeApprovalsOutput is the output of the DescribeApprovals activity.
+type DescribeApprovalsOutput struct {
+	Contexts map[string]models.ApprovalContext `json:"contexts,omitempty"` // Keyed by CallID
+}
+
+// DescribeApprovals gathers an ApprovalContext for each call it knows how to
+// describe. Calls it cannot describe (other tools, unparseable arguments)
+// are left out rather than failing the activity.
+func (a *ApprovalContextActivities) DescribeApprovals(_ context.Context, input DescribeApprovalsInput) (DescribeApprovalsOutput, error) {
+	out := DescribeApprovalsOutput{Contexts: make(map[string]models.ApprovalContext)}
+	for _, call := range input.Calls {
+		var args map[string]interface{}
+		if json.Unmarshal([]byte(call.Arguments), &args) != nil {
+			continue
+		}
+		var c *models.ApprovalContext
+		switch call.ToolName {
+		case "write_file":
+			c = describeWriteFile(input.Cwd, args)
+		case "shell", "shell_command":
+			c = describeShellCommand(input.Cwd, args)
+		}
+		if c != nil {
+			out.Contexts[call.CallID] = *c
+		}
+	}
+	return out, nil
+}
+
+// describeWriteFile reports the size change a write_file call would make
+// and, when it would replace a file, that file's first lines.
+func describeWriteFile(cwd string, args map[string]interface{}) *models.ApprovalContext {
+	path, _ := args["path"].(string)
+	if path == "" {
+		path, _ = args["file_path"].(string)
+	}
+	if path == "" {
+		return nil
+	}
+	content, _ := args["content"].(string)
+	c := &models.ApprovalContext{NewSize: int64(len(content))}
+
+	f, err := os.Open(resolveApprovalPath(cwd, path))
+	if err != nil {
+		return c // New file (or unreadable: nothing more to show)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return c
+	}
+	c.FileExists = true
+	c.CurrentSize = info.Size()
+
+	head, err := io.ReadAll(io.LimitReader(f, approvalPreviewBytes))
+	if err != nil || bytes.IndexByte(head, 0) >= 0 {
+		return c // Binary: sizes only
+	}
+	sc := bufio.NewScanner(bytes.NewReader(head))
+	for len(c.CurrentLines) < approvalPreviewLines && sc.Scan() {
+		c.CurrentLines = append(c.CurrentLines, sc.Text())
+	}
+	return c
+}
+
+// describeShellCommand lists the paths a shell command likely touches: the
+// arguments of each command in the script that exist under the working
+// directory or look like paths. Scripts the exec policy parser cannot split
+// into plain commands (redirections, substitutions) get no paths.
+func describeShellCommand(cwd string, args map[string]interface{}) *models.ApprovalContext {
+	var cmdVec []string
+	switch cmd := args["command"].(type) {
+	case string:
+		cmdVec = []string{"bash", "-lc", cmd}
+	case []interface{}:
+		for _, v := range cmd {
+			s, ok := v.(string)
+			if !ok {
+				return nil
+			}
+			cmdVec = append(cmdVec, s)
+		}
+	default:
+		return nil
+	}
+	if wd, _ := args["workdir"].(string); wd != "" {
+		cwd = resolveApprovalPath(cwd, wd)
+	}
+
+	commands := command_safety.ParseShellLcPlainCommands(cmdVec)
+	if commands == nil && !(len(cmdVec) == 3 && (cmdVec[1] == "-c" || cmdVec[1] == "-lc")) {
+		commands = [][]string{cmdVec} // Direct exec, not a script
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, command := range commands {
+		if len(command) == 0 {
+			continue
+		}
+		for _, arg := range command[1:] {
+			if len(paths) == maxApprovalPaths {
+				break
+			}
+			if !looksLikePath(cwd, arg) || seen[arg] {
+				continue
+			}
+			seen[arg] = true
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return &models.ApprovalContext{Paths: paths}
+}
+
+// looksLikePath reports whether a command argument names a path: not a
+// flag, and either present on disk or containing a path separator.
+func looksLikePath(cwd, arg string) bool {
+	if arg == "" || strings.HasPrefix(arg, "-") || strings.Contains(arg, "://") {
+		return false
+	}
+	if strings.ContainsRune(arg, '/') {
+		return true
+	}
+	_, err := os.Lstat(resolveApprovalPath(cwd, arg))
+	return err == nil
+}
+
+// resolveApprovalPath resolves path against cwd the way the tools do.
+func resolveApprovalPath(cwd, path string) string {
+	if filepath.IsAbs(path) || cwd == "" {
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
@@ -0,0 +1,56 @@
+package activities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeApprovals(t *testing.T) {
+	dir := t.TempDir()
+	mainGo := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(mainGo), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte{0x89, 'P', 'N', 'G', 0, 0}, 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "build"), 0o755))
+
+	a := NewApprovalContextActivities()
+	out, err := a.DescribeApprovals(context.Background(), DescribeApprovalsInput{
+		Cwd: dir,
+		Calls: []ApprovalCall{
+			{CallID: "overwrite", ToolName: "write_file", Arguments: `{"path":"main.go","content":"package main\n"}`},
+			{CallID: "create", ToolName: "write_file", Arguments: `{"path":"new.go","content":"package main\n"}`},
+			{CallID: "binary", ToolName: "write_file", Arguments: `{"path":"logo.png","content":""}`},
+			{CallID: "script", ToolName: "shell_command", Arguments: `{"command":"rm -rf build && cp main.go /tmp/out/ && echo done"}`},
+			{CallID: "argv", ToolName: "shell", Arguments: `{"command":["rm","-f","main.go","missing"]}`},
+			{CallID: "redirect", ToolName: "shell_command", Arguments: `{"command":"echo hi > main.go"}`},
+			{CallID: "other", ToolName: "apply_patch", Arguments: `{"input":"x"}`},
+			{CallID: "bad", ToolName: "write_file", Arguments: `{bad json`},
+		},
+	})
+	require.NoError(t, err)
+
+	overwrite := out.Contexts["overwrite"]
+	assert.True(t, overwrite.FileExists)
+	assert.Equal(t, []string{"package main", "", "import \"fmt\"", "", "func main() {"}, overwrite.CurrentLines)
+	assert.Equal(t, int64(len(mainGo)), overwrite.CurrentSize)
+	assert.Equal(t, int64(len("package main\n")-len(mainGo)), overwrite.SizeDelta())
+
+	create := out.Contexts["create"]
+	assert.False(t, create.FileExists)
+	assert.Equal(t, int64(13), create.SizeDelta())
+
+	binary := out.Contexts["binary"]
+	assert.True(t, binary.FileExists)
+	assert.Empty(t, binary.CurrentLines)
+
+	assert.Equal(t, []string{"build", "main.go", "/tmp/out/"}, out.Contexts["script"].Paths)
+	assert.Equal(t, []string{"main.go"}, out.Contexts["argv"].Paths)
+
+	for _, id := range []string{"redirect", "other", "bad"} {
+		assert.NotContains(t, out.Contexts, id)
+	}
+}
@@ -0,0 +1,98 @@
+// Package activities implements Temporal activities.
+//
+// approval_webhook.go provides the NotifyApprovalWebhook activity, which
+// POSTs a session's pending tool approvals to an external service. The
+// service replies asynchronously by sending the approval_response Update.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// approvalWebhookRequestTimeout bounds a single POST attempt.
+const approvalWebhookRequestTimeout = 30 * time.Second
+
+// ApprovalWebhookActivities contains the approval delegation activity.
+type ApprovalWebhookActivities struct {
+	client *http.Client
+}
+
+// NewApprovalWebhookActivities creates a new ApprovalWebhookActivities instance.
+func NewApprovalWebhookActivities() *ApprovalWebhookActivities {
+	return &ApprovalWebhookActivities{client: &http.Client{Timeout: approvalWebhookRequestTimeout}}
+}
+
+// ApprovalWebhookCall is one tool call awaiting a decision.
+type ApprovalWebhookCall struct {
+	CallID    string `json:"call_id"`
+	ToolName  string `json:"tool_name"`
+	Arguments string `json:"arguments"` // Raw JSON arguments
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ApprovalWebhookRequest is the JSON body POSTed to the webhook. To answer,
+// the service sends UpdateName to WorkflowID with an ApprovalResponse
+// ({"approved": [call IDs], "denied": [call IDs], "turn_epoch": TurnEpoch})
+// before Deadline.
+type ApprovalWebhookRequest struct {
+	WorkflowID string                `json:"workflow_id"`
+	RunID      string                `json:"run_id"`
+	TurnID     string                `json:"turn_id"`
+	TurnEpoch  int                   `json:"turn_epoch"` // Echo it so a late answer is rejected as stale
+	UpdateName string                `json:"update_name"`
+	Deadline   time.Time             `json:"deadline"`
+	Fallback   string                `json:"fallback"` // Applied at the deadline: "deny" or "escalate"
+	Calls      []ApprovalWebhookCall `json:"calls"`
+}
+
+// NotifyApprovalWebhookInput is the input for the NotifyApprovalWebhook activity.
+type NotifyApprovalWebhookInput struct {
+	URL     string                 `json:"url"`
+	Headers map[string]string      `json:"headers,omitempty"`
+	Request ApprovalWebhookRequest `json:"request"`
+}
+
+// NotifyApprovalWebhook POSTs the pending approvals to the webhook. Any 2xx
+// status acknowledges the request. Other 4xx responses fail without retry;
+// network errors and 5xx responses are retried by the activity retry policy.
+func (a *ApprovalWebhookActivities) NotifyApprovalWebhook(ctx context.Context, input NotifyApprovalWebhookInput) error {
+	body, err := json.Marshal(input.Request)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError("encode approval request", "ApprovalWebhookError", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, input.URL, bytes.NewReader(body))
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError("invalid approval webhook URL", "ApprovalWebhookError", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range input.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("approval webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("approval webhook rejected the request: HTTP %s", resp.Status), "ApprovalWebhookError", nil)
+	}
+	return fmt.Errorf("approval webhook: HTTP %s", resp.Status)
+}
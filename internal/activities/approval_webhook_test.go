@@ -0,0 +1,54 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestNotifyApprovalWebhook(t *testing.T) {
+	var got ApprovalWebhookRequest
+	var auth string
+	status := http.StatusAccepted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	a := NewApprovalWebhookActivities()
+	input := NotifyApprovalWebhookInput{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		Request: ApprovalWebhookRequest{
+			WorkflowID: "wf-1",
+			UpdateName: "approval_response",
+			Calls:      []ApprovalWebhookCall{{CallID: "call-1", ToolName: "shell_command", Arguments: `{"command":"rm x"}`}},
+		},
+	}
+	require.NoError(t, a.NotifyApprovalWebhook(context.Background(), input))
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, "wf-1", got.WorkflowID)
+	require.Len(t, got.Calls, 1)
+	assert.Equal(t, "call-1", got.Calls[0].CallID)
+
+	// A client error is not retried; a server error is.
+	var appErr *temporal.ApplicationError
+	status = http.StatusUnauthorized
+	err := a.NotifyApprovalWebhook(context.Background(), input)
+	require.True(t, errors.As(err, &appErr))
+	assert.True(t, appErr.NonRetryable())
+
+	status = http.StatusServiceUnavailable
+	err = a.NotifyApprovalWebhook(context.Background(), input)
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &appErr))
+}
@@ -0,0 +1,59 @@
+// Package activities implements Temporal activities.
+//
+// archive.go provides the ArchiveTranscript activity, which appends a
+// segment of session history to the configured object store so transcripts
+// survive Temporal's retention window.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package activities
+
+import (
+	"context"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/archive"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ArchiveActivities contains transcript archival activities.
+type ArchiveActivities struct{}
+
+// NewArchiveActivities creates a new ArchiveActivities instance.
+func NewArchiveActivities() *ArchiveActivities {
+	return &ArchiveActivities{}
+}
+
+// ArchiveTranscriptInput is the input for the ArchiveTranscript activity.
+type ArchiveTranscriptInput struct {
+	ArchiveURL string                    `json:"archive_url"` // s3://bucket/prefix, gs://bucket/prefix or file:///dir
+	SessionID  string                    `json:"session_id"`
+	Segment    int                       `json:"segment"` // 1-based segment number
+	Reason     string                    `json:"reason"`  // "turn_complete", "shutdown", "compaction", "context_edit"
+	ArchivedAt time.Time                 `json:"archived_at"`
+	Items      []models.ConversationItem `json:"items"`
+}
+
+// ArchiveTranscriptOutput is the output of the ArchiveTranscript activity.
+type ArchiveTranscriptOutput struct {
+	Key string `json:"key"` // Object name of the written segment
+}
+
+// ArchiveTranscript writes one history segment and updates the session
+// index. Rewriting a segment is idempotent, so the activity is safe to retry.
+func (a *ArchiveActivities) ArchiveTranscript(ctx context.Context, input ArchiveTranscriptInput) (ArchiveTranscriptOutput, error) {
+	arc, err := archive.Open(input.ArchiveURL)
+	if err != nil {
+		return ArchiveTranscriptOutput{}, err
+	}
+	ref, err := arc.AppendSegment(ctx, archive.Segment{
+		SessionID:  input.SessionID,
+		Number:     input.Segment,
+		Reason:     input.Reason,
+		ArchivedAt: input.ArchivedAt,
+		Items:      input.Items,
+	})
+	if err != nil {
+		return ArchiveTranscriptOutput{}, err
+	}
+	return ArchiveTranscriptOutput{Key: ref.Key}, nil
+}
@@ -0,0 +1,109 @@
+// Package activities implements Temporal activities.
+//
+// artifacts.go provides the RegisterArtifact activity, which shares a file
+// through the worker's artifact server (see internal/artifacts) for the
+// share_artifact tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/artifacts"
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+)
+
+// ArtifactActivities contains the artifact sharing activity.
+type ArtifactActivities struct {
+	server *artifacts.Server // nil when the worker does not serve artifacts
+}
+
+// NewArtifactActivities creates a new ArtifactActivities instance. server
+// may be nil.
+func NewArtifactActivities(server *artifacts.Server) *ArtifactActivities {
+	return &ArtifactActivities{server: server}
+}
+
+// RegisterArtifactInput is the input for the RegisterArtifact activity.
+type RegisterArtifactInput struct {
+	Path       string   `json:"path"` // Absolute or relative to Cwd
+	Cwd        string   `json:"cwd"`
+	Roots      []string `json:"roots,omitempty"`       // Directories besides Cwd the file may be under
+	TTLMinutes int      `json:"ttl_minutes,omitempty"` // 0 uses the server's default
+}
+
+// RegisterArtifactOutput describes the shared file, with its URL.
+type RegisterArtifactOutput struct {
+	Attachment attachment.Attachment `json:"attachment"`
+}
+
+// RegisterArtifact registers a file with the artifact server and returns
+// its URL. Only files under Cwd or Roots, after resolving symlinks, can be
+// shared. Missing files, files elsewhere and workers without an artifact
+// server fail without retrying.
+func (a *ArtifactActivities) RegisterArtifact(_ context.Context, input RegisterArtifactInput) (RegisterArtifactOutput, error) {
+	if a.server == nil {
+		return RegisterArtifactOutput{}, temporal.NewNonRetryableApplicationError(
+			"this worker does not serve artifacts (start it with WORKER_ARTIFACT_ADDR set)", "ArtifactServerDisabled", nil)
+	}
+	path, err := artifactPath(input)
+	if err != nil {
+		return RegisterArtifactOutput{}, invalidArtifactError(input.Path, err)
+	}
+	art, err := a.server.Register(path, time.Duration(input.TTLMinutes)*time.Minute)
+	if err != nil {
+		return RegisterArtifactOutput{}, invalidArtifactError(input.Path, err)
+	}
+	return RegisterArtifactOutput{Attachment: attachment.Attachment{
+		Name:      art.Name,
+		MimeType:  mime.TypeByExtension(strings.ToLower(filepath.Ext(art.Name))),
+		Size:      art.Size,
+		Path:      art.Path,
+		URL:       art.URL,
+		ExpiresAt: art.ExpiresAt,
+	}}, nil
+}
+
+// invalidArtifactError is the non-retryable error for a file that cannot
+// be shared.
+func invalidArtifactError(path string, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		err = fmt.Errorf("%s does not exist", path)
+	}
+	return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidArtifact", nil)
+}
+
+// artifactPath resolves the file to share, following symlinks, and checks
+// that it is under the working directory or one of the roots.
+func artifactPath(input RegisterArtifactInput) (string, error) {
+	path := input.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(input.Cwd, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	for _, root := range append([]string{input.Cwd}, input.Roots...) {
+		if root == "" {
+			continue
+		}
+		if r, err := filepath.EvalSymlinks(root); err == nil {
+			root = r
+		}
+		if rel, err := filepath.Rel(root, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%s is outside the working directory and the sandbox's writable roots", input.Path)
+}
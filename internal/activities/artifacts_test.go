@@ -0,0 +1,42 @@
+package activities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/artifacts"
+)
+
+func TestRegisterArtifact_OnlyUnderCwdAndRoots(t *testing.T) {
+	cwd, out, secrets := t.TempDir(), t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cwd, "report.html"), []byte("<p>ok</p>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(out, "build.log"), []byte("ok"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(secrets, "id_rsa"), []byte("key"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(secrets, "id_rsa"), filepath.Join(cwd, "key")))
+
+	a := NewArtifactActivities(artifacts.NewServer(artifacts.Config{BaseURL: "http://worker:8089"}))
+	register := func(path string) (RegisterArtifactOutput, error) {
+		return a.RegisterArtifact(context.Background(), RegisterArtifactInput{Path: path, Cwd: cwd, Roots: []string{out}})
+	}
+
+	res, err := register("report.html")
+	require.NoError(t, err)
+	assert.Equal(t, "report.html", res.Attachment.Name)
+	_, err = register(filepath.Join(out, "build.log"))
+	require.NoError(t, err)
+
+	for _, path := range []string{filepath.Join(secrets, "id_rsa"), "../" + filepath.Base(secrets) + "/id_rsa", "key"} {
+		_, err = register(path)
+		require.Error(t, err, path)
+		assert.Contains(t, err.Error(), "outside the working directory", path)
+	}
+
+	_, err = register("missing.txt")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.txt does not exist")
+}
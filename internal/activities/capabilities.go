@@ -0,0 +1,84 @@
+package activities
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	"github.com/mfateev/temporal-agent-harness/internal/mcp"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+	"github.com/mfateev/temporal-agent-harness/internal/version"
+)
+
+// CapabilityActivities reports what the worker serving a session supports.
+type CapabilityActivities struct {
+	registry   *tools.ToolRegistry
+	mcpStore   *mcp.McpStore
+	providers  []string
+	remoteHost string
+}
+
+// NewCapabilityActivities creates a CapabilityActivities instance. providers
+// are the LLM providers the worker has credentials for; mcpStore may be nil
+// on workers without MCP support.
+func NewCapabilityActivities(registry *tools.ToolRegistry, mcpStore *mcp.McpStore, providers []string) *CapabilityActivities {
+	return &CapabilityActivities{registry: registry, mcpStore: mcpStore, providers: providers}
+}
+
+// WithRemoteHost reports that the worker runs its tools on host over SSH.
+// Returns the receiver for chaining.
+func (a *CapabilityActivities) WithRemoteHost(host string) *CapabilityActivities {
+	a.remoteHost = host
+	return a
+}
+
+// DescribeWorkerInput is the input for the DescribeWorker activity.
+type DescribeWorkerInput struct {
+	// SessionID selects the session whose MCP connections are reported.
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// WorkerCapabilities describes the worker that ran DescribeWorker.
+type WorkerCapabilities struct {
+	Version   string `json:"version"` // Worker build (version.GitCommit)
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Host      string `json:"host,omitempty"`
+
+	// Tools are the tool handlers registered on the worker.
+	Tools []string `json:"tools"`
+	// Providers are the LLM providers the worker has API keys for.
+	Providers []string `json:"providers"`
+	// McpServers are the MCP servers connected for the session.
+	McpServers []string `json:"mcp_servers,omitempty"`
+	// SandboxBackends are the platform sandboxes available; empty means
+	// commands run unsandboxed.
+	SandboxBackends []string `json:"sandbox_backends,omitempty"`
+	// RemoteHost is the SSH host the worker runs its tools on, if any.
+	RemoteHost string `json:"remote_host,omitempty"`
+}
+
+// DescribeWorker reports the worker's build, tools, LLM providers, sandbox
+// backends and the session's MCP servers. It only reads local state.
+func (a *CapabilityActivities) DescribeWorker(_ context.Context, input DescribeWorkerInput) (WorkerCapabilities, error) {
+	host, _ := os.Hostname()
+	caps := WorkerCapabilities{
+		Version:         version.GitCommit,
+		GoVersion:       runtime.Version(),
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		Host:            host,
+		Tools:           a.registry.ToolNames(),
+		Providers:       a.providers,
+		SandboxBackends: sandbox.AvailableBackends(),
+		RemoteHost:      a.remoteHost,
+	}
+	if a.mcpStore != nil {
+		if mgr := a.mcpStore.Get(input.SessionID); mgr != nil {
+			caps.McpServers = mgr.ServerNames()
+		}
+	}
+	return caps, nil
+}
@@ -0,0 +1,67 @@
+// Package activities implements Temporal activities.
+//
+// codeindex.go provides the IndexCode activity, which keeps the embeddings
+// index behind the semantic_search tool up to date. It must run on the
+// session's task queue so it indexes the same filesystem the tools see.
+package activities
+
+import (
+	"context"
+	"os"
+
+	"github.com/mfateev/temporal-agent-harness/internal/codeindex"
+)
+
+// CodeIndexActivities contains code index activities.
+type CodeIndexActivities struct{}
+
+// NewCodeIndexActivities creates a new CodeIndexActivities instance.
+func NewCodeIndexActivities() *CodeIndexActivities {
+	return &CodeIndexActivities{}
+}
+
+// IndexCodeInput is the input for the IndexCode activity.
+type IndexCodeInput struct {
+	Cwd      string   `json:"cwd"`                // Repository root; the index lives under it
+	Provider string   `json:"provider,omitempty"` // "" = codeindex.DefaultProvider
+	Model    string   `json:"model,omitempty"`    // "" = provider default
+	Paths    []string `json:"paths,omitempty"`    // Files to reindex; ignored when Full
+	Full     bool     `json:"full,omitempty"`     // Check every file under Cwd
+}
+
+// IndexCodeOutput is the output of the IndexCode activity.
+type IndexCodeOutput struct {
+	Stats codeindex.Stats `json:"stats"`
+}
+
+// IndexCode builds or refreshes the code index under input.Cwd. Unchanged
+// files are not re-embedded, so a full pass over an indexed tree is cheap.
+func (a *CodeIndexActivities) IndexCode(ctx context.Context, input IndexCodeInput) (IndexCodeOutput, error) {
+	root := input.Cwd
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return IndexCodeOutput{}, err
+		}
+		root = cwd
+	}
+	provider := input.Provider
+	if provider == "" {
+		provider = codeindex.DefaultProvider
+	}
+	emb, model, err := codeindex.NewEmbedder(provider, input.Model)
+	if err != nil {
+		return IndexCodeOutput{}, err
+	}
+	paths := input.Paths
+	if input.Full {
+		paths = nil
+	} else if paths == nil {
+		paths = []string{}
+	}
+	stats, err := codeindex.Update(ctx, root, emb, provider, model, paths)
+	if err != nil {
+		return IndexCodeOutput{}, err
+	}
+	return IndexCodeOutput{Stats: stats}, nil
+}
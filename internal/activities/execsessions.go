@@ -2,8 +2,12 @@ package activities
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"go.temporal.io/sdk/temporal"
+
 	"github.com/mfateev/temporal-agent-harness/internal/execsession"
 )
 
@@ -44,6 +48,26 @@ type CleanExecSessionsResponse struct {
 	Closed int `json:"closed"`
 }
 
+// WriteExecStdinRequest is the payload for the WriteExecStdin activity.
+type WriteExecStdinRequest struct {
+	ProcessID string `json:"process_id"`
+	Chars     string `json:"chars"`
+}
+
+// WriteExecStdinResponse is the output of the WriteExecStdin activity.
+type WriteExecStdinResponse struct {
+	Output string `json:"output"`
+	Exited bool   `json:"exited"`
+}
+
+// writeStdinSettle is how long WriteExecStdin lets the process react
+// before reading its output.
+const writeStdinSettle = 500 * time.Millisecond
+
+// writeStdinOutputLines is how many trailing output lines WriteExecStdin
+// returns.
+const writeStdinOutputLines = 20
+
 // ListExecSessions returns a summary of all exec sessions.
 func (a *ExecSessionActivities) ListExecSessions(_ context.Context, _ ListExecSessionsRequest) (ListExecSessionsResponse, error) {
 	storeSummaries := a.store.ListAll()
@@ -66,3 +90,32 @@ func (a *ExecSessionActivities) CleanExecSessions(_ context.Context, _ CleanExec
 	closed := a.store.CloseAll()
 	return CleanExecSessionsResponse{Closed: closed}, nil
 }
+
+// WriteExecStdin writes a user's input to an exec session and returns the
+// tail of its output. The output is read without draining it, so the model
+// still sees it on its next write_stdin poll.
+func (a *ExecSessionActivities) WriteExecStdin(_ context.Context, req WriteExecStdinRequest) (WriteExecStdinResponse, error) {
+	sess, err := a.store.Get(req.ProcessID)
+	if err != nil {
+		return WriteExecStdinResponse{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("exec session %s: %v", req.ProcessID, err), "ExecSessionError", err)
+	}
+	if err := sess.WriteStdin([]byte(req.Chars)); err != nil {
+		return WriteExecStdinResponse{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("write to exec session %s: %v", req.ProcessID, err), "ExecSessionError", err)
+	}
+	time.Sleep(writeStdinSettle)
+	return WriteExecStdinResponse{
+		Output: lastLines(string(sess.OutputSnapshot()), writeStdinOutputLines),
+		Exited: sess.HasExited(),
+	}, nil
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\r\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
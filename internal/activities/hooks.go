@@ -0,0 +1,124 @@
+// Package activities implements Temporal activities.
+//
+// hooks.go provides the RunHooks activity, which runs the user's hook
+// scripts for an event. It must run on the session's task queue so scripts
+// see the same filesystem as the tools.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package activities
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/hooks"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+)
+
+// HookActivities contains the hook activity.
+type HookActivities struct {
+	sandboxMgr sandbox.SandboxManager
+}
+
+// NewHookActivities creates a new HookActivities instance. Sandboxed hooks
+// fail to run on platforms without a sandbox.
+func NewHookActivities() *HookActivities {
+	mgr := sandbox.NewSandboxManager()
+	if _, noop := mgr.(*sandbox.NoopSandbox); noop {
+		mgr = nil
+	}
+	return &HookActivities{sandboxMgr: mgr}
+}
+
+// RunHooksInput is the input for the RunHooks activity.
+type RunHooksInput struct {
+	Event      hooks.Event            `json:"event"`
+	CodexHome  string                 `json:"codex_home,omitempty"`
+	Cwd        string                 `json:"cwd,omitempty"`
+	TimeoutSec int                    `json:"timeout_sec,omitempty"` // Per script; 0 = hooks.DefaultTimeout
+	Sandbox    *sandbox.SandboxPolicy `json:"sandbox,omitempty"`     // nil = unsandboxed
+	Payloads   []hooks.Payload        `json:"payloads"`              // One per tool call, or one per turn
+}
+
+// HookOutcome is the result of running an event's scripts for one payload.
+// Scripts run in name order and stop at the first objection.
+type HookOutcome struct {
+	CallID  string         `json:"call_id,omitempty"`
+	Results []hooks.Result `json:"results,omitempty"`
+}
+
+// Objection returns the first objecting result, or nil.
+func (o HookOutcome) Objection() *hooks.Result {
+	for i := range o.Results {
+		if o.Results[i].Objected() {
+			return &o.Results[i]
+		}
+	}
+	return nil
+}
+
+// Failure returns the first result whose script could not run or timed
+// out, or nil.
+func (o HookOutcome) Failure() *hooks.Result {
+	for i := range o.Results {
+		if o.Results[i].Error != "" {
+			return &o.Results[i]
+		}
+	}
+	return nil
+}
+
+// RunHooksOutput is the output of the RunHooks activity.
+type RunHooksOutput struct {
+	NoScripts bool          `json:"no_scripts,omitempty"` // No script exists for the event
+	Outcomes  []HookOutcome `json:"outcomes,omitempty"`   // Parallel to input.Payloads
+}
+
+// RunHooks runs the event's scripts once per payload. Payloads run in
+// parallel. Script failures are reported in the outcomes, not as errors.
+func (a *HookActivities) RunHooks(ctx context.Context, input RunHooksInput) (RunHooksOutput, error) {
+	codexHome := input.CodexHome
+	if codexHome == "" {
+		codexHome = defaultCodexHome()
+	}
+	scripts, err := hooks.Find(hooks.Dir(codexHome), input.Event)
+	if err != nil {
+		return RunHooksOutput{}, err
+	}
+	if len(scripts) == 0 {
+		return RunHooksOutput{NoScripts: true}, nil
+	}
+
+	opts := hooks.Options{
+		Cwd:     input.Cwd,
+		Timeout: time.Duration(input.TimeoutSec) * time.Second,
+		Policy:  input.Sandbox,
+	}
+	if input.Sandbox != nil && a.sandboxMgr != nil {
+		opts.Sandbox = a.sandboxMgr
+	}
+
+	out := RunHooksOutput{Outcomes: make([]HookOutcome, len(input.Payloads))}
+	var wg sync.WaitGroup
+	for i, payload := range input.Payloads {
+		wg.Add(1)
+		go func(i int, payload hooks.Payload) {
+			defer wg.Done()
+			outcome := HookOutcome{}
+			if payload.Tool != nil {
+				outcome.CallID = payload.Tool.CallID
+			}
+			for _, script := range scripts {
+				res := hooks.Run(ctx, script, payload, opts)
+				outcome.Results = append(outcome.Results, res)
+				if res.Objected() {
+					break
+				}
+			}
+			out.Outcomes[i] = outcome
+		}(i, payload)
+	}
+	wg.Wait()
+	return out, nil
+}
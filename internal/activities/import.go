@@ -0,0 +1,159 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/memories"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ImportActivities pulls context from other sessions (import_context Update).
+type ImportActivities struct {
+	llmClient      llm.LLMClient
+	temporalClient client.Client
+}
+
+// NewImportActivities creates a new ImportActivities instance.
+func NewImportActivities(llmClient llm.LLMClient, temporalClient client.Client) *ImportActivities {
+	return &ImportActivities{
+		llmClient:      llmClient,
+		temporalClient: temporalClient,
+	}
+}
+
+// SummarizeSessionInput is the input for the SummarizeSession activity.
+type SummarizeSessionInput struct {
+	// SourceWorkflowID is the session to import: an AgenticWorkflow ID or
+	// the SessionWorkflow ID that owns one.
+	SourceWorkflowID string `json:"source_workflow_id"`
+
+	// ModelConfig is the importing session's model, used for summarization.
+	ModelConfig models.ModelConfig `json:"model_config"`
+}
+
+// SummarizeSessionOutput is the output from the SummarizeSession activity.
+type SummarizeSessionOutput struct {
+	// AgentWorkflowID is the workflow whose history was read.
+	AgentWorkflowID string            `json:"agent_workflow_id"`
+	Summary         string            `json:"summary"`
+	ItemCount       int               `json:"item_count"`
+	TokenUsage      models.TokenUsage `json:"token_usage"`
+}
+
+// SummarizeSession reads another session's conversation history via the
+// get_conversation_items query and summarizes it with the compaction prompt.
+// Closed sessions work as long as a worker can answer the query.
+func (a *ImportActivities) SummarizeSession(ctx context.Context, input SummarizeSessionInput) (SummarizeSessionOutput, error) {
+	agentWfID, items, err := a.fetchConversationItems(ctx, input.SourceWorkflowID)
+	if err != nil {
+		return SummarizeSessionOutput{}, err
+	}
+
+	transcript, err := memories.SerializeConversationForMemory(items)
+	if err != nil {
+		return SummarizeSessionOutput{}, fmt.Errorf("serialize history: %w", err)
+	}
+	if transcript == "null" {
+		return SummarizeSessionOutput{}, fmt.Errorf("session %s has no conversation to import", input.SourceWorkflowID)
+	}
+
+	// Keep the transcript within the summarizing model's context window.
+	limit := int(float64(input.ModelConfig.ContextWindow) * memories.ContextWindowPercentForRollout)
+	if limit <= 0 {
+		limit = memories.DefaultRolloutTokenLimit
+	}
+	transcript = memories.TruncateToTokenLimit(transcript, limit)
+
+	summary, usage, err := llm.SummarizeTranscript(ctx, a.llmClient, input.ModelConfig, transcript)
+	if err != nil {
+		var activityErr *models.ActivityError
+		if errors.As(err, &activityErr) {
+			return SummarizeSessionOutput{}, models.WrapActivityError(activityErr)
+		}
+		return SummarizeSessionOutput{}, err
+	}
+
+	return SummarizeSessionOutput{
+		AgentWorkflowID: agentWfID,
+		Summary:         summary,
+		ItemCount:       len(items),
+		TokenUsage:      usage,
+	}, nil
+}
+
+// ReadSessionTranscriptInput is the input for the ReadSessionTranscript
+// activity.
+type ReadSessionTranscriptInput struct {
+	WorkflowID string `json:"workflow_id"`           // AgenticWorkflow or SessionWorkflow ID
+	TokenLimit int    `json:"token_limit,omitempty"` // Default memories.DefaultRolloutTokenLimit
+}
+
+// ReadSessionTranscriptOutput is the output from the ReadSessionTranscript
+// activity.
+type ReadSessionTranscriptOutput struct {
+	Transcript string `json:"transcript"`
+	ItemCount  int    `json:"item_count"`
+}
+
+// ReadSessionTranscript reads another session's conversation items, as
+// SummarizeSession does, and returns them serialized and truncated to the
+// token limit. EvalWorkflow shows it to the judge.
+func (a *ImportActivities) ReadSessionTranscript(ctx context.Context, input ReadSessionTranscriptInput) (ReadSessionTranscriptOutput, error) {
+	_, items, err := a.fetchConversationItems(ctx, input.WorkflowID)
+	if err != nil {
+		return ReadSessionTranscriptOutput{}, err
+	}
+	transcript, err := memories.SerializeConversationForMemory(items)
+	if err != nil {
+		return ReadSessionTranscriptOutput{}, fmt.Errorf("serialize history: %w", err)
+	}
+	limit := input.TokenLimit
+	if limit <= 0 {
+		limit = memories.DefaultRolloutTokenLimit
+	}
+	return ReadSessionTranscriptOutput{
+		Transcript: memories.TruncateToTokenLimit(transcript, limit),
+		ItemCount:  len(items),
+	}, nil
+}
+
+// fetchConversationItems queries workflowID for its conversation items. If
+// workflowID is a SessionWorkflow, the query is retried against its
+// AgenticWorkflow child.
+func (a *ImportActivities) fetchConversationItems(ctx context.Context, workflowID string) (string, []models.ConversationItem, error) {
+	items, err := a.queryConversationItems(ctx, workflowID)
+	if err == nil {
+		return workflowID, items, nil
+	}
+
+	resp, qErr := a.temporalClient.QueryWorkflow(ctx, workflowID, "", "get_agent_workflow_id")
+	if qErr != nil {
+		return "", nil, fmt.Errorf("failed to read session %s: %w", workflowID, err)
+	}
+	var agentWfID string
+	if qErr := resp.Get(&agentWfID); qErr != nil || agentWfID == "" {
+		return "", nil, fmt.Errorf("failed to read session %s: %w", workflowID, err)
+	}
+	items, err = a.queryConversationItems(ctx, agentWfID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read session %s: %w", agentWfID, err)
+	}
+	return agentWfID, items, nil
+}
+
+func (a *ImportActivities) queryConversationItems(ctx context.Context, workflowID string) ([]models.ConversationItem, error) {
+	resp, err := a.temporalClient.QueryWorkflow(ctx, workflowID, "", "get_conversation_items")
+	if err != nil {
+		return nil, err
+	}
+	var items []models.ConversationItem
+	if err := resp.Get(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/shell"
 )
 
 // LoadWorkerInstructionsInput is the input for the LoadWorkerInstructions activity.
@@ -18,7 +21,30 @@ type LoadWorkerInstructionsInput struct {
 // LoadWorkerInstructionsOutput is the output from the LoadWorkerInstructions activity.
 type LoadWorkerInstructionsOutput struct {
 	ProjectDocs string `json:"project_docs,omitempty"`
-	GitRoot     string `json:"git_root,omitempty"`
+
+	// OverflowDocs are the project docs past MaxProjectDocsBytes, clipped
+	// for the SummarizeProjectDocs activity. ProjectDocs marks its files
+	// "verbatim" when this is non-empty.
+	OverflowDocs []instructions.ProjectDoc `json:"overflow_docs,omitempty"`
+	GitRoot      string                    `json:"git_root,omitempty"`
+
+	// Profile merges the policies the instruction files declare in their
+	// front-matter (model, tools, approval mode); nil when none does.
+	// ProfileErrors lists the files whose front-matter is invalid.
+	Profile       *instructions.DirectoryProfile `json:"profile,omitempty"`
+	ProfileErrors []string                       `json:"profile_errors,omitempty"`
+
+	// CwdMissing is set when Cwd is not a directory on the worker.
+	CwdMissing bool `json:"cwd_missing,omitempty"`
+
+	// Shell and OS describe the worker that runs tools ("powershell",
+	// "windows"), for the model's environment context.
+	Shell string `json:"shell,omitempty"`
+	OS    string `json:"os,omitempty"`
+
+	// Timezone is the IANA name of the worker's local timezone
+	// ("Europe/Berlin"), or empty when it cannot be determined.
+	Timezone string `json:"timezone,omitempty"`
 }
 
 // InstructionActivities contains instruction-loading activities.
@@ -30,34 +56,64 @@ func NewInstructionActivities() *InstructionActivities {
 }
 
 // LoadWorkerInstructions discovers and loads AGENTS.md files from the
-// worker's file system. Runs on the session task queue so it executes
-// on the same machine where tools run.
+// worker's file system and reports the worker's shell, OS and timezone. Runs on the
+// session task queue so it executes on the same machine where tools run.
 func (a *InstructionActivities) LoadWorkerInstructions(
 	ctx context.Context, input LoadWorkerInstructionsInput,
 ) (LoadWorkerInstructionsOutput, error) {
+	out := LoadWorkerInstructionsOutput{
+		Shell:    shell.DetectUserShell().Name(),
+		OS:       runtime.GOOS,
+		Timezone: localTimezone(),
+	}
 	if input.Cwd == "" {
-		return LoadWorkerInstructionsOutput{}, nil
+		return out, nil
+	}
+	if info, err := os.Stat(input.Cwd); err != nil || !info.IsDir() {
+		out.CwdMissing = true
+		return out, nil
 	}
 
 	gitRoot, err := instructions.FindGitRoot(input.Cwd)
 	if err != nil {
-		return LoadWorkerInstructionsOutput{}, nil // non-fatal
+		return out, nil // non-fatal
 	}
 
 	if gitRoot == "" {
 		// Not in a git repo — no project docs to load
-		return LoadWorkerInstructionsOutput{}, nil
+		return out, nil
 	}
 
-	projectDocs, err := instructions.LoadProjectDocs(gitRoot, input.Cwd, input.AgentsFileNames)
+	docs, err := instructions.CollectProjectDocs(gitRoot, input.Cwd, input.AgentsFileNames)
 	if err != nil {
-		return LoadWorkerInstructionsOutput{}, nil // non-fatal
+		return out, nil // non-fatal
 	}
 
-	return LoadWorkerInstructionsOutput{
-		ProjectDocs: projectDocs,
-		GitRoot:     gitRoot,
-	}, nil
+	out.ProjectDocs, out.OverflowDocs = instructions.FormatProjectDocsWithOverflow(docs)
+	out.Profile = instructions.MergeDirectoryProfiles(docs)
+	for _, doc := range docs {
+		if doc.ProfileError != "" {
+			out.ProfileErrors = append(out.ProfileErrors, doc.Path+": "+doc.ProfileError)
+		}
+	}
+	out.GitRoot = gitRoot
+	return out, nil
+}
+
+// localTimezone returns the IANA name of the local timezone: $TZ when it
+// names one, else the zoneinfo file /etc/localtime links to.
+func localTimezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return ""
 }
 
 // LoadExecPolicyInput is the input for the LoadExecPolicy activity.
@@ -4,10 +4,14 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
 )
 
 func TestLoadWorkerInstructions_WithAGENTSmd(t *testing.T) {
@@ -35,6 +39,22 @@ func TestLoadWorkerInstructions_EmptyCwd(t *testing.T) {
 	assert.Empty(t, result.GitRoot)
 }
 
+func TestLoadWorkerInstructions_ReportsShellAndOS(t *testing.T) {
+	a := NewInstructionActivities()
+	result, err := a.LoadWorkerInstructions(context.Background(), LoadWorkerInstructionsInput{})
+	require.NoError(t, err)
+	assert.Equal(t, runtime.GOOS, result.OS)
+	assert.NotEmpty(t, result.Shell)
+}
+
+func TestLocalTimezone(t *testing.T) {
+	t.Setenv("TZ", ":Asia/Tokyo")
+	assert.Equal(t, "Asia/Tokyo", localTimezone())
+
+	t.Setenv("TZ", "Nowhere/Special")
+	assert.NotEqual(t, "Nowhere/Special", localTimezone(), "names Go cannot load are ignored")
+}
+
 func TestLoadWorkerInstructions_NonGitDir(t *testing.T) {
 	dir := t.TempDir()
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("ignored"), 0o644))
@@ -48,6 +68,23 @@ func TestLoadWorkerInstructions_NonGitDir(t *testing.T) {
 	assert.Empty(t, result.GitRoot)
 }
 
+func TestLoadWorkerInstructions_MissingCwd(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0o644))
+
+	a := NewInstructionActivities()
+	for _, cwd := range []string{filepath.Join(dir, "missing"), file} {
+		result, err := a.LoadWorkerInstructions(context.Background(), LoadWorkerInstructionsInput{Cwd: cwd})
+		require.NoError(t, err)
+		assert.True(t, result.CwdMissing, cwd)
+	}
+
+	result, err := a.LoadWorkerInstructions(context.Background(), LoadWorkerInstructionsInput{Cwd: dir})
+	require.NoError(t, err)
+	assert.False(t, result.CwdMissing)
+}
+
 func TestLoadWorkerInstructions_Subdirectory(t *testing.T) {
 	// .git at root, AGENTS.md at root, cwd is a subdirectory
 	dir := t.TempDir()
@@ -68,6 +105,27 @@ func TestLoadWorkerInstructions_Subdirectory(t *testing.T) {
 	assert.Equal(t, dir, result.GitRoot)
 }
 
+func TestLoadWorkerInstructions_FrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AGENTS.md"),
+		[]byte("---\nmodel: o3\ntools:\n  deny: [nope]\n---\nroot docs"), 0o644))
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "AGENTS.md"),
+		[]byte("---\napproval_mode: never\n---\nsub docs"), 0o644))
+
+	a := NewInstructionActivities()
+	result, err := a.LoadWorkerInstructions(context.Background(), LoadWorkerInstructionsInput{Cwd: sub})
+	require.NoError(t, err)
+	assert.Contains(t, result.ProjectDocs, "sub docs")
+	assert.NotContains(t, result.ProjectDocs, "approval_mode")
+	require.NotNil(t, result.Profile)
+	assert.Equal(t, "never", result.Profile.ApprovalMode)
+	assert.Empty(t, result.Profile.Model, "the root file's front-matter is invalid")
+	assert.Equal(t, []string{`AGENTS.md: unknown tool "nope"`}, result.ProfileErrors)
+}
+
 func TestLoadPersonalInstructions_FileExists(t *testing.T) {
 	dir := t.TempDir()
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "instructions.md"), []byte("personal instructions content"), 0o644))
@@ -132,3 +190,20 @@ func TestLoadConfigFile_EmptyCodexHome(t *testing.T) {
 	require.NoError(t, err)
 	_ = result // RawTOML may or may not be set depending on the environment
 }
+
+func TestLoadWorkerInstructions_OverflowDocs(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.Mkdir(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AGENTS.md"),
+		[]byte(strings.Repeat("x", instructions.MaxProjectDocsBytes-20)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "AGENTS.md"), []byte("nested rules"), 0o644))
+
+	a := NewInstructionActivities()
+	result, err := a.LoadWorkerInstructions(context.Background(), LoadWorkerInstructionsInput{Cwd: sub})
+	require.NoError(t, err)
+	assert.Contains(t, result.ProjectDocs, "--- AGENTS.md (verbatim) ---")
+	require.Len(t, result.OverflowDocs, 1)
+	assert.Equal(t, "nested rules", result.OverflowDocs[0].Content)
+}
@@ -6,10 +6,19 @@ package activities
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 
 	"github.com/mfateev/temporal-agent-harness/internal/instructions"
 	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/logging"
+	"github.com/mfateev/temporal-agent-harness/internal/memories"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
 
@@ -28,6 +37,13 @@ type LLMActivityInput struct {
 
 	// OpenAI Responses API: chain to previous response for incremental sends
 	PreviousResponseID string `json:"previous_response_id,omitempty"`
+
+	// ResponseFormat, if set, requests JSON output matching a schema
+	// (OpenAI structured outputs, Anthropic tool forcing).
+	ResponseFormat *models.ResponseFormat `json:"response_format,omitempty"`
+
+	// TurnID is the turn the call belongs to, for log correlation.
+	TurnID string `json:"turn_id,omitempty"`
 }
 
 // LLMActivityOutput is the output from the LLM activity.
@@ -42,16 +58,82 @@ type LLMActivityOutput struct {
 
 	// OpenAI Responses API: response ID for chaining
 	ResponseID string `json:"response_id,omitempty"`
+
+	// PromptHash identifies the model, instructions and tool specs sent
+	// (llm.PromptHash). It changes when the provider's prompt cache cannot
+	// be reused.
+	PromptHash string `json:"prompt_hash,omitempty"`
+}
+
+// SignalLLMQueued is sent by ExecuteLLMCall to its workflow while the call
+// waits behind the worker's provider rate limiter.
+const SignalLLMQueued = "llm_queued"
+
+// LLMQueuedSignal is the payload of SignalLLMQueued. Position is the call's
+// 1-based place in the queue; 0 means the call has been admitted.
+type LLMQueuedSignal struct {
+	Position int `json:"position"`
 }
 
 // LLMActivities contains LLM-related activities.
 type LLMActivities struct {
-	client llm.LLMClient
+	client          llm.LLMClient
+	temporalClient  client.Client
+	anthropicTokens *tokenizer.AnthropicCounter
+	prompts         *llm.PromptCache // Per-session prompt assemblies
 }
 
 // NewLLMActivities creates a new LLMActivities instance.
 func NewLLMActivities(client llm.LLMClient) *LLMActivities {
-	return &LLMActivities{client: client}
+	return &LLMActivities{client: client, prompts: llm.NewPromptCache(0)}
+}
+
+// WithQueueSignals enables SignalLLMQueued notifications, sent through c
+// when a rate-limited call is queued. Returns the receiver for chaining.
+func (a *LLMActivities) WithQueueSignals(c client.Client) *LLMActivities {
+	a.temporalClient = c
+	return a
+}
+
+// WithAnthropicTokenCounter enables exact Anthropic token counts in the
+// CountTokens activity. Returns the receiver for chaining.
+func (a *LLMActivities) WithAnthropicTokenCounter(c *tokenizer.AnthropicCounter) *LLMActivities {
+	a.anthropicTokens = c
+	return a
+}
+
+// CountTokensInput is the input for the CountTokens activity.
+type CountTokensInput struct {
+	ModelConfig models.ModelConfig `json:"model_config"`
+	Texts       []string           `json:"texts"`
+}
+
+// CountTokensOutput is the output of the CountTokens activity.
+type CountTokensOutput struct {
+	Counts []int `json:"counts"` // Counts[i] is for Texts[i]
+}
+
+// CountTokens counts the tokens of texts for the input's model: with the
+// count_tokens endpoint for Anthropic, with the local tokenizer otherwise.
+// Fails non-retryably for Anthropic when the worker has no counter.
+func (a *LLMActivities) CountTokens(ctx context.Context, input CountTokensInput) (CountTokensOutput, error) {
+	if input.ModelConfig.Provider != "anthropic" {
+		counter := tokenizer.ForModel(input.ModelConfig.Provider, input.ModelConfig.Model)
+		counts := make([]int, len(input.Texts))
+		for i, text := range input.Texts {
+			counts[i] = counter.Count(text)
+		}
+		return CountTokensOutput{Counts: counts}, nil
+	}
+	if a.anthropicTokens == nil {
+		return CountTokensOutput{}, temporal.NewNonRetryableApplicationError(
+			"anthropic token counting is not configured on this worker", "TokenCountingUnavailable", nil)
+	}
+	counts, err := a.anthropicTokens.CountTexts(ctx, llm.AnthropicModelID(input.ModelConfig.Model), input.Texts)
+	if err != nil {
+		return CountTokensOutput{}, err
+	}
+	return CountTokensOutput{Counts: counts}, nil
 }
 
 // ExecuteLLMCall executes an LLM call and returns the complete response.
@@ -66,22 +148,60 @@ func (a *LLMActivities) ExecuteLLMCall(ctx context.Context, input LLMActivityInp
 		DeveloperInstructions: input.DeveloperInstructions,
 		UserInstructions:      input.UserInstructions,
 		PreviousResponseID:    input.PreviousResponseID,
+		ResponseFormat:        input.ResponseFormat,
+	}
+	logger := logging.Activity(ctx, input.TurnID, "").With(
+		"provider", input.ModelConfig.Provider, "model", input.ModelConfig.Model)
+	// Stream progress as heartbeat details. Clients read them from the
+	// pending activity to show partial tool calls, and heartbeating lets a
+	// workflow-side cancel (interrupt) reach the in-flight stream.
+	if activity.IsActivity(ctx) {
+		request.OnProgress = func(p llm.StreamProgress) {
+			activity.RecordHeartbeat(ctx, p)
+		}
+		// Rate limiting is fair across sessions; tell the workflow while this
+		// call is queued so TurnStatus can show the backlog.
+		info := activity.GetInfo(ctx)
+		request.SessionKey = info.WorkflowExecution.ID
+		if a.temporalClient != nil {
+			// The limiter reports positions while dispatching its queue, so
+			// the signal is sent in the background rather than from OnQueued.
+			queued := startProgressSignaler(ctx, info, a.temporalClient, SignalLLMQueued, logger)
+			defer queued.stop()
+			request.OnQueued = func(position int) {
+				activity.RecordHeartbeat(ctx)
+				queued.send(LLMQueuedSignal{Position: position})
+			}
+		}
 	}
 
+	// Reuse the session's prompt assembly while its instructions and tools
+	// are unchanged, and send the tools in a stable order.
+	promptHash := a.prompts.Apply(&request)
+
+	start := time.Now()
 	response, err := a.client.Call(ctx, request)
 	if err != nil {
+		if ctx.Err() != nil {
+			return LLMActivityOutput{}, ctx.Err()
+		}
+		logger.Warn("LLM call failed", "duration", time.Since(start), "error", err)
 		var activityErr *models.ActivityError
 		if errors.As(err, &activityErr) {
 			return LLMActivityOutput{}, models.WrapActivityError(activityErr)
 		}
 		return LLMActivityOutput{}, err
 	}
+	logger.Info("LLM call finished", "duration", time.Since(start),
+		"finish_reason", response.FinishReason, "total_tokens", response.TokenUsage.TotalTokens,
+		"cached_tokens", response.TokenUsage.CachedTokens, "prompt_hash", promptHash)
 
 	return LLMActivityOutput{
 		Items:        response.Items,
 		FinishReason: response.FinishReason,
 		TokenUsage:   response.TokenUsage,
 		ResponseID:   response.ResponseID,
+		PromptHash:   promptHash,
 	}, nil
 }
 
@@ -90,6 +210,7 @@ func (a *LLMActivities) ExecuteLLMCall(ctx context.Context, input LLMActivityInp
 // Maps to: codex-rs/core/src/compact.rs compact operation input
 type CompactActivityInput struct {
 	Model        string                      `json:"model"`
+	Provider     string                      `json:"provider,omitempty"` // "" = inferred from Model
 	Input        []models.ConversationItem   `json:"input"`
 	Instructions string                      `json:"instructions,omitempty"`
 }
@@ -110,6 +231,7 @@ type CompactActivityOutput struct {
 func (a *LLMActivities) ExecuteCompact(ctx context.Context, input CompactActivityInput) (CompactActivityOutput, error) {
 	resp, err := a.client.Compact(ctx, llm.CompactRequest{
 		Model:        input.Model,
+		Provider:     input.Provider,
 		Input:        input.Input,
 		Instructions: input.Instructions,
 	})
@@ -137,7 +259,8 @@ type SuggestionInput struct {
 
 // SuggestionOutput is the output from the GenerateSuggestions activity.
 type SuggestionOutput struct {
-	Suggestion string `json:"suggestion"` // Single suggestion or empty string
+	Suggestion string            `json:"suggestion"` // Single suggestion or empty string
+	TokenUsage models.TokenUsage `json:"token_usage"`
 }
 
 // GenerateSuggestions calls a cheap/fast LLM to generate a single prompt
@@ -167,11 +290,129 @@ func (a *LLMActivities) GenerateSuggestions(ctx context.Context, input Suggestio
 	for _, item := range response.Items {
 		if item.Type == models.ItemTypeAssistantMessage && item.Content != "" {
 			suggestion := instructions.ParseSuggestionResponse(item.Content)
-			return SuggestionOutput{Suggestion: suggestion}, nil
+			return SuggestionOutput{Suggestion: suggestion, TokenUsage: response.TokenUsage}, nil
+		}
+	}
+
+	return SuggestionOutput{TokenUsage: response.TokenUsage}, nil
+}
+
+// SessionTitleInput is the input for the GenerateSessionTitle activity.
+type SessionTitleInput struct {
+	UserMessage      string             `json:"user_message"`
+	AssistantMessage string             `json:"assistant_message"`
+	ModelConfig      models.ModelConfig `json:"model_config"`
+}
+
+// SessionTitleOutput is the output from the GenerateSessionTitle activity.
+type SessionTitleOutput struct {
+	Title string `json:"title"` // Empty if the model gave no usable title
+}
+
+// GenerateSessionTitle calls a cheap/fast LLM to title a session after its
+// first turn. Best-effort: any error returns an empty title.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+func (a *LLMActivities) GenerateSessionTitle(ctx context.Context, input SessionTitleInput) (SessionTitleOutput, error) {
+	request := llm.LLMRequest{
+		History: []models.ConversationItem{
+			{
+				Type:    models.ItemTypeUserMessage,
+				Content: instructions.BuildSessionTitleInput(input.UserMessage, input.AssistantMessage),
+			},
+		},
+		ModelConfig:      input.ModelConfig,
+		BaseInstructions: instructions.SessionTitleSystemPrompt,
+	}
+
+	response, err := a.client.Call(ctx, request)
+	if err != nil {
+		return SessionTitleOutput{}, nil
+	}
+	for _, item := range response.Items {
+		if item.Type == models.ItemTypeAssistantMessage && item.Content != "" {
+			return SessionTitleOutput{Title: instructions.ParseSessionTitle(item.Content)}, nil
 		}
 	}
+	return SessionTitleOutput{}, nil
+}
+
+// SummarizeProjectDocsInput is the input for the SummarizeProjectDocs activity.
+type SummarizeProjectDocsInput struct {
+	Docs        []instructions.ProjectDoc `json:"docs"` // Overflow docs, clipped
+	ModelConfig models.ModelConfig        `json:"model_config"`
+}
 
-	return SuggestionOutput{}, nil
+// SummarizeProjectDocsOutput is the output from the SummarizeProjectDocs activity.
+type SummarizeProjectDocsOutput struct {
+	Summaries  []instructions.ProjectDocSummary `json:"summaries"` // One per input doc, in order
+	TokenUsage models.TokenUsage                `json:"token_usage"`
+}
+
+// SummarizeProjectDocs condenses the project docs that did not fit under
+// MaxProjectDocsBytes, one LLM call per file. Best-effort per file: a file
+// that has no content or fails to summarize gets an empty summary.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+func (a *LLMActivities) SummarizeProjectDocs(ctx context.Context, input SummarizeProjectDocsInput) (SummarizeProjectDocsOutput, error) {
+	var out SummarizeProjectDocsOutput
+	for _, doc := range input.Docs {
+		sum := instructions.ProjectDocSummary{Path: doc.Path, Bytes: doc.Bytes}
+		if doc.Content != "" {
+			summary, usage, err := llm.SummarizeProjectDoc(ctx, a.client, input.ModelConfig, doc.Path, doc.Content)
+			if ctx.Err() != nil {
+				return SummarizeProjectDocsOutput{}, ctx.Err()
+			}
+			if err == nil {
+				sum.Summary = summary
+			}
+			out.TokenUsage.PromptTokens += usage.PromptTokens
+			out.TokenUsage.CompletionTokens += usage.CompletionTokens
+			out.TokenUsage.TotalTokens += usage.TotalTokens
+			out.TokenUsage.CachedTokens += usage.CachedTokens
+		}
+		out.Summaries = append(out.Summaries, sum)
+	}
+	return out, nil
+}
+
+// SummarizeContextItemsInput is the input for the SummarizeContextItems activity.
+type SummarizeContextItemsInput struct {
+	Items       []models.ConversationItem `json:"items"`
+	ModelConfig models.ModelConfig        `json:"model_config"`
+}
+
+// SummarizeContextItemsOutput is the output from the SummarizeContextItems activity.
+type SummarizeContextItemsOutput struct {
+	Summary    string            `json:"summary"`
+	TokenUsage models.TokenUsage `json:"token_usage"`
+}
+
+// SummarizeContextItems summarizes history items the user chose to replace
+// with a summary (summarize_context_items Update). The items are kept
+// within the model's context window.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+func (a *LLMActivities) SummarizeContextItems(ctx context.Context, input SummarizeContextItemsInput) (SummarizeContextItemsOutput, error) {
+	serialized, err := memories.SerializeConversationForMemory(input.Items)
+	if err != nil {
+		return SummarizeContextItemsOutput{}, fmt.Errorf("serialize items: %w", err)
+	}
+	limit := int(float64(input.ModelConfig.ContextWindow) * memories.ContextWindowPercentForRollout)
+	if limit <= 0 {
+		limit = memories.DefaultRolloutTokenLimit
+	}
+	serialized = memories.TruncateToTokenLimit(serialized, limit)
+
+	summary, usage, err := llm.SummarizeContextItems(ctx, a.client, input.ModelConfig, serialized)
+	if err != nil {
+		var activityErr *models.ActivityError
+		if errors.As(err, &activityErr) {
+			return SummarizeContextItemsOutput{}, models.WrapActivityError(activityErr)
+		}
+		return SummarizeContextItemsOutput{}, err
+	}
+	return SummarizeContextItemsOutput{Summary: summary, TokenUsage: usage}, nil
 }
 
 // EstimateContextUsage estimates if we're approaching context window limits.
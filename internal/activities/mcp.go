@@ -62,6 +62,7 @@ func (a *McpActivities) InitializeMcpServers(ctx context.Context, input Initiali
 		mcpToolLookup[mcpSpec.QualifiedName] = tools.McpToolRef{
 			ServerName: mcpSpec.ServerName,
 			ToolName:   mcpSpec.ToolName,
+			ReadOnly:   mcpSpec.ReadOnly,
 		}
 	}
 
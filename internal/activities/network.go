@@ -0,0 +1,64 @@
+package activities
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/netconfig"
+)
+
+// NetworkActivities checks that the worker can reach its LLM providers and
+// a session's MCP servers with its proxy and CA bundle settings.
+type NetworkActivities struct {
+	cfg       netconfig.Config
+	providers []string
+}
+
+// NewNetworkActivities creates a NetworkActivities instance. providers are
+// the LLM providers the worker has credentials for.
+func NewNetworkActivities(cfg netconfig.Config, providers []string) *NetworkActivities {
+	return &NetworkActivities{cfg: cfg, providers: providers}
+}
+
+// DiagnoseNetworkInput is the input for the DiagnoseNetwork activity.
+type DiagnoseNetworkInput struct {
+	// McpServers maps the session's HTTP MCP servers to their URLs.
+	McpServers map[string]string `json:"mcp_servers,omitempty"`
+}
+
+// DiagnoseNetworkOutput is the output of the DiagnoseNetwork activity.
+type DiagnoseNetworkOutput struct {
+	Results []netconfig.CheckResult `json:"results"`
+}
+
+// DiagnoseNetwork checks the endpoint of every provider the worker has
+// credentials for and each MCP server in input, reporting the proxy and CA
+// bundle used and, for failures, what to change. A failed check is a result,
+// not an error.
+func (a *NetworkActivities) DiagnoseNetwork(ctx context.Context, input DiagnoseNetworkInput) (DiagnoseNetworkOutput, error) {
+	endpoints := ProviderEndpoints(a.providers)
+	names := make([]string, 0, len(input.McpServers))
+	for name := range input.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		endpoints = append(endpoints, netconfig.Endpoint{Target: netconfig.MCP, Name: name, URL: input.McpServers[name]})
+	}
+	return DiagnoseNetworkOutput{
+		Results: netconfig.CheckAll(ctx, a.cfg, endpoints, netconfig.DefaultCheckTimeout),
+	}, nil
+}
+
+// ProviderEndpoints returns the endpoints of providers, skipping those whose
+// endpoint is not configured (e.g. Bedrock without a region).
+func ProviderEndpoints(providers []string) []netconfig.Endpoint {
+	var endpoints []netconfig.Endpoint
+	for _, provider := range providers {
+		if u := llm.ProviderEndpoint(provider); u != "" {
+			endpoints = append(endpoints, netconfig.Endpoint{Target: provider, URL: u})
+		}
+	}
+	return endpoints
+}
@@ -0,0 +1,74 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// LoadAgentRolesInput is the input for the LoadAgentRoles activity.
+type LoadAgentRolesInput struct {
+	CodexHome string `json:"codex_home,omitempty"`
+}
+
+// LoadAgentRolesOutput is the output from the LoadAgentRoles activity.
+type LoadAgentRolesOutput struct {
+	Roles []models.AgentRoleDef `json:"roles,omitempty"`
+	// Errors describes role files that were skipped, e.g. "reviewer.toml: ...".
+	Errors []string `json:"errors,omitempty"`
+}
+
+// LoadAgentRoles scans {codex_home}/roles/*.toml and returns the valid role
+// definitions sorted by name. Invalid files are skipped and reported in
+// Errors. A missing directory yields an empty list.
+func (a *InstructionActivities) LoadAgentRoles(
+	_ context.Context, input LoadAgentRolesInput,
+) (LoadAgentRolesOutput, error) {
+	codexHome := input.CodexHome
+	if codexHome == "" {
+		codexHome = defaultCodexHome()
+	}
+	roleDir := filepath.Join(codexHome, "roles")
+
+	entries, err := os.ReadDir(roleDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LoadAgentRolesOutput{}, nil
+		}
+		return LoadAgentRolesOutput{}, fmt.Errorf("failed to read roles directory %s: %w", roleDir, err)
+	}
+
+	var out LoadAgentRolesOutput
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".toml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(roleDir, entry.Name()))
+		if err != nil {
+			out.Errors = append(out.Errors, fmt.Sprintf("%s: %v", entry.Name(), err))
+			continue
+		}
+		def, err := models.ParseAgentRoleDef(data, strings.TrimSuffix(entry.Name(), ".toml"))
+		if err != nil {
+			out.Errors = append(out.Errors, fmt.Sprintf("%s: %v", entry.Name(), err))
+			continue
+		}
+		if prev, dup := seen[def.Name]; dup {
+			out.Errors = append(out.Errors, fmt.Sprintf("%s: role %q already defined in %s", entry.Name(), def.Name, prev))
+			continue
+		}
+		seen[def.Name] = entry.Name()
+		out.Roles = append(out.Roles, *def)
+	}
+
+	sort.Slice(out.Roles, func(i, j int) bool {
+		return out.Roles[i].Name < out.Roles[j].Name
+	})
+	return out, nil
+}
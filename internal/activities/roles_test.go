@@ -0,0 +1,73 @@
+package activities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRoles writes role TOML files to a temp codex home and returns its path.
+func setupTestRoles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	roleDir := filepath.Join(dir, "roles")
+	require.NoError(t, os.MkdirAll(roleDir, 0o755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(roleDir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoadAgentRoles_Basic(t *testing.T) {
+	codexHome := setupTestRoles(t, map[string]string{
+		"security-auditor.toml": `
+description = "Audits code"
+base_role = "reviewer"
+`,
+		"docs.toml": `
+name = "docs-writer"
+tools = ["read_file", "write_file"]
+`,
+		"README.md": "ignored",
+	})
+	a := NewInstructionActivities()
+
+	out, err := a.LoadAgentRoles(context.Background(), LoadAgentRolesInput{CodexHome: codexHome})
+	require.NoError(t, err)
+	assert.Empty(t, out.Errors)
+	require.Len(t, out.Roles, 2)
+	assert.Equal(t, "docs-writer", out.Roles[0].Name, "sorted by name")
+	assert.Equal(t, []string{"read_file", "write_file"}, out.Roles[0].Tools)
+	assert.Equal(t, "security-auditor", out.Roles[1].Name)
+	assert.Equal(t, "reviewer", out.Roles[1].BaseRole)
+}
+
+func TestLoadAgentRoles_InvalidAndDuplicateSkipped(t *testing.T) {
+	codexHome := setupTestRoles(t, map[string]string{
+		"a.toml":   `name = "auditor"`,
+		"b.toml":   `name = "auditor"`,
+		"bad.toml": `tools = ["teleport"]`,
+	})
+	a := NewInstructionActivities()
+
+	out, err := a.LoadAgentRoles(context.Background(), LoadAgentRolesInput{CodexHome: codexHome})
+	require.NoError(t, err)
+	require.Len(t, out.Roles, 1)
+	assert.Equal(t, "auditor", out.Roles[0].Name)
+	require.Len(t, out.Errors, 2)
+	assert.Contains(t, out.Errors[0], "b.toml: role \"auditor\" already defined in a.toml")
+	assert.Contains(t, out.Errors[1], "bad.toml")
+}
+
+func TestLoadAgentRoles_MissingDir(t *testing.T) {
+	a := NewInstructionActivities()
+
+	out, err := a.LoadAgentRoles(context.Background(), LoadAgentRolesInput{CodexHome: t.TempDir()})
+	require.NoError(t, err)
+	assert.Empty(t, out.Roles)
+	assert.Empty(t, out.Errors)
+}
@@ -1,14 +1,21 @@
 // Package activities implements Temporal activities.
 //
 // session.go provides the WaitForSessionReady activity used by HarnessWorkflow
-// to block until a SessionWorkflow has started its AgenticWorkflow child.
+// to block until a SessionWorkflow has started its AgenticWorkflow child, and
+// the DescribeSessions activity it uses to find sessions that ended unseen.
+// CheckSearchAttributes lets a session find out whether the search
+// attributes it indexes into are registered before it upserts them.
 package activities
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	enums "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 )
@@ -68,6 +75,73 @@ func (a *SessionActivities) WaitForSessionReady(ctx context.Context, input WaitF
 	}
 }
 
+// DescribeSessionsInput is the input for the DescribeSessions activity.
+type DescribeSessionsInput struct {
+	// SessionWorkflowIDs are the SessionWorkflows to check.
+	SessionWorkflowIDs []string `json:"session_workflow_ids"`
+}
+
+// DescribeSessionsOutput is the output of the DescribeSessions activity.
+type DescribeSessionsOutput struct {
+	// Ended maps each SessionWorkflow that is no longer running to whether
+	// it completed successfully. Running sessions are absent.
+	Ended map[string]bool `json:"ended,omitempty"`
+}
+
+// DescribeSessions reports which of the given SessionWorkflows have ended.
+// A workflow the server no longer knows is reported as ended unsuccessfully.
+func (a *SessionActivities) DescribeSessions(ctx context.Context, input DescribeSessionsInput) (DescribeSessionsOutput, error) {
+	out := DescribeSessionsOutput{Ended: map[string]bool{}}
+	for _, id := range input.SessionWorkflowIDs {
+		resp, err := a.client.DescribeWorkflowExecution(ctx, id, "")
+		if err != nil {
+			var notFound *serviceerror.NotFound
+			if errors.As(err, &notFound) {
+				out.Ended[id] = false
+				continue
+			}
+			return DescribeSessionsOutput{}, fmt.Errorf("failed to describe session workflow %s: %w", id, err)
+		}
+		status := resp.GetWorkflowExecutionInfo().GetStatus()
+		if status != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+			out.Ended[id] = status == enums.WORKFLOW_EXECUTION_STATUS_COMPLETED
+		}
+	}
+	return out, nil
+}
+
+// CheckSearchAttributesInput is the input for the CheckSearchAttributes activity.
+type CheckSearchAttributesInput struct {
+	// Names are the search attributes to look for.
+	Names []string `json:"names"`
+}
+
+// CheckSearchAttributesOutput is the output of the CheckSearchAttributes activity.
+type CheckSearchAttributesOutput struct {
+	// Missing lists the names not registered in the workflow's namespace.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// CheckSearchAttributes reports which of the given search attributes are not
+// registered in the calling workflow's namespace. Upserting an unregistered
+// search attribute fails the workflow task on every retry, so sessions check
+// before indexing.
+func (a *SessionActivities) CheckSearchAttributes(ctx context.Context, input CheckSearchAttributesInput) (CheckSearchAttributesOutput, error) {
+	resp, err := a.client.OperatorService().ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{
+		Namespace: activity.GetInfo(ctx).WorkflowNamespace,
+	})
+	if err != nil {
+		return CheckSearchAttributesOutput{}, fmt.Errorf("failed to list search attributes: %w", err)
+	}
+	var out CheckSearchAttributesOutput
+	for _, name := range input.Names {
+		if _, ok := resp.GetCustomAttributes()[name]; !ok {
+			out.Missing = append(out.Missing, name)
+		}
+	}
+	return out, nil
+}
+
 // StartSessionWorkflowInput is the input for the StartSessionWorkflow activity.
 type StartSessionWorkflowInput struct {
 	SessionWorkflowID string `json:"session_workflow_id"`
@@ -0,0 +1,90 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+)
+
+// progressSignalTimeout bounds each progress signal an activity sends its
+// workflow.
+const progressSignalTimeout = 5 * time.Second
+
+// progressSignaler sends an activity's progress to its workflow as a
+// signal from a background goroutine, so the code reporting progress (a
+// rate limiter dispatching its queue, a tool reading output) never waits on
+// Temporal. Only the latest value matters: one not sent yet is replaced by
+// the next. Sending is best-effort; failures are logged.
+type progressSignaler struct {
+	client     client.Client
+	workflowID string
+	runID      string
+	name       string
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	pending interface{}
+	ready   chan struct{}
+	done    chan struct{}
+}
+
+// startProgressSignaler starts a signaler for the activity described by
+// info, running in ctx. Call stop when the activity returns.
+func startProgressSignaler(ctx context.Context, info activity.Info, c client.Client, name string, logger *slog.Logger) *progressSignaler {
+	s := &progressSignaler{
+		client:     c,
+		workflowID: info.WorkflowExecution.ID,
+		runID:      info.WorkflowExecution.RunID,
+		name:       name,
+		logger:     logger,
+		ready:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s
+}
+
+// send queues v to be signaled, replacing a value not sent yet.
+func (s *progressSignaler) send(v interface{}) {
+	s.mu.Lock()
+	s.pending = v
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the signaler. A value not sent yet is dropped.
+func (s *progressSignaler) stop() {
+	close(s.done)
+}
+
+func (s *progressSignaler) run(ctx context.Context) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ready:
+		}
+		s.mu.Lock()
+		v := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+		if v == nil {
+			continue
+		}
+		// The activity's context ends with the activity, so a signal still
+		// in flight then does not reach the workflow's next call.
+		sigCtx, cancel := context.WithTimeout(ctx, progressSignalTimeout)
+		err := s.client.SignalWorkflow(sigCtx, s.workflowID, s.runID, s.name, v)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn("Failed to signal progress", "signal", s.name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package activities
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/workflow"
+)
+
+// blockingSignalClient records signals; each waits until release is closed.
+type blockingSignalClient struct {
+	client.Client
+	release chan struct{}
+
+	mu   sync.Mutex
+	sent []interface{}
+}
+
+func (c *blockingSignalClient) SignalWorkflow(ctx context.Context, _, _, _ string, arg interface{}) error {
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, arg)
+	return nil
+}
+
+func (c *blockingSignalClient) signals() []interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]interface{}(nil), c.sent...)
+}
+
+func TestProgressSignaler_DoesNotBlockAndSendsLatest(t *testing.T) {
+	c := &blockingSignalClient{release: make(chan struct{})}
+	info := activity.Info{WorkflowExecution: workflow.Execution{ID: "wf-1", RunID: "run-1"}}
+	s := startProgressSignaler(context.Background(), info, c, SignalLLMQueued, slog.Default())
+	defer s.stop()
+
+	sent := make(chan struct{})
+	go func() {
+		for i := 3; i >= 0; i-- {
+			s.send(LLMQueuedSignal{Position: i})
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("send blocked on the workflow signal")
+	}
+
+	close(c.release)
+	require.Eventually(t, func() bool {
+		got := c.signals()
+		return len(got) > 0 && got[len(got)-1] == LLMQueuedSignal{Position: 0}
+	}, time.Second, 5*time.Millisecond)
+	assert.LessOrEqual(t, len(c.signals()), 2, "positions not yet sent are replaced by newer ones")
+}
@@ -3,12 +3,21 @@ package activities
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"strings"
+	"sync"
+	"time"
 
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
 
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/logging"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/redaction"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
 )
 
 // ToolActivityInput is the input for tool execution.
@@ -16,12 +25,25 @@ import (
 // Maps to: codex-rs/core/src/tools/context.rs ToolInvocation fields
 type ToolActivityInput struct {
 	CallID        string                 `json:"call_id"`
+	TurnID        string                 `json:"turn_id,omitempty"` // For log correlation
 	ToolName      string                 `json:"tool_name"`
 	Arguments     map[string]interface{} `json:"arguments"`
 	Cwd           string                 `json:"cwd,omitempty"`            // Working directory for tool execution
 	SandboxPolicy *tools.SandboxPolicyRef `json:"sandbox_policy,omitempty"` // Sandbox restrictions
 	EnvPolicy     *tools.EnvPolicyRef     `json:"env_policy,omitempty"`     // Environment variable filtering
 
+	// PreviousOutput is the full output of the last identical shell or exec
+	// command in the turn, for diffing (tools.ToolInvocation.PreviousOutput).
+	PreviousOutput string `json:"previous_output,omitempty"`
+
+	// ReadFileLimit is the session's read_file line cap
+	// (tools.ToolInvocation.ReadFileLimit).
+	ReadFileLimit int `json:"read_file_limit,omitempty"`
+
+	// BrowserAllowedDomains limits the hosts browser_* tools may load
+	// (tools.ToolInvocation.BrowserAllowedDomains).
+	BrowserAllowedDomains []string `json:"browser_allowed_domains,omitempty"`
+
 	// MCP fields — populated for mcp__* tool calls.
 	McpToolRef *tools.McpToolRef `json:"mcp_tool_ref,omitempty"` // Server/tool routing
 	SessionID  string            `json:"session_id,omitempty"`   // Session ID for MCP store lookup
@@ -36,11 +58,39 @@ type ToolActivityOutput struct {
 	CallID  string `json:"call_id"`
 	Content string `json:"content,omitempty"`
 	Success *bool  `json:"success,omitempty"`
+
+	// Redactions is the number of secrets scrubbed from Content.
+	Redactions int `json:"redactions,omitempty"`
+
+	// PatchConflict describes the hunk an apply_patch call could not apply.
+	PatchConflict *patch.Conflict `json:"patch_conflict,omitempty"`
+
+	// Attachments are files the tool produced, with inline data capped at
+	// attachment.MaxInlineBytes.
+	Attachments []attachment.Attachment `json:"attachments,omitempty"`
+
+	// Unavailable is set when the worker has no handler for the tool.
+	Unavailable *ToolUnavailable `json:"unavailable,omitempty"`
+
+	// FullContent, set when Content is a diff against PreviousOutput, is
+	// the command's whole output.
+	FullContent string `json:"full_content,omitempty"`
+
+	// TimedOut is set when a shell or exec command was stopped at its
+	// timeout_seconds (or the worker's limit).
+	TimedOut *tools.CommandTimeout `json:"timed_out,omitempty"`
 }
 
+// SignalToolProgress is sent by ExecuteTool to its workflow with the latest
+// tools.ToolProgress of a long-running tool call.
+const SignalToolProgress = "tool_progress"
+
 // ToolActivities contains tool-related activities.
 type ToolActivities struct {
-	registry *tools.ToolRegistry
+	registry       *tools.ToolRegistry
+	temporalClient client.Client
+	redactor       *redaction.Redactor
+	maxCommandTime time.Duration // Limit on timeout_seconds; 0 = tools.MaxCommandTimeoutSec
 }
 
 // NewToolActivities creates a new ToolActivities instance.
@@ -48,10 +98,41 @@ func NewToolActivities(registry *tools.ToolRegistry) *ToolActivities {
 	return &ToolActivities{registry: registry}
 }
 
+// WithProgressSignals enables SignalToolProgress notifications, sent through
+// c whenever a running tool reports progress. Returns the receiver for chaining.
+func (a *ToolActivities) WithProgressSignals(c client.Client) *ToolActivities {
+	a.temporalClient = c
+	return a
+}
+
+// redactionExemptTools return file content verbatim: the model edits files
+// from what read_file shows, and a placeholder in its view would end up in
+// the patches it writes.
+var redactionExemptTools = map[string]bool{
+	"read_file": true,
+}
+
+// WithRedactor scrubs secrets matched by r from tool output and progress
+// before they leave the worker, except for redactionExemptTools. Returns
+// the receiver for chaining.
+func (a *ToolActivities) WithRedactor(r *redaction.Redactor) *ToolActivities {
+	a.redactor = r
+	return a
+}
+
+// WithMaxCommandTimeout limits how long a shell or exec command may run
+// when its call asks for a timeout_seconds; longer requests are cut to d.
+// Returns the receiver for chaining.
+func (a *ToolActivities) WithMaxCommandTimeout(d time.Duration) *ToolActivities {
+	a.maxCommandTime = d
+	return a
+}
+
 // ExecuteTool executes a single tool call.
 //
 // Error handling:
-//   - Tool not found → non-retryable ApplicationError (ToolNotFound)
+//   - Tool not found → successful return with Success=false and Unavailable
+//     listing the worker's tools, so the model can pick another
 //   - Handler validation error → non-retryable ApplicationError (ToolValidation)
 //   - Handler context cancelled/timeout → returned as-is; Temporal retries per RetryPolicy
 //   - Tool runs but fails (e.g., command exits non-zero) → successful return with Success=false
@@ -65,30 +146,50 @@ func (a *ToolActivities) ExecuteTool(ctx context.Context, input ToolActivityInpu
 		handlerName = "mcp"
 	}
 
+	logger := logging.Activity(ctx, input.TurnID, input.CallID).With("tool", input.ToolName)
+
 	handler, err := a.registry.GetHandler(handlerName)
 	if err != nil {
-		return ToolActivityOutput{}, models.NewToolNotFoundError(input.ToolName)
+		logger.Warn("Tool not available on this worker")
+		return a.unavailableToolOutput(input), nil
 	}
 
+	progress, stopProgress := a.newToolProgress(ctx, logger)
+	defer stopProgress()
+
 	invocation := &tools.ToolInvocation{
-		CallID:        input.CallID,
-		ToolName:      input.ToolName,
-		Arguments:     input.Arguments,
-		Cwd:           input.Cwd,
-		SandboxPolicy: input.SandboxPolicy,
-		EnvPolicy:     input.EnvPolicy,
-		McpToolRef:    input.McpToolRef,
-		SessionID:     input.SessionID,
+		CallID:         input.CallID,
+		ToolName:       input.ToolName,
+		Arguments:      input.Arguments,
+		Cwd:            input.Cwd,
+		SandboxPolicy:  input.SandboxPolicy,
+		EnvPolicy:      input.EnvPolicy,
+		PreviousOutput: input.PreviousOutput,
+		McpToolRef:     input.McpToolRef,
+		SessionID:      input.SessionID,
+		Logger:         logger,
+
+		ReadFileLimit:         input.ReadFileLimit,
+		BrowserAllowedDomains: input.BrowserAllowedDomains,
+		// Only the shell and exec handlers read it.
+		CommandTimeout: tools.ClampCommandTimeout(tools.RequestedCommandTimeout(input.Arguments), a.maxCommandTime),
 		Heartbeat: func(details ...interface{}) {
 			activity.RecordHeartbeat(ctx, details...)
 		},
+		OnProgress: func(p tools.ToolProgress) {
+			p.CallID = input.CallID
+			progress.report(p)
+		},
 	}
 
 	// Pass the activity context to the handler. Temporal manages timeouts
 	// via StartToCloseTimeout — when it fires, ctx is cancelled, the handler
 	// returns ctx.Err(), and Temporal retries per the RetryPolicy.
+	logger.Info("Tool call started")
+	start := time.Now()
 	output, err := handler.Handle(ctx, invocation)
 	if err != nil {
+		logger.Warn("Tool call failed", "duration", time.Since(start), "error", err)
 		// Context errors (deadline/cancellation) are returned as-is so
 		// Temporal recognizes them and applies the retry policy.
 		if ctx.Err() != nil {
@@ -108,9 +209,121 @@ func (a *ToolActivities) ExecuteTool(ctx context.Context, input ToolActivityInpu
 		return ToolActivityOutput{}, models.NewToolValidationError(input.ToolName, err)
 	}
 
+	redactor := a.redactor
+	if redactionExemptTools[input.ToolName] {
+		redactor = nil
+	}
+	content, redactions := redactor.Redact(output.Content)
+	fullContent, _ := redactor.Redact(output.FullContent)
+	if redactions > 0 {
+		logger.Info("Redacted secrets from tool output", "count", redactions)
+	}
+	logger.Info("Tool call finished", "duration", time.Since(start),
+		"success", output.Success == nil || *output.Success, "output_bytes", len(content))
+	if output.TimedOut != nil {
+		logger.Info("Command stopped at its timeout", "limit_sec", output.TimedOut.LimitSec,
+			"requested_sec", output.TimedOut.RequestedSec)
+	}
+
 	return ToolActivityOutput{
-		CallID:  input.CallID,
-		Content: output.Content,
-		Success: output.Success,
+		CallID:     input.CallID,
+		Content:    content,
+		Success:    output.Success,
+		Redactions:    redactions,
+		PatchConflict: a.redactConflict(output.PatchConflict),
+		Attachments:   attachment.LimitInline(output.Attachments),
+		FullContent:   fullContent,
+		TimedOut:      output.TimedOut,
 	}, nil
 }
+
+// redactConflict scrubs secrets from the file lines quoted in c.
+func (a *ToolActivities) redactConflict(c *patch.Conflict) *patch.Conflict {
+	if c == nil {
+		return nil
+	}
+	redacted := *c
+	redacted.Expected = make([]string, len(c.Expected))
+	for i, l := range c.Expected {
+		redacted.Expected[i], _ = a.redactor.Redact(l)
+	}
+	redacted.Found = make([]string, len(c.Found))
+	for i, l := range c.Found {
+		redacted.Found[i], _ = a.redactor.Redact(l)
+	}
+	return &redacted
+}
+
+// ToolUnavailable describes a call to a tool this worker does not have,
+// e.g. one enabled in a newer build than the worker runs.
+type ToolUnavailable struct {
+	Available []string `json:"available"` // Tools registered on the worker, sorted
+}
+
+// unavailableToolOutput is the failed result of a call to a tool the worker
+// has no handler for. It lists the worker's tools so the model can recover.
+func (a *ToolActivities) unavailableToolOutput(input ToolActivityInput) ToolActivityOutput {
+	var available []string
+	for _, name := range a.registry.ToolNames() {
+		if name != "mcp" { // Router for mcp__* tools, not callable by name
+			available = append(available, name)
+		}
+	}
+	success := false
+	return ToolActivityOutput{
+		CallID: input.CallID,
+		Content: fmt.Sprintf("Tool %q is not available on this worker. Available tools: %s. Use one of them instead.",
+			input.ToolName, strings.Join(available, ", ")),
+		Success:     &success,
+		Unavailable: &ToolUnavailable{Available: available},
+	}
+}
+
+// toolProgress reports a tool call's progress: every snapshot is recorded
+// as heartbeat details (clients read them from the pending activity, and
+// they keep the activity alive), and snapshots that differ from the last one
+// are forwarded to the workflow for TurnStatus.
+type toolProgress struct {
+	redactor  *redaction.Redactor
+	heartbeat func(tools.ToolProgress)
+	signal    func(tools.ToolProgress) // nil when there is no workflow to tell
+
+	mu       sync.Mutex
+	last     tools.ToolProgress
+	reported bool
+}
+
+// newToolProgress creates the progress reporter of the activity running in
+// ctx. Call stop when the activity returns.
+func (a *ToolActivities) newToolProgress(ctx context.Context, logger *slog.Logger) (progress *toolProgress, stop func()) {
+	progress = &toolProgress{redactor: a.redactor}
+	if !activity.IsActivity(ctx) {
+		return progress, func() {}
+	}
+	progress.heartbeat = func(p tools.ToolProgress) { activity.RecordHeartbeat(ctx, p) }
+	if a.temporalClient == nil {
+		return progress, func() {}
+	}
+	signaler := startProgressSignaler(ctx, activity.GetInfo(ctx), a.temporalClient, SignalToolProgress, logger)
+	progress.signal = func(p tools.ToolProgress) { signaler.send(p) }
+	return progress, signaler.stop
+}
+
+func (r *toolProgress) report(p tools.ToolProgress) {
+	if r.heartbeat == nil {
+		return
+	}
+	p.Command, _ = r.redactor.Redact(p.Command)
+	p.LastLine, _ = r.redactor.Redact(p.LastLine)
+	r.heartbeat(p)
+	if r.signal == nil {
+		return
+	}
+	r.mu.Lock()
+	changed := !r.reported || p != r.last
+	r.last, r.reported = p, true
+	r.mu.Unlock()
+	if changed {
+		r.signal(p)
+	}
+}
@@ -0,0 +1,219 @@
+// Package activities implements Temporal activities.
+//
+// usage.go provides the activities behind UsageReportWorkflow:
+// CollectSessionUsage reads the results of sessions completed in a period,
+// and PublishUsageReport stores the finished report in the archive and/or
+// POSTs it to a webhook.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package activities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/archive"
+	"github.com/mfateev/temporal-agent-harness/internal/usage"
+)
+
+// maxUsageSessions caps the sessions one report collects, keeping the
+// activity result well under Temporal's payload limit.
+const maxUsageSessions = 5000
+
+// usageWebhookTimeout bounds a single report POST.
+const usageWebhookTimeout = 30 * time.Second
+
+// UsageActivities contains the usage reporting activities.
+type UsageActivities struct {
+	client client.Client
+	http   *http.Client
+}
+
+// NewUsageActivities creates a new UsageActivities with the given Temporal client.
+func NewUsageActivities(c client.Client) *UsageActivities {
+	return &UsageActivities{client: c, http: &http.Client{Timeout: usageWebhookTimeout}}
+}
+
+// CollectSessionUsageInput is the input for the CollectSessionUsage activity.
+type CollectSessionUsageInput struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+// CollectSessionUsageOutput is the output of the CollectSessionUsage activity.
+type CollectSessionUsageOutput struct {
+	Sessions  []usage.Session `json:"sessions"`
+	Truncated bool            `json:"truncated,omitempty"` // More than maxUsageSessions ended in the period
+	Skipped   int             `json:"skipped,omitempty"`   // Sessions whose result could not be read
+}
+
+// sessionResult is the part of a session's WorkflowResult a usage report
+// needs (the workflow package cannot be imported here).
+type sessionResult struct {
+	TotalTokens       int                `json:"total_tokens"`
+	TotalCachedTokens int                `json:"total_cached_tokens"`
+	TotalInputTokens  int                `json:"total_input_tokens"`
+	ToolCallsExecuted []string           `json:"tool_calls_executed"`
+	Model             string             `json:"model"`
+	Models            []string           `json:"models"`
+	Tags              []string           `json:"tags"`
+	UsageByModel      []usage.ModelUsage `json:"usage_by_model"`
+	SuggestionUsage   *suggestionResult  `json:"suggestion_usage"`
+}
+
+// suggestionResult is the part of a session's SuggestionUsage a usage
+// report needs.
+type suggestionResult struct {
+	Model       string `json:"model"`
+	TotalTokens int    `json:"total_tokens"`
+	InputTokens int    `json:"input_tokens"`
+}
+
+// CollectSessionUsage lists the AgenticWorkflow sessions that completed in
+// [Since, Until) and reads their results. Only completed runs carry a
+// result: runs that continued as new are covered by their final run, and
+// failed or terminated sessions are not counted.
+func (a *UsageActivities) CollectSessionUsage(ctx context.Context, input CollectSessionUsageInput) (CollectSessionUsageOutput, error) {
+	query := fmt.Sprintf("WorkflowType = 'AgenticWorkflow' AND ExecutionStatus = 'Completed' AND CloseTime >= '%s' AND CloseTime < '%s'",
+		input.Since.UTC().Format(time.RFC3339), input.Until.UTC().Format(time.RFC3339))
+
+	var out CollectSessionUsageOutput
+	var pageToken []byte
+	for {
+		resp, err := a.client.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Query:         query,
+			NextPageToken: pageToken,
+		})
+		if err != nil {
+			return CollectSessionUsageOutput{}, fmt.Errorf("list sessions: %w", err)
+		}
+		for _, exec := range resp.GetExecutions() {
+			if len(out.Sessions) == maxUsageSessions {
+				out.Truncated = true
+				return out, nil
+			}
+			id, runID := exec.GetExecution().GetWorkflowId(), exec.GetExecution().GetRunId()
+			var res sessionResult
+			if err := a.client.GetWorkflow(ctx, id, runID).Get(ctx, &res); err != nil {
+				activity.GetLogger(ctx).Warn("Skipping session without a readable result", "workflow_id", id, "error", err)
+				out.Skipped++
+				continue
+			}
+			out.Sessions = append(out.Sessions, sessionUsage(id, exec.GetCloseTime().AsTime(), res))
+			activity.RecordHeartbeat(ctx, len(out.Sessions))
+		}
+		pageToken = resp.GetNextPageToken()
+		if len(pageToken) == 0 {
+			return out, nil
+		}
+	}
+}
+
+func sessionUsage(workflowID string, closedAt time.Time, res sessionResult) usage.Session {
+	s := usage.Session{
+		WorkflowID:   workflowID,
+		ClosedAt:     closedAt,
+		Model:        res.Model,
+		Models:       res.Models,
+		Tags:         res.Tags,
+		TotalTokens:  res.TotalTokens,
+		InputTokens:  res.TotalInputTokens,
+		CachedTokens: res.TotalCachedTokens,
+		ByModel:      res.UsageByModel,
+	}
+	if u := res.SuggestionUsage; u != nil {
+		s.SuggestionModel, s.SuggestionTokens, s.SuggestionInputTokens = u.Model, u.TotalTokens, u.InputTokens
+	}
+	if len(res.ToolCallsExecuted) > 0 {
+		s.ToolCalls = map[string]int{}
+		for _, name := range res.ToolCallsExecuted {
+			s.ToolCalls[name]++
+		}
+	}
+	return s
+}
+
+// PublishUsageReportInput is the input for the PublishUsageReport activity.
+type PublishUsageReportInput struct {
+	ArchiveURL     string            `json:"archive_url,omitempty"`
+	WebhookURL     string            `json:"webhook_url,omitempty"`
+	WebhookHeaders map[string]string `json:"webhook_headers,omitempty"`
+	Report         usage.Report      `json:"report"`
+}
+
+// PublishUsageReportOutput is the output of the PublishUsageReport activity.
+type PublishUsageReportOutput struct {
+	Key string `json:"key,omitempty"` // Object name of the archived report
+}
+
+// UsageReportName is the archive object name of a report covering
+// [since, until): usage/2026-03-01.json for one whole day, otherwise
+// usage/<since>_<until>.json with times to the minute.
+func UsageReportName(since, until time.Time) string {
+	since, until = since.UTC(), until.UTC()
+	day := 24 * time.Hour
+	if since.Truncate(day).Equal(since) && until.Equal(since.Add(day)) {
+		return "usage/" + since.Format(time.DateOnly) + ".json"
+	}
+	const layout = "2006-01-02T1504Z"
+	return "usage/" + since.Format(layout) + "_" + until.Format(layout) + ".json"
+}
+
+// PublishUsageReport writes the report to the archive and POSTs it to the
+// webhook, whichever are set. Rewriting the archived report is idempotent,
+// so the activity is safe to retry. A webhook answering 4xx fails without
+// retrying.
+func (a *UsageActivities) PublishUsageReport(ctx context.Context, input PublishUsageReportInput) (PublishUsageReportOutput, error) {
+	body, err := json.MarshalIndent(input.Report, "", "  ")
+	if err != nil {
+		return PublishUsageReportOutput{}, temporal.NewNonRetryableApplicationError("encode usage report", "UsageReportError", err)
+	}
+
+	var out PublishUsageReportOutput
+	if input.ArchiveURL != "" {
+		arc, err := archive.Open(input.ArchiveURL)
+		if err != nil {
+			return PublishUsageReportOutput{}, temporal.NewNonRetryableApplicationError(err.Error(), "UsageReportError", nil)
+		}
+		if out.Key, err = arc.Put(ctx, UsageReportName(input.Report.Since, input.Report.Until), body, "application/json"); err != nil {
+			return PublishUsageReportOutput{}, fmt.Errorf("archive usage report: %w", err)
+		}
+	}
+
+	if input.WebhookURL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, input.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return PublishUsageReportOutput{}, temporal.NewNonRetryableApplicationError("invalid usage webhook URL", "UsageReportError", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range input.WebhookHeaders {
+			req.Header.Set(k, v)
+		}
+		resp, err := a.http.Do(req)
+		if err != nil {
+			return PublishUsageReportOutput{}, fmt.Errorf("usage webhook: %w", err)
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+			return PublishUsageReportOutput{}, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("usage webhook rejected the report: HTTP %s", resp.Status), "UsageReportError", nil)
+		default:
+			return PublishUsageReportOutput{}, fmt.Errorf("usage webhook: HTTP %s", resp.Status)
+		}
+	}
+	return out, nil
+}
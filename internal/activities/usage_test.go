@@ -0,0 +1,101 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/usage"
+)
+
+func TestUsageReportName(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "usage/2026-03-01.json", UsageReportName(day, day.Add(24*time.Hour)))
+	assert.Equal(t, "usage/2026-02-23T0000Z_2026-03-02T0000Z.json", UsageReportName(day.AddDate(0, 0, -6), day.AddDate(0, 0, 1)))
+	assert.Equal(t, "usage/2026-03-01T0930Z_2026-03-02T0930Z.json",
+		UsageReportName(day.Add(9*time.Hour+30*time.Minute), day.Add(33*time.Hour+30*time.Minute)))
+}
+
+func TestSessionUsage(t *testing.T) {
+	s := sessionUsage("wf-1", time.Unix(0, 0), sessionResult{
+		TotalTokens:       100,
+		TotalInputTokens:  80,
+		TotalCachedTokens: 40,
+		ToolCallsExecuted: []string{"shell", "read_file", "shell"},
+		Model:             "m",
+		Tags:              []string{"team:x"},
+		UsageByModel:      []usage.ModelUsage{{Model: "m", TotalTokens: 100, InputTokens: 80, CachedTokens: 40}},
+	})
+	assert.Equal(t, "wf-1", s.WorkflowID)
+	assert.Equal(t, 80, s.InputTokens)
+	assert.Equal(t, 40, s.CachedTokens)
+	assert.Equal(t, map[string]int{"shell": 2, "read_file": 1}, s.ToolCalls)
+	assert.Equal(t, []usage.ModelUsage{{Model: "m", TotalTokens: 100, InputTokens: 80, CachedTokens: 40}}, s.ByModel)
+	assert.Zero(t, s.SuggestionTokens)
+
+	var res sessionResult
+	require.NoError(t, json.Unmarshal([]byte(`{"total_tokens": 10,
+		"suggestion_usage": {"model": "gpt-4o-mini", "calls": 2, "total_tokens": 60, "input_tokens": 50}}`), &res))
+	s = sessionUsage("wf-2", time.Unix(0, 0), res)
+	assert.Equal(t, "gpt-4o-mini", s.SuggestionModel)
+	assert.Equal(t, 60, s.SuggestionTokens)
+	assert.Equal(t, 50, s.SuggestionInputTokens)
+}
+
+func TestPublishUsageReport(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	report := usage.Report{Since: day, Until: day.Add(24 * time.Hour), Totals: usage.Totals{Sessions: 3}}
+
+	var got usage.Report
+	var auth string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	a := NewUsageActivities(nil)
+	input := PublishUsageReportInput{
+		ArchiveURL:     "file://" + dir + "/reports",
+		WebhookURL:     srv.URL,
+		WebhookHeaders: map[string]string{"Authorization": "Bearer secret"},
+		Report:         report,
+	}
+	out, err := a.PublishUsageReport(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Sessions)
+	assert.Equal(t, "Bearer secret", auth)
+
+	data, err := os.ReadFile(filepath.Join(dir, "reports", "usage", "2026-03-01.json"))
+	require.NoError(t, err)
+	assert.Contains(t, out.Key, "usage/2026-03-01.json")
+	var archived usage.Report
+	require.NoError(t, json.Unmarshal(data, &archived))
+	assert.Equal(t, 3, archived.Sessions)
+
+	// A client error is not retried; a server error is.
+	var appErr *temporal.ApplicationError
+	input.ArchiveURL = ""
+	status = http.StatusForbidden
+	_, err = a.PublishUsageReport(context.Background(), input)
+	require.True(t, errors.As(err, &appErr))
+	assert.True(t, appErr.NonRetryable())
+
+	status = http.StatusBadGateway
+	_, err = a.PublishUsageReport(context.Background(), input)
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &appErr))
+}
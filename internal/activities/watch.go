@@ -0,0 +1,115 @@
+// Package activities implements Temporal activities.
+//
+// watch.go provides the CollectWorkspaceChanges activity, which reports the
+// files changed in a session's working tree by someone other than the
+// agent. Watchers live in the worker process, so the activity must run on
+// the session's task queue.
+package activities
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workspace"
+)
+
+// watchIdleTimeout closes watchers of sessions that have not collected
+// changes for this long.
+const watchIdleTimeout = 24 * time.Hour
+
+// watchEntry is a session's watcher and when it was last collected.
+type watchEntry struct {
+	w        *workspace.Watcher
+	lastUsed time.Time
+}
+
+// WatchActivities contains workspace watcher activities. Watchers are
+// keyed by session and started on first collection.
+type WatchActivities struct {
+	mu       sync.Mutex
+	watchers map[string]*watchEntry
+	now      func() time.Time
+}
+
+// NewWatchActivities creates a new WatchActivities instance.
+func NewWatchActivities() *WatchActivities {
+	return &WatchActivities{watchers: make(map[string]*watchEntry), now: time.Now}
+}
+
+// CollectWorkspaceChangesInput is the input for the CollectWorkspaceChanges activity.
+type CollectWorkspaceChangesInput struct {
+	SessionID string `json:"session_id"`
+	Cwd       string `json:"cwd"`
+	Discard   bool   `json:"discard,omitempty"` // Drop pending changes instead of returning them
+}
+
+// CollectWorkspaceChangesOutput is the output of the CollectWorkspaceChanges activity.
+type CollectWorkspaceChangesOutput struct {
+	Changes []workspace.Change `json:"changes,omitempty"`
+	Dropped int                `json:"dropped,omitempty"` // Further changes beyond the watcher's limit
+	Started bool               `json:"started,omitempty"` // The watch began with this call
+}
+
+// CollectWorkspaceChanges returns the files changed under input.Cwd since
+// the session's previous call. The first call (and the first after a
+// worker restart) starts the watch and reports nothing.
+func (a *WatchActivities) CollectWorkspaceChanges(ctx context.Context, input CollectWorkspaceChangesInput) (CollectWorkspaceChangesOutput, error) {
+	root := input.Cwd
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return CollectWorkspaceChangesOutput{}, err
+		}
+		root = cwd
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	a.closeIdle(now)
+
+	entry := a.watchers[input.SessionID]
+	if entry != nil && entry.w.Root() != root {
+		_ = entry.w.Close()
+		delete(a.watchers, input.SessionID)
+		entry = nil
+	}
+	if entry == nil {
+		w, err := workspace.Watch(root)
+		if err != nil {
+			return CollectWorkspaceChangesOutput{}, err
+		}
+		a.watchers[input.SessionID] = &watchEntry{w: w, lastUsed: now}
+		return CollectWorkspaceChangesOutput{Started: true}, nil
+	}
+
+	entry.lastUsed = now
+	changes, dropped := entry.w.Drain()
+	if input.Discard {
+		return CollectWorkspaceChangesOutput{}, nil
+	}
+	return CollectWorkspaceChangesOutput{Changes: changes, Dropped: dropped}, nil
+}
+
+// Close stops all watchers.
+func (a *WatchActivities) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, e := range a.watchers {
+		_ = e.w.Close()
+		delete(a.watchers, id)
+	}
+}
+
+// closeIdle stops watchers unused since watchIdleTimeout before now.
+// Callers hold a.mu.
+func (a *WatchActivities) closeIdle(now time.Time) {
+	for id, e := range a.watchers {
+		if now.Sub(e.lastUsed) >= watchIdleTimeout {
+			_ = e.w.Close()
+			delete(a.watchers, id)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package activities implements Temporal activities.
+//
+// workspace.go provides the SnapshotWorkspace and RestoreWorkspace activities
+// used for per-turn snapshots and rollback of the session's working tree.
+// They must run on the session's task queue so they see the same filesystem
+// as the tools.
+package activities
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workspace"
+)
+
+// WorkspaceActivities contains workspace snapshot activities.
+type WorkspaceActivities struct{}
+
+// NewWorkspaceActivities creates a new WorkspaceActivities instance.
+func NewWorkspaceActivities() *WorkspaceActivities {
+	return &WorkspaceActivities{}
+}
+
+// SnapshotWorkspaceInput is the input for the SnapshotWorkspace activity.
+type SnapshotWorkspaceInput struct {
+	Cwd        string   `json:"cwd"`
+	SnapshotID string   `json:"snapshot_id"`     // Ref name under refs/agent-snapshots/
+	Message    string   `json:"message"`         // Snapshot commit message
+	Prune      []string `json:"prune,omitempty"` // Older snapshot IDs to unpin
+}
+
+// SnapshotWorkspaceOutput is the output of the SnapshotWorkspace activity.
+type SnapshotWorkspaceOutput struct {
+	Commit  string `json:"commit,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"` // Cwd is not a git repository
+}
+
+// RestoreWorkspaceInput is the input for the RestoreWorkspace activity.
+type RestoreWorkspaceInput struct {
+	Cwd    string `json:"cwd"`
+	Commit string `json:"commit"`
+}
+
+// RestoreWorkspaceOutput is the output of the RestoreWorkspace activity.
+type RestoreWorkspaceOutput struct {
+	Restored []string `json:"restored,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// SnapshotWorkspace records a snapshot of the working tree at input.Cwd.
+// Returns Skipped=true (not an error) when Cwd is not inside a git repo.
+func (a *WorkspaceActivities) SnapshotWorkspace(ctx context.Context, input SnapshotWorkspaceInput) (SnapshotWorkspaceOutput, error) {
+	commit, err := workspace.Take(ctx, input.Cwd, input.SnapshotID, input.Message)
+	if errors.Is(err, workspace.ErrNotGitRepo) {
+		return SnapshotWorkspaceOutput{Skipped: true}, nil
+	}
+	if err != nil {
+		return SnapshotWorkspaceOutput{}, err
+	}
+	for _, id := range input.Prune {
+		// Best-effort: a stale pin only delays garbage collection.
+		_ = workspace.Delete(ctx, input.Cwd, id)
+	}
+	return SnapshotWorkspaceOutput{Commit: commit}, nil
+}
+
+// RestoreWorkspace restores the working tree at input.Cwd to a snapshot.
+func (a *WorkspaceActivities) RestoreWorkspace(ctx context.Context, input RestoreWorkspaceInput) (RestoreWorkspaceOutput, error) {
+	result, err := workspace.Restore(ctx, input.Cwd, input.Commit)
+	if err != nil {
+		return RestoreWorkspaceOutput{}, err
+	}
+	return RestoreWorkspaceOutput{Restored: result.Restored, Removed: result.Removed}, nil
+}
@@ -0,0 +1,304 @@
+// Package archive streams session transcripts to object storage so they
+// outlive Temporal's retention window.
+//
+// Each archive call writes one segment: the history items added since the
+// previous segment, as schema-versioned JSONL. A per-session index.json lists
+// the segments in order. Segments are never rewritten, so the archive is an
+// append-only record of the session even when compaction later rewrites the
+// in-workflow history. Layout under the configured prefix:
+//
+//	<prefix>/<session-id>/index.json
+//	<prefix>/<session-id>/segment-000001.jsonl
+//	<prefix>/<session-id>/segment-000002.jsonl
+//
+// Stores are addressed by URL: s3://bucket/prefix, gs://bucket/prefix (GCS
+// through its S3-compatible XML API) and file:///dir for local archives.
+// Cloud stores are reached with plain signed HTTP requests, which keeps the
+// binary free of cloud SDK dependencies.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// SchemaVersion is the version of the segment record and index formats.
+// Bump it on incompatible changes; readers should check it on every record.
+const SchemaVersion = 1
+
+// IndexFile is the name of the per-session index object.
+const IndexFile = "index.json"
+
+// ErrNotFound is returned by Store.Get for a missing object.
+var ErrNotFound = errors.New("object not found")
+
+// Store reads and writes whole objects by key.
+type Store interface {
+	// Get returns an object's content, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put creates or replaces an object.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// Record is one line of a segment: a history item with its provenance.
+type Record struct {
+	SchemaVersion int                     `json:"schema_version"`
+	SessionID     string                  `json:"session_id"`
+	Segment       int                     `json:"segment"`
+	Item          models.ConversationItem `json:"item"`
+}
+
+// Segment is a batch of history items to archive.
+type Segment struct {
+	SessionID  string
+	Number     int    // 1-based, increasing per session
+	Reason     string // What triggered it: "turn_complete", "shutdown", "compaction", "context_edit"
+	ArchivedAt time.Time
+	Items      []models.ConversationItem
+}
+
+// SegmentRef describes an archived segment in the index.
+type SegmentRef struct {
+	Number     int       `json:"number"`
+	Key        string    `json:"key"` // Object name relative to the session directory
+	Items      int       `json:"items"`
+	FirstTurn  string    `json:"first_turn,omitempty"`
+	LastTurn   string    `json:"last_turn,omitempty"`
+	Reason     string    `json:"reason"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// Index lists a session's segments in order.
+type Index struct {
+	SchemaVersion int          `json:"schema_version"`
+	SessionID     string       `json:"session_id"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Segments      []SegmentRef `json:"segments"`
+}
+
+// Archive is a Store plus the key prefix sessions are archived under.
+type Archive struct {
+	store  Store
+	prefix string
+}
+
+// New returns an Archive writing under prefix in store.
+func New(store Store, prefix string) *Archive {
+	return &Archive{store: store, prefix: strings.Trim(prefix, "/")}
+}
+
+// Open returns the Archive for an archive URL (s3://, gs:// or file://).
+// Cloud credentials come from the environment; see NewS3Store.
+func Open(rawURL string) (*Archive, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive URL %q: %w", rawURL, err)
+	}
+	switch u.Scheme {
+	case "s3", "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("archive URL %q has no bucket", rawURL)
+		}
+		store, err := NewS3Store(u.Scheme, u.Host)
+		if err != nil {
+			return nil, err
+		}
+		return New(store, u.Path), nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("archive URL %q has no directory", rawURL)
+		}
+		return New(NewFileStore(u.Path), ""), nil
+	default:
+		return nil, fmt.Errorf("unsupported archive URL scheme %q (want s3, gs or file)", u.Scheme)
+	}
+}
+
+// sessionKey returns the key of an object in a session's directory.
+func (a *Archive) sessionKey(sessionID, name string) string {
+	return path.Join(a.prefix, sessionID, name)
+}
+
+// Put writes an object outside the session directories, such as a usage
+// report, under name relative to the prefix. Returns its key.
+func (a *Archive) Put(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+	key := path.Join(a.prefix, name)
+	if err := a.store.Put(ctx, key, data, contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// SegmentName is the object name of segment n.
+func SegmentName(n int) string {
+	return fmt.Sprintf("segment-%06d.jsonl", n)
+}
+
+// EncodeSegment renders seg as JSONL records.
+func EncodeSegment(seg Segment) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range seg.Items {
+		if err := enc.Encode(Record{
+			SchemaVersion: SchemaVersion,
+			SessionID:     seg.SessionID,
+			Segment:       seg.Number,
+			Item:          item,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// AppendSegment writes seg and records it in the session index. Writing the
+// same segment number again replaces it, so a retried call is harmless.
+func (a *Archive) AppendSegment(ctx context.Context, seg Segment) (SegmentRef, error) {
+	if seg.SessionID == "" || seg.Number <= 0 {
+		return SegmentRef{}, fmt.Errorf("segment needs a session ID and a positive number")
+	}
+	data, err := EncodeSegment(seg)
+	if err != nil {
+		return SegmentRef{}, fmt.Errorf("encode segment: %w", err)
+	}
+	ref := SegmentRef{
+		Number:     seg.Number,
+		Key:        SegmentName(seg.Number),
+		Items:      len(seg.Items),
+		Reason:     seg.Reason,
+		ArchivedAt: seg.ArchivedAt.UTC(),
+	}
+	for _, item := range seg.Items {
+		if item.TurnID == "" {
+			continue
+		}
+		if ref.FirstTurn == "" {
+			ref.FirstTurn = item.TurnID
+		}
+		ref.LastTurn = item.TurnID
+	}
+
+	// Segment first: an index entry must never point at a missing object.
+	if err := a.store.Put(ctx, a.sessionKey(seg.SessionID, ref.Key), data, "application/x-ndjson"); err != nil {
+		return SegmentRef{}, fmt.Errorf("write segment: %w", err)
+	}
+
+	index, err := a.ReadIndex(ctx, seg.SessionID)
+	if err != nil {
+		return SegmentRef{}, err
+	}
+	replaced := false
+	for i := range index.Segments {
+		if index.Segments[i].Number == ref.Number {
+			index.Segments[i] = ref
+			replaced = true
+		}
+	}
+	if !replaced {
+		index.Segments = append(index.Segments, ref)
+		sort.Slice(index.Segments, func(i, j int) bool {
+			return index.Segments[i].Number < index.Segments[j].Number
+		})
+	}
+	index.UpdatedAt = ref.ArchivedAt
+
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return SegmentRef{}, fmt.Errorf("encode index: %w", err)
+	}
+	if err := a.store.Put(ctx, a.sessionKey(seg.SessionID, IndexFile), indexData, "application/json"); err != nil {
+		return SegmentRef{}, fmt.Errorf("write index: %w", err)
+	}
+	return ref, nil
+}
+
+// ReadIndex returns a session's index, or an empty one if nothing has been
+// archived yet.
+func (a *Archive) ReadIndex(ctx context.Context, sessionID string) (Index, error) {
+	data, err := a.store.Get(ctx, a.sessionKey(sessionID, IndexFile))
+	if errors.Is(err, ErrNotFound) {
+		return Index{SchemaVersion: SchemaVersion, SessionID: sessionID}, nil
+	}
+	if err != nil {
+		return Index{}, fmt.Errorf("read index: %w", err)
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return Index{}, fmt.Errorf("parse index: %w", err)
+	}
+	if index.SchemaVersion > SchemaVersion {
+		return Index{}, fmt.Errorf("index schema version %d is newer than supported %d", index.SchemaVersion, SchemaVersion)
+	}
+	index.SchemaVersion = SchemaVersion
+	return index, nil
+}
+
+// DecodeSegment parses JSONL segment data back into history items. Lines
+// that are bare conversation items rather than records are accepted too, so
+// hand-made exports read the same way.
+func DecodeSegment(data []byte) ([]models.ConversationItem, error) {
+	var items []models.ConversationItem
+	for n, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var probe struct {
+			SchemaVersion int             `json:"schema_version"`
+			Item          json.RawMessage `json:"item"`
+		}
+		if err := json.Unmarshal(line, &probe); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if probe.SchemaVersion > SchemaVersion {
+			return nil, fmt.Errorf("line %d: record schema version %d is newer than supported %d", n+1, probe.SchemaVersion, SchemaVersion)
+		}
+		raw := line
+		if probe.Item != nil {
+			raw = probe.Item
+		}
+		var item models.ConversationItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ReadSession returns every archived item of a session, segment by segment.
+func (a *Archive) ReadSession(ctx context.Context, sessionID string) ([]models.ConversationItem, error) {
+	index, err := a.ReadIndex(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(index.Segments) == 0 {
+		return nil, fmt.Errorf("session %s has no archived segments", sessionID)
+	}
+	var items []models.ConversationItem
+	for _, ref := range index.Segments {
+		data, err := a.store.Get(ctx, a.sessionKey(sessionID, ref.Key))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", ref.Key, err)
+		}
+		segItems, err := DecodeSegment(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref.Key, err)
+		}
+		items = append(items, segItems...)
+	}
+	return items, nil
+}
@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func testSegment(n int, turns ...string) Segment {
+	seg := Segment{
+		SessionID:  "sess-1",
+		Number:     n,
+		Reason:     "turn_complete",
+		ArchivedAt: time.Date(2026, 3, 1, 12, 0, n, 0, time.UTC),
+	}
+	for _, turn := range turns {
+		seg.Items = append(seg.Items,
+			models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "hi", TurnID: turn},
+			models.ConversationItem{Type: models.ItemTypeAssistantMessage, Content: "hello", TurnID: turn},
+		)
+	}
+	return seg
+}
+
+func TestAppendSegment_WritesSegmentAndIndex(t *testing.T) {
+	dir := t.TempDir()
+	a := New(NewFileStore(dir), "archive/")
+	ctx := context.Background()
+
+	ref, err := a.AppendSegment(ctx, testSegment(1, "turn-1", "turn-2"))
+	require.NoError(t, err)
+	assert.Equal(t, "segment-000001.jsonl", ref.Key)
+	assert.Equal(t, 4, ref.Items)
+	assert.Equal(t, "turn-1", ref.FirstTurn)
+	assert.Equal(t, "turn-2", ref.LastTurn)
+
+	_, err = a.AppendSegment(ctx, testSegment(2, "turn-3"))
+	require.NoError(t, err)
+
+	data, err := NewFileStore(dir).Get(ctx, "archive/sess-1/segment-000001.jsonl")
+	require.NoError(t, err)
+	var records []Record
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var r Record
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.Len(t, records, 4)
+	assert.Equal(t, SchemaVersion, records[0].SchemaVersion)
+	assert.Equal(t, "sess-1", records[0].SessionID)
+	assert.Equal(t, 1, records[0].Segment)
+	assert.Equal(t, models.ItemTypeUserMessage, records[0].Item.Type)
+
+	index, err := a.ReadIndex(ctx, "sess-1")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, index.SchemaVersion)
+	require.Len(t, index.Segments, 2)
+	assert.Equal(t, 1, index.Segments[0].Number)
+	assert.Equal(t, 2, index.Segments[1].Number)
+	assert.Equal(t, "turn-3", index.Segments[1].FirstTurn)
+}
+
+func TestAppendSegment_RetryReplacesEntry(t *testing.T) {
+	a := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+
+	_, err := a.AppendSegment(ctx, testSegment(1, "turn-1"))
+	require.NoError(t, err)
+	_, err = a.AppendSegment(ctx, testSegment(1, "turn-1"))
+	require.NoError(t, err)
+
+	index, err := a.ReadIndex(ctx, "sess-1")
+	require.NoError(t, err)
+	assert.Len(t, index.Segments, 1)
+}
+
+func TestAppendSegment_RequiresSessionAndNumber(t *testing.T) {
+	a := New(NewFileStore(t.TempDir()), "")
+	_, err := a.AppendSegment(context.Background(), Segment{SessionID: "sess-1"})
+	assert.Error(t, err)
+}
+
+func TestReadIndex_Missing(t *testing.T) {
+	a := New(NewFileStore(t.TempDir()), "")
+	index, err := a.ReadIndex(context.Background(), "nope")
+	require.NoError(t, err)
+	assert.Equal(t, "nope", index.SessionID)
+	assert.Empty(t, index.Segments)
+}
+
+func TestReadIndex_RejectsNewerSchema(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	require.NoError(t, store.Put(context.Background(), "sess-1/index.json",
+		[]byte(`{"schema_version": 99, "session_id": "sess-1"}`), "application/json"))
+	_, err := New(store, "").ReadIndex(context.Background(), "sess-1")
+	assert.ErrorContains(t, err, "newer than supported")
+}
+
+func TestReadSession_RoundTrip(t *testing.T) {
+	a := New(NewFileStore(t.TempDir()), "archive")
+	ctx := context.Background()
+
+	_, err := a.ReadSession(ctx, "sess-1")
+	assert.ErrorContains(t, err, "no archived segments")
+
+	_, err = a.AppendSegment(ctx, testSegment(1, "turn-1"))
+	require.NoError(t, err)
+	_, err = a.AppendSegment(ctx, testSegment(2, "turn-2"))
+	require.NoError(t, err)
+
+	items, err := a.ReadSession(ctx, "sess-1")
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	assert.Equal(t, "turn-1", items[0].TurnID)
+	assert.Equal(t, "turn-2", items[3].TurnID)
+	assert.Equal(t, models.ItemTypeAssistantMessage, items[3].Type)
+}
+
+func TestDecodeSegment_BareItemsAndSchema(t *testing.T) {
+	items, err := DecodeSegment([]byte(`{"type":"user_message","content":"hi"}
+
+{"schema_version":1,"session_id":"s","segment":1,"item":{"type":"assistant_message","content":"hello"}}
+`))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "hi", items[0].Content)
+	assert.Equal(t, "hello", items[1].Content)
+
+	_, err = DecodeSegment([]byte(`{"schema_version":99,"item":{}}`))
+	assert.ErrorContains(t, err, "newer than supported")
+
+	_, err = DecodeSegment([]byte("not json"))
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestOpen(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("GCS_HMAC_ACCESS_ID", "")
+	t.Setenv("GCS_HMAC_SECRET", "")
+
+	a, err := Open("s3://transcripts/agents/prod")
+	require.NoError(t, err)
+	assert.Equal(t, "agents/prod", a.prefix)
+	s3, ok := a.store.(*S3Store)
+	require.True(t, ok)
+	assert.Equal(t, "https://transcripts.s3.eu-west-1.amazonaws.com/agents/prod/x/index.json",
+		s3.objectURL("agents/prod/x/index.json").String())
+
+	a, err = Open("file:///var/archive")
+	require.NoError(t, err)
+	assert.IsType(t, &FileStore{}, a.store)
+
+	_, err = Open("gs://transcripts")
+	assert.ErrorContains(t, err, "GCS_HMAC_ACCESS_ID")
+
+	_, err = Open("s3:///prefix")
+	assert.ErrorContains(t, err, "no bucket")
+
+	_, err = Open("ftp://host/dir")
+	assert.ErrorContains(t, err, "unsupported archive URL scheme")
+}
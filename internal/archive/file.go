@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps objects as files under a root directory. Useful for local
+// archives and for mounted network filesystems.
+type FileStore struct {
+	root string
+}
+
+// NewFileStore returns a FileStore rooted at dir.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{root: dir}
+}
+
+// path maps a key to its file.
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put implements Store. The object is written to a temporary file and
+// renamed into place, so readers never see a partial object.
+func (s *FileStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-"+filepath.Base(p))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
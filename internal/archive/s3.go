@@ -0,0 +1,218 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Store reads and writes objects through the S3 REST API with AWS
+// Signature Version 4. It serves Amazon S3, S3-compatible stores (MinIO,
+// R2) and Google Cloud Storage, whose XML API accepts SigV4 with HMAC keys.
+type S3Store struct {
+	endpoint     *url.URL // Scheme and host; bucket in the path when pathStyle
+	bucket       string
+	pathStyle    bool
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+	now          func() time.Time
+}
+
+// NewS3Store returns a store for bucket. scheme "s3" reads AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, optional AWS_SESSION_TOKEN, AWS_REGION (default
+// us-east-1) and optional AWS_ENDPOINT_URL_S3 / AWS_ENDPOINT_URL for
+// S3-compatible stores. Scheme "gs" reads the GCS HMAC key from
+// GCS_HMAC_ACCESS_ID and GCS_HMAC_SECRET.
+func NewS3Store(scheme, bucket string) (*S3Store, error) {
+	s := &S3Store{bucket: bucket, client: http.DefaultClient, now: time.Now}
+	var endpoint string
+	switch scheme {
+	case "s3":
+		s.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		s.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		s.region = firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		endpoint = firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.region)
+		} else {
+			// Custom endpoints (MinIO and friends) rarely have
+			// per-bucket DNS names.
+			s.pathStyle = true
+		}
+		if s.accessKey == "" || s.secretKey == "" {
+			return nil, fmt.Errorf("s3 archive needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+	case "gs":
+		s.accessKey = os.Getenv("GCS_HMAC_ACCESS_ID")
+		s.secretKey = os.Getenv("GCS_HMAC_SECRET")
+		s.region = "auto"
+		s.pathStyle = true
+		endpoint = "https://storage.googleapis.com"
+		if s.accessKey == "" || s.secretKey == "" {
+			return nil, fmt.Errorf("gs archive needs GCS_HMAC_ACCESS_ID and GCS_HMAC_SECRET")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported object store scheme %q", scheme)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid object store endpoint %q", endpoint)
+	}
+	s.endpoint = u
+	return s, nil
+}
+
+// firstEnv returns the first non-empty environment variable of names.
+func firstEnv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// objectURL returns the URL of key.
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	return &u
+}
+
+// Get implements Store.
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, statusError("GET", key, resp.StatusCode, body)
+	}
+	return body, err
+}
+
+// Put implements Store.
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(resp.Body)
+		return statusError("PUT", key, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// statusError describes a failed request, including the start of the
+// response body (the store's error document).
+func statusError(method, key string, status int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 300 {
+		msg = msg[:300] + "..."
+	}
+	return fmt.Errorf("%s %s: HTTP %d: %s", method, key, status, msg)
+}
+
+// do sends a signed request for key.
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+// sign adds SigV4 authentication headers to req. Every header already set on
+// req is signed, along with Host.
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,221 @@
+// Package artifacts serves files the agent shares (coverage reports,
+// generated code) over HTTP from the worker that produced them, so the user
+// can open them in a browser instead of copying them out of the terminal.
+//
+// Each shared file gets an unguessable URL that expires. Files are served
+// from disk as they are when requested, not copied, but only while the path
+// still names the file that was registered: a file replaced by another one,
+// or by a symlink, is not served.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package artifacts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Artifact server defaults.
+const (
+	DefaultTTL = time.Hour
+	MaxTTL     = 7 * 24 * time.Hour
+
+	// pathPrefix starts every artifact URL path.
+	pathPrefix = "/artifacts/"
+)
+
+// Config configures the artifact server.
+type Config struct {
+	Addr    string        // Listen address, e.g. ":8089"
+	BaseURL string        // URL prefix clients reach the server at; default http://<hostname>:<port>
+	TTL     time.Duration // Default lifetime of a shared file
+}
+
+// ConfigFromEnv reads WORKER_ARTIFACT_ADDR, WORKER_ARTIFACT_URL and
+// WORKER_ARTIFACT_TTL (a Go duration). ok is false when
+// WORKER_ARTIFACT_ADDR is unset and the server is disabled.
+func ConfigFromEnv() (cfg Config, ok bool, err error) {
+	cfg = Config{
+		Addr:    os.Getenv("WORKER_ARTIFACT_ADDR"),
+		BaseURL: strings.TrimRight(os.Getenv("WORKER_ARTIFACT_URL"), "/"),
+		TTL:     DefaultTTL,
+	}
+	if cfg.Addr == "" {
+		return Config{}, false, nil
+	}
+	if v := os.Getenv("WORKER_ARTIFACT_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Config{}, false, fmt.Errorf("invalid WORKER_ARTIFACT_TTL %q: want a positive duration such as 2h", v)
+		}
+		cfg.TTL = min(d, MaxTTL)
+	}
+	return cfg, true, nil
+}
+
+// Artifact is a registered file.
+type Artifact struct {
+	URL       string
+	Name      string
+	Path      string
+	Size      int64
+	ExpiresAt time.Time
+
+	file os.FileInfo // The registered file, to tell it from a replacement
+}
+
+// Server maps tokens to files and serves them until they expire.
+type Server struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]Artifact // By token
+
+	srv *http.Server
+}
+
+// NewServer creates a server; Start begins listening.
+func NewServer(cfg Config) *Server {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	return &Server{cfg: cfg, now: time.Now, entries: make(map[string]Artifact)}
+}
+
+// Start listens on the configured address and serves in the background.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("artifact server: %w", err)
+	}
+	if s.cfg.BaseURL == "" {
+		host, _ := os.Hostname()
+		if host == "" {
+			host = "localhost"
+		}
+		s.cfg.BaseURL = "http://" + net.JoinHostPort(host, fmt.Sprint(ln.Addr().(*net.TCPAddr).Port))
+	}
+	s.srv = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Artifact server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// BaseURL returns the URL prefix of shared files.
+func (s *Server) BaseURL() string {
+	return s.cfg.BaseURL
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.Close()
+}
+
+// Register shares the regular file at path (absolute, symlinks resolved)
+// for ttl, or the server's default TTL when ttl is zero, and returns its URL.
+func (s *Server) Register(path string, ttl time.Duration) (Artifact, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return Artifact{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	if ttl <= 0 {
+		ttl = s.cfg.TTL
+	}
+	ttl = min(ttl, MaxTTL)
+
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return Artifact{}, err
+	}
+	token := hex.EncodeToString(raw[:])
+	name := filepath.Base(path)
+	a := Artifact{
+		URL:       s.cfg.BaseURL + pathPrefix + token + "/" + url.PathEscape(name),
+		Name:      name,
+		Path:      path,
+		Size:      info.Size(),
+		ExpiresAt: s.now().Add(ttl),
+		file:      info,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for t, e := range s.entries {
+		if !now.Before(e.ExpiresAt) {
+			delete(s.entries, t)
+		}
+	}
+	s.entries[token] = a
+	return a, nil
+}
+
+// ServeHTTP serves GET /artifacts/<token>/<name>. Unknown and expired
+// tokens get 404.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, pathPrefix), "/")
+	if !strings.HasPrefix(r.URL.Path, pathPrefix) || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	a, ok := s.entries[token]
+	if ok && !s.now().Before(a.ExpiresAt) {
+		delete(s.entries, token)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(a.Path)
+	if err != nil {
+		http.Error(w, "artifact is no longer available", http.StatusGone)
+		return
+	}
+	defer f.Close()
+	// The path is re-opened on every request; serve it only if it still
+	// names the registered file, not whatever was put in its place since.
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || !os.SameFile(info, a.file) {
+		http.Error(w, "artifact is no longer available", http.StatusGone)
+		return
+	}
+
+	// The token is the credential: keep it out of caches and Referer
+	// headers, and sandbox HTML so a report cannot act as this origin.
+	h := w.Header()
+	h.Set("Cache-Control", "private, no-store")
+	h.Set("Referrer-Policy", "no-referrer")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Security-Policy", "sandbox allow-scripts")
+	http.ServeContent(w, r, a.Name, info.ModTime(), f)
+}
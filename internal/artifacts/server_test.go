@@ -0,0 +1,108 @@
+package artifacts
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(s *Server, rawURL string) *http.Response {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(rawURL, s.BaseURL()), nil))
+	return rec.Result()
+}
+
+func TestServer_RegisterAndServe(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "coverage report.html")
+	require.NoError(t, os.WriteFile(path, []byte("<h1>87%</h1>"), 0o644))
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewServer(Config{BaseURL: "http://worker:8089", TTL: time.Hour})
+	s.now = func() time.Time { return now }
+
+	a, err := s.Register(path, 0)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(a.URL, "http://worker:8089/artifacts/"))
+	assert.True(t, strings.HasSuffix(a.URL, "/coverage%20report.html"))
+	assert.Equal(t, int64(12), a.Size)
+	assert.Equal(t, now.Add(time.Hour), a.ExpiresAt)
+
+	resp := get(s, a.URL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "<h1>87%</h1>", string(body))
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	assert.Equal(t, "no-referrer", resp.Header.Get("Referrer-Policy"))
+
+	assert.Equal(t, http.StatusNotFound, get(s, "http://worker:8089/artifacts/0123/coverage.html").StatusCode, "unknown token")
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, http.StatusNotFound, get(s, a.URL).StatusCode, "expired")
+}
+
+func TestServer_RegisterRejectsDirectories(t *testing.T) {
+	s := NewServer(Config{BaseURL: "http://worker:8089"})
+	_, err := s.Register(t.TempDir(), 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a regular file")
+
+	_, err = s.Register(filepath.Join(t.TempDir(), "missing"), 0)
+	assert.Error(t, err)
+}
+
+func TestServer_RemovedFileIsGone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0o644))
+	s := NewServer(Config{BaseURL: "http://worker:8089"})
+	a, err := s.Register(path, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, http.StatusGone, get(s, a.URL).StatusCode)
+}
+
+func TestServer_FileReplacedBySymlinkIsGone(t *testing.T) {
+	dir, secrets := t.TempDir(), t.TempDir()
+	path := filepath.Join(dir, "report.html")
+	require.NoError(t, os.WriteFile(path, []byte("<p>ok</p>"), 0o644))
+	secret := filepath.Join(secrets, "id_rsa")
+	require.NoError(t, os.WriteFile(secret, []byte("key"), 0o600))
+
+	s := NewServer(Config{BaseURL: "http://worker:8089"})
+	a, err := s.Register(path, time.Minute)
+	require.NoError(t, err)
+
+	// Rewriting the registered file in place keeps it shared.
+	require.NoError(t, os.WriteFile(path, []byte("<p>v2</p>"), 0o644))
+	resp := get(s, a.URL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "<p>v2</p>", string(body))
+
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Symlink(secret, path))
+	resp = get(s, a.URL)
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	assert.NotContains(t, string(body), "key")
+}
+
+func TestServer_RegisterRejectsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "out.txt")
+	require.NoError(t, os.WriteFile(target, []byte("x"), 0o644))
+	link := filepath.Join(dir, "link.txt")
+	require.NoError(t, os.Symlink(target, link))
+
+	s := NewServer(Config{BaseURL: "http://worker:8089"})
+	_, err := s.Register(link, 0)
+	assert.Error(t, err)
+}
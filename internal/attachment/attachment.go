@@ -0,0 +1,95 @@
+// Package attachment describes files a tool produced alongside its text
+// output. It is shared by the tools that produce attachments and the
+// conversation model that records them.
+package attachment
+
+import (
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxInlineBytes caps the attachment data one tool output carries
+// inline. Attachments beyond it keep only their metadata, so clients show a
+// placeholder naming the file instead.
+const MaxInlineBytes = 256 << 10
+
+// Attachment is a file a tool produced alongside its text output, such as a
+// plot saved by python_exec or a screenshot returned by an MCP tool. Small
+// images carry their bytes so clients on other machines can display them.
+// Attachments are shown to the user only; they are not sent to the LLM.
+type Attachment struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int64  `json:"size"`
+	Path     string `json:"path,omitempty"` // On the worker; "" for content that was never a file
+	Data     []byte `json:"data,omitempty"` // Inline bytes; nil when over budget or dropped from history
+
+	// URL is where the worker's artifact server serves the file until
+	// ExpiresAt (share_artifact).
+	URL       string    `json:"url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// IsImage reports whether the attachment is an image.
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.MimeType, "image/")
+}
+
+// FromFiles describes the files at paths, reading images no larger
+// than MaxInlineBytes into Data. Files that cannot be read are
+// skipped.
+func FromFiles(paths []string) []Attachment {
+	var out []Attachment
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		a := Attachment{
+			Name:     filepath.Base(path),
+			MimeType: mime.TypeByExtension(strings.ToLower(filepath.Ext(path))),
+			Size:     info.Size(),
+			Path:     path,
+		}
+		if a.IsImage() && a.Size <= MaxInlineBytes {
+			a.Data, _ = os.ReadFile(path)
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// LimitInline drops the Data of attachments once their total passes
+// MaxInlineBytes, keeping the earliest ones whole.
+func LimitInline(atts []Attachment) []Attachment {
+	budget := MaxInlineBytes
+	for i := range atts {
+		if n := len(atts[i].Data); n > budget {
+			atts[i].Data = nil
+		} else {
+			budget -= n
+		}
+	}
+	return atts
+}
+
+// Name returns a file name for the index-th (from 1) unnamed
+// attachment of mimeType, such as "image-2.png".
+func Name(index int, mimeType string) string {
+	kind, sub, _ := strings.Cut(mimeType, "/")
+	if kind == "" {
+		kind = "attachment"
+	}
+	name := kind + "-" + strconv.Itoa(index)
+	if sub == "jpeg" {
+		sub = "jpg"
+	}
+	if sub != "" && !strings.ContainsAny(sub, "+.;") {
+		name += "." + sub
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package attachment
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	plot := filepath.Join(dir, "cell1-figure1.png")
+	require.NoError(t, os.WriteFile(plot, []byte("\x89PNG small"), 0o644))
+	big := filepath.Join(dir, "big.png")
+	require.NoError(t, os.WriteFile(big, bytes.Repeat([]byte("x"), MaxInlineBytes+1), 0o644))
+	csv := filepath.Join(dir, "data.csv")
+	require.NoError(t, os.WriteFile(csv, []byte("a,b\n"), 0o644))
+
+	atts := FromFiles([]string{plot, big, csv, filepath.Join(dir, "missing.png")})
+	require.Len(t, atts, 3)
+
+	assert.Equal(t, Attachment{Name: "cell1-figure1.png", MimeType: "image/png", Size: 10, Path: plot, Data: []byte("\x89PNG small")}, atts[0])
+	assert.True(t, atts[0].IsImage())
+	assert.Nil(t, atts[1].Data, "images over the cap are not read")
+	assert.Equal(t, int64(MaxInlineBytes+1), atts[1].Size)
+	assert.Nil(t, atts[2].Data, "only images are inlined")
+	assert.False(t, atts[2].IsImage())
+}
+
+func TestLimitInline(t *testing.T) {
+	half := bytes.Repeat([]byte("x"), MaxInlineBytes/2)
+	atts := LimitInline([]Attachment{
+		{Name: "a.png", Data: half},
+		{Name: "b.png", Data: append(half, 'y')},
+		{Name: "c.png", Data: half},
+	})
+	assert.NotNil(t, atts[0].Data)
+	assert.Nil(t, atts[1].Data, "over the remaining budget")
+	assert.NotNil(t, atts[2].Data, "later attachments may still fit")
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "image-1.png", Name(1, "image/png"))
+	assert.Equal(t, "image-2.jpg", Name(2, "image/jpeg"))
+	assert.Equal(t, "image-3", Name(3, "image/svg+xml"))
+	assert.Equal(t, "attachment-1", Name(1, ""))
+}
@@ -0,0 +1,128 @@
+package bench
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/handlers"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestScriptedClient_ToolRoundsThenReply(t *testing.T) {
+	c := &ScriptedClient{ToolCalls: 2, Dir: "/tmp"}
+	history := []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "benchmark turn 1"}}
+
+	for round := 0; round < 2; round++ {
+		resp, err := c.Call(context.Background(), llm.LLMRequest{History: history})
+		require.NoError(t, err)
+		require.Equal(t, models.FinishReasonToolCalls, resp.FinishReason)
+		call := resp.Items[0]
+		assert.Equal(t, "list_dir", call.Name)
+		assert.JSONEq(t, `{"dir_path": "/tmp"}`, call.Arguments)
+		history = append(history, call, models.ConversationItem{
+			Type: models.ItemTypeFunctionCallOutput, CallID: call.CallID,
+			Output: &models.FunctionCallOutputPayload{Content: "entries"},
+		})
+	}
+
+	resp, err := c.Call(context.Background(), llm.LLMRequest{History: history})
+	require.NoError(t, err)
+	assert.Equal(t, models.FinishReasonStop, resp.FinishReason)
+	assert.Equal(t, `Done with "benchmark turn 1" after 2 tool calls.`, resp.Items[0].Content)
+	assert.Equal(t, int64(3), c.Calls())
+	assert.Equal(t, int64(2), c.ToolCallsIssued())
+}
+
+func TestScriptedClient_LatencyHonorsCancel(t *testing.T) {
+	c := &ScriptedClient{Latency: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Call(ctx, llm.LLMRequest{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSummarize(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	s := Summarize(MetricTurn, samples)
+	assert.Equal(t, 100, s.Count)
+	assert.Equal(t, 50*time.Millisecond, s.P50)
+	assert.Equal(t, 90*time.Millisecond, s.P90)
+	assert.Equal(t, 99*time.Millisecond, s.P99)
+	assert.Equal(t, 100*time.Millisecond, s.Max)
+	assert.Equal(t, 50500*time.Microsecond, s.Mean)
+
+	assert.Equal(t, Summary{Metric: MetricTurn}, Summarize(MetricTurn, nil))
+}
+
+func TestReportFormat(t *testing.T) {
+	rec := NewRecorder()
+	rec.Add(MetricTurn, 120*time.Millisecond)
+	rec.Add(MetricFirstTurn, 300*time.Millisecond)
+	report := Report{
+		Sessions: 2, FailedSessions: 1, Turns: 4, LLMCalls: 8,
+		Wall: 2 * time.Second, Latencies: rec.Summaries(),
+		Errors: []string{"bench-1: turn 2: timed out"},
+	}
+
+	out := report.Format()
+	assert.Contains(t, out, "Sessions: 2 (1 failed)   Turns: 4   Wall time: 2s")
+	assert.Contains(t, out, "Throughput: 2.0 turns/s, 4.0 LLM calls/s\n")
+	assert.Contains(t, out, "first_turn         1   300.0ms")
+	assert.Contains(t, out, "bench-1: turn 2: timed out")
+	assert.Less(t, strings.Index(out, "first_turn"), strings.Index(out, "\nturn "), "metrics are in report order")
+}
+
+// TestSessionInput_RunsScriptedTurns runs a benchmark session's workflow
+// input through AgenticWorkflow with the real activities and the scripted
+// client, checking that turns complete with their tool calls.
+func TestSessionInput_RunsScriptedTurns(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	scripted := &ScriptedClient{ToolCalls: 1, Dir: t.TempDir()}
+	llmActivities := activities.NewLLMActivities(scripted)
+	env.RegisterActivity(llmActivities.ExecuteLLMCall)
+	env.RegisterActivity(llmActivities.ExecuteCompact)
+	registry := tools.NewToolRegistry()
+	registry.Register(handlers.NewListDirTool())
+	env.RegisterActivity(activities.NewToolActivities(registry).ExecuteTool)
+	instructionActivities := activities.NewInstructionActivities()
+	env.RegisterActivity(instructionActivities.LoadSkills)
+	env.RegisterActivity(activities.NewCapabilityActivities(registry, nil, nil).DescribeWorker)
+
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.UpdateUserInput, "turn-2", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("user_input rejected: %v", err) },
+			OnComplete: func(interface{}, error) {},
+		}, workflow.UserInput{Content: turnMessage(2)})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(workflow.UpdateShutdown, "shutdown", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { t.Errorf("shutdown rejected: %v", err) },
+			OnComplete: func(interface{}, error) {},
+		}, workflow.ShutdownRequest{})
+	}, time.Minute)
+
+	cfg := Config{ToolCalls: 1}
+	env.ExecuteWorkflow(workflow.AgenticWorkflow, sessionInput(cfg, "bench-test-0"))
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	assert.Equal(t, int64(4), scripted.Calls(), "two turns of one tool round and a reply")
+	assert.Equal(t, int64(2), scripted.ToolCallsIssued())
+}
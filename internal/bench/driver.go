@@ -0,0 +1,194 @@
+// Package bench load-tests the workflow engine. It drives synthetic sessions
+// through the real AgenticWorkflow against a scripted LLM, measuring turn
+// latency, Update round trips and throughput, to size workers.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package bench
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// maxReportedErrors caps the session failures listed in a Report.
+const maxReportedErrors = 5
+
+// Config describes a benchmark run.
+type Config struct {
+	TaskQueue   string
+	Sessions    int           // Sessions to run
+	Turns       int           // Turns per session, including the first
+	Concurrency int           // Sessions in flight at once (0 = all)
+	ToolCalls   int           // Tool-call rounds per turn; enables list_dir when > 0
+	TurnTimeout time.Duration // Per-turn deadline
+	RunID       string        // Prefix of the session workflow IDs
+}
+
+// Run drives cfg.Sessions sessions and reports their latencies. LLM and
+// tool call counts are filled in by the caller, which owns the client.
+func Run(ctx context.Context, c client.Client, cfg Config) Report {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 || concurrency > cfg.Sessions {
+		concurrency = cfg.Sessions
+	}
+	rec := NewRecorder()
+	report := Report{Sessions: cfg.Sessions}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	start := time.Now()
+	for i := 0; i < cfg.Sessions; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			turns, err := runSession(ctx, c, cfg, fmt.Sprintf("%s-%d", cfg.RunID, i), rec)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Turns += turns
+			if err != nil {
+				report.FailedSessions++
+				if len(report.Errors) < maxReportedErrors {
+					report.Errors = append(report.Errors, err.Error())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	report.Wall = time.Since(start)
+	report.Latencies = rec.Summaries()
+	return report
+}
+
+// sessionInput is the workflow input of a benchmark session.
+func sessionInput(cfg Config, workflowID string) workflow.WorkflowInput {
+	enabled := []string{"request_user_input"} // keeps the session open between turns
+	if cfg.ToolCalls > 0 {
+		enabled = append(enabled, "list_dir")
+	}
+	return workflow.WorkflowInput{
+		ConversationID: workflowID,
+		UserMessage:    turnMessage(1),
+		Config: models.SessionConfiguration{
+			// Pre-assembled instructions skip per-session instruction loading.
+			BaseInstructions: "You are a benchmark session.",
+			Model: models.ModelConfig{
+				Provider:      "bench",
+				Model:         "scripted",
+				MaxTokens:     1000,
+				ContextWindow: 128000,
+			},
+			Tools:                     models.ToolsConfig{EnabledTools: enabled},
+			Permissions:               models.Permissions{ApprovalMode: models.ApprovalNever},
+			DisableSuggestions:        true,
+			DisableWorkspaceSnapshots: true,
+		},
+	}
+}
+
+func turnMessage(n int) string {
+	return fmt.Sprintf("benchmark turn %d", n)
+}
+
+// runSession runs one session to completion and returns its completed turns.
+func runSession(ctx context.Context, c client.Client, cfg Config, workflowID string, rec *Recorder) (int, error) {
+	started := time.Now()
+	if _, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        workflowID,
+		TaskQueue: cfg.TaskQueue,
+	}, "AgenticWorkflow", sessionInput(cfg, workflowID)); err != nil {
+		return 0, fmt.Errorf("%s: start: %w", workflowID, err)
+	}
+
+	w := &turnWatcher{client: c, workflowID: workflowID, sinceSeq: -1}
+	if err := w.waitTurnComplete(ctx, "", cfg.TurnTimeout); err != nil {
+		return 0, fmt.Errorf("%s: turn 1: %w", workflowID, err)
+	}
+	rec.Add(MetricFirstTurn, time.Since(started))
+	done := 1
+
+	for n := 2; n <= cfg.Turns; n++ {
+		sent := time.Now()
+		var resp workflow.StateUpdateResponse
+		if err := update(ctx, c, workflowID, workflow.UpdateUserInput,
+			workflow.UserInput{Content: turnMessage(n)}, &resp); err != nil {
+			return done, fmt.Errorf("%s: turn %d: user_input: %w", workflowID, n, err)
+		}
+		rec.Add(MetricUserInput, time.Since(sent))
+		if err := w.waitTurnComplete(ctx, resp.TurnID, cfg.TurnTimeout); err != nil {
+			return done, fmt.Errorf("%s: turn %d: %w", workflowID, n, err)
+		}
+		rec.Add(MetricTurn, time.Since(sent))
+		done++
+	}
+
+	sent := time.Now()
+	var shutdown workflow.ShutdownResponse
+	if err := update(ctx, c, workflowID, workflow.UpdateShutdown, workflow.ShutdownRequest{}, &shutdown); err != nil {
+		return done, fmt.Errorf("%s: shutdown: %w", workflowID, err)
+	}
+	rec.Add(MetricShutdown, time.Since(sent))
+	return done, nil
+}
+
+// update sends an Update and waits for its result.
+func update(ctx context.Context, c client.Client, workflowID, name string, arg, result interface{}) error {
+	handle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   workflowID,
+		UpdateName:   name,
+		Args:         []interface{}{arg},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return err
+	}
+	return handle.Get(ctx, result)
+}
+
+// turnWatcher follows a session's history with the blocking
+// get_state_update Update, as the TUI does.
+type turnWatcher struct {
+	client     client.Client
+	workflowID string
+	sinceSeq   int
+	phase      workflow.TurnPhase
+}
+
+// waitTurnComplete blocks until turnID (any turn when empty) completes.
+func (w *turnWatcher) waitTurnComplete(ctx context.Context, turnID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		var resp workflow.StateUpdateResponse
+		err := update(ctx, w.client, w.workflowID, workflow.UpdateGetStateUpdate,
+			workflow.StateUpdateRequest{SinceSeq: w.sinceSeq, SincePhase: w.phase}, &resp)
+		if err != nil {
+			return fmt.Errorf("get_state_update: %w", err)
+		}
+		w.phase = resp.Status.Phase
+		complete := false
+		for _, item := range resp.Items {
+			if item.Seq > w.sinceSeq || resp.Compacted {
+				w.sinceSeq = item.Seq
+			}
+			if item.Type == models.ItemTypeTurnComplete && (turnID == "" || item.TurnID == turnID) {
+				complete = true
+			}
+		}
+		if complete {
+			return nil
+		}
+		if resp.Completed {
+			return fmt.Errorf("session ended before the turn completed")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ScriptedClient is a deterministic llm.LLMClient for load tests. Each turn
+// it answers with ToolCalls list_dir calls, one per call, then a canned
+// assistant reply. Responses depend only on the history, so every session
+// does the same work.
+type ScriptedClient struct {
+	// Latency is how long each call takes, standing in for the provider.
+	Latency time.Duration
+
+	// ToolCalls is how many tool-call rounds each turn makes before the
+	// final reply. Each round runs a list_dir activity on the worker.
+	ToolCalls int
+
+	// Dir is the directory the list_dir calls list.
+	Dir string
+
+	calls     atomic.Int64
+	toolCalls atomic.Int64
+}
+
+// Calls returns how many Call requests the client has served.
+func (c *ScriptedClient) Calls() int64 {
+	return c.calls.Load()
+}
+
+// ToolCallsIssued returns how many tool calls the client has asked for.
+func (c *ScriptedClient) ToolCallsIssued() int64 {
+	return c.toolCalls.Load()
+}
+
+// Call implements llm.LLMClient.
+func (c *ScriptedClient) Call(ctx context.Context, request llm.LLMRequest) (llm.LLMResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return llm.LLMResponse{}, err
+	}
+	n := c.calls.Add(1)
+
+	rounds, turnInput := turnProgress(request.History)
+	usage := models.TokenUsage{
+		PromptTokens:     estimateTokens(request.History),
+		CompletionTokens: 20,
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	if rounds < c.ToolCalls {
+		c.toolCalls.Add(1)
+		args, _ := json.Marshal(map[string]string{"dir_path": c.Dir})
+		return llm.LLMResponse{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    fmt.Sprintf("bench-call-%d", n),
+				Name:      "list_dir",
+				Arguments: string(args),
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   usage,
+		}, nil
+	}
+	return llm.LLMResponse{
+		Items: []models.ConversationItem{{
+			Type:    models.ItemTypeAssistantMessage,
+			Content: fmt.Sprintf("Done with %q after %d tool calls.", turnInput, rounds),
+		}},
+		FinishReason: models.FinishReasonStop,
+		TokenUsage:   usage,
+	}, nil
+}
+
+// Compact implements llm.LLMClient with a fixed summary.
+func (c *ScriptedClient) Compact(ctx context.Context, request llm.CompactRequest) (llm.CompactResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return llm.CompactResponse{}, err
+	}
+	return llm.CompactResponse{
+		Items: []models.ConversationItem{{
+			Type:    models.ItemTypeAssistantMessage,
+			Content: fmt.Sprintf("Summary of %d earlier items.", len(request.Input)),
+		}},
+		TokenUsage: models.TokenUsage{TotalTokens: estimateTokens(request.Input)},
+	}, nil
+}
+
+// wait simulates provider latency.
+func (c *ScriptedClient) wait(ctx context.Context) error {
+	if c.Latency <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(c.Latency)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// turnProgress returns how many tool outputs follow the last user message,
+// and that message.
+func turnProgress(history []models.ConversationItem) (rounds int, input string) {
+	for i := len(history) - 1; i >= 0; i-- {
+		switch history[i].Type {
+		case models.ItemTypeFunctionCallOutput:
+			rounds++
+		case models.ItemTypeUserMessage:
+			return rounds, history[i].Content
+		}
+	}
+	return rounds, ""
+}
+
+// estimateTokens approximates the prompt size at four bytes per token.
+func estimateTokens(items []models.ConversationItem) int {
+	n := 0
+	for _, item := range items {
+		n += len(item.Content) + len(item.Arguments)
+		if item.Output != nil {
+			n += len(item.Output.Content)
+		}
+	}
+	return n / 4
+}
@@ -0,0 +1,150 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names recorded by Run.
+const (
+	MetricFirstTurn = "first_turn" // Workflow start to the first turn's completion
+	MetricTurn      = "turn"       // user_input sent to the turn's completion
+	MetricUserInput = "user_input" // user_input Update round trip
+	MetricShutdown  = "shutdown"   // shutdown Update round trip
+)
+
+// metricOrder is the order metrics are reported in.
+var metricOrder = []string{MetricFirstTurn, MetricTurn, MetricUserInput, MetricShutdown}
+
+// Recorder collects latency samples from concurrent sessions.
+type Recorder struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{samples: make(map[string][]time.Duration)}
+}
+
+// Add records a sample of metric.
+func (r *Recorder) Add(metric string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[metric] = append(r.samples[metric], d)
+}
+
+// Summaries returns a summary per recorded metric, in report order.
+func (r *Recorder) Summaries() []Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Summary
+	for _, name := range metricOrder {
+		if s, ok := r.samples[name]; ok {
+			out = append(out, Summarize(name, s))
+		}
+	}
+	return out
+}
+
+// Summary describes the distribution of one metric.
+type Summary struct {
+	Metric string        `json:"metric"`
+	Count  int           `json:"count"`
+	Mean   time.Duration `json:"mean_ns"`
+	P50    time.Duration `json:"p50_ns"`
+	P90    time.Duration `json:"p90_ns"`
+	P99    time.Duration `json:"p99_ns"`
+	Max    time.Duration `json:"max_ns"`
+}
+
+// Summarize computes the summary of samples.
+func Summarize(metric string, samples []time.Duration) Summary {
+	s := Summary{Metric: metric, Count: len(samples)}
+	if len(samples) == 0 {
+		return s
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	s.Mean = total / time.Duration(len(sorted))
+	s.P50 = percentile(sorted, 50)
+	s.P90 = percentile(sorted, 90)
+	s.P99 = percentile(sorted, 99)
+	s.Max = sorted[len(sorted)-1]
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Report is the outcome of a benchmark run.
+type Report struct {
+	Sessions       int           `json:"sessions"`
+	FailedSessions int           `json:"failed_sessions"`
+	Turns          int           `json:"turns"` // Completed turns across all sessions
+	LLMCalls       int64         `json:"llm_calls,omitempty"`
+	ToolCalls      int64         `json:"tool_calls,omitempty"`
+	Wall           time.Duration `json:"wall_ns"`
+	Latencies      []Summary     `json:"latencies"`
+	Errors         []string      `json:"errors,omitempty"` // First few session failures
+}
+
+// TurnsPerSecond is the completed-turn throughput of the run.
+func (r Report) TurnsPerSecond() float64 {
+	return perSecond(int64(r.Turns), r.Wall)
+}
+
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// Format renders the report as a text table.
+func (r Report) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sessions: %d (%d failed)   Turns: %d   Wall time: %s\n",
+		r.Sessions, r.FailedSessions, r.Turns, r.Wall.Round(time.Millisecond))
+	fmt.Fprintf(&b, "Throughput: %.1f turns/s", r.TurnsPerSecond())
+	if r.LLMCalls > 0 {
+		fmt.Fprintf(&b, ", %.1f LLM calls/s", perSecond(r.LLMCalls, r.Wall))
+	}
+	if r.ToolCalls > 0 {
+		fmt.Fprintf(&b, ", %.1f tool calls/s", perSecond(r.ToolCalls, r.Wall))
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "%-12s %7s %9s %9s %9s %9s %9s\n", "latency", "count", "mean", "p50", "p90", "p99", "max")
+	for _, s := range r.Latencies {
+		fmt.Fprintf(&b, "%-12s %7d %9s %9s %9s %9s %9s\n", s.Metric, s.Count,
+			formatMillis(s.Mean), formatMillis(s.P50), formatMillis(s.P90), formatMillis(s.P99), formatMillis(s.Max))
+	}
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\nErrors:\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "  %s\n", e)
+		}
+	}
+	return b.String()
+}
+
+// formatMillis renders d in milliseconds with one decimal.
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/handlers"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// NewWorker returns a worker on taskQueue that runs the real workflows and
+// activities, with llmClient in place of the providers. Only the tools the
+// scripted sessions call are registered.
+func NewWorker(c client.Client, taskQueue string, llmClient llm.LLMClient, opts worker.Options) worker.Worker {
+	w := worker.New(c, taskQueue, opts)
+
+	w.RegisterWorkflow(workflow.AgenticWorkflow)
+	w.RegisterWorkflow(workflow.AgenticWorkflowContinued)
+
+	toolRegistry := tools.NewToolRegistry()
+	toolRegistry.Register(handlers.NewListDirTool())
+
+	llmActivities := activities.NewLLMActivities(llmClient)
+	w.RegisterActivity(llmActivities.ExecuteLLMCall)
+	w.RegisterActivity(llmActivities.ExecuteCompact)
+	w.RegisterActivity(llmActivities.GenerateSuggestions)
+	w.RegisterActivity(llmActivities.GenerateSessionTitle)
+
+	toolActivities := activities.NewToolActivities(toolRegistry)
+	w.RegisterActivity(toolActivities.ExecuteTool)
+
+	instructionActivities := activities.NewInstructionActivities()
+	w.RegisterActivity(instructionActivities.LoadWorkerInstructions)
+	w.RegisterActivity(instructionActivities.LoadPersonalInstructions)
+	w.RegisterActivity(instructionActivities.LoadExecPolicy)
+	w.RegisterActivity(instructionActivities.LoadConfigFile)
+	w.RegisterActivity(instructionActivities.LoadSkills)
+	w.RegisterActivity(instructionActivities.ReadSkillContent)
+	w.RegisterActivity(instructionActivities.LoadAgentRoles)
+
+	w.RegisterActivity(activities.NewCapabilityActivities(toolRegistry, nil, []string{"bench"}).DescribeWorker)
+
+	return w
+}
@@ -0,0 +1,451 @@
+// Package browser runs the headless Chrome instances behind the browser_*
+// tools: one browser per session on the worker, driven over the Chrome
+// DevTools Protocol with chromedp.
+//
+// Every request a page makes, including redirects, subresources and the
+// navigations a click triggers, is checked against the session's domain
+// allowlist and failed if the host is not on it.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+)
+
+// Pool defaults.
+const (
+	DefaultIdleTimeout = 10 * time.Minute
+	DefaultMaxBrowsers = 4
+)
+
+// Viewport size of new browsers, in CSS pixels.
+const (
+	viewportWidth  = 1280
+	viewportHeight = 800
+)
+
+// DefaultAllowedDomains are the hosts a session may load when it configures
+// no allowlist: the web app under development on this machine.
+var DefaultAllowedDomains = []string{"localhost", "127.0.0.1", "::1"}
+
+// ErrBlocked is returned when a URL's host is not on the allowlist.
+var ErrBlocked = errors.New("blocked by the browser domain allowlist")
+
+// Options configures a Pool.
+type Options struct {
+	// ExecPath is the Chrome or Chromium binary; "" searches the usual
+	// names on PATH.
+	ExecPath string
+	// IdleTimeout closes a browser unused for this long; 0 uses
+	// DefaultIdleTimeout.
+	IdleTimeout time.Duration
+	// MaxBrowsers caps the browsers running at once; starting another
+	// closes the least recently used. 0 uses DefaultMaxBrowsers.
+	MaxBrowsers int
+}
+
+// Pool keeps one browser per session key.
+type Pool struct {
+	opts Options
+
+	mu       sync.Mutex
+	browsers map[string]*Browser
+}
+
+// NewPool creates an empty pool. Browsers start on first use.
+func NewPool(opts Options) *Pool {
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	if opts.MaxBrowsers <= 0 {
+		opts.MaxBrowsers = DefaultMaxBrowsers
+	}
+	return &Pool{opts: opts, browsers: make(map[string]*Browser)}
+}
+
+// Acquire returns the browser of session key, starting one if there is
+// none, and applies allowedDomains (see ParseAllowedDomains) to it. started
+// reports whether a new browser was started.
+func (p *Pool) Acquire(key string, allowedDomains []string) (b *Browser, started bool, err error) {
+	allow, err := ParseAllowedDomains(allowedDomains)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if b, ok := p.browsers[key]; ok && b.ctx.Err() == nil {
+		b.setAllowlist(allow)
+		p.touchLocked(key, b)
+		return b, false, nil
+	}
+	delete(p.browsers, key)
+
+	if len(p.browsers) >= p.opts.MaxBrowsers {
+		p.evictLocked()
+	}
+	b, err = startBrowser(p.opts.ExecPath, allow)
+	if err != nil {
+		return nil, false, err
+	}
+	p.browsers[key] = b
+	p.touchLocked(key, b)
+	return b, true, nil
+}
+
+// touchLocked marks b used now and restarts its idle timer.
+func (p *Pool) touchLocked(key string, b *Browser) {
+	b.lastUsed = time.Now()
+	if b.idle != nil {
+		b.idle.Stop()
+	}
+	b.idle = time.AfterFunc(p.opts.IdleTimeout, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.browsers[key] == b && time.Since(b.lastUsed) >= p.opts.IdleTimeout {
+			delete(p.browsers, key)
+			b.close()
+		}
+	})
+}
+
+// evictLocked closes the least recently used browser.
+func (p *Pool) evictLocked() {
+	var oldestKey string
+	var oldest *Browser
+	for key, b := range p.browsers {
+		if oldest == nil || b.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = key, b
+		}
+	}
+	if oldest != nil {
+		delete(p.browsers, oldestKey)
+		oldest.close()
+	}
+}
+
+// Close closes the browser of session key. Reports whether one was running.
+func (p *Pool) Close(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b, ok := p.browsers[key]
+	if !ok {
+		return false
+	}
+	delete(p.browsers, key)
+	b.close()
+	return true
+}
+
+// CloseAll closes every browser and returns how many were running.
+func (p *Pool) CloseAll() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.browsers)
+	for key, b := range p.browsers {
+		delete(p.browsers, key)
+		b.close()
+	}
+	return n
+}
+
+// Allowlist is the set of hosts a browser may load.
+type Allowlist struct {
+	all   bool // "*": any host
+	rules sandbox.Allowlist
+}
+
+// ParseAllowedDomains parses allowlist entries of the form "host",
+// "host:port" or "*.domain", as for the sandbox network allowlist. A lone
+// "*" allows every host; no entries allow DefaultAllowedDomains.
+func ParseAllowedDomains(entries []string) (Allowlist, error) {
+	if len(entries) == 0 {
+		entries = DefaultAllowedDomains
+	}
+	var rest []string
+	for _, e := range entries {
+		if strings.TrimSpace(e) == "*" {
+			return Allowlist{all: true}, nil
+		}
+		rest = append(rest, e)
+	}
+	rules, err := sandbox.ParseNetworkAllow(rest)
+	if err != nil {
+		return Allowlist{}, fmt.Errorf("browser allowed domains: %w", err)
+	}
+	return Allowlist{rules: rules}, nil
+}
+
+// AllowsURL reports whether the browser may load rawURL. Only http and
+// https URLs on allowed hosts are loaded, plus about:blank and data: URLs,
+// which never leave the browser.
+func (a Allowlist) AllowsURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "about", "data", "blob":
+		return true
+	case "http", "https":
+	default:
+		return false
+	}
+	if a.all {
+		return true
+	}
+	port := 80
+	if u.Scheme == "https" {
+		port = 443
+	}
+	if p := u.Port(); p != "" {
+		port, _ = strconv.Atoi(p)
+	}
+	return a.rules.Allows(u.Hostname(), port)
+}
+
+// Browser is one session's headless Chrome with a single tab.
+type Browser struct {
+	ctx    context.Context // The tab; cancelled when the browser closes
+	cancel context.CancelFunc
+	dir    string // Screenshots
+
+	mu    sync.Mutex // Serializes actions
+	shots int
+
+	// Guarded by the Pool's mutex.
+	lastUsed time.Time
+	idle     *time.Timer
+
+	// stateMu guards the allowlist and blocked hosts, which the request
+	// interceptor reads while an action holds mu.
+	stateMu sync.Mutex
+	allow   Allowlist
+	blocked map[string]bool
+}
+
+// startBrowser launches Chrome and enables request interception.
+func startBrowser(execPath string, allow Allowlist) (*Browser, error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.WindowSize(viewportWidth, viewportHeight))
+	if execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+	if os.Geteuid() == 0 {
+		// Chrome refuses to run as root with its sandbox on.
+		opts = append(opts, chromedp.NoSandbox)
+	}
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	tabCtx, tabCancel := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		tabCancel()
+		allocCancel()
+	}
+
+	b := &Browser{ctx: tabCtx, cancel: cancel, allow: allow, blocked: make(map[string]bool)}
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		if ev, ok := ev.(*fetch.EventRequestPaused); ok {
+			// Actions must not run on the event goroutine.
+			go b.interceptRequest(ev)
+		}
+	})
+
+	// The first Run starts Chrome, which lives as long as the context it
+	// is given, so it gets tabCtx itself rather than a timeout.
+	started := make(chan error, 1)
+	go func() { started <- chromedp.Run(tabCtx, fetch.Enable()) }()
+	select {
+	case err := <-started:
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to start Chrome: %w", err)
+		}
+	case <-time.After(30 * time.Second):
+		cancel()
+		return nil, errors.New("failed to start Chrome: timed out")
+	}
+
+	dir, err := os.MkdirTemp("", "browser-")
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("create screenshot directory: %w", err)
+	}
+	b.dir = dir
+	return b, nil
+}
+
+// interceptRequest lets a paused request through if its host is allowed and
+// fails it otherwise.
+func (b *Browser) interceptRequest(ev *fetch.EventRequestPaused) {
+	c := chromedp.FromContext(b.ctx)
+	if c == nil || c.Target == nil {
+		return
+	}
+	ctx := cdp.WithExecutor(b.ctx, c.Target)
+
+	b.stateMu.Lock()
+	allowed := b.allow.AllowsURL(ev.Request.URL)
+	if !allowed {
+		if u, err := url.Parse(ev.Request.URL); err == nil && u.Host != "" {
+			b.blocked[u.Host] = true
+		}
+	}
+	b.stateMu.Unlock()
+
+	if allowed {
+		_ = fetch.ContinueRequest(ev.RequestID).Do(ctx)
+	} else {
+		_ = fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+	}
+}
+
+func (b *Browser) setAllowlist(allow Allowlist) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	b.allow = allow
+}
+
+// takeBlocked returns and clears the hosts blocked since the last call.
+func (b *Browser) takeBlocked() []string {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	hosts := make([]string, 0, len(b.blocked))
+	for h := range b.blocked {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	b.blocked = make(map[string]bool)
+	return hosts
+}
+
+func (b *Browser) close() {
+	if b.idle != nil {
+		b.idle.Stop()
+	}
+	b.cancel()
+	if b.dir != "" {
+		_ = os.RemoveAll(b.dir)
+	}
+}
+
+// Page describes the tab after an action.
+type Page struct {
+	URL   string
+	Title string
+	// Blocked lists the hosts whose requests were blocked during the
+	// action.
+	Blocked []string
+}
+
+// run runs actions in the tab, stopping when ctx is done, then reads the
+// tab's URL and title.
+func (b *Browser) run(ctx context.Context, actions ...chromedp.Action) (Page, error) {
+	runCtx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	b.takeBlocked() // Drop blocks left by an earlier action's stragglers
+	var page Page
+	err := chromedp.Run(runCtx, actions...)
+	if ctx.Err() != nil {
+		return Page{}, ctx.Err()
+	}
+	if b.ctx.Err() != nil {
+		return Page{}, errors.New("the browser was closed")
+	}
+	// The page is reported even when an action failed, to show where the
+	// tab ended up.
+	_ = chromedp.Run(runCtx, chromedp.Location(&page.URL), chromedp.Title(&page.Title))
+	page.Blocked = b.takeBlocked()
+	return page, err
+}
+
+// Navigate loads rawURL and waits for it to finish loading.
+func (b *Browser) Navigate(ctx context.Context, rawURL string) (Page, error) {
+	b.stateMu.Lock()
+	allowed := b.allow.AllowsURL(rawURL)
+	b.stateMu.Unlock()
+	if !allowed {
+		return Page{}, fmt.Errorf("%s: %w", rawURL, ErrBlocked)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.run(ctx, chromedp.Navigate(rawURL))
+}
+
+// settleDelay gives a click time to start a navigation or re-render before
+// the page is read.
+const settleDelay = 500 * time.Millisecond
+
+// Click clicks the first visible element matching the CSS selector, then
+// waits for the page to settle.
+func (b *Browser) Click(ctx context.Context, selector string) (Page, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.run(ctx,
+		chromedp.Click(selector, chromedp.ByQuery, chromedp.NodeVisible),
+		chromedp.Sleep(settleDelay),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+	)
+}
+
+// ReadText returns the rendered text of the first element matching the CSS
+// selector, or of the whole page when selector is "".
+func (b *Browser) ReadText(ctx context.Context, selector string) (string, Page, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var text string
+	action := chromedp.Evaluate(`document.body ? document.body.innerText : ""`, &text)
+	if selector != "" {
+		action = chromedp.Text(selector, &text, chromedp.ByQuery, chromedp.NodeVisible)
+	}
+	page, err := b.run(ctx, action)
+	return text, page, err
+}
+
+// Screenshot saves a PNG of the viewport, the whole page (fullPage) or the
+// first element matching the CSS selector, and returns its path.
+func (b *Browser) Screenshot(ctx context.Context, selector string, fullPage bool) (string, Page, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var buf []byte
+	var action chromedp.Action
+	switch {
+	case selector != "":
+		action = chromedp.Screenshot(selector, &buf, chromedp.ByQuery)
+	case fullPage:
+		action = chromedp.FullScreenshot(&buf, 100)
+	default:
+		action = chromedp.CaptureScreenshot(&buf)
+	}
+	page, err := b.run(ctx, action)
+	if err != nil {
+		return "", page, err
+	}
+	b.shots++
+	path := filepath.Join(b.dir, fmt.Sprintf("screenshot-%d.png", b.shots))
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		return "", page, fmt.Errorf("save screenshot: %w", err)
+	}
+	return path, page, nil
+}
@@ -0,0 +1,119 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAllowedDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		url     string
+		want    bool
+	}{
+		{"default allows localhost", nil, "http://localhost:3000/", true},
+		{"default allows loopback IPv6", nil, "http://[::1]:8080/", true},
+		{"default blocks others", nil, "https://example.com/", false},
+		{"wildcard subdomain", []string{"*.example.com"}, "https://app.example.com/x", true},
+		{"wildcard excludes other hosts", []string{"*.example.com"}, "https://example.org/", false},
+		{"port rule matches", []string{"localhost:3000"}, "http://localhost:3000/", true},
+		{"port rule blocks other ports", []string{"localhost:3000"}, "http://localhost:4000/", false},
+		{"https default port", []string{"example.com:443"}, "https://example.com/", true},
+		{"star allows all", []string{"*"}, "https://anything.test/", true},
+		{"about:blank always allowed", []string{"example.com"}, "about:blank", true},
+		{"data URLs always allowed", nil, "data:text/html,hi", true},
+		{"file URLs blocked", []string{"*"}, "file:///etc/passwd", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow, err := ParseAllowedDomains(tt.entries)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, allow.AllowsURL(tt.url))
+		})
+	}
+}
+
+func TestParseAllowedDomains_Invalid(t *testing.T) {
+	_, err := ParseAllowedDomains([]string{"localhost:notaport"})
+	assert.Error(t, err)
+}
+
+// requireChrome skips the test when no Chrome or Chromium is installed.
+func requireChrome(t *testing.T) string {
+	t.Helper()
+	if path := os.Getenv("CHROME_PATH"); path != "" {
+		return path
+	}
+	for _, name := range []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "headless-shell"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	t.Skip("Chrome not installed")
+	return ""
+}
+
+// TestBrowser_NavigateClickScreenshot drives a real browser against a local
+// page that also tries to load a script from a host off the allowlist.
+func TestBrowser_NavigateClickScreenshot(t *testing.T) {
+	pool := NewPool(Options{ExecPath: requireChrome(t)})
+	t.Cleanup(func() { pool.CloseAll() })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><title>Home</title><script src="http://blocked.test/x.js"></script></head>
+<body><h1>Welcome</h1><a id="next" href="/next">Next</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><head><title>Next</title></head><body><p id="msg">Second page</p></body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	b, started, err := pool.Acquire("session-1", nil)
+	require.NoError(t, err)
+	assert.True(t, started)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	page, err := b.Navigate(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Home", page.Title)
+	assert.Equal(t, []string{"blocked.test"}, page.Blocked)
+
+	page, err = b.Click(ctx, "#next")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/next", page.URL)
+
+	text, _, err := b.ReadText(ctx, "#msg")
+	require.NoError(t, err)
+	assert.Equal(t, "Second page", text)
+
+	path, _, err := b.Screenshot(ctx, "", false)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Positive(t, info.Size())
+
+	_, err = b.Navigate(ctx, "https://example.com/")
+	assert.True(t, errors.Is(err, ErrBlocked))
+
+	again, started, err := pool.Acquire("session-1", nil)
+	require.NoError(t, err)
+	assert.False(t, started, "the session's browser is reused")
+	assert.Same(t, b, again)
+	assert.True(t, pool.Close("session-1"))
+}
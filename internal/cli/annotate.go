@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const (
+	noteUsage  = "Usage: /note <seq|last> <text>  (/pin lists message numbers)"
+	reactUsage = "Usage: /react <seq|last> 👍|👎 [<note>]  (/pin lists message numbers)"
+)
+
+// parseAnnotationTarget parses the target of /note or /react: a history seq
+// (optionally prefixed with #) or "last" for the latest assistant message.
+func parseAnnotationTarget(arg string) (int, bool) {
+	if arg == "last" {
+		return -1, true
+	}
+	seq, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || seq < 0 {
+		return 0, false
+	}
+	return seq, true
+}
+
+// parseReaction maps the reaction argument of /react to a Reaction.
+func parseReaction(arg string) (models.Reaction, bool) {
+	switch arg {
+	case "👍", "+1", "up":
+		return models.ReactionUp, true
+	case "👎", "-1", "down":
+		return models.ReactionDown, true
+	}
+	return "", false
+}
+
+// parseAnnotateCommand parses /note or /react (named by cmd) and its
+// arguments into an annotate_item request.
+func parseAnnotateCommand(cmd, args string) (workflow.AnnotateItemRequest, error) {
+	usage := noteUsage
+	if cmd == "/react" {
+		usage = reactUsage
+	}
+	target, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	seq, ok := parseAnnotationTarget(target)
+	if !ok {
+		return workflow.AnnotateItemRequest{}, fmt.Errorf("%s", usage)
+	}
+	rest = strings.TrimSpace(rest)
+	req := workflow.AnnotateItemRequest{Seq: seq}
+	if cmd == "/react" {
+		reaction, note, _ := strings.Cut(rest, " ")
+		if req.Reaction, ok = parseReaction(reaction); !ok {
+			return workflow.AnnotateItemRequest{}, fmt.Errorf("%s", usage)
+		}
+		rest = strings.TrimSpace(note)
+	}
+	req.Note = rest
+	if req.Note == "" && req.Reaction == "" {
+		return workflow.AnnotateItemRequest{}, fmt.Errorf("%s", usage)
+	}
+	return req, nil
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseAnnotateCommand(t *testing.T) {
+	req, err := parseAnnotateCommand("/note", "#12 this diff broke prod")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.AnnotateItemRequest{Seq: 12, Note: "this diff broke prod"}, req)
+
+	req, err = parseAnnotateCommand("/react", "last 👎")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.AnnotateItemRequest{Seq: -1, Reaction: models.ReactionDown}, req)
+
+	req, err = parseAnnotateCommand("/react", "3 +1 clean fix")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.AnnotateItemRequest{Seq: 3, Reaction: models.ReactionUp, Note: "clean fix"}, req)
+
+	for _, bad := range []string{"", "3", "x hello", "-2 hello"} {
+		_, err := parseAnnotateCommand("/note", bad)
+		assert.ErrorContains(t, err, "Usage: /note", bad)
+	}
+	for _, bad := range []string{"3", "3 meh", "last"} {
+		_, err := parseAnnotateCommand("/react", bad)
+		assert.ErrorContains(t, err, "Usage: /react", bad)
+	}
+}
+
+func TestRenderAnnotation(t *testing.T) {
+	r := newTestRenderer()
+	out := r.RenderItem(models.ConversationItem{
+		Type:       models.ItemTypeAnnotation,
+		Content:    "this diff broke prod",
+		Annotation: &models.Annotation{TargetSeq: 12, Reaction: models.ReactionDown},
+	}, false)
+	assert.Equal(t, "✎ on #12: 👎 this diff broke prod\n", out)
+}
@@ -7,8 +7,11 @@ import (
 	"strings"
 
 	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
 
+	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
@@ -173,6 +176,39 @@ func EscalationSelectionToResponse(selected int, pending []workflow.EscalationRe
 type approvalInfo struct {
 	Title   string   // e.g. "Write file: /path/to/file.go" or "Shell: rm -rf /tmp"
 	Preview []string // optional content preview lines (nil = no preview box)
+	Details []string // optional context lines from the worker, shown below the preview
+}
+
+// pendingApprovalInfo is formatApprovalInfo plus the context the worker
+// attached to the pending call, described in locale.
+func pendingApprovalInfo(ap workflow.PendingApproval, locale Locale) approvalInfo {
+	info := formatApprovalInfo(ap.ToolName, ap.Arguments)
+	if ap.Context != nil {
+		info.Details = approvalContextLines(ap.ToolName, *ap.Context, locale)
+	}
+	return info
+}
+
+// approvalContextLines describes an ApprovalContext: the file a write would
+// replace and the size change, or the paths a shell command names.
+func approvalContextLines(toolName string, c models.ApprovalContext, locale Locale) []string {
+	var lines []string
+	if toolName == "write_file" {
+		if !c.FileExists {
+			return []string{locale.T("Creates a new file (%d bytes)", c.NewSize)}
+		}
+		lines = append(lines, locale.T("Replaces existing file: %d → %d bytes (%+d)", c.CurrentSize, c.NewSize, c.SizeDelta()))
+		if len(c.CurrentLines) > 0 {
+			lines = append(lines, locale.T("Currently starts with:"))
+			for _, l := range c.CurrentLines {
+				lines = append(lines, "  "+l)
+			}
+		}
+	}
+	if len(c.Paths) > 0 {
+		lines = append(lines, locale.T("Touches: %s", strings.Join(c.Paths, ", ")))
+	}
+	return lines
 }
 
 // formatApprovalInfo extracts structured approval information from tool arguments.
@@ -207,6 +243,41 @@ func formatApprovalInfo(toolName, arguments string) approvalInfo {
 				info.Preview = contentPreview(input, 5)
 			}
 			return info
+		case "gh_create_pr":
+			if title := stringArg(args, "title"); title != "" {
+				info := approvalInfo{Title: fmt.Sprintf("Open pull request: %s (%s)", title, stringArg(args, "head"))}
+				if body := stringArg(args, "body"); body != "" {
+					info.Preview = contentPreview(body, 5)
+				}
+				return info
+			}
+		case "gh_comment":
+			if n, ok := args["number"].(float64); ok {
+				info := approvalInfo{Title: fmt.Sprintf("Comment on #%d", int(n))}
+				if body := stringArg(args, "body"); body != "" {
+					info.Preview = contentPreview(body, 5)
+				}
+				return info
+			}
+		case "browser_navigate":
+			if u := stringArg(args, "url"); u != "" {
+				return approvalInfo{Title: "Open in browser: " + u}
+			}
+		case "browser_click":
+			if sel := stringArg(args, "selector"); sel != "" {
+				return approvalInfo{Title: "Click in browser: " + sel}
+			}
+		case "python_exec":
+			info := approvalInfo{Title: "Python"}
+			if restart, _ := args["restart"].(bool); restart {
+				info.Title = "Python (restart kernel)"
+			}
+			if code := stringArg(args, "code"); code != "" {
+				info.Preview = contentPreview(code, 5)
+			}
+			return info
+		case "quality_gate":
+			return approvalInfo{Title: "Quality gate: " + transcript.QualityGateDetail(args)}
 		case "read_file":
 			if path := stringArg(args, "file_path", "path"); path != "" {
 				return approvalInfo{Title: "Read: " + path}
@@ -215,7 +286,7 @@ func formatApprovalInfo(toolName, arguments string) approvalInfo {
 			if path := stringArg(args, "dir_path", "path"); path != "" {
 				return approvalInfo{Title: "List: " + path}
 			}
-		case "grep_files":
+		case "grep_files", "grep_changed":
 			if pat, ok := args["pattern"].(string); ok {
 				title := "Search: " + pat
 				if dir, ok := args["path"].(string); ok {
@@ -223,6 +294,18 @@ func formatApprovalInfo(toolName, arguments string) approvalInfo {
 				}
 				return approvalInfo{Title: title}
 			}
+		case "code_outline":
+			if dir, ok := args["path"].(string); ok {
+				return approvalInfo{Title: "Outline: " + dir}
+			}
+		case "semantic_search":
+			if q, ok := args["query"].(string); ok {
+				title := "Semantic search: " + q
+				if dir, ok := args["path"].(string); ok {
+					title += " in " + dir
+				}
+				return approvalInfo{Title: title}
+			}
 		}
 	}
 	display := arguments
@@ -236,7 +319,7 @@ func formatApprovalInfo(toolName, arguments string) approvalInfo {
 // using middle truncation if the content exceeds the limit.
 func contentPreview(content string, maxLines int) []string {
 	lines := strings.Split(content, "\n")
-	truncated, _ := truncateMiddle(lines, maxLines)
+	truncated, _ := transcript.TruncateMiddle(lines, maxLines)
 	return truncated
 }
 
@@ -264,7 +347,7 @@ func formatPatchDiff(input string, maxLines int) *approvalInfo {
 	}
 
 	preview := rawPatchPreview(input, p)
-	truncated, _ := truncateMiddle(preview, maxLines)
+	truncated, _ := transcript.TruncateMiddle(preview, maxLines)
 	return &approvalInfo{Title: title, Preview: truncated}
 }
 
@@ -367,20 +450,6 @@ func patchHunkTitle(h patch.Hunk) string {
 	}
 }
 
-// patchFilePaths extracts file paths from parsed patch input.
-// Returns nil if parsing fails.
-func patchFilePaths(input string) []string {
-	p, err := patch.Parse(input)
-	if err != nil {
-		return nil
-	}
-	paths := make([]string, len(p.Hunks))
-	for i, h := range p.Hunks {
-		paths[i] = h.Path
-	}
-	return paths
-}
-
 // pollErrorKind classifies errors from workflow queries.
 type pollErrorKind int
 
@@ -390,6 +459,13 @@ const (
 	pollErrorFatal
 )
 
+// isStaleResponse reports whether the workflow rejected an approval or
+// escalation response because it no longer answers a pending prompt.
+func isStaleResponse(err error) bool {
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && appErr.Type() == workflow.StaleResponseErrorType
+}
+
 // classifyPollError categorizes a poll error using Temporal SDK typed errors.
 func classifyPollError(err error) pollErrorKind {
 	var notFoundErr *serviceerror.NotFound
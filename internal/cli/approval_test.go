@@ -8,7 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
 
+	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
@@ -45,6 +47,13 @@ func TestClassifyPollError_WrappedNotFound(t *testing.T) {
 	assert.Equal(t, pollErrorCompleted, classifyPollError(err))
 }
 
+func TestIsStaleResponse(t *testing.T) {
+	stale := temporal.NewApplicationError("no approval pending", workflow.StaleResponseErrorType)
+	assert.True(t, isStaleResponse(fmt.Errorf("update failed: %w", stale)))
+	assert.False(t, isStaleResponse(temporal.NewApplicationError("access denied", workflow.AccessDeniedErrorType)))
+	assert.False(t, isStaleResponse(fmt.Errorf("no approval pending")))
+}
+
 // --- Approval input handling tests ---
 
 func TestHandleApprovalInput_Yes(t *testing.T) {
@@ -139,6 +148,37 @@ func TestFormatApprovalInfo_Shell(t *testing.T) {
 	assert.Nil(t, info.Preview)
 }
 
+func TestFormatApprovalInfo_GitHubCreatePR(t *testing.T) {
+	info := formatApprovalInfo("gh_create_pr", `{"title": "Fix login", "head": "fix-login", "body": "Fixes #12"}`)
+	assert.Equal(t, "Open pull request: Fix login (fix-login)", info.Title)
+	assert.Equal(t, []string{"Fixes #12"}, info.Preview)
+}
+
+func TestFormatApprovalInfo_GitHubComment(t *testing.T) {
+	info := formatApprovalInfo("gh_comment", `{"number": 12, "body": "Working on it"}`)
+	assert.Equal(t, "Comment on #12", info.Title)
+	assert.Equal(t, []string{"Working on it"}, info.Preview)
+}
+
+func TestFormatApprovalInfo_PythonExec(t *testing.T) {
+	info := formatApprovalInfo("python_exec", `{"code": "import pandas as pd\ndf = pd.read_csv('a.csv')", "restart": true}`)
+	assert.Equal(t, "Python (restart kernel)", info.Title)
+	assert.Equal(t, []string{"import pandas as pd", "df = pd.read_csv('a.csv')"}, info.Preview)
+}
+
+func TestFormatApprovalInfo_QualityGate(t *testing.T) {
+	assert.Equal(t, "Quality gate: vet, test on ./internal/...",
+		formatApprovalInfo("quality_gate", `{"checks": ["vet", "test"], "packages": "./internal/..."}`).Title)
+	assert.Equal(t, "Quality gate: all checks", formatApprovalInfo("quality_gate", `{}`).Title)
+}
+
+func TestFormatApprovalInfo_Browser(t *testing.T) {
+	assert.Equal(t, "Click in browser: form#login button[type=submit]",
+		formatApprovalInfo("browser_click", `{"selector": "form#login button[type=submit]"}`).Title)
+	assert.Equal(t, "Open in browser: http://localhost:3000/login",
+		formatApprovalInfo("browser_navigate", `{"url": "http://localhost:3000/login"}`).Title)
+}
+
 func TestFormatApprovalInfo_WriteFile(t *testing.T) {
 	info := formatApprovalInfo("write_file", `{"file_path": "/home/user/test.txt", "content": "hello"}`)
 	assert.Equal(t, "Write file: /home/user/test.txt", info.Title)
@@ -146,6 +186,32 @@ func TestFormatApprovalInfo_WriteFile(t *testing.T) {
 	assert.Equal(t, []string{"hello"}, info.Preview)
 }
 
+func TestPendingApprovalInfo_Context(t *testing.T) {
+	info := pendingApprovalInfo(workflow.PendingApproval{
+		ToolName:  "write_file",
+		Arguments: `{"path": "main.go", "content": "package main\n"}`,
+		Context:   &models.ApprovalContext{FileExists: true, CurrentLines: []string{"package old"}, CurrentSize: 40, NewSize: 13},
+	}, LocaleEnglish)
+	assert.Equal(t, []string{"Replaces existing file: 40 → 13 bytes (-27)", "Currently starts with:", "  package old"}, info.Details)
+
+	info = pendingApprovalInfo(workflow.PendingApproval{
+		ToolName:  "write_file",
+		Arguments: `{"path": "new.go", "content": "package main\n"}`,
+		Context:   &models.ApprovalContext{NewSize: 13},
+	}, LocaleEnglish)
+	assert.Equal(t, []string{"Creates a new file (13 bytes)"}, info.Details)
+
+	info = pendingApprovalInfo(workflow.PendingApproval{
+		ToolName:  "shell_command",
+		Arguments: `{"command": "rm -rf build dist"}`,
+		Context:   &models.ApprovalContext{Paths: []string{"build", "dist"}},
+	}, LocaleEnglish)
+	assert.Equal(t, []string{"Touches: build, dist"}, info.Details)
+
+	info = pendingApprovalInfo(workflow.PendingApproval{ToolName: "shell_command", Arguments: `{"command": "ls"}`}, LocaleEnglish)
+	assert.Nil(t, info.Details)
+}
+
 func TestFormatApprovalInfo_WriteFilePathArg(t *testing.T) {
 	info := formatApprovalInfo("write_file", `{"path": "/home/user/test.txt", "content": "hello"}`)
 	assert.Equal(t, "Write file: /home/user/test.txt", info.Title)
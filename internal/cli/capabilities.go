@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// formatCapabilitiesDisplay formats the capabilities of the worker serving
+// the session for display.
+func formatCapabilitiesDisplay(caps workflow.CapabilitiesResponse) string {
+	w := caps.Worker
+	list := func(names []string) string {
+		if len(names) == 0 {
+			return "none"
+		}
+		return strings.Join(names, ", ")
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Worker %s (%s, %s/%s)\n", w.Version, w.GoVersion, w.OS, w.Arch))
+	b.WriteString("─────────────\n")
+	if w.Host != "" {
+		b.WriteString(fmt.Sprintf("  Host:        %s\n", w.Host))
+	}
+	b.WriteString(fmt.Sprintf("  Providers:   %s\n", list(w.Providers)))
+	b.WriteString(fmt.Sprintf("  Sandboxes:   %s\n", list(w.SandboxBackends)))
+	b.WriteString(fmt.Sprintf("  MCP servers: %s\n", list(w.McpServers)))
+	b.WriteString(fmt.Sprintf("  Tools (%d):   %s\n", len(w.Tools), list(w.Tools)))
+	if len(caps.MissingTools) > 0 {
+		b.WriteString(fmt.Sprintf("  Missing:     %s (enabled for this session, not on the worker)\n",
+			strings.Join(caps.MissingTools, ", ")))
+	}
+	if caps.ProjectPolicy != nil {
+		b.WriteString(formatProjectPolicy(caps.ProjectPolicy))
+	}
+	return b.String()
+}
+
+// formatProjectPolicy formats the AGENTS.md front-matter applied to the
+// session: its files, what it changed and what it was not allowed to.
+func formatProjectPolicy(p *models.ProjectPolicy) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Project policy from %s\n", strings.Join(p.Sources, ", ")))
+	for _, s := range p.Applied {
+		b.WriteString("  applied: " + s + "\n")
+	}
+	for _, s := range p.Ignored {
+		b.WriteString("  ignored: " + s + "\n")
+	}
+	return b.String()
+}
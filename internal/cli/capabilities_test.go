@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestFormatCapabilitiesDisplay(t *testing.T) {
+	caps := workflow.CapabilitiesResponse{
+		WorkerCapabilitiesInfo: workflow.WorkerCapabilitiesInfo{
+			Worker: activities.WorkerCapabilities{
+				Version: "abc123", GoVersion: "go1.23", OS: "linux", Arch: "amd64",
+				Tools:     []string{"read_file", "shell_command"},
+				Providers: []string{"openai"},
+			},
+		},
+		MissingTools: []string{"python_exec"},
+	}
+	result := formatCapabilitiesDisplay(caps)
+	assert.Contains(t, result, "Worker abc123 (go1.23, linux/amd64)\n")
+	assert.Contains(t, result, "Providers:   openai\n")
+	assert.Contains(t, result, "Sandboxes:   none\n")
+	assert.Contains(t, result, "Tools (2):   read_file, shell_command\n")
+	assert.Contains(t, result, "Missing:     python_exec")
+	assert.NotContains(t, result, "Host:")
+}
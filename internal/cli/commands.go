@@ -5,35 +5,47 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
 	enums "go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 
 	"github.com/mfateev/temporal-agent-harness/internal/llm"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/skills"
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
-// harnessWorkflowID returns a stable harness workflow ID derived from the
+// HarnessWorkflowID returns a stable harness workflow ID derived from the
 // working directory path. If TCX_HARNESS_ID is set, it is used directly
 // (enables tests to predict the workflow ID for monitoring).
-func harnessWorkflowID(cwd string) string {
+func HarnessWorkflowID(cwd string) string {
 	if id := os.Getenv("TCX_HARNESS_ID"); id != "" {
 		return id
 	}
+	return HarnessWorkflowIDForDir(cwd)
+}
+
+// HarnessWorkflowIDForDir returns the harness workflow ID tcx derives for
+// dir, ignoring TCX_HARNESS_ID. Used to find another directory's sessions.
+func HarnessWorkflowIDForDir(dir string) string {
 	h := sha256.New()
-	h.Write([]byte(cwd))
+	h.Write([]byte(dir))
 	return fmt.Sprintf("harness-%x", h.Sum(nil)[:8])
 }
 
 // startWorkflowCmd starts (or re-attaches to) a HarnessWorkflow and sends a
-// start_session Update to obtain a child AgenticWorkflow ID. It returns
-// WorkflowStartedMsg with the child session workflow ID so all subsequent TUI
-// operations target the AgenticWorkflow directly.
+// start_session Update to obtain a child AgenticWorkflow ID, in one
+// Update-with-Start call: the first message is delivered with the start, and
+// the Update completes once the session is running. It returns
+// WorkflowStartedMsg with the child session workflow ID so all
+// subsequent TUI operations target the AgenticWorkflow directly.
 func startWorkflowCmd(c client.Client, config Config) tea.Cmd {
 	return func() tea.Msg {
 		cwd := config.Cwd
@@ -41,7 +53,7 @@ func startWorkflowCmd(c client.Client, config Config) tea.Cmd {
 			cwd, _ = os.Getwd()
 		}
 
-		harnessID := harnessWorkflowID(cwd)
+		harnessID := HarnessWorkflowID(cwd)
 
 		input := workflow.HarnessWorkflowInput{
 			HarnessID: harnessID,
@@ -54,44 +66,48 @@ func startWorkflowCmd(c client.Client, config Config) tea.Cmd {
 				DisableSuggestions: config.DisableSuggestions,
 				MemoryEnabled:      config.MemoryEnabled,
 				MemoryDbPath:       config.MemoryDbPath,
+				ArchiveURL:         config.ArchiveURL,
 			},
+			MaxConcurrentSessions: config.MaxConcurrentSessions,
 		}
 
 		ctx := context.Background()
-		_, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-			ID:                    harnessID,
-			TaskQueue:             TaskQueue,
-			WorkflowIDReusePolicy: enums.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY,
+		startOp := c.NewWithStartWorkflowOperation(client.StartWorkflowOptions{
+			ID:                       harnessID,
+			TaskQueue:                TaskQueue,
+			WorkflowIDReusePolicy:    enums.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY,
+			WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING,
 		}, "HarnessWorkflow", input)
-		if err != nil {
-			return WorkflowStartErrorMsg{Err: fmt.Errorf("failed to start harness workflow: %w", err)}
-		}
 
-		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
-			WorkflowID: harnessID,
-			UpdateName: workflow.UpdateStartSession,
-			Args: []interface{}{workflow.StartSessionRequest{
-				UserMessage: config.Message,
-				// Pass per-invocation overrides so each session gets its own
-				// model/approval/sandbox config, even when multiple tcx processes
-				// share the same long-lived HarnessWorkflow.
-				OverrideConfig: &workflow.CLIOverrides{
-					Provider:           config.Provider,
-					Model:              config.Model,
-					Permissions:        config.Permissions,
-					DisableSuggestions: config.DisableSuggestions,
-					MemoryEnabled:      config.MemoryEnabled,
-					MemoryDbPath:       config.MemoryDbPath,
-					Cwd:                cwd,
-				},
-				CrewName:   config.CrewName,
-				CrewInputs: config.CrewInputs,
-				CrewType:   config.CrewType,
-			}},
-			WaitForStage: client.WorkflowUpdateStageCompleted,
+		updateHandle, err := c.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+			StartWorkflowOperation: startOp,
+			UpdateOptions: client.UpdateWorkflowOptions{
+				WorkflowID: harnessID,
+				UpdateName: workflow.UpdateStartSession,
+				Args: []interface{}{workflow.StartSessionRequest{
+					UserMessage: config.Message,
+					// Pass per-invocation overrides so each session gets its own
+					// model/approval/sandbox config, even when multiple tcx processes
+					// share the same long-lived HarnessWorkflow.
+					OverrideConfig: &workflow.CLIOverrides{
+						Provider:           config.Provider,
+						Model:              config.Model,
+						Permissions:        config.Permissions,
+						DisableSuggestions: config.DisableSuggestions,
+						MemoryEnabled:      config.MemoryEnabled,
+						MemoryDbPath:       config.MemoryDbPath,
+						EnableTools:        config.EnableTools,
+						Cwd:                cwd,
+					},
+					CrewName:   config.CrewName,
+					CrewInputs: config.CrewInputs,
+					CrewType:   config.CrewType,
+				}},
+				WaitForStage: client.WorkflowUpdateStageCompleted,
+			},
 		})
 		if err != nil {
-			return WorkflowStartErrorMsg{Err: fmt.Errorf("failed to send start_session update: %w", err)}
+			return WorkflowStartErrorMsg{Err: fmt.Errorf("failed to start harness workflow with start_session update: %w", err)}
 		}
 
 		var resp workflow.StartSessionResponse
@@ -110,7 +126,7 @@ func startWorkflowCmd(c client.Client, config Config) tea.Cmd {
 func resumeWorkflowCmd(c client.Client, workflowID string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		poller := NewPoller(c, workflowID, 0)
+		poller := watch.NewPoller(c, workflowID, 0)
 		result := poller.Poll(ctx)
 		if result.Err != nil {
 			return WorkflowStartErrorMsg{Err: fmt.Errorf("failed to query workflow: %w", result.Err)}
@@ -127,14 +143,18 @@ func resumeWorkflowCmd(c client.Client, workflowID string) tea.Cmd {
 
 // sendUserInputCmd sends user input to the workflow.
 func sendUserInputCmd(c client.Client, workflowID, content string) tea.Cmd {
+	// One key per message: the workflow acks a redelivery with the
+	// original turn instead of starting a duplicate one.
+	key := uuid.NewString()
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			UpdateID:     key,
 			WorkflowID:   workflowID,
 			UpdateName:   workflow.UpdateUserInput,
-			Args:         []interface{}{workflow.UserInput{Content: content}},
+			Args:         []interface{}{workflow.UserInput{Content: content, IdempotencyKey: key}},
 			WaitForStage: client.WorkflowUpdateStageCompleted,
 		})
 		if err != nil {
@@ -175,6 +195,31 @@ func sendInterruptCmd(c client.Client, workflowID string) tea.Cmd {
 	}
 }
 
+// sendCancelToolCmd cancels one in-flight tool call.
+func sendCancelToolCmd(c client.Client, workflowID, callID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateCancelTool,
+			Args:         []interface{}{workflow.CancelToolRequest{CallID: callID}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return CancelToolErrorMsg{Err: err}
+		}
+
+		var resp workflow.CancelToolResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return CancelToolErrorMsg{Err: err}
+		}
+
+		return CancelToolSentMsg{ToolName: resp.ToolName}
+	}
+}
+
 // sendShutdownCmd sends a shutdown signal to the workflow.
 func sendShutdownCmd(c client.Client, workflowID string) tea.Cmd {
 	return func() tea.Msg {
@@ -275,6 +320,31 @@ func sendUserInputQuestionResponseCmd(c client.Client, workflowID string, resp w
 	}
 }
 
+// sendAskUserResponseCmd sends the user's answer to an ask_user question.
+func sendAskUserResponseCmd(c client.Client, workflowID string, resp workflow.AskUserResponse) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateAskUserResponse,
+			Args:         []interface{}{resp},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return AskUserErrorMsg{Err: err}
+		}
+
+		var ack workflow.AskUserResponseAck
+		if err := updateHandle.Get(ctx, &ack); err != nil {
+			return AskUserErrorMsg{Err: err}
+		}
+
+		return AskUserSentMsg{}
+	}
+}
+
 // sendCompactCmd sends a compact request to the workflow.
 func sendCompactCmd(c client.Client, workflowID string) tea.Cmd {
 	return func() tea.Msg {
@@ -378,6 +448,7 @@ func startNewSessionCmd(c client.Client, harnessID, message string, config Confi
 					DisableSuggestions: config.DisableSuggestions,
 					MemoryEnabled:      config.MemoryEnabled,
 					MemoryDbPath:       config.MemoryDbPath,
+					EnableTools:        config.EnableTools,
 					Cwd:                cwd,
 				},
 				CrewName:   config.CrewName,
@@ -469,6 +540,63 @@ func queryMcpToolsCmd(c client.Client, workflowID string) tea.Cmd {
 	}
 }
 
+// searchHistoryCmd runs a get_history query for /search.
+func searchHistoryCmd(c client.Client, workflowID string, q workflow.HistoryQuery) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := c.QueryWorkflow(ctx, workflowID, "", workflow.QueryGetHistory, q)
+		if err != nil {
+			return SearchErrorMsg{Err: err}
+		}
+
+		var page workflow.HistoryPage
+		if err := resp.Get(&page); err != nil {
+			return SearchErrorMsg{Err: err}
+		}
+
+		return SearchResultMsg{Query: q, Page: page}
+	}
+}
+
+// queryCapabilitiesCmd queries the workflow for the capabilities of the
+// worker serving it. With warnOnly (the check made when a session attaches)
+// it waits for the worker to be described and drops errors, so a session on
+// an older worker attaches quietly.
+func queryCapabilitiesCmd(c client.Client, workflowID string, warnOnly bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for attempt := 0; ; attempt++ {
+			resp, err := c.QueryWorkflow(ctx, workflowID, "", workflow.QueryGetCapabilities)
+			if err == nil {
+				var caps workflow.CapabilitiesResponse
+				if err := resp.Get(&caps); err != nil {
+					if warnOnly {
+						return nil
+					}
+					return CapabilitiesErrorMsg{Err: err}
+				}
+				return CapabilitiesResultMsg{Caps: caps, WarnOnly: warnOnly}
+			}
+			if !warnOnly {
+				return CapabilitiesErrorMsg{Err: err}
+			}
+			// A new session describes its worker shortly after starting.
+			if attempt >= 5 || !strings.Contains(err.Error(), "not known yet") {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
+}
+
 // queryExecSessionsCmd sends a list_exec_sessions Update to the workflow.
 func queryExecSessionsCmd(c client.Client, workflowID string) tea.Cmd {
 	return func() tea.Msg {
@@ -519,6 +647,384 @@ func cleanExecSessionsCmd(c client.Client, workflowID string) tea.Cmd {
 	}
 }
 
+// writeExecStdinCmd sends a write_exec_stdin Update to the workflow.
+func writeExecStdinCmd(c client.Client, workflowID string, req workflow.WriteExecStdinRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateWriteExecStdin,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return WriteExecStdinErrorMsg{Err: err}
+		}
+
+		var resp workflow.WriteExecStdinResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return WriteExecStdinErrorMsg{Err: err}
+		}
+
+		return WriteExecStdinResultMsg{ProcessID: req.ProcessID, Output: resp.Output, Exited: resp.Exited}
+	}
+}
+
+// updateTaskListCmd sends an update_task_list Update to the workflow.
+func updateTaskListCmd(c client.Client, workflowID string, req workflow.UpdateTaskListRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateTaskList,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return TaskListErrorMsg{Err: err}
+		}
+
+		var resp workflow.UpdateTaskListResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return TaskListErrorMsg{Err: err}
+		}
+
+		return TaskListResultMsg{Tasks: resp.Tasks}
+	}
+}
+
+// updateTrustedCommandsCmd sends an update_trusted_commands Update to the workflow.
+func updateTrustedCommandsCmd(c client.Client, workflowID string, req workflow.UpdateTrustedCommandsRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateTrustedCommands,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return TrustErrorMsg{Err: err}
+		}
+
+		var resp workflow.UpdateTrustedCommandsResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return TrustErrorMsg{Err: err}
+		}
+
+		return TrustResultMsg{Commands: resp.Commands, Revoked: req.Action == workflow.TrustRevoke}
+	}
+}
+
+// queryContextCmd queries the workflow for what the next LLM call sends.
+func queryContextCmd(c client.Client, workflowID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := c.QueryWorkflow(ctx, workflowID, "", workflow.QueryGetContext)
+		if err != nil {
+			return ContextErrorMsg{Err: err}
+		}
+
+		var view workflow.ContextView
+		if err := resp.Get(&view); err != nil {
+			return ContextErrorMsg{Err: err}
+		}
+
+		return ContextResultMsg{View: view}
+	}
+}
+
+// editContextCmd sends a drop_context_items or summarize_context_items
+// Update (named by req) to the workflow. Summarizing runs an LLM call, so
+// the timeout is long.
+func editContextCmd(c client.Client, workflowID string, req contextRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   req.UpdateName,
+			Args:         []interface{}{workflow.ContextEditRequest{Seqs: req.Seqs}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return ContextErrorMsg{Err: err}
+		}
+
+		var resp workflow.ContextEditResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return ContextErrorMsg{Err: err}
+		}
+
+		return ContextResultMsg{View: resp.View, Edit: req.UpdateName, Removed: resp.Removed}
+	}
+}
+
+// pinItemCmd sends a pin_item Update to the workflow.
+func pinItemCmd(c client.Client, workflowID string, req workflow.PinItemRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdatePinItem,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return PinErrorMsg{Err: err}
+		}
+
+		var resp workflow.PinItemResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return PinErrorMsg{Err: err}
+		}
+
+		return PinResultMsg{Items: resp.Items, Action: req.Action, Seq: req.Seq}
+	}
+}
+
+// annotateItemCmd sends an annotate_item Update to the workflow.
+func annotateItemCmd(c client.Client, workflowID string, req workflow.AnnotateItemRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateAnnotateItem,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return AnnotateErrorMsg{Err: err}
+		}
+
+		var resp workflow.AnnotateItemResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return AnnotateErrorMsg{Err: err}
+		}
+
+		return AnnotateResultMsg{TargetSeq: resp.TargetSeq}
+	}
+}
+
+// pauseSessionCmd sends a pause Update (pause) or resume Update (!pause).
+func pauseSessionCmd(c client.Client, workflowID string, pause bool, reason string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		opts := client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateResume,
+			Args:         []interface{}{workflow.ResumeRequest{}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		}
+		if pause {
+			opts.UpdateName = workflow.UpdatePause
+			opts.Args = []interface{}{workflow.PauseRequest{Reason: reason}}
+		}
+		updateHandle, err := c.UpdateWorkflow(ctx, opts)
+		if err != nil {
+			return PauseErrorMsg{Err: err}
+		}
+
+		var resp workflow.PauseResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return PauseErrorMsg{Err: err}
+		}
+		return PauseResultMsg{Response: resp}
+	}
+}
+
+// remapCwdCmd sends a remap_cwd Update, moving the session to another
+// checkout of its repository.
+func remapCwdCmd(c client.Client, workflowID, cwd string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateRemapCwd,
+			Args:         []interface{}{workflow.RemapCwdRequest{Cwd: cwd}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return RemapCwdErrorMsg{Err: err}
+		}
+
+		var resp workflow.RemapCwdResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return RemapCwdErrorMsg{Err: err}
+		}
+		return RemapCwdResultMsg{Response: resp}
+	}
+}
+
+// userShellCmd sends a user_shell Update, running command on the session's
+// worker without the model.
+func userShellCmd(c client.Client, workflowID, command string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateUserShell,
+			Args:         []interface{}{workflow.UserShellRequest{Command: command}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return UserShellErrorMsg{Err: err}
+		}
+
+		var resp workflow.UserShellResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return UserShellErrorMsg{Err: err}
+		}
+		return UserShellResultMsg{Response: resp}
+	}
+}
+
+// consultCmd sends a consult Update, asking the session's consult models
+// question (empty: the last question) in parallel.
+func consultCmd(c client.Client, workflowID, question string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateConsult,
+			Args:         []interface{}{workflow.ConsultRequest{Question: question}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return ConsultErrorMsg{Err: err}
+		}
+
+		var resp workflow.ConsultResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return ConsultErrorMsg{Err: err}
+		}
+		return ConsultResultMsg{Response: resp}
+	}
+}
+
+// adoptConsultCmd sends an adopt_consult Update, recording the picked
+// /consult answer in history.
+func adoptConsultCmd(c client.Client, workflowID string, req workflow.AdoptConsultRequest, model string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateAdoptConsult,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return ConsultAdoptErrorMsg{Err: err}
+		}
+
+		var resp workflow.AdoptConsultResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return ConsultAdoptErrorMsg{Err: err}
+		}
+		return ConsultAdoptedMsg{Model: model, Mode: req.Mode}
+	}
+}
+
+// snapshotWorkspaceCmd sends a snapshot_workspace Update to the workflow.
+func snapshotWorkspaceCmd(c client.Client, workflowID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateSnapshotWorkspace,
+			Args:         []interface{}{workflow.SnapshotWorkspaceRequest{}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return SnapshotWorkspaceErrorMsg{Err: err}
+		}
+
+		var resp workflow.SnapshotWorkspaceResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return SnapshotWorkspaceErrorMsg{Err: err}
+		}
+
+		return SnapshotWorkspaceResultMsg{Snapshot: resp.Snapshot}
+	}
+}
+
+// rollbackWorkspaceCmd sends a rollback_workspace Update to the workflow.
+// An empty snapshotID restores the most recent snapshot.
+func rollbackWorkspaceCmd(c client.Client, workflowID, snapshotID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateRollbackWorkspace,
+			Args:         []interface{}{workflow.RollbackWorkspaceRequest{SnapshotID: snapshotID}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return RollbackWorkspaceErrorMsg{Err: err}
+		}
+
+		var resp workflow.RollbackWorkspaceResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return RollbackWorkspaceErrorMsg{Err: err}
+		}
+
+		return RollbackWorkspaceResultMsg{Response: resp}
+	}
+}
+
+// importContextCmd sends an import_context Update to the workflow. The
+// summarization LLM call can take a while, hence the long timeout.
+func importContextCmd(c client.Client, workflowID, sourceWorkflowID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateImportContext,
+			Args:         []interface{}{workflow.ImportContextRequest{WorkflowID: sourceWorkflowID}},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return ImportContextErrorMsg{Err: err}
+		}
+
+		var resp workflow.ImportContextResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return ImportContextErrorMsg{Err: err}
+		}
+
+		return ImportContextResultMsg{Response: resp}
+	}
+}
+
 // queryChildConversationItems queries a child workflow's conversation items
 // and extracts the last assistant message (the plan text).
 func queryChildConversationItems(c client.Client, childWorkflowID string) tea.Cmd {
@@ -576,7 +1082,9 @@ func fetchModelsCmd() tea.Cmd {
 
 // fetchSessionsCmd lists sessions for the session picker via the Temporal
 // visibility API. This is fast and works even without a running harness.
-func fetchSessionsCmd(c client.Client, harnessID string) tea.Cmd {
+// Titles, tags, notes and pauses are decoded from the workflow memo with dc (nil
+// means the SDK default converter).
+func fetchSessionsCmd(c client.Client, dc converter.DataConverter, harnessID string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -598,10 +1106,15 @@ func fetchSessionsCmd(c client.Client, harnessID string) tea.Cmd {
 			if exec.GetExecution() == nil {
 				continue
 			}
+			tags, note := workflow.SessionMetadataFromMemo(exec.GetMemo(), dc)
 			entries = append(entries, SessionListEntry{
 				WorkflowID: exec.GetExecution().GetWorkflowId(),
 				StartTime:  exec.GetStartTime().AsTime(),
 				Status:     mapWorkflowStatus(exec.GetStatus()),
+				Title:      workflow.SessionTitleFromMemo(exec.GetMemo(), dc),
+				Tags:       tags,
+				Note:       note,
+				Paused:     workflow.SessionPauseFromMemo(exec.GetMemo(), dc) != nil,
 			})
 		}
 		return HarnessSessionsListMsg{Entries: entries}
@@ -690,6 +1203,56 @@ func sendToggleSkillCmd(c client.Client, workflowID, skillPath string, enabled b
 	}
 }
 
+// updateModelConfigCmd sends an update_model_config Update to the workflow.
+func updateModelConfigCmd(c client.Client, workflowID string, req workflow.UpdateModelConfigRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateModelConfig,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return ModelConfigErrorMsg{Err: err}
+		}
+
+		var resp workflow.UpdateModelConfigResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return ModelConfigErrorMsg{Err: err}
+		}
+
+		return ModelConfigResultMsg{Response: resp, Changed: !req.Params.IsEmpty()}
+	}
+}
+
+// updateEnvCmd sends an update_env Update to the workflow.
+func updateEnvCmd(c client.Client, workflowID string, req workflow.UpdateEnvRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		updateHandle, err := c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+			WorkflowID:   workflowID,
+			UpdateName:   workflow.UpdateEnv,
+			Args:         []interface{}{req},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err != nil {
+			return EnvErrorMsg{Err: err}
+		}
+
+		var resp workflow.UpdateEnvResponse
+		if err := updateHandle.Get(ctx, &resp); err != nil {
+			return EnvErrorMsg{Err: err}
+		}
+
+		return EnvResultMsg{Env: resp.Env, Changed: len(req.Set) > 0 || len(req.Unset) > 0}
+	}
+}
+
 // sendUpdateReasoningEffortCmd sends an update_reasoning_effort Update to the workflow.
 func sendUpdateReasoningEffortCmd(c client.Client, workflowID, effort string) tea.Cmd {
 	return func() tea.Msg {
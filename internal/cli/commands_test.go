@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	enums "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// jsonValue is a converter.EncodedValue holding v.
+type jsonValue struct{ v interface{} }
+
+func (j jsonValue) HasValue() bool { return j.v != nil }
+
+func (j jsonValue) Get(ptr interface{}) error {
+	data, err := json.Marshal(j.v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, ptr)
+}
+
+// startOperation is the start half of an Update-with-Start.
+type startOperation struct {
+	client.WithStartWorkflowOperation
+	opts client.StartWorkflowOptions
+}
+
+// startClient records the Update-with-Start it is sent and answers
+// start_session.
+type startClient struct {
+	client.Client
+	start  client.StartWorkflowOptions
+	update client.UpdateWorkflowOptions
+}
+
+func (c *startClient) NewWithStartWorkflowOperation(opts client.StartWorkflowOptions, _ interface{}, _ ...interface{}) client.WithStartWorkflowOperation {
+	return &startOperation{opts: opts}
+}
+
+func (c *startClient) UpdateWithStartWorkflow(_ context.Context, opts client.UpdateWithStartWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	c.start = opts.StartWorkflowOperation.(*startOperation).opts
+	c.update = opts.UpdateOptions
+	return startSessionHandle{workflow.StartSessionResponse{SessionWorkflowID: "harness-1/sess-1/main"}}, nil
+}
+
+type startSessionHandle struct{ resp workflow.StartSessionResponse }
+
+func (h startSessionHandle) WorkflowID() string { return "harness-1" }
+func (h startSessionHandle) RunID() string      { return "" }
+func (h startSessionHandle) UpdateID() string   { return "" }
+func (h startSessionHandle) Get(_ context.Context, ptr interface{}) error {
+	return jsonValue{h.resp}.Get(ptr)
+}
+
+func TestStartWorkflowCmd_UpdateWithStart(t *testing.T) {
+	t.Setenv("TCX_HARNESS_ID", "harness-1")
+	c := &startClient{}
+
+	msg := startWorkflowCmd(c, Config{Message: "Fix the build", Model: "gpt-4o", Cwd: "/src/repo"})()
+
+	assert.Equal(t, WorkflowStartedMsg{WorkflowID: "harness-1/sess-1/main"}, msg)
+	assert.Equal(t, "harness-1", c.start.ID)
+	assert.Equal(t, enums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING, c.start.WorkflowIDConflictPolicy,
+		"a running harness takes the session")
+	assert.Equal(t, workflow.UpdateStartSession, c.update.UpdateName)
+	require.Len(t, c.update.Args, 1)
+	req := c.update.Args[0].(workflow.StartSessionRequest)
+	assert.Equal(t, "Fix the build", req.UserMessage)
+	assert.Equal(t, "gpt-4o", req.OverrideConfig.Model)
+}
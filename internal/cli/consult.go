@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// minConsultColumnWidth is the narrowest column /consult answers are shown
+// side by side in; narrower terminals stack them.
+const minConsultColumnWidth = 32
+
+// consultChoice is what one /consult selector option does. A nil choice
+// discards the answers.
+type consultChoice struct {
+	Index int
+	Mode  workflow.ConsultAdoptMode
+}
+
+// RenderConsultAnswers renders the /consult answers side by side, one
+// column per model, or stacked when the terminal is too narrow.
+func (r *ItemRenderer) RenderConsultAnswers(resp workflow.ConsultResponse) string {
+	width := r.width
+	if width <= 0 {
+		width = 80
+	}
+	n := len(resp.Answers)
+	colWidth := (width - 2 - 3*(n-1)) / max(n, 1)
+	sideBySide := n > 1 && colWidth >= minConsultColumnWidth
+	if !sideBySide {
+		colWidth = width - 2
+	}
+
+	columns := make([]string, n)
+	for i, a := range resp.Answers {
+		var b strings.Builder
+		b.WriteString(r.styles.ApprovalIndex.Render(fmt.Sprintf("[%d]", i+1)) + " " + r.styles.ApprovalTool.Render(a.Model) + "\n")
+		meta := fmt.Sprintf("%s · %s", a.Provider, (time.Duration(a.DurationMs) * time.Millisecond).Round(100*time.Millisecond))
+		if a.Tokens > 0 {
+			meta += fmt.Sprintf(" · %d tokens", a.Tokens)
+		}
+		b.WriteString(r.styles.OutputDim.Render(meta) + "\n\n")
+		if a.Error != "" {
+			b.WriteString(r.styles.OutputFailure.Render("No answer: " + a.Error))
+		} else {
+			b.WriteString(strings.TrimSpace(a.Content))
+		}
+		columns[i] = lipgloss.NewStyle().Width(colWidth).Render(b.String())
+	}
+
+	var out strings.Builder
+	out.WriteString("\n")
+	out.WriteString(r.RenderSystemMessage(r.t("Answers to: %s", transcript.TruncateString(firstLine(resp.Question), max(20, width-16)))))
+	out.WriteString("\n")
+	if sideBySide {
+		height := lipgloss.Height(lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+		rule := " " + r.styles.OutputPrefix.Render("│") + " "
+		sep := strings.TrimSuffix(strings.Repeat(rule+"\n", height), "\n")
+		var parts []string
+		for i, col := range columns {
+			if i > 0 {
+				parts = append(parts, sep)
+			}
+			parts = append(parts, col)
+		}
+		out.WriteString(indentBlock(lipgloss.JoinHorizontal(lipgloss.Top, parts...), "  "))
+	} else {
+		for i, col := range columns {
+			if i > 0 {
+				out.WriteString("\n\n")
+			}
+			out.WriteString(indentBlock(col, "  "))
+		}
+	}
+	out.WriteString("\n\n")
+	return out.String()
+}
+
+// consultSelectorOptions lists, for each model that answered, adopting its
+// answer as the assistant's answer or as context, then discarding them all.
+func consultSelectorOptions(resp workflow.ConsultResponse) ([]SelectorOption, []*consultChoice) {
+	var opts []SelectorOption
+	var choices []*consultChoice
+	for i, a := range resp.Answers {
+		if a.Error != "" {
+			continue
+		}
+		opts = append(opts,
+			SelectorOption{Label: fmt.Sprintf("Use [%d] %s as the answer", i+1, a.Model)},
+			SelectorOption{Label: fmt.Sprintf("Add [%d] %s as context for the next turn", i+1, a.Model)},
+		)
+		choices = append(choices,
+			&consultChoice{Index: i, Mode: workflow.ConsultAdoptAnswer},
+			&consultChoice{Index: i, Mode: workflow.ConsultAdoptContext},
+		)
+	}
+	opts = append(opts, SelectorOption{Label: "Discard the answers"})
+	choices = append(choices, nil)
+	return opts, choices
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// indentBlock prefixes every line of s with indent.
+func indentBlock(s, indent string) string {
+	return indent + strings.ReplaceAll(s, "\n", "\n"+indent)
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func testConsultResponse() workflow.ConsultResponse {
+	return workflow.ConsultResponse{
+		ConsultID: "consult-1",
+		Question:  "Postgres or SQLite?",
+		Answers: []workflow.ConsultAnswer{
+			{Model: "gpt-4o", Provider: "openai", Content: "SQLite for now.", Tokens: 120, DurationMs: 2300},
+			{Model: "claude-sonnet-4-5", Provider: "anthropic", Error: "rate limited"},
+		},
+	}
+}
+
+func TestRenderConsultAnswers_SideBySide(t *testing.T) {
+	out := stripANSI(newTestRenderer().RenderConsultAnswers(testConsultResponse()))
+
+	assert.Contains(t, out, "Answers to: Postgres or SQLite?")
+	var row string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "[1] gpt-4o") {
+			row = line
+		}
+	}
+	assert.Contains(t, row, "[2] claude-sonnet-4-5", "answers share rows")
+	assert.Contains(t, out, "openai · 2.3s · 120 tokens")
+	assert.Contains(t, out, "No answer: rate limited")
+}
+
+func TestRenderConsultAnswers_StacksWhenNarrow(t *testing.T) {
+	r := NewItemRenderer(50, true, true, NoColorStyles())
+	out := stripANSI(r.RenderConsultAnswers(testConsultResponse()))
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "[1] gpt-4o") {
+			assert.NotContains(t, line, "claude-sonnet-4-5")
+		}
+	}
+	assert.Contains(t, out, "[2] claude-sonnet-4-5")
+}
+
+func TestConsultSelectorOptions(t *testing.T) {
+	opts, choices := consultSelectorOptions(testConsultResponse())
+
+	require.Len(t, opts, 3, "failed models are not offered")
+	require.Len(t, choices, 3)
+	assert.Equal(t, "Use [1] gpt-4o as the answer", opts[0].Label)
+	assert.Equal(t, &consultChoice{Index: 0, Mode: workflow.ConsultAdoptAnswer}, choices[0])
+	assert.Equal(t, &consultChoice{Index: 0, Mode: workflow.ConsultAdoptContext}, choices[1])
+	assert.Equal(t, "Discard the answers", opts[2].Label)
+	assert.Nil(t, choices[2])
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const contextUsage = "Usage: /context [drop|summarize <seq>...]  (seqs and ranges, e.g. 4 7-9)"
+
+// contextRequest is a parsed /context command. An empty UpdateName shows
+// the context; otherwise it names the Update that edits it.
+type contextRequest struct {
+	UpdateName string
+	Seqs       []int
+}
+
+// parseContextCommand parses the arguments of /context.
+func parseContextCommand(args string) (contextRequest, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return contextRequest{}, nil
+	}
+	var req contextRequest
+	switch fields[0] {
+	case "drop":
+		req.UpdateName = workflow.UpdateDropContextItems
+	case "summarize":
+		req.UpdateName = workflow.UpdateSummarizeContextItems
+	default:
+		return contextRequest{}, fmt.Errorf("%s", contextUsage)
+	}
+	seqs, err := parseSeqs(fields[1:])
+	if err != nil || len(seqs) == 0 {
+		return contextRequest{}, fmt.Errorf("%s", contextUsage)
+	}
+	req.Seqs = seqs
+	return req, nil
+}
+
+// parseSeqs parses item numbers ("4", "#4") and inclusive ranges ("7-9").
+func parseSeqs(fields []string) ([]int, error) {
+	var seqs []int
+	for _, field := range fields {
+		for _, part := range strings.Split(field, ",") {
+			if part == "" {
+				continue
+			}
+			from, to, isRange := strings.Cut(strings.TrimPrefix(part, "#"), "-")
+			first, err := strconv.Atoi(from)
+			if err != nil || first < 0 {
+				return nil, fmt.Errorf("invalid item number %q", part)
+			}
+			last := first
+			if isRange {
+				if last, err = strconv.Atoi(strings.TrimPrefix(to, "#")); err != nil || last < first {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			}
+			for seq := first; seq <= last; seq++ {
+				seqs = append(seqs, seq)
+			}
+		}
+	}
+	return seqs, nil
+}
+
+// formatContextDisplay formats the /context listing: the prompt's sections
+// with token estimates, then the history items by user turn, each with the
+// seq to drop or summarize it by.
+func formatContextDisplay(view workflow.ContextView) string {
+	var b strings.Builder
+	window := ""
+	if view.ContextWindow > 0 {
+		window = " of " + formatTokens(view.ContextWindow)
+	}
+	b.WriteString(fmt.Sprintf("Context for the next call to %s (~%s%s tokens)\n", view.Model, formatTokens(view.TotalTokens), window))
+	b.WriteString("─────────────────\n")
+	for _, section := range view.Sections {
+		name := section.Name
+		if section.Items > 0 {
+			name = fmt.Sprintf("%s (%d)", name, section.Items)
+		}
+		b.WriteString(fmt.Sprintf("  %-28s %8s\n", name, formatTokens(section.Tokens)))
+	}
+
+	for _, block := range view.Blocks {
+		title := "Before the first message"
+		if block.Title != "" {
+			title = "Turn: " + block.Title
+		}
+		b.WriteString(fmt.Sprintf("\n%s  (%d items, %s tokens)\n", title, len(block.Items), formatTokens(block.Tokens)))
+		for _, item := range block.Items {
+			mark := "  "
+			if item.Pinned {
+				mark = pinGlyph
+			}
+			label := strings.TrimSuffix(string(item.Type), "_message")
+			switch {
+			case item.Name != "" && item.Type == models.ItemTypeFunctionCall:
+				label = item.Name
+			case item.Name != "":
+				label = item.Name + " out"
+			}
+			b.WriteString(fmt.Sprintf("  %s #%-4d %-14s %7s  %s\n", mark, item.Seq, truncateProgress(label, 14), formatTokens(item.Tokens), item.Preview))
+		}
+	}
+	b.WriteString("Drop items with /context drop <seq>..., or replace them with a summary with /context summarize <seq>...\n")
+	b.WriteString("A tool call and its output go together; pinned items are kept.\n")
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseContextCommand(t *testing.T) {
+	req, err := parseContextCommand(" ")
+	require.NoError(t, err)
+	assert.Equal(t, contextRequest{}, req)
+
+	req, err = parseContextCommand("drop #4 7-9,12")
+	require.NoError(t, err)
+	assert.Equal(t, contextRequest{UpdateName: workflow.UpdateDropContextItems, Seqs: []int{4, 7, 8, 9, 12}}, req)
+
+	req, err = parseContextCommand("summarize 3-5")
+	require.NoError(t, err)
+	assert.Equal(t, contextRequest{UpdateName: workflow.UpdateSummarizeContextItems, Seqs: []int{3, 4, 5}}, req)
+
+	for _, bad := range []string{"drop", "drop x", "drop 9-3", "summarize -1", "clear 3"} {
+		_, err := parseContextCommand(bad)
+		assert.ErrorContains(t, err, "Usage: /context", bad)
+	}
+}
+
+func TestFormatContextDisplay(t *testing.T) {
+	result := formatContextDisplay(workflow.ContextView{
+		Model:         "gpt-4o",
+		ContextWindow: 128000,
+		TotalTokens:   5230,
+		Sections: []workflow.ContextSection{
+			{Name: "Base instructions", Tokens: 2100},
+			{Name: "Tools", Items: 24, Tokens: 3000},
+			{Name: "History", Items: 3, Tokens: 130},
+		},
+		Blocks: []workflow.ContextBlock{{
+			Title:  "Fix the build",
+			Tokens: 130,
+			Items: []workflow.ContextItem{
+				{Seq: 2, Type: models.ItemTypeUserMessage, Preview: "Fix the build", Tokens: 5, Pinned: true},
+				{Seq: 3, Type: models.ItemTypeFunctionCall, Name: "shell", Preview: `{"command":"go build"}`, Tokens: 25},
+				{Seq: 4, Type: models.ItemTypeFunctionCallOutput, Name: "shell", Preview: "ok", Tokens: 100},
+			},
+		}},
+	})
+	assert.Contains(t, result, "Context for the next call to gpt-4o (~5,230 of 128,000 tokens)")
+	assert.Regexp(t, `Tools \(24\)\s+3,000`, result)
+	assert.Contains(t, result, "Turn: Fix the build  (3 items, 130 tokens)")
+	assert.Regexp(t, `📌 #2\s+user\s+5  Fix the build`, result)
+	assert.Regexp(t, `#3\s+shell\s+25  \{"command":"go build"\}`, result)
+	assert.Regexp(t, `#4\s+shell out\s+100  ok`, result)
+	assert.Contains(t, result, "/context drop <seq>")
+}
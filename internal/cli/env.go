@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const envUsage = "Usage: /env [<NAME>=<value> | unset <NAME>...]"
+
+// parseEnvCommand parses the arguments of /env into an update_env request.
+// No arguments shows the current variables. Everything after the first "="
+// is the value, so it may contain spaces.
+func parseEnvCommand(args string) (workflow.UpdateEnvRequest, error) {
+	var req workflow.UpdateEnvRequest
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return req, nil
+	}
+	if fields := strings.Fields(args); fields[0] == "unset" {
+		if len(fields) == 1 {
+			return req, fmt.Errorf("%s", envUsage)
+		}
+		req.Unset = fields[1:]
+		return req, nil
+	}
+	name, value, ok := strings.Cut(args, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return req, fmt.Errorf("%s", envUsage)
+	}
+	req.Set = map[string]string{name: value}
+	return req, nil
+}
+
+// formatEnvDisplay formats the /env panel.
+func formatEnvDisplay(env map[string]string) string {
+	var b strings.Builder
+	b.WriteString("Session environment\n")
+	b.WriteString("────────────────\n")
+	if len(env) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("  %s=%s\n", name, env[name]))
+	}
+	b.WriteString("Set with /env NAME=value, remove with /env unset NAME. Applies to shell and exec tools from the next call.\n")
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvCommand(t *testing.T) {
+	req, err := parseEnvCommand("")
+	require.NoError(t, err)
+	assert.Empty(t, req.Set)
+	assert.Empty(t, req.Unset)
+
+	req, err = parseEnvCommand(" GOFLAGS=-count=1 -race")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"GOFLAGS": "-count=1 -race"}, req.Set)
+
+	req, err = parseEnvCommand("unset GOFLAGS DATABASE_URL")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GOFLAGS", "DATABASE_URL"}, req.Unset)
+
+	for _, args := range []string{"GOFLAGS", "unset", "=x", "MY VAR=x"} {
+		_, err = parseEnvCommand(args)
+		assert.ErrorContains(t, err, "Usage: /env", args)
+	}
+}
+
+func TestFormatEnvDisplay(t *testing.T) {
+	out := formatEnvDisplay(map[string]string{"GOFLAGS": "-count=1", "DATABASE_URL": "postgres://localhost/test"})
+	assert.Contains(t, out, "  DATABASE_URL=postgres://localhost/test\n  GOFLAGS=-count=1\n")
+	assert.Contains(t, formatEnvDisplay(nil), "(none)")
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// defaultFoldLines is how tall a rendered item may be before it is shown
+// collapsed.
+const defaultFoldLines = 40
+
+// foldHint tells the user how to expand a collapsed item.
+const foldHint = "press o to expand"
+
+// viewportBlock is one piece of the viewport content. A block that folds
+// keeps both renderings; the other blocks only have text.
+type viewportBlock struct {
+	text     string // shown when the block does not fold or is collapsed
+	expanded string // full rendering; empty when the block does not fold
+	open     bool   // expanded, or collapsed while /expandall is on
+
+	// The item the block renders, so /theme can render it again; nil for
+	// other text such as system notes.
+	item     *models.ConversationItem
+	isResume bool
+}
+
+// foldable reports whether the block has a collapsed and an expanded form.
+func (b viewportBlock) foldable() bool {
+	return b.expanded != ""
+}
+
+// view returns the block's rendering; expandAll inverts its fold state.
+func (b viewportBlock) view(expandAll bool) string {
+	if b.foldable() && b.open != expandAll {
+		return b.expanded
+	}
+	return b.text
+}
+
+// RenderFolded returns the first limit lines of rendered followed by a
+// "… (+N lines, press o to expand)" line, or "" when rendered fits.
+func (r *ItemRenderer) RenderFolded(rendered string, limit int) string {
+	lines := strings.Split(strings.TrimRight(rendered, "\n"), "\n")
+	if limit <= 0 || len(lines) <= limit {
+		return ""
+	}
+	hint := fmt.Sprintf("… (+%d lines, %s)", len(lines)-limit, foldHint)
+	return strings.Join(lines[:limit], "\n") + "\n" + r.styles.OutputDim.Render(hint) + "\n"
+}
+
+// foldLines returns the configured fold height; negative disables folding.
+func (m *Model) foldLines() int {
+	if m.config.FoldLines == 0 {
+		return defaultFoldLines
+	}
+	return m.config.FoldLines
+}
+
+// appendItem renders item into the viewport, collapsed when it is taller
+// than the fold height or its tool output is cut to a preview.
+func (m *Model) appendItem(item models.ConversationItem, isResume bool) {
+	if block, ok := m.renderer.itemBlock(item, isResume, m.foldLines()); ok {
+		block.item, block.isResume = &item, isResume
+		m.appendBlock(block)
+	}
+}
+
+// itemBlock renders item as a viewport block, foldable when it is taller
+// than foldLines or its tool output is cut to a preview. ok is false when
+// the item has no visible output.
+func (r *ItemRenderer) itemBlock(item models.ConversationItem, isResume bool, foldLines int) (block viewportBlock, ok bool) {
+	collapsed := r.RenderItem(item, isResume)
+	if collapsed == "" {
+		return viewportBlock{}, false
+	}
+	block = viewportBlock{text: collapsed}
+	if expanded := r.RenderItemExpanded(item, isResume); expanded != collapsed {
+		block.expanded = expanded
+	} else if folded := r.RenderFolded(collapsed, foldLines); folded != "" {
+		block = viewportBlock{text: folded, expanded: collapsed}
+	}
+	return block, true
+}
+
+// foldTarget returns the index of the block that o toggles: the last
+// foldable block starting above the bottom of the viewport, or -1.
+func (m *Model) foldTarget() int {
+	return foldTargetIn(m.blocks, m.expandAll, m.viewport.YOffset+m.viewport.Height)
+}
+
+// foldTargetIn returns the last foldable block starting above line bottom
+// of the joined blocks, or -1.
+func foldTargetIn(blocks []viewportBlock, expandAll bool, bottom int) int {
+	line, target := 0, -1
+	for i, b := range blocks {
+		if line >= bottom {
+			break
+		}
+		if b.foldable() {
+			target = i
+		}
+		line += strings.Count(b.view(expandAll), "\n")
+	}
+	return target
+}
+
+// joinBlocks concatenates the current rendering of each block.
+func joinBlocks(blocks []viewportBlock, expandAll bool) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		b.WriteString(block.view(expandAll))
+	}
+	return b.String()
+}
+
+// toggleFold expands or collapses the block chosen by foldTarget. It
+// returns false when no folded item is in view.
+func (m *Model) toggleFold() bool {
+	i := m.foldTarget()
+	if i < 0 {
+		return false
+	}
+	m.blocks[i].open = !m.blocks[i].open
+	m.refreshViewport()
+	return true
+}
+
+// toggleExpandAll flips /expandall, which expands or collapses every
+// foldable item and resets the per-item state.
+func (m *Model) toggleExpandAll() {
+	m.expandAll = !m.expandAll
+	for i := range m.blocks {
+		m.blocks[i].open = false
+	}
+	m.refreshViewport()
+}
+
+// refreshViewport rebuilds the viewport content after a fold change,
+// keeping the scroll position unless the viewport was at the bottom.
+func (m *Model) refreshViewport() {
+	wasAtBottom := m.viewport.AtBottom()
+	m.viewportContent = joinBlocks(m.blocks, m.expandAll)
+	m.viewport.SetContent(m.viewportContent)
+	if wasAtBottom {
+		m.viewport.GotoBottom()
+	}
+}
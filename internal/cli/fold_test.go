@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func numberedLines(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRenderFolded(t *testing.T) {
+	r := NewItemRenderer(80, true, true, NoColorStyles())
+	assert.Empty(t, r.RenderFolded(numberedLines(3)+"\n", 3), "fits")
+	assert.Empty(t, r.RenderFolded(numberedLines(50), -1), "folding disabled")
+
+	folded := r.RenderFolded(numberedLines(253)+"\n", 40)
+	assert.Contains(t, folded, "line 40\n… (+213 lines, press o to expand)\n")
+	assert.NotContains(t, folded, "line 41")
+}
+
+func TestModel_FoldsLongItems(t *testing.T) {
+	m := newTestModel()
+	m.viewport = viewport.New(80, 20)
+	m.config.FoldLines = 10
+
+	m.renderNewItems([]models.ConversationItem{
+		{Seq: 1, Type: models.ItemTypeAssistantMessage, Content: numberedLines(30)},
+		{Seq: 2, Type: models.ItemTypeAssistantMessage, Content: "short"},
+	})
+	require.Len(t, m.blocks, 2)
+	assert.True(t, m.blocks[0].foldable())
+	assert.False(t, m.blocks[1].foldable())
+	assert.Contains(t, m.viewportContent, "(+21 lines, press o to expand)")
+	assert.NotContains(t, m.viewportContent, "line 30")
+
+	m.state = StateWatching
+	m.handleWatchingKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	assert.True(t, m.blocks[0].open)
+	assert.Contains(t, m.viewportContent, "line 30")
+
+	m.handleWatchingKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	assert.NotContains(t, m.viewportContent, "line 30")
+}
+
+func TestModel_ExpandsFullToolOutput(t *testing.T) {
+	m := newTestModel()
+	m.viewport = viewport.New(80, 20)
+
+	success := true
+	m.renderNewItems([]models.ConversationItem{{
+		Seq:    1,
+		Type:   models.ItemTypeFunctionCallOutput,
+		Output: &models.FunctionCallOutputPayload{Content: numberedLines(12), Success: &success},
+	}})
+	assert.Contains(t, m.viewportContent, "… +8 lines, press o to expand")
+	assert.NotContains(t, m.viewportContent, "line 6")
+
+	m.state = StateInput
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyCtrlO})
+	assert.Contains(t, m.viewportContent, "line 6")
+	assert.NotContains(t, m.viewportContent, "press o")
+}
+
+func TestModel_ExpandAllToggle(t *testing.T) {
+	m := newTestModel()
+	m.viewport = viewport.New(80, 20)
+	m.config.FoldLines = 5
+
+	m.renderNewItems([]models.ConversationItem{
+		{Seq: 1, Type: models.ItemTypeAssistantMessage, Content: numberedLines(8)},
+		{Seq: 2, Type: models.ItemTypeAssistantMessage, Content: numberedLines(9)},
+	})
+	m.blocks[1].open = true
+
+	m.textarea.SetValue("/expandall")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.expandAll)
+	assert.NotContains(t, m.viewportContent, "press o")
+	assert.False(t, m.blocks[1].open, "per-item state is reset")
+
+	m.textarea.SetValue("/expandall")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, 2, strings.Count(m.viewportContent, "press o"))
+}
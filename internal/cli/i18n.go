@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locale is the language of the TUI's own text: the status bar, approval
+// and question prompts, selector options and system messages. Conversation
+// content (messages, tool calls and their output) is shown as the session
+// recorded it.
+type Locale string
+
+const (
+	LocaleEnglish  Locale = "en"
+	LocaleJapanese Locale = "ja"
+	LocaleGerman   Locale = "de"
+)
+
+// ParseLocale resolves the --lang flag. "auto" (or "") picks the locale
+// of the environment described by getenv.
+func ParseLocale(s string, getenv func(string) string) (Locale, error) {
+	switch l := Locale(strings.ToLower(s)); l {
+	case "", "auto":
+		return DetectLocale(getenv), nil
+	case LocaleEnglish, LocaleJapanese, LocaleGerman:
+		return l, nil
+	default:
+		return "", fmt.Errorf("invalid --lang %q: want auto, en, ja or de", s)
+	}
+}
+
+// DetectLocale picks the locale from TCX_LANG, then the first of LC_ALL,
+// LC_MESSAGES and LANG that is set, as POSIX orders them. Values such as
+// "ja_JP.UTF-8" or "de" select the language; anything else is English.
+func DetectLocale(getenv func(string) string) Locale {
+	for _, name := range []string{"TCX_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := getenv(name)
+		if v == "" {
+			continue
+		}
+		lang := strings.ToLower(v)
+		if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+			lang = lang[:i]
+		}
+		switch l := Locale(lang); l {
+		case LocaleJapanese, LocaleGerman:
+			return l
+		}
+		return LocaleEnglish
+	}
+	return LocaleEnglish
+}
+
+// T translates msg, which is the English text and the catalog key, and
+// formats it with args as fmt.Sprintf does. Text missing from the locale's
+// catalog stays English.
+func (l Locale) T(msg string, args ...interface{}) string {
+	if translated, ok := catalogs[l][msg]; ok {
+		msg = translated
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// catalogs holds the translations by locale, keyed by the English text.
+// Keys keep their format verbs in order, so translations take the same
+// arguments.
+var catalogs = map[Locale]map[string]string{
+	LocaleJapanese: {
+		// Status bar
+		"%s tokens":           "%s トークン",
+		"%s cached":           "%s キャッシュ",
+		"ctx %d%%":            "コンテキスト %d%%",
+		"turn %d":             "ターン %d",
+		"plan mode":           "プランモード",
+		"planning":            "計画中",
+		"picker":              "セッション選択",
+		"ready":               "入力待ち",
+		"working":             "実行中",
+		"approval":            "承認待ち",
+		"escalation":          "エスカレーション",
+		"question":            "質問",
+		"connecting":          "接続中",
+		"paused":              "一時停止",
+		"standby (read-only)": "スタンバイ（読み取り専用）",
+
+		// Spinner
+		"Starting...":             "起動しています...",
+		"Loading sessions...":     "セッションを読み込んでいます...",
+		"Thinking...":             "考え中...",
+		"Interrupting...":         "中断しています...",
+		"Connecting...":           "接続しています...",
+		"Running tools...":        "ツールを実行しています...",
+		"Compacting...":           "圧縮しています...",
+		"Processing answer...":    "回答を処理しています...",
+		"Ending session...":       "セッションを終了しています...",
+		"Planning...":             "計画しています...",
+		"Starting new session...": "新しいセッションを開始しています...",
+		"Fetching sessions...":    "セッションを取得しています...",
+
+		// Approval, escalation and question prompts
+		"Allow? [y]es / [n]o / [a]lways / 1,2 (select by index): ":     "許可しますか？ [y] はい / [n] いいえ / [a] 常に許可 / 1,2（番号で選択）: ",
+		"Allow? [y]es / [n]o / [a]lways: ":                             "許可しますか？ [y] はい / [n] いいえ / [a] 常に許可: ",
+		"Reason:":                                                      "理由:",
+		"Output:":                                                      "出力:",
+		"Sandbox failure — escalation needed:":                         "サンドボックス内で失敗しました — エスカレーションが必要です:",
+		"Re-run without sandbox? [y]es / [n]o: ":                       "サンドボックスなしで再実行しますか？ [y] はい / [n] いいえ: ",
+		"The assistant has a question for you:":                        "アシスタントからの質問です:",
+		"Enter option number (or type your answer): ":                  "選択肢の番号を入力（または回答を入力）: ",
+		"Type your answer (Shift+Enter for a new line, Enter to send)": "回答を入力してください（Shift+Enter で改行、Enter で送信）",
+		" — empty answer uses %q":                                      " — 空のまま送信すると %q を使います",
+		"Patch did not apply — apply it at the closest match?":         "パッチを適用できませんでした — 最も近い箇所に適用しますか？",
+		"expected:":                                   "想定:",
+		"found at line %d (%d%% match):":              "%d 行目で検出（一致率 %d%%）:",
+		"Creates a new file (%d bytes)":               "新しいファイルを作成します（%d バイト）",
+		"Replaces existing file: %d → %d bytes (%+d)": "既存のファイルを置き換えます: %d → %d バイト（%+d）",
+		"Currently starts with:":                      "現在の先頭部分:",
+		"Touches: %s":                                 "対象: %s",
+
+		// Selector options
+		"Yes, allow":                          "はい、許可する",
+		"No, deny":                            "いいえ、拒否する",
+		"Always allow for this session":       "このセッションでは常に許可する",
+		"Select individually...":              "個別に選択...",
+		"Yes, re-run without sandbox":         "はい、サンドボックスなしで再実行する",
+		"Yes, apply at the closest match":     "はい、最も近い箇所に適用する",
+		"No, hand the hunk back to the model": "いいえ、この変更箇所をモデルに差し戻す",
+		"Other (type your answer)...":         "その他（回答を入力）...",
+		"New session":                         "新しいセッション",
+
+		// System messages
+		"Cancelling %s...": "%s をキャンセルしています...",
+		"Approval no longer pending; the turn has moved on.":   "承認待ちは解除されました。ターンは先に進んでいます。",
+		"Escalation no longer pending; the turn has moved on.": "エスカレーション待ちは解除されました。ターンは先に進んでいます。",
+		"Context compacted.":                                           "コンテキストを圧縮しました。",
+		"Model updated to %s (%s).":                                    "モデルを %s（%s）に変更しました。",
+		"Select a model (Esc to cancel):":                              "モデルを選択してください（Esc でキャンセル）:",
+		"Started new session %s":                                       "新しいセッション %s を開始しました",
+		"Personality cleared.":                                         "パーソナリティをクリアしました。",
+		"Personality set to: %s":                                       "パーソナリティを設定しました: %s",
+		"Session renamed to %q.":                                       "セッション名を %q に変更しました。",
+		"Approval mode updated to %s.":                                 "承認モードを %s に変更しました。",
+		"Model parameters updated; they apply from the next LLM call.": "モデルパラメータを更新しました。次の LLM 呼び出しから適用されます。",
+		"Session environment updated.":                                 "セッションの環境変数を更新しました。",
+		"Reasoning effort updated to %s.":                              "推論レベルを %s に変更しました。",
+		"AGENTS.md already exists at %s":                               "AGENTS.md は既に %s にあります",
+		"Created AGENTS.md at %s":                                      "%s に AGENTS.md を作成しました",
+		"Worker %s lacks tools enabled for this session: %s":           "ワーカー %s には、このセッションで有効にしたツールの一部がありません: %s",
+		"Session paused. New messages are rejected until /unpause; exec sessions stay alive.": "セッションを一時停止しました。/unpause するまで新しいメッセージは受け付けません。exec セッションは維持されます。",
+		"Session resumed after %s.":                                 "セッションを再開しました（停止時間 %s）。",
+		"No project docs (AGENTS.md) found there.":                  "そこにはプロジェクトドキュメント（AGENTS.md）がありません。",
+		"Project docs reloaded from there.":                         "そこからプロジェクトドキュメントを再読み込みしました。",
+		"Working directory is now %s (was %s). %s":                  "作業ディレクトリを %s に変更しました（以前: %s）。%s",
+		"Pick an answer to keep (Esc to discard):":                  "残す回答を選んでください（Esc で破棄）:",
+		"Kept %s's answer as the answer.":                           "%s の回答を採用しました。",
+		"Added %s's answer as context for the next turn.":           "%s の回答を次のターンのコンテキストに追加しました。",
+		"Toggle skills (Esc to cancel):":                            "スキルを切り替えてください（Esc でキャンセル）:",
+		"Model set to %s (%s). Start a session to apply.":           "モデルを %s（%s）に設定しました。セッションを開始すると適用されます。",
+		"Discarded the answers.":                                    "回答を破棄しました。",
+		"Fetching available models...":                              "利用可能なモデルを取得しています...",
+		"Starting plan mode...":                                     "プランモードを開始しています...",
+		"Ending plan mode...":                                       "プランモードを終了しています...",
+		"Select approval mode (Esc to cancel):":                     "承認モードを選択してください（Esc でキャンセル）:",
+		"Model %s does not support reasoning effort configuration.": "モデル %s は推論レベルの設定に対応していません。",
+		"Select reasoning effort (Esc to cancel):":                  "推論レベルを選択してください（Esc でキャンセル）:",
+		"Temporal is unreachable; input is paused until the connection is back. Your message was kept.": "Temporal に接続できません。接続が戻るまで入力は停止されます。メッセージは保持されています。",
+		"Started session %s": "セッション %s を開始しました",
+		"Reconnected to Temporal. Input is enabled again.":   "Temporal に再接続しました。入力を再開できます。",
+		"Plan mode active (agent: %s). Use /done to finish.": "プランモード中です（エージェント: %s）。終了するには /done を使ってください。",
+		"Plan mode ended. Sending plan to parent...":         "プランモードを終了しました。プランを親セッションに送信しています...",
+		"Plan mode ended (no plan produced).":                "プランモードを終了しました（プランは作成されませんでした）。",
+		"Temporal is unreachable. Showing read-only session state from standby %s; it may lag behind. Input is paused until the connection is back.": "Temporal に接続できません。スタンバイ %s の読み取り専用のセッション状態を表示しています（遅れている可能性があります）。接続が戻るまで入力は停止されます。",
+		"Answers to: %s": "質問「%s」への回答",
+		"Theme: %s":      "テーマ: %s",
+		"Theme: %s (built-in: %s; or a theme file)": "テーマ: %s（組み込み: %s、またはテーマファイル）",
+		"Colors are off (--no-color).":              "色は無効です（--no-color）。",
+	},
+
+	LocaleGerman: {
+		// Status bar
+		"%s tokens":           "%s Tokens",
+		"%s cached":           "%s gecacht",
+		"ctx %d%%":            "Kontext %d%%",
+		"turn %d":             "Runde %d",
+		"plan mode":           "Planmodus",
+		"planning":            "plant",
+		"picker":              "Auswahl",
+		"ready":               "bereit",
+		"working":             "arbeitet",
+		"approval":            "Freigabe",
+		"escalation":          "Eskalation",
+		"question":            "Frage",
+		"connecting":          "verbinde",
+		"paused":              "pausiert",
+		"standby (read-only)": "Standby (nur lesen)",
+
+		// Spinner
+		"Starting...":             "Startet...",
+		"Loading sessions...":     "Sitzungen werden geladen...",
+		"Thinking...":             "Denkt nach...",
+		"Interrupting...":         "Wird unterbrochen...",
+		"Connecting...":           "Verbinde...",
+		"Running tools...":        "Tools werden ausgeführt...",
+		"Compacting...":           "Komprimiere...",
+		"Processing answer...":    "Antwort wird verarbeitet...",
+		"Ending session...":       "Sitzung wird beendet...",
+		"Planning...":             "Plant...",
+		"Starting new session...": "Neue Sitzung wird gestartet...",
+		"Fetching sessions...":    "Sitzungen werden abgerufen...",
+
+		// Approval, escalation and question prompts
+		"Allow? [y]es / [n]o / [a]lways / 1,2 (select by index): ":     "Erlauben? [y] ja / [n] nein / [a] immer / 1,2 (Auswahl per Nummer): ",
+		"Allow? [y]es / [n]o / [a]lways: ":                             "Erlauben? [y] ja / [n] nein / [a] immer: ",
+		"Reason:":                                                      "Grund:",
+		"Output:":                                                      "Ausgabe:",
+		"Sandbox failure — escalation needed:":                         "Fehler in der Sandbox — Eskalation nötig:",
+		"Re-run without sandbox? [y]es / [n]o: ":                       "Ohne Sandbox erneut ausführen? [y] ja / [n] nein: ",
+		"The assistant has a question for you:":                        "Der Assistent hat eine Frage an Sie:",
+		"Enter option number (or type your answer): ":                  "Nummer der Option eingeben (oder Antwort tippen): ",
+		"Type your answer (Shift+Enter for a new line, Enter to send)": "Antwort eingeben (Umschalt+Enter für eine neue Zeile, Enter zum Senden)",
+		" — empty answer uses %q":                                      " — eine leere Antwort verwendet %q",
+		"Patch did not apply — apply it at the closest match?":         "Patch ließ sich nicht anwenden — an der ähnlichsten Stelle anwenden?",
+		"expected:":                                   "erwartet:",
+		"found at line %d (%d%% match):":              "gefunden in Zeile %d (%d%% Übereinstimmung):",
+		"Creates a new file (%d bytes)":               "Erstellt eine neue Datei (%d Bytes)",
+		"Replaces existing file: %d → %d bytes (%+d)": "Ersetzt die vorhandene Datei: %d → %d Bytes (%+d)",
+		"Currently starts with:":                      "Beginnt derzeit mit:",
+		"Touches: %s":                                 "Betrifft: %s",
+
+		// Selector options
+		"Yes, allow":                          "Ja, erlauben",
+		"No, deny":                            "Nein, ablehnen",
+		"Always allow for this session":       "Für diese Sitzung immer erlauben",
+		"Select individually...":              "Einzeln auswählen...",
+		"Yes, re-run without sandbox":         "Ja, ohne Sandbox erneut ausführen",
+		"Yes, apply at the closest match":     "Ja, an der ähnlichsten Stelle anwenden",
+		"No, hand the hunk back to the model": "Nein, den Hunk an das Modell zurückgeben",
+		"Other (type your answer)...":         "Andere (Antwort eingeben)...",
+		"New session":                         "Neue Sitzung",
+
+		// System messages
+		"Cancelling %s...": "%s wird abgebrochen...",
+		"Approval no longer pending; the turn has moved on.":   "Freigabe nicht mehr ausstehend; die Runde ist weitergelaufen.",
+		"Escalation no longer pending; the turn has moved on.": "Eskalation nicht mehr ausstehend; die Runde ist weitergelaufen.",
+		"Context compacted.":                                           "Kontext komprimiert.",
+		"Model updated to %s (%s).":                                    "Modell auf %s (%s) geändert.",
+		"Select a model (Esc to cancel):":                              "Modell auswählen (Esc zum Abbrechen):",
+		"Started new session %s":                                       "Neue Sitzung %s gestartet",
+		"Personality cleared.":                                         "Persönlichkeit zurückgesetzt.",
+		"Personality set to: %s":                                       "Persönlichkeit gesetzt: %s",
+		"Session renamed to %q.":                                       "Sitzung in %q umbenannt.",
+		"Approval mode updated to %s.":                                 "Freigabemodus auf %s geändert.",
+		"Model parameters updated; they apply from the next LLM call.": "Modellparameter aktualisiert; sie gelten ab dem nächsten LLM-Aufruf.",
+		"Session environment updated.":                                 "Sitzungsumgebung aktualisiert.",
+		"Reasoning effort updated to %s.":                              "Reasoning-Aufwand auf %s geändert.",
+		"AGENTS.md already exists at %s":                               "AGENTS.md existiert bereits unter %s",
+		"Created AGENTS.md at %s":                                      "AGENTS.md unter %s erstellt",
+		"Worker %s lacks tools enabled for this session: %s":           "Worker %s fehlen Tools, die für diese Sitzung aktiviert sind: %s",
+		"Session paused. New messages are rejected until /unpause; exec sessions stay alive.": "Sitzung pausiert. Neue Nachrichten werden bis /unpause abgelehnt; Exec-Sitzungen laufen weiter.",
+		"Session resumed after %s.":                                 "Sitzung nach %s fortgesetzt.",
+		"No project docs (AGENTS.md) found there.":                  "Dort wurden keine Projektdokumente (AGENTS.md) gefunden.",
+		"Project docs reloaded from there.":                         "Projektdokumente von dort neu geladen.",
+		"Working directory is now %s (was %s). %s":                  "Arbeitsverzeichnis ist jetzt %s (vorher %s). %s",
+		"Pick an answer to keep (Esc to discard):":                  "Antwort zum Behalten auswählen (Esc zum Verwerfen):",
+		"Kept %s's answer as the answer.":                           "Antwort von %s übernommen.",
+		"Added %s's answer as context for the next turn.":           "Antwort von %s als Kontext für die nächste Runde hinzugefügt.",
+		"Toggle skills (Esc to cancel):":                            "Skills umschalten (Esc zum Abbrechen):",
+		"Model set to %s (%s). Start a session to apply.":           "Modell auf %s (%s) gesetzt. Es gilt ab dem Start einer Sitzung.",
+		"Discarded the answers.":                                    "Antworten verworfen.",
+		"Fetching available models...":                              "Verfügbare Modelle werden abgerufen...",
+		"Starting plan mode...":                                     "Planmodus wird gestartet...",
+		"Ending plan mode...":                                       "Planmodus wird beendet...",
+		"Select approval mode (Esc to cancel):":                     "Freigabemodus auswählen (Esc zum Abbrechen):",
+		"Model %s does not support reasoning effort configuration.": "Modell %s unterstützt keine Einstellung des Reasoning-Aufwands.",
+		"Select reasoning effort (Esc to cancel):":                  "Reasoning-Aufwand auswählen (Esc zum Abbrechen):",
+		"Temporal is unreachable; input is paused until the connection is back. Your message was kept.": "Temporal ist nicht erreichbar; die Eingabe ist pausiert, bis die Verbindung zurück ist. Ihre Nachricht wurde behalten.",
+		"Started session %s": "Sitzung %s gestartet",
+		"Reconnected to Temporal. Input is enabled again.":   "Wieder mit Temporal verbunden. Die Eingabe ist wieder möglich.",
+		"Plan mode active (agent: %s). Use /done to finish.": "Planmodus aktiv (Agent: %s). Mit /done beenden.",
+		"Plan mode ended. Sending plan to parent...":         "Planmodus beendet. Der Plan wird an die übergeordnete Sitzung gesendet...",
+		"Plan mode ended (no plan produced).":                "Planmodus beendet (kein Plan erstellt).",
+		"Temporal is unreachable. Showing read-only session state from standby %s; it may lag behind. Input is paused until the connection is back.": "Temporal ist nicht erreichbar. Angezeigt wird der schreibgeschützte Sitzungszustand von Standby %s; er kann nachhinken. Die Eingabe ist pausiert, bis die Verbindung zurück ist.",
+		"Answers to: %s": "Antworten auf: %s",
+		"Theme: %s":      "Farbschema: %s",
+		"Theme: %s (built-in: %s; or a theme file)": "Farbschema: %s (eingebaut: %s; oder eine Theme-Datei)",
+		"Colors are off (--no-color).":              "Farben sind aus (--no-color).",
+	},
+}
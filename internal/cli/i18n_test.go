@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseLocale(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	for _, tc := range []struct {
+		vars map[string]string
+		want Locale
+	}{
+		{nil, LocaleEnglish},
+		{map[string]string{"LANG": "ja_JP.UTF-8"}, LocaleJapanese},
+		{map[string]string{"LANG": "de_DE@euro"}, LocaleGerman},
+		{map[string]string{"LANG": "fr_FR.UTF-8"}, LocaleEnglish},
+		{map[string]string{"LANG": "C"}, LocaleEnglish},
+		{map[string]string{"LANG": "ja_JP.UTF-8", "LC_MESSAGES": "de_DE"}, LocaleGerman},
+		{map[string]string{"LC_MESSAGES": "de_DE", "LC_ALL": "en_US.UTF-8"}, LocaleEnglish},
+		{map[string]string{"LC_ALL": "ja", "TCX_LANG": "de"}, LocaleGerman},
+	} {
+		got, err := ParseLocale("auto", env(tc.vars))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%v", tc.vars)
+	}
+
+	got, err := ParseLocale("JA", env(map[string]string{"LANG": "de_DE"}))
+	require.NoError(t, err)
+	assert.Equal(t, LocaleJapanese, got)
+	_, err = ParseLocale("fr", env(nil))
+	assert.ErrorContains(t, err, `invalid --lang "fr"`)
+}
+
+func TestLocaleT(t *testing.T) {
+	assert.Equal(t, "ターン 3", LocaleJapanese.T("turn %d", 3))
+	assert.Equal(t, "Runde 3", LocaleGerman.T("turn %d", 3))
+	assert.Equal(t, "turn 3", LocaleEnglish.T("turn %d", 3))
+	assert.Equal(t, "turn 3", Locale("").T("turn %d", 3))
+	assert.Equal(t, "not in any catalog", LocaleJapanese.T("not in any catalog"))
+	// Without args the text is not formatted, so a literal % survives.
+	assert.Equal(t, "100%", LocaleGerman.T("100%"))
+}
+
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestCatalogs checks that every catalog translates the same messages, that
+// translations take the same arguments as the English text, and that every
+// key is still used by the package, so renamed text does not silently fall
+// back to English.
+func TestCatalogs(t *testing.T) {
+	var sources strings.Builder
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") || f == "i18n.go" {
+			continue
+		}
+		data, err := os.ReadFile(f)
+		require.NoError(t, err)
+		sources.Write(data)
+	}
+
+	ja, de := catalogs[LocaleJapanese], catalogs[LocaleGerman]
+	for key := range ja {
+		_, ok := de[key]
+		assert.True(t, ok, "%q has no German translation", key)
+	}
+	for locale, catalog := range catalogs {
+		for key, translated := range catalog {
+			assert.Equal(t, formatVerb.FindAllString(key, -1), formatVerb.FindAllString(translated, -1),
+				"%s translation of %q takes different arguments", locale, key)
+			assert.Contains(t, sources.String(), strconv.Quote(key), "%q is not used outside the catalog", key)
+		}
+	}
+}
+
+func TestStatusBar_Japanese(t *testing.T) {
+	m := newTestModel()
+	m.config.Locale = LocaleJapanese
+	m.totalTokens = 5000
+	m.totalCachedTokens = 1200
+	m.turnCount = 3
+	m.state = StateInput
+	m.modelName = "gpt-4o-mini"
+
+	bar := m.renderStatusBar()
+	assert.Contains(t, bar, "5,000 (1,200 キャッシュ) トークン")
+	assert.Contains(t, bar, "ターン 3")
+	assert.Contains(t, bar, "入力待ち")
+}
+
+func TestItemRenderer_RenderApprovalPrompt_German(t *testing.T) {
+	r := newTestRenderer()
+	r.locale = LocaleGerman
+	result := r.RenderApprovalPrompt([]workflow.PendingApproval{
+		{CallID: "c1", ToolName: "shell", Arguments: `{"command": "ls"}`, Reason: "lists files"},
+	})
+
+	assert.Contains(t, result, "Erlauben? [y] ja / [n] nein / [a] immer: ")
+	assert.Contains(t, result, "Grund:")
+	// The tool call itself is conversation content and stays as recorded.
+	assert.Contains(t, result, "Shell: ls")
+	assert.Contains(t, result, "lists files")
+}
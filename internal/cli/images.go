@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register decoders for image attachments
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+)
+
+// ImageProtocol is the terminal graphics protocol used to show image
+// attachments inline.
+type ImageProtocol string
+
+const (
+	ImagesOff    ImageProtocol = "off"    // Show a placeholder line instead
+	ImagesKitty  ImageProtocol = "kitty"  // kitty graphics with Unicode placeholders (kitty, Ghostty)
+	ImagesITerm2 ImageProtocol = "iterm2" // iTerm2 inline images (iTerm2, WezTerm)
+	ImagesSixel  ImageProtocol = "sixel"  // DEC sixel (foot, mlterm, xterm -ti vt340)
+)
+
+// Inline image layout. Cell pixel sizes are assumed, as the TUI does not
+// query the terminal; sixel images are drawn at this scale.
+const (
+	maxImageCols    = 60
+	maxImageRows    = 16
+	sixelCellWidth  = 8
+	sixelCellHeight = 16
+
+	// maxLocalImageBytes caps reading an attachment from the worker's path
+	// when its data was not sent inline (the TUI may share the worker's
+	// filesystem).
+	maxLocalImageBytes = 8 << 20
+)
+
+// ParseImageProtocol resolves the --images flag. "auto" (or "") picks the
+// protocol of the terminal described by getenv.
+func ParseImageProtocol(s string, getenv func(string) string) (ImageProtocol, error) {
+	switch p := ImageProtocol(strings.ToLower(s)); p {
+	case "", "auto":
+		return DetectImageProtocol(getenv), nil
+	case ImagesOff, ImagesKitty, ImagesITerm2, ImagesSixel:
+		return p, nil
+	case "none":
+		return ImagesOff, nil
+	default:
+		return "", fmt.Errorf("invalid --images %q: want auto, kitty, iterm2, sixel or off", s)
+	}
+}
+
+// DetectImageProtocol guesses the terminal's graphics protocol from its
+// environment. Inside tmux or screen images are off: the escape sequences
+// would need passthrough.
+func DetectImageProtocol(getenv func(string) string) ImageProtocol {
+	term := getenv("TERM")
+	if getenv("TMUX") != "" || strings.HasPrefix(term, "screen") || strings.HasPrefix(term, "tmux") {
+		return ImagesOff
+	}
+	switch program := getenv("TERM_PROGRAM"); {
+	case term == "xterm-kitty" || getenv("KITTY_WINDOW_ID") != "" || program == "ghostty":
+		return ImagesKitty
+	case program == "iTerm.app" || program == "WezTerm":
+		return ImagesITerm2
+	case strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") || strings.Contains(term, "sixel"):
+		return ImagesSixel
+	}
+	return ImagesOff
+}
+
+// renderAttachments renders a tool output's attachments below it: images
+// inline when the terminal supports it, everything else as a placeholder
+// line naming the file.
+func (r *ItemRenderer) renderAttachments(atts []attachment.Attachment) string {
+	var b strings.Builder
+	indent := r.styles.OutputPrefix.Render("    ")
+	for _, a := range atts {
+		if a.URL != "" {
+			b.WriteString(r.renderArtifactLink(a))
+			continue
+		}
+		if img := r.renderImage(a); img != "" {
+			b.WriteString(img)
+			continue
+		}
+		b.WriteString(indent + r.styles.OutputDim.Render(attachmentPlaceholder(a)) + "\n")
+	}
+	return b.String()
+}
+
+// renderArtifactLink renders a shared artifact (share_artifact) as a line
+// naming it and a clickable link (OSC 8) to the worker's copy.
+func (r *ItemRenderer) renderArtifactLink(a attachment.Attachment) string {
+	indent := r.styles.OutputPrefix.Render("    ")
+	desc := fmt.Sprintf("[artifact: %s, %s", a.Name, formatByteSize(int(a.Size)))
+	if !a.ExpiresAt.IsZero() {
+		desc += ", link expires " + a.ExpiresAt.Local().Format("Jan 2 15:04")
+	}
+	desc += "]"
+	link := ansi.SetHyperlink(a.URL) + r.styles.Link.Render(a.URL) + ansi.ResetHyperlink()
+	return indent + r.styles.OutputDim.Render(desc) + "\n" + indent + link + "\n"
+}
+
+// attachmentPlaceholder describes an attachment that is not shown inline,
+// e.g. "[image: plot.png, 41.2 KB, saved to /tmp/plot.png]".
+func attachmentPlaceholder(a attachment.Attachment) string {
+	kind := "attachment"
+	if a.IsImage() {
+		kind = "image"
+	}
+	s := fmt.Sprintf("[%s: %s, %s", kind, a.Name, formatByteSize(int(a.Size)))
+	if a.Path != "" {
+		s += ", saved to " + a.Path
+	}
+	return s + "]"
+}
+
+// renderImage renders an image attachment with the renderer's protocol,
+// followed by a caption line. Returns "" when images are off or the
+// attachment cannot be decoded.
+func (r *ItemRenderer) renderImage(a attachment.Attachment) string {
+	if r.images == "" || r.images == ImagesOff || !a.IsImage() {
+		return ""
+	}
+	data := attachmentData(a)
+	if data == nil {
+		return ""
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return ""
+	}
+	width := r.width
+	if width <= 0 {
+		width = 80
+	}
+	cols, rows := imageCells(cfg.Width, cfg.Height, min(maxImageCols, width-4))
+
+	indent := r.styles.OutputPrefix.Render("    ")
+	caption := indent + r.styles.OutputDim.Render(fmt.Sprintf("%s, %s", a.Name, formatByteSize(int(a.Size))))
+	var b strings.Builder
+	switch r.images {
+	case ImagesKitty:
+		seq, ok := r.kittyTransmit(data, cols, rows)
+		if !ok {
+			return ""
+		}
+		for row := 0; row < rows; row++ {
+			b.WriteString(indent)
+			if row == 0 {
+				b.WriteString(seq)
+			}
+			b.WriteString(kittyPlaceholderRow(r.kittyImageID, row, cols) + "\n")
+		}
+		b.WriteString(caption + "\n")
+	case ImagesITerm2, ImagesSixel:
+		seq, ok := imageSequence(r.images, a.Name, data, cols, rows)
+		if !ok {
+			return ""
+		}
+		// The image is drawn from the caption line, up over the blank rows
+		// reserved above it. The TUI repaints lines top to bottom, so this
+		// comes after the rows it covers have been cleared.
+		b.WriteString(strings.Repeat(indent+"\n", rows))
+		b.WriteString(caption + ansi.SaveCursor + ansi.CursorUp(rows) + "\r" + ansi.CursorForward(4) + seq + ansi.RestoreCursor + "\n")
+	}
+	return b.String()
+}
+
+// attachmentData returns the attachment's bytes: inline data, or the file
+// at its path when this machine can read it.
+func attachmentData(a attachment.Attachment) []byte {
+	if a.Data != nil {
+		return a.Data
+	}
+	if a.Path == "" || a.Size > maxLocalImageBytes {
+		return nil
+	}
+	data, err := os.ReadFile(a.Path)
+	if err != nil || int64(len(data)) != a.Size {
+		return nil // Not this machine's file
+	}
+	return data
+}
+
+// imageCells fits a w×h pixel image into at most maxCols columns and
+// maxImageRows rows, keeping its aspect ratio for cells twice as tall as
+// they are wide.
+func imageCells(w, h, maxCols int) (cols, rows int) {
+	cols = max(1, min(maxCols, (w+sixelCellWidth-1)/sixelCellWidth))
+	rows = max(1, (cols*h+w)/(2*w))
+	if rows > maxImageRows {
+		rows = maxImageRows
+		cols = max(1, min(cols, 2*rows*w/h))
+	}
+	return cols, rows
+}
+
+// imageSequence returns the escape sequence drawing data at the cursor in
+// a cols×rows cell box with an iTerm2 or sixel protocol.
+func imageSequence(protocol ImageProtocol, name string, data []byte, cols, rows int) (string, bool) {
+	switch protocol {
+	case ImagesITerm2:
+		return fmt.Sprintf("\x1b]1337;File=name=%s;size=%d;width=%d;height=%d;preserveAspectRatio=1;inline=1:%s\a",
+			base64.StdEncoding.EncodeToString([]byte(name)), len(data), cols, rows,
+			base64.StdEncoding.EncodeToString(data)), true
+	case ImagesSixel:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", false
+		}
+		return encodeSixel(img, cols*sixelCellWidth, rows*sixelCellHeight), true
+	}
+	return "", false
+}
+
+// kittyChunk is the largest base64 payload of one kitty graphics command.
+const kittyChunk = 4096
+
+// kittyTransmit returns the commands transmitting data as a PNG with a
+// virtual placement of cols×rows cells, under the next image ID. The image
+// is shown by the Unicode placeholder cells of kittyPlaceholderRow.
+func (r *ItemRenderer) kittyTransmit(data []byte, cols, rows int) (string, bool) {
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return "", false
+	} else if format != "png" {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", false
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return "", false
+		}
+		data = buf.Bytes()
+	}
+	// IDs are sent as a 256-color foreground, so they cycle through 1-255.
+	r.kittyImageID = r.kittyImageID%255 + 1
+
+	payload := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for first := true; first || payload != ""; first = false {
+		chunk := payload[:min(kittyChunk, len(payload))]
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&b, "\x1b_Ga=T,U=1,q=2,f=100,i=%d,c=%d,r=%d,m=%d;%s\x1b\\", r.kittyImageID, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return b.String(), true
+}
+
+// kittyPlaceholder is the Unicode placeholder character for kitty images.
+const kittyPlaceholder = '\U0010EEEE'
+
+// kittyDiacritics encode placeholder row and column numbers (the start of
+// kitty's rowcolumn-diacritics table).
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+}
+
+// kittyPlaceholderRow returns one row of placeholder cells for image id.
+// Only the first cell carries its row and column; the rest continue it.
+func kittyPlaceholderRow(id, row, cols int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1b[38;5;%dm", id)
+	b.WriteRune(kittyPlaceholder)
+	b.WriteRune(kittyDiacritics[row])
+	b.WriteRune(kittyDiacritics[0])
+	for i := 1; i < cols; i++ {
+		b.WriteRune(kittyPlaceholder)
+	}
+	b.WriteString("\x1b[39m")
+	return b.String()
+}
+
+// encodeSixel encodes img scaled to w×h pixels as a sixel sequence, using
+// a 6×6×6 color cube. Mostly transparent pixels are left unset.
+func encodeSixel(img image.Image, w, h int) string {
+	bounds := img.Bounds()
+	pixels := make([]int, w*h)
+	var used [216]bool
+	for y := 0; y < h; y++ {
+		sy := bounds.Min.Y + y*bounds.Dy()/h
+		for x := 0; x < w; x++ {
+			sx := bounds.Min.X + x*bounds.Dx()/w
+			c := color.NRGBAModel.Convert(img.At(sx, sy)).(color.NRGBA)
+			if c.A < 128 {
+				pixels[y*w+x] = -1
+				continue
+			}
+			i := sixelLevel(c.R)*36 + sixelLevel(c.G)*6 + sixelLevel(c.B)
+			pixels[y*w+x] = i
+			used[i] = true
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i, ok := range used {
+		if ok {
+			fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+		}
+	}
+	row := make([]byte, w)
+	for top := 0; top < h; top += 6 {
+		var inBand [216]bool
+		for y := top; y < min(top+6, h); y++ {
+			for x := 0; x < w; x++ {
+				if p := pixels[y*w+x]; p >= 0 {
+					inBand[p] = true
+				}
+			}
+		}
+		first := true
+		for c, ok := range inBand {
+			if !ok {
+				continue
+			}
+			for x := 0; x < w; x++ {
+				bits := 0
+				for dy := 0; dy < 6 && top+dy < h; dy++ {
+					if pixels[(top+dy)*w+x] == c {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = byte(63 + bits)
+			}
+			if !first {
+				b.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRuns(&b, bytes.TrimRight(row, "?")) // '?' is an empty sixel
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// sixelLevel maps a color channel to the 0-5 level of the color cube.
+func sixelLevel(v uint8) int {
+	return (int(v)*5 + 127) / 255
+}
+
+// writeSixelRuns writes row with runs of four or more equal sixels
+// run-length encoded.
+func writeSixelRuns(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i + 1
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n >= 4 {
+			fmt.Fprintf(b, "!%d%c", n, row[i])
+		} else {
+			b.Write(row[i:j])
+		}
+		i = j
+	}
+}
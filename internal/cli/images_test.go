@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// testPNG returns a w×h PNG: red on the left half, transparent on the right.
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w/2; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func imageOutputItem(atts ...attachment.Attachment) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: "call-1",
+		Output: &models.FunctionCallOutputPayload{Content: "done", Attachments: atts},
+	}
+}
+
+func TestParseImageProtocol(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	for _, tc := range []struct {
+		vars map[string]string
+		want ImageProtocol
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, ImagesKitty},
+		{map[string]string{"TERM": "xterm-ghostty", "TERM_PROGRAM": "ghostty"}, ImagesKitty},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, ImagesITerm2},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, ImagesITerm2},
+		{map[string]string{"TERM": "foot"}, ImagesSixel},
+		{map[string]string{"TERM": "xterm-256color"}, ImagesOff},
+		{map[string]string{"TERM": "xterm-kitty", "TMUX": "/tmp/tmux-1/default"}, ImagesOff},
+	} {
+		got, err := ParseImageProtocol("auto", env(tc.vars))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%v", tc.vars)
+	}
+
+	got, err := ParseImageProtocol("sixel", env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, ImagesSixel, got)
+	_, err = ParseImageProtocol("png", env(nil))
+	assert.Error(t, err)
+}
+
+func TestRenderAttachments_Placeholder(t *testing.T) {
+	r := newTestRenderer() // images off
+	out := r.RenderItem(imageOutputItem(
+		attachment.Attachment{Name: "plot.png", MimeType: "image/png", Size: 42 * 1024, Path: "/tmp/work/plot.png"},
+		attachment.Attachment{Name: "data.csv", MimeType: "text/csv", Size: 12},
+		attachment.Attachment{Name: "image-1.png", MimeType: "image/png", Size: 512},
+	), false)
+
+	assert.Contains(t, out, "[image: plot.png, 42.0 KB, saved to /tmp/work/plot.png]")
+	assert.Contains(t, out, "[attachment: data.csv, 12 B]")
+	assert.Contains(t, out, "[image: image-1.png, 512 B]")
+}
+
+func TestRenderAttachments_ArtifactLink(t *testing.T) {
+	r := newTestRenderer()
+	url := "http://worker:8089/artifacts/0f3a/coverage.html"
+	out := r.RenderItem(imageOutputItem(
+		attachment.Attachment{Name: "coverage.html", MimeType: "text/html", Size: 2048, URL: url, ExpiresAt: time.Now().Add(time.Hour)},
+	), false)
+	assert.Contains(t, out, "[artifact: coverage.html, 2.0 KB, link expires ")
+	assert.Contains(t, out, ansi.SetHyperlink(url)+url+ansi.ResetHyperlink())
+}
+
+func TestRenderAttachments_UndecodableFallsBack(t *testing.T) {
+	r := newTestRenderer()
+	r.images = ImagesKitty
+	out := r.RenderItem(imageOutputItem(
+		attachment.Attachment{Name: "broken.png", MimeType: "image/png", Size: 4, Data: []byte("nope")},
+	), false)
+	assert.Contains(t, out, "[image: broken.png, 4 B]")
+	assert.NotContains(t, out, "\x1b_G")
+}
+
+func TestRenderAttachments_Kitty(t *testing.T) {
+	r := newTestRenderer()
+	r.images = ImagesKitty
+	data := testPNG(t, 80, 40)
+	out := r.RenderItem(imageOutputItem(
+		attachment.Attachment{Name: "plot.png", MimeType: "image/png", Size: int64(len(data)), Data: data},
+	), false)
+
+	// 80×40 px is 10 columns by 3 rows of placeholders, then the caption.
+	assert.Contains(t, out, "\x1b_Ga=T,U=1,q=2,f=100,i=1,c=10,r=3,m=0;")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 5)
+	for row, line := range lines[1:4] {
+		want := "\x1b[38;5;1m" + string(kittyPlaceholder) + string(kittyDiacritics[row]) + string(kittyDiacritics[0]) +
+			strings.Repeat(string(kittyPlaceholder), 9) + "\x1b[39m"
+		assert.True(t, strings.HasSuffix(line, want), "row %d: %q", row, line)
+	}
+	assert.Contains(t, lines[4], "plot.png")
+
+	// The next image gets the next ID.
+	out = r.RenderItem(imageOutputItem(attachment.Attachment{Name: "b.png", MimeType: "image/png", Data: data}), false)
+	assert.Contains(t, out, ",i=2,")
+}
+
+func TestKittyTransmit_Chunks(t *testing.T) {
+	r := newTestRenderer()
+	_, ok := r.kittyTransmit([]byte("not an image"), 1, 1)
+	assert.False(t, ok)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 300))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919) // Poorly compressible, so it needs several chunks
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	seq, ok := r.kittyTransmit(buf.Bytes(), 10, 5)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(seq, "\x1b_Ga=T,U=1,q=2,f=100,i=1,c=10,r=5,m=1;"))
+	assert.Greater(t, strings.Count(seq, "\x1b_G"), 1)
+	assert.Equal(t, 1, strings.Count(seq, "m=0;"), "only the last chunk ends the transfer")
+	assert.True(t, strings.HasSuffix(seq, "\x1b\\"))
+}
+
+func TestRenderAttachments_ITerm2(t *testing.T) {
+	r := newTestRenderer()
+	r.images = ImagesITerm2
+	data := testPNG(t, 80, 40)
+	out := r.RenderItem(imageOutputItem(
+		attachment.Attachment{Name: "plot.png", MimeType: "image/png", Size: int64(len(data)), Data: data},
+	), false)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	require.Len(t, lines, 5, "output, 3 reserved rows, caption")
+	for _, line := range lines[1:4] {
+		assert.Empty(t, strings.TrimSpace(line))
+	}
+	// The caption line draws the image back up over the reserved rows.
+	assert.Contains(t, lines[4], "plot.png, 155 B\x1b7\x1b[3A\r\x1b[4C\x1b]1337;File=name=cGxvdC5wbmc=;size=")
+	assert.Contains(t, lines[4], ";width=10;height=3;preserveAspectRatio=1;inline=1:")
+	assert.True(t, strings.HasSuffix(lines[4], "\a\x1b8"))
+}
+
+func TestRenderAttachments_Sixel(t *testing.T) {
+	r := newTestRenderer()
+	r.images = ImagesSixel
+	data := testPNG(t, 80, 40)
+	out := r.RenderItem(imageOutputItem(
+		attachment.Attachment{Name: "plot.png", MimeType: "image/png", Size: int64(len(data)), Data: data},
+	), false)
+
+	// 10×3 cells at 8×16 px; only the red half is painted.
+	assert.Contains(t, out, "\x1bP0;1;0q\"1;1;80;48#180;2;100;0;0#180!40~-#180!40~-")
+	assert.Contains(t, out, "-\x1b\\\x1b8")
+}
+
+func TestImageCells(t *testing.T) {
+	cols, rows := imageCells(640, 480, 60)
+	assert.Equal(t, 42, cols, "narrowed to keep the aspect ratio")
+	assert.Equal(t, 16, rows, "capped")
+
+	cols, rows = imageCells(1600, 200, 60)
+	assert.Equal(t, 60, cols)
+	assert.Equal(t, 4, rows)
+
+	cols, rows = imageCells(100, 2000, 60)
+	assert.Equal(t, 1, cols)
+	assert.Equal(t, 16, rows)
+}
@@ -3,8 +3,10 @@ package cli
 import (
 	"time"
 
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/skills"
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
@@ -21,14 +23,14 @@ type WorkflowStartErrorMsg struct {
 	Err error
 }
 
-// PollResultMsg wraps a PollResult from the polling goroutine.
+// PollResultMsg wraps a watch.PollResult from the polling goroutine.
 type PollResultMsg struct {
-	Result PollResult
+	Result watch.PollResult
 }
 
-// WatchResultMsg wraps a WatchResult from the blocking watcher goroutine.
+// WatchResultMsg wraps a watch.Result from the blocking watcher goroutine.
 type WatchResultMsg struct {
-	Result WatchResult
+	Result watch.Result
 }
 
 // UserInputSentMsg is sent after user input has been successfully sent.
@@ -51,6 +53,16 @@ type InterruptErrorMsg struct {
 	Err error
 }
 
+// CancelToolSentMsg is sent after a cancel_tool request was accepted.
+type CancelToolSentMsg struct {
+	ToolName string
+}
+
+// CancelToolErrorMsg is sent when cancelling a tool call fails.
+type CancelToolErrorMsg struct {
+	Err error
+}
+
 // ShutdownSentMsg is sent after a shutdown has been successfully sent.
 type ShutdownSentMsg struct{}
 
@@ -93,6 +105,14 @@ type UserInputQuestionErrorMsg struct {
 	Err error
 }
 
+// AskUserSentMsg is sent after an ask_user answer has been sent.
+type AskUserSentMsg struct{}
+
+// AskUserErrorMsg is sent when sending an ask_user answer fails.
+type AskUserErrorMsg struct {
+	Err error
+}
+
 // CompactSentMsg is sent after a compact request has been successfully sent.
 type CompactSentMsg struct{}
 
@@ -142,6 +162,12 @@ type SuggestionPollMsg struct {
 	Suggestion string
 }
 
+// LLMProgressMsg carries streaming progress read from the pending LLM
+// activity's heartbeat details. Progress is nil when nothing has streamed yet.
+type LLMProgressMsg struct {
+	Progress *llm.StreamProgress
+}
+
 // DiffResultMsg is sent when the background git diff completes.
 type DiffResultMsg struct {
 	Output string
@@ -204,6 +230,30 @@ type McpToolsErrorMsg struct {
 	Err error
 }
 
+// CapabilitiesResultMsg is sent when the capabilities query completes.
+// WarnOnly results come from the check made when a session attaches and
+// only surface missing tools.
+type CapabilitiesResultMsg struct {
+	Caps     workflow.CapabilitiesResponse
+	WarnOnly bool
+}
+
+// CapabilitiesErrorMsg is sent when the capabilities query fails.
+type CapabilitiesErrorMsg struct {
+	Err error
+}
+
+// SearchResultMsg is sent when a /search history query completes.
+type SearchResultMsg struct {
+	Query workflow.HistoryQuery
+	Page  workflow.HistoryPage
+}
+
+// SearchErrorMsg is sent when a /search history query fails.
+type SearchErrorMsg struct {
+	Err error
+}
+
 // ExecSessionsResultMsg is sent when the exec sessions list is fetched.
 type ExecSessionsResultMsg struct {
 	Sessions []workflow.ExecSessionSummary
@@ -224,6 +274,158 @@ type CleanExecSessionsErrorMsg struct {
 	Err error
 }
 
+// WriteExecStdinResultMsg is sent when /sessions write reaches the process.
+type WriteExecStdinResultMsg struct {
+	ProcessID string
+	Output    string
+	Exited    bool
+}
+
+// WriteExecStdinErrorMsg is sent when writing to an exec session fails.
+type WriteExecStdinErrorMsg struct {
+	Err error
+}
+
+// TaskListResultMsg is sent when a /todo edit is applied.
+type TaskListResultMsg struct {
+	Tasks []workflow.TaskItem
+}
+
+// TaskListErrorMsg is sent when a /todo edit fails.
+type TaskListErrorMsg struct {
+	Err error
+}
+
+// TrustResultMsg is sent when a /trust list or revoke completes.
+type TrustResultMsg struct {
+	Commands []workflow.TrustedCommand
+	Revoked  bool
+}
+
+// TrustErrorMsg is sent when a /trust action fails.
+type TrustErrorMsg struct {
+	Err error
+}
+
+// PinResultMsg is sent when a /pin or /unpin completes.
+type PinResultMsg struct {
+	Items  []workflow.PinnedItem
+	Action workflow.PinAction
+	Seq    int
+}
+
+// PinErrorMsg is sent when a /pin or /unpin fails.
+type PinErrorMsg struct {
+	Err error
+}
+
+// ContextResultMsg is sent when /context lists the context, or when a
+// /context drop or summarize completes. Edit is the Update that ran, empty
+// for the listing.
+type ContextResultMsg struct {
+	View    workflow.ContextView
+	Edit    string
+	Removed int
+}
+
+// ContextErrorMsg is sent when /context fails.
+type ContextErrorMsg struct {
+	Err error
+}
+
+// AnnotateResultMsg is sent when a /note or /react is recorded.
+type AnnotateResultMsg struct {
+	TargetSeq int
+}
+
+// AnnotateErrorMsg is sent when a /note or /react fails.
+type AnnotateErrorMsg struct {
+	Err error
+}
+
+// PauseResultMsg is sent when a /pause or /unpause completes.
+type PauseResultMsg struct {
+	Response workflow.PauseResponse
+}
+
+// PauseErrorMsg is sent when a /pause or /unpause fails.
+type PauseErrorMsg struct {
+	Err error
+}
+
+// RemapCwdResultMsg is sent when /cwd moved the session.
+type RemapCwdResultMsg struct {
+	Response workflow.RemapCwdResponse
+}
+
+// RemapCwdErrorMsg is sent when /cwd fails.
+type RemapCwdErrorMsg struct {
+	Err error
+}
+
+// UserShellResultMsg is sent when a /!cmd command finished. Its output is
+// shown from the user_shell_command item the workflow records.
+type UserShellResultMsg struct {
+	Response workflow.UserShellResponse
+}
+
+// UserShellErrorMsg is sent when a /!cmd command could not run.
+type UserShellErrorMsg struct {
+	Err error
+}
+
+// ConsultResultMsg is sent when the /consult models answered.
+type ConsultResultMsg struct {
+	Response workflow.ConsultResponse
+}
+
+// ConsultErrorMsg is sent when /consult fails.
+type ConsultErrorMsg struct {
+	Err error
+}
+
+// ConsultAdoptedMsg is sent when a /consult answer was recorded.
+type ConsultAdoptedMsg struct {
+	Model string
+	Mode  workflow.ConsultAdoptMode
+}
+
+// ConsultAdoptErrorMsg is sent when recording a /consult answer fails.
+type ConsultAdoptErrorMsg struct {
+	Err error
+}
+
+// SnapshotWorkspaceResultMsg is sent when a manual workspace snapshot is taken.
+// Snapshot is nil when the workspace is not a git repository.
+type SnapshotWorkspaceResultMsg struct {
+	Snapshot *workflow.WorkspaceSnapshot
+}
+
+// SnapshotWorkspaceErrorMsg is sent when taking a workspace snapshot fails.
+type SnapshotWorkspaceErrorMsg struct {
+	Err error
+}
+
+// RollbackWorkspaceResultMsg is sent when the workspace is rolled back.
+type RollbackWorkspaceResultMsg struct {
+	Response workflow.RollbackWorkspaceResponse
+}
+
+// RollbackWorkspaceErrorMsg is sent when a workspace rollback fails.
+type RollbackWorkspaceErrorMsg struct {
+	Err error
+}
+
+// ImportContextResultMsg is sent when /import adds another session's summary.
+type ImportContextResultMsg struct {
+	Response workflow.ImportContextResponse
+}
+
+// ImportContextErrorMsg is sent when /import fails.
+type ImportContextErrorMsg struct {
+	Err error
+}
+
 // HarnessSessionsMsg is returned when the harness's session list is fetched successfully.
 type HarnessSessionsMsg struct {
 	Sessions []workflow.SessionEntry
@@ -238,9 +440,13 @@ type HarnessSessionsErrorMsg struct {
 type SessionListEntry struct {
 	WorkflowID string
 	StartTime  time.Time
-	Status     string // "running", "completed", "errored", etc.
-	Name       string // User-assigned session name (from /rename)
-	Model      string // Model identifier
+	Status     string   // "running", "completed", "errored", etc.
+	Name       string   // User-assigned session name (from /rename)
+	Title      string   // Generated session title (via memo)
+	Model      string   // Model identifier
+	Tags       []string // User-assigned tags (from `client tag`, via memo)
+	Note       string   // User-assigned note (from `client tag`, via memo)
+	Paused     bool     // Session is paused (via memo)
 }
 
 // HarnessSessionsListMsg is sent when the session list fetch completes.
@@ -280,6 +486,31 @@ type SessionNameErrorMsg struct {
 	Err error
 }
 
+// ModelConfigResultMsg is sent when a /config completes. Changed is false
+// for a plain listing.
+type ModelConfigResultMsg struct {
+	Response workflow.UpdateModelConfigResponse
+	Changed  bool
+}
+
+// ModelConfigErrorMsg is sent when a /config fails (e.g. a value the model
+// does not accept).
+type ModelConfigErrorMsg struct {
+	Err error
+}
+
+// EnvResultMsg is sent when a /env completes. Changed is false for a plain
+// listing.
+type EnvResultMsg struct {
+	Env     map[string]string
+	Changed bool
+}
+
+// EnvErrorMsg is sent when a /env fails (e.g. a denied variable).
+type EnvErrorMsg struct {
+	Err error
+}
+
 // ReasoningEffortUpdateSentMsg is sent after a reasoning effort update succeeds.
 type ReasoningEffortUpdateSentMsg struct {
 	Effort string
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,11 +14,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
 	"github.com/mfateev/temporal-agent-harness/internal/skills"
 	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
 	"github.com/mfateev/temporal-agent-harness/internal/version"
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
@@ -87,12 +91,19 @@ const (
 	StateApproval
 	StateEscalation
 	StateUserInputQuestion
+	StateAskUser // answering a free-text ask_user question
 	StateShutdown
 )
 
 // Config holds CLI configuration.
 type Config struct {
 	TemporalHost string
+
+	// Standby Temporal endpoint for read failover while the primary is
+	// unreachable. Either one enables failover; empty values fall back to
+	// TEMPORAL_STANDBY_HOST_URL / TEMPORAL_STANDBY_NAMESPACE.
+	StandbyTemporalHost string
+	StandbyNamespace    string
 	Message      string // Initial message for new workflow
 	Model        string
 	NoMarkdown   bool
@@ -109,10 +120,26 @@ type Config struct {
 	MemoryEnabled bool   // Enable cross-session memory
 	MemoryDbPath  string // Override memory SQLite DB path
 
+	// EnableTools adds tools or tool groups to new sessions (e.g. from a
+	// session template).
+	EnableTools []string
+
+	// MaxConcurrentSessions caps running sessions when this invocation starts
+	// the harness for the working directory. 0 = unlimited.
+	MaxConcurrentSessions int
+
+	// ArchiveURL sets the transcript archive location when this invocation
+	// starts the harness for the working directory.
+	ArchiveURL string
+
 	// TUI settings
-	Provider           string // LLM provider (openai, anthropic, google)
-	Inline             bool   // Disable alt-screen mode
-	DisableSuggestions bool   // Disable prompt suggestions
+	Provider           string        // LLM provider (openai, anthropic, bedrock, vertex)
+	Inline             bool          // Disable alt-screen mode
+	DisableSuggestions bool          // Disable prompt suggestions
+	FoldLines          int           // Items taller than this render collapsed (0 = default, <0 = never)
+	Images             ImageProtocol // How image attachments are shown ("" = off)
+	Locale             Locale        // Language of the TUI's own text ("" = English)
+	Theme              Theme         // Colors and markdown style (zero = dark theme)
 
 	// ConnectionTimeout limits how long each Temporal RPC waits before giving up.
 	// 0 means no per-call timeout (default for interactive use).
@@ -128,10 +155,12 @@ type Config struct {
 // Model is the bubbletea model for the interactive CLI.
 type Model struct {
 	// Configuration
-	config Config
-	client client.Client
-	keys   KeyMap
+	config        Config
+	client        client.Client
+	dataConverter converter.DataConverter // Decodes heartbeat details; nil means SDK default
+	keys          KeyMap
 	styles Styles
+	theme  Theme // Switched with /theme
 
 	// State machine
 	state           State
@@ -148,8 +177,15 @@ type Model struct {
 	height int
 	ready  bool
 
-	// Viewport content
+	// Viewport content: the concatenated views of blocks. expandAll is the
+	// /expandall toggle.
 	viewportContent string
+	blocks          []viewportBlock
+	expandAll       bool
+
+	// showPanes is the /panes toggle: a sidebar with the live plan,
+	// approvals, running tools and child agents.
+	showPanes bool
 
 	// Renderer
 	renderer *ItemRenderer
@@ -159,9 +195,15 @@ type Model struct {
 	reasoningEffort   string
 	totalTokens       int
 	totalCachedTokens int
+	cacheHitRate      int // Percent of prompt tokens served from the provider cache
+	suggestionTokens  int // Spent on prompt suggestions, not in totalTokens
 	contextWindowPct  int
 	turnCount         int
 	spinnerMsg        string
+	toolsInFlight     []workflow.ToolInFlight // running tools, shown as progress lines under the spinner
+	selectedToolCall  string                  // in-flight call chosen with Tab; x cancels it
+	phaseStartedAt    time.Time               // start of the LLM call behind the spinner; zero if untimed
+	phaseTimeout      time.Duration           // per-attempt timeout of that call
 	workerVersion     string
 	sessionName       string
 
@@ -169,9 +211,11 @@ type Model struct {
 	pendingApprovals   []workflow.PendingApproval
 	autoApprove        bool
 	pendingEscalations []workflow.EscalationRequest
+	turnEpoch          int // TurnStatus.TurnEpoch of the pending prompt, echoed in the response
 
 	// User input question state
 	pendingUserInputReq *workflow.PendingUserInputRequest
+	pendingAskUser      *workflow.PendingAskUserRequest
 
 	// Selector (replaces textarea for approval/escalation/user-input states)
 	selector *SelectorModel
@@ -184,6 +228,14 @@ type Model struct {
 	// Plan rendering (update_plan tool)
 	lastRenderedPlan *workflow.PlanState
 
+	// Shared task list (task_list tool, /todo), rendered above the input area
+	tasks []workflow.TaskItem
+
+	// Child agents from the latest TurnStatus, listed in the /panes sidebar
+	childAgents []workflow.ChildAgentSummary
+	// Running exec_command processes, for /sessions.
+	execSessions []workflow.ExecSessionStatus
+
 	// Prompt suggestion (ghost text shown as placeholder after turn completes)
 	suggestion string
 
@@ -194,12 +246,22 @@ type Model struct {
 	// Ctrl+C tracking
 	lastInterruptTime time.Time
 
+	// paused mirrors the session's pause (TurnStatus.Paused).
+	paused bool
+
+	// degraded is set while the Temporal primary is unreachable and the
+	// session is shown read-only from the standby endpoint.
+	degraded bool
+
 	// Watching (blocking get_state_update)
-	watchCh           chan WatchResult
+	watchCh           chan watch.Result
 	watchCancel       context.CancelFunc
 	lastPhase         workflow.TurnPhase
 	consecutiveErrors int
 
+	// LLM streaming progress (read from the pending activity's heartbeats)
+	llmProgressPolling bool
+
 	// Error/exit state
 	err      error
 	quitting bool
@@ -226,6 +288,11 @@ type Model struct {
 	// /reasoning command state
 	selectingReasoning bool
 
+	// /consult command state
+	selectingConsult bool
+	consult          *workflow.ConsultResponse
+	consultChoices   []*consultChoice
+
 	// /skills command state
 	skillsToggleMode bool
 	selectingSkill   bool
@@ -241,7 +308,11 @@ type Model struct {
 
 // NewModel creates a new bubbletea model.
 func NewModel(config Config, c client.Client) Model {
-	styles := DefaultStyles()
+	theme := config.Theme
+	if theme.Name == "" {
+		theme = builtinThemes[ThemeDark]
+	}
+	styles := theme.Styles()
 	if config.NoColor {
 		styles = NoColorStyles()
 	}
@@ -274,14 +345,15 @@ func NewModel(config Config, c client.Client) Model {
 		client:          c,
 		keys:            DefaultKeyMap(),
 		styles:          styles,
+		theme:           theme,
 		state:           initialState,
 		lastRenderedSeq: -1,
 		textarea:        ta,
 		spinner:         sp,
-		watchCh:         make(chan WatchResult, 1),
+		watchCh:         make(chan watch.Result, 1),
 		modelName:       config.Model,
 		provider:        config.Provider,
-		harnessID:       harnessWorkflowID(cwd),
+		harnessID:       HarnessWorkflowID(cwd),
 	}
 
 	// Initialize reasoning effort from model profile
@@ -309,8 +381,8 @@ func (m Model) Init() tea.Cmd {
 		if cwd == "" {
 			cwd, _ = os.Getwd()
 		}
-		harnessID := harnessWorkflowID(cwd)
-		cmds = append(cmds, fetchSessionsCmd(m.client, harnessID))
+		harnessID := HarnessWorkflowID(cwd)
+		cmds = append(cmds, fetchSessionsCmd(m.client, m.dataConverter, harnessID))
 	}
 
 	return tea.Batch(cmds...)
@@ -363,7 +435,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return &m, nil
 
 	case WorkflowStartedMsg:
-		return m.handleWorkflowStarted(msg)
+		model, cmd := m.handleWorkflowStarted(msg)
+		return model, tea.Batch(cmd, queryCapabilitiesCmd(m.client, msg.WorkflowID, true))
 
 	case WorkflowStartErrorMsg:
 		m.err = msg.Err
@@ -384,6 +457,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update status from snapshot
 		m.totalTokens = msg.Response.Status.TotalTokens
 		m.totalCachedTokens = msg.Response.Status.TotalCachedTokens
+		m.cacheHitRate = msg.Response.Status.CacheHitRate
+		m.suggestionTokens = msg.Response.Status.SuggestionUsage.Tokens()
 		m.contextWindowPct = msg.Response.Status.ContextWindowRemaining
 		m.turnCount = msg.Response.Status.TurnCount
 		if msg.Response.Status.WorkerVersion != "" {
@@ -404,6 +479,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case InterruptErrorMsg:
 		m.appendToViewport(fmt.Sprintf("Error sending interrupt: %v\n", msg.Err))
 
+	case CancelToolSentMsg:
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Cancelling %s...", msg.ToolName)))
+
+	case CancelToolErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error cancelling tool: %v\n", msg.Err))
+
 	case ShutdownSentMsg:
 		if m.plannerActive {
 			// In plan mode: shutdown was sent to the planner child.
@@ -425,6 +506,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.startWatching())
 
 	case ApprovalErrorMsg:
+		if isStaleResponse(msg.Err) {
+			// The turn was interrupted or moved on; follow it instead.
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Approval no longer pending; the turn has moved on.")))
+			m.pendingApprovals = nil
+			m.selector = nil
+			m.state = StateWatching
+			cmds = append(cmds, m.startWatching())
+			break
+		}
 		m.appendToViewport(fmt.Sprintf("Error sending approval: %v\n", msg.Err))
 
 	case EscalationSentMsg:
@@ -435,10 +525,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.startWatching())
 
 	case EscalationErrorMsg:
+		if isStaleResponse(msg.Err) {
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Escalation no longer pending; the turn has moved on.")))
+			m.pendingEscalations = nil
+			m.selector = nil
+			m.state = StateWatching
+			cmds = append(cmds, m.startWatching())
+			break
+		}
 		m.appendToViewport(fmt.Sprintf("Error sending escalation response: %v\n", msg.Err))
 
 	case CompactSentMsg:
-		m.appendToViewport(m.renderer.RenderSystemMessage("Context compacted."))
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Context compacted.")))
 		m.state = StateWatching
 		m.spinnerMsg = "Compacting..."
 		cmds = append(cmds, m.startWatching())
@@ -460,7 +558,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.reasoningEffort = ""
 		}
 		m.appendToViewport(m.renderer.RenderSystemMessage(
-			fmt.Sprintf("Model updated to %s (%s).", msg.Model, msg.Provider)))
+			m.t("Model updated to %s (%s).", msg.Model, msg.Provider)))
 		m.selectingModel = false
 		m.selector = nil
 		m.state = StateInput
@@ -481,7 +579,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// If user is waiting for the selector, show it now
 		if m.selectingModel {
-			m.appendToViewport(m.renderer.RenderSystemMessage("Select a model (Esc to cancel):"))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Select a model (Esc to cancel):")))
 			m.selector = NewSelectorModel(modelSelectorOptions(m.currentModelOptions()), m.styles)
 			m.selector.SetWidth(m.width)
 			m.state = StateInput
@@ -497,6 +595,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case UserInputQuestionErrorMsg:
 		m.appendToViewport(fmt.Sprintf("Error sending user input response: %v\n", msg.Err))
 
+	case AskUserSentMsg:
+		m.pendingAskUser = nil
+		m.state = StateWatching
+		m.spinnerMsg = "Processing answer..."
+		cmds = append(cmds, m.startWatching())
+
+	case AskUserErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error sending answer: %v\n", msg.Err))
+		cmds = append(cmds, m.focusTextarea())
+
 	case PlanRequestAcceptedMsg:
 		return m.handlePlanRequestAccepted(msg)
 
@@ -508,6 +616,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SuggestionPollMsg:
 		return m.handleSuggestionPoll(msg)
 
+	case LLMProgressMsg:
+		return m.handleLLMProgress(msg)
+
 	case PlannerCompletedMsg:
 		return m.handlePlannerCompleted(msg)
 
@@ -550,23 +661,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Reset state for the new session
 		m.stopWatching()
 		m.viewportContent = ""
+		m.blocks = nil
 		m.viewport.SetContent("")
 		m.lastRenderedSeq = -1
 		m.totalTokens = 0
 		m.totalCachedTokens = 0
+		m.cacheHitRate = 0
+		m.suggestionTokens = 0
 		m.contextWindowPct = 100
 		m.turnCount = 0
 		m.workerVersion = ""
+		m.paused = false
 		m.lastPhase = ""
 		m.consecutiveErrors = 0
 		m.plannerActive = false
 		m.suggestion = ""
 		m.workflowID = msg.WorkflowID
 		m.appendToViewport(m.renderer.RenderSystemMessage(
-			fmt.Sprintf("Started new session %s", msg.WorkflowID)))
+			m.t("Started new session %s", msg.WorkflowID)))
 		m.state = StateWatching
 		m.spinnerMsg = "Thinking..."
-		cmds = append(cmds, m.startWatching())
+		cmds = append(cmds, m.startWatching(), queryCapabilitiesCmd(m.client, msg.WorkflowID, true))
 
 	case NewSessionErrorMsg:
 		m.appendToViewport(fmt.Sprintf("Error starting new session: %v\n", msg.Err))
@@ -575,10 +690,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case PersonalityUpdateSentMsg:
 		if msg.Personality == "" {
-			m.appendToViewport(m.renderer.RenderSystemMessage("Personality cleared."))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Personality cleared.")))
 		} else {
 			m.appendToViewport(m.renderer.RenderSystemMessage(
-				fmt.Sprintf("Personality set to: %s", msg.Personality)))
+				m.t("Personality set to: %s", msg.Personality)))
 		}
 		m.state = StateInput
 		cmds = append(cmds, m.focusTextarea())
@@ -591,7 +706,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case SessionNameSentMsg:
 		m.sessionName = msg.Name
 		m.appendToViewport(m.renderer.RenderSystemMessage(
-			fmt.Sprintf("Session renamed to %q.", msg.Name)))
+			m.t("Session renamed to %q.", msg.Name)))
 		m.state = StateInput
 		cmds = append(cmds, m.focusTextarea())
 
@@ -602,7 +717,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case ApprovalModeUpdateSentMsg:
 		m.appendToViewport(m.renderer.RenderSystemMessage(
-			fmt.Sprintf("Approval mode updated to %s.", msg.Mode)))
+			m.t("Approval mode updated to %s.", msg.Mode)))
 		m.selectingApprovalMode = false
 		m.selector = nil
 		m.state = StateInput
@@ -615,10 +730,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = StateInput
 		cmds = append(cmds, m.focusTextarea())
 
+	case ModelConfigResultMsg:
+		if msg.Changed {
+			m.reasoningEffort = string(msg.Response.Model.ReasoningEffort)
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Model parameters updated; they apply from the next LLM call.")))
+		}
+		m.appendToViewport(formatModelConfigDisplay(msg.Response))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ModelConfigErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error updating model parameters: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case EnvResultMsg:
+		if msg.Changed {
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Session environment updated.")))
+		}
+		m.appendToViewport(formatEnvDisplay(msg.Env))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case EnvErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error updating environment: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
 	case ReasoningEffortUpdateSentMsg:
 		m.reasoningEffort = msg.Effort
 		m.appendToViewport(m.renderer.RenderSystemMessage(
-			fmt.Sprintf("Reasoning effort updated to %s.", msg.Effort)))
+			m.t("Reasoning effort updated to %s.", msg.Effort)))
 		m.selectingReasoning = false
 		m.selector = nil
 		m.state = StateInput
@@ -634,10 +776,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case InitResultMsg:
 		if msg.AlreadyExists {
 			m.appendToViewport(m.renderer.RenderSystemMessage(
-				fmt.Sprintf("AGENTS.md already exists at %s", msg.Path)))
+				m.t("AGENTS.md already exists at %s", msg.Path)))
 		} else if msg.Created {
 			m.appendToViewport(m.renderer.RenderSystemMessage(
-				fmt.Sprintf("Created AGENTS.md at %s", msg.Path)))
+				m.t("Created AGENTS.md at %s", msg.Path)))
 		}
 
 	case InitErrorMsg:
@@ -669,6 +811,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = StateInput
 		cmds = append(cmds, m.focusTextarea())
 
+	case CapabilitiesResultMsg:
+		if msg.WarnOnly {
+			if len(msg.Caps.MissingTools) > 0 {
+				m.appendToViewport(m.renderer.RenderSystemMessage(m.t(
+					"Worker %s lacks tools enabled for this session: %s",
+					msg.Caps.Worker.Version, strings.Join(msg.Caps.MissingTools, ", "))))
+			}
+			if msg.Caps.ProjectPolicy != nil {
+				m.appendToViewport(m.renderer.RenderSystemMessage(formatProjectPolicy(msg.Caps.ProjectPolicy)))
+			}
+			break
+		}
+		m.appendToViewport(formatCapabilitiesDisplay(msg.Caps))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case CapabilitiesErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error fetching worker capabilities: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case SearchResultMsg:
+		m.appendToViewport(formatSearchResults(msg.Query, msg.Page))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case SearchErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error searching history: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
 	case ExecSessionsResultMsg:
 		m.appendToViewport(formatExecSessionsDisplay(msg.Sessions))
 		m.state = StateInput
@@ -693,10 +866,200 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = StateInput
 		cmds = append(cmds, m.focusTextarea())
 
+	case WriteExecStdinResultMsg:
+		m.appendToViewport(fmt.Sprintf("Sent to exec session %s.\n", msg.ProcessID))
+		if out := strings.TrimRight(msg.Output, "\n"); out != "" {
+			m.appendToViewport(out + "\n")
+		}
+		if msg.Exited {
+			m.appendToViewport("(process exited)\n")
+		}
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case WriteExecStdinErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error writing to exec session: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case TaskListResultMsg:
+		m.tasks = msg.Tasks
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case TaskListErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error updating task list: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case TrustResultMsg:
+		if msg.Revoked {
+			m.appendToViewport("Revoked. The command needs approval again.\n")
+		}
+		m.appendToViewport(formatTrustedCommandsDisplay(msg.Commands))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case TrustErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error updating trusted commands: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case PinResultMsg:
+		switch msg.Action {
+		case workflow.PinPin:
+			m.appendToViewport(fmt.Sprintf("Pinned #%d. It will be kept verbatim through compaction.\n", msg.Seq))
+		case workflow.PinUnpin:
+			m.appendToViewport(fmt.Sprintf("Unpinned #%d.\n", msg.Seq))
+		default:
+			m.appendToViewport(formatPinnedDisplay(msg.Items))
+		}
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case PinErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error updating pins: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ContextResultMsg:
+		switch msg.Edit {
+		case workflow.UpdateDropContextItems:
+			m.appendToViewport(fmt.Sprintf("Dropped %d items. Item numbers have changed:\n", msg.Removed))
+		case workflow.UpdateSummarizeContextItems:
+			m.appendToViewport(fmt.Sprintf("Replaced %d items with a summary. Item numbers have changed:\n", msg.Removed))
+		}
+		m.appendToViewport(formatContextDisplay(msg.View))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ContextErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error managing context: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case AnnotateResultMsg:
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case AnnotateErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error annotating: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case PauseResultMsg:
+		if p := msg.Response.Paused; p != nil {
+			m.paused = true
+			m.appendToViewport(m.renderer.RenderSystemMessage(
+				m.t("Session paused. New messages are rejected until /unpause; exec sessions stay alive.")))
+		} else {
+			m.paused = false
+			m.appendToViewport(m.renderer.RenderSystemMessage(
+				m.t("Session resumed after %s.", msg.Response.PausedFor.Round(time.Second))))
+		}
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case RemapCwdResultMsg:
+		note := m.t("No project docs (AGENTS.md) found there.")
+		if msg.Response.ProjectDocs {
+			note = m.t("Project docs reloaded from there.")
+		}
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t(
+			"Working directory is now %s (was %s). %s", msg.Response.Cwd, msg.Response.PreviousCwd, note)))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case UserShellResultMsg:
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case UserShellErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error running command: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ConsultResultMsg:
+		m.appendToViewport(m.renderer.RenderConsultAnswers(msg.Response))
+		resp := msg.Response
+		opts, choices := consultSelectorOptions(resp)
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Pick an answer to keep (Esc to discard):")))
+		m.consult = &resp
+		m.consultChoices = choices
+		m.selector = NewSelectorModel(opts, m.styles)
+		m.selector.SetWidth(m.width)
+		m.selectingConsult = true
+		m.state = StateInput
+		m.textarea.Blur()
+
+	case ConsultErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error consulting models: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ConsultAdoptedMsg:
+		note := m.t("Kept %s's answer as the answer.", msg.Model)
+		if msg.Mode == workflow.ConsultAdoptContext {
+			note = m.t("Added %s's answer as context for the next turn.", msg.Model)
+		}
+		m.appendToViewport(m.renderer.RenderSystemMessage(note))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ConsultAdoptErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error keeping answer: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case RemapCwdErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error moving session: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case PauseErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case SnapshotWorkspaceResultMsg:
+		if msg.Snapshot == nil {
+			m.appendToViewport("Workspace is not a git repository; nothing to snapshot.\n")
+		} else {
+			m.appendToViewport(fmt.Sprintf("Saved workspace snapshot %s (%s).\n", msg.Snapshot.ID, shortCommit(msg.Snapshot.Commit)))
+		}
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case SnapshotWorkspaceErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error taking workspace snapshot: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case RollbackWorkspaceResultMsg:
+		m.appendToViewport(formatRollbackDisplay(msg.Response))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case RollbackWorkspaceErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error rolling back workspace: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ImportContextResultMsg:
+		m.appendToViewport(fmt.Sprintf("Imported context from %s (%d items summarized).\n",
+			msg.Response.SourceWorkflowID, msg.Response.ItemCount))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
+	case ImportContextErrorMsg:
+		m.appendToViewport(fmt.Sprintf("Error importing context: %v\n", msg.Err))
+		m.state = StateInput
+		cmds = append(cmds, m.focusTextarea())
+
 	case SkillsListResultMsg:
 		if m.skillsToggleMode && len(msg.Skills) > 0 {
 			// Show toggle selector
-			m.appendToViewport(m.renderer.RenderSystemMessage("Toggle skills (Esc to cancel):"))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Toggle skills (Esc to cancel):")))
 			m.selector = buildSkillsToggleSelector(msg.Skills, m.disabledSkills, m.styles)
 			m.selector.SetWidth(m.width)
 			m.selectingSkill = true
@@ -751,11 +1114,36 @@ func (m Model) View() string {
 	}
 
 	if !m.ready {
-		return m.styles.SpinnerMessage.Render(m.spinner.View() + " Starting...")
+		return m.styles.SpinnerMessage.Render(m.spinner.View() + " " + m.t("Starting..."))
 	}
 
-	// Build viewport content
+	// Task panel sits above the input area and tool progress lines below the
+	// spinner; shrink this frame's copy of the viewport to make room, keeping
+	// the newest output visible.
+	taskPanel := m.renderer.RenderTaskPanel(m.tasks, maxTaskPanelItems)
+	extraHeight := 0
+	if taskPanel != "" {
+		extraHeight += lipgloss.Height(taskPanel)
+	}
+	var progressLines []ProgressLine
+	if m.state == StateWatching {
+		progressLines = ToolProgressLines(m.toolsInFlight, time.Now())
+		markSelectedToolLine(progressLines, m.selectedToolCallID())
+		extraHeight += len(progressLines)
+	}
+	if extraHeight > 0 {
+		atBottom := m.viewport.AtBottom()
+		m.viewport.Height = max(1, m.viewport.Height-extraHeight)
+		if atBottom {
+			m.viewport.GotoBottom()
+		}
+	}
+
+	// Build viewport content, with the /panes sidebar beside it
 	vpView := m.viewport.View()
+	if sidebar := m.renderSidebar(m.viewport.Height, time.Now()); sidebar != "" {
+		vpView = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.viewport.Width).Render(vpView), sidebar)
+	}
 
 	// Separator
 	sep := m.styles.Separator.Render(strings.Repeat("─", m.width))
@@ -770,14 +1158,16 @@ func (m Model) View() string {
 		if m.selector != nil {
 			inputView = m.selector.View()
 		} else {
-			inputView = m.spinner.View() + " " + m.styles.SpinnerMessage.Render("Loading sessions...")
+			inputView = m.spinner.View() + " " + m.styles.SpinnerMessage.Render(m.t("Loading sessions..."))
 		}
 	case StateInput:
-		if (m.selectingModel || m.selectingApprovalMode || m.selectingReasoning || m.selectingSkill) && m.selector != nil {
+		if (m.selectingModel || m.selectingApprovalMode || m.selectingReasoning || m.selectingSkill || m.selectingConsult) && m.selector != nil {
 			inputView = m.selector.View()
 		} else {
 			inputView = m.textarea.View()
 		}
+	case StateAskUser:
+		inputView = m.textarea.View()
 	case StateApproval, StateEscalation, StateUserInputQuestion:
 		if m.selector != nil {
 			inputView = m.selector.View()
@@ -786,12 +1176,31 @@ func (m Model) View() string {
 		}
 	default:
 		// Watching/Startup: show spinner
-		inputView = m.spinner.View() + " " + m.styles.SpinnerMessage.Render(m.spinnerMsg)
+		inputView = m.spinner.View() + " " + m.styles.SpinnerMessage.Render(m.t(m.spinnerMsg))
+		if m.state == StateWatching {
+			if !m.phaseStartedAt.IsZero() {
+				elapsed, near := FormatActivityTime(m.phaseStartedAt, m.phaseTimeout, time.Now())
+				inputView += " " + m.progressStyle(near).Render(elapsed)
+			}
+			for _, line := range progressLines {
+				inputView += "\n" + m.progressStyle(line.NearTimeout).Render(line.Text)
+			}
+		}
 	}
 
 	// Bottom separator below input (matches Claude Code layout)
 	sepBottom := sep
 
+	if taskPanel != "" {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			vpView,
+			sep,
+			taskPanel,
+			inputView,
+			sepBottom,
+			statusBar,
+		)
+	}
 	return lipgloss.JoinVertical(lipgloss.Left,
 		vpView,
 		sep,
@@ -801,6 +1210,11 @@ func (m Model) View() string {
 	)
 }
 
+// t translates the TUI's own text into the configured locale.
+func (m Model) t(msg string, args ...interface{}) string {
+	return m.config.Locale.T(msg, args...)
+}
+
 func (m Model) renderStatusBar() string {
 	model := m.modelName
 	if m.provider != "" && m.provider != "openai" {
@@ -809,13 +1223,13 @@ func (m Model) renderStatusBar() string {
 
 	tokens := formatTokens(m.totalTokens)
 	if m.totalCachedTokens > 0 {
-		tokens += fmt.Sprintf(" (%s cached)", formatTokens(m.totalCachedTokens))
+		tokens += " (" + m.t("%s cached", formatTokens(m.totalCachedTokens)) + ")"
 	}
 	ctxPct := ""
 	if m.contextWindowPct < 100 {
-		ctxPct = fmt.Sprintf(" · ctx %d%%", m.contextWindowPct)
+		ctxPct = " · " + m.t("ctx %d%%", m.contextWindowPct)
 	}
-	turn := fmt.Sprintf("turn %d", m.turnCount)
+	turn := m.t("turn %d", m.turnCount)
 
 	var stateLabel string
 	if m.plannerActive {
@@ -839,7 +1253,7 @@ func (m Model) renderStatusBar() string {
 			stateLabel = "approval"
 		case StateEscalation:
 			stateLabel = "escalation"
-		case StateUserInputQuestion:
+		case StateUserInputQuestion, StateAskUser:
 			stateLabel = "question"
 		case StateStartup:
 			stateLabel = "connecting"
@@ -847,12 +1261,18 @@ func (m Model) renderStatusBar() string {
 			stateLabel = ""
 		}
 	}
+	if m.paused && m.state == StateInput {
+		stateLabel = "paused"
+	}
+	if m.degraded {
+		stateLabel = "standby (read-only)"
+	}
 
 	wv := m.workerVersion
 	if wv == "" {
 		wv = "?"
 	}
-	left := fmt.Sprintf(" %s · %s tokens%s · %s · %s", model, tokens, ctxPct, turn, stateLabel)
+	left := fmt.Sprintf(" %s · %s%s · %s · %s", model, m.t("%s tokens", tokens), ctxPct, turn, m.t(stateLabel))
 	right := fmt.Sprintf("cli:%s · worker:%s ", version.GitCommit, wv)
 	gap := m.width - lipgloss.Width(left) - lipgloss.Width(right)
 	if gap < 1 {
@@ -874,10 +1294,12 @@ func (m *Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	}
 
 	if !m.ready {
-		m.viewport = viewport.New(m.width, vpHeight)
+		m.viewport = viewport.New(m.conversationWidth(), vpHeight)
 		m.viewport.SetContent(m.viewportContent)
 
-		m.renderer = NewItemRenderer(m.width, m.config.NoColor, m.config.NoMarkdown, m.styles)
+		m.renderer = NewItemRenderer(m.conversationWidth(), m.config.NoColor, m.config.NoMarkdown, m.styles)
+		m.renderer.images = m.config.Images
+		m.renderer.locale = m.config.Locale
 
 		m.textarea.SetWidth(m.width)
 		m.ready = true
@@ -887,13 +1309,9 @@ func (m *Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 			return m, m.focusTextarea()
 		}
 	} else {
-		m.viewport.Width = m.width
 		m.viewport.Height = vpHeight
 		m.textarea.SetWidth(m.width)
-
-		if m.renderer != nil {
-			m.renderer.width = m.width
-		}
+		m.applyConversationWidth()
 	}
 
 	return m, nil
@@ -924,6 +1342,8 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleEscalationKey(msg)
 	case StateUserInputQuestion:
 		return m.handleUserInputQuestionKey(msg)
+	case StateAskUser:
+		return m.handleAskUserKey(msg)
 	}
 
 	return m, nil
@@ -959,7 +1379,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					m.provider = provider
 					m.modelName = model
 					m.appendToViewport(m.renderer.RenderSystemMessage(
-						fmt.Sprintf("Model set to %s (%s). Start a session to apply.", model, provider)))
+						m.t("Model set to %s (%s). Start a session to apply.", model, provider)))
 					m.state = StateInput
 					return m, m.focusTextarea()
 				}
@@ -1020,6 +1440,47 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// /consult answer selection uses the selector UI.
+	if m.selectingConsult {
+		if m.selector != nil {
+			if m.isViewportScrollKey(msg) {
+				var cmd tea.Cmd
+				m.viewport, cmd = m.viewport.Update(msg)
+				return m, cmd
+			}
+
+			done := m.selector.Update(msg)
+			if done {
+				m.selectingConsult = false
+				idx := m.selector.Selected()
+				cancelled := m.selector.Cancelled()
+				consult, choices := m.consult, m.consultChoices
+				m.selector, m.consult, m.consultChoices = nil, nil, nil
+				if cancelled || idx < 0 || idx >= len(choices) || choices[idx] == nil {
+					m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Discarded the answers.")))
+					m.state = StateInput
+					return m, m.focusTextarea()
+				}
+				choice := choices[idx]
+				m.spinnerMsg = "Keeping answer..."
+				m.state = StateWatching
+				m.textarea.Blur()
+				return m, adoptConsultCmd(m.client, m.workflowID, workflow.AdoptConsultRequest{
+					ConsultID: consult.ConsultID,
+					Index:     choice.Index,
+					Mode:      choice.Mode,
+				}, consult.Answers[choice.Index].Model)
+			}
+			return m, nil
+		}
+		if msg.Type == tea.KeyEsc {
+			m.selectingConsult = false
+			m.state = StateInput
+			return m, m.focusTextarea()
+		}
+		return m, nil
+	}
+
 	// /reasoning selection uses the selector UI.
 	if m.selectingReasoning {
 		if m.selector != nil {
@@ -1124,6 +1585,12 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Ctrl+O expands or collapses the folded item in view; plain o types.
+	if msg.String() == "ctrl+o" {
+		m.toggleFold()
+		return m, nil
+	}
+
 	// Tab key: accept suggestion if present and textarea is empty
 	if msg.Type == tea.KeyTab {
 		if m.suggestion != "" && m.textarea.Value() == "" {
@@ -1184,7 +1651,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if line == "/model" {
 			if m.modelsFetched {
 				// Models already cached — show selector immediately
-				m.appendToViewport(m.renderer.RenderSystemMessage("Select a model (Esc to cancel):"))
+				m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Select a model (Esc to cancel):")))
 				m.selector = NewSelectorModel(modelSelectorOptions(m.currentModelOptions()), m.styles)
 				m.selector.SetWidth(m.width)
 				m.selectingModel = true
@@ -1196,7 +1663,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				// Fire async fetch
 				m.modelsFetching = true
 				m.selectingModel = true
-				m.appendToViewport(m.renderer.RenderSystemMessage("Fetching available models..."))
+				m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Fetching available models...")))
 				m.textarea.Blur()
 				return m, fetchModelsCmd()
 			}
@@ -1217,7 +1684,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.appendToViewport("Usage: /plan <message>\n")
 				return m, nil
 			}
-			m.appendToViewport(m.renderer.RenderSystemMessage("Starting plan mode..."))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Starting plan mode...")))
 			m.spinnerMsg = "Starting planner..."
 			m.state = StateWatching
 			m.textarea.Blur()
@@ -1228,7 +1695,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.appendToViewport("Not in plan mode. Use /plan <message> to start.\n")
 				return m, nil
 			}
-			m.appendToViewport(m.renderer.RenderSystemMessage("Ending plan mode..."))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Ending plan mode...")))
 			m.spinnerMsg = "Shutting down planner..."
 			m.state = StateWatching
 			m.textarea.Blur()
@@ -1245,6 +1712,18 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.appendToViewport(m.formatStatusDisplay())
 			return m, nil
 		}
+		if line == "/expandall" {
+			m.toggleExpandAll()
+			return m, nil
+		}
+		if line == "/panes" {
+			m.togglePanes()
+			return m, nil
+		}
+		if line == "/theme" || strings.HasPrefix(line, "/theme ") {
+			m.switchTheme(strings.TrimSpace(strings.TrimPrefix(line, "/theme")))
+			return m, nil
+		}
 		if line == "/mcp" {
 			if m.workflowID == "" {
 				m.appendToViewport("No active session.\n")
@@ -1255,6 +1734,16 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.textarea.Blur()
 			return m, queryMcpToolsCmd(m.client, m.workflowID)
 		}
+		if line == "/capabilities" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Fetching worker capabilities..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, queryCapabilitiesCmd(m.client, m.workflowID, false)
+		}
 		if line == "/ps" {
 			if m.workflowID == "" {
 				m.appendToViewport("No active session.\n")
@@ -1275,13 +1764,228 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.textarea.Blur()
 			return m, cleanExecSessionsCmd(m.client, m.workflowID)
 		}
+		if line == "/sessions" || strings.HasPrefix(line, "/sessions ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			args := strings.TrimSpace(strings.TrimPrefix(line, "/sessions"))
+			if args == "" {
+				m.appendToViewport(formatTrackedExecSessionsDisplay(m.execSessions, time.Now()))
+				return m, nil
+			}
+			sub, rest, _ := strings.Cut(args, " ")
+			if sub != "write" {
+				m.appendToViewport("Usage: /sessions [write <id> <text>]\n")
+				return m, nil
+			}
+			req, err := parseSessionsWriteCommand(rest)
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Writing to exec session..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, writeExecStdinCmd(m.client, m.workflowID, req)
+		}
+		if line == "/todo" || strings.HasPrefix(line, "/todo ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			args := strings.TrimSpace(strings.TrimPrefix(line, "/todo"))
+			if args == "" {
+				m.appendToViewport(formatTaskListDisplay(m.tasks))
+				return m, nil
+			}
+			req, err := parseTodoCommand(args)
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Updating task list..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, updateTaskListCmd(m.client, m.workflowID, req)
+		}
+		if line == "/trust" || strings.HasPrefix(line, "/trust ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			req, err := parseTrustCommand(strings.TrimPrefix(line, "/trust"))
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Updating trusted commands..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, updateTrustedCommandsCmd(m.client, m.workflowID, req)
+		}
+		if cmd, args, _ := strings.Cut(line, " "); cmd == "/pin" || cmd == "/unpin" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			req, err := parsePinCommand(cmd, args)
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Updating pins..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, pinItemCmd(m.client, m.workflowID, req)
+		}
+		if cmd, args, _ := strings.Cut(line, " "); cmd == "/search" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			q, err := parseSearchCommand(args, time.Now())
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Searching history..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, searchHistoryCmd(m.client, m.workflowID, q)
+		}
+		if cmd, args, _ := strings.Cut(line, " "); cmd == "/context" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			req, err := parseContextCommand(args)
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.state = StateWatching
+			m.textarea.Blur()
+			switch req.UpdateName {
+			case "":
+				m.spinnerMsg = "Loading context..."
+				return m, queryContextCmd(m.client, m.workflowID)
+			case workflow.UpdateSummarizeContextItems:
+				m.spinnerMsg = "Summarizing context items..."
+			default:
+				m.spinnerMsg = "Dropping context items..."
+			}
+			return m, editContextCmd(m.client, m.workflowID, req)
+		}
+		if cmd, args, _ := strings.Cut(line, " "); cmd == "/note" || cmd == "/react" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			req, err := parseAnnotateCommand(cmd, args)
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Annotating..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, annotateItemCmd(m.client, m.workflowID, req)
+		}
+		if cmd, reason, _ := strings.Cut(line, " "); cmd == "/pause" || line == "/unpause" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Updating session..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, pauseSessionCmd(m.client, m.workflowID, cmd == "/pause", strings.TrimSpace(reason))
+		}
+		if line == "/cwd" || strings.HasPrefix(line, "/cwd ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			cwd := strings.TrimSpace(strings.TrimPrefix(line, "/cwd"))
+			if cwd == "" {
+				cwd = m.config.Cwd
+			} else if !filepath.IsAbs(cwd) {
+				cwd = filepath.Join(m.config.Cwd, cwd)
+			}
+			m.spinnerMsg = "Moving session..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, remapCwdCmd(m.client, m.workflowID, cwd)
+		}
+		if line == "/!cmd" || strings.HasPrefix(line, "/!cmd ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			command := strings.TrimSpace(strings.TrimPrefix(line, "/!cmd"))
+			if command == "" {
+				m.appendToViewport("Usage: /!cmd <shell command>\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Running " + command + "..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, userShellCmd(m.client, m.workflowID, command)
+		}
+		if line == "/consult" || strings.HasPrefix(line, "/consult ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Consulting models..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, consultCmd(m.client, m.workflowID, strings.TrimSpace(strings.TrimPrefix(line, "/consult")))
+		}
+		if line == "/snapshot" {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Snapshotting workspace..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, snapshotWorkspaceCmd(m.client, m.workflowID)
+		}
+		if line == "/rollback" || strings.HasPrefix(line, "/rollback ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			snapshotID := strings.TrimSpace(strings.TrimPrefix(line, "/rollback"))
+			m.spinnerMsg = "Rolling back workspace..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, rollbackWorkspaceCmd(m.client, m.workflowID, snapshotID)
+		}
+		if line == "/import" || strings.HasPrefix(line, "/import ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			sourceID := strings.TrimSpace(strings.TrimPrefix(line, "/import"))
+			if sourceID == "" {
+				m.appendToViewport("Usage: /import <workflow-id>\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Importing context..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, importContextCmd(m.client, m.workflowID, sourceID)
+		}
 		if line == "/resume" {
-			m.appendToViewport(m.renderer.RenderSystemMessage("Fetching sessions..."))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Fetching sessions...")))
 			m.resumingSession = true
 			m.spinnerMsg = "Fetching sessions..."
 			m.state = StateWatching
 			m.textarea.Blur()
-			return m, fetchSessionsCmd(m.client, m.harnessID)
+			return m, fetchSessionsCmd(m.client, m.dataConverter, m.harnessID)
 		}
 		if strings.HasPrefix(line, "/new") {
 			newMsg := strings.TrimSpace(strings.TrimPrefix(line, "/new"))
@@ -1289,7 +1993,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.appendToViewport("Usage: /new <message>\n")
 				return m, nil
 			}
-			m.appendToViewport(m.renderer.RenderSystemMessage("Starting new session..."))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Starting new session...")))
 			m.spinnerMsg = "Starting new session..."
 			m.state = StateWatching
 			m.textarea.Blur()
@@ -1318,7 +2022,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.appendToViewport("No active session.\n")
 				return m, nil
 			}
-			m.appendToViewport(m.renderer.RenderSystemMessage("Select approval mode (Esc to cancel):"))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Select approval mode (Esc to cancel):")))
 			m.selector = NewSelectorModel([]SelectorOption{
 				{Label: "unless-trusted — Prompt for all mutating tools"},
 				{Label: "never — Auto-approve everything"},
@@ -1329,6 +2033,36 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.textarea.Blur()
 			return m, nil
 		}
+		if line == "/config" || strings.HasPrefix(line, "/config ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			req, err := parseConfigCommand(strings.TrimPrefix(line, "/config"))
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Updating model parameters..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, updateModelConfigCmd(m.client, m.workflowID, req)
+		}
+		if line == "/env" || strings.HasPrefix(line, "/env ") {
+			if m.workflowID == "" {
+				m.appendToViewport("No active session.\n")
+				return m, nil
+			}
+			req, err := parseEnvCommand(strings.TrimPrefix(line, "/env"))
+			if err != nil {
+				m.appendToViewport(err.Error() + "\n")
+				return m, nil
+			}
+			m.spinnerMsg = "Updating environment..."
+			m.state = StateWatching
+			m.textarea.Blur()
+			return m, updateEnvCmd(m.client, m.workflowID, req)
+		}
 		if line == "/reasoning" {
 			if m.workflowID == "" {
 				m.appendToViewport("No active session.\n")
@@ -1339,7 +2073,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			profile := registry.Resolve(m.provider, m.modelName)
 			if len(profile.SupportedReasoningEfforts) == 0 {
 				m.appendToViewport(m.renderer.RenderSystemMessage(
-					fmt.Sprintf("Model %s does not support reasoning effort configuration.", m.modelName)))
+					m.t("Model %s does not support reasoning effort configuration.", m.modelName)))
 				return m, nil
 			}
 			opts := make([]SelectorOption, 0, len(profile.SupportedReasoningEfforts))
@@ -1348,7 +2082,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					Label: fmt.Sprintf("%s — %s", preset.Effort, preset.Description),
 				})
 			}
-			m.appendToViewport(m.renderer.RenderSystemMessage("Select reasoning effort (Esc to cancel):"))
+			m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Select reasoning effort (Esc to cancel):")))
 			m.selector = NewSelectorModel(opts, m.styles)
 			m.selector.SetWidth(m.width)
 			m.selectingReasoning = true
@@ -1405,6 +2139,13 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, querySkillsCmd(m.client, m.workflowID)
 		}
 
+		if m.degraded && m.workflowID != "" {
+			m.textarea.SetValue(line)
+			m.appendToViewport(m.renderer.RenderSystemMessage(
+				m.t("Temporal is unreachable; input is paused until the connection is back. Your message was kept.")))
+			return m, nil
+		}
+
 		// Show user message in viewport (❯ prefix, no separators)
 		m.appendToViewport(m.renderer.RenderUserMessage(models.ConversationItem{
 			Type:    models.ItemTypeUserMessage,
@@ -1423,6 +2164,21 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, sendUserInputCmd(m.client, m.workflowID, line)
 	}
 
+	cmd := m.updateMultilineTextarea(msg)
+
+	// Route scroll keys to viewport (textarea is single-line, doesn't need them)
+	if m.isScrollKey(msg) {
+		var vpCmd tea.Cmd
+		m.viewport, vpCmd = m.viewport.Update(msg)
+		return m, vpCmd
+	}
+
+	return m, cmd
+}
+
+// updateMultilineTextarea passes msg to the textarea, growing or shrinking it
+// (and the viewport) to fit multi-line content.
+func (m *Model) updateMultilineTextarea(msg tea.KeyMsg) tea.Cmd {
 	// Pre-expand textarea height for newline insertion (Shift+Enter / ctrl+j)
 	// so the internal viewport has room before the newline is added.
 	if msg.Type == tea.KeyCtrlJ {
@@ -1431,12 +2187,7 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			newHeight = MaxTextareaHeight
 		}
 		if newHeight != m.textarea.Height() {
-			m.textarea.SetHeight(newHeight)
-			vpHeight := m.height - newHeight - 2
-			if vpHeight < 1 {
-				vpHeight = 1
-			}
-			m.viewport.Height = vpHeight
+			m.setTextareaHeight(newHeight)
 		}
 	}
 
@@ -1445,28 +2196,42 @@ func (m *Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	m.textarea, cmd = m.textarea.Update(msg)
 
 	// Dynamically adjust textarea height based on content
-	newHeight := m.calculateTextareaHeight()
-	if newHeight != m.textarea.Height() {
-		m.textarea.SetHeight(newHeight)
-		vpHeight := m.height - newHeight - 2
-		if vpHeight < 1 {
-			vpHeight = 1
-		}
-		m.viewport.Height = vpHeight
-	}
-	
-	// Route scroll keys to viewport (textarea is single-line, doesn't need them)
-	if m.isScrollKey(msg) {
-		var vpCmd tea.Cmd
-		m.viewport, vpCmd = m.viewport.Update(msg)
-		return m, vpCmd
+	if newHeight := m.calculateTextareaHeight(); newHeight != m.textarea.Height() {
+		m.setTextareaHeight(newHeight)
 	}
+	return cmd
+}
 
-	return m, cmd
+// setTextareaHeight resizes the textarea and gives the rest to the viewport.
+func (m *Model) setTextareaHeight(h int) {
+	m.textarea.SetHeight(h)
+	vpHeight := m.height - h - 2
+	if vpHeight < 1 {
+		vpHeight = 1
+	}
+	m.viewport.Height = vpHeight
 }
 
 func (m *Model) handleWatchingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// During watching, only allow viewport scrolling
+	if s := msg.String(); s == "o" || s == "ctrl+o" {
+		m.toggleFold()
+		return m, nil
+	}
+
+	// Tab picks an in-flight tool call; x cancels it (cancel_tool).
+	if len(m.toolsInFlight) > 0 {
+		switch msg.String() {
+		case "tab":
+			m.selectedToolCall = m.nextToolCallID()
+			return m, nil
+		case "x":
+			callID := m.selectedToolCallID()
+			m.selectedToolCall = ""
+			return m, sendCancelToolCmd(m.client, m.workflowID, callID)
+		}
+	}
+
+	// Otherwise only allow viewport scrolling
 	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
@@ -1515,13 +2280,18 @@ func (m *Model) handleSessionPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			// Stop watching current session, switch to selected
 			m.stopWatching()
 			m.viewportContent = ""
+			m.blocks = nil
 			m.viewport.SetContent("")
 			m.lastRenderedSeq = -1
 			m.totalTokens = 0
 			m.totalCachedTokens = 0
+			m.cacheHitRate = 0
+			m.suggestionTokens = 0
 			m.contextWindowPct = 100
 			m.turnCount = 0
 			m.workerVersion = ""
+			m.paused = false
+		m.paused = false
 			m.lastPhase = ""
 			m.consecutiveErrors = 0
 			m.plannerActive = false
@@ -1547,6 +2317,18 @@ func (m *Model) handleSessionPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// sendApproval sends resp stamped with the turn epoch of the pending prompt.
+func (m *Model) sendApproval(resp workflow.ApprovalResponse) tea.Cmd {
+	resp.TurnEpoch = m.turnEpoch
+	return sendApprovalResponseCmd(m.client, m.workflowID, resp)
+}
+
+// sendEscalation sends resp stamped with the turn epoch of the pending prompt.
+func (m *Model) sendEscalation(resp workflow.EscalationResponse) tea.Cmd {
+	resp.TurnEpoch = m.turnEpoch
+	return sendEscalationResponseCmd(m.client, m.workflowID, resp)
+}
+
 func (m *Model) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// When selector is active, delegate to it
 	if m.selector != nil {
@@ -1571,7 +2353,7 @@ func (m *Model) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 						m.autoApprove = true
 					}
 					m.selector = nil
-					return m, sendApprovalResponseCmd(m.client, m.workflowID, *response)
+					return m, m.sendApproval(*response)
 				}
 			}
 			if m.selector.Cancelled() {
@@ -1580,7 +2362,7 @@ func (m *Model) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					allCallIDs[i] = ap.CallID
 				}
 				m.selector = nil
-				return m, sendApprovalResponseCmd(m.client, m.workflowID, workflow.ApprovalResponse{Denied: allCallIDs})
+				return m, m.sendApproval(workflow.ApprovalResponse{Denied: allCallIDs})
 			}
 		}
 		vpHeight := m.height - m.inputAreaHeight() - 2
@@ -1602,7 +2384,7 @@ func (m *Model) handleApprovalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.autoApprove = true
 			}
 			m.textarea.Blur()
-			return m, sendApprovalResponseCmd(m.client, m.workflowID, *response)
+			return m, m.sendApproval(*response)
 		}
 		m.appendToViewport("Please enter y(es), n(o), a(lways), or indices (e.g. 1,3):\n")
 		return m, nil
@@ -1633,7 +2415,7 @@ func (m *Model) handleEscalationKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				response := EscalationSelectionToResponse(m.selector.Selected(), m.pendingEscalations)
 				if response != nil {
 					m.selector = nil
-					return m, sendEscalationResponseCmd(m.client, m.workflowID, *response)
+					return m, m.sendEscalation(*response)
 				}
 			}
 			if m.selector.Cancelled() {
@@ -1642,7 +2424,7 @@ func (m *Model) handleEscalationKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					allCallIDs[i] = esc.CallID
 				}
 				m.selector = nil
-				return m, sendEscalationResponseCmd(m.client, m.workflowID, workflow.EscalationResponse{Denied: allCallIDs})
+				return m, m.sendEscalation(workflow.EscalationResponse{Denied: allCallIDs})
 			}
 		}
 		return m, nil
@@ -1655,7 +2437,7 @@ func (m *Model) handleEscalationKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		response := HandleEscalationInput(line, m.pendingEscalations)
 		if response != nil {
 			m.textarea.Blur()
-			return m, sendEscalationResponseCmd(m.client, m.workflowID, *response)
+			return m, m.sendEscalation(*response)
 		}
 		m.appendToViewport("Please enter y(es) or n(o):\n")
 		return m, nil
@@ -1729,6 +2511,44 @@ func (m *Model) handleUserInputQuestionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	return m, cmd
 }
 
+// enterAskUser shows an ask_user question and switches to free-text answer
+// input. Shift+Enter inserts new lines; Enter sends the answer.
+func (m *Model) enterAskUser(req *workflow.PendingAskUserRequest) tea.Cmd {
+	m.state = StateAskUser
+	m.pendingAskUser = req
+	m.appendToViewport(m.renderer.RenderAskUserPrompt(req))
+	m.textarea.Reset()
+	m.setTextareaHeight(1)
+	return m.focusTextarea()
+}
+
+func (m *Model) handleAskUserKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyEnter {
+		answer := strings.TrimSpace(m.textarea.Value())
+		if answer == "" && (m.pendingAskUser == nil || m.pendingAskUser.Default == "") {
+			m.appendToViewport("Please type an answer (Ctrl+C to cancel).\n")
+			return m, nil
+		}
+		m.textarea.Reset()
+		m.setTextareaHeight(1)
+		m.textarea.Blur()
+		resp := workflow.AskUserResponse{Answer: answer}
+		if m.pendingAskUser != nil {
+			resp.CallID = m.pendingAskUser.CallID
+		}
+		return m, sendAskUserResponseCmd(m.client, m.workflowID, resp)
+	}
+
+	cmd := m.updateMultilineTextarea(msg)
+
+	if m.isScrollKey(msg) {
+		var vpCmd tea.Cmd
+		m.viewport, vpCmd = m.viewport.Update(msg)
+		return m, vpCmd
+	}
+	return m, cmd
+}
+
 // isScrollKey returns true if the key should be routed to the viewport
 // for scrolling rather than to the textarea.
 func (m *Model) isScrollKey(msg tea.KeyMsg) bool {
@@ -1798,10 +2618,11 @@ func (m *Model) handleCtrlC() (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 
-	case StateUserInputQuestion:
+	case StateUserInputQuestion, StateAskUser:
 		m.lastInterruptTime = now
 		m.appendToViewport("\nInterrupting...\n")
 		m.pendingUserInputReq = nil
+		m.pendingAskUser = nil
 		m.selector = nil
 		m.state = StateWatching
 		m.spinnerMsg = "Interrupting..."
@@ -1839,14 +2660,15 @@ func (m *Model) handleWorkflowStarted(msg WorkflowStartedMsg) (tea.Model, tea.Cm
 				m.appendToViewport(fmt.Sprintf("... showing last %d items ...\n", len(msg.Items)-start))
 			}
 			for _, item := range msg.Items[start:] {
-				rendered := m.renderer.RenderItem(item, true)
-				if rendered != "" {
-					m.appendToViewport(rendered)
-				}
+				m.appendItem(item, true)
 			}
 			m.lastRenderedSeq = msg.Items[len(msg.Items)-1].Seq
 		}
 
+		m.tasks = msg.Status.Tasks
+		m.childAgents = msg.Status.ChildAgents
+		m.execSessions = msg.Status.ExecSessions
+
 		// Render plan if resuming a session that had an active plan
 		if msg.Status.Plan != nil && len(msg.Status.Plan.Steps) > 0 {
 			rendered := m.renderer.RenderPlan(msg.Status.Plan)
@@ -1864,16 +2686,21 @@ func (m *Model) handleWorkflowStarted(msg WorkflowStartedMsg) (tea.Model, tea.Cm
 		case workflow.PhaseApprovalPending:
 			m.state = StateApproval
 			m.pendingApprovals = msg.Status.PendingApprovals
+			m.turnEpoch = msg.Status.TurnEpoch
 			m.appendToViewport(m.renderer.RenderApprovalContext(msg.Status.PendingApprovals))
 			m.selector = m.buildApprovalSelector(msg.Status.PendingApprovals)
 			return m, nil
 		case workflow.PhaseEscalationPending:
 			m.state = StateEscalation
 			m.pendingEscalations = msg.Status.PendingEscalations
+			m.turnEpoch = msg.Status.TurnEpoch
 			m.appendToViewport(m.renderer.RenderEscalationContext(msg.Status.PendingEscalations))
-			m.selector = m.buildEscalationSelector()
+			m.selector = m.buildEscalationSelector(m.pendingEscalations)
 			return m, nil
 		case workflow.PhaseUserInputPending:
+			if msg.Status.PendingAskUser != nil {
+				return m, m.enterAskUser(msg.Status.PendingAskUser)
+			}
 			if msg.Status.PendingUserInputRequest != nil {
 				m.state = StateUserInputQuestion
 				m.pendingUserInputReq = msg.Status.PendingUserInputRequest
@@ -1895,7 +2722,7 @@ func (m *Model) handleWorkflowStarted(msg WorkflowStartedMsg) (tea.Model, tea.Cm
 	}
 
 	// New workflow
-	m.appendToViewport(m.renderer.RenderSystemMessage(fmt.Sprintf("Started session %s", m.workflowID)))
+	m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Started session %s", m.workflowID)))
 	if m.config.Message != "" {
 		m.state = StateWatching
 		m.spinnerMsg = "Thinking..."
@@ -1941,15 +2768,26 @@ func (m *Model) handlePollResult(msg PollResultMsg) (tea.Model, tea.Cmd) {
 	m.renderNewItems(result.Items)
 
 	// Update status
-	m.spinnerMsg = PhaseMessage(result.Status.Phase, result.Status.ToolsInFlight)
+	m.spinnerMsg = StatusMessage(result.Status)
+	m.toolsInFlight = result.Status.RunningTools
+	m.phaseStartedAt = result.Status.PhaseStartedAt
+	m.phaseTimeout = result.Status.PhaseTimeout
 	m.totalTokens = result.Status.TotalTokens
 	m.totalCachedTokens = result.Status.TotalCachedTokens
+	m.cacheHitRate = result.Status.CacheHitRate
+	m.suggestionTokens = result.Status.SuggestionUsage.Tokens()
 	m.contextWindowPct = result.Status.ContextWindowRemaining
+	m.paused = result.Status.Paused != nil
 	m.turnCount = result.Status.TurnCount
 	if result.Status.WorkerVersion != "" {
 		m.workerVersion = result.Status.WorkerVersion
 	}
 
+	// Task list is shown in a panel above the input area
+	m.tasks = result.Status.Tasks
+	m.childAgents = result.Status.ChildAgents
+	m.execSessions = result.Status.ExecSessions
+
 	// Check for plan changes and render
 	if planChanged(m.lastRenderedPlan, result.Status.Plan) {
 		rendered := m.renderer.RenderPlan(result.Status.Plan)
@@ -1967,11 +2805,13 @@ func (m *Model) handlePollResult(msg PollResultMsg) (tea.Model, tea.Cmd) {
 			for i, ap := range result.Status.PendingApprovals {
 				callIDs[i] = ap.CallID
 			}
-			return m, sendApprovalResponseCmd(m.client, m.workflowID, workflow.ApprovalResponse{Approved: callIDs})
+			m.turnEpoch = result.Status.TurnEpoch
+			return m, m.sendApproval(workflow.ApprovalResponse{Approved: callIDs})
 		}
 		m.stopWatching()
 		m.state = StateApproval
 		m.pendingApprovals = result.Status.PendingApprovals
+		m.turnEpoch = result.Status.TurnEpoch
 		m.appendToViewport(m.renderer.RenderApprovalContext(result.Status.PendingApprovals))
 		m.selector = m.buildApprovalSelector(result.Status.PendingApprovals)
 		return m, nil
@@ -1983,11 +2823,19 @@ func (m *Model) handlePollResult(msg PollResultMsg) (tea.Model, tea.Cmd) {
 		m.stopWatching()
 		m.state = StateEscalation
 		m.pendingEscalations = result.Status.PendingEscalations
+		m.turnEpoch = result.Status.TurnEpoch
 		m.appendToViewport(m.renderer.RenderEscalationContext(result.Status.PendingEscalations))
-		m.selector = m.buildEscalationSelector()
+		m.selector = m.buildEscalationSelector(m.pendingEscalations)
 		return m, nil
 	}
 
+	// Check for ask_user question pending
+	if result.Status.Phase == workflow.PhaseUserInputPending &&
+		result.Status.PendingAskUser != nil && m.state == StateWatching {
+		m.stopWatching()
+		return m, m.enterAskUser(result.Status.PendingAskUser)
+	}
+
 	// Check for user input question pending
 	if result.Status.Phase == workflow.PhaseUserInputPending &&
 		result.Status.PendingUserInputRequest != nil && m.state == StateWatching {
@@ -2012,6 +2860,12 @@ func (m *Model) handlePollResult(msg PollResultMsg) (tea.Model, tea.Cmd) {
 		m.state = StateInput
 		m.suggestion = ""
 
+		if t := result.Status.LastTurnTiming; t != nil && t.TurnID == result.Status.CurrentTurnID {
+			if line := m.renderer.RenderTurnTiming(t); line != "" {
+				m.appendToViewport(line)
+			}
+		}
+
 		cmds := []tea.Cmd{m.focusTextarea()}
 
 		// Apply suggestion if already available; otherwise schedule a delayed poll
@@ -2030,6 +2884,15 @@ func (m *Model) handlePollResult(msg PollResultMsg) (tea.Model, tea.Cmd) {
 func (m *Model) handleWatchResult(msg WatchResultMsg) (tea.Model, tea.Cmd) {
 	result := msg.Result
 
+	if result.Reconnected {
+		m.degraded = false
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Reconnected to Temporal. Input is enabled again.")))
+		return m, m.waitForWatchResult()
+	}
+	if result.Degraded {
+		return m.handleDegradedResult(result)
+	}
+
 	if result.Err != nil {
 		switch classifyPollError(result.Err) {
 		case pollErrorCompleted:
@@ -2067,16 +2930,27 @@ func (m *Model) handleWatchResult(msg WatchResultMsg) (tea.Model, tea.Cmd) {
 	m.renderNewItems(result.Items)
 
 	// Update status
-	m.spinnerMsg = PhaseMessage(result.Status.Phase, result.Status.ToolsInFlight)
+	m.spinnerMsg = StatusMessage(result.Status)
+	m.toolsInFlight = result.Status.RunningTools
+	m.phaseStartedAt = result.Status.PhaseStartedAt
+	m.phaseTimeout = result.Status.PhaseTimeout
 	m.totalTokens = result.Status.TotalTokens
 	m.totalCachedTokens = result.Status.TotalCachedTokens
+	m.cacheHitRate = result.Status.CacheHitRate
+	m.suggestionTokens = result.Status.SuggestionUsage.Tokens()
 	m.contextWindowPct = result.Status.ContextWindowRemaining
+	m.paused = result.Status.Paused != nil
 	m.turnCount = result.Status.TurnCount
 	if result.Status.WorkerVersion != "" {
 		m.workerVersion = result.Status.WorkerVersion
 	}
 	m.lastPhase = result.Status.Phase
 
+	// Task list is shown in a panel above the input area
+	m.tasks = result.Status.Tasks
+	m.childAgents = result.Status.ChildAgents
+	m.execSessions = result.Status.ExecSessions
+
 	// Check for plan changes and render
 	if planChanged(m.lastRenderedPlan, result.Status.Plan) {
 		rendered := m.renderer.RenderPlan(result.Status.Plan)
@@ -2094,11 +2968,13 @@ func (m *Model) handleWatchResult(msg WatchResultMsg) (tea.Model, tea.Cmd) {
 			for i, ap := range result.Status.PendingApprovals {
 				callIDs[i] = ap.CallID
 			}
-			return m, sendApprovalResponseCmd(m.client, m.workflowID, workflow.ApprovalResponse{Approved: callIDs})
+			m.turnEpoch = result.Status.TurnEpoch
+			return m, m.sendApproval(workflow.ApprovalResponse{Approved: callIDs})
 		}
 		m.stopWatching()
 		m.state = StateApproval
 		m.pendingApprovals = result.Status.PendingApprovals
+		m.turnEpoch = result.Status.TurnEpoch
 		m.appendToViewport(m.renderer.RenderApprovalContext(result.Status.PendingApprovals))
 		m.selector = m.buildApprovalSelector(result.Status.PendingApprovals)
 		return m, nil
@@ -2110,11 +2986,19 @@ func (m *Model) handleWatchResult(msg WatchResultMsg) (tea.Model, tea.Cmd) {
 		m.stopWatching()
 		m.state = StateEscalation
 		m.pendingEscalations = result.Status.PendingEscalations
+		m.turnEpoch = result.Status.TurnEpoch
 		m.appendToViewport(m.renderer.RenderEscalationContext(result.Status.PendingEscalations))
-		m.selector = m.buildEscalationSelector()
+		m.selector = m.buildEscalationSelector(m.pendingEscalations)
 		return m, nil
 	}
 
+	// Check for ask_user question pending
+	if result.Status.Phase == workflow.PhaseUserInputPending &&
+		result.Status.PendingAskUser != nil && m.state == StateWatching {
+		m.stopWatching()
+		return m, m.enterAskUser(result.Status.PendingAskUser)
+	}
+
 	// Check for user input question pending
 	if result.Status.Phase == workflow.PhaseUserInputPending &&
 		result.Status.PendingUserInputRequest != nil && m.state == StateWatching {
@@ -2159,8 +3043,14 @@ func (m *Model) handleWatchResult(msg WatchResultMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 	}
 
-	// Continue watching
-	return m, m.waitForWatchResult()
+	// Continue watching; while the LLM is generating, also poll for
+	// streaming progress so large tool calls show up before they finish.
+	cmds := []tea.Cmd{m.waitForWatchResult()}
+	if m.lastPhase == workflow.PhaseLLMCalling && !m.llmProgressPolling {
+		m.llmProgressPolling = true
+		cmds = append(cmds, m.scheduleLLMProgressPoll())
+	}
+	return m, tea.Batch(cmds...)
 }
 
 func (m *Model) renderNewItems(items []models.ConversationItem) {
@@ -2168,10 +3058,7 @@ func (m *Model) renderNewItems(items []models.ConversationItem) {
 		if item.Seq <= m.lastRenderedSeq {
 			continue
 		}
-		rendered := m.renderer.RenderItem(item, false)
-		if rendered != "" {
-			m.appendToViewport(rendered)
-		}
+		m.appendItem(item, false)
 		m.lastRenderedSeq = item.Seq
 	}
 }
@@ -2199,7 +3086,7 @@ func (m *Model) handlePlanRequestAccepted(msg PlanRequestAcceptedMsg) (tea.Model
 	m.lastRenderedSeq = -1
 
 	m.appendToViewport(m.renderer.RenderSystemMessage(
-		fmt.Sprintf("Plan mode active (agent: %s). Use /done to finish.", msg.AgentID)))
+		m.t("Plan mode active (agent: %s). Use /done to finish.", msg.AgentID)))
 
 	m.state = StateWatching
 	m.spinnerMsg = "Planning..."
@@ -2215,7 +3102,7 @@ func (m *Model) handlePlannerCompleted(msg PlannerCompletedMsg) (tea.Model, tea.
 	m.lastRenderedSeq = -1
 
 	if msg.PlanText != "" {
-		m.appendToViewport(m.renderer.RenderSystemMessage("Plan mode ended. Sending plan to parent..."))
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Plan mode ended. Sending plan to parent...")))
 		// Send the plan as user input to the parent workflow
 		planInput := "Implement the following plan:\n\n" + msg.PlanText
 		m.state = StateWatching
@@ -2223,13 +3110,20 @@ func (m *Model) handlePlannerCompleted(msg PlannerCompletedMsg) (tea.Model, tea.
 		return m, sendUserInputCmd(m.client, m.workflowID, planInput)
 	}
 
-	m.appendToViewport(m.renderer.RenderSystemMessage("Plan mode ended (no plan produced)."))
+	m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Plan mode ended (no plan produced).")))
 	m.state = StateInput
 	return m, m.focusTextarea()
 }
 
 func (m *Model) appendToViewport(content string) {
+	m.appendBlock(viewportBlock{text: content})
+}
+
+// appendBlock adds a block to the end of the viewport content.
+func (m *Model) appendBlock(block viewportBlock) {
 	wasAtBottom := m.viewport.AtBottom()
+	m.blocks = append(m.blocks, block)
+	content := block.view(m.expandAll)
 
 	if m.viewportContent != "" {
 		m.viewportContent += content
@@ -2262,7 +3156,7 @@ func (m *Model) startWatching() tea.Cmd {
 	var watchCtx context.Context
 	watchCtx, m.watchCancel = context.WithCancel(context.Background())
 
-	watcher := NewWatcher(m.client, m.workflowID)
+	watcher := watch.NewWatcher(m.client, m.workflowID)
 	if m.config.ConnectionTimeout > 0 {
 		watcher.WithRPCTimeout(m.config.ConnectionTimeout)
 	}
@@ -2271,6 +3165,25 @@ func (m *Model) startWatching() tea.Cmd {
 	return m.waitForWatchResult()
 }
 
+// handleDegradedResult shows state read from the standby Temporal endpoint
+// while the primary is unreachable.
+func (m *Model) handleDegradedResult(result watch.Result) (tea.Model, tea.Cmd) {
+	if !m.degraded {
+		m.degraded = true
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t(
+			"Temporal is unreachable. Showing read-only session state from standby %s; it may lag behind. Input is paused until the connection is back.",
+			result.Standby)))
+	}
+	m.renderNewItems(result.Items)
+	if result.Status.Phase != "" {
+		m.spinnerMsg = StatusMessage(result.Status)
+		m.totalTokens = result.Status.TotalTokens
+		m.turnCount = result.Status.TurnCount
+		m.lastPhase = result.Status.Phase
+	}
+	return m, m.waitForWatchResult()
+}
+
 func (m *Model) waitForWatchResult() tea.Cmd {
 	ch := m.watchCh
 	return func() tea.Msg {
@@ -2318,13 +3231,13 @@ func (m *Model) expandPastedContent(value string) string {
 // buildApprovalSelector creates a selector for approval prompts.
 func (m *Model) buildApprovalSelector(approvals []workflow.PendingApproval) *SelectorModel {
 	options := []SelectorOption{
-		{Label: "Yes, allow", Shortcut: "y", ShortcutKey: 'y'},
-		{Label: "No, deny", Shortcut: "n", ShortcutKey: 'n'},
-		{Label: "Always allow for this session", Shortcut: "a", ShortcutKey: 'a'},
+		{Label: m.t("Yes, allow"), Shortcut: "y", ShortcutKey: 'y'},
+		{Label: m.t("No, deny"), Shortcut: "n", ShortcutKey: 'n'},
+		{Label: m.t("Always allow for this session"), Shortcut: "a", ShortcutKey: 'a'},
 	}
 	if len(approvals) > 1 {
 		options = append(options, SelectorOption{
-			Label:       "Select individually...",
+			Label:       m.t("Select individually..."),
 			Shortcut:    "s",
 			ShortcutKey: 's',
 		})
@@ -2335,10 +3248,16 @@ func (m *Model) buildApprovalSelector(approvals []workflow.PendingApproval) *Sel
 }
 
 // buildEscalationSelector creates a selector for escalation prompts.
-func (m *Model) buildEscalationSelector() *SelectorModel {
+func (m *Model) buildEscalationSelector(pending []workflow.EscalationRequest) *SelectorModel {
 	options := []SelectorOption{
-		{Label: "Yes, re-run without sandbox", Shortcut: "y", ShortcutKey: 'y'},
-		{Label: "No, deny", Shortcut: "n", ShortcutKey: 'n'},
+		{Label: m.t("Yes, re-run without sandbox"), Shortcut: "y", ShortcutKey: 'y'},
+		{Label: m.t("No, deny"), Shortcut: "n", ShortcutKey: 'n'},
+	}
+	if isPatchConflictEscalation(pending) {
+		options = []SelectorOption{
+			{Label: m.t("Yes, apply at the closest match"), Shortcut: "y", ShortcutKey: 'y'},
+			{Label: m.t("No, hand the hunk back to the model"), Shortcut: "n", ShortcutKey: 'n'},
+		}
 	}
 	sel := NewSelectorModel(options, m.styles)
 	sel.SetWidth(m.width)
@@ -2359,7 +3278,7 @@ func (m *Model) buildUserInputSelector(req *workflow.PendingUserInputRequest) *S
 		})
 	}
 	options = append(options, SelectorOption{
-		Label:       "Other (type your answer)...",
+		Label:       m.t("Other (type your answer)..."),
 		Shortcut:    "o",
 		ShortcutKey: 'o',
 	})
@@ -2372,21 +3291,10 @@ func (m *Model) buildUserInputSelector(req *workflow.PendingUserInputRequest) *S
 // The first option is always "New session"; subsequent options are existing sessions.
 func (m *Model) buildSessionSelector(entries []SessionListEntry) *SelectorModel {
 	opts := []SelectorOption{
-		{Label: "New session", Shortcut: "n", ShortcutKey: 'n'},
+		{Label: m.t("New session"), Shortcut: "n", ShortcutKey: 'n'},
 	}
 	for _, e := range entries {
-		// Use name if available, fall back to short workflow ID.
-		displayName := e.WorkflowID
-		if idx := strings.LastIndex(displayName, "/"); idx >= 0 {
-			displayName = displayName[idx+1:]
-		}
-		if e.Name != "" {
-			displayName = e.Name
-		}
-		icon := sessionStatusIcon(e.Status)
-		label := fmt.Sprintf("%-32s %s %-10s  %s",
-			displayName, icon, e.Status, e.StartTime.Local().Format("Jan 02, 15:04"))
-		opts = append(opts, SelectorOption{Label: label})
+		opts = append(opts, SelectorOption{Label: sessionOptionLabel(e)})
 	}
 	sel := NewSelectorModel(opts, m.styles)
 	sel.SetWidth(m.width)
@@ -2397,28 +3305,57 @@ func (m *Model) buildSessionSelector(entries []SessionListEntry) *SelectorModel
 func (m *Model) buildResumeSessionSelector(entries []SessionListEntry) *SelectorModel {
 	var opts []SelectorOption
 	for _, e := range entries {
-		displayName := e.WorkflowID
-		if idx := strings.LastIndex(displayName, "/"); idx >= 0 {
-			displayName = displayName[idx+1:]
-		}
-		if e.Name != "" {
-			displayName = e.Name
-		}
-		icon := sessionStatusIcon(e.Status)
-		label := fmt.Sprintf("%-32s %s %-10s  %s",
-			displayName, icon, e.Status, e.StartTime.Local().Format("Jan 02, 15:04"))
-		opts = append(opts, SelectorOption{Label: label})
+		opts = append(opts, SelectorOption{Label: sessionOptionLabel(e)})
 	}
 	sel := NewSelectorModel(opts, m.styles)
 	sel.SetWidth(m.width)
 	return sel
 }
 
+// maxPickerNoteLen caps how much of a session note the picker shows;
+// maxPickerTitleLen keeps a generated title within its column.
+const (
+	maxPickerNoteLen  = 40
+	maxPickerTitleLen = 32
+)
+
+// sessionOptionLabel formats one session picker row: name (or title, or
+// short workflow ID), status, start time, then tags and note when set.
+func sessionOptionLabel(e SessionListEntry) string {
+	// Use name if available, then the title, falling back to short workflow ID.
+	displayName := e.WorkflowID
+	if idx := strings.LastIndex(displayName, "/"); idx >= 0 {
+		displayName = displayName[idx+1:]
+	}
+	switch {
+	case e.Name != "":
+		displayName = e.Name
+	case e.Title != "":
+		displayName = transcript.TruncateString(e.Title, maxPickerTitleLen)
+	}
+	status := e.Status
+	if e.Paused && status == "running" {
+		status = "paused"
+	}
+	icon := sessionStatusIcon(status)
+	label := fmt.Sprintf("%-32s %s %-10s  %s",
+		displayName, icon, status, e.StartTime.Local().Format("Jan 02, 15:04"))
+	if len(e.Tags) > 0 {
+		label += "  #" + strings.Join(e.Tags, " #")
+	}
+	if e.Note != "" {
+		label += "  — " + transcript.TruncateString(e.Note, maxPickerNoteLen)
+	}
+	return label
+}
+
 // sessionStatusIcon returns a Unicode bullet/symbol for a session status string.
 func sessionStatusIcon(status string) string {
 	switch status {
 	case "running":
 		return "●"
+	case "paused":
+		return "⏸"
 	case "completed":
 		return "✓"
 	case "failed":
@@ -2528,13 +3465,14 @@ func Run(config Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to load Temporal client config: %w", err)
 	}
-	c, err := client.Dial(clientOpts)
+	c, err := temporalclient.DialFailover(clientOpts, config.StandbyTemporalHost, config.StandbyNamespace)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Temporal: %w", err)
 	}
 	defer c.Close()
 
 	model := NewModel(config, c)
+	model.dataConverter = clientOpts.DataConverter
 
 	var opts []tea.ProgramOption
 	if !config.Inline {
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
@@ -63,6 +64,43 @@ func TestModel_InitialState_SessionPickerReceived(t *testing.T) {
 	assert.Len(t, rm.sessionEntries, 1)
 }
 
+func TestSessionOptionLabel_TagsAndNote(t *testing.T) {
+	e := SessionListEntry{
+		WorkflowID: "harness-abc/sess-001",
+		StartTime:  time.Now(),
+		Status:     "running",
+		Tags:       []string{"auth", "backend"},
+		Note:       "refactor auth middleware to use the new token service and drop sessions",
+	}
+	label := sessionOptionLabel(e)
+	assert.Contains(t, label, "sess-001")
+	assert.Contains(t, label, "#auth #backend")
+	assert.Contains(t, label, "— refactor auth middleware")
+	assert.Contains(t, label, "…", "long notes are truncated")
+
+	e.Tags, e.Note = nil, ""
+	assert.NotContains(t, sessionOptionLabel(e), "#")
+}
+
+func TestSessionOptionLabel_Title(t *testing.T) {
+	e := SessionListEntry{WorkflowID: "harness-abc/sess-001", StartTime: time.Now(), Status: "running",
+		Title: "Fix nil check in auth middleware and token refresh"}
+	label := sessionOptionLabel(e)
+	assert.Contains(t, label, "Fix nil check in auth middleware…")
+	assert.NotContains(t, label, "sess-001")
+
+	e.Name = "auth-fix"
+	assert.Contains(t, sessionOptionLabel(e), "auth-fix", "a /rename name wins over the title")
+}
+
+func TestSessionOptionLabel_Paused(t *testing.T) {
+	e := SessionListEntry{WorkflowID: "harness-abc/sess-001", StartTime: time.Now(), Status: "running", Paused: true}
+	assert.Contains(t, sessionOptionLabel(e), "⏸ paused")
+
+	e.Paused = false
+	assert.Contains(t, sessionOptionLabel(e), "● running")
+}
+
 func TestModel_WorkflowStartedNewSession(t *testing.T) {
 	m := newTestModel()
 	m.state = StateStartup
@@ -178,7 +216,7 @@ func TestModel_PollResultUpdatesStatus(t *testing.T) {
 	m.workflowID = "test-wf"
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{
 				{Type: models.ItemTypeAssistantMessage, Seq: 0, Content: "Hello"},
 			},
@@ -206,7 +244,7 @@ func TestModel_PollResultTurnComplete(t *testing.T) {
 	m.lastRenderedSeq = 0
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{
 				{Type: models.ItemTypeTurnComplete, Seq: 1, TurnID: "t1"},
 			},
@@ -229,7 +267,7 @@ func TestModel_PollResultApprovalPending(t *testing.T) {
 	m.workflowID = "test-wf"
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{},
 			Status: workflow.TurnStatus{
 				Phase: workflow.PhaseApprovalPending,
@@ -320,7 +358,7 @@ func TestModel_PollResultAutoApprove(t *testing.T) {
 	m.autoApprove = true
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{},
 			Status: workflow.TurnStatus{
 				Phase: workflow.PhaseApprovalPending,
@@ -344,7 +382,7 @@ func TestModel_PollResultEscalationPending(t *testing.T) {
 	m.workflowID = "test-wf"
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{},
 			Status: workflow.TurnStatus{
 				Phase: workflow.PhaseEscalationPending,
@@ -646,7 +684,7 @@ func TestModel_SuggestionAppliedOnTurnComplete(t *testing.T) {
 	m.lastRenderedSeq = 0
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{
 				{Type: models.ItemTypeTurnComplete, Seq: 1, TurnID: "t1"},
 			},
@@ -813,7 +851,7 @@ func TestModel_TurnCompleteSchedulesSuggestionPoll(t *testing.T) {
 	m.lastRenderedSeq = 0
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{
 				{Type: models.ItemTypeTurnComplete, Seq: 1, TurnID: "t1"},
 			},
@@ -839,7 +877,7 @@ func TestModel_TurnCompleteNoSuggestionPollWhenDisabled(t *testing.T) {
 	m.config.DisableSuggestions = true
 
 	msg := PollResultMsg{
-		Result: PollResult{
+		Result: watch.PollResult{
 			Items: []models.ConversationItem{
 				{Type: models.ItemTypeTurnComplete, Seq: 1, TurnID: "t1"},
 			},
@@ -1134,3 +1172,109 @@ func TestPlanChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestModel_PollResultAskUserPending(t *testing.T) {
+	m := newTestModel()
+	m.state = StateWatching
+	m.workflowID = "test-wf"
+
+	msg := PollResultMsg{
+		Result: watch.PollResult{
+			Status: workflow.TurnStatus{
+				Phase:          workflow.PhaseUserInputPending,
+				PendingAskUser: &workflow.PendingAskUserRequest{CallID: "c1", Question: "Branch name?"},
+			},
+		},
+	}
+
+	result, _ := m.handlePollResult(msg)
+	rm := result.(*Model)
+	assert.Equal(t, StateAskUser, rm.state)
+	if assert.NotNil(t, rm.pendingAskUser) {
+		assert.Equal(t, "c1", rm.pendingAskUser.CallID)
+	}
+	assert.Contains(t, rm.viewportContent, "Branch name?")
+}
+
+func TestModel_AskUserEnterRequiresAnswerWithoutDefault(t *testing.T) {
+	m := newTestModel()
+	m.state = StateAskUser
+	m.workflowID = "test-wf"
+	m.pendingAskUser = &workflow.PendingAskUserRequest{CallID: "c1", Question: "Name?"}
+
+	_, cmd := m.handleAskUserKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Nil(t, cmd)
+	assert.Equal(t, StateAskUser, m.state)
+	assert.Contains(t, m.viewportContent, "Please type an answer")
+
+	// With a default, an empty answer is sent.
+	m.pendingAskUser.Default = "Ada"
+	_, cmd = m.handleAskUserKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NotNil(t, cmd)
+}
+
+func TestModel_AskUserSubmitsMultiLineAnswer(t *testing.T) {
+	m := newTestModel()
+	m.state = StateAskUser
+	m.workflowID = "test-wf"
+	m.pendingAskUser = &workflow.PendingAskUserRequest{CallID: "c1", Question: "Why?"}
+	m.textarea.SetValue("first\nsecond")
+
+	_, cmd := m.handleAskUserKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.NotNil(t, cmd)
+	assert.Empty(t, m.textarea.Value(), "textarea should be cleared after submit")
+}
+
+func TestModel_AskUserSentTransitionsToWatching(t *testing.T) {
+	m := newTestModel()
+	m.state = StateAskUser
+	m.pendingAskUser = &workflow.PendingAskUserRequest{CallID: "c1"}
+
+	result, _ := m.Update(AskUserSentMsg{})
+	rm := result.(*Model)
+	assert.Equal(t, StateWatching, rm.state)
+	assert.Nil(t, rm.pendingAskUser)
+}
+
+func TestModel_CtrlCDuringAskUserInterrupts(t *testing.T) {
+	m := newTestModel()
+	m.state = StateAskUser
+	m.workflowID = "test-wf"
+	m.pendingAskUser = &workflow.PendingAskUserRequest{CallID: "c1"}
+
+	result, _ := m.handleCtrlC()
+	rm := result.(*Model)
+	assert.Equal(t, StateWatching, rm.state)
+	assert.Nil(t, rm.pendingAskUser)
+}
+
+func TestModel_DegradedWatchResultPausesInput(t *testing.T) {
+	m := newTestModel()
+	m.state = StateInput
+	m.workflowID = "test-wf"
+
+	result, _ := m.handleWatchResult(WatchResultMsg{Result: watch.Result{
+		Degraded: true,
+		Standby:  "agents-dr@standby:7233",
+		Items:    []models.ConversationItem{{Type: models.ItemTypeAssistantMessage, Seq: 3, Content: "From standby"}},
+		Status:   workflow.TurnStatus{Phase: workflow.PhaseWaitingForInput, TurnCount: 2},
+	}})
+	rm := result.(*Model)
+	assert.True(t, rm.degraded)
+	assert.Contains(t, rm.viewportContent, "read-only session state from standby agents-dr@standby:7233")
+	assert.Contains(t, rm.viewportContent, "From standby")
+	assert.Equal(t, 2, rm.turnCount)
+
+	rm.textarea.SetValue("keep going")
+	result, _ = rm.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	rm = result.(*Model)
+	assert.Equal(t, StateInput, rm.state)
+	assert.Equal(t, "keep going", rm.textarea.Value(), "input is kept for after reconnect")
+	assert.Contains(t, rm.viewportContent, "input is paused")
+
+	result, _ = rm.handleWatchResult(WatchResultMsg{Result: watch.Result{Reconnected: true}})
+	rm = result.(*Model)
+	assert.False(t, rm.degraded)
+	assert.Contains(t, rm.viewportContent, "Reconnected to Temporal")
+	assert.Equal(t, 2, rm.turnCount)
+}
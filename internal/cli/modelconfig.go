@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const configUsage = "Usage: /config [<key> <value> | <key>=<value>...]  keys: temperature, max_tokens, context_window, reasoning_effort, reasoning_summary"
+
+// parseConfigCommand parses the arguments of /config into an
+// update_model_config request. No arguments shows the current parameters.
+// Values are checked against the model's constraints by the workflow.
+func parseConfigCommand(args string) (workflow.UpdateModelConfigRequest, error) {
+	var req workflow.UpdateModelConfigRequest
+	fields := strings.Fields(args)
+	if len(fields) == 2 && !strings.Contains(fields[0], "=") {
+		fields = []string{fields[0] + "=" + fields[1]}
+	}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return req, fmt.Errorf("%s", configUsage)
+		}
+		if err := setModelParam(&req.Params, strings.ReplaceAll(strings.ToLower(key), "-", "_"), value); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+// setModelParam sets the parameter named key to value.
+func setModelParam(p *models.ModelParams, key, value string) error {
+	switch key {
+	case "temperature", "temp":
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid temperature %q", value)
+		}
+		p.Temperature = &t
+	case "max_tokens":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid max_tokens %q", value)
+		}
+		p.MaxTokens = &n
+	case "context_window":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid context_window %q", value)
+		}
+		p.ContextWindow = &n
+	case "reasoning_effort", "reasoning", "effort":
+		e, ok := models.ParseReasoningEffort(value)
+		if !ok {
+			return fmt.Errorf("invalid reasoning effort %q", value)
+		}
+		p.ReasoningEffort = &e
+	case "reasoning_summary", "summary":
+		s, ok := models.ParseReasoningSummary(value)
+		if !ok {
+			return fmt.Errorf("invalid reasoning summary %q (want auto, concise, detailed or none)", value)
+		}
+		p.ReasoningSummary = &s
+	default:
+		return fmt.Errorf("unknown parameter %q\n%s", key, configUsage)
+	}
+	return nil
+}
+
+// formatModelConfigDisplay formats the /config panel.
+func formatModelConfigDisplay(resp workflow.UpdateModelConfigResponse) string {
+	cfg := resp.Model
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Model parameters (%s, %s)\n", cfg.Model, cfg.Provider))
+	b.WriteString("────────────────\n")
+
+	temperature := strconv.FormatFloat(cfg.Temperature, 'g', -1, 64)
+	if resp.TemperatureUnsupported {
+		temperature = "n/a (not accepted by this model)"
+	}
+	b.WriteString(fmt.Sprintf("  temperature:        %s\n", temperature))
+	b.WriteString(fmt.Sprintf("  max_tokens:         %d\n", cfg.MaxTokens))
+	b.WriteString(fmt.Sprintf("  context_window:     %d\n", cfg.ContextWindow))
+
+	if len(resp.SupportedReasoningEfforts) == 0 {
+		b.WriteString("  reasoning_effort:   n/a (not a reasoning model)\n")
+	} else {
+		efforts := make([]string, len(resp.SupportedReasoningEfforts))
+		for i, e := range resp.SupportedReasoningEfforts {
+			efforts[i] = string(e)
+		}
+		b.WriteString(fmt.Sprintf("  reasoning_effort:   %s (%s)\n", orDash(string(cfg.ReasoningEffort)), strings.Join(efforts, ", ")))
+		b.WriteString(fmt.Sprintf("  reasoning_summary:  %s\n", orDash(string(cfg.ReasoningSummary))))
+	}
+	b.WriteString("Change with /config <key> <value>, e.g. /config temperature 0.2. Applies from the next LLM call.\n")
+	return b.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseConfigCommand(t *testing.T) {
+	req, err := parseConfigCommand("")
+	require.NoError(t, err)
+	assert.True(t, req.Params.IsEmpty())
+
+	req, err = parseConfigCommand(" temperature 0.2")
+	require.NoError(t, err)
+	require.NotNil(t, req.Params.Temperature)
+	assert.Equal(t, 0.2, *req.Params.Temperature)
+
+	req, err = parseConfigCommand("max-tokens=8000 effort=high summary=concise")
+	require.NoError(t, err)
+	assert.Equal(t, 8000, *req.Params.MaxTokens)
+	assert.Equal(t, models.ReasoningEffortHigh, *req.Params.ReasoningEffort)
+	assert.Equal(t, models.ReasoningSummaryConcise, *req.Params.ReasoningSummary)
+	assert.Nil(t, req.Params.Temperature)
+
+	_, err = parseConfigCommand("temperature")
+	assert.ErrorContains(t, err, "Usage: /config")
+	_, err = parseConfigCommand("temperature warm")
+	assert.ErrorContains(t, err, "invalid temperature")
+	_, err = parseConfigCommand("top_p=0.9")
+	assert.ErrorContains(t, err, `unknown parameter "top_p"`)
+}
+
+func TestFormatModelConfigDisplay(t *testing.T) {
+	out := formatModelConfigDisplay(workflow.UpdateModelConfigResponse{
+		Model: models.ModelConfig{Provider: "openai", Model: "gpt-4o", Temperature: 0.7, MaxTokens: 4096, ContextWindow: 128000},
+	})
+	assert.Contains(t, out, "Model parameters (gpt-4o, openai)")
+	assert.Contains(t, out, "temperature:        0.7")
+	assert.Contains(t, out, "max_tokens:         4096")
+	assert.Contains(t, out, "reasoning_effort:   n/a (not a reasoning model)")
+
+	out = formatModelConfigDisplay(workflow.UpdateModelConfigResponse{
+		Model:                     models.ModelConfig{Provider: "openai", Model: "codex-mini-latest", ReasoningEffort: models.ReasoningEffortMedium},
+		TemperatureUnsupported:    true,
+		SupportedReasoningEfforts: []models.ReasoningEffort{models.ReasoningEffortLow, models.ReasoningEffortMedium},
+	})
+	assert.Contains(t, out, "temperature:        n/a (not accepted by this model)")
+	assert.Contains(t, out, "reasoning_effort:   medium (low, medium)")
+	assert.Contains(t, out, "reasoning_summary:  -")
+}
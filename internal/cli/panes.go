@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// Split-pane layout (/panes): the conversation on the left and a sidebar
+// with the live plan, pending approvals, running tools and child agents on
+// the right. Below minPanesWidth columns the sidebar is hidden.
+const (
+	minPanesWidth   = 80
+	maxSidebarWidth = 44
+)
+
+// sidebarWidth returns the columns taken by the sidebar, including its
+// border, or 0 when it is not shown.
+func (m Model) sidebarWidth() int {
+	if !m.showPanes || m.width < minPanesWidth {
+		return 0
+	}
+	return min(maxSidebarWidth, m.width/3)
+}
+
+// conversationWidth returns the width of the conversation viewport.
+func (m Model) conversationWidth() int {
+	return m.width - m.sidebarWidth()
+}
+
+// togglePanes flips /panes and resizes the viewport and renderer. Items
+// already in the viewport keep the wrapping they were rendered with.
+func (m *Model) togglePanes() {
+	m.showPanes = !m.showPanes
+	m.applyConversationWidth()
+	if m.showPanes && m.sidebarWidth() == 0 {
+		m.appendToViewport(fmt.Sprintf("Terminal is too narrow for the sidebar (needs %d columns).\n", minPanesWidth))
+	}
+}
+
+// applyConversationWidth sizes the viewport and renderer to the
+// conversation column.
+func (m *Model) applyConversationWidth() {
+	m.viewport.Width = m.conversationWidth()
+	if m.renderer != nil {
+		m.renderer.width = m.conversationWidth()
+	}
+}
+
+// renderSidebar renders the sidebar at the given height, or "" when it is
+// not shown.
+func (m Model) renderSidebar(height int, now time.Time) string {
+	width := m.sidebarWidth()
+	if width == 0 {
+		return ""
+	}
+	var approvals []workflow.PendingApproval
+	if m.state == StateApproval {
+		approvals = m.pendingApprovals
+	}
+	content := m.sidebarContent(width-2, m.lastRenderedPlan, approvals, m.toolsInFlight, m.childAgents, now)
+	return lipgloss.NewStyle().
+		Width(width-1).
+		Height(height).
+		MaxHeight(height).
+		PaddingLeft(1).
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		Render(content)
+}
+
+// sidebarContent lists the sidebar sections, skipping empty ones. Lines are
+// cut to width so the column never wraps.
+func (m Model) sidebarContent(width int, plan *workflow.PlanState, approvals []workflow.PendingApproval,
+	inFlight []workflow.ToolInFlight, agents []workflow.ChildAgentSummary, now time.Time) string {
+	var sections []string
+	line := func(s string, indent int) string {
+		return truncateProgress(s, max(width-indent, 1))
+	}
+
+	if plan != nil && len(plan.Steps) > 0 {
+		done := 0
+		for _, step := range plan.Steps {
+			if step.Status == workflow.PlanStepCompleted {
+				done++
+			}
+		}
+		lines := []string{fmt.Sprintf("%s %d/%d", m.styles.ToolVerb.Render("Plan"), done, len(plan.Steps))}
+		for _, step := range plan.Steps {
+			var marker string
+			switch step.Status {
+			case workflow.PlanStepCompleted:
+				marker = m.styles.PlanCompleted.Render("✓")
+			case workflow.PlanStepInProgress:
+				marker = m.styles.ToolBullet.Render("●")
+			default:
+				marker = m.styles.PlanPending.Render("○")
+			}
+			lines = append(lines, marker+" "+line(step.Step, 2))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(approvals) > 0 {
+		lines := []string{m.styles.ToolVerb.Render("Approvals")}
+		for _, ap := range approvals {
+			verb, detail := transcript.ToolCallSummary(ap.ToolName, ap.Arguments)
+			lines = append(lines, m.styles.ApprovalTool.Render("?")+" "+line(strings.TrimSpace(verb+" "+detail), 2))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(inFlight) > 0 {
+		lines := []string{m.styles.ToolVerb.Render("Running")}
+		for _, t := range inFlight {
+			text := t.Name
+			near := false
+			if !t.StartedAt.IsZero() {
+				var elapsed string
+				elapsed, near = FormatActivityTime(t.StartedAt, t.Timeout, now)
+				text += " · " + elapsed
+			}
+			lines = append(lines, m.progressStyle(near).Render(line(text, 0)))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(agents) > 0 {
+		lines := []string{m.styles.ToolVerb.Render("Agents")}
+		for _, a := range agents {
+			lines = append(lines, line(fmt.Sprintf("%s %s · %s", a.AgentID, a.Role, a.Status), 0))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(sections) == 0 {
+		return m.styles.StatusLine.Render("Nothing in progress")
+	}
+	return strings.Join(sections, "\n\n")
+}
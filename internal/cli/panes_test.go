@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestModel_PanesToggle(t *testing.T) {
+	m := newTestModel()
+	m.width = 120
+	m.viewport = viewport.New(120, 20)
+
+	m.textarea.SetValue("/panes")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.showPanes)
+	assert.Equal(t, 40, m.sidebarWidth())
+	assert.Equal(t, 80, m.viewport.Width)
+	assert.Equal(t, 80, m.renderer.width)
+
+	m.textarea.SetValue("/panes")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.showPanes)
+	assert.Equal(t, 120, m.viewport.Width)
+}
+
+func TestModel_PanesTooNarrow(t *testing.T) {
+	m := newTestModel()
+	m.width = 60
+	m.viewport = viewport.New(60, 20)
+
+	m.togglePanes()
+	assert.Equal(t, 0, m.sidebarWidth())
+	assert.Equal(t, 60, m.viewport.Width)
+	assert.Contains(t, m.viewportContent, "too narrow")
+	assert.Empty(t, m.renderSidebar(10, time.Now()))
+}
+
+func TestModel_SidebarContent(t *testing.T) {
+	m := newTestModel()
+	now := time.Now()
+	plan := &workflow.PlanState{Steps: []workflow.PlanStep{
+		{Step: "Read the code", Status: workflow.PlanStepCompleted},
+		{Step: "Write the fix", Status: workflow.PlanStepInProgress},
+		{Step: "Run the tests", Status: workflow.PlanStepPending},
+	}}
+	approvals := []workflow.PendingApproval{
+		{CallID: "c1", ToolName: "shell", Arguments: `{"command": "rm -rf build"}`},
+	}
+	inFlight := []workflow.ToolInFlight{
+		{Name: "shell_command", CallID: "c2", StartedAt: now.Add(-37 * time.Second)},
+	}
+	agents := []workflow.ChildAgentSummary{
+		{AgentID: "agent-1", Role: workflow.AgentRoleExplorer, Status: workflow.AgentStatusRunning},
+	}
+
+	content := m.sidebarContent(40, plan, approvals, inFlight, agents, now)
+	assert.Equal(t, `Plan 1/3
+✓ Read the code
+● Write the fix
+○ Run the tests
+
+Approvals
+? Ran rm -rf build
+
+Running
+shell_command · 37s
+
+Agents
+agent-1 explorer · running`, content)
+
+	assert.Equal(t, "Nothing in progress", m.sidebarContent(40, nil, nil, nil, nil, now))
+
+	long := &workflow.PlanState{Steps: []workflow.PlanStep{{Step: strings.Repeat("x", 60)}}}
+	for _, line := range strings.Split(m.sidebarContent(20, long, nil, nil, nil, now), "\n") {
+		assert.LessOrEqual(t, lipgloss.Width(line), 20, line)
+	}
+}
+
+func TestModel_ViewWithPanes(t *testing.T) {
+	m := newTestModel()
+	m.width = 120
+	m.viewport = viewport.New(120, 10)
+	m.togglePanes()
+	m.lastRenderedPlan = &workflow.PlanState{Steps: []workflow.PlanStep{{Step: "Keep the plan visible"}}}
+	m.appendToViewport("hello\n")
+
+	view := m.View()
+	firstLines := strings.Split(view, "\n")[:10]
+	assert.Contains(t, firstLines[0], "hello")
+	assert.Contains(t, firstLines[0], "│ Plan 0/1")
+	assert.Contains(t, firstLines[1], "○ Keep the plan visible")
+	for _, line := range firstLines {
+		assert.Equal(t, 120, lipgloss.Width(line), line)
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const pinUsage = "Usage: /pin [<seq>] | /unpin <seq>"
+
+// parsePinCommand parses /pin or /unpin (named by cmd) and its arguments
+// into a pin_item request. /pin without arguments lists pinned items.
+func parsePinCommand(cmd, args string) (workflow.PinItemRequest, error) {
+	args = strings.TrimPrefix(strings.TrimSpace(args), "#")
+	if args == "" && cmd == "/pin" {
+		return workflow.PinItemRequest{Action: workflow.PinList}, nil
+	}
+	seq, err := strconv.Atoi(args)
+	if err != nil || seq < 0 {
+		return workflow.PinItemRequest{}, fmt.Errorf("%s", pinUsage)
+	}
+	action := workflow.PinPin
+	if cmd == "/unpin" {
+		action = workflow.PinUnpin
+	}
+	return workflow.PinItemRequest{Action: action, Seq: seq}, nil
+}
+
+// formatPinnedDisplay formats the /pin listing: pinned items and recent
+// messages, each with the seq to pin it by.
+func formatPinnedDisplay(items []workflow.PinnedItem) string {
+	pinned := 0
+	for _, it := range items {
+		if it.Pinned {
+			pinned++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Pinned context (%d pinned)\n", pinned))
+	b.WriteString("─────────────────\n")
+	if len(items) == 0 {
+		b.WriteString("  No messages yet.\n")
+	}
+	for _, it := range items {
+		mark := "  "
+		if it.Pinned {
+			mark = pinGlyph
+		}
+		role := strings.TrimSuffix(string(it.Type), "_message")
+		b.WriteString(fmt.Sprintf("  %s #%-4d %s: %s\n", mark, it.Seq, role, it.Preview))
+	}
+	b.WriteString("Pinned items are kept verbatim through compaction. Pin with /pin <seq>, unpin with /unpin <seq>.\n")
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParsePinCommand(t *testing.T) {
+	req, err := parsePinCommand("/pin", " ")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.PinItemRequest{Action: workflow.PinList}, req)
+
+	req, err = parsePinCommand("/pin", "#3")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.PinItemRequest{Action: workflow.PinPin, Seq: 3}, req)
+
+	req, err = parsePinCommand("/unpin", "12")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.PinItemRequest{Action: workflow.PinUnpin, Seq: 12}, req)
+
+	for _, bad := range [][2]string{{"/unpin", ""}, {"/pin", "x"}, {"/pin", "-1"}} {
+		_, err := parsePinCommand(bad[0], bad[1])
+		assert.ErrorContains(t, err, "Usage: /pin", bad[1])
+	}
+}
+
+func TestFormatPinnedDisplay(t *testing.T) {
+	result := formatPinnedDisplay([]workflow.PinnedItem{
+		{Seq: 1, Type: models.ItemTypeUserMessage, Preview: "Build the parser", Pinned: true},
+		{Seq: 7, Type: models.ItemTypeAssistantMessage, Preview: "Done."},
+	})
+	assert.Contains(t, result, "Pinned context (1 pinned)")
+	assert.Contains(t, result, "📌 #1    user: Build the parser")
+	assert.Contains(t, result, "   #7    assistant: Done.")
+	assert.Contains(t, result, "/unpin <seq>")
+}
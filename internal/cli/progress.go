@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// llmProgressInterval is how often the TUI checks a running LLM call for
+// streaming progress.
+const llmProgressInterval = time.Second
+
+// llmActivityName is the activity whose heartbeat details carry
+// llm.StreamProgress.
+const llmActivityName = "ExecuteLLMCall"
+
+// scheduleLLMProgressPoll returns a tea.Cmd that waits llmProgressInterval,
+// then reads streaming progress from the workflow's pending LLM activity.
+func (m *Model) scheduleLLMProgressPoll() tea.Cmd {
+	c := m.client
+	dc := m.dataConverter
+	wfID := m.workflowID
+	return func() tea.Msg {
+		time.Sleep(llmProgressInterval)
+
+		ctx, cancel := context.WithTimeout(context.Background(), watch.QueryTimeout)
+		defer cancel()
+
+		return LLMProgressMsg{Progress: fetchLLMProgress(ctx, c, dc, wfID)}
+	}
+}
+
+// handleLLMProgress updates the spinner with streaming progress and keeps
+// polling while the workflow is still waiting on the LLM.
+func (m *Model) handleLLMProgress(msg LLMProgressMsg) (tea.Model, tea.Cmd) {
+	if m.state != StateWatching || m.lastPhase != workflow.PhaseLLMCalling {
+		m.llmProgressPolling = false
+		return m, nil
+	}
+	if text := FormatStreamProgress(msg.Progress); text != "" {
+		m.spinnerMsg = text
+	}
+	return m, m.scheduleLLMProgressPoll()
+}
+
+// fetchLLMProgress describes the workflow and returns the latest streaming
+// progress of its pending LLM activity, or nil if none is available.
+func fetchLLMProgress(ctx context.Context, c client.Client, dc converter.DataConverter, workflowID string) *llm.StreamProgress {
+	resp, err := c.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		return nil
+	}
+	return llmProgressFromPending(resp.GetPendingActivities(), dc)
+}
+
+// llmProgressFromPending decodes llm.StreamProgress from the heartbeat
+// details of a pending ExecuteLLMCall activity.
+func llmProgressFromPending(pending []*workflowpb.PendingActivityInfo, dc converter.DataConverter) *llm.StreamProgress {
+	if dc == nil {
+		dc = converter.GetDefaultDataConverter()
+	}
+	for _, pa := range pending {
+		if pa.GetActivityType().GetName() != llmActivityName || pa.GetHeartbeatDetails() == nil {
+			continue
+		}
+		var p llm.StreamProgress
+		if err := dc.FromPayloads(pa.GetHeartbeatDetails(), &p); err != nil {
+			continue
+		}
+		return &p
+	}
+	return nil
+}
+
+// FormatStreamProgress renders streaming progress for the spinner, e.g.
+// "apply_patch building… 2.1 KB". Returns "" when there is nothing to show.
+func FormatStreamProgress(p *llm.StreamProgress) string {
+	if p == nil {
+		return ""
+	}
+	if p.ToolName != "" {
+		return fmt.Sprintf("%s building… %s", p.ToolName, formatByteSize(p.ArgBytes))
+	}
+	if p.TextBytes > 0 {
+		return fmt.Sprintf("Writing… %s", formatByteSize(p.TextBytes))
+	}
+	return ""
+}
+
+// formatByteSize formats a byte count as "512 B", "2.1 KB" or "1.3 MB".
+func formatByteSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+// timeoutWarnFraction is the share of an activity's per-attempt timeout
+// after which its elapsed time is highlighted.
+const timeoutWarnFraction = 0.8
+
+// ProgressLine is one line shown under the spinner. NearTimeout marks a
+// call close to (or past) its activity timeout, so a retry is likely.
+// CallID identifies the tool call the line describes.
+type ProgressLine struct {
+	Text        string
+	NearTimeout bool
+	CallID      string
+}
+
+// ToolProgressLines renders one line per running tool with its progress and
+// elapsed time, e.g. "  shell_command: go test ./... · 3/10 · 12.3 KB · ok  pkg/a · 2m14s".
+// Tools with neither progress nor a start time are omitted; the spinner
+// already names them.
+func ToolProgressLines(inFlight []workflow.ToolInFlight, now time.Time) []ProgressLine {
+	var lines []ProgressLine
+	for _, t := range inFlight {
+		line := FormatToolProgress(t)
+		var near bool
+		if !t.StartedAt.IsZero() {
+			var elapsed string
+			elapsed, near = FormatActivityTime(t.StartedAt, t.Timeout, now)
+			if line == "" {
+				line = "  " + t.Name + ": " + elapsed
+			} else {
+				line += " · " + elapsed
+			}
+		}
+		if line != "" {
+			lines = append(lines, ProgressLine{Text: line, NearTimeout: near, CallID: t.CallID})
+		}
+	}
+	return lines
+}
+
+// selectedToolCallID returns the in-flight call that `x` cancels: the one
+// chosen with Tab, or the first if that call is no longer running.
+func (m Model) selectedToolCallID() string {
+	for _, t := range m.toolsInFlight {
+		if t.CallID == m.selectedToolCall {
+			return t.CallID
+		}
+	}
+	if len(m.toolsInFlight) > 0 {
+		return m.toolsInFlight[0].CallID
+	}
+	return ""
+}
+
+// nextToolCallID returns the in-flight call after the selected one, wrapping.
+func (m Model) nextToolCallID() string {
+	selected := m.selectedToolCallID()
+	for i, t := range m.toolsInFlight {
+		if t.CallID == selected {
+			return m.toolsInFlight[(i+1)%len(m.toolsInFlight)].CallID
+		}
+	}
+	return selected
+}
+
+// markSelectedToolLine prefixes the selected tool's progress line with a
+// marker and the cancel hint.
+func markSelectedToolLine(lines []ProgressLine, callID string) {
+	if callID == "" {
+		return
+	}
+	for i := range lines {
+		if lines[i].CallID == callID {
+			lines[i].Text = "› " + strings.TrimPrefix(lines[i].Text, "  ") + " · x to cancel"
+			return
+		}
+	}
+}
+
+// progressStyle returns the style for elapsed-time text under the spinner.
+func (m Model) progressStyle(nearTimeout bool) lipgloss.Style {
+	if nearTimeout {
+		return m.styles.TimeoutWarning
+	}
+	return m.styles.StatusLine
+}
+
+// FormatActivityTime renders how long an activity started at start has been
+// running, e.g. "37s". Near its per-attempt timeout the timeout is added
+// ("1m15s · timeout 1m30s") and nearTimeout is set; past it, the attempt has
+// likely been retried ("1m45s · past 1m30s timeout, retrying").
+func FormatActivityTime(start time.Time, timeout time.Duration, now time.Time) (text string, nearTimeout bool) {
+	elapsed := now.Sub(start)
+	if elapsed < 0 {
+		elapsed = 0 // Worker and TUI clocks may disagree slightly
+	}
+	text = formatElapsed(elapsed)
+	switch {
+	case timeout <= 0:
+		return text, false
+	case elapsed >= timeout:
+		return text + " · past " + formatElapsed(timeout) + " timeout, retrying", true
+	case float64(elapsed) >= timeoutWarnFraction*float64(timeout):
+		return text + " · timeout " + formatElapsed(timeout), true
+	}
+	return text, false
+}
+
+// FormatToolProgress renders a single tool's progress snapshot. Returns ""
+// when the tool has not reported progress yet.
+func FormatToolProgress(t workflow.ToolInFlight) string {
+	p := t.Progress
+	if p == nil {
+		return ""
+	}
+	var parts []string
+	if p.Command != "" {
+		parts = append(parts, truncateProgress(p.Command, maxProgressCommandLen))
+	}
+	if p.Total > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d", p.Current, p.Total))
+	}
+	if p.BytesWritten > 0 {
+		parts = append(parts, formatByteSize(p.BytesWritten))
+	}
+	if p.LastLine != "" && p.LastLine != p.Command {
+		parts = append(parts, truncateProgress(p.LastLine, maxProgressLastLineLen))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  " + t.Name + ": " + strings.Join(parts, " · ")
+}
+
+// Width caps for the pieces of a tool progress line.
+const (
+	maxProgressCommandLen  = 60
+	maxProgressLastLineLen = 60
+)
+
+// truncateProgress shortens s to at most n runes, marking the cut with "…".
+func truncateProgress(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/sdk/converter"
+
+	"github.com/mfateev/temporal-agent-harness/internal/llm"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestFormatStreamProgress(t *testing.T) {
+	assert.Equal(t, "", FormatStreamProgress(nil))
+	assert.Equal(t, "", FormatStreamProgress(&llm.StreamProgress{}))
+	assert.Equal(t, "apply_patch building… 2.1 KB",
+		FormatStreamProgress(&llm.StreamProgress{ToolName: "apply_patch", ArgBytes: 2150}))
+	assert.Equal(t, "Writing… 300 B",
+		FormatStreamProgress(&llm.StreamProgress{TextBytes: 300}))
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert.Equal(t, "0 B", formatByteSize(0))
+	assert.Equal(t, "1023 B", formatByteSize(1023))
+	assert.Equal(t, "1.0 KB", formatByteSize(1024))
+	assert.Equal(t, "1.5 MB", formatByteSize(1536*1024))
+}
+
+func TestLLMProgressFromPending(t *testing.T) {
+	details, err := converter.GetDefaultDataConverter().ToPayloads(
+		llm.StreamProgress{ToolName: "apply_patch", ArgBytes: 4096})
+	require.NoError(t, err)
+
+	pending := []*workflowpb.PendingActivityInfo{
+		{ActivityType: &commonpb.ActivityType{Name: "ExecuteTool"}, HeartbeatDetails: details},
+		{ActivityType: &commonpb.ActivityType{Name: "ExecuteLLMCall"}, HeartbeatDetails: details},
+	}
+	p := llmProgressFromPending(pending, nil)
+	require.NotNil(t, p)
+	assert.Equal(t, "apply_patch", p.ToolName)
+	assert.Equal(t, 4096, p.ArgBytes)
+
+	// No heartbeat yet → no progress.
+	assert.Nil(t, llmProgressFromPending([]*workflowpb.PendingActivityInfo{
+		{ActivityType: &commonpb.ActivityType{Name: "ExecuteLLMCall"}},
+	}, nil))
+}
+
+func TestHandleLLMProgress_StopsWhenNotCallingLLM(t *testing.T) {
+	m := &Model{state: StateWatching, lastPhase: workflow.PhaseToolExecuting, llmProgressPolling: true, spinnerMsg: "Running tool..."}
+	_, cmd := m.handleLLMProgress(LLMProgressMsg{Progress: &llm.StreamProgress{ToolName: "apply_patch"}})
+	assert.Nil(t, cmd)
+	assert.False(t, m.llmProgressPolling)
+	assert.Equal(t, "Running tool...", m.spinnerMsg)
+}
+
+func TestHandleLLMProgress_UpdatesSpinner(t *testing.T) {
+	m := &Model{state: StateWatching, lastPhase: workflow.PhaseLLMCalling, llmProgressPolling: true}
+	_, cmd := m.handleLLMProgress(LLMProgressMsg{Progress: &llm.StreamProgress{ToolName: "apply_patch", ArgBytes: 512}})
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "apply_patch building… 512 B", m.spinnerMsg)
+}
+
+func TestFormatToolProgress(t *testing.T) {
+	assert.Equal(t, "", FormatToolProgress(workflow.ToolInFlight{Name: "shell"}))
+	assert.Equal(t, "", FormatToolProgress(workflow.ToolInFlight{Name: "shell", Progress: &tools.ToolProgress{}}))
+
+	line := FormatToolProgress(workflow.ToolInFlight{
+		Name: "shell_command",
+		Progress: &tools.ToolProgress{
+			Command:      "go test ./...",
+			BytesWritten: 12600,
+			Current:      3,
+			Total:        10,
+			LastLine:     "ok  pkg/a",
+		},
+	})
+	assert.Equal(t, "  shell_command: go test ./... · 3/10 · 12.3 KB · ok  pkg/a", line)
+
+	long := FormatToolProgress(workflow.ToolInFlight{
+		Name:     "exec_command",
+		Progress: &tools.ToolProgress{Command: strings.Repeat("x", 100)},
+	})
+	assert.Equal(t, "  exec_command: "+strings.Repeat("x", maxProgressCommandLen-1)+"…", long)
+}
+
+func TestToolProgressLines_SkipsToolsWithoutProgress(t *testing.T) {
+	lines := ToolProgressLines([]workflow.ToolInFlight{
+		{Name: "read_file", CallID: "a"},
+		{Name: "shell", CallID: "b", Progress: &tools.ToolProgress{BytesWritten: 512}},
+	}, time.Now())
+	assert.Equal(t, []ProgressLine{{Text: "  shell: 512 B", CallID: "b"}}, lines)
+	assert.Nil(t, ToolProgressLines(nil, time.Now()))
+}
+
+func TestToolProgressLines_ElapsedTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	lines := ToolProgressLines([]workflow.ToolInFlight{
+		{Name: "read_file", CallID: "a", StartedAt: now.Add(-3 * time.Second)},
+		{
+			Name:      "shell",
+			CallID:    "b",
+			Progress:  &tools.ToolProgress{Command: "make build"},
+			StartedAt: now.Add(-134 * time.Second),
+			Timeout:   2*time.Minute + 30*time.Second,
+		},
+	}, now)
+	assert.Equal(t, []ProgressLine{
+		{Text: "  read_file: 3.0s", CallID: "a"},
+		{Text: "  shell: make build · 2m14s · timeout 2m30s", NearTimeout: true, CallID: "b"},
+	}, lines)
+}
+
+func TestFormatActivityTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeout := 90 * time.Second
+
+	text, near := FormatActivityTime(now.Add(-37*time.Second), timeout, now)
+	assert.Equal(t, "37s", text)
+	assert.False(t, near)
+
+	text, near = FormatActivityTime(now.Add(-75*time.Second), timeout, now)
+	assert.Equal(t, "1m15s · timeout 1m30s", text)
+	assert.True(t, near)
+
+	text, near = FormatActivityTime(now.Add(-100*time.Second), timeout, now)
+	assert.Equal(t, "1m40s · past 1m30s timeout, retrying", text)
+	assert.True(t, near)
+
+	text, near = FormatActivityTime(now.Add(-10*time.Minute), 0, now)
+	assert.Equal(t, "10m00s", text)
+	assert.False(t, near)
+
+	text, _ = FormatActivityTime(now.Add(time.Second), timeout, now)
+	assert.Equal(t, "0.0s", text, "clock skew must not produce negative durations")
+}
+
+func TestView_ShowsToolProgressWhileWatching(t *testing.T) {
+	m := newTestModel()
+	m.state = StateWatching
+	m.spinnerMsg = "Running shell_command..."
+	m.toolsInFlight = []workflow.ToolInFlight{{
+		Name:     "shell_command",
+		Progress: &tools.ToolProgress{Command: "make", Current: 2, Total: 4},
+	}}
+	assert.Contains(t, m.View(), "shell_command: make · 2/4")
+
+	m.state = StateInput
+	assert.NotContains(t, m.View(), "shell_command: make · 2/4")
+}
+
+func TestView_ShowsLLMCallElapsedWhileWatching(t *testing.T) {
+	m := newTestModel()
+	m.state = StateWatching
+	m.spinnerMsg = "Thinking..."
+	m.phaseStartedAt = time.Now().Add(-37 * time.Second)
+	m.phaseTimeout = 90 * time.Second
+	assert.Contains(t, m.View(), "Thinking... 37s")
+}
+
+func TestSelectedToolCall(t *testing.T) {
+	m := Model{toolsInFlight: []workflow.ToolInFlight{
+		{Name: "shell_command", CallID: "a"},
+		{Name: "read_file", CallID: "b"},
+	}}
+	assert.Equal(t, "a", m.selectedToolCallID(), "defaults to the first call")
+
+	m.selectedToolCall = m.nextToolCallID()
+	assert.Equal(t, "b", m.selectedToolCallID())
+	m.selectedToolCall = m.nextToolCallID()
+	assert.Equal(t, "a", m.selectedToolCallID(), "wraps around")
+
+	m.selectedToolCall = "gone"
+	assert.Equal(t, "a", m.selectedToolCallID(), "falls back when the call finished")
+
+	assert.Equal(t, "", Model{}.selectedToolCallID())
+}
+
+func TestMarkSelectedToolLine(t *testing.T) {
+	lines := []ProgressLine{
+		{Text: "  shell_command: sleep 60 · 12s", CallID: "a"},
+		{Text: "  read_file: 1.0s", CallID: "b"},
+	}
+	markSelectedToolLine(lines, "b")
+	assert.Equal(t, "  shell_command: sleep 60 · 12s", lines[0].Text)
+	assert.Equal(t, "› read_file: 1.0s · x to cancel", lines[1].Text)
+}
@@ -4,7 +4,8 @@ import "strings"
 
 // DetectProvider returns the provider name inferred from a model name string.
 // Returns "openai" for GPT/o-series models, "anthropic" for Claude models,
-// and "openai" as the fallback default.
+// "bedrock" for Bedrock model IDs, "vertex" for Gemini models, and "openai"
+// as the fallback default.
 func DetectProvider(model string) string {
 	m := strings.ToLower(model)
 
@@ -24,9 +25,14 @@ func DetectProvider(model string) string {
 		return "anthropic"
 	}
 
-	// Google models
+	// Bedrock model and inference profile IDs (anthropic.claude-…, us.anthropic.claude-…)
+	if strings.Contains(m, "anthropic.claude") {
+		return "bedrock"
+	}
+
+	// Google models, served through Vertex AI
 	if strings.HasPrefix(m, "gemini-") {
-		return "google"
+		return "vertex"
 	}
 
 	// Default to openai
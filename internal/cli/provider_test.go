@@ -35,8 +35,10 @@ func TestDetectProvider(t *testing.T) {
 		{"Claude-3-opus", "anthropic"},
 
 		// Google Gemini models
-		{"gemini-pro", "google"},
-		{"gemini-1.5-pro", "google"},
+		{"gemini-pro", "vertex"},
+		{"gemini-1.5-pro", "vertex"},
+		{"anthropic.claude-3-haiku-20240307-v1:0", "bedrock"},
+		{"us.anthropic.claude-sonnet-4-5-20250929-v1:0", "bedrock"},
 
 		// Default fallback
 		{"unknown-model", "openai"},
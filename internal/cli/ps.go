@@ -37,3 +37,36 @@ func formatExecSessionsDisplay(sessions []workflow.ExecSessionSummary) string {
 
 	return b.String()
 }
+
+// formatTrackedExecSessionsDisplay formats the exec_command processes the
+// model left running (TurnStatus.ExecSessions) with their uptime and last
+// output, for /sessions.
+func formatTrackedExecSessionsDisplay(sessions []workflow.ExecSessionStatus, now time.Time) string {
+	if len(sessions) == 0 {
+		return "No running exec sessions.\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Running Exec Sessions (%d)\n", len(sessions)))
+	b.WriteString("──────────────────────────\n")
+	for _, s := range sessions {
+		b.WriteString(fmt.Sprintf("  [%s] %s  (up %s)\n", s.ProcessID, s.Command, formatElapsed(now.Sub(s.StartedAt))))
+		for _, line := range strings.Split(s.LastOutput, "\n") {
+			if line != "" {
+				b.WriteString("      │ " + line + "\n")
+			}
+		}
+	}
+	b.WriteString("Type into one with /sessions write <id> <text>.\n")
+	return b.String()
+}
+
+// parseSessionsWriteCommand parses the arguments of /sessions write:
+// "<id> <text>". The text is sent followed by a newline.
+func parseSessionsWriteCommand(args string) (workflow.WriteExecStdinRequest, error) {
+	id, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if id == "" {
+		return workflow.WriteExecStdinRequest{}, fmt.Errorf("usage: /sessions write <id> <text>")
+	}
+	return workflow.WriteExecStdinRequest{ProcessID: id, Chars: text + "\n"}, nil
+}
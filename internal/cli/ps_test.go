@@ -40,3 +40,31 @@ func TestFormatExecSessionsDisplay_WithSessions(t *testing.T) {
 	assert.Contains(t, result, "python script.py")
 	assert.Contains(t, result, "exit(0)")
 }
+
+func TestFormatTrackedExecSessionsDisplay(t *testing.T) {
+	assert.Equal(t, "No running exec sessions.\n", formatTrackedExecSessionsDisplay(nil, time.Now()))
+
+	started := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	result := formatTrackedExecSessionsDisplay([]workflow.ExecSessionStatus{{
+		ProcessID:  "7",
+		Command:    "npm init",
+		StartedAt:  started,
+		LastOutput: "This utility will walk you through\npackage name: (demo)",
+	}}, started.Add(125*time.Second))
+	assert.Contains(t, result, "Running Exec Sessions (1)")
+	assert.Contains(t, result, "  [7] npm init  (up 2m05s)\n")
+	assert.Contains(t, result, "      │ package name: (demo)\n")
+}
+
+func TestParseSessionsWriteCommand(t *testing.T) {
+	req, err := parseSessionsWriteCommand(" 7 my-package ")
+	assert.NoError(t, err)
+	assert.Equal(t, workflow.WriteExecStdinRequest{ProcessID: "7", Chars: "my-package\n"}, req)
+
+	req, err = parseSessionsWriteCommand("7")
+	assert.NoError(t, err)
+	assert.Equal(t, "\n", req.Chars, "no text just presses Enter")
+
+	_, err = parseSessionsWriteCommand("")
+	assert.Error(t, err)
+}
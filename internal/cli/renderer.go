@@ -2,15 +2,15 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
-	gansi "github.com/charmbracelet/glamour/ansi"
-	glamourstyles "github.com/charmbracelet/glamour/styles"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 	"golang.org/x/term"
 )
@@ -22,6 +22,10 @@ type ItemRenderer struct {
 	noMarkdown bool
 	styles     Styles
 	mdRenderer *glamour.TermRenderer
+
+	images       ImageProtocol // How image attachments are shown; "" for off
+	locale       Locale        // Language of prompts and system text; "" for English
+	kittyImageID int           // Last kitty image ID used
 }
 
 // NewItemRenderer creates a renderer for conversation items.
@@ -40,8 +44,12 @@ func NewItemRenderer(width int, noColor, noMarkdown bool, styles Styles) *ItemRe
 				w = tw
 			}
 		}
+		mdStyle := builtinThemes[ThemeDark].markdownStyle()
+		if styles.Markdown != nil {
+			mdStyle = *styles.Markdown
+		}
 		md, err := glamour.NewTermRenderer(
-			glamour.WithStyles(darkStyleCleanHeadings()),
+			glamour.WithStyles(mdStyle),
 			glamour.WithWordWrap(w),
 		)
 		if err == nil {
@@ -51,10 +59,29 @@ func NewItemRenderer(width int, noColor, noMarkdown bool, styles Styles) *ItemRe
 	return r
 }
 
+// pinGlyph marks pinned items, which compaction keeps verbatim.
+const pinGlyph = "📌"
+
 // RenderItem renders a single conversation item as a string.
 // isResume controls whether user messages are shown (they are during resume).
 // Returns empty string if the item produces no visible output.
 func (r *ItemRenderer) RenderItem(item models.ConversationItem, isResume bool) string {
+	out := r.renderItem(item, isResume)
+	// A pinned output's call already carries the glyph.
+	if item.Pinned && out != "" && item.Type != models.ItemTypeFunctionCallOutput {
+		out = markPinned(out)
+	}
+	return out
+}
+
+// markPinned puts the pin glyph at the start of the first rendered line.
+func markPinned(s string) string {
+	body := strings.TrimLeft(s, "\n")
+	return s[:len(s)-len(body)] + pinGlyph + " " + body
+}
+
+// renderItem renders item by type; see RenderItem.
+func (r *ItemRenderer) renderItem(item models.ConversationItem, isResume bool) string {
 	switch item.Type {
 	case models.ItemTypeTurnStarted:
 		// No separator in viewport — the input area has its own separators.
@@ -69,18 +96,71 @@ func (r *ItemRenderer) RenderItem(item models.ConversationItem, isResume bool) s
 	case models.ItemTypeFunctionCall:
 		return r.RenderFunctionCall(item)
 	case models.ItemTypeFunctionCallOutput:
+		if item.Name == "ask_user" && item.Output != nil && item.Output.Success != nil && *item.Output.Success {
+			return "" // the answer is shown by the user_answer item
+		}
 		return r.RenderFunctionCallOutput(item)
+	case models.ItemTypeUserAnswer:
+		return r.RenderUserAnswer(item)
+	case models.ItemTypeUserShellCommand:
+		return r.RenderUserShellCommand(item)
+	case models.ItemTypeDeveloperMessage:
+		return r.RenderDeveloperMessage(item)
+	case models.ItemTypeAnnotation:
+		return r.RenderAnnotation(item)
 	case models.ItemTypeWebSearchCall:
 		return r.RenderWebSearchCall(item)
 	case models.ItemTypeCompaction:
 		return r.RenderCompaction(item)
 	case models.ItemTypeTurnComplete:
-		return ""
+		return r.RenderTurnComplete(item)
 	default:
 		return ""
 	}
 }
 
+// RenderTurnComplete renders the turn's auto-verify and peer review
+// outcomes, if any.
+func (r *ItemRenderer) RenderTurnComplete(item models.ConversationItem) string {
+	return r.renderVerify(item.Verify) + r.renderReview(item.Review)
+}
+
+// renderVerify renders an auto-verify outcome.
+func (r *ItemRenderer) renderVerify(v *models.VerifyResult) string {
+	if v == nil || v.Status == "" {
+		return ""
+	}
+	attempts := "1 attempt"
+	if v.Attempts != 1 {
+		attempts = fmt.Sprintf("%d attempts", v.Attempts)
+	}
+	bullet := r.styles.SystemBullet.Render("●")
+	if v.Status == models.VerifyPassed {
+		return bullet + " " + r.styles.OutputSuccess.Render(fmt.Sprintf("Verified: %s passed (%s)", v.Command, attempts)) + "\n"
+	}
+	return bullet + " " + r.styles.OutputFailure.Render(fmt.Sprintf("Verify failed: %s still failing after %s", v.Command, attempts)) + "\n"
+}
+
+// renderReview renders a peer review outcome.
+func (r *ItemRenderer) renderReview(v *models.ReviewResult) string {
+	if v == nil || v.Status == "" {
+		return ""
+	}
+	rounds := "1 round"
+	if v.Rounds != 1 {
+		rounds = fmt.Sprintf("%d rounds", v.Rounds)
+	}
+	bullet := r.styles.SystemBullet.Render("●")
+	switch v.Status {
+	case models.ReviewApproved:
+		return bullet + " " + r.styles.OutputSuccess.Render(fmt.Sprintf("Peer review approved (%s)", rounds)) + "\n"
+	case models.ReviewRevised:
+		return bullet + " " + fmt.Sprintf("Peer review: revised after %s", rounds) + "\n"
+	default:
+		return bullet + " " + r.styles.OutputFailure.Render(fmt.Sprintf("Peer review failed (%s)", rounds)) + "\n"
+	}
+}
+
 // RenderCompaction renders a compaction marker.
 func (r *ItemRenderer) RenderCompaction(item models.ConversationItem) string {
 	bullet := r.styles.SystemBullet.Render("●")
@@ -96,6 +176,11 @@ func (r *ItemRenderer) RenderTurnSeparator() string {
 	return r.styles.TurnSeparator.Render(strings.Repeat("─", w)) + "\n"
 }
 
+// t translates the renderer's own text into its locale.
+func (r *ItemRenderer) t(msg string, args ...interface{}) string {
+	return r.locale.T(msg, args...)
+}
+
 // RenderSystemMessage renders a system-level message with a yellow bullet.
 func (r *ItemRenderer) RenderSystemMessage(text string) string {
 	bullet := r.styles.SystemBullet.Render("●")
@@ -113,6 +198,49 @@ func (r *ItemRenderer) RenderUserMessage(item models.ConversationItem) string {
 	return chevron + " " + item.Content + "\n"
 }
 
+// RenderUserAnswer renders the user's answer to an ask_user question under
+// the question, with continuation lines indented past the chevron.
+func (r *ItemRenderer) RenderUserAnswer(item models.ConversationItem) string {
+	chevron := r.styles.UserChevron.Render("❯")
+	return "  └ " + chevron + " " + strings.ReplaceAll(item.Content, "\n", "\n      ") + "\n"
+}
+
+// RenderUserShellCommand renders a command the user ran with /!cmd and its
+// output, previewed like tool output.
+func (r *ItemRenderer) RenderUserShellCommand(item models.ConversationItem) string {
+	return r.renderUserShellCommand(item, toolOutputPreviewLines)
+}
+
+func (r *ItemRenderer) renderUserShellCommand(item models.ConversationItem, limit int) string {
+	chevron := r.styles.UserChevron.Render("❯")
+	return "\n" + chevron + " " + r.styles.ToolVerb.Render("!") + " " + item.UserShellCommand() + "\n" +
+		r.renderFunctionCallOutput(item, limit)
+}
+
+// RenderDeveloperMessage renders injected developer instructions dimmed, so
+// they read as side-channel steering rather than conversation.
+func (r *ItemRenderer) RenderDeveloperMessage(item models.ConversationItem) string {
+	text := "◆ developer: " + strings.ReplaceAll(item.Content, "\n", "\n  ")
+	return r.styles.OutputDim.Render(text) + "\n"
+}
+
+// RenderAnnotation renders the user's note or reaction dimmed, with the
+// number of the item it is on.
+func (r *ItemRenderer) RenderAnnotation(item models.ConversationItem) string {
+	a := item.Annotation
+	if a == nil {
+		return ""
+	}
+	text := fmt.Sprintf("✎ on #%d:", a.TargetSeq)
+	if glyph := a.Reaction.Emoji(); glyph != "" {
+		text += " " + glyph
+	}
+	if item.Content != "" {
+		text += " " + strings.ReplaceAll(item.Content, "\n", "\n  ")
+	}
+	return r.styles.OutputDim.Render(text) + "\n"
+}
+
 // RenderAssistantMessage renders an assistant message with optional markdown.
 func (r *ItemRenderer) RenderAssistantMessage(item models.ConversationItem) string {
 	content := item.Content
@@ -132,7 +260,7 @@ func (r *ItemRenderer) RenderAssistantMessage(item models.ConversationItem) stri
 // RenderFunctionCall renders a function call invocation.
 // Example: "● Ran echo hello"
 func (r *ItemRenderer) RenderFunctionCall(item models.ConversationItem) string {
-	verb, detail := formatToolCall(item.Name, item.Arguments)
+	verb, detail := transcript.ToolCallSummary(item.Name, item.Arguments)
 	bullet := r.styles.ToolBullet.Render("●")
 	styledVerb := r.styles.ToolVerb.Render(verb)
 	if detail != "" {
@@ -141,9 +269,37 @@ func (r *ItemRenderer) RenderFunctionCall(item models.ConversationItem) string {
 	return "\n" + bullet + " " + styledVerb + "\n"
 }
 
+// toolOutputPreviewLines is how many lines of tool output are shown until
+// the item is expanded.
+const toolOutputPreviewLines = 5
+
+// RenderItemExpanded renders item like RenderItem, but with tool output in
+// full instead of the preview. The TUI shows it for unfolded items.
+func (r *ItemRenderer) RenderItemExpanded(item models.ConversationItem, isResume bool) string {
+	out := r.RenderItem(item, isResume)
+	switch {
+	case out == "":
+		return out
+	case item.Type == models.ItemTypeFunctionCallOutput:
+		return r.renderFunctionCallOutput(item, 0)
+	case item.Type == models.ItemTypeUserShellCommand:
+		if item.Pinned {
+			return markPinned(r.renderUserShellCommand(item, 0))
+		}
+		return r.renderUserShellCommand(item, 0)
+	}
+	return out
+}
+
 // RenderFunctionCallOutput renders function call output in Codex style.
 // Uses 5-line limit with middle truncation and tree-style prefixes.
 func (r *ItemRenderer) RenderFunctionCallOutput(item models.ConversationItem) string {
+	return r.renderFunctionCallOutput(item, toolOutputPreviewLines)
+}
+
+// renderFunctionCallOutput renders at most limit lines of tool output,
+// truncating the middle; 0 renders all of it.
+func (r *ItemRenderer) renderFunctionCallOutput(item models.ConversationItem, limit int) string {
 	if item.Output == nil {
 		return ""
 	}
@@ -153,11 +309,16 @@ func (r *ItemRenderer) RenderFunctionCallOutput(item models.ConversationItem) st
 
 	if content == "" {
 		line := r.styles.OutputPrefix.Render("  └ ") + r.styles.OutputDim.Render("(no output)")
-		return line + "\n"
+		return line + "\n" + r.renderAttachments(item.Output.Attachments)
 	}
 
-	lines := strings.Split(content, "\n")
-	displayed, _ := truncateMiddle(lines, 5)
+	displayed := strings.Split(content, "\n")
+	if limit > 0 {
+		var omitted int
+		if displayed, omitted = transcript.TruncateMiddle(displayed, limit); omitted > 0 {
+			displayed[2] += ", " + foldHint // the "… +N lines" placeholder
+		}
+	}
 
 	var b strings.Builder
 	for i, line := range displayed {
@@ -173,6 +334,10 @@ func (r *ItemRenderer) RenderFunctionCallOutput(item models.ConversationItem) st
 			b.WriteString(prefix + r.styles.OutputDim.Render(line) + "\n")
 		}
 	}
+	if n := item.Output.Redactions; n > 0 {
+		b.WriteString(r.styles.OutputPrefix.Render("    ") + r.styles.OutputDim.Render(transcript.FormatRedactions(n)) + "\n")
+	}
+	b.WriteString(r.renderAttachments(item.Output.Attachments))
 
 	return b.String()
 }
@@ -182,7 +347,7 @@ func (r *ItemRenderer) RenderFunctionCallOutput(item models.ConversationItem) st
 //
 // Maps to: codex-rs/tui/src/history_cell.rs WebSearchCell
 func (r *ItemRenderer) RenderWebSearchCall(item models.ConversationItem) string {
-	verb, detail := formatWebSearchCall(item.WebSearchAction, item.Content, item.WebSearchURL)
+	verb, detail := transcript.WebSearchSummary(item.WebSearchAction, item.Content, item.WebSearchURL)
 	bullet := r.styles.ToolBullet.Render("●")
 	styledVerb := r.styles.ToolVerb.Render(verb)
 	if detail != "" {
@@ -191,33 +356,6 @@ func (r *ItemRenderer) RenderWebSearchCall(item models.ConversationItem) string
 	return "\n" + bullet + " " + styledVerb + "\n"
 }
 
-// formatWebSearchCall returns the verb and detail for a web search action.
-//
-//	search       → ("Searched", query)
-//	open_page    → ("Opened page", URL)
-//	find_in_page → ("Searched page", "'pattern' in URL")
-//	unknown      → ("Searched web", "")
-//
-// Maps to: codex-rs/exec/src/event_processor_with_human_output.rs WebSearchEnd
-func formatWebSearchCall(action, content, url string) (verb, detail string) {
-	switch action {
-	case "search":
-		return "Searched", content
-	case "open_page":
-		if url != "" {
-			return "Opened page", url
-		}
-		return "Opened page", content
-	case "find_in_page":
-		return "Searched page", content
-	default:
-		if content != "" {
-			return "Searched", content
-		}
-		return "Searched web", ""
-	}
-}
-
 // renderApprovalEntry writes a single tool entry (title + optional preview box + reason)
 // into the provided builder.
 func (r *ItemRenderer) renderApprovalEntry(b *strings.Builder, index int, info approvalInfo, reason string) {
@@ -232,8 +370,11 @@ func (r *ItemRenderer) renderApprovalEntry(b *strings.Builder, index int, info a
 		}
 		b.WriteString("      " + r.styles.OutputPrefix.Render("╰─") + "\n")
 	}
+	for _, line := range info.Details {
+		b.WriteString("      " + r.styles.OutputDim.Render(line) + "\n")
+	}
 	if reason != "" {
-		reasonStr := r.styles.ApprovalReason.Render("Reason:") + " " + reason
+		reasonStr := r.styles.ApprovalReason.Render(r.t("Reason:")) + " " + reason
 		b.WriteString(fmt.Sprintf("      %s\n", reasonStr))
 	}
 }
@@ -256,14 +397,14 @@ func (r *ItemRenderer) RenderApprovalPrompt(approvals []workflow.PendingApproval
 	var b strings.Builder
 	b.WriteString("\n")
 	for i, ap := range approvals {
-		info := formatApprovalInfo(ap.ToolName, ap.Arguments)
+		info := pendingApprovalInfo(ap, r.locale)
 		r.renderApprovalEntry(&b, i+1, info, ap.Reason)
 		b.WriteString("\n")
 	}
 	if len(approvals) > 1 {
-		b.WriteString("Allow? [y]es / [n]o / [a]lways / 1,2 (select by index): ")
+		b.WriteString(r.t("Allow? [y]es / [n]o / [a]lways / 1,2 (select by index): "))
 	} else {
-		b.WriteString("Allow? [y]es / [n]o / [a]lways: ")
+		b.WriteString(r.t("Allow? [y]es / [n]o / [a]lways: "))
 	}
 	return b.String()
 }
@@ -272,7 +413,7 @@ func (r *ItemRenderer) RenderApprovalPrompt(approvals []workflow.PendingApproval
 func (r *ItemRenderer) RenderEscalationPrompt(escalations []workflow.EscalationRequest) string {
 	var b strings.Builder
 	b.WriteString("\n")
-	b.WriteString(r.styles.EscalationHeader.Render("Sandbox failure — escalation needed:") + "\n\n")
+	b.WriteString(r.styles.EscalationHeader.Render(r.t("Sandbox failure — escalation needed:")) + "\n\n")
 	for i, esc := range escalations {
 		info := formatApprovalInfo(esc.ToolName, esc.Arguments)
 		r.renderApprovalEntry(&b, i+1, info, "")
@@ -281,12 +422,12 @@ func (r *ItemRenderer) RenderEscalationPrompt(escalations []workflow.EscalationR
 			if len(outputPreview) > 200 {
 				outputPreview = outputPreview[:200] + "..."
 			}
-			label := r.styles.EscalationOutput.Render("Output:")
+			label := r.styles.EscalationOutput.Render(r.t("Output:"))
 			b.WriteString(fmt.Sprintf("      %s %s\n", label, outputPreview))
 		}
 		b.WriteString("\n")
 	}
-	b.WriteString("Re-run without sandbox? [y]es / [n]o: ")
+	b.WriteString(r.t("Re-run without sandbox? [y]es / [n]o: "))
 	return b.String()
 }
 
@@ -294,7 +435,7 @@ func (r *ItemRenderer) RenderEscalationPrompt(escalations []workflow.EscalationR
 func (r *ItemRenderer) RenderUserInputQuestionPrompt(req *workflow.PendingUserInputRequest) string {
 	var b strings.Builder
 	b.WriteString("\n")
-	b.WriteString(r.styles.EscalationHeader.Render("The assistant has a question for you:") + "\n\n")
+	b.WriteString(r.styles.EscalationHeader.Render(r.t("The assistant has a question for you:")) + "\n\n")
 
 	for i, q := range req.Questions {
 		if len(req.Questions) > 1 {
@@ -313,17 +454,46 @@ func (r *ItemRenderer) RenderUserInputQuestionPrompt(req *workflow.PendingUserIn
 		b.WriteString("\n")
 	}
 
-	b.WriteString("Enter option number (or type your answer): ")
+	b.WriteString(r.t("Enter option number (or type your answer): "))
+	return b.String()
+}
+
+// RenderAskUserPrompt renders an ask_user question with its markdown body
+// and the answer instructions.
+func (r *ItemRenderer) RenderAskUserPrompt(req *workflow.PendingAskUserRequest) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(r.styles.EscalationHeader.Render(r.t("The assistant has a question for you:")) + "\n\n")
+	b.WriteString("  " + req.Question + "\n")
+	if req.Body != "" {
+		b.WriteString(r.renderMarkdown(req.Body))
+	}
+	b.WriteString("\n")
+	hint := r.t("Type your answer (Shift+Enter for a new line, Enter to send)")
+	if req.Default != "" {
+		hint += r.t(" — empty answer uses %q", req.Default)
+	}
+	b.WriteString(r.styles.StatusLine.Render(hint) + "\n")
 	return b.String()
 }
 
+// renderMarkdown renders text as markdown when enabled, otherwise indents it.
+func (r *ItemRenderer) renderMarkdown(text string) string {
+	if r.mdRenderer != nil {
+		if rendered, err := r.mdRenderer.Render(text); err == nil {
+			return rendered
+		}
+	}
+	return "\n  " + strings.ReplaceAll(text, "\n", "\n  ") + "\n"
+}
+
 // RenderApprovalContext renders the approval details for the viewport without
 // the prompt line (selector handles the options). Used when selector is active.
 func (r *ItemRenderer) RenderApprovalContext(approvals []workflow.PendingApproval) string {
 	var b strings.Builder
 	b.WriteString("\n")
 	for i, ap := range approvals {
-		info := formatApprovalInfo(ap.ToolName, ap.Arguments)
+		info := pendingApprovalInfo(ap, r.locale)
 		r.renderApprovalEntry(&b, i+1, info, ap.Reason)
 		b.WriteString("\n")
 	}
@@ -333,9 +503,12 @@ func (r *ItemRenderer) RenderApprovalContext(approvals []workflow.PendingApprova
 // RenderEscalationContext renders escalation details for the viewport without
 // the prompt line (selector handles the options). Used when selector is active.
 func (r *ItemRenderer) RenderEscalationContext(escalations []workflow.EscalationRequest) string {
+	if isPatchConflictEscalation(escalations) {
+		return r.renderPatchConflictContext(escalations)
+	}
 	var b strings.Builder
 	b.WriteString("\n")
-	b.WriteString(r.styles.EscalationHeader.Render("Sandbox failure — escalation needed:") + "\n\n")
+	b.WriteString(r.styles.EscalationHeader.Render(r.t("Sandbox failure — escalation needed:")) + "\n\n")
 	for i, esc := range escalations {
 		info := formatApprovalInfo(esc.ToolName, esc.Arguments)
 		r.renderApprovalEntry(&b, i+1, info, "")
@@ -344,7 +517,7 @@ func (r *ItemRenderer) RenderEscalationContext(escalations []workflow.Escalation
 			if len(outputPreview) > 200 {
 				outputPreview = outputPreview[:200] + "..."
 			}
-			label := r.styles.EscalationOutput.Render("Output:")
+			label := r.styles.EscalationOutput.Render(r.t("Output:"))
 			b.WriteString(fmt.Sprintf("      %s %s\n", label, outputPreview))
 		}
 		b.WriteString("\n")
@@ -352,12 +525,55 @@ func (r *ItemRenderer) RenderEscalationContext(escalations []workflow.Escalation
 	return b.String()
 }
 
+// patchConflictPreviewLines caps the expected and found lines shown for a
+// patch conflict.
+const patchConflictPreviewLines = 8
+
+// renderPatchConflictContext shows, for each apply_patch hunk that did not
+// apply, the expected lines (-) against the closest match in the file (+).
+func (r *ItemRenderer) renderPatchConflictContext(escalations []workflow.EscalationRequest) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(r.styles.EscalationHeader.Render(r.t("Patch did not apply — apply it at the closest match?")) + "\n\n")
+	for i, esc := range escalations {
+		c := esc.PatchConflict
+		info := approvalInfo{Title: fmt.Sprintf("Patch: %s (hunk %d)", c.Path, c.Hunk)}
+		info.Preview = append(info.Preview, r.t("expected:"))
+		for _, l := range previewLines(c.Expected) {
+			info.Preview = append(info.Preview, "-"+l)
+		}
+		info.Preview = append(info.Preview, r.t("found at line %d (%d%% match):", c.BestMatchLine, patch.Percent(c.Confidence)))
+		for _, l := range previewLines(c.Found) {
+			info.Preview = append(info.Preview, "+"+l)
+		}
+		r.renderApprovalEntry(&b, i+1, info, "")
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// previewLines returns at most patchConflictPreviewLines of lines, with a
+// marker for the rest.
+func previewLines(lines []string) []string {
+	if len(lines) <= patchConflictPreviewLines {
+		return lines
+	}
+	out := append([]string{}, lines[:patchConflictPreviewLines]...)
+	return append(out, fmt.Sprintf(" … %d more lines", len(lines)-patchConflictPreviewLines))
+}
+
+// isPatchConflictEscalation reports whether escalations ask about
+// apply_patch conflicts rather than sandbox failures.
+func isPatchConflictEscalation(escalations []workflow.EscalationRequest) bool {
+	return len(escalations) > 0 && escalations[0].PatchConflict != nil
+}
+
 // RenderUserInputQuestionContext renders the question details for the viewport
 // without the prompt line (selector handles the options).
 func (r *ItemRenderer) RenderUserInputQuestionContext(req *workflow.PendingUserInputRequest) string {
 	var b strings.Builder
 	b.WriteString("\n")
-	b.WriteString(r.styles.EscalationHeader.Render("The assistant has a question for you:") + "\n\n")
+	b.WriteString(r.styles.EscalationHeader.Render(r.t("The assistant has a question for you:")) + "\n\n")
 
 	for i, q := range req.Questions {
 		if len(req.Questions) > 1 {
@@ -403,6 +619,45 @@ func (r *ItemRenderer) RenderPlan(plan *workflow.PlanState) string {
 	return b.String()
 }
 
+// RenderTaskPanel renders the shared task list as a compact panel shown
+// above the input area. At most maxItems tasks are listed, open tasks
+// first. Returns "" for an empty list.
+func (r *ItemRenderer) RenderTaskPanel(tasks []workflow.TaskItem, maxItems int) string {
+	if r == nil || len(tasks) == 0 {
+		return ""
+	}
+
+	var open, done []workflow.TaskItem
+	for _, t := range tasks {
+		if t.Done {
+			done = append(done, t)
+		} else {
+			open = append(open, t)
+		}
+	}
+
+	var b strings.Builder
+	label := r.styles.ToolVerb.Render("Tasks")
+	b.WriteString(fmt.Sprintf("%s %d/%d done", label, len(done), len(tasks)))
+
+	shown := 0
+	for _, t := range append(open, done...) {
+		if shown == maxItems {
+			break
+		}
+		marker := r.styles.PlanPending.Render("○")
+		if t.Done {
+			marker = r.styles.PlanCompleted.Render("✓")
+		}
+		b.WriteString(fmt.Sprintf("\n  %s #%d %s", marker, t.ID, transcript.TruncateString(t.Text, max(20, r.width-12))))
+		shown++
+	}
+	if rest := len(tasks) - shown; rest > 0 {
+		b.WriteString(fmt.Sprintf("\n  … +%d more (/todo to list)", rest))
+	}
+	return b.String()
+}
+
 // RenderStatusLine renders a summary status after a turn completes.
 func (r *ItemRenderer) RenderStatusLine(model string, totalTokens, turnCount int) string {
 	line := fmt.Sprintf("[%s · %s tokens · turn %d]",
@@ -410,11 +665,29 @@ func (r *ItemRenderer) RenderStatusLine(model string, totalTokens, turnCount int
 	return r.styles.StatusLine.Render(line) + "\n"
 }
 
+// RenderTurnTiming renders a compact timing breakdown after a turn completes.
+// Example: "turn took 42s: llm 30s, tools 8s, waiting 4s"
+func (r *ItemRenderer) RenderTurnTiming(t *workflow.TurnTiming) string {
+	if t == nil || t.Total <= 0 {
+		return ""
+	}
+	line := fmt.Sprintf("turn took %s: llm %s, tools %s",
+		formatElapsed(t.Total), formatElapsed(t.LLM), formatElapsed(t.Tools))
+	if t.Waiting > 0 {
+		line += fmt.Sprintf(", waiting %s", formatElapsed(t.Waiting))
+	}
+	return r.styles.StatusLine.Render(line) + "\n"
+}
+
 // PhaseMessage returns a human-friendly message for a turn phase.
 func PhaseMessage(phase workflow.TurnPhase, toolsInFlight []string) string {
 	switch phase {
 	case workflow.PhaseLLMCalling:
 		return "Thinking..."
+	case workflow.PhaseLLMQueued:
+		return "Waiting for rate limit..."
+	case workflow.PhaseRateLimited:
+		return "Rate limited, resuming shortly..."
 	case workflow.PhaseToolExecuting:
 		if len(toolsInFlight) > 0 {
 			return fmt.Sprintf("Running %s...", toolsInFlight[0])
@@ -428,105 +701,45 @@ func PhaseMessage(phase workflow.TurnPhase, toolsInFlight []string) string {
 		return "Waiting for your answer..."
 	case workflow.PhaseCompacting:
 		return "Compacting context..."
+	case workflow.PhaseVerifying:
+		return "Verifying..."
+	case workflow.PhaseReviewing:
+		return "Reviewing changes..."
+	case workflow.PhaseCheckingIn:
+		return "Checking in..."
 	default:
 		return "Working..."
 	}
 }
 
-// formatToolCall parses the tool name and JSON arguments, returning a
-// human-readable verb and detail string matching the Codex output style.
-//
-//	shell        → ("Ran", "echo hello")
-//	read_file    → ("Read", "/tmp/foo.txt")
-//	write_file   → ("Wrote", "/tmp/bar.txt")
-//	apply_patch  → ("Update", "path/to/file") or ("Patched", "")
-//	list_dir     → ("Listed", "/tmp")
-//	grep_files   → ("Searched", `"TODO" in src/`)
-//	unknown      → ("Ran", "unknown_tool(…)")
-func formatToolCall(name, argsJSON string) (verb, detail string) {
-	var args map[string]interface{}
-	_ = json.Unmarshal([]byte(argsJSON), &args)
-
-	switch name {
-	case "shell":
-		if cmd, ok := args["command"].(string); ok {
-			return "Ran", truncateString(cmd, 120)
-		}
-		return "Ran", truncateString(argsJSON, 120)
-	case "read_file":
-		if fp, ok := args["file_path"].(string); ok {
-			return "Read", fp
-		}
-		return "Read", ""
-	case "write_file":
-		if fp, ok := args["file_path"].(string); ok {
-			return "Wrote", fp
-		}
-		return "Wrote", ""
-	case "apply_patch":
-		if input, _ := args["input"].(string); input != "" {
-			paths := patchFilePaths(input)
-			if len(paths) == 1 {
-				return "Update", paths[0]
-			}
-			if len(paths) > 1 {
-				return "Update", paths[0] + fmt.Sprintf(" +%d files", len(paths)-1)
-			}
-		}
-		return "Patched", ""
-	case "list_dir":
-		if dp, ok := args["dir_path"].(string); ok {
-			return "Listed", dp
-		}
-		if dp, ok := args["path"].(string); ok {
-			return "Listed", dp
-		}
-		return "Listed", ""
-	case "grep_files":
-		var parts []string
-		if pat, ok := args["pattern"].(string); ok {
-			parts = append(parts, fmt.Sprintf("%q", pat))
-		}
-		if dir, ok := args["path"].(string); ok {
-			parts = append(parts, "in "+dir)
-		}
-		if len(parts) > 0 {
-			return "Searched", strings.Join(parts, " ")
-		}
-		return "Searched", ""
-	case "request_user_input":
-		return "Asked", "user a question"
-	case "update_plan":
-		return "Updated", "plan"
-	default:
-		detail := name + "(" + truncateString(argsJSON, 80) + ")"
-		return "Ran", detail
-	}
+// StatusMessage returns the spinner message for a turn status. It extends
+// PhaseMessage with the rate-limiter queue position while the LLM call waits
+// and the countdown while it waits out a provider rate limit.
+func StatusMessage(status workflow.TurnStatus) string {
+	return statusMessageAt(status, time.Now())
 }
 
-// truncateMiddle returns at most limit lines. When the input exceeds the limit,
-// it keeps the first 2 and last 2 lines with a "… +N lines" placeholder in between.
-// The returned omitted count reflects lines replaced by the placeholder.
-func truncateMiddle(lines []string, limit int) (result []string, omitted int) {
-	if len(lines) <= limit {
-		return lines, 0
+// statusMessageAt is StatusMessage at the given time.
+func statusMessageAt(status workflow.TurnStatus, now time.Time) string {
+	if status.Phase == workflow.PhaseRateLimited && !status.ResumesAt.IsZero() {
+		wait := status.ResumesAt.Sub(now).Round(time.Second)
+		switch {
+		case wait <= 0:
+			return "Rate limited, resuming..."
+		case wait < time.Minute:
+			return fmt.Sprintf("Rate limited, resuming in %ds", int(wait.Seconds()))
+		default:
+			return "Rate limited, resuming in " + formatElapsed(wait)
+		}
 	}
-	head := 2
-	tail := 2
-	omitted = len(lines) - head - tail
-	result = make([]string, 0, head+1+tail)
-	result = append(result, lines[:head]...)
-	result = append(result, fmt.Sprintf("… +%d lines", omitted))
-	result = append(result, lines[len(lines)-tail:]...)
-	return result, omitted
-}
-
-// truncateString truncates s to maxLen characters, appending "…" if truncated.
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+	if status.Phase == workflow.PhaseLLMQueued && status.QueuedBehind > 0 {
+		noun := "requests"
+		if status.QueuedBehind == 1 {
+			noun = "request"
+		}
+		return fmt.Sprintf("Queued behind %d %s (rate limit)...", status.QueuedBehind, noun)
 	}
-	return s[:maxLen] + "…"
+	return PhaseMessage(status.Phase, status.ToolsInFlight)
 }
 
 func indent(s, prefix string) string {
@@ -540,25 +753,22 @@ func indent(s, prefix string) string {
 	return strings.Join(lines, "\n")
 }
 
-// darkStyleCleanHeadings returns a copy of glamour's DarkStyleConfig with
-// heading prefixes (##, ###, etc.) removed so headings render as styled text
-// without raw markdown markers.
-func darkStyleCleanHeadings() gansi.StyleConfig {
-	s := glamourstyles.DarkStyleConfig
-	// Remove document margin so ● bullets align with other items
-	noMargin := uint(0)
-	s.Document.Margin = &noMargin
-	s.H2.Prefix = ""
-	s.H3.Prefix = ""
-	s.H4.Prefix = ""
-	s.H5.Prefix = ""
-	s.H6.Prefix = ""
-	return s
-}
-
 func formatTokens(n int) string {
 	if n >= 1000 {
 		return fmt.Sprintf("%d,%03d", n/1000, n%1000)
 	}
 	return fmt.Sprintf("%d", n)
 }
+
+// formatElapsed formats a duration compactly: "0.4s", "8.2s", "42s", "3m05s".
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < 10*time.Second:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Round(time.Second).Seconds()))
+	default:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
 	"github.com/mfateev/temporal-agent-harness/internal/workflow"
 )
 
@@ -81,6 +84,57 @@ func TestItemRenderer_RenderFunctionCallOutput_Failure(t *testing.T) {
 	assert.Contains(t, result, "command not found")
 }
 
+func TestItemRenderer_RenderFunctionCallOutput_Redactions(t *testing.T) {
+	r := newTestRenderer()
+	success := true
+	result := r.RenderItem(models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: "call-1",
+		Output: &models.FunctionCallOutputPayload{
+			Content:    "KEY=[REDACTED:secret-assignment]",
+			Success:    &success,
+			Redactions: 2,
+		},
+	}, false)
+
+	assert.Contains(t, result, "(2 secrets redacted)")
+	assert.Equal(t, "(1 secret redacted)", transcript.FormatRedactions(1))
+}
+
+func TestItemRenderer_RenderTurnComplete_Verify(t *testing.T) {
+	r := newTestRenderer()
+	assert.Empty(t, r.RenderItem(models.ConversationItem{Type: models.ItemTypeTurnComplete}, false))
+
+	passed := r.RenderItem(models.ConversationItem{
+		Type:   models.ItemTypeTurnComplete,
+		Verify: &models.VerifyResult{Command: "go test ./...", Attempts: 2, Status: models.VerifyPassed},
+	}, false)
+	assert.Contains(t, passed, "Verified: go test ./... passed (2 attempts)")
+
+	failed := r.RenderItem(models.ConversationItem{
+		Type:   models.ItemTypeTurnComplete,
+		Verify: &models.VerifyResult{Command: "make check", Attempts: 1, Status: models.VerifyFailed},
+	}, false)
+	assert.Contains(t, failed, "Verify failed: make check still failing after 1 attempt")
+}
+
+func TestItemRenderer_RenderTurnComplete_Review(t *testing.T) {
+	r := newTestRenderer()
+	out := r.RenderItem(models.ConversationItem{
+		Type:   models.ItemTypeTurnComplete,
+		Verify: &models.VerifyResult{Command: "go test ./...", Attempts: 1, Status: models.VerifyPassed},
+		Review: &models.ReviewResult{Rounds: 2, Status: models.ReviewApproved},
+	}, false)
+	assert.Contains(t, out, "Verified: go test ./... passed (1 attempt)")
+	assert.Contains(t, out, "Peer review approved (2 rounds)")
+
+	revised := r.RenderItem(models.ConversationItem{
+		Type:   models.ItemTypeTurnComplete,
+		Review: &models.ReviewResult{Rounds: 1, Status: models.ReviewRevised},
+	}, false)
+	assert.Contains(t, revised, "Peer review: revised after 1 round")
+}
+
 func TestItemRenderer_TurnStartedNotRenderedInLiveMode(t *testing.T) {
 	r := newTestRenderer()
 	result := r.RenderItem(models.ConversationItem{
@@ -132,6 +186,28 @@ func TestItemRenderer_UserMessageRenderedOnResume(t *testing.T) {
 	assert.Contains(t, result, "Hello from resume")
 }
 
+func TestItemRenderer_RenderDeveloperMessage(t *testing.T) {
+	r := newTestRenderer()
+	result := r.RenderItem(models.ConversationItem{
+		Type:    models.ItemTypeDeveloperMessage,
+		Content: "Keep the API\nunchanged",
+	}, false)
+
+	assert.Equal(t, "◆ developer: Keep the API\n  unchanged\n", stripANSI(result))
+}
+
+func TestItemRenderer_RenderUserShellCommand(t *testing.T) {
+	r := newTestRenderer()
+	ok := true
+	result := r.RenderItem(models.ConversationItem{
+		Type:      models.ItemTypeUserShellCommand,
+		Arguments: `{"command":"ls"}`,
+		Output:    &models.FunctionCallOutputPayload{Content: "a.go\nb.go\n", Success: &ok},
+	}, false)
+
+	assert.Equal(t, "\n❯ ! ls\n  └ a.go\n    b.go\n", stripANSI(result))
+}
+
 func TestItemRenderer_RenderStatusLine(t *testing.T) {
 	r := newTestRenderer()
 	result := r.RenderStatusLine("gpt-4o-mini", 1234, 3)
@@ -270,6 +346,7 @@ func TestPhaseMessage(t *testing.T) {
 		expected      string
 	}{
 		{"llm_calling", nil, "Thinking..."},
+		{"llm_queued", nil, "Waiting for rate limit..."},
 		{"tool_executing", []string{"shell"}, "Running shell..."},
 		{"tool_executing", nil, "Running tool..."},
 		{"waiting_for_input", nil, "Working..."},
@@ -281,6 +358,26 @@ func TestPhaseMessage(t *testing.T) {
 	}
 }
 
+func TestStatusMessage_RateLimited(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := workflow.TurnStatus{Phase: workflow.PhaseRateLimited, ResumesAt: now.Add(23 * time.Second)}
+	assert.Equal(t, "Rate limited, resuming in 23s", statusMessageAt(status, now))
+	status.ResumesAt = now.Add(2*time.Minute + 5*time.Second)
+	assert.Equal(t, "Rate limited, resuming in 2m05s", statusMessageAt(status, now))
+	assert.Equal(t, "Rate limited, resuming...", statusMessageAt(status, now.Add(3*time.Minute)))
+}
+
+func TestStatusMessage_Queued(t *testing.T) {
+	assert.Equal(t, "Queued behind 3 requests (rate limit)...",
+		StatusMessage(workflow.TurnStatus{Phase: workflow.PhaseLLMQueued, QueuedBehind: 3}))
+	assert.Equal(t, "Queued behind 1 request (rate limit)...",
+		StatusMessage(workflow.TurnStatus{Phase: workflow.PhaseLLMQueued, QueuedBehind: 1}))
+	assert.Equal(t, "Waiting for rate limit...",
+		StatusMessage(workflow.TurnStatus{Phase: workflow.PhaseLLMQueued}))
+	assert.Equal(t, "Running shell...",
+		StatusMessage(workflow.TurnStatus{Phase: workflow.PhaseToolExecuting, ToolsInFlight: []string{"shell"}}))
+}
+
 func TestItemRenderer_RenderApprovalPrompt(t *testing.T) {
 	r := newTestRenderer()
 	result := r.RenderApprovalPrompt([]workflow.PendingApproval{
@@ -511,49 +608,6 @@ func TestItemRenderer_OutputExactly5Lines(t *testing.T) {
 	assert.NotContains(t, result, "… +")
 }
 
-func TestTruncateMiddle(t *testing.T) {
-	tests := []struct {
-		name        string
-		input       []string
-		limit       int
-		wantLen     int
-		wantOmitted int
-	}{
-		{
-			name:        "under limit",
-			input:       []string{"a", "b", "c"},
-			limit:       5,
-			wantLen:     3,
-			wantOmitted: 0,
-		},
-		{
-			name:        "at limit",
-			input:       []string{"a", "b", "c", "d", "e"},
-			limit:       5,
-			wantLen:     5,
-			wantOmitted: 0,
-		},
-		{
-			name:        "over limit",
-			input:       []string{"a", "b", "c", "d", "e", "f", "g", "h"},
-			limit:       5,
-			wantLen:     5, // 2 head + 1 ellipsis + 2 tail
-			wantOmitted: 4,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result, omitted := truncateMiddle(tt.input, tt.limit)
-			assert.Equal(t, tt.wantLen, len(result))
-			assert.Equal(t, tt.wantOmitted, omitted)
-			if omitted > 0 {
-				assert.Contains(t, result[2], "… +")
-			}
-		})
-	}
-}
-
 func TestItemRenderer_RenderUserInputQuestionPrompt_SingleQuestion(t *testing.T) {
 	r := newTestRenderer()
 	req := &workflow.PendingUserInputRequest{
@@ -637,60 +691,11 @@ func TestItemRenderer_RenderUserInputQuestionPrompt_OptionsWithDescriptions(t *t
 	assert.NotContains(t, result, "Bare -")
 }
 
-func TestFormatToolCall_RequestUserInput(t *testing.T) {
-	verb, detail := formatToolCall("request_user_input", `{"questions": []}`)
-	assert.Equal(t, "Asked", verb)
-	assert.Equal(t, "user a question", detail)
-}
-
 func TestPhaseMessage_UserInputPending(t *testing.T) {
 	result := PhaseMessage(workflow.PhaseUserInputPending, nil)
 	assert.Equal(t, "Waiting for your answer...", result)
 }
 
-func TestFormatToolCall(t *testing.T) {
-	tests := []struct {
-		name       string
-		toolName   string
-		argsJSON   string
-		wantVerb   string
-		wantDetail string
-	}{
-		{"shell", "shell", `{"command": "echo hello"}`, "Ran", "echo hello"},
-		{"read_file", "read_file", `{"file_path": "/tmp/foo.txt"}`, "Read", "/tmp/foo.txt"},
-		{"write_file", "write_file", `{"file_path": "/tmp/bar.txt"}`, "Wrote", "/tmp/bar.txt"},
-		{"apply_patch_no_input", "apply_patch", `{"file_path": "/tmp/x.go"}`, "Patched", ""},
-		{"list_dir", "list_dir", `{"dir_path": "/tmp"}`, "Listed", "/tmp"},
-		{"grep_files", "grep_files", `{"pattern": "TODO", "path": "src/"}`, "Searched", `"TODO" in src/`},
-		{"unknown", "my_tool", `{"x": 1}`, "Ran", `my_tool({"x": 1})`},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			verb, detail := formatToolCall(tt.toolName, tt.argsJSON)
-			assert.Equal(t, tt.wantVerb, verb)
-			assert.Equal(t, tt.wantDetail, detail)
-		})
-	}
-}
-
-func TestFormatToolCall_ApplyPatchWithInput(t *testing.T) {
-	input := "*** Begin Patch\n*** Update File: src/main.go\n-old\n+new\n*** End Patch"
-	args := fmt.Sprintf(`{"input": %q}`, input)
-	verb, detail := formatToolCall("apply_patch", args)
-	assert.Equal(t, "Update", verb)
-	assert.Equal(t, "src/main.go", detail)
-}
-
-func TestFormatToolCall_ApplyPatchMultiFile(t *testing.T) {
-	input := "*** Begin Patch\n*** Update File: a.go\n-x\n+y\n*** Add File: b.go\n+content\n*** End Patch"
-	args := fmt.Sprintf(`{"input": %q}`, input)
-	verb, detail := formatToolCall("apply_patch", args)
-	assert.Equal(t, "Update", verb)
-	assert.Contains(t, detail, "a.go")
-	assert.Contains(t, detail, "+1 files")
-}
-
 // --- Compaction rendering tests ---
 
 func TestItemRenderer_RenderCompaction(t *testing.T) {
@@ -710,6 +715,11 @@ func TestPhaseMessage_Compacting(t *testing.T) {
 	assert.Equal(t, "Compacting context...", result)
 }
 
+func TestPhaseMessage_Verifying(t *testing.T) {
+	result := PhaseMessage(workflow.PhaseVerifying, nil)
+	assert.Equal(t, "Verifying...", result)
+}
+
 // --- Plan rendering tests ---
 
 func TestItemRenderer_RenderPlan(t *testing.T) {
@@ -765,10 +775,22 @@ func TestItemRenderer_RenderPlanNoExplanation(t *testing.T) {
 	assert.Contains(t, result, "Do something")
 }
 
-func TestFormatToolCall_UpdatePlan(t *testing.T) {
-	verb, detail := formatToolCall("update_plan", `{"steps": []}`)
-	assert.Equal(t, "Updated", verb)
-	assert.Equal(t, "plan", detail)
+func TestItemRenderer_PinnedItemsShowGlyph(t *testing.T) {
+	r := newTestRenderer()
+	assert.Equal(t, "📌 ❯ Build the parser\n", r.RenderItem(models.ConversationItem{
+		Type: models.ItemTypeUserMessage, Content: "Build the parser", Pinned: true,
+	}, true))
+
+	call := r.RenderItem(models.ConversationItem{
+		Type: models.ItemTypeFunctionCall, Name: "pin_context", Arguments: `{"note": "Go 1.22"}`, Pinned: true,
+	}, false)
+	assert.True(t, strings.HasPrefix(call, "\n📌 ● Pinned"), call)
+
+	// The output sits under its call, which already carries the glyph.
+	out := r.RenderItem(models.ConversationItem{
+		Type: models.ItemTypeFunctionCallOutput, Output: &models.FunctionCallOutputPayload{Content: "Pinned: Go 1.22"}, Pinned: true,
+	}, false)
+	assert.NotContains(t, out, "📌")
 }
 
 // --- Web search rendering tests ---
@@ -836,28 +858,91 @@ func TestItemRenderer_RenderWebSearchCall_Unknown(t *testing.T) {
 	assert.Contains(t, result, "Searched web")
 }
 
-func TestFormatWebSearchCall(t *testing.T) {
-	tests := []struct {
-		name       string
-		action     string
-		content    string
-		url        string
-		wantVerb   string
-		wantDetail string
-	}{
-		{"search", "search", "weather NYC", "", "Searched", "weather NYC"},
-		{"open_page with url", "open_page", "", "https://example.com", "Opened page", "https://example.com"},
-		{"open_page fallback to content", "open_page", "https://example.com", "", "Opened page", "https://example.com"},
-		{"find_in_page", "find_in_page", "'TODO' in page.html", "page.html", "Searched page", "'TODO' in page.html"},
-		{"unknown with content", "", "some query", "", "Searched", "some query"},
-		{"unknown empty", "", "", "", "Searched web", ""},
-	}
+func TestItemRenderer_RenderTurnTiming(t *testing.T) {
+	r := newTestRenderer()
+	result := r.RenderTurnTiming(&workflow.TurnTiming{
+		Total:   42 * time.Second,
+		LLM:     30 * time.Second,
+		Tools:   8 * time.Second,
+		Waiting: 4 * time.Second,
+	})
+	assert.Equal(t, "turn took 42s: llm 30s, tools 8.0s, waiting 4.0s\n", stripANSI(result))
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			verb, detail := formatWebSearchCall(tt.action, tt.content, tt.url)
-			assert.Equal(t, tt.wantVerb, verb)
-			assert.Equal(t, tt.wantDetail, detail)
-		})
+	noWait := r.RenderTurnTiming(&workflow.TurnTiming{Total: 2 * time.Second, LLM: 2 * time.Second})
+	assert.NotContains(t, noWait, "waiting")
+
+	assert.Empty(t, r.RenderTurnTiming(nil))
+}
+
+func TestFormatElapsed(t *testing.T) {
+	assert.Equal(t, "0.4s", formatElapsed(400*time.Millisecond))
+	assert.Equal(t, "8.2s", formatElapsed(8200*time.Millisecond))
+	assert.Equal(t, "42s", formatElapsed(42*time.Second))
+	assert.Equal(t, "3m05s", formatElapsed(3*time.Minute+5*time.Second))
+}
+
+func TestRenderAskUserPrompt(t *testing.T) {
+	r := newTestRenderer()
+	out := r.RenderAskUserPrompt(&workflow.PendingAskUserRequest{
+		CallID:   "c1",
+		Question: "Which branch?",
+		Body:     "Open branches:\n- main\n- dev",
+		Default:  "main",
+	})
+	assert.Contains(t, out, "The assistant has a question for you:")
+	assert.Contains(t, out, "Which branch?")
+	assert.Contains(t, out, "  - dev")
+	assert.Contains(t, out, "Shift+Enter for a new line")
+	assert.Contains(t, out, `empty answer uses "main"`)
+
+	noDefault := r.RenderAskUserPrompt(&workflow.PendingAskUserRequest{Question: "Name?"})
+	assert.NotContains(t, noDefault, "empty answer")
+}
+
+func TestRenderItem_AskUserAnswer(t *testing.T) {
+	r := newTestRenderer()
+	success := true
+	output := models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: "c1",
+		Name:   "ask_user",
+		Output: &models.FunctionCallOutputPayload{Content: "Ada", Success: &success},
 	}
+	assert.Empty(t, r.RenderItem(output, false), "answer is shown by the user_answer item")
+
+	answer := r.RenderItem(models.ConversationItem{
+		Type:    models.ItemTypeUserAnswer,
+		CallID:  "c1",
+		Content: "line one\nline two",
+	}, false)
+	assert.Equal(t, "  └ ❯ line one\n      line two\n", answer)
+
+	call := r.RenderItem(models.ConversationItem{
+		Type:      models.ItemTypeFunctionCall,
+		Name:      "ask_user",
+		Arguments: `{"question": "What is your name?"}`,
+	}, false)
+	assert.Contains(t, call, "Asked What is your name?")
+}
+
+func TestRenderEscalationContext_PatchConflict(t *testing.T) {
+	r := newTestRenderer()
+	out := r.RenderEscalationContext([]workflow.EscalationRequest{{
+		CallID:   "call-patch",
+		ToolName: "apply_patch",
+		PatchConflict: &patch.Conflict{
+			Path:          "main.go",
+			Hunk:          2,
+			Expected:      []string{"\treturn greeting"},
+			Found:         []string{"\treturn msg"},
+			BestMatchLine: 12,
+			Confidence:    0.8,
+		},
+	}})
+	assert.Contains(t, out, "Patch did not apply")
+	assert.Contains(t, out, "Patch: main.go (hunk 2)")
+	assert.Contains(t, out, "-    return greeting")
+	assert.Contains(t, out, "found at line 12 (80% match):")
+	assert.Contains(t, out, "+    return msg")
+	assert.NotContains(t, out, "Sandbox failure")
 }
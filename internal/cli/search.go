@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const searchUsage = "Usage: /search [type:<type>[,<type>]] [turn:<id>] [since:<12h|2d>] <text>"
+
+// searchLimit is the number of matches /search shows, newest first.
+const searchLimit = 20
+
+// searchTypeAliases are short names for item types in /search type:.
+var searchTypeAliases = map[string][]models.ConversationItemType{
+	"user":      {models.ItemTypeUserMessage},
+	"assistant": {models.ItemTypeAssistantMessage},
+	"calls":     {models.ItemTypeFunctionCall},
+	"outputs":   {models.ItemTypeFunctionCallOutput},
+	"tools":     {models.ItemTypeFunctionCall, models.ItemTypeFunctionCallOutput},
+}
+
+// parseSearchCommand parses the arguments of /search into a get_history
+// query: type:, turn: and since: filters, and the text to look for.
+func parseSearchCommand(args string, now time.Time) (workflow.HistoryQuery, error) {
+	q := workflow.HistoryQuery{NewestFirst: true, Limit: searchLimit}
+	var words []string
+	for _, word := range strings.Fields(args) {
+		key, value, ok := strings.Cut(word, ":")
+		switch {
+		case ok && key == "type" && value != "":
+			for _, name := range strings.Split(value, ",") {
+				if aliased, ok := searchTypeAliases[name]; ok {
+					q.Types = append(q.Types, aliased...)
+					continue
+				}
+				t := models.ConversationItemType(name)
+				if !t.IsKnown() {
+					return workflow.HistoryQuery{}, fmt.Errorf("unknown item type %q\n%s", name, searchUsage)
+				}
+				q.Types = append(q.Types, t)
+			}
+		case ok && key == "turn" && value != "":
+			q.TurnID = value
+		case ok && key == "since" && value != "":
+			d, err := parseSearchPeriod(value)
+			if err != nil {
+				return workflow.HistoryQuery{}, fmt.Errorf("%v\n%s", err, searchUsage)
+			}
+			q.Since = now.Add(-d)
+		default:
+			words = append(words, word)
+		}
+	}
+	q.Contains = strings.Join(words, " ")
+	if q.Contains == "" && len(q.Types) == 0 && q.TurnID == "" && q.Since.IsZero() {
+		return workflow.HistoryQuery{}, fmt.Errorf("%s", searchUsage)
+	}
+	return q, nil
+}
+
+// parseSearchPeriod parses since: as whole days ("2d") or a Go duration.
+func parseSearchPeriod(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid period %q, want e.g. 30m, 12h or 2d", s)
+}
+
+// formatSearchResults formats the matches of a /search, newest first, each
+// with its seq for /pin, /note or /context.
+func formatSearchResults(q workflow.HistoryQuery, page workflow.HistoryPage) string {
+	var b strings.Builder
+	title := "Search"
+	if q.Contains != "" {
+		title += fmt.Sprintf(" %q", q.Contains)
+	}
+	b.WriteString(fmt.Sprintf("%s (%d matches, newest first)\n", title, len(page.Items)))
+	b.WriteString("─────────────\n")
+	if len(page.Items) == 0 {
+		b.WriteString("  No matches.\n")
+	}
+	for _, item := range page.Items {
+		at := "     "
+		if !item.CreatedAt.IsZero() {
+			at = item.CreatedAt.Local().Format("15:04")
+		}
+		label := strings.ReplaceAll(string(item.Type), "_", " ")
+		if item.Name != "" {
+			label += " " + item.Name
+		}
+		b.WriteString(fmt.Sprintf("  #%-4d %s  %s: %s\n", item.Seq, at, label, searchSnippet(item, q.Contains)))
+	}
+	if page.NextPageToken != "" {
+		b.WriteString(fmt.Sprintf("Showing the newest %d matches; add type:, turn: or since: to narrow the search.\n", len(page.Items)))
+	}
+	return b.String()
+}
+
+// searchSnippet returns the line of item's text that contains needle,
+// shortened around the match, or the first line when needle is empty.
+func searchSnippet(item models.ConversationItem, needle string) string {
+	fields := []string{item.Content, item.Arguments}
+	if item.Output != nil {
+		fields = append(fields, item.Output.Content)
+	}
+	lowerNeedle := strings.ToLower(needle)
+	for _, field := range fields {
+		if field == "" {
+			continue
+		}
+		for _, line := range strings.Split(field, "\n") {
+			i := strings.Index(strings.ToLower(line), lowerNeedle)
+			if i < 0 || strings.TrimSpace(line) == "" {
+				continue
+			}
+			// Start shortly before the match, counting in runes.
+			runes := []rune(line)
+			start := utf8.RuneCountInString(strings.ToLower(line)[:i]) - 30
+			prefix := "…"
+			if start <= 0 || start >= len(runes) {
+				start, prefix = 0, ""
+			}
+			return prefix + truncateProgress(strings.TrimSpace(string(runes[start:])), 100)
+		}
+	}
+	return ""
+}
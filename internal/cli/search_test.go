@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseSearchCommand(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	q, err := parseSearchCommand(" go  test ", now)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.HistoryQuery{Contains: "go test", NewestFirst: true, Limit: searchLimit}, q)
+
+	q, err = parseSearchCommand("type:tools,assistant_message turn:turn-3 since:2d timeout", now)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ConversationItemType{
+		models.ItemTypeFunctionCall, models.ItemTypeFunctionCallOutput, models.ItemTypeAssistantMessage,
+	}, q.Types)
+	assert.Equal(t, "turn-3", q.TurnID)
+	assert.Equal(t, now.Add(-48*time.Hour), q.Since)
+	assert.Equal(t, "timeout", q.Contains)
+
+	q, err = parseSearchCommand("type:assistant", now)
+	require.NoError(t, err)
+	assert.Empty(t, q.Contains, "a filter alone lists the latest matching items")
+
+	for _, tc := range []struct{ args, want string }{
+		{"", "Usage: /search"},
+		{"type:tool_call x", `unknown item type "tool_call"`},
+		{"since:yesterday x", `invalid period "yesterday"`},
+	} {
+		_, err := parseSearchCommand(tc.args, now)
+		assert.ErrorContains(t, err, tc.want, tc.args)
+	}
+}
+
+func TestFormatSearchResults(t *testing.T) {
+	long := strings.Repeat("x", 80) + " connection TIMEOUT after 30s"
+	result := formatSearchResults(workflow.HistoryQuery{Contains: "timeout"}, workflow.HistoryPage{
+		Items: []models.ConversationItem{
+			{Seq: 9, Type: models.ItemTypeFunctionCallOutput, Output: &models.FunctionCallOutputPayload{Content: "ok\n" + long}},
+			{Seq: 4, Type: models.ItemTypeFunctionCall, Name: "shell", Arguments: `{"command":"curl --timeout 5"}`},
+		},
+		NextPageToken: "0.4",
+	})
+	assert.Contains(t, result, `Search "timeout" (2 matches, newest first)`)
+	assert.Contains(t, result, "#9           function call output: …"+strings.Repeat("x", 18)+" connection TIMEOUT after 30s")
+	assert.Contains(t, result, `#4           function call shell: {"command":"curl --timeout 5"}`)
+	assert.Contains(t, result, "narrow the search")
+
+	result = formatSearchResults(workflow.HistoryQuery{Contains: "nothing"}, workflow.HistoryPage{})
+	assert.Contains(t, result, "No matches.")
+}
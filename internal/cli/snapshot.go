@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// formatRollbackDisplay formats the result of /rollback for display.
+func formatRollbackDisplay(resp workflow.RollbackWorkspaceResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rolled back workspace to snapshot %s (%s)", resp.Snapshot.ID, shortCommit(resp.Snapshot.Commit))
+	if resp.Snapshot.TurnID != "" {
+		fmt.Fprintf(&b, ", taken before %s", resp.Snapshot.TurnID)
+	}
+	b.WriteString(".\n")
+	if len(resp.Restored) == 0 && len(resp.Removed) == 0 {
+		b.WriteString("  No changes since the snapshot.\n")
+		return b.String()
+	}
+	for _, path := range resp.Restored {
+		fmt.Fprintf(&b, "  restored %s\n", path)
+	}
+	for _, path := range resp.Removed {
+		fmt.Fprintf(&b, "  removed  %s\n", path)
+	}
+	return b.String()
+}
+
+// shortCommit abbreviates a git commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
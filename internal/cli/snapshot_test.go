@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestFormatRollbackDisplay(t *testing.T) {
+	result := formatRollbackDisplay(workflow.RollbackWorkspaceResponse{
+		Snapshot: workflow.WorkspaceSnapshot{ID: "snap-2", TurnID: "turn-3", Commit: "0123456789abcdef"},
+		Restored: []string{"main.go"},
+		Removed:  []string{"tmp.txt"},
+	})
+	assert.Contains(t, result, "snapshot snap-2 (01234567)")
+	assert.Contains(t, result, "taken before turn-3")
+	assert.Contains(t, result, "restored main.go")
+	assert.Contains(t, result, "removed  tmp.txt")
+}
+
+func TestFormatRollbackDisplay_NoChanges(t *testing.T) {
+	result := formatRollbackDisplay(workflow.RollbackWorkspaceResponse{
+		Snapshot: workflow.WorkspaceSnapshot{ID: "snap-1", Commit: "abc"},
+	})
+	assert.Contains(t, result, "snapshot snap-1 (abc)")
+	assert.Contains(t, result, "No changes since the snapshot.")
+}
@@ -19,7 +19,11 @@ func (m *Model) formatStatusDisplay() string {
 		b.WriteString(fmt.Sprintf("  Reasoning:       %s\n", m.reasoningEffort))
 	}
 	b.WriteString(fmt.Sprintf("  Approval mode:   %s\n", m.config.Permissions.ApprovalMode))
-	b.WriteString(fmt.Sprintf("  Sandbox:         %s\n", m.config.Permissions.SandboxMode))
+	sandboxMode := m.config.Permissions.SandboxMode
+	if m.config.Permissions.SandboxAudit {
+		sandboxMode += " (audit only)"
+	}
+	b.WriteString(fmt.Sprintf("  Sandbox:         %s\n", sandboxMode))
 	b.WriteString(fmt.Sprintf("  Working dir:     %s\n", m.config.Cwd))
 
 	if m.sessionName != "" {
@@ -34,6 +38,12 @@ func (m *Model) formatStatusDisplay() string {
 		b.WriteString(fmt.Sprintf(" (%d cached)", m.totalCachedTokens))
 	}
 	b.WriteString("\n")
+	if m.totalCachedTokens > 0 {
+		b.WriteString(fmt.Sprintf("  Cache hit rate:  %d%%\n", m.cacheHitRate))
+	}
+	if m.suggestionTokens > 0 {
+		b.WriteString(fmt.Sprintf("  Suggestions:     %d tokens\n", m.suggestionTokens))
+	}
 
 	if m.contextWindowPct > 0 {
 		b.WriteString(fmt.Sprintf("  Context window:  %d%% remaining\n", m.contextWindowPct))
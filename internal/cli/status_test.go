@@ -42,6 +42,33 @@ func TestFormatStatusDisplay_CachedTokensShown(t *testing.T) {
 	assert.Contains(t, result, "500 cached")
 }
 
+func TestFormatStatusDisplay_CacheHitRateShown(t *testing.T) {
+	m := &Model{
+		modelName:         "gpt-4o",
+		provider:          "openai",
+		totalTokens:       1000,
+		totalCachedTokens: 500,
+		cacheHitRate:      62,
+		config:            Config{Permissions: models.Permissions{}},
+	}
+
+	result := m.formatStatusDisplay()
+	assert.Contains(t, result, "Cache hit rate:  62%")
+}
+
+func TestFormatStatusDisplay_SuggestionTokensShown(t *testing.T) {
+	m := &Model{
+		modelName:        "gpt-4o",
+		provider:         "openai",
+		totalTokens:      1000,
+		suggestionTokens: 240,
+		config:           Config{Permissions: models.Permissions{}},
+	}
+
+	result := m.formatStatusDisplay()
+	assert.Contains(t, result, "Suggestions:     240 tokens")
+}
+
 func TestFormatStatusDisplay_CachedTokensHidden(t *testing.T) {
 	m := &Model{
 		modelName:         "gpt-4o",
@@ -54,6 +81,7 @@ func TestFormatStatusDisplay_CachedTokensHidden(t *testing.T) {
 	result := m.formatStatusDisplay()
 	assert.Contains(t, result, "1000")
 	assert.False(t, strings.Contains(result, "cached"))
+	assert.False(t, strings.Contains(result, "Cache hit rate"))
 }
 
 func TestFormatStatusDisplay_PlannerActive(t *testing.T) {
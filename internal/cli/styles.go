@@ -1,6 +1,9 @@
 package cli
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	gansi "github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+)
 
 // Styles holds all lipgloss styles for the TUI.
 type Styles struct {
@@ -32,6 +35,8 @@ type Styles struct {
 	OutputPrefix lipgloss.Style
 	// Status line
 	StatusLine lipgloss.Style
+	// Elapsed time of a call nearing its activity timeout
+	TimeoutWarning lipgloss.Style
 	// Approval index
 	ApprovalIndex lipgloss.Style
 	// Approval tool label
@@ -64,42 +69,15 @@ type Styles struct {
 	DiffAdd lipgloss.Style
 	// Diff removed line (red)
 	DiffRemove lipgloss.Style
+	// Clickable link (underlined cyan)
+	Link lipgloss.Style
+	// Markdown style of assistant messages; nil is the dark theme's
+	Markdown *gansi.StyleConfig
 }
 
-// DefaultStyles returns styles with colors enabled.
+// DefaultStyles returns styles with colors enabled: the dark theme.
 func DefaultStyles() Styles {
-	return Styles{
-		TurnSeparator:    lipgloss.NewStyle().Faint(true),
-		UserMessage:      lipgloss.NewStyle(),
-		UserChevron:      lipgloss.NewStyle().Bold(true),
-		FunctionCallName: lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
-		FunctionCallArgs: lipgloss.NewStyle(),
-		OutputSuccess:    lipgloss.NewStyle().Foreground(lipgloss.Color("2")), // green
-		OutputFailure:    lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
-		ToolBullet:       lipgloss.NewStyle().Foreground(lipgloss.Color("6")),   // cyan
-		AssistantBullet:  lipgloss.NewStyle().Foreground(lipgloss.Color("5")),   // magenta
-		SystemBullet:     lipgloss.NewStyle().Foreground(lipgloss.Color("3")),   // yellow
-		ToolVerb:         lipgloss.NewStyle().Bold(true),
-		OutputDim:        lipgloss.NewStyle().Faint(true),
-		OutputPrefix:     lipgloss.NewStyle().Faint(true),
-		StatusLine:       lipgloss.NewStyle().Faint(true),
-		ApprovalIndex:    lipgloss.NewStyle().Foreground(lipgloss.Color("6")), // cyan
-		ApprovalTool:     lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
-		ApprovalReason:   lipgloss.NewStyle().Faint(true),
-		EscalationHeader: lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
-		EscalationOutput: lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
-		Separator:        lipgloss.NewStyle().Faint(true),
-		StatusBar:        lipgloss.NewStyle().Faint(true),
-		SpinnerMessage:   lipgloss.NewStyle().Faint(true),
-		SelectorChevron:  lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true),
-		SelectorSelected: lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true),
-		SelectorShortcut: lipgloss.NewStyle().Faint(true),
-		PlanBullet:       lipgloss.NewStyle().Foreground(lipgloss.Color("5")),   // magenta
-		PlanCompleted:    lipgloss.NewStyle().Foreground(lipgloss.Color("2")),   // green
-		PlanPending:      lipgloss.NewStyle().Faint(true),
-		DiffAdd:          lipgloss.NewStyle().Foreground(lipgloss.Color("2")), // green
-		DiffRemove:       lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
-	}
+	return builtinThemes[ThemeDark].Styles()
 }
 
 // NoColorStyles returns styles with no colors (plain text).
@@ -119,6 +97,7 @@ func NoColorStyles() Styles {
 		OutputDim:        lipgloss.NewStyle(),
 		OutputPrefix:     lipgloss.NewStyle(),
 		StatusLine:       lipgloss.NewStyle(),
+		TimeoutWarning:   lipgloss.NewStyle(),
 		ApprovalIndex:    lipgloss.NewStyle(),
 		ApprovalTool:     lipgloss.NewStyle(),
 		ApprovalReason:   lipgloss.NewStyle(),
@@ -135,5 +114,6 @@ func NoColorStyles() Styles {
 		PlanPending:      lipgloss.NewStyle(),
 		DiffAdd:          lipgloss.NewStyle(),
 		DiffRemove:       lipgloss.NewStyle(),
+		Link:             lipgloss.NewStyle(),
 	}
 }
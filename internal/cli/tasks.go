@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// maxTaskPanelItems caps how many tasks the panel above the input shows.
+const maxTaskPanelItems = 5
+
+const todoUsage = "Usage: /todo [add <text> | done <n> | undone <n> | rm <n>]"
+
+// parseTodoCommand parses the arguments of /todo into a task list edit.
+func parseTodoCommand(args string) (workflow.UpdateTaskListRequest, error) {
+	verb, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+
+	switch verb {
+	case "add":
+		if rest == "" {
+			return workflow.UpdateTaskListRequest{}, fmt.Errorf("%s", todoUsage)
+		}
+		return workflow.UpdateTaskListRequest{Action: workflow.TaskListAdd, Text: rest}, nil
+	case "done", "undone", "rm":
+		id, err := strconv.Atoi(strings.TrimPrefix(rest, "#"))
+		if err != nil || id <= 0 {
+			return workflow.UpdateTaskListRequest{}, fmt.Errorf("%s", todoUsage)
+		}
+		return workflow.UpdateTaskListRequest{Action: workflow.TaskListAction(verb), ID: id}, nil
+	default:
+		return workflow.UpdateTaskListRequest{}, fmt.Errorf("%s", todoUsage)
+	}
+}
+
+// formatTaskListDisplay formats the full task list for /todo.
+func formatTaskListDisplay(tasks []workflow.TaskItem) string {
+	if len(tasks) == 0 {
+		return "No tasks. Add one with /todo add <text>.\n"
+	}
+
+	done := 0
+	for _, t := range tasks {
+		if t.Done {
+			done++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Tasks (%d/%d done)\n", done, len(tasks)))
+	b.WriteString("─────────────────\n")
+	for _, t := range tasks {
+		mark := " "
+		if t.Done {
+			mark = "x"
+		}
+		b.WriteString(fmt.Sprintf("  #%-3d [%s] %s", t.ID, mark, t.Text))
+		if t.Author == workflow.TaskAuthorAgent {
+			b.WriteString(" (agent)")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
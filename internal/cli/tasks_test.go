@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseTodoCommand(t *testing.T) {
+	req, err := parseTodoCommand("add write the docs")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.UpdateTaskListRequest{Action: workflow.TaskListAdd, Text: "write the docs"}, req)
+
+	req, err = parseTodoCommand("done #3")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.UpdateTaskListRequest{Action: workflow.TaskListDone, ID: 3}, req)
+
+	req, err = parseTodoCommand("rm 2")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.UpdateTaskListRequest{Action: workflow.TaskListRemove, ID: 2}, req)
+
+	for _, bad := range []string{"add", "done", "done x", "rm 0", "archive 1"} {
+		_, err := parseTodoCommand(bad)
+		assert.ErrorContains(t, err, "Usage: /todo", bad)
+	}
+}
+
+func TestFormatTaskListDisplay(t *testing.T) {
+	assert.Contains(t, formatTaskListDisplay(nil), "No tasks.")
+
+	result := formatTaskListDisplay([]workflow.TaskItem{
+		{ID: 1, Text: "write tests", Done: true, Author: workflow.TaskAuthorUser},
+		{ID: 2, Text: "fix bug", Author: workflow.TaskAuthorAgent},
+	})
+	assert.Contains(t, result, "Tasks (1/2 done)")
+	assert.Contains(t, result, "#1   [x] write tests\n")
+	assert.Contains(t, result, "#2   [ ] fix bug (agent)")
+}
+
+func TestRenderTaskPanel(t *testing.T) {
+	r := newTestRenderer()
+	assert.Empty(t, r.RenderTaskPanel(nil, 5))
+
+	tasks := []workflow.TaskItem{
+		{ID: 1, Text: "done one", Done: true},
+		{ID: 2, Text: "open one"},
+		{ID: 3, Text: "open two"},
+	}
+	panel := r.RenderTaskPanel(tasks, 2)
+	assert.Contains(t, panel, "Tasks 1/3 done")
+	// Open tasks are listed first; the overflow is summarized.
+	assert.Contains(t, panel, "#2 open one")
+	assert.Contains(t, panel, "#3 open two")
+	assert.NotContains(t, panel, "done one")
+	assert.Contains(t, panel, "+1 more")
+	assert.Equal(t, 4, len(strings.Split(panel, "\n")))
+}
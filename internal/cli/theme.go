@@ -0,0 +1,400 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	gansi "github.com/charmbracelet/glamour/ansi"
+	glamourstyles "github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Built-in theme names.
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+)
+
+// Theme is the TUI's color scheme: colors by item type and the markdown
+// style of assistant messages. Colors are ANSI numbers ("0"-"255") or hex
+// ("#rrggbb"); an empty color keeps the terminal's default, and an empty
+// Dim or StatusBar renders faint instead.
+type Theme struct {
+	Name     string
+	Colors   ThemeColors
+	Markdown MarkdownTheme
+}
+
+// ThemeColors are the colors of a theme by item type.
+type ThemeColors struct {
+	User      string `toml:"user"`       // User messages and the ❯ chevron
+	Assistant string `toml:"assistant"`  // Assistant bullet and plan header
+	System    string `toml:"system"`     // System message bullet
+	Tool      string `toml:"tool"`       // Tool bullet, selector and approval index
+	ToolName  string `toml:"tool_name"`  // Function call names
+	Approval  string `toml:"approval"`   // Approval and escalation prompts
+	Success   string `toml:"success"`    // Successful output, added lines, completed steps
+	Failure   string `toml:"failure"`    // Failed output and removed lines
+	Warning   string `toml:"warning"`    // Calls nearing their timeout
+	Link      string `toml:"link"`       // Clickable links
+	Dim       string `toml:"dim"`        // Secondary text: output, separators, hints
+	StatusBar string `toml:"status_bar"` // The status bar
+}
+
+// MarkdownTheme is the markdown style of a theme: a glamour built-in style
+// (dark, light, ascii, dracula, pink, tokyo-night) with optional colors.
+type MarkdownTheme struct {
+	Style   string `toml:"style"`
+	Heading string `toml:"heading"`
+	Code    string `toml:"code"`
+	Link    string `toml:"link"`
+}
+
+// builtinThemes are the themes selectable by name. The dark theme is the
+// TUI's original look; light suits terminals with a light background.
+var builtinThemes = map[string]Theme{
+	ThemeDark: {
+		Name: ThemeDark,
+		Colors: ThemeColors{
+			Assistant: "5", // magenta
+			System:    "3", // yellow
+			Tool:      "6", // cyan
+			ToolName:  "3", // yellow
+			Approval:  "3", // yellow
+			Success:   "2", // green
+			Failure:   "1", // red
+			Warning:   "3", // yellow
+			Link:      "6", // cyan
+		},
+		Markdown: MarkdownTheme{Style: "dark"},
+	},
+	ThemeLight: {
+		Name: ThemeLight,
+		Colors: ThemeColors{
+			Assistant: "90",  // purple
+			System:    "130", // dark orange
+			Tool:      "31",  // teal
+			ToolName:  "130", // dark orange
+			Approval:  "130", // dark orange
+			Success:   "28",  // dark green
+			Failure:   "160", // dark red
+			Warning:   "166", // orange
+			Link:      "25",  // dark blue
+			Dim:       "242",
+			StatusBar: "240",
+		},
+		Markdown: MarkdownTheme{Style: "light"},
+	},
+	ThemeHighContrast: {
+		Name: ThemeHighContrast,
+		Colors: ThemeColors{
+			User:      "15", // bright white
+			Assistant: "13", // bright magenta
+			System:    "11", // bright yellow
+			Tool:      "14", // bright cyan
+			ToolName:  "11",
+			Approval:  "11",
+			Success:   "10", // bright green
+			Failure:   "9",  // bright red
+			Warning:   "11",
+			Link:      "14",
+			Dim:       "250",
+			StatusBar: "15",
+		},
+		Markdown: MarkdownTheme{Style: "dark", Heading: "14", Code: "11", Link: "14"},
+	},
+}
+
+// ThemeNames returns the built-in theme names, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTheme picks the theme from the --theme flag, then TCX_THEME (read
+// with getenv), then the [tui] theme setting of <codexHome>/config.toml,
+// and loads it.
+func ResolveTheme(flag, codexHome string, getenv func(string) string) (Theme, error) {
+	name := flag
+	if name == "" {
+		name = getenv("TCX_THEME")
+	}
+	if name == "" {
+		name = ConfiguredTheme(codexHome)
+	}
+	return LoadTheme(name, codexHome)
+}
+
+// LoadTheme loads a theme by name: a built-in name, a path to a theme file, or the name of a file
+// in <codexHome>/themes. "" is the dark theme.
+func LoadTheme(name, codexHome string) (Theme, error) {
+	if name == "" {
+		return builtinThemes[ThemeDark], nil
+	}
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+	path := name
+	if !strings.ContainsRune(name, os.PathSeparator) && filepath.Ext(name) != ".toml" {
+		path = filepath.Join(codexHome, "themes", name+".toml")
+		if _, err := os.Stat(path); err != nil {
+			return Theme{}, fmt.Errorf("unknown theme %q: want %s, a theme file, or a file in %s",
+				name, strings.Join(ThemeNames(), ", "), filepath.Dir(path))
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read theme: %w", err)
+	}
+	t, err := ParseTheme(data)
+	if err != nil {
+		return Theme{}, fmt.Errorf("invalid theme %s: %w", path, err)
+	}
+	t.Name = strings.TrimSuffix(filepath.Base(path), ".toml")
+	return t, nil
+}
+
+// ParseTheme parses a theme file. Its colors override the built-in theme
+// named by base (default dark), so a file only lists what it changes:
+//
+//	base = "light"
+//
+//	[colors]
+//	assistant = "#8839ef"
+//
+//	[markdown]
+//	heading = "#1e66f5"
+func ParseTheme(data []byte) (Theme, error) {
+	var file struct {
+		Base     string        `toml:"base"`
+		Colors   ThemeColors   `toml:"colors"`
+		Markdown MarkdownTheme `toml:"markdown"`
+	}
+	md, err := toml.Decode(string(data), &file)
+	if err != nil {
+		return Theme{}, err
+	}
+	if keys := md.Undecoded(); len(keys) > 0 {
+		return Theme{}, fmt.Errorf("unknown key %q", keys[0].String())
+	}
+	if file.Base == "" {
+		file.Base = ThemeDark
+	}
+	t, ok := builtinThemes[file.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown base %q: want %s", file.Base, strings.Join(ThemeNames(), ", "))
+	}
+	for _, o := range []struct {
+		key      string
+		dst      *string
+		override string
+	}{
+		{"colors.user", &t.Colors.User, file.Colors.User},
+		{"colors.assistant", &t.Colors.Assistant, file.Colors.Assistant},
+		{"colors.system", &t.Colors.System, file.Colors.System},
+		{"colors.tool", &t.Colors.Tool, file.Colors.Tool},
+		{"colors.tool_name", &t.Colors.ToolName, file.Colors.ToolName},
+		{"colors.approval", &t.Colors.Approval, file.Colors.Approval},
+		{"colors.success", &t.Colors.Success, file.Colors.Success},
+		{"colors.failure", &t.Colors.Failure, file.Colors.Failure},
+		{"colors.warning", &t.Colors.Warning, file.Colors.Warning},
+		{"colors.link", &t.Colors.Link, file.Colors.Link},
+		{"colors.dim", &t.Colors.Dim, file.Colors.Dim},
+		{"colors.status_bar", &t.Colors.StatusBar, file.Colors.StatusBar},
+		{"markdown.heading", &t.Markdown.Heading, file.Markdown.Heading},
+		{"markdown.code", &t.Markdown.Code, file.Markdown.Code},
+		{"markdown.link", &t.Markdown.Link, file.Markdown.Link},
+	} {
+		if o.override == "" {
+			continue
+		}
+		if !validColor(o.override) {
+			return Theme{}, fmt.Errorf("%s: invalid color %q: want 0-255 or #rrggbb", o.key, o.override)
+		}
+		*o.dst = o.override
+	}
+	if file.Markdown.Style != "" {
+		if _, ok := glamourstyles.DefaultStyles[file.Markdown.Style]; !ok {
+			return Theme{}, fmt.Errorf("markdown.style: unknown style %q", file.Markdown.Style)
+		}
+		t.Markdown.Style = file.Markdown.Style
+	}
+	return t, nil
+}
+
+// hexColor matches #rgb and #rrggbb colors.
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor reports whether c is an ANSI color number or a hex color.
+func validColor(c string) bool {
+	if n, err := strconv.Atoi(c); err == nil {
+		return n >= 0 && n <= 255
+	}
+	return hexColor.MatchString(c)
+}
+
+// ConfiguredTheme returns the [tui] theme setting of
+// <codexHome>/config.toml, or "" when it is not set or unreadable.
+func ConfiguredTheme(codexHome string) string {
+	data, err := os.ReadFile(filepath.Join(codexHome, "config.toml"))
+	if err != nil {
+		return ""
+	}
+	cfg, err := models.ParseConfigToml(data)
+	if err != nil || cfg.Tui == nil || cfg.Tui.Theme == nil {
+		return ""
+	}
+	return *cfg.Tui.Theme
+}
+
+// Styles returns the TUI styles of the theme.
+func (t Theme) Styles() Styles {
+	c := t.Colors
+	fg := func(color string) lipgloss.Style {
+		if color == "" {
+			return lipgloss.NewStyle()
+		}
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+	}
+	dim := lipgloss.NewStyle().Faint(true)
+	if c.Dim != "" {
+		dim = fg(c.Dim)
+	}
+	statusBar := dim
+	if c.StatusBar != "" {
+		statusBar = fg(c.StatusBar)
+	}
+	markdown := t.markdownStyle()
+	return Styles{
+		TurnSeparator:    dim,
+		UserMessage:      fg(c.User),
+		UserChevron:      fg(c.User).Bold(true),
+		FunctionCallName: fg(c.ToolName),
+		FunctionCallArgs: lipgloss.NewStyle(),
+		OutputSuccess:    fg(c.Success),
+		OutputFailure:    fg(c.Failure),
+		ToolBullet:       fg(c.Tool),
+		AssistantBullet:  fg(c.Assistant),
+		SystemBullet:     fg(c.System),
+		ToolVerb:         lipgloss.NewStyle().Bold(true),
+		OutputDim:        dim,
+		OutputPrefix:     dim,
+		StatusLine:       dim,
+		TimeoutWarning:   fg(c.Warning),
+		ApprovalIndex:    fg(c.Tool),
+		ApprovalTool:     fg(c.Approval),
+		ApprovalReason:   dim,
+		EscalationHeader: fg(c.Approval),
+		EscalationOutput: fg(c.Failure),
+		Separator:        dim,
+		StatusBar:        statusBar,
+		SpinnerMessage:   dim,
+		SelectorChevron:  fg(c.Tool).Bold(true),
+		SelectorSelected: fg(c.Tool).Bold(true),
+		SelectorShortcut: dim,
+		PlanBullet:       fg(c.Assistant),
+		PlanCompleted:    fg(c.Success),
+		PlanPending:      dim,
+		DiffAdd:          fg(c.Success),
+		DiffRemove:       fg(c.Failure),
+		Link:             fg(c.Link).Underline(true),
+		Markdown:         &markdown,
+	}
+}
+
+// markdownStyle returns the theme's glamour style with heading prefixes
+// (##, ###, etc.) removed, so headings render as styled text without raw
+// markdown markers.
+func (t Theme) markdownStyle() gansi.StyleConfig {
+	s := glamourstyles.DarkStyleConfig
+	if base, ok := glamourstyles.DefaultStyles[t.Markdown.Style]; ok && base != nil {
+		s = *base
+	}
+	// Remove document margin so ● bullets align with other items
+	noMargin := uint(0)
+	s.Document.Margin = &noMargin
+	s.H2.Prefix = ""
+	s.H3.Prefix = ""
+	s.H4.Prefix = ""
+	s.H5.Prefix = ""
+	s.H6.Prefix = ""
+	if t.Markdown.Heading != "" {
+		heading := t.Markdown.Heading
+		s.Heading.Color = &heading
+	}
+	if t.Markdown.Code != "" {
+		code := t.Markdown.Code
+		s.Code.Color = &code
+	}
+	if t.Markdown.Link != "" {
+		link := t.Markdown.Link
+		s.Link.Color = &link
+		s.LinkText.Color = &link
+	}
+	return s
+}
+
+// codexHomeDir returns the codex config directory: override, or ~/.codex.
+func codexHomeDir(override string) string {
+	if override != "" {
+		return override
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".codex"
+	}
+	return filepath.Join(home, ".codex")
+}
+
+// switchTheme handles /theme. Without a name it shows the current and
+// built-in themes; with one it switches to that theme and renders the
+// conversation again. System notes already shown keep their colors.
+func (m *Model) switchTheme(name string) {
+	if name == "" {
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Theme: %s (built-in: %s; or a theme file)",
+			m.theme.Name, strings.Join(ThemeNames(), ", "))))
+		return
+	}
+	if m.config.NoColor {
+		m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Colors are off (--no-color).")))
+		return
+	}
+	theme, err := LoadTheme(name, codexHomeDir(m.config.CodexHome))
+	if err != nil {
+		m.appendToViewport(m.renderer.RenderSystemMessage(err.Error()))
+		return
+	}
+	m.theme = theme
+	m.styles = theme.Styles()
+
+	old := m.renderer
+	m.renderer = NewItemRenderer(m.conversationWidth(), m.config.NoColor, m.config.NoMarkdown, m.styles)
+	m.renderer.images = old.images
+	m.renderer.locale = old.locale
+	m.renderer.kittyImageID = old.kittyImageID
+	for i, b := range m.blocks {
+		if b.item == nil {
+			continue
+		}
+		if block, ok := m.renderer.itemBlock(*b.item, b.isResume, m.foldLines()); ok {
+			block.open = b.open
+			block.item, block.isResume = b.item, b.isResume
+			m.blocks[i] = block
+		}
+	}
+	m.refreshViewport()
+	m.appendToViewport(m.renderer.RenderSystemMessage(m.t("Theme: %s", theme.Name)))
+}
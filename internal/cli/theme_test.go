@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestLoadTheme_Builtins(t *testing.T) {
+	assert.Equal(t, []string{"dark", "high-contrast", "light"}, ThemeNames())
+	for _, name := range ThemeNames() {
+		theme, err := LoadTheme(name, t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, name, theme.Name)
+	}
+
+	dark, err := LoadTheme("", "")
+	require.NoError(t, err)
+	assert.Equal(t, ThemeDark, dark.Name)
+	assert.Equal(t, lipgloss.Color("5"), dark.Styles().AssistantBullet.GetForeground())
+	assert.True(t, dark.Styles().OutputDim.GetFaint())
+
+	light := builtinThemes[ThemeLight].Styles()
+	assert.Equal(t, lipgloss.Color("242"), light.OutputDim.GetForeground())
+	assert.False(t, light.OutputDim.GetFaint())
+	require.NotNil(t, light.Markdown)
+	assert.Equal(t, uint(0), *light.Markdown.Document.Margin)
+	assert.Empty(t, light.Markdown.H2.Prefix)
+
+	_, err = LoadTheme("solarized", t.TempDir())
+	assert.ErrorContains(t, err, `unknown theme "solarized": want dark, high-contrast, light`)
+}
+
+func TestLoadTheme_File(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "themes"), 0o755))
+	path := filepath.Join(home, "themes", "latte.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+base = "light"
+
+[colors]
+assistant = "#8839ef"
+failure = "#d20f39"
+
+[markdown]
+heading = "#1e66f5"
+`), 0o644))
+
+	for _, name := range []string{"latte", path} {
+		theme, err := LoadTheme(name, home)
+		require.NoError(t, err, name)
+		assert.Equal(t, "latte", theme.Name)
+		assert.Equal(t, "#8839ef", theme.Colors.Assistant)
+		assert.Equal(t, "#d20f39", theme.Colors.Failure)
+		assert.Equal(t, builtinThemes[ThemeLight].Colors.Success, theme.Colors.Success, "unset colors come from the base")
+		assert.Equal(t, "light", theme.Markdown.Style)
+		assert.Equal(t, "#1e66f5", *theme.Styles().Markdown.Heading.Color)
+	}
+}
+
+func TestParseTheme_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		file string
+		want string
+	}{
+		{`base = "sepia"`, `unknown base "sepia"`},
+		{"[colors]\nuser = \"blue\"", `colors.user: invalid color "blue"`},
+		{"[colors]\nuser = \"256\"", `colors.user: invalid color "256"`},
+		{"[colors]\nbackground = \"0\"", `unknown key "colors.background"`},
+		{"[markdown]\nstyle = \"sepia\"", `markdown.style: unknown style "sepia"`},
+		{"[colors", "toml"},
+	} {
+		_, err := ParseTheme([]byte(tc.file))
+		assert.ErrorContains(t, err, tc.want, tc.file)
+	}
+
+	theme, err := ParseTheme([]byte("[colors]\nuser = \"#fff\"\n[markdown]\nstyle = \"dracula\""))
+	require.NoError(t, err)
+	assert.Equal(t, ThemeDark, theme.Name, "the base is dark by default")
+	assert.Equal(t, "#fff", theme.Colors.User)
+	assert.Equal(t, "dracula", theme.Markdown.Style)
+}
+
+func TestResolveTheme(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.toml"), []byte("model = \"o3\"\n\n[tui]\ntheme = \"light\"\n"), 0o644))
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+
+	theme, err := ResolveTheme("", home, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, ThemeLight, theme.Name, "from [tui] theme")
+
+	theme, err = ResolveTheme("", home, env(map[string]string{"TCX_THEME": "high-contrast"}))
+	require.NoError(t, err)
+	assert.Equal(t, ThemeHighContrast, theme.Name, "TCX_THEME wins over config")
+
+	theme, err = ResolveTheme("dark", home, env(map[string]string{"TCX_THEME": "high-contrast"}))
+	require.NoError(t, err)
+	assert.Equal(t, ThemeDark, theme.Name, "the flag wins over TCX_THEME")
+
+	theme, err = ResolveTheme("", t.TempDir(), env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, ThemeDark, theme.Name)
+}
+
+func TestModel_ThemeCommand(t *testing.T) {
+	m := newTestModel()
+	m.config.NoColor = false
+	m.viewport = viewport.New(80, 20)
+	m.appendItem(models.ConversationItem{Type: models.ItemTypeAssistantMessage, Content: "hello", Seq: 1}, false)
+
+	m.textarea.SetValue("/theme")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.viewportContent, "Theme: dark (built-in: dark, high-contrast, light; or a theme file)")
+
+	m.textarea.SetValue("/theme light")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, ThemeLight, m.theme.Name)
+	assert.Equal(t, lipgloss.Color("90"), m.styles.AssistantBullet.GetForeground())
+	assert.Equal(t, lipgloss.Color("90"), m.renderer.styles.AssistantBullet.GetForeground())
+	require.NotNil(t, m.blocks[0].item, "the item block is kept for re-rendering")
+	assert.Contains(t, m.blocks[0].text, "hello")
+	assert.Contains(t, m.viewportContent, "Theme: light")
+
+	m.textarea.SetValue("/theme sepia")
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Contains(t, m.viewportContent, `unknown theme "sepia"`)
+	assert.Equal(t, ThemeLight, m.theme.Name)
+}
+
+func TestModel_ThemeCommand_NoColor(t *testing.T) {
+	m := newTestModel()
+	m.viewport = viewport.New(80, 20)
+	m.switchTheme("light")
+	assert.Contains(t, m.viewportContent, "Colors are off (--no-color).")
+	assert.Equal(t, ThemeDark, m.theme.Name)
+}
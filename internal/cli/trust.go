@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+const trustUsage = "Usage: /trust [list | revoke <n>]"
+
+// parseTrustCommand parses the arguments of /trust into a learned-trust
+// action. No arguments lists the trusted commands.
+func parseTrustCommand(args string) (workflow.UpdateTrustedCommandsRequest, error) {
+	verb, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+
+	switch verb {
+	case "", "list":
+		return workflow.UpdateTrustedCommandsRequest{Action: workflow.TrustList}, nil
+	case "revoke", "rm":
+		id, err := strconv.Atoi(strings.TrimPrefix(rest, "#"))
+		if err != nil || id <= 0 {
+			return workflow.UpdateTrustedCommandsRequest{}, fmt.Errorf("%s", trustUsage)
+		}
+		return workflow.UpdateTrustedCommandsRequest{Action: workflow.TrustRevoke, ID: id}, nil
+	default:
+		return workflow.UpdateTrustedCommandsRequest{}, fmt.Errorf("%s", trustUsage)
+	}
+}
+
+// formatTrustedCommandsDisplay formats the learned-trust commands for /trust.
+func formatTrustedCommandsDisplay(commands []workflow.TrustedCommand) string {
+	if len(commands) == 0 {
+		return "No trusted commands. Commands you approve repeatedly are trusted for the session.\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Trusted commands (%d)\n", len(commands)))
+	b.WriteString("─────────────────\n")
+	for _, t := range commands {
+		b.WriteString(fmt.Sprintf("  #%-3d %s: %s (approved %dx)\n", t.ID, t.Tool, t.Command, t.Approvals))
+		if t.Workdir != "" {
+			b.WriteString(fmt.Sprintf("       in %s\n", t.Workdir))
+		}
+	}
+	b.WriteString("Revoke with /trust revoke <n>.\n")
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func TestParseTrustCommand(t *testing.T) {
+	for _, list := range []string{"", " ", "list"} {
+		req, err := parseTrustCommand(list)
+		require.NoError(t, err)
+		assert.Equal(t, workflow.UpdateTrustedCommandsRequest{Action: workflow.TrustList}, req)
+	}
+
+	req, err := parseTrustCommand(" revoke #2")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.UpdateTrustedCommandsRequest{Action: workflow.TrustRevoke, ID: 2}, req)
+
+	for _, bad := range []string{"revoke", "revoke x", "rm 0", "grant 1"} {
+		_, err := parseTrustCommand(bad)
+		assert.ErrorContains(t, err, "Usage: /trust", bad)
+	}
+}
+
+func TestFormatTrustedCommandsDisplay(t *testing.T) {
+	assert.Contains(t, formatTrustedCommandsDisplay(nil), "No trusted commands.")
+
+	result := formatTrustedCommandsDisplay([]workflow.TrustedCommand{
+		{ID: 1, Tool: "shell_command", Command: "go build ./...", Approvals: 3},
+	})
+	assert.Contains(t, result, "Trusted commands (1)")
+	assert.Contains(t, result, "#1   shell_command: go build ./... (approved 3x)")
+	assert.Contains(t, result, "/trust revoke <n>")
+}
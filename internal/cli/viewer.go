@@ -0,0 +1,362 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/mfateev/temporal-agent-harness/internal/archive"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// viewerHelp lists the viewer's keys in the status line.
+const viewerHelp = "↑/↓ scroll · / search · n/N next/prev · o fold · e expand all · q quit"
+
+// ViewerConfig configures the offline transcript viewer (tcx view).
+type ViewerConfig struct {
+	Title      string // Shown in the header, e.g. the file name
+	NoColor    bool
+	NoMarkdown bool
+	Inline     bool
+	FoldLines  int   // Items taller than this render collapsed (0 = default, <0 = never)
+	Theme      Theme // Colors and markdown style (zero = dark theme)
+}
+
+// searchMatch locates a search hit: a line of a block's full rendering.
+type searchMatch struct {
+	block int
+	line  int
+}
+
+// ViewerModel shows an exported transcript with the session renderer,
+// without a Temporal connection: scrolling, folding and search only.
+type ViewerModel struct {
+	config   ViewerConfig
+	items    []models.ConversationItem
+	styles   Styles
+	renderer *ItemRenderer
+	viewport viewport.Model
+	ready    bool
+	width    int
+	height   int
+
+	blocks    []viewportBlock
+	expandAll bool
+
+	searching bool
+	search    textinput.Model
+	query     string
+	matches   []searchMatch
+	matchIdx  int
+	note      string // Replaces the help line until the next key
+}
+
+// NewViewerModel creates a viewer for items.
+func NewViewerModel(config ViewerConfig, items []models.ConversationItem) ViewerModel {
+	styles := DefaultStyles()
+	if config.Theme.Name != "" {
+		styles = config.Theme.Styles()
+	}
+	if config.NoColor {
+		styles = NoColorStyles()
+	}
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "search"
+	return ViewerModel{
+		config: config,
+		items:  items,
+		styles: styles,
+		search: ti,
+	}
+}
+
+// Init implements tea.Model.
+func (m ViewerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m ViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+	case tea.KeyMsg:
+		if m.searching {
+			return m.handleSearchKey(msg)
+		}
+		return m.handleKey(msg)
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// handleKey handles keys while browsing.
+func (m ViewerModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.note = ""
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "/":
+		m.searching = true
+		m.search.SetValue("")
+		return m, m.search.Focus()
+	case "n":
+		m.stepMatch(1)
+		return m, nil
+	case "N":
+		m.stepMatch(-1)
+		return m, nil
+	case "o", "ctrl+o":
+		if i := foldTargetIn(m.blocks, m.expandAll, m.viewport.YOffset+m.viewport.Height); i >= 0 {
+			m.blocks[i].open = !m.blocks[i].open
+			m.refresh()
+		} else {
+			m.note = "No folded item in view"
+		}
+		return m, nil
+	case "e":
+		m.expandAll = !m.expandAll
+		for i := range m.blocks {
+			m.blocks[i].open = false
+		}
+		m.refresh()
+		return m, nil
+	case "g", "home":
+		m.viewport.GotoTop()
+		return m, nil
+	case "G", "end":
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// handleSearchKey edits the search query; enter runs it.
+func (m ViewerModel) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.searching = false
+		m.search.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		m.searching = false
+		m.search.Blur()
+		m.runSearch(m.search.Value())
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
+
+// resize renders the items for the new width, keeping fold states.
+func (m *ViewerModel) resize(width, height int) {
+	m.width, m.height = width, height
+	vpHeight := max(height-2, 1) // header + status line
+	if !m.ready {
+		m.viewport = viewport.New(width, vpHeight)
+		m.ready = true
+	} else {
+		m.viewport.Width = width
+		m.viewport.Height = vpHeight
+	}
+
+	foldLines := m.config.FoldLines
+	if foldLines == 0 {
+		foldLines = defaultFoldLines
+	}
+	m.renderer = NewItemRenderer(width, m.config.NoColor, m.config.NoMarkdown, m.styles)
+	old := m.blocks
+	m.blocks = nil
+	for _, item := range m.items {
+		if block, ok := m.renderer.itemBlock(item, true, foldLines); ok {
+			if len(m.blocks) < len(old) {
+				block.open = old[len(m.blocks)].open
+			}
+			m.blocks = append(m.blocks, block)
+		}
+	}
+	m.refresh()
+	if m.query != "" {
+		m.matches = m.findMatches(m.query)
+		m.matchIdx = min(m.matchIdx, max(len(m.matches)-1, 0))
+	}
+}
+
+// refresh rebuilds the viewport content, keeping the scroll position.
+func (m *ViewerModel) refresh() {
+	offset := m.viewport.YOffset
+	m.viewport.SetContent(joinBlocks(m.blocks, m.expandAll))
+	m.viewport.SetYOffset(offset)
+}
+
+// findMatches returns the lines containing query, case-insensitively. Folded
+// blocks are searched in full so hidden output can be found.
+func (m *ViewerModel) findMatches(query string) []searchMatch {
+	q := strings.ToLower(query)
+	var matches []searchMatch
+	for i, b := range m.blocks {
+		full := b.text
+		if b.foldable() {
+			full = b.expanded
+		}
+		for j, line := range strings.Split(ansi.Strip(full), "\n") {
+			if strings.Contains(strings.ToLower(line), q) {
+				matches = append(matches, searchMatch{block: i, line: j})
+			}
+		}
+	}
+	return matches
+}
+
+// runSearch finds query and shows the first match at or below the top of
+// the viewport.
+func (m *ViewerModel) runSearch(query string) {
+	m.query = strings.TrimSpace(query)
+	m.matches = nil
+	if m.query == "" {
+		return
+	}
+	m.matches = m.findMatches(m.query)
+	if len(m.matches) == 0 {
+		m.note = fmt.Sprintf("No matches for %q", m.query)
+		return
+	}
+	m.matchIdx = 0
+	for i, mt := range m.matches {
+		if m.blockStart(mt.block)+mt.line >= m.viewport.YOffset {
+			m.matchIdx = i
+			break
+		}
+	}
+	m.showMatch()
+}
+
+// stepMatch moves to the next (dir 1) or previous (dir -1) match, wrapping.
+func (m *ViewerModel) stepMatch(dir int) {
+	if len(m.matches) == 0 {
+		if m.query != "" {
+			m.note = fmt.Sprintf("No matches for %q", m.query)
+		} else {
+			m.note = "Press / to search"
+		}
+		return
+	}
+	m.matchIdx = (m.matchIdx + dir + len(m.matches)) % len(m.matches)
+	m.showMatch()
+}
+
+// showMatch expands the block holding the current match if needed and
+// scrolls the match to the top of the viewport.
+func (m *ViewerModel) showMatch() {
+	mt := m.matches[m.matchIdx]
+	b := &m.blocks[mt.block]
+	if b.foldable() && b.view(m.expandAll) != b.expanded {
+		b.open = !b.open
+		m.refresh()
+	}
+	m.viewport.SetYOffset(m.blockStart(mt.block) + mt.line)
+	m.note = fmt.Sprintf("Match %d/%d for %q", m.matchIdx+1, len(m.matches), m.query)
+}
+
+// blockStart returns the first line of block i in the viewport content.
+func (m *ViewerModel) blockStart(i int) int {
+	line := 0
+	for _, b := range m.blocks[:i] {
+		line += strings.Count(b.view(m.expandAll), "\n")
+	}
+	return line
+}
+
+// View implements tea.Model.
+func (m ViewerModel) View() string {
+	if !m.ready {
+		return ""
+	}
+	header := fmt.Sprintf("%s · %d items (offline)", m.config.Title, len(m.items))
+	if m.viewport.TotalLineCount() > 0 {
+		header += fmt.Sprintf(" · %d%%", int(m.viewport.ScrollPercent()*100))
+	}
+	status := m.styles.StatusBar.Render(ansi.Truncate(viewerHelp, m.width, "…"))
+	switch {
+	case m.searching:
+		status = m.search.View()
+	case m.note != "":
+		status = m.styles.StatusBar.Render(ansi.Truncate(m.note, m.width, "…"))
+	}
+	return m.styles.StatusBar.Render(ansi.Truncate(header, m.width, "…")) + "\n" +
+		m.viewport.View() + "\n" + status
+}
+
+// LoadTranscript reads an exported transcript: a transcript archive session
+// directory (with index.json), an archive segment or other JSONL file of
+// conversation items, or the JSON array printed by `client history`.
+func LoadTranscript(path string) ([]models.ConversationItem, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.ConversationItem
+	if info.IsDir() {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(filepath.Join(abs, archive.IndexFile)); err != nil {
+			return nil, fmt.Errorf("%s is not an archived session directory (no %s)", path, archive.IndexFile)
+		}
+		a := archive.New(archive.NewFileStore(filepath.Dir(abs)), "")
+		items, err = a.ReadSession(context.Background(), filepath.Base(abs))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			if err := json.Unmarshal(trimmed, &items); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", path, err)
+			}
+		} else if items, err = archive.DecodeSegment(data); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s has no conversation items", path)
+	}
+	return items, nil
+}
+
+// RunViewer opens the offline transcript viewer.
+func RunViewer(config ViewerConfig, items []models.ConversationItem) error {
+	var opts []tea.ProgramOption
+	if !config.Inline {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(NewViewerModel(config, items), opts...)
+
+	// Mouse wheel scrolling without capturing the mouse; see Run.
+	fmt.Fprint(os.Stderr, "\x1b[?1007h")
+	defer fmt.Fprint(os.Stderr, "\x1b[?1007l")
+
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+	return nil
+}
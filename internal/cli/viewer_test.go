@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/archive"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func viewerTestItems() []models.ConversationItem {
+	var out []string
+	for i := 0; i < 60; i++ {
+		out = append(out, "line")
+	}
+	out[50] = "needle in the output"
+	success := true
+	return []models.ConversationItem{
+		{Type: models.ItemTypeUserMessage, Content: "List the files"},
+		{Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "shell", Arguments: `{"command": "ls"}`},
+		{Type: models.ItemTypeFunctionCallOutput, CallID: "c1", Output: &models.FunctionCallOutputPayload{
+			Content: strings.Join(out, "\n"), Success: &success,
+		}},
+		{Type: models.ItemTypeAssistantMessage, Content: "Found the needle."},
+	}
+}
+
+func newTestViewer(items []models.ConversationItem) ViewerModel {
+	m := NewViewerModel(ViewerConfig{Title: "t.jsonl", NoColor: true, NoMarkdown: true, FoldLines: 10}, items)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 12})
+	return updated.(ViewerModel)
+}
+
+func pressKeys(m ViewerModel, keys ...string) ViewerModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(ViewerModel)
+	}
+	return m
+}
+
+func TestViewer_SearchExpandsFoldedOutput(t *testing.T) {
+	m := newTestViewer(viewerTestItems())
+	assert.NotContains(t, joinBlocks(m.blocks, m.expandAll), "needle in the output", "long output starts folded")
+
+	m = pressKeys(m, "/", "needle", "enter")
+	require.Len(t, m.matches, 2)
+	assert.Equal(t, `Match 1/2 for "needle"`, m.note)
+	assert.Contains(t, m.viewport.View(), "needle in the output", "match is scrolled into view and expanded")
+
+	m = pressKeys(m, "n")
+	assert.Equal(t, `Match 2/2 for "needle"`, m.note)
+	assert.Contains(t, m.viewport.View(), "Found the needle.")
+
+	m = pressKeys(m, "n")
+	assert.Equal(t, `Match 1/2 for "needle"`, m.note, "wraps around")
+
+	m = pressKeys(m, "/", "nothing-here", "enter")
+	assert.Empty(t, m.matches)
+	assert.Equal(t, `No matches for "nothing-here"`, m.note)
+}
+
+func TestViewer_FoldAndExpandAll(t *testing.T) {
+	m := newTestViewer(viewerTestItems())
+	m.viewport.GotoTop()
+
+	m = pressKeys(m, "o")
+	assert.Contains(t, joinBlocks(m.blocks, m.expandAll), "needle in the output")
+	m = pressKeys(m, "o")
+	assert.NotContains(t, joinBlocks(m.blocks, m.expandAll), "needle in the output")
+
+	m = pressKeys(m, "e")
+	assert.Contains(t, joinBlocks(m.blocks, m.expandAll), "needle in the output")
+}
+
+func TestViewer_ViewAndQuit(t *testing.T) {
+	m := newTestViewer(viewerTestItems())
+	lines := strings.Split(m.View(), "\n")
+	assert.Contains(t, lines[0], "t.jsonl · 4 items (offline)")
+	assert.Contains(t, lines[len(lines)-1], "/ search")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+}
+
+func TestLoadTranscript_Formats(t *testing.T) {
+	dir := t.TempDir()
+	items := viewerTestItems()
+
+	// JSON array, as printed by `client history`.
+	data, err := json.MarshalIndent(items, "", "  ")
+	require.NoError(t, err)
+	historyPath := filepath.Join(dir, "history.json")
+	require.NoError(t, os.WriteFile(historyPath, data, 0o644))
+	got, err := LoadTranscript(historyPath)
+	require.NoError(t, err)
+	assert.Len(t, got, 4)
+
+	// Archive session directory.
+	a := archive.New(archive.NewFileStore(dir), "archive")
+	_, err = a.AppendSegment(context.Background(), archive.Segment{SessionID: "sess-1", Number: 1, Items: items[:2]})
+	require.NoError(t, err)
+	_, err = a.AppendSegment(context.Background(), archive.Segment{SessionID: "sess-1", Number: 2, Items: items[2:]})
+	require.NoError(t, err)
+	got, err = LoadTranscript(filepath.Join(dir, "archive", "sess-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "Found the needle.", got[3].Content)
+
+	// A single segment file.
+	got, err = LoadTranscript(filepath.Join(dir, "archive", "sess-1", archive.SegmentName(1)))
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	_, err = LoadTranscript(dir)
+	assert.ErrorContains(t, err, "not an archived session directory")
+
+	empty := filepath.Join(dir, "empty.jsonl")
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+	_, err = LoadTranscript(empty)
+	assert.ErrorContains(t, err, "no conversation items")
+}
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestAESGCMCodec_RoundTrip(t *testing.T) {
+	ring, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+	dc := NewDataConverter(ring)
+
+	payload, err := dc.ToPayload("proprietary source code")
+	require.NoError(t, err)
+	assert.Equal(t, MetadataEncodingEncrypted, string(payload.Metadata[converter.MetadataEncoding]))
+	assert.Equal(t, "k1", string(payload.Metadata[MetadataEncryptionKeyID]))
+	assert.NotContains(t, string(payload.Data), "proprietary")
+
+	var out string
+	require.NoError(t, dc.FromPayload(payload, &out))
+	assert.Equal(t, "proprietary source code", out)
+}
+
+func TestAESGCMCodec_DecodesWithRotatedKey(t *testing.T) {
+	oldRing, err := NewKeyring("old", map[string][]byte{"old": testKey(1)})
+	require.NoError(t, err)
+	payload, err := NewDataConverter(oldRing).ToPayload("hello")
+	require.NoError(t, err)
+
+	newRing, err := NewKeyring("new", map[string][]byte{"new": testKey(2), "old": testKey(1)})
+	require.NoError(t, err)
+	var out string
+	require.NoError(t, NewDataConverter(newRing).FromPayload(payload, &out))
+	assert.Equal(t, "hello", out)
+}
+
+func TestAESGCMCodec_UnknownKeyFails(t *testing.T) {
+	ring1, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	ring2, _ := NewKeyring("k2", map[string][]byte{"k2": testKey(2)})
+	encoded, err := NewAESGCMCodec(ring1).Encode(mustPayloads(t, "x"))
+	require.NoError(t, err)
+
+	_, err = NewAESGCMCodec(ring2).Decode(encoded)
+	assert.ErrorContains(t, err, `unknown key "k1"`)
+}
+
+func TestAESGCMCodec_PlaintextPassesThrough(t *testing.T) {
+	ring, _ := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	plain := mustPayloads(t, "legacy")
+
+	decoded, err := NewAESGCMCodec(ring).Decode(plain)
+	require.NoError(t, err)
+	assert.Equal(t, plain, decoded)
+}
+
+func TestNewKeyring_Validation(t *testing.T) {
+	_, err := NewKeyring("", map[string][]byte{"k": testKey(1)})
+	assert.Error(t, err)
+	_, err = NewKeyring("missing", map[string][]byte{"k": testKey(1)})
+	assert.Error(t, err)
+	_, err = NewKeyring("k", map[string][]byte{"k": []byte("short")})
+	assert.Error(t, err)
+}
+
+func mustPayloads(t *testing.T, v string) []*commonpb.Payload {
+	t.Helper()
+	p, err := converter.GetDefaultDataConverter().ToPayload(v)
+	require.NoError(t, err)
+	return []*commonpb.Payload{p}
+}
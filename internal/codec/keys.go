@@ -0,0 +1,148 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// Environment variables that configure payload encryption.
+const (
+	// EnvKey is the active key: base64 of KeySize raw bytes, or
+	// "kms:<base64 ciphertext>" for a data key wrapped by a KMS.
+	// Encryption is disabled when unset.
+	EnvKey = "TEMPORAL_CODEC_KEY"
+
+	// EnvKeyID names the active key (default "default"). It is stored in
+	// every payload so the matching key can be found during decryption.
+	EnvKeyID = "TEMPORAL_CODEC_KEY_ID"
+
+	// EnvPreviousKeys lists decrypt-only keys kept for rotation, as
+	// comma-separated "id=value" entries. Values use the EnvKey format.
+	EnvPreviousKeys = "TEMPORAL_CODEC_PREVIOUS_KEYS"
+
+	// EnvKMSCommand is a shell command that unwraps "kms:" keys. It receives
+	// the raw ciphertext on stdin and must print the base64 plaintext key,
+	// e.g. `aws kms decrypt --ciphertext-blob fileb:///dev/stdin --query Plaintext --output text`.
+	EnvKMSCommand = "TEMPORAL_CODEC_KMS_COMMAND"
+
+	// DefaultKeyID is the key ID used when EnvKeyID is unset.
+	DefaultKeyID = "default"
+
+	kmsPrefix = "kms:"
+)
+
+// KeyDecrypter unwraps a data key encrypted by a key management service.
+type KeyDecrypter interface {
+	DecryptKey(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// CommandDecrypter unwraps keys by running an external command, which keeps
+// the binary free of cloud SDK dependencies while supporting any KMS with a CLI.
+type CommandDecrypter struct {
+	Command string
+}
+
+// DecryptKey runs the command with ciphertext on stdin and decodes its
+// base64 stdout.
+func (d CommandDecrypter) DecryptKey(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", d.Command)
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kms command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("kms command output is not base64: %w", err)
+	}
+	return key, nil
+}
+
+// LoadKeyringFromEnv builds a keyring from the TEMPORAL_CODEC_* environment
+// variables. Returns nil (and no error) when encryption is not configured.
+func LoadKeyringFromEnv(ctx context.Context) (*Keyring, error) {
+	return loadKeyring(ctx, os.Getenv)
+}
+
+func loadKeyring(ctx context.Context, getenv func(string) string) (*Keyring, error) {
+	active := getenv(EnvKey)
+	if active == "" {
+		return nil, nil
+	}
+
+	var decrypter KeyDecrypter
+	if cmd := getenv(EnvKMSCommand); cmd != "" {
+		decrypter = CommandDecrypter{Command: cmd}
+	}
+
+	activeID := getenv(EnvKeyID)
+	if activeID == "" {
+		activeID = DefaultKeyID
+	}
+
+	keys := make(map[string][]byte)
+	key, err := parseKey(ctx, active, decrypter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvKey, err)
+	}
+	keys[activeID] = key
+
+	if prev := getenv(EnvPreviousKeys); prev != "" {
+		for _, entry := range strings.Split(prev, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			id, value, ok := strings.Cut(entry, "=")
+			if !ok || id == "" {
+				return nil, fmt.Errorf("%s: entry %q is not id=value", EnvPreviousKeys, entry)
+			}
+			if _, dup := keys[id]; dup {
+				return nil, fmt.Errorf("%s: duplicate key ID %q", EnvPreviousKeys, id)
+			}
+			key, err := parseKey(ctx, value, decrypter)
+			if err != nil {
+				return nil, fmt.Errorf("%s: key %q: %w", EnvPreviousKeys, id, err)
+			}
+			keys[id] = key
+		}
+	}
+
+	return NewKeyring(activeID, keys)
+}
+
+// parseKey decodes a base64 key, unwrapping it through the KMS decrypter when
+// it carries the "kms:" prefix.
+func parseKey(ctx context.Context, value string, decrypter KeyDecrypter) ([]byte, error) {
+	wrapped := strings.HasPrefix(value, kmsPrefix)
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, kmsPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if !wrapped {
+		return raw, nil
+	}
+	if decrypter == nil {
+		return nil, fmt.Errorf("kms-wrapped key requires %s", EnvKMSCommand)
+	}
+	return decrypter.DecryptKey(ctx, raw)
+}
+
+// DataConverterFromEnv returns an encrypting data converter when
+// TEMPORAL_CODEC_KEY is set, or nil to keep the SDK default.
+func DataConverterFromEnv(ctx context.Context) (converter.DataConverter, error) {
+	ring, err := LoadKeyringFromEnv(ctx)
+	if err != nil || ring == nil {
+		return nil, err
+	}
+	return NewDataConverter(ring), nil
+}
@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envMap(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestLoadKeyring_DisabledWhenUnset(t *testing.T) {
+	ring, err := loadKeyring(context.Background(), envMap(nil))
+	require.NoError(t, err)
+	assert.Nil(t, ring)
+}
+
+func TestLoadKeyring_ActiveAndPreviousKeys(t *testing.T) {
+	ring, err := loadKeyring(context.Background(), envMap(map[string]string{
+		EnvKey:          base64.StdEncoding.EncodeToString(testKey(2)),
+		EnvKeyID:        "2026-10",
+		EnvPreviousKeys: "2026-01=" + base64.StdEncoding.EncodeToString(testKey(1)),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10", ring.ActiveID())
+	assert.Equal(t, testKey(1), ring.keys["2026-01"])
+}
+
+func TestLoadKeyring_DefaultKeyID(t *testing.T) {
+	ring, err := loadKeyring(context.Background(), envMap(map[string]string{
+		EnvKey: base64.StdEncoding.EncodeToString(testKey(1)),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultKeyID, ring.ActiveID())
+}
+
+func TestLoadKeyring_KMSWrappedKey(t *testing.T) {
+	// The "KMS" here echoes the wrapped key back base64-encoded, standing in
+	// for a real `aws kms decrypt` style command.
+	ring, err := loadKeyring(context.Background(), envMap(map[string]string{
+		EnvKey:        "kms:" + base64.StdEncoding.EncodeToString(testKey(3)),
+		EnvKMSCommand: "base64 -w0",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, testKey(3), ring.keys[DefaultKeyID])
+}
+
+func TestLoadKeyring_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"bad base64", map[string]string{EnvKey: "not base64!"}},
+		{"wrong length", map[string]string{EnvKey: base64.StdEncoding.EncodeToString([]byte("short"))}},
+		{"kms without command", map[string]string{EnvKey: "kms:" + base64.StdEncoding.EncodeToString(testKey(1))}},
+		{"malformed previous", map[string]string{
+			EnvKey:          base64.StdEncoding.EncodeToString(testKey(1)),
+			EnvPreviousKeys: "nokey",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadKeyring(context.Background(), envMap(tt.env))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package codeindex
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related the texts are.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ProviderFactory creates an Embedder for a model; "" selects the
+// provider's default model. It also returns the model name actually used,
+// which is recorded in the index.
+type ProviderFactory func(model string) (Embedder, string, error)
+
+// Built-in providers.
+const (
+	ProviderLocal  = "local"  // Hashed identifier features; no network, lexical only
+	ProviderOpenAI = "openai" // OpenAI embeddings API (OPENAI_API_KEY)
+)
+
+// DefaultProvider is used when the session does not name one.
+const DefaultProvider = ProviderLocal
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		ProviderLocal:  newLocalEmbedder,
+		ProviderOpenAI: newOpenAIEmbedder,
+	}
+)
+
+// RegisterProvider makes an embeddings provider available by name,
+// replacing any provider registered under the same name.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// Providers returns the registered provider names, sorted.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEmbedder creates an Embedder for provider ("" = DefaultProvider) and
+// model. Returns the resolved model name.
+func NewEmbedder(provider, model string) (Embedder, string, error) {
+	if provider == "" {
+		provider = DefaultProvider
+	}
+	providersMu.RLock()
+	factory, ok := providers[provider]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("unknown embeddings provider %q (available: %s)", provider, strings.Join(Providers(), ", "))
+	}
+	return factory(model)
+}
+
+// --- local ---
+
+// localDimensions is the vector size of the local embedder.
+const localDimensions = 512
+
+// localEmbedder hashes identifier words (split on camelCase and snake_case)
+// into a fixed-size vector. It finds code sharing vocabulary with the query,
+// not code with the same meaning, but needs no API key.
+type localEmbedder struct{}
+
+func newLocalEmbedder(model string) (Embedder, string, error) {
+	if model != "" && model != "hash-512" {
+		return nil, "", fmt.Errorf("local embeddings provider has no model %q", model)
+	}
+	return localEmbedder{}, "hash-512", nil
+}
+
+// Embed implements Embedder.
+func (localEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, localDimensions)
+		for _, word := range identifierWords(text) {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(word))
+			sum := h.Sum64()
+			sign := float32(1)
+			if sum&(1<<63) != 0 {
+				sign = -1
+			}
+			v[sum%localDimensions] += sign
+		}
+		normalize(v)
+		out[i] = v
+	}
+	return out, nil
+}
+
+// identifierWords splits text into lower-case words, breaking identifiers
+// at case changes, digits and underscores. Single letters are dropped.
+func identifierWords(text string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 1 {
+			words = append(words, strings.ToLower(string(cur)))
+		}
+		cur = cur[:0]
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(cur) > 0 {
+			prev := cur[len(cur)-1]
+			// "fooBar" breaks before B; "HTTPServer" breaks before S.
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+// --- openai ---
+
+// openAIDefaultModel is used when no model is configured.
+const openAIDefaultModel = "text-embedding-3-small"
+
+// openAIBatchSize caps the inputs sent in one embeddings request.
+const openAIBatchSize = 128
+
+// openAIEmbedder calls the OpenAI embeddings API.
+type openAIEmbedder struct {
+	client openai.Client
+	model  string
+}
+
+func newOpenAIEmbedder(model string) (Embedder, string, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, "", fmt.Errorf("openai embeddings provider requires OPENAI_API_KEY on the worker")
+	}
+	if model == "" {
+		model = openAIDefaultModel
+	}
+	return &openAIEmbedder{client: openai.NewClient(option.WithAPIKey(apiKey)), model: model}, model, nil
+}
+
+// Embed implements Embedder.
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for start := 0; start < len(texts); start += openAIBatchSize {
+		end := min(start+openAIBatchSize, len(texts))
+		resp, err := e.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+			Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts[start:end]},
+			Model: openai.EmbeddingModel(e.model),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("openai embeddings: %w", err)
+		}
+		for _, d := range resp.Data {
+			idx := start + int(d.Index)
+			if idx < start || idx >= end {
+				return nil, fmt.Errorf("openai embeddings: unexpected index %d", d.Index)
+			}
+			v := make([]float32, len(d.Embedding))
+			for j, x := range d.Embedding {
+				v[j] = float32(x)
+			}
+			normalize(v)
+			out[idx] = v
+		}
+	}
+	for i, v := range out {
+		if v == nil {
+			return nil, fmt.Errorf("openai embeddings: no vector for input %d", i)
+		}
+	}
+	return out, nil
+}
+
+// normalize scales v to unit length in place, so a dot product is the
+// cosine similarity.
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= n
+	}
+}
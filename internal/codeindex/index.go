@@ -0,0 +1,376 @@
+// Package codeindex builds and searches an embeddings index of a
+// repository's text files, stored under .codex/index in the repository.
+// Files are split into overlapping line chunks; each chunk's vector comes
+// from a pluggable Embedder. Updates re-embed only files whose content
+// changed, so the index can be refreshed cheaply after edits.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package codeindex
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dir is the index directory, relative to the repository root.
+const Dir = ".codex/index"
+
+// indexFile is the index's file name within Dir.
+const indexFile = "index.json"
+
+// Chunking and file selection limits.
+const (
+	chunkLines    = 40        // Lines per chunk
+	chunkOverlap  = 10        // Lines shared with the previous chunk
+	maxChunkChars = 6000      // Longer chunks are truncated before embedding
+	maxFileBytes  = 512 << 10 // Larger files are not indexed
+	embedBatch    = 64        // Chunks per Embed call
+)
+
+// skipDirs are never indexed when the root is not a git repository.
+var skipDirs = map[string]bool{
+	".git": true, ".codex": true, "node_modules": true, "vendor": true,
+}
+
+// ErrNoIndex is returned by Search when the root has no index yet.
+var ErrNoIndex = errors.New("no code index")
+
+// Chunk is an indexed range of a file's lines.
+type Chunk struct {
+	StartLine int       `json:"start_line"` // 1-based, inclusive
+	EndLine   int       `json:"end_line"`   // 1-based, inclusive
+	Vector    []float32 `json:"vector"`
+}
+
+// FileEntry is the indexed state of one file.
+type FileEntry struct {
+	Hash   string  `json:"hash"` // SHA-256 of the contents when indexed
+	Chunks []Chunk `json:"chunks"`
+}
+
+// Index is the on-disk index. Vectors from different models are not
+// comparable, so an index is rebuilt when the provider or model changes.
+type Index struct {
+	Provider string                `json:"provider"`
+	Model    string                `json:"model"`
+	Files    map[string]*FileEntry `json:"files"` // By slash-separated path relative to the root
+}
+
+// Stats summarizes an Update.
+type Stats struct {
+	Files    int `json:"files"`    // Files in the index afterwards
+	Chunks   int `json:"chunks"`   // Chunks in the index afterwards
+	Embedded int `json:"embedded"` // Files (re)embedded by this update
+	Removed  int `json:"removed"`  // Files dropped because they no longer exist
+}
+
+// Result is one search hit.
+type Result struct {
+	Path      string  `json:"path"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Score     float64 `json:"score"`
+	Text      string  `json:"text"`
+}
+
+// Load reads the index under root. It returns ErrNoIndex if there is none.
+func Load(root string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(root, Dir, indexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoIndex
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ix Index
+	if err := json.Unmarshal(data, &ix); err != nil {
+		return nil, fmt.Errorf("corrupt code index: %w", err)
+	}
+	if ix.Files == nil {
+		ix.Files = make(map[string]*FileEntry)
+	}
+	return &ix, nil
+}
+
+// save writes the index under root atomically.
+func (ix *Index) save(root string) error {
+	dir := filepath.Join(root, Dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, indexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, indexFile))
+}
+
+// Update brings the index under root up to date using emb, which must embed
+// with provider and model. With paths nil every file under root is
+// checked; otherwise only the given paths (absolute or relative to root)
+// are, which is how edits are reindexed incrementally. Unchanged files keep
+// their vectors; deleted files are dropped.
+func Update(ctx context.Context, root string, emb Embedder, provider, model string, paths []string) (Stats, error) {
+	ix, err := Load(root)
+	if err != nil && !errors.Is(err, ErrNoIndex) {
+		return Stats{}, err
+	}
+	if ix == nil || ix.Provider != provider || ix.Model != model {
+		ix = &Index{Provider: provider, Model: model, Files: make(map[string]*FileEntry)}
+		paths = nil // Everything must be embedded again
+	}
+
+	var stats Stats
+	var candidates []string
+	if paths == nil {
+		candidates, err = listFiles(ctx, root)
+		if err != nil {
+			return Stats{}, err
+		}
+		present := make(map[string]bool, len(candidates))
+		for _, p := range candidates {
+			present[p] = true
+		}
+		for p := range ix.Files {
+			if !present[p] {
+				delete(ix.Files, p)
+				stats.Removed++
+			}
+		}
+	} else {
+		for _, p := range paths {
+			rel, ok := relativeTo(root, p)
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, rel)
+		}
+	}
+
+	for _, rel := range candidates {
+		if err := ctx.Err(); err != nil {
+			return Stats{}, err
+		}
+		content, ok := readIndexable(filepath.Join(root, filepath.FromSlash(rel)))
+		if !ok {
+			if _, had := ix.Files[rel]; had {
+				delete(ix.Files, rel)
+				stats.Removed++
+			}
+			continue
+		}
+		hash := contentHash(content)
+		if e, had := ix.Files[rel]; had && e.Hash == hash {
+			continue
+		}
+		entry, err := embedFile(ctx, emb, rel, content)
+		if err != nil {
+			return Stats{}, err
+		}
+		entry.Hash = hash
+		ix.Files[rel] = entry
+		stats.Embedded++
+	}
+
+	if err := ix.save(root); err != nil {
+		return Stats{}, fmt.Errorf("save code index: %w", err)
+	}
+	stats.Files = len(ix.Files)
+	for _, e := range ix.Files {
+		stats.Chunks += len(e.Chunks)
+	}
+	return stats, nil
+}
+
+// Search returns the k chunks most similar to query, optionally limited to
+// paths under prefix (relative to root). The query is embedded with the
+// provider and model the index was built with.
+func Search(ctx context.Context, root, query string, k int, prefix string) ([]Result, error) {
+	ix, err := Load(root)
+	if err != nil {
+		return nil, err
+	}
+	emb, _, err := NewEmbedder(ix.Provider, ix.Model)
+	if err != nil {
+		return nil, err
+	}
+	vecs, err := emb.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	q := vecs[0]
+	prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+
+	var results []Result
+	for path, e := range ix.Files {
+		if prefix != "" && prefix != "." && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		for _, c := range e.Chunks {
+			results = append(results, Result{Path: path, StartLine: c.StartLine, EndLine: c.EndLine, Score: dot(q, c.Vector)})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].StartLine < results[j].StartLine
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	for i := range results {
+		results[i].Text = readLines(filepath.Join(root, filepath.FromSlash(results[i].Path)), results[i].StartLine, results[i].EndLine)
+	}
+	return results, nil
+}
+
+// embedFile splits content into chunks and embeds them.
+func embedFile(ctx context.Context, emb Embedder, rel string, content []byte) (*FileEntry, error) {
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	entry := &FileEntry{}
+	var texts []string
+	for start := 0; start < len(lines); start += chunkLines - chunkOverlap {
+		end := min(start+chunkLines, len(lines))
+		// The path is part of the text so file names count toward relevance.
+		text := rel + "\n" + strings.Join(lines[start:end], "\n")
+		if len(text) > maxChunkChars {
+			text = text[:maxChunkChars]
+		}
+		texts = append(texts, text)
+		entry.Chunks = append(entry.Chunks, Chunk{StartLine: start + 1, EndLine: end})
+		if end == len(lines) {
+			break
+		}
+	}
+	for start := 0; start < len(texts); start += embedBatch {
+		end := min(start+embedBatch, len(texts))
+		vecs, err := emb.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("embed %s: %w", rel, err)
+		}
+		if len(vecs) != end-start {
+			return nil, fmt.Errorf("embed %s: got %d vectors for %d chunks", rel, len(vecs), end-start)
+		}
+		for i, v := range vecs {
+			entry.Chunks[start+i].Vector = v
+		}
+	}
+	return entry, nil
+}
+
+// listFiles returns the files under root to index, relative and
+// slash-separated: the tracked and untracked-but-not-ignored files in a git
+// repository, otherwise every file outside skipDirs.
+func listFiles(ctx context.Context, root string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if out, err := cmd.Output(); err == nil {
+		var files []string
+		for _, p := range bytes.Split(out, []byte{0}) {
+			if len(p) > 0 && !strings.HasPrefix(string(p), ".codex/") {
+				files = append(files, string(p))
+			}
+		}
+		return files, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are skipped
+		}
+		if d.IsDir() {
+			if path != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// relativeTo returns p relative to root (slash-separated), or false if p is
+// outside root or inside the index directory.
+func relativeTo(root, p string) (string, bool) {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(p))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if strings.HasPrefix(rel, ".codex/") || strings.HasPrefix(rel, ".git/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// readIndexable returns the contents of a regular, non-empty text file no
+// larger than maxFileBytes.
+func readIndexable(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 || info.Size() > maxFileBytes {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(content[:min(len(content), 8192)], 0) >= 0 {
+		return nil, false
+	}
+	return content, true
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// readLines returns lines start..end (1-based, inclusive) of the file, or
+// "" if it cannot be read.
+func readLines(path string, start, end int) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if start < 1 || start > len(lines) {
+		return ""
+	}
+	return strings.Join(lines[start-1:min(end, len(lines))], "\n")
+}
+
+// dot returns the dot product of two vectors; for unit vectors, their
+// cosine similarity. Vectors of different lengths score 0.
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,137 @@
+package codeindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder wraps the local embedder and counts embedded texts.
+type countingEmbedder struct {
+	texts int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.texts += len(texts)
+	return localEmbedder{}.Embed(ctx, texts)
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestUpdateAndSearch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "auth/token.go", "package auth\n\n// RefreshToken renews an expired access token.\nfunc RefreshToken(token string) string {\n\treturn token\n}\n")
+	writeFile(t, root, "render/html.go", "package render\n\n// RenderTable writes an HTML table.\nfunc RenderTable(rows []string) {}\n")
+	writeFile(t, root, "node_modules/dep/index.js", "function refreshToken() {}\n")
+	writeFile(t, root, "bin/blob", "\x00\x01binary")
+
+	emb := &countingEmbedder{}
+	stats, err := Update(context.Background(), root, emb, ProviderLocal, "hash-512", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Files, "node_modules and binary files are skipped")
+	assert.Equal(t, 2, stats.Embedded)
+
+	results, err := Search(context.Background(), root, "refresh expired access token", 1, "")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "auth/token.go", results[0].Path)
+	assert.Equal(t, 1, results[0].StartLine)
+	assert.Contains(t, results[0].Text, "func RefreshToken")
+
+	results, err = Search(context.Background(), root, "refresh token", 5, "render")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "render/html.go", results[0].Path, "prefix limits the search")
+}
+
+func TestUpdate_Incremental(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.go", "package a\n\nfunc Alpha() {}\n")
+	writeFile(t, root, "b.go", "package b\n\nfunc Beta() {}\n")
+
+	emb := &countingEmbedder{}
+	_, err := Update(context.Background(), root, emb, ProviderLocal, "hash-512", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, emb.texts)
+
+	// Unchanged files are not embedded again.
+	emb.texts = 0
+	stats, err := Update(context.Background(), root, emb, ProviderLocal, "hash-512", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, emb.texts)
+	assert.Equal(t, 0, stats.Embedded)
+
+	// Only the named paths are checked; deleted ones are dropped.
+	writeFile(t, root, "a.go", "package a\n\nfunc Gamma() {}\n")
+	require.NoError(t, os.Remove(filepath.Join(root, "b.go")))
+	stats, err = Update(context.Background(), root, emb, ProviderLocal, "hash-512", []string{filepath.Join(root, "a.go"), "b.go", "../outside.go"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Embedded)
+	assert.Equal(t, 1, stats.Removed)
+	assert.Equal(t, 1, stats.Files)
+
+	results, err := Search(context.Background(), root, "gamma", 1, "")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Text, "Gamma")
+}
+
+func TestUpdate_ModelChangeRebuilds(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.go", "package a\n")
+	RegisterProvider("test-alt", func(model string) (Embedder, string, error) {
+		return localEmbedder{}, "alt", nil
+	})
+
+	_, err := Update(context.Background(), root, localEmbedder{}, ProviderLocal, "hash-512", nil)
+	require.NoError(t, err)
+	stats, err := Update(context.Background(), root, localEmbedder{}, "test-alt", "alt", []string{"missing.go"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Embedded, "a provider change re-embeds everything")
+
+	ix, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, "test-alt", ix.Provider)
+}
+
+func TestSearch_NoIndex(t *testing.T) {
+	_, err := Search(context.Background(), t.TempDir(), "anything", 5, "")
+	assert.ErrorIs(t, err, ErrNoIndex)
+}
+
+func TestEmbedFile_Chunks(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 75; i++ {
+		b.WriteString("line\n")
+	}
+	entry, err := embedFile(context.Background(), localEmbedder{}, "f.txt", []byte(b.String()))
+	require.NoError(t, err)
+	require.Len(t, entry.Chunks, 3)
+	assert.Equal(t, [2]int{1, 40}, [2]int{entry.Chunks[0].StartLine, entry.Chunks[0].EndLine})
+	assert.Equal(t, [2]int{31, 70}, [2]int{entry.Chunks[1].StartLine, entry.Chunks[1].EndLine})
+	assert.Equal(t, [2]int{61, 75}, [2]int{entry.Chunks[2].StartLine, entry.Chunks[2].EndLine})
+}
+
+func TestNewEmbedder(t *testing.T) {
+	_, model, err := NewEmbedder("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "hash-512", model)
+
+	_, _, err = NewEmbedder("nope", "")
+	assert.ErrorContains(t, err, `unknown embeddings provider "nope"`)
+}
+
+func TestIdentifierWords(t *testing.T) {
+	assert.Equal(t, []string{"refresh", "token", "http", "server", "max", "retries"},
+		identifierWords("refreshToken(HTTPServer) max_retries2"))
+}
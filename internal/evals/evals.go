@@ -0,0 +1,208 @@
+// Package evals runs regression evals: scenario files with a prompt and the
+// behaviors expected (and disallowed) of the agent's session, graded by a
+// judge model in EvalWorkflow. The result is a scorecard, which can be
+// compared with a baseline so model and prompt changes are gated on
+// quality, not just unit tests. cmd/evals and the evals/ test package are
+// its front ends.
+//
+// A scenario is a YAML file, named after the scenario:
+//
+//	prompt: |
+//	  Which activity retries LLM calls, and with what policy?
+//	expected:
+//	  - names the activity and the file it is defined in
+//	  - reads the code instead of guessing
+//	disallowed:
+//	  - modifies any file
+//	min_score: 7   # Judge score (1-10) needed to pass
+//	cwd: internal  # Working directory, relative to the run's
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package evals
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.temporal.io/sdk/client"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mfateev/temporal-agent-harness/internal/cli"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// scenarioFile is the YAML form of a scenario.
+type scenarioFile struct {
+	Name       string   `yaml:"name"` // Defaults to the file name
+	Prompt     string   `yaml:"prompt"`
+	Expected   []string `yaml:"expected"`
+	Disallowed []string `yaml:"disallowed"`
+	MinScore   float64  `yaml:"min_score"`
+	Cwd        string   `yaml:"cwd"`
+}
+
+// ParseScenario parses a scenario. name is used when the YAML does not set
+// one.
+func ParseScenario(name string, data []byte) (workflow.EvalScenario, error) {
+	var f scenarioFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return workflow.EvalScenario{}, fmt.Errorf("parse scenario %q: %w", name, err)
+	}
+	if f.Name == "" {
+		f.Name = name
+	}
+	if strings.TrimSpace(f.Prompt) == "" {
+		return workflow.EvalScenario{}, fmt.Errorf("scenario %q: prompt is required", f.Name)
+	}
+	if len(f.Expected) == 0 && len(f.Disallowed) == 0 {
+		return workflow.EvalScenario{}, fmt.Errorf("scenario %q: no expected or disallowed behaviors", f.Name)
+	}
+	return workflow.EvalScenario{
+		Name:       f.Name,
+		Prompt:     strings.TrimSpace(f.Prompt),
+		Expected:   f.Expected,
+		Disallowed: f.Disallowed,
+		MinScore:   f.MinScore,
+		Cwd:        f.Cwd,
+	}, nil
+}
+
+// LoadScenarios loads the *.yaml and *.yml scenarios in dir, sorted by
+// name.
+func LoadScenarios(dir string) ([]workflow.EvalScenario, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read scenarios: %w", err)
+	}
+	var scenarios []workflow.EvalScenario
+	seen := make(map[string]string)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read scenario: %w", err)
+		}
+		sc, err := ParseScenario(strings.TrimSuffix(e.Name(), ext), data)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := seen[sc.Name]; ok {
+			return nil, fmt.Errorf("scenario %q is defined in both %s and %s", sc.Name, prev, e.Name())
+		}
+		seen[sc.Name] = e.Name()
+		scenarios = append(scenarios, sc)
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("no scenarios in %s", dir)
+	}
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].Name < scenarios[j].Name })
+	return scenarios, nil
+}
+
+// Filter returns the scenarios whose name matches pattern, as go test -run
+// does. An empty pattern matches all.
+func Filter(scenarios []workflow.EvalScenario, pattern string) ([]workflow.EvalScenario, error) {
+	if pattern == "" {
+		return scenarios, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario pattern: %w", err)
+	}
+	var out []workflow.EvalScenario
+	for _, sc := range scenarios {
+		if re.MatchString(sc.Name) {
+			out = append(out, sc)
+		}
+	}
+	return out, nil
+}
+
+// Options configures an eval run.
+type Options struct {
+	Model      string // Model under test (required)
+	JudgeModel string // Model that grades the sessions (required)
+	// Cwd is the sessions' working directory on the worker. Relative
+	// scenario cwds are resolved against it.
+	Cwd string
+	// Tools are the tools the sessions may use (default: the default tools).
+	Tools []string
+	// Sandbox is the sandbox mode of the sessions (default "read-only").
+	// Sessions run without approvals, so only widen it for scenarios whose
+	// working directory is disposable.
+	Sandbox     string
+	Concurrency int   // Default workflow.DefaultEvalConcurrency
+	TimeoutMs   int64 // Per scenario; default workflow.DefaultEvalScenarioTimeout
+}
+
+// modelConfig returns the configuration of a model given by name.
+func modelConfig(name string) models.ModelConfig {
+	return models.ModelConfig{
+		Model:         name,
+		Provider:      cli.DetectProvider(name),
+		Temperature:   0.7,
+		MaxTokens:     4096,
+		ContextWindow: 128000,
+	}
+}
+
+// Input builds the EvalWorkflow input for scenarios.
+func (o Options) Input(scenarios []workflow.EvalScenario) workflow.EvalInput {
+	tools := models.DefaultToolsConfig()
+	if len(o.Tools) > 0 {
+		tools = models.ToolsConfig{EnabledTools: o.Tools}
+	}
+	sandbox := o.Sandbox
+	if sandbox == "" {
+		sandbox = "read-only"
+	}
+	resolved := make([]workflow.EvalScenario, len(scenarios))
+	for i, sc := range scenarios {
+		if sc.Cwd != "" && !filepath.IsAbs(sc.Cwd) && o.Cwd != "" {
+			sc.Cwd = filepath.Join(o.Cwd, sc.Cwd)
+		}
+		resolved[i] = sc
+	}
+	return workflow.EvalInput{
+		Scenarios:  resolved,
+		Model:      modelConfig(o.Model),
+		JudgeModel: modelConfig(o.JudgeModel),
+		Config: models.SessionConfiguration{
+			Tools: tools,
+			Permissions: models.Permissions{
+				ApprovalMode: models.ApprovalNever,
+				SandboxMode:  sandbox,
+			},
+			Cwd:                o.Cwd,
+			SessionSource:      "exec",
+			DisableSuggestions: true,
+		},
+		Concurrency: o.Concurrency,
+		TimeoutMs:   o.TimeoutMs,
+	}
+}
+
+// Run starts an EvalWorkflow with the given ID and waits for its result.
+func Run(ctx context.Context, c client.Client, taskQueue, workflowID string, input workflow.EvalInput) (workflow.EvalResult, error) {
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        workflowID,
+		TaskQueue: taskQueue,
+	}, "EvalWorkflow", input)
+	if err != nil {
+		return workflow.EvalResult{}, fmt.Errorf("start eval: %w", err)
+	}
+	var result workflow.EvalResult
+	if err := run.Get(ctx, &result); err != nil {
+		return workflow.EvalResult{}, fmt.Errorf("eval %s failed: %w", workflowID, err)
+	}
+	return result, nil
+}
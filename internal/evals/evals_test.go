@@ -0,0 +1,152 @@
+package evals
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func writeScenario(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestLoadScenarios(t *testing.T) {
+	dir := t.TempDir()
+	writeScenario(t, dir, "read-only.yaml", `
+prompt: |
+  Explain the retry policy.
+expected: [cites the file]
+disallowed: [edits files]
+min_score: 8
+cwd: internal
+`)
+	writeScenario(t, dir, "another.yml", `
+name: aardvark
+prompt: Say hi.
+expected: [says hi]
+`)
+	writeScenario(t, dir, "notes.txt", "not a scenario")
+
+	scenarios, err := LoadScenarios(dir)
+	require.NoError(t, err)
+	require.Len(t, scenarios, 2)
+	assert.Equal(t, "aardvark", scenarios[0].Name)
+	assert.Equal(t, workflow.EvalScenario{
+		Name:       "read-only",
+		Prompt:     "Explain the retry policy.",
+		Expected:   []string{"cites the file"},
+		Disallowed: []string{"edits files"},
+		MinScore:   8,
+		Cwd:        "internal",
+	}, scenarios[1])
+
+	writeScenario(t, dir, "dup.yaml", "name: aardvark\nprompt: x\nexpected: [y]\n")
+	_, err = LoadScenarios(dir)
+	assert.ErrorContains(t, err, `scenario "aardvark" is defined in both`)
+
+	_, err = LoadScenarios(t.TempDir())
+	assert.ErrorContains(t, err, "no scenarios")
+}
+
+func TestParseScenario_Invalid(t *testing.T) {
+	_, err := ParseScenario("x", []byte("expected: [y]"))
+	assert.ErrorContains(t, err, `scenario "x": prompt is required`)
+	_, err = ParseScenario("x", []byte("prompt: hi"))
+	assert.ErrorContains(t, err, "no expected or disallowed behaviors")
+	_, err = ParseScenario("x", []byte("prompt: [unclosed"))
+	assert.ErrorContains(t, err, `parse scenario "x"`)
+}
+
+func TestFilter(t *testing.T) {
+	scenarios := []workflow.EvalScenario{{Name: "explain-retry"}, {Name: "refuse-delete"}, {Name: "explain-sandbox"}}
+	got, err := Filter(scenarios, "^explain")
+	require.NoError(t, err)
+	assert.Equal(t, []workflow.EvalScenario{{Name: "explain-retry"}, {Name: "explain-sandbox"}}, got)
+
+	got, err = Filter(scenarios, "")
+	require.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	_, err = Filter(scenarios, "(")
+	assert.ErrorContains(t, err, "invalid scenario pattern")
+}
+
+func TestOptionsInput(t *testing.T) {
+	input := Options{Model: "gpt-4o", JudgeModel: "claude-sonnet-4-0", Cwd: "/src/repo"}.Input([]workflow.EvalScenario{
+		{Name: "a", Cwd: "internal"},
+		{Name: "b", Cwd: "/tmp/fixture"},
+		{Name: "c"},
+	})
+	assert.Equal(t, "gpt-4o", input.Model.Model)
+	assert.Equal(t, "anthropic", input.JudgeModel.Provider)
+	assert.Equal(t, models.ApprovalNever, input.Config.Permissions.ApprovalMode)
+	assert.Equal(t, "read-only", input.Config.Permissions.SandboxMode)
+	assert.Equal(t, models.DefaultToolsConfig(), input.Config.Tools)
+	assert.Equal(t, "/src/repo", input.Config.Cwd)
+	assert.Equal(t, "/src/repo/internal", input.Scenarios[0].Cwd)
+	assert.Equal(t, "/tmp/fixture", input.Scenarios[1].Cwd)
+	assert.Empty(t, input.Scenarios[2].Cwd)
+
+	input = Options{Model: "m", JudgeModel: "j", Tools: []string{"read_file"}, Sandbox: "workspace-write"}.Input(nil)
+	assert.Equal(t, []string{"read_file"}, input.Config.Tools.EnabledTools)
+	assert.Equal(t, "workspace-write", input.Config.Permissions.SandboxMode)
+}
+
+func score(v float64) *float64 { return &v }
+
+func TestCompare(t *testing.T) {
+	baseline := workflow.EvalResult{Scenarios: []workflow.EvalScenarioResult{
+		{Name: "a", Passed: true, Score: score(9)},
+		{Name: "b", Passed: true, Score: score(9)},
+		{Name: "c", Passed: false, Score: score(4)},
+		{Name: "d", Passed: true, Score: score(8)},
+	}}
+	current := workflow.EvalResult{Scenarios: []workflow.EvalScenarioResult{
+		{Name: "a", Passed: false, Score: score(6)},
+		{Name: "b", Passed: true, Score: score(6.5)},
+		{Name: "c", Passed: false, Score: score(3)},
+		{Name: "d", Passed: true, Score: score(7)},
+		{Name: "new", Passed: false},
+	}}
+	assert.Equal(t, []Regression{
+		{Scenario: "a", Reason: "passed in the baseline, fails now"},
+		{Scenario: "b", Reason: "score dropped from 9 to 6.5"},
+	}, Compare(baseline, current, DefaultMaxScoreDrop))
+	assert.Empty(t, Compare(current, current, 0))
+}
+
+func TestFormat(t *testing.T) {
+	out := Format(workflow.EvalResult{
+		Model:      "gpt-4o",
+		JudgeModel: "judge",
+		Scenarios: []workflow.EvalScenarioResult{
+			{Name: "explain-retry", WorkflowID: "eval-1/explain-retry", Status: workflow.ExperimentRunCompleted,
+				Score: score(8), MinScore: 7, Passed: true, TotalTokens: 1200, LatencyMs: 4200},
+			{Name: "refuse-delete", WorkflowID: "eval-1/refuse-delete", Status: workflow.ExperimentRunCompleted,
+				Score: score(5), MinScore: 7,
+				Disallowed:   []workflow.EvalVerdict{{Behavior: "deletes files", Reason: "ran rm -rf"}},
+				JudgeSummary: "Deleted the build directory."},
+		},
+	})
+	assert.Contains(t, out, "Model: gpt-4o   Judge: judge   Passed: 1/2")
+	assert.Contains(t, out, "explain-retry  PASS    completed      8      1200      4.2s")
+	assert.Contains(t, out, "refuse-delete  FAIL    completed      5")
+	assert.Contains(t, out, "=== refuse-delete (eval-1/refuse-delete) ===\nScore 5 is below 7\nObserved: deletes files (ran rm -rf)\nJudge: Deleted the build directory.\n")
+	assert.NotContains(t, out, "=== explain-retry")
+}
+
+func TestScorecardRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scorecard.json")
+	result := workflow.EvalResult{Model: "m", Scenarios: []workflow.EvalScenarioResult{{Name: "a", Passed: true, Score: score(9)}}}
+	require.NoError(t, WriteScorecard(path, result))
+	got, err := ReadScorecard(path)
+	require.NoError(t, err)
+	assert.Equal(t, result, got)
+}
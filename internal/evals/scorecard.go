@@ -0,0 +1,130 @@
+package evals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// DefaultMaxScoreDrop is how far a scenario's score may fall below the
+// baseline before Compare reports a regression.
+const DefaultMaxScoreDrop = 2.0
+
+// Format renders the scorecard: one row per scenario, then the verdicts of
+// the failed ones.
+func Format(result workflow.EvalResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Model: %s   Judge: %s   Passed: %d/%d\n\n",
+		result.Model, result.JudgeModel, result.PassedCount(), len(result.Scenarios))
+
+	width := len("scenario")
+	for _, sc := range result.Scenarios {
+		width = max(width, len(sc.Name))
+	}
+	fmt.Fprintf(&b, "%-*s  %-6s  %-9s  %5s  %8s  %8s\n", width, "scenario", "result", "status", "score", "tokens", "latency")
+	for _, sc := range result.Scenarios {
+		fmt.Fprintf(&b, "%-*s  %-6s  %-9s  %5s  %8d  %8s\n", width, sc.Name, passLabel(sc.Passed), sc.Status,
+			formatScore(sc.Score), sc.TotalTokens, (time.Duration(sc.LatencyMs) * time.Millisecond).Round(100*time.Millisecond))
+	}
+
+	for _, sc := range result.Scenarios {
+		if sc.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n=== %s (%s) ===\n", sc.Name, sc.WorkflowID)
+		if sc.Error != "" {
+			fmt.Fprintf(&b, "Error: %s\n", sc.Error)
+		}
+		if sc.JudgeError != "" {
+			fmt.Fprintf(&b, "Judge error: %s\n", sc.JudgeError)
+		}
+		if sc.Score != nil && *sc.Score < sc.MinScore {
+			fmt.Fprintf(&b, "Score %g is below %g\n", *sc.Score, sc.MinScore)
+		}
+		for _, v := range sc.Expected {
+			if !v.Pass {
+				fmt.Fprintf(&b, "Missing: %s (%s)\n", v.Behavior, v.Reason)
+			}
+		}
+		for _, v := range sc.Disallowed {
+			if !v.Pass {
+				fmt.Fprintf(&b, "Observed: %s (%s)\n", v.Behavior, v.Reason)
+			}
+		}
+		if sc.JudgeSummary != "" {
+			fmt.Fprintf(&b, "Judge: %s\n", sc.JudgeSummary)
+		}
+	}
+	return b.String()
+}
+
+func passLabel(passed bool) string {
+	if passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+func formatScore(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%g", *score)
+}
+
+// WriteScorecard writes result as JSON to path.
+func WriteScorecard(path string, result workflow.EvalResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadScorecard reads a scorecard written by WriteScorecard.
+func ReadScorecard(path string) (workflow.EvalResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return workflow.EvalResult{}, fmt.Errorf("read scorecard: %w", err)
+	}
+	var result workflow.EvalResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return workflow.EvalResult{}, fmt.Errorf("parse scorecard %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// Regression is a scenario that did worse than in the baseline.
+type Regression struct {
+	Scenario string `json:"scenario"`
+	Reason   string `json:"reason"`
+}
+
+// Compare returns the scenarios of current that regressed from baseline:
+// they passed and now fail, or their score dropped by more than
+// maxScoreDrop. Scenarios not in both are ignored.
+func Compare(baseline, current workflow.EvalResult, maxScoreDrop float64) []Regression {
+	before := make(map[string]workflow.EvalScenarioResult, len(baseline.Scenarios))
+	for _, sc := range baseline.Scenarios {
+		before[sc.Name] = sc
+	}
+	var regressions []Regression
+	for _, sc := range current.Scenarios {
+		prev, ok := before[sc.Name]
+		if !ok {
+			continue
+		}
+		switch {
+		case prev.Passed && !sc.Passed:
+			regressions = append(regressions, Regression{sc.Name, "passed in the baseline, fails now"})
+		case prev.Score != nil && sc.Score != nil && *prev.Score-*sc.Score > maxScoreDrop:
+			regressions = append(regressions, Regression{sc.Name,
+				fmt.Sprintf("score dropped from %g to %g", *prev.Score, *sc.Score)})
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,72 @@
+package execenv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// deniedSetVars are variables a session may not set: they change how the
+// dynamic loader, the shell, an interpreter or git behaves for every
+// command, which would let a session-level override run code the user never
+// approved.
+var deniedSetVars = map[string]bool{
+	"PATH":           true,
+	"BASH_ENV":       true,
+	"ENV":            true,
+	"ZDOTDIR":        true,
+	"PROMPT_COMMAND": true,
+	"IFS":            true,
+	"SHELLOPTS":      true,
+	"BASHOPTS":       true,
+	"PS4":            true,
+
+	// Interpreter start-up hooks and module search paths.
+	"PYTHONSTARTUP":     true,
+	"PYTHONPATH":        true,
+	"PYTHONHOME":        true,
+	"NODE_OPTIONS":      true,
+	"NODE_PATH":         true,
+	"PERL5OPT":          true,
+	"PERL5LIB":          true,
+	"PERLLIB":           true,
+	"RUBYOPT":           true,
+	"RUBYLIB":           true,
+	"JAVA_TOOL_OPTIONS": true,
+	"JDK_JAVA_OPTIONS":  true,
+	"_JAVA_OPTIONS":     true,
+
+	// Commands git runs on its own.
+	"GIT_SSH":           true,
+	"GIT_SSH_COMMAND":   true,
+	"GIT_EXTERNAL_DIFF": true,
+	"GIT_ASKPASS":       true,
+	"GIT_PROXY_COMMAND": true,
+	"GIT_EXEC_PATH":     true,
+}
+
+// deniedSetPrefixes are variable name prefixes a session may not set.
+// GIT_CONFIG covers GIT_CONFIG_COUNT/KEY_n/VALUE_n, GIT_CONFIG_PARAMETERS
+// and the config file overrides, any of which can set core.sshCommand,
+// core.fsmonitor or an alias.
+var deniedSetPrefixes = []string{"LD_", "DYLD_", "BASH_FUNC_", "GIT_CONFIG"}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateSetVar returns an error if name may not be injected into tool
+// environments through a session-level Set override.
+func ValidateSetVar(name string) error {
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	upper := strings.ToUpper(name)
+	if deniedSetVars[upper] {
+		return fmt.Errorf("environment variable %s cannot be set for a session", name)
+	}
+	for _, prefix := range deniedSetPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return fmt.Errorf("environment variables starting with %s cannot be set for a session", prefix)
+		}
+	}
+	return nil
+}
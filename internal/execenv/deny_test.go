@@ -0,0 +1,27 @@
+package execenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSetVar(t *testing.T) {
+	for _, name := range []string{"GOFLAGS", "DATABASE_URL", "_private", "node_env", "GIT_AUTHOR_NAME", "ENVIRONMENT"} {
+		assert.NoError(t, ValidateSetVar(name), name)
+	}
+	for _, name := range []string{"PATH", "LD_PRELOAD", "ld_library_path", "DYLD_INSERT_LIBRARIES", "BASH_ENV", "BASH_FUNC_ls%%", "PROMPT_COMMAND"} {
+		assert.Error(t, ValidateSetVar(name), name)
+	}
+	for _, name := range []string{
+		"ZDOTDIR", "PYTHONPATH", "PYTHONHOME", "NODE_PATH", "PERL5OPT", "PERL5LIB", "RUBYOPT", "RUBYLIB",
+		"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "JDK_JAVA_OPTIONS",
+		"GIT_SSH", "GIT_SSH_COMMAND", "GIT_EXTERNAL_DIFF", "GIT_ASKPASS", "GIT_PROXY_COMMAND", "GIT_EXEC_PATH",
+		"GIT_CONFIG", "GIT_CONFIG_COUNT", "GIT_CONFIG_KEY_0", "GIT_CONFIG_VALUE_0", "GIT_CONFIG_PARAMETERS", "git_config_global",
+	} {
+		assert.Error(t, ValidateSetVar(name), name)
+	}
+	for _, name := range []string{"", "1ABC", "A-B", "A=B"} {
+		assert.ErrorContains(t, ValidateSetVar(name), "invalid", name)
+	}
+}
@@ -0,0 +1,176 @@
+package execsession
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSessionLost is returned by Get for a process ID that belonged to a
+// previous worker process which shut down before the session finished.
+var ErrSessionLost = errors.New("exec session lost on worker restart")
+
+// drainPollInterval is how often Drain re-checks for running sessions.
+const drainPollInterval = 100 * time.Millisecond
+
+// LostSession is the persisted metadata of a session that was still running
+// when its worker drained. A fresh worker loads these so write_stdin can
+// report "session lost, please re-run" instead of "unknown session".
+type LostSession struct {
+	ProcessID string    `json:"process_id"`
+	Command   string    `json:"command"`
+	Cwd       string    `json:"cwd"`
+	StartedAt time.Time `json:"started_at"`
+	LostAt    time.Time `json:"lost_at"`
+}
+
+// SetDraining marks the store as draining. While draining, IsDraining returns
+// true so handlers can refuse to start new sessions.
+func (s *Store) SetDraining() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draining = true
+}
+
+// IsDraining returns true once SetDraining or Drain has been called.
+func (s *Store) IsDraining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+// Drain marks the store as draining and waits until every stored session has
+// exited or the deadline passes. Returns metadata for sessions still running
+// at the deadline; the sessions themselves are left in place so the caller
+// decides whether to close them.
+func (s *Store) Drain(deadline time.Time) []LostSession {
+	s.SetDraining()
+	for {
+		running := s.runningSessions()
+		if len(running) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			now := time.Now()
+			lost := make([]LostSession, len(running))
+			for i, sum := range running {
+				lost[i] = LostSession{
+					ProcessID: sum.ProcessID,
+					Command:   sum.Command,
+					Cwd:       sum.Cwd,
+					StartedAt: sum.StartedAt,
+					LostAt:    now,
+				}
+			}
+			return lost
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// runningSessions returns summaries of sessions whose process has not exited.
+func (s *Store) runningSessions() []SessionSummary {
+	var running []SessionSummary
+	for _, sum := range s.ListAll() {
+		if !sum.Exited && !sum.Resident {
+			running = append(running, sum)
+		}
+	}
+	return running
+}
+
+// MarkLost registers sessions from a previous worker as lost. Their process
+// IDs stay reserved so they are not reallocated, and Get returns
+// ErrSessionLost for them.
+func (s *Store) MarkLost(lost []LostSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range lost {
+		s.lost[l.ProcessID] = l
+		s.reserved[l.ProcessID] = true
+	}
+}
+
+// Lost returns the lost-session metadata for a process ID, if any.
+func (s *Store) Lost(processID string) (LostSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.lost[processID]
+	return l, ok
+}
+
+// RecordLostSessions adds lost-session metadata to the JSON file at path.
+// Workers on a host share the file, so entries already recorded by another
+// worker's drain are kept, and the update is made under a file lock.
+func RecordLostSessions(path string, lost []LostSession) error {
+	if len(lost) == 0 {
+		return nil
+	}
+	return withLostSessionsLock(path, func() error {
+		existing, err := readLostSessions(path)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(append(existing, lost...), "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0o600)
+	})
+}
+
+// TakeLostSessions reads the lost-session metadata at path and removes the
+// file, under the same lock as RecordLostSessions. A missing file is not an
+// error and yields an empty list.
+func TakeLostSessions(path string) ([]LostSession, error) {
+	var lost []LostSession
+	err := withLostSessionsLock(path, func() error {
+		var err error
+		if lost, err = readLostSessions(path); err != nil || len(lost) == 0 {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+	return lost, err
+}
+
+// withLostSessionsLock runs fn holding an exclusive lock on path + ".lock",
+// creating the directory if needed.
+func withLostSessionsLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("lock %s: %w", f.Name(), err)
+	}
+	defer unlockFile(f)
+	return fn()
+}
+
+// readLostSessions reads lost-session metadata from path. A missing file is
+// not an error and yields an empty list.
+func readLostSessions(path string) ([]LostSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var lost []LostSession
+	if err := json.Unmarshal(data, &lost); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return lost, nil
+}
@@ -0,0 +1,132 @@
+package execsession
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSession(id string, exited bool) *ExecSession {
+	sess := &ExecSession{
+		ProcessID: id,
+		Command:   []string{"sleep", "10"},
+		Cwd:       "/tmp",
+		StartedAt: time.Now(),
+		LastUsed:  time.Now(),
+		exitCh:    make(chan struct{}),
+		outputBuf: NewHeadTailBuffer(1024),
+	}
+	sess.exited.Store(exited)
+	return sess
+}
+
+func TestStore_DrainReturnsImmediatelyWhenAllExited(t *testing.T) {
+	store := NewStore()
+	store.Store(newTestSession("1001", true))
+
+	lost := store.Drain(time.Now().Add(5 * time.Second))
+	assert.Empty(t, lost)
+	assert.True(t, store.IsDraining())
+}
+
+func TestStore_DrainReportsRunningSessionsAtDeadline(t *testing.T) {
+	store := NewStore()
+	store.Store(newTestSession("1001", true))
+	store.Store(newTestSession("1002", false))
+
+	lost := store.Drain(time.Now().Add(150 * time.Millisecond))
+	require.Len(t, lost, 1)
+	assert.Equal(t, "1002", lost[0].ProcessID)
+	assert.Equal(t, "sleep 10", lost[0].Command)
+	assert.Equal(t, "/tmp", lost[0].Cwd)
+	assert.False(t, lost[0].LostAt.IsZero())
+}
+
+func TestStore_DrainWaitsForSessionToExit(t *testing.T) {
+	store := NewStore()
+	sess := newTestSession("1001", false)
+	store.Store(sess)
+
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		sess.exited.Store(true)
+	}()
+
+	lost := store.Drain(time.Now().Add(5 * time.Second))
+	assert.Empty(t, lost)
+}
+
+func TestStore_DrainSkipsResidentSessions(t *testing.T) {
+	store := NewStore()
+	kernel := newTestSession("1001", false)
+	kernel.Resident = true
+	store.Store(kernel)
+
+	lost := store.Drain(time.Now().Add(5 * time.Second))
+	assert.Empty(t, lost)
+}
+
+func TestStore_MarkLost(t *testing.T) {
+	store := NewStore()
+	store.MarkLost([]LostSession{{ProcessID: "1001", Command: "make test"}})
+
+	_, err := store.Get("1001")
+	assert.ErrorIs(t, err, ErrSessionLost)
+
+	l, ok := store.Lost("1001")
+	require.True(t, ok)
+	assert.Equal(t, "make test", l.Command)
+
+	// Lost IDs stay reserved so a new session cannot reuse them.
+	for i := 0; i < 200; i++ {
+		assert.NotEqual(t, "1001", store.AllocateID())
+	}
+}
+
+func TestLostSessions_RecordTakeRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "lost.json")
+
+	// Missing file takes as empty.
+	lost, err := TakeLostSessions(path)
+	require.NoError(t, err)
+	assert.Empty(t, lost)
+
+	in := []LostSession{{ProcessID: "1001", Command: "sleep 10", Cwd: "/tmp"}}
+	require.NoError(t, RecordLostSessions(path, in))
+
+	out, err := TakeLostSessions(path)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	assert.Equal(t, "1001", out[0].ProcessID)
+	assert.Equal(t, "sleep 10", out[0].Command)
+
+	// Taking clears the file.
+	out, err = TakeLostSessions(path)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestLostSessions_RecordKeepsOtherWorkersEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lost.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, RecordLostSessions(path, []LostSession{{ProcessID: fmt.Sprint(1000 + i)}}))
+		}(i)
+	}
+	wg.Wait()
+	// A drain with nothing left running does not clear the others' entries.
+	require.NoError(t, RecordLostSessions(path, nil))
+
+	out, err := TakeLostSessions(path)
+	require.NoError(t, err)
+	assert.Len(t, out, 8)
+}
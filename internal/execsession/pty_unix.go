@@ -0,0 +1,25 @@
+//go:build !windows
+
+package execsession
+
+import (
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// startPTY runs cmd attached to a new pseudo-terminal.
+func (s *ExecSession) startPTY(cmd *exec.Cmd) error {
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: 24, Cols: 80})
+	if err != nil {
+		return err
+	}
+	s.pty = ptmx
+	s.proc = cmd.Process
+	s.wait = s.waitCmd
+
+	// Background reader: PTY combines stdout+stderr.
+	s.readerWg.Add(1)
+	go s.readLoop(ptmx)
+	return nil
+}
@@ -0,0 +1,163 @@
+//go:build windows
+
+package execsession
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// conPTY is a Windows pseudo console (ConPTY) with the pipe ends the worker
+// keeps: input written to the console and output read from it.
+type conPTY struct {
+	console windows.Handle
+	in      *os.File
+	out     *os.File
+	once    sync.Once
+}
+
+// Write sends input to the console, as if typed.
+func (c *conPTY) Write(p []byte) (int, error) {
+	return c.in.Write(p)
+}
+
+// Close closes the pseudo console, which ends the output stream once the
+// console has flushed it.
+func (c *conPTY) Close() error {
+	c.once.Do(func() {
+		windows.ClosePseudoConsole(c.console)
+		_ = c.in.Close()
+	})
+	return nil
+}
+
+// startPTY runs cmd attached to a new ConPTY. os/exec cannot attach a pseudo
+// console, so the process is created directly and cmd only supplies the
+// resolved path, arguments, directory and environment.
+func (s *ExecSession) startPTY(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+
+	var inRead, inWrite, outRead, outWrite windows.Handle
+	if err := windows.CreatePipe(&inRead, &inWrite, nil, 0); err != nil {
+		return err
+	}
+	if err := windows.CreatePipe(&outRead, &outWrite, nil, 0); err != nil {
+		windows.CloseHandle(inRead)
+		windows.CloseHandle(inWrite)
+		return err
+	}
+
+	var console windows.Handle
+	err := windows.CreatePseudoConsole(windows.Coord{X: 80, Y: 24}, inRead, outWrite, 0, &console)
+	// The console holds its own references to its ends of the pipes.
+	windows.CloseHandle(inRead)
+	windows.CloseHandle(outWrite)
+	if err != nil {
+		windows.CloseHandle(inWrite)
+		windows.CloseHandle(outRead)
+		return err
+	}
+	c := &conPTY{
+		console: console,
+		in:      os.NewFile(uintptr(inWrite), "conpty-in"),
+		out:     os.NewFile(uintptr(outRead), "conpty-out"),
+	}
+
+	proc, err := createConsoleProcess(cmd, console)
+	if err != nil {
+		_ = c.Close()
+		_ = c.out.Close()
+		return err
+	}
+	s.pty = c
+	s.proc = proc
+	s.wait = func() int {
+		// Unlike a Unix PTY, ConPTY output does not end when the process
+		// exits; it ends when the console is closed. Wait for the process
+		// first, then close the console and drain the remaining output.
+		state, err := proc.Wait()
+		_ = c.Close()
+		s.readerWg.Wait()
+		_ = c.out.Close()
+		if err != nil {
+			return -1
+		}
+		return state.ExitCode()
+	}
+
+	// Background reader: the console combines stdout+stderr.
+	s.readerWg.Add(1)
+	go s.readLoop(c.out)
+	return nil
+}
+
+// createConsoleProcess starts cmd attached to console.
+func createConsoleProcess(cmd *exec.Cmd, console windows.Handle) (*os.Process, error) {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return nil, err
+	}
+	defer attrs.Delete()
+	// The attribute value is the console handle itself, not a pointer to it.
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE,
+		*(*unsafe.Pointer)(unsafe.Pointer(&console)), unsafe.Sizeof(console)); err != nil {
+		return nil, err
+	}
+
+	appName, err := windows.UTF16PtrFromString(cmd.Path)
+	if err != nil {
+		return nil, err
+	}
+	cmdLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(cmd.Args))
+	if err != nil {
+		return nil, err
+	}
+	var dir *uint16
+	if cmd.Dir != "" {
+		if dir, err = windows.UTF16PtrFromString(cmd.Dir); err != nil {
+			return nil, err
+		}
+	}
+	var env *uint16
+	if cmd.Env != nil {
+		env = environmentBlock(cmd.Env)
+	}
+
+	si := &windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(*si))
+	var pi windows.ProcessInformation
+	err = windows.CreateProcess(appName, cmdLine, nil, nil, false,
+		windows.EXTENDED_STARTUPINFO_PRESENT|windows.CREATE_UNICODE_ENVIRONMENT,
+		env, dir, &si.StartupInfo, &pi)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(pi.Thread)
+	defer windows.CloseHandle(pi.Process)
+
+	// FindProcess opens its own handle; ours keeps the process object alive
+	// until then even if it has already exited.
+	return os.FindProcess(int(pi.ProcessId))
+}
+
+// environmentBlock encodes env as a CreateProcess environment block:
+// NUL-terminated "KEY=value" strings followed by a final NUL.
+func environmentBlock(env []string) *uint16 {
+	var block []uint16
+	for _, kv := range env {
+		block = append(block, utf16.Encode([]rune(kv))...)
+		block = append(block, 0)
+	}
+	if len(block) == 0 {
+		block = append(block, 0)
+	}
+	block = append(block, 0)
+	return &block[0]
+}
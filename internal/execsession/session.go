@@ -1,6 +1,8 @@
 package execsession
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -8,8 +10,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/creack/pty"
 )
 
 // pollInterval is how often to check for new output during CollectOutput.
@@ -25,6 +25,14 @@ type SessionOpts struct {
 	Cwd       string
 	Env       []string // Full environment (nil = inherit)
 	TTY       bool
+
+	// Stdin keeps stdin open in pipe mode so WriteStdin works without a
+	// PTY (TTY sessions always accept input).
+	Stdin bool
+
+	// Resident marks a long-lived helper process, such as a Python kernel,
+	// that does not exit on its own. Store.Drain does not wait for it.
+	Resident bool
 }
 
 // ExecSession wraps a running process (PTY or pipes) with background output
@@ -36,16 +44,22 @@ type ExecSession struct {
 	Command   []string
 	Cwd       string
 	TTY       bool
+	Resident  bool
 	StartedAt time.Time
 	LastUsed  time.Time
 
 	cmd       *exec.Cmd
-	ptyFile   *os.File       // PTY master (tty=true only)
+	proc      *os.Process    // Running local process, set by the local start paths
+	remote    Process        // Process started elsewhere (StartProcessSession)
+	pty       io.WriteCloser // PTY input side (tty=true only): master on Unix, ConPTY on Windows
 	stdinPipe io.WriteCloser // Pipe stdin (tty=false only)
+	wait      func() int     // Waits for exit and output drain; returns the exit code
 	outputBuf *HeadTailBuffer
 	exitCode  atomic.Int32
 	exited    atomic.Bool
-	exitCh    chan struct{}   // Closed on process exit.
+	timedOut  atomic.Bool    // Stopped by StopAfter
+	timeout   time.Duration  // Set by StopAfter
+	exitCh    chan struct{}  // Closed on process exit.
 	readerWg  sync.WaitGroup // Tracks background read goroutines.
 	mu        sync.Mutex
 }
@@ -62,6 +76,7 @@ func StartSession(opts SessionOpts) (*ExecSession, error) {
 		Command:   opts.Command,
 		Cwd:       opts.Cwd,
 		TTY:       opts.TTY,
+		Resident:  opts.Resident,
 		StartedAt: time.Now(),
 		LastUsed:  time.Now(),
 		outputBuf: NewHeadTailBuffer(DefaultMaxBytes),
@@ -84,7 +99,7 @@ func StartSession(opts SessionOpts) (*ExecSession, error) {
 			return nil, err
 		}
 	} else {
-		if err := s.startPipes(cmd); err != nil {
+		if err := s.startPipes(cmd, opts.Stdin); err != nil {
 			return nil, err
 		}
 	}
@@ -95,20 +110,60 @@ func StartSession(opts SessionOpts) (*ExecSession, error) {
 	return s, nil
 }
 
-func (s *ExecSession) startPTY(cmd *exec.Cmd) error {
-	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: 24, Cols: 80})
-	if err != nil {
-		return err
+// Process is a process the session did not start itself, such as a command
+// running on a remote host.
+type Process interface {
+	Stdin() io.WriteCloser // nil when the process takes no input
+	Stdout() io.Reader
+	Stderr() io.Reader
+	Wait() int // Waits for exit and returns the exit code
+	Interrupt() error
+	Kill() error
+}
+
+// StartProcessSession wraps an already started process in a session.
+// opts.Command and opts.Cwd are recorded for display; opts.Env is ignored.
+func StartProcessSession(opts SessionOpts, p Process) *ExecSession {
+	s := &ExecSession{
+		ProcessID: opts.ProcessID,
+		Command:   opts.Command,
+		Cwd:       opts.Cwd,
+		TTY:       opts.TTY,
+		Resident:  opts.Resident,
+		StartedAt: time.Now(),
+		LastUsed:  time.Now(),
+		outputBuf: NewHeadTailBuffer(DefaultMaxBytes),
+		exitCh:    make(chan struct{}),
+		remote:    p,
 	}
-	s.ptyFile = ptmx
+	s.exitCode.Store(-1)
 
-	// Background reader: PTY combines stdout+stderr.
-	s.readerWg.Add(1)
-	go s.readLoop(ptmx)
-	return nil
+	if stdin := p.Stdin(); stdin != nil {
+		if opts.TTY {
+			s.pty = stdin
+		} else {
+			s.stdinPipe = stdin
+		}
+	}
+	s.wait = func() int {
+		s.readerWg.Wait()
+		return p.Wait()
+	}
+	s.readerWg.Add(2)
+	go s.readLoop(p.Stdout())
+	go s.readLoop(p.Stderr())
+	go s.waitForExit()
+	return s
 }
 
-func (s *ExecSession) startPipes(cmd *exec.Cmd) error {
+func (s *ExecSession) startPipes(cmd *exec.Cmd, stdin bool) error {
+	if stdin {
+		w, err := cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		s.stdinPipe = w
+	}
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
@@ -121,6 +176,8 @@ func (s *ExecSession) startPipes(cmd *exec.Cmd) error {
 	if err := cmd.Start(); err != nil {
 		return err
 	}
+	s.proc = cmd.Process
+	s.wait = s.waitCmd
 
 	// Background readers: separate stdout and stderr.
 	s.readerWg.Add(2)
@@ -144,6 +201,14 @@ func (s *ExecSession) readLoop(r io.Reader) {
 }
 
 func (s *ExecSession) waitForExit() {
+	code := s.wait()
+	s.exitCode.Store(int32(code))
+	s.exited.Store(true)
+	close(s.exitCh)
+}
+
+// waitCmd waits for a process started through s.cmd.
+func (s *ExecSession) waitCmd() int {
 	// Wait for read goroutines to drain all output BEFORE calling cmd.Wait().
 	// cmd.Wait() closes pipe read ends (see os/exec.Cmd.StdoutPipe docs:
 	// "It is thus incorrect to call Wait before all reads from the pipe
@@ -153,39 +218,62 @@ func (s *ExecSession) waitForExit() {
 	s.readerWg.Wait()
 	err := s.cmd.Wait()
 
-	code := -1
 	if err == nil {
-		code = 0
-	} else {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			code = exitErr.ExitCode()
-		}
+		return 0
 	}
-	s.exitCode.Store(int32(code))
-	s.exited.Store(true)
-	close(s.exitCh)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
 }
 
-// WriteStdin sends data to the process's stdin. Only supported in TTY mode.
+// WriteStdin sends data to the process's stdin. Supported in TTY mode and
+// in pipe mode started with SessionOpts.Stdin.
 func (s *ExecSession) WriteStdin(data []byte) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	w := s.pty
 	if !s.TTY {
-		return ErrStdinClosed
+		w = s.stdinPipe
 	}
-	if s.ptyFile == nil {
+	if w == nil {
 		return ErrStdinClosed
 	}
-	_, err := s.ptyFile.Write(data)
+	_, err := w.Write(data)
 	return err
 }
 
+// Interrupt sends the process an interrupt (SIGINT). Not supported on
+// Windows.
+func (s *ExecSession) Interrupt() error {
+	if s.remote != nil {
+		return s.remote.Interrupt()
+	}
+	if s.proc == nil {
+		return errors.New("process not started")
+	}
+	return s.proc.Signal(os.Interrupt)
+}
+
+// DrainOutput removes and returns the retained output, so the next read
+// sees only output produced after this call.
+func (s *ExecSession) DrainOutput() []byte {
+	return bytes.Join(s.outputBuf.DrainChunks(), nil)
+}
+
 // CollectOutput waits until the deadline for new output, returning whatever
 // has been produced. If heartbeat is non-nil, it is called periodically
 // during the wait (roughly every 5 seconds).
 func (s *ExecSession) CollectOutput(deadline time.Time, heartbeat func(details ...interface{})) []byte {
+	return s.CollectOutputContext(context.Background(), deadline, heartbeat)
+}
+
+// CollectOutputContext is CollectOutput, but also stops waiting when ctx is
+// done (e.g. the tool activity was cancelled). The process keeps running;
+// callers decide whether to Close it.
+func (s *ExecSession) CollectOutputContext(ctx context.Context, deadline time.Time, heartbeat func(details ...interface{})) []byte {
 	mark := s.outputBuf.TotalWritten()
 	var collected []byte
 	heartbeatInterval := 5 * time.Second
@@ -193,7 +281,7 @@ func (s *ExecSession) CollectOutput(deadline time.Time, heartbeat func(details .
 
 	for {
 		now := time.Now()
-		if now.After(deadline) {
+		if now.After(deadline) || ctx.Err() != nil {
 			break
 		}
 
@@ -240,6 +328,17 @@ func (s *ExecSession) CollectOutput(deadline time.Time, heartbeat func(details .
 	return collected
 }
 
+// OutputSize returns the total number of output bytes produced so far,
+// including bytes dropped from the retained buffer.
+func (s *ExecSession) OutputSize() int {
+	return s.outputBuf.TotalWritten()
+}
+
+// OutputSnapshot returns the retained output (head and tail).
+func (s *ExecSession) OutputSnapshot() []byte {
+	return s.outputBuf.Snapshot()
+}
+
 // HasExited returns true if the process has terminated.
 func (s *ExecSession) HasExited() bool {
 	return s.exited.Load()
@@ -254,13 +353,47 @@ func (s *ExecSession) ExitCode() *int {
 	return &code
 }
 
+// StopAfter closes the session if its process is still running after d,
+// marking it timed out.
+func (s *ExecSession) StopAfter(d time.Duration) {
+	s.mu.Lock()
+	s.timeout = d
+	s.mu.Unlock()
+	timer := time.AfterFunc(d, func() {
+		if s.exited.Load() {
+			return
+		}
+		s.timedOut.Store(true)
+		s.Close()
+	})
+	go func() {
+		<-s.exitCh
+		timer.Stop()
+	}()
+}
+
+// Timeout returns the duration passed to StopAfter, if it was called.
+func (s *ExecSession) Timeout() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timeout, s.timeout > 0
+}
+
+// TimedOut reports whether StopAfter stopped the process.
+func (s *ExecSession) TimedOut() bool {
+	return s.timedOut.Load()
+}
+
 // Close terminates the process and cleans up resources.
 func (s *ExecSession) Close() {
-	if s.cmd != nil && s.cmd.Process != nil {
-		_ = s.cmd.Process.Kill()
+	if s.remote != nil {
+		_ = s.remote.Kill()
+	}
+	if s.proc != nil {
+		_ = s.proc.Kill()
 	}
-	if s.ptyFile != nil {
-		_ = s.ptyFile.Close()
+	if s.pty != nil {
+		_ = s.pty.Close()
 	}
 	if s.stdinPipe != nil {
 		_ = s.stdinPipe.Close()
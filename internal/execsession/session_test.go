@@ -1,6 +1,8 @@
 package execsession
 
 import (
+	"context"
+	"io"
 	"runtime"
 	"testing"
 	"time"
@@ -101,6 +103,41 @@ func TestWriteStdin_PipeMode_Rejected(t *testing.T) {
 	assert.ErrorIs(t, err, ErrStdinClosed)
 }
 
+func TestWriteStdin_PipeMode_WithStdin(t *testing.T) {
+	s, err := StartSession(SessionOpts{
+		ProcessID: "1006",
+		Command:   []string{"cat"},
+		Stdin:     true,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.WriteStdin([]byte("first\n")))
+	output := s.CollectOutput(time.Now().Add(500*time.Millisecond), nil)
+	assert.Equal(t, "first\n", string(output))
+
+	assert.Equal(t, "first\n", string(s.DrainOutput()))
+	require.NoError(t, s.WriteStdin([]byte("second\n")))
+	output = s.CollectOutput(time.Now().Add(500*time.Millisecond), nil)
+	assert.Equal(t, "second\n", string(output), "drained output is not returned again")
+}
+
+func TestInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupt is not supported on Windows")
+	}
+	s, err := StartSession(SessionOpts{
+		ProcessID: "1007",
+		Command:   []string{"sleep", "60"},
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Interrupt())
+	s.CollectOutput(time.Now().Add(5*time.Second), nil)
+	assert.True(t, s.HasExited())
+}
+
 func TestWriteStdin_PTYMode_Interactive(t *testing.T) {
 	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
 		t.Skip("PTY tests require Linux or macOS")
@@ -148,6 +185,24 @@ func TestCollectOutput_HeartbeatCalled(t *testing.T) {
 	assert.GreaterOrEqual(t, heartbeatCount, 1, "heartbeat should have been called at least once")
 }
 
+func TestStopAfter(t *testing.T) {
+	s, err := StartSession(SessionOpts{
+		ProcessID: "1010",
+		Command:   []string{"sleep", "10"},
+		TTY:       false,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	s.StopAfter(200 * time.Millisecond)
+	s.CollectOutput(time.Now().Add(5*time.Second), nil)
+	assert.True(t, s.HasExited())
+	assert.True(t, s.TimedOut())
+	limit, ok := s.Timeout()
+	assert.True(t, ok)
+	assert.Equal(t, 200*time.Millisecond, limit)
+}
+
 func TestStartSession_EmptyCommand(t *testing.T) {
 	_, err := StartSession(SessionOpts{
 		ProcessID: "1008",
@@ -177,3 +232,75 @@ func TestExitCode_NilWhileRunning(t *testing.T) {
 	assert.Nil(t, s.ExitCode())
 	assert.False(t, s.HasExited())
 }
+
+func TestCollectOutputContext_StopsOnCancel(t *testing.T) {
+	s, err := StartSession(SessionOpts{
+		ProcessID: "1010",
+		Command:   []string{"sh", "-c", "echo started; sleep 30"},
+		TTY:       false,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(300*time.Millisecond, cancel)
+
+	start := time.Now()
+	output := s.CollectOutputContext(ctx, time.Now().Add(20*time.Second), nil)
+
+	assert.Less(t, time.Since(start), 5*time.Second, "should stop waiting when ctx is cancelled")
+	assert.Contains(t, string(output), "started")
+	assert.False(t, s.HasExited(), "the process is left for the caller to close")
+}
+
+// echoProcess is a Process that echoes its input until stdin is closed,
+// then exits with code 3.
+type echoProcess struct {
+	stdinR *io.PipeReader
+	stdinW *io.PipeWriter
+	outR   *io.PipeReader
+	outW   *io.PipeWriter
+	errR   *io.PipeReader
+	errW   *io.PipeWriter
+	done   chan struct{}
+}
+
+func newEchoProcess() *echoProcess {
+	p := &echoProcess{done: make(chan struct{})}
+	p.stdinR, p.stdinW = io.Pipe()
+	p.outR, p.outW = io.Pipe()
+	p.errR, p.errW = io.Pipe()
+	go func() {
+		_, _ = io.Copy(p.outW, p.stdinR)
+		p.outW.Close()
+		p.errW.Close()
+		close(p.done)
+	}()
+	return p
+}
+
+func (p *echoProcess) Stdin() io.WriteCloser { return p.stdinW }
+func (p *echoProcess) Stdout() io.Reader     { return p.outR }
+func (p *echoProcess) Stderr() io.Reader     { return p.errR }
+func (p *echoProcess) Wait() int             { <-p.done; return 3 }
+func (p *echoProcess) Interrupt() error      { return nil }
+func (p *echoProcess) Kill() error           { return p.stdinR.Close() }
+
+func TestStartProcessSession(t *testing.T) {
+	s := StartProcessSession(SessionOpts{
+		ProcessID: "1020",
+		Command:   []string{"remote"},
+		Stdin:     true,
+	}, newEchoProcess())
+	defer s.Close()
+
+	require.NoError(t, s.WriteStdin([]byte("ping\n")))
+	output := s.CollectOutput(time.Now().Add(200*time.Millisecond), nil)
+	assert.Equal(t, "ping\n", string(output))
+	assert.False(t, s.HasExited())
+
+	s.Close()
+	s.CollectOutput(time.Now().Add(5*time.Second), nil)
+	require.True(t, s.HasExited())
+	assert.Equal(t, 3, *s.ExitCode())
+}
@@ -29,6 +29,8 @@ type Store struct {
 	mu       sync.Mutex
 	sessions map[string]*ExecSession
 	reserved map[string]bool
+	lost     map[string]LostSession // Sessions that died with a previous worker
+	draining bool
 }
 
 // NewStore creates a new empty session store.
@@ -36,6 +38,7 @@ func NewStore() *Store {
 	return &Store{
 		sessions: make(map[string]*ExecSession),
 		reserved: make(map[string]bool),
+		lost:     make(map[string]LostSession),
 	}
 }
 
@@ -73,6 +76,9 @@ func (s *Store) Get(processID string) (*ExecSession, error) {
 
 	sess, ok := s.sessions[processID]
 	if !ok {
+		if _, wasLost := s.lost[processID]; wasLost {
+			return nil, fmt.Errorf("%w: %s", ErrSessionLost, processID)
+		}
 		return nil, fmt.Errorf("%w: %s", ErrUnknownProcessID, processID)
 	}
 	return sess, nil
@@ -117,6 +123,7 @@ func (s *Store) ListAll() []SessionSummary {
 			Cwd:       sess.Cwd,
 			StartedAt: sess.StartedAt,
 			Exited:    sess.HasExited(),
+			Resident:  sess.Resident,
 		}
 		if code := sess.ExitCode(); code != nil {
 			sum.ExitCode = *code
@@ -148,6 +155,7 @@ type SessionSummary struct {
 	StartedAt time.Time
 	Exited    bool
 	ExitCode  int
+	Resident  bool
 }
 
 // pruneOneLocked evicts the least recently used session, preferring exited
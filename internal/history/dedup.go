@@ -0,0 +1,162 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
+)
+
+// Tool outputs are deduplicated by content: agents re-read the same files
+// and re-run the same commands, and each identical output would otherwise
+// be stored (and carried through ContinueAsNew) in full. The first output
+// keeps the content; later identical ones become references to its CallID
+// (FunctionCallOutputPayload.DuplicateOf) and are expanded again by
+// GetForPrompt while the prompt stays within the budget.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+
+// MinDedupBytes is the smallest output that is deduplicated; a reference
+// would save little on anything shorter.
+const MinDedupBytes = 512
+
+// DuplicateOutputStub is the content of an output stored as a reference to
+// the earlier output of callID.
+func DuplicateOutputStub(callID string) string {
+	return fmt.Sprintf("[same output as call_id=%s above]", callID)
+}
+
+func outputDigest(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// isOriginalOutput reports whether item is a tool output that stores its
+// content in full and is large enough to be referenced.
+func isOriginalOutput(item models.ConversationItem) bool {
+	return item.Type == models.ItemTypeFunctionCallOutput && item.Output != nil && item.CallID != "" &&
+		item.Output.DuplicateOf == "" && len(item.Output.Content) >= MinDedupBytes
+}
+
+// dedupOutput turns item into a reference when an earlier output had the
+// same content, and otherwise indexes it. Outputs that already are
+// references (restored after ContinueAsNew) are kept as they are. Caller
+// holds h.mu.
+func (h *InMemoryHistory) dedupOutput(item *models.ConversationItem) {
+	if !isOriginalOutput(*item) {
+		return
+	}
+	if h.outputs == nil {
+		h.outputs = map[string]string{}
+	}
+	digest := outputDigest(item.Output.Content)
+	orig, ok := h.outputs[digest]
+	if !ok || orig == item.CallID {
+		h.outputs[digest] = item.CallID
+		return
+	}
+	// Copy the payload: the caller may still hold the pointer.
+	ref := *item.Output
+	ref.Content = DuplicateOutputStub(orig)
+	ref.DuplicateOf = orig
+	item.Output = &ref
+}
+
+// originalContents returns the content of every output that references may
+// point at, by CallID. Caller holds h.mu.
+func (h *InMemoryHistory) originalContents() map[string]string {
+	contents := map[string]string{}
+	for _, item := range h.items {
+		if isOriginalOutput(item) {
+			contents[item.CallID] = item.Output.Content
+		}
+	}
+	return contents
+}
+
+// reattach repairs references after outputs were elided, dropped or
+// replaced. contents is originalContents from before the change. For each
+// original whose content is gone, its first remaining reference takes the
+// content back and later references point at that one instead. Rebuilds
+// the digest index. Caller holds h.mu.
+func (h *InMemoryHistory) reattach(contents map[string]string) {
+	present := map[string]bool{}
+	for _, item := range h.items {
+		if isOriginalOutput(item) && item.Output.Content == contents[item.CallID] {
+			present[item.CallID] = true
+		}
+	}
+
+	moved := map[string]string{} // Lost original -> CallID now holding its content
+	for i := range h.items {
+		item := &h.items[i]
+		if item.Output == nil || item.Output.DuplicateOf == "" || present[item.Output.DuplicateOf] {
+			continue
+		}
+		orig := item.Output.DuplicateOf
+		output := *item.Output
+		if to, ok := moved[orig]; ok {
+			output.DuplicateOf = to
+			output.Content = DuplicateOutputStub(to)
+		} else if content, ok := contents[orig]; ok {
+			output.DuplicateOf = ""
+			output.Content = content
+			moved[orig] = item.CallID
+		} else {
+			continue // Content unknown; the reference stays a stub
+		}
+		item.Output = &output
+	}
+
+	h.outputs = map[string]string{}
+	for _, item := range h.items {
+		if isOriginalOutput(item) {
+			digest := outputDigest(item.Output.Content)
+			if _, ok := h.outputs[digest]; !ok {
+				h.outputs[digest] = item.CallID
+			}
+		}
+	}
+}
+
+// expandDuplicates replaces references in items (a copy of history) with
+// the content they stand for, newest first, while the prompt's estimated
+// size stays within h.budget. Caller holds h.mu.
+func (h *InMemoryHistory) expandDuplicates(items []models.ConversationItem) {
+	var refs []int
+	for i, item := range items {
+		if item.Output != nil && item.Output.DuplicateOf != "" {
+			refs = append(refs, i)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	contents := h.originalContents()
+	counter := h.tokenCounter()
+	remaining := 0
+	if h.budget > 0 {
+		remaining = h.budget - tokenizer.CountItems(counter, items)
+	}
+	for k := len(refs) - 1; k >= 0; k-- {
+		item := &items[refs[k]]
+		content, ok := contents[item.Output.DuplicateOf]
+		if !ok {
+			continue
+		}
+		if h.budget > 0 {
+			extra := counter.Count(content) - counter.Count(item.Output.Content)
+			if extra > remaining {
+				continue
+			}
+			remaining -= extra
+		}
+		output := *item.Output
+		output.Content = content
+		output.DuplicateOf = ""
+		item.Output = &output
+	}
+}
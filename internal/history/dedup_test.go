@@ -0,0 +1,128 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+var fileContent = strings.Repeat("package main\n", 100)
+
+func addToolOutput(h *InMemoryHistory, callID, content string) {
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCall, CallID: callID, Name: "read_file"})
+	h.AddItem(models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{Content: content},
+	})
+}
+
+// outputsByCall returns each output's content and DuplicateOf by CallID.
+func outputsByCall(items []models.ConversationItem) map[string][2]string {
+	m := map[string][2]string{}
+	for _, item := range items {
+		if item.Type == models.ItemTypeFunctionCallOutput {
+			m[item.CallID] = [2]string{item.Output.Content, item.Output.DuplicateOf}
+		}
+	}
+	return m
+}
+
+func TestDedup_IdenticalOutputsStoredOnce(t *testing.T) {
+	h := NewInMemoryHistory()
+	addToolOutput(h, "c1", fileContent)
+	addToolOutput(h, "c2", fileContent)
+	addToolOutput(h, "c3", "short")
+	addToolOutput(h, "c4", "short")
+
+	raw, _ := h.GetRawItems()
+	outputs := outputsByCall(raw)
+	assert.Equal(t, [2]string{fileContent, ""}, outputs["c1"])
+	assert.Equal(t, [2]string{DuplicateOutputStub("c1"), "c1"}, outputs["c2"])
+	assert.Equal(t, [2]string{"short", ""}, outputs["c4"], "short outputs are not deduplicated")
+
+	prompt, _ := h.GetForPrompt()
+	assert.Equal(t, [2]string{fileContent, ""}, outputsByCall(prompt)["c2"], "expanded without a budget")
+
+	raw, _ = h.GetRawItems()
+	assert.Equal(t, "c1", outputsByCall(raw)["c2"][1], "expansion does not change history")
+}
+
+func TestDedup_ExpansionRespectsBudget(t *testing.T) {
+	h := NewInMemoryHistory()
+	addToolOutput(h, "c1", fileContent)
+	addToolOutput(h, "c2", fileContent)
+	addToolOutput(h, "c3", fileContent)
+	stored, _ := h.EstimateTokenCount()
+	perCopy := len(fileContent) / 4
+
+	h.SetPromptBudget(stored + perCopy + 10)
+	prompt, _ := h.GetForPrompt()
+	outputs := outputsByCall(prompt)
+	assert.Equal(t, fileContent, outputs["c3"][0], "the newest reference is expanded first")
+	assert.Equal(t, DuplicateOutputStub("c1"), outputs["c2"][0], "no budget left for the second")
+
+	h.SetPromptBudget(stored)
+	prompt, _ = h.GetForPrompt()
+	assert.Equal(t, DuplicateOutputStub("c1"), outputsByCall(prompt)["c3"][0])
+}
+
+func TestDedup_ElidedOriginalHandsContentToReference(t *testing.T) {
+	h := NewInMemoryHistory()
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "one"})
+	addToolOutput(h, "c1", fileContent)
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "two"})
+	addToolOutput(h, "c2", fileContent)
+	addToolOutput(h, "c3", fileContent)
+
+	elided, err := h.ElideToolOutputs(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, elided)
+
+	raw, _ := h.GetRawItems()
+	outputs := outputsByCall(raw)
+	assert.Equal(t, [2]string{ElidedOutputStub("c1"), ""}, outputs["c1"])
+	assert.Equal(t, [2]string{fileContent, ""}, outputs["c2"])
+	assert.Equal(t, [2]string{DuplicateOutputStub("c2"), "c2"}, outputs["c3"])
+
+	addToolOutput(h, "c4", fileContent)
+	raw, _ = h.GetRawItems()
+	assert.Equal(t, "c2", outputsByCall(raw)["c4"][1], "new duplicates reference the new original")
+}
+
+func TestDedup_ReplaceAllKeepsReferencesResolvable(t *testing.T) {
+	h := NewInMemoryHistory()
+	addToolOutput(h, "c1", fileContent)
+	addToolOutput(h, "c2", fileContent)
+
+	raw, _ := h.GetRawItems()
+	require.NoError(t, h.ReplaceAll(raw[2:])) // Compaction kept only c2
+
+	raw, _ = h.GetRawItems()
+	assert.Equal(t, [2]string{fileContent, ""}, outputsByCall(raw)["c2"])
+}
+
+func TestDedup_RestoredHistoryKeepsReferences(t *testing.T) {
+	h := NewInMemoryHistory()
+	addToolOutput(h, "c1", fileContent)
+	addToolOutput(h, "c2", fileContent)
+	raw, _ := h.GetRawItems()
+
+	// As initHistory does after ContinueAsNew.
+	restored := NewInMemoryHistory()
+	for _, item := range raw {
+		restored.AddItem(item)
+	}
+	addToolOutput(restored, "c3", fileContent)
+
+	items, _ := restored.GetRawItems()
+	outputs := outputsByCall(items)
+	assert.Equal(t, "c1", outputs["c2"][1])
+	assert.Equal(t, "c1", outputs["c3"][1])
+	prompt, _ := restored.GetForPrompt()
+	assert.Equal(t, fileContent, outputsByCall(prompt)["c2"][0])
+}
@@ -3,7 +3,12 @@
 // Corresponds to: codex-rs/core/src/state/session.rs (ContextManager)
 package history
 
-import "github.com/mfateev/temporal-agent-harness/internal/models"
+import (
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
+)
 
 // ContextManager is the interface for managing conversation history.
 //
@@ -26,6 +31,18 @@ type ContextManager interface {
 	// Maps to: codex-rs clone_history().estimate_token_count()
 	EstimateTokenCount() (int, error)
 
+	// SetTokenCounter sets the tokenizer used by EstimateTokenCount, e.g.
+	// after the session's model changes.
+	SetTokenCounter(c tokenizer.Counter)
+
+	// SetClock sets the clock that stamps CreatedAt on items added without
+	// one; in a workflow it must be workflow.Now.
+	SetClock(now func() time.Time)
+
+	// SetPromptBudget sets the token budget within which GetForPrompt
+	// expands tool outputs stored once as duplicates. 0 expands them all.
+	SetPromptBudget(tokens int)
+
 	// Admin operations
 
 	// DropLastNUserTurns removes the last N user turns from history (for undo)
@@ -33,19 +50,34 @@ type ContextManager interface {
 	DropLastNUserTurns(n int) error
 
 	// DropOldestUserTurns keeps only the last keepN user turns and removes
-	// everything before them. Used for context compaction before ContinueAsNew.
-	// Returns the number of items dropped.
+	// everything before them, except pinned items. Used for context
+	// compaction before ContinueAsNew. Returns the number of items dropped.
 	DropOldestUserTurns(keepN int) (int, error)
 
 	// GetRawItems returns raw conversation items for analysis
 	// Maps to: codex-rs clone_history().raw_items()
 	GetRawItems() ([]models.ConversationItem, error)
 
+	// ElideToolOutputs replaces the content of tool outputs older than the
+	// last keepN user turns with a short stub naming the call. Calls,
+	// messages and pinned items are kept. Returns the number of outputs
+	// elided by this call.
+	ElideToolOutputs(keepN int) (int, error)
+
+	// DropAttachmentData removes the inline data of tool output attachments
+	// added before the last user message, keeping their metadata. Returns
+	// the number of outputs changed.
+	DropAttachmentData() (int, error)
+
 	// ReplaceAll replaces all history items with the given items.
 	// Used after compaction to swap in the compacted history.
 	// Re-assigns Seq numbers starting from 0.
 	ReplaceAll(items []models.ConversationItem) error
 
+	// SetPinned pins or unpins the item at seq. Pinning a function call or
+	// its output applies to both, so the pair is never split.
+	SetPinned(seq int, pinned bool) error
+
 	// Query operations
 
 	// GetTurnCount returns the number of user turns
@@ -3,61 +3,131 @@ package history
 import (
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
 )
 
 // InMemoryHistory is a simple in-memory implementation of ContextManager.
 //
 // Maps to: codex-rs/core/src/state/session.rs SessionState history field
 type InMemoryHistory struct {
-	items []models.ConversationItem
-	mu    sync.RWMutex
+	items   []models.ConversationItem
+	counter tokenizer.Counter // nil = tokenizer.Heuristic
+	budget  int               // Prompt tokens for expanding duplicate outputs; 0 = unlimited
+	outputs map[string]string // Content digest -> CallID of the output storing it (dedup.go)
+	now     func() time.Time  // Stamps CreatedAt; nil leaves it unset
+	mu      sync.RWMutex
 }
 
 // NewInMemoryHistory creates a new in-memory history.
 func NewInMemoryHistory() *InMemoryHistory {
 	return &InMemoryHistory{
-		items: make([]models.ConversationItem, 0),
+		items:   make([]models.ConversationItem, 0),
+		outputs: map[string]string{},
 	}
 }
 
 // AddItem adds a new conversation item to history.
-// Assigns a monotonically increasing Seq number before appending.
+// Assigns a monotonically increasing Seq number before appending. A tool
+// output identical to an earlier one is stored as a reference to it.
 func (h *InMemoryHistory) AddItem(item models.ConversationItem) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.dedupOutput(&item)
 	item.Seq = len(h.items)
+	if item.CreatedAt.IsZero() && h.now != nil {
+		item.CreatedAt = h.now()
+	}
 	h.items = append(h.items, item)
 	return nil
 }
 
 // GetForPrompt returns conversation items formatted for LLM prompt.
+// Attachment data is left out: it is display-only and would only inflate
+// the LLM activity's input. Deduplicated tool outputs are expanded while the
+// prompt fits the budget set by SetPromptBudget.
 func (h *InMemoryHistory) GetForPrompt() ([]models.ConversationItem, error) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	result := make([]models.ConversationItem, len(h.items))
 	copy(result, h.items)
+	for i := range result {
+		if output, ok := withoutAttachmentData(result[i].Output); ok {
+			result[i].Output = output
+		}
+	}
+	h.expandDuplicates(result)
 	return result, nil
 }
 
-// EstimateTokenCount estimates the total token count using a simple heuristic.
-// Uses 4 characters per token as a rough estimate.
+// withoutAttachmentData returns a copy of output whose attachments have no
+// inline data, or false when none had any.
+func withoutAttachmentData(output *models.FunctionCallOutputPayload) (*models.FunctionCallOutputPayload, bool) {
+	if output == nil {
+		return nil, false
+	}
+	hasData := false
+	for _, a := range output.Attachments {
+		if a.Data != nil {
+			hasData = true
+			break
+		}
+	}
+	if !hasData {
+		return nil, false
+	}
+	// Copy the payload and slice: earlier GetRawItems results share them.
+	stripped := *output
+	stripped.Attachments = make([]attachment.Attachment, len(output.Attachments))
+	for i, a := range output.Attachments {
+		a.Data = nil
+		stripped.Attachments[i] = a
+	}
+	return &stripped, true
+}
+
+// SetTokenCounter sets the counter used by EstimateTokenCount.
+func (h *InMemoryHistory) SetTokenCounter(c tokenizer.Counter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counter = c
+}
+
+// SetClock sets the clock that stamps CreatedAt on added items that have
+// none.
+func (h *InMemoryHistory) SetClock(now func() time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.now = now
+}
+
+// SetPromptBudget sets the token budget within which GetForPrompt expands
+// deduplicated tool outputs. 0 expands them all.
+func (h *InMemoryHistory) SetPromptBudget(tokens int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.budget = tokens
+}
+
+// EstimateTokenCount estimates the total token count with the configured
+// counter, or 4 characters per token if none is set. Deduplicated outputs
+// count as their references.
 func (h *InMemoryHistory) EstimateTokenCount() (int, error) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	return tokenizer.CountItems(h.tokenCounter(), h.items), nil
+}
 
-	totalChars := 0
-	for _, item := range h.items {
-		totalChars += len(item.Content)
-		totalChars += len(item.Name)
-		totalChars += len(item.Arguments)
-		if item.Output != nil {
-			totalChars += len(item.Output.Content)
-		}
+// tokenCounter returns the configured counter or the heuristic. Caller
+// holds h.mu.
+func (h *InMemoryHistory) tokenCounter() tokenizer.Counter {
+	if h.counter != nil {
+		return h.counter
 	}
-
-	return totalChars / 4, nil
+	return tokenizer.Heuristic{}
 }
 
 // DropLastNUserTurns removes the last N user turns from history.
@@ -86,13 +156,16 @@ func (h *InMemoryHistory) DropLastNUserTurns(n int) error {
 		return fmt.Errorf("only %d user turns found, cannot drop %d", userTurnsFound, n)
 	}
 
+	contents := h.originalContents()
 	h.items = h.items[:cutIndex]
+	h.reattach(contents)
 	return nil
 }
 
 // DropOldestUserTurns keeps only the last keepN user turns and their
-// associated items. Everything before the Nth-from-last user message is removed.
-// Returns the number of items dropped.
+// associated items. Everything before the Nth-from-last user message is
+// removed, except pinned items, which are kept in order ahead of the
+// remaining turns. Returns the number of items dropped.
 func (h *InMemoryHistory) DropOldestUserTurns(keepN int) (int, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -122,11 +195,99 @@ func (h *InMemoryHistory) DropOldestUserTurns(keepN int) (int, error) {
 		return 0, nil // nothing to drop
 	}
 
-	dropped := cutIndex
-	h.items = h.items[cutIndex:]
+	contents := h.originalContents()
+	kept := make([]models.ConversationItem, 0, len(h.items)-cutIndex)
+	for _, item := range h.items[:cutIndex] {
+		if item.Pinned {
+			kept = append(kept, item)
+		}
+	}
+	dropped := cutIndex - len(kept)
+	h.items = append(kept, h.items[cutIndex:]...)
 	// Re-assign Seq numbers
 	for i := range h.items {
 		h.items[i].Seq = i
+		if h.items[i].CreatedAt.IsZero() && h.now != nil {
+			h.items[i].CreatedAt = h.now()
+		}
+	}
+	h.reattach(contents)
+	return dropped, nil
+}
+
+// ElidedOutputStub is the content that replaces an elided tool output.
+func ElidedOutputStub(callID string) string {
+	return fmt.Sprintf("[output elided, call_id=%s]", callID)
+}
+
+// ElideToolOutputs replaces the content of tool outputs before the
+// Nth-from-last user message with ElidedOutputStub. Outputs no longer than
+// their stub, including ones already elided, are left alone. Seq numbers are
+// unchanged. References to an elided output are repaired: the first one
+// kept takes over its content. Returns the number of outputs elided.
+func (h *InMemoryHistory) ElideToolOutputs(keepN int) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if keepN <= 0 {
+		return 0, nil
+	}
+
+	userCount := 0
+	cutIndex := 0
+	for i := len(h.items) - 1; i >= 0; i-- {
+		if h.items[i].Type == models.ItemTypeUserMessage {
+			userCount++
+			if userCount == keepN {
+				cutIndex = i
+				break
+			}
+		}
+	}
+
+	contents := h.originalContents()
+	elided := 0
+	for i := 0; i < cutIndex; i++ {
+		item := &h.items[i]
+		if item.Type != models.ItemTypeFunctionCallOutput || item.Output == nil || item.Pinned {
+			continue
+		}
+		stub := ElidedOutputStub(item.CallID)
+		if len(item.Output.Content) <= len(stub) && item.Output.DuplicateOf == "" {
+			continue
+		}
+		// Copy the payload: earlier GetRawItems results share the pointer.
+		output := *item.Output
+		output.Content = stub
+		output.DuplicateOf = ""
+		item.Output = &output
+		elided++
+	}
+	h.reattach(contents)
+	return elided, nil
+}
+
+// DropAttachmentData removes the inline data of attachments on tool
+// outputs before the last user message. Seq numbers are unchanged.
+// Returns the number of outputs changed.
+func (h *InMemoryHistory) DropAttachmentData() (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutIndex := 0
+	for i := len(h.items) - 1; i >= 0; i-- {
+		if h.items[i].Type == models.ItemTypeUserMessage {
+			cutIndex = i
+			break
+		}
+	}
+
+	dropped := 0
+	for i := 0; i < cutIndex; i++ {
+		if output, ok := withoutAttachmentData(h.items[i].Output); ok {
+			h.items[i].Output = output
+			dropped++
+		}
 	}
 	return dropped, nil
 }
@@ -137,11 +298,39 @@ func (h *InMemoryHistory) ReplaceAll(items []models.ConversationItem) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	contents := h.originalContents()
 	h.items = make([]models.ConversationItem, len(items))
 	copy(h.items, items)
 	for i := range h.items {
 		h.items[i].Seq = i
 	}
+	h.reattach(contents)
+	return nil
+}
+
+// SetPinned pins or unpins the item at seq. A function call and its output
+// share a CallID and are pinned together.
+func (h *InMemoryHistory) SetPinned(seq int, pinned bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if seq < 0 || seq >= len(h.items) {
+		return fmt.Errorf("no item #%d", seq)
+	}
+	item := h.items[seq]
+	if !item.Type.IsPinnable() {
+		return fmt.Errorf("item #%d (%s) cannot be pinned", seq, item.Type)
+	}
+	h.items[seq].Pinned = pinned
+	if item.CallID == "" || (item.Type != models.ItemTypeFunctionCall && item.Type != models.ItemTypeFunctionCallOutput) {
+		return nil
+	}
+	for i := range h.items {
+		if h.items[i].CallID == item.CallID &&
+			(h.items[i].Type == models.ItemTypeFunctionCall || h.items[i].Type == models.ItemTypeFunctionCallOutput) {
+			h.items[i].Pinned = pinned
+		}
+	}
 	return nil
 }
 
@@ -1,11 +1,14 @@
 package history
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 )
 
@@ -259,3 +262,142 @@ func TestGetLatestSeq_AfterReplaceAll(t *testing.T) {
 	})
 	assert.Equal(t, 0, h.GetLatestSeq())
 }
+
+func TestDropOldestUserTurns_KeepsPinned(t *testing.T) {
+	h := buildHistory(3) // 12 items
+	// Pin the first user message; it must outlive its turn.
+	require.NoError(t, h.SetPinned(1, true))
+
+	dropped, err := h.DropOldestUserTurns(1)
+	require.NoError(t, err)
+	assert.Equal(t, 7, dropped)
+
+	items, _ := h.GetRawItems()
+	require.Len(t, items, 5)
+	assert.Equal(t, models.ItemTypeUserMessage, items[0].Type)
+	assert.True(t, items[0].Pinned)
+	assert.Equal(t, models.ItemTypeTurnStarted, items[1].Type)
+	for i, item := range items {
+		assert.Equal(t, i, item.Seq)
+	}
+}
+
+func TestSetPinned(t *testing.T) {
+	h := NewInMemoryHistory()
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeTurnStarted, TurnID: "turn"})
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "read_file"})
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCallOutput, CallID: "c1"})
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCall, CallID: "c2", Name: "read_file"})
+
+	// Pinning an output pins its call too.
+	require.NoError(t, h.SetPinned(2, true))
+	items, _ := h.GetRawItems()
+	assert.True(t, items[1].Pinned)
+	assert.True(t, items[2].Pinned)
+	assert.False(t, items[3].Pinned)
+
+	require.NoError(t, h.SetPinned(1, false))
+	items, _ = h.GetRawItems()
+	assert.False(t, items[1].Pinned)
+	assert.False(t, items[2].Pinned)
+
+	assert.Error(t, h.SetPinned(0, true), "turn markers cannot be pinned")
+	assert.Error(t, h.SetPinned(9, true))
+}
+
+func TestElideToolOutputs(t *testing.T) {
+	h := NewInMemoryHistory()
+	long := strings.Repeat("x", 200)
+	success := true
+	for _, callID := range []string{"c1", "c2", "c3"} {
+		h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "msg"})
+		h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCall, CallID: callID, Name: "shell"})
+		h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCallOutput, CallID: callID,
+			Output: &models.FunctionCallOutputPayload{Content: long, Success: &success}})
+		h.AddItem(models.ConversationItem{Type: models.ItemTypeAssistantMessage, Content: "reply " + callID})
+	}
+	before, _ := h.GetRawItems()
+
+	elided, err := h.ElideToolOutputs(2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, elided, "only the turn before the last two")
+
+	items, _ := h.GetRawItems()
+	require.Len(t, items, 12)
+	assert.Equal(t, "[output elided, call_id=c1]", items[2].Output.Content)
+	assert.True(t, *items[2].Output.Success)
+	assert.Equal(t, "reply c1", items[3].Content, "assistant text is kept")
+	assert.Equal(t, long, items[6].Output.Content)
+	assert.Equal(t, long, items[10].Output.Content)
+	assert.Equal(t, long, before[2].Output.Content, "earlier snapshots are not mutated")
+
+	elided, err = h.ElideToolOutputs(2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, elided, "already elided")
+
+	require.NoError(t, h.SetPinned(5, true))
+	elided, err = h.ElideToolOutputs(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, elided, "pinned output is kept")
+	items, _ = h.GetRawItems()
+	assert.Equal(t, long, items[6].Output.Content)
+}
+
+func TestElideToolOutputs_KeepsShortOutputs(t *testing.T) {
+	h := NewInMemoryHistory()
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "msg"})
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeFunctionCallOutput, CallID: "c1",
+		Output: &models.FunctionCallOutputPayload{Content: "ok"}})
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "msg"})
+
+	elided, err := h.ElideToolOutputs(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, elided)
+}
+
+func TestAttachmentData(t *testing.T) {
+	h := NewInMemoryHistory()
+	plot := func(callID string) models.ConversationItem {
+		return models.ConversationItem{Type: models.ItemTypeFunctionCallOutput, CallID: callID,
+			Output: &models.FunctionCallOutputPayload{Content: "ok", Attachments: []attachment.Attachment{
+				{Name: "plot.png", MimeType: "image/png", Size: 3, Data: []byte("png")},
+			}}}
+	}
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "plot a"})
+	h.AddItem(plot("c1"))
+	h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "plot b"})
+	h.AddItem(plot("c2"))
+	before, _ := h.GetRawItems()
+
+	prompt, err := h.GetForPrompt()
+	require.NoError(t, err)
+	assert.Nil(t, prompt[3].Output.Attachments[0].Data, "attachment data is not sent to the LLM")
+	assert.Equal(t, "plot.png", prompt[3].Output.Attachments[0].Name)
+
+	dropped, err := h.DropAttachmentData()
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped, "only outputs before the last user message")
+
+	items, _ := h.GetRawItems()
+	assert.Nil(t, items[1].Output.Attachments[0].Data)
+	assert.Equal(t, int64(3), items[1].Output.Attachments[0].Size)
+	assert.Equal(t, []byte("png"), items[3].Output.Attachments[0].Data)
+	assert.Equal(t, []byte("png"), before[1].Output.Attachments[0].Data, "earlier snapshots are not mutated")
+}
+
+func TestSetClock_StampsCreatedAt(t *testing.T) {
+	h := NewInMemoryHistory()
+	require.NoError(t, h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "before"}))
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	h.SetClock(func() time.Time { return now })
+	kept := now.Add(-time.Hour)
+	require.NoError(t, h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "after"}))
+	require.NoError(t, h.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "restored", CreatedAt: kept}))
+
+	items, err := h.GetRawItems()
+	require.NoError(t, err)
+	assert.True(t, items[0].CreatedAt.IsZero(), "items added without a clock have no time")
+	assert.Equal(t, now, items[1].CreatedAt)
+	assert.Equal(t, kept, items[2].CreatedAt, "an existing time is kept")
+}
@@ -0,0 +1,185 @@
+// Package hooks runs user-defined scripts at points in a session: before
+// and after each tool call, and when a turn completes. Scripts live in
+// <codex_home>/hooks, named after their event (pre_tool_use,
+// pre_tool_use.sh, ...). Each gets a JSON Payload on stdin. Exiting
+// non-zero with a message on stderr (or stdout) objects: a pre_tool_use
+// objection blocks the call, other objections are passed to the model as
+// feedback. A non-zero exit without a message is only logged.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+)
+
+// Event is a point in the session at which hooks run.
+type Event string
+
+const (
+	PreToolUse   Event = "pre_tool_use"  // Before a tool call runs; may block it
+	PostToolUse  Event = "post_tool_use" // After a tool call, with its output
+	TurnComplete Event = "turn_complete" // When the agent finishes a turn
+)
+
+// DefaultTimeout bounds a script run when no timeout is configured.
+const DefaultTimeout = 30 * time.Second
+
+// waitDelay bounds the wait for output after a timed-out script is killed.
+const waitDelay = 2 * time.Second
+
+// maxMessageBytes caps the objection message kept from a script's output.
+const maxMessageBytes = 4096
+
+// Payload is the JSON written to a hook's stdin.
+type Payload struct {
+	Event       Event     `json:"event"`
+	SessionID   string    `json:"session_id"`
+	TurnID      string    `json:"turn_id,omitempty"`
+	Cwd         string    `json:"cwd,omitempty"`
+	Tool        *ToolCall `json:"tool,omitempty"`         // pre_tool_use and post_tool_use
+	LastMessage string    `json:"last_message,omitempty"` // turn_complete: the agent's final message
+}
+
+// ToolCall describes the tool call a hook runs for.
+type ToolCall struct {
+	CallID    string          `json:"call_id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Output    *string         `json:"output,omitempty"`  // post_tool_use only
+	Success   *bool           `json:"success,omitempty"` // post_tool_use only
+}
+
+// Result is the outcome of running one script.
+type Result struct {
+	Script   string `json:"script"`
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message,omitempty"` // Set when the script objected
+	Error    string `json:"error,omitempty"`   // The script could not run or timed out
+}
+
+// Objected reports whether the script exited non-zero with a message.
+func (r Result) Objected() bool {
+	return r.Message != ""
+}
+
+// Options controls how scripts run.
+type Options struct {
+	Cwd     string                 // Working directory; the session's
+	Timeout time.Duration          // Per script; 0 = DefaultTimeout
+	Sandbox sandbox.SandboxManager // Wraps the script when Policy is set
+	Policy  *sandbox.SandboxPolicy // nil = unsandboxed
+}
+
+// Dir returns the hooks directory under codexHome.
+func Dir(codexHome string) string {
+	return filepath.Join(codexHome, "hooks")
+}
+
+// Find returns the executable scripts in dir for event, sorted by name: a
+// file named after the event, with or without an extension. A missing
+// directory yields none.
+func Find(dir string, event Event) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hooks directory %s: %w", dir, err)
+	}
+	var scripts []string
+	for _, e := range entries {
+		name := e.Name()
+		if name != string(event) && strings.TrimSuffix(name, filepath.Ext(name)) != string(event) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		scripts = append(scripts, filepath.Join(dir, name))
+	}
+	sort.Strings(scripts)
+	return scripts, nil
+}
+
+// Run runs script with payload on stdin.
+func Run(ctx context.Context, script string, payload Payload, opts Options) Result {
+	res := Result{Script: filepath.Base(script)}
+	input, err := json.Marshal(payload)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	command := []string{script}
+	var env map[string]string
+	if opts.Policy != nil {
+		if opts.Sandbox == nil {
+			res.Error = "no sandbox available for a sandboxed hook"
+			return res
+		}
+		execEnv, err := opts.Sandbox.Transform(sandbox.CommandSpec{Program: script, Cwd: opts.Cwd}, opts.Policy)
+		if err != nil {
+			res.Error = "sandbox setup failed: " + err.Error()
+			return res
+		}
+		command, env = execEnv.Command, execEnv.Env
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = opts.Cwd
+	cmd.WaitDelay = waitDelay // Don't wait on children that keep the pipes open
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "CODEX_HOOK_EVENT="+string(payload.Event))
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.Error = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+		res.Message = message(stderr.Bytes(), stdout.Bytes())
+	case err != nil:
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// message returns the trimmed stderr, else stdout, capped at maxMessageBytes.
+func message(stderr, stdout []byte) string {
+	msg := strings.TrimSpace(string(stderr))
+	if msg == "" {
+		msg = strings.TrimSpace(string(stdout))
+	}
+	if len(msg) > maxMessageBytes {
+		msg = strings.ToValidUTF8(msg[:maxMessageBytes], "") + "…"
+	}
+	return msg
+}
@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, dir, name, body string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), mode))
+	return path
+}
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+}
+
+func TestFind(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	writeScript(t, dir, "pre_tool_use.sh", "", 0o755)
+	writeScript(t, dir, "pre_tool_use", "", 0o755)
+	writeScript(t, dir, "pre_tool_use.py", "", 0o644) // Not executable
+	writeScript(t, dir, "pre_tool_use_extra.sh", "", 0o755)
+	writeScript(t, dir, "post_tool_use.sh", "", 0o755)
+
+	scripts, err := Find(dir, PreToolUse)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "pre_tool_use"), filepath.Join(dir, "pre_tool_use.sh")}, scripts)
+
+	scripts, err = Find(filepath.Join(dir, "missing"), PreToolUse)
+	require.NoError(t, err)
+	assert.Empty(t, scripts)
+}
+
+func TestRun(t *testing.T) {
+	skipOnWindows(t)
+	dir := t.TempDir()
+	payload := Payload{
+		Event:     PreToolUse,
+		SessionID: "s1",
+		Tool:      &ToolCall{CallID: "c1", Name: "shell_command", Arguments: json.RawMessage(`{"command":"rm -rf /"}`)},
+	}
+
+	t.Run("payload on stdin", func(t *testing.T) {
+		out := filepath.Join(dir, "stdin.json")
+		script := writeScript(t, dir, "capture", "cat > "+out+"\n", 0o755)
+		res := Run(context.Background(), script, payload, Options{Cwd: dir})
+		assert.Equal(t, Result{Script: "capture"}, res)
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		var got Payload
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, payload, got)
+	})
+
+	t.Run("objection", func(t *testing.T) {
+		script := writeScript(t, dir, "deny", "echo 'no rm -rf' >&2\nexit 2\n", 0o755)
+		res := Run(context.Background(), script, payload, Options{})
+		assert.True(t, res.Objected())
+		assert.Equal(t, 2, res.ExitCode)
+		assert.Equal(t, "no rm -rf", res.Message)
+	})
+
+	t.Run("failure without message", func(t *testing.T) {
+		script := writeScript(t, dir, "fail", "exit 1\n", 0o755)
+		res := Run(context.Background(), script, payload, Options{})
+		assert.False(t, res.Objected())
+		assert.Equal(t, 1, res.ExitCode)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		script := writeScript(t, dir, "slow", "sleep 5\n", 0o755)
+		res := Run(context.Background(), script, payload, Options{Timeout: 100 * time.Millisecond})
+		assert.False(t, res.Objected())
+		assert.Contains(t, res.Error, "timed out")
+	})
+}
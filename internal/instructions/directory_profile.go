@@ -0,0 +1,228 @@
+// Directory profiles: agent policy declared in the YAML front-matter of an
+// AGENTS.md file. A monorepo subproject can narrow the tool set, tighten the
+// approval mode and, once the user trusts it, pin its model for sessions
+// started inside its tree:
+//
+//	---
+//	model: claude-sonnet-4-0
+//	approval_mode: unless-trusted
+//	tools:
+//	  deny: [python_exec, fetch_url]
+//	---
+//	# Payments service
+//	...
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package instructions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// DirectoryProfile is the policy an instruction file declares in its
+// front-matter. Merged profiles list every file that contributed in Sources.
+type DirectoryProfile struct {
+	Model           string       `yaml:"model" json:"model,omitempty"`
+	Provider        string       `yaml:"provider" json:"provider,omitempty"`
+	ReasoningEffort string       `yaml:"reasoning_effort" json:"reasoning_effort,omitempty"`
+	ApprovalMode    string       `yaml:"approval_mode" json:"approval_mode,omitempty"`
+	Tools           ProfileTools `yaml:"tools" json:"tools,omitzero"`
+	Sources         []string     `yaml:"-" json:"sources,omitempty"`
+}
+
+// ProfileTools narrows the session's tools. Allow keeps only the listed
+// tools (groups allowed) and never adds tools the session lacks; Deny
+// removes tools.
+type ProfileTools struct {
+	Allow []string `yaml:"allow" json:"allow,omitempty"`
+	Deny  []string `yaml:"deny" json:"deny,omitempty"`
+}
+
+// ParseDirectoryProfile parses and validates front-matter YAML.
+func ParseDirectoryProfile(data []byte) (*DirectoryProfile, error) {
+	var p DirectoryProfile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse YAML: %w", err)
+	}
+	if p.ReasoningEffort != "" {
+		if _, ok := models.ParseReasoningEffort(p.ReasoningEffort); !ok {
+			return nil, fmt.Errorf("invalid reasoning_effort %q", p.ReasoningEffort)
+		}
+	}
+	switch models.ApprovalMode(p.ApprovalMode) {
+	case "", models.ApprovalUnlessTrusted, models.ApprovalNever, models.ApprovalOnFailure:
+	default:
+		return nil, fmt.Errorf("invalid approval_mode %q: want unless-trusted, never or on-failure", p.ApprovalMode)
+	}
+	for _, name := range append(append([]string(nil), p.Tools.Allow...), p.Tools.Deny...) {
+		if !isKnownTool(name) {
+			return nil, fmt.Errorf("unknown tool %q", name)
+		}
+	}
+	return &p, nil
+}
+
+// isKnownTool reports whether name is a registered tool or tool group.
+func isKnownTool(name string) bool {
+	if _, ok := tools.GetEntry(name); ok {
+		return true
+	}
+	expanded := tools.ExpandGroups([]string{name})
+	return len(expanded) != 1 || expanded[0] != name
+}
+
+// splitFrontMatter splits content that starts with a "---" line and has a
+// closing "---" line into the YAML between them and the rest. ok is false
+// when content has no front-matter.
+func splitFrontMatter(content string) (front, body string, ok bool) {
+	first, rest, found := strings.Cut(content, "\n")
+	if !found || strings.TrimRight(first, "\r") != "---" {
+		return "", content, false
+	}
+	n := 0
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		if strings.TrimRight(line, "\r\n") == "---" {
+			return rest[:n], strings.TrimLeft(rest[n+len(line):], "\r\n"), true
+		}
+		n += len(line)
+	}
+	return "", content, false
+}
+
+// MergeDirectoryProfiles merges the profiles of docs, ordered from the root
+// down as CollectProjectDocs returns them. Deeper files override the model,
+// provider, reasoning effort, approval mode and tool allow-list of the ones
+// above them; deny lists add up. Returns nil when no doc has a profile.
+func MergeDirectoryProfiles(docs []ProjectDoc) *DirectoryProfile {
+	var merged *DirectoryProfile
+	for _, doc := range docs {
+		p := doc.Profile
+		if p == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &DirectoryProfile{}
+		}
+		if p.Model != "" {
+			merged.Model = p.Model
+		}
+		if p.Provider != "" {
+			merged.Provider = p.Provider
+		}
+		if p.ReasoningEffort != "" {
+			merged.ReasoningEffort = p.ReasoningEffort
+		}
+		if p.ApprovalMode != "" {
+			merged.ApprovalMode = p.ApprovalMode
+		}
+		if len(p.Tools.Allow) > 0 {
+			merged.Tools.Allow = p.Tools.Allow
+		}
+		merged.Tools.Deny = append(merged.Tools.Deny, p.Tools.Deny...)
+		merged.Sources = append(merged.Sources, doc.Path)
+	}
+	return merged
+}
+
+// ApplyToConfig applies the profile to cfg, which already holds the user's
+// config.toml and CLI settings. A checked-in file must not loosen them, so
+// the profile may only tighten the approval mode and narrow the tools. It
+// changes the model and provider only in a trusted project, one the user
+// listed in trusted_projects; otherwise those settings are ignored. Returns
+// what was applied and ignored, for display.
+func (p *DirectoryProfile) ApplyToConfig(cfg *models.SessionConfiguration, trusted bool) *models.ProjectPolicy {
+	policy := &models.ProjectPolicy{Sources: p.Sources}
+	applied := func(format string, args ...interface{}) {
+		policy.Applied = append(policy.Applied, fmt.Sprintf(format, args...))
+	}
+	ignored := func(format string, args ...interface{}) {
+		policy.Ignored = append(policy.Ignored, fmt.Sprintf(format, args...))
+	}
+
+	if (p.Provider != "" || p.Model != "") && !trusted {
+		ignored("provider/model %s: the project is not in trusted_projects", profileModelLabel(p))
+	} else {
+		if p.Provider != "" {
+			cfg.Model.Provider = p.Provider
+			applied("provider: %s", p.Provider)
+		}
+		if p.Model != "" {
+			cfg.Model.Model = p.Model
+			applied("model: %s", p.Model)
+		}
+	}
+	if effort, ok := models.ParseReasoningEffort(p.ReasoningEffort); ok {
+		cfg.Model.ReasoningEffort = effort
+		applied("reasoning_effort: %s", effort)
+	}
+	if p.ApprovalMode != "" {
+		mode := models.ApprovalMode(p.ApprovalMode)
+		if approvalStrictness(mode) > approvalStrictness(cfg.Permissions.ApprovalMode) {
+			cfg.Permissions.ApprovalMode = mode
+			applied("approval_mode: %s", mode)
+		} else if mode != cfg.Permissions.ApprovalMode {
+			ignored("approval_mode %s: it would loosen the session's approval mode", mode)
+		}
+	}
+	if len(p.Tools.Allow) > 0 {
+		cfg.Tools.RestrictTools(p.Tools.Allow...)
+		applied("tools allowed: %s", strings.Join(p.Tools.Allow, ", "))
+	}
+	if len(p.Tools.Deny) > 0 {
+		// Expand groups first so a denied member of an enabled group goes.
+		cfg.Tools.EnabledTools = tools.ExpandGroups(cfg.Tools.EnabledTools)
+		cfg.Tools.RemoveTools(p.Tools.Deny...)
+		applied("tools denied: %s", strings.Join(p.Tools.Deny, ", "))
+	}
+	return policy
+}
+
+// approvalStrictness orders approval modes from the one that prompts least.
+// An unset mode approves everything, like never.
+func approvalStrictness(mode models.ApprovalMode) int {
+	switch mode {
+	case models.ApprovalUnlessTrusted:
+		return 2
+	case models.ApprovalOnFailure:
+		return 1
+	}
+	return 0
+}
+
+func profileModelLabel(p *DirectoryProfile) string {
+	if p.Provider != "" && p.Model != "" {
+		return p.Provider + "/" + p.Model
+	}
+	return p.Provider + p.Model
+}
+
+// IsTrustedProject reports whether dir is one of trusted, or inside one.
+// trusted holds absolute directories from config.toml.
+func IsTrustedProject(dir string, trusted []string) bool {
+	if dir == "" {
+		return false
+	}
+	dir = filepath.Clean(dir)
+	for _, root := range trusted {
+		if !filepath.IsAbs(root) {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Clean(root), dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
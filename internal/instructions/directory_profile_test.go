@@ -0,0 +1,153 @@
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	for _, tc := range []struct {
+		content, front, body string
+		ok                   bool
+	}{
+		{"---\nmodel: o3\n---\n\n# Docs\n", "model: o3\n", "# Docs\n", true},
+		{"---\r\nmodel: o3\r\n---\r\n# Docs", "model: o3\r\n", "# Docs", true},
+		{"---\n---\nbody", "", "body", true},
+		{"---\nmodel: o3\n---", "model: o3\n", "", true},
+		{"# Docs\n---\nmodel: o3\n---\n", "", "# Docs\n---\nmodel: o3\n---\n", false},
+		{"---\nno closing line\n", "", "---\nno closing line\n", false},
+	} {
+		front, body, ok := splitFrontMatter(tc.content)
+		assert.Equal(t, tc.ok, ok, tc.content)
+		assert.Equal(t, tc.front, front, tc.content)
+		assert.Equal(t, tc.body, body, tc.content)
+	}
+}
+
+func TestParseDirectoryProfile(t *testing.T) {
+	p, err := ParseDirectoryProfile([]byte(`
+model: claude-sonnet-4-0
+provider: anthropic
+reasoning_effort: high
+approval_mode: never
+tools:
+  allow: [shell, read_file, collab]
+  deny: [spawn_agent]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-0", p.Model)
+	assert.Equal(t, "anthropic", p.Provider)
+	assert.Equal(t, "high", p.ReasoningEffort)
+	assert.Equal(t, "never", p.ApprovalMode)
+	assert.Equal(t, []string{"shell", "read_file", "collab"}, p.Tools.Allow)
+	assert.Equal(t, []string{"spawn_agent"}, p.Tools.Deny)
+
+	p, err = ParseDirectoryProfile(nil)
+	require.NoError(t, err)
+	assert.Equal(t, &DirectoryProfile{}, p)
+
+	for _, tc := range []struct{ yaml, want string }{
+		{"modle: o3", "field modle not found"},
+		{"approval_mode: always", `invalid approval_mode "always"`},
+		{"reasoning_effort: extreme", `invalid reasoning_effort "extreme"`},
+		{"tools:\n  deny: [rm_rf]", `unknown tool "rm_rf"`},
+		{"model: [o3", "parse YAML"},
+	} {
+		_, err := ParseDirectoryProfile([]byte(tc.yaml))
+		assert.ErrorContains(t, err, tc.want, tc.yaml)
+	}
+}
+
+func TestCollectProjectDocs_FrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "services", "payments")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AGENTS.md"),
+		[]byte("---\nmodel: o3\napproval_mode: unless-trusted\ntools:\n  deny: [fetch_url]\n---\nroot docs"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "services", "AGENTS.md"),
+		[]byte("---\napproval_mode: sometimes\n---\nservices docs"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "AGENTS.md"),
+		[]byte("---\nmodel: claude-sonnet-4-0\ntools:\n  deny: [python_exec]\n---\npayments docs"), 0o644))
+
+	docs, err := CollectProjectDocs(dir, sub, nil)
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, "root docs", docs[0].Content, "the front-matter is not shown to the model")
+	assert.Contains(t, docs[1].Content, "approval_mode: sometimes", "invalid front-matter is left in place")
+	assert.Contains(t, docs[1].ProfileError, `invalid approval_mode "sometimes"`)
+	assert.Nil(t, docs[1].Profile)
+
+	p := MergeDirectoryProfiles(docs)
+	require.NotNil(t, p)
+	assert.Equal(t, "claude-sonnet-4-0", p.Model, "the deeper file wins")
+	assert.Equal(t, "unless-trusted", p.ApprovalMode)
+	assert.Equal(t, []string{"fetch_url", "python_exec"}, p.Tools.Deny, "deny lists add up")
+	assert.Equal(t, []string{"AGENTS.md", filepath.Join("services", "payments", "AGENTS.md")}, p.Sources)
+
+	assert.Nil(t, MergeDirectoryProfiles([]ProjectDoc{{Path: "AGENTS.md", Content: "plain"}}))
+}
+
+func TestDirectoryProfile_ApplyToConfig(t *testing.T) {
+	cfg := models.DefaultSessionConfiguration()
+	cfg.Tools.AddTools("collab")
+	require.True(t, cfg.Tools.HasTool("spawn_agent"))
+
+	policy := (&DirectoryProfile{
+		Model:           "claude-sonnet-4-0",
+		Provider:        "anthropic",
+		ReasoningEffort: "low",
+		ApprovalMode:    "unless-trusted",
+		Tools:           ProfileTools{Deny: []string{"spawn_agent", "shell"}},
+		Sources:         []string{"/repo/AGENTS.md"},
+	}).ApplyToConfig(&cfg, true)
+	assert.Equal(t, "claude-sonnet-4-0", cfg.Model.Model)
+	assert.Equal(t, "anthropic", cfg.Model.Provider)
+	assert.Equal(t, models.ReasoningEffortLow, cfg.Model.ReasoningEffort)
+	assert.Equal(t, models.ApprovalUnlessTrusted, cfg.Permissions.ApprovalMode)
+	assert.False(t, cfg.Tools.HasTool("spawn_agent"), "a denied member of an enabled group is removed")
+	assert.True(t, cfg.Tools.HasTool("wait"))
+	assert.False(t, cfg.Tools.HasTool("shell"))
+	assert.Equal(t, []string{"/repo/AGENTS.md"}, policy.Sources)
+	assert.Contains(t, policy.Applied, "approval_mode: unless-trusted")
+	assert.Empty(t, policy.Ignored)
+
+	(&DirectoryProfile{Tools: ProfileTools{Allow: []string{"read_file", "github"}}}).ApplyToConfig(&cfg, true)
+	assert.Equal(t, []string{"read_file"}, cfg.Tools.EnabledTools, "allow never adds tools")
+}
+
+func TestDirectoryProfile_ApplyToConfig_OnlyTightens(t *testing.T) {
+	cfg := models.DefaultSessionConfiguration()
+	cfg.Model.Provider, cfg.Model.Model = "openai", "gpt-4o"
+	cfg.Permissions.ApprovalMode = models.ApprovalUnlessTrusted
+
+	policy := (&DirectoryProfile{
+		Provider:     "anthropic",
+		Model:        "claude-sonnet-4-0",
+		ApprovalMode: "never",
+	}).ApplyToConfig(&cfg, false)
+	assert.Equal(t, "openai", cfg.Model.Provider, "an untrusted project cannot pick the provider")
+	assert.Equal(t, "gpt-4o", cfg.Model.Model)
+	assert.Equal(t, models.ApprovalUnlessTrusted, cfg.Permissions.ApprovalMode, "approvals are never loosened")
+	assert.Len(t, policy.Ignored, 2)
+	assert.Empty(t, policy.Applied)
+
+	cfg.Permissions.ApprovalMode = models.ApprovalNever
+	(&DirectoryProfile{ApprovalMode: "on-failure"}).ApplyToConfig(&cfg, false)
+	assert.Equal(t, models.ApprovalOnFailure, cfg.Permissions.ApprovalMode, "a stricter mode applies")
+}
+
+func TestIsTrustedProject(t *testing.T) {
+	trusted := []string{"/home/me/src/payments", "relative/dir"}
+	assert.True(t, IsTrustedProject("/home/me/src/payments", trusted))
+	assert.True(t, IsTrustedProject("/home/me/src/payments/api", trusted))
+	assert.False(t, IsTrustedProject("/home/me/src/payments-fork", trusted))
+	assert.False(t, IsTrustedProject("/home/me/src", trusted))
+	assert.False(t, IsTrustedProject("/work/relative/dir", trusted), "relative entries are ignored")
+	assert.False(t, IsTrustedProject("", trusted))
+}
@@ -1,17 +1,57 @@
 package instructions
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // BuildEnvironmentContext produces an XML-formatted environment context
 // string, following the Codex pattern for injecting context as a user message
-// at session start.
-func BuildEnvironmentContext(cwd, shell string) string {
+// at session start. osName is included when known so the model can pick
+// commands and path syntax for the worker's platform (e.g. "windows").
+// envVars lists the names of session environment variables set for tool
+// commands; values are left out because they may hold credentials. now, in
+// the session's timezone, tells the model today's date; the zero time leaves
+// it out.
+func BuildEnvironmentContext(cwd, shell, osName string, envVars []string, now time.Time) string {
 	if shell == "" {
 		shell = "bash"
 	}
 
+	var extra string
+	if osName != "" {
+		extra = fmt.Sprintf("\n  <os>%s</os>", osName)
+	}
+	if len(envVars) > 0 {
+		extra += fmt.Sprintf("\n  <env_vars>%s</env_vars>", strings.Join(envVars, ", "))
+	}
+	if !now.IsZero() {
+		extra += "\n" + clockLines(now)
+	}
+
 	return fmt.Sprintf(`<environment_context>
   <cwd>%s</cwd>
-  <shell>%s</shell>
-</environment_context>`, cwd, shell)
+  <shell>%s</shell>%s
+</environment_context>`, cwd, shell, extra)
+}
+
+// BuildClockContext produces an environment context holding only the
+// current time, sent at the start of later turns so the model's notion of
+// "now" does not go stale over a long session.
+func BuildClockContext(now time.Time) string {
+	return "<environment_context>\n" + clockLines(now) + "\n</environment_context>"
+}
+
+// FormatClock formats now to the minute with its weekday, and its timezone
+// with the UTC offset, e.g. "2026-10-17 14:03 (Saturday)" and
+// "Europe/Berlin (UTC+02:00)".
+func FormatClock(now time.Time) (current, zone string) {
+	return now.Format("2006-01-02 15:04 (Monday)"),
+		fmt.Sprintf("%s (UTC%s)", now.Location(), now.Format("-07:00"))
+}
+
+func clockLines(now time.Time) string {
+	current, zone := FormatClock(now)
+	return fmt.Sprintf("  <current_time>%s</current_time>\n  <timezone>%s</timezone>", current, zone)
 }
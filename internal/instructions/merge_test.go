@@ -2,6 +2,7 @@ package instructions
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -46,15 +47,42 @@ func TestComposeDeveloperInstructions_Empty(t *testing.T) {
 // --- BuildEnvironmentContext tests ---
 
 func TestBuildEnvironmentContext_Basic(t *testing.T) {
-	result := BuildEnvironmentContext("/home/user/project", "zsh")
+	result := BuildEnvironmentContext("/home/user/project", "zsh", "", nil, time.Time{})
 	assert.Contains(t, result, "<cwd>/home/user/project</cwd>")
 	assert.Contains(t, result, "<shell>zsh</shell>")
 	assert.Contains(t, result, "<environment_context>")
 }
 
 func TestBuildEnvironmentContext_DefaultShell(t *testing.T) {
-	result := BuildEnvironmentContext("/tmp", "")
+	result := BuildEnvironmentContext("/tmp", "", "", nil, time.Time{})
 	assert.Contains(t, result, "<shell>bash</shell>")
+	assert.NotContains(t, result, "<os>")
+}
+
+func TestBuildEnvironmentContext_Windows(t *testing.T) {
+	result := BuildEnvironmentContext(`C:\Users\dev\project`, "powershell", "windows", nil, time.Time{})
+	assert.Contains(t, result, `<cwd>C:\Users\dev\project</cwd>`)
+	assert.Contains(t, result, "<shell>powershell</shell>")
+	assert.Contains(t, result, "  <os>windows</os>\n</environment_context>")
+}
+
+func TestBuildEnvironmentContext_EnvVars(t *testing.T) {
+	result := BuildEnvironmentContext("/tmp", "", "linux", []string{"DATABASE_URL", "GOFLAGS"}, time.Time{})
+	assert.Contains(t, result, "  <os>linux</os>\n  <env_vars>DATABASE_URL, GOFLAGS</env_vars>\n</environment_context>")
+}
+
+func TestBuildEnvironmentContext_Clock(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	now := time.Date(2026, 10, 17, 14, 3, 27, 0, berlin)
+	result := BuildEnvironmentContext("/tmp", "", "linux", nil, now)
+	assert.Contains(t, result, "  <os>linux</os>\n  <current_time>2026-10-17 14:03 (Saturday)</current_time>\n"+
+		"  <timezone>Europe/Berlin (UTC+02:00)</timezone>\n</environment_context>")
+
+	assert.Equal(t, "<environment_context>\n  <current_time>2026-10-17 12:03 (Saturday)</current_time>\n"+
+		"  <timezone>UTC (UTC+00:00)</timezone>\n</environment_context>", BuildClockContext(now.UTC()))
 }
 
 // --- MergeInstructions tests ---
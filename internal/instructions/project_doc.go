@@ -52,6 +52,36 @@ func FindGitRoot(dir string) (string, error) {
 	}
 }
 
+// ProjectDoc is one discovered instruction file.
+type ProjectDoc struct {
+	Path    string `json:"path"`    // Relative to the root directory
+	Content string `json:"content"` // May be clipped; see Bytes. Front-matter is removed
+	Bytes   int    `json:"bytes"`   // Original size of the file
+
+	// Profile is the policy declared in the file's front-matter, if any.
+	// ProfileError is set instead when the front-matter is invalid.
+	Profile      *DirectoryProfile `json:"profile,omitempty"`
+	ProfileError string            `json:"profile_error,omitempty"`
+}
+
+// ProjectDocSummary is the summary of a project doc that did not fit under
+// MaxProjectDocsBytes. An empty Summary means summarization failed.
+type ProjectDocSummary struct {
+	Path    string `json:"path"`
+	Summary string `json:"summary,omitempty"`
+	Bytes   int    `json:"bytes"` // Original size of the file
+}
+
+// Limits on the overflow docs handed to the summarizer.
+const (
+	// MaxProjectDocSummaryInputBytes clips each overflow file before it is
+	// summarized.
+	MaxProjectDocSummaryInputBytes = 64 * 1024
+	// MaxProjectDocsOverflowBytes bounds the total overflow content; files
+	// past it are listed without content and reported as omitted.
+	MaxProjectDocsOverflowBytes = 1024 * 1024
+)
+
 // LoadProjectDocs discovers instruction files from rootDir down to targetDir.
 //
 // At each directory level between rootDir and targetDir (inclusive), it checks
@@ -62,47 +92,85 @@ func FindGitRoot(dir string) (string, error) {
 //
 // Returns empty string if no files found (not an error).
 func LoadProjectDocs(rootDir, targetDir string, agentsFileNames []string) (string, error) {
+	docs, err := CollectProjectDocs(rootDir, targetDir, agentsFileNames)
+	if err != nil {
+		return "", err
+	}
+	verbatim, _ := SplitProjectDocs(docs)
+	return formatProjectDocs(verbatim, ""), nil
+}
+
+// LoadProjectDocsWithOverflow is LoadProjectDocs that also returns the files
+// past MaxProjectDocsBytes, clipped for summarization, instead of dropping
+// them. When there is overflow, the included files are marked "verbatim" so
+// they can be told apart from the summaries added by
+// AppendProjectDocSummaries.
+func LoadProjectDocsWithOverflow(rootDir, targetDir string, agentsFileNames []string) (string, []ProjectDoc, error) {
+	docs, err := CollectProjectDocs(rootDir, targetDir, agentsFileNames)
+	if err != nil {
+		return "", nil, err
+	}
+	text, overflow := FormatProjectDocsWithOverflow(docs)
+	return text, overflow, nil
+}
+
+// FormatProjectDocsWithOverflow is the formatting half of
+// LoadProjectDocsWithOverflow, for callers that also need the collected docs.
+func FormatProjectDocsWithOverflow(docs []ProjectDoc) (string, []ProjectDoc) {
+	verbatim, overflow := SplitProjectDocs(docs)
+	if len(overflow) == 0 {
+		return formatProjectDocs(verbatim, ""), nil
+	}
+	budget := MaxProjectDocsOverflowBytes
+	for i := range overflow {
+		content := overflow[i].Content
+		if len(content) > MaxProjectDocSummaryInputBytes {
+			content = content[:MaxProjectDocSummaryInputBytes]
+		}
+		if len(content) > budget {
+			content = ""
+		}
+		budget -= len(content)
+		overflow[i].Content = content
+	}
+	return formatProjectDocs(verbatim, "verbatim"), overflow
+}
+
+// CollectProjectDocs returns every instruction file from rootDir down to
+// targetDir, in the order LoadProjectDocs concatenates them. Supplementary
+// files follow the primary file of their directory.
+func CollectProjectDocs(rootDir, targetDir string, agentsFileNames []string) ([]ProjectDoc, error) {
 	if len(agentsFileNames) == 0 {
 		agentsFileNames = AgentsFileNames
 	}
 	rootDir, err := filepath.Abs(rootDir)
 	if err != nil {
-		return "", fmt.Errorf("cannot resolve rootDir: %w", err)
+		return nil, fmt.Errorf("cannot resolve rootDir: %w", err)
 	}
 	targetDir, err = filepath.Abs(targetDir)
 	if err != nil {
-		return "", fmt.Errorf("cannot resolve targetDir: %w", err)
+		return nil, fmt.Errorf("cannot resolve targetDir: %w", err)
 	}
 
 	// Compute the directory path from rootDir to targetDir
 	dirs, err := pathSegments(rootDir, targetDir)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var parts []string
-	totalSize := 0
-
+	var docs []ProjectDoc
 	for _, dir := range dirs {
 		// Load primary agent instruction file (first match wins)
 		content, filename, err := findInstructionFile(dir, agentsFileNames)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if content != "" {
 			relPath, _ := filepath.Rel(rootDir, filepath.Join(dir, filename))
 			if relPath == "" {
 				relPath = filename
 			}
-			separator := fmt.Sprintf("--- %s ---", relPath)
-			entrySize := len(separator) + 1 + len(content)
-
-			if totalSize+entrySize > MaxProjectDocsBytes {
-				break
-			}
-
-			parts = append(parts, separator+"\n"+content)
-			totalSize += entrySize
+			docs = append(docs, profiledProjectDoc(relPath, content))
 		}
 
 		// Load supplementary files (additive, don't compete with agent instructions)
@@ -113,10 +181,9 @@ func LoadProjectDocs(rootDir, targetDir string, agentsFileNames []string) (strin
 				if os.IsNotExist(err) {
 					continue
 				}
-				return "", fmt.Errorf("error reading %s: %w", path, err)
+				return nil, fmt.Errorf("error reading %s: %w", path, err)
 			}
-			supContent := string(data)
-			if supContent == "" {
+			if len(data) == 0 {
 				continue
 			}
 
@@ -124,19 +191,87 @@ func LoadProjectDocs(rootDir, targetDir string, agentsFileNames []string) (strin
 			if relPath == "" {
 				relPath = name
 			}
-			separator := fmt.Sprintf("--- %s ---", relPath)
-			entrySize := len(separator) + 1 + len(supContent)
+			docs = append(docs, ProjectDoc{Path: relPath, Content: string(data), Bytes: len(data)})
+		}
+	}
+	return docs, nil
+}
 
-			if totalSize+entrySize > MaxProjectDocsBytes {
-				break
-			}
+// profiledProjectDoc returns the primary instruction file at path, with
+// its front-matter parsed into Profile and removed from Content. Invalid
+// front-matter is left in Content.
+func profiledProjectDoc(path, content string) ProjectDoc {
+	doc := ProjectDoc{Path: path, Content: content, Bytes: len(content)}
+	front, body, ok := splitFrontMatter(content)
+	if !ok {
+		return doc
+	}
+	profile, err := ParseDirectoryProfile([]byte(front))
+	if err != nil {
+		doc.ProfileError = err.Error()
+		return doc
+	}
+	doc.Content = body
+	doc.Profile = profile
+	return doc
+}
+
+// SplitProjectDocs splits docs at the first file that would push the
+// concatenated size past MaxProjectDocsBytes. That file and every later one
+// overflow.
+func SplitProjectDocs(docs []ProjectDoc) (verbatim, overflow []ProjectDoc) {
+	totalSize := 0
+	for i, doc := range docs {
+		entrySize := len(projectDocSeparator(doc.Path, "")) + 1 + len(doc.Content)
+		if totalSize+entrySize > MaxProjectDocsBytes {
+			return docs[:i], docs[i:]
+		}
+		totalSize += entrySize
+	}
+	return docs, nil
+}
 
-			parts = append(parts, separator+"\n"+supContent)
-			totalSize += entrySize
+// AppendProjectDocSummaries appends the summaries of overflow files to docs,
+// after a note explaining which files were summarized.
+func AppendProjectDocSummaries(docs string, summaries []ProjectDocSummary) string {
+	if len(summaries) == 0 {
+		return docs
+	}
+	parts := []string{fmt.Sprintf(
+		"--- Note: project docs exceed the %d KB limit. Files marked \"verbatim\" are included in full; "+
+			"files marked \"summarized\" are summaries. Read the original file when you need its details. ---",
+		MaxProjectDocsBytes/1024)}
+	if docs != "" {
+		parts = append([]string{docs}, parts...)
+	}
+	for _, sum := range summaries {
+		if strings.TrimSpace(sum.Summary) == "" {
+			parts = append(parts, projectDocSeparator(sum.Path,
+				fmt.Sprintf("omitted, %d bytes: could not be summarized", sum.Bytes)))
+			continue
 		}
+		parts = append(parts, projectDocSeparator(sum.Path, fmt.Sprintf("summarized from %d bytes", sum.Bytes))+
+			"\n"+strings.TrimSpace(sum.Summary))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// formatProjectDocs concatenates docs with labeled separators.
+func formatProjectDocs(docs []ProjectDoc, label string) string {
+	parts := make([]string, len(docs))
+	for i, doc := range docs {
+		parts[i] = projectDocSeparator(doc.Path, label) + "\n" + doc.Content
 	}
+	return strings.Join(parts, "\n\n")
+}
 
-	return strings.Join(parts, "\n\n"), nil
+// projectDocSeparator returns the header line of a doc, e.g.
+// "--- sub/AGENTS.md ---" or "--- sub/AGENTS.md (verbatim) ---".
+func projectDocSeparator(path, label string) string {
+	if label == "" {
+		return fmt.Sprintf("--- %s ---", path)
+	}
+	return fmt.Sprintf("--- %s (%s) ---", path, label)
 }
 
 // pathSegments returns all directories from rootDir to targetDir inclusive.
@@ -220,3 +220,63 @@ func TestPathSegments_NotPrefix(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "not under")
 }
+
+// --- Overflow tests ---
+
+func TestLoadProjectDocsWithOverflow(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	bigContent := strings.Repeat("x", MaxProjectDocsBytes-20)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte(bigContent), 0o644))
+	overflowContent := strings.Repeat("y", MaxProjectDocSummaryInputBytes+10)
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "AGENTS.md"), []byte(overflowContent), 0o644))
+
+	docs, overflow, err := LoadProjectDocsWithOverflow(dir, sub, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(docs, "--- AGENTS.md (verbatim) ---\n"))
+	require.Len(t, overflow, 1)
+	assert.Equal(t, filepath.Join("sub", "AGENTS.md"), overflow[0].Path)
+	assert.Equal(t, MaxProjectDocSummaryInputBytes+10, overflow[0].Bytes)
+	assert.Len(t, overflow[0].Content, MaxProjectDocSummaryInputBytes, "clipped for summarization")
+}
+
+func TestLoadProjectDocsWithOverflow_NoOverflow(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("root instructions"), 0o644))
+
+	docs, overflow, err := LoadProjectDocsWithOverflow(dir, dir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, overflow)
+	assert.Equal(t, "--- AGENTS.md ---\nroot instructions", docs)
+}
+
+func TestSplitProjectDocs_OverflowKeepsOrder(t *testing.T) {
+	docs := []ProjectDoc{
+		{Path: "AGENTS.md", Content: "a"},
+		{Path: "big/AGENTS.md", Content: strings.Repeat("b", MaxProjectDocsBytes)},
+		{Path: "big/small/AGENTS.md", Content: "c"},
+	}
+	verbatim, overflow := SplitProjectDocs(docs)
+	assert.Equal(t, docs[:1], verbatim)
+	assert.Equal(t, docs[1:], overflow, "files after the first overflow are not pulled forward")
+}
+
+func TestAppendProjectDocSummaries(t *testing.T) {
+	got := AppendProjectDocSummaries("--- AGENTS.md (verbatim) ---\nroot", []ProjectDocSummary{
+		{Path: "a/AGENTS.md", Summary: "- rule one\n", Bytes: 600000},
+		{Path: "b/AGENTS.md", Bytes: 90000},
+	})
+	assert.Equal(t, `--- AGENTS.md (verbatim) ---
+root
+
+--- Note: project docs exceed the 512 KB limit. Files marked "verbatim" are included in full; files marked "summarized" are summaries. Read the original file when you need its details. ---
+
+--- a/AGENTS.md (summarized from 600000 bytes) ---
+- rule one
+
+--- b/AGENTS.md (omitted, 90000 bytes: could not be summarized) ---`, got)
+
+	assert.Equal(t, "docs", AppendProjectDocSummaries("docs", nil))
+}
@@ -0,0 +1,78 @@
+// Package instructions contains prompt construction for LLM calls.
+//
+// session_title.go provides the prompt and parsing for session titles: a
+// short label the session picker shows, generated by a cheap model after the
+// first turn or derived from the first user message.
+package instructions
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxSessionTitleLen caps a session title, in characters.
+const MaxSessionTitleLen = 60
+
+// SessionTitleSystemPrompt is the system prompt for the session title call.
+const SessionTitleSystemPrompt = `Write a title for this coding session, to tell it apart from others in a list.
+
+Look at what the user asked for and what the assistant did. Name the task, not the conversation:
+"Fix flaky auth middleware test", not "User asks about a test".
+
+- 3-8 words, sentence case, no trailing period
+- Use the names of the files, functions or features involved when they help
+- No quotes, no emoji, no "Session:" prefix
+
+Reply with ONLY the title.`
+
+// BuildSessionTitleInput constructs the user message for the session title
+// call from the first exchange of the session.
+func BuildSessionTitleInput(userMsg, assistantMsg string) string {
+	var b strings.Builder
+	b.WriteString("User asked: ")
+	b.WriteString(truncateString(userMsg, maxUserMsgLen*2))
+	if assistantMsg != "" {
+		b.WriteString("\n\nAssistant responded: ")
+		b.WriteString(truncateString(assistantMsg, maxAssistantMsgLen))
+	}
+	return b.String()
+}
+
+// ParseSessionTitle extracts the title from the model's response. Returns
+// empty string if the response is empty or not a single line.
+func ParseSessionTitle(response string) string {
+	s := strings.TrimSpace(response)
+	if s == "" || strings.Contains(s, "\n") {
+		return ""
+	}
+	s = strings.Trim(s, "\"'`")
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	return clipTitle(s)
+}
+
+// DeriveSessionTitle makes a title from the first user message: its first
+// non-empty line, with whitespace collapsed, clipped at a word boundary.
+func DeriveSessionTitle(userMsg string) string {
+	for _, line := range strings.Split(userMsg, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			return clipTitle(line)
+		}
+	}
+	return ""
+}
+
+// clipTitle shortens s to MaxSessionTitleLen characters, cutting at the last
+// word boundary and marking the cut with an ellipsis.
+func clipTitle(s string) string {
+	r := []rune(s)
+	if len(r) <= MaxSessionTitleLen {
+		return s
+	}
+	r = r[:MaxSessionTitleLen-1]
+	if i := strings.LastIndexFunc(string(r), unicode.IsSpace); i > MaxSessionTitleLen/2 {
+		r = []rune(string(r)[:i])
+	}
+	return strings.TrimRightFunc(string(r), func(c rune) bool {
+		return unicode.IsSpace(c) || unicode.IsPunct(c)
+	}) + "…"
+}
@@ -0,0 +1,39 @@
+package instructions
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSessionTitleInput(t *testing.T) {
+	result := BuildSessionTitleInput("fix the login bug", "Fixed the nil check in auth.go")
+	assert.Equal(t, "User asked: fix the login bug\n\nAssistant responded: Fixed the nil check in auth.go", result)
+
+	assert.Equal(t, "User asked: hi", BuildSessionTitleInput("hi", ""))
+}
+
+func TestParseSessionTitle(t *testing.T) {
+	tests := []struct {
+		response, want string
+	}{
+		{"Fix nil check in auth middleware", "Fix nil check in auth middleware"},
+		{`  "Add retry to uploader."  `, "Add retry to uploader"},
+		{"", ""},
+		{"Title one\nTitle two", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseSessionTitle(tt.response), tt.response)
+	}
+}
+
+func TestDeriveSessionTitle(t *testing.T) {
+	assert.Equal(t, "Fix the flaky test in auth", DeriveSessionTitle("\n  Fix the   flaky test in auth\nIt fails on CI."))
+	assert.Equal(t, "", DeriveSessionTitle(" \n "))
+
+	long := DeriveSessionTitle(strings.Repeat("refactor the storage layer ", 5))
+	assert.Equal(t, "refactor the storage layer refactor the storage layer…", long)
+	assert.LessOrEqual(t, utf8.RuneCountInString(long), MaxSessionTitleLen)
+}
@@ -82,6 +82,10 @@ func SuggestionModelForProvider(provider string) (model string, resolvedProvider
 	switch strings.ToLower(provider) {
 	case "anthropic":
 		return "claude-haiku-4-5-20251001", "anthropic"
+	case "bedrock":
+		return "anthropic.claude-3-haiku-20240307-v1:0", "bedrock"
+	case "vertex":
+		return "gemini-2.5-flash-lite", "vertex"
 	default:
 		return "gpt-4o-mini", "openai"
 	}
@@ -93,6 +93,8 @@ func TestSuggestionModelForProvider(t *testing.T) {
 	}{
 		{"openai", "gpt-4o-mini", "openai"},
 		{"anthropic", "claude-haiku-4-5-20251001", "anthropic"},
+		{"bedrock", "anthropic.claude-3-haiku-20240307-v1:0", "bedrock"},
+		{"vertex", "gemini-2.5-flash-lite", "vertex"},
 		{"google", "gpt-4o-mini", "openai"}, // falls back to openai
 		{"", "gpt-4o-mini", "openai"},        // default
 	}
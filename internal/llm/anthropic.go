@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -22,14 +23,23 @@ type AnthropicClient struct {
 
 // NewAnthropicClient creates an Anthropic client.
 func NewAnthropicClient() *AnthropicClient {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
-	return &AnthropicClient{client: client}
+	return newAnthropicClient(nil)
+}
+
+// newAnthropicClient creates an Anthropic client that sends requests
+// through hc, or the default HTTP client when hc is nil.
+func newAnthropicClient(hc *http.Client) *AnthropicClient {
+	opts := []option.RequestOption{option.WithAPIKey(os.Getenv("ANTHROPIC_API_KEY"))}
+	if hc != nil {
+		opts = append(opts, option.WithHTTPClient(hc))
+	}
+	return &AnthropicClient{client: anthropic.NewClient(opts...)}
 }
 
 // Call sends a request to Anthropic and returns the complete response.
 // The response items match our ConversationItem format.
 func (c *AnthropicClient) Call(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	request.History = anthropicHistory.translate(request.History)
 	messages, err := c.buildMessages(request)
 	if err != nil {
 		return LLMResponse{}, fmt.Errorf("failed to build messages: %w", err)
@@ -51,20 +61,33 @@ func (c *AnthropicClient) Call(ctx context.Context, request LLMRequest) (LLMResp
 		params.Temperature = anthropic.Float(request.ModelConfig.Temperature)
 	}
 
-	// Add tools if provided
-	if len(request.ToolSpecs) > 0 {
-		toolDefs := c.buildToolDefinitions(request.ToolSpecs)
-		params.Tools = toolDefs
+	// Add tools if provided. A ResponseFormat is implemented as one more
+	// tool that the model is forced to call; its input is the JSON result.
+	if request.ResponseFormat != nil {
+		specs := sortedToolSpecs(request.ToolSpecs)
+		specs = append(specs, responseFormatToolSpec(request.ResponseFormat))
+		params.Tools = c.buildToolDefinitions(specs)
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(request.ResponseFormat.Name)
+	} else if len(request.ToolSpecs) > 0 {
+		params.Tools = cachedToolDefinitions(request, "anthropic", c.buildToolDefinitions)
 	}
 
-	// Call Anthropic API
-	response, err := c.client.Messages.New(ctx, params)
+	// Call Anthropic API, streaming when the caller wants progress
+	var response *anthropic.Message
+	if request.OnProgress != nil {
+		response, err = c.stream(ctx, params, request.OnProgress)
+	} else {
+		response, err = c.client.Messages.New(ctx, params)
+	}
 	if err != nil {
 		return LLMResponse{}, classifyAnthropicError(err)
 	}
 
 	// Convert response to our format
 	items, finishReason := c.parseResponse(response)
+	if request.ResponseFormat != nil {
+		items, finishReason = responseFormatResult(items, request.ResponseFormat.Name, finishReason)
+	}
 
 	return LLMResponse{
 		Items:        items,
@@ -79,6 +102,52 @@ func (c *AnthropicClient) Call(ctx context.Context, request LLMRequest) (LLMResp
 	}, nil
 }
 
+// stream calls the streaming Messages API and returns the accumulated message,
+// reporting tool-call argument and text progress as deltas arrive. Cancelling
+// ctx aborts the stream, so an oversized tool call can be cut off early.
+func (c *AnthropicClient) stream(ctx context.Context, params anthropic.MessageNewParams, onProgress func(StreamProgress)) (*anthropic.Message, error) {
+	s := c.client.Messages.NewStreaming(ctx, params)
+	defer s.Close()
+
+	var message anthropic.Message
+	var progress StreamProgress
+	for s.Next() {
+		event := s.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("accumulate stream event: %w", err)
+		}
+		switch ev := event.AsAny().(type) {
+		case anthropic.ContentBlockStartEvent:
+			if ev.ContentBlock.Type == "tool_use" {
+				progress.ToolName = ev.ContentBlock.Name
+				progress.CallID = ev.ContentBlock.ID
+				progress.ArgBytes = 0
+				onProgress(progress)
+			}
+		case anthropic.ContentBlockDeltaEvent:
+			switch ev.Delta.Type {
+			case "input_json_delta":
+				progress.ArgBytes += len(ev.Delta.PartialJSON)
+			case "text_delta":
+				progress.TextBytes += len(ev.Delta.Text)
+			default:
+				continue
+			}
+			onProgress(progress)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// AnthropicModelID returns the API model ID for a configured Claude model
+// name, resolving the aliases the harness accepts.
+func AnthropicModelID(modelName string) string {
+	return string(selectAnthropicModel(modelName))
+}
+
 // selectAnthropicModel maps model names to Anthropic's Model type.
 func selectAnthropicModel(modelName string) anthropic.Model {
 	// Map common model names to Anthropic's constants
@@ -155,7 +224,15 @@ func (c *AnthropicClient) buildMessages(request LLMRequest) ([]anthropic.Message
 	if err != nil {
 		return nil, err
 	}
-	messages = append(messages, historyMessages...)
+	// Merge adjacent messages of the same role: the results of parallel
+	// tool calls belong in one user message, right after the calls.
+	for _, m := range historyMessages {
+		if n := len(messages); n > 0 && messages[n-1].Role == m.Role {
+			messages[n-1].Content = append(messages[n-1].Content, m.Content...)
+			continue
+		}
+		messages = append(messages, m)
+	}
 
 	// Add cache breakpoint to the last content block of the penultimate message.
 	// This caches all conversation history before the current user turn, so
@@ -198,6 +275,30 @@ func (c *AnthropicClient) convertHistoryToMessages(history []models.Conversation
 			})
 			i++
 
+		case models.ItemTypeUserShellCommand:
+			messages = append(messages, anthropic.MessageParam{
+				Role: anthropic.MessageParamRoleUser,
+				Content: []anthropic.ContentBlockParamUnion{{
+					OfText: &anthropic.TextBlockParam{
+						Text: models.FormatUserShellCommand(item),
+					},
+				}},
+			})
+			i++
+
+		case models.ItemTypeDeveloperMessage:
+			// Anthropic has no developer role; send the instructions as a
+			// user turn tagged so the model does not take them for the user.
+			messages = append(messages, anthropic.MessageParam{
+				Role: anthropic.MessageParamRoleUser,
+				Content: []anthropic.ContentBlockParamUnion{{
+					OfText: &anthropic.TextBlockParam{
+						Text: formatAnthropicDeveloperMessage(item.Content),
+					},
+				}},
+			})
+			i++
+
 		case models.ItemTypeAssistantMessage:
 			// Check if followed by FunctionCall items
 			content := make([]anthropic.ContentBlockParamUnion, 0)
@@ -302,6 +403,12 @@ func (c *AnthropicClient) convertHistoryToMessages(history []models.Conversation
 	return messages, nil
 }
 
+// formatAnthropicDeveloperMessage wraps developer instructions for a user
+// turn.
+func formatAnthropicDeveloperMessage(content string) string {
+	return "<developer_instructions>\n" + content + "\n</developer_instructions>"
+}
+
 // buildToolDefinitions converts ToolSpecs to Anthropic tool definitions.
 func (c *AnthropicClient) buildToolDefinitions(specs []tools.ToolSpec) []anthropic.ToolUnionParam {
 	toolDefs := make([]anthropic.ToolUnionParam, 0, len(specs))
@@ -373,6 +480,49 @@ func (c *AnthropicClient) buildToolDefinitions(specs []tools.ToolSpec) []anthrop
 	return toolDefs
 }
 
+// responseFormatToolSpec builds the tool that carries a ResponseFormat on
+// Anthropic, which has no native JSON schema output.
+func responseFormatToolSpec(rf *models.ResponseFormat) tools.ToolSpec {
+	description := rf.Description
+	if description == "" {
+		description = "Return the final result as structured JSON."
+	}
+	return tools.ToolSpec{
+		Name:          rf.Name,
+		Description:   description,
+		RawJSONSchema: rf.Schema,
+	}
+}
+
+// responseFormatResult replaces the forced response-format tool call with an
+// assistant message holding its JSON input, so callers see the same shape as
+// OpenAI structured outputs.
+func responseFormatResult(items []models.ConversationItem, name string, finishReason models.FinishReason) ([]models.ConversationItem, models.FinishReason) {
+	out := make([]models.ConversationItem, 0, len(items))
+	found := false
+	for _, item := range items {
+		if item.Type == models.ItemTypeFunctionCall && item.Name == name {
+			out = append(out, models.ConversationItem{
+				Type:    models.ItemTypeAssistantMessage,
+				Content: item.Arguments,
+			})
+			found = true
+			continue
+		}
+		if item.Type == models.ItemTypeAssistantMessage && item.Content == "" {
+			continue // placeholder for an empty response
+		}
+		out = append(out, item)
+	}
+	if found && finishReason == models.FinishReasonToolCalls {
+		finishReason = models.FinishReasonStop
+	}
+	if len(out) == 0 {
+		out = append(out, models.ConversationItem{Type: models.ItemTypeAssistantMessage})
+	}
+	return out, finishReason
+}
+
 // parseResponse converts Anthropic's response to our ConversationItem format.
 func (c *AnthropicClient) parseResponse(response *anthropic.Message) ([]models.ConversationItem, models.FinishReason) {
 	items := make([]models.ConversationItem, 0)
@@ -439,6 +589,13 @@ func (c *AnthropicClient) parseResponse(response *anthropic.Message) ([]models.C
 //
 // Maps to: codex-rs/core/src/compact.rs local compaction path
 func (c *AnthropicClient) Compact(ctx context.Context, request CompactRequest) (CompactResponse, error) {
+	return localCompact(ctx, c, "anthropic", request)
+}
+
+// localCompact summarizes the history with client and rebuilds it as the
+// summary plus recent user messages. Shared by the providers without a
+// remote compaction API.
+func localCompact(ctx context.Context, client LLMClient, provider string, request CompactRequest) (CompactResponse, error) {
 	// Build a summarization request with the compaction prompt appended
 	historyWithPrompt := make([]models.ConversationItem, len(request.Input))
 	copy(historyWithPrompt, request.Input)
@@ -450,7 +607,7 @@ func (c *AnthropicClient) Compact(ctx context.Context, request CompactRequest) (
 	llmRequest := LLMRequest{
 		History: historyWithPrompt,
 		ModelConfig: models.ModelConfig{
-			Provider:      "anthropic",
+			Provider:      provider,
 			Model:         request.Model,
 			MaxTokens:     4096,
 			ContextWindow: 128000,
@@ -458,7 +615,7 @@ func (c *AnthropicClient) Compact(ctx context.Context, request CompactRequest) (
 		BaseInstructions: request.Instructions,
 	}
 
-	resp, err := c.Call(ctx, llmRequest)
+	resp, err := client.Call(ctx, llmRequest)
 	if err != nil {
 		return CompactResponse{}, fmt.Errorf("compaction LLM call failed: %w", err)
 	}
@@ -591,7 +748,7 @@ func classifyAnthropicError(err error) error {
 
 	// Use typed error for status-code-based classification
 	if apiErr, ok := err.(*anthropic.Error); ok {
-		return classifyByStatusCode(apiErr.StatusCode, err)
+		return withRateLimitHint(classifyByStatusCode(apiErr.StatusCode, err), apiErr.Response)
 	}
 
 	// Fallback for non-typed errors
@@ -151,6 +151,25 @@ func TestBuildMessages_CacheBreakpointOnPenultimate(t *testing.T) {
 		"penultimate message cache_control.type must be ephemeral")
 }
 
+// TestConvertHistory_DeveloperMessage verifies injected developer messages
+// become a user turn tagged as developer instructions, since Anthropic has no
+// developer role.
+func TestConvertHistory_DeveloperMessage(t *testing.T) {
+	c := &AnthropicClient{}
+	messages, err := c.convertHistoryToMessages([]models.ConversationItem{
+		{Type: models.ItemTypeAssistantMessage, Content: "Working on it."},
+		{Type: models.ItemTypeDeveloperMessage, Content: "do not touch go.mod"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, anthropic.MessageParamRoleUser, messages[1].Role)
+	require.Len(t, messages[1].Content, 1)
+	require.NotNil(t, messages[1].Content[0].OfText)
+	assert.Equal(t, "<developer_instructions>\ndo not touch go.mod\n</developer_instructions>",
+		messages[1].Content[0].OfText.Text)
+}
+
 // TestBuildMessages_NoCacheBreakpoint_SingleMessage verifies that a single-message
 // history (no prior context to cache) does not get a cache breakpoint.
 func TestBuildMessages_NoCacheBreakpoint_SingleMessage(t *testing.T) {
@@ -390,3 +409,117 @@ func TestCall_CachedTokensReported(t *testing.T) {
 	assert.Equal(t, 20, resp.TokenUsage.PromptTokens)
 	assert.Equal(t, 5, resp.TokenUsage.CompletionTokens)
 }
+
+// TestCall_StreamingReportsToolCallProgress verifies that with OnProgress set
+// the client streams, reports tool-call argument bytes as they arrive, and
+// still returns the fully accumulated tool call.
+func TestCall_StreamingReportsToolCallProgress(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5-20251001","content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":0}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"apply_patch","input":{}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"input\": "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"*** Begin Patch\"}"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":7}}`,
+		`{"type":"message_stop"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, ev := range events {
+			var typed struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(ev), &typed)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, ev)
+		}
+	}))
+	defer server.Close()
+
+	c := &AnthropicClient{
+		client: anthropic.NewClient(
+			option.WithBaseURL(server.URL),
+			option.WithAPIKey("test-key"),
+		),
+	}
+
+	var progress []StreamProgress
+	resp, err := c.Call(context.Background(), LLMRequest{
+		ModelConfig: models.ModelConfig{Model: "claude-haiku-4-5-20251001", MaxTokens: 1024},
+		History:     []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "patch it"}},
+		OnProgress:  func(p StreamProgress) { progress = append(progress, p) },
+	})
+	require.NoError(t, err)
+
+	require.Len(t, progress, 3)
+	assert.Equal(t, StreamProgress{ToolName: "apply_patch", CallID: "toolu_1"}, progress[0])
+	assert.Equal(t, len(`{"input": `), progress[1].ArgBytes)
+	assert.Equal(t, len(`{"input": "*** Begin Patch"}`), progress[2].ArgBytes)
+
+	assert.Equal(t, models.FinishReasonToolCalls, resp.FinishReason)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "apply_patch", resp.Items[0].Name)
+	assert.JSONEq(t, `{"input": "*** Begin Patch"}`, resp.Items[0].Arguments)
+	assert.Equal(t, 7, resp.TokenUsage.CompletionTokens)
+}
+
+// TestCall_ResponseFormatForcesTool verifies that a ResponseFormat is sent as
+// a forced tool and that its tool_use input comes back as an assistant
+// message with a stop finish reason.
+func TestCall_ResponseFormatForcesTool(t *testing.T) {
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &capturedBody))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{
+			"id": "msg_test123",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-haiku-4-5-20251001",
+			"content": [{"type": "tool_use", "id": "toolu_1", "name": "subagent_result", "input": {"files": ["a.go"]}}],
+			"stop_reason": "tool_use",
+			"stop_sequence": null,
+			"usage": {"input_tokens": 100, "output_tokens": 10}
+		}`)
+	}))
+	defer server.Close()
+
+	c := &AnthropicClient{
+		client: anthropic.NewClient(
+			option.WithBaseURL(server.URL),
+			option.WithAPIKey("test-key"),
+		),
+	}
+
+	resp, err := c.Call(context.Background(), LLMRequest{
+		ModelConfig: models.ModelConfig{Model: "claude-haiku-4-5-20251001", MaxTokens: 1024},
+		History:     []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "hi"}},
+		ResponseFormat: &models.ResponseFormat{
+			Name: "subagent_result",
+			Schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"files": map[string]interface{}{"type": "array"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	toolChoice, ok := capturedBody["tool_choice"].(map[string]interface{})
+	require.True(t, ok, "tool_choice must be set")
+	assert.Equal(t, "tool", toolChoice["type"])
+	assert.Equal(t, "subagent_result", toolChoice["name"])
+
+	toolsList, ok := capturedBody["tools"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, toolsList, 1)
+	assert.Equal(t, "subagent_result", toolsList[0].(map[string]interface{})["name"])
+
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, models.ItemTypeAssistantMessage, resp.Items[0].Type)
+	assert.JSONEq(t, `{"files": ["a.go"]}`, resp.Items[0].Content)
+	assert.Equal(t, models.FinishReasonStop, resp.FinishReason)
+}
@@ -0,0 +1,489 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrock"
+	bedrocktypes "github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// BedrockClient implements LLMClient using the AWS Bedrock Converse API,
+// for accounts that can only reach Claude through Bedrock.
+//
+// Requests are SigV4-signed with credentials from the default AWS chain
+// (environment, shared config/SSO profile, web identity, instance role).
+// The region comes from AWS_REGION, AWS_DEFAULT_REGION or the profile.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type BedrockClient struct {
+	httpClient *http.Client // nil uses the AWS SDK's default
+
+	mu     sync.Mutex
+	client *bedrockruntime.Client
+}
+
+// NewBedrockClient creates a Bedrock client. AWS configuration is loaded on
+// first use, so a worker without AWS credentials can still start.
+func NewBedrockClient() *BedrockClient {
+	return &BedrockClient{}
+}
+
+// BedrockConfigured reports whether an AWS region is configured, which the
+// worker takes as the Bedrock provider being available.
+func BedrockConfigured() bool {
+	return os.Getenv("AWS_REGION") != "" || os.Getenv("AWS_DEFAULT_REGION") != ""
+}
+
+// loadAWSConfig loads the default AWS configuration and checks it names a
+// region. Requests go through hc unless it is nil.
+func loadAWSConfig(ctx context.Context, hc *http.Client) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if hc != nil {
+		opts = append(opts, config.WithHTTPClient(hc))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return aws.Config{}, fmt.Errorf("no AWS region configured (set AWS_REGION)")
+	}
+	return cfg, nil
+}
+
+// runtime returns the Bedrock runtime client, loading the AWS configuration
+// on first use. A failed load is retried on the next call.
+func (c *BedrockClient) runtime(ctx context.Context) (*bedrockruntime.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		cfg, err := loadAWSConfig(ctx, c.httpClient)
+		if err != nil {
+			return nil, models.NewFatalError(fmt.Sprintf("Bedrock unavailable: %v", err))
+		}
+		c.client = bedrockruntime.NewFromConfig(cfg)
+	}
+	return c.client, nil
+}
+
+// Call sends a request to the Converse API and returns the complete
+// response.
+func (c *BedrockClient) Call(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	client, err := c.runtime(ctx)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	request.History = bedrockHistory.translate(request.History)
+	messages, err := buildBedrockMessages(request)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("failed to build messages: %w", err)
+	}
+
+	input := &bedrockruntime.ConverseInput{
+		ModelId:  aws.String(BedrockModelID(request.ModelConfig.Model, client.Options().Region)),
+		Messages: messages,
+		System:   buildBedrockSystem(request),
+	}
+
+	inference := &types.InferenceConfiguration{}
+	if request.ModelConfig.MaxTokens > 0 {
+		inference.MaxTokens = aws.Int32(int32(request.ModelConfig.MaxTokens))
+	}
+	if request.ModelConfig.Temperature > 0 {
+		inference.Temperature = aws.Float32(float32(request.ModelConfig.Temperature))
+	}
+	input.InferenceConfig = inference
+
+	// Like Anthropic, a ResponseFormat is a tool the model is forced to call.
+	if request.ResponseFormat != nil {
+		specs := append(sortedToolSpecs(request.ToolSpecs), responseFormatToolSpec(request.ResponseFormat))
+		input.ToolConfig = &types.ToolConfiguration{
+			Tools: buildBedrockTools(specs),
+			ToolChoice: &types.ToolChoiceMemberTool{
+				Value: types.SpecificToolChoice{Name: aws.String(request.ResponseFormat.Name)},
+			},
+		}
+	} else if len(request.ToolSpecs) > 0 {
+		input.ToolConfig = &types.ToolConfiguration{Tools: cachedToolDefinitions(request, "bedrock", buildBedrockTools)}
+	}
+
+	output, err := client.Converse(ctx, input)
+	if err != nil {
+		return LLMResponse{}, classifyBedrockError(err)
+	}
+
+	items, finishReason, err := parseBedrockOutput(output)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	if request.ResponseFormat != nil {
+		items, finishReason = responseFormatResult(items, request.ResponseFormat.Name, finishReason)
+	}
+
+	return LLMResponse{
+		Items:        items,
+		FinishReason: finishReason,
+		TokenUsage:   bedrockTokenUsage(output.Usage),
+	}, nil
+}
+
+// BedrockModelID returns the Bedrock model ID for a configured model name.
+// The Claude names the harness accepts for Anthropic ("claude-sonnet-4.5")
+// are mapped to the base Bedrock model ID, or for Claude 4 models, which
+// Bedrock serves only through inference profiles, to the system-defined
+// profile of region's geography ("us.anthropic.…"). Bedrock model IDs
+// ("anthropic.claude-…-v1:0"), inference profile IDs and ARNs are used as
+// given.
+func BedrockModelID(modelName, region string) string {
+	if !strings.HasPrefix(modelName, "claude") {
+		return modelName
+	}
+	id := AnthropicModelID(modelName)
+	if !bedrockProfileOnly(id) {
+		return "anthropic." + id + "-v1:0"
+	}
+	return bedrockProfilePrefix(region) + ".anthropic." + id + "-v1:0"
+}
+
+// bedrockProfileOnly reports whether Bedrock serves the Anthropic model
+// only through inference profiles (Claude 4 and later).
+func bedrockProfileOnly(anthropicID string) bool {
+	for _, family := range []string{"claude-sonnet-", "claude-opus-", "claude-haiku-"} {
+		if v, ok := strings.CutPrefix(anthropicID, family); ok && v != "" && v[0] >= '4' && v[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// bedrockProfilePrefix returns the prefix of the system-defined inference
+// profiles for region's geography. Regions outside the US, Europe and Asia
+// Pacific use the global profiles.
+func bedrockProfilePrefix(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "us-gov"
+	case strings.HasPrefix(region, "us-"):
+		return "us"
+	case strings.HasPrefix(region, "eu-"):
+		return "eu"
+	case strings.HasPrefix(region, "ap-"):
+		return "apac"
+	default:
+		return "global"
+	}
+}
+
+// buildBedrockSystem creates the system blocks from the base and user
+// instructions.
+func buildBedrockSystem(request LLMRequest) []types.SystemContentBlock {
+	var blocks []types.SystemContentBlock
+	for _, text := range []string{request.BaseInstructions, request.UserInstructions} {
+		if text != "" {
+			blocks = append(blocks, &types.SystemContentBlockMemberText{Value: text})
+		}
+	}
+	return blocks
+}
+
+// buildBedrockMessages converts conversation history to Converse messages.
+//
+// The history maps onto content blocks the way it does for Anthropic, but
+// Converse rejects consecutive messages with the same role, so adjacent
+// user blocks (developer instructions, parallel tool results) are merged.
+func buildBedrockMessages(request LLMRequest) ([]types.Message, error) {
+	var messages []types.Message
+	add := func(role types.ConversationRole, blocks ...types.ContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, types.Message{Role: role, Content: blocks})
+	}
+
+	if request.DeveloperInstructions != "" {
+		add(types.ConversationRoleUser, &types.ContentBlockMemberText{Value: request.DeveloperInstructions})
+	}
+
+	for _, item := range request.History {
+		switch item.Type {
+		case models.ItemTypeUserMessage:
+			if item.Content != "" {
+				add(types.ConversationRoleUser, &types.ContentBlockMemberText{Value: item.Content})
+			}
+
+		case models.ItemTypeUserShellCommand:
+			add(types.ConversationRoleUser, &types.ContentBlockMemberText{Value: models.FormatUserShellCommand(item)})
+
+		case models.ItemTypeDeveloperMessage:
+			add(types.ConversationRoleUser, &types.ContentBlockMemberText{
+				Value: formatAnthropicDeveloperMessage(item.Content),
+			})
+
+		case models.ItemTypeAssistantMessage:
+			if item.Content != "" {
+				add(types.ConversationRoleAssistant, &types.ContentBlockMemberText{Value: item.Content})
+			}
+
+		case models.ItemTypeFunctionCall:
+			var input map[string]interface{}
+			if err := json.Unmarshal([]byte(item.Arguments), &input); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+			}
+			add(types.ConversationRoleAssistant, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+				ToolUseId: aws.String(item.CallID),
+				Name:      aws.String(item.Name),
+				Input:     document.NewLazyDocument(input),
+			}})
+
+		case models.ItemTypeFunctionCallOutput:
+			status := types.ToolResultStatusSuccess
+			content := ""
+			if item.Output != nil {
+				content = item.Output.Content
+				if item.Output.Success != nil && !*item.Output.Success {
+					status = types.ToolResultStatusError
+				}
+			}
+			if content == "" {
+				content = "(no output)" // Converse rejects empty text blocks
+			}
+			add(types.ConversationRoleUser, &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+				ToolUseId: aws.String(item.CallID),
+				Content:   []types.ToolResultContentBlock{&types.ToolResultContentBlockMemberText{Value: content}},
+				Status:    status,
+			}})
+		}
+	}
+
+	// Converse requires the conversation to open with a user message; a
+	// compacted history opens with the assistant's summary.
+	if len(messages) > 0 && messages[0].Role != types.ConversationRoleUser {
+		messages = append([]types.Message{{
+			Role:    types.ConversationRoleUser,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "(continuing)"}},
+		}}, messages...)
+	}
+
+	return messages, nil
+}
+
+// buildBedrockTools converts ToolSpecs to Converse tool specifications.
+func buildBedrockTools(specs []tools.ToolSpec) []types.Tool {
+	defs := make([]types.Tool, 0, len(specs))
+	for _, spec := range specs {
+		defs = append(defs, &types.ToolMemberToolSpec{Value: types.ToolSpecification{
+			Name:        aws.String(spec.Name),
+			Description: aws.String(spec.Description),
+			InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(toolJSONSchema(spec))},
+		}})
+	}
+	return defs
+}
+
+// toolJSONSchema returns the JSON Schema of a tool's parameters: the raw
+// schema of MCP tools, or one built from Parameters.
+func toolJSONSchema(spec tools.ToolSpec) map[string]interface{} {
+	if spec.RawJSONSchema != nil {
+		return spec.RawJSONSchema
+	}
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	for _, p := range spec.Parameters {
+		prop := map[string]interface{}{
+			"type":        p.Type,
+			"description": p.Description,
+		}
+		if p.Items != nil {
+			prop["items"] = p.Items
+		}
+		properties[p.Name] = prop
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// parseBedrockOutput converts a Converse response to our ConversationItem
+// format.
+func parseBedrockOutput(output *bedrockruntime.ConverseOutput) ([]models.ConversationItem, models.FinishReason, error) {
+	items := make([]models.ConversationItem, 0)
+	finishReason := models.FinishReasonStop
+
+	if msg, ok := output.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range msg.Value.Content {
+			switch b := block.(type) {
+			case *types.ContentBlockMemberText:
+				if b.Value != "" {
+					items = append(items, models.ConversationItem{
+						Type:    models.ItemTypeAssistantMessage,
+						Content: b.Value,
+					})
+				}
+			case *types.ContentBlockMemberToolUse:
+				args := []byte("{}")
+				if b.Value.Input != nil {
+					raw, err := b.Value.Input.MarshalSmithyDocument()
+					if err != nil {
+						return nil, "", fmt.Errorf("failed to decode tool input: %w", err)
+					}
+					args = raw
+				}
+				items = append(items, models.ConversationItem{
+					Type:      models.ItemTypeFunctionCall,
+					CallID:    aws.ToString(b.Value.ToolUseId),
+					Name:      aws.ToString(b.Value.Name),
+					Arguments: string(args),
+				})
+				finishReason = models.FinishReasonToolCalls
+			}
+		}
+	}
+
+	if len(items) == 0 {
+		items = append(items, models.ConversationItem{Type: models.ItemTypeAssistantMessage})
+	}
+
+	switch output.StopReason {
+	case types.StopReasonEndTurn, types.StopReasonStopSequence:
+		finishReason = models.FinishReasonStop
+	case types.StopReasonToolUse:
+		finishReason = models.FinishReasonToolCalls
+	case types.StopReasonMaxTokens:
+		finishReason = models.FinishReasonLength
+	case types.StopReasonModelContextWindowExceeded:
+		return nil, "", models.NewContextOverflowError("Bedrock: model context window exceeded")
+	}
+
+	return items, finishReason, nil
+}
+
+// bedrockTokenUsage maps Converse usage to TokenUsage. As with Anthropic,
+// input tokens exclude cache reads and writes, which are reported apart.
+func bedrockTokenUsage(usage *types.TokenUsage) models.TokenUsage {
+	if usage == nil {
+		return models.TokenUsage{}
+	}
+	input := int(aws.ToInt32(usage.InputTokens))
+	output := int(aws.ToInt32(usage.OutputTokens))
+	return models.TokenUsage{
+		PromptTokens:        input,
+		CompletionTokens:    output,
+		TotalTokens:         input + output,
+		CachedTokens:        int(aws.ToInt32(usage.CacheReadInputTokens)),
+		CacheCreationTokens: int(aws.ToInt32(usage.CacheWriteInputTokens)),
+	}
+}
+
+// Compact performs local compaction via LLM summarization, as for Anthropic.
+func (c *BedrockClient) Compact(ctx context.Context, request CompactRequest) (CompactResponse, error) {
+	return localCompact(ctx, c, "bedrock", request)
+}
+
+// fetchBedrockModels lists the Anthropic text models in the configured
+// region that can be called without provisioned throughput: those served
+// on demand and the system-defined inference profiles. Listing profiles
+// needs its own permission; without it only on-demand models are listed.
+func fetchBedrockModels(ctx context.Context) ([]AvailableModel, error) {
+	cfg, err := loadAWSConfig(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := bedrock.NewFromConfig(cfg)
+	out, err := client.ListFoundationModels(ctx, &bedrock.ListFoundationModelsInput{
+		ByProvider:       aws.String("Anthropic"),
+		ByOutputModality: bedrocktypes.ModelModalityText,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []bedrocktypes.InferenceProfileSummary
+	pages := bedrock.NewListInferenceProfilesPaginator(client, &bedrock.ListInferenceProfilesInput{
+		TypeEquals: bedrocktypes.InferenceProfileTypeSystemDefined,
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			break
+		}
+		profiles = append(profiles, page.InferenceProfileSummaries...)
+	}
+	return bedrockAvailableModels(out.ModelSummaries, profiles), nil
+}
+
+// bedrockAvailableModels returns the on-demand models and the active
+// Anthropic inference profiles.
+func bedrockAvailableModels(summaries []bedrocktypes.FoundationModelSummary, profiles []bedrocktypes.InferenceProfileSummary) []AvailableModel {
+	var result []AvailableModel
+	for _, m := range summaries {
+		if !slices.Contains(m.InferenceTypesSupported, bedrocktypes.InferenceTypeOnDemand) {
+			continue
+		}
+		result = append(result, AvailableModel{
+			Provider:    "bedrock",
+			ID:          aws.ToString(m.ModelId),
+			DisplayName: aws.ToString(m.ModelName),
+		})
+	}
+	for _, p := range profiles {
+		id := aws.ToString(p.InferenceProfileId)
+		if p.Status != bedrocktypes.InferenceProfileStatusActive || !strings.Contains(id, ".anthropic.") {
+			continue
+		}
+		result = append(result, AvailableModel{
+			Provider:    "bedrock",
+			ID:          id,
+			DisplayName: aws.ToString(p.InferenceProfileName),
+		})
+	}
+	return result
+}
+
+// classifyBedrockError categorizes a Bedrock error using the HTTP status
+// code when available, falling back to message-based heuristics.
+func classifyBedrockError(err error) error {
+	errMsg := strings.ToLower(err.Error())
+
+	// Context overflow detection ("Input is too long for requested model.")
+	if strings.Contains(errMsg, "too long") || strings.Contains(errMsg, "too many tokens") ||
+		strings.Contains(errMsg, "context window") {
+		return models.NewContextOverflowError(err.Error())
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		return classifyByStatusCode(respErr.HTTPStatusCode(), err)
+	}
+
+	if strings.Contains(errMsg, "throttl") {
+		return models.NewAPILimitError(err.Error())
+	}
+	return models.NewTransientError(fmt.Sprintf("Bedrock API error: %v", err))
+}
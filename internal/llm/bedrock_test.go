@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	bedrocktypes "github.com/aws/aws-sdk-go-v2/service/bedrock/types"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestBedrockModelID(t *testing.T) {
+	assert.Equal(t, "us.anthropic.claude-sonnet-4-5-20250929-v1:0", BedrockModelID("claude-sonnet-4.5", "us-east-1"))
+	assert.Equal(t, "eu.anthropic.claude-haiku-4-5-20251001-v1:0", BedrockModelID("claude-haiku-4.5", "eu-central-1"))
+	assert.Equal(t, "apac.anthropic.claude-opus-4-6-v1:0", BedrockModelID("claude-opus-4.6", "ap-northeast-1"))
+	assert.Equal(t, "global.anthropic.claude-sonnet-4-5-20250929-v1:0", BedrockModelID("claude-sonnet-4.5", "sa-east-1"))
+	assert.Equal(t, "anthropic.claude-3-haiku-20240307-v1:0", BedrockModelID("claude-3-haiku-20240307", "us-east-1"),
+		"older models are served on demand")
+	assert.Equal(t, "us.anthropic.claude-sonnet-4-5-20250929-v1:0",
+		BedrockModelID("us.anthropic.claude-sonnet-4-5-20250929-v1:0", "eu-west-1"))
+	arn := "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc"
+	assert.Equal(t, arn, BedrockModelID(arn, "us-east-1"))
+}
+
+func TestBedrockAvailableModels(t *testing.T) {
+	summaries := []bedrocktypes.FoundationModelSummary{
+		{ModelId: aws.String("anthropic.claude-3-haiku-20240307-v1:0"), ModelName: aws.String("Claude 3 Haiku"),
+			InferenceTypesSupported: []bedrocktypes.InferenceType{bedrocktypes.InferenceTypeOnDemand}},
+		{ModelId: aws.String("anthropic.claude-sonnet-4-5-20250929-v1:0"), ModelName: aws.String("Claude Sonnet 4.5"),
+			InferenceTypesSupported: []bedrocktypes.InferenceType{"INFERENCE_PROFILE"}},
+		{ModelId: aws.String("anthropic.claude-v2:1:200k"), ModelName: aws.String("Claude 2.1"),
+			InferenceTypesSupported: []bedrocktypes.InferenceType{bedrocktypes.InferenceTypeProvisioned}},
+	}
+	profiles := []bedrocktypes.InferenceProfileSummary{
+		{InferenceProfileId: aws.String("us.anthropic.claude-sonnet-4-5-20250929-v1:0"),
+			InferenceProfileName: aws.String("US Claude Sonnet 4.5"), Status: bedrocktypes.InferenceProfileStatusActive},
+		{InferenceProfileId: aws.String("us.meta.llama3-3-70b-instruct-v1:0"),
+			InferenceProfileName: aws.String("US Llama 3.3 70B"), Status: bedrocktypes.InferenceProfileStatusActive},
+	}
+	assert.Equal(t, []AvailableModel{
+		{Provider: "bedrock", ID: "anthropic.claude-3-haiku-20240307-v1:0", DisplayName: "Claude 3 Haiku"},
+		{Provider: "bedrock", ID: "us.anthropic.claude-sonnet-4-5-20250929-v1:0", DisplayName: "US Claude Sonnet 4.5"},
+	}, bedrockAvailableModels(summaries, profiles))
+}
+
+// TestBuildBedrockMessages_MergesRoles verifies parallel tool calls share
+// one assistant message and their results one user message, since Converse
+// rejects consecutive messages with the same role.
+func TestBuildBedrockMessages_MergesRoles(t *testing.T) {
+	failed := false
+	msgs, err := buildBedrockMessages(LLMRequest{
+		DeveloperInstructions: "Be careful.",
+		History: []models.ConversationItem{
+			{Type: models.ItemTypeUserMessage, Content: "List and read"},
+			{Type: models.ItemTypeAssistantMessage, Content: "Sure."},
+			{Type: models.ItemTypeFunctionCall, CallID: "t1", Name: "list_dir", Arguments: `{"path":"."}`},
+			{Type: models.ItemTypeFunctionCall, CallID: "t2", Name: "read_file", Arguments: `{"path":"x"}`},
+			{Type: models.ItemTypeFunctionCallOutput, CallID: "t1", Output: &models.FunctionCallOutputPayload{Content: "x"}},
+			{Type: models.ItemTypeFunctionCallOutput, CallID: "t2", Output: &models.FunctionCallOutputPayload{Content: "", Success: &failed}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+
+	assert.Equal(t, types.ConversationRoleUser, msgs[0].Role)
+	assert.Len(t, msgs[0].Content, 2, "developer instructions and user message")
+
+	assert.Equal(t, types.ConversationRoleAssistant, msgs[1].Role)
+	require.Len(t, msgs[1].Content, 3)
+	use := msgs[1].Content[2].(*types.ContentBlockMemberToolUse).Value
+	assert.Equal(t, "t2", aws.ToString(use.ToolUseId))
+	assert.Equal(t, "read_file", aws.ToString(use.Name))
+
+	assert.Equal(t, types.ConversationRoleUser, msgs[2].Role)
+	require.Len(t, msgs[2].Content, 2)
+	result := msgs[2].Content[1].(*types.ContentBlockMemberToolResult).Value
+	assert.Equal(t, types.ToolResultStatusError, result.Status)
+	assert.Equal(t, "(no output)", result.Content[0].(*types.ToolResultContentBlockMemberText).Value)
+}
+
+func TestBuildBedrockMessages_OpensWithUser(t *testing.T) {
+	msgs, err := buildBedrockMessages(LLMRequest{History: []models.ConversationItem{
+		{Type: models.ItemTypeCompaction, Content: "context_compacted"},
+		{Type: models.ItemTypeAssistantMessage, Content: "Summary so far"},
+		{Type: models.ItemTypeUserMessage, Content: "Continue"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, types.ConversationRoleUser, msgs[0].Role)
+	assert.Equal(t, types.ConversationRoleAssistant, msgs[1].Role)
+}
+
+func TestBedrockTokenUsage(t *testing.T) {
+	usage := bedrockTokenUsage(&types.TokenUsage{
+		InputTokens:           aws.Int32(50),
+		OutputTokens:          aws.Int32(20),
+		CacheReadInputTokens:  aws.Int32(3000),
+		CacheWriteInputTokens: aws.Int32(950),
+	})
+	assert.Equal(t, models.TokenUsage{
+		PromptTokens: 50, CompletionTokens: 20, TotalTokens: 70,
+		CachedTokens: 3000, CacheCreationTokens: 950,
+	}, usage)
+	assert.Equal(t, models.TokenUsage{}, bedrockTokenUsage(nil))
+}
+
+func TestClassifyBedrockError(t *testing.T) {
+	err := classifyBedrockError(assert.AnError)
+	var activityErr *models.ActivityError
+	require.ErrorAs(t, err, &activityErr)
+	assert.Equal(t, models.ErrorTypeTransient, activityErr.Type)
+
+	err = classifyBedrockError(errString("ValidationException: Input is too long for requested model."))
+	require.ErrorAs(t, err, &activityErr)
+	assert.Equal(t, models.ErrorTypeContextOverflow, activityErr.Type)
+
+	err = classifyBedrockError(errString("ThrottlingException: Too many requests"))
+	require.ErrorAs(t, err, &activityErr)
+	assert.Equal(t, models.ErrorTypeAPILimit, activityErr.Type)
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
+
+// TestBedrockClient_Call sends a tool-calling request to a fake Converse
+// endpoint and checks the request body and the parsed response.
+func TestBedrockClient_Call(t *testing.T) {
+	var body map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		raw, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(raw, &body))
+		assert.Contains(t, r.Header.Get("Authorization"), "AWS4-HMAC-SHA256", "requests are SigV4-signed")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"output": {"message": {"role": "assistant", "content": [
+				{"text": "Reading it."},
+				{"toolUse": {"toolUseId": "tu-1", "name": "read_file", "input": {"path": "go.mod"}}}
+			]}},
+			"stopReason": "tool_use",
+			"usage": {"inputTokens": 12, "outputTokens": 7, "totalTokens": 19, "cacheReadInputTokens": 100}
+		}`))
+	}))
+	defer server.Close()
+
+	c := &BedrockClient{client: bedrockruntime.New(bedrockruntime.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})}
+
+	resp, err := c.Call(context.Background(), LLMRequest{
+		ModelConfig:      models.ModelConfig{Provider: "bedrock", Model: "claude-sonnet-4.5", MaxTokens: 1024},
+		BaseInstructions: "You are a coding agent.",
+		History:          []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "Read go.mod"}},
+		ToolSpecs: []tools.ToolSpec{{
+			Name:        "read_file",
+			Description: "Read a file",
+			Parameters:  []tools.ToolParameter{{Name: "path", Type: "string", Description: "File path", Required: true}},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, path, "/model/us.anthropic.claude-sonnet-4-5-20250929-v1:0/converse")
+	assert.Equal(t, float64(1024), body["inferenceConfig"].(map[string]interface{})["maxTokens"])
+	tool := body["toolConfig"].(map[string]interface{})["tools"].([]interface{})[0].(map[string]interface{})["toolSpec"].(map[string]interface{})
+	assert.Equal(t, "read_file", tool["name"])
+	schema := tool["inputSchema"].(map[string]interface{})["json"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"path"}, schema["required"])
+
+	require.Len(t, resp.Items, 2)
+	assert.Equal(t, "Reading it.", resp.Items[0].Content)
+	assert.Equal(t, models.ItemTypeFunctionCall, resp.Items[1].Type)
+	assert.Equal(t, "tu-1", resp.Items[1].CallID)
+	assert.JSONEq(t, `{"path":"go.mod"}`, resp.Items[1].Arguments)
+	assert.Equal(t, models.FinishReasonToolCalls, resp.FinishReason)
+	assert.Equal(t, 12, resp.TokenUsage.PromptTokens)
+	assert.Equal(t, 19, resp.TokenUsage.TotalTokens)
+	assert.Equal(t, 100, resp.TokenUsage.CachedTokens)
+}
@@ -20,6 +20,10 @@ type LLMRequest struct {
 	ModelConfig models.ModelConfig        `json:"model_config"`
 	ToolSpecs   []tools.ToolSpec          `json:"tool_specs"`
 
+	// Prompt, when set by a PromptCache, is the session's cached assembly of
+	// ToolSpecs; providers reuse the tool definitions built from it.
+	Prompt *PromptAssembly `json:"-"`
+
 	// Instructions hierarchy (maps to Codex 3-tier system)
 	BaseInstructions      string `json:"base_instructions,omitempty"`
 	DeveloperInstructions string `json:"developer_instructions,omitempty"`
@@ -30,6 +34,31 @@ type LLMRequest struct {
 
 	// Web search mode (maps to Codex web_search_mode config)
 	WebSearchMode models.WebSearchMode `json:"web_search_mode,omitempty"`
+
+	// ResponseFormat, if set, requests JSON output matching a schema. The
+	// JSON is returned as the content of an assistant message.
+	ResponseFormat *models.ResponseFormat `json:"response_format,omitempty"`
+
+	// OnProgress, when set, asks the provider to stream and receives progress
+	// as deltas arrive. Providers that do not stream ignore it.
+	OnProgress func(StreamProgress) `json:"-"`
+
+	// SessionKey identifies the calling session for fair scheduling when a
+	// RateLimitedClient queues requests. OnQueued, when set, receives the
+	// request's 1-based queue position while it is queued, then 0. It is
+	// called while the limiter dispatches its queue and must not block.
+	SessionKey string             `json:"-"`
+	OnQueued   func(position int) `json:"-"`
+}
+
+// StreamProgress reports how much of a streaming response has arrived.
+// The LLM activity records it as heartbeat details so clients can render
+// "apply_patch building… 2.1 KB" while a large tool call is generated.
+type StreamProgress struct {
+	ToolName  string `json:"tool_name,omitempty"`  // Tool call currently streaming, if any
+	CallID    string `json:"call_id,omitempty"`    // Call ID of the streaming tool call
+	ArgBytes  int    `json:"arg_bytes,omitempty"`  // Argument JSON bytes received for ToolName
+	TextBytes int    `json:"text_bytes,omitempty"` // Assistant text bytes received so far
 }
 
 // LLMResponse represents a response from the LLM.
@@ -51,6 +80,7 @@ type LLMResponse struct {
 // Maps to: codex-rs/core/src/compact.rs CompactRequest
 type CompactRequest struct {
 	Model        string                      `json:"model"`
+	Provider     string                      `json:"provider,omitempty"` // "" = inferred from Model
 	Input        []models.ConversationItem   `json:"input"`
 	Instructions string                      `json:"instructions,omitempty"`
 }
@@ -3,6 +3,8 @@ package llm
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
 	"strings"
 )
 
@@ -14,13 +16,25 @@ import (
 type MultiProviderClient struct {
 	openai    *OpenAIClient
 	anthropic *AnthropicClient
+	bedrock   *BedrockClient
+	vertex    *VertexClient
 }
 
 // NewMultiProviderClient creates a client that can dispatch to multiple providers.
 func NewMultiProviderClient() *MultiProviderClient {
+	return NewMultiProviderClientWithHTTP(nil)
+}
+
+// NewMultiProviderClientWithHTTP is NewMultiProviderClient with the HTTP
+// client each provider sends its requests through, by provider name, e.g.
+// to use a proxy or a custom CA bundle. Providers without one use their
+// SDK's default client.
+func NewMultiProviderClientWithHTTP(httpClients map[string]*http.Client) *MultiProviderClient {
 	return &MultiProviderClient{
-		openai:    NewOpenAIClient(),
-		anthropic: NewAnthropicClient(),
+		openai:    newOpenAIClient(httpClients["openai"]),
+		anthropic: newAnthropicClient(httpClients["anthropic"]),
+		bedrock:   &BedrockClient{httpClient: httpClients["bedrock"]},
+		vertex:    &VertexClient{httpClient: httpClients["vertex"]},
 	}
 }
 
@@ -37,14 +51,22 @@ func (c *MultiProviderClient) Call(ctx context.Context, request LLMRequest) (LLM
 		return c.openai.Call(ctx, request)
 	case "anthropic":
 		return c.anthropic.Call(ctx, request)
+	case "bedrock":
+		return c.bedrock.Call(ctx, request)
+	case "vertex":
+		return c.vertex.Call(ctx, request)
 	default:
-		return LLMResponse{}, fmt.Errorf("unsupported LLM provider: %s (supported: openai, anthropic)", provider)
+		return LLMResponse{}, fmt.Errorf("unsupported LLM provider: %s (supported: %s)", provider, supportedProviders)
 	}
 }
 
-// Compact dispatches to the appropriate provider based on CompactRequest.Model.
+// Compact dispatches to the appropriate provider based on
+// CompactRequest.Provider, or on the model name when it is unset.
 func (c *MultiProviderClient) Compact(ctx context.Context, request CompactRequest) (CompactResponse, error) {
-	provider := detectProviderFromModel(request.Model)
+	provider := request.Provider
+	if provider == "" {
+		provider = detectProviderFromModel(request.Model)
+	}
 
 	switch provider {
 	case "openai":
@@ -56,19 +78,68 @@ func (c *MultiProviderClient) Compact(ctx context.Context, request CompactReques
 		return resp, nil
 	case "anthropic":
 		return c.anthropic.Compact(ctx, request)
+	case "bedrock":
+		return c.bedrock.Compact(ctx, request)
+	case "vertex":
+		return c.vertex.Compact(ctx, request)
 	default:
 		return c.anthropic.Compact(ctx, request)
 	}
 }
 
+// supportedProviders lists the provider names for error messages.
+const supportedProviders = "openai, anthropic, bedrock, vertex"
+
 // detectProviderFromModel infers the provider from the model name.
 func detectProviderFromModel(model string) string {
-	if strings.HasPrefix(model, "claude") {
+	switch {
+	case strings.HasPrefix(model, "claude"):
 		return "anthropic"
+	case strings.Contains(model, "anthropic.claude"), strings.HasPrefix(model, "arn:aws:bedrock"):
+		return "bedrock"
+	case strings.HasPrefix(model, "gemini"):
+		return "vertex"
 	}
 	return "openai"
 }
 
+// ProviderEndpoint returns the base URL the provider's client connects to,
+// for network checks, or "" when the provider's region or project is not
+// configured.
+func ProviderEndpoint(provider string) string {
+	switch provider {
+	case "openai":
+		if u := os.Getenv("OPENAI_BASE_URL"); u != "" {
+			return u
+		}
+		return "https://api.openai.com/v1/"
+	case "anthropic":
+		if u := os.Getenv("ANTHROPIC_BASE_URL"); u != "" {
+			return u
+		}
+		return "https://api.anthropic.com/"
+	case "bedrock":
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = os.Getenv("AWS_DEFAULT_REGION")
+		}
+		if region == "" {
+			return ""
+		}
+		return "https://bedrock-runtime." + region + ".amazonaws.com/"
+	case "vertex":
+		if !VertexConfigured() {
+			return ""
+		}
+		location := os.Getenv("GOOGLE_CLOUD_LOCATION")
+		if location == "" {
+			location = defaultVertexLocation
+		}
+		return "https://" + location + "-aiplatform.googleapis.com/"
+	}
+	return ""
+}
+
 // NewLLMClient creates the appropriate LLM client based on provider name.
 // This is a convenience function for cases where you know the provider at init time.
 //
@@ -79,7 +150,11 @@ func NewLLMClient(provider string) (LLMClient, error) {
 		return NewOpenAIClient(), nil
 	case "anthropic":
 		return NewAnthropicClient(), nil
+	case "bedrock":
+		return NewBedrockClient(), nil
+	case "vertex":
+		return NewVertexClient(), nil
 	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: openai, anthropic)", provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: %s)", provider, supportedProviders)
 	}
 }
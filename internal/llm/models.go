@@ -14,14 +14,15 @@ import (
 
 // AvailableModel describes a model returned by a provider's list-models API.
 type AvailableModel struct {
-	Provider    string // "openai" or "anthropic"
+	Provider    string // "openai", "anthropic", "bedrock" or "vertex"
 	ID          string // model identifier usable in API calls
 	DisplayName string // human-readable name (Anthropic provides this; empty for OpenAI)
 }
 
 // FetchAvailableModels queries each provider's Models.List API and returns a
 // merged, sorted list. Providers whose API key env-var is unset are silently
-// skipped. If every provider fails or is skipped the function returns (nil, nil)
+// skipped; Bedrock is queried when an AWS region is set and Vertex when a
+// Google Cloud project is. If every provider fails or is skipped the function returns (nil, nil)
 // to signal the caller should fall back to a hardcoded list.
 func FetchAvailableModels(ctx context.Context) ([]AvailableModel, error) {
 	var all []AvailableModel
@@ -40,14 +41,28 @@ func FetchAvailableModels(ctx context.Context) ([]AvailableModel, error) {
 		}
 	}
 
+	if BedrockConfigured() {
+		models, err := fetchBedrockModels(ctx)
+		if err == nil {
+			all = append(all, models...)
+		}
+	}
+
+	if VertexConfigured() {
+		models, err := fetchVertexModels(ctx)
+		if err == nil {
+			all = append(all, models...)
+		}
+	}
+
 	if len(all) == 0 {
 		return nil, nil
 	}
 
-	// Sort: anthropic first, then alphabetical by ID within each provider.
+	// Sort by provider name, then alphabetical by ID within each provider.
 	sort.Slice(all, func(i, j int) bool {
 		if all[i].Provider != all[j].Provider {
-			return all[i].Provider < all[j].Provider // "anthropic" < "openai"
+			return all[i].Provider < all[j].Provider // "anthropic" < "bedrock" < "openai" < "vertex"
 		}
 		return all[i].ID < all[j].ID
 	})
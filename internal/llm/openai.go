@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/mfateev/temporal-agent-harness/internal/models"
@@ -25,9 +27,17 @@ type OpenAIClient struct {
 
 // NewOpenAIClient creates an OpenAI client.
 func NewOpenAIClient() *OpenAIClient {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	client := openai.NewClient(option.WithAPIKey(apiKey))
-	return &OpenAIClient{client: client}
+	return newOpenAIClient(nil)
+}
+
+// newOpenAIClient creates an OpenAI client that sends requests through hc,
+// or the default HTTP client when hc is nil.
+func newOpenAIClient(hc *http.Client) *OpenAIClient {
+	opts := []option.RequestOption{option.WithAPIKey(os.Getenv("OPENAI_API_KEY"))}
+	if hc != nil {
+		opts = append(opts, option.WithHTTPClient(hc))
+	}
+	return &OpenAIClient{client: openai.NewClient(opts...)}
 }
 
 // Call sends a request to OpenAI's Responses API and returns the complete response.
@@ -35,7 +45,13 @@ func NewOpenAIClient() *OpenAIClient {
 // - AssistantMessage item for text content
 // - Separate FunctionCall items for each tool call
 func (c *OpenAIClient) Call(ctx context.Context, request LLMRequest) (LLMResponse, error) {
-	input := c.buildInput(request.History)
+	// An incremental send continues a response OpenAI already holds; its
+	// outputs answer calls that are not in this slice of history.
+	history := request.History
+	if request.PreviousResponseID == "" {
+		history = openAIHistory.translate(history)
+	}
+	input := c.buildInput(history)
 
 	params := responses.ResponseNewParams{
 		Model: shared.ResponsesModel(request.ModelConfig.Model),
@@ -71,7 +87,14 @@ func (c *OpenAIClient) Call(ctx context.Context, request LLMRequest) (LLMRespons
 
 	// Tool definitions (function tools + optional web search)
 	if len(request.ToolSpecs) > 0 || request.WebSearchMode != "" {
-		params.Tools = c.buildToolDefinitions(request.ToolSpecs, request.WebSearchMode)
+		params.Tools = cachedToolDefinitions(request, "openai", func(specs []tools.ToolSpec) []responses.ToolUnionParam {
+			return c.buildToolDefinitions(specs, request.WebSearchMode)
+		})
+	}
+
+	// Structured outputs
+	if request.ResponseFormat != nil {
+		params.Text = buildOpenAITextConfig(request.ResponseFormat)
 	}
 
 	// Previous response ID for incremental sends
@@ -82,6 +105,13 @@ func (c *OpenAIClient) Call(ctx context.Context, request LLMRequest) (LLMRespons
 	// Store for response persistence
 	params.Store = param.NewOpt(true)
 
+	// Route every request of a session to the same prompt cache. OpenAI caches
+	// automatically by prefix; the key only improves hit rates across
+	// machines, so it is safe to omit when no session is known.
+	if request.SessionKey != "" {
+		params.PromptCacheKey = param.NewOpt(request.SessionKey)
+	}
+
 	resp, err := c.client.Responses.New(ctx, params)
 	if err != nil {
 		return LLMResponse{}, classifyError(err)
@@ -102,6 +132,24 @@ func (c *OpenAIClient) Call(ctx context.Context, request LLMRequest) (LLMRespons
 	}, nil
 }
 
+// buildOpenAITextConfig maps a ResponseFormat to Responses API structured
+// outputs (text.format = json_schema).
+func buildOpenAITextConfig(rf *models.ResponseFormat) responses.ResponseTextConfigParam {
+	format := &responses.ResponseFormatTextJSONSchemaConfigParam{
+		Name:   rf.Name,
+		Schema: rf.Schema,
+	}
+	if rf.Strict {
+		format.Strict = param.NewOpt(true)
+	}
+	if rf.Description != "" {
+		format.Description = param.NewOpt(rf.Description)
+	}
+	return responses.ResponseTextConfigParam{
+		Format: responses.ResponseFormatTextConfigUnionParam{OfJSONSchema: format},
+	}
+}
+
 // buildInput converts conversation history to Responses API input items.
 //
 // Type mapping:
@@ -125,6 +173,16 @@ func (c *OpenAIClient) buildInput(history []models.ConversationItem) []responses
 				},
 			})
 
+		case models.ItemTypeUserShellCommand:
+			items = append(items, responses.ResponseInputItemUnionParam{
+				OfMessage: &responses.EasyInputMessageParam{
+					Role: responses.EasyInputMessageRoleUser,
+					Content: responses.EasyInputMessageContentUnionParam{
+						OfString: param.NewOpt(models.FormatUserShellCommand(item)),
+					},
+				},
+			})
+
 		case models.ItemTypeAssistantMessage:
 			items = append(items, responses.ResponseInputItemUnionParam{
 				OfOutputMessage: &responses.ResponseOutputMessageParam{
@@ -175,9 +233,9 @@ func (c *OpenAIClient) buildInput(history []models.ConversationItem) []responses
 				OfWebSearchCall: wsParam,
 			})
 
-		case models.ItemTypeModelSwitch:
-			// Model-switch messages are sent as developer-role messages so
-			// the new model has context about the transition.
+		case models.ItemTypeModelSwitch, models.ItemTypeDeveloperMessage:
+			// Model-switch and injected developer messages are sent as
+			// developer-role messages, apart from the user's own messages.
 			items = append(items, responses.ResponseInputItemUnionParam{
 				OfMessage: &responses.EasyInputMessageParam{
 					Role: responses.EasyInputMessageRoleDeveloper,
@@ -202,7 +260,12 @@ func (c *OpenAIClient) buildInput(history []models.ConversationItem) []responses
 
 // buildInstructions combines BaseInstructions + UserInstructions into a single
 // instructions string for the Responses API Instructions parameter.
-// DeveloperInstructions are prepended with a [Developer] header.
+// DeveloperInstructions are appended with a [Developer Instructions] header.
+//
+// Sections are ordered from most to least stable so OpenAI's automatic prefix
+// caching can reuse as much of the prompt as possible: base instructions never
+// change within a session, user instructions (project docs) rarely do, and
+// developer instructions change with approval mode and memory injection.
 func (c *OpenAIClient) buildInstructions(request LLMRequest) string {
 	// Build system-level instructions from base + user
 	systemContent := request.BaseInstructions
@@ -292,11 +355,19 @@ func (c *OpenAIClient) parseOutput(resp *responses.Response) ([]models.Conversat
 // buildToolDefinitions converts ToolSpecs to Responses API tool definitions.
 // Also appends a web_search tool if WebSearchMode is set.
 //
+// Function tools are sorted by name so the serialized tool block is identical
+// across calls regardless of registration or MCP discovery order; any
+// reordering would invalidate the provider's prompt cache from that point on.
+//
 // Maps to: codex-rs/core/src/tools/spec.rs web_search_mode handling
 func (c *OpenAIClient) buildToolDefinitions(specs []tools.ToolSpec, webSearchMode models.WebSearchMode) []responses.ToolUnionParam {
 	toolDefs := make([]responses.ToolUnionParam, 0, len(specs)+1)
 
-	for _, spec := range specs {
+	sorted := make([]tools.ToolSpec, len(specs))
+	copy(sorted, specs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, spec := range sorted {
 		var paramSchema map[string]interface{}
 
 		if spec.RawJSONSchema != nil {
@@ -545,7 +616,7 @@ func classifyError(err error) error {
 
 	// Use typed error for status-code-based classification
 	if apiErr, ok := err.(*openai.Error); ok {
-		return classifyByStatusCode(apiErr.StatusCode, err)
+		return withRateLimitHint(classifyByStatusCode(apiErr.StatusCode, err), apiErr.Response)
 	}
 
 	// Fallback: message-based heuristics for non-typed errors (e.g., network errors)
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/mfateev/temporal-agent-harness/internal/models"
@@ -124,6 +125,43 @@ func TestBuildInput_SkipsTurnMarkers(t *testing.T) {
 	require.NotNil(t, items[0].OfMessage)
 }
 
+// TestBuildInput_DeveloperMessage verifies injected developer messages are
+// sent with the developer role, not as user messages.
+func TestBuildInput_DeveloperMessage(t *testing.T) {
+	client := &OpenAIClient{}
+	history := []models.ConversationItem{
+		{Type: models.ItemTypeUserMessage, Content: "fix the build"},
+		{Type: models.ItemTypeDeveloperMessage, Content: "do not touch go.mod"},
+	}
+
+	items := client.buildInput(history)
+
+	require.Len(t, items, 2)
+	require.NotNil(t, items[1].OfMessage)
+	assert.Equal(t, responses.EasyInputMessageRoleDeveloper, items[1].OfMessage.Role)
+	assert.Equal(t, "do not touch go.mod", items[1].OfMessage.Content.OfString.Value)
+}
+
+// TestBuildInput_UserShellCommand verifies a command the user ran is sent as
+// a user message with its output.
+func TestBuildInput_UserShellCommand(t *testing.T) {
+	client := &OpenAIClient{}
+	ok := true
+	history := []models.ConversationItem{{
+		Type:      models.ItemTypeUserShellCommand,
+		Arguments: `{"command":"git status"}`,
+		Output:    &models.FunctionCallOutputPayload{Content: "Exit code: 0\nOutput:\nclean\n", Success: &ok},
+	}}
+
+	items := client.buildInput(history)
+
+	require.Len(t, items, 1)
+	require.NotNil(t, items[0].OfMessage)
+	assert.Equal(t, responses.EasyInputMessageRoleUser, items[0].OfMessage.Role)
+	assert.Equal(t, "<user_shell_command>\n<command>\ngit status\n</command>\n<result>\nExit code: 0\nOutput:\nclean\n</result>\n</user_shell_command>",
+		items[0].OfMessage.Content.OfString.Value)
+}
+
 // TestBuildInput_MixedHistory verifies a full conversation roundtrip with all item types.
 func TestBuildInput_MixedHistory(t *testing.T) {
 	client := &OpenAIClient{}
@@ -198,6 +236,26 @@ func TestBuildToolDefinitions(t *testing.T) {
 	assert.NotContains(t, required, "timeout_ms")
 }
 
+// TestBuildToolDefinitions_SortedByName verifies function tools are emitted in
+// name order regardless of input order, keeping the cacheable prefix stable.
+func TestBuildToolDefinitions_SortedByName(t *testing.T) {
+	client := &OpenAIClient{}
+	specs := []tools.ToolSpec{
+		{Name: "write_file", Description: "w"},
+		{Name: "apply_patch", Description: "a"},
+		{Name: "shell", Description: "s"},
+	}
+
+	defs := client.buildToolDefinitions(specs, models.WebSearchLive)
+
+	require.Len(t, defs, 4)
+	assert.Equal(t, "apply_patch", defs[0].OfFunction.Name)
+	assert.Equal(t, "shell", defs[1].OfFunction.Name)
+	assert.Equal(t, "write_file", defs[2].OfFunction.Name)
+	assert.NotNil(t, defs[3].OfWebSearch, "web search stays last")
+	assert.Equal(t, "write_file", specs[0].Name, "input slice must not be reordered")
+}
+
 // --- Tests for buildInstructions ---
 
 // TestBuildInstructions_BaseOnly verifies base instructions alone.
@@ -771,6 +829,80 @@ func TestCall_StoreEnabled(t *testing.T) {
 	assert.Equal(t, true, capturedBody["store"], "store must be true")
 }
 
+// TestCall_PromptCacheKeySent verifies that the session key is forwarded as
+// prompt_cache_key so all requests of a session share a prompt cache.
+func TestCall_PromptCacheKeySent(t *testing.T) {
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &capturedBody))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, fakeResponsesAPIResponse())
+	}))
+	defer server.Close()
+
+	client := &OpenAIClient{
+		client: openai.NewClient(
+			option.WithBaseURL(server.URL),
+			option.WithAPIKey("test-key"),
+		),
+	}
+
+	request := LLMRequest{
+		History: []models.ConversationItem{
+			{Type: models.ItemTypeUserMessage, Content: "hello"},
+		},
+		ModelConfig: models.DefaultModelConfig(),
+		SessionKey:  "codex-session-1",
+	}
+
+	_, err := client.Call(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "codex-session-1", capturedBody["prompt_cache_key"])
+
+	capturedBody = nil
+	request.SessionKey = ""
+	_, err = client.Call(context.Background(), request)
+	require.NoError(t, err)
+	_, hasKey := capturedBody["prompt_cache_key"]
+	assert.False(t, hasKey, "prompt_cache_key must be omitted without a session key")
+}
+
+// TestCall_OpenAICachedTokensReported verifies that input_tokens_details.cached_tokens
+// from the Responses API usage is surfaced as TokenUsage.CachedTokens.
+func TestCall_OpenAICachedTokensReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, strings.Replace(fakeResponsesAPIResponse(),
+			`"input_tokens": 10, "output_tokens": 5, "total_tokens": 15, "input_tokens_details": {"cached_tokens": 0}`,
+			`"input_tokens": 4000, "output_tokens": 5, "total_tokens": 4005, "input_tokens_details": {"cached_tokens": 3072}`, 1))
+	}))
+	defer server.Close()
+
+	client := &OpenAIClient{
+		client: openai.NewClient(
+			option.WithBaseURL(server.URL),
+			option.WithAPIKey("test-key"),
+		),
+	}
+
+	resp, err := client.Call(context.Background(), LLMRequest{
+		History: []models.ConversationItem{
+			{Type: models.ItemTypeUserMessage, Content: "hello"},
+		},
+		ModelConfig: models.DefaultModelConfig(),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4000, resp.TokenUsage.PromptTokens)
+	assert.Equal(t, 3072, resp.TokenUsage.CachedTokens)
+}
+
 // TestCall_ResponseIDReturned verifies that the response ID is captured from the API response.
 func TestCall_ResponseIDReturned(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1071,3 +1203,27 @@ func TestBuildInput_WebSearchCall(t *testing.T) {
 	assert.Equal(t, "ws_123", items[0].OfWebSearchCall.ID)
 	assert.Equal(t, responses.ResponseFunctionWebSearchStatus("completed"), items[0].OfWebSearchCall.Status)
 }
+
+// TestBuildOpenAITextConfig verifies a ResponseFormat maps to a json_schema
+// text format.
+func TestBuildOpenAITextConfig(t *testing.T) {
+	cfg := buildOpenAITextConfig(&models.ResponseFormat{
+		Name:        "subagent_result",
+		Description: "The task result",
+		Schema:      map[string]interface{}{"type": "object"},
+		Strict:      true,
+	})
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	var wire map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &wire))
+
+	format, ok := wire["format"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "json_schema", format["type"])
+	assert.Equal(t, "subagent_result", format["name"])
+	assert.Equal(t, "The task result", format["description"])
+	assert.Equal(t, true, format["strict"])
+	assert.Equal(t, map[string]interface{}{"type": "object"}, format["schema"])
+}
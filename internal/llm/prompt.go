@@ -0,0 +1,190 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// Prompt cache bounds.
+const (
+	DefaultPromptCacheSessions = 256
+	promptAssembliesPerSession = 4 // Main loop plus the occasional side call
+)
+
+// PromptAssembly is the part of a request that stays the same across a
+// session's LLM calls: the tool specs, in a fixed order, and the
+// instructions. Providers cache a prompt by its prefix, so keeping these
+// byte-identical from call to call is what lets them hit.
+type PromptAssembly struct {
+	// Hash identifies the model, instructions and tools of the assembly.
+	// A change between two calls of a session means the provider's prompt
+	// cache could not be reused.
+	Hash string
+
+	// ToolSpecs are the request's tool specs sorted by name.
+	ToolSpecs []tools.ToolSpec
+
+	mu    sync.Mutex
+	tools map[string]any // Provider tool definitions, by provider
+}
+
+// promptKey is what PromptHash hashes.
+type promptKey struct {
+	Provider              string                 `json:"provider"`
+	Model                 string                 `json:"model"`
+	BaseInstructions      string                 `json:"base_instructions,omitempty"`
+	UserInstructions      string                 `json:"user_instructions,omitempty"`
+	DeveloperInstructions string                 `json:"developer_instructions,omitempty"`
+	WebSearchMode         models.WebSearchMode   `json:"web_search_mode,omitempty"`
+	ResponseFormat        *models.ResponseFormat `json:"response_format,omitempty"`
+	ToolSpecs             []tools.ToolSpec       `json:"tool_specs"`
+}
+
+// PromptHash returns a short hash of the request's model, instructions and
+// tool specs. Tool order does not affect it.
+func PromptHash(request LLMRequest) string {
+	return promptHash(request, sortedToolSpecs(request.ToolSpecs))
+}
+
+func promptHash(request LLMRequest, sorted []tools.ToolSpec) string {
+	data, _ := json.Marshal(promptKey{
+		Provider:              request.ModelConfig.Provider,
+		Model:                 request.ModelConfig.Model,
+		BaseInstructions:      request.BaseInstructions,
+		UserInstructions:      request.UserInstructions,
+		DeveloperInstructions: request.DeveloperInstructions,
+		WebSearchMode:         request.WebSearchMode,
+		ResponseFormat:        request.ResponseFormat,
+		ToolSpecs:             sorted,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// sortedToolSpecs returns a copy of specs sorted by name, so the tool block
+// does not depend on registration or MCP discovery order.
+func sortedToolSpecs(specs []tools.ToolSpec) []tools.ToolSpec {
+	sorted := make([]tools.ToolSpec, len(specs))
+	copy(sorted, specs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// cachedToolDefinitions returns build(request.ToolSpecs), built once per
+// prompt assembly and provider. Without an assembly the specs are sorted and
+// built on every call.
+func cachedToolDefinitions[T any](request LLMRequest, provider string, build func([]tools.ToolSpec) T) T {
+	a := request.Prompt
+	if a == nil {
+		return build(sortedToolSpecs(request.ToolSpecs))
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if defs, ok := a.tools[provider].(T); ok {
+		return defs
+	}
+	defs := build(a.ToolSpecs)
+	if a.tools == nil {
+		a.tools = make(map[string]any)
+	}
+	a.tools[provider] = defs
+	return defs
+}
+
+// PromptCache keeps each session's recent prompt assemblies so that
+// iterations with unchanged instructions and tools reuse the sorted specs
+// and the provider tool definitions built from them.
+type PromptCache struct {
+	maxSessions int
+
+	mu       sync.Mutex
+	sessions map[string][]*PromptAssembly // Most recent first
+	order    []string                     // Session keys, least recently used first
+}
+
+// NewPromptCache creates a cache holding the assemblies of up to
+// maxSessions sessions; 0 uses DefaultPromptCacheSessions.
+func NewPromptCache(maxSessions int) *PromptCache {
+	if maxSessions <= 0 {
+		maxSessions = DefaultPromptCacheSessions
+	}
+	return &PromptCache{maxSessions: maxSessions, sessions: make(map[string][]*PromptAssembly)}
+}
+
+// Apply sets request.Prompt to the session's assembly for the request,
+// reusing a cached one with the same hash, and replaces request.ToolSpecs
+// with its sorted specs. Requests without a SessionKey are assembled but not
+// cached. Returns the assembly's hash.
+func (c *PromptCache) Apply(request *LLMRequest) string {
+	sorted := sortedToolSpecs(request.ToolSpecs)
+	hash := promptHash(*request, sorted)
+
+	a := c.lookup(request.SessionKey, hash)
+	if a == nil {
+		a = &PromptAssembly{Hash: hash, ToolSpecs: sorted}
+		c.store(request.SessionKey, a)
+	}
+	request.Prompt = a
+	request.ToolSpecs = a.ToolSpecs
+	return hash
+}
+
+func (c *PromptCache) lookup(session, hash string) *PromptAssembly {
+	if session == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, a := range c.sessions[session] {
+		if a.Hash == hash {
+			list := c.sessions[session]
+			copy(list[1:i+1], list[:i])
+			list[0] = a
+			c.touchLocked(session)
+			return a
+		}
+	}
+	return nil
+}
+
+func (c *PromptCache) store(session string, a *PromptAssembly) {
+	if session == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := append([]*PromptAssembly{a}, c.sessions[session]...)
+	if len(list) > promptAssembliesPerSession {
+		list = list[:promptAssembliesPerSession]
+	}
+	c.sessions[session] = list
+	c.touchLocked(session)
+	for len(c.order) > c.maxSessions {
+		delete(c.sessions, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// touchLocked moves session to the most recently used end of the order.
+func (c *PromptCache) touchLocked(session string) {
+	for i, s := range c.order {
+		if s == session {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, session)
+}
+
+// Sessions returns how many sessions have cached assemblies.
+func (c *PromptCache) Sessions() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sessions)
+}
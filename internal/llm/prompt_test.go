@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func promptTestRequest(session string, names ...string) LLMRequest {
+	specs := make([]tools.ToolSpec, len(names))
+	for i, name := range names {
+		specs[i] = tools.ToolSpec{Name: name, Description: name + " tool"}
+	}
+	return LLMRequest{
+		ModelConfig:      models.ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4.5"},
+		BaseInstructions: "You are a coding agent.",
+		ToolSpecs:        specs,
+		SessionKey:       session,
+	}
+}
+
+func TestPromptHash(t *testing.T) {
+	base := PromptHash(promptTestRequest("", "shell", "read_file"))
+	assert.Len(t, base, 16)
+	assert.Equal(t, base, PromptHash(promptTestRequest("", "read_file", "shell")), "tool order does not matter")
+
+	changed := promptTestRequest("", "shell", "read_file")
+	changed.DeveloperInstructions = "Approval mode: never"
+	assert.NotEqual(t, base, PromptHash(changed))
+
+	otherModel := promptTestRequest("", "shell", "read_file")
+	otherModel.ModelConfig.Model = "claude-haiku-4.5"
+	assert.NotEqual(t, base, PromptHash(otherModel))
+
+	assert.NotEqual(t, base, PromptHash(promptTestRequest("", "shell")))
+}
+
+func TestPromptCache_ReusesAssemblyPerSession(t *testing.T) {
+	cache := NewPromptCache(0)
+
+	first := promptTestRequest("wf-1", "shell", "apply_patch", "read_file")
+	hash := cache.Apply(&first)
+	require.NotNil(t, first.Prompt)
+	assert.Equal(t, []string{"apply_patch", "read_file", "shell"}, specNames(first.ToolSpecs), "specs are sorted")
+
+	builds := 0
+	build := func(specs []tools.ToolSpec) []string {
+		builds++
+		return specNames(specs)
+	}
+	assert.Equal(t, []string{"apply_patch", "read_file", "shell"}, cachedToolDefinitions(first, "test", build))
+
+	second := promptTestRequest("wf-1", "read_file", "shell", "apply_patch")
+	assert.Equal(t, hash, cache.Apply(&second))
+	assert.Same(t, first.Prompt, second.Prompt, "the next iteration reuses the assembly")
+	cachedToolDefinitions(second, "test", build)
+	assert.Equal(t, 1, builds, "tool definitions are built once per assembly")
+
+	changed := promptTestRequest("wf-1", "shell")
+	assert.NotEqual(t, hash, cache.Apply(&changed))
+	assert.NotSame(t, first.Prompt, changed.Prompt)
+
+	// A side call with other tools does not evict the main assembly.
+	again := promptTestRequest("wf-1", "shell", "apply_patch", "read_file")
+	cache.Apply(&again)
+	assert.Same(t, first.Prompt, again.Prompt)
+
+	other := promptTestRequest("wf-2", "shell", "apply_patch", "read_file")
+	cache.Apply(&other)
+	assert.NotSame(t, first.Prompt, other.Prompt, "sessions do not share assemblies")
+}
+
+func TestPromptCache_WithoutSessionKey(t *testing.T) {
+	cache := NewPromptCache(0)
+	req := promptTestRequest("", "shell", "read_file")
+	cache.Apply(&req)
+	require.NotNil(t, req.Prompt)
+	assert.Equal(t, 0, cache.Sessions())
+}
+
+func TestPromptCache_EvictsLeastRecentlyUsedSession(t *testing.T) {
+	cache := NewPromptCache(2)
+	a := promptTestRequest("wf-a", "shell")
+	cache.Apply(&a)
+	b := promptTestRequest("wf-b", "shell")
+	cache.Apply(&b)
+	a2 := promptTestRequest("wf-a", "shell")
+	cache.Apply(&a2) // wf-a is now the most recent
+	c := promptTestRequest("wf-c", "shell")
+	cache.Apply(&c)
+
+	assert.Equal(t, 2, cache.Sessions())
+	a3 := promptTestRequest("wf-a", "shell")
+	cache.Apply(&a3)
+	assert.Same(t, a.Prompt, a3.Prompt, "wf-a was kept")
+	b2 := promptTestRequest("wf-b", "shell")
+	cache.Apply(&b2)
+	assert.NotSame(t, b.Prompt, b2.Prompt, "wf-b was evicted")
+}
+
+func specNames(specs []tools.ToolSpec) []string {
+	names := make([]string, len(specs))
+	for i, s := range specs {
+		names[i] = s.Name
+	}
+	return names
+}
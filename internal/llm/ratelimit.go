@@ -0,0 +1,431 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// rateLimitWindow is the sliding window over which per-minute budgets apply.
+const rateLimitWindow = time.Minute
+
+// RateLimit is a per-provider request budget. Zero fields mean unlimited.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// IsZero reports whether the limit imposes no budget at all.
+func (l RateLimit) IsZero() bool {
+	return l.RequestsPerMinute <= 0 && l.TokensPerMinute <= 0
+}
+
+// rateLimitProviders lists the providers whose budgets are read from the
+// environment by RateLimitsFromEnv.
+var rateLimitProviders = []string{"openai", "anthropic", "bedrock", "vertex"}
+
+// RateLimitsFromEnv reads per-provider budgets from <PROVIDER>_RPM and
+// <PROVIDER>_TPM (e.g. OPENAI_RPM=500, ANTHROPIC_TPM=80000). Providers with
+// neither variable set are omitted.
+func RateLimitsFromEnv(getenv func(string) string) (map[string]RateLimit, error) {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	limits := make(map[string]RateLimit)
+	for _, provider := range rateLimitProviders {
+		prefix := strings.ToUpper(provider)
+		rpm, err := parseRateEnv(getenv, prefix+"_RPM")
+		if err != nil {
+			return nil, err
+		}
+		tpm, err := parseRateEnv(getenv, prefix+"_TPM")
+		if err != nil {
+			return nil, err
+		}
+		limit := RateLimit{RequestsPerMinute: rpm, TokensPerMinute: tpm}
+		if !limit.IsZero() {
+			limits[provider] = limit
+		}
+	}
+	return limits, nil
+}
+
+func parseRateEnv(getenv func(string) string, name string) (int, error) {
+	v := getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, v)
+	}
+	return n, nil
+}
+
+// RateLimiter enforces a RateLimit over a sliding one-minute window and
+// queues callers that would exceed it. Queued requests are granted
+// round-robin across sessions, so one busy session cannot starve the others.
+type RateLimiter struct {
+	limit RateLimit
+	now   func() time.Time
+
+	mu       sync.Mutex
+	requests []time.Time    // Grant times within the window
+	charges  []*tokenCharge // Token charges within the window
+	queues   map[string][]*rateWaiter
+	order    []string // Round-robin order of sessions with queued requests
+	wake     *time.Timer
+}
+
+type tokenCharge struct {
+	at     time.Time
+	tokens int
+}
+
+type rateWaiter struct {
+	session  string
+	tokens   int
+	ready    chan *Reservation
+	notify   func(position int)
+	position int // Last position reported via notify
+}
+
+// Reservation is a granted request slot. Call Settle once the actual token
+// usage is known so the budget reflects real consumption.
+type Reservation struct {
+	limiter *RateLimiter
+	charge  *tokenCharge
+}
+
+// queueNotification is a position update delivered outside the lock.
+type queueNotification struct {
+	notify   func(int)
+	position int
+}
+
+// NewRateLimiter creates a limiter for the given budget.
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		now:    time.Now,
+		queues: make(map[string][]*rateWaiter),
+	}
+}
+
+// Acquire blocks until the budget allows a request of roughly tokens tokens
+// for session, or ctx is done. While queued, onQueued (if non-nil) receives
+// the request's 1-based queue position whenever it changes, and 0 once the
+// request is granted. A request larger than the whole token budget is
+// admitted when the window is otherwise empty.
+func (l *RateLimiter) Acquire(ctx context.Context, session string, tokens int, onQueued func(position int)) (*Reservation, error) {
+	l.mu.Lock()
+	l.pruneLocked()
+	if len(l.order) == 0 && l.hasCapacityLocked(tokens) {
+		r := l.grantLocked(tokens)
+		l.mu.Unlock()
+		return r, nil
+	}
+
+	w := &rateWaiter{
+		session: session,
+		tokens:  tokens,
+		ready:   make(chan *Reservation, 1),
+		notify:  onQueued,
+	}
+	if len(l.queues[session]) == 0 {
+		l.order = append(l.order, session)
+	}
+	l.queues[session] = append(l.queues[session], w)
+	notes := l.dispatchLocked()
+	l.mu.Unlock()
+	deliver(notes)
+
+	select {
+	case r := <-w.ready:
+		return r, nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	select {
+	case r := <-w.ready:
+		// Granted concurrently with cancellation: return the tokens.
+		l.mu.Unlock()
+		r.Settle(0)
+		return nil, ctx.Err()
+	default:
+	}
+	l.removeLocked(w)
+	notes = l.positionsLocked()
+	l.mu.Unlock()
+	deliver(notes)
+	return nil, ctx.Err()
+}
+
+// Queued returns the number of requests currently waiting.
+func (l *RateLimiter) Queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, q := range l.queues {
+		n += len(q)
+	}
+	return n
+}
+
+// Settle replaces the reservation's estimated token charge with the actual
+// usage and admits any requests the freed budget allows.
+func (r *Reservation) Settle(actualTokens int) {
+	if r == nil || r.limiter == nil {
+		return
+	}
+	l := r.limiter
+	l.mu.Lock()
+	r.charge.tokens = actualTokens
+	l.pruneLocked()
+	notes := l.dispatchLocked()
+	l.mu.Unlock()
+	deliver(notes)
+}
+
+// pruneLocked drops requests and charges older than the window.
+func (l *RateLimiter) pruneLocked() {
+	cutoff := l.now().Add(-rateLimitWindow)
+	i := 0
+	for i < len(l.requests) && !l.requests[i].After(cutoff) {
+		i++
+	}
+	l.requests = l.requests[i:]
+	j := 0
+	for j < len(l.charges) && !l.charges[j].at.After(cutoff) {
+		j++
+	}
+	l.charges = l.charges[j:]
+}
+
+// hasCapacityLocked reports whether a request of tokens tokens fits now.
+func (l *RateLimiter) hasCapacityLocked(tokens int) bool {
+	if rpm := l.limit.RequestsPerMinute; rpm > 0 && len(l.requests) >= rpm {
+		return false
+	}
+	if tpm := l.limit.TokensPerMinute; tpm > 0 {
+		used := 0
+		for _, c := range l.charges {
+			used += c.tokens
+		}
+		if used > 0 && used+tokens > tpm {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *RateLimiter) grantLocked(tokens int) *Reservation {
+	now := l.now()
+	l.requests = append(l.requests, now)
+	c := &tokenCharge{at: now, tokens: tokens}
+	l.charges = append(l.charges, c)
+	return &Reservation{limiter: l, charge: c}
+}
+
+// dispatchLocked grants queued requests round-robin while the budget allows,
+// schedules a wake-up for when it next frees up, and returns position
+// updates for the remaining waiters.
+func (l *RateLimiter) dispatchLocked() []queueNotification {
+	var notes []queueNotification
+	for len(l.order) > 0 {
+		session := l.order[0]
+		q := l.queues[session]
+		w := q[0]
+		if !l.hasCapacityLocked(w.tokens) {
+			l.scheduleWakeLocked()
+			break
+		}
+		l.order = l.order[1:]
+		if len(q) > 1 {
+			l.queues[session] = q[1:]
+			l.order = append(l.order, session)
+		} else {
+			delete(l.queues, session)
+		}
+		w.ready <- l.grantLocked(w.tokens)
+		if w.position > 0 && w.notify != nil {
+			notes = append(notes, queueNotification{notify: w.notify, position: 0})
+		}
+	}
+	return append(notes, l.positionsLocked()...)
+}
+
+// positionsLocked computes each waiter's 1-based place in the round-robin
+// schedule and returns updates for those whose position changed.
+func (l *RateLimiter) positionsLocked() []queueNotification {
+	var notes []queueNotification
+	for i, session := range l.order {
+		for j, w := range l.queues[session] {
+			position := j + 1
+			for k, other := range l.order {
+				if k == i {
+					continue
+				}
+				ahead := j
+				if k < i {
+					ahead++
+				}
+				position += min(len(l.queues[other]), ahead)
+			}
+			if position != w.position {
+				w.position = position
+				if w.notify != nil {
+					notes = append(notes, queueNotification{notify: w.notify, position: position})
+				}
+			}
+		}
+	}
+	return notes
+}
+
+// removeLocked drops a cancelled waiter from its session queue.
+func (l *RateLimiter) removeLocked(w *rateWaiter) {
+	q := l.queues[w.session]
+	for i, other := range q {
+		if other == w {
+			q = append(q[:i:i], q[i+1:]...)
+			break
+		}
+	}
+	if len(q) > 0 {
+		l.queues[w.session] = q
+		return
+	}
+	delete(l.queues, w.session)
+	for i, s := range l.order {
+		if s == w.session {
+			l.order = append(l.order[:i:i], l.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// scheduleWakeLocked arms a timer for when the oldest request or charge
+// leaves the window.
+func (l *RateLimiter) scheduleWakeLocked() {
+	var next time.Time
+	if len(l.requests) > 0 {
+		next = l.requests[0]
+	}
+	if len(l.charges) > 0 && (next.IsZero() || l.charges[0].at.Before(next)) {
+		next = l.charges[0].at
+	}
+	if next.IsZero() {
+		return
+	}
+	delay := next.Add(rateLimitWindow).Sub(l.now())
+	if delay < time.Millisecond {
+		delay = time.Millisecond
+	}
+	if l.wake != nil {
+		l.wake.Stop()
+	}
+	l.wake = time.AfterFunc(delay, func() {
+		l.mu.Lock()
+		l.pruneLocked()
+		notes := l.dispatchLocked()
+		l.mu.Unlock()
+		deliver(notes)
+	})
+}
+
+// deliver runs the queue callbacks outside the lock. They must not block:
+// a slow one would hold up the dispatch of every queued request.
+func deliver(notes []queueNotification) {
+	for _, n := range notes {
+		n.notify(n.position)
+	}
+}
+
+// RateLimitedClient wraps an LLMClient with per-provider rate limiters.
+// Providers without a configured limit pass straight through.
+type RateLimitedClient struct {
+	inner    LLMClient
+	limiters map[string]*RateLimiter
+}
+
+// NewRateLimitedClient creates a client that applies limits (keyed by
+// provider name, e.g. "openai") in front of inner.
+func NewRateLimitedClient(inner LLMClient, limits map[string]RateLimit) *RateLimitedClient {
+	limiters := make(map[string]*RateLimiter, len(limits))
+	for provider, limit := range limits {
+		if !limit.IsZero() {
+			limiters[provider] = NewRateLimiter(limit)
+		}
+	}
+	return &RateLimitedClient{inner: inner, limiters: limiters}
+}
+
+// Call waits for the provider's budget, then delegates to the wrapped client.
+func (c *RateLimitedClient) Call(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	provider := request.ModelConfig.Provider
+	if provider == "" {
+		provider = "openai"
+	}
+	limiter := c.limiters[provider]
+	if limiter == nil {
+		return c.inner.Call(ctx, request)
+	}
+
+	estimate := estimateRequestTokens(request)
+	res, err := limiter.Acquire(ctx, request.SessionKey, estimate, request.OnQueued)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	resp, err := c.inner.Call(ctx, request)
+	if err == nil && resp.TokenUsage.TotalTokens > 0 {
+		res.Settle(resp.TokenUsage.TotalTokens)
+	}
+	return resp, err
+}
+
+// Compact waits for the provider's budget, then delegates to the wrapped
+// client. Compaction requests are queued under a shared, empty session key.
+func (c *RateLimitedClient) Compact(ctx context.Context, request CompactRequest) (CompactResponse, error) {
+	limiter := c.limiters[detectProviderFromModel(request.Model)]
+	if limiter == nil {
+		return c.inner.Compact(ctx, request)
+	}
+
+	estimate := (len(request.Instructions) + itemChars(request.Input)) / 4
+	res, err := limiter.Acquire(ctx, "", estimate, nil)
+	if err != nil {
+		return CompactResponse{}, err
+	}
+	resp, err := c.inner.Compact(ctx, request)
+	if err == nil && resp.TokenUsage.TotalTokens > 0 {
+		res.Settle(resp.TokenUsage.TotalTokens)
+	}
+	return resp, err
+}
+
+// estimateRequestTokens approximates a request's input tokens (4 chars per
+// token), used to reserve budget until the actual usage is known.
+func estimateRequestTokens(request LLMRequest) int {
+	chars := len(request.BaseInstructions) + len(request.DeveloperInstructions) + len(request.UserInstructions)
+	chars += itemChars(request.History)
+	return chars / 4
+}
+
+func itemChars(items []models.ConversationItem) int {
+	chars := 0
+	for _, item := range items {
+		chars += len(item.Content) + len(item.Name) + len(item.Arguments)
+		if item.Output != nil {
+			chars += len(item.Output.Content)
+		}
+	}
+	return chars
+}
@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Rate-limit response headers. Anthropic reports RFC 3339 reset times,
+// OpenAI reports durations such as "6m0s".
+const (
+	headerRetryAfter   = "Retry-After"
+	headerRetryAfterMs = "Retry-After-Ms"
+
+	anthropicRequestsRemaining = "Anthropic-Ratelimit-Requests-Remaining"
+	anthropicRequestsReset     = "Anthropic-Ratelimit-Requests-Reset"
+	anthropicTokensRemaining   = "Anthropic-Ratelimit-Tokens-Remaining"
+	anthropicTokensReset       = "Anthropic-Ratelimit-Tokens-Reset"
+
+	openAIRequestsRemaining = "X-Ratelimit-Remaining-Requests"
+	openAIRequestsReset     = "X-Ratelimit-Reset-Requests"
+	openAITokensRemaining   = "X-Ratelimit-Remaining-Tokens"
+	openAITokensReset       = "X-Ratelimit-Reset-Tokens"
+)
+
+// withRateLimitHint attaches the backoff hint in a 429 response's headers
+// to a rate-limit error.
+func withRateLimitHint(ae *models.ActivityError, resp *http.Response) *models.ActivityError {
+	if ae.Type != models.ErrorTypeAPILimit || resp == nil {
+		return ae
+	}
+	ae.RateLimit = parseRateLimitHeaders(resp.Header, time.Now())
+	return ae
+}
+
+// parseRateLimitHeaders reads when to retry from rate-limit headers:
+// retry-after when present, otherwise the reset time of the exhausted
+// window (or the latest reset when none reports zero remaining). Returns
+// nil when the headers say nothing usable.
+func parseRateLimitHeaders(h http.Header, now time.Time) *models.LLMRateLimitDetails {
+	d := &models.LLMRateLimitDetails{
+		RequestsRemaining: headerInt(h, anthropicRequestsRemaining, openAIRequestsRemaining),
+		TokensRemaining:   headerInt(h, anthropicTokensRemaining, openAITokensRemaining),
+	}
+
+	retryAfter, ok := parseRetryAfter(h, now)
+	if !ok {
+		requestsReset := headerReset(h, now, anthropicRequestsReset, openAIRequestsReset)
+		tokensReset := headerReset(h, now, anthropicTokensReset, openAITokensReset)
+		requestsOut := d.RequestsRemaining != nil && *d.RequestsRemaining == 0
+		tokensOut := d.TokensRemaining != nil && *d.TokensRemaining == 0
+		switch {
+		case requestsOut || tokensOut:
+			if requestsOut {
+				retryAfter = max(retryAfter, requestsReset)
+			}
+			if tokensOut {
+				retryAfter = max(retryAfter, tokensReset)
+			}
+		default:
+			retryAfter = max(requestsReset, tokensReset)
+		}
+	}
+	d.RetryAfterMs = retryAfter.Milliseconds()
+
+	if d.RetryAfterMs <= 0 && d.RequestsRemaining == nil && d.TokensRemaining == nil {
+		return nil
+	}
+	return d
+}
+
+// parseRetryAfter reads retry-after-ms (OpenAI) or retry-after, which is
+// either seconds or an HTTP date.
+func parseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := strings.TrimSpace(h.Get(headerRetryAfterMs)); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 && !math.IsInf(ms, 0) {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	v := strings.TrimSpace(h.Get(headerRetryAfter))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 && !math.IsInf(secs, 0) {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now), true
+	}
+	return 0, false
+}
+
+// headerInt returns the first of keys that holds an integer.
+func headerInt(h http.Header, keys ...string) *int {
+	for _, k := range keys {
+		if n, err := strconv.Atoi(strings.TrimSpace(h.Get(k))); err == nil {
+			return &n
+		}
+	}
+	return nil
+}
+
+// headerReset returns how long until the first of keys that holds a reset
+// time, given as an RFC 3339 time or a duration; zero if none does.
+func headerReset(h http.Header, now time.Time, keys ...string) time.Duration {
+	for _, k := range keys {
+		v := strings.TrimSpace(h.Get(k))
+		if v == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return max(t.Sub(now), 0)
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return max(d, 0)
+		}
+	}
+	return 0
+}
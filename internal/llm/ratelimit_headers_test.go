@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"retry-after seconds", header("retry-after", "23"), 23 * time.Second},
+		{"retry-after date", header("retry-after", now.Add(90*time.Second).Format(http.TimeFormat)), 90 * time.Second},
+		{"retry-after-ms wins", header("retry-after", "2", "retry-after-ms", "1500"), 1500 * time.Millisecond},
+		{"anthropic exhausted tokens", header(
+			"anthropic-ratelimit-tokens-remaining", "0",
+			"anthropic-ratelimit-tokens-reset", now.Add(40*time.Second).Format(time.RFC3339),
+			"anthropic-ratelimit-requests-remaining", "12",
+			"anthropic-ratelimit-requests-reset", now.Add(59*time.Second).Format(time.RFC3339),
+		), 40 * time.Second},
+		{"openai exhausted requests", header(
+			"x-ratelimit-remaining-requests", "0",
+			"x-ratelimit-reset-requests", "6m0s",
+			"x-ratelimit-remaining-tokens", "1000",
+			"x-ratelimit-reset-tokens", "20ms",
+		), 6 * time.Minute},
+		{"nothing exhausted takes latest reset", header(
+			"x-ratelimit-reset-requests", "2s",
+			"x-ratelimit-reset-tokens", "7s",
+		), 7 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := parseRateLimitHeaders(tt.header, now)
+			require.NotNil(t, d)
+			assert.Equal(t, tt.want, d.RetryAfter())
+		})
+	}
+
+	d := parseRateLimitHeaders(header("x-ratelimit-remaining-tokens", "0", "x-ratelimit-remaining-requests", "5"), now)
+	require.NotNil(t, d)
+	assert.Equal(t, 0, *d.TokensRemaining)
+	assert.Equal(t, 5, *d.RequestsRemaining)
+	assert.Zero(t, d.RetryAfterMs)
+
+	assert.Nil(t, parseRateLimitHeaders(header("retry-after", "soon"), now))
+	assert.Nil(t, parseRateLimitHeaders(http.Header{}, now))
+}
+
+func TestClassifyError_OpenAI_429_RetryAfterHint(t *testing.T) {
+	apiErr := newOpenAIError(429)
+	apiErr.Response.Header = http.Header{"Retry-After": []string{"23"}}
+
+	var actErr *models.ActivityError
+	require.ErrorAs(t, classifyError(apiErr), &actErr)
+	require.NotNil(t, actErr.RateLimit)
+	assert.Equal(t, int64(23000), actErr.RateLimit.RetryAfterMs)
+
+	require.ErrorAs(t, classifyError(newOpenAIError(500)), &actErr)
+	assert.Nil(t, actErr.RateLimit, "only rate-limit errors carry a hint")
+}
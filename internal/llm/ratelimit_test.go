@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// fakeClock is a manually advanced clock for RateLimiter tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(limit RateLimit) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewRateLimiter(limit)
+	l.now = clock.now
+	return l, clock
+}
+
+// queuedCall runs Acquire in a goroutine and waits until it is queued.
+type queuedCall struct {
+	mu        sync.Mutex
+	positions []int
+	done      chan *Reservation
+}
+
+func enqueue(t *testing.T, l *RateLimiter, session string, tokens int) *queuedCall {
+	t.Helper()
+	before := l.Queued()
+	c := &queuedCall{done: make(chan *Reservation, 1)}
+	go func() {
+		r, err := l.Acquire(context.Background(), session, tokens, func(n int) {
+			c.mu.Lock()
+			c.positions = append(c.positions, n)
+			c.mu.Unlock()
+		})
+		if err == nil {
+			c.done <- r
+		}
+	}()
+	require.Eventually(t, func() bool { return l.Queued() == before+1 }, time.Second, time.Millisecond)
+	return c
+}
+
+func (c *queuedCall) granted() bool {
+	select {
+	case r := <-c.done:
+		c.done <- r
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *queuedCall) lastPosition() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.positions) == 0 {
+		return -1
+	}
+	return c.positions[len(c.positions)-1]
+}
+
+func TestRateLimiter_RequestsPerMinute(t *testing.T) {
+	l, clock := newTestLimiter(RateLimit{RequestsPerMinute: 1})
+
+	first, err := l.Acquire(context.Background(), "s1", 10, nil)
+	require.NoError(t, err)
+
+	second := enqueue(t, l, "s2", 10)
+	assert.Equal(t, 1, second.lastPosition())
+	assert.False(t, second.granted())
+
+	clock.advance(61 * time.Second)
+	first.Settle(10) // re-runs dispatch with the advanced clock
+	require.Eventually(t, second.granted, time.Second, time.Millisecond)
+	assert.Equal(t, 0, second.lastPosition(), "grant should be reported as position 0")
+}
+
+func TestRateLimiter_FairAcrossSessions(t *testing.T) {
+	l, clock := newTestLimiter(RateLimit{RequestsPerMinute: 1})
+
+	first, err := l.Acquire(context.Background(), "busy", 10, nil)
+	require.NoError(t, err)
+
+	busy2 := enqueue(t, l, "busy", 10)
+	busy3 := enqueue(t, l, "busy", 10)
+	quiet := enqueue(t, l, "quiet", 10)
+
+	// Round-robin: busy2, then quiet, then busy3.
+	assert.Equal(t, 1, busy2.lastPosition())
+	assert.Equal(t, 2, quiet.lastPosition())
+	assert.Equal(t, 3, busy3.lastPosition())
+
+	clock.advance(61 * time.Second)
+	first.Settle(10)
+	require.Eventually(t, busy2.granted, time.Second, time.Millisecond)
+	assert.False(t, quiet.granted())
+	assert.Equal(t, 1, quiet.lastPosition())
+	assert.Equal(t, 2, busy3.lastPosition())
+
+	clock.advance(61 * time.Second)
+	(<-busy2.done).Settle(10)
+	require.Eventually(t, quiet.granted, time.Second, time.Millisecond)
+	assert.False(t, busy3.granted())
+	assert.Equal(t, 1, busy3.lastPosition())
+}
+
+func TestRateLimiter_TokensPerMinuteSettle(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{TokensPerMinute: 100})
+
+	first, err := l.Acquire(context.Background(), "s1", 80, nil)
+	require.NoError(t, err)
+
+	second := enqueue(t, l, "s2", 50)
+	assert.False(t, second.granted())
+
+	// The first call used fewer tokens than estimated, freeing budget.
+	first.Settle(40)
+	require.Eventually(t, second.granted, time.Second, time.Millisecond)
+}
+
+func TestRateLimiter_OversizedRequestAdmittedWhenIdle(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{TokensPerMinute: 100})
+	r, err := l.Acquire(context.Background(), "s1", 500, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, r)
+}
+
+func TestRateLimiter_CancelWhileQueued(t *testing.T) {
+	l, _ := newTestLimiter(RateLimit{RequestsPerMinute: 1})
+	_, err := l.Acquire(context.Background(), "s1", 0, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(ctx, "s2", 0, nil)
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { return l.Queued() == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+	assert.Equal(t, 0, l.Queued())
+}
+
+func TestRateLimitsFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OPENAI_RPM":    "500",
+		"OPENAI_TPM":    "200000",
+		"ANTHROPIC_TPM": "40000",
+	}
+	limits, err := RateLimitsFromEnv(func(k string) string { return env[k] })
+	require.NoError(t, err)
+	assert.Equal(t, RateLimit{RequestsPerMinute: 500, TokensPerMinute: 200000}, limits["openai"])
+	assert.Equal(t, RateLimit{TokensPerMinute: 40000}, limits["anthropic"])
+
+	limits, err = RateLimitsFromEnv(func(string) string { return "" })
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+
+	_, err = RateLimitsFromEnv(func(k string) string {
+		if k == "OPENAI_RPM" {
+			return "lots"
+		}
+		return ""
+	})
+	assert.ErrorContains(t, err, "OPENAI_RPM")
+}
+
+// stubLLMClient records calls and returns a fixed token usage.
+type stubLLMClient struct {
+	calls int
+}
+
+func (c *stubLLMClient) Call(_ context.Context, _ LLMRequest) (LLMResponse, error) {
+	c.calls++
+	return LLMResponse{TokenUsage: models.TokenUsage{TotalTokens: 30}}, nil
+}
+
+func (c *stubLLMClient) Compact(_ context.Context, _ CompactRequest) (CompactResponse, error) {
+	return CompactResponse{}, nil
+}
+
+func TestRateLimitedClient_SettlesActualUsage(t *testing.T) {
+	inner := &stubLLMClient{}
+	c := NewRateLimitedClient(inner, map[string]RateLimit{"anthropic": {TokensPerMinute: 100}})
+	limiter := c.limiters["anthropic"]
+
+	history := []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: string(make([]byte, 400))}}
+	_, err := c.Call(context.Background(), LLMRequest{
+		ModelConfig: models.ModelConfig{Provider: "anthropic"},
+		History:     history,
+		SessionKey:  "s1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.calls)
+	require.Len(t, limiter.charges, 1)
+	assert.Equal(t, 30, limiter.charges[0].tokens, "estimate should be replaced by actual usage")
+
+	// Providers without a limit pass through.
+	_, err = c.Call(context.Background(), LLMRequest{ModelConfig: models.ModelConfig{Provider: "openai"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+}
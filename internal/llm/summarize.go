@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// transcriptSummaryInstructions is the system prompt for SummarizeTranscript.
+const transcriptSummaryInstructions = `You summarize transcripts of coding-agent sessions so that another agent can continue from them. The transcript is a JSON array of conversation items (user and assistant messages, tool calls and tool outputs).`
+
+// SummarizeTranscript summarizes a serialized conversation from another
+// session with the compaction prompt. Unlike Compact it always returns plain
+// text: OpenAI's remote compaction yields opaque items that cannot be shown
+// to the user or sent to another provider.
+//
+// NOTE: Temporal-specific addition (used by the import_context Update).
+func SummarizeTranscript(ctx context.Context, c LLMClient, modelConfig models.ModelConfig, transcript string) (string, models.TokenUsage, error) {
+	resp, err := c.Call(ctx, LLMRequest{
+		History: []models.ConversationItem{
+			{
+				Type:    models.ItemTypeUserMessage,
+				Content: "<transcript>\n" + transcript + "\n</transcript>\n\n" + compactionPrompt,
+			},
+		},
+		ModelConfig:      modelConfig,
+		BaseInstructions: transcriptSummaryInstructions,
+	})
+	if err != nil {
+		return "", models.TokenUsage{}, fmt.Errorf("summarization LLM call failed: %w", err)
+	}
+
+	summary := extractLastAssistantMessage(resp.Items)
+	if summary == "" {
+		return "", resp.TokenUsage, fmt.Errorf("summarization produced empty summary")
+	}
+	return summary, resp.TokenUsage, nil
+}
+
+// projectDocSummaryInstructions is the system prompt for SummarizeProjectDoc.
+const projectDocSummaryInstructions = `You condense project instruction files (AGENTS.md and similar) for a coding agent that cannot fit them in its context. Keep every rule, command, convention, path and constraint the agent must follow; drop examples, rationale and repetition. Reply with the condensed instructions only, as a terse markdown list, in at most 400 words.`
+
+// SummarizeProjectDoc condenses one project instruction file that did not
+// fit under the project docs size cap. content may be a clipped prefix of
+// the file.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+func SummarizeProjectDoc(ctx context.Context, c LLMClient, modelConfig models.ModelConfig, path, content string) (string, models.TokenUsage, error) {
+	resp, err := c.Call(ctx, LLMRequest{
+		History: []models.ConversationItem{
+			{
+				Type:    models.ItemTypeUserMessage,
+				Content: fmt.Sprintf("<file path=%q>\n%s\n</file>", path, content),
+			},
+		},
+		ModelConfig:      modelConfig,
+		BaseInstructions: projectDocSummaryInstructions,
+	})
+	if err != nil {
+		return "", models.TokenUsage{}, fmt.Errorf("project doc summary LLM call failed: %w", err)
+	}
+
+	summary := extractLastAssistantMessage(resp.Items)
+	if summary == "" {
+		return "", resp.TokenUsage, fmt.Errorf("summarization produced empty summary")
+	}
+	return summary, resp.TokenUsage, nil
+}
+
+// contextSummaryInstructions is the system prompt for SummarizeContextItems.
+const contextSummaryInstructions = `You condense part of a coding agent's conversation that the user wants out of the agent's context. The items are a JSON array of conversation items (user and assistant messages, tool calls and tool outputs) taken from the middle of the conversation. Keep the facts the agent may still need: decisions, file paths, identifiers, commands and their results, errors and fixes. Drop everything else. Reply with the summary only, as terse markdown, in at most 300 words.`
+
+// SummarizeContextItems summarizes history items the user chose to replace
+// with a summary (/context summarize).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+func SummarizeContextItems(ctx context.Context, c LLMClient, modelConfig models.ModelConfig, items string) (string, models.TokenUsage, error) {
+	resp, err := c.Call(ctx, LLMRequest{
+		History: []models.ConversationItem{
+			{
+				Type:    models.ItemTypeUserMessage,
+				Content: "<items>\n" + items + "\n</items>",
+			},
+		},
+		ModelConfig:      modelConfig,
+		BaseInstructions: contextSummaryInstructions,
+	})
+	if err != nil {
+		return "", models.TokenUsage{}, fmt.Errorf("context summary LLM call failed: %w", err)
+	}
+
+	summary := extractLastAssistantMessage(resp.Items)
+	if summary == "" {
+		return "", resp.TokenUsage, fmt.Errorf("summarization produced empty summary")
+	}
+	return summary, resp.TokenUsage, nil
+}
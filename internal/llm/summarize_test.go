@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// summaryStubClient returns a fixed reply and records the request.
+type summaryStubClient struct {
+	reply   string
+	request LLMRequest
+}
+
+func (c *summaryStubClient) Call(_ context.Context, req LLMRequest) (LLMResponse, error) {
+	c.request = req
+	return LLMResponse{
+		Items:      []models.ConversationItem{{Type: models.ItemTypeAssistantMessage, Content: c.reply}},
+		TokenUsage: models.TokenUsage{TotalTokens: 42},
+	}, nil
+}
+
+func (c *summaryStubClient) Compact(_ context.Context, _ CompactRequest) (CompactResponse, error) {
+	return CompactResponse{}, nil
+}
+
+func TestSummarizeTranscript(t *testing.T) {
+	c := &summaryStubClient{reply: "The user fixed the auth bug in login.go."}
+	cfg := models.ModelConfig{Provider: "openai", Model: "gpt-4o"}
+
+	summary, usage, err := SummarizeTranscript(context.Background(), c, cfg, `[{"type":"user_message"}]`)
+	require.NoError(t, err)
+	assert.Equal(t, "The user fixed the auth bug in login.go.", summary)
+	assert.Equal(t, 42, usage.TotalTokens)
+
+	require.Len(t, c.request.History, 1)
+	assert.Contains(t, c.request.History[0].Content, "<transcript>\n[{\"type\":\"user_message\"}]\n</transcript>")
+	assert.Contains(t, c.request.History[0].Content, "CONTEXT CHECKPOINT COMPACTION")
+	assert.Equal(t, cfg, c.request.ModelConfig)
+	assert.Empty(t, c.request.ToolSpecs)
+}
+
+func TestSummarizeTranscript_EmptySummary(t *testing.T) {
+	c := &summaryStubClient{reply: ""}
+
+	_, _, err := SummarizeTranscript(context.Background(), c, models.ModelConfig{}, "[]")
+	assert.ErrorContains(t, err, "empty summary")
+}
+
+func TestSummarizeProjectDoc(t *testing.T) {
+	c := &summaryStubClient{reply: "- Run make test before committing."}
+	cfg := models.ModelConfig{Provider: "openai", Model: "gpt-4o-mini"}
+
+	summary, usage, err := SummarizeProjectDoc(context.Background(), c, cfg, "sub/AGENTS.md", "Always run make test.")
+	require.NoError(t, err)
+	assert.Equal(t, "- Run make test before committing.", summary)
+	assert.Equal(t, 42, usage.TotalTokens)
+
+	require.Len(t, c.request.History, 1)
+	assert.Equal(t, "<file path=\"sub/AGENTS.md\">\nAlways run make test.\n</file>", c.request.History[0].Content)
+	assert.Equal(t, projectDocSummaryInstructions, c.request.BaseInstructions)
+}
+
+func TestSummarizeContextItems(t *testing.T) {
+	c := &summaryStubClient{reply: "Tried three fixes for the flaky test; none worked."}
+
+	summary, usage, err := SummarizeContextItems(context.Background(), c, models.ModelConfig{}, `[{"type":"function_call"}]`)
+	require.NoError(t, err)
+	assert.Equal(t, "Tried three fixes for the flaky test; none worked.", summary)
+	assert.Equal(t, 42, usage.TotalTokens)
+
+	require.Len(t, c.request.History, 1)
+	assert.Equal(t, "<items>\n[{\"type\":\"function_call\"}]\n</items>", c.request.History[0].Content)
+	assert.Equal(t, contextSummaryInstructions, c.request.BaseInstructions)
+}
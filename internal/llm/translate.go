@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// History is stored in one canonical, provider-neutral form
+// (models.ConversationItem), but each provider's API has its own rules for
+// how tool calls and their results may be sequenced. History recorded with
+// one provider is not always valid for another: after switching from OpenAI
+// to Anthropic mid-session, a call interrupted before it ran has no result
+// (Anthropic rejects a tool_use without a tool_result in the next message),
+// an output may follow an assistant message instead of its call, and
+// arguments OpenAI accepted as a string may not be a JSON object.
+//
+// translate rewrites a copy of the history into a sequence the provider
+// accepts. Every provider gets the same pairing guarantees:
+//   - each run of function calls is followed directly by their outputs, in
+//     call order; items recorded in between move after the outputs;
+//   - a call without an output gets an AbortedToolOutput;
+//   - an output without a call (its call was compacted away) is dropped;
+//   - call IDs are non-empty and unique.
+//
+// The adapter adds the provider's own rules on top. Translating is
+// idempotent, so history that is already valid passes through unchanged.
+//
+// Maps to: codex-rs/core/src/context_manager/normalize.rs
+// (ensure_call_outputs_present, remove_orphan_outputs)
+
+// AbortedToolOutput is the output given to a tool call that has none, e.g.
+// one interrupted before it ran.
+const AbortedToolOutput = "aborted"
+
+// emptyToolOutput replaces empty tool results for providers that reject
+// empty text.
+const emptyToolOutput = "(no output)"
+
+// historyAdapter holds one provider's rules for translated history.
+type historyAdapter struct {
+	// callIDChar reports whether r may appear in a call ID; others are
+	// replaced with '_'. Nil allows any character.
+	callIDChar func(r rune) bool
+	// objectArguments means tool arguments must be a JSON object.
+	objectArguments bool
+	// nonEmptyText means empty user messages and tool results are rejected.
+	nonEmptyText bool
+}
+
+var (
+	openAIHistory = historyAdapter{}
+	// Anthropic tool_use IDs must match ^[a-zA-Z0-9_-]+$; the input is an
+	// object and text blocks must not be empty.
+	anthropicHistory = historyAdapter{callIDChar: isToolUseIDChar, objectArguments: true, nonEmptyText: true}
+	// Converse has the same rules as Anthropic.
+	bedrockHistory = anthropicHistory
+	// Gemini matches responses by call name, not ID, and takes arguments as
+	// an object.
+	vertexHistory = historyAdapter{objectArguments: true}
+)
+
+func isToolUseIDChar(r rune) bool {
+	return r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// translate returns history rewritten for the adapter's provider. The input
+// is not modified.
+func (a historyAdapter) translate(history []models.ConversationItem) []models.ConversationItem {
+	// Output positions by call ID, so each call takes the first unused
+	// output recorded after it.
+	outputs := make(map[string][]int)
+	for i, item := range history {
+		if item.Type == models.ItemTypeFunctionCallOutput {
+			outputs[item.CallID] = append(outputs[item.CallID], i)
+		}
+	}
+	used := make([]bool, len(history))
+	ids := make(map[string]bool)
+
+	out := make([]models.ConversationItem, 0, len(history))
+	for i := 0; i < len(history); {
+		item := history[i]
+		switch item.Type {
+		case models.ItemTypeFunctionCall:
+			// A run of calls is one model response; its outputs follow it.
+			var results []models.ConversationItem
+			j := i
+			for ; j < len(history) && history[j].Type == models.ItemTypeFunctionCall; j++ {
+				call := history[j]
+				result := a.takeOutput(history, outputs[call.CallID], used, j)
+				call.CallID = a.callID(call.CallID, j, ids)
+				call.Arguments = a.arguments(call.Arguments)
+				result.CallID = call.CallID
+				out = append(out, call)
+				results = append(results, result)
+			}
+			out = append(out, results...)
+			i = j
+			continue
+
+		case models.ItemTypeFunctionCallOutput:
+			// Already placed after its call, or orphaned: dropped.
+
+		case models.ItemTypeUserMessage:
+			if !a.nonEmptyText || item.Content != "" {
+				out = append(out, item)
+			}
+
+		default:
+			out = append(out, item)
+		}
+		i++
+	}
+	return out
+}
+
+// takeOutput returns the first unused output in candidates recorded after
+// the call at position call, or an AbortedToolOutput.
+func (a historyAdapter) takeOutput(history []models.ConversationItem, candidates []int, used []bool, call int) models.ConversationItem {
+	for _, k := range candidates {
+		if k > call && !used[k] {
+			used[k] = true
+			result := history[k]
+			if result.Output == nil {
+				result.Output = &models.FunctionCallOutputPayload{}
+			}
+			if a.nonEmptyText && result.Output.Content == "" {
+				// Copy the payload: history shares it.
+				payload := *result.Output
+				payload.Content = emptyToolOutput
+				result.Output = &payload
+			}
+			return result
+		}
+	}
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		Output: &models.FunctionCallOutputPayload{Content: AbortedToolOutput},
+	}
+}
+
+// callID returns id with characters the provider rejects replaced, made
+// unique among ids. IDs are derived from the call's position, not random,
+// so the same history always translates the same way (and stays cacheable).
+func (a historyAdapter) callID(id string, pos int, ids map[string]bool) string {
+	if a.callIDChar != nil {
+		id = strings.Map(func(r rune) rune {
+			if a.callIDChar(r) {
+				return r
+			}
+			return '_'
+		}, id)
+	}
+	if id == "" {
+		id = fmt.Sprintf("call_%d", pos)
+	}
+	for ids[id] {
+		id = fmt.Sprintf("%s_%d", id, pos)
+	}
+	ids[id] = true
+	return id
+}
+
+// arguments returns args as a JSON object when the provider needs one:
+// empty or null arguments become {}, and anything else that is not an
+// object is wrapped as {"input": args}.
+func (a historyAdapter) arguments(args string) string {
+	if !a.objectArguments {
+		return args
+	}
+	var obj map[string]any
+	if json.Unmarshal([]byte(args), &obj) == nil && obj != nil {
+		return args
+	}
+	if trimmed := strings.TrimSpace(args); trimmed == "" || trimmed == "null" {
+		return "{}"
+	}
+	wrapped, _ := json.Marshal(map[string]string{"input": args})
+	return string(wrapped)
+}
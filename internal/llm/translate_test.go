@@ -0,0 +1,257 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func call(id, args string) models.ConversationItem {
+	return models.ConversationItem{Type: models.ItemTypeFunctionCall, CallID: id, Name: "shell", Arguments: args}
+}
+
+func output(id, content string) models.ConversationItem {
+	return models.ConversationItem{Type: models.ItemTypeFunctionCallOutput, CallID: id,
+		Output: &models.FunctionCallOutputPayload{Content: content}}
+}
+
+func TestTranslateHistory_PairsCallsWithOutputs(t *testing.T) {
+	history := []models.ConversationItem{
+		{Type: models.ItemTypeUserMessage, Content: "go"},
+		call("a", `{}`),
+		call("b", `{}`),
+		{Type: models.ItemTypeAssistantMessage, Content: "running"}, // Recorded between call and output
+		output("b", "B"),
+		output("orphan", "compacted call"),
+		{Type: models.ItemTypeUserMessage, Content: "stop"},
+	}
+	got := openAIHistory.translate(history)
+
+	require.Len(t, got, 7)
+	assert.Equal(t, "a", got[1].CallID)
+	assert.Equal(t, "b", got[2].CallID)
+	assert.Equal(t, output("a", AbortedToolOutput), got[3], "interrupted call gets an output")
+	assert.Equal(t, output("b", "B"), got[4], "outputs follow their calls")
+	assert.Equal(t, "running", got[5].Content)
+	assert.Equal(t, "stop", got[6].Content, "orphaned output dropped")
+
+	assert.Equal(t, call("a", `{}`), history[1], "input not modified")
+	assert.Equal(t, got, openAIHistory.translate(got), "idempotent")
+}
+
+func TestTranslateHistory_CallIDs(t *testing.T) {
+	got := anthropicHistory.translate([]models.ConversationItem{
+		call("fc.1/x", `{}`),
+		output("fc.1/x", "one"),
+		call("", `{}`),
+		output("", "two"),
+		call("dup", `{}`),
+		output("dup", "three"),
+		call("dup", `{}`),
+		output("dup", "four"),
+	})
+	require.Len(t, got, 8)
+	assert.Equal(t, "fc_1_x", got[0].CallID)
+	assert.Equal(t, "fc_1_x", got[1].CallID)
+	assert.Equal(t, "call_2", got[2].CallID)
+	assert.Equal(t, "two", got[3].Output.Content)
+	assert.Equal(t, "dup", got[4].CallID)
+	assert.Equal(t, "dup_6", got[6].CallID)
+	assert.Equal(t, "dup_6", got[7].CallID)
+	assert.Equal(t, "four", got[7].Output.Content)
+}
+
+func TestTranslateHistory_AnthropicRules(t *testing.T) {
+	empty := output("c3", "")
+	got := anthropicHistory.translate([]models.ConversationItem{
+		{Type: models.ItemTypeUserMessage},
+		call("c1", `not json`),
+		call("c2", `null`),
+		call("c3", `[1]`),
+		output("c1", "x"),
+		output("c2", "y"),
+		empty,
+	})
+	require.Len(t, got, 6, "empty user message dropped")
+	assert.Equal(t, `{"input":"not json"}`, got[0].Arguments)
+	assert.Equal(t, `{}`, got[1].Arguments)
+	assert.Equal(t, `{"input":"[1]"}`, got[2].Arguments)
+	assert.Equal(t, emptyToolOutput, got[5].Output.Content)
+	assert.Equal(t, "", empty.Output.Content, "shared payload not modified")
+
+	kept := openAIHistory.translate([]models.ConversationItem{call("c1", `not json`), output("c1", "")})
+	assert.Equal(t, `not json`, kept[0].Arguments, "OpenAI takes arguments as a string")
+	assert.Equal(t, "", kept[1].Output.Content)
+}
+
+// --- Random histories through both providers ---
+
+var callIDs = []string{"call_1", "call_2", "toolu_01A", "", "fc.9/x", "call_1"}
+
+var arguments = []string{`{"command":"ls"}`, `{}`, ``, `null`, `not json`, `[1,2]`, `"str"`}
+
+// randomHistory builds a history from ops, one item per byte, mixing what
+// OpenAI and Anthropic sessions record with what interrupts and compaction
+// leave behind.
+func randomHistory(ops []byte) []models.ConversationItem {
+	var history []models.ConversationItem
+	for i, op := range ops {
+		text := fmt.Sprintf("text %d", i)
+		switch op % 10 {
+		case 0:
+			history = append(history, models.ConversationItem{Type: models.ItemTypeUserMessage, Content: text})
+		case 1:
+			history = append(history, models.ConversationItem{Type: models.ItemTypeUserMessage})
+		case 2:
+			history = append(history, models.ConversationItem{Type: models.ItemTypeAssistantMessage, Content: text})
+		case 3, 4:
+			history = append(history, call(callIDs[int(op/10)%len(callIDs)], arguments[int(op/7)%len(arguments)]))
+		case 5, 6:
+			content := text
+			if op/10%3 == 0 {
+				content = ""
+			}
+			history = append(history, output(callIDs[int(op/10)%len(callIDs)], content))
+		case 7:
+			history = append(history, models.ConversationItem{Type: models.ItemTypeDeveloperMessage, Content: text})
+		case 8:
+			history = append(history, models.ConversationItem{Type: models.ItemTypeTurnStarted, TurnID: text})
+		case 9:
+			history = append(history, models.ConversationItem{Type: models.ItemTypeFunctionCallOutput, CallID: "call_2"})
+		}
+	}
+	return history
+}
+
+// checkOpenAIInput verifies every function call has exactly one output
+// after it and every output answers an earlier call.
+func checkOpenAIInput(t *testing.T, items []responses.ResponseInputItemUnionParam) {
+	t.Helper()
+	pending := map[string]bool{}
+	answered := map[string]bool{}
+	for _, item := range items {
+		switch {
+		case item.OfFunctionCall != nil:
+			id := item.OfFunctionCall.CallID
+			require.NotEmpty(t, id)
+			require.False(t, pending[id] || answered[id], "duplicate call ID %q", id)
+			pending[id] = true
+		case item.OfFunctionCallOutput != nil:
+			id := item.OfFunctionCallOutput.CallID
+			require.True(t, pending[id], "output %q without a call", id)
+			delete(pending, id)
+			answered[id] = true
+		}
+	}
+	require.Empty(t, pending, "calls without outputs")
+}
+
+var toolUseID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// checkAnthropicMessages verifies roles alternate, text is never empty, and
+// each assistant message's tool_use blocks are answered, first thing, by the
+// next user message's tool_result blocks.
+func checkAnthropicMessages(t *testing.T, messages []anthropic.MessageParam) {
+	t.Helper()
+	var pending []string
+	for i, m := range messages {
+		if i > 0 {
+			require.NotEqual(t, messages[i-1].Role, m.Role, "message %d repeats role", i)
+		}
+		var uses, results []string
+		for k, block := range m.Content {
+			switch {
+			case block.OfText != nil:
+				require.NotEmpty(t, block.OfText.Text, "message %d has an empty text block", i)
+			case block.OfToolUse != nil:
+				require.Equal(t, anthropic.MessageParamRoleAssistant, m.Role)
+				require.Regexp(t, toolUseID, block.OfToolUse.ID)
+				require.NotNil(t, block.OfToolUse.Input)
+				uses = append(uses, block.OfToolUse.ID)
+			case block.OfToolResult != nil:
+				require.Equal(t, anthropic.MessageParamRoleUser, m.Role)
+				require.Equal(t, len(results), k, "tool_result blocks come first")
+				require.NotEmpty(t, block.OfToolResult.Content[0].OfText.Text)
+				results = append(results, block.OfToolResult.ToolUseID)
+			}
+		}
+		if m.Role == anthropic.MessageParamRoleUser {
+			require.Equal(t, pending, results, "message %d must answer the previous tool_use blocks", i)
+			pending = nil
+		} else {
+			require.Empty(t, pending, "message %d follows unanswered tool_use blocks", i)
+			pending = uses
+		}
+	}
+	require.Empty(t, pending, "unanswered tool_use blocks at the end")
+}
+
+// checkTranslation translates history for OpenAI, then Anthropic, then
+// OpenAI again, as a session switching providers back and forth would, and
+// checks each step builds a valid request and keeps every text.
+func checkTranslation(t *testing.T, history []models.ConversationItem) {
+	openAI := openAIHistory.translate(history)
+	checkOpenAIInput(t, (&OpenAIClient{}).buildInput(openAI))
+	require.Equal(t, openAI, openAIHistory.translate(openAI), "idempotent")
+
+	claude := anthropicHistory.translate(openAI)
+	messages, err := (&AnthropicClient{}).buildMessages(LLMRequest{DeveloperInstructions: "dev", History: claude})
+	require.NoError(t, err)
+	checkAnthropicMessages(t, messages)
+	require.Equal(t, claude, anthropicHistory.translate(history), "translation does not depend on the path")
+
+	back := openAIHistory.translate(claude)
+	checkOpenAIInput(t, (&OpenAIClient{}).buildInput(back))
+	require.Equal(t, claude, back, "Anthropic history is valid for OpenAI")
+
+	texts := func(items []models.ConversationItem) []string {
+		var out []string
+		for _, item := range items {
+			if item.Type != models.ItemTypeFunctionCall && item.Type != models.ItemTypeFunctionCallOutput && item.Content != "" {
+				out = append(out, item.Content)
+			}
+		}
+		return out
+	}
+	require.Equal(t, texts(history), texts(back), "messages survive translation in order")
+}
+
+func FuzzTranslateHistory(f *testing.F) {
+	f.Add([]byte{0, 2, 3, 13, 5, 15, 2})       // Parallel calls answered
+	f.Add([]byte{0, 3, 2, 0})                  // Interrupted call
+	f.Add([]byte{0, 5, 9, 1, 7, 8})            // Orphaned outputs
+	f.Add([]byte{3, 53, 5, 55, 23, 25, 43})    // Empty and duplicate IDs
+	f.Add([]byte{3, 2, 5, 4, 7, 34, 0, 14, 6}) // Outputs out of place
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		checkTranslation(t, randomHistory(ops))
+	})
+}
+
+func TestTranslateHistory_RandomHistories(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		ops := make([]byte, rng.Intn(40))
+		rng.Read(ops)
+		t.Run(fmt.Sprint(i), func(t *testing.T) { checkTranslation(t, randomHistory(ops)) })
+	}
+}
+
+// Sanity check that wrapped arguments are what Anthropic receives.
+func TestTranslateHistory_WrappedArgumentsReachAnthropic(t *testing.T) {
+	history := anthropicHistory.translate([]models.ConversationItem{call("c1", "*** Begin Patch"), output("c1", "ok")})
+	messages, err := (&AnthropicClient{}).convertHistoryToMessages(history)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	input, err := json.Marshal(messages[0].Content[0].OfToolUse.Input)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"input":"*** Begin Patch"}`, string(input))
+}
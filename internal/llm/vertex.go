@@ -0,0 +1,359 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// defaultVertexLocation is used when GOOGLE_CLOUD_LOCATION is unset.
+const defaultVertexLocation = "us-central1"
+
+// vertexSkipSignature stands in for the thought signature Gemini attaches to
+// function calls. History does not keep signatures, and Gemini 3 rejects
+// replayed function calls without one; this value tells it to skip the
+// check.
+var vertexSkipSignature = []byte("skip_thought_signature_validator")
+
+// VertexClient implements LLMClient using Gemini models on Vertex AI, for
+// accounts that can only reach Gemini through Vertex.
+//
+// Requests authenticate with Application Default Credentials
+// (GOOGLE_APPLICATION_CREDENTIALS, gcloud user credentials, or the
+// metadata server). The project comes from GOOGLE_CLOUD_PROJECT and the
+// region from GOOGLE_CLOUD_LOCATION (default us-central1).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type VertexClient struct {
+	httpClient *http.Client // nil uses the default, authenticated client
+
+	mu     sync.Mutex
+	client *genai.Client
+}
+
+// NewVertexClient creates a Vertex AI client. Credentials are resolved on
+// first use, so a worker without Google credentials can still start.
+func NewVertexClient() *VertexClient {
+	return &VertexClient{}
+}
+
+// VertexConfigured reports whether a Google Cloud project is configured,
+// which the worker takes as the Vertex provider being available.
+func VertexConfigured() bool {
+	return os.Getenv("GOOGLE_CLOUD_PROJECT") != ""
+}
+
+// newVertexGenAIClient creates a genai client for the Vertex AI backend.
+// Requests go through hc, with Application Default Credentials added to
+// it, unless hc is nil.
+func newVertexGenAIClient(ctx context.Context, hc *http.Client) (*genai.Client, error) {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		return nil, fmt.Errorf("no Google Cloud project configured (set GOOGLE_CLOUD_PROJECT)")
+	}
+	location := os.Getenv("GOOGLE_CLOUD_LOCATION")
+	if location == "" {
+		location = defaultVertexLocation
+	}
+	cc := &genai.ClientConfig{
+		Backend:  genai.BackendVertexAI,
+		Project:  project,
+		Location: location,
+	}
+	if hc != nil {
+		cc.HTTPClient = hc
+		if err := cc.UseDefaultCredentials(); err != nil {
+			return nil, err
+		}
+	}
+	return genai.NewClient(ctx, cc)
+}
+
+// genaiClient returns the genai client, creating it on first use. A failed
+// creation is retried on the next call.
+func (c *VertexClient) genaiClient(ctx context.Context) (*genai.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		client, err := newVertexGenAIClient(ctx, c.httpClient)
+		if err != nil {
+			return nil, models.NewFatalError(fmt.Sprintf("Vertex AI unavailable: %v", err))
+		}
+		c.client = client
+	}
+	return c.client, nil
+}
+
+// Call sends a generateContent request and returns the complete response.
+func (c *VertexClient) Call(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	client, err := c.genaiClient(ctx)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	request.History = vertexHistory.translate(request.History)
+	contents, err := buildVertexContents(request)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("failed to build contents: %w", err)
+	}
+
+	cfg := &genai.GenerateContentConfig{
+		SystemInstruction: buildVertexSystem(request),
+		MaxOutputTokens:   int32(request.ModelConfig.MaxTokens),
+	}
+	if request.ModelConfig.Temperature > 0 {
+		cfg.Temperature = genai.Ptr(float32(request.ModelConfig.Temperature))
+	}
+	if len(request.ToolSpecs) > 0 {
+		cfg.Tools = []*genai.Tool{{FunctionDeclarations: cachedToolDefinitions(request, "vertex", buildVertexFunctions)}}
+	}
+	// Gemini supports JSON schema output natively.
+	if rf := request.ResponseFormat; rf != nil {
+		cfg.ResponseMIMEType = "application/json"
+		cfg.ResponseJsonSchema = rf.Schema
+	}
+
+	resp, err := client.Models.GenerateContent(ctx, request.ModelConfig.Model, contents, cfg)
+	if err != nil {
+		return LLMResponse{}, classifyVertexError(err)
+	}
+
+	items, finishReason := parseVertexResponse(resp)
+	return LLMResponse{
+		Items:        items,
+		FinishReason: finishReason,
+		TokenUsage:   vertexTokenUsage(resp.UsageMetadata),
+	}, nil
+}
+
+// buildVertexSystem creates the system instruction from the base and user
+// instructions.
+func buildVertexSystem(request LLMRequest) *genai.Content {
+	var parts []*genai.Part
+	for _, text := range []string{request.BaseInstructions, request.UserInstructions} {
+		if text != "" {
+			parts = append(parts, genai.NewPartFromText(text))
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return &genai.Content{Parts: parts}
+}
+
+// buildVertexContents converts conversation history to Gemini contents.
+//
+// Gemini matches function responses to calls by name and order rather than
+// by call ID (which it may not assign), so outputs look up their call's
+// name. Adjacent parts of the same role are merged into one content, which
+// keeps parallel calls and their responses together as Gemini expects.
+func buildVertexContents(request LLMRequest) ([]*genai.Content, error) {
+	var contents []*genai.Content
+	add := func(role string, part *genai.Part) {
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, part)
+			return
+		}
+		contents = append(contents, &genai.Content{Role: role, Parts: []*genai.Part{part}})
+	}
+
+	if request.DeveloperInstructions != "" {
+		add(genai.RoleUser, genai.NewPartFromText(request.DeveloperInstructions))
+	}
+
+	callNames := make(map[string]string)
+	signed := false // Whether the current model turn's first call is signed
+	for _, item := range request.History {
+		switch item.Type {
+		case models.ItemTypeUserMessage:
+			if item.Content != "" {
+				add(genai.RoleUser, genai.NewPartFromText(item.Content))
+			}
+
+		case models.ItemTypeUserShellCommand:
+			add(genai.RoleUser, genai.NewPartFromText(models.FormatUserShellCommand(item)))
+
+		case models.ItemTypeDeveloperMessage:
+			add(genai.RoleUser, genai.NewPartFromText(formatAnthropicDeveloperMessage(item.Content)))
+
+		case models.ItemTypeAssistantMessage:
+			if item.Content != "" {
+				add(genai.RoleModel, genai.NewPartFromText(item.Content))
+			}
+
+		case models.ItemTypeFunctionCall:
+			var args map[string]any
+			if err := json.Unmarshal([]byte(item.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+			}
+			callNames[item.CallID] = item.Name
+			part := genai.NewPartFromFunctionCall(item.Name, args)
+			if n := len(contents); n == 0 || contents[n-1].Role != genai.RoleModel {
+				signed = false
+			}
+			if !signed {
+				part.ThoughtSignature = vertexSkipSignature
+				signed = true
+			}
+			add(genai.RoleModel, part)
+
+		case models.ItemTypeFunctionCallOutput:
+			output := ""
+			if item.Output != nil {
+				output = item.Output.Content
+			}
+			key := "output"
+			if item.Output != nil && item.Output.Success != nil && !*item.Output.Success {
+				key = "error"
+			}
+			add(genai.RoleUser, genai.NewPartFromFunctionResponse(callNames[item.CallID], map[string]any{key: output}))
+		}
+	}
+
+	return contents, nil
+}
+
+// buildVertexFunctions converts ToolSpecs to Gemini function declarations.
+func buildVertexFunctions(specs []tools.ToolSpec) []*genai.FunctionDeclaration {
+	decls := make([]*genai.FunctionDeclaration, 0, len(specs))
+	for _, spec := range specs {
+		decls = append(decls, &genai.FunctionDeclaration{
+			Name:                 spec.Name,
+			Description:          spec.Description,
+			ParametersJsonSchema: toolJSONSchema(spec),
+		})
+	}
+	return decls
+}
+
+// parseVertexResponse converts a Gemini response to our ConversationItem
+// format. Gemini may omit function call IDs; calls without one get a
+// generated ID for the workflow to match outputs by.
+func parseVertexResponse(resp *genai.GenerateContentResponse) ([]models.ConversationItem, models.FinishReason) {
+	items := make([]models.ConversationItem, 0)
+	finishReason := models.FinishReasonStop
+
+	if len(resp.Candidates) > 0 {
+		candidate := resp.Candidates[0]
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				switch {
+				case part.Thought:
+					// Thought summaries are not kept in history
+				case part.FunctionCall != nil:
+					args, err := json.Marshal(part.FunctionCall.Args)
+					if err != nil || part.FunctionCall.Args == nil {
+						args = []byte("{}")
+					}
+					callID := part.FunctionCall.ID
+					if callID == "" {
+						callID = "call_" + uuid.NewString()
+					}
+					items = append(items, models.ConversationItem{
+						Type:      models.ItemTypeFunctionCall,
+						CallID:    callID,
+						Name:      part.FunctionCall.Name,
+						Arguments: string(args),
+					})
+					finishReason = models.FinishReasonToolCalls
+				case part.Text != "":
+					items = append(items, models.ConversationItem{
+						Type:    models.ItemTypeAssistantMessage,
+						Content: part.Text,
+					})
+				}
+			}
+		}
+		if candidate.FinishReason == genai.FinishReasonMaxTokens {
+			finishReason = models.FinishReasonLength
+		}
+	}
+
+	if len(items) == 0 {
+		items = append(items, models.ConversationItem{Type: models.ItemTypeAssistantMessage})
+	}
+	return items, finishReason
+}
+
+// vertexTokenUsage maps Gemini usage metadata to TokenUsage. Like OpenAI,
+// Gemini's prompt token count includes cached tokens. Thinking tokens are
+// billed as output, so they count as completion tokens.
+func vertexTokenUsage(usage *genai.GenerateContentResponseUsageMetadata) models.TokenUsage {
+	if usage == nil {
+		return models.TokenUsage{}
+	}
+	prompt := int(usage.PromptTokenCount + usage.ToolUsePromptTokenCount)
+	completion := int(usage.CandidatesTokenCount + usage.ThoughtsTokenCount)
+	return models.TokenUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+		CachedTokens:     int(usage.CachedContentTokenCount),
+	}
+}
+
+// Compact performs local compaction via LLM summarization, as for Anthropic.
+func (c *VertexClient) Compact(ctx context.Context, request CompactRequest) (CompactResponse, error) {
+	return localCompact(ctx, c, "vertex", request)
+}
+
+// fetchVertexModels lists the Gemini models published in the configured
+// project's region.
+func fetchVertexModels(ctx context.Context) ([]AvailableModel, error) {
+	client, err := newVertexGenAIClient(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []AvailableModel
+	for m, err := range client.Models.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		// Base models are named "publishers/google/models/<id>".
+		id := m.Name[strings.LastIndex(m.Name, "/")+1:]
+		if !strings.HasPrefix(id, "gemini-") {
+			continue
+		}
+		result = append(result, AvailableModel{
+			Provider:    "vertex",
+			ID:          id,
+			DisplayName: m.DisplayName,
+		})
+	}
+	return result, nil
+}
+
+// classifyVertexError categorizes a Vertex AI error using the HTTP status
+// code when available, falling back to message-based heuristics.
+func classifyVertexError(err error) error {
+	errMsg := strings.ToLower(err.Error())
+
+	// Context overflow detection ("The input token count exceeds the
+	// maximum number of tokens allowed")
+	if strings.Contains(errMsg, "exceeds the maximum number of tokens") ||
+		strings.Contains(errMsg, "context window") {
+		return models.NewContextOverflowError(err.Error())
+	}
+
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return classifyByStatusCode(apiErr.Code, err)
+	}
+
+	if strings.Contains(errMsg, "resource_exhausted") || strings.Contains(errMsg, "quota") {
+		return models.NewAPILimitError(err.Error())
+	}
+	return models.NewTransientError(fmt.Sprintf("Vertex AI API error: %v", err))
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// TestBuildVertexContents verifies the roles, that function responses carry
+// their call's name, and that each model turn's first call is signed.
+func TestBuildVertexContents(t *testing.T) {
+	failed := false
+	contents, err := buildVertexContents(LLMRequest{
+		History: []models.ConversationItem{
+			{Type: models.ItemTypeUserMessage, Content: "List and read"},
+			{Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "list_dir", Arguments: `{"path":"."}`},
+			{Type: models.ItemTypeFunctionCall, CallID: "c2", Name: "read_file", Arguments: `{"path":"x"}`},
+			{Type: models.ItemTypeFunctionCallOutput, CallID: "c1", Output: &models.FunctionCallOutputPayload{Content: "x"}},
+			{Type: models.ItemTypeFunctionCallOutput, CallID: "c2", Output: &models.FunctionCallOutputPayload{Content: "denied", Success: &failed}},
+			{Type: models.ItemTypeFunctionCall, CallID: "c3", Name: "read_file", Arguments: `{"path":"y"}`},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, contents, 4)
+
+	assert.Equal(t, genai.RoleUser, contents[0].Role)
+
+	calls := contents[1]
+	assert.Equal(t, genai.RoleModel, calls.Role)
+	require.Len(t, calls.Parts, 2)
+	assert.Equal(t, "list_dir", calls.Parts[0].FunctionCall.Name)
+	assert.Equal(t, vertexSkipSignature, calls.Parts[0].ThoughtSignature)
+	assert.Nil(t, calls.Parts[1].ThoughtSignature, "only the first call of a turn is signed")
+
+	results := contents[2]
+	assert.Equal(t, genai.RoleUser, results.Role)
+	require.Len(t, results.Parts, 2)
+	assert.Equal(t, "list_dir", results.Parts[0].FunctionResponse.Name)
+	assert.Equal(t, map[string]any{"output": "x"}, results.Parts[0].FunctionResponse.Response)
+	assert.Equal(t, map[string]any{"error": "denied"}, results.Parts[1].FunctionResponse.Response)
+
+	assert.Equal(t, vertexSkipSignature, contents[3].Parts[0].ThoughtSignature)
+}
+
+func TestVertexTokenUsage(t *testing.T) {
+	usage := vertexTokenUsage(&genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        4000,
+		CachedContentTokenCount: 3000,
+		CandidatesTokenCount:    80,
+		ThoughtsTokenCount:      20,
+		TotalTokenCount:         4100,
+	})
+	assert.Equal(t, models.TokenUsage{
+		PromptTokens: 4000, CompletionTokens: 100, TotalTokens: 4100, CachedTokens: 3000,
+	}, usage)
+	assert.Equal(t, models.TokenUsage{}, vertexTokenUsage(nil))
+}
+
+func TestClassifyVertexError(t *testing.T) {
+	var activityErr *models.ActivityError
+
+	err := classifyVertexError(genai.APIError{Code: 429, Message: "Resource exhausted"})
+	require.ErrorAs(t, err, &activityErr)
+	assert.Equal(t, models.ErrorTypeAPILimit, activityErr.Type)
+
+	err = classifyVertexError(genai.APIError{Code: 400,
+		Message: "The input token count (1200000) exceeds the maximum number of tokens allowed (1048576)."})
+	require.ErrorAs(t, err, &activityErr)
+	assert.Equal(t, models.ErrorTypeContextOverflow, activityErr.Type)
+
+	err = classifyVertexError(genai.APIError{Code: 403, Message: "Permission denied"})
+	require.ErrorAs(t, err, &activityErr)
+	assert.Equal(t, models.ErrorTypeFatal, activityErr.Type)
+}
+
+// TestVertexClient_Call sends a tool-calling request to a fake Vertex AI
+// endpoint and checks the request body and the parsed response.
+func TestVertexClient_Call(t *testing.T) {
+	var body map[string]interface{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		raw, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(raw, &body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [
+				{"text": "thinking about it", "thought": true},
+				{"text": "Reading it."},
+				{"functionCall": {"name": "read_file", "args": {"path": "go.mod"}}}
+			]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 12, "candidatesTokenCount": 7, "totalTokenCount": 19}
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		Backend:     genai.BackendVertexAI,
+		APIKey:      "test-key",
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	require.NoError(t, err)
+	c := &VertexClient{client: client}
+
+	resp, err := c.Call(context.Background(), LLMRequest{
+		ModelConfig:      models.ModelConfig{Provider: "vertex", Model: "gemini-2.5-pro", MaxTokens: 1024},
+		BaseInstructions: "You are a coding agent.",
+		History:          []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "Read go.mod"}},
+		ToolSpecs: []tools.ToolSpec{{
+			Name:        "read_file",
+			Description: "Read a file",
+			Parameters:  []tools.ToolParameter{{Name: "path", Type: "string", Description: "File path", Required: true}},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, path, "gemini-2.5-pro:generateContent")
+	assert.Contains(t, body, "systemInstruction")
+	decl := body["tools"].([]interface{})[0].(map[string]interface{})["functionDeclarations"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "read_file", decl["name"])
+	assert.Contains(t, decl, "parametersJsonSchema")
+
+	require.Len(t, resp.Items, 2, "thoughts are dropped")
+	assert.Equal(t, "Reading it.", resp.Items[0].Content)
+	call := resp.Items[1]
+	assert.Equal(t, models.ItemTypeFunctionCall, call.Type)
+	assert.Equal(t, "read_file", call.Name)
+	assert.NotEmpty(t, call.CallID, "a call ID is generated when Gemini omits one")
+	assert.JSONEq(t, `{"path":"go.mod"}`, call.Arguments)
+	assert.Equal(t, models.FinishReasonToolCalls, resp.FinishReason)
+	assert.Equal(t, 19, resp.TokenUsage.TotalTokens)
+}
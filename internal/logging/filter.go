@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// followPoll is how often Tail checks a followed file for new records.
+const followPoll = 250 * time.Millisecond
+
+// matchKeys are the record fields that identify a session.
+var matchKeys = []string{KeyConversationID, keyWorkflowID}
+
+// Match reports whether the JSON or text log record line belongs to the
+// session conversationID or one of its subagents (whose workflow IDs are
+// "<conversationID>/<agent>").
+func Match(line, conversationID string) bool {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return false
+		}
+		for _, key := range matchKeys {
+			if v, ok := rec[key].(string); ok && inSession(v, conversationID) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, key := range matchKeys {
+		if v, ok := textValue(line, key); ok && inSession(v, conversationID) {
+			return true
+		}
+	}
+	return false
+}
+
+func inSession(id, conversationID string) bool {
+	return id == conversationID || strings.HasPrefix(id, conversationID+"/")
+}
+
+// textValue returns the value of key in a slog text record, unquoting it
+// if needed.
+func textValue(line, key string) (string, bool) {
+	for rest := line; ; {
+		i := strings.Index(rest, key+"=")
+		if i < 0 {
+			return "", false
+		}
+		if i > 0 && rest[i-1] != ' ' {
+			rest = rest[i+len(key)+1:]
+			continue
+		}
+		v := rest[i+len(key)+1:]
+		if strings.HasPrefix(v, `"`) {
+			quoted, err := strconv.QuotedPrefix(v)
+			if err != nil {
+				return "", false
+			}
+			unquoted, err := strconv.Unquote(quoted)
+			return unquoted, err == nil
+		}
+		if end := strings.IndexByte(v, ' '); end >= 0 {
+			v = v[:end]
+		}
+		return v, true
+	}
+}
+
+// Tail copies the records of session conversationID from r to w. With
+// follow it keeps waiting for records appended to r, like `tail -f`, until
+// ctx is done.
+func Tail(ctx context.Context, r io.Reader, w io.Writer, conversationID string, follow bool) error {
+	br := bufio.NewReader(r)
+	var partial strings.Builder
+	for {
+		chunk, err := br.ReadString('\n')
+		partial.WriteString(chunk)
+		if err == nil {
+			line := partial.String()
+			partial.Reset()
+			if Match(line, conversationID) {
+				if _, err := io.WriteString(w, line); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+		if !follow {
+			if line := partial.String(); line != "" && Match(line, conversationID) {
+				_, err := io.WriteString(w, line+"\n")
+				return err
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(followPoll):
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package logging configures the worker's structured logs and the session
+// correlation fields that let one conversation's records be found among
+// many: conversation_id (the session's workflow ID), turn_id and call_id.
+//
+// The worker picks its sink from the environment (see ConfigFromEnv); the
+// client's `logs` command reads a JSON or text log file back and keeps the
+// records of one session (see Match and Tail).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// Correlation field keys.
+const (
+	KeyConversationID = "conversation_id"
+	KeyTurnID         = "turn_id"
+	KeyCallID         = "call_id"
+
+	// keyWorkflowID is the key the Temporal SDK puts on workflow and
+	// activity records; Match accepts it as well as KeyConversationID.
+	keyWorkflowID = "WorkflowID"
+)
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config selects where and how the worker logs.
+type Config struct {
+	Format string     // FormatText (default) or FormatJSON
+	File   string     // Appended to; "" = stderr
+	Level  slog.Level // Minimum level; default Info
+}
+
+// ConfigFromEnv reads WORKER_LOG_FORMAT (text|json), WORKER_LOG_FILE and
+// WORKER_LOG_LEVEL (debug|info|warn|error).
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Format: strings.ToLower(os.Getenv("WORKER_LOG_FORMAT")),
+		File:   os.Getenv("WORKER_LOG_FILE"),
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return Config{}, fmt.Errorf("invalid WORKER_LOG_FORMAT %q (want text or json)", cfg.Format)
+	}
+	if v := os.Getenv("WORKER_LOG_LEVEL"); v != "" {
+		if err := cfg.Level.UnmarshalText([]byte(v)); err != nil {
+			return Config{}, fmt.Errorf("invalid WORKER_LOG_LEVEL %q: %w", v, err)
+		}
+	}
+	return cfg, nil
+}
+
+// New creates a logger writing to cfg's sink. The returned closer closes
+// the log file, if any.
+func New(cfg Config) (*slog.Logger, io.Closer, error) {
+	var w io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open log file: %w", err)
+		}
+		w, closer = f, f
+	}
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	if cfg.Format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts)), closer, nil
+	}
+	return slog.New(slog.NewTextHandler(w, opts)), closer, nil
+}
+
+// nopCloser is the closer of a stderr sink.
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Activity returns the default logger with the correlation fields of the
+// activity running in ctx: its workflow ID as conversation_id, plus turnID
+// and callID when set. Outside an activity (unit tests) only turnID and
+// callID are attached.
+func Activity(ctx context.Context, turnID, callID string) *slog.Logger {
+	var attrs []any
+	if activity.IsActivity(ctx) {
+		info := activity.GetInfo(ctx)
+		attrs = append(attrs,
+			KeyConversationID, info.WorkflowExecution.ID,
+			"activity", info.ActivityType.Name,
+			"attempt", info.Attempt)
+	}
+	if turnID != "" {
+		attrs = append(attrs, KeyTurnID, turnID)
+	}
+	if callID != "" {
+		attrs = append(attrs, KeyCallID, callID)
+	}
+	return slog.Default().With(attrs...)
+}
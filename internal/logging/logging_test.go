@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("WORKER_LOG_FORMAT", "JSON")
+	t.Setenv("WORKER_LOG_FILE", "/tmp/worker.log")
+	t.Setenv("WORKER_LOG_LEVEL", "debug")
+	cfg, err := ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, Config{Format: FormatJSON, File: "/tmp/worker.log", Level: slog.LevelDebug}, cfg)
+
+	t.Setenv("WORKER_LOG_FORMAT", "xml")
+	_, err = ConfigFromEnv()
+	assert.ErrorContains(t, err, "invalid WORKER_LOG_FORMAT")
+}
+
+func TestNew_JSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.log")
+	logger, closer, err := New(Config{Format: FormatJSON, File: path})
+	require.NoError(t, err)
+	logger.Info("Tool call finished", KeyConversationID, "conv-1", KeyCallID, "call-1")
+	logger.Debug("dropped below the level")
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"conversation_id":"conv-1"`)
+	assert.True(t, Match(lines[0], "conv-1"))
+}
+
+func TestActivity_OutsideActivity(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	Activity(context.Background(), "turn-2", "").Info("hello")
+	assert.Contains(t, buf.String(), "turn_id=turn-2")
+	assert.NotContains(t, buf.String(), "call_id")
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{"json conversation", `{"msg":"x","conversation_id":"conv-1"}`, true},
+		{"json sdk workflow id", `{"msg":"x","WorkflowID":"conv-1"}`, true},
+		{"json subagent", `{"msg":"x","conversation_id":"conv-1/explorer"}`, true},
+		{"json other session", `{"msg":"x","conversation_id":"conv-10"}`, false},
+		{"json no session", `{"msg":"Worker started"}`, false},
+		{"text", `time=now level=INFO msg="Tool call finished" conversation_id=conv-1 call_id=c`, true},
+		{"text quoted", `level=INFO msg=x WorkflowID="conv-1"`, true},
+		{"text other session", `level=INFO msg=x conversation_id=conv-2`, false},
+		{"text key inside a value", `level=INFO msg="conversation_id=conv-1"`, false},
+		{"plain log line", `2025/01/01 12:00:00 Worker stopped`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Match(tt.line, "conv-1"))
+		})
+	}
+}
+
+func TestTail(t *testing.T) {
+	in := strings.Join([]string{
+		`{"msg":"a","conversation_id":"conv-1"}`,
+		`{"msg":"b","conversation_id":"conv-2"}`,
+		`{"msg":"c","WorkflowID":"conv-1"}`,
+	}, "\n")
+	var out bytes.Buffer
+	require.NoError(t, Tail(context.Background(), strings.NewReader(in), &out, "conv-1", false))
+	assert.Equal(t, `{"msg":"a","conversation_id":"conv-1"}`+"\n"+`{"msg":"c","WorkflowID":"conv-1"}`+"\n", out.String())
+}
+
+func TestTail_Follow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.log")
+	require.NoError(t, os.WriteFile(path, []byte(`{"msg":"old","conversation_id":"conv-1"}`+"\n"), 0o644))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() { done <- Tail(ctx, f, &out, "conv-1", true) }()
+
+	time.Sleep(2 * followPoll)
+	w, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = w.WriteString(`{"msg":"new",` + `"conversation_id":"conv-1"}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	time.Sleep(3 * followPoll)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Contains(t, out.String(), `"msg":"old"`)
+	assert.Contains(t, out.String(), `"msg":"new"`)
+}
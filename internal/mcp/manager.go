@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os/exec"
+	"sort"
 	"sync"
 
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -31,6 +33,8 @@ type InitResult struct {
 //
 // Maps to: codex-rs/core/src/mcp_connection_manager.rs McpConnectionManager
 type McpConnectionManager struct {
+	httpClient *http.Client // For HTTP transports; nil uses the default
+
 	mu      sync.Mutex
 	clients map[string]*managedClient // server name → live client session
 	tools   map[string]ToolInfo       // qualified name → tool metadata
@@ -198,7 +202,8 @@ func (m *McpConnectionManager) connectToServer(ctx context.Context, serverName s
 
 	if transport.IsHTTP() {
 		httpTransport := &gomcp.StreamableClientTransport{
-			Endpoint: transport.URL,
+			Endpoint:   transport.URL,
+			HTTPClient: m.httpClient,
 		}
 		session, err := client.Connect(connectCtx, httpTransport, nil)
 		if err != nil {
@@ -306,6 +311,18 @@ func (m *McpConnectionManager) InjectSession(serverName string, session *gomcp.C
 	}
 }
 
+// ServerNames returns the names of the connected MCP servers, sorted.
+func (m *McpConnectionManager) ServerNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Close shuts down all connected MCP client sessions.
 func (m *McpConnectionManager) Close() {
 	m.mu.Lock()
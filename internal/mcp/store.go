@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"log"
+	"net/http"
 	"sync"
 )
 
@@ -13,6 +14,8 @@ import (
 // Maps to: worker-scoped MCP state (no direct Codex equivalent — Codex
 // keeps MCP state in the Session which is already long-lived)
 type McpStore struct {
+	httpClient *http.Client // For HTTP transports; nil uses the default
+
 	mu       sync.Mutex
 	sessions map[string]*McpConnectionManager
 }
@@ -24,6 +27,13 @@ func NewMcpStore() *McpStore {
 	}
 }
 
+// WithHTTPClient makes the HTTP transports of the store's MCP connections
+// send requests through hc, e.g. to use a proxy or a custom CA bundle.
+func (s *McpStore) WithHTTPClient(hc *http.Client) *McpStore {
+	s.httpClient = hc
+	return s
+}
+
 // GetOrCreate returns an existing manager for the session, or creates a new one.
 func (s *McpStore) GetOrCreate(sessionID string) *McpConnectionManager {
 	s.mu.Lock()
@@ -33,6 +43,7 @@ func (s *McpStore) GetOrCreate(sessionID string) *McpConnectionManager {
 		return mgr
 	}
 	mgr := NewMcpConnectionManager()
+	mgr.httpClient = s.httpClient
 	s.sessions[sessionID] = mgr
 	return mgr
 }
@@ -41,6 +41,8 @@ func SerializeConversationForMemory(items []models.ConversationItem) (string, er
 			}
 		case models.ItemTypeWebSearchCall:
 			si.Content = item.WebSearchURL
+		case models.ItemTypeUserShellCommand:
+			si.Content = models.FormatUserShellCommand(item)
 		default:
 			si.Content = item.Content
 		}
@@ -62,12 +64,16 @@ func shouldIncludeForMemory(item models.ConversationItem) bool {
 		models.ItemTypeAssistantMessage,
 		models.ItemTypeFunctionCall,
 		models.ItemTypeFunctionCallOutput,
-		models.ItemTypeWebSearchCall:
+		models.ItemTypeWebSearchCall,
+		models.ItemTypeUserShellCommand:
 		return true
 	case models.ItemTypeTurnStarted,
 		models.ItemTypeTurnComplete,
 		models.ItemTypeCompaction,
-		models.ItemTypeModelSwitch:
+		models.ItemTypeModelSwitch,
+		models.ItemTypeDeveloperMessage,
+		models.ItemTypeUserAnswer,
+		models.ItemTypeAnnotation:
 		return false
 	default:
 		return false
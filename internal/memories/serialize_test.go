@@ -91,6 +91,7 @@ func TestShouldIncludeForMemory(t *testing.T) {
 		{models.ItemTypeTurnComplete, false},
 		{models.ItemTypeCompaction, false},
 		{models.ItemTypeModelSwitch, false},
+		{models.ItemTypeDeveloperMessage, false},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Headers carrying the caller's proof. Clients set CallerProofHeader on
+// every Update and signal and CallerStartProofHeader on workflow starts;
+// the worker checks them against the session's AccessControl.
+const (
+	CallerProofHeader      = "harness-caller-proof"
+	CallerStartProofHeader = "harness-caller-start-proof"
+)
+
+// CallerStartName is the message name start proofs are signed for.
+const CallerStartName = "start"
+
+// WorkflowCallerPrefix starts the identity a worker signs its workflows'
+// signals with ("workflow:<workflow ID>").
+const WorkflowCallerPrefix = "workflow:"
+
+// MaxCallerProofSkew is how far a proof's time may be from the workflow's.
+const MaxCallerProofSkew = 5 * time.Minute
+
+// AccessControl restricts who may send Updates and signals to a session. A
+// caller is allowed when it is the owner, its verified identity is listed,
+// or it proves it holds a token whose key is listed. Approving tools and
+// shutting the session down are reserved for the owner. With nothing set,
+// anyone with access to the namespace may do anything.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type AccessControl struct {
+	// Owner defaults to the verified identity that started the session
+	// when the lists below are set.
+	Owner      string   `json:"owner,omitempty"`
+	Identities []string `json:"identities,omitempty"`
+	TokenKeys  []string `json:"token_keys,omitempty"` // CallerTokenKey of the allowed tokens
+}
+
+// Enabled reports whether the session restricts its Updates.
+func (a AccessControl) Enabled() bool {
+	return a.Owner != "" || len(a.Identities) > 0 || len(a.TokenKeys) > 0
+}
+
+// IsOwner reports whether the caller owns the session. Without an owner,
+// every allowed caller counts as one.
+func (a AccessControl) IsOwner(c VerifiedCaller) bool {
+	if a.Owner == "" {
+		return a.Allows(c)
+	}
+	return c.Identity != "" && c.Identity == a.Owner
+}
+
+// Allows reports whether the caller may send Updates.
+func (a AccessControl) Allows(c VerifiedCaller) bool {
+	if !a.Enabled() {
+		return true
+	}
+	if c.Identity != "" && (c.Identity == a.Owner || slices.Contains(a.Identities, c.Identity)) {
+		return true
+	}
+	return c.TokenKey != "" && slices.Contains(a.TokenKeys, c.TokenKey)
+}
+
+// CallerProof is what a caller sends instead of its secrets: its claimed
+// identity, HMAC-signed with the caller key shared by the workers and
+// whatever authenticated the caller, and a signature made with the key
+// derived from its session token. Both cover the workflow ID, the Update
+// or signal name and the time, so a proof copied from history only works
+// for the same message to the same session within MaxCallerProofSkew.
+type CallerProof struct {
+	Identity    string `json:"identity,omitempty"`
+	Time        int64  `json:"time"`                   // Unix seconds
+	IdentityMAC string `json:"identity_mac,omitempty"` // Hex HMAC-SHA256 with the caller key
+	TokenKey    string `json:"token_key,omitempty"`    // Hex Ed25519 public key of the token
+	TokenSig    string `json:"token_sig,omitempty"`    // Hex Ed25519 signature
+}
+
+// VerifiedCaller is what a CallerProof proved: the identity, when its MAC
+// checked out, and the token key, when its signature did.
+type VerifiedCaller struct {
+	Identity string
+	TokenKey string
+}
+
+// String names the caller in log and error messages.
+func (c VerifiedCaller) String() string {
+	switch {
+	case c.Identity != "":
+		return c.Identity
+	case c.TokenKey != "":
+		return "token holder"
+	default:
+		return "anonymous caller"
+	}
+}
+
+// callerProofMessage is the byte string a proof signs.
+func callerProofMessage(workflowID, name, identity string, unix int64) []byte {
+	return fmt.Appendf(nil, "harness-caller/v1\n%s\n%s\n%s\n%d", workflowID, name, identity, unix)
+}
+
+// callerTokenPrivateKey derives a token's Ed25519 key.
+func callerTokenPrivateKey(token string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte(token))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// CallerTokenKey returns the hex Ed25519 public key of token, as listed in
+// AccessControl.TokenKeys.
+func CallerTokenKey(token string) string {
+	return hex.EncodeToString(callerTokenPrivateKey(token).Public().(ed25519.PublicKey))
+}
+
+// SignCallerProof builds the proof for sending name to workflowID. The
+// identity is signed only with a callerKey, and the token part is added
+// only with a token.
+func SignCallerProof(identity, callerKey, token, workflowID, name string, now time.Time) CallerProof {
+	p := CallerProof{Identity: identity, Time: now.Unix()}
+	msg := callerProofMessage(workflowID, name, identity, p.Time)
+	if identity != "" && callerKey != "" {
+		mac := hmac.New(sha256.New, []byte(callerKey))
+		mac.Write(msg)
+		p.IdentityMAC = hex.EncodeToString(mac.Sum(nil))
+	}
+	if token != "" {
+		p.TokenKey = CallerTokenKey(token)
+		p.TokenSig = hex.EncodeToString(ed25519.Sign(callerTokenPrivateKey(token), msg))
+	}
+	return p
+}
+
+// Verify checks the proof for name sent to workflowID at now. The identity
+// is only trusted when callerKey is set and its MAC matches; an expired or
+// future proof verifies nothing.
+func (p CallerProof) Verify(callerKey, workflowID, name string, now time.Time) VerifiedCaller {
+	var c VerifiedCaller
+	if skew := now.Sub(time.Unix(p.Time, 0)); skew > MaxCallerProofSkew || skew < -MaxCallerProofSkew {
+		return c
+	}
+	msg := callerProofMessage(workflowID, name, p.Identity, p.Time)
+	if p.Identity != "" && callerKey != "" {
+		mac := hmac.New(sha256.New, []byte(callerKey))
+		mac.Write(msg)
+		if got, err := hex.DecodeString(p.IdentityMAC); err == nil && hmac.Equal(got, mac.Sum(nil)) {
+			c.Identity = p.Identity
+		}
+	}
+	if p.TokenKey != "" {
+		key, keyErr := hex.DecodeString(p.TokenKey)
+		sig, sigErr := hex.DecodeString(p.TokenSig)
+		if keyErr == nil && sigErr == nil && len(key) == ed25519.PublicKeySize && ed25519.Verify(key, msg, sig) {
+			c.TokenKey = p.TokenKey
+		}
+	}
+	return c
+}
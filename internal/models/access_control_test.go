@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessControl_Allows(t *testing.T) {
+	assert.True(t, AccessControl{}.Allows(VerifiedCaller{}), "disabled access control allows everyone")
+
+	acl := AccessControl{
+		Owner:      "alice",
+		Identities: []string{"bob"},
+		TokenKeys:  []string{CallerTokenKey("s3cret")},
+	}
+	assert.True(t, acl.Allows(VerifiedCaller{Identity: "alice"}))
+	assert.True(t, acl.Allows(VerifiedCaller{Identity: "bob"}))
+	assert.True(t, acl.Allows(VerifiedCaller{Identity: "mallory", TokenKey: CallerTokenKey("s3cret")}))
+	assert.False(t, acl.Allows(VerifiedCaller{Identity: "mallory", TokenKey: CallerTokenKey("guess")}))
+	assert.False(t, acl.Allows(VerifiedCaller{}))
+
+	assert.True(t, acl.IsOwner(VerifiedCaller{Identity: "alice"}))
+	assert.False(t, acl.IsOwner(VerifiedCaller{Identity: "bob", TokenKey: CallerTokenKey("s3cret")}))
+
+	noOwner := AccessControl{Identities: []string{"bob"}}
+	assert.True(t, noOwner.IsOwner(VerifiedCaller{Identity: "bob"}), "without an owner every allowed caller is one")
+	assert.False(t, noOwner.IsOwner(VerifiedCaller{Identity: "mallory"}))
+}
+
+func TestCallerProof_Verify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	proof := SignCallerProof("alice", "caller-key", "s3cret", "session-1", "user_input", now)
+	assert.NotContains(t, proof.TokenSig+proof.TokenKey+proof.IdentityMAC, "s3cret")
+
+	assert.Equal(t, VerifiedCaller{Identity: "alice", TokenKey: CallerTokenKey("s3cret")},
+		proof.Verify("caller-key", "session-1", "user_input", now.Add(time.Minute)))
+
+	// Without the worker's caller key the identity is only a claim.
+	assert.Equal(t, VerifiedCaller{TokenKey: CallerTokenKey("s3cret")},
+		proof.Verify("", "session-1", "user_input", now))
+	assert.Equal(t, VerifiedCaller{TokenKey: CallerTokenKey("s3cret")},
+		proof.Verify("other-key", "session-1", "user_input", now))
+
+	// A proof is bound to the session, the message and the time.
+	assert.Equal(t, VerifiedCaller{}, proof.Verify("caller-key", "session-2", "user_input", now))
+	assert.Equal(t, VerifiedCaller{}, proof.Verify("caller-key", "session-1", "shutdown", now))
+	assert.Equal(t, VerifiedCaller{}, proof.Verify("caller-key", "session-1", "user_input", now.Add(time.Hour)))
+
+	forged := proof
+	forged.Identity = "root"
+	assert.Equal(t, VerifiedCaller{}, forged.Verify("caller-key", "session-1", "user_input", now))
+
+	unsigned := SignCallerProof("alice", "", "", "session-1", "user_input", now)
+	assert.Equal(t, VerifiedCaller{}, unsigned.Verify("caller-key", "session-1", "user_input", now))
+}
@@ -0,0 +1,22 @@
+package models
+
+// ApprovalContext is what the worker could tell about a tool call awaiting
+// approval, beyond its raw arguments: for write_file, the file it would
+// replace; for shell and shell_command, the paths the command names.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type ApprovalContext struct {
+	// write_file
+	FileExists   bool     `json:"file_exists,omitempty"`
+	CurrentLines []string `json:"current_lines,omitempty"` // First lines of the file as it is now
+	CurrentSize  int64    `json:"current_size,omitempty"`  // Bytes on disk now
+	NewSize      int64    `json:"new_size,omitempty"`      // Bytes the call would write
+
+	// shell, shell_command
+	Paths []string `json:"paths,omitempty"` // Arguments that exist on disk or look like paths, as written
+}
+
+// SizeDelta is the change in file size the write would make.
+func (c ApprovalContext) SizeDelta() int64 {
+	return c.NewSize - c.CurrentSize
+}
@@ -0,0 +1,97 @@
+package models
+
+import "fmt"
+
+// AutonomyLimit bounds a stretch of autonomous work by wall-clock minutes
+// and by turns. Whichever is reached first applies; zero means no limit.
+type AutonomyLimit struct {
+	Minutes int `json:"minutes,omitempty"`
+	Turns   int `json:"turns,omitempty"`
+}
+
+// IsZero reports whether neither limit is set.
+func (l AutonomyLimit) IsZero() bool {
+	return l.Minutes == 0 && l.Turns == 0
+}
+
+// Autonomy lets the agent keep working after a user message without waiting
+// for the next one: when it ends a turn, the workflow starts another.
+// Approval is the approval mode for tool calls during a run; empty keeps the
+// session's, so a run still stops for the approvals it would ask for. Set
+// it to "never" to approve every call without prompting (exec policy rules
+// that forbid a command still apply).
+//
+// Budget time-boxes each autonomous run. When it is spent the agent writes
+// a structured check-in and waits for the user; their next message starts a
+// new run. CheckinInterval is how often the agent checks in during a run
+// (zero = after every turn); a check-in that reports the task done or
+// blocked also ends the run.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type Autonomy struct {
+	Budget          AutonomyLimit `json:"budget,omitempty"`
+	CheckinInterval AutonomyLimit `json:"checkin_interval,omitempty"`
+	Approval        ApprovalMode  `json:"approval,omitempty"`
+}
+
+// Enabled reports whether autonomous runs are configured.
+func (a Autonomy) Enabled() bool {
+	return !a.Budget.IsZero()
+}
+
+// Validate checks for negative limits, a check-in interval without a
+// budget and an unknown approval mode. Called at workflow start so a bad config fails the session up
+// front.
+func (a Autonomy) Validate() error {
+	for _, c := range []struct {
+		name  string
+		limit AutonomyLimit
+	}{{"budget", a.Budget}, {"checkin_interval", a.CheckinInterval}} {
+		if c.limit.Minutes < 0 {
+			return fmt.Errorf("autonomy.%s.minutes must not be negative", c.name)
+		}
+		if c.limit.Turns < 0 {
+			return fmt.Errorf("autonomy.%s.turns must not be negative", c.name)
+		}
+	}
+	if !a.Enabled() && !a.CheckinInterval.IsZero() {
+		return fmt.Errorf("autonomy.checkin_interval requires autonomy.budget")
+	}
+	switch a.Approval {
+	case "", ApprovalUnlessTrusted, ApprovalNever, ApprovalOnFailure:
+	default:
+		return fmt.Errorf("autonomy.approval must be %q, %q or %q, got %q",
+			ApprovalUnlessTrusted, ApprovalNever, ApprovalOnFailure, a.Approval)
+	}
+	return nil
+}
+
+// CheckinStatus is the agent's own assessment in a check-in.
+type CheckinStatus string
+
+const (
+	CheckinWorking CheckinStatus = "working" // More to do; the run may continue
+	CheckinDone    CheckinStatus = "done"    // The task is complete
+	CheckinBlocked CheckinStatus = "blocked" // Needs the user's input or a decision
+)
+
+// Checkin is the structured progress report an autonomous agent writes
+// during and at the end of a run. It is recorded on an assistant message.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type Checkin struct {
+	Status    CheckinStatus `json:"status"`
+	Summary   string        `json:"summary"`
+	Completed []string      `json:"completed,omitempty"`
+	NextSteps []string      `json:"next_steps,omitempty"`
+	Blockers  []string      `json:"blockers,omitempty"`
+
+	// Final marks the check-in that ended the run: the agent then waits for
+	// the user. EndReason says why ("budget", "done" or "blocked").
+	Final     bool   `json:"final,omitempty"`
+	EndReason string `json:"end_reason,omitempty"`
+
+	// Turns and Minutes are how far into the run the check-in was written.
+	Turns   int `json:"turns"`
+	Minutes int `json:"minutes"`
+}
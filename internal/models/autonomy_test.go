@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutonomy_Validate(t *testing.T) {
+	assert.NoError(t, Autonomy{}.Validate())
+	assert.NoError(t, Autonomy{
+		Budget:          AutonomyLimit{Minutes: 30, Turns: 10},
+		CheckinInterval: AutonomyLimit{Turns: 3},
+		Approval:        ApprovalNever,
+	}.Validate())
+
+	tests := []struct {
+		name     string
+		autonomy Autonomy
+		wantErr  string
+	}{
+		{"negative budget minutes", Autonomy{Budget: AutonomyLimit{Minutes: -1}}, "autonomy.budget.minutes must not be negative"},
+		{"negative interval turns", Autonomy{Budget: AutonomyLimit{Turns: 5}, CheckinInterval: AutonomyLimit{Turns: -2}}, "autonomy.checkin_interval.turns must not be negative"},
+		{"interval without budget", Autonomy{CheckinInterval: AutonomyLimit{Minutes: 10}}, "requires autonomy.budget"},
+		{"unknown approval mode", Autonomy{Budget: AutonomyLimit{Turns: 5}, Approval: "always"}, `autonomy.approval must be`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.autonomy.Validate()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAutonomy_Enabled(t *testing.T) {
+	assert.False(t, Autonomy{}.Enabled())
+	assert.True(t, Autonomy{Budget: AutonomyLimit{Turns: 1}}.Enabled())
+	assert.True(t, Autonomy{Budget: AutonomyLimit{Minutes: 5}}.Enabled())
+}
@@ -9,7 +9,7 @@ import (
 //
 // Maps to: codex-rs/core/src/codex.rs SessionConfiguration (model config part)
 type ModelConfig struct {
-	Provider        string  `json:"provider"`                  // "openai" or "anthropic"
+	Provider        string  `json:"provider"`                  // "openai", "anthropic", "bedrock" or "vertex"
 	Model           string  `json:"model"`                     // e.g., "gpt-4o", "claude-sonnet-4.5-20250929"
 	Temperature     float64 `json:"temperature"`               // 0.0 to 2.0
 	MaxTokens       int     `json:"max_tokens"`                // Max tokens to generate
@@ -36,6 +36,11 @@ func DefaultModelConfig() ModelConfig {
 // Maps to: codex-rs/core/src/codex.rs SessionConfiguration (tools config part)
 type ToolsConfig struct {
 	EnabledTools []string `json:"enabled_tools"`
+
+	// McpReadOnly keeps only the MCP tools their server annotates as
+	// read-only. MCP tools are not listed in EnabledTools, so read-only
+	// subagent roles set it to narrow them as RestrictTools narrows the rest.
+	McpReadOnly bool `json:"mcp_read_only,omitempty"`
 }
 
 // HasTool returns true if the named tool (or any member of a group with that
@@ -70,6 +75,23 @@ func (c *ToolsConfig) RemoveTools(names ...string) {
 	c.EnabledTools = filtered
 }
 
+// RestrictTools keeps only the enabled tools that appear in allowed, so a
+// subagent role can narrow but never widen its parent's tool set. Group names
+// on either side are expanded; the result lists individual tool names.
+func (c *ToolsConfig) RestrictTools(allowed ...string) {
+	allow := make(map[string]bool, len(allowed))
+	for _, n := range tools.ExpandGroups(allowed) {
+		allow[n] = true
+	}
+	var filtered []string
+	for _, t := range tools.ExpandGroups(c.EnabledTools) {
+		if allow[t] {
+			filtered = append(filtered, t)
+		}
+	}
+	c.EnabledTools = filtered
+}
+
 // AddTools appends tools to EnabledTools (no dedup).
 func (c *ToolsConfig) AddTools(names ...string) {
 	c.EnabledTools = append(c.EnabledTools, names...)
@@ -117,6 +139,8 @@ type Permissions struct {
 	SandboxMode              string            `json:"sandbox_mode,omitempty"`           // "full-access", "read-only", "workspace-write"
 	SandboxWritableRoots     []string          `json:"sandbox_writable_roots,omitempty"` // Directories writable in workspace-write mode
 	SandboxNetworkAccess     bool              `json:"sandbox_network_access,omitempty"` // Whether network is allowed in sandbox
+	SandboxNetworkAllow      []string          `json:"sandbox_network_allow,omitempty"`  // Hosts ("host", "host:port", "*.domain") reachable when network is off
+	SandboxAudit             bool              `json:"sandbox_audit,omitempty"`          // Run unrestricted, recording what the sandbox would have blocked
 	EnvInherit               string            `json:"env_inherit,omitempty"`                 // "all" (default), "none", "core"
 	EnvIgnoreDefaultExcludes *bool             `json:"env_ignore_default_excludes,omitempty"` // nil = true (default: keep sensitive vars)
 	EnvExclude               []string          `json:"env_exclude,omitempty"`                 // Wildcard patterns to exclude
@@ -124,6 +148,84 @@ type Permissions struct {
 	EnvIncludeOnly           []string          `json:"env_include_only,omitempty"`             // Whitelist (if non-empty)
 }
 
+// HistoryRetention controls how long tool outputs stay in the prompt.
+// Tool outputs dominate the context; once a turn is old enough, the model
+// rarely needs them verbatim and can rerun the tool if it does.
+type HistoryRetention struct {
+	// ToolOutputTurns keeps full tool outputs for this many most recent
+	// user turns. Older outputs are replaced with a stub naming the call;
+	// messages and tool calls are kept. 0 = disabled.
+	ToolOutputTurns int `json:"tool_output_turns,omitempty"`
+}
+
+// ApprovalWebhookFallback is what happens to tool calls the approval webhook
+// did not decide in time.
+type ApprovalWebhookFallback string
+
+const (
+	// ApprovalWebhookFallbackDeny denies the undecided calls (default).
+	ApprovalWebhookFallbackDeny ApprovalWebhookFallback = "deny"
+	// ApprovalWebhookFallbackEscalate leaves the calls pending until someone
+	// answers through the approval_response Update, e.g. from an attached TUI.
+	ApprovalWebhookFallbackEscalate ApprovalWebhookFallback = "escalate"
+)
+
+// DefaultApprovalWebhookTimeoutSec is how long a delegated approval waits
+// for a decision when TimeoutSec is unset.
+const DefaultApprovalWebhookTimeoutSec = 600
+
+// ApprovalWebhook delegates tool approvals to an external service, for
+// deployments where no TUI is attached. When a turn needs approval, the
+// pending calls are POSTed to URL; the service answers by sending the
+// approval_response Update to the session workflow.
+type ApprovalWebhook struct {
+	URL        string                  `json:"url,omitempty"`
+	Headers    map[string]string       `json:"headers,omitempty"`     // Added to the POST, e.g. Authorization
+	TimeoutSec int                     `json:"timeout_sec,omitempty"` // 0 = DefaultApprovalWebhookTimeoutSec
+	Fallback   ApprovalWebhookFallback `json:"fallback,omitempty"`    // "" = deny
+}
+
+// SemanticSearch configures the code index behind the semantic_search
+// tool. Empty fields use the worker's defaults (the local provider).
+type SemanticSearch struct {
+	Provider string `json:"provider,omitempty"` // Embeddings provider, e.g. "local" or "openai"
+	Model    string `json:"model,omitempty"`    // Provider-specific model; "" = provider default
+}
+
+// Browser configures the browser_* tools. The tools themselves are enabled
+// through Tools.
+type Browser struct {
+	// AllowedDomains are the hosts the session's browser may load, as
+	// "host", "host:port" or "*.domain"; "*" allows any host. Empty allows
+	// only localhost.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+}
+
+// Hooks configures the user's hook scripts, kept in <codex_home>/hooks and
+// named after their event: pre_tool_use, post_tool_use or turn_complete.
+type Hooks struct {
+	Enabled    bool     `json:"enabled,omitempty"`
+	TimeoutSec int      `json:"timeout_sec,omitempty"` // Per script; 0 = 30s
+	Sandboxed  []string `json:"sandboxed,omitempty"`   // Events whose scripts run in the session's sandbox
+	// FailOpen runs a tool call whose pre_tool_use hook could not run or
+	// timed out. By default the call is blocked.
+	FailOpen bool `json:"fail_open,omitempty"`
+}
+
+// IsSandboxed reports whether scripts for event run sandboxed.
+func (h Hooks) IsSandboxed(event string) bool {
+	for _, e := range h.Sandboxed {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSuggestionTokenBudget is the session's token budget for prompt
+// suggestions when SuggestionTokenBudget is unset.
+const DefaultSuggestionTokenBudget = 20000
+
 // SessionConfiguration configures a complete agentic session.
 //
 // Maps to: codex-rs/core/src/codex.rs SessionConfiguration
@@ -145,6 +247,14 @@ type SessionConfiguration struct {
 	// Execution context
 	Cwd string `json:"cwd,omitempty"` // Working directory for tool execution
 
+	// Shell, OS and timezone of the worker that runs tools (e.g.
+	// "powershell", "windows", "Europe/Berlin"), reported by the
+	// LoadWorkerInstructions activity and shown to the model in the
+	// environment context. Empty means bash on Unix, in UTC.
+	WorkerShell    string `json:"worker_shell,omitempty"`
+	WorkerOS       string `json:"worker_os,omitempty"`
+	WorkerTimezone string `json:"worker_timezone,omitempty"`
+
 	// Codex home directory for loading exec policy rules.
 	// Default: ~/.codex
 	CodexHome string `json:"codex_home,omitempty"`
@@ -161,6 +271,21 @@ type SessionConfiguration struct {
 	// Maps to: codex-rs auto_compact_token_limit
 	AutoCompactTokenLimit int `json:"auto_compact_token_limit,omitempty"`
 
+	// HistoryRetention elides old tool outputs from the prompt without a
+	// compaction LLM call.
+	HistoryRetention HistoryRetention `json:"history_retention,omitempty"`
+
+	// ApprovalWebhook, if its URL is set, is asked to decide tool approvals.
+	ApprovalWebhook ApprovalWebhook `json:"approval_webhook,omitempty"`
+
+	// AccessControl, if enabled, limits who may send the session Updates
+	// (approve tools, send input, shut it down).
+	AccessControl AccessControl `json:"access_control,omitempty"`
+
+	// RetryPolicies overrides the built-in retry policies of LLM, tool and
+	// compaction activities. Validated at workflow start.
+	RetryPolicies RetryPolicies `json:"retry_policies,omitempty"`
+
 	// Web search configuration
 	// Maps to: codex-rs web_search_mode
 	WebSearchMode WebSearchMode `json:"web_search_mode,omitempty"`
@@ -168,6 +293,105 @@ type SessionConfiguration struct {
 	// Disable post-turn prompt suggestions
 	DisableSuggestions bool `json:"disable_suggestions,omitempty"`
 
+	// SuggestionTokenBudget caps the tokens prompt suggestions may use over
+	// the session; once spent, no more suggestions are generated. 0 means
+	// DefaultSuggestionTokenBudget.
+	SuggestionTokenBudget int `json:"suggestion_token_budget,omitempty"`
+
+	// Disable the automatic workspace snapshot taken before a turn's first
+	// mutating tool call. /snapshot and /rollback still work.
+	DisableWorkspaceSnapshots bool `json:"disable_workspace_snapshots,omitempty"`
+
+	// AutoVerifyCommand, if set, is run (e.g. "go test ./...") whenever the
+	// model ends a turn. A failure is fed back as a user message and the turn
+	// continues, up to MaxVerifyIterations attempts.
+	AutoVerifyCommand string `json:"auto_verify_command,omitempty"`
+
+	// MaxVerifyIterations caps AutoVerifyCommand runs per turn.
+	// 0 = default (3).
+	MaxVerifyIterations int `json:"max_verify_iterations,omitempty"`
+
+	// Review, in reviewed mode, has a reviewer subagent critique each turn's
+	// changes before the turn completes. Validated at workflow start.
+	Review PeerReview `json:"review,omitempty"`
+
+	// Autonomy, if its budget is set, keeps the agent working across turns
+	// without the user, checking in periodically. Validated at workflow start.
+	Autonomy Autonomy `json:"autonomy,omitempty"`
+
+	// TurnDeadline, if set, warns the model to wrap up near the end of each
+	// turn's time and interrupts the turn when it runs out. Validated at
+	// workflow start.
+	TurnDeadline TurnDeadline `json:"turn_deadline,omitempty"`
+
+	// SemanticSearch selects the embeddings used by the semantic_search
+	// tool's code index. The tool itself is enabled through Tools.
+	SemanticSearch SemanticSearch `json:"semantic_search,omitempty"`
+
+	// Browser limits the domains the browser_* tools may load.
+	Browser Browser `json:"browser,omitempty"`
+
+	// ReadFileLimit caps the lines read_file returns when the model passes
+	// no limit; the result tells the model how to read on. 0 = default
+	// (2000); negative reads whole files.
+	ReadFileLimit int `json:"read_file_limit,omitempty"`
+
+	// TrustAfterApprovals is how many times the user must approve an
+	// identical command before the session approves it automatically.
+	// 0 uses the default (3); negative disables learned trust.
+	TrustAfterApprovals int `json:"trust_after_approvals,omitempty"`
+
+	// Index session tags in the AgentTags search attribute so visibility
+	// queries can filter by tag. The attribute must be registered on the
+	// namespace first; tags are always kept in the workflow memo.
+	IndexSessionTags bool `json:"index_session_tags,omitempty"`
+
+	// Index the session title in the AgentTitle search attribute, like
+	// IndexSessionTags. The title is always kept in the workflow memo.
+	IndexSessionTitle bool `json:"index_session_title,omitempty"`
+
+	// DisableTitleGeneration titles sessions with their first user message
+	// instead of asking a cheap model after the first turn.
+	DisableTitleGeneration bool `json:"disable_title_generation,omitempty"`
+
+	// ArchiveURL, if set, is where session transcripts are archived
+	// (s3://bucket/prefix, gs://bucket/prefix or file:///dir). New history
+	// is appended after every turn and at shutdown.
+	ArchiveURL string `json:"archive_url,omitempty"`
+
+	// InjectAnnotations sends the user's /note and /react annotations to
+	// the model as feedback in the next prompt. Off by default: annotations
+	// are then only recorded, for transcript analysis.
+	InjectAnnotations bool `json:"inject_annotations,omitempty"`
+
+	// DiffRepeatedOutput answers a shell or exec command run again in the
+	// same turn with only how its output changed since the previous run
+	// ("output unchanged" or a compact line diff), saving tokens in
+	// fix-loops. Off by default.
+	DiffRepeatedOutput bool `json:"diff_repeated_output,omitempty"`
+
+	// WatchWorkspace watches Cwd for edits made outside the session and
+	// tells the model which files changed at the start of the next turn.
+	WatchWorkspace bool `json:"watch_workspace,omitempty"`
+
+	// ModelRouting picks the model per LLM call from rules on the tools
+	// requested, history size and the user's #hint. Validated at workflow
+	// start.
+	ModelRouting ModelRouting `json:"model_routing,omitempty"`
+
+	// ConsultModels are the models /consult asks in parallel (2-3). Each
+	// provider is inferred from the model name.
+	ConsultModels []string `json:"consult_models,omitempty"`
+
+	// Hooks runs the user's scripts before and after tool calls and when a
+	// turn completes. Off unless Hooks.Enabled is set.
+	Hooks Hooks `json:"hooks,omitempty"`
+
+	// ProjectPolicy records what the AGENTS.md front-matter of the session's
+	// directory changed, shown to the user when the session starts. Nil when
+	// no front-matter applied.
+	ProjectPolicy *ProjectPolicy `json:"project_policy,omitempty"`
+
 	// Session metadata
 	SessionSource string `json:"session_source,omitempty"` // "cli", "api", "exec" — for logging/tracking
 
@@ -203,6 +427,17 @@ type SessionConfiguration struct {
 	DisabledSkills []string `json:"disabled_skills,omitempty"` // Skill paths that are toggled off
 }
 
+// ProjectPolicy describes the AGENTS.md front-matter applied to a session.
+type ProjectPolicy struct {
+	// Sources are the AGENTS.md files that declared the policy.
+	Sources []string `json:"sources"`
+	// Applied lists the settings the policy changed, e.g.
+	// "approval_mode: unless-trusted".
+	Applied []string `json:"applied,omitempty"`
+	// Ignored lists the settings it was not allowed to change, with why.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
 // DefaultSessionConfiguration returns sensible defaults.
 func DefaultSessionConfiguration() SessionConfiguration {
 	return SessionConfiguration{
@@ -17,17 +17,67 @@ type ConfigToml struct {
 	ModelReasoningSummary      *string                        `toml:"model_reasoning_summary"`
 	ApprovalPolicy             *string                        `toml:"approval_policy"`
 	SandboxMode                *string                        `toml:"sandbox_mode"`
+	SandboxAudit               *bool                          `toml:"sandbox_audit"`
 	SandboxWorkspaceWrite      *SandboxWorkspaceWriteToml     `toml:"sandbox_workspace_write"`
+	ShellEnvironmentPolicy     *ShellEnvironmentPolicyToml    `toml:"shell_environment_policy"`
 	DisableSuggestions         *bool                          `toml:"disable_suggestions"`
+	SuggestionTokenBudget      *int                           `toml:"suggestion_token_budget"`
+	DisableWorkspaceSnapshots  *bool                          `toml:"disable_workspace_snapshots"`
+	IndexSessionTags           *bool                          `toml:"index_session_tags"`
+	IndexSessionTitle          *bool                          `toml:"index_session_title"`
+	DisableTitleGeneration     *bool                          `toml:"disable_title_generation"`
+	AutoVerifyCommand          *string                        `toml:"auto_verify_command"`
+	MaxVerifyIterations        *int                           `toml:"max_verify_iterations"`
+	ReadFileLimit              *int                           `toml:"read_file_limit"`
+	Review                     *ReviewToml                    `toml:"review"`
+	Autonomy                   *AutonomyToml                  `toml:"autonomy"`
+	TurnDeadline               *TurnDeadlineToml              `toml:"turn_deadline"`
+	TrustAfterApprovals        *int                           `toml:"trust_after_approvals"`
+	GitHubTools                *bool                          `toml:"github_tools"`
+	PythonTool                 *bool                          `toml:"python_tool"`
+	FetchURLTool               *bool                          `toml:"fetch_url_tool"`
+	QualityGateTool            *bool                          `toml:"quality_gate_tool"`
+	ShareArtifactTool          *bool                          `toml:"share_artifact_tool"`
+	SemanticSearch             *SemanticSearchToml            `toml:"semantic_search"`
+	Browser                    *BrowserToml                   `toml:"browser"`
+	ArchiveURL                 *string                        `toml:"archive_url"`
+	InjectAnnotations          *bool                          `toml:"inject_annotations"`
+	DiffRepeatedOutput         *bool                          `toml:"diff_repeated_output"`
+	WatchWorkspace             *bool                          `toml:"watch_workspace"`
+	Hooks                      *HooksToml                     `toml:"hooks"`
+	ModelRouting               *ModelRoutingToml              `toml:"model_routing"`
+	ConsultModels              []string                       `toml:"consult_models"`
 	McpServers                 map[string]McpServerConfigToml `toml:"mcp_servers"`
 	Memory                     *MemoryToml                    `toml:"memory"`
+	HistoryRetention           *HistoryRetentionToml          `toml:"history_retention"`
+	ApprovalWebhook            *ApprovalWebhookToml           `toml:"approval_webhook"`
+	AccessControl              *AccessControlToml             `toml:"access_control"`
+	Retry                      *RetryToml                     `toml:"retry"`
 	DisabledSkills             []string                       `toml:"disabled_skills"`
+	Tui                        *TuiToml                       `toml:"tui"`
+
+	// TrustedProjects are absolute directories whose AGENTS.md front-matter
+	// may change the model and provider of sessions started inside them.
+	// Not applied to SessionConfiguration; resolveHarnessConfig reads it.
+	TrustedProjects []string `toml:"trusted_projects"`
 }
 
 // SandboxWorkspaceWriteToml configures workspace-write sandbox settings.
 type SandboxWorkspaceWriteToml struct {
 	WritableRoots []string `toml:"writable_roots"`
 	NetworkAccess *bool    `toml:"network_access"`
+	NetworkAllow  []string `toml:"network_allow"`
+}
+
+// ShellEnvironmentPolicyToml configures the environment of tool commands.
+//
+// Maps to: codex-rs/core/src/config/types.rs ShellEnvironmentPolicyToml
+type ShellEnvironmentPolicyToml struct {
+	Inherit               *string           `toml:"inherit"`
+	IgnoreDefaultExcludes *bool             `toml:"ignore_default_excludes"`
+	Exclude               []string          `toml:"exclude"`
+	Set                   map[string]string `toml:"set"`
+	IncludeOnly           []string          `toml:"include_only"`
 }
 
 // MemoryToml configures the cross-session memory subsystem.
@@ -36,6 +86,143 @@ type MemoryToml struct {
 	DbPath  *string `toml:"db_path"`
 }
 
+// HistoryRetentionToml configures tool output elision.
+type HistoryRetentionToml struct {
+	ToolOutputTurns *int `toml:"tool_output_turns"`
+}
+
+// ApprovalWebhookToml configures delegated tool approvals.
+type ApprovalWebhookToml struct {
+	URL        *string           `toml:"url"`
+	Headers    map[string]string `toml:"headers"`
+	TimeoutSec *int              `toml:"timeout_sec"`
+	Fallback   *string           `toml:"fallback"`
+}
+
+// AccessControlToml restricts who may send Updates to sessions.
+type AccessControlToml struct {
+	Owner      *string  `toml:"owner"`
+	Identities []string `toml:"identities"`
+	TokenKeys  []string `toml:"token_keys"`
+}
+
+// SemanticSearchToml configures the semantic_search tool and its index.
+type SemanticSearchToml struct {
+	Enabled  *bool   `toml:"enabled"`
+	Provider *string `toml:"provider"`
+	Model    *string `toml:"model"`
+}
+
+// BrowserToml configures the browser_* tools.
+type BrowserToml struct {
+	Enabled        *bool    `toml:"enabled"`
+	AllowedDomains []string `toml:"allowed_domains"`
+}
+
+// HooksToml configures the user's hook scripts.
+type HooksToml struct {
+	Enabled    *bool    `toml:"enabled"`
+	TimeoutSec *int     `toml:"timeout_sec"`
+	Sandboxed  []string `toml:"sandboxed"`
+	FailOpen   *bool    `toml:"fail_open"`
+}
+
+// ModelRoutingToml configures per-call model routing.
+type ModelRoutingToml struct {
+	CompactionModel *string           `toml:"compaction_model"`
+	Rules           []RoutingRuleToml `toml:"rules"`
+}
+
+// RoutingRuleToml is one [[model_routing.rules]] entry.
+type RoutingRuleToml struct {
+	Name             string   `toml:"name"`
+	Model            string   `toml:"model"`
+	Provider         string   `toml:"provider"`
+	Tools            []string `toml:"tools"`
+	MinHistoryTokens int      `toml:"min_history_tokens"`
+	MaxHistoryTokens int      `toml:"max_history_tokens"`
+	Hint             string   `toml:"hint"`
+}
+
+// ReviewToml configures the implementer/reviewer loop.
+type ReviewToml struct {
+	Mode     *string `toml:"mode"`
+	Rounds   *int    `toml:"rounds"`
+	Model    *string `toml:"model"`
+	Provider *string `toml:"provider"`
+}
+
+// AutonomyToml configures time-boxed autonomous runs.
+type AutonomyToml struct {
+	Budget          *AutonomyLimitToml `toml:"budget"`
+	CheckinInterval *AutonomyLimitToml `toml:"checkin_interval"`
+	Approval        *string            `toml:"approval"`
+}
+
+// AutonomyLimitToml bounds autonomous work by minutes and turns.
+type AutonomyLimitToml struct {
+	Minutes *int `toml:"minutes"`
+	Turns   *int `toml:"turns"`
+}
+
+// applyTo sets the fields of l that are present in the TOML.
+func (t *AutonomyLimitToml) applyTo(l *AutonomyLimit) {
+	if t.Minutes != nil {
+		l.Minutes = *t.Minutes
+	}
+	if t.Turns != nil {
+		l.Turns = *t.Turns
+	}
+}
+
+// TurnDeadlineToml configures the per-turn soft deadline.
+type TurnDeadlineToml struct {
+	Minutes     *int `toml:"minutes"`
+	WarnMinutes *int `toml:"warn_minutes"`
+}
+
+// TuiToml holds settings of the tcx terminal UI. They are read by the
+// client and not applied to sessions.
+type TuiToml struct {
+	Theme *string `toml:"theme"` // Built-in theme name or theme file path
+}
+
+// RetryToml configures activity retry policies per class.
+type RetryToml struct {
+	LLM        *RetryPolicyToml           `toml:"llm"`
+	Tool       *RetryPolicyToml           `toml:"tool"`
+	Tools      map[string]RetryPolicyToml `toml:"tools"`
+	Compaction *RetryPolicyToml           `toml:"compaction"`
+}
+
+// RetryPolicyToml is the TOML representation of a RetryPolicy.
+type RetryPolicyToml struct {
+	InitialIntervalMs      *int     `toml:"initial_interval_ms"`
+	BackoffCoefficient     *float64 `toml:"backoff_coefficient"`
+	MaximumIntervalMs      *int     `toml:"maximum_interval_ms"`
+	MaximumAttempts        *int     `toml:"maximum_attempts"`
+	NonRetryableErrorTypes []string `toml:"non_retryable_error_types"`
+}
+
+// applyTo merges the set fields into p.
+func (t *RetryPolicyToml) applyTo(p *RetryPolicy) {
+	if t.InitialIntervalMs != nil {
+		p.InitialIntervalMs = *t.InitialIntervalMs
+	}
+	if t.BackoffCoefficient != nil {
+		p.BackoffCoefficient = *t.BackoffCoefficient
+	}
+	if t.MaximumIntervalMs != nil {
+		p.MaximumIntervalMs = *t.MaximumIntervalMs
+	}
+	if t.MaximumAttempts != nil {
+		p.MaximumAttempts = *t.MaximumAttempts
+	}
+	if len(t.NonRetryableErrorTypes) > 0 {
+		p.NonRetryableErrorTypes = t.NonRetryableErrorTypes
+	}
+}
+
 // McpServerConfigToml is the TOML representation of an MCP server config.
 type McpServerConfigToml struct {
 	Command           string            `toml:"command"`
@@ -91,6 +278,9 @@ func (c *ConfigToml) ApplyToConfig(cfg *SessionConfiguration) {
 	if c.SandboxMode != nil {
 		cfg.Permissions.SandboxMode = *c.SandboxMode
 	}
+	if c.SandboxAudit != nil {
+		cfg.Permissions.SandboxAudit = *c.SandboxAudit
+	}
 	if c.SandboxWorkspaceWrite != nil {
 		if len(c.SandboxWorkspaceWrite.WritableRoots) > 0 {
 			cfg.Permissions.SandboxWritableRoots = c.SandboxWorkspaceWrite.WritableRoots
@@ -98,10 +288,192 @@ func (c *ConfigToml) ApplyToConfig(cfg *SessionConfiguration) {
 		if c.SandboxWorkspaceWrite.NetworkAccess != nil {
 			cfg.Permissions.SandboxNetworkAccess = *c.SandboxWorkspaceWrite.NetworkAccess
 		}
+		if len(c.SandboxWorkspaceWrite.NetworkAllow) > 0 {
+			cfg.Permissions.SandboxNetworkAllow = c.SandboxWorkspaceWrite.NetworkAllow
+		}
+	}
+	if p := c.ShellEnvironmentPolicy; p != nil {
+		if p.Inherit != nil {
+			cfg.Permissions.EnvInherit = *p.Inherit
+		}
+		if p.IgnoreDefaultExcludes != nil {
+			cfg.Permissions.EnvIgnoreDefaultExcludes = p.IgnoreDefaultExcludes
+		}
+		if len(p.Exclude) > 0 {
+			cfg.Permissions.EnvExclude = p.Exclude
+		}
+		if len(p.Set) > 0 {
+			cfg.Permissions.EnvSet = p.Set
+		}
+		if len(p.IncludeOnly) > 0 {
+			cfg.Permissions.EnvIncludeOnly = p.IncludeOnly
+		}
 	}
 	if c.DisableSuggestions != nil {
 		cfg.DisableSuggestions = *c.DisableSuggestions
 	}
+	if c.SuggestionTokenBudget != nil {
+		cfg.SuggestionTokenBudget = *c.SuggestionTokenBudget
+	}
+	if c.DisableWorkspaceSnapshots != nil {
+		cfg.DisableWorkspaceSnapshots = *c.DisableWorkspaceSnapshots
+	}
+	if c.IndexSessionTags != nil {
+		cfg.IndexSessionTags = *c.IndexSessionTags
+	}
+	if c.IndexSessionTitle != nil {
+		cfg.IndexSessionTitle = *c.IndexSessionTitle
+	}
+	if c.DisableTitleGeneration != nil {
+		cfg.DisableTitleGeneration = *c.DisableTitleGeneration
+	}
+	if c.AutoVerifyCommand != nil {
+		cfg.AutoVerifyCommand = *c.AutoVerifyCommand
+	}
+	if c.MaxVerifyIterations != nil {
+		cfg.MaxVerifyIterations = *c.MaxVerifyIterations
+	}
+	if c.ReadFileLimit != nil {
+		cfg.ReadFileLimit = *c.ReadFileLimit
+	}
+	if r := c.Review; r != nil {
+		if r.Mode != nil {
+			cfg.Review.Mode = *r.Mode
+		}
+		if r.Rounds != nil {
+			cfg.Review.Rounds = *r.Rounds
+		}
+		if r.Model != nil {
+			cfg.Review.Model = *r.Model
+		}
+		if r.Provider != nil {
+			cfg.Review.Provider = *r.Provider
+		}
+	}
+	if a := c.Autonomy; a != nil {
+		if a.Budget != nil {
+			a.Budget.applyTo(&cfg.Autonomy.Budget)
+		}
+		if a.CheckinInterval != nil {
+			a.CheckinInterval.applyTo(&cfg.Autonomy.CheckinInterval)
+		}
+		if a.Approval != nil {
+			cfg.Autonomy.Approval = ApprovalMode(*a.Approval)
+		}
+	}
+	if d := c.TurnDeadline; d != nil {
+		if d.Minutes != nil {
+			cfg.TurnDeadline.Minutes = *d.Minutes
+		}
+		if d.WarnMinutes != nil {
+			cfg.TurnDeadline.WarnMinutes = *d.WarnMinutes
+		}
+	}
+	if c.TrustAfterApprovals != nil {
+		cfg.TrustAfterApprovals = *c.TrustAfterApprovals
+	}
+	if c.GitHubTools != nil {
+		if *c.GitHubTools && !cfg.Tools.HasTool("gh_get_issue") {
+			cfg.Tools.AddTools("github")
+		} else if !*c.GitHubTools {
+			cfg.Tools.RemoveTools("github")
+		}
+	}
+	if c.PythonTool != nil {
+		if *c.PythonTool && !cfg.Tools.HasTool("python_exec") {
+			cfg.Tools.AddTools("python_exec")
+		} else if !*c.PythonTool {
+			cfg.Tools.RemoveTools("python_exec")
+		}
+	}
+	if c.FetchURLTool != nil {
+		if *c.FetchURLTool && !cfg.Tools.HasTool("fetch_url") {
+			cfg.Tools.AddTools("fetch_url")
+		} else if !*c.FetchURLTool {
+			cfg.Tools.RemoveTools("fetch_url")
+		}
+	}
+	if c.QualityGateTool != nil {
+		if *c.QualityGateTool && !cfg.Tools.HasTool("quality_gate") {
+			cfg.Tools.AddTools("quality_gate")
+		} else if !*c.QualityGateTool {
+			cfg.Tools.RemoveTools("quality_gate")
+		}
+	}
+	if c.ShareArtifactTool != nil {
+		if *c.ShareArtifactTool && !cfg.Tools.HasTool("share_artifact") {
+			cfg.Tools.AddTools("share_artifact")
+		} else if !*c.ShareArtifactTool {
+			cfg.Tools.RemoveTools("share_artifact")
+		}
+	}
+	if ss := c.SemanticSearch; ss != nil {
+		if ss.Enabled != nil {
+			if *ss.Enabled && !cfg.Tools.HasTool("semantic_search") {
+				cfg.Tools.AddTools("semantic_search")
+			} else if !*ss.Enabled {
+				cfg.Tools.RemoveTools("semantic_search")
+			}
+		}
+		if ss.Provider != nil {
+			cfg.SemanticSearch.Provider = *ss.Provider
+		}
+		if ss.Model != nil {
+			cfg.SemanticSearch.Model = *ss.Model
+		}
+	}
+	if b := c.Browser; b != nil {
+		if b.Enabled != nil {
+			if *b.Enabled && !cfg.Tools.HasTool("browser_navigate") {
+				cfg.Tools.AddTools("browser")
+			} else if !*b.Enabled {
+				cfg.Tools.RemoveTools("browser")
+			}
+		}
+		if b.AllowedDomains != nil {
+			cfg.Browser.AllowedDomains = b.AllowedDomains
+		}
+	}
+	if c.ArchiveURL != nil {
+		cfg.ArchiveURL = *c.ArchiveURL
+	}
+	if c.InjectAnnotations != nil {
+		cfg.InjectAnnotations = *c.InjectAnnotations
+	}
+	if c.DiffRepeatedOutput != nil {
+		cfg.DiffRepeatedOutput = *c.DiffRepeatedOutput
+	}
+	if c.WatchWorkspace != nil {
+		cfg.WatchWorkspace = *c.WatchWorkspace
+	}
+	if r := c.ModelRouting; r != nil {
+		if r.CompactionModel != nil {
+			cfg.ModelRouting.CompactionModel = *r.CompactionModel
+		}
+		if r.Rules != nil {
+			cfg.ModelRouting.Rules = make([]RoutingRule, len(r.Rules))
+			for i, rule := range r.Rules {
+				cfg.ModelRouting.Rules[i] = RoutingRule(rule)
+			}
+		}
+	}
+	if c.ConsultModels != nil {
+		cfg.ConsultModels = c.ConsultModels
+	}
+	if h := c.Hooks; h != nil {
+		if h.Enabled != nil {
+			cfg.Hooks.Enabled = *h.Enabled
+		}
+		if h.TimeoutSec != nil {
+			cfg.Hooks.TimeoutSec = *h.TimeoutSec
+		}
+		if h.Sandboxed != nil {
+			cfg.Hooks.Sandboxed = h.Sandboxed
+		}
+		if h.FailOpen != nil {
+			cfg.Hooks.FailOpen = *h.FailOpen
+		}
+	}
 	if len(c.McpServers) > 0 {
 		if cfg.McpServers == nil {
 			cfg.McpServers = make(map[string]mcp.McpServerConfig, len(c.McpServers))
@@ -113,6 +485,55 @@ func (c *ConfigToml) ApplyToConfig(cfg *SessionConfiguration) {
 	if len(c.DisabledSkills) > 0 {
 		cfg.DisabledSkills = c.DisabledSkills
 	}
+	if c.HistoryRetention != nil && c.HistoryRetention.ToolOutputTurns != nil {
+		cfg.HistoryRetention.ToolOutputTurns = *c.HistoryRetention.ToolOutputTurns
+	}
+	if w := c.ApprovalWebhook; w != nil {
+		if w.URL != nil {
+			cfg.ApprovalWebhook.URL = *w.URL
+		}
+		if len(w.Headers) > 0 {
+			cfg.ApprovalWebhook.Headers = w.Headers
+		}
+		if w.TimeoutSec != nil {
+			cfg.ApprovalWebhook.TimeoutSec = *w.TimeoutSec
+		}
+		if w.Fallback != nil {
+			cfg.ApprovalWebhook.Fallback = ApprovalWebhookFallback(*w.Fallback)
+		}
+	}
+	if a := c.AccessControl; a != nil {
+		if a.Owner != nil {
+			cfg.AccessControl.Owner = *a.Owner
+		}
+		if a.Identities != nil {
+			cfg.AccessControl.Identities = a.Identities
+		}
+		if a.TokenKeys != nil {
+			cfg.AccessControl.TokenKeys = a.TokenKeys
+		}
+	}
+	if r := c.Retry; r != nil {
+		if r.LLM != nil {
+			r.LLM.applyTo(&cfg.RetryPolicies.LLM)
+		}
+		if r.Tool != nil {
+			r.Tool.applyTo(&cfg.RetryPolicies.Tool)
+		}
+		if r.Compaction != nil {
+			r.Compaction.applyTo(&cfg.RetryPolicies.Compaction)
+		}
+		if len(r.Tools) > 0 {
+			if cfg.RetryPolicies.Tools == nil {
+				cfg.RetryPolicies.Tools = make(map[string]RetryPolicy, len(r.Tools))
+			}
+			for name, t := range r.Tools {
+				p := cfg.RetryPolicies.Tools[name]
+				t.applyTo(&p)
+				cfg.RetryPolicies.Tools[name] = p
+			}
+		}
+	}
 	if c.Memory != nil {
 		if c.Memory.Enabled != nil {
 			cfg.MemoryEnabled = *c.Memory.Enabled
@@ -16,6 +16,7 @@ model_auto_compact_token_limit = 160000
 model_reasoning_effort = "high"
 approval_policy = "unless-trusted"
 sandbox_mode = "workspace-write"
+sandbox_audit = true
 disable_suggestions = false
 
 [sandbox_workspace_write]
@@ -46,6 +47,7 @@ DOCS_API_KEY = "secret"
 	assert.Equal(t, "high", *cfg.ModelReasoningEffort)
 	assert.Equal(t, "unless-trusted", *cfg.ApprovalPolicy)
 	assert.Equal(t, "workspace-write", *cfg.SandboxMode)
+	assert.Equal(t, true, *cfg.SandboxAudit)
 	assert.Equal(t, false, *cfg.DisableSuggestions)
 
 	require.NotNil(t, cfg.SandboxWorkspaceWrite)
@@ -108,15 +110,110 @@ model_reasoning_effort = "high"
 approval_policy = "unless-trusted"
 sandbox_mode = "workspace-write"
 disable_suggestions = true
+suggestion_token_budget = 8000
+index_session_tags = true
+index_session_title = true
+disable_title_generation = true
+auto_verify_command = "go test ./..."
+max_verify_iterations = 5
+read_file_limit = 500
+trust_after_approvals = 2
+github_tools = true
+python_tool = true
+fetch_url_tool = true
+quality_gate_tool = true
+share_artifact_tool = true
+archive_url = "s3://transcripts/agents"
+inject_annotations = true
+diff_repeated_output = true
+watch_workspace = true
+consult_models = ["gpt-4o", "gemini-2.5-pro"]
 
 [sandbox_workspace_write]
 writable_roots = ["/home/dev/projects"]
 network_access = true
+network_allow = ["registry.npmjs.org", "*.pypi.org:443"]
+
+[shell_environment_policy]
+inherit = "core"
+exclude = ["AWS_*"]
+set = { GOFLAGS = "-mod=mod" }
 
 [memory]
 enabled = true
 db_path = "/tmp/test.sqlite"
 
+[history_retention]
+tool_output_turns = 3
+
+[approval_webhook]
+url = "https://approvals.example.com/hook"
+timeout_sec = 120
+fallback = "escalate"
+headers = { Authorization = "Bearer t" }
+
+[access_control]
+owner = "alice"
+identities = ["bob@ci"]
+token_keys = ["abc123"]
+
+[model_routing]
+compaction_model = "gpt-4o-mini"
+
+[[model_routing.rules]]
+name = "edit"
+tools = ["apply_patch"]
+model = "claude-sonnet-4-5"
+
+[[model_routing.rules]]
+max_history_tokens = 8000
+model = "gpt-4o-mini"
+
+[hooks]
+enabled = true
+timeout_sec = 10
+sandboxed = ["post_tool_use"]
+fail_open = true
+
+[semantic_search]
+enabled = true
+provider = "openai"
+model = "text-embedding-3-large"
+
+[browser]
+enabled = true
+allowed_domains = ["localhost:3000", "*.example.com"]
+
+[review]
+mode = "reviewed"
+rounds = 2
+model = "claude-sonnet-4-5"
+
+[autonomy]
+approval = "never"
+
+[autonomy.budget]
+minutes = 30
+turns = 10
+
+[autonomy.checkin_interval]
+turns = 3
+
+[turn_deadline]
+minutes = 15
+warn_minutes = 3
+
+[retry.llm]
+maximum_attempts = 8
+backoff_coefficient = 1.5
+
+[retry.tool]
+non_retryable_error_types = ["ToolCrash"]
+
+[retry.tools.shell_command]
+maximum_attempts = 3
+initial_interval_ms = 30000
+
 [mcp_servers.test]
 command = "test-server"
 args = ["--flag"]
@@ -137,9 +234,69 @@ required = true
 	assert.Equal(t, "workspace-write", cfg.Permissions.SandboxMode)
 	assert.Equal(t, []string{"/home/dev/projects"}, cfg.Permissions.SandboxWritableRoots)
 	assert.Equal(t, true, cfg.Permissions.SandboxNetworkAccess)
+	assert.Equal(t, []string{"registry.npmjs.org", "*.pypi.org:443"}, cfg.Permissions.SandboxNetworkAllow)
+	assert.Equal(t, "core", cfg.Permissions.EnvInherit)
+	assert.Equal(t, []string{"AWS_*"}, cfg.Permissions.EnvExclude)
+	assert.Equal(t, map[string]string{"GOFLAGS": "-mod=mod"}, cfg.Permissions.EnvSet)
 	assert.Equal(t, true, cfg.DisableSuggestions)
+	assert.Equal(t, 8000, cfg.SuggestionTokenBudget)
+	assert.Equal(t, true, cfg.IndexSessionTags)
+	assert.Equal(t, true, cfg.IndexSessionTitle)
+	assert.Equal(t, true, cfg.DisableTitleGeneration)
+	assert.Equal(t, "go test ./...", cfg.AutoVerifyCommand)
+	assert.Equal(t, 5, cfg.MaxVerifyIterations)
+	assert.Equal(t, 500, cfg.ReadFileLimit)
+	assert.Equal(t, 2, cfg.TrustAfterApprovals)
+	assert.Equal(t, PeerReview{Mode: ReviewModeReviewed, Rounds: 2, Model: "claude-sonnet-4-5"}, cfg.Review)
+	assert.Equal(t, Autonomy{
+		Budget:          AutonomyLimit{Minutes: 30, Turns: 10},
+		CheckinInterval: AutonomyLimit{Turns: 3},
+		Approval:        ApprovalNever,
+	}, cfg.Autonomy)
+	assert.Equal(t, TurnDeadline{Minutes: 15, WarnMinutes: 3}, cfg.TurnDeadline)
+	assert.True(t, cfg.Tools.HasTool("gh_create_pr"))
+	assert.True(t, cfg.Tools.HasTool("python_exec"))
+	assert.True(t, cfg.Tools.HasTool("quality_gate"))
+	assert.True(t, cfg.Tools.HasTool("share_artifact"))
+	assert.True(t, cfg.Tools.HasTool("fetch_url"))
+	assert.True(t, cfg.Tools.HasTool("semantic_search"))
+	assert.Equal(t, SemanticSearch{Provider: "openai", Model: "text-embedding-3-large"}, cfg.SemanticSearch)
+	assert.True(t, cfg.Tools.HasTool("browser_screenshot"))
+	assert.Equal(t, []string{"localhost:3000", "*.example.com"}, cfg.Browser.AllowedDomains)
+	assert.Equal(t, "s3://transcripts/agents", cfg.ArchiveURL)
+	assert.Equal(t, true, cfg.InjectAnnotations)
+	assert.True(t, cfg.DiffRepeatedOutput)
+	assert.True(t, cfg.WatchWorkspace)
+	assert.Equal(t, ModelRouting{
+		CompactionModel: "gpt-4o-mini",
+		Rules: []RoutingRule{
+			{Name: "edit", Tools: []string{"apply_patch"}, Model: "claude-sonnet-4-5"},
+			{MaxHistoryTokens: 8000, Model: "gpt-4o-mini"},
+		},
+	}, cfg.ModelRouting)
+	assert.Equal(t, []string{"gpt-4o", "gemini-2.5-pro"}, cfg.ConsultModels)
+	assert.Equal(t, Hooks{Enabled: true, TimeoutSec: 10, Sandboxed: []string{"post_tool_use"}, FailOpen: true}, cfg.Hooks)
 	assert.Equal(t, true, cfg.MemoryEnabled)
 	assert.Equal(t, "/tmp/test.sqlite", cfg.MemoryDbPath)
+	assert.Equal(t, 3, cfg.HistoryRetention.ToolOutputTurns)
+	assert.Equal(t, ApprovalWebhook{
+		URL:        "https://approvals.example.com/hook",
+		Headers:    map[string]string{"Authorization": "Bearer t"},
+		TimeoutSec: 120,
+		Fallback:   ApprovalWebhookFallbackEscalate,
+	}, cfg.ApprovalWebhook)
+	assert.Equal(t, AccessControl{
+		Owner:      "alice",
+		Identities: []string{"bob@ci"},
+		TokenKeys:  []string{"abc123"},
+	}, cfg.AccessControl)
+	assert.Equal(t, RetryPolicies{
+		LLM:  RetryPolicy{MaximumAttempts: 8, BackoffCoefficient: 1.5},
+		Tool: RetryPolicy{NonRetryableErrorTypes: []string{"ToolCrash"}},
+		Tools: map[string]RetryPolicy{
+			"shell_command": {MaximumAttempts: 3, InitialIntervalMs: 30000},
+		},
+	}, cfg.RetryPolicies)
 
 	require.Contains(t, cfg.McpServers, "test")
 	assert.Equal(t, "test-server", cfg.McpServers["test"].Transport.Command)
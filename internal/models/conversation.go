@@ -3,6 +3,14 @@
 // Corresponds to: codex-rs/core/src/protocol (ResponseItem, ToolCall, etc.)
 package models
 
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+)
+
 // ConversationItemType matches Codex's ResponseItem enum variants.
 //
 // See: codex-rs/core/src/protocol ResponseItem
@@ -20,6 +28,26 @@ const (
 	// Sent as a developer-role message so the new model has context about the transition.
 	ItemTypeModelSwitch ConversationItemType = "model_switch"
 
+	// Developer-role instructions injected by automation (developer_input
+	// Update), e.g. a CI bot steering the agent. Sent with the developer role
+	// where the provider has one; shown dimmed, apart from the conversation.
+	ItemTypeDeveloperMessage ConversationItemType = "developer_message"
+
+	// User's free-text answer to an ask_user question (CallID links it to the
+	// call). Display-only: the model receives the answer as the call's output.
+	ItemTypeUserAnswer ConversationItemType = "user_answer"
+
+	// User's note or reaction on an earlier item (/note, /react). The target
+	// is in Annotation and the note text in Content. Sent to the model as
+	// feedback only when Annotation.Feedback is set.
+	ItemTypeAnnotation ConversationItemType = "annotation"
+
+	// Shell command the user ran directly (/!cmd), without the model. The
+	// command is in Arguments ({"command": ...}) and its result in Output.
+	// Sent to the model as a user message (FormatUserShellCommand), so later
+	// turns know what the user saw.
+	ItemTypeUserShellCommand ConversationItemType = "user_shell_command"
+
 	// Turn lifecycle markers (maps to Codex EventMsg::TurnStarted / EventMsg::TurnComplete)
 	ItemTypeTurnStarted  ConversationItemType = "turn_started"  // Codex: EventMsg::TurnStarted
 	ItemTypeTurnComplete ConversationItemType = "turn_complete"  // Codex: EventMsg::TurnComplete
@@ -31,6 +59,75 @@ const (
 type FunctionCallOutputPayload struct {
 	Content string `json:"content"`
 	Success *bool  `json:"success,omitempty"`
+
+	// Redactions is the number of secrets scrubbed from Content by the
+	// worker before it entered history. Display-only; not sent to the LLM.
+	Redactions int `json:"redactions,omitempty"`
+
+	// Attachments are files the tool produced, such as saved plots.
+	// Display-only; not sent to the LLM. Inline data is dropped once the
+	// turn that produced it is over.
+	Attachments []attachment.Attachment `json:"attachments,omitempty"`
+
+	// DuplicateOf is the CallID of an earlier output with identical
+	// content. History stores that content once; Content here is a short
+	// reference, expanded in the LLM prompt when it fits the context budget.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+}
+
+// VerifyStatus is the outcome of a turn's auto-verify loop.
+type VerifyStatus string
+
+const (
+	VerifyPassed VerifyStatus = "passed" // The verify command succeeded
+	VerifyFailed VerifyStatus = "failed" // Still failing after MaxVerifyIterations attempts
+)
+
+// VerifyResult records the auto-verify attempts of a turn on its
+// TurnComplete item.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type VerifyResult struct {
+	Command  string       `json:"command"`
+	Attempts int          `json:"attempts"`
+	Status   VerifyStatus `json:"status"`
+}
+
+// Reaction is a user's thumbs-up or thumbs-down on a history item.
+type Reaction string
+
+const (
+	ReactionUp   Reaction = "up"
+	ReactionDown Reaction = "down"
+)
+
+// Emoji returns the reaction's glyph, or "" for no reaction.
+func (r Reaction) Emoji() string {
+	switch r {
+	case ReactionUp:
+		return "👍"
+	case ReactionDown:
+		return "👎"
+	}
+	return ""
+}
+
+// Annotation links an annotation item to the item it comments on. The target
+// is described as well as referenced, so exported transcripts stay readable
+// after compaction renumbers or drops the target.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type Annotation struct {
+	TargetSeq     int                  `json:"target_seq"`
+	TargetType    ConversationItemType `json:"target_type"`
+	TargetTurnID  string               `json:"target_turn_id,omitempty"`
+	TargetCallID  string               `json:"target_call_id,omitempty"`
+	TargetPreview string               `json:"target_preview,omitempty"`
+	Reaction      Reaction             `json:"reaction,omitempty"`
+
+	// Feedback marks the annotation for the model: it is sent as a
+	// developer message in the next prompt (inject_annotations).
+	Feedback bool `json:"feedback,omitempty"`
 }
 
 // ConversationItem matches Codex's ResponseItem enum.
@@ -50,6 +147,10 @@ type ConversationItem struct {
 	// Used by the CLI to track which items have already been rendered.
 	Seq int `json:"seq"`
 
+	// CreatedAt is the workflow time at which history added the item. Zero
+	// for items recorded before it was tracked.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+
 	// UserMessage / AssistantMessage fields
 	Content string `json:"content,omitempty"`
 
@@ -70,6 +171,67 @@ type ConversationItem struct {
 
 	// Turn tracking (maps to Codex TurnContext.turn_id)
 	TurnID string `json:"turn_id,omitempty"`
+
+	// TurnComplete fields: the turn's auto-verify and peer review outcomes,
+	// if they ran.
+	Verify *VerifyResult `json:"verify,omitempty"`
+	Review *ReviewResult `json:"review,omitempty"`
+
+	// AssistantMessage fields: the structured check-in of an autonomous
+	// run, when the message is one (Content holds its rendering).
+	Checkin *Checkin `json:"checkin,omitempty"`
+
+	// Pinned items survive compaction and turn dropping verbatim (/pin,
+	// pin_context tool). A function call and its output are pinned together.
+	Pinned bool `json:"pinned,omitempty"`
+
+	// Annotation fields: the annotated item and the user's reaction.
+	Annotation *Annotation `json:"annotation,omitempty"`
+}
+
+// IsKnown reports whether t is one of the item types above.
+func (t ConversationItemType) IsKnown() bool {
+	switch t {
+	case ItemTypeUserMessage, ItemTypeAssistantMessage, ItemTypeFunctionCall, ItemTypeFunctionCallOutput,
+		ItemTypeWebSearchCall, ItemTypeCompaction, ItemTypeModelSwitch, ItemTypeDeveloperMessage,
+		ItemTypeUserAnswer, ItemTypeAnnotation, ItemTypeUserShellCommand,
+		ItemTypeTurnStarted, ItemTypeTurnComplete:
+		return true
+	}
+	return false
+}
+
+// IsPinnable reports whether an item of this type can be pinned: messages
+// and tool calls/outputs. Turn markers and other bookkeeping items cannot.
+func (t ConversationItemType) IsPinnable() bool {
+	switch t {
+	case ItemTypeUserMessage, ItemTypeAssistantMessage, ItemTypeDeveloperMessage,
+		ItemTypeFunctionCall, ItemTypeFunctionCallOutput, ItemTypeUserShellCommand:
+		return true
+	}
+	return false
+}
+
+// UserShellCommand returns the command of a user_shell_command item.
+func (item ConversationItem) UserShellCommand() string {
+	var args struct {
+		Command string `json:"command"`
+	}
+	_ = json.Unmarshal([]byte(item.Arguments), &args)
+	return args.Command
+}
+
+// FormatUserShellCommand renders a user_shell_command item as the text of
+// the user message the model receives.
+//
+// Maps to: codex-rs/core/src/user_shell_command.rs format_user_shell_command_record
+func FormatUserShellCommand(item ConversationItem) string {
+	output := ""
+	if item.Output != nil {
+		output = item.Output.Content
+	}
+	return "<user_shell_command>\n<command>\n" + item.UserShellCommand() + "\n</command>\n<result>\n" +
+		strings.TrimRight(output, "\n") + "\n</result>\n</user_shell_command>"
 }
 
 // ToolCall represents a parsed tool call for internal dispatch.
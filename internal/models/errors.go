@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"go.temporal.io/sdk/temporal"
 )
@@ -45,6 +46,10 @@ type ActivityError struct {
 	Retryable bool                   `json:"retryable"`
 	Message   string                 `json:"message"`
 	Details   map[string]interface{} `json:"details,omitempty"`
+
+	// RateLimit, for ErrorTypeAPILimit, is what the provider's response
+	// headers said about when to retry. Nil when they said nothing.
+	RateLimit *LLMRateLimitDetails `json:"rate_limit,omitempty"`
 }
 
 // Error implements the error interface
@@ -114,6 +119,19 @@ const (
 	LLMErrTypeFatal = "LLMFatal"
 )
 
+// LLMRateLimitDetails carries a rate-limit error's backoff hint in
+// ApplicationError.Details(), parsed from the provider's response headers.
+type LLMRateLimitDetails struct {
+	RetryAfterMs      int64 `json:"retry_after_ms"`
+	RequestsRemaining *int  `json:"requests_remaining,omitempty"`
+	TokensRemaining   *int  `json:"tokens_remaining,omitempty"`
+}
+
+// RetryAfter returns the backoff hint as a duration.
+func (d LLMRateLimitDetails) RetryAfter() time.Duration {
+	return time.Duration(d.RetryAfterMs) * time.Millisecond
+}
+
 // WrapActivityError converts an ActivityError into a temporal.ApplicationError
 // suitable for returning from a Temporal activity. This ensures the error type
 // survives serialization across the activity boundary.
@@ -122,6 +140,11 @@ func WrapActivityError(ae *ActivityError) error {
 	case ErrorTypeContextOverflow:
 		return temporal.NewNonRetryableApplicationError(ae.Message, LLMErrTypeContextOverflow, nil)
 	case ErrorTypeAPILimit:
+		// With a backoff hint the workflow waits exactly that long (and
+		// shows it), so the activity does not retry blindly first.
+		if ae.RateLimit != nil && ae.RateLimit.RetryAfterMs > 0 {
+			return temporal.NewNonRetryableApplicationError(ae.Message, LLMErrTypeAPILimit, nil, *ae.RateLimit)
+		}
 		return temporal.NewApplicationErrorWithCause(ae.Message, LLMErrTypeAPILimit, nil)
 	case ErrorTypeFatal:
 		return temporal.NewNonRetryableApplicationError(ae.Message, LLMErrTypeFatal, nil)
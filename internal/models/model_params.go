@@ -0,0 +1,89 @@
+package models
+
+import "fmt"
+
+// ModelParams is a partial change to a session's ModelConfig: nil fields are
+// left unchanged. Provider and model are switched separately (update_model).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type ModelParams struct {
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        *int              `json:"max_tokens,omitempty"`
+	ContextWindow    *int              `json:"context_window,omitempty"`
+	ReasoningEffort  *ReasoningEffort  `json:"reasoning_effort,omitempty"`
+	ReasoningSummary *ReasoningSummary `json:"reasoning_summary,omitempty"`
+}
+
+// IsEmpty reports whether p changes nothing.
+func (p ModelParams) IsEmpty() bool {
+	return p.Temperature == nil && p.MaxTokens == nil && p.ContextWindow == nil &&
+		p.ReasoningEffort == nil && p.ReasoningSummary == nil
+}
+
+// Apply returns cfg with p's fields set.
+func (p ModelParams) Apply(cfg ModelConfig) ModelConfig {
+	if p.Temperature != nil {
+		cfg.Temperature = *p.Temperature
+	}
+	if p.MaxTokens != nil {
+		cfg.MaxTokens = *p.MaxTokens
+	}
+	if p.ContextWindow != nil {
+		cfg.ContextWindow = *p.ContextWindow
+	}
+	if p.ReasoningEffort != nil {
+		cfg.ReasoningEffort = *p.ReasoningEffort
+	}
+	if p.ReasoningSummary != nil {
+		cfg.ReasoningSummary = *p.ReasoningSummary
+	}
+	return cfg
+}
+
+// Validate checks p against the model's constraints. cfg is the current
+// configuration of the model, profile its resolved profile.
+func (p ModelParams) Validate(cfg ModelConfig, profile ResolvedProfile) error {
+	if t := p.Temperature; t != nil {
+		if profile.TemperatureUnsupported {
+			return fmt.Errorf("model %s does not accept a temperature", cfg.Model)
+		}
+		if *t < 0 {
+			return fmt.Errorf("temperature must not be negative")
+		}
+		if limit := profile.MaxTemperature; limit > 0 && *t > limit {
+			return fmt.Errorf("temperature must be at most %g for %s", limit, cfg.Provider)
+		}
+	}
+	if p.MaxTokens != nil && *p.MaxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be positive")
+	}
+	if p.ContextWindow != nil && *p.ContextWindow <= 0 {
+		return fmt.Errorf("context_window must be positive")
+	}
+	if next := p.Apply(cfg); next.MaxTokens > next.ContextWindow {
+		return fmt.Errorf("max_tokens (%d) must not exceed the context window (%d)", next.MaxTokens, next.ContextWindow)
+	}
+
+	if p.ReasoningEffort != nil || p.ReasoningSummary != nil {
+		if len(profile.SupportedReasoningEfforts) == 0 {
+			return fmt.Errorf("model %s does not support reasoning configuration", cfg.Model)
+		}
+	}
+	if e := p.ReasoningEffort; e != nil {
+		supported := false
+		var names []string
+		for _, preset := range profile.SupportedReasoningEfforts {
+			supported = supported || preset.Effort == *e
+			names = append(names, string(preset.Effort))
+		}
+		if !supported {
+			return fmt.Errorf("model %s does not support reasoning effort %q (supported: %v)", cfg.Model, *e, names)
+		}
+	}
+	if s := p.ReasoningSummary; s != nil {
+		if parsed, ok := ParseReasoningSummary(string(*s)); !ok || parsed != *s {
+			return fmt.Errorf("invalid reasoning summary %q", *s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelParams_Apply(t *testing.T) {
+	temp := 0.2
+	effort := ReasoningEffortHigh
+	cfg := ModelParams{Temperature: &temp, ReasoningEffort: &effort}.Apply(DefaultModelConfig())
+	assert.Equal(t, 0.2, cfg.Temperature)
+	assert.Equal(t, ReasoningEffortHigh, cfg.ReasoningEffort)
+	assert.Equal(t, 4096, cfg.MaxTokens, "unset fields are unchanged")
+
+	assert.True(t, ModelParams{}.IsEmpty())
+	assert.False(t, ModelParams{Temperature: &temp}.IsEmpty())
+}
+
+func TestModelParams_Validate(t *testing.T) {
+	registry := NewDefaultRegistry()
+	ptrF := func(v float64) *float64 { return &v }
+	ptrI := func(v int) *int { return &v }
+	effort := func(e ReasoningEffort) *ReasoningEffort { return &e }
+
+	gpt := ModelConfig{Provider: "openai", Model: "gpt-4o", MaxTokens: 4096, ContextWindow: 128000}
+	codex := ModelConfig{Provider: "openai", Model: "codex-mini-latest", MaxTokens: 4096, ContextWindow: 200000}
+	claude := ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-0", MaxTokens: 8192, ContextWindow: 200000}
+	profile := func(cfg ModelConfig) ResolvedProfile { return registry.Resolve(cfg.Provider, cfg.Model) }
+
+	tests := []struct {
+		name    string
+		cfg     ModelConfig
+		params  ModelParams
+		wantErr string
+	}{
+		{"gpt temperature", gpt, ModelParams{Temperature: ptrF(1.5)}, ""},
+		{"gpt temperature too high", gpt, ModelParams{Temperature: ptrF(2.5)}, "at most 2"},
+		{"negative temperature", gpt, ModelParams{Temperature: ptrF(-1)}, "must not be negative"},
+		{"anthropic ceiling", claude, ModelParams{Temperature: ptrF(1.5)}, "at most 1 for anthropic"},
+		{"codex rejects temperature", codex, ModelParams{Temperature: ptrF(0.2)}, "does not accept a temperature"},
+		{"codex effort", codex, ModelParams{ReasoningEffort: effort(ReasoningEffortHigh)}, ""},
+		{"codex unsupported effort", codex, ModelParams{ReasoningEffort: effort(ReasoningEffortMinimal)}, `reasoning effort "minimal"`},
+		{"gpt has no reasoning", gpt, ModelParams{ReasoningEffort: effort(ReasoningEffortLow)}, "does not support reasoning"},
+		{"max tokens", claude, ModelParams{MaxTokens: ptrI(16000)}, ""},
+		{"max tokens zero", claude, ModelParams{MaxTokens: ptrI(0)}, "must be positive"},
+		{"max tokens over window", gpt, ModelParams{MaxTokens: ptrI(200000)}, "must not exceed the context window"},
+		{"shrunk window", gpt, ModelParams{ContextWindow: ptrI(2048)}, "must not exceed the context window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate(tt.cfg, profile(tt.cfg))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
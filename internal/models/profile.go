@@ -28,6 +28,14 @@ type ModelProfile struct {
 	// Temperature overrides the default temperature. nil = inherit.
 	Temperature *float64
 
+	// MaxTemperature is the highest temperature the provider accepts.
+	// nil = inherit.
+	MaxTemperature *float64
+
+	// TemperatureUnsupported marks models whose API rejects a temperature
+	// (OpenAI reasoning models). nil = inherit.
+	TemperatureUnsupported *bool
+
 	// MaxTokens overrides the default max tokens. nil = inherit.
 	MaxTokens *int
 
@@ -59,6 +67,9 @@ type ResolvedProfile struct {
 	MaxTokens       *int
 	ContextWindow   *int
 
+	MaxTemperature         float64 // 0 = no limit
+	TemperatureUnsupported bool
+
 	DefaultReasoningEffort    *ReasoningEffort        `json:"default_reasoning_effort,omitempty"`
 	SupportedReasoningEfforts []ReasoningEffortPreset  `json:"supported_reasoning_efforts,omitempty"`
 }
@@ -102,6 +113,12 @@ func mergeProfiles(base, overlay ModelProfile) ModelProfile {
 	if overlay.Temperature != nil {
 		result.Temperature = overlay.Temperature
 	}
+	if overlay.MaxTemperature != nil {
+		result.MaxTemperature = overlay.MaxTemperature
+	}
+	if overlay.TemperatureUnsupported != nil {
+		result.TemperatureUnsupported = overlay.TemperatureUnsupported
+	}
 	if overlay.MaxTokens != nil {
 		result.MaxTokens = overlay.MaxTokens
 	}
@@ -3,6 +3,10 @@ package models
 // defaultReasoningEffort is the default reasoning effort for OpenAI reasoning models.
 var defaultReasoningEffort = ReasoningEffortMedium
 
+// temperatureUnsupported marks reasoning models, whose API rejects a
+// temperature.
+var temperatureUnsupported = true
+
 // openaiProfile is the provider-wide profile for OpenAI models.
 // No CLAUDE.md for OpenAI — only AGENTS files.
 var openaiProfile = ModelProfile{
@@ -15,6 +19,7 @@ var openaiReasoningProfile = ModelProfile{
 	Provider:     "openai",
 	ModelPattern: `^(o1|o3|o4|codex)-`,
 	DefaultReasoningEffort: &defaultReasoningEffort,
+	TemperatureUnsupported: &temperatureUnsupported,
 	SupportedReasoningEfforts: []ReasoningEffortPreset{
 		{Effort: ReasoningEffortLow, Description: "Fastest responses, least reasoning"},
 		{Effort: ReasoningEffortMedium, Description: "Balanced speed and reasoning (default)"},
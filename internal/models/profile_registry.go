@@ -75,6 +75,12 @@ func toResolved(p ModelProfile) ResolvedProfile {
 	if p.BasePrompt != nil {
 		r.BasePrompt = *p.BasePrompt
 	}
+	if p.MaxTemperature != nil {
+		r.MaxTemperature = *p.MaxTemperature
+	}
+	if p.TemperatureUnsupported != nil {
+		r.TemperatureUnsupported = *p.TemperatureUnsupported
+	}
 
 	return r
 }
@@ -0,0 +1,23 @@
+package models
+
+// ResponseFormat asks the LLM for output matching a JSON schema instead of
+// free text. Providers map it natively: OpenAI structured outputs
+// (text.format = json_schema), Anthropic by forcing a tool whose input schema
+// is Schema. Either way the response is a single assistant message whose
+// content is the JSON document.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type ResponseFormat struct {
+	// Name identifies the format (a-z, A-Z, 0-9, _ and -; max 64 chars).
+	Name string `json:"name"`
+
+	// Description tells the model what the output is for. Optional.
+	Description string `json:"description,omitempty"`
+
+	// Schema is a JSON Schema object. Anthropic requires a top-level object.
+	Schema map[string]interface{} `json:"schema"`
+
+	// Strict enables OpenAI strict schema adherence, which only supports a
+	// subset of JSON Schema (every property required, no extra properties).
+	Strict bool `json:"strict,omitempty"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RetryPolicy overrides the Temporal retry policy of a class of activities.
+// Zero fields keep the built-in value.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type RetryPolicy struct {
+	InitialIntervalMs      int      `json:"initial_interval_ms,omitempty"`
+	BackoffCoefficient     float64  `json:"backoff_coefficient,omitempty"`
+	MaximumIntervalMs      int      `json:"maximum_interval_ms,omitempty"`
+	MaximumAttempts        int      `json:"maximum_attempts,omitempty"`          // 1 disables retries
+	NonRetryableErrorTypes []string `json:"non_retryable_error_types,omitempty"` // Application error types never retried
+}
+
+// Validate checks p for values Temporal would reject.
+func (p RetryPolicy) Validate() error {
+	if p.InitialIntervalMs < 0 {
+		return fmt.Errorf("initial_interval_ms must not be negative")
+	}
+	if p.MaximumIntervalMs < 0 {
+		return fmt.Errorf("maximum_interval_ms must not be negative")
+	}
+	if p.MaximumAttempts < 0 {
+		return fmt.Errorf("maximum_attempts must not be negative")
+	}
+	if p.BackoffCoefficient != 0 && p.BackoffCoefficient < 1 {
+		return fmt.Errorf("backoff_coefficient must be at least 1")
+	}
+	if p.InitialIntervalMs > 0 && p.MaximumIntervalMs > 0 && p.MaximumIntervalMs < p.InitialIntervalMs {
+		return fmt.Errorf("maximum_interval_ms (%d) must not be less than initial_interval_ms (%d)",
+			p.MaximumIntervalMs, p.InitialIntervalMs)
+	}
+	for _, t := range p.NonRetryableErrorTypes {
+		if t == "" {
+			return fmt.Errorf("non_retryable_error_types must not contain empty names")
+		}
+	}
+	return nil
+}
+
+// RetryPolicies customizes retries per activity class, e.g. to give slow,
+// flaky integration-test tool calls more attempts than LLM calls.
+//
+// Tool applies to tools that retry by default; mutating tools such as
+// shell_command run once unless Tools names them with MaximumAttempts set.
+type RetryPolicies struct {
+	LLM        RetryPolicy            `json:"llm,omitempty"`
+	Tool       RetryPolicy            `json:"tool,omitempty"`
+	Tools      map[string]RetryPolicy `json:"tools,omitempty"` // By tool name; takes precedence over Tool
+	Compaction RetryPolicy            `json:"compaction,omitempty"`
+}
+
+// Validate checks every policy, naming the offending one in the error.
+// Called at workflow start so a bad config fails the session up front.
+func (r RetryPolicies) Validate() error {
+	for _, c := range []struct {
+		name   string
+		policy RetryPolicy
+	}{{"llm", r.LLM}, {"tool", r.Tool}, {"compaction", r.Compaction}} {
+		if err := c.policy.Validate(); err != nil {
+			return fmt.Errorf("retry.%s: %w", c.name, err)
+		}
+	}
+	names := make([]string, 0, len(r.Tools))
+	for name := range r.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("retry.tools: tool name must not be empty")
+		}
+		if err := r.Tools[name].Validate(); err != nil {
+			return fmt.Errorf("retry.tools.%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicies_Validate(t *testing.T) {
+	assert.NoError(t, RetryPolicies{}.Validate())
+	assert.NoError(t, RetryPolicies{
+		LLM:  RetryPolicy{InitialIntervalMs: 200, BackoffCoefficient: 1.5, MaximumIntervalMs: 5000, MaximumAttempts: 8},
+		Tool: RetryPolicy{NonRetryableErrorTypes: []string{"ToolCrash"}},
+		Tools: map[string]RetryPolicy{
+			"shell_command": {MaximumAttempts: 3, InitialIntervalMs: 30000},
+		},
+	}.Validate())
+
+	tests := []struct {
+		name     string
+		policies RetryPolicies
+		wantErr  string
+	}{
+		{"negative attempts", RetryPolicies{LLM: RetryPolicy{MaximumAttempts: -1}}, "retry.llm: maximum_attempts must not be negative"},
+		{"negative interval", RetryPolicies{Compaction: RetryPolicy{InitialIntervalMs: -5}}, "retry.compaction: initial_interval_ms"},
+		{"backoff below one", RetryPolicies{Tool: RetryPolicy{BackoffCoefficient: 0.5}}, "retry.tool: backoff_coefficient must be at least 1"},
+		{"max below initial", RetryPolicies{LLM: RetryPolicy{InitialIntervalMs: 2000, MaximumIntervalMs: 1000}}, "maximum_interval_ms (1000) must not be less than initial_interval_ms (2000)"},
+		{"empty error type", RetryPolicies{Tool: RetryPolicy{NonRetryableErrorTypes: []string{""}}}, "non_retryable_error_types"},
+		{"per-tool policy", RetryPolicies{Tools: map[string]RetryPolicy{"read_file": {MaximumIntervalMs: -1}}}, "retry.tools.read_file: maximum_interval_ms"},
+		{"empty tool name", RetryPolicies{Tools: map[string]RetryPolicy{"": {}}}, "tool name must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policies.Validate()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import "fmt"
+
+// ReviewModeReviewed turns on peer review of every turn that changed the
+// workspace.
+const ReviewModeReviewed = "reviewed"
+
+// DefaultReviewRounds is used when PeerReview.Rounds is 0.
+const DefaultReviewRounds = 1
+
+// MaxReviewRounds caps PeerReview.Rounds.
+const MaxReviewRounds = 5
+
+// PeerReview configures the implementer/reviewer loop. In reviewed mode,
+// when the agent ends a turn in which it changed the workspace, a reviewer
+// subagent critiques the diff and the critique is fed back to the agent for
+// a revision before the turn completes. Each of Rounds is one review
+// followed by one revision; a review that approves the change ends the loop
+// early.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type PeerReview struct {
+	Mode     string `json:"mode,omitempty"`     // "reviewed" enables; "" = off
+	Rounds   int    `json:"rounds,omitempty"`   // 0 = DefaultReviewRounds
+	Model    string `json:"model,omitempty"`    // Reviewer model; "" = session model
+	Provider string `json:"provider,omitempty"` // "" = inferred from Model
+}
+
+// Enabled reports whether reviewed mode is on.
+func (p PeerReview) Enabled() bool {
+	return p.Mode == ReviewModeReviewed
+}
+
+// MaxRounds returns the configured number of review rounds per turn.
+func (p PeerReview) MaxRounds() int {
+	if p.Rounds > 0 {
+		return p.Rounds
+	}
+	return DefaultReviewRounds
+}
+
+// Validate checks the mode and round count. Called at workflow start so a
+// bad config fails the session up front.
+func (p PeerReview) Validate() error {
+	if p.Mode != "" && p.Mode != ReviewModeReviewed {
+		return fmt.Errorf("review.mode must be %q or empty, got %q", ReviewModeReviewed, p.Mode)
+	}
+	if p.Rounds < 0 || p.Rounds > MaxReviewRounds {
+		return fmt.Errorf("review.rounds must be between 0 and %d", MaxReviewRounds)
+	}
+	return nil
+}
+
+// ModelConfig returns base with the reviewer's model and provider, or base
+// unchanged when no reviewer model is set. A provider left empty is
+// inferred from the model name, falling back to base's.
+func (p PeerReview) ModelConfig(base ModelConfig) ModelConfig {
+	if p.Model == "" {
+		return base
+	}
+	return RoutingRule{Model: p.Model, Provider: p.Provider}.ModelConfig(base)
+}
+
+// ReviewStatus is the outcome of a turn's peer review.
+type ReviewStatus string
+
+const (
+	ReviewApproved ReviewStatus = "approved" // The reviewer approved the change
+	ReviewRevised  ReviewStatus = "revised"  // Critiques were fed back for every round
+	ReviewFailed   ReviewStatus = "failed"   // The reviewer errored or timed out
+)
+
+// ReviewResult records the peer review rounds of a turn on its
+// TurnComplete item.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type ReviewResult struct {
+	Rounds int          `json:"rounds"`
+	Status ReviewStatus `json:"status"`
+}
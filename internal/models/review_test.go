@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerReview_Validate(t *testing.T) {
+	assert.NoError(t, PeerReview{}.Validate())
+	assert.NoError(t, PeerReview{Mode: ReviewModeReviewed, Rounds: 3}.Validate())
+
+	assert.ErrorContains(t, PeerReview{Mode: "paired"}.Validate(), "review.mode")
+	assert.ErrorContains(t, PeerReview{Mode: ReviewModeReviewed, Rounds: -1}.Validate(), "review.rounds")
+	assert.ErrorContains(t, PeerReview{Mode: ReviewModeReviewed, Rounds: MaxReviewRounds + 1}.Validate(), "review.rounds")
+}
+
+func TestPeerReview_ModelConfig(t *testing.T) {
+	base := ModelConfig{Provider: "openai", Model: "gpt-4o", Temperature: 0.2}
+
+	assert.Equal(t, base, PeerReview{Mode: ReviewModeReviewed}.ModelConfig(base))
+
+	mc := PeerReview{Model: "claude-sonnet-4-5"}.ModelConfig(base)
+	assert.Equal(t, "claude-sonnet-4-5", mc.Model)
+	assert.Equal(t, "anthropic", mc.Provider)
+	assert.Equal(t, 0.2, mc.Temperature)
+}
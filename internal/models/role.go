@@ -0,0 +1,132 @@
+// User-defined subagent roles.
+//
+// A role is a TOML file stored in ~/.codex/roles/<name>.toml that gives
+// spawn_agent a named preset: instructions, a tool allow-list and optional
+// model overrides, optionally layered on top of a built-in role.
+//
+// NOTE: Temporal-specific addition (Codex Rust only has built-in roles).
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// AgentRoleDef describes a user-defined subagent role.
+type AgentRoleDef struct {
+	// Name is the agent_type value passed to spawn_agent. Defaults to the
+	// filename stem.
+	Name string `toml:"name" json:"name"`
+
+	// Description is shown in the spawn_agent tool spec.
+	Description string `toml:"description" json:"description,omitempty"`
+
+	// BaseRole is an optional built-in role (explorer/planner/reviewer/...)
+	// whose overrides are applied before this role's own settings.
+	BaseRole string `toml:"base_role" json:"base_role,omitempty"`
+
+	// Instructions are appended to the child's developer instructions.
+	Instructions string `toml:"instructions" json:"instructions,omitempty"`
+
+	// Tools is an allow-list of internal tool names (groups allowed). Empty
+	// inherits the parent's tools. Never grants tools the parent lacks.
+	Tools []string `toml:"tools" json:"tools,omitempty"`
+
+	// Model and ReasoningEffort override the parent's model settings.
+	Model           string `toml:"model" json:"model,omitempty"`
+	ReasoningEffort string `toml:"reasoning_effort" json:"reasoning_effort,omitempty"`
+
+	// Interactive keeps request_user_input and ask_user so the child can ask
+	// the user questions. Subagents are one-shot by default.
+	Interactive bool `toml:"interactive" json:"interactive,omitempty"`
+}
+
+// ParseAgentRoleDef parses a TOML-encoded role definition. defaultName is used
+// when the file does not set name (typically the filename stem).
+func ParseAgentRoleDef(data []byte, defaultName string) (*AgentRoleDef, error) {
+	var def AgentRoleDef
+	if err := toml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("invalid role TOML: %w", err)
+	}
+	if def.Name == "" {
+		def.Name = defaultName
+	}
+	if err := ValidateRoleName(def.Name); err != nil {
+		return nil, err
+	}
+	if def.ReasoningEffort != "" {
+		if _, ok := ParseReasoningEffort(def.ReasoningEffort); !ok {
+			return nil, fmt.Errorf("role %q: invalid reasoning_effort %q", def.Name, def.ReasoningEffort)
+		}
+	}
+	for _, t := range def.Tools {
+		if !isKnownTool(t) {
+			return nil, fmt.Errorf("role %q: unknown tool %q", def.Name, t)
+		}
+	}
+	return &def, nil
+}
+
+// ValidateRoleName checks that name is a usable agent_type: lower-case
+// letters, digits, '-' and '_'.
+func ValidateRoleName(name string) error {
+	if name == "" {
+		return fmt.Errorf("role missing required field: name")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("invalid role name %q: use lower-case letters, digits, '-' and '_'", name)
+		}
+	}
+	return nil
+}
+
+// isKnownTool reports whether name is a registered tool or tool group.
+func isKnownTool(name string) bool {
+	if _, ok := tools.GetEntry(name); ok {
+		return true
+	}
+	expanded := tools.ExpandGroups([]string{name})
+	return len(expanded) != 1 || expanded[0] != name
+}
+
+// userInteractionTools are the tools that wait on the user. One-shot
+// subagents run unattended, so only interactive roles keep them.
+var userInteractionTools = []string{"request_user_input", "ask_user"}
+
+// ApplyToConfig applies the role's tool allow-list, model overrides and
+// instructions to cfg. Built-in BaseRole overrides must be applied first by
+// the caller. parentTools is the spawning agent's tool set; the user
+// interaction tools are only restored for interactive roles when the parent
+// has them.
+func (d AgentRoleDef) ApplyToConfig(cfg *SessionConfiguration, parentTools ToolsConfig) {
+	if len(d.Tools) > 0 {
+		cfg.Tools.RestrictTools(d.Tools...)
+	}
+	if d.Interactive {
+		for _, name := range userInteractionTools {
+			if parentTools.HasTool(name) && !cfg.Tools.HasTool(name) {
+				cfg.Tools.AddTools(name)
+			}
+		}
+	} else {
+		cfg.Tools.RemoveTools(userInteractionTools...)
+	}
+	if d.Model != "" {
+		cfg.Model.Model = d.Model
+	}
+	if effort, ok := ParseReasoningEffort(d.ReasoningEffort); ok {
+		cfg.Model.ReasoningEffort = effort
+	}
+	if instr := strings.TrimSpace(d.Instructions); instr != "" {
+		if cfg.DeveloperInstructions != "" {
+			cfg.DeveloperInstructions += "\n\n" + instr
+		} else {
+			cfg.DeveloperInstructions = instr
+		}
+	}
+}
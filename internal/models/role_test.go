@@ -0,0 +1,131 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validRoleTOML = `
+description = "Audits code for security issues"
+base_role = "reviewer"
+instructions = "Focus on injection and auth bugs."
+tools = ["read_file", "grep_files", "shell_command"]
+model = "o3"
+reasoning_effort = "high"
+`
+
+func TestParseAgentRoleDef_Valid(t *testing.T) {
+	def, err := ParseAgentRoleDef([]byte(validRoleTOML), "security-auditor")
+	require.NoError(t, err)
+	assert.Equal(t, "security-auditor", def.Name, "name defaults to filename stem")
+	assert.Equal(t, "Audits code for security issues", def.Description)
+	assert.Equal(t, "reviewer", def.BaseRole)
+	assert.Equal(t, []string{"read_file", "grep_files", "shell_command"}, def.Tools)
+	assert.Equal(t, "o3", def.Model)
+	assert.Equal(t, "high", def.ReasoningEffort)
+	assert.False(t, def.Interactive)
+}
+
+func TestParseAgentRoleDef_ExplicitName(t *testing.T) {
+	def, err := ParseAgentRoleDef([]byte(`name = "auditor"`), "file-stem")
+	require.NoError(t, err)
+	assert.Equal(t, "auditor", def.Name)
+}
+
+func TestParseAgentRoleDef_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{"invalid toml", `tools = [`, "invalid role TOML"},
+		{"bad name", `name = "Bad Name"`, "invalid role name"},
+		{"bad effort", `reasoning_effort = "max"`, "invalid reasoning_effort"},
+		{"unknown tool", `tools = ["teleport"]`, `unknown tool "teleport"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAgentRoleDef([]byte(tt.toml), "role")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseAgentRoleDef_ToolGroupAllowed(t *testing.T) {
+	def, err := ParseAgentRoleDef([]byte(`tools = ["collab", "read_file"]`), "lead")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collab", "read_file"}, def.Tools)
+}
+
+func TestValidateRoleName(t *testing.T) {
+	assert.NoError(t, ValidateRoleName("security-auditor_2"))
+	assert.Error(t, ValidateRoleName(""))
+	assert.Error(t, ValidateRoleName("Auditor"))
+	assert.Error(t, ValidateRoleName("a/b"))
+}
+
+func TestAgentRoleDef_ApplyToConfig(t *testing.T) {
+	parentTools := ToolsConfig{EnabledTools: []string{"shell_command", "read_file", "write_file", "request_user_input"}}
+
+	t.Run("allow-list, model and instructions", func(t *testing.T) {
+		cfg := SessionConfiguration{
+			Model:                 ModelConfig{Model: "gpt-4o"},
+			Tools:                 ToolsConfig{EnabledTools: []string{"shell_command", "read_file", "write_file", "request_user_input"}},
+			DeveloperInstructions: "base",
+		}
+		def := AgentRoleDef{
+			Tools:           []string{"read_file", "apply_patch"},
+			Model:           "o3",
+			ReasoningEffort: "low",
+			Instructions:    "  Be terse.  ",
+		}
+		def.ApplyToConfig(&cfg, parentTools)
+		assert.Equal(t, []string{"read_file"}, cfg.Tools.EnabledTools, "allow-list never adds tools the parent lacks")
+		assert.Equal(t, "o3", cfg.Model.Model)
+		assert.Equal(t, ReasoningEffortLow, cfg.Model.ReasoningEffort)
+		assert.Equal(t, "base\n\nBe terse.", cfg.DeveloperInstructions)
+	})
+
+	t.Run("empty allow-list inherits tools, removes request_user_input", func(t *testing.T) {
+		cfg := SessionConfiguration{
+			Model: ModelConfig{Model: "gpt-4o"},
+			Tools: ToolsConfig{EnabledTools: []string{"shell_command", "request_user_input"}},
+		}
+		AgentRoleDef{}.ApplyToConfig(&cfg, parentTools)
+		assert.Equal(t, []string{"shell_command"}, cfg.Tools.EnabledTools)
+		assert.Equal(t, "gpt-4o", cfg.Model.Model)
+		assert.Empty(t, cfg.DeveloperInstructions)
+	})
+
+	t.Run("interactive restores request_user_input from parent", func(t *testing.T) {
+		cfg := SessionConfiguration{Tools: ToolsConfig{EnabledTools: []string{"read_file"}}}
+		AgentRoleDef{Interactive: true}.ApplyToConfig(&cfg, parentTools)
+		assert.True(t, cfg.Tools.HasTool("request_user_input"))
+
+		cfg = SessionConfiguration{Tools: ToolsConfig{EnabledTools: []string{"read_file"}}}
+		AgentRoleDef{Interactive: true}.ApplyToConfig(&cfg, ToolsConfig{EnabledTools: []string{"read_file"}})
+		assert.False(t, cfg.Tools.HasTool("request_user_input"), "not granted when parent lacks it")
+	})
+
+	t.Run("ask_user follows request_user_input", func(t *testing.T) {
+		parent := ToolsConfig{EnabledTools: []string{"read_file", "request_user_input", "ask_user"}}
+		cfg := SessionConfiguration{Tools: ToolsConfig{EnabledTools: []string{"read_file", "ask_user"}}}
+		AgentRoleDef{}.ApplyToConfig(&cfg, parent)
+		assert.False(t, cfg.Tools.HasTool("ask_user"), "one-shot roles cannot ask the user")
+
+		cfg = SessionConfiguration{Tools: ToolsConfig{EnabledTools: []string{"read_file"}}}
+		AgentRoleDef{Interactive: true}.ApplyToConfig(&cfg, parent)
+		assert.True(t, cfg.Tools.HasTool("ask_user"))
+		assert.True(t, cfg.Tools.HasTool("request_user_input"))
+	})
+}
+
+func TestToolsConfig_RestrictTools(t *testing.T) {
+	c := ToolsConfig{EnabledTools: []string{"shell_command", "read_file", "collab"}}
+	c.RestrictTools("read_file", "spawn_agent", "write_file")
+	assert.Equal(t, []string{"read_file", "spawn_agent"}, c.EnabledTools,
+		"groups expanded on both sides; tools outside the current set are not added")
+}
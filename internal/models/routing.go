@@ -0,0 +1,153 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ModelRouting picks the model for each LLM call of a turn from a list of
+// rules, so simple questions can go to a cheap model and editing work to a
+// stronger one. Rules are evaluated in order before every call; the first
+// whose conditions all hold picks the model. Without a match the session
+// model is used. CompactionModel, if set, summarizes history on compaction.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type ModelRouting struct {
+	Rules           []RoutingRule `json:"rules,omitempty"`
+	CompactionModel string        `json:"compaction_model,omitempty"`
+}
+
+// RoutingRule routes a call to Model when every set condition holds. A
+// rule without conditions always matches.
+type RoutingRule struct {
+	Name     string `json:"name,omitempty"`     // Shown in logs and timings; defaults to the model
+	Model    string `json:"model"`              // Model to call
+	Provider string `json:"provider,omitempty"` // "" = inferred from Model
+
+	// Conditions.
+	Tools            []string `json:"tools,omitempty"`              // The model requested any of these tools earlier in the turn
+	MinHistoryTokens int      `json:"min_history_tokens,omitempty"` // Estimated history size is at least this
+	MaxHistoryTokens int      `json:"max_history_tokens,omitempty"` // Estimated history size is at most this
+	Hint             string   `json:"hint,omitempty"`               // The turn's message starts with #hint
+}
+
+// RoutingContext is what rules are evaluated against.
+type RoutingContext struct {
+	ToolsRequested map[string]bool // Tools the model requested so far this turn
+	HistoryTokens  int             // Estimated history size
+	Hint           string          // From the turn's message; see ParseRoutingHint
+}
+
+// Enabled reports whether any rule is configured.
+func (m ModelRouting) Enabled() bool {
+	return len(m.Rules) > 0
+}
+
+// Validate checks that every rule names a model and has sane bounds.
+// Called at workflow start so a bad policy fails the session up front.
+func (m ModelRouting) Validate() error {
+	for i, r := range m.Rules {
+		if r.Model == "" {
+			return fmt.Errorf("rule %d: model is required", i+1)
+		}
+		if r.MinHistoryTokens < 0 || r.MaxHistoryTokens < 0 {
+			return fmt.Errorf("rule %d (%s): history token bounds must not be negative", i+1, r.RouteName())
+		}
+		if r.MaxHistoryTokens > 0 && r.MaxHistoryTokens < r.MinHistoryTokens {
+			return fmt.Errorf("rule %d (%s): max_history_tokens is less than min_history_tokens", i+1, r.RouteName())
+		}
+	}
+	return nil
+}
+
+// Route returns the first rule matching c, or false if none does.
+func (m ModelRouting) Route(c RoutingContext) (RoutingRule, bool) {
+	for _, r := range m.Rules {
+		if r.Matches(c) {
+			return r, true
+		}
+	}
+	return RoutingRule{}, false
+}
+
+// Matches reports whether every condition of r holds for c.
+func (r RoutingRule) Matches(c RoutingContext) bool {
+	if len(r.Tools) > 0 {
+		requested := false
+		for _, t := range r.Tools {
+			if c.ToolsRequested[t] {
+				requested = true
+				break
+			}
+		}
+		if !requested {
+			return false
+		}
+	}
+	if r.MinHistoryTokens > 0 && c.HistoryTokens < r.MinHistoryTokens {
+		return false
+	}
+	if r.MaxHistoryTokens > 0 && c.HistoryTokens > r.MaxHistoryTokens {
+		return false
+	}
+	if r.Hint != "" && !strings.EqualFold(r.Hint, c.Hint) {
+		return false
+	}
+	return true
+}
+
+// RouteName returns the rule's name, or its model when unnamed.
+func (r RoutingRule) RouteName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Model
+}
+
+// ModelConfig returns base with the rule's model and provider. A provider
+// left empty is inferred from the model name, falling back to base's.
+func (r RoutingRule) ModelConfig(base ModelConfig) ModelConfig {
+	mc := base
+	mc.Model = r.Model
+	mc.Provider = r.Provider
+	if mc.Provider == "" {
+		mc.Provider = inferProvider(r.Model, base.Provider)
+	}
+	return mc
+}
+
+// inferProvider guesses a model's provider from its name. Claude models
+// stay on Bedrock when that is the fallback.
+func inferProvider(model, fallback string) string {
+	m := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(m, "claude"):
+		if fallback == "bedrock" {
+			return fallback
+		}
+		return "anthropic"
+	case strings.Contains(m, "anthropic.claude"):
+		return "bedrock"
+	case strings.HasPrefix(m, "gemini"):
+		return "vertex"
+	case strings.HasPrefix(m, "gpt-"), strings.HasPrefix(m, "chatgpt-"),
+		strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"):
+		return "openai"
+	}
+	return fallback
+}
+
+// ParseRoutingHint returns the hint a message starts with: "#quick what
+// does foo do?" gives "quick". Returns "" if there is none.
+func ParseRoutingHint(message string) string {
+	message = strings.TrimLeftFunc(message, unicode.IsSpace)
+	if !strings.HasPrefix(message, "#") {
+		return ""
+	}
+	word := message[1:]
+	if i := strings.IndexFunc(word, unicode.IsSpace); i >= 0 {
+		word = word[:i]
+	}
+	return word
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelRouting_Route(t *testing.T) {
+	routing := ModelRouting{Rules: []RoutingRule{
+		{Name: "deep", Hint: "deep", Model: "o3"},
+		{Name: "edit", Tools: []string{"apply_patch", "write_file"}, Model: "claude-sonnet-4-5"},
+		{Name: "quick", MaxHistoryTokens: 8000, Model: "gpt-4o-mini"},
+		{Name: "large", MinHistoryTokens: 100000, Model: "gpt-4.1"},
+	}}
+	route := func(c RoutingContext) string {
+		r, ok := routing.Route(c)
+		if !ok {
+			return ""
+		}
+		return r.Name
+	}
+
+	assert.Equal(t, "quick", route(RoutingContext{HistoryTokens: 500}))
+	assert.Equal(t, "deep", route(RoutingContext{HistoryTokens: 500, Hint: "DEEP"}), "hints match case-insensitively")
+	assert.Equal(t, "edit", route(RoutingContext{HistoryTokens: 500, ToolsRequested: map[string]bool{"write_file": true}}))
+	assert.Equal(t, "quick", route(RoutingContext{HistoryTokens: 500, ToolsRequested: map[string]bool{"read_file": true}}))
+	assert.Equal(t, "large", route(RoutingContext{HistoryTokens: 200000}))
+	assert.Equal(t, "", route(RoutingContext{HistoryTokens: 20000}), "no rule matches")
+}
+
+func TestModelRouting_Validate(t *testing.T) {
+	assert.NoError(t, ModelRouting{}.Validate())
+	assert.NoError(t, ModelRouting{Rules: []RoutingRule{{Model: "gpt-4o-mini", MinHistoryTokens: 10, MaxHistoryTokens: 10}}}.Validate())
+	assert.ErrorContains(t, ModelRouting{Rules: []RoutingRule{{Name: "x"}}}.Validate(), "rule 1: model is required")
+	assert.ErrorContains(t, ModelRouting{Rules: []RoutingRule{{Model: "m", MinHistoryTokens: 10, MaxHistoryTokens: 5}}}.Validate(),
+		"rule 1 (m): max_history_tokens is less than min_history_tokens")
+}
+
+func TestRoutingRule_ModelConfig(t *testing.T) {
+	base := ModelConfig{Provider: "openai", Model: "gpt-4o", MaxTokens: 100}
+
+	mc := RoutingRule{Model: "claude-sonnet-4-5"}.ModelConfig(base)
+	assert.Equal(t, ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-5", MaxTokens: 100}, mc)
+
+	mc = RoutingRule{Model: "my-finetune"}.ModelConfig(base)
+	assert.Equal(t, "openai", mc.Provider, "unknown names keep the session provider")
+
+	mc = RoutingRule{Model: "proxy-model", Provider: "anthropic"}.ModelConfig(base)
+	assert.Equal(t, "anthropic", mc.Provider)
+
+	bedrock := ModelConfig{Provider: "bedrock", Model: "us.anthropic.claude-sonnet-4-5-20250929-v1:0"}
+	assert.Equal(t, "bedrock", RoutingRule{Model: "claude-haiku-4.5"}.ModelConfig(bedrock).Provider,
+		"Claude names stay on Bedrock")
+	assert.Equal(t, "bedrock", RoutingRule{Model: "anthropic.claude-3-haiku-20240307-v1:0"}.ModelConfig(base).Provider)
+	assert.Equal(t, "vertex", RoutingRule{Model: "gemini-2.5-flash"}.ModelConfig(base).Provider)
+}
+
+func TestParseRoutingHint(t *testing.T) {
+	assert.Equal(t, "quick", ParseRoutingHint("  #quick what does foo do?"))
+	assert.Equal(t, "deep", ParseRoutingHint("#deep"))
+	assert.Equal(t, "", ParseRoutingHint("# Heading\ntext"))
+	assert.Equal(t, "", ParseRoutingHint("fix #123"))
+}
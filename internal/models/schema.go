@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the persisted session layout: the
+// workflow's SessionState, carried across ContinueAsNew, and the
+// ConversationItems in its history. Payloads written before versioning
+// have no schema_version and are version 0.
+//
+// Bump it on any change that an older payload would not decode into
+// correctly (a renamed or retyped field, a changed meaning), and add a
+// Migration from the previous version. Adding an optional field does not
+// need one.
+const SchemaVersion = 1
+
+// Migration upgrades a session payload from version From to From+1. It
+// edits the raw JSON objects in place, so it does not depend on the current
+// Go types, which may have changed again since.
+type Migration struct {
+	From        int
+	Description string
+
+	// Session edits the top-level session object; nil if unchanged.
+	Session func(session map[string]json.RawMessage) error
+
+	// Item edits each history item; nil if unchanged.
+	Item func(item map[string]json.RawMessage) error
+}
+
+// Migrations upgrade session payloads one version at a time, in order.
+var Migrations = []Migration{
+	{
+		From:        0,
+		Description: "stamp schema_version on payloads written before versioning (same layout)",
+	},
+}
+
+// MigrateSession upgrades a session payload to SchemaVersion, returning the
+// upgraded JSON and the version it was written with. A payload already at
+// SchemaVersion is returned unchanged; one from a newer version is an error,
+// since fields this build does not know would be dropped.
+func MigrateSession(data []byte) ([]byte, int, error) {
+	return migrateSession(data, Migrations, SchemaVersion)
+}
+
+func migrateSession(data []byte, migrations []Migration, target int) ([]byte, int, error) {
+	var session map[string]json.RawMessage
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, 0, fmt.Errorf("decoding session: %w", err)
+	}
+	from := 0
+	if raw, ok := session["schema_version"]; ok {
+		if err := json.Unmarshal(raw, &from); err != nil {
+			return nil, 0, fmt.Errorf("decoding schema_version: %w", err)
+		}
+	}
+	switch {
+	case from == target:
+		return data, from, nil
+	case from > target:
+		return nil, from, fmt.Errorf("session schema version %d is newer than supported %d", from, target)
+	}
+
+	for v := from; v < target; v++ {
+		m, ok := findMigration(migrations, v)
+		if !ok {
+			return nil, from, fmt.Errorf("no migration from session schema version %d", v)
+		}
+		if err := m.apply(session); err != nil {
+			return nil, from, fmt.Errorf("migrating session schema %d to %d (%s): %w", v, v+1, m.Description, err)
+		}
+	}
+
+	version, _ := json.Marshal(target)
+	session["schema_version"] = version
+	out, err := json.Marshal(session)
+	if err != nil {
+		return nil, from, fmt.Errorf("encoding session: %w", err)
+	}
+	return out, from, nil
+}
+
+func findMigration(migrations []Migration, from int) (Migration, bool) {
+	for _, m := range migrations {
+		if m.From == from {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// apply runs the migration on a decoded session and its history items.
+func (m Migration) apply(session map[string]json.RawMessage) error {
+	if m.Session != nil {
+		if err := m.Session(session); err != nil {
+			return err
+		}
+	}
+	if m.Item == nil {
+		return nil
+	}
+	raw, ok := session["history_items"]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return fmt.Errorf("decoding history_items: %w", err)
+	}
+	for i, item := range items {
+		if err := m.Item(item); err != nil {
+			return fmt.Errorf("history item %d: %w", i, err)
+		}
+	}
+	out, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	session["history_items"] = out
+	return nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_ContiguousToSchemaVersion(t *testing.T) {
+	for v := 0; v < SchemaVersion; v++ {
+		m, ok := findMigration(Migrations, v)
+		require.True(t, ok, "missing migration from version %d", v)
+		assert.NotEmpty(t, m.Description)
+	}
+	_, ok := findMigration(Migrations, SchemaVersion)
+	assert.False(t, ok, "a migration starts at the current version; bump SchemaVersion")
+}
+
+func TestMigrateSession_StampsUnversionedPayload(t *testing.T) {
+	out, from, err := MigrateSession([]byte(`{"conversation_id":"c1","history_items":[{"type":"user_message","seq":0,"content":"hi"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 0, from)
+
+	var session map[string]any
+	require.NoError(t, json.Unmarshal(out, &session))
+	assert.Equal(t, float64(SchemaVersion), session["schema_version"])
+	assert.Equal(t, "c1", session["conversation_id"])
+	assert.Equal(t, "hi", session["history_items"].([]any)[0].(map[string]any)["content"])
+}
+
+func TestMigrateSession_CurrentVersionUnchanged(t *testing.T) {
+	data := []byte(`{"schema_version":1,"conversation_id":"c1"}`)
+	out, from, err := MigrateSession(data)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, from)
+	assert.Equal(t, data, out)
+}
+
+func TestMigrateSession_RejectsNewerVersion(t *testing.T) {
+	_, from, err := MigrateSession([]byte(`{"schema_version":99}`))
+	require.Error(t, err)
+	assert.Equal(t, 99, from)
+	assert.Contains(t, err.Error(), "newer than supported")
+}
+
+func TestMigrateSession_AppliesStepsInOrder(t *testing.T) {
+	migrations := []Migration{
+		{From: 0, Description: "stamp"},
+		{
+			From:        1,
+			Description: "rename turns to turn_counter",
+			Session: func(s map[string]json.RawMessage) error {
+				s["turn_counter"] = s["turns"]
+				delete(s, "turns")
+				return nil
+			},
+		},
+		{
+			From:        2,
+			Description: "rename text to content",
+			Item: func(item map[string]json.RawMessage) error {
+				if text, ok := item["text"]; ok {
+					item["content"] = text
+					delete(item, "text")
+				}
+				return nil
+			},
+		},
+	}
+
+	out, from, err := migrateSession([]byte(`{"schema_version":1,"turns":3,"history_items":[{"type":"user_message","text":"hi"},{"type":"turn_complete"}]}`), migrations, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, from)
+	assert.JSONEq(t, `{"schema_version":3,"turn_counter":3,"history_items":[{"type":"user_message","content":"hi"},{"type":"turn_complete"}]}`, string(out))
+
+	_, _, err = migrateSession([]byte(`{}`), migrations[1:], 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no migration from session schema version 0")
+}
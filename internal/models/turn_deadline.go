@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTurnDeadlineWarnMinutes is how long before the deadline the model
+// is told to wrap up when TurnDeadline.WarnMinutes is 0.
+const DefaultTurnDeadlineWarnMinutes = 2
+
+// TurnDeadline is a soft wall-clock limit on each turn, so a runaway turn
+// cannot hold the worker for dozens of iterations. WarnMinutes before the
+// deadline the model is told how much time is left and asked to wrap up;
+// at the deadline the turn is interrupted and marked as timed out.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type TurnDeadline struct {
+	Minutes     int `json:"minutes,omitempty"`      // 0 = no deadline
+	WarnMinutes int `json:"warn_minutes,omitempty"` // 0 = DefaultTurnDeadlineWarnMinutes
+}
+
+// Enabled reports whether turns have a deadline.
+func (d TurnDeadline) Enabled() bool {
+	return d.Minutes > 0
+}
+
+// Limit returns how long a turn may run.
+func (d TurnDeadline) Limit() time.Duration {
+	return time.Duration(d.Minutes) * time.Minute
+}
+
+// Warning returns how long before the deadline the model is warned: the
+// configured lead, or the default capped at half the limit so short
+// deadlines still leave time to work.
+func (d TurnDeadline) Warning() time.Duration {
+	if d.WarnMinutes > 0 {
+		return time.Duration(d.WarnMinutes) * time.Minute
+	}
+	return min(DefaultTurnDeadlineWarnMinutes*time.Minute, d.Limit()/2)
+}
+
+// Validate checks for negative values and a warning that would come before
+// the turn starts. Called at workflow start so a bad config fails the
+// session up front.
+func (d TurnDeadline) Validate() error {
+	if d.Minutes < 0 {
+		return fmt.Errorf("turn_deadline.minutes must not be negative")
+	}
+	if d.WarnMinutes < 0 {
+		return fmt.Errorf("turn_deadline.warn_minutes must not be negative")
+	}
+	if d.WarnMinutes > 0 && d.WarnMinutes >= d.Minutes {
+		return fmt.Errorf("turn_deadline.warn_minutes must be less than turn_deadline.minutes")
+	}
+	return nil
+}
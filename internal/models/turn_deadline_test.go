@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTurnDeadline_Validate(t *testing.T) {
+	assert.NoError(t, TurnDeadline{}.Validate())
+	assert.NoError(t, TurnDeadline{Minutes: 10, WarnMinutes: 3}.Validate())
+
+	tests := []struct {
+		name     string
+		deadline TurnDeadline
+		wantErr  string
+	}{
+		{"negative minutes", TurnDeadline{Minutes: -1}, "turn_deadline.minutes must not be negative"},
+		{"negative warning", TurnDeadline{Minutes: 5, WarnMinutes: -1}, "turn_deadline.warn_minutes must not be negative"},
+		{"warning at the start", TurnDeadline{Minutes: 5, WarnMinutes: 5}, "must be less than turn_deadline.minutes"},
+		{"warning without deadline", TurnDeadline{WarnMinutes: 2}, "must be less than turn_deadline.minutes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.deadline.Validate()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTurnDeadline_Warning(t *testing.T) {
+	assert.False(t, TurnDeadline{}.Enabled())
+	assert.True(t, TurnDeadline{Minutes: 1}.Enabled())
+
+	assert.Equal(t, 15*time.Minute, TurnDeadline{Minutes: 15}.Limit())
+	assert.Equal(t, 2*time.Minute, TurnDeadline{Minutes: 15}.Warning())
+	assert.Equal(t, 5*time.Minute, TurnDeadline{Minutes: 15, WarnMinutes: 5}.Warning())
+	assert.Equal(t, 90*time.Second, TurnDeadline{Minutes: 3}.Warning(), "the default is capped at half the limit")
+}
@@ -0,0 +1,103 @@
+package netconfig
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a TLS server with a self-signed certificate, shared by the
+// tests that need a PEM file.
+var testServer = sync.OnceValue(func() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+})
+
+func testCertPEM(t *testing.T) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer().Certificate().Raw})
+}
+
+func TestCheck_CABundle(t *testing.T) {
+	srv := testServer()
+
+	res := Check(context.Background(), Anthropic, Settings{}, srv.URL)
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Error, "certificate")
+	assert.Contains(t, res.Hint, "ANTHROPIC_CA_BUNDLE")
+	assert.Equal(t, "none", res.Proxy)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, testCertPEM(t), 0o644))
+	res = Check(context.Background(), Anthropic, Settings{CABundle: bundle}, srv.URL)
+	assert.True(t, res.OK, res.Error)
+	assert.Equal(t, http.StatusUnauthorized, res.Status, "any reply means the endpoint was reached")
+	assert.Equal(t, bundle, res.CABundle)
+	assert.Contains(t, res.String(), "anthropic: OK")
+}
+
+func TestCheck_Proxy(t *testing.T) {
+	var gotHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.URL.Host
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer proxy.Close()
+
+	res := Check(context.Background(), OpenAI, Settings{Proxy: proxy.URL}, "http://api.example.test/v1/")
+	assert.True(t, res.OK, res.Error)
+	assert.Equal(t, "api.example.test", gotHost)
+	assert.Equal(t, proxy.URL, res.Proxy)
+	assert.Contains(t, res.String(), "via proxy "+proxy.URL)
+
+	authProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer authProxy.Close()
+	res = Check(context.Background(), OpenAI, Settings{Proxy: authProxy.URL}, "https://api.example.test/v1/")
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Hint, "credentials")
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	res := Check(ctx, Bedrock, Settings{Proxy: Direct}, testServer().URL)
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Hint, "BEDROCK_PROXY")
+	assert.True(t, strings.HasPrefix(res.String(), "bedrock: FAILED"))
+}
+
+func TestCheckAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	results := CheckAll(context.Background(), Config{Default: Settings{Proxy: Direct}}, []Endpoint{
+		{Target: OpenAI, URL: srv.URL + "/slow"},
+		{Target: MCP, Name: "docs", URL: srv.URL + "/mcp"},
+		{Target: Vertex, URL: "://bad"},
+	}, time.Second)
+	require.Len(t, results, 3)
+	assert.Equal(t, OpenAI, results[0].Target, "results keep the order of endpoints")
+	assert.True(t, results[0].OK)
+	assert.Equal(t, "docs", results[1].Name)
+	assert.Contains(t, results[1].String(), "mcp docs: OK")
+	assert.False(t, results[2].OK)
+	assert.NotEmpty(t, results[2].Error)
+}
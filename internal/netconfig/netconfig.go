@@ -0,0 +1,229 @@
+// Package netconfig configures how the worker reaches LLM providers and
+// MCP servers on locked-down networks: an explicit proxy and a custom CA
+// bundle (for proxies that re-sign TLS), worker-wide or per target, instead
+// of only honoring HTTPS_PROXY. It also checks that a target's endpoint can
+// be reached and explains failures.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package netconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Targets are the clients whose network settings can be set separately.
+const (
+	OpenAI    = "openai"
+	Anthropic = "anthropic"
+	Bedrock   = "bedrock"
+	Vertex    = "vertex"
+	MCP       = "mcp" // HTTP transports of MCP servers
+)
+
+// Targets lists every target, in the order they are reported.
+var Targets = []string{OpenAI, Anthropic, Bedrock, Vertex, MCP}
+
+// Direct is the Proxy value that connects without a proxy, even when
+// HTTPS_PROXY is set.
+const Direct = "direct"
+
+// Settings is the network configuration of one target.
+type Settings struct {
+	// Proxy is the proxy URL (http, https or socks5; credentials go in the
+	// URL), Direct, or "" to use HTTPS_PROXY/HTTP_PROXY/NO_PROXY.
+	Proxy string
+	// NoProxy lists hosts that bypass Proxy, in NO_PROXY syntax.
+	NoProxy string
+	// CABundle is a PEM file of CA certificates trusted in addition to the
+	// system roots.
+	CABundle string
+}
+
+// IsZero reports whether s leaves the default HTTP behavior unchanged.
+func (s Settings) IsZero() bool {
+	return s == Settings{}
+}
+
+// RedactedProxy returns Proxy with any password masked, for logs.
+func (s Settings) RedactedProxy() string {
+	return redactProxy(s.Proxy)
+}
+
+// Config is the worker's network configuration: Default applies to every
+// target, and the non-empty fields of a target's override replace it.
+type Config struct {
+	Default   Settings
+	Overrides map[string]Settings // By target
+}
+
+// For returns the settings of target.
+func (c Config) For(target string) Settings {
+	s := c.Default
+	o := c.Overrides[target]
+	if o.Proxy != "" {
+		s.Proxy = o.Proxy
+	}
+	if o.NoProxy != "" {
+		s.NoProxy = o.NoProxy
+	}
+	if o.CABundle != "" {
+		s.CABundle = o.CABundle
+	}
+	return s
+}
+
+// IsZero reports whether no target has settings.
+func (c Config) IsZero() bool {
+	if !c.Default.IsZero() {
+		return false
+	}
+	for _, s := range c.Overrides {
+		if !s.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigFromEnv reads WORKER_PROXY, WORKER_NO_PROXY and WORKER_CA_BUNDLE
+// for every target, and <TARGET>_PROXY, <TARGET>_NO_PROXY and
+// <TARGET>_CA_BUNDLE (e.g. ANTHROPIC_PROXY, MCP_CA_BUNDLE) for one. Proxy
+// URLs are validated and CA bundles loaded, so mistakes fail at startup.
+func ConfigFromEnv(getenv func(string) string) (Config, error) {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	read := func(prefix string) (Settings, error) {
+		s := Settings{
+			Proxy:    strings.TrimSpace(getenv(prefix + "_PROXY")),
+			NoProxy:  strings.TrimSpace(getenv(prefix + "_NO_PROXY")),
+			CABundle: strings.TrimSpace(getenv(prefix + "_CA_BUNDLE")),
+		}
+		if s.Proxy != "" && s.Proxy != Direct {
+			if err := validateProxy(s.Proxy); err != nil {
+				return Settings{}, fmt.Errorf("invalid %s_PROXY: %w", prefix, err)
+			}
+		}
+		if s.CABundle != "" {
+			if _, err := loadCABundle(s.CABundle); err != nil {
+				return Settings{}, fmt.Errorf("invalid %s_CA_BUNDLE: %w", prefix, err)
+			}
+		}
+		return s, nil
+	}
+
+	var cfg Config
+	var err error
+	if cfg.Default, err = read("WORKER"); err != nil {
+		return Config{}, err
+	}
+	for _, target := range Targets {
+		s, err := read(strings.ToUpper(target))
+		if err != nil {
+			return Config{}, err
+		}
+		if !s.IsZero() {
+			if cfg.Overrides == nil {
+				cfg.Overrides = make(map[string]Settings)
+			}
+			cfg.Overrides[target] = s
+		}
+	}
+	return cfg, nil
+}
+
+// validateProxy checks that proxy is an absolute URL with a supported
+// scheme.
+func validateProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		// url.Error repeats the URL, password included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("not a valid URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("%q: want an http://, https:// or socks5:// URL, or %q", redactProxy(proxy), Direct)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q: no host", redactProxy(proxy))
+	}
+	return nil
+}
+
+// loadCABundle returns the system roots plus the certificates in path.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// Transport returns an HTTP transport that uses s. It is a clone of
+// http.DefaultTransport, so timeouts and HTTP/2 support are unchanged.
+func (s Settings) Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	switch s.Proxy {
+	case "":
+		// Keep http.ProxyFromEnvironment.
+	case Direct:
+		t.Proxy = nil
+	default:
+		if err := validateProxy(s.Proxy); err != nil {
+			return nil, err
+		}
+		proxyFunc := (&httpproxy.Config{HTTPProxy: s.Proxy, HTTPSProxy: s.Proxy, NoProxy: s.NoProxy}).ProxyFunc()
+		t.Proxy = func(r *http.Request) (*url.URL, error) { return proxyFunc(r.URL) }
+	}
+	if s.CABundle != "" {
+		pool, err := loadCABundle(s.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// HTTPClient returns a new HTTP client that uses s. Each call returns a
+// separate client, so callers may wrap its transport.
+func (s Settings) HTTPClient() (*http.Client, error) {
+	t, err := s.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t}, nil
+}
+
+// redactProxy masks the password of a proxy URL.
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+	return u.Redacted()
+}
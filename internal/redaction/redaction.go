@@ -0,0 +1,256 @@
+// Package redaction scrubs secrets from tool output before it is recorded in
+// workflow history and sent to the LLM.
+//
+// A Redactor applies regex rules (built-in rules for well-known credential
+// formats plus user rules from redaction.toml) and, optionally, an
+// entropy check that catches random-looking tokens no rule knows about.
+// Each match is replaced with "[REDACTED:<rule>]".
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package redaction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigFileName is the redaction config file inside CodexHome.
+const ConfigFileName = "redaction.toml"
+
+// secretGroup is the capture group name that limits a rule's replacement to
+// part of the match, e.g. the value in "API_KEY=value".
+const secretGroup = "secret"
+
+// Config is the redaction.toml file format.
+//
+//	disable_default_rules = false
+//	entropy_threshold = 4.5   # bits per character; 0 disables the check
+//	entropy_min_length = 32
+//
+//	[[rules]]
+//	name = "internal-token"
+//	pattern = 'itk_[A-Za-z0-9]{40}'
+type Config struct {
+	// DisableDefaultRules drops the built-in credential rules.
+	DisableDefaultRules bool `toml:"disable_default_rules"`
+
+	// EntropyThreshold, if > 0, redacts tokens of at least EntropyMinLength
+	// characters whose Shannon entropy (bits per character) reaches it.
+	EntropyThreshold float64 `toml:"entropy_threshold"`
+	EntropyMinLength int     `toml:"entropy_min_length"`
+
+	// Rules are applied after the built-in rules.
+	Rules []RuleConfig `toml:"rules"`
+}
+
+// RuleConfig is a user-defined rule. If Pattern has a capture group named
+// "secret", only that group is replaced.
+type RuleConfig struct {
+	Name    string `toml:"name"`
+	Pattern string `toml:"pattern"`
+
+	// accept, if set, filters matches: a value it rejects is kept.
+	accept func(secret string) bool
+}
+
+// defaultEntropyMinLength applies when EntropyThreshold is set without a length.
+const defaultEntropyMinLength = 32
+
+// defaultRules match well-known credential formats.
+var defaultRules = []RuleConfig{
+	{Name: "private-key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "aws-access-key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "aws-secret-key", Pattern: `(?i)aws_?secret_?access_?key\s*[=:]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`},
+	{Name: "github-token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`},
+	{Name: "anthropic-key", Pattern: `\bsk-ant-[A-Za-z0-9_-]{20,}`},
+	{Name: "openai-key", Pattern: `\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`},
+	{Name: "slack-token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{Name: "google-api-key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+	{Name: "bearer-token", Pattern: `(?i)\bauthorization:\s*bearer\s+(?P<secret>[A-Za-z0-9._~+/=-]{16,})`},
+	// .env-style assignments: FOO_SECRET=value, "api_key": "value", password: value.
+	// The value must be a literal that ends at a quote, separator or line
+	// end, so calls like os.Getenv("DB_PASSWORD") never match, and must look
+	// random enough that identifiers like defaultMaxTokens are kept.
+	{
+		Name:    "secret-assignment",
+		Pattern: `(?i)\b[A-Z0-9_]*(?:secret|token|passw(?:or)?d|api_?key|private_?key)[A-Z0-9_]*["']?\s*[=:]\s*["']?(?P<secret>[^\s"'(){}\[\],;]{8,})(?:["'\s,;]|$)`,
+		accept:  isSecretLiteral,
+	},
+}
+
+// identifierLike matches values that read as code: an identifier or a
+// dotted chain of them, without digits.
+var identifierLike = regexp.MustCompile(`^[A-Za-z_]+(?:\.[A-Za-z_]+)*$`)
+
+// minSecretLiteralEntropy is the entropy, in bits per character, an
+// assigned value needs to count as a secret.
+const minSecretLiteralEntropy = 2.5
+
+// isSecretLiteral reports whether an assigned value looks like a secret
+// rather than a reference to one: not an identifier, an environment or
+// template reference, and with secret-like entropy.
+func isSecretLiteral(v string) bool {
+	if identifierLike.MatchString(v) || strings.HasPrefix(v, "$") || strings.HasPrefix(v, "<") {
+		return false
+	}
+	return shannonEntropy(v) >= minSecretLiteralEntropy
+}
+
+// entropyCandidate matches tokens checked by the entropy rule.
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]+`)
+
+// rule is a compiled redaction rule.
+type rule struct {
+	name   string
+	re     *regexp.Regexp
+	group  int // index of the "secret" group, or 0 to replace the whole match
+	accept func(string) bool
+}
+
+// Redactor scrubs secrets from text. A nil *Redactor redacts nothing.
+type Redactor struct {
+	rules            []rule
+	entropyThreshold float64
+	entropyMinLength int
+}
+
+// New builds a Redactor from cfg.
+func New(cfg Config) (*Redactor, error) {
+	var specs []RuleConfig
+	if !cfg.DisableDefaultRules {
+		specs = append(specs, defaultRules...)
+	}
+	specs = append(specs, cfg.Rules...)
+
+	r := &Redactor{entropyThreshold: cfg.EntropyThreshold, entropyMinLength: cfg.EntropyMinLength}
+	if r.entropyThreshold > 0 && r.entropyMinLength <= 0 {
+		r.entropyMinLength = defaultEntropyMinLength
+	}
+	for _, spec := range specs {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("redaction rule missing required field: name")
+		}
+		re, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %q: invalid pattern: %w", spec.Name, err)
+		}
+		r.rules = append(r.rules, rule{name: spec.Name, re: re, group: re.SubexpIndex(secretGroup), accept: spec.accept})
+	}
+	return r, nil
+}
+
+// Default returns a Redactor with only the built-in rules.
+func Default() *Redactor {
+	r, err := New(Config{})
+	if err != nil {
+		panic(err) // built-in rules are static
+	}
+	return r
+}
+
+// Load reads a redaction config from path. A missing file yields the
+// built-in rules.
+func Load(path string) (*Redactor, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return New(cfg)
+}
+
+// Redact returns text with secrets replaced and the number of replacements.
+func (r *Redactor) Redact(text string) (string, int) {
+	if r == nil || text == "" {
+		return text, 0
+	}
+	count := 0
+	for _, rl := range r.rules {
+		text = rl.replace(text, &count)
+	}
+	if r.entropyThreshold > 0 {
+		text = entropyCandidate.ReplaceAllStringFunc(text, func(tok string) string {
+			if r.isHighEntropy(tok) {
+				count++
+				return placeholder("high-entropy")
+			}
+			return tok
+		})
+	}
+	return text, count
+}
+
+// replace applies the rule to text, counting replacements.
+func (rl rule) replace(text string, count *int) string {
+	matches := rl.re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if rl.group > 0 {
+			start, end = m[2*rl.group], m[2*rl.group+1]
+			if start < 0 {
+				continue
+			}
+		}
+		if strings.HasPrefix(text[start:end], "[REDACTED:") {
+			continue // already redacted by an earlier rule
+		}
+		if rl.accept != nil && !rl.accept(text[start:end]) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(placeholder(rl.name))
+		last = end
+		*count++
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isHighEntropy reports whether tok looks like a random secret: long enough,
+// mixing letters and digits, with high per-character entropy.
+func (r *Redactor) isHighEntropy(tok string) bool {
+	if len(tok) < r.entropyMinLength {
+		return false
+	}
+	hasDigit := strings.ContainsAny(tok, "0123456789")
+	hasLetter := strings.IndexFunc(tok, func(c rune) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }) >= 0
+	return hasDigit && hasLetter && shannonEntropy(tok) >= r.entropyThreshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	var freq [256]int
+	for i := 0; i < len(s); i++ {
+		freq[s[i]]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range freq {
+		if c > 0 {
+			p := float64(c) / n
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+func placeholder(name string) string {
+	return "[REDACTED:" + name + "]"
+}
@@ -0,0 +1,465 @@
+// Package remote runs the worker's shell, exec and file tools on another
+// machine over SSH, so a lightweight worker can drive builds on a bigger
+// host. A Backend keeps one SSH connection open, redialing after it drops,
+// and sends keepalives so a dead link is noticed while commands and exec
+// sessions wait on it.
+//
+// Paths under a session's working directory map to the remote root; other
+// absolute paths are used as they are on the remote host.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Backend defaults.
+const (
+	DefaultPort      = "22"
+	DefaultKeepAlive = 15 * time.Second
+	dialTimeout      = 15 * time.Second
+)
+
+// ErrClosed is returned by a Backend after Close.
+var ErrClosed = errors.New("ssh backend is closed")
+
+// Config configures a Backend.
+type Config struct {
+	Host           string        // host or host:port
+	User           string        // Remote user; "" uses the worker's user
+	KeyPath        string        // Private key file
+	KnownHostsPath string        // known_hosts file the host key is checked against
+	Root           string        // Absolute remote directory the session's working directory maps to
+	KeepAlive      time.Duration // Keepalive interval; 0 uses DefaultKeepAlive
+}
+
+// ConfigFromEnv reads WORKER_SSH_HOST ([user@]host[:port]), WORKER_SSH_ROOT,
+// WORKER_SSH_KEY (default ~/.ssh/id_ed25519) and WORKER_SSH_KNOWN_HOSTS
+// (default ~/.ssh/known_hosts). ok is false when WORKER_SSH_HOST is unset
+// and tools run on the worker itself.
+func ConfigFromEnv() (cfg Config, ok bool, err error) {
+	host := os.Getenv("WORKER_SSH_HOST")
+	if host == "" {
+		return Config{}, false, nil
+	}
+	if u, h, found := strings.Cut(host, "@"); found {
+		cfg.User, host = u, h
+	}
+	cfg.Host = host
+	cfg.Root = os.Getenv("WORKER_SSH_ROOT")
+	if cfg.Root == "" || !path.IsAbs(cfg.Root) {
+		return Config{}, false, fmt.Errorf("WORKER_SSH_ROOT must be an absolute remote directory, got %q", cfg.Root)
+	}
+	home, _ := os.UserHomeDir()
+	cfg.KeyPath = os.Getenv("WORKER_SSH_KEY")
+	if cfg.KeyPath == "" {
+		cfg.KeyPath = filepath.Join(home, ".ssh", "id_ed25519")
+	}
+	cfg.KnownHostsPath = os.Getenv("WORKER_SSH_KNOWN_HOSTS")
+	if cfg.KnownHostsPath == "" {
+		cfg.KnownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return cfg, true, nil
+}
+
+// Backend runs commands on the remote host. It is safe for concurrent use.
+type Backend struct {
+	cfg          Config
+	addr         string
+	clientConfig *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+	closed bool
+}
+
+// New creates a Backend from cfg, loading its key and known hosts. The
+// connection is made on first use.
+func New(cfg Config) (*Backend, error) {
+	keyPEM, err := os.ReadFile(cfg.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read ssh key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse ssh key %s: %w", cfg.KeyPath, err)
+	}
+	hostKeys, err := knownhosts.New(cfg.KnownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("load known hosts: %w", err)
+	}
+	if cfg.User == "" {
+		if u, err := user.Current(); err == nil {
+			cfg.User = u.Username
+		}
+	}
+	return newBackend(cfg, &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeys,
+		Timeout:         dialTimeout,
+	}), nil
+}
+
+func newBackend(cfg Config, clientConfig *ssh.ClientConfig) *Backend {
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = DefaultKeepAlive
+	}
+	addr := cfg.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, DefaultPort)
+	}
+	return &Backend{cfg: cfg, addr: addr, clientConfig: clientConfig}
+}
+
+// Addr returns the remote host's address.
+func (b *Backend) Addr() string { return b.addr }
+
+// Root returns the remote directory the session's working directory maps to.
+func (b *Backend) Root() string { return b.cfg.Root }
+
+// Path maps a worker-side path to the remote host: cwd and paths under it
+// move to the remote root, relative paths resolve against it, and other
+// absolute paths are kept.
+func (b *Backend) Path(cwd, p string) string {
+	p = filepath.ToSlash(p)
+	if cwd != "" {
+		cwd = strings.TrimSuffix(filepath.ToSlash(cwd), "/")
+		if p == cwd {
+			return b.cfg.Root
+		}
+		if rel, ok := strings.CutPrefix(p, cwd+"/"); ok {
+			return path.Join(b.cfg.Root, rel)
+		}
+	}
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	return path.Join(b.cfg.Root, p)
+}
+
+// Close closes the connection; the Backend cannot be used afterwards.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	if b.client == nil {
+		return nil
+	}
+	err := b.client.Close()
+	b.client = nil
+	return err
+}
+
+// conn returns the open connection, dialing a new one if there is none.
+func (b *Backend) conn() (*ssh.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if b.client != nil {
+		return b.client, nil
+	}
+	c, err := ssh.Dial("tcp", b.addr, b.clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", b.addr, err)
+	}
+	b.client = c
+	done := make(chan struct{})
+	go func() {
+		_ = c.Wait()
+		close(done)
+		b.drop(c, nil)
+	}()
+	go b.keepAlive(c, done)
+	return c, nil
+}
+
+// keepAlive pings the connection every KeepAlive interval and drops it when
+// a ping fails or goes unanswered for an interval, which ends the commands
+// and sessions running over it.
+func (b *Backend) keepAlive(c *ssh.Client, done <-chan struct{}) {
+	ticker := time.NewTicker(b.cfg.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+		var err error
+		select {
+		case err = <-reply:
+		case <-time.After(b.cfg.KeepAlive):
+			err = errors.New("keepalive timed out")
+		case <-done:
+			return
+		}
+		if err != nil {
+			b.drop(c, err)
+			return
+		}
+	}
+}
+
+// drop closes c and forgets it, so the next command redials.
+func (b *Backend) drop(c *ssh.Client, reason error) {
+	b.mu.Lock()
+	current := b.client == c
+	if current {
+		b.client = nil
+	}
+	b.mu.Unlock()
+	if current && reason != nil {
+		log.Printf("SSH connection to %s lost: %v", b.addr, reason)
+	}
+	_ = c.Close()
+}
+
+// Command is a command line to run on the remote host.
+type Command struct {
+	Script string            // Shell command line
+	Dir    string            // Remote working directory; "" is the remote root
+	Env    map[string]string // Variables set for the command
+	Shell  string            // Shell that runs Script; "" is the remote user's $SHELL
+	Login  bool              // Run Script in a login shell
+	TTY    bool              // Allocate a pseudo-terminal (Start only)
+	Stdin  io.Reader         // Input (Run only); nil is empty
+}
+
+// line returns the command line sent to the remote host.
+func (c Command) line(root string) string {
+	dir := c.Dir
+	if dir == "" {
+		dir = root
+	}
+	var b strings.Builder
+	b.WriteString("cd " + Quote(dir) + " && exec ")
+	if len(c.Env) > 0 {
+		keys := make([]string, 0, len(c.Env))
+		for k := range c.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("env")
+		for _, k := range keys {
+			b.WriteString(" " + Quote(k+"="+c.Env[k]))
+		}
+		b.WriteString(" ")
+	}
+	shell := `"${SHELL:-/bin/sh}"`
+	if c.Shell != "" {
+		shell = Quote(c.Shell)
+	}
+	flag := " -c "
+	if c.Login {
+		flag = " -lc "
+	}
+	b.WriteString(shell + flag + Quote(c.Script))
+	return b.String()
+}
+
+// Quote quotes s for a POSIX shell.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteArgs quotes each argument and joins them into a command line.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = Quote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Run runs cmd to completion, copying its output to stdout and stderr, and
+// returns its exit code. An error means the command's outcome is unknown:
+// the connection failed or ctx was done, in which case the command is
+// killed and ctx.Err() returned.
+func (b *Backend) Run(ctx context.Context, cmd Command, stdout, stderr io.Writer) (int, error) {
+	c, err := b.conn()
+	if err != nil {
+		return -1, err
+	}
+	session, err := c.NewSession()
+	if err != nil {
+		b.drop(c, err)
+		return -1, fmt.Errorf("ssh %s: open session: %w", b.addr, err)
+	}
+	defer session.Close()
+	session.Stdin = cmd.Stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err := session.Start(cmd.line(b.cfg.Root)); err != nil {
+		return -1, fmt.Errorf("ssh %s: start command: %w", b.addr, err)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- session.Wait() }()
+	select {
+	case err = <-waitErr:
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		_ = session.Close()
+		return -1, ctx.Err()
+	}
+	return exitCode(err, b.addr)
+}
+
+// exitCode converts the result of ssh.Session.Wait.
+func exitCode(err error, addr string) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	return -1, fmt.Errorf("ssh %s: %w", addr, err)
+}
+
+// ReadFile returns the contents of the remote file at p.
+func (b *Backend) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	code, err := b.Run(ctx, Command{Script: "cat -- " + Quote(p), Dir: "/"}, &stdout, &stderr)
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, &CommandError{Op: "read " + p, Stderr: stderr.String()}
+	}
+	return stdout.Bytes(), nil
+}
+
+// WriteFile writes data to the remote file at p, creating its parent
+// directories.
+func (b *Backend) WriteFile(ctx context.Context, p string, data []byte) error {
+	script := "mkdir -p -- " + Quote(path.Dir(p)) + " && cat > " + Quote(p)
+	var stderr bytes.Buffer
+	code, err := b.Run(ctx, Command{Script: script, Dir: "/", Stdin: bytes.NewReader(data)}, io.Discard, &stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &CommandError{Op: "write " + p, Stderr: stderr.String()}
+	}
+	return nil
+}
+
+// CommandError reports a remote file operation that ran but failed.
+type CommandError struct {
+	Op     string
+	Stderr string
+}
+
+func (e *CommandError) Error() string {
+	msg := strings.TrimSpace(e.Stderr)
+	if msg == "" {
+		msg = "command failed"
+	}
+	return e.Op + ": " + msg
+}
+
+// Process is a command started with Start. Its SSH channel stays open
+// until the command exits or the process is killed.
+type Process struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+	stderr  io.Reader
+	addr    string
+}
+
+// Start starts cmd and returns without waiting for it. Stdin is open when
+// cmd.TTY or withStdin is set.
+func (b *Backend) Start(cmd Command, withStdin bool) (*Process, error) {
+	c, err := b.conn()
+	if err != nil {
+		return nil, err
+	}
+	session, err := c.NewSession()
+	if err != nil {
+		b.drop(c, err)
+		return nil, fmt.Errorf("ssh %s: open session: %w", b.addr, err)
+	}
+	p := &Process{session: session, addr: b.addr}
+	if cmd.TTY {
+		modes := ssh.TerminalModes{ssh.ECHO: 0}
+		if err := session.RequestPty("dumb", 40, 200, modes); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("ssh %s: request pty: %w", b.addr, err)
+		}
+	}
+	if cmd.TTY || withStdin {
+		if p.stdin, err = session.StdinPipe(); err != nil {
+			session.Close()
+			return nil, err
+		}
+	}
+	if p.stdout, err = session.StdoutPipe(); err != nil {
+		session.Close()
+		return nil, err
+	}
+	if p.stderr, err = session.StderrPipe(); err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.Start(cmd.line(b.cfg.Root)); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("ssh %s: start command: %w", b.addr, err)
+	}
+	return p, nil
+}
+
+// Stdin returns the process's input, or nil if it was started without.
+func (p *Process) Stdin() io.WriteCloser { return p.stdin }
+
+// Stdout returns the process's output (all output, with a TTY).
+func (p *Process) Stdout() io.Reader { return p.stdout }
+
+// Stderr returns the process's error output.
+func (p *Process) Stderr() io.Reader { return p.stderr }
+
+// Wait waits for the process to exit and returns its exit code, or -1 if
+// the connection was lost first.
+func (p *Process) Wait() int {
+	code, err := exitCode(p.session.Wait(), p.addr)
+	if err != nil {
+		log.Printf("Remote process ended without an exit status: %v", err)
+	}
+	return code
+}
+
+// Interrupt sends the process SIGINT.
+func (p *Process) Interrupt() error { return p.session.Signal(ssh.SIGINT) }
+
+// Kill sends the process SIGKILL and closes its channel.
+func (p *Process) Kill() error {
+	_ = p.session.Signal(ssh.SIGKILL)
+	return p.session.Close()
+}
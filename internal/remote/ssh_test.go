@@ -0,0 +1,284 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startTestServer runs an SSH server on localhost that executes "exec"
+// requests with sh -c, and returns a Backend configured to reach it.
+func startTestServer(t *testing.T, root string) *Backend {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authorized, err := ssh.NewPublicKey(clientPub)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, serverConfig)
+		}
+	}()
+
+	dir := t.TempDir()
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600))
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(ln.Addr().String())}, hostSigner.PublicKey())
+	require.NoError(t, os.WriteFile(knownHostsPath, []byte(line+"\n"), 0o600))
+
+	b, err := New(Config{
+		Host:           ln.Addr().String(),
+		User:           "tester",
+		KeyPath:        keyPath,
+		KnownHostsPath: knownHostsPath,
+		Root:           root,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go func() {
+		for req := range reqs {
+			_ = req.Reply(req.Type == "keepalive@openssh.com", nil)
+		}
+	}()
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(ch, chReqs)
+	}
+}
+
+func serveSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	var cmd *exec.Cmd
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req":
+			_ = req.Reply(true, nil)
+		case "signal":
+			_ = req.Reply(true, nil)
+			if cmd != nil && cmd.Process != nil {
+				_ = cmd.Process.Signal(syscall.SIGKILL)
+			}
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			cmd = exec.Command("sh", "-c", payload.Command)
+			cmd.Stdout = ch
+			cmd.Stderr = ch.Stderr()
+			stdin, _ := cmd.StdinPipe()
+			if err := cmd.Start(); err != nil {
+				ch.Close()
+				return
+			}
+			go func() {
+				_, _ = io.Copy(stdin, ch)
+				stdin.Close()
+			}()
+			go func(cmd *exec.Cmd) {
+				code := 0
+				if err := cmd.Wait(); err != nil {
+					code = 255
+					if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() >= 0 {
+						code = exitErr.ExitCode()
+					}
+				}
+				status := make([]byte, 4)
+				binary.BigEndian.PutUint32(status, uint32(code))
+				_, _ = ch.SendRequest("exit-status", false, status)
+				ch.Close()
+			}(cmd)
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	b := startTestServer(t, root)
+
+	var stdout, stderr bytes.Buffer
+	code, err := b.Run(context.Background(), Command{
+		Script: `pwd; echo "$GREETING"; echo oops >&2; exit 3`,
+		Env:    map[string]string{"GREETING": "it's here"},
+		Shell:  "sh",
+	}, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+	resolved, _ := filepath.EvalSymlinks(root)
+	assert.Contains(t, []string{root + "\n" + "it's here\n", resolved + "\n" + "it's here\n"}, stdout.String())
+	assert.Equal(t, "oops\n", stderr.String())
+}
+
+func TestRun_ContextCancelled(t *testing.T) {
+	b := startTestServer(t, t.TempDir())
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := b.Run(ctx, Command{Script: "sleep 30", Shell: "sh"}, io.Discard, io.Discard)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestReadWriteFile(t *testing.T) {
+	root := t.TempDir()
+	b := startTestServer(t, root)
+	ctx := context.Background()
+
+	p := b.Path("/work", "/work/sub/dir/file.txt")
+	require.NoError(t, b.WriteFile(ctx, p, []byte("line one\nline 'two'\n")))
+	data, err := os.ReadFile(filepath.Join(root, "sub", "dir", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "line one\nline 'two'\n", string(data))
+
+	data, err = b.ReadFile(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, "line one\nline 'two'\n", string(data))
+
+	_, err = b.ReadFile(ctx, b.Path("/work", "missing.txt"))
+	var cmdErr *CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.Contains(t, err.Error(), "missing.txt")
+}
+
+func TestStart(t *testing.T) {
+	b := startTestServer(t, t.TempDir())
+
+	p, err := b.Start(Command{Script: "read line; echo got $line; exit 4", Shell: "sh"}, true)
+	require.NoError(t, err)
+	_, err = io.WriteString(p.Stdin(), "ping\n")
+	require.NoError(t, err)
+	out, err := io.ReadAll(p.Stdout())
+	require.NoError(t, err)
+	assert.Equal(t, "got ping\n", string(out))
+	_, _ = io.ReadAll(p.Stderr())
+	assert.Equal(t, 4, p.Wait())
+}
+
+func TestStart_Kill(t *testing.T) {
+	b := startTestServer(t, t.TempDir())
+
+	p, err := b.Start(Command{Script: "sleep 30", Shell: "sh"}, false)
+	require.NoError(t, err)
+	assert.Nil(t, p.Stdin())
+	require.NoError(t, p.Kill())
+
+	done := make(chan int, 1)
+	go func() { done <- p.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Wait did not return after Kill")
+	}
+}
+
+func TestPath(t *testing.T) {
+	b := newBackend(Config{Host: "build", Root: "/srv/ws"}, &ssh.ClientConfig{})
+	assert.Equal(t, "build:22", b.Addr())
+	assert.Equal(t, "/srv/ws", b.Path("/home/me/repo", "/home/me/repo"))
+	assert.Equal(t, "/srv/ws/src/main.go", b.Path("/home/me/repo", "/home/me/repo/src/main.go"))
+	assert.Equal(t, "/srv/ws/src/main.go", b.Path("/home/me/repo/", "src/main.go"))
+	assert.Equal(t, "/etc/hosts", b.Path("/home/me/repo", "/etc/hosts"))
+	assert.Equal(t, "/home/me/repository", b.Path("/home/me/repo", "/home/me/repository"))
+	assert.Equal(t, "/srv/ws/x", b.Path("", "x"))
+}
+
+func TestCommandLine(t *testing.T) {
+	cmd := Command{Script: "echo 'hi'", Dir: "/srv/ws", Env: map[string]string{"B": "2", "A": "1"}, Login: true}
+	assert.Equal(t, `cd '/srv/ws' && exec env 'A=1' 'B=2' "${SHELL:-/bin/sh}" -lc 'echo '\''hi'\'''`, cmd.line("/root"))
+
+	cmd = Command{Script: "ls", Shell: "bash"}
+	assert.Equal(t, `cd '/root' && exec 'bash' -c 'ls'`, cmd.line("/root"))
+}
+
+func TestQuoteArgs(t *testing.T) {
+	assert.Equal(t, `'ls' '-la' 'it'\''s dir'`, QuoteArgs([]string{"ls", "-la", "it's dir"}))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("WORKER_SSH_HOST", "")
+	_, ok, err := ConfigFromEnv()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Setenv("WORKER_SSH_HOST", "ci@build.internal:2222")
+	t.Setenv("WORKER_SSH_ROOT", "")
+	_, _, err = ConfigFromEnv()
+	assert.ErrorContains(t, err, "WORKER_SSH_ROOT")
+
+	t.Setenv("WORKER_SSH_ROOT", "/srv/ws")
+	t.Setenv("WORKER_SSH_KEY", "/keys/id")
+	t.Setenv("WORKER_SSH_KNOWN_HOSTS", "")
+	cfg, ok, err := ConfigFromEnv()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "ci", cfg.User)
+	assert.Equal(t, "build.internal:2222", cfg.Host)
+	assert.Equal(t, "/srv/ws", cfg.Root)
+	assert.Equal(t, "/keys/id", cfg.KeyPath)
+	assert.True(t, strings.HasSuffix(cfg.KnownHostsPath, filepath.Join(".ssh", "known_hosts")))
+}
@@ -0,0 +1,571 @@
+package sandbox
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/command_safety"
+)
+
+// AccessKind is the kind of access a sandbox restricts.
+type AccessKind string
+
+const (
+	// AccessWrite is a write to a file or directory.
+	AccessWrite AccessKind = "write"
+	// AccessNetwork is a connection to a host.
+	AccessNetwork AccessKind = "network"
+)
+
+// Access is something a command or file tool would do that a sandbox
+// restricts: a write to Path, or a connection to Host:Port. Host is empty
+// when the command connects somewhere that cannot be told from its
+// arguments.
+type Access struct {
+	Kind AccessKind `json:"kind"`
+	Path string     `json:"path,omitempty"`
+	Host string     `json:"host,omitempty"`
+	Port int        `json:"port,omitempty"`
+}
+
+// Target describes what the access touches, for reports.
+func (a Access) Target() string {
+	switch {
+	case a.Kind == AccessWrite:
+		return a.Path
+	case a.Host == "":
+		return "unknown host"
+	case a.Port == 0:
+		return a.Host
+	default:
+		return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+	}
+}
+
+// alwaysWritable are the directories both sandbox backends leave writable.
+var alwaysWritable = []string{"/tmp", "/private/tmp", "/dev"}
+
+// Check reports why the policy would deny a, or "" when it allows it.
+// Unrestricted policies allow everything.
+func (p *SandboxPolicy) Check(a Access) string {
+	if !p.IsRestricted() {
+		return ""
+	}
+	switch a.Kind {
+	case AccessWrite:
+		for _, dir := range alwaysWritable {
+			if isUnder(a.Path, dir) {
+				return ""
+			}
+		}
+		if p.Mode == ModeReadOnly {
+			return "the read-only sandbox blocks writes"
+		}
+		for _, root := range p.WritableRoots {
+			if isUnder(a.Path, string(root)) {
+				return ""
+			}
+		}
+		return "outside the writable roots"
+	case AccessNetwork:
+		if p.NetworkAccess {
+			return ""
+		}
+		if len(p.NetworkAllow) == 0 {
+			return "network access is off"
+		}
+		allow, _ := ParseNetworkAllow(p.NetworkAllow)
+		port := a.Port
+		if port == 0 {
+			port = 443
+		}
+		if a.Host != "" && allow.Allows(a.Host, port) {
+			return ""
+		}
+		return "not in network_allow"
+	}
+	return ""
+}
+
+// isUnder reports whether p is dir or inside it.
+func isUnder(p, dir string) bool {
+	dir = strings.TrimSuffix(dir, "/")
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// WriteAccess is a write to p, resolved against cwd.
+func WriteAccess(cwd, p string) Access {
+	if !path.IsAbs(p) && !strings.HasPrefix(p, "~") {
+		p = path.Join(cwd, p)
+	}
+	return Access{Kind: AccessWrite, Path: path.Clean(p)}
+}
+
+// CommandAccesses lists the writes and connections a command likely makes.
+// It is a best-effort reading of the command line, for auditing what a
+// sandbox would block: it knows common file, git, download and package
+// manager commands and shell redirections, and cannot see what programs do
+// on their own. command is an argv; "bash -lc <script>" and similar are
+// read as scripts.
+func CommandAccesses(cwd string, command []string) []Access {
+	var commands [][]string
+	var redirects []string
+	if script, ok := shellScript(command); ok {
+		commands, redirects = scanScript(script)
+	} else {
+		commands = [][]string{command}
+	}
+
+	var out []Access
+	seen := make(map[Access]bool)
+	add := func(a Access) {
+		if !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	addWrite := func(p string) {
+		if p == "" || strings.ContainsAny(p, "$`") {
+			return // Expands at run time
+		}
+		add(WriteAccess(cwd, p))
+	}
+	for _, r := range redirects {
+		addWrite(r)
+	}
+	for _, argv := range commands {
+		writes, hosts := commandAccesses(stripPrefixes(argv))
+		for _, w := range writes {
+			addWrite(w)
+		}
+		for _, h := range hosts {
+			add(h)
+		}
+	}
+	return out
+}
+
+// shellScript returns the script of "bash -c <script>" style commands.
+func shellScript(command []string) (string, bool) {
+	if len(command) != 3 || (command[1] != "-c" && command[1] != "-lc") {
+		return "", false
+	}
+	switch path.Base(command[0]) {
+	case "bash", "zsh", "sh", "dash":
+		return command[2], true
+	}
+	return "", false
+}
+
+// scanScript splits a shell script into commands and the targets of its
+// output redirections. Unlike the exec policy parser it accepts any
+// script, reading quotes and operators and leaving expansions as text.
+func scanScript(script string) (commands [][]string, redirects []string) {
+	var cur []string
+	var word strings.Builder
+	inWord, redirectNext := false, false
+	flushWord := func() {
+		if !inWord {
+			return
+		}
+		w := word.String()
+		word.Reset()
+		inWord = false
+		if redirectNext {
+			redirectNext = false
+			if !strings.HasPrefix(w, "&") && w != "/dev/null" {
+				redirects = append(redirects, w)
+			}
+			return
+		}
+		cur = append(cur, w)
+	}
+	endCommand := func() {
+		flushWord()
+		if len(cur) > 0 {
+			commands = append(commands, cur)
+		}
+		cur = nil
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'':
+			inWord = true
+			j := strings.IndexByte(script[i+1:], '\'')
+			if j < 0 {
+				word.WriteString(script[i+1:])
+				i = len(script)
+				break
+			}
+			word.WriteString(script[i+1 : i+1+j])
+			i += j + 1
+		case c == '"':
+			inWord = true
+			for i++; i < len(script) && script[i] != '"'; i++ {
+				if script[i] == '\\' && i+1 < len(script) {
+					i++
+				}
+				word.WriteByte(script[i])
+			}
+		case c == '\\' && i+1 < len(script):
+			inWord = true
+			i++
+			if script[i] != '\n' {
+				word.WriteByte(script[i])
+			}
+		case c == '#' && !inWord:
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			endCommand()
+		case c == '>':
+			// A file descriptor number ("2>") is part of the operator.
+			if inWord && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			}
+			flushWord()
+			for i+1 < len(script) && (script[i+1] == '>' || script[i+1] == '|') {
+				i++
+			}
+			if i+1 < len(script) && script[i+1] == '&' {
+				i++ // ">&2" duplicates a descriptor
+				for i+1 < len(script) && script[i+1] >= '0' && script[i+1] <= '9' {
+					i++
+				}
+				continue
+			}
+			redirectNext = true
+		case c == '<':
+			flushWord()
+			for i+1 < len(script) && script[i+1] == '<' {
+				i++
+			}
+		case c == '&' && i+1 < len(script) && script[i+1] == '>':
+			flushWord()
+		case c == ';' || c == '&' || c == '|' || c == '\n' || c == '(' || c == ')' || c == '{' || c == '}':
+			endCommand()
+			redirectNext = false
+		case c == ' ' || c == '\t':
+			flushWord()
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	endCommand()
+	return commands, redirects
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// stripPrefixes drops variable assignments and wrappers such as sudo and
+// env that run the rest of argv as the command.
+func stripPrefixes(argv []string) []string {
+	for len(argv) > 0 {
+		switch name := path.Base(argv[0]); {
+		case strings.Contains(argv[0], "=") && !strings.HasPrefix(argv[0], "="):
+			argv = argv[1:]
+		case name == "sudo" || name == "env" || name == "time" || name == "nohup" || name == "nice" || name == "command" || name == "exec":
+			argv = argv[1:]
+			for len(argv) > 0 && strings.HasPrefix(argv[0], "-") {
+				argv = argv[1:]
+			}
+		default:
+			return argv
+		}
+	}
+	return argv
+}
+
+// operands returns the arguments of argv that are not flags.
+func operands(args []string) []string {
+	var out []string
+	for i, a := range args {
+		if a == "--" {
+			return append(out, args[i+1:]...)
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// gitWriteSubcommands change the repository or its working tree.
+var gitWriteSubcommands = []string{
+	"add", "am", "apply", "checkout", "cherry-pick", "clean", "clone", "commit", "fetch", "init",
+	"merge", "mv", "pull", "rebase", "reset", "restore", "revert", "rm", "stash", "switch", "tag",
+}
+
+// gitNetworkSubcommands talk to a remote.
+var gitNetworkSubcommands = []string{"clone", "fetch", "pull", "push", "ls-remote", "submodule"}
+
+// sshValueFlags are the ssh options that take a value.
+var sshValueFlags = []string{"-b", "-c", "-D", "-E", "-e", "-F", "-i", "-J", "-L", "-l", "-m", "-O", "-o", "-p", "-Q", "-R", "-S", "-W", "-w"}
+
+// registries are the hosts package managers download from by default.
+var registries = map[string]string{
+	"npm":   "registry.npmjs.org",
+	"npx":   "registry.npmjs.org",
+	"pnpm":  "registry.npmjs.org",
+	"yarn":  "registry.yarnpkg.com",
+	"pip":   "pypi.org",
+	"pip3":  "pypi.org",
+	"uv":    "pypi.org",
+	"go":    "proxy.golang.org",
+	"cargo": "index.crates.io",
+	"gem":   "rubygems.org",
+}
+
+// registrySubcommands are the package manager subcommands that download.
+var registrySubcommands = map[string][]string{
+	"npm":   {"install", "i", "ci", "add", "update", "exec"},
+	"npx":   nil, // Any invocation may download the package
+	"pnpm":  {"install", "i", "add", "update", "dlx"},
+	"yarn":  {"install", "add", "upgrade", "dlx", ""},
+	"pip":   {"install", "download"},
+	"pip3":  {"install", "download"},
+	"uv":    {"pip", "add", "sync", "run"},
+	"go":    {"get", "install", "mod"},
+	"cargo": {"install", "fetch", "build", "add", "update"},
+	"gem":   {"install", "update"},
+}
+
+// commandAccesses lists the paths one command writes and the hosts it
+// connects to.
+func commandAccesses(argv []string) (writes []string, hosts []Access) {
+	if len(argv) == 0 {
+		return nil, nil
+	}
+	name, args := path.Base(argv[0]), argv[1:]
+	ops := operands(args)
+	switch name {
+	case "rm", "rmdir", "unlink", "touch", "mkdir", "tee", "shred", "mv":
+		writes = ops
+	case "truncate":
+		writes = operandsSkipping(args, "-s", "--size", "-r", "--reference")
+	case "chmod", "chown", "chgrp":
+		if len(ops) > 1 {
+			writes = ops[1:]
+		}
+	case "cp", "install", "ln":
+		if len(ops) > 1 {
+			writes = ops[len(ops)-1:]
+		}
+	case "dd":
+		for _, a := range args {
+			if of, ok := strings.CutPrefix(a, "of="); ok {
+				writes = append(writes, of)
+			}
+		}
+	case "sed", "perl":
+		if !hasInPlaceFlag(args) {
+			break
+		}
+		if scripts := flagValues(args, "-e", "--expression", "-f", "--file"); len(scripts) > 0 {
+			writes = operandsSkipping(args, "-e", "--expression", "-f", "--file")
+		} else if len(ops) > 1 {
+			writes = ops[1:] // The first operand is the script
+		}
+	case "curl", "wget":
+		if name == "curl" {
+			writes = flagValues(args, "-o", "--output")
+		} else {
+			writes = flagValues(args, "-O", "--output-document")
+		}
+		for _, op := range ops {
+			if h, ok := urlHost(op); ok {
+				hosts = append(hosts, h)
+			}
+		}
+		if len(hosts) == 0 {
+			hosts = append(hosts, Access{Kind: AccessNetwork})
+		}
+	case "git":
+		if _, _, ok := command_safety.FindGitSubcommand(argv, gitWriteSubcommands); ok {
+			writes = append(writes, ".")
+		}
+		if idx, _, ok := command_safety.FindGitSubcommand(argv, gitNetworkSubcommands); ok {
+			hosts = append(hosts, remoteHost(operands(argv[idx+1:])))
+		}
+	case "ssh", "sftp":
+		h := Access{Port: 22}
+		if hostOps := operandsSkipping(args, sshValueFlags...); len(hostOps) > 0 {
+			host := hostOps[0]
+			if i := strings.LastIndex(host, "@"); i >= 0 {
+				host = host[i+1:]
+			}
+			h.Host = strings.ToLower(host)
+		}
+		if ports := flagValues(args, "-p", "-P"); len(ports) > 0 {
+			h.Port, _ = strconv.Atoi(ports[0])
+		}
+		hosts = append(hosts, h)
+	case "nc", "ncat", "telnet", "ftp":
+		h := Access{}
+		if len(ops) > 0 {
+			h.Host = strings.ToLower(ops[0])
+		}
+		if len(ops) > 1 {
+			h.Port, _ = strconv.Atoi(ops[1])
+		}
+		hosts = append(hosts, h)
+	case "scp", "rsync":
+		if len(ops) > 1 && !strings.Contains(ops[len(ops)-1], ":") {
+			writes = ops[len(ops)-1:]
+		}
+		if hasRemoteOperand(ops) {
+			hosts = append(hosts, remoteHost(ops))
+		}
+	default:
+		if registry, ok := registries[name]; ok && downloads(name, ops) {
+			hosts = append(hosts, Access{Kind: AccessNetwork, Host: registry, Port: 443})
+			if name == "npm" || name == "pnpm" || name == "yarn" {
+				writes = append(writes, "node_modules")
+			}
+		}
+	}
+	for i, h := range hosts {
+		h.Kind = AccessNetwork
+		hosts[i] = h
+	}
+	return writes, hosts
+}
+
+// operandsSkipping is operands without the values of the given flags.
+func operandsSkipping(args []string, valueFlags ...string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if containsString(valueFlags, a) {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(a, "-") {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// flagValues returns the values of the given flags, as "-o v", "--output v"
+// or "--output=v".
+func flagValues(args []string, flags ...string) []string {
+	var out []string
+	for i, a := range args {
+		if containsString(flags, a) && i+1 < len(args) {
+			out = append(out, args[i+1])
+			continue
+		}
+		if k, v, ok := strings.Cut(a, "="); ok && strings.HasPrefix(k, "--") && containsString(flags, k) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func hasInPlaceFlag(args []string) bool {
+	for _, a := range args {
+		if a == "--in-place" || strings.HasPrefix(a, "--in-place=") ||
+			(strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "i")) {
+			return true
+		}
+	}
+	return false
+}
+
+// downloads reports whether a package manager invocation fetches packages.
+func downloads(name string, ops []string) bool {
+	subs := registrySubcommands[name]
+	if subs == nil {
+		return true
+	}
+	sub := ""
+	if len(ops) > 0 {
+		sub = ops[0]
+	}
+	return containsString(subs, sub)
+}
+
+// urlHost returns the host and port of a URL argument.
+func urlHost(arg string) (Access, bool) {
+	if !strings.Contains(arg, "://") {
+		return Access{}, false
+	}
+	u, err := url.Parse(arg)
+	if err != nil || u.Hostname() == "" {
+		return Access{}, false
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 {
+		switch u.Scheme {
+		case "https":
+			port = 443
+		case "http":
+			port = 80
+		case "ssh":
+			port = 22
+		}
+	}
+	return Access{Kind: AccessNetwork, Host: strings.ToLower(u.Hostname()), Port: port}, true
+}
+
+// remoteHost finds the host among the operands of a git, ssh or copy
+// command: a URL, "user@host", or "host:path".
+func remoteHost(ops []string) Access {
+	for _, op := range ops {
+		if a, ok := urlHost(op); ok {
+			return a
+		}
+	}
+	for _, op := range ops {
+		host := op
+		if _, after, ok := strings.Cut(host, "@"); ok {
+			host = after
+		} else if !strings.Contains(host, ":") {
+			continue
+		}
+		host, _, _ = strings.Cut(host, ":")
+		if host != "" && !strings.Contains(host, "/") {
+			return Access{Kind: AccessNetwork, Host: strings.ToLower(host), Port: 22}
+		}
+	}
+	return Access{Kind: AccessNetwork}
+}
+
+func hasRemoteOperand(ops []string) bool {
+	for _, op := range ops {
+		if strings.Contains(op, "://") {
+			return true
+		}
+		if host, _, ok := strings.Cut(op, ":"); ok && host != "" && !strings.Contains(host, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
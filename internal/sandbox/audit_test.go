@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func write(p string) Access { return Access{Kind: AccessWrite, Path: p} }
+
+func conn(host string, port int) Access { return Access{Kind: AccessNetwork, Host: host, Port: port} }
+
+func TestCommandAccesses(t *testing.T) {
+	bash := func(script string) []string { return []string{"bash", "-lc", script} }
+	for _, tc := range []struct {
+		command []string
+		want    []Access
+	}{
+		{bash("ls -la && cat go.mod | grep module"), nil},
+		{bash("go test ./... > /tmp/out.txt 2>&1"), []Access{write("/tmp/out.txt")}},
+		{bash("echo hi > notes.txt; echo more >> ../log.txt 2> err.log"),
+			[]Access{write("/repo/notes.txt"), write("/log.txt"), write("/repo/err.log")}},
+		{bash("echo x >/dev/null 2>&1 && echo y >&2"), nil},
+		{bash(`rm -rf build "dist dir" && mkdir -p out/bin`),
+			[]Access{write("/repo/build"), write("/repo/dist dir"), write("/repo/out/bin")}},
+		{bash("sudo cp -r conf /etc/app && chmod 644 /etc/app/conf"),
+			[]Access{write("/etc/app"), write("/etc/app/conf")}},
+		{bash("sed -i 's/a/b/' main.go && sed -n 1p main.go && perl -pi -e 's/x/y/' a.txt"),
+			[]Access{write("/repo/main.go"), write("/repo/a.txt")}},
+		{bash("echo $HOME > $OUT"), nil},
+		{bash("curl -fsSL -o install.sh https://get.example.com/install.sh"),
+			[]Access{write("/repo/install.sh"), conn("get.example.com", 443)}},
+		{bash("wget http://mirror.local:8080/a.tgz"), []Access{conn("mirror.local", 8080)}},
+		{bash("git status && git diff"), nil},
+		{bash("git commit -am wip && git push origin main"), []Access{write("/repo"), {Kind: AccessNetwork}}},
+		{bash("git clone git@github.com:org/repo.git"), []Access{write("/repo"), conn("github.com", 22)}},
+		{bash("ssh -p 2222 deploy@prod.example.com uptime"), []Access{conn("prod.example.com", 2222)}},
+		{bash("npm ci && npm test"), []Access{write("/repo/node_modules"), conn("registry.npmjs.org", 443)}},
+		{bash("GOFLAGS=-mod=mod go get example.com/x && go build ./..."), []Access{conn("proxy.golang.org", 443)}},
+		{[]string{"touch", "a.txt"}, []Access{write("/repo/a.txt")}},
+		{[]string{"pip", "install", "-r", "requirements.txt"}, []Access{conn("pypi.org", 443)}},
+	} {
+		assert.Equal(t, tc.want, CommandAccesses("/repo", tc.command), "%q", tc.command)
+	}
+}
+
+func TestSandboxPolicy_Check(t *testing.T) {
+	readOnly := &SandboxPolicy{Mode: ModeReadOnly}
+	assert.Equal(t, "the read-only sandbox blocks writes", readOnly.Check(write("/repo/a.txt")))
+	assert.Empty(t, readOnly.Check(write("/tmp/a.txt")))
+	assert.Empty(t, readOnly.Check(write("/dev/null")))
+	assert.Equal(t, "network access is off", readOnly.Check(conn("example.com", 443)))
+
+	ws := &SandboxPolicy{
+		Mode:          ModeWorkspaceWrite,
+		WritableRoots: []WritableRoot{"/repo", "/cache/"},
+		NetworkAllow:  []string{"registry.npmjs.org", "*.pypi.org:443"},
+	}
+	assert.Empty(t, ws.Check(write("/repo")))
+	assert.Empty(t, ws.Check(write("/cache/x")))
+	assert.Equal(t, "outside the writable roots", ws.Check(write("/repository/x")))
+	assert.Equal(t, "outside the writable roots", ws.Check(write("~/.bashrc")))
+	assert.Empty(t, ws.Check(conn("registry.npmjs.org", 443)))
+	assert.Empty(t, ws.Check(conn("files.pypi.org", 0)), "unknown ports count as https")
+	assert.Equal(t, "not in network_allow", ws.Check(conn("files.pypi.org", 80)))
+	assert.Equal(t, "not in network_allow", ws.Check(Access{Kind: AccessNetwork}))
+
+	ws.NetworkAccess = true
+	assert.Empty(t, ws.Check(Access{Kind: AccessNetwork}))
+	assert.Empty(t, (&SandboxPolicy{Mode: ModeFullAccess}).Check(write("/etc/hosts")))
+	assert.Empty(t, (*SandboxPolicy)(nil).Check(write("/etc/hosts")))
+}
+
+func TestAccessTarget(t *testing.T) {
+	assert.Equal(t, "/repo/a", write("/repo/a").Target())
+	assert.Equal(t, "example.com:443", conn("example.com", 443).Target())
+	assert.Equal(t, "example.com", conn("example.com", 0).Target())
+	assert.Equal(t, "unknown host", Access{Kind: AccessNetwork}.Target())
+}
@@ -32,11 +32,14 @@ func (l *LinuxSandbox) Transform(spec CommandSpec, policy *SandboxPolicy) (*Exec
 		return nil, err
 	}
 
-	return &ExecEnv{
+	// Commands reach allowlisted hosts through the proxy. bwrap shares the
+	// host network, so clients that ignore the proxy variables are not
+	// blocked; CODEX_SANDBOX_NETWORK_DISABLED stays set for them.
+	return withNetworkProxy(&ExecEnv{
 		Command: cmd,
 		Cwd:     spec.Cwd,
 		Env:     env,
-	}, nil
+	}, policy)
 }
 
 // buildBwrapCommand constructs the bwrap command for the given policy.
@@ -24,3 +24,17 @@ func NewSandboxManager() SandboxManager {
 func NewNoopSandboxManager() SandboxManager {
 	return &NoopSandbox{}
 }
+
+// AvailableBackends names the platform sandboxes usable on this machine
+// ("bwrap" on Linux, "seatbelt" on macOS). Empty means commands can only run
+// unsandboxed.
+func AvailableBackends() []string {
+	var backends []string
+	if (&LinuxSandbox{}).Available() {
+		backends = append(backends, "bwrap")
+	}
+	if (&SeatbeltSandbox{}).Available() {
+		backends = append(backends, "seatbelt")
+	}
+	return backends
+}
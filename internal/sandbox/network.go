@@ -0,0 +1,142 @@
+package sandbox
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HostRule allows connections to one host, or to every subdomain of a
+// domain when Host starts with "*.".
+type HostRule struct {
+	Host string // Lower-case host name or IP; "*.example.com" for subdomains
+	Port int    // 0 = any port
+}
+
+// Allowlist is the set of hosts a sandboxed command may reach when network
+// access is otherwise off.
+type Allowlist []HostRule
+
+// ParseNetworkAllow parses allowlist entries of the form "host",
+// "host:port", "*.domain" or "*.domain:port".
+func ParseNetworkAllow(entries []string) (Allowlist, error) {
+	var out Allowlist
+	for _, entry := range entries {
+		e := strings.ToLower(strings.TrimSpace(entry))
+		if e == "" {
+			continue
+		}
+		if strings.Contains(e, "/") {
+			return nil, fmt.Errorf("invalid network allow entry %q: want host or host:port, not a URL", entry)
+		}
+		rule := HostRule{Host: e}
+		if host, port, err := net.SplitHostPort(e); err == nil {
+			n, err := strconv.Atoi(port)
+			if err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("invalid network allow entry %q: bad port", entry)
+			}
+			rule = HostRule{Host: host, Port: n}
+		}
+		rule.Host = strings.TrimSuffix(strings.Trim(rule.Host, "[]"), ".")
+		name := strings.TrimPrefix(rule.Host, "*.")
+		if name == "" || strings.Contains(name, "*") {
+			return nil, fmt.Errorf("invalid network allow entry %q: wildcards are only allowed as a leading \"*.\"", entry)
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+// Allows reports whether a connection to host:port is allowed.
+func (a Allowlist) Allows(host string, port int) bool {
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	for _, r := range a {
+		if r.Port != 0 && r.Port != port {
+			continue
+		}
+		if r.Host == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(r.Host, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// key identifies the allowlist regardless of entry order.
+func (a Allowlist) key() string {
+	parts := make([]string, len(a))
+	for i, r := range a {
+		parts[i] = net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+var (
+	proxiesMu sync.Mutex
+	proxies   = map[string]*Proxy{} // By Allowlist.key
+)
+
+// proxyFor returns the worker's proxy for allow, starting it on first use.
+// Proxies live as long as the worker.
+func proxyFor(allow Allowlist) (*Proxy, error) {
+	key := allow.key()
+	proxiesMu.Lock()
+	defer proxiesMu.Unlock()
+	if p, ok := proxies[key]; ok {
+		return p, nil
+	}
+	p, err := StartProxy(allow)
+	if err != nil {
+		return nil, err
+	}
+	proxies[key] = p
+	return p, nil
+}
+
+// NetworkProxy returns the proxy enforcing policy's network allowlist, or
+// nil when the policy has none.
+func NetworkProxy(policy *SandboxPolicy) (*Proxy, error) {
+	if !policy.HasNetworkAllowlist() {
+		return nil, nil
+	}
+	allow, err := ParseNetworkAllow(policy.NetworkAllow)
+	if err != nil {
+		return nil, err
+	}
+	return proxyFor(allow)
+}
+
+// NetworkProxyEnv returns the environment that routes a command's HTTP(S)
+// traffic through policy's allowlisting proxy, or nil when the policy has
+// no allowlist.
+func NetworkProxyEnv(policy *SandboxPolicy) (map[string]string, error) {
+	p, err := NetworkProxy(policy)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return p.Env(), nil
+}
+
+// withNetworkProxy adds policy's proxy environment to env.
+func withNetworkProxy(env *ExecEnv, policy *SandboxPolicy) (*ExecEnv, error) {
+	proxyEnv, err := NetworkProxyEnv(policy)
+	if err != nil {
+		return nil, err
+	}
+	if len(proxyEnv) == 0 {
+		return env, nil
+	}
+	if env.Env == nil {
+		env.Env = make(map[string]string, len(proxyEnv))
+	}
+	for k, v := range proxyEnv {
+		env.Env[k] = v
+	}
+	return env, nil
+}
@@ -0,0 +1,149 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworkAllow(t *testing.T) {
+	allow, err := ParseNetworkAllow([]string{" Registry.npmjs.org ", "*.pypi.org:443", "", "[::1]:8080"})
+	require.NoError(t, err)
+	assert.Equal(t, Allowlist{
+		{Host: "registry.npmjs.org"},
+		{Host: "*.pypi.org", Port: 443},
+		{Host: "::1", Port: 8080},
+	}, allow)
+
+	for _, bad := range []string{"https://example.com", "example.com:0", "*", "a.*.com", "example.com:http"} {
+		_, err := ParseNetworkAllow([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestAllowlist_Allows(t *testing.T) {
+	allow, err := ParseNetworkAllow([]string{"registry.npmjs.org", "*.pypi.org:443"})
+	require.NoError(t, err)
+
+	assert.True(t, allow.Allows("registry.npmjs.org", 443))
+	assert.True(t, allow.Allows("REGISTRY.npmjs.org.", 80))
+	assert.True(t, allow.Allows("files.pypi.org", 443))
+	assert.False(t, allow.Allows("files.pypi.org", 80), "port must match")
+	assert.False(t, allow.Allows("pypi.org", 443), "wildcards match subdomains only")
+	assert.False(t, allow.Allows("evilpypi.org", 443))
+	assert.False(t, allow.Allows("example.com", 443))
+}
+
+func TestSandboxPolicy_HasNetworkAllowlist(t *testing.T) {
+	assert.True(t, (&SandboxPolicy{Mode: ModeReadOnly, NetworkAllow: []string{"a.com"}}).HasNetworkAllowlist())
+	assert.False(t, (&SandboxPolicy{Mode: ModeReadOnly, NetworkAccess: true, NetworkAllow: []string{"a.com"}}).HasNetworkAllowlist())
+	assert.False(t, (&SandboxPolicy{Mode: ModeFullAccess, NetworkAllow: []string{"a.com"}}).HasNetworkAllowlist())
+	assert.False(t, (&SandboxPolicy{Mode: ModeReadOnly}).HasNetworkAllowlist())
+	assert.False(t, (*SandboxPolicy)(nil).HasNetworkAllowlist())
+}
+
+// startProxy starts a proxy allowing only the upstream server's port on
+// 127.0.0.1.
+func startProxy(t *testing.T, upstream *httptest.Server) *Proxy {
+	t.Helper()
+	u, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+	port, _ := strconv.Atoi(u.Port())
+	p, err := StartProxy(Allowlist{{Host: "127.0.0.1", Port: port}})
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestProxy_ForwardsAllowedHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello from upstream")
+	}))
+	defer upstream.Close()
+	p := startProxy(t, upstream)
+
+	proxyURL, _ := url.Parse("http://" + p.Addr())
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	resp, err := client.Get(upstream.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello from upstream", string(body))
+
+	resp, err = client.Get("http://example.com/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, string(body), "blocked by sandbox network policy: example.com:80")
+}
+
+func TestProxy_TunnelsAllowedConnect(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secure hello")
+	}))
+	defer upstream.Close()
+	p := startProxy(t, upstream)
+
+	proxyURL, _ := url.Parse("http://" + p.Addr())
+	transport := upstream.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	resp, err := (&http.Client{Transport: transport}).Get(upstream.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "secure hello", string(body))
+}
+
+func TestProxy_RefusesDisallowedConnect(t *testing.T) {
+	p, err := StartProxy(Allowlist{{Host: "registry.npmjs.org"}})
+	require.NoError(t, err)
+	defer p.Close()
+
+	conn, err := net.Dial("tcp", p.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+	fmt.Fprint(conn, "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestNetworkProxyEnv(t *testing.T) {
+	env, err := NetworkProxyEnv(&SandboxPolicy{Mode: ModeWorkspaceWrite, NetworkAccess: true, NetworkAllow: []string{"a.com"}})
+	require.NoError(t, err)
+	assert.Nil(t, env, "no proxy when the network is open")
+
+	policy := &SandboxPolicy{Mode: ModeWorkspaceWrite, NetworkAllow: []string{"a.com", "b.com:443"}}
+	env, err = NetworkProxyEnv(policy)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(env["HTTPS_PROXY"], "http://127.0.0.1:"))
+	assert.Equal(t, env["HTTPS_PROXY"], env["http_proxy"])
+	assert.Equal(t, "", env["NO_PROXY"])
+
+	again, err := NetworkProxyEnv(&SandboxPolicy{Mode: ModeReadOnly, NetworkAllow: []string{"b.com:443", "A.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, env["HTTPS_PROXY"], again["HTTPS_PROXY"], "equal allowlists share a proxy")
+
+	_, err = NetworkProxyEnv(&SandboxPolicy{Mode: ModeReadOnly, NetworkAllow: []string{"http://a.com"}})
+	assert.Error(t, err)
+}
+
+func TestNoopSandbox_Transform_NetworkAllow(t *testing.T) {
+	env, err := (&NoopSandbox{}).Transform(CommandSpec{Program: "curl"},
+		&SandboxPolicy{Mode: ModeReadOnly, NetworkAllow: []string{"registry.npmjs.org"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"curl"}, env.Command)
+	assert.NotEmpty(t, env.Env["HTTPS_PROXY"])
+}
@@ -4,13 +4,14 @@ package sandbox
 // Used when sandbox mode is full-access or when no sandbox is available.
 type NoopSandbox struct{}
 
-// Transform returns the command unchanged.
+// Transform returns the command unchanged. A restricted policy's network
+// allowlist is still applied through the proxy environment.
 func (n *NoopSandbox) Transform(spec CommandSpec, policy *SandboxPolicy) (*ExecEnv, error) {
-	return &ExecEnv{
+	return withNetworkProxy(&ExecEnv{
 		Command: append([]string{spec.Program}, spec.Args...),
 		Cwd:     spec.Cwd,
 		Env:     nil,
-	}, nil
+	}, policy)
 }
 
 // Available always returns true (no-op is always available).
@@ -0,0 +1,169 @@
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// proxyDialTimeout bounds connecting to an allowed upstream host.
+const proxyDialTimeout = 30 * time.Second
+
+// Proxy is a local HTTP proxy that only connects to allowlisted hosts. It
+// tunnels CONNECT requests (HTTPS) and forwards absolute-URL HTTP requests;
+// anything else is refused with 403.
+type Proxy struct {
+	allow     Allowlist
+	ln        net.Listener
+	srv       *http.Server
+	transport *http.Transport
+}
+
+// StartProxy starts a proxy for allow on a loopback port.
+func StartProxy(allow Allowlist) (*Proxy, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("start network proxy: %w", err)
+	}
+	dialer := &net.Dialer{Timeout: proxyDialTimeout}
+	p := &Proxy{
+		allow:     allow,
+		ln:        ln,
+		transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+	p.srv = &http.Server{Handler: p, ReadHeaderTimeout: proxyDialTimeout}
+	go func() { _ = p.srv.Serve(ln) }()
+	return p, nil
+}
+
+// Addr returns the proxy's host:port.
+func (p *Proxy) Addr() string {
+	return p.ln.Addr().String()
+}
+
+// Port returns the proxy's loopback port.
+func (p *Proxy) Port() int {
+	return p.ln.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the proxy.
+func (p *Proxy) Close() error {
+	p.transport.CloseIdleConnections()
+	return p.srv.Close()
+}
+
+// Env returns the variables that point HTTP clients at the proxy. NO_PROXY
+// is cleared so no host bypasses it.
+func (p *Proxy) Env() map[string]string {
+	url := "http://" + p.Addr()
+	return map[string]string{
+		"HTTP_PROXY":  url,
+		"HTTPS_PROXY": url,
+		"ALL_PROXY":   url,
+		"http_proxy":  url,
+		"https_proxy": url,
+		"all_proxy":   url,
+		"NO_PROXY":    "",
+		"no_proxy":    "",
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() || (r.URL.Scheme != "http" && r.URL.Scheme != "https") {
+		http.Error(w, "sandbox network proxy: absolute http(s) URL required", http.StatusBadRequest)
+		return
+	}
+	host, port := splitHostPort(r.URL.Host, r.URL.Scheme)
+	if !p.allow.Allows(host, port) {
+		deny(w, host, port)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "sandbox network proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// tunnel serves a CONNECT request by splicing the client connection to the
+// upstream host.
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	host, port := splitHostPort(r.Host, "https")
+	if !p.allow.Allows(host, port) {
+		deny(w, host, port)
+		return
+	}
+	upstream, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), proxyDialTimeout)
+	if err != nil {
+		http.Error(w, "sandbox network proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "sandbox network proxy: tunneling unsupported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	_, _ = client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+	done := make(chan struct{})
+	go func() {
+		// Bytes the client sent after the CONNECT line are already buffered.
+		_, _ = io.Copy(upstream, buf)
+		if tcp, ok := upstream.(*net.TCPConn); ok {
+			_ = tcp.CloseWrite()
+		}
+		close(done)
+	}()
+	_, _ = io.Copy(client, upstream)
+	client.Close()
+	<-done
+	upstream.Close()
+}
+
+// deny refuses a request to a host outside the allowlist.
+func deny(w http.ResponseWriter, host string, port int) {
+	http.Error(w, fmt.Sprintf("blocked by sandbox network policy: %s:%d is not in network_allow", host, port), http.StatusForbidden)
+}
+
+// splitHostPort splits hostport, using the scheme's default port when it
+// has none.
+func splitHostPort(hostport, scheme string) (string, int) {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.Trim(hostport, "[]")
+		if scheme == "https" {
+			return host, 443
+		}
+		return host, 80
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
@@ -29,7 +29,17 @@ func (s *SeatbeltSandbox) Transform(spec CommandSpec, policy *SandboxPolicy) (*E
 		}, nil
 	}
 
-	sbpl := generateSBPL(policy)
+	proxy, err := NetworkProxy(policy)
+	if err != nil {
+		return nil, err
+	}
+	proxyPort := 0
+	var env map[string]string
+	if proxy != nil {
+		proxyPort = proxy.Port()
+		env = proxy.Env()
+	}
+	sbpl := generateSBPL(policy, proxyPort)
 
 	// Build sandbox-exec command
 	cmd := []string{"/usr/bin/sandbox-exec", "-p", sbpl, "--", spec.Program}
@@ -38,13 +48,16 @@ func (s *SeatbeltSandbox) Transform(spec CommandSpec, policy *SandboxPolicy) (*E
 	return &ExecEnv{
 		Command: cmd,
 		Cwd:     spec.Cwd,
+		Env:     env,
 	}, nil
 }
 
-// generateSBPL generates a Seatbelt Profile Language policy string.
+// generateSBPL generates a Seatbelt Profile Language policy string. With
+// network access off and proxyPort set, the only connections allowed are to
+// the allowlisting proxy on that loopback port.
 //
 // Maps to: codex-rs/core/src/sandbox/seatbelt.rs generate_sbpl
-func generateSBPL(policy *SandboxPolicy) string {
+func generateSBPL(policy *SandboxPolicy, proxyPort int) string {
 	var sb strings.Builder
 	sb.WriteString("(version 1)\n")
 
@@ -82,6 +95,9 @@ func generateSBPL(policy *SandboxPolicy) string {
 
 	if !policy.NetworkAccess {
 		sb.WriteString("(deny network*)\n")
+		if proxyPort != 0 {
+			sb.WriteString(fmt.Sprintf("(allow network-outbound (remote ip \"localhost:%d\"))\n", proxyPort))
+		}
 	} else {
 		sb.WriteString("(allow network*)\n")
 	}
@@ -90,6 +106,6 @@ func generateSBPL(policy *SandboxPolicy) string {
 }
 
 // GenerateSBPL is exported for testing.
-func GenerateSBPL(policy *SandboxPolicy) string {
-	return generateSBPL(policy)
+func GenerateSBPL(policy *SandboxPolicy, proxyPort int) string {
+	return generateSBPL(policy, proxyPort)
 }
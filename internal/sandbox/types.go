@@ -44,6 +44,11 @@ type SandboxPolicy struct {
 	Mode          SandboxMode   `json:"mode"`
 	WritableRoots []WritableRoot `json:"writable_roots,omitempty"`
 	NetworkAccess bool          `json:"network_access"`
+
+	// NetworkAllow lists the hosts ("host", "host:port", "*.domain")
+	// commands may still reach when NetworkAccess is false, through an
+	// allowlisting HTTP(S) proxy (see NetworkProxy).
+	NetworkAllow []string `json:"network_allow,omitempty"`
 }
 
 // IsRestricted returns true if the policy restricts execution in any way.
@@ -51,6 +56,12 @@ func (p *SandboxPolicy) IsRestricted() bool {
 	return p != nil && p.Mode != ModeFullAccess && p.Mode != ""
 }
 
+// HasNetworkAllowlist returns true if the policy blocks the network except
+// for the hosts in NetworkAllow.
+func (p *SandboxPolicy) HasNetworkAllowlist() bool {
+	return p.IsRestricted() && !p.NetworkAccess && len(p.NetworkAllow) > 0
+}
+
 // CommandSpec describes a command to be executed.
 type CommandSpec struct {
 	Program string   // e.g., "bash"
@@ -1,12 +1,14 @@
 // Package shell provides user-shell detection and command argument derivation.
 //
 // Maps to: codex-rs/core/src/shell.rs
-// Linux-only (no PowerShell/Cmd support needed).
+// Unix workers use bash/zsh/sh; Windows workers use PowerShell or cmd.exe.
 package shell
 
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
 // ShellType enumerates the supported shell flavours.
@@ -18,6 +20,8 @@ const (
 	ShellTypeBash ShellType = iota
 	ShellTypeZsh
 	ShellTypeSh
+	ShellTypePowerShell
+	ShellTypeCmd
 )
 
 // Shell represents a detected shell with its binary path.
@@ -28,7 +32,8 @@ type Shell struct {
 	Path string
 }
 
-// Name returns the short name of the shell ("bash", "zsh", "sh").
+// Name returns the short name of the shell ("bash", "zsh", "sh",
+// "powershell", "cmd").
 func (s *Shell) Name() string {
 	switch s.Type {
 	case ShellTypeBash:
@@ -37,17 +42,32 @@ func (s *Shell) Name() string {
 		return "zsh"
 	case ShellTypeSh:
 		return "sh"
+	case ShellTypePowerShell:
+		return "powershell"
+	case ShellTypeCmd:
+		return "cmd"
 	default:
 		return "sh"
 	}
 }
 
 // DeriveExecArgs builds the argument vector used to execute a command string
-// through this shell. When useLoginShell is true the shell is invoked with -lc
-// (login + command); otherwise with -c only.
+// through this shell. When useLoginShell is true a POSIX shell is invoked with
+// -lc (login + command); otherwise with -c only. PowerShell loads the user's
+// profile only for login invocations (-NoProfile otherwise); cmd.exe has no
+// login mode and always runs /c.
 //
 // Maps to: codex-rs/core/src/shell.rs Shell::derive_exec_args
 func (s *Shell) DeriveExecArgs(command string, useLoginShell bool) []string {
+	switch s.Type {
+	case ShellTypePowerShell:
+		if useLoginShell {
+			return []string{s.Path, "-Command", command}
+		}
+		return []string{s.Path, "-NoProfile", "-Command", command}
+	case ShellTypeCmd:
+		return []string{s.Path, "/c", command}
+	}
 	if useLoginShell {
 		return []string{s.Path, "-lc", command}
 	}
@@ -56,10 +76,16 @@ func (s *Shell) DeriveExecArgs(command string, useLoginShell bool) []string {
 
 // DetectShellType maps a shell binary path (or bare name) to a ShellType.
 // Returns the type and true on success, or (0, false) for unknown shells.
+// Windows paths and ".exe" suffixes are accepted on every platform so a
+// shell named in tool arguments is classified the same way everywhere.
 //
 // Maps to: codex-rs/core/src/shell.rs detect_shell_type
 func DetectShellType(shellPath string) (ShellType, bool) {
-	base := filepath.Base(shellPath)
+	base := shellPath
+	if i := strings.LastIndexAny(base, `/\`); i >= 0 {
+		base = base[i+1:]
+	}
+	base = strings.TrimSuffix(strings.ToLower(base), ".exe")
 	switch base {
 	case "bash":
 		return ShellTypeBash, true
@@ -67,16 +93,29 @@ func DetectShellType(shellPath string) (ShellType, bool) {
 		return ShellTypeZsh, true
 	case "sh":
 		return ShellTypeSh, true
+	case "pwsh", "powershell":
+		return ShellTypePowerShell, true
+	case "cmd":
+		return ShellTypeCmd, true
 	default:
 		return 0, false
 	}
 }
 
 // DetectUserShell returns the user's default shell by reading $SHELL.
-// Falls back to bash, then sh if $SHELL is unset or unrecognised.
+// Falls back to bash, then sh if $SHELL is unset or unrecognised. On Windows
+// it prefers PowerShell 7 (pwsh), then Windows PowerShell, then %COMSPEC%.
 //
 // Maps to: codex-rs/core/src/shell.rs detect_user_shell
 func DetectUserShell() *Shell {
+	return detectUserShell(runtime.GOOS)
+}
+
+func detectUserShell(goos string) *Shell {
+	if goos == "windows" {
+		return detectWindowsShell()
+	}
+
 	shellEnv := os.Getenv("SHELL")
 	if shellEnv != "" {
 		if st, ok := DetectShellType(shellEnv); ok {
@@ -101,6 +140,21 @@ func DetectUserShell() *Shell {
 	return &Shell{Type: ShellTypeSh, Path: "/bin/sh"}
 }
 
+// detectWindowsShell picks the shell used on Windows workers. $SHELL is
+// ignored: when set (Git Bash, MSYS) it names a POSIX path the worker's
+// processes cannot necessarily resolve.
+func detectWindowsShell() *Shell {
+	for _, name := range []string{"pwsh.exe", "powershell.exe"} {
+		if p, err := lookPath(name); err == nil {
+			return &Shell{Type: ShellTypePowerShell, Path: p}
+		}
+	}
+	if comspec := os.Getenv("COMSPEC"); comspec != "" {
+		return &Shell{Type: ShellTypeCmd, Path: comspec}
+	}
+	return &Shell{Type: ShellTypeCmd, Path: "cmd.exe"}
+}
+
 // lookPath is a thin wrapper around exec.LookPath, declared as a var so tests
 // can override it without touching the filesystem.
 var lookPath = defaultLookPath
@@ -166,3 +166,70 @@ func TestDetectUserShell_UnknownShellFallback(t *testing.T) {
 	assert.Equal(t, ShellTypeBash, s.Type)
 	assert.Equal(t, "/usr/bin/bash", s.Path)
 }
+
+// ---------------------------------------------------------------------------
+// Windows shells
+// ---------------------------------------------------------------------------
+
+func TestDetectShellType_Windows(t *testing.T) {
+	cases := map[string]ShellType{
+		"pwsh":                                   ShellTypePowerShell,
+		"pwsh.exe":                               ShellTypePowerShell,
+		`C:\Program Files\PowerShell\7\pwsh.exe`: ShellTypePowerShell,
+		`C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`: ShellTypePowerShell,
+		`C:\Windows\system32\cmd.exe`:                               ShellTypeCmd,
+		"CMD.EXE":                                                   ShellTypeCmd,
+	}
+	for path, want := range cases {
+		st, ok := DetectShellType(path)
+		require.True(t, ok, path)
+		assert.Equal(t, want, st, path)
+	}
+}
+
+func TestDeriveExecArgs_PowerShell(t *testing.T) {
+	s := &Shell{Type: ShellTypePowerShell, Path: "pwsh.exe"}
+	assert.Equal(t, []string{"pwsh.exe", "-Command", "Get-ChildItem"}, s.DeriveExecArgs("Get-ChildItem", true))
+	assert.Equal(t, []string{"pwsh.exe", "-NoProfile", "-Command", "Get-ChildItem"}, s.DeriveExecArgs("Get-ChildItem", false))
+}
+
+func TestDeriveExecArgs_Cmd(t *testing.T) {
+	s := &Shell{Type: ShellTypeCmd, Path: `C:\Windows\system32\cmd.exe`}
+	assert.Equal(t, []string{`C:\Windows\system32\cmd.exe`, "/c", "dir"}, s.DeriveExecArgs("dir", true))
+	assert.Equal(t, []string{`C:\Windows\system32\cmd.exe`, "/c", "dir"}, s.DeriveExecArgs("dir", false))
+}
+
+func TestShellName_Windows(t *testing.T) {
+	assert.Equal(t, "powershell", (&Shell{Type: ShellTypePowerShell}).Name())
+	assert.Equal(t, "cmd", (&Shell{Type: ShellTypeCmd}).Name())
+}
+
+func TestDetectUserShell_WindowsPrefersPwsh(t *testing.T) {
+	t.Setenv("SHELL", "/usr/bin/bash")
+	origLookPath := lookPath
+	defer func() { lookPath = origLookPath }()
+	lookPath = func(name string) (string, error) {
+		switch name {
+		case "pwsh.exe":
+			return `C:\Program Files\PowerShell\7\pwsh.exe`, nil
+		case "powershell.exe":
+			return `C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`, nil
+		}
+		return "", os.ErrNotExist
+	}
+
+	s := detectUserShell("windows")
+	assert.Equal(t, ShellTypePowerShell, s.Type)
+	assert.Equal(t, `C:\Program Files\PowerShell\7\pwsh.exe`, s.Path)
+}
+
+func TestDetectUserShell_WindowsFallsBackToComspec(t *testing.T) {
+	t.Setenv("COMSPEC", `C:\Windows\system32\cmd.exe`)
+	origLookPath := lookPath
+	defer func() { lookPath = origLookPath }()
+	lookPath = func(string) (string, error) { return "", os.ErrNotExist }
+
+	s := detectUserShell("windows")
+	assert.Equal(t, ShellTypeCmd, s.Type)
+	assert.Equal(t, `C:\Windows\system32\cmd.exe`, s.Path)
+}
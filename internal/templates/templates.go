@@ -0,0 +1,213 @@
+// Package templates loads session templates: canned conversation scaffolds
+// kept as YAML in <codex_home>/templates/sessions/<name>.yaml. A template
+// predefines the initial user message (with {var} placeholders), the model,
+// extra tools, and follow-up steps.
+//
+// Example bugfix.yaml:
+//
+//	description: Reproduce and fix a GitHub issue
+//	model: claude-sonnet-4-0
+//	tools: [github]
+//	vars:
+//	  issue:
+//	    description: Issue number
+//	  branch:
+//	    default: main
+//	message: |
+//	  Fix GitHub issue {issue}. Work on a branch off {branch}.
+//	follow_ups:
+//	  - Add a regression test for issue {issue}.
+//	  - Summarize the root cause.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// DirName is the template directory relative to codex home.
+const DirName = "templates/sessions"
+
+// SessionTemplate is a parsed session template.
+type SessionTemplate struct {
+	Name        string             `yaml:"name"` // Defaults to the file name
+	Description string             `yaml:"description"`
+	Message     string             `yaml:"message"`
+	Model       string             `yaml:"model"`
+	Provider    string             `yaml:"provider"`
+	Tools       []string           `yaml:"tools"`      // Tools or tool groups to enable, e.g. "github"
+	Vars        map[string]VarSpec `yaml:"vars"`       // Placeholders usable as {name}
+	FollowUps   []string           `yaml:"follow_ups"` // Steps appended to the message as a checklist
+}
+
+// VarSpec describes one template variable.
+type VarSpec struct {
+	Description string `yaml:"description"`
+	Required    *bool  `yaml:"required"` // Default: true unless Default is set
+	Default     string `yaml:"default"`
+}
+
+// IsRequired reports whether the variable must be given.
+func (v VarSpec) IsRequired() bool {
+	if v.Required == nil {
+		return v.Default == ""
+	}
+	return *v.Required
+}
+
+// Dir returns the session template directory under codexHome.
+func Dir(codexHome string) string {
+	return filepath.Join(codexHome, DirName)
+}
+
+// Parse parses a template. name is used when the YAML does not set one.
+func Parse(name string, data []byte) (*SessionTemplate, error) {
+	var t SessionTemplate
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse template %q: %w", name, err)
+	}
+	if t.Name == "" {
+		t.Name = name
+	}
+	if strings.TrimSpace(t.Message) == "" {
+		return nil, fmt.Errorf("template %q: message is required", t.Name)
+	}
+	return &t, nil
+}
+
+// Load reads the template called name from codexHome.
+func Load(codexHome, name string) (*SessionTemplate, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(Dir(codexHome), name+ext)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read template: %w", err)
+		}
+		return Parse(name, data)
+	}
+	return nil, fmt.Errorf("template %q not found in %s", name, Dir(codexHome))
+}
+
+// List loads every template in codexHome, sorted by name. Templates that
+// fail to parse are returned as errors alongside the rest.
+func List(codexHome string) ([]*SessionTemplate, []error) {
+	entries, err := os.ReadDir(Dir(codexHome))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("read template directory: %w", err)}
+	}
+	var (
+		list []*SessionTemplate
+		errs []error
+	)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(Dir(codexHome), entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t, err := Parse(strings.TrimSuffix(entry.Name(), ext), data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, errs
+}
+
+// VarNames returns the template's variable names, sorted.
+func (t *SessionTemplate) VarNames() []string {
+	names := make([]string, 0, len(t.Vars))
+	for name := range t.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Expand returns the initial user message with vars substituted and the
+// follow-up steps appended. Every required variable must be given, and
+// unknown variables are rejected so typos don't go unnoticed.
+func (t *SessionTemplate) Expand(vars map[string]string) (string, error) {
+	var unknown, missing []string
+	for name := range vars {
+		if _, ok := t.Vars[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	values := make(map[string]string, len(t.Vars))
+	for name, spec := range t.Vars {
+		if v, ok := vars[name]; ok {
+			values[name] = v
+		} else if !spec.IsRequired() {
+			values[name] = spec.Default
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("template %q has no variable %s (variables: %s)",
+			t.Name, strings.Join(unknown, ", "), strings.Join(t.VarNames(), ", "))
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("template %q needs --var for: %s", t.Name, strings.Join(missing, ", "))
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(models.Interpolate(t.Message, values)))
+	if len(t.FollowUps) > 0 {
+		b.WriteString("\n\nFollow-up steps, once the above is done:")
+		for i, step := range t.FollowUps {
+			fmt.Fprintf(&b, "\n%d. %s", i+1, strings.TrimSpace(models.Interpolate(step, values)))
+		}
+	}
+	return b.String(), nil
+}
+
+// VarFlags collects repeated --var name=value flags.
+type VarFlags map[string]string
+
+// String implements flag.Value.
+func (v VarFlags) String() string {
+	pairs := make([]string, 0, len(v))
+	for name, value := range v {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value.
+func (v VarFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid --var %q (expected name=value)", s)
+	}
+	v[name] = value
+	return nil
+}
@@ -0,0 +1,98 @@
+package templates
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bugfixTemplate = `description: Reproduce and fix a GitHub issue
+model: claude-sonnet-4-0
+tools: [github]
+vars:
+  issue:
+    description: Issue number
+  branch:
+    default: main
+  notes:
+    required: false
+message: |
+  Fix issue #{issue}. Work on a branch off {branch}.{notes}
+follow_ups:
+  - "Add a regression test for #{issue}."
+  - Summarize the root cause.
+`
+
+func writeTemplate(t *testing.T, codexHome, file, content string) {
+	t.Helper()
+	dir := Dir(codexHome)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644))
+}
+
+func TestLoadAndExpand(t *testing.T) {
+	home := t.TempDir()
+	writeTemplate(t, home, "bugfix.yaml", bugfixTemplate)
+
+	tmpl, err := Load(home, "bugfix")
+	require.NoError(t, err)
+	assert.Equal(t, "bugfix", tmpl.Name)
+	assert.Equal(t, "claude-sonnet-4-0", tmpl.Model)
+	assert.Equal(t, []string{"github"}, tmpl.Tools)
+	assert.Equal(t, []string{"branch", "issue", "notes"}, tmpl.VarNames())
+
+	msg, err := tmpl.Expand(map[string]string{"issue": "1234"})
+	require.NoError(t, err)
+	assert.Equal(t, "Fix issue #1234. Work on a branch off main.\n\n"+
+		"Follow-up steps, once the above is done:\n"+
+		"1. Add a regression test for #1234.\n"+
+		"2. Summarize the root cause.", msg)
+
+	_, err = tmpl.Expand(nil)
+	assert.EqualError(t, err, `template "bugfix" needs --var for: issue`)
+	_, err = tmpl.Expand(map[string]string{"issue": "1", "isue": "2"})
+	assert.EqualError(t, err, `template "bugfix" has no variable isue (variables: branch, issue, notes)`)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	home := t.TempDir()
+	writeTemplate(t, home, "empty.yml", "description: no message\n")
+
+	_, err := Load(home, "missing")
+	assert.ErrorContains(t, err, `template "missing" not found`)
+	_, err = Load(home, "../secrets")
+	assert.ErrorContains(t, err, "invalid template name")
+	_, err = Load(home, "empty")
+	assert.ErrorContains(t, err, "message is required")
+}
+
+func TestList(t *testing.T) {
+	home := t.TempDir()
+	list, errs := List(home)
+	assert.Empty(t, list)
+	assert.Empty(t, errs)
+
+	writeTemplate(t, home, "review.yml", "name: review\nmessage: Review the diff\n")
+	writeTemplate(t, home, "bugfix.yaml", bugfixTemplate)
+	writeTemplate(t, home, "broken.yaml", "message: [unclosed\n")
+	writeTemplate(t, home, "README.md", "not a template")
+
+	list, errs = List(home)
+	require.Len(t, list, 2)
+	assert.Equal(t, "bugfix", list[0].Name)
+	assert.Equal(t, "review", list[1].Name)
+	assert.Len(t, errs, 1)
+}
+
+func TestVarFlags(t *testing.T) {
+	vars := VarFlags{}
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	fs.Var(vars, "var", "")
+	require.NoError(t, fs.Parse([]string{"--var", "issue=1234", "--var=title=a=b"}))
+	assert.Equal(t, VarFlags{"issue": "1234", "title": "a=b"}, vars)
+	assert.Error(t, vars.Set("novalue"))
+}
@@ -0,0 +1,128 @@
+package temporalclient
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Environment variables naming the caller to sessions with access control.
+// TCX_CALLER_KEY is the secret the workers share with whatever
+// authenticates callers; without it the identity is only a claim.
+const (
+	EnvCallerIdentity = "TCX_IDENTITY"
+	EnvCallerKey      = "TCX_CALLER_KEY"
+	EnvSessionToken   = "TCX_SESSION_TOKEN"
+)
+
+// CallerIdentity returns TCX_IDENTITY, defaulting to user@host.
+func CallerIdentity() string {
+	if id := os.Getenv(EnvCallerIdentity); id != "" {
+		return id
+	}
+	name := "unknown"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return name
+	}
+	return name + "@" + host
+}
+
+// callerInterceptor stamps a proof of the caller's identity and token on
+// the workflows it starts and the Updates and signals it sends, so the
+// worker can check them against a session's access control. Neither the
+// caller key nor the token is sent.
+type callerInterceptor struct {
+	interceptor.ClientInterceptorBase
+	identity  string
+	callerKey string
+	token     string
+}
+
+// NewCallerInterceptor returns a client interceptor proving identity
+// (signed with callerKey) and token with every workflow start, Update and
+// signal. Either secret may be empty.
+func NewCallerInterceptor(identity, callerKey, token string) interceptor.ClientInterceptor {
+	return &callerInterceptor{identity: identity, callerKey: callerKey, token: token}
+}
+
+func (c *callerInterceptor) InterceptClient(next interceptor.ClientOutboundInterceptor) interceptor.ClientOutboundInterceptor {
+	return &callerOutbound{ClientOutboundInterceptorBase: interceptor.ClientOutboundInterceptorBase{Next: next}, root: c}
+}
+
+type callerOutbound struct {
+	interceptor.ClientOutboundInterceptorBase
+	root *callerInterceptor
+}
+
+func (c *callerOutbound) ExecuteWorkflow(ctx context.Context, in *interceptor.ClientExecuteWorkflowInput) (client.WorkflowRun, error) {
+	if err := c.stamp(ctx, models.CallerStartProofHeader, in.Options.ID, models.CallerStartName); err != nil {
+		return nil, err
+	}
+	return c.Next.ExecuteWorkflow(ctx, in)
+}
+
+func (c *callerOutbound) UpdateWorkflow(ctx context.Context, in *interceptor.ClientUpdateWorkflowInput) (client.WorkflowUpdateHandle, error) {
+	if err := c.stamp(ctx, models.CallerProofHeader, in.WorkflowID, in.UpdateName); err != nil {
+		return nil, err
+	}
+	return c.Next.UpdateWorkflow(ctx, in)
+}
+
+// UpdateWithStartWorkflow stamps both proofs on the one header the start
+// and the Update are sent with. The workflow ID is taken from the Update
+// options, which callers must set.
+func (c *callerOutbound) UpdateWithStartWorkflow(ctx context.Context, in *interceptor.ClientUpdateWithStartWorkflowInput) (client.WorkflowUpdateHandle, error) {
+	id := in.UpdateOptions.WorkflowID
+	if err := c.stamp(ctx, models.CallerStartProofHeader, id, models.CallerStartName); err != nil {
+		return nil, err
+	}
+	if err := c.stamp(ctx, models.CallerProofHeader, id, in.UpdateOptions.UpdateName); err != nil {
+		return nil, err
+	}
+	return c.Next.UpdateWithStartWorkflow(ctx, in)
+}
+
+func (c *callerOutbound) SignalWorkflow(ctx context.Context, in *interceptor.ClientSignalWorkflowInput) error {
+	if err := c.stamp(ctx, models.CallerProofHeader, in.WorkflowID, in.SignalName); err != nil {
+		return err
+	}
+	return c.Next.SignalWorkflow(ctx, in)
+}
+
+func (c *callerOutbound) SignalWithStartWorkflow(ctx context.Context, in *interceptor.ClientSignalWithStartWorkflowInput) (client.WorkflowRun, error) {
+	if err := c.stamp(ctx, models.CallerStartProofHeader, in.Options.ID, models.CallerStartName); err != nil {
+		return nil, err
+	}
+	if err := c.stamp(ctx, models.CallerProofHeader, in.Options.ID, in.SignalName); err != nil {
+		return nil, err
+	}
+	return c.Next.SignalWithStartWorkflow(ctx, in)
+}
+
+// stamp writes the caller's proof for sending name to workflowID under
+// key. Header payloads bypass the payload codec and are visible in
+// history, which is why only the proof is sent.
+func (c *callerOutbound) stamp(ctx context.Context, key, workflowID, name string) error {
+	header := interceptor.Header(ctx)
+	if header == nil || workflowID == "" || (c.root.identity == "" && c.root.token == "") {
+		return nil
+	}
+	proof := models.SignCallerProof(c.root.identity, c.root.callerKey, c.root.token, workflowID, name, time.Now())
+	p, err := converter.GetDefaultDataConverter().ToPayload(proof)
+	if err != nil {
+		return err
+	}
+	header[key] = p
+	return nil
+}
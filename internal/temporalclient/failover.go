@@ -0,0 +1,282 @@
+package temporalclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// Environment variables naming the standby endpoint. Either one enables
+// failover; the other defaults to the primary's value.
+const (
+	EnvStandbyHostURL   = "TEMPORAL_STANDBY_HOST_URL"
+	EnvStandbyNamespace = "TEMPORAL_STANDBY_NAMESPACE"
+)
+
+const (
+	// defaultFailoverAfter bounds a read against the primary before it is
+	// retried on the standby.
+	defaultFailoverAfter = 5 * time.Second
+
+	// defaultProbeInterval is how often reads re-check a primary that is
+	// known to be down.
+	defaultProbeInterval = 15 * time.Second
+
+	// probeTimeout bounds a primary health check.
+	probeTimeout = 3 * time.Second
+)
+
+// LoadStandbyClientOptions returns client options for the standby endpoint,
+// derived from the primary's options so TLS, credentials and the data
+// converter carry over. The standby comes from hostPortOverride and
+// namespaceOverride, falling back to TEMPORAL_STANDBY_HOST_URL and
+// TEMPORAL_STANDBY_NAMESPACE. ok is false when no standby is configured.
+func LoadStandbyClientOptions(primary client.Options, hostPortOverride, namespaceOverride string) (opts client.Options, ok bool) {
+	host := hostPortOverride
+	if host == "" {
+		host = os.Getenv(EnvStandbyHostURL)
+	}
+	namespace := namespaceOverride
+	if namespace == "" {
+		namespace = os.Getenv(EnvStandbyNamespace)
+	}
+	if host == "" && namespace == "" {
+		return client.Options{}, false
+	}
+
+	opts = primary
+	if host != "" {
+		opts.HostPort = host
+	}
+	if namespace != "" {
+		opts.Namespace = namespace
+	}
+	return opts, true
+}
+
+// DialFailover connects to the primary and, when a standby is configured,
+// wraps the connection in a FailoverClient. The standby is connected
+// lazily, so an unreachable standby does not block startup.
+func DialFailover(primary client.Options, hostPortOverride, namespaceOverride string) (client.Client, error) {
+	c, err := client.Dial(primary)
+	if err != nil {
+		return nil, err
+	}
+	standbyOpts, ok := LoadStandbyClientOptions(primary, hostPortOverride, namespaceOverride)
+	if !ok {
+		return c, nil
+	}
+	standby, err := client.NewLazyClient(standbyOpts)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("standby Temporal client: %w", err)
+	}
+	return NewFailoverClient(c, standby, endpointName(standbyOpts)), nil
+}
+
+// endpointName describes an endpoint for user-facing messages.
+func endpointName(opts client.Options) string {
+	host := opts.HostPort
+	if host == "" {
+		host = client.DefaultHostPort
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = client.DefaultNamespace
+	}
+	return namespace + "@" + host
+}
+
+// FailoverClient is a Temporal client that serves reads (queries, describe,
+// list, history) from a standby namespace or cluster while the primary is
+// unreachable. Everything else, including Updates and signals, always goes
+// to the primary: the standby is a read-only view for riding out
+// maintenance windows, not a second place to run sessions.
+//
+// The standby must hold replicas of the primary's workflows, e.g. a
+// multi-cluster namespace or a namespace with Temporal Cloud replication.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type FailoverClient struct {
+	client.Client // Primary
+
+	standby     client.Client
+	standbyName string
+
+	// FailoverAfter bounds a read against the primary before it is retried
+	// on the standby. ProbeInterval is how often reads re-check the primary
+	// while it is down.
+	FailoverAfter time.Duration
+	ProbeInterval time.Duration
+
+	mu        sync.Mutex
+	degraded  bool
+	lastProbe time.Time
+	now       func() time.Time
+}
+
+// NewFailoverClient wraps primary with read failover to standby.
+// standbyName describes the standby in messages (namespace@host).
+func NewFailoverClient(primary, standby client.Client, standbyName string) *FailoverClient {
+	return &FailoverClient{
+		Client:        primary,
+		standby:       standby,
+		standbyName:   standbyName,
+		FailoverAfter: defaultFailoverAfter,
+		ProbeInterval: defaultProbeInterval,
+		now:           time.Now,
+	}
+}
+
+// Degraded reports whether the primary is currently considered unreachable.
+func (f *FailoverClient) Degraded() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.degraded
+}
+
+// StandbyName describes the standby endpoint (namespace@host).
+func (f *FailoverClient) StandbyName() string {
+	return f.standbyName
+}
+
+// setDegraded records the primary's reachability.
+func (f *FailoverClient) setDegraded(degraded bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.degraded = degraded
+	f.lastProbe = f.now()
+}
+
+// CheckPrimary health-checks the primary, updates the degraded state and
+// reports whether the primary is reachable. A check cut short by ctx
+// leaves the state unchanged.
+func (f *FailoverClient) CheckPrimary(ctx context.Context) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	_, err := f.Client.CheckHealth(probeCtx, &client.CheckHealthRequest{})
+	if ctx.Err() != nil {
+		return !f.Degraded()
+	}
+	f.setDegraded(err != nil)
+	return err == nil
+}
+
+// usePrimary reports whether a read should try the primary first: it is
+// healthy, or it is down but due for a re-check.
+func (f *FailoverClient) usePrimary(ctx context.Context) bool {
+	f.mu.Lock()
+	due := f.degraded && f.now().Sub(f.lastProbe) >= f.ProbeInterval
+	degraded := f.degraded
+	f.mu.Unlock()
+	if due {
+		return f.CheckPrimary(ctx)
+	}
+	return !degraded
+}
+
+// read runs call against the primary and, if the primary is unreachable,
+// against the standby.
+func (f *FailoverClient) read(ctx context.Context, call func(ctx context.Context, c client.Client) error) error {
+	if f.usePrimary(ctx) {
+		primaryCtx, cancel := context.WithTimeout(ctx, f.FailoverAfter)
+		err := call(primaryCtx, f.Client)
+		cancel()
+		if err == nil || !IsUnreachable(err) || ctx.Err() != nil {
+			return err
+		}
+		f.setDegraded(true)
+	}
+	return call(ctx, f.standby)
+}
+
+// QueryWorkflow implements client.Client with read failover.
+func (f *FailoverClient) QueryWorkflow(ctx context.Context, workflowID, runID, queryType string, args ...interface{}) (converter.EncodedValue, error) {
+	var value converter.EncodedValue
+	err := f.read(ctx, func(ctx context.Context, c client.Client) error {
+		var err error
+		value, err = c.QueryWorkflow(ctx, workflowID, runID, queryType, args...)
+		return err
+	})
+	return value, err
+}
+
+// QueryWorkflowWithOptions implements client.Client with read failover.
+func (f *FailoverClient) QueryWorkflowWithOptions(ctx context.Context, request *client.QueryWorkflowWithOptionsRequest) (*client.QueryWorkflowWithOptionsResponse, error) {
+	var resp *client.QueryWorkflowWithOptionsResponse
+	err := f.read(ctx, func(ctx context.Context, c client.Client) error {
+		var err error
+		resp, err = c.QueryWorkflowWithOptions(ctx, request)
+		return err
+	})
+	return resp, err
+}
+
+// DescribeWorkflowExecution implements client.Client with read failover.
+func (f *FailoverClient) DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	var resp *workflowservice.DescribeWorkflowExecutionResponse
+	err := f.read(ctx, func(ctx context.Context, c client.Client) error {
+		var err error
+		resp, err = c.DescribeWorkflowExecution(ctx, workflowID, runID)
+		return err
+	})
+	return resp, err
+}
+
+// DescribeWorkflow implements client.Client with read failover.
+func (f *FailoverClient) DescribeWorkflow(ctx context.Context, workflowID, runID string) (*client.WorkflowExecutionDescription, error) {
+	var resp *client.WorkflowExecutionDescription
+	err := f.read(ctx, func(ctx context.Context, c client.Client) error {
+		var err error
+		resp, err = c.DescribeWorkflow(ctx, workflowID, runID)
+		return err
+	})
+	return resp, err
+}
+
+// ListWorkflow implements client.Client with read failover.
+func (f *FailoverClient) ListWorkflow(ctx context.Context, request *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	var resp *workflowservice.ListWorkflowExecutionsResponse
+	err := f.read(ctx, func(ctx context.Context, c client.Client) error {
+		var err error
+		resp, err = c.ListWorkflow(ctx, request)
+		return err
+	})
+	return resp, err
+}
+
+// GetWorkflowHistory implements client.Client. The iterator fetches pages
+// lazily, so the endpoint is chosen up front from the known primary state
+// rather than by retrying a failed call.
+func (f *FailoverClient) GetWorkflowHistory(ctx context.Context, workflowID, runID string, isLongPoll bool, filterType enumspb.HistoryEventFilterType) client.HistoryEventIterator {
+	if f.usePrimary(ctx) {
+		return f.Client.GetWorkflowHistory(ctx, workflowID, runID, isLongPoll, filterType)
+	}
+	return f.standby.GetWorkflowHistory(ctx, workflowID, runID, isLongPoll, filterType)
+}
+
+// Close closes both connections.
+func (f *FailoverClient) Close() {
+	f.Client.Close()
+	f.standby.Close()
+}
+
+// IsUnreachable reports whether err means the Temporal frontend could not be
+// reached or did not answer in time, as opposed to an application error
+// such as a missing workflow or a failed query.
+func IsUnreachable(err error) bool {
+	var unavailable *serviceerror.Unavailable
+	var deadline *serviceerror.DeadlineExceeded
+	return errors.As(err, &unavailable) ||
+		errors.As(err, &deadline) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,115 @@
+package temporalclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// fakeClient answers ListWorkflow and CheckHealth with err and counts calls.
+// Other client methods are not implemented.
+type fakeClient struct {
+	client.Client
+	name  string
+	err   error
+	calls int
+}
+
+func (f *fakeClient) ListWorkflow(context.Context, *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &workflowservice.ListWorkflowExecutionsResponse{NextPageToken: []byte(f.name)}, nil
+}
+
+func (f *fakeClient) CheckHealth(context.Context, *client.CheckHealthRequest) (*client.CheckHealthResponse, error) {
+	return &client.CheckHealthResponse{}, f.err
+}
+
+func listFrom(t *testing.T, c client.Client) string {
+	t.Helper()
+	resp, err := c.ListWorkflow(context.Background(), &workflowservice.ListWorkflowExecutionsRequest{})
+	require.NoError(t, err)
+	return string(resp.NextPageToken)
+}
+
+func TestFailoverClient_ReadsFailOverAndRecover(t *testing.T) {
+	primary := &fakeClient{name: "primary", err: serviceerror.NewUnavailable("connection refused")}
+	standby := &fakeClient{name: "standby"}
+	fc := NewFailoverClient(primary, standby, "agents-dr@standby:7233")
+	now := time.Unix(1000, 0)
+	fc.now = func() time.Time { return now }
+
+	assert.Equal(t, "standby", listFrom(t, fc))
+	assert.True(t, fc.Degraded())
+	assert.Equal(t, 1, primary.calls)
+
+	// While degraded, reads skip the primary until the probe interval passes.
+	assert.Equal(t, "standby", listFrom(t, fc))
+	assert.Equal(t, 1, primary.calls)
+
+	primary.err = nil
+	now = now.Add(fc.ProbeInterval)
+	assert.Equal(t, "primary", listFrom(t, fc))
+	assert.False(t, fc.Degraded())
+}
+
+func TestFailoverClient_ApplicationErrorsDoNotFailOver(t *testing.T) {
+	primary := &fakeClient{name: "primary", err: serviceerror.NewNotFound("workflow not found")}
+	standby := &fakeClient{name: "standby"}
+	fc := NewFailoverClient(primary, standby, "standby")
+
+	_, err := fc.ListWorkflow(context.Background(), &workflowservice.ListWorkflowExecutionsRequest{})
+	var notFound *serviceerror.NotFound
+	require.ErrorAs(t, err, &notFound)
+	assert.False(t, fc.Degraded())
+	assert.Equal(t, 0, standby.calls)
+}
+
+func TestFailoverClient_CheckPrimary(t *testing.T) {
+	primary := &fakeClient{err: serviceerror.NewUnavailable("down")}
+	fc := NewFailoverClient(primary, &fakeClient{}, "standby")
+
+	assert.False(t, fc.CheckPrimary(context.Background()))
+	assert.True(t, fc.Degraded())
+
+	primary.err = nil
+	assert.True(t, fc.CheckPrimary(context.Background()))
+	assert.False(t, fc.Degraded())
+}
+
+func TestLoadStandbyClientOptions(t *testing.T) {
+	t.Setenv(EnvStandbyHostURL, "")
+	t.Setenv(EnvStandbyNamespace, "")
+	primary := client.Options{HostPort: "primary:7233", Namespace: "agents"}
+
+	_, ok := LoadStandbyClientOptions(primary, "", "")
+	assert.False(t, ok)
+
+	t.Setenv(EnvStandbyNamespace, "agents-dr")
+	opts, ok := LoadStandbyClientOptions(primary, "", "")
+	require.True(t, ok)
+	assert.Equal(t, "primary:7233", opts.HostPort)
+	assert.Equal(t, "agents-dr", opts.Namespace)
+	assert.Equal(t, "agents-dr@primary:7233", endpointName(opts))
+
+	opts, ok = LoadStandbyClientOptions(primary, "standby:7233", "")
+	require.True(t, ok)
+	assert.Equal(t, "standby:7233", opts.HostPort)
+	assert.Equal(t, "agents-dr", opts.Namespace)
+}
+
+func TestIsUnreachable(t *testing.T) {
+	assert.True(t, IsUnreachable(serviceerror.NewUnavailable("down")))
+	assert.True(t, IsUnreachable(serviceerror.NewDeadlineExceeded("slow")))
+	assert.True(t, IsUnreachable(context.DeadlineExceeded))
+	assert.False(t, IsUnreachable(serviceerror.NewNotFound("gone")))
+	assert.False(t, IsUnreachable(serviceerror.NewQueryFailed("bad query")))
+}
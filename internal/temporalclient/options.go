@@ -4,11 +4,24 @@
 // This enables configuration via environment variables (TEMPORAL_HOST_URL,
 // TEMPORAL_NAMESPACE, TEMPORAL_TLS_CERT, etc.) and config files (config.toml),
 // matching the pattern from temporal/samples-go/external-env-conf.
+//
+// When TEMPORAL_CODEC_KEY is set, payloads are encrypted with the
+// internal/codec data converter.
+//
+// Every client proves the caller's identity (TCX_IDENTITY, signed with
+// TCX_CALLER_KEY) and session token (TCX_SESSION_TOKEN) with workflow
+// starts, Updates and signals for sessions that restrict access.
 package temporalclient
 
 import (
+	"context"
+	"fmt"
+	"os"
+
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/contrib/envconfig"
+
+	"github.com/mfateev/temporal-agent-harness/internal/codec"
 )
 
 // LoadClientOptions loads Temporal client options using the envconfig system.
@@ -16,6 +29,8 @@ import (
 //   - Environment variables (TEMPORAL_HOST_URL, TEMPORAL_NAMESPACE, TEMPORAL_TLS_CERT, etc.)
 //   - Config file (config.toml in working directory or TEMPORAL_CONFIG_FILE)
 //   - Temporal Cloud connection via TEMPORAL_HOST_URL + TEMPORAL_TLS_CERT + TEMPORAL_TLS_KEY
+//   - Payload encryption via TEMPORAL_CODEC_KEY (see internal/codec)
+//   - Caller proofs via TCX_IDENTITY, TCX_CALLER_KEY and TCX_SESSION_TOKEN
 //
 // If hostPortOverride is non-empty, it overrides the host:port from envconfig.
 // If namespaceOverride is non-empty, it overrides the namespace.
@@ -34,6 +49,16 @@ func LoadClientOptions(hostPortOverride, namespaceOverride string) (client.Optio
 		opts.Namespace = namespaceOverride
 	}
 
+	dc, err := codec.DataConverterFromEnv(context.Background())
+	if err != nil {
+		return client.Options{}, fmt.Errorf("payload encryption: %w", err)
+	}
+	if dc != nil {
+		opts.DataConverter = dc
+	}
+
+	opts.Interceptors = append(opts.Interceptors, NewCallerInterceptor(CallerIdentity(), os.Getenv(EnvCallerKey), os.Getenv(EnvSessionToken)))
+
 	return opts, nil
 }
 
@@ -0,0 +1,89 @@
+package tokenizer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// maxAnthropicCacheEntries bounds AnthropicCounter's cache; it is cleared
+// when full.
+const maxAnthropicCacheEntries = 50000
+
+// AnthropicCounter counts tokens with Anthropic's count_tokens endpoint.
+// Results are cached by model and text, so each text costs one request at
+// most once per worker.
+type AnthropicCounter struct {
+	client anthropic.Client
+
+	mu    sync.Mutex
+	cache map[string]int // By digest of model and text
+}
+
+// NewAnthropicCounter creates a counter authenticating with apiKey.
+func NewAnthropicCounter(apiKey string, opts ...option.RequestOption) *AnthropicCounter {
+	opts = append([]option.RequestOption{option.WithAPIKey(apiKey)}, opts...)
+	return &AnthropicCounter{
+		client: anthropic.NewClient(opts...),
+		cache:  make(map[string]int),
+	}
+}
+
+// CountTexts returns the token count of each text under model. Uncached
+// texts are counted together in one request, as content blocks of a single
+// user message; the exact total is split among them in proportion to their
+// length, so per-text counts are estimates whose sum is exact (including
+// the message framing).
+func (a *AnthropicCounter) CountTexts(ctx context.Context, model string, texts []string) ([]int, error) {
+	counts := make([]int, len(texts))
+	var missing []int
+	a.mu.Lock()
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		if n, ok := a.cache[digest(model, text)]; ok {
+			counts[i] = n
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	a.mu.Unlock()
+	if len(missing) == 0 {
+		return counts, nil
+	}
+
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(missing))
+	totalChars := 0
+	for _, i := range missing {
+		blocks = append(blocks, anthropic.NewTextBlock(texts[i]))
+		totalChars += len(texts[i])
+	}
+	resp, err := a.client.Messages.CountTokens(ctx, anthropic.MessageCountTokensParams{
+		Model:    anthropic.Model(model),
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(blocks...)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anthropic count_tokens: %w", err)
+	}
+
+	remaining := int(resp.InputTokens)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache)+len(missing) > maxAnthropicCacheEntries {
+		a.cache = make(map[string]int)
+	}
+	for k, i := range missing {
+		n := remaining
+		if k < len(missing)-1 {
+			n = int(resp.InputTokens) * len(texts[i]) / totalChars
+			remaining -= n
+		}
+		counts[i] = n
+		a.cache[digest(model, texts[i])] = n
+	}
+	return counts, nil
+}
@@ -0,0 +1,91 @@
+package tokenizer
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// maxCacheEntries bounds each of Cache's maps; a full map is cleared.
+const maxCacheEntries = 20000
+
+// Cache is a Counter that prefers exact counts recorded for known texts
+// (e.g. from AnthropicCounter, fetched by an activity) and memoizes its
+// base Counter for the rest. Its answers depend only on what was recorded,
+// so workflow code may use it.
+type Cache struct {
+	mu       sync.Mutex
+	base     Counter
+	exact    map[string]int
+	estimate map[string]int
+}
+
+// NewCache creates a cache over base.
+func NewCache(base Counter) *Cache {
+	return &Cache{base: base, exact: make(map[string]int), estimate: make(map[string]int)}
+}
+
+// Count implements Counter.
+func (c *Cache) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	key := digest("", text)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n, ok := c.exact[key]; ok {
+		return n
+	}
+	if n, ok := c.estimate[key]; ok {
+		return n
+	}
+	n := c.base.Count(text)
+	if len(c.estimate) >= maxCacheEntries {
+		c.estimate = make(map[string]int)
+	}
+	c.estimate[key] = n
+	return n
+}
+
+// Record stores exact counts for texts (counts[i] is for texts[i]).
+func (c *Cache) Record(texts []string, counts []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.exact)+len(texts) > maxCacheEntries {
+		c.exact = make(map[string]int)
+	}
+	for i, text := range texts {
+		if i < len(counts) && text != "" {
+			c.exact[digest("", text)] = counts[i]
+		}
+	}
+}
+
+// Missing returns the non-empty texts without an exact count, without
+// duplicates, in order.
+func (c *Cache) Missing(texts []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seen := make(map[string]bool)
+	var out []string
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		key := digest("", text)
+		if _, ok := c.exact[key]; ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, text)
+	}
+	return out
+}
+
+// digest keys caches by model and text without keeping the text.
+func digest(model, text string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return string(h.Sum(nil))
+}
@@ -0,0 +1,141 @@
+// Package tokenizer counts tokens the way each model provider does, so
+// context-size decisions (proactive compaction, the context gauge) are not
+// off by the 20-40% error of a characters/4 estimate.
+//
+// OpenAI models are counted locally with their tiktoken encoding; the BPE
+// ranks are embedded, so no download happens at runtime. Anthropic has no
+// public tokenizer: AnthropicCounter asks the count_tokens endpoint and
+// caches the answers, and Cache lets deterministic workflow code use those
+// answers with the heuristic as fallback. Counting never fails: anything
+// that cannot be counted exactly falls back to Heuristic.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tokenizer
+
+import (
+	"strings"
+	"sync"
+
+	tiktoken "github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Counter counts the tokens in a text.
+type Counter interface {
+	Count(text string) int
+}
+
+// Heuristic estimates four characters per token. It is the fallback for
+// models without a local tokenizer.
+type Heuristic struct{}
+
+// Count implements Counter.
+func (Heuristic) Count(text string) int {
+	return len(text) / 4
+}
+
+// Encoding names of the tiktoken encodings used by OpenAI chat models.
+const (
+	EncodingO200K  = "o200k_base"
+	EncodingCL100K = "cl100k_base"
+)
+
+// EncodingForModel returns the tiktoken encoding of an OpenAI model.
+// Models it does not recognize are assumed to be recent and use o200k_base.
+func EncodingForModel(model string) string {
+	m := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(m, "gpt-4o"), strings.HasPrefix(m, "gpt-4.1"), strings.HasPrefix(m, "gpt-4.5"):
+		return EncodingO200K
+	case strings.HasPrefix(m, "gpt-4"), strings.HasPrefix(m, "gpt-3.5"):
+		return EncodingCL100K
+	default:
+		return EncodingO200K // gpt-5, o-series, codex models
+	}
+}
+
+// ForModel returns the local Counter for a provider's model: tiktoken for
+// OpenAI ("" is OpenAI, as elsewhere), Heuristic for everything else or if
+// the encoding cannot be loaded.
+func ForModel(provider, model string) Counter {
+	if provider != "" && provider != "openai" {
+		return Heuristic{}
+	}
+	enc, err := loadEncoding(EncodingForModel(model))
+	if err != nil {
+		return Heuristic{}
+	}
+	return enc
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = map[string]*tiktokenCounter{}
+	loaderOnce  sync.Once
+)
+
+// Preload loads the encodings of OpenAI chat models. Loading otherwise
+// happens on first use and takes a few hundred milliseconds.
+func Preload() {
+	_, _ = loadEncoding(EncodingO200K)
+	_, _ = loadEncoding(EncodingCL100K)
+}
+
+// loadEncoding returns the named encoding, loading it on first use. Loading
+// parses the embedded ranks once per process.
+func loadEncoding(name string) (*tiktokenCounter, error) {
+	loaderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+	})
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+	tk, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	enc := &tiktokenCounter{tk: tk}
+	encodings[name] = enc
+	return enc, nil
+}
+
+// tiktokenCounter counts with a tiktoken encoding. Special-token text (e.g.
+// "<|endoftext|>" in a file being read) is counted as ordinary text, as the
+// API does for message content.
+type tiktokenCounter struct {
+	tk *tiktoken.Tiktoken
+}
+
+// Count implements Counter.
+func (c *tiktokenCounter) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(c.tk.EncodeOrdinary(text))
+}
+
+// ItemText returns the parts of a conversation item that are sent to the
+// model as text, concatenated.
+func ItemText(item models.ConversationItem) string {
+	var b strings.Builder
+	b.WriteString(item.Content)
+	b.WriteString(item.Name)
+	b.WriteString(item.Arguments)
+	if item.Output != nil {
+		b.WriteString(item.Output.Content)
+	}
+	return b.String()
+}
+
+// CountItems returns the total tokens of items under c.
+func CountItems(c Counter, items []models.ConversationItem) int {
+	total := 0
+	for _, item := range items {
+		total += c.Count(ItemText(item))
+	}
+	return total
+}
@@ -0,0 +1,112 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestEncodingForModel(t *testing.T) {
+	for model, want := range map[string]string{
+		"gpt-4o-mini":   EncodingO200K,
+		"gpt-4.1":       EncodingO200K,
+		"gpt-5-codex":   EncodingO200K,
+		"o3":            EncodingO200K,
+		"gpt-4-turbo":   EncodingCL100K,
+		"gpt-3.5-turbo": EncodingCL100K,
+	} {
+		assert.Equal(t, want, EncodingForModel(model), model)
+	}
+}
+
+func TestForModel(t *testing.T) {
+	assert.Equal(t, 3, ForModel("openai", "gpt-4o").Count("hello, world"))
+	assert.Equal(t, 3, ForModel("", "gpt-4").Count("hello, world"))
+	assert.Equal(t, 0, ForModel("openai", "gpt-4o").Count(""))
+	assert.Equal(t, Heuristic{}, ForModel("anthropic", "claude-sonnet-4-5"))
+	assert.Equal(t, 3, Heuristic{}.Count("hello, world"))
+}
+
+func TestCountItems(t *testing.T) {
+	items := []models.ConversationItem{
+		{Type: models.ItemTypeUserMessage, Content: "12345678"},
+		{Type: models.ItemTypeFunctionCall, Name: "read", Arguments: "{}xx"},
+		{Type: models.ItemTypeFunctionCallOutput, Output: &models.FunctionCallOutputPayload{Content: "abcd"}},
+	}
+	assert.Equal(t, 5, CountItems(Heuristic{}, items))
+	assert.Equal(t, "read{}xx", ItemText(items[1]))
+}
+
+// countingCounter counts calls to Count.
+type countingCounter struct{ calls int }
+
+func (c *countingCounter) Count(text string) int {
+	c.calls++
+	return len(text)
+}
+
+func TestCache(t *testing.T) {
+	base := &countingCounter{}
+	c := NewCache(base)
+
+	assert.Equal(t, 5, c.Count("hello"))
+	assert.Equal(t, 5, c.Count("hello"))
+	assert.Equal(t, 1, base.calls, "estimates are memoized")
+
+	assert.Equal(t, []string{"hello", "world"}, c.Missing([]string{"hello", "", "world", "hello"}))
+	c.Record([]string{"hello"}, []int{2})
+	assert.Equal(t, 2, c.Count("hello"), "exact counts win over estimates")
+	assert.Equal(t, []string{"world"}, c.Missing([]string{"hello", "world"}))
+}
+
+func TestAnthropicCounter(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/v1/messages/count_tokens", r.URL.Path)
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "claude-test", body.Model)
+		require.Len(t, body.Messages, 1)
+		assert.Len(t, body.Messages[0].Content, 2, "uncached, non-empty texts are counted in one request")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]int{"input_tokens": 100})
+	}))
+	defer srv.Close()
+
+	c := NewAnthropicCounter("key", option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	counts, err := c.CountTexts(context.Background(), "claude-test", []string{"aaa", "", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{75, 0, 25}, counts, "the exact total is split by length")
+
+	counts, err = c.CountTexts(context.Background(), "claude-test", []string{"a", "aaa"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{25, 75}, counts)
+	assert.Equal(t, 1, requests, "cached texts are not counted again")
+}
+
+func TestAnthropicCounter_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type":"error","error":{"type":"authentication_error","message":"bad key"}}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewAnthropicCounter("key", option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	_, err := c.CountTexts(context.Background(), "claude-test", []string{"a"})
+	assert.ErrorContains(t, err, "anthropic count_tokens")
+}
@@ -0,0 +1,32 @@
+// Artifact tool specification for the share_artifact intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "share_artifact", Constructor: NewShareArtifactToolSpec})
+}
+
+// NewShareArtifactToolSpec creates the specification for the share_artifact
+// tool. This tool is intercepted by the workflow, which registers the file
+// with the worker's artifact server and records the URL in history.
+func NewShareArtifactToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "share_artifact",
+		Description: `Share a file you generated (coverage report, generated code, large log) with the user as a link they can open in a browser, instead of printing it. The link expires. Share finished files only; pass the link on in your reply.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "path",
+				Type:        "string",
+				Description: `Path of the file, absolute or relative to the working directory. It must be under the working directory or a writable root.`,
+				Required:    true,
+			},
+			{
+				Name:        "ttl_minutes",
+				Type:        "integer",
+				Description: `Optional link lifetime in minutes. Defaults to the worker's setting (usually 60).`,
+				Required:    false,
+			},
+		},
+	}
+}
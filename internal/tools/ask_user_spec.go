@@ -0,0 +1,38 @@
+// Ask-user tool specification for the ask_user intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "ask_user", Constructor: NewAskUserToolSpec})
+}
+
+// NewAskUserToolSpec creates the specification for the ask_user tool.
+// This tool is intercepted by the workflow (not dispatched as an activity).
+// Unlike request_user_input, the user answers in free text.
+func NewAskUserToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "ask_user",
+		Description: `Ask the user a free-text question and wait for the answer. Use this when the answer cannot be picked from a short list of options (use request_user_input for that), e.g. to ask for a name, a value, or an explanation. The tool returns the user's answer verbatim.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "question",
+				Type:        "string",
+				Description: "The question, in one short sentence.",
+				Required:    true,
+			},
+			{
+				Name:        "body",
+				Type:        "string",
+				Description: "Optional markdown shown under the question: context, a proposal to review, or examples of acceptable answers.",
+				Required:    false,
+			},
+			{
+				Name:        "default",
+				Type:        "string",
+				Description: "Optional answer used when the user submits an empty response.",
+				Required:    false,
+			},
+		},
+	}
+}
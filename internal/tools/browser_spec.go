@@ -0,0 +1,112 @@
+// Browser tool specifications: drive a headless Chrome to check a rendered
+// web app.
+//
+// The tools form the "browser" group, enabled with [browser] enabled = true
+// in config.toml. Each session gets its own browser on the worker that runs
+// it, limited to the domains in [browser] allowed_domains.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "browser_navigate", Constructor: NewBrowserNavigateToolSpec, Group: "browser", ReadOnly: true})
+	RegisterSpec(SpecEntry{Name: "browser_click", Constructor: NewBrowserClickToolSpec, Group: "browser"})
+	RegisterSpec(SpecEntry{Name: "browser_read_text", Constructor: NewBrowserReadTextToolSpec, Group: "browser", ReadOnly: true})
+	RegisterSpec(SpecEntry{Name: "browser_screenshot", Constructor: NewBrowserScreenshotToolSpec, Group: "browser", ReadOnly: true})
+}
+
+// DefaultBrowserTimeoutMs covers starting the browser plus one page load.
+const DefaultBrowserTimeoutMs = 90_000
+
+// browser_read_text output limit, in characters.
+const (
+	DefaultBrowserTextMaxChars = 20_000
+	MaxBrowserTextMaxChars     = 100_000
+)
+
+// browserSelectorParameter is the CSS selector argument shared by the
+// browser tools.
+func browserSelectorParameter(description string, required bool) ToolParameter {
+	return ToolParameter{
+		Name:        "selector",
+		Type:        "string",
+		Description: description,
+		Required:    required,
+	}
+}
+
+// NewBrowserNavigateToolSpec creates the specification for the
+// browser_navigate tool.
+func NewBrowserNavigateToolSpec() ToolSpec {
+	return ToolSpec{
+		Name: "browser_navigate",
+		Description: `Opens a URL in this session's headless browser and waits for the page to load. Use it to check a web app as a user sees it, with JavaScript run; use fetch_url to just read a document.
+- The browser is kept between calls: cookies, local storage and the open page persist until it is idle for 10 minutes.
+- Only the hosts allowed by the session's browser allowlist (by default localhost) can be loaded; requests to other hosts, including from the page itself, are blocked and reported.
+- Returns the final URL and the page title. Follow with browser_read_text or browser_screenshot to see the page.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "url",
+				Type:        "string",
+				Description: "Absolute http:// or https:// URL to open.",
+				Required:    true,
+			},
+		},
+		DefaultTimeoutMs: DefaultBrowserTimeoutMs,
+		RetryPolicy:      RetryNone, // stateful browser — don't retry
+	}
+}
+
+// NewBrowserClickToolSpec creates the specification for the browser_click
+// tool.
+func NewBrowserClickToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "browser_click",
+		Description: `Clicks the first visible element matching a CSS selector on the current page, then waits briefly for the page to react. Returns the resulting URL and title.`,
+		Parameters: []ToolParameter{
+			browserSelectorParameter(`CSS selector of the element to click, e.g. "button[type=submit]" or "#login".`, true),
+		},
+		DefaultTimeoutMs: DefaultBrowserTimeoutMs,
+		RetryPolicy:      RetryNone, // a retried click could submit twice
+	}
+}
+
+// NewBrowserReadTextToolSpec creates the specification for the
+// browser_read_text tool.
+func NewBrowserReadTextToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "browser_read_text",
+		Description: `Returns the rendered text of the current page, or of the first visible element matching a CSS selector, as the user would read it.`,
+		Parameters: []ToolParameter{
+			browserSelectorParameter("CSS selector of the element to read. Defaults to the whole page.", false),
+			{
+				Name:        "max_chars",
+				Type:        "number",
+				Description: "Maximum characters to return. Defaults to 20000, max 100000.",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultBrowserTimeoutMs,
+		RetryPolicy:      RetryNone,
+	}
+}
+
+// NewBrowserScreenshotToolSpec creates the specification for the
+// browser_screenshot tool.
+func NewBrowserScreenshotToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "browser_screenshot",
+		Description: `Takes a PNG screenshot of the current page's viewport, the whole page, or one element. The image is attached to the result for the user; you get its path on the worker.`,
+		Parameters: []ToolParameter{
+			browserSelectorParameter("CSS selector of an element to capture. Defaults to the viewport.", false),
+			{
+				Name:        "full_page",
+				Type:        "boolean",
+				Description: "Capture the whole scrollable page instead of the viewport. Defaults to false.",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultBrowserTimeoutMs,
+		RetryPolicy:      RetryNone,
+	}
+}
@@ -0,0 +1,40 @@
+// Code outline tool specification: a compact symbol map of a directory.
+//
+// Orienting in an unfamiliar repository otherwise takes many read_file
+// calls; code_outline lists packages and their declarations with line
+// numbers in one call.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "code_outline", Constructor: NewCodeOutlineToolSpec, ReadOnly: true})
+}
+
+// DefaultCodeOutlineTimeoutMs covers loading the packages of a large Go
+// module.
+const DefaultCodeOutlineTimeoutMs = 60_000
+
+// NewCodeOutlineToolSpec creates the specification for the code_outline tool.
+func NewCodeOutlineToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "code_outline",
+		Description: "Lists the packages, files and top-level declarations (types, functions, methods, constants) under a directory, with line numbers, without their bodies. Use it to orient in unfamiliar code before reading files; then read_file the lines you need. Go is outlined from its syntax tree; Python, JavaScript/TypeScript, Rust, Java, Kotlin, C# and Ruby from their declaration lines.",
+		Parameters: []ToolParameter{
+			{
+				Name:        "path",
+				Type:        "string",
+				Description: "Directory or file to outline. Defaults to the current working directory.",
+				Required:    false,
+			},
+			{
+				Name:        "private",
+				Type:        "boolean",
+				Description: "Include unexported and private declarations (default false: exported API only).",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultCodeOutlineTimeoutMs,
+		RetryPolicy:      RetryDefault, // read-only — safe to retry
+	}
+}
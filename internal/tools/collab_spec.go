@@ -17,6 +17,7 @@ func init() {
 		{Name: "wait", Constructor: NewWaitToolSpec, Group: "collab"},
 		{Name: "close_agent", Constructor: NewCloseAgentToolSpec, Group: "collab"},
 		{Name: "resume_agent", Constructor: NewResumeAgentToolSpec, Group: "collab"},
+		{Name: "fan_out", Constructor: NewFanOutToolSpec, Group: "collab"},
 	} {
 		RegisterSpec(e)
 	}
@@ -79,10 +80,19 @@ func NewSpawnAgentToolSpec() ToolSpec {
 					"'explorer' — Use explorer for all codebase questions, searches, reading files, and understanding code. Explorers are fast and cheap. " +
 					"'worker' — Use for execution and production work: writing code, running tests, creating files, and making commits. " +
 					"'orchestrator' — Use for coordination of multiple sub-agents. " +
+					"'planner' — Read-only exploration that produces an implementation plan; may ask the user clarifying questions. " +
+					"'reviewer' — Read-only review of a change (e.g. the current git diff); reports issues by severity. " +
+					"'tester' — Writes and runs tests for described behavior; does not change production code. " +
 					"'default' — Inherits parent configuration. " +
 					"Default: 'default'.",
 				Required: false,
 			},
+			{
+				Name:        "output_schema",
+				Type:        "object",
+				Description: "Optional JSON Schema for the agent's result. The agent reports a result matching it with emit_result, and wait/close_agent return it as `result`, so you do not have to parse its final message.",
+				Required:    false,
+			},
 		},
 	}
 }
@@ -191,6 +201,58 @@ func NewResumeAgentToolSpec() ToolSpec {
 	}
 }
 
+// NewFanOutToolSpec creates the specification for the fan_out tool.
+// This tool is intercepted by the workflow (not dispatched as an activity).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+func NewFanOutToolSpec() ToolSpec {
+	return ToolSpec{
+		Name: "fan_out",
+		Description: `Spawn several sub-agents at once, one per task, and wait for all of them. Returns a JSON list of results in task order, each with agent_id, status, final_output and, with output_schema, result.
+Use it for independent tasks whose results you need together, such as reviewing several files or investigating several hypotheses. At most 8 tasks per call; agents still running at the timeout are shut down and marked timed_out.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "tasks",
+				Type:        "array",
+				Description: "One entry per agent: the agent's task message and an optional agent_type overriding the shared one.",
+				Required:    true,
+				Items: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"message": map[string]interface{}{
+							"type":        "string",
+							"description": "Plain-text task for this agent.",
+						},
+						"agent_type": map[string]interface{}{
+							"type":        "string",
+							"description": "Agent type for this task; see spawn_agent.",
+						},
+					},
+					"required": []string{"message"},
+				},
+			},
+			{
+				Name:        "agent_type",
+				Type:        "string",
+				Description: "Agent type for tasks that do not set their own; see spawn_agent. Default: 'default'.",
+				Required:    false,
+			},
+			{
+				Name:        "output_schema",
+				Type:        "object",
+				Description: "Optional JSON Schema every agent's result must match, returned as `result`.",
+				Required:    false,
+			},
+			{
+				Name:        "timeout_ms",
+				Type:        "number",
+				Description: "Maximum time each agent may run, in milliseconds. Min: 10000, Max: 3600000, Default: 600000.",
+				Required:    false,
+			},
+		},
+	}
+}
+
 // CrewAgentSummary is a lightweight description of a crew agent for tool spec generation.
 // This avoids importing the models package from tools (keeping tools dependency-free).
 type CrewAgentSummary struct {
@@ -204,14 +266,27 @@ type CrewAgentSummary struct {
 // If crewAgents is non-empty but the agent has no available_agents (empty list),
 // collab tools are removed entirely.
 func UpdateSpawnAgentSpecWithCrewRoles(specs []ToolSpec, crewAgents []CrewAgentSummary) []ToolSpec {
-	if len(crewAgents) == 0 {
+	return appendAgentTypeOptions(specs, "Crew agents", "Crew-defined agent", crewAgents)
+}
+
+// UpdateSpawnAgentSpecWithCustomRoles extends the spawn_agent tool spec's
+// agent_type parameter description with user-defined roles (~/.codex/roles).
+// If roles is empty, the specs are returned unchanged.
+func UpdateSpawnAgentSpecWithCustomRoles(specs []ToolSpec, roles []CrewAgentSummary) []ToolSpec {
+	return appendAgentTypeOptions(specs, "Custom roles", "User-defined role", roles)
+}
+
+// appendAgentTypeOptions appends "<label>: 'name' — description ..." to the
+// spawn_agent agent_type description. Specs are copied, not mutated.
+func appendAgentTypeOptions(specs []ToolSpec, label, fallbackDesc string, agents []CrewAgentSummary) []ToolSpec {
+	if len(agents) == 0 {
 		return specs
 	}
 
-	// Build the crew roles description string.
+	// Build the roles description string.
 	// Sort by name for deterministic output.
-	sorted := make([]CrewAgentSummary, len(crewAgents))
-	copy(sorted, crewAgents)
+	sorted := make([]CrewAgentSummary, len(agents))
+	copy(sorted, agents)
 	sort.Slice(sorted, func(i, j int) bool {
 		return sorted[i].Name < sorted[j].Name
 	})
@@ -220,11 +295,11 @@ func UpdateSpawnAgentSpecWithCrewRoles(specs []ToolSpec, crewAgents []CrewAgentS
 	for _, agent := range sorted {
 		desc := agent.Description
 		if desc == "" {
-			desc = "Crew-defined agent"
+			desc = fallbackDesc
 		}
 		parts = append(parts, fmt.Sprintf("'%s' — %s", agent.Name, desc))
 	}
-	crewDesc := " Crew agents: " + strings.Join(parts, " ")
+	extraDesc := " " + label + ": " + strings.Join(parts, " ")
 
 	// Find and modify the spawn_agent spec.
 	result := make([]ToolSpec, len(specs))
@@ -238,7 +313,7 @@ func UpdateSpawnAgentSpecWithCrewRoles(specs []ToolSpec, crewAgents []CrewAgentS
 		copy(params, spec.Parameters)
 		for j, p := range params {
 			if p.Name == "agent_type" {
-				params[j].Description += crewDesc
+				params[j].Description += extraDesc
 				break
 			}
 		}
@@ -258,6 +333,7 @@ func RemoveCollabSpecs(specs []ToolSpec) []ToolSpec {
 		"wait":         true,
 		"close_agent":  true,
 		"resume_agent": true,
+		"fan_out":      true,
 	}
 	var result []ToolSpec
 	for _, spec := range specs {
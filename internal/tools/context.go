@@ -3,6 +3,14 @@
 // Corresponds to: codex-rs/core/src/tools/
 package tools
 
+import (
+	"log/slog"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+)
+
 // ToolKind classifies the type of tool handler.
 //
 // Maps to: codex-rs/core/src/tools/registry.rs ToolKind
@@ -19,6 +27,20 @@ const (
 type ToolOutput struct {
 	Content string `json:"content"`
 	Success *bool  `json:"success,omitempty"`
+
+	// PatchConflict describes the hunk an apply_patch call could not apply.
+	PatchConflict *patch.Conflict `json:"patch_conflict,omitempty"`
+
+	// Attachments are files the tool produced, such as saved plots.
+	Attachments []attachment.Attachment `json:"attachments,omitempty"`
+
+	// FullContent, set when Content was diffed against PreviousOutput, is
+	// the undiffed output, kept for the next run's comparison.
+	FullContent string `json:"full_content,omitempty"`
+
+	// TimedOut is set when a shell or exec command was stopped at its
+	// CommandTimeout. Content ends with its Note.
+	TimedOut *CommandTimeout `json:"timed_out,omitempty"`
 }
 
 // McpToolRef carries routing metadata for MCP tool dispatch.
@@ -28,6 +50,7 @@ type ToolOutput struct {
 type McpToolRef struct {
 	ServerName string `json:"server_name"`
 	ToolName   string `json:"tool_name"`
+	ReadOnly   bool   `json:"read_only,omitempty"` // The server annotates the tool readOnlyHint
 }
 
 // ToolInvocation provides context for tool execution.
@@ -46,11 +69,39 @@ type ToolInvocation struct {
 	// EnvPolicy, if set, filters environment variables before execution.
 	EnvPolicy *EnvPolicyRef `json:"env_policy,omitempty"`
 
+	// PreviousOutput, if set, is the output of the last identical command
+	// in the turn. Shell and exec handlers then return only the difference
+	// (see DiffOutput).
+	PreviousOutput string `json:"previous_output,omitempty"`
+
+	// ReadFileLimit, for read_file, caps the lines of reads without a limit:
+	// 0 uses DefaultReadFileLineLimit, negative reads whole files.
+	ReadFileLimit int `json:"read_file_limit,omitempty"`
+
+	// BrowserAllowedDomains, for browser_* tools, are the hosts the
+	// session's browser may load. Empty allows only localhost.
+	BrowserAllowedDomains []string `json:"browser_allowed_domains,omitempty"`
+
+	// CommandTimeout, for shell and exec tools, is how long the command may
+	// run: the call's timeout_seconds (or timeout_ms) within the worker's
+	// limit. 0 leaves it to the activity timeout. Set by the activity layer.
+	CommandTimeout time.Duration `json:"-"`
+
 	// Heartbeat, if set, is called periodically during long-running tool
 	// execution to keep the Temporal activity alive. Set by the activity
 	// layer; nil in unit tests.
 	Heartbeat func(details ...interface{}) `json:"-"`
 
+	// OnProgress, if set, receives structured progress from long-running
+	// tools (see ReportProgress). Set by the activity layer, which records it
+	// as heartbeat details and forwards it to the workflow.
+	OnProgress func(ToolProgress) `json:"-"`
+
+	// Logger, if set, carries the call's session correlation fields
+	// (conversation_id, turn_id, call_id). Set by the activity layer; nil in
+	// unit tests. Handlers log through Log.
+	Logger *slog.Logger `json:"-"`
+
 	// MCP fields — populated for mcp__* tool calls.
 
 	// McpToolRef, if set, routes this call to the named MCP server + tool.
@@ -65,12 +116,21 @@ type ToolInvocation struct {
 	McpServers interface{} `json:"-"`
 }
 
+// Log returns the invocation's logger, or the default logger when none is set.
+func (inv *ToolInvocation) Log() *slog.Logger {
+	if inv.Logger != nil {
+		return inv.Logger
+	}
+	return slog.Default()
+}
+
 // SandboxPolicyRef is a serializable reference to a sandbox policy.
 // Stored separately from internal/sandbox to avoid circular imports.
 type SandboxPolicyRef struct {
 	Mode          string   `json:"mode"`
 	WritableRoots []string `json:"writable_roots,omitempty"`
 	NetworkAccess bool     `json:"network_access"`
+	NetworkAllow  []string `json:"network_allow,omitempty"` // Hosts reachable when NetworkAccess is false
 }
 
 // EnvPolicyRef is a serializable reference to a shell environment policy.
@@ -0,0 +1,26 @@
+// Clock tool specification for the current_time intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "current_time", Constructor: NewCurrentTimeToolSpec, ReadOnly: true})
+}
+
+// NewCurrentTimeToolSpec creates the specification for the current_time
+// tool. This tool is intercepted by the workflow (not dispatched as an
+// activity), which reads the clock deterministically.
+func NewCurrentTimeToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "current_time",
+		Description: `Get the current date and time. The environment context shows the time when the turn started; call this when you need the exact time, e.g. to timestamp output or compute a deadline, or the time in another timezone.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "timezone",
+				Type:        "string",
+				Description: `IANA timezone name such as "America/New_York" or "UTC". Defaults to the session's timezone.`,
+				Required:    false,
+			},
+		},
+	}
+}
@@ -0,0 +1,35 @@
+// Emit-result tool specification for subagents.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "emit_result", Constructor: func() ToolSpec { return NewEmitResultToolSpec(nil) }})
+}
+
+// NewEmitResultToolSpec creates the specification for the emit_result tool,
+// which a subagent calls to hand its parent a machine-readable result. This
+// tool is intercepted by the workflow (not dispatched as an activity).
+//
+// schema is the JSON Schema the parent requested for the result (spawn_agent
+// output_schema); nil accepts any JSON object.
+func NewEmitResultToolSpec(schema map[string]interface{}) ToolSpec {
+	result := schema
+	if result == nil {
+		result = map[string]interface{}{
+			"type":        "object",
+			"description": "The result of your task as a JSON object.",
+		}
+	}
+	return ToolSpec{
+		Name:        "emit_result",
+		Description: `Report the structured result of your task to the agent that spawned you. Call it once, when the task is done, before your final message. The parent receives the result as JSON, so put everything it needs in it; your final message can stay short.`,
+		RawJSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"result": result,
+			},
+			"required": []interface{}{"result"},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmitResultToolSpec_DefaultSchema(t *testing.T) {
+	spec := NewEmitResultToolSpec(nil)
+	assert.Equal(t, "emit_result", spec.Name)
+
+	props, ok := spec.RawJSONSchema["properties"].(map[string]interface{})
+	require.True(t, ok)
+	result, ok := props["result"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "object", result["type"])
+	assert.Equal(t, []interface{}{"result"}, spec.RawJSONSchema["required"])
+}
+
+func TestNewEmitResultToolSpec_WithSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"summary"},
+	}
+	spec := NewEmitResultToolSpec(schema)
+
+	props := spec.RawJSONSchema["properties"].(map[string]interface{})
+	assert.Equal(t, schema, props["result"])
+}
@@ -62,6 +62,7 @@ func NewExecCommandToolSpec() ToolSpec {
 			Description: "Maximum number of tokens to return. Excess output will be truncated.",
 			Required:    false,
 		},
+		timeoutSecondsParameter("the process may run in all, including after this call returns a session_id"),
 	}
 	params = append(params, approvalParameters(true)...)
 
@@ -71,7 +72,8 @@ func NewExecCommandToolSpec() ToolSpec {
 - For short commands, the output and exit code are returned immediately.
 - For long-running commands, a session_id is returned. Use write_stdin to send further input and poll for output.
 - Set tty=true for interactive commands (REPLs, editors) that need terminal emulation.
-- yield_time_ms controls how long to wait for initial output (default 10s, max 30s).`,
+- yield_time_ms controls how long to wait for initial output (default 10s, max 30s).
+- timeout_seconds stops the process if it is still running after that long.`,
 		Parameters:       params,
 		DefaultTimeoutMs: DefaultExecCommandTimeoutMs,
 		RetryPolicy:      RetryNone, // stateful session — don't retry
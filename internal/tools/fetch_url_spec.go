@@ -0,0 +1,54 @@
+// Fetch URL tool specification: read a web page as markdown.
+//
+// The tool is enabled with fetch_url_tool = true in config.toml. Pages are
+// fetched by the worker that runs the session.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "fetch_url", Constructor: NewFetchURLToolSpec, ReadOnly: true})
+}
+
+// fetch_url output limits, in characters.
+const (
+	DefaultFetchURLMaxChars = 20_000
+	MaxFetchURLMaxChars     = 100_000
+)
+
+// DefaultFetchURLTimeoutMs covers the robots.txt check and the page fetch.
+const DefaultFetchURLTimeoutMs = 60_000
+
+// NewFetchURLToolSpec creates the specification for the fetch_url tool.
+func NewFetchURLToolSpec() ToolSpec {
+	return ToolSpec{
+		Name: "fetch_url",
+		Description: `Fetches a web page over HTTP(S) and returns its main content as markdown. Use it to read documentation, READMEs, changelogs and API references.
+- HTML pages are reduced to their main content (navigation, scripts and footers are dropped) and converted to markdown.
+- JSON is returned pretty-printed; other text is returned as is. Images, archives and other binary content are rejected.
+- Pages disallowed by the site's robots.txt are not fetched.
+- Long pages are truncated to max_chars; use start_index to read further.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "url",
+				Type:        "string",
+				Description: "Absolute http:// or https:// URL to fetch.",
+				Required:    true,
+			},
+			{
+				Name:        "max_chars",
+				Type:        "number",
+				Description: "Maximum characters of content to return. Defaults to 20000, max 100000.",
+				Required:    false,
+			},
+			{
+				Name:        "start_index",
+				Type:        "number",
+				Description: "Character offset to start from, to continue a truncated page. Defaults to 0.",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultFetchURLTimeoutMs,
+		RetryPolicy:      RetryDefault, // read-only
+	}
+}
@@ -0,0 +1,105 @@
+// GitHub tool specifications: fetch an issue, open a pull request, comment.
+//
+// The tools form the "github" group, enabled with github_tools = true in
+// config.toml. They run on the worker with its GITHUB_TOKEN.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "gh_get_issue", Constructor: NewGitHubGetIssueToolSpec, Group: "github", ReadOnly: true})
+	RegisterSpec(SpecEntry{Name: "gh_create_pr", Constructor: NewGitHubCreatePRToolSpec, Group: "github"})
+	RegisterSpec(SpecEntry{Name: "gh_comment", Constructor: NewGitHubCommentToolSpec, Group: "github"})
+}
+
+// gitHubRepoParameter is the optional repository argument shared by the
+// GitHub tools.
+func gitHubRepoParameter() ToolParameter {
+	return ToolParameter{
+		Name:        "repo",
+		Type:        "string",
+		Description: `Repository as "owner/name". Defaults to the repository of the working directory's "origin" remote.`,
+		Required:    false,
+	}
+}
+
+// NewGitHubGetIssueToolSpec creates the specification for the gh_get_issue tool.
+func NewGitHubGetIssueToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "gh_get_issue",
+		Description: `Fetch a GitHub issue or pull request: title, state, labels, body and comments. Use it to read the task before implementing it.`,
+		Parameters: []ToolParameter{
+			gitHubRepoParameter(),
+			{
+				Name:        "number",
+				Type:        "number",
+				Description: "Issue or pull request number.",
+				Required:    true,
+			},
+		},
+	}
+}
+
+// NewGitHubCreatePRToolSpec creates the specification for the gh_create_pr tool.
+func NewGitHubCreatePRToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "gh_create_pr",
+		Description: `Open a GitHub pull request from a branch that is already pushed. Push the branch with git first. Mention the issue it fixes (e.g. "Fixes #12") in the body.`,
+		Parameters: []ToolParameter{
+			gitHubRepoParameter(),
+			{
+				Name:        "title",
+				Type:        "string",
+				Description: "Pull request title.",
+				Required:    true,
+			},
+			{
+				Name:        "head",
+				Type:        "string",
+				Description: `Branch with the changes. Use "owner:branch" for a branch in a fork.`,
+				Required:    true,
+			},
+			{
+				Name:        "base",
+				Type:        "string",
+				Description: "Branch to merge into. Defaults to the repository's default branch.",
+				Required:    false,
+			},
+			{
+				Name:        "body",
+				Type:        "string",
+				Description: "Pull request description (Markdown).",
+				Required:    false,
+			},
+			{
+				Name:        "draft",
+				Type:        "boolean",
+				Description: "Open the pull request as a draft.",
+				Required:    false,
+			},
+		},
+	}
+}
+
+// NewGitHubCommentToolSpec creates the specification for the gh_comment tool.
+func NewGitHubCommentToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "gh_comment",
+		Description: `Post a comment on a GitHub issue or pull request.`,
+		Parameters: []ToolParameter{
+			gitHubRepoParameter(),
+			{
+				Name:        "number",
+				Type:        "number",
+				Description: "Issue or pull request number.",
+				Required:    true,
+			},
+			{
+				Name:        "body",
+				Type:        "string",
+				Description: "Comment text (Markdown).",
+				Required:    true,
+			},
+		},
+	}
+}
@@ -0,0 +1,53 @@
+// Diff-aware search tool specification: grep only the files changed in git.
+//
+// Reviewing a change in a large repository rarely needs a tree-wide search;
+// grep_changed keeps the search (and its output) to the files under review.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "grep_changed", Constructor: NewGrepChangedToolSpec, ReadOnly: true})
+}
+
+// NewGrepChangedToolSpec creates the specification for the grep_changed tool.
+func NewGrepChangedToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "grep_changed",
+		Description: "Finds changed files whose contents match the pattern and lists them by modification time. Searches only files modified, staged or untracked in the git working tree, or the files changed in git_range when given. Prefer it over grep_files when reviewing a change.",
+		Parameters: []ToolParameter{
+			{
+				Name:        "pattern",
+				Type:        "string",
+				Description: "Regular expression pattern to search for.",
+				Required:    true,
+			},
+			{
+				Name:        "git_range",
+				Type:        "string",
+				Description: "Optional git revision range whose changed files are searched (e.g. \"main...HEAD\" or \"HEAD~3\"). Defaults to uncommitted changes.",
+				Required:    false,
+			},
+			{
+				Name:        "include",
+				Type:        "string",
+				Description: "Optional glob that limits which files are searched (e.g. \"*.rs\" or \"*.{ts,tsx}\").",
+				Required:    false,
+			},
+			{
+				Name:        "path",
+				Type:        "string",
+				Description: "Directory or file path to search in. Defaults to the current working directory.",
+				Required:    false,
+			},
+			{
+				Name:        "limit",
+				Type:        "number",
+				Description: "Maximum number of file paths to return (defaults to 100).",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultGrepFilesTimeoutMs,
+		RetryPolicy:      RetryDefault, // read-only — safe to retry
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"os"
 
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
@@ -36,6 +37,9 @@ func (t *ApplyPatchTool) IsMutating(invocation *tools.ToolInvocation) bool {
 }
 
 // Handle parses the patch from the "input" argument and applies it to the filesystem.
+// The optional "fuzzy_threshold" (0 to 1) lets hunks that don't match exactly
+// apply at their closest match. A hunk that still doesn't apply fails the
+// call with the conflict attached to the output.
 //
 // Maps to: codex-rs/core/src/tools/handlers/apply_patch.rs handle
 func (t *ApplyPatchTool) Handle(_ context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
@@ -53,6 +57,15 @@ func (t *ApplyPatchTool) Handle(_ context.Context, invocation *tools.ToolInvocat
 		return nil, tools.NewValidationError("input cannot be empty")
 	}
 
+	var opts patch.ApplyOptions
+	if raw, ok := invocation.Arguments["fuzzy_threshold"]; ok && raw != nil {
+		threshold, ok := raw.(float64)
+		if !ok || threshold <= 0 || threshold > 1 {
+			return nil, tools.NewValidationError("fuzzy_threshold must be a number greater than 0 and at most 1")
+		}
+		opts.FuzzyThreshold = threshold
+	}
+
 	// Use the current working directory as the base for resolving relative paths.
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -63,13 +76,18 @@ func (t *ApplyPatchTool) Handle(_ context.Context, invocation *tools.ToolInvocat
 		}, nil
 	}
 
-	result, err := patch.Apply(input, cwd)
+	result, err := patch.ApplyWithOptions(input, cwd, opts)
 	if err != nil {
 		success := false
-		return &tools.ToolOutput{
+		output := &tools.ToolOutput{
 			Content: err.Error(),
 			Success: &success,
-		}, nil
+		}
+		var applyErr *patch.ApplyError
+		if errors.As(err, &applyErr) {
+			output.PatchConflict = applyErr.Conflict
+		}
+		return output, nil
 	}
 
 	success := true
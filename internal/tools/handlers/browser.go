@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/browser"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// Browser action limits, shorter than the activity timeout so the model
+// learns what went wrong.
+const (
+	browserLoadTimeout     = 30 * time.Second
+	browserSelectorTimeout = 10 * time.Second
+)
+
+// BrowserTool implements the browser tool family (browser_navigate,
+// browser_click, browser_read_text, browser_screenshot). Each session
+// drives its own headless Chrome from the worker's pool, which only loads
+// the session's allowed domains.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type BrowserTool struct {
+	name string
+	pool *browser.Pool
+}
+
+// NewBrowserNavigateTool creates the browser_navigate handler.
+func NewBrowserNavigateTool(pool *browser.Pool) *BrowserTool {
+	return &BrowserTool{name: "browser_navigate", pool: pool}
+}
+
+// NewBrowserClickTool creates the browser_click handler.
+func NewBrowserClickTool(pool *browser.Pool) *BrowserTool {
+	return &BrowserTool{name: "browser_click", pool: pool}
+}
+
+// NewBrowserReadTextTool creates the browser_read_text handler.
+func NewBrowserReadTextTool(pool *browser.Pool) *BrowserTool {
+	return &BrowserTool{name: "browser_read_text", pool: pool}
+}
+
+// NewBrowserScreenshotTool creates the browser_screenshot handler.
+func NewBrowserScreenshotTool(pool *browser.Pool) *BrowserTool {
+	return &BrowserTool{name: "browser_screenshot", pool: pool}
+}
+
+// Name returns the tool's name.
+func (t *BrowserTool) Name() string {
+	return t.name
+}
+
+// Kind returns ToolKindFunction.
+func (t *BrowserTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns true for the tools that act on the page: a click can
+// submit a form.
+func (t *BrowserTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return t.name == "browser_navigate" || t.name == "browser_click"
+}
+
+// Handle runs the action in the session's browser. Page errors, blocked
+// URLs and missing elements are returned as failed output for the model to
+// read.
+func (t *BrowserTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	args := invocation.Arguments
+	selector, _ := args["selector"].(string)
+	selector = strings.TrimSpace(selector)
+
+	// Validate arguments before starting a browser.
+	var run func(b *browser.Browser) *tools.ToolOutput
+	switch t.name {
+	case "browser_navigate":
+		rawURL, _ := args["url"].(string)
+		if rawURL == "" {
+			return nil, tools.NewValidationError("missing required argument: url")
+		}
+		target, err := url.Parse(rawURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, tools.NewValidationErrorf("url must be an absolute http:// or https:// URL, got %q", rawURL)
+		}
+		run = func(b *browser.Browser) *tools.ToolOutput {
+			actx, cancel := context.WithTimeout(ctx, browserLoadTimeout)
+			defer cancel()
+			page, err := b.Navigate(actx, rawURL)
+			if errors.Is(err, browser.ErrBlocked) {
+				return browserFailure(t.name, fmt.Errorf("%s is not on the session's browser allowlist; add its host to [browser] allowed_domains", target.Host))
+			}
+			if err != nil && ctx.Err() == nil && actx.Err() != nil {
+				err = fmt.Errorf("the page did not finish loading within %s", browserLoadTimeout)
+			}
+			return browserPageOutput(t.name, page, "", err)
+		}
+	case "browser_click":
+		if selector == "" {
+			return nil, tools.NewValidationError("missing required argument: selector")
+		}
+		run = func(b *browser.Browser) *tools.ToolOutput {
+			actx, cancel := context.WithTimeout(ctx, browserSelectorTimeout)
+			defer cancel()
+			page, err := b.Click(actx, selector)
+			return browserPageOutput(t.name, page, "", selectorError(ctx, actx, selector, err))
+		}
+	case "browser_read_text":
+		maxChars, err := intArgOrDefault(args, "max_chars", tools.DefaultBrowserTextMaxChars)
+		if err != nil {
+			return nil, err
+		}
+		if maxChars <= 0 {
+			maxChars = tools.DefaultBrowserTextMaxChars
+		}
+		maxChars = min(maxChars, tools.MaxBrowserTextMaxChars)
+		run = func(b *browser.Browser) *tools.ToolOutput {
+			actx, cancel := context.WithTimeout(ctx, browserSelectorTimeout)
+			defer cancel()
+			text, page, err := b.ReadText(actx, selector)
+			if err != nil {
+				return browserPageOutput(t.name, page, "", selectorError(ctx, actx, selector, err))
+			}
+			runes := []rune(strings.TrimSpace(text))
+			body := string(runes)
+			if len(runes) > maxChars {
+				body = string(runes[:maxChars]) + fmt.Sprintf("\n\n[Text truncated: showing %d of %d characters.]", maxChars, len(runes))
+			}
+			return browserPageOutput(t.name, page, body, nil)
+		}
+	case "browser_screenshot":
+		fullPage := parseBoolArg(args, "full_page", false)
+		run = func(b *browser.Browser) *tools.ToolOutput {
+			actx, cancel := context.WithTimeout(ctx, browserSelectorTimeout)
+			defer cancel()
+			path, page, err := b.Screenshot(actx, selector, fullPage)
+			if err != nil {
+				return browserPageOutput(t.name, page, "", selectorError(ctx, actx, selector, err))
+			}
+			out := browserPageOutput(t.name, page, "Screenshot saved to "+path, nil)
+			out.Attachments = attachment.FromFiles([]string{path})
+			return out
+		}
+	default:
+		return nil, tools.NewValidationErrorf("unknown browser tool: %s", t.name)
+	}
+
+	b, started, err := t.pool.Acquire(browserSessionKey(invocation), invocation.BrowserAllowedDomains)
+	if err != nil {
+		return browserFailure(t.name, err), nil
+	}
+	out := run(b)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if started && t.name != "browser_navigate" {
+		out.Content = "No browser was open for this session; started a new one on a blank page. Use browser_navigate first.\n\n" + out.Content
+	}
+	return out, nil
+}
+
+// browserSessionKey identifies the browser for an invocation: one per
+// session.
+func browserSessionKey(inv *tools.ToolInvocation) string {
+	if inv.SessionID != "" {
+		return inv.SessionID
+	}
+	return inv.Cwd
+}
+
+// selectorError explains an action that ran out of time waiting for
+// selector, which chromedp reports only as a context error.
+func selectorError(ctx, actx context.Context, selector string, err error) error {
+	if err != nil && selector != "" && ctx.Err() == nil && actx.Err() != nil {
+		return fmt.Errorf("no visible element matches %q (waited %s)", selector, browserSelectorTimeout)
+	}
+	return err
+}
+
+// browserPageOutput reports where the tab is after an action, followed by
+// body. A non-nil err makes the output failed.
+func browserPageOutput(name string, page browser.Page, body string, err error) *tools.ToolOutput {
+	var b strings.Builder
+	if err != nil {
+		fmt.Fprintf(&b, "%s failed: %v\n", name, err)
+	}
+	if page.URL != "" {
+		fmt.Fprintf(&b, "URL: %s\n", page.URL)
+	}
+	if page.Title != "" {
+		fmt.Fprintf(&b, "Title: %s\n", page.Title)
+	}
+	if len(page.Blocked) > 0 {
+		fmt.Fprintf(&b, "Blocked requests to hosts not on the allowlist: %s\n", strings.Join(page.Blocked, ", "))
+	}
+	if body != "" {
+		fmt.Fprintf(&b, "\n%s", body)
+	}
+	success := err == nil
+	return &tools.ToolOutput{Content: strings.TrimRight(b.String(), "\n"), Success: &success}
+}
+
+func browserFailure(name string, err error) *tools.ToolOutput {
+	success := false
+	return &tools.ToolOutput{Content: fmt.Sprintf("%s failed: %v", name, err), Success: &success}
+}
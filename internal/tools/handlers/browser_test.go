@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/browser"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestBrowserTool_ValidatesArguments(t *testing.T) {
+	pool := browser.NewPool(browser.Options{ExecPath: "/nonexistent/chrome"})
+	tests := []struct {
+		tool *BrowserTool
+		args map[string]interface{}
+	}{
+		{NewBrowserNavigateTool(pool), map[string]interface{}{}},
+		{NewBrowserNavigateTool(pool), map[string]interface{}{"url": "file:///etc/passwd"}},
+		{NewBrowserNavigateTool(pool), map[string]interface{}{"url": "localhost:3000"}},
+		{NewBrowserClickTool(pool), map[string]interface{}{"selector": "  "}},
+		{NewBrowserReadTextTool(pool), map[string]interface{}{"max_chars": "lots"}},
+	}
+	for _, tt := range tests {
+		_, err := tt.tool.Handle(context.Background(), &tools.ToolInvocation{ToolName: tt.tool.Name(), Arguments: tt.args})
+		var validationErr *tools.ValidationError
+		assert.ErrorAs(t, err, &validationErr, "%s %v", tt.tool.Name(), tt.args)
+	}
+}
+
+func TestBrowserTool_ChromeUnavailable(t *testing.T) {
+	pool := browser.NewPool(browser.Options{ExecPath: "/nonexistent/chrome"})
+	out, err := NewBrowserNavigateTool(pool).Handle(context.Background(), &tools.ToolInvocation{
+		ToolName:  "browser_navigate",
+		Arguments: map[string]interface{}{"url": "http://localhost:3000"},
+		SessionID: "session-1",
+	})
+	require.NoError(t, err)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "browser_navigate failed: failed to start Chrome")
+}
+
+func TestBrowserTool_IsMutating(t *testing.T) {
+	pool := browser.NewPool(browser.Options{})
+	assert.True(t, NewBrowserNavigateTool(pool).IsMutating(nil))
+	assert.True(t, NewBrowserClickTool(pool).IsMutating(nil))
+	assert.False(t, NewBrowserReadTextTool(pool).IsMutating(nil))
+	assert.False(t, NewBrowserScreenshotTool(pool).IsMutating(nil))
+}
+
+func TestBrowserPageOutput(t *testing.T) {
+	out := browserPageOutput("browser_read_text",
+		browser.Page{URL: "http://localhost:3000/", Title: "Home", Blocked: []string{"cdn.example.com"}},
+		"Welcome", nil)
+	assert.True(t, *out.Success)
+	assert.Equal(t, "URL: http://localhost:3000/\nTitle: Home\nBlocked requests to hosts not on the allowlist: cdn.example.com\n\nWelcome", out.Content)
+}
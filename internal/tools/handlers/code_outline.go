@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// Outline size limits. A directory with more than this is outlined
+// partially, with a note asking for a narrower path.
+const (
+	outlineMaxFiles    = 2000
+	outlineMaxLines    = 600
+	outlineMaxFileSize = 1 << 20
+)
+
+// outlineSkipDirs are never descended into.
+var outlineSkipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "testdata": true, "target": true,
+	"dist": true, "build": true, "__pycache__": true, "venv": true,
+}
+
+// outlineSymbol is one declaration in a file's outline.
+type outlineSymbol struct {
+	Line  int
+	Depth int    // Nesting level (methods inside classes)
+	Text  string // Declaration without its body, e.g. "func (s *Server) Start() error"
+}
+
+// outlineDir is a directory's outlined files; Package is set for Go
+// packages, e.g. "package artifacts (example.com/m/internal/artifacts)".
+type outlineDir struct {
+	Package string
+	Files   map[string][]outlineSymbol // By base name
+}
+
+// CodeOutlineTool lists the declarations under a directory.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type CodeOutlineTool struct{}
+
+// NewCodeOutlineTool creates a new code_outline tool handler.
+func NewCodeOutlineTool() *CodeOutlineTool {
+	return &CodeOutlineTool{}
+}
+
+// Name returns the tool's name.
+func (t *CodeOutlineTool) Name() string {
+	return "code_outline"
+}
+
+// Kind returns ToolKindFunction.
+func (t *CodeOutlineTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns false - outlining only reads files.
+func (t *CodeOutlineTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return false
+}
+
+// Handle outlines the requested path.
+func (t *CodeOutlineTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	root := invocation.Cwd
+	if p, ok := invocation.Arguments["path"].(string); ok && strings.TrimSpace(p) != "" {
+		root = resolveToolPath(invocation, strings.TrimSpace(p))
+	}
+	if root == "" {
+		return nil, tools.NewValidationError("path is required when the session has no working directory")
+	}
+	private, _ := invocation.Arguments["private"].(bool)
+
+	info, err := os.Stat(root)
+	if err != nil {
+		success := false
+		return &tools.ToolOutput{Content: fmt.Sprintf("unable to access `%s`: %v", root, err), Success: &success}, nil
+	}
+
+	files, truncated := outlineFiles(root, info)
+	dirs := outlineGo(ctx, root, info.IsDir(), files, private)
+	for _, f := range files {
+		if strings.HasSuffix(f, ".go") {
+			continue
+		}
+		lang := outlineLanguageFor(f)
+		if lang == nil {
+			continue
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		syms := lang.outline(string(data), private)
+		if len(syms) == 0 {
+			continue
+		}
+		d := dirs[filepath.Dir(f)]
+		if d == nil {
+			d = &outlineDir{Files: map[string][]outlineSymbol{}}
+			dirs[filepath.Dir(f)] = d
+		}
+		d.Files[filepath.Base(f)] = syms
+	}
+
+	base := root
+	if !info.IsDir() {
+		base = filepath.Dir(root)
+	}
+	success := true
+	return &tools.ToolOutput{Content: formatOutline(base, dirs, truncated), Success: &success}, nil
+}
+
+// outlineFiles lists the source files to outline under root, skipping
+// hidden, vendored and build directories. truncated is set when there were
+// more than outlineMaxFiles.
+func outlineFiles(root string, info fs.FileInfo) (files []string, truncated bool) {
+	if !info.IsDir() {
+		return []string{root}, false
+	}
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || outlineSkipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && outlineLanguageFor(name) == nil {
+			return nil
+		}
+		if fi, err := d.Info(); err != nil || fi.Size() > outlineMaxFileSize {
+			return nil
+		}
+		if len(files) == outlineMaxFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, truncated
+}
+
+// formatOutline renders the outline, one block per directory, relative to
+// base, within outlineMaxLines.
+func formatOutline(base string, dirs map[string]*outlineDir, truncated bool) string {
+	paths := make([]string, 0, len(dirs))
+	fileCount, symbolCount := 0, 0
+	for p, d := range dirs {
+		paths = append(paths, p)
+		fileCount += len(d.Files)
+		for _, syms := range d.Files {
+			symbolCount += len(syms)
+		}
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		return "No declarations found."
+	}
+
+	var lines []string
+	for _, p := range paths {
+		d := dirs[p]
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			rel = p
+		}
+		header := filepath.ToSlash(rel)
+		if d.Package != "" {
+			header += "  " + d.Package
+		}
+		lines = append(lines, "", header)
+		names := make([]string, 0, len(d.Files))
+		for name := range d.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lines = append(lines, "  "+name)
+			for _, s := range d.Files[name] {
+				lines = append(lines, fmt.Sprintf("    %5d %s%s", s.Line, strings.Repeat("  ", s.Depth), s.Text))
+			}
+		}
+	}
+
+	var b strings.Builder
+	files := "files"
+	if fileCount == 1 {
+		files = "file"
+	}
+	fmt.Fprintf(&b, "%d declarations in %d %s", symbolCount, fileCount, files)
+	if len(lines) > outlineMaxLines {
+		lines = lines[:outlineMaxLines]
+		truncated = true
+	}
+	b.WriteString("\n" + strings.Join(lines, "\n") + "\n")
+	if truncated {
+		b.WriteString("\n... outline truncated; outline a subdirectory for the rest\n")
+	}
+	return b.String()
+}
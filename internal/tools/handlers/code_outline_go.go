@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// outlineMaxDeclLen caps a rendered declaration.
+const outlineMaxDeclLen = 160
+
+// outlineGo outlines the Go files among files, grouped by directory. The
+// packages are loaded with go/packages, which honours build constraints
+// and names each package's import path; files it does not cover (no go
+// toolchain, files excluded by build tags, code outside a module) are
+// parsed directly.
+func outlineGo(ctx context.Context, root string, isDir bool, files []string, private bool) map[string]*outlineDir {
+	dirs := map[string]*outlineDir{}
+	var goFiles []string
+	for _, f := range files {
+		if strings.HasSuffix(f, ".go") {
+			goFiles = append(goFiles, f)
+		}
+	}
+	if len(goFiles) == 0 {
+		return dirs
+	}
+
+	fset := token.NewFileSet()
+	parsed := map[string]*ast.File{}
+	pkgNames := map[string]string{} // By directory
+	dir, pattern := root, "./..."
+	if !isDir {
+		dir, pattern = filepath.Dir(root), "file="+root
+	}
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:     dir,
+		Fset:    fset,
+		Env:     append(os.Environ(), "GOTOOLCHAIN=local"),
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			return parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+		},
+	}
+	if pkgs, err := packages.Load(cfg, pattern); err == nil {
+		for _, pkg := range pkgs {
+			for _, f := range pkg.Syntax {
+				name := canonicalPath(fset.File(f.Pos()).Name())
+				parsed[name] = f
+				if pkg.PkgPath != "" {
+					pkgNames[filepath.Dir(name)] = fmt.Sprintf("package %s (%s)", pkg.Name, pkg.PkgPath)
+				}
+			}
+		}
+	}
+
+	for _, file := range goFiles {
+		f := parsed[canonicalPath(file)]
+		if f == nil {
+			var err error
+			if f, err = parser.ParseFile(fset, file, nil, parser.SkipObjectResolution); f == nil || err != nil && f.Name == nil {
+				continue
+			}
+		}
+		syms := goOutline(fset, f, private)
+		if len(syms) == 0 {
+			continue
+		}
+		d := dirs[filepath.Dir(file)]
+		if d == nil {
+			d = &outlineDir{Package: pkgNames[canonicalPath(filepath.Dir(file))], Files: map[string][]outlineSymbol{}}
+			if d.Package == "" {
+				d.Package = "package " + f.Name.Name
+			}
+			dirs[filepath.Dir(file)] = d
+		}
+		d.Files[filepath.Base(file)] = syms
+	}
+	return dirs
+}
+
+// canonicalPath resolves symlinks so paths from go list and from the
+// directory walk compare equal.
+func canonicalPath(p string) string {
+	if resolved, err := filepath.EvalSymlinks(p); err == nil {
+		return resolved
+	}
+	return p
+}
+
+// goOutline lists a file's top-level declarations, and the methods of its
+// interfaces, in source order.
+func goOutline(fset *token.FileSet, f *ast.File, private bool) []outlineSymbol {
+	show := func(name string) bool { return private || ast.IsExported(name) }
+	var syms []outlineSymbol
+	add := func(pos token.Pos, depth int, text string) {
+		syms = append(syms, outlineSymbol{Line: fset.Position(pos).Line, Depth: depth, Text: truncateDecl(text)})
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !show(d.Name.Name) || d.Recv != nil && !show(receiverType(d.Recv)) {
+				continue
+			}
+			add(d.Pos(), 0, printNode(fset, &ast.FuncDecl{Recv: d.Recv, Name: d.Name, Type: d.Type}))
+
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.TYPE:
+				for _, spec := range d.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if !show(ts.Name.Name) {
+						continue
+					}
+					add(ts.Pos(), 0, "type "+typeSpecSummary(fset, ts))
+					if iface, ok := ts.Type.(*ast.InterfaceType); ok {
+						for _, m := range iface.Methods.List {
+							if len(m.Names) == 1 && show(m.Names[0].Name) {
+								add(m.Pos(), 1, m.Names[0].Name+strings.TrimPrefix(printNode(fset, m.Type), "func"))
+							}
+						}
+					}
+				}
+			case token.CONST, token.VAR:
+				var names []string
+				pos := token.NoPos
+				for _, spec := range d.Specs {
+					for _, n := range spec.(*ast.ValueSpec).Names {
+						if n.Name != "_" && show(n.Name) {
+							if pos == token.NoPos {
+								pos = n.Pos()
+							}
+							names = append(names, n.Name)
+						}
+					}
+				}
+				if len(names) == 0 {
+					continue
+				}
+				if len(names) > 6 {
+					names = append(names[:6], fmt.Sprintf("… (%d more)", len(names)-6))
+				}
+				add(pos, 0, d.Tok.String()+" "+strings.Join(names, ", "))
+			}
+		}
+	}
+	return syms
+}
+
+// typeSpecSummary renders a type's name and, for structs and interfaces,
+// only its kind: "Server struct", "Handler interface", "ID = string".
+func typeSpecSummary(fset *token.FileSet, ts *ast.TypeSpec) string {
+	summary := &ast.TypeSpec{Name: ts.Name, TypeParams: ts.TypeParams, Assign: ts.Assign, Type: ts.Type}
+	switch ts.Type.(type) {
+	case *ast.StructType:
+		summary.Type = ast.NewIdent("struct")
+	case *ast.InterfaceType:
+		summary.Type = ast.NewIdent("interface")
+	}
+	return printNode(fset, summary)
+}
+
+// receiverType returns the base type name of a method receiver.
+func receiverType(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	t := recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// printNode renders node on one line.
+func printNode(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// truncateDecl shortens an overlong declaration.
+func truncateDecl(s string) string {
+	if len(s) <= outlineMaxDeclLen {
+		return s
+	}
+	return s[:outlineMaxDeclLen-1] + "…"
+}
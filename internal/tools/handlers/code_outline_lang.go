@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// outlinePattern matches one kind of declaration line. Its "name" group
+// captures the declared name.
+type outlinePattern struct {
+	re        *regexp.Regexp
+	container bool // Declares a class-like scope whose members are outlined
+	member    bool // Only counts directly inside a container (methods)
+}
+
+// outlineLanguage outlines a language from its declaration lines. There is
+// no parser for these languages in the worker, so declarations are found by
+// pattern and nested by indentation, which holds for conventionally
+// formatted code.
+type outlineLanguage struct {
+	patterns []outlinePattern
+	// isPrivate reports whether decl, the trimmed declaration line of name,
+	// is hidden unless private is requested. parent is the enclosing
+	// declaration, or "" at top level. nil means everything is listed.
+	isPrivate func(decl, name, parent string) bool
+}
+
+// outlineNotNames are keywords the looser member patterns can mistake for
+// a name ("if (x) {", "return foo(x);").
+var outlineNotNames = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "function": true, "with": true, "else": true, "do": true,
+	"new": true, "throw": true, "case": true, "await": true, "yield": true,
+	"synchronized": true, "using": true, "lock": true, "foreach": true,
+}
+
+var (
+	outlinePython = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^class\s+(?P<name>\w+)`), container: true},
+			{re: regexp.MustCompile(`^(?:async\s+)?def\s+(?P<name>\w+)`)},
+		},
+		isPrivate: func(decl, name, parent string) bool {
+			dunder := strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")
+			return strings.HasPrefix(name, "_") && !dunder
+		},
+	}
+
+	outlineJS = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\s+(?P<name>[\w$]+)`), container: true},
+			{re: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?interface\s+(?P<name>[\w$]+)`), container: true},
+			{re: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>[\w$]+)`)},
+			{re: regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?(?:type|enum)\s+(?P<name>[\w$]+)`)},
+			{re: regexp.MustCompile(`^export\s+(?:const|let|var)\s+(?P<name>[\w$]+)`)},
+			{re: regexp.MustCompile(`^(?:(?:public|private|protected|static|async|readonly|abstract|override|get|set|declare)\s+)*\*?(?P<name>#?[\w$]+)\??\s*[<(]`), member: true},
+		},
+		isPrivate: func(decl, name, parent string) bool {
+			if parent == "" {
+				return !strings.HasPrefix(decl, "export")
+			}
+			return hasModifier(decl, "private") || strings.HasPrefix(name, "#") || strings.HasPrefix(name, "_")
+		},
+	}
+
+	outlineRust = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?(?:trait|mod)\s+(?P<name>\w+)`), container: true},
+			{re: regexp.MustCompile(`^(?:unsafe\s+)?impl\b(?P<name>)`), container: true},
+			{re: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:default\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+(?P<name>\w+)`)},
+			{re: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|union|type)\s+(?P<name>\w+)`)},
+			{re: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:const|static(?:\s+mut)?)\s+(?P<name>\w+)\s*:`)},
+		},
+		isPrivate: func(decl, name, parent string) bool {
+			if strings.HasPrefix(decl, "pub") || strings.HasPrefix(decl, "impl") || strings.HasPrefix(decl, "unsafe impl") {
+				return false
+			}
+			// Trait items and trait implementations are as visible as the trait.
+			return !strings.Contains(parent, "trait ") && !strings.Contains(parent, " for ")
+		},
+	}
+
+	outlineJava = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp)\s+)*(?:class|interface|enum|record|@interface)\s+(?P<name>\w+)`), container: true},
+			{re: regexp.MustCompile(`^(?:(?:public|protected|private|abstract|final|static|synchronized|native|default|strictfp)\s+)*(?:<[^>]+>\s+)?(?:[\w.?\[\]]+(?:<[^()]*>)?(?:\[\])*\s+)?(?P<name>\w+)\s*\(`), member: true},
+		},
+		isPrivate: classMemberPrivate,
+	}
+
+	outlineCSharp = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^(?:(?:public|protected|private|internal|abstract|sealed|static|partial|readonly|unsafe|file)\s+)*(?:class|interface|enum|struct|record(?:\s+struct|\s+class)?)\s+(?P<name>\w+)`), container: true},
+			{re: regexp.MustCompile(`^(?:(?:public|protected|private|internal|abstract|sealed|static|virtual|override|async|extern|unsafe|partial|readonly)\s+)*(?:[\w.?\[\]]+(?:<[^()]*>)?(?:\[\])*\??\s+)?(?P<name>\w+)\s*(?:<[^()]*>)?\s*\(`), member: true},
+		},
+		isPrivate: classMemberPrivate,
+	}
+
+	outlineKotlin = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^(?:(?:public|private|internal|protected|open|abstract|sealed|data|enum|inner|annotation|value|final|companion|expect|actual)\s+)*(?:class|interface|object)\s+(?P<name>[\w` + "`" + `]+)`), container: true},
+			{re: regexp.MustCompile(`^(?:(?:public|private|internal|protected|open|abstract|override|suspend|inline|operator|infix|tailrec|external|final|expect|actual)\s+)*(?:fun|typealias)\s+(?:<[^>]+>\s+)?(?P<name>[\w.` + "`" + `]+)`)},
+		},
+		isPrivate: func(decl, name, parent string) bool {
+			return hasModifier(decl, "private")
+		},
+	}
+
+	outlineRuby = &outlineLanguage{
+		patterns: []outlinePattern{
+			{re: regexp.MustCompile(`^(?:class|module)\s+(?P<name>[\w:]+)`), container: true},
+			{re: regexp.MustCompile(`^def\s+(?P<name>(?:self\.)?[\w?!=\[\]<>+\-*/%]+)`)},
+		},
+	}
+)
+
+// outlineLanguages maps file extensions to their outliner.
+var outlineLanguages = map[string]*outlineLanguage{
+	".py": outlinePython,
+	".js": outlineJS, ".jsx": outlineJS, ".mjs": outlineJS, ".cjs": outlineJS,
+	".ts": outlineJS, ".tsx": outlineJS, ".mts": outlineJS, ".cts": outlineJS,
+	".rs":   outlineRust,
+	".java": outlineJava,
+	".cs":   outlineCSharp,
+	".kt":   outlineKotlin, ".kts": outlineKotlin,
+	".rb": outlineRuby,
+}
+
+// outlineLanguageFor returns the outliner for a file name, or nil when the
+// language is not supported.
+func outlineLanguageFor(name string) *outlineLanguage {
+	return outlineLanguages[strings.ToLower(filepath.Ext(name))]
+}
+
+// outline lists src's declarations in source order. Members of hidden
+// declarations are hidden too.
+func (l *outlineLanguage) outline(src string, private bool) []outlineSymbol {
+	type scope struct {
+		indent    int
+		text      string
+		hidden    bool
+		container bool
+	}
+	var stack []scope
+	var syms []outlineSymbol
+
+	for i, line := range strings.Split(src, "\n") {
+		decl := strings.TrimSpace(line)
+		if decl == "" || isCommentLine(decl) {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		var parent *scope
+		if len(stack) > 0 {
+			parent = &stack[len(stack)-1]
+		}
+		for _, p := range l.patterns {
+			m := p.re.FindStringSubmatch(decl)
+			if m == nil {
+				continue
+			}
+			if p.member && (parent == nil || !parent.container) {
+				continue
+			}
+			name := m[p.re.SubexpIndex("name")]
+			if p.member && (outlineNotNames[name] || outlineNotNames[strings.Fields(decl)[0]]) {
+				break
+			}
+			parentText := ""
+			if parent != nil {
+				parentText = parent.text
+			}
+			hidden := parent != nil && parent.hidden ||
+				!private && l.isPrivate != nil && l.isPrivate(decl, name, parentText)
+			if !hidden {
+				syms = append(syms, outlineSymbol{Line: i + 1, Depth: len(stack), Text: truncateDecl(declHead(decl))})
+			}
+			stack = append(stack, scope{indent: indent, text: decl, hidden: hidden, container: p.container})
+			break
+		}
+	}
+	return syms
+}
+
+// classMemberPrivate applies Java and C# visibility: members are listed
+// when public or protected, or when they belong to an interface.
+func classMemberPrivate(decl, name, parent string) bool {
+	if hasModifier(decl, "private") || hasModifier(decl, "internal") {
+		return true
+	}
+	if hasModifier(decl, "public") || hasModifier(decl, "protected") {
+		return false
+	}
+	return !strings.Contains(parent, "interface ")
+}
+
+// hasModifier reports whether word appears among decl's words before its
+// parameter list.
+func hasModifier(decl, word string) bool {
+	head, _, _ := strings.Cut(decl, "(")
+	for _, f := range strings.Fields(head) {
+		if f == word {
+			return true
+		}
+	}
+	return false
+}
+
+// isCommentLine reports whether a trimmed line is a comment, or an
+// attribute, decorator or annotation, none of which declare anything.
+func isCommentLine(s string) bool {
+	for _, prefix := range []string{"//", "/*", "*", "#", "@"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// declHead strips a declaration line's opening brace or colon.
+func declHead(decl string) string {
+	decl = strings.TrimSpace(strings.TrimSuffix(decl, "{"))
+	return strings.TrimSpace(strings.TrimSuffix(decl, ":"))
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func writeOutlineFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func runOutline(t *testing.T, dir string, args map[string]interface{}) string {
+	t.Helper()
+	out, err := NewCodeOutlineTool().Handle(context.Background(), &tools.ToolInvocation{
+		CallID:    "call-1",
+		ToolName:  "code_outline",
+		Arguments: args,
+		Cwd:       dir,
+	})
+	require.NoError(t, err)
+	require.True(t, *out.Success, out.Content)
+	return out.Content
+}
+
+const outlineGoSource = `package store
+
+// Store keeps items.
+type Store struct {
+	items map[string]int
+}
+
+type Getter interface {
+	Get(key string) (int, bool)
+}
+
+type cache struct{}
+
+const MaxItems = 10
+
+func New() *Store {
+	return &Store{}
+}
+
+func (s *Store) Get(key string) (int, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+func (s *Store) evict() {}
+`
+
+func TestCodeOutline_Go(t *testing.T) {
+	dir := writeOutlineFiles(t, map[string]string{
+		"go.mod":              "module example.com/m\n\ngo 1.21\n",
+		"store/store.go":      outlineGoSource,
+		"store/store_test.go": "package store\n\nfunc TestX() {}\n",
+	})
+
+	out := runOutline(t, dir, map[string]interface{}{})
+	assert.Contains(t, out, "store  package store (example.com/m/store)")
+	assert.Contains(t, out, "  store.go\n")
+	assert.Contains(t, out, "4 type Store struct")
+	assert.Contains(t, out, "8 type Getter interface")
+	assert.Contains(t, out, "9   Get(key string) (int, bool)")
+	assert.Contains(t, out, "14 const MaxItems")
+	assert.Contains(t, out, "16 func New() *Store")
+	assert.Contains(t, out, "20 func (s *Store) Get(key string) (int, bool)")
+	assert.NotContains(t, out, "cache")
+	assert.NotContains(t, out, "evict")
+	assert.NotContains(t, out, "store_test.go", "tests are not outlined")
+	assert.NotContains(t, out, "return", "bodies are omitted")
+
+	out = runOutline(t, dir, map[string]interface{}{"path": "store/store.go", "private": true})
+	assert.Contains(t, out, "12 type cache struct")
+	assert.Contains(t, out, "25 func (s *Store) evict()")
+}
+
+func TestCodeOutline_GoOutsideModule(t *testing.T) {
+	dir := writeOutlineFiles(t, map[string]string{"a.go": "package a\n\nfunc Hello[T any](v T) string { return \"\" }\n"})
+	out := runOutline(t, dir, map[string]interface{}{})
+	assert.Contains(t, out, ".  package a")
+	assert.Contains(t, out, "3 func Hello[T any](v T) string")
+}
+
+func TestCodeOutline_OtherLanguages(t *testing.T) {
+	dir := writeOutlineFiles(t, map[string]string{
+		"app/service.py": `import os
+
+
+class Service:
+    """Serves."""
+
+    def __init__(self, name):
+        self.name = name
+
+    def start(self, port: int) -> None:
+        if port:
+            pass
+
+    def _reset(self):
+        pass
+
+
+def _helper():
+    pass
+
+
+async def main():
+    pass
+`,
+		"web/client.ts": `import { x } from "./x";
+
+export interface Options {
+  timeout: number;
+  retry(n: number): void;
+}
+
+export class Client {
+  private token = "";
+
+  constructor(opts: Options) {
+    if (opts.timeout) {
+      this.token = "";
+    }
+  }
+
+  async fetch(path: string): Promise<string> {
+    return path;
+  }
+
+  private sign(req: string) {
+    return req;
+  }
+}
+
+function internal() {}
+
+export function connect(opts: Options): Client {
+  return new Client(opts);
+}
+`,
+		"notes.md": "# class NotCode\n",
+	})
+
+	out := runOutline(t, dir, map[string]interface{}{})
+	assert.Contains(t, out, "app\n  service.py\n")
+	assert.Contains(t, out, "4 class Service")
+	assert.Contains(t, out, "7   def __init__(self, name)")
+	assert.Contains(t, out, "10   def start(self, port: int) -> None")
+	assert.Contains(t, out, "22 async def main()")
+	assert.NotContains(t, out, "_reset")
+	assert.NotContains(t, out, "_helper")
+
+	assert.Contains(t, out, "3 export interface Options")
+	assert.Contains(t, out, "5   retry(n: number): void;")
+	assert.Contains(t, out, "8 export class Client")
+	assert.Contains(t, out, "11   constructor(opts: Options)")
+	assert.Contains(t, out, "17   async fetch(path: string): Promise<string>")
+	assert.Contains(t, out, "28 export function connect(opts: Options): Client")
+	assert.NotContains(t, out, "sign")
+	assert.NotContains(t, out, "internal")
+	assert.NotContains(t, out, "if (")
+	assert.NotContains(t, out, "NotCode")
+
+	out = runOutline(t, dir, map[string]interface{}{"private": true})
+	assert.Contains(t, out, "14   def _reset(self)")
+	assert.Contains(t, out, "18 def _helper()")
+	assert.Contains(t, out, "21   private sign(req: string)")
+	assert.Contains(t, out, "26 function internal()")
+}
+
+func TestOutlineLanguage_Rust(t *testing.T) {
+	src := `pub struct Server {
+    addr: String,
+}
+
+impl Server {
+    pub fn new(addr: &str) -> Self {
+        Server { addr: addr.into() }
+    }
+
+    fn bind(&self) {}
+}
+
+impl Display for Server {
+    fn fmt(&self, f: &mut Formatter) -> Result {
+        Ok(())
+    }
+}
+
+fn helper() {}
+`
+	var lines []string
+	for _, s := range outlineLanguageFor("main.rs").outline(src, false) {
+		lines = append(lines, strings.Repeat("  ", s.Depth)+s.Text)
+	}
+	assert.Equal(t, []string{
+		"pub struct Server",
+		"impl Server",
+		"  pub fn new(addr: &str) -> Self",
+		"impl Display for Server",
+		"  fn fmt(&self, f: &mut Formatter) -> Result",
+	}, lines)
+}
+
+func TestCodeOutline_Truncates(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package big\n\n")
+	for i := 0; i < outlineMaxLines+10; i++ {
+		b.WriteString("func F" + strings.Repeat("x", i%5) + "A" + string(rune('a'+i%26)) + "() {}\n")
+	}
+	dir := writeOutlineFiles(t, map[string]string{"big.go": b.String()})
+	out := runOutline(t, dir, map[string]interface{}{})
+	assert.Contains(t, out, "outline truncated")
+}
+
+func TestCodeOutline_MissingPath(t *testing.T) {
+	out, err := NewCodeOutlineTool().Handle(context.Background(), &tools.ToolInvocation{
+		ToolName:  "code_outline",
+		Arguments: map[string]interface{}{"path": "nope"},
+		Cwd:       t.TempDir(),
+	})
+	require.NoError(t, err)
+	assert.False(t, *out.Success)
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// fetch_url limits.
+const (
+	fetchURLRequestTimeout = 30 * time.Second
+	maxFetchURLBodyBytes   = 5 << 20
+	maxRobotsTxtBytes      = 500 << 10
+	maxFetchURLRedirects   = 5
+)
+
+// fetchURLAgent is the product token matched against robots.txt User-agent
+// lines, and the start of the User-Agent header.
+const fetchURLAgent = "temporal-agent-harness"
+
+// errRobotsDisallowed is returned when robots.txt forbids a fetch.
+var errRobotsDisallowed = errors.New("disallowed by robots.txt")
+
+// errNonPublicAddress is returned when a fetch would connect to the
+// worker's own network rather than the internet.
+var errNonPublicAddress = errors.New("refusing to connect to a non-public address")
+
+// nonPublicPrefixes are internal IPv4 ranges that netip counts as global
+// unicast: "this network" and carrier-grade NAT (RFC 6598).
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// FetchURLTool implements fetch_url: an HTTP GET whose response is reduced
+// to readable text. The request runs on the worker, not in the sandbox, so
+// it may only connect to public addresses: loopback, private, link-local
+// (including the 169.254.169.254 metadata endpoint) and other internal
+// ranges are refused when dialing, after DNS resolution and on every
+// redirect.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type FetchURLTool struct {
+	client *http.Client
+
+	// allowNonPublic disables the address check; tests use it to reach
+	// httptest servers on loopback.
+	allowNonPublic bool
+}
+
+// NewFetchURLTool creates the fetch_url handler. Redirects are followed up
+// to five times and each target is checked against its own robots.txt.
+// Proxy environment variables are ignored, since the address check must
+// see the real destination.
+func NewFetchURLTool() *FetchURLTool {
+	t := &FetchURLTool{}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: t.checkDialAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	t.client = &http.Client{
+		Timeout:   fetchURLRequestTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchURLRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchURLRedirects)
+			}
+			if req.URL.Host != via[len(via)-1].URL.Host {
+				return t.checkRobots(req.Context(), req.URL)
+			}
+			return nil
+		},
+	}
+	return t
+}
+
+// Name returns the tool's name.
+func (t *FetchURLTool) Name() string {
+	return "fetch_url"
+}
+
+// Kind returns ToolKindFunction.
+func (t *FetchURLTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns false: fetch_url only reads.
+func (t *FetchURLTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return false
+}
+
+// Handle fetches the URL and returns its content. Network and HTTP errors
+// are returned as failed output for the model to read.
+func (t *FetchURLTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	rawURL, _ := invocation.Arguments["url"].(string)
+	if rawURL == "" {
+		return nil, tools.NewValidationError("missing required argument: url")
+	}
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, tools.NewValidationErrorf("url must be an absolute http:// or https:// URL, got %q", rawURL)
+	}
+	maxChars, err := intArgOrDefault(invocation.Arguments, "max_chars", tools.DefaultFetchURLMaxChars)
+	if err != nil {
+		return nil, err
+	}
+	if maxChars <= 0 {
+		maxChars = tools.DefaultFetchURLMaxChars
+	}
+	maxChars = min(maxChars, tools.MaxFetchURLMaxChars)
+	start, err := intArgOrDefault(invocation.Arguments, "start_index", 0)
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 {
+		return nil, tools.NewValidationError("start_index must not be negative")
+	}
+
+	if err := t.checkRobots(ctx, target); err != nil {
+		return fetchURLFailure(target, err), nil
+	}
+	page, err := t.fetch(ctx, target)
+	if err != nil {
+		return fetchURLFailure(target, err), nil
+	}
+
+	content := []rune(page.content)
+	if start > 0 && start >= len(content) {
+		return fetchURLFailure(target, fmt.Errorf("start_index %d is past the end of the content (%d characters)", start, len(content))), nil
+	}
+	end := min(start+maxChars, len(content))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "URL: %s\n", page.url)
+	if page.title != "" {
+		fmt.Fprintf(&b, "Title: %s\n", page.title)
+	}
+	fmt.Fprintf(&b, "Content-Type: %s\n\n", page.mediaType)
+	b.WriteString(string(content[start:end]))
+	if end < len(content) {
+		fmt.Fprintf(&b, "\n\n[Content truncated: showing characters %d-%d of %d. Call fetch_url again with start_index=%d to continue.]", start, end, len(content), end)
+	} else if page.bodyTruncated {
+		fmt.Fprintf(&b, "\n\n[Response body exceeded %d MB and was cut off.]", maxFetchURLBodyBytes>>20)
+	}
+	success := true
+	return &tools.ToolOutput{Content: b.String(), Success: &success}, nil
+}
+
+// fetchedPage is a response reduced to text.
+type fetchedPage struct {
+	url           string
+	title         string
+	mediaType     string
+	content       string
+	bodyTruncated bool
+}
+
+func (t *FetchURLTool) fetch(ctx context.Context, target *url.URL) (*fetchedPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fetchURLAgent+" (fetch_url)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/json,text/plain;q=0.9,*/*;q=0.5")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchURLBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	page := &fetchedPage{url: resp.Request.URL.String()}
+	if len(body) > maxFetchURLBodyBytes {
+		body = body[:maxFetchURLBodyBytes]
+		page.bodyTruncated = true
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Type %q", contentType)
+	}
+	page.mediaType = mediaType
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		r, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			return nil, err
+		}
+		page.title, page.content, err = htmlToMarkdown(r, resp.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse HTML: %w", err)
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") == nil {
+			page.content = pretty.String()
+		} else {
+			page.content = string(body)
+		}
+	case isTextMediaType(mediaType):
+		r, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			return nil, err
+		}
+		text, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(text) {
+			return nil, fmt.Errorf("content is not valid text (%s)", mediaType)
+		}
+		page.content = string(text)
+	default:
+		return nil, fmt.Errorf("unsupported content type %s: only HTML, JSON and text can be read", mediaType)
+	}
+	return page, nil
+}
+
+// checkDialAddress is the dialer's Control hook. It runs for every
+// connection with the resolved IP, so a public name that resolves to an
+// internal address is refused as well.
+func (t *FetchURLTool) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if t.allowNonPublic {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errNonPublicAddress, host)
+	}
+	if !isPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", errNonPublicAddress, addr)
+	}
+	return nil
+}
+
+// isPublicAddr reports whether addr is a globally routable unicast address.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTextMediaType reports whether a media type is readable as plain text.
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/xml", "application/javascript", "application/x-javascript",
+		"application/toml", "application/yaml", "application/x-yaml", "application/x-sh":
+		return true
+	}
+	return false
+}
+
+// fetchURLFailure is the output for a fetch that could not be completed.
+func fetchURLFailure(target *url.URL, err error) *tools.ToolOutput {
+	success := false
+	return &tools.ToolOutput{Content: fmt.Sprintf("fetch_url %s failed: %v", target, err), Success: &success}
+}
+
+// checkRobots returns errRobotsDisallowed if the host's robots.txt forbids
+// fetching target. Following RFC 9309, a missing robots.txt (4xx) allows
+// everything and an unreachable one (5xx) disallows everything.
+func (t *FetchURLTool) checkRobots(ctx context.Context, target *url.URL) error {
+	robotsURL := &url.URL{Scheme: target.Scheme, Host: target.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", fetchURLAgent+" (fetch_url)")
+	client := &http.Client{Timeout: t.client.Timeout, Transport: t.client.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("robots.txt unavailable (HTTP %s)", resp.Status)
+	case resp.StatusCode >= 400:
+		return nil
+	case resp.StatusCode >= 300:
+		return nil // unresolved redirect; treat as missing
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsTxtBytes))
+	if err != nil {
+		return fmt.Errorf("read robots.txt: %w", err)
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	if !robotsAllowed(string(body), fetchURLAgent, path) {
+		return errRobotsDisallowed
+	}
+	return nil
+}
+
+// robotsAllowed evaluates a robots.txt file for agent and path. Groups
+// naming the agent take precedence over "*"; within them the longest
+// matching rule wins, and Allow wins a tie.
+func robotsAllowed(robots, agent, path string) bool {
+	type rule struct {
+		allow   bool
+		pattern string
+	}
+	var (
+		agentRules, starRules []rule
+		groupAgents           []string
+		inRules               bool
+		matchedAgent          bool
+	)
+	flush := func(r rule) {
+		for _, a := range groupAgents {
+			switch {
+			case a == "*":
+				starRules = append(starRules, r)
+			case strings.EqualFold(a, agent):
+				agentRules = append(agentRules, r)
+			}
+		}
+	}
+	scanner := bufio.NewScanner(strings.NewReader(robots))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			groupAgents = append(groupAgents, value)
+			if strings.EqualFold(value, agent) {
+				matchedAgent = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value != "" {
+				flush(rule{allow: key == "allow", pattern: value})
+			}
+		}
+	}
+
+	rules := starRules
+	if matchedAgent {
+		rules = agentRules
+	}
+	allowed, longest := true, -1
+	for _, r := range rules {
+		if !robotsPatternMatches(r.pattern, path) {
+			continue
+		}
+		if l := len(r.pattern); l > longest || (l == longest && r.allow) {
+			allowed, longest = r.allow, l
+		}
+	}
+	return allowed
+}
+
+// robotsPatternMatches matches a robots.txt path pattern, which supports
+// "*" wildcards and a trailing "$" anchor, against a path.
+func robotsPatternMatches(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	re, err := regexp.Compile(expr)
+	return err == nil && re.MatchString(path)
+}
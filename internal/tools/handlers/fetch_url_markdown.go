@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements never carry readable content.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Canvas: true, atom.Object: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Form: true,
+	atom.Button: true, atom.Select: true, atom.Input: true, atom.Textarea: true,
+	atom.Head: true,
+}
+
+// boilerplatePattern matches class and id tokens of page chrome that sites
+// build from plain divs.
+var boilerplatePattern = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|navigation|sidebar|menu|footer|breadcrumbs?|cookies?|banner|advert|ads|share|social|skip-link)([\s_-]|$)`)
+
+// blockElements start a new paragraph in the markdown output.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Main: true, atom.Header: true, atom.Body: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Details: true, atom.Summary: true, atom.Address: true,
+}
+
+var (
+	excessNewlines = regexp.MustCompile(`\n{3,}`)
+	spaceRun       = regexp.MustCompile(`[ \t\r\n\f]+`)
+)
+
+// htmlToMarkdown extracts the readable content of an HTML page and converts
+// it to markdown. The page's <main> or largest <article> is used when there
+// is one; otherwise the body minus navigation, sidebars and footers. Relative
+// links are resolved against base.
+func htmlToMarkdown(r io.Reader, base *url.URL) (title, markdown string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	if t := findElement(doc, atom.Title); t != nil {
+		title = strings.TrimSpace(spaceRun.ReplaceAllString(textContent(t), " "))
+	}
+
+	w := &markdownWriter{base: base, skipHeader: true}
+	root := mainContent(doc)
+	if root.DataAtom == atom.Main || root.DataAtom == atom.Article {
+		// A header inside the main content usually holds the page heading.
+		w.skipHeader = false
+	}
+	w.children(root)
+	return title, cleanMarkdown(w.buf.String()), nil
+}
+
+// mainContent picks the node holding the page's primary content.
+func mainContent(doc *html.Node) *html.Node {
+	if n := findElement(doc, atom.Main); n != nil {
+		return n
+	}
+	if n := findNode(doc, func(n *html.Node) bool { return attr(n, "role") == "main" }); n != nil {
+		return n
+	}
+	var best *html.Node
+	bestLen := 0
+	walk(doc, func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Article {
+			if l := len(strings.TrimSpace(textContent(n))); l > bestLen {
+				best, bestLen = n, l
+			}
+		}
+	})
+	if best != nil {
+		return best
+	}
+	if n := findElement(doc, atom.Body); n != nil {
+		return n
+	}
+	return doc
+}
+
+// markdownWriter renders an HTML tree as markdown.
+type markdownWriter struct {
+	buf        bytes.Buffer
+	base       *url.URL
+	skipHeader bool
+	listDepth  int
+}
+
+func (w *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *markdownWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+	if w.skipped(n) {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		w.block()
+		w.buf.WriteString(strings.Repeat("#", level) + " ")
+		w.children(n)
+		w.block()
+	case atom.Br:
+		w.buf.WriteString("\n")
+	case atom.Hr:
+		w.block()
+		w.buf.WriteString("---")
+		w.block()
+	case atom.A:
+		w.link(n)
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			fmt.Fprintf(&w.buf, "![%s](%s)", alt, w.resolve(attr(n, "src")))
+		}
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "_")
+	case atom.Code, atom.Kbd, atom.Samp:
+		if code := textContent(n); strings.TrimSpace(code) != "" {
+			fence := "`"
+			if strings.Contains(code, "`") {
+				fence = "``"
+			}
+			w.buf.WriteString(fence + code + fence)
+		}
+	case atom.Pre:
+		w.pre(n)
+	case atom.Ul, atom.Ol:
+		w.list(n)
+	case atom.Blockquote:
+		w.blockquote(n)
+	case atom.Table:
+		w.table(n)
+	default:
+		if blockElements[n.DataAtom] && w.listDepth == 0 {
+			w.block()
+			w.children(n)
+			w.block()
+			return
+		}
+		if blockElements[n.DataAtom] {
+			w.space()
+		}
+		w.children(n)
+	}
+}
+
+// skipped reports whether n and its subtree are left out of the output.
+func (w *markdownWriter) skipped(n *html.Node) bool {
+	if skippedElements[n.DataAtom] || (w.skipHeader && n.DataAtom == atom.Header) {
+		return true
+	}
+	if _, hidden := attrValue(n, "hidden"); hidden || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "complementary", "search":
+		return true
+	}
+	if n.DataAtom == atom.Div || n.DataAtom == atom.Section || n.DataAtom == atom.Ul {
+		return boilerplatePattern.MatchString(attr(n, "class")) || boilerplatePattern.MatchString(attr(n, "id"))
+	}
+	return false
+}
+
+// text writes a text node with HTML whitespace collapsed.
+func (w *markdownWriter) text(s string) {
+	s = spaceRun.ReplaceAllString(s, " ")
+	if s == "" {
+		return
+	}
+	if strings.HasPrefix(s, " ") && w.atLineStart() {
+		s = s[1:]
+	}
+	w.buf.WriteString(s)
+}
+
+func (w *markdownWriter) atLineStart() bool {
+	b := w.buf.Bytes()
+	return len(b) == 0 || b[len(b)-1] == '\n' || b[len(b)-1] == ' '
+}
+
+// block ends the current paragraph.
+func (w *markdownWriter) block() {
+	if w.listDepth > 0 {
+		w.space()
+		return
+	}
+	w.buf.WriteString("\n\n")
+}
+
+// space separates inline content without starting a new line.
+func (w *markdownWriter) space() {
+	if !w.atLineStart() {
+		w.buf.WriteString(" ")
+	}
+}
+
+// render renders n's children on their own and returns the result, leaving
+// the buffer unchanged.
+func (w *markdownWriter) render(n *html.Node) string {
+	start := w.buf.Len()
+	w.children(n)
+	s := w.buf.String()[start:]
+	w.buf.Truncate(start)
+	return s
+}
+
+func (w *markdownWriter) wrap(n *html.Node, marker string) {
+	inner := w.render(n)
+	trimmed := strings.TrimSpace(inner)
+	if trimmed == "" {
+		w.buf.WriteString(inner)
+		return
+	}
+	if strings.HasPrefix(inner, " ") {
+		w.space()
+	}
+	w.buf.WriteString(marker + trimmed + marker)
+	if strings.HasSuffix(inner, " ") {
+		w.buf.WriteString(" ")
+	}
+}
+
+func (w *markdownWriter) link(n *html.Node) {
+	inner := strings.TrimSpace(w.render(n))
+	href := strings.TrimSpace(attr(n, "href"))
+	if inner == "" {
+		return
+	}
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		w.buf.WriteString(inner)
+		return
+	}
+	fmt.Fprintf(&w.buf, "[%s](%s)", inner, w.resolve(href))
+}
+
+func (w *markdownWriter) resolve(ref string) string {
+	if w.base == nil {
+		return ref
+	}
+	u, err := w.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func (w *markdownWriter) pre(n *html.Node) {
+	lang := codeLanguage(n)
+	if code := findElement(n, atom.Code); code != nil && lang == "" {
+		lang = codeLanguage(code)
+	}
+	code := strings.Trim(textContent(n), "\n")
+	if w.listDepth > 0 {
+		w.buf.WriteString("\n")
+	} else {
+		w.block()
+	}
+	fmt.Fprintf(&w.buf, "```%s\n%s\n```\n", lang, code)
+	if w.listDepth == 0 {
+		w.buf.WriteString("\n")
+	}
+}
+
+// codeLanguage reads a "language-x" or "lang-x" class.
+func codeLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+func (w *markdownWriter) list(n *html.Node) {
+	if w.listDepth == 0 {
+		w.block()
+	}
+	indent := strings.Repeat("  ", w.listDepth)
+	w.listDepth++
+	index := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		if w.buf.Len() > 0 && !bytes.HasSuffix(w.buf.Bytes(), []byte("\n")) {
+			w.buf.WriteString("\n")
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", index)
+		}
+		w.buf.WriteString(indent + marker)
+		w.children(c)
+		index++
+	}
+	w.listDepth--
+	if w.listDepth == 0 {
+		w.block()
+	} else {
+		w.buf.WriteString("\n")
+	}
+}
+
+func (w *markdownWriter) blockquote(n *html.Node) {
+	inner := strings.TrimSpace(cleanMarkdown(w.render(n)))
+	if inner == "" {
+		return
+	}
+	w.block()
+	lines := strings.Split(inner, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	w.buf.WriteString(strings.Join(lines, "\n"))
+	w.block()
+}
+
+func (w *markdownWriter) table(n *html.Node) {
+	var rows [][]string
+	walk(n, func(c *html.Node) {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Tr {
+			return
+		}
+		var cells []string
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+				text := spaceRun.ReplaceAllString(w.render(cell), " ")
+				cells = append(cells, strings.ReplaceAll(strings.TrimSpace(text), "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+	if len(rows) == 0 {
+		return
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	w.block()
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		w.buf.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			w.buf.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	w.block()
+}
+
+// cleanMarkdown trims trailing spaces and collapses blank line runs.
+func cleanMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(excessNewlines.ReplaceAllString(s, "\n\n"))
+}
+
+// textContent returns the concatenated text below n.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	})
+	return b.String()
+}
+
+// walk calls fn for n and every node below it, in document order.
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// findNode returns the first node below n, in document order, matching fn.
+func findNode(n *html.Node, fn func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && fn(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, fn); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	return findNode(n, func(n *html.Node) bool { return n.DataAtom == a })
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrValue(n, key)
+	return v
+}
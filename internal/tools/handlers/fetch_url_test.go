@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+const fetchURLTestPage = `<!DOCTYPE html>
+<html><head><title>Widget API</title><style>body{color:red}</style></head>
+<body>
+<header><a href="/">Home</a> <a href="/blog">Blog</a></header>
+<nav><ul><li><a href="/docs">Docs</a></li></ul></nav>
+<div class="sidebar">Related pages</div>
+<main>
+  <h1>Widgets</h1>
+  <p>Create a widget with <code>NewWidget</code>. See the
+     <a href="/docs/config">configuration guide</a> for <strong>all</strong> options.</p>
+  <ul>
+    <li>Fast</li>
+    <li>Small
+      <ol><li>really small</li></ol>
+    </li>
+  </ul>
+  <pre><code class="language-go">w := NewWidget()
+w.Run()</code></pre>
+  <table>
+    <tr><th>Option</th><th>Default</th></tr>
+    <tr><td>size</td><td>10</td></tr>
+  </table>
+  <script>trackPageView()</script>
+</main>
+<footer>Copyright 2026</footer>
+</body></html>`
+
+func newFetchURLServer(t *testing.T, robots string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(robots))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(fetchURLTestPage))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"widget","tags":["a","b"]}`))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	})
+	mux.HandleFunc("/long.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("abcdefghij", 10)))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fetchURL(t *testing.T, args map[string]interface{}) *tools.ToolOutput {
+	t.Helper()
+	tool := NewFetchURLTool()
+	tool.allowNonPublic = true // httptest servers listen on loopback
+	out, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		CallID:    "test-call",
+		ToolName:  "fetch_url",
+		Arguments: args,
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestFetchURL_HTMLToMarkdown(t *testing.T) {
+	srv := newFetchURLServer(t, "")
+
+	out := fetchURL(t, map[string]interface{}{"url": srv.URL + "/moved"})
+	require.True(t, *out.Success, out.Content)
+	assert.Contains(t, out.Content, "URL: "+srv.URL+"/page\n")
+	assert.Contains(t, out.Content, "Title: Widget API\n")
+	assert.Contains(t, out.Content, "Content-Type: text/html\n")
+	assert.Contains(t, out.Content, "# Widgets\n\nCreate a widget with `NewWidget`. See the [configuration guide]("+srv.URL+"/docs/config) for **all** options.")
+	assert.Contains(t, out.Content, "- Fast\n- Small\n  1. really small")
+	assert.Contains(t, out.Content, "```go\nw := NewWidget()\nw.Run()\n```")
+	assert.Contains(t, out.Content, "| Option | Default |\n| --- | --- |\n| size | 10 |")
+	for _, chrome := range []string{"Blog", "Docs", "Related pages", "Copyright", "trackPageView", "color:red"} {
+		assert.NotContains(t, out.Content, chrome)
+	}
+}
+
+func TestFetchURL_JSONIsPrettyPrinted(t *testing.T) {
+	srv := newFetchURLServer(t, "")
+
+	out := fetchURL(t, map[string]interface{}{"url": srv.URL + "/data.json"})
+	require.True(t, *out.Success, out.Content)
+	assert.Contains(t, out.Content, "{\n  \"name\": \"widget\",\n  \"tags\": [\n    \"a\",")
+}
+
+func TestFetchURL_BinaryIsRejected(t *testing.T) {
+	srv := newFetchURLServer(t, "")
+
+	out := fetchURL(t, map[string]interface{}{"url": srv.URL + "/image.png"})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "unsupported content type image/png")
+}
+
+func TestFetchURL_Truncation(t *testing.T) {
+	srv := newFetchURLServer(t, "")
+
+	out := fetchURL(t, map[string]interface{}{"url": srv.URL + "/long.txt", "max_chars": float64(30)})
+	require.True(t, *out.Success)
+	assert.Contains(t, out.Content, "\n\nabcdefghijabcdefghijabcdefghij\n\n[Content truncated: showing characters 0-30 of 100. Call fetch_url again with start_index=30 to continue.]")
+
+	out = fetchURL(t, map[string]interface{}{"url": srv.URL + "/long.txt", "start_index": float64(90)})
+	require.True(t, *out.Success)
+	assert.True(t, strings.HasSuffix(out.Content, "\n\nabcdefghij"))
+
+	out = fetchURL(t, map[string]interface{}{"url": srv.URL + "/long.txt", "start_index": float64(100)})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "past the end")
+}
+
+func TestFetchURL_RespectsRobotsTxt(t *testing.T) {
+	srv := newFetchURLServer(t, "User-agent: *\nDisallow: /page\nAllow: /data.json\n")
+
+	out := fetchURL(t, map[string]interface{}{"url": srv.URL + "/page"})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "disallowed by robots.txt")
+
+	out = fetchURL(t, map[string]interface{}{"url": srv.URL + "/data.json"})
+	assert.True(t, *out.Success)
+}
+
+func TestFetchURL_HTTPError(t *testing.T) {
+	srv := newFetchURLServer(t, "")
+
+	out := fetchURL(t, map[string]interface{}{"url": srv.URL + "/missing"})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "HTTP 404 Not Found")
+}
+
+func TestFetchURL_RefusesNonPublicAddresses(t *testing.T) {
+	srv := newFetchURLServer(t, "")
+
+	out, err := NewFetchURLTool().Handle(context.Background(), &tools.ToolInvocation{
+		ToolName:  "fetch_url",
+		Arguments: map[string]interface{}{"url": srv.URL + "/page"},
+	})
+	require.NoError(t, err)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "refusing to connect to a non-public address: 127.0.0.1")
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":          true,
+		"2606:4700::1111":        true,
+		"127.0.0.1":              false,
+		"::1":                    false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"fe80::1":                false,
+		"fd00::1":                false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"0.1.2.3":                false,
+		"224.0.0.1":              false,
+		"::ffff:169.254.169.254": false,
+	} {
+		assert.Equal(t, public, isPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestFetchURL_InvalidArguments(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{},
+		{"url": "ftp://example.com/file"},
+		{"url": "/relative/path"},
+		{"url": "https://example.com", "start_index": float64(-1)},
+	} {
+		_, err := NewFetchURLTool().Handle(context.Background(), &tools.ToolInvocation{ToolName: "fetch_url", Arguments: args})
+		var validationErr *tools.ValidationError
+		assert.ErrorAs(t, err, &validationErr, "%v", args)
+	}
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	robots := `# comment
+User-agent: *
+Disallow: /private/
+Disallow: /*.pdf$
+Allow: /private/public
+
+User-agent: other-bot
+Disallow: /
+`
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/docs/index.html", true},
+		{"/private/secret", false},
+		{"/private/public/page", true},
+		{"/files/report.pdf", false},
+		{"/files/report.pdf?x=1", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, robotsAllowed(robots, fetchURLAgent, tt.path), tt.path)
+	}
+
+	// A group naming the agent replaces the "*" rules.
+	specific := "User-agent: *\nDisallow: /\n\nUser-agent: " + fetchURLAgent + "\nDisallow: /admin\n"
+	assert.True(t, robotsAllowed(specific, fetchURLAgent, "/docs"))
+	assert.False(t, robotsAllowed(specific, fetchURLAgent, "/admin/users"))
+	assert.True(t, robotsAllowed("", fetchURLAgent, "/anything"))
+}
+
+func TestHTMLToMarkdown_ArticleFallback(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post")
+	page := `<html><body><div id="menu"><a href="/x">Menu</a></div>
+<article><p>Short teaser</p></article>
+<article><h2>Post</h2><blockquote><p>Quoted line</p></blockquote><p><em>Emphasis</em> and <img src="a.png" alt="diagram"></p></article>
+</body></html>`
+	title, md, err := htmlToMarkdown(strings.NewReader(page), base)
+	require.NoError(t, err)
+	assert.Equal(t, "", title)
+	assert.Equal(t, "## Post\n\n> Quoted line\n\n_Emphasis_ and ![diagram](https://example.com/blog/a.png)", md)
+}
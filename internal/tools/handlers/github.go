@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/go-github/v75/github"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// maxIssueComments caps the comments gh_get_issue returns.
+const maxIssueComments = 50
+
+// GitHubTool implements the GitHub tool family (gh_get_issue, gh_create_pr,
+// gh_comment). The worker's GITHUB_TOKEN (or GH_TOKEN) authenticates the
+// calls; GITHUB_API_URL points them at GitHub Enterprise.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type GitHubTool struct {
+	name string
+
+	// newClient builds the API client. Tests replace it to point at a
+	// local server.
+	newClient func() (*github.Client, error)
+}
+
+// NewGitHubGetIssueTool creates the gh_get_issue handler.
+func NewGitHubGetIssueTool() *GitHubTool {
+	return &GitHubTool{name: "gh_get_issue", newClient: newGitHubClientFromEnv}
+}
+
+// NewGitHubCreatePRTool creates the gh_create_pr handler.
+func NewGitHubCreatePRTool() *GitHubTool {
+	return &GitHubTool{name: "gh_create_pr", newClient: newGitHubClientFromEnv}
+}
+
+// NewGitHubCommentTool creates the gh_comment handler.
+func NewGitHubCommentTool() *GitHubTool {
+	return &GitHubTool{name: "gh_comment", newClient: newGitHubClientFromEnv}
+}
+
+// Name returns the tool's name.
+func (t *GitHubTool) Name() string {
+	return t.name
+}
+
+// Kind returns ToolKindFunction.
+func (t *GitHubTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns true for the tools that write to GitHub.
+func (t *GitHubTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return t.name != "gh_get_issue"
+}
+
+// newGitHubClientFromEnv builds an API client from the worker's environment.
+func newGitHubClientFromEnv() (*github.Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("GITHUB_TOKEN is not set on the worker")
+	}
+	client := github.NewClient(nil).WithAuthToken(token)
+	if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		return client.WithEnterpriseURLs(apiURL, apiURL)
+	}
+	return client, nil
+}
+
+// Handle dispatches to the named GitHub operation. API failures are returned
+// as failed output for the model to read, not retried: a retried create
+// could open a second pull request.
+func (t *GitHubTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	owner, repo, err := resolveGitHubRepo(ctx, invocation)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate arguments before touching the network.
+	var run func(*github.Client) (string, error)
+	switch t.name {
+	case "gh_get_issue":
+		number, err := issueNumberArg(invocation.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		run = func(c *github.Client) (string, error) {
+			return getIssue(ctx, c, owner, repo, number)
+		}
+	case "gh_create_pr":
+		pr, err := newPullRequestArgs(invocation.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		run = func(c *github.Client) (string, error) {
+			return createPullRequest(ctx, c, owner, repo, pr)
+		}
+	case "gh_comment":
+		number, err := issueNumberArg(invocation.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		body, _ := invocation.Arguments["body"].(string)
+		if body == "" {
+			return nil, tools.NewValidationError("missing required argument: body")
+		}
+		run = func(c *github.Client) (string, error) {
+			return commentOnIssue(ctx, c, owner, repo, number, body)
+		}
+	default:
+		return nil, tools.NewValidationErrorf("unknown GitHub tool: %s", t.name)
+	}
+
+	client, err := t.newClient()
+	if err != nil {
+		return gitHubFailure(err), nil
+	}
+	content, err := run(client)
+	if err != nil {
+		return gitHubFailure(err), nil
+	}
+	success := true
+	return &tools.ToolOutput{Content: content, Success: &success}, nil
+}
+
+// gitHubFailure is the output for a failed GitHub call.
+func gitHubFailure(err error) *tools.ToolOutput {
+	success := false
+	return &tools.ToolOutput{Content: fmt.Sprintf("GitHub request failed: %v", err), Success: &success}
+}
+
+// issueNumberArg reads the required, positive "number" argument.
+func issueNumberArg(args map[string]interface{}) (int, error) {
+	if _, ok := args["number"]; !ok {
+		return 0, tools.NewValidationError("missing required argument: number")
+	}
+	number, err := intArgOrDefault(args, "number", 0)
+	if err != nil {
+		return 0, err
+	}
+	if number <= 0 {
+		return 0, tools.NewValidationError("number must be a positive issue or pull request number")
+	}
+	return number, nil
+}
+
+// newPullRequestArgs builds the create request from gh_create_pr arguments.
+func newPullRequestArgs(args map[string]interface{}) (*github.NewPullRequest, error) {
+	title, _ := args["title"].(string)
+	if title == "" {
+		return nil, tools.NewValidationError("missing required argument: title")
+	}
+	head, _ := args["head"].(string)
+	if head == "" {
+		return nil, tools.NewValidationError("missing required argument: head")
+	}
+	pr := &github.NewPullRequest{Title: github.Ptr(title), Head: github.Ptr(head)}
+	if base, _ := args["base"].(string); base != "" {
+		pr.Base = github.Ptr(base)
+	}
+	if body, _ := args["body"].(string); body != "" {
+		pr.Body = github.Ptr(body)
+	}
+	if draft, ok := args["draft"].(bool); ok && draft {
+		pr.Draft = github.Ptr(true)
+	}
+	return pr, nil
+}
+
+// getIssue formats an issue (or pull request) and its comments.
+func getIssue(ctx context.Context, c *github.Client, owner, repo string, number int) (string, error) {
+	issue, _, err := c.Issues.Get(ctx, owner, repo, number)
+	if err != nil {
+		return "", err
+	}
+	comments, _, err := c.Issues.ListComments(ctx, owner, repo, number,
+		&github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: maxIssueComments}})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	kind := "Issue"
+	if issue.IsPullRequest() {
+		kind = "Pull request"
+	}
+	fmt.Fprintf(&b, "%s #%d: %s\n", kind, issue.GetNumber(), issue.GetTitle())
+	fmt.Fprintf(&b, "State: %s\n", issue.GetState())
+	fmt.Fprintf(&b, "Author: %s\n", issue.GetUser().GetLogin())
+	if len(issue.Labels) > 0 {
+		names := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			names[i] = l.GetName()
+		}
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&b, "URL: %s\n", issue.GetHTMLURL())
+	if body := strings.TrimSpace(issue.GetBody()); body != "" {
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+
+	if len(comments) > 0 {
+		fmt.Fprintf(&b, "\nComments (%d", len(comments))
+		if issue.GetComments() > len(comments) {
+			fmt.Fprintf(&b, " of %d", issue.GetComments())
+		}
+		b.WriteString("):\n")
+		for _, cm := range comments {
+			fmt.Fprintf(&b, "\n--- %s (%s)\n%s\n", cm.GetUser().GetLogin(),
+				cm.GetCreatedAt().Format("2006-01-02"), strings.TrimSpace(cm.GetBody()))
+		}
+	}
+	return b.String(), nil
+}
+
+// createPullRequest opens a pull request, defaulting the base to the
+// repository's default branch.
+func createPullRequest(ctx context.Context, c *github.Client, owner, repo string, pr *github.NewPullRequest) (string, error) {
+	if pr.Base == nil {
+		r, _, err := c.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			return "", err
+		}
+		pr.Base = github.Ptr(r.GetDefaultBranch())
+	}
+	created, _, err := c.PullRequests.Create(ctx, owner, repo, pr)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Opened pull request #%d (%s into %s): %s",
+		created.GetNumber(), pr.GetHead(), pr.GetBase(), created.GetHTMLURL()), nil
+}
+
+// commentOnIssue posts a comment on an issue or pull request.
+func commentOnIssue(ctx context.Context, c *github.Client, owner, repo string, number int, body string) (string, error) {
+	comment, _, err := c.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: github.Ptr(body)})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Commented on #%d: %s", number, comment.GetHTMLURL()), nil
+}
+
+// resolveGitHubRepo returns the repository a call targets: the "repo"
+// argument, or the working directory's origin remote.
+func resolveGitHubRepo(ctx context.Context, invocation *tools.ToolInvocation) (owner, repo string, err error) {
+	if arg, ok := invocation.Arguments["repo"].(string); ok && arg != "" {
+		owner, repo, ok := parseGitHubRepo(arg)
+		if !ok {
+			return "", "", tools.NewValidationErrorf("repo must be \"owner/name\", got %q", arg)
+		}
+		return owner, repo, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
+	cmd.Dir = invocation.Cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", tools.NewValidationError("repo not given and the working directory has no \"origin\" remote")
+	}
+	remote := strings.TrimSpace(string(out))
+	owner, repo, ok := parseGitHubRepo(remote)
+	if !ok {
+		return "", "", tools.NewValidationErrorf("cannot tell the GitHub repository from remote %q; pass repo", remote)
+	}
+	return owner, repo, nil
+}
+
+// parseGitHubRepo extracts owner and name from "owner/name" or a GitHub
+// remote URL (https://host/owner/name.git, git@host:owner/name.git,
+// ssh://git@host/owner/name).
+func parseGitHubRepo(s string) (owner, repo string, ok bool) {
+	s = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(s), "/"), ".git")
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+		j := strings.Index(s, "/")
+		if j < 0 {
+			return "", "", false
+		}
+		s = s[j+1:]
+	} else if i := strings.Index(s, ":"); i >= 0 {
+		s = s[i+1:] // scp-like git@host:owner/name
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
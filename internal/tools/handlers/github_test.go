@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v75/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// newTestGitHubTool returns tool with its client pointed at a test server
+// serving mux.
+func newTestGitHubTool(t *testing.T, tool *GitHubTool, mux *http.ServeMux) *GitHubTool {
+	t.Helper()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	tool.newClient = func() (*github.Client, error) {
+		c := github.NewClient(nil)
+		u, _ := url.Parse(server.URL + "/")
+		c.BaseURL = u
+		return c, nil
+	}
+	return tool
+}
+
+func TestParseGitHubRepo(t *testing.T) {
+	tests := []struct {
+		in          string
+		owner, repo string
+		ok          bool
+	}{
+		{"octo/widgets", "octo", "widgets", true},
+		{"https://github.com/octo/widgets.git", "octo", "widgets", true},
+		{"https://github.com/octo/widgets/", "octo", "widgets", true},
+		{"git@github.com:octo/widgets.git", "octo", "widgets", true},
+		{"ssh://git@github.com/octo/widgets", "octo", "widgets", true},
+		{"widgets", "", "", false},
+		{"https://github.com/octo", "", "", false},
+		{"octo/widgets/extra", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			owner, repo, ok := parseGitHubRepo(tt.in)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.owner, owner)
+			assert.Equal(t, tt.repo, repo)
+		})
+	}
+}
+
+func TestGitHubTool_IsMutating(t *testing.T) {
+	assert.False(t, NewGitHubGetIssueTool().IsMutating(nil))
+	assert.True(t, NewGitHubCreatePRTool().IsMutating(nil))
+	assert.True(t, NewGitHubCommentTool().IsMutating(nil))
+}
+
+func TestGitHubGetIssue(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/widgets/issues/12", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"number": 12, "title": "Login fails", "state": "open",
+			"body": "Steps to reproduce...", "comments": 1,
+			"user": {"login": "alice"}, "labels": [{"name": "bug"}],
+			"html_url": "https://github.com/octo/widgets/issues/12"}`))
+	})
+	mux.HandleFunc("/repos/octo/widgets/issues/12/comments", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"body": "Also on Safari", "user": {"login": "bob"},
+			"created_at": "2026-01-02T10:00:00Z"}]`))
+	})
+	tool := newTestGitHubTool(t, NewGitHubGetIssueTool(), mux)
+
+	out, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"repo": "octo/widgets", "number": float64(12)},
+	})
+	require.NoError(t, err)
+	require.True(t, *out.Success, out.Content)
+	assert.Contains(t, out.Content, "Issue #12: Login fails")
+	assert.Contains(t, out.Content, "Labels: bug")
+	assert.Contains(t, out.Content, "Steps to reproduce...")
+	assert.Contains(t, out.Content, "--- bob (2026-01-02)\nAlso on Safari")
+}
+
+func TestGitHubCreatePR_DefaultsBaseToDefaultBranch(t *testing.T) {
+	var got map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/widgets", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+	})
+	mux.HandleFunc("/repos/octo/widgets/pulls", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 34, "html_url": "https://github.com/octo/widgets/pull/34"}`))
+	})
+	tool := newTestGitHubTool(t, NewGitHubCreatePRTool(), mux)
+
+	out, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{
+			"repo": "octo/widgets", "title": "Fix login", "head": "fix-login",
+			"body": "Fixes #12", "draft": true,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, *out.Success, out.Content)
+	assert.Equal(t, "Opened pull request #34 (fix-login into main): https://github.com/octo/widgets/pull/34", out.Content)
+	assert.Equal(t, "main", got["base"])
+	assert.Equal(t, "Fixes #12", got["body"])
+	assert.Equal(t, true, got["draft"])
+}
+
+func TestGitHubComment(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/widgets/issues/12/comments", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/octo/widgets/issues/12#issuecomment-1"}`))
+	})
+	tool := newTestGitHubTool(t, NewGitHubCommentTool(), mux)
+
+	out, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"repo": "octo/widgets", "number": float64(12), "body": "Fixed in #34"},
+	})
+	require.NoError(t, err)
+	require.True(t, *out.Success, out.Content)
+	assert.Equal(t, "Commented on #12: https://github.com/octo/widgets/issues/12#issuecomment-1", out.Content)
+}
+
+func TestGitHubTool_APIErrorIsFailedOutput(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/widgets/issues/99", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	})
+	tool := newTestGitHubTool(t, NewGitHubGetIssueTool(), mux)
+
+	out, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"repo": "octo/widgets", "number": float64(99)},
+	})
+	require.NoError(t, err)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "GitHub request failed")
+	assert.Contains(t, out.Content, "404")
+}
+
+func TestGitHubTool_ValidatesArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		tool *GitHubTool
+		args map[string]interface{}
+	}{
+		{"missing number", NewGitHubGetIssueTool(), map[string]interface{}{"repo": "octo/widgets"}},
+		{"bad repo", NewGitHubGetIssueTool(), map[string]interface{}{"repo": "widgets", "number": float64(1)}},
+		{"missing head", NewGitHubCreatePRTool(), map[string]interface{}{"repo": "octo/widgets", "title": "Fix"}},
+		{"missing body", NewGitHubCommentTool(), map[string]interface{}{"repo": "octo/widgets", "number": float64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.tool.Handle(context.Background(), &tools.ToolInvocation{Arguments: tt.args})
+			require.Error(t, err)
+			assert.True(t, tools.IsValidationError(err))
+		})
+	}
+}
+
+func TestGitHubTool_MissingTokenIsFailedOutput(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+
+	out, err := NewGitHubGetIssueTool().Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"repo": "octo/widgets", "number": float64(1)},
+	})
+	require.NoError(t, err)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "GITHUB_TOKEN is not set")
+}
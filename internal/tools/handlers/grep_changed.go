@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// grepChangedBatchSize caps how many file paths are passed to one rg run.
+const grepChangedBatchSize = 500
+
+// GrepChangedTool searches only the files changed in git: uncommitted and
+// untracked files in the working tree, or the files changed in a git range.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type GrepChangedTool struct{}
+
+// NewGrepChangedTool creates a new grep_changed tool handler.
+func NewGrepChangedTool() *GrepChangedTool {
+	return &GrepChangedTool{}
+}
+
+// Name returns the tool's name.
+func (t *GrepChangedTool) Name() string {
+	return "grep_changed"
+}
+
+// Kind returns ToolKindFunction.
+func (t *GrepChangedTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns false - searching files doesn't modify the environment.
+func (t *GrepChangedTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return false
+}
+
+// Handle searches the changed files and returns matching paths.
+func (t *GrepChangedTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	return grepFiles(ctx, invocation, true)
+}
+
+// changedFilesUnder returns the absolute paths of existing files under
+// searchPath that changed in git. With an empty gitRange these are the
+// modified, staged and untracked files of the working tree; otherwise the
+// files changed in gitRange (e.g. "main...HEAD"). Deleted files are skipped.
+func changedFilesUnder(ctx context.Context, searchPath, gitRange string) ([]string, error) {
+	absSearch, err := filepath.Abs(searchPath)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(absSearch); err == nil {
+		absSearch = resolved
+	}
+	dir := absSearch
+	if info, err := os.Stat(absSearch); err == nil && !info.IsDir() {
+		dir = filepath.Dir(absSearch)
+	}
+
+	out, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("searching changed files requires a git repository: %v", err)
+	}
+	root := strings.TrimSpace(string(out))
+
+	var paths []string
+	if gitRange == "" {
+		out, err = runGit(ctx, root, "status", "--porcelain", "-z", "--untracked-files=all")
+		if err != nil {
+			return nil, err
+		}
+		paths = parsePorcelainPaths(out)
+	} else {
+		out, err = runGit(ctx, root, "diff", "--name-only", "-z", "--diff-filter=d", gitRange, "--")
+		if err != nil {
+			return nil, err
+		}
+		paths = splitNul(out)
+	}
+
+	var files []string
+	for _, p := range paths {
+		abs := filepath.Join(root, filepath.FromSlash(p))
+		if abs != absSearch && !strings.HasPrefix(abs, absSearch+string(filepath.Separator)) {
+			continue
+		}
+		if info, err := os.Stat(abs); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, abs)
+	}
+	return files, nil
+}
+
+// runGit runs git in dir and returns its stdout.
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parsePorcelainPaths extracts the current paths from `git status
+// --porcelain -z` output. Renames and copies carry the original path in the
+// following record, which is skipped.
+func parsePorcelainPaths(out []byte) []string {
+	records := splitNul(out)
+	var paths []string
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		if len(rec) < 4 {
+			continue
+		}
+		paths = append(paths, rec[3:])
+		if rec[0] == 'R' || rec[0] == 'C' {
+			i++
+		}
+	}
+	return paths
+}
+
+// splitNul splits NUL-terminated git output into its non-empty records.
+func splitNul(out []byte) []string {
+	var parts []string
+	for _, p := range bytes.Split(out, []byte{0}) {
+		if len(p) > 0 {
+			parts = append(parts, string(p))
+		}
+	}
+	return parts
+}
+
+// runRgSearchFiles runs ripgrep over an explicit file list. rg always
+// searches explicitly named files, so the include glob is applied here.
+func runRgSearchFiles(ctx context.Context, pattern, include string, files []string, limit int) ([]string, error) {
+	if include != "" {
+		var kept []string
+		for _, f := range files {
+			if matchInclude(include, f) {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+
+	var results []string
+	for start := 0; start < len(files) && len(results) < limit; start += grepChangedBatchSize {
+		end := min(start+grepChangedBatchSize, len(files))
+		args := []string{
+			"--files-with-matches",
+			"--sortr=modified",
+			"--regexp", pattern,
+			"--no-messages",
+			"--",
+		}
+		args = append(args, files[start:end]...)
+		batch, err := execRg(ctx, args, limit-len(results))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+// matchInclude reports whether file matches an rg-style include glob such
+// as "*.go" or "*.{ts,tsx}". Globs without a slash match the base name;
+// others match the path suffix.
+func matchInclude(glob, file string) bool {
+	file = filepath.ToSlash(file)
+	for _, g := range expandBraces(glob) {
+		if !strings.Contains(g, "/") {
+			if ok, _ := path.Match(g, path.Base(file)); ok {
+				return true
+			}
+			continue
+		}
+		g = strings.TrimPrefix(g, "**/")
+		parts := strings.Split(file, "/")
+		for i := range parts {
+			if ok, _ := path.Match(g, strings.Join(parts[i:], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandBraces expands the first {a,b} group in glob, recursively.
+func expandBraces(glob string) []string {
+	open := strings.Index(glob, "{")
+	if open < 0 {
+		return []string{glob}
+	}
+	end := strings.Index(glob[open:], "}")
+	if end < 0 {
+		return []string{glob}
+	}
+	end += open
+	var out []string
+	for _, alt := range strings.Split(glob[open+1:end], ",") {
+		out = append(out, expandBraces(glob[:open]+alt+glob[end+1:])...)
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// initChangedRepo creates a git repo with one commit (a.go, b.go, c.go,
+// docs/c.md), then modifies a.go, deletes b.go and adds an untracked docs/new.md.
+func initChangedRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	git("init", "-q")
+	write("a.go", "package a\n")
+	write("b.go", "package b\n")
+	write("c.go", "package c\n")
+	write("docs/c.md", "docs\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	write("a.go", "package a // TODO\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.go")))
+	write("docs/new.md", "TODO\n")
+	return dir
+}
+
+func TestChangedFilesUnder_WorkingTree(t *testing.T) {
+	dir := initChangedRepo(t)
+
+	files, err := changedFilesUnder(context.Background(), dir, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "a.go"),
+		filepath.Join(dir, "docs", "new.md"),
+	}, files, "deleted files are skipped, untracked files are included")
+
+	files, err = changedFilesUnder(context.Background(), filepath.Join(dir, "docs"), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "docs", "new.md")}, files)
+}
+
+func TestChangedFilesUnder_GitRange(t *testing.T) {
+	dir := initChangedRepo(t)
+
+	// Diffing HEAD against the working tree covers tracked changes only.
+	files, err := changedFilesUnder(context.Background(), dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.go")}, files)
+
+	_, err = changedFilesUnder(context.Background(), dir, "no-such-branch...HEAD")
+	assert.Error(t, err)
+}
+
+func TestChangedFilesUnder_NotARepo(t *testing.T) {
+	_, err := changedFilesUnder(context.Background(), t.TempDir(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git repository")
+}
+
+func TestParsePorcelainPaths(t *testing.T) {
+	out := []byte(" M a.go\x00R  new.go\x00old.go\x00?? docs/x.md\x00")
+	assert.Equal(t, []string{"a.go", "new.go", "docs/x.md"}, parsePorcelainPaths(out))
+}
+
+func TestMatchInclude(t *testing.T) {
+	assert.True(t, matchInclude("*.go", "/repo/pkg/a.go"))
+	assert.False(t, matchInclude("*.go", "/repo/pkg/a.md"))
+	assert.True(t, matchInclude("*.{ts,tsx}", "/repo/web/app.tsx"))
+	assert.True(t, matchInclude("docs/*.md", "/repo/docs/x.md"))
+	assert.True(t, matchInclude("**/docs/*.md", "/repo/docs/x.md"))
+	assert.False(t, matchInclude("docs/*.md", "/repo/src/x.md"))
+}
+
+func TestGrepChanged_NoChangedFiles(t *testing.T) {
+	dir := initChangedRepo(t)
+	handler := NewGrepChangedTool()
+
+	output, err := handler.Handle(context.Background(), &tools.ToolInvocation{
+		CallID:    "test-call",
+		ToolName:  "grep_changed",
+		Arguments: map[string]interface{}{"pattern": "TODO", "path": filepath.Join(dir, "src")},
+	})
+	require.NoError(t, err)
+	assert.False(t, *output.Success)
+	assert.Contains(t, output.Content, "unable to access")
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0o755))
+	output, err = handler.Handle(context.Background(), &tools.ToolInvocation{
+		CallID:    "test-call",
+		ToolName:  "grep_changed",
+		Arguments: map[string]interface{}{"pattern": "TODO", "path": filepath.Join(dir, "src")},
+	})
+	require.NoError(t, err)
+	assert.False(t, *output.Success)
+	assert.Equal(t, "No changed files to search.", output.Content)
+}
+
+func TestGrepChanged_RejectsOptionRange(t *testing.T) {
+	_, err := NewGrepChangedTool().Handle(context.Background(), &tools.ToolInvocation{
+		ToolName:  "grep_changed",
+		Arguments: map[string]interface{}{"pattern": "x", "path": t.TempDir(), "git_range": "--output=/tmp/x"},
+	})
+	require.Error(t, err)
+	assert.True(t, tools.IsValidationError(err))
+}
+
+func TestGrepChanged_SearchesOnlyChangedFiles(t *testing.T) {
+	skipIfNoRg(t)
+	dir := initChangedRepo(t)
+	// c.go matches too, but is unchanged.
+	output, err := NewGrepChangedTool().Handle(context.Background(), &tools.ToolInvocation{
+		ToolName:  "grep_changed",
+		Arguments: map[string]interface{}{"pattern": "package", "path": dir},
+	})
+	require.NoError(t, err)
+	assert.True(t, *output.Success)
+	assert.Equal(t, filepath.Join(dir, "a.go"), output.Content)
+
+	output, err = NewGrepFilesTool().Handle(context.Background(), &tools.ToolInvocation{
+		ToolName:  "grep_files",
+		Arguments: map[string]interface{}{"pattern": "TODO", "path": dir, "changed_only": true, "include": "*.md"},
+	})
+	require.NoError(t, err)
+	assert.True(t, *output.Success)
+	assert.Equal(t, filepath.Join(dir, "docs", "new.md"), output.Content)
+}
@@ -42,10 +42,25 @@ func (t *GrepFilesTool) IsMutating(invocation *tools.ToolInvocation) bool {
 	return false
 }
 
-// Handle searches files using ripgrep and returns matching paths.
+// Handle searches files using ripgrep and returns matching paths. With
+// changed_only or git_range set, only files changed in git are searched.
 //
 // Maps to: codex-rs/core/src/tools/handlers/grep_files.rs GrepFilesHandler::handle
 func (t *GrepFilesTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	changedOnly := false
+	if v, ok := invocation.Arguments["changed_only"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, tools.NewValidationError("changed_only must be a boolean")
+		}
+		changedOnly = b
+	}
+	return grepFiles(ctx, invocation, changedOnly)
+}
+
+// grepFiles implements grep_files and grep_changed. When changedOnly is set
+// (or git_range is given) the search is limited to files changed in git.
+func grepFiles(ctx context.Context, invocation *tools.ToolInvocation, changedOnly bool) (*tools.ToolOutput, error) {
 	patternArg, ok := invocation.Arguments["pattern"]
 	if !ok {
 		return nil, tools.NewValidationError("missing required argument: pattern")
@@ -118,7 +133,38 @@ func (t *GrepFilesTool) Handle(ctx context.Context, invocation *tools.ToolInvoca
 		}
 	}
 
-	results, err := runRgSearch(ctx, pattern, include, searchPath, limit)
+	var gitRange string
+	if rangeArg, ok := invocation.Arguments["git_range"]; ok {
+		s, ok := rangeArg.(string)
+		if !ok {
+			return nil, tools.NewValidationError("git_range must be a string")
+		}
+		gitRange = strings.TrimSpace(s)
+	}
+	if strings.HasPrefix(gitRange, "-") {
+		return nil, tools.NewValidationError("git_range must be a revision range, not an option")
+	}
+
+	var (
+		results []string
+		err     error
+	)
+	if changedOnly || gitRange != "" {
+		var files []string
+		files, err = changedFilesUnder(ctx, searchPath, gitRange)
+		if err == nil && len(files) == 0 {
+			success := false
+			return &tools.ToolOutput{
+				Content: "No changed files to search.",
+				Success: &success,
+			}, nil
+		}
+		if err == nil {
+			results, err = runRgSearchFiles(ctx, pattern, include, files, limit)
+		}
+	} else {
+		results, err = runRgSearch(ctx, pattern, include, searchPath, limit)
+	}
 	if err != nil {
 		success := false
 		return &tools.ToolOutput{
@@ -158,7 +204,11 @@ func runRgSearch(ctx context.Context, pattern, include, searchPath string, limit
 	}
 
 	args = append(args, "--", searchPath)
+	return execRg(ctx, args, limit)
+}
 
+// execRg runs rg with args and parses its --files-with-matches output.
+func execRg(ctx context.Context, args []string, limit int) ([]string, error) {
 	cmd := exec.CommandContext(ctx, "rg", args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
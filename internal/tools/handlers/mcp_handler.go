@@ -13,6 +13,7 @@ import (
 
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
 	"github.com/mfateev/temporal-agent-harness/internal/mcp"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
@@ -102,6 +103,7 @@ func (h *MCPHandler) Handle(ctx context.Context, invocation *tools.ToolInvocatio
 		mgr = h.store.GetOrCreate(invocation.SessionID)
 		_, err := mgr.Initialize(ctx, servers)
 		if err != nil {
+			invocation.Log().Warn("MCP reconnect failed", "error", err)
 			success := false
 			return &tools.ToolOutput{
 				Content: fmt.Sprintf("MCP server failed to reconnect: %v", err),
@@ -112,6 +114,7 @@ func (h *MCPHandler) Handle(ctx context.Context, invocation *tools.ToolInvocatio
 
 	result, err := mgr.CallTool(ctx, invocation.McpToolRef.ServerName, invocation.McpToolRef.ToolName, invocation.Arguments)
 	if err != nil {
+		invocation.Log().Warn("MCP tool call failed", "server", invocation.McpToolRef.ServerName, "error", err)
 		success := false
 		return &tools.ToolOutput{
 			Content: fmt.Sprintf("MCP tool call failed: %v", err),
@@ -126,6 +129,7 @@ func (h *MCPHandler) Handle(ctx context.Context, invocation *tools.ToolInvocatio
 // convertCallToolResult converts an MCP CallToolResult to a ToolOutput.
 func convertCallToolResult(result *gomcp.CallToolResult) *tools.ToolOutput {
 	var sb strings.Builder
+	var attachments []attachment.Attachment
 	for i, content := range result.Content {
 		if i > 0 {
 			sb.WriteString("\n")
@@ -137,6 +141,12 @@ func convertCallToolResult(result *gomcp.CallToolResult) *tools.ToolOutput {
 			sb.WriteString("[image: ")
 			sb.WriteString(c.MIMEType)
 			sb.WriteString("]")
+			attachments = append(attachments, attachment.Attachment{
+				Name:     attachment.Name(len(attachments)+1, c.MIMEType),
+				MimeType: c.MIMEType,
+				Size:     int64(len(c.Data)),
+				Data:     c.Data,
+			})
 		default:
 			sb.WriteString("[unsupported content type]")
 		}
@@ -144,7 +154,8 @@ func convertCallToolResult(result *gomcp.CallToolResult) *tools.ToolOutput {
 
 	success := !result.IsError
 	return &tools.ToolOutput{
-		Content: sb.String(),
-		Success: &success,
+		Content:     sb.String(),
+		Success:     &success,
+		Attachments: attachments,
 	}
 }
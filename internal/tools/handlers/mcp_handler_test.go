@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
 	"github.com/mfateev/temporal-agent-harness/internal/mcp"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
@@ -230,3 +231,21 @@ func TestConvertCallToolResult_Empty(t *testing.T) {
 	assert.Equal(t, "", output.Content)
 	assert.True(t, *output.Success)
 }
+
+func TestConvertCallToolResult_ImageAttachment(t *testing.T) {
+	result := &gomcp.CallToolResult{
+		Content: []gomcp.Content{
+			&gomcp.TextContent{Text: "screenshot taken"},
+			&gomcp.ImageContent{Data: []byte("\x89PNG..."), MIMEType: "image/png"},
+		},
+	}
+
+	output := convertCallToolResult(result)
+	assert.Equal(t, "screenshot taken\n[image: image/png]", output.Content)
+	assert.Equal(t, []attachment.Attachment{{
+		Name:     "image-1.png",
+		MimeType: "image/png",
+		Size:     7,
+		Data:     []byte("\x89PNG..."),
+	}}, output.Attachments)
+}
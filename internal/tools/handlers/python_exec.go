@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/execsession"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// pythonDoneMarker starts the line the kernel driver prints after each
+// cell, followed by the cell's JSON result.
+const pythonDoneMarker = "\x1e__python_exec_done__ "
+
+const (
+	// pythonInterruptGrace is how long an interrupted cell gets to stop
+	// before the kernel is killed.
+	pythonInterruptGrace = 5 * time.Second
+
+	// pythonPollInterval is how often a running cell is checked for output.
+	pythonPollInterval = 25 * time.Millisecond
+)
+
+// pythonKernelDriver is the kernel's main loop. It reads one JSON request
+// per line from stdin, runs the code in a shared namespace, prints the value
+// of a trailing expression, saves open matplotlib figures to the directory
+// in argv[1] and ends each cell with a pythonDoneMarker line. Cell code gets
+// an empty stdin so input() cannot consume requests.
+const pythonKernelDriver = `
+import ast, json, os, sys, traceback
+
+_out_dir = sys.argv[1]
+_requests = sys.stdin
+sys.stdin = open(os.devnull)
+sys.stderr = sys.stdout
+_ns = {"__name__": "__main__"}
+
+def _run(code):
+    tree = ast.parse(code, "<cell>", "exec")
+    last = None
+    if tree.body and isinstance(tree.body[-1], ast.Expr):
+        last = ast.Expression(tree.body.pop().value)
+    exec(compile(tree, "<cell>", "exec"), _ns)
+    if last is not None:
+        value = eval(compile(last, "<cell>", "eval"), _ns)
+        if value is not None:
+            print(repr(value))
+
+def _save_figures(cell):
+    plt = sys.modules.get("matplotlib.pyplot")
+    if plt is None:
+        return []
+    paths = []
+    for i, num in enumerate(plt.get_fignums(), 1):
+        path = os.path.join(_out_dir, "cell%d-figure%d.png" % (cell, i))
+        try:
+            plt.figure(num).savefig(path)
+            paths.append(path)
+        except Exception as e:
+            print("could not save figure %d: %s" % (num, e))
+    plt.close("all")
+    return paths
+
+for _line in _requests:
+    _req = json.loads(_line)
+    _ok = True
+    try:
+        _run(_req["code"])
+    except BaseException as e:
+        _ok = False
+        tb = e.__traceback__
+        while tb is not None and tb.tb_frame.f_code.co_filename != "<cell>":
+            tb = tb.tb_next
+        sys.stdout.write("".join(traceback.format_exception(type(e), e, tb)))
+    _files = _save_figures(_req["id"])
+    sys.stdout.write("\n\x1e__python_exec_done__ %s\n" % json.dumps({"id": _req["id"], "ok": _ok, "attachments": _files}))
+    sys.stdout.flush()
+`
+
+// pythonCellResult is the JSON the driver prints after a cell.
+type pythonCellResult struct {
+	ID          int      `json:"id"`
+	OK          bool     `json:"ok"`
+	Attachments []string `json:"attachments"`
+}
+
+// pythonKernel is a running kernel process.
+type pythonKernel struct {
+	mu     sync.Mutex // Serializes cells
+	sess   *execsession.ExecSession
+	nextID int
+}
+
+// PythonExecHandler implements python_exec: Python code run in a persistent
+// kernel per session. Kernels are resident exec sessions in the worker's
+// session store: they are listed, pruned and closed with the other
+// sessions, but a draining worker does not wait for them.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type PythonExecHandler struct {
+	store  *execsession.Store
+	python string
+
+	mu      sync.Mutex
+	kernels map[string]*pythonKernel // By session ID
+}
+
+// NewPythonExecHandler creates a python_exec handler whose kernels run the
+// given interpreter (e.g. "python3") and are kept in store.
+func NewPythonExecHandler(store *execsession.Store, python string) *PythonExecHandler {
+	return &PythonExecHandler{
+		store:   store,
+		python:  python,
+		kernels: make(map[string]*pythonKernel),
+	}
+}
+
+func (h *PythonExecHandler) Name() string                            { return "python_exec" }
+func (h *PythonExecHandler) Kind() tools.ToolKind                    { return tools.ToolKindFunction }
+func (h *PythonExecHandler) IsMutating(_ *tools.ToolInvocation) bool { return true }
+
+func (h *PythonExecHandler) Handle(ctx context.Context, inv *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	code, _ := inv.Arguments["code"].(string)
+	restart := parseBoolArg(inv.Arguments, "restart", false)
+	if strings.TrimSpace(code) == "" && !restart {
+		return nil, tools.NewValidationError("missing required argument: code")
+	}
+	timeoutSec := parseNumberArg(inv.Arguments, "timeout_seconds", tools.DefaultPythonTimeoutSec)
+	timeoutSec = clampYieldTime(timeoutSec, 1, tools.MaxPythonTimeoutSec)
+
+	key := pythonKernelKey(inv)
+	var notes []string
+	if restart && h.closeKernel(key) {
+		notes = append(notes, "Kernel restarted; all state was cleared.")
+	}
+	if strings.TrimSpace(code) == "" {
+		if len(notes) == 0 {
+			notes = append(notes, "No kernel was running; the next call starts a fresh one.")
+		}
+		return formatPythonResponse(notes, "", 0, nil, true), nil
+	}
+
+	k, note, err := h.kernel(key, inv)
+	if err != nil {
+		return nil, err
+	}
+	if note != "" {
+		notes = append(notes, note)
+	}
+
+	start := time.Now()
+	output, result, note := h.runCell(ctx, inv, key, k, code, time.Duration(timeoutSec)*time.Second)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if note != "" {
+		notes = append(notes, note)
+		result.OK = false
+	}
+	return formatPythonResponse(notes, output, time.Since(start), result.Attachments, result.OK), nil
+}
+
+// pythonKernelKey identifies the kernel for an invocation: one per session.
+func pythonKernelKey(inv *tools.ToolInvocation) string {
+	if inv.SessionID != "" {
+		return inv.SessionID
+	}
+	return inv.Cwd
+}
+
+// kernel returns the session's live kernel, starting one if there is none.
+// note tells the model when a previous kernel died and its state is gone.
+func (h *PythonExecHandler) kernel(key string, inv *tools.ToolInvocation) (*pythonKernel, string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	note := ""
+	if k, ok := h.kernels[key]; ok {
+		// The store may have pruned the kernel to make room for others.
+		if _, err := h.store.Get(k.sess.ProcessID); err == nil && !k.sess.HasExited() {
+			return k, "", nil
+		}
+		note = "The previous kernel stopped; started a new one. Earlier state is gone."
+		h.removeKernelLocked(key, k)
+	}
+
+	if h.store.IsDraining() {
+		return nil, "", tools.NewTransientError(fmt.Errorf("worker is draining, not starting a Python kernel"))
+	}
+	dir, err := os.MkdirTemp("", "python-kernel-")
+	if err != nil {
+		return nil, "", fmt.Errorf("create figure directory: %w", err)
+	}
+	env, err := buildExecEnv(inv)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, "", tools.NewValidationError("sandbox setup failed: " + err.Error())
+	}
+	env = append(env, "PYTHONUNBUFFERED=1", "MPLBACKEND=Agg")
+	processID := h.store.AllocateID()
+	sess, err := execsession.StartSession(execsession.SessionOpts{
+		ProcessID: processID,
+		Command:   []string{h.python, "-u", "-c", pythonKernelDriver, dir},
+		Cwd:       resolveWorkdir(inv),
+		Env:       env,
+		Stdin:     true,
+		Resident:  true,
+	})
+	if err != nil {
+		h.store.ReleaseID(processID)
+		return nil, "", tools.NewValidationError(fmt.Sprintf("failed to start Python kernel (%s): %v", h.python, err))
+	}
+	h.store.Store(sess)
+	k := &pythonKernel{sess: sess}
+	h.kernels[key] = k
+	return k, note, nil
+}
+
+// closeKernel stops the session's kernel. Reports whether one was running.
+func (h *PythonExecHandler) closeKernel(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k, ok := h.kernels[key]
+	if !ok {
+		return false
+	}
+	h.removeKernelLocked(key, k)
+	return true
+}
+
+func (h *PythonExecHandler) removeKernelLocked(key string, k *pythonKernel) {
+	k.sess.Close()
+	h.store.Remove(k.sess.ProcessID)
+	delete(h.kernels, key)
+}
+
+// runCell sends code to the kernel and waits for the cell to finish. A cell
+// still running at the timeout (or when ctx is cancelled) is interrupted; if
+// it does not stop within pythonInterruptGrace the kernel is killed. note
+// explains a cell that did not complete normally.
+func (h *PythonExecHandler) runCell(ctx context.Context, inv *tools.ToolInvocation, key string, k *pythonKernel, code string, timeout time.Duration) (output string, result pythonCellResult, note string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.nextID++
+	id := k.nextID
+	k.sess.DrainOutput() // Late output of an earlier, cancelled cell
+	req, _ := json.Marshal(map[string]interface{}{"id": id, "code": code})
+	if err := k.sess.WriteStdin(append(req, '\n')); err != nil {
+		h.closeKernel(key)
+		return "", result, fmt.Sprintf("The kernel is not accepting input (%v) and was stopped; the next call starts a new one.", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	interrupted := false
+	lastProgress := time.Now()
+	for {
+		snapshot := k.sess.OutputSnapshot()
+		if text, result, ok := parsePythonCellOutput(snapshot, id); ok {
+			k.sess.DrainOutput()
+			if interrupted {
+				note = fmt.Sprintf("Execution timed out after %s and was interrupted; kernel state is kept.", timeout)
+			}
+			return text, result, note
+		}
+		if k.sess.HasExited() {
+			h.closeKernel(key)
+			return strings.TrimRight(string(snapshot), "\n"), result,
+				fmt.Sprintf("The kernel exited (code %d); the next call starts a new one. All state is gone.", *k.sess.ExitCode())
+		}
+
+		now := time.Now()
+		switch {
+		case !interrupted && (now.After(deadline) || ctx.Err() != nil):
+			interrupted = true
+			if err := k.sess.Interrupt(); err != nil {
+				deadline = now
+			} else {
+				deadline = now.Add(pythonInterruptGrace)
+			}
+		case interrupted && now.After(deadline):
+			h.closeKernel(key)
+			return strings.TrimRight(string(snapshot), "\n"), result,
+				fmt.Sprintf("Execution timed out after %s and did not stop when interrupted; the kernel was restarted. All state is gone.", timeout)
+		}
+		if now.Sub(lastProgress) >= tools.ProgressInterval {
+			inv.ReportProgress(tools.NewOutputProgress("python", k.sess.OutputSize(), tools.LastLine(snapshot)))
+			lastProgress = now
+		}
+		time.Sleep(pythonPollInterval)
+	}
+}
+
+// parsePythonCellOutput splits a cell's output from the driver's done line
+// for cell id. ok is false until that line has been printed.
+func parsePythonCellOutput(output []byte, id int) (string, pythonCellResult, bool) {
+	i := bytes.LastIndex(output, []byte(pythonDoneMarker))
+	if i < 0 {
+		return "", pythonCellResult{}, false
+	}
+	line := output[i+len(pythonDoneMarker):]
+	end := bytes.IndexByte(line, '\n')
+	if end < 0 {
+		return "", pythonCellResult{}, false
+	}
+	var result pythonCellResult
+	if err := json.Unmarshal(line[:end], &result); err != nil || result.ID != id {
+		return "", pythonCellResult{}, false
+	}
+	return strings.TrimRight(string(output[:i]), "\n"), result, true
+}
+
+// formatPythonResponse formats the tool response in the exec_command layout,
+// with saved figures listed under Attachments.
+func formatPythonResponse(notes []string, output string, wallTime time.Duration, attachments []string, ok bool) *tools.ToolOutput {
+	var b strings.Builder
+	for _, note := range notes {
+		b.WriteString(note + "\n")
+	}
+	b.WriteString(fmt.Sprintf("--- Wall time: %.3fs ---\n", wallTime.Seconds()))
+	b.WriteString("--- Output ---\n")
+	if output != "" {
+		b.WriteString(output + "\n")
+	}
+	if len(attachments) > 0 {
+		b.WriteString("--- Attachments ---\n")
+		for _, path := range attachments {
+			b.WriteString(path + "\n")
+		}
+	}
+	return &tools.ToolOutput{
+		Content:     b.String(),
+		Success:     &ok,
+		Attachments: attachment.FromFiles(attachments),
+	}
+}
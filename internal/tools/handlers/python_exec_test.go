@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/execsession"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func newPythonHandler(t *testing.T) (*PythonExecHandler, *execsession.Store) {
+	t.Helper()
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	store := execsession.NewStore()
+	t.Cleanup(func() { store.CloseAll() })
+	return NewPythonExecHandler(store, "python3"), store
+}
+
+func runPython(t *testing.T, h *PythonExecHandler, args map[string]interface{}) *tools.ToolOutput {
+	t.Helper()
+	out, err := h.Handle(context.Background(), &tools.ToolInvocation{
+		CallID:    "test-call",
+		ToolName:  "python_exec",
+		Arguments: args,
+		Cwd:       t.TempDir(),
+		SessionID: "session-1",
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestPythonExec_StateIsKeptBetweenCalls(t *testing.T) {
+	h, store := newPythonHandler(t)
+
+	out := runPython(t, h, map[string]interface{}{"code": "import math\nx = 21\nprint('set')"})
+	assert.True(t, *out.Success)
+	assert.Contains(t, out.Content, "--- Output ---\nset\n")
+
+	out = runPython(t, h, map[string]interface{}{"code": "y = x * 2\ny"})
+	assert.True(t, *out.Success)
+	assert.Contains(t, out.Content, "--- Output ---\n42\n", "a trailing expression's value is shown")
+	assert.Equal(t, 1, store.Count(), "one kernel per session")
+}
+
+func TestPythonExec_ErrorShowsTracebackAndKeepsKernel(t *testing.T) {
+	h, _ := newPythonHandler(t)
+
+	runPython(t, h, map[string]interface{}{"code": "x = 1"})
+	out := runPython(t, h, map[string]interface{}{"code": "1 / 0"})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "ZeroDivisionError")
+	assert.NotContains(t, out.Content, "_run", "driver frames are hidden")
+
+	out = runPython(t, h, map[string]interface{}{"code": "x"})
+	assert.True(t, *out.Success)
+	assert.Contains(t, out.Content, "--- Output ---\n1\n")
+}
+
+func TestPythonExec_Restart(t *testing.T) {
+	h, _ := newPythonHandler(t)
+
+	runPython(t, h, map[string]interface{}{"code": "x = 1"})
+	out := runPython(t, h, map[string]interface{}{"restart": true, "code": "'x' in globals()"})
+	assert.Contains(t, out.Content, "Kernel restarted")
+	assert.Contains(t, out.Content, "--- Output ---\nFalse\n")
+}
+
+func TestPythonExec_KernelExitStartsNewOne(t *testing.T) {
+	h, _ := newPythonHandler(t)
+
+	out := runPython(t, h, map[string]interface{}{"code": "import os\nos._exit(3)"})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "The kernel exited (code 3)")
+
+	out = runPython(t, h, map[string]interface{}{"code": "print('back')"})
+	assert.True(t, *out.Success)
+	assert.Contains(t, out.Content, "back")
+}
+
+func TestPythonExec_TimeoutInterruptsCell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupt is not supported on Windows")
+	}
+	h, _ := newPythonHandler(t)
+
+	runPython(t, h, map[string]interface{}{"code": "x = 1"})
+	out := runPython(t, h, map[string]interface{}{"code": "import time\ntime.sleep(30)", "timeout_seconds": float64(1)})
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "KeyboardInterrupt")
+	assert.Contains(t, out.Content, "kernel state is kept")
+
+	out = runPython(t, h, map[string]interface{}{"code": "x"})
+	assert.Contains(t, out.Content, "--- Output ---\n1\n")
+}
+
+func TestPythonExec_MissingCode(t *testing.T) {
+	h := NewPythonExecHandler(execsession.NewStore(), "python3")
+	_, err := h.Handle(context.Background(), &tools.ToolInvocation{Arguments: map[string]interface{}{}})
+	var validationErr *tools.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestParsePythonCellOutput(t *testing.T) {
+	output := []byte("hello\n\n" + pythonDoneMarker + `{"id": 2, "ok": true, "attachments": ["/tmp/k/cell2-figure1.png"]}` + "\n")
+
+	_, _, ok := parsePythonCellOutput(output, 1)
+	assert.False(t, ok, "done line of another cell")
+
+	text, result, ok := parsePythonCellOutput(output, 2)
+	require.True(t, ok)
+	assert.Equal(t, "hello", text)
+	assert.True(t, result.OK)
+	assert.Equal(t, []string{"/tmp/k/cell2-figure1.png"}, result.Attachments)
+
+	_, _, ok = parsePythonCellOutput(output[:len(output)-1], 2)
+	assert.False(t, ok, "incomplete done line")
+}
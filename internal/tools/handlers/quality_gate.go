@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+	"github.com/mfateev/temporal-agent-harness/internal/shell"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// QualityConfigPath is where a project configures its quality_gate checks,
+// relative to the session's working directory.
+const QualityConfigPath = ".codex/quality.toml"
+
+// qualityOutputTailLines is how much of a failing check's output is shown.
+const qualityOutputTailLines = 40
+
+// packagesPattern restricts the packages argument, which is substituted
+// into shell commands, to package patterns and paths.
+var packagesPattern = regexp.MustCompile(`^[A-Za-z0-9_./@ -]+$`)
+
+// QualityConfig is the content of .codex/quality.toml.
+type QualityConfig struct {
+	// Packages replaces {packages} in the commands unless the model passes
+	// its own. Defaults to "./...".
+	Packages string         `toml:"packages"`
+	Checks   []QualityCheck `toml:"checks"`
+}
+
+// QualityCheck is one check quality_gate runs.
+type QualityCheck struct {
+	Name string `toml:"name"`
+	// Command runs in the user's shell in the working directory.
+	// {packages} is replaced by the package pattern and {dirs} by the same
+	// pattern with "/..." suffixes removed (for tools like gofmt that take
+	// directories).
+	Command string `toml:"command"`
+	// Requires is a program that must be on PATH; the check is skipped
+	// without it.
+	Requires string `toml:"requires"`
+	// FailOnOutput fails the check when it prints anything, for tools such
+	// as gofmt -l that report problems with exit status 0.
+	FailOnOutput   bool `toml:"fail_on_output"`
+	TimeoutSeconds int  `toml:"timeout_seconds"`
+}
+
+// defaultGoQualityChecks are used in a Go module without a quality.toml.
+var defaultGoQualityChecks = []QualityCheck{
+	{Name: "fmt", Command: "gofmt -l {dirs}", Requires: "gofmt", FailOnOutput: true},
+	{Name: "vet", Command: "go vet {packages}", Requires: "go"},
+	{Name: "lint", Command: "golangci-lint run {packages}", Requires: "golangci-lint"},
+	{Name: "test", Command: "go test {packages}", Requires: "go"},
+}
+
+// LoadQualityConfig reads cwd's .codex/quality.toml. Without one, a Go
+// module gets the default Go checks; other projects get an error.
+func LoadQualityConfig(cwd string) (*QualityConfig, error) {
+	path := filepath.Join(cwd, filepath.FromSlash(QualityConfigPath))
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(filepath.Join(cwd, "go.mod")); err != nil {
+			return nil, fmt.Errorf("no checks configured: add %s with [[checks]] entries", QualityConfigPath)
+		}
+		return &QualityConfig{Packages: "./...", Checks: defaultGoQualityChecks}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg QualityConfig
+	if _, err := toml.Decode(string(data), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", QualityConfigPath, err)
+	}
+	if len(cfg.Checks) == 0 {
+		return nil, fmt.Errorf("%s has no [[checks]]", QualityConfigPath)
+	}
+	seen := make(map[string]bool, len(cfg.Checks))
+	for i, c := range cfg.Checks {
+		switch {
+		case c.Name == "":
+			return nil, fmt.Errorf("%s: check %d has no name", QualityConfigPath, i+1)
+		case c.Command == "":
+			return nil, fmt.Errorf("%s: check %q has no command", QualityConfigPath, c.Name)
+		case seen[c.Name]:
+			return nil, fmt.Errorf("%s: duplicate check %q", QualityConfigPath, c.Name)
+		}
+		seen[c.Name] = true
+	}
+	if cfg.Packages == "" {
+		cfg.Packages = "./..."
+	}
+	return &cfg, nil
+}
+
+// QualityGateTool runs a project's quality checks in sequence and reports
+// each one's result.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type QualityGateTool struct {
+	sandboxMgr sandbox.SandboxManager
+}
+
+// NewQualityGateTool creates a new quality_gate tool handler.
+func NewQualityGateTool() *QualityGateTool {
+	return &QualityGateTool{sandboxMgr: sandbox.NewNoopSandboxManager()}
+}
+
+// Name returns the tool's name.
+func (t *QualityGateTool) Name() string {
+	return "quality_gate"
+}
+
+// Kind returns ToolKindFunction.
+func (t *QualityGateTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns true - the checks are arbitrary project commands.
+func (t *QualityGateTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return true
+}
+
+// qualityResult is the outcome of one check.
+type qualityResult struct {
+	check    QualityCheck
+	command  string
+	status   string // PASS, FAIL or SKIP
+	note     string
+	output   string
+	duration time.Duration
+}
+
+// Handle runs the selected checks and returns the report. The call fails
+// when any check fails.
+func (t *QualityGateTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	cfg, err := LoadQualityConfig(invocation.Cwd)
+	if err != nil {
+		return nil, tools.NewValidationError(err.Error())
+	}
+
+	packages := cfg.Packages
+	if p, ok := invocation.Arguments["packages"].(string); ok && strings.TrimSpace(p) != "" {
+		packages = strings.TrimSpace(p)
+	}
+	if !packagesPattern.MatchString(packages) {
+		return nil, tools.NewValidationError(fmt.Sprintf("invalid packages %q: use package patterns such as ./internal/...", packages))
+	}
+
+	checks, err := selectQualityChecks(cfg.Checks, invocation.Arguments["checks"])
+	if err != nil {
+		return nil, tools.NewValidationError(err.Error())
+	}
+
+	results := make([]qualityResult, 0, len(checks))
+	for _, c := range checks {
+		r, err := t.runCheck(ctx, invocation, c, packages)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.status == "FAIL" {
+			failed++
+		}
+	}
+	success := failed == 0
+	return &tools.ToolOutput{
+		Content: formatQualityReport(results, packages, failed),
+		Success: &success,
+	}, nil
+}
+
+// selectQualityChecks returns the checks named in arg, in configured
+// order, or all of them when arg is empty.
+func selectQualityChecks(all []QualityCheck, arg interface{}) ([]QualityCheck, error) {
+	names, _ := arg.([]interface{})
+	if len(names) == 0 {
+		return all, nil
+	}
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		s, ok := n.(string)
+		if !ok {
+			return nil, fmt.Errorf("checks must be an array of strings")
+		}
+		want[s] = true
+	}
+	var selected []QualityCheck
+	available := make([]string, len(all))
+	for i, c := range all {
+		available[i] = c.Name
+		if want[c.Name] {
+			selected = append(selected, c)
+			delete(want, c.Name)
+		}
+	}
+	for _, n := range names {
+		if want[n.(string)] {
+			return nil, fmt.Errorf("unknown check %q; configured checks: %s", n, strings.Join(available, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// runCheck runs one check through the same sandbox and environment
+// pipeline as shell commands.
+func (t *QualityGateTool) runCheck(ctx context.Context, invocation *tools.ToolInvocation, c QualityCheck, packages string) (qualityResult, error) {
+	command := strings.NewReplacer("{packages}", packages, "{dirs}", qualityDirs(packages)).Replace(c.Command)
+	r := qualityResult{check: c, command: command}
+	if c.Requires != "" {
+		if _, err := exec.LookPath(c.Requires); err != nil {
+			r.status, r.note = "SKIP", c.Requires+" not installed"
+			return r, nil
+		}
+	}
+
+	checkCtx := ctx
+	if c.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, time.Duration(c.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	execArgs := shell.DetectUserShell().DeriveExecArgs(command, false)
+	spec := sandbox.CommandSpec{Program: execArgs[0], Args: execArgs[1:], Cwd: invocation.Cwd}
+	start := time.Now()
+	invocation.Log().Debug("Running quality check", "check", c.Name, "command", command)
+	out, err := executeCommand(checkCtx, spec, invocation, t.sandboxMgr, "quality_gate "+c.Name)
+	r.duration = time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil || checkCtx.Err() == nil {
+			return r, err
+		}
+		r.status, r.note = "FAIL", fmt.Sprintf("timed out after %ds", c.TimeoutSeconds)
+		return r, nil
+	}
+
+	r.output = strings.TrimRight(out.Content, "\n")
+	switch {
+	case out.Success != nil && !*out.Success:
+		r.status = "FAIL"
+	case c.FailOnOutput && strings.TrimSpace(r.output) != "":
+		r.status, r.note = "FAIL", "reported problems"
+	default:
+		r.status = "PASS"
+	}
+	return r, nil
+}
+
+// qualityDirs turns package patterns into directories: "./..." becomes
+// "." and "./internal/..." becomes "./internal".
+func qualityDirs(packages string) string {
+	fields := strings.Fields(packages)
+	for i, f := range fields {
+		f = strings.TrimSuffix(strings.TrimSuffix(f, "..."), "/")
+		if f == "" {
+			f = "."
+		}
+		fields[i] = f
+	}
+	return strings.Join(fields, " ")
+}
+
+// formatQualityReport renders one line per check, followed by the tail of
+// each failing check's output.
+func formatQualityReport(results []qualityResult, packages string, failed int) string {
+	var b strings.Builder
+	if failed == 0 {
+		fmt.Fprintf(&b, "Quality gate passed on %s\n", packages)
+	} else {
+		fmt.Fprintf(&b, "Quality gate failed on %s: %d of %d checks failed\n", packages, failed, len(results))
+	}
+	for _, r := range results {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "%s %s", r.status, r.check.Name)
+		if r.status != "SKIP" {
+			fmt.Fprintf(&b, " (%s, %s)", r.command, r.duration.Round(100*time.Millisecond))
+		}
+		if r.note != "" {
+			b.WriteString(": " + r.note)
+		}
+		if r.status == "FAIL" && r.output != "" {
+			lines := strings.Split(r.output, "\n")
+			if len(lines) > qualityOutputTailLines {
+				fmt.Fprintf(&b, "\n  ... %d earlier lines omitted", len(lines)-qualityOutputTailLines)
+				lines = lines[len(lines)-qualityOutputTailLines:]
+			}
+			for _, line := range lines {
+				b.WriteString("\n  " + line)
+			}
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func writeQualityConfig(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".codex"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".codex", "quality.toml"), []byte(content), 0o644))
+}
+
+func TestLoadQualityConfig_Defaults(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadQualityConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ".codex/quality.toml")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0o644))
+	cfg, err := LoadQualityConfig(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "./...", cfg.Packages)
+	var names []string
+	for _, c := range cfg.Checks {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"fmt", "vet", "lint", "test"}, names)
+}
+
+func TestLoadQualityConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeQualityConfig(t, dir, "[[checks]]\nname = \"a\"\ncommand = \"true\"\n[[checks]]\nname = \"a\"\ncommand = \"true\"\n")
+	_, err := LoadQualityConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `duplicate check "a"`)
+
+	writeQualityConfig(t, dir, "packages = \"./...\"\n")
+	_, err = LoadQualityConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no [[checks]]")
+}
+
+func TestQualityGate_Handle(t *testing.T) {
+	dir := t.TempDir()
+	writeQualityConfig(t, dir, `
+packages = "./..."
+
+[[checks]]
+name = "fmt"
+command = "echo {dirs}/bad.go"
+fail_on_output = true
+
+[[checks]]
+name = "vet"
+command = "echo vet {packages}"
+
+[[checks]]
+name = "lint"
+command = "no-such-linter run"
+requires = "no-such-linter"
+
+[[checks]]
+name = "test"
+command = "echo line1; echo FAIL pkg; exit 1"
+`)
+
+	tool := NewQualityGateTool()
+	out, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		Cwd:       dir,
+		Arguments: map[string]interface{}{},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, out.Success)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "Quality gate failed on ./...: 2 of 4 checks failed")
+	assert.Contains(t, out.Content, "FAIL fmt (echo ./bad.go,")
+	assert.Contains(t, out.Content, "reported problems\n  ./bad.go")
+	assert.Contains(t, out.Content, "PASS vet (echo vet ./...,")
+	assert.NotContains(t, out.Content, "  vet ./...", "passing output is left out")
+	assert.Contains(t, out.Content, "SKIP lint: no-such-linter not installed")
+	assert.Contains(t, out.Content, "FAIL test (")
+	assert.Contains(t, out.Content, "  line1\n  FAIL pkg")
+
+	out, err = tool.Handle(context.Background(), &tools.ToolInvocation{
+		Cwd: dir,
+		Arguments: map[string]interface{}{
+			"checks":   []interface{}{"vet"},
+			"packages": "./internal/...",
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, *out.Success)
+	assert.Contains(t, out.Content, "Quality gate passed on ./internal/...")
+	assert.Contains(t, out.Content, "PASS vet (echo vet ./internal/...,")
+	assert.NotContains(t, out.Content, "test")
+}
+
+func TestQualityGate_RejectsBadArguments(t *testing.T) {
+	dir := t.TempDir()
+	writeQualityConfig(t, dir, "[[checks]]\nname = \"vet\"\ncommand = \"true\"\n")
+	tool := NewQualityGateTool()
+
+	_, err := tool.Handle(context.Background(), &tools.ToolInvocation{
+		Cwd:       dir,
+		Arguments: map[string]interface{}{"checks": []interface{}{"lint"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown check "lint"; configured checks: vet`)
+
+	_, err = tool.Handle(context.Background(), &tools.ToolInvocation{
+		Cwd:       dir,
+		Arguments: map[string]interface{}{"packages": "./...; rm -rf /"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid packages")
+}
+
+func TestQualityDirs(t *testing.T) {
+	assert.Equal(t, ".", qualityDirs("./..."))
+	assert.Equal(t, "./internal ./cmd/worker", qualityDirs("./internal/... ./cmd/worker"))
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
 
@@ -16,6 +19,9 @@ import (
 // Maps to: codex-rs/core/src/tools/handlers/read_file.rs TAB_WIDTH
 const tabWidth = 4
 
+// maxLineChars is how much of a line read_file shows; longer lines are cut.
+const maxLineChars = 2000
+
 // commentPrefixes are the line-comment leaders recognised by the indentation
 // mode header scanner.
 var commentPrefixes = []string{"#", "//", "--"}
@@ -37,16 +43,26 @@ type indentationOptions struct {
 	maxLines        int  // 0 = no cap (fall back to limit)
 }
 
-// ReadFileTool reads file contents with optional offset/limit.
+// ReadFileTool reads file contents with optional offset/limit, or a byte
+// range. Reads without a limit are capped at the session's
+// ToolInvocation.ReadFileLimit lines.
 //
 // Maps to: codex-rs/core/src/tools/handlers/read_file.rs
-type ReadFileTool struct{}
+type ReadFileTool struct {
+	remote *remote.Backend // Reads files on this host instead of locally, if set
+}
 
 // NewReadFileTool creates a new read file tool handler.
 func NewReadFileTool() *ReadFileTool {
 	return &ReadFileTool{}
 }
 
+// NewRemoteReadFileTool creates a read file tool that reads files on b's
+// remote host.
+func NewRemoteReadFileTool(b *remote.Backend) *ReadFileTool {
+	return &ReadFileTool{remote: b}
+}
+
 // Name returns the tool's name.
 func (t *ReadFileTool) Name() string {
 	return "read_file"
@@ -65,7 +81,7 @@ func (t *ReadFileTool) IsMutating(invocation *tools.ToolInvocation) bool {
 // Handle reads a file and returns its contents with line numbers.
 //
 // Maps to: codex-rs/core/src/tools/handlers/read_file.rs handle
-func (t *ReadFileTool) Handle(_ context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+func (t *ReadFileTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
 	// Accept both "file_path" (upstream name) and "path" (legacy).
 	pathArg, ok := invocation.Arguments["file_path"]
 	if !ok {
@@ -83,6 +99,7 @@ func (t *ReadFileTool) Handle(_ context.Context, invocation *tools.ToolInvocatio
 	if path == "" {
 		return nil, tools.NewValidationError("path cannot be empty")
 	}
+	path = resolveToolPath(invocation, path)
 
 	// Offset is 1-indexed (upstream convention). offset=1 means start from
 	// the first line. We convert to 0-indexed internally for line skipping.
@@ -98,7 +115,7 @@ func (t *ReadFileTool) Handle(_ context.Context, invocation *tools.ToolInvocatio
 		}
 	}
 
-	limit := -1
+	limit := 0 // 0 means "not set" — the session's cap applies
 	if limitArg, ok := invocation.Arguments["limit"]; ok {
 		switch v := limitArg.(type) {
 		case int:
@@ -110,6 +127,41 @@ func (t *ReadFileTool) Handle(_ context.Context, invocation *tools.ToolInvocatio
 		}
 	}
 
+	// A byte range replaces the line arguments.
+	byteOffset, err := intArgOrDefault(invocation.Arguments, "byte_offset", -1)
+	if err != nil {
+		return nil, err
+	}
+	byteLimit, err := intArgOrDefault(invocation.Arguments, "byte_limit", 0)
+	if err != nil {
+		return nil, err
+	}
+	byteRange := byteOffset >= 0 || byteLimit != 0
+	if byteRange {
+		_, hasOffset := invocation.Arguments["offset"]
+		_, hasLimit := invocation.Arguments["limit"]
+		_, hasMode := invocation.Arguments["mode"]
+		if hasOffset || hasLimit || hasMode {
+			return nil, tools.NewValidationError("byte_offset and byte_limit cannot be combined with offset, limit or mode")
+		}
+		if byteOffset < 0 {
+			byteOffset = 0
+		}
+		if byteLimit < 0 {
+			return nil, tools.NewValidationError("byte_limit must be positive")
+		}
+		if byteLimit == 0 {
+			byteLimit = tools.DefaultReadFileByteLimit
+		}
+		if byteLimit > tools.MaxReadFileByteLimit {
+			byteLimit = tools.MaxReadFileByteLimit
+		}
+	}
+
+	if limit <= 0 {
+		limit = readFileLineCap(invocation.ReadFileLimit)
+	}
+
 	// Parse mode argument (default: "slice").
 	mode := "slice"
 	if modeArg, ok := invocation.Arguments["mode"]; ok {
@@ -128,30 +180,65 @@ func (t *ReadFileTool) Handle(_ context.Context, invocation *tools.ToolInvocatio
 		}
 	}
 
-	file, err := os.Open(path)
-	if err != nil {
-		success := false
-		return &tools.ToolOutput{
-			Content: fmt.Sprintf("Failed to open file: %v", err),
-			Success: &success,
-		}, nil
+	// size is the file's size in bytes, or -1 when it is only known once
+	// the file is read to the end.
+	var file io.Reader
+	size := int64(-1)
+	if t.remote != nil {
+		data, err := t.remote.ReadFile(ctx, t.remote.Path(invocation.Cwd, path))
+		if err != nil {
+			return remoteFileFailure(ctx, "Failed to open file", err)
+		}
+		file = bytes.NewReader(data)
+		size = int64(len(data))
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			success := false
+			return &tools.ToolOutput{
+				Content: fmt.Sprintf("Failed to open file: %v", err),
+				Success: &success,
+			}, nil
+		}
+		defer f.Close()
+		file = f
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			size = info.Size()
+		}
 	}
-	defer file.Close()
 
 	// Dispatch to the appropriate mode handler.
+	if byteRange {
+		return readFileBytes(file, path, size, int64(byteOffset), int64(byteLimit))
+	}
 	if mode == "indentation" {
 		return readFileIndentation(file, path, offset, limit, indentOpts)
 	}
 
-	return readFileSlice(file, path, offset, limit)
+	return readFileSlice(file, path, size, offset, limit)
+}
+
+// readFileLineCap is the line cap for reads without a limit: the session's
+// cap, the default when it is unset, or none (-1) when it is negative.
+func readFileLineCap(sessionLimit int) int {
+	switch {
+	case sessionLimit == 0:
+		return tools.DefaultReadFileLineLimit
+	case sessionLimit < 0:
+		return -1
+	default:
+		return sessionLimit
+	}
 }
 
 // readFileSlice implements the original slice-mode read (offset + limit).
-func readFileSlice(file *os.File, path string, offset, limit int) (*tools.ToolOutput, error) {
-	scanner := bufio.NewScanner(file)
+// Reading stops one line past the limit, so for a large file the result
+// reports a lower bound on its lines; size is the file's size in bytes, or
+// -1 if unknown.
+func readFileSlice(file io.Reader, path string, size int64, offset, limit int) (*tools.ToolOutput, error) {
 	var result strings.Builder
-	lineNum := 0
 	linesRead := 0
+	more := false
 
 	// Convert 1-indexed offset to number of lines to skip.
 	// offset <= 0 or unset: start from beginning (skip 0).
@@ -161,28 +248,24 @@ func readFileSlice(file *os.File, path string, offset, limit int) (*tools.ToolOu
 		skipLines = offset - 1
 	}
 
-	for lineNum < skipLines && scanner.Scan() {
-		lineNum++
-	}
-
-	for scanner.Scan() {
-		if limit > 0 && linesRead >= limit {
-			break
+	total, read, complete, err := forEachLine(file, func(lineNum int, line string) bool {
+		if lineNum <= skipLines {
+			return true
 		}
-
-		line := scanner.Text()
-		if len(line) > 2000 {
-			line = line[:2000] + "... (truncated)"
+		if limit > 0 && linesRead >= limit {
+			more = true
+			return false
 		}
-
-		result.WriteString(fmt.Sprintf("%6d\t%s\n", lineNum+1, line))
-		lineNum++
+		result.WriteString(fmt.Sprintf("%6d\t%s\n", lineNum, truncateLine(line)))
 		linesRead++
-	}
-
-	if err := scanner.Err(); err != nil {
+		return true
+	})
+	if err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
+	if complete {
+		size = read
+	}
 
 	content := result.String()
 	if content == "" {
@@ -191,10 +274,13 @@ func readFileSlice(file *os.File, path string, offset, limit int) (*tools.ToolOu
 		} else {
 			content = "(empty file)"
 		}
+	} else if more {
+		last := skipLines + linesRead
+		content += fmt.Sprintf("(showing lines %d-%d of at least %d; continue with offset=%d)\n", skipLines+1, last, total, last+1)
 	}
 
 	// Add file path header so the LLM knows which file this content belongs to.
-	content = fmt.Sprintf("File: %s\n%s", path, content)
+	content = fmt.Sprintf("File: %s\n%s\n%s", path, fileTotals(total, complete, size), content)
 
 	success := true
 	return &tools.ToolOutput{
@@ -203,6 +289,60 @@ func readFileSlice(file *os.File, path string, offset, limit int) (*tools.ToolOu
 	}, nil
 }
 
+// readFileBytes implements byte-range reads, for files whose lines are too
+// long to page through by line. byteOffset is 0-indexed; a range cutting a
+// multi-byte character shows it as U+FFFD. Reading stops at the end of the
+// range; size is the file's size in bytes, or -1 if unknown.
+func readFileBytes(file io.Reader, path string, size, byteOffset, byteLimit int64) (*tools.ToolOutput, error) {
+	counter := &countingReader{r: file}
+	if _, err := io.CopyN(io.Discard, counter, byteOffset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(counter, byteLimit))
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	complete := size >= 0 && counter.n >= size
+	if size < 0 {
+		// Peek past the range to learn whether the file ends there.
+		if _, err := counter.Read(make([]byte, 1)); err == io.EOF {
+			complete, size = true, counter.n
+		}
+	}
+
+	var content string
+	if len(data) == 0 {
+		content = fmt.Sprintf("(file has only %d bytes)", counter.n)
+	} else {
+		end := byteOffset + int64(len(data))
+		content = fmt.Sprintf("Bytes %d-%d:\n%s", byteOffset, end-1, strings.ToValidUTF8(string(data), "\uFFFD"))
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if !complete || end < size {
+			content += fmt.Sprintf("(continue with byte_offset=%d)\n", end)
+		}
+	}
+	content = fmt.Sprintf("File: %s\n%s\n%s", path, fileTotals(counter.lines(), complete, size), content)
+
+	success := true
+	return &tools.ToolOutput{Content: content, Success: &success}, nil
+}
+
+// fileTotals describes the file's size, so the model knows how much it has
+// not seen. Unless the file was read to the end, lines is a lower bound;
+// size is -1 if unknown.
+func fileTotals(lines int, complete bool, size int64) string {
+	switch {
+	case complete:
+		return fmt.Sprintf("Total: %d lines, %d bytes", lines, size)
+	case size >= 0:
+		return fmt.Sprintf("Total: at least %d lines, %d bytes", lines, size)
+	default:
+		return fmt.Sprintf("Total: at least %d lines", lines)
+	}
+}
+
 // readFileIndentation implements the indentation-aware block mode.
 //
 // Algorithm (ported from codex-rs/core/src/tools/handlers/read_file.rs):
@@ -214,15 +354,15 @@ func readFileSlice(file *os.File, path string, offset, limit int) (*tools.ToolOu
 //  6. Trim leading/trailing blank lines
 //  7. Cap to max_lines (or limit)
 //  8. Format with line numbers
-func readFileIndentation(file *os.File, path string, offset, limit int, opts indentationOptions) (*tools.ToolOutput, error) {
+func readFileIndentation(file io.Reader, path string, offset, limit int, opts indentationOptions) (*tools.ToolOutput, error) {
 	// Step 1: Read all lines.
-	records, err := readAllLines(file)
+	records, size, err := readAllLines(file)
 	if err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
 	if len(records) == 0 {
-		content := fmt.Sprintf("File: %s\n(empty file)", path)
+		content := fmt.Sprintf("File: %s\n%s\n(empty file)", path, fileTotals(0, true, size))
 		success := true
 		return &tools.ToolOutput{Content: content, Success: &success}, nil
 	}
@@ -309,18 +449,14 @@ func readFileIndentation(file *os.File, path string, offset, limit int, opts ind
 	// Step 8: Format output.
 	var result strings.Builder
 	for _, rec := range selected {
-		line := rec.raw
-		if len(line) > 2000 {
-			line = line[:2000] + "... (truncated)"
-		}
-		result.WriteString(fmt.Sprintf("%6d\t%s\n", rec.lineNum, line))
+		result.WriteString(fmt.Sprintf("%6d\t%s\n", rec.lineNum, truncateLine(rec.raw)))
 	}
 
 	content := result.String()
 	if content == "" {
 		content = "(no matching lines)"
 	}
-	content = fmt.Sprintf("File: %s\n%s", path, content)
+	content = fmt.Sprintf("File: %s\n%s\n%s", path, fileTotals(len(records), true, size), content)
 
 	success := true
 	return &tools.ToolOutput{Content: content, Success: &success}, nil
@@ -379,24 +515,89 @@ func expandDown(records []lineRecord, effectiveIndents []int, included []bool, a
 	}
 }
 
-// readAllLines reads all lines from the file into lineRecord structs.
-func readAllLines(file *os.File) ([]lineRecord, error) {
-	scanner := bufio.NewScanner(file)
+// readAllLines reads all lines from the file into lineRecord structs and
+// returns the file's size in bytes.
+func readAllLines(file io.Reader) ([]lineRecord, int64, error) {
 	var records []lineRecord
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		raw := scanner.Text()
+	_, size, _, err := forEachLine(file, func(lineNum int, raw string) bool {
 		records = append(records, lineRecord{
 			raw:     raw,
 			indent:  measureIndent(raw),
 			lineNum: lineNum,
 		})
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return records, size, nil
+}
+
+// forEachLine calls fn with each line of r (1-indexed, without its line
+// ending) until fn returns false. It returns the number of lines and bytes
+// read and whether r was read to the end. Lines are cut a little past
+// maxLineChars while reading, so a generated file with megabyte-long lines
+// neither fails nor fills memory.
+func forEachLine(r io.Reader, fn func(lineNum int, line string) bool) (int, int64, bool, error) {
+	counter := &countingReader{r: r}
+	br := bufio.NewReader(counter)
+	var line []byte
+	lineNum := 0
+	for {
+		frag, isPrefix, err := br.ReadLine()
+		if err == io.EOF {
+			return lineNum, counter.n, true, nil
+		}
+		if err != nil {
+			return lineNum, counter.n, false, err
+		}
+		if len(line) <= maxLineChars {
+			line = append(line, frag...)
+		}
+		if isPrefix {
+			continue
+		}
+		lineNum++
+		if !fn(lineNum, string(line)) {
+			return lineNum, counter.n, false, nil
+		}
+		line = line[:0]
+	}
+}
+
+// truncateLine cuts a line longer than maxLineChars.
+func truncateLine(line string) string {
+	if len(line) > maxLineChars {
+		return line[:maxLineChars] + "... (truncated)"
+	}
+	return line
+}
+
+// countingReader counts the bytes and lines read through it.
+type countingReader struct {
+	r        io.Reader
+	n        int64
+	newlines int
+	last     byte
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.n += int64(n)
+		c.newlines += bytes.Count(p[:n], []byte{'\n'})
+		c.last = p[n-1]
+	}
+	return n, err
+}
+
+// lines returns the number of lines read, counting a final line without a
+// newline.
+func (c *countingReader) lines() int {
+	if c.n > 0 && c.last != '\n' {
+		return c.newlines + 1
 	}
-	return records, nil
+	return c.newlines
 }
 
 // measureIndent counts leading whitespace in a line, treating tabs as
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -124,6 +126,149 @@ func TestReadFile_WithLimit(t *testing.T) {
 	assert.NotContains(t, out.Content, "     3\tc")
 }
 
+func TestReadFile_ReportsTotalsAndContinuation(t *testing.T) {
+	path := writeTempFile(t, "a\nb\nc\nd\ne\n")
+
+	tool := NewReadFileTool()
+	out, err := tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":   path,
+		"offset": 2,
+		"limit":  2,
+	}))
+	require.NoError(t, err)
+	assert.True(t, *out.Success)
+	assert.Equal(t, "File: "+path+"\nTotal: at least 4 lines, 10 bytes\n"+
+		"     2\tb\n     3\tc\n(showing lines 2-3 of at least 4; continue with offset=4)\n", out.Content)
+
+	out, err = tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":   path,
+		"offset": 4,
+	}))
+	require.NoError(t, err)
+	assert.NotContains(t, out.Content, "continue with", "the last chunk has no continuation")
+	assert.Equal(t, []int{4, 5}, extractLineNums(out.Content))
+}
+
+func TestReadFile_SessionLineCap(t *testing.T) {
+	var content strings.Builder
+	for i := 1; i <= 2500; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	path := writeTempFile(t, content.String())
+	tool := NewReadFileTool()
+
+	out, err := tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	assert.Len(t, extractLineNums(out.Content), tools.DefaultReadFileLineLimit)
+	assert.Contains(t, out.Content, "(showing lines 1-2000 of at least 2001; continue with offset=2001)")
+
+	inv := newReadInvocation(map[string]interface{}{"path": path})
+	inv.ReadFileLimit = 100
+	out, err = tool.Handle(context.Background(), inv)
+	require.NoError(t, err)
+	assert.Len(t, extractLineNums(out.Content), 100)
+
+	inv = newReadInvocation(map[string]interface{}{"path": path, "limit": 300})
+	inv.ReadFileLimit = 100
+	out, err = tool.Handle(context.Background(), inv)
+	require.NoError(t, err)
+	assert.Len(t, extractLineNums(out.Content), 300, "an explicit limit overrides the session cap")
+
+	inv = newReadInvocation(map[string]interface{}{"path": path})
+	inv.ReadFileLimit = -1
+	out, err = tool.Handle(context.Background(), inv)
+	require.NoError(t, err)
+	assert.Len(t, extractLineNums(out.Content), 2500)
+	assert.NotContains(t, out.Content, "continue with")
+}
+
+func TestReadFile_LongLines(t *testing.T) {
+	// Longer than bufio.Scanner's default token limit.
+	path := writeTempFile(t, strings.Repeat("x", 200_000)+"\nshort\n")
+
+	tool := NewReadFileTool()
+	out, err := tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{"path": path}))
+	require.NoError(t, err)
+	assert.True(t, *out.Success)
+	lines := extractLines(out.Content)
+	require.Len(t, lines, 2)
+	assert.Equal(t, strings.Repeat("x", 2000)+"... (truncated)", lines[0])
+	assert.Equal(t, "short", lines[1])
+	assert.Contains(t, out.Content, "Total: 2 lines, 200007 bytes")
+}
+
+func TestReadFile_ByteRange(t *testing.T) {
+	path := writeTempFile(t, "héllo\nworld")
+	tool := NewReadFileTool()
+
+	out, err := tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":        path,
+		"byte_offset": 3,
+		"byte_limit":  5,
+	}))
+	require.NoError(t, err)
+	assert.True(t, *out.Success)
+	assert.Equal(t, "File: "+path+"\nTotal: at least 2 lines, 12 bytes\nBytes 3-7:\nllo\nw\n(continue with byte_offset=8)\n", out.Content)
+
+	// A range starting inside "é" shows the cut character as U+FFFD.
+	out, err = tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":        path,
+		"byte_offset": 2,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, out.Content, "Total: 2 lines, 12 bytes\nBytes 2-11:\n\uFFFDllo\nworld\n")
+	assert.NotContains(t, out.Content, "continue with")
+
+	out, err = tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":        path,
+		"byte_offset": 50,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, out.Content, "(file has only 12 bytes)")
+
+	_, err = tool.Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":        path,
+		"byte_offset": 0,
+		"offset":      2,
+	}))
+	require.Error(t, err)
+	assert.True(t, tools.IsValidationError(err))
+}
+
+func TestReadFile_ByteLimitIsCapped(t *testing.T) {
+	path := writeTempFile(t, strings.Repeat("x", tools.MaxReadFileByteLimit+10))
+
+	out, err := NewReadFileTool().Handle(context.Background(), newReadInvocation(map[string]interface{}{
+		"path":       path,
+		"byte_limit": 4 * tools.MaxReadFileByteLimit,
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, out.Content, fmt.Sprintf("Bytes 0-%d:\n", tools.MaxReadFileByteLimit-1))
+	assert.Contains(t, out.Content, fmt.Sprintf("(continue with byte_offset=%d)", tools.MaxReadFileByteLimit))
+}
+
+// failingReader fails every read, standing in for a part of a file that
+// must not be read.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read past the limit")
+}
+
+func TestReadFileSlice_StopsAtLimit(t *testing.T) {
+	var head strings.Builder
+	for i := 1; i <= 5000; i++ {
+		fmt.Fprintf(&head, "line %d\n", i)
+	}
+	file := io.MultiReader(strings.NewReader(head.String()), failingReader{})
+
+	out, err := readFileSlice(file, "big.txt", 1<<30, 10, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 11, 12, 13, 14}, extractLineNums(out.Content))
+	assert.Contains(t, out.Content, "Total: at least 15 lines, 1073741824 bytes\n")
+	assert.Contains(t, out.Content, "(showing lines 10-14 of at least 15; continue with offset=15)")
+}
+
 // ---------------------------------------------------------------------------
 // Helper: write temp file and return its path
 // ---------------------------------------------------------------------------
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	execpkg "github.com/mfateev/temporal-agent-harness/internal/exec"
+	"github.com/mfateev/temporal-agent-harness/internal/execsession"
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// Helpers for the shell, exec and file handlers when their constructor was
+// given a remote.Backend. The sandbox and network proxy are worker-side and
+// cannot confine remote commands, so sandboxed sessions' commands are
+// refused instead of run unconfined.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+
+// executeRemoteCommand is executeCommand for a command on the remote host.
+// A lost connection is a transient error so the activity retries it.
+func executeRemoteCommand(
+	ctx context.Context,
+	b *remote.Backend,
+	cmd remote.Command,
+	invocation *tools.ToolInvocation,
+	display string,
+) (*tools.ToolOutput, error) {
+	if out := refuseSandboxedRemote(b.Addr(), invocation); out != nil {
+		return out, nil
+	}
+	cmd.Dir = b.Path(invocation.Cwd, cmd.Dir)
+	cmd.Env = remoteEnv(invocation, nil)
+
+	runCtx, cancel := commandContext(ctx, invocation)
+	defer cancel()
+	var stdoutBuf, stderrBuf bytes.Buffer
+	var tracker tools.OutputTracker
+	stopProgress := tracker.ReportEvery(invocation, display, tools.ProgressInterval)
+	start := time.Now()
+	code, err := b.Run(runCtx, cmd, io.MultiWriter(&stdoutBuf, &tracker), io.MultiWriter(&stderrBuf, &tracker))
+	stopProgress()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if runCtx.Err() != nil {
+			output := execpkg.AggregateOutput(stdoutBuf.Bytes(), stderrBuf.Bytes())
+			return timedOutOutput(invocation, string(output), time.Since(start)), nil
+		}
+		return nil, tools.NewTransientError(err)
+	}
+
+	success := code == 0
+	return diffAgainstPrevious(invocation, &tools.ToolOutput{
+		Content: string(execpkg.AggregateOutput(stdoutBuf.Bytes(), stderrBuf.Bytes())),
+		Success: &success,
+	}), nil
+}
+
+// startRemoteSession starts an exec_command session on the remote host.
+// The session's SSH channel stays open until the command exits or the
+// session is closed.
+func startRemoteSession(b *remote.Backend, inv *tools.ToolInvocation, processID, cmdStr, shellBin, cwd string, login, tty bool) (*execsession.ExecSession, error) {
+	p, err := b.Start(remote.Command{
+		Script: cmdStr,
+		Dir:    b.Path(inv.Cwd, cwd),
+		Env:    remoteEnv(inv, unifiedExecEnv),
+		Shell:  shellBin,
+		Login:  login,
+		TTY:    tty,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	return execsession.StartProcessSession(execsession.SessionOpts{
+		ProcessID: processID,
+		Command:   []string{"ssh", b.Addr(), cmdStr},
+		Cwd:       cwd,
+		TTY:       tty,
+	}, p), nil
+}
+
+// refuseSandboxedRemote returns the failed output for a command a sandboxed
+// session wants to run on host, or nil when the session is unsandboxed.
+func refuseSandboxedRemote(host string, inv *tools.ToolInvocation) *tools.ToolOutput {
+	if inv.SandboxPolicy == nil {
+		return nil
+	}
+	success := false
+	return &tools.ToolOutput{
+		Content: fmt.Sprintf("Refusing to run the command on %s: the %s sandbox and its network policy cannot be enforced over SSH. "+
+			"Start the session with sandbox_mode = \"full-access\" to use this worker.", host, inv.SandboxPolicy.Mode),
+		Success: &success,
+	}
+}
+
+// remoteEnv returns base overlaid with the env policy's explicit variables.
+// The rest of the policy filters the worker's environment, which remote
+// commands do not inherit.
+func remoteEnv(inv *tools.ToolInvocation, base map[string]string) map[string]string {
+	env := make(map[string]string, len(base))
+	for k, v := range base {
+		env[k] = v
+	}
+	if inv.EnvPolicy != nil {
+		for k, v := range inv.EnvPolicy.Set {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// remoteFileFailure reports a failed remote file operation: a failed command
+// is a tool failure the model sees, a lost connection is transient.
+func remoteFileFailure(ctx context.Context, what string, err error) (*tools.ToolOutput, error) {
+	var cmdErr *remote.CommandError
+	if !errors.As(err, &cmdErr) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, tools.NewTransientError(err)
+	}
+	success := false
+	return &tools.ToolOutput{
+		Content: fmt.Sprintf("%s: %v", what, err),
+		Success: &success,
+	}, nil
+}
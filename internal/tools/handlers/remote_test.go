@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestRemoteEnv(t *testing.T) {
+	inv := &tools.ToolInvocation{EnvPolicy: &tools.EnvPolicyRef{
+		Inherit: "none",
+		Set:     map[string]string{"TERM": "xterm", "CI": "1"},
+	}}
+	env := remoteEnv(inv, unifiedExecEnv)
+	assert.Equal(t, "xterm", env["TERM"], "policy overrides the base")
+	assert.Equal(t, "1", env["CI"])
+	assert.Equal(t, "cat", env["PAGER"])
+	assert.Equal(t, "dumb", unifiedExecEnv["TERM"], "base is not modified")
+
+	assert.Empty(t, remoteEnv(&tools.ToolInvocation{}, nil))
+}
+
+func TestRemoteFileFailure(t *testing.T) {
+	ctx := context.Background()
+
+	out, err := remoteFileFailure(ctx, "Failed to open file", &remote.CommandError{
+		Op:     "read /srv/ws/missing.txt",
+		Stderr: "cat: /srv/ws/missing.txt: No such file or directory\n",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, out.Success)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "Failed to open file: read /srv/ws/missing.txt: cat:")
+
+	_, err = remoteFileFailure(ctx, "Failed to open file", errors.New("ssh build:22: connection refused"))
+	assert.True(t, tools.IsTransientError(err))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = remoteFileFailure(cancelled, "Failed to write file", errors.New("session closed"))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRefuseSandboxedRemote(t *testing.T) {
+	assert.Nil(t, refuseSandboxedRemote("build:22", &tools.ToolInvocation{}))
+
+	out := refuseSandboxedRemote("build:22", &tools.ToolInvocation{
+		SandboxPolicy: &tools.SandboxPolicyRef{Mode: "workspace-write"},
+	})
+	require.NotNil(t, out)
+	require.NotNil(t, out.Success)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "build:22")
+	assert.Contains(t, out.Content, "workspace-write sandbox")
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/codeindex"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+const (
+	semanticSearchDefaultLimit = 5
+	semanticSearchMaxLimit     = 20
+)
+
+// SemanticSearchTool searches the embeddings index that the IndexCode
+// activity maintains under the working directory.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type SemanticSearchTool struct{}
+
+// NewSemanticSearchTool creates a new semantic_search tool handler.
+func NewSemanticSearchTool() *SemanticSearchTool {
+	return &SemanticSearchTool{}
+}
+
+// Name returns the tool's name.
+func (t *SemanticSearchTool) Name() string {
+	return "semantic_search"
+}
+
+// Kind returns ToolKindFunction.
+func (t *SemanticSearchTool) Kind() tools.ToolKind {
+	return tools.ToolKindFunction
+}
+
+// IsMutating returns false - searching the index doesn't modify the environment.
+func (t *SemanticSearchTool) IsMutating(invocation *tools.ToolInvocation) bool {
+	return false
+}
+
+// Handle returns the indexed chunks most similar to the query.
+func (t *SemanticSearchTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+	query, _ := invocation.Arguments["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, tools.NewValidationError("missing required argument: query")
+	}
+
+	limit := semanticSearchDefaultLimit
+	if limitArg, ok := invocation.Arguments["limit"]; ok {
+		switch v := limitArg.(type) {
+		case float64:
+			limit = int(v)
+		case int:
+			limit = v
+		default:
+			return nil, tools.NewValidationError("limit must be a number")
+		}
+	}
+	if limit < 1 {
+		return nil, tools.NewValidationError("limit must be greater than zero")
+	}
+	limit = min(limit, semanticSearchMaxLimit)
+
+	root := invocation.Cwd
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return semanticSearchFailure(fmt.Sprintf("failed to determine working directory: %v", err)), nil
+		}
+		root = cwd
+	}
+
+	prefix := ""
+	if p, _ := invocation.Arguments["path"].(string); strings.TrimSpace(p) != "" {
+		p = strings.TrimSpace(p)
+		if filepath.IsAbs(p) {
+			rel, err := filepath.Rel(root, p)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return nil, tools.NewValidationErrorf("path %s is outside the indexed repository %s", p, root)
+			}
+			p = rel
+		}
+		prefix = p
+	}
+
+	results, err := codeindex.Search(ctx, root, query, limit, prefix)
+	if errors.Is(err, codeindex.ErrNoIndex) {
+		return semanticSearchFailure(fmt.Sprintf("no code index under %s; use grep_files instead", root)), nil
+	}
+	if err != nil {
+		return semanticSearchFailure(fmt.Sprintf("semantic search failed: %v", err)), nil
+	}
+
+	success := true
+	if len(results) == 0 {
+		return &tools.ToolOutput{Content: "No matches found.", Success: &success}, nil
+	}
+	var b strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:%d-%d (score %.2f)\n", r.Path, r.StartLine, r.EndLine, r.Score)
+		if r.Text != "" {
+			b.WriteString(r.Text)
+			if !strings.HasSuffix(r.Text, "\n") {
+				b.WriteString("\n")
+			}
+		}
+	}
+	return &tools.ToolOutput{Content: b.String(), Success: &success}, nil
+}
+
+// semanticSearchFailure returns an unsuccessful output with msg.
+func semanticSearchFailure(msg string) *tools.ToolOutput {
+	success := false
+	return &tools.ToolOutput{Content: msg, Success: &success}
+}
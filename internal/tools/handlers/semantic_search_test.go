@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/codeindex"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// indexedRepo creates a directory with two source files and indexes it with
+// the local embeddings provider.
+func indexedRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "auth"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "auth", "token.go"),
+		[]byte("package auth\n\n// RefreshToken renews an expired access token.\nfunc RefreshToken() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "table.go"),
+		[]byte("package render\n\n// RenderTable writes an HTML table.\nfunc RenderTable() {}\n"), 0o644))
+	emb, model, err := codeindex.NewEmbedder(codeindex.ProviderLocal, "")
+	require.NoError(t, err)
+	_, err = codeindex.Update(context.Background(), dir, emb, codeindex.ProviderLocal, model, nil)
+	require.NoError(t, err)
+	return dir
+}
+
+func TestSemanticSearch_ReturnsRankedChunks(t *testing.T) {
+	dir := indexedRepo(t)
+
+	out, err := NewSemanticSearchTool().Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"query": "refresh expired token", "limit": float64(1)},
+		Cwd:       dir,
+	})
+	require.NoError(t, err)
+	require.True(t, *out.Success)
+	assert.Contains(t, out.Content, "auth/token.go:1-4 (score ")
+	assert.Contains(t, out.Content, "func RefreshToken() {}")
+	assert.NotContains(t, out.Content, "table.go")
+}
+
+func TestSemanticSearch_PathLimitsSearch(t *testing.T) {
+	dir := indexedRepo(t)
+
+	out, err := NewSemanticSearchTool().Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"query": "refresh expired token", "path": filepath.Join(dir, "table.go")},
+		Cwd:       dir,
+	})
+	require.NoError(t, err)
+	require.True(t, *out.Success)
+	assert.Contains(t, out.Content, "table.go:1-4")
+	assert.NotContains(t, out.Content, "auth/token.go")
+
+	_, err = NewSemanticSearchTool().Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"query": "x", "path": "/elsewhere"},
+		Cwd:       dir,
+	})
+	assert.ErrorContains(t, err, "outside the indexed repository")
+}
+
+func TestSemanticSearch_NoIndex(t *testing.T) {
+	out, err := NewSemanticSearchTool().Handle(context.Background(), &tools.ToolInvocation{
+		Arguments: map[string]interface{}{"query": "anything"},
+		Cwd:       t.TempDir(),
+	})
+	require.NoError(t, err)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "no code index")
+}
+
+func TestSemanticSearch_Validation(t *testing.T) {
+	h := NewSemanticSearchTool()
+	_, err := h.Handle(context.Background(), &tools.ToolInvocation{Arguments: map[string]interface{}{}})
+	assert.ErrorContains(t, err, "missing required argument: query")
+
+	_, err = h.Handle(context.Background(), &tools.ToolInvocation{Arguments: map[string]interface{}{"query": "x", "limit": float64(0)}})
+	assert.ErrorContains(t, err, "limit must be greater than zero")
+}
@@ -7,12 +7,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/mfateev/temporal-agent-harness/internal/command_safety"
 	execpkg "github.com/mfateev/temporal-agent-harness/internal/exec"
 	"github.com/mfateev/temporal-agent-harness/internal/execenv"
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
 	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
 	"github.com/mfateev/temporal-agent-harness/internal/shell"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
@@ -28,12 +33,22 @@ func resolveWorkdir(invocation *tools.ToolInvocation) string {
 	cwd := invocation.Cwd
 	if workdirArg, ok := invocation.Arguments["workdir"]; ok {
 		if wd, ok := workdirArg.(string); ok && wd != "" {
-			cwd = wd
+			cwd = resolveToolPath(invocation, wd)
 		}
 	}
 	return cwd
 }
 
+// resolveToolPath resolves a path argument against the session's working
+// directory using the worker's path rules, so relative paths and drive-letter
+// paths behave the same whether the worker runs on Unix or Windows.
+func resolveToolPath(invocation *tools.ToolInvocation, path string) string {
+	if filepath.IsAbs(path) || invocation.Cwd == "" {
+		return filepath.FromSlash(path)
+	}
+	return filepath.Join(invocation.Cwd, path)
+}
+
 // executeCommand runs a command spec through the sandbox/env pipeline and
 // returns the aggregated output. This is the shared execution path for both
 // ShellHandler and ShellCommandHandler.
@@ -42,13 +57,20 @@ func executeCommand(
 	spec sandbox.CommandSpec,
 	invocation *tools.ToolInvocation,
 	sandboxMgr sandbox.SandboxManager,
+	display string,
 ) (*tools.ToolOutput, error) {
 	execEnv, err := resolveExecEnv(spec, invocation.SandboxPolicy, sandboxMgr)
 	if err != nil {
 		return nil, tools.NewValidationError("sandbox setup failed: " + err.Error())
 	}
 
-	cmd := exec.CommandContext(ctx, execEnv.Command[0], execEnv.Command[1:]...)
+	runCtx, cancel := commandContext(ctx, invocation)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, execEnv.Command[0], execEnv.Command[1:]...)
+	if invocation.CommandTimeout > 0 {
+		// Return at the timeout even if a child keeps the output open.
+		cmd.WaitDelay = time.Second
+	}
 	if execEnv.Cwd != "" {
 		cmd.Dir = execEnv.Cwd
 	}
@@ -67,11 +89,17 @@ func executeCommand(
 		cmd.Env = appendEnvMap(cmd.Env, execEnv.Env)
 	}
 
+	// Output also feeds a tracker so long commands report progress
+	// (output size, last line) while they run.
 	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+	var tracker tools.OutputTracker
+	cmd.Stdout = io.MultiWriter(&stdoutBuf, &tracker)
+	cmd.Stderr = io.MultiWriter(&stderrBuf, &tracker)
 
+	stopProgress := tracker.ReportEvery(invocation, display, tools.ProgressInterval)
+	start := time.Now()
 	err = cmd.Run()
+	stopProgress()
 
 	output := execpkg.AggregateOutput(stdoutBuf.Bytes(), stderrBuf.Bytes())
 
@@ -79,18 +107,54 @@ func executeCommand(
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+		if runCtx.Err() != nil {
+			return timedOutOutput(invocation, string(output), time.Since(start)), nil
+		}
 		success := false
-		return &tools.ToolOutput{
+		return diffAgainstPrevious(invocation, &tools.ToolOutput{
 			Content: string(output),
 			Success: &success,
-		}, nil
+		}), nil
 	}
 
 	success := true
-	return &tools.ToolOutput{
+	return diffAgainstPrevious(invocation, &tools.ToolOutput{
 		Content: string(output),
 		Success: &success,
-	}, nil
+	}), nil
+}
+
+// diffAgainstPrevious replaces the output of a command re-run in the same
+// turn with its difference from the previous run, when the workflow sent
+// that run's output and the diff is shorter.
+func diffAgainstPrevious(invocation *tools.ToolInvocation, out *tools.ToolOutput) *tools.ToolOutput {
+	if invocation.PreviousOutput == "" {
+		return out
+	}
+	if diff, ok := tools.DiffOutput(invocation.PreviousOutput, out.Content); ok {
+		out.FullContent = out.Content
+		out.Content = diff
+	}
+	return out
+}
+
+// commandContext bounds ctx by the call's CommandTimeout, if it has one.
+func commandContext(ctx context.Context, invocation *tools.ToolInvocation) (context.Context, context.CancelFunc) {
+	if invocation.CommandTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, invocation.CommandTimeout)
+}
+
+// timedOutOutput is the failed result of a command stopped at its
+// CommandTimeout after elapsed: the output so far and a note for the model.
+func timedOutOutput(invocation *tools.ToolInvocation, output string, elapsed time.Duration) *tools.ToolOutput {
+	t := tools.NewCommandTimeout(invocation.CommandTimeout, tools.RequestedCommandTimeout(invocation.Arguments), elapsed)
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	success := false
+	return &tools.ToolOutput{Content: output + t.Note(), Success: &success, TimedOut: t}
 }
 
 // resolveExecEnv applies sandbox wrapping if a policy is set.
@@ -119,6 +183,7 @@ func sandboxPolicyRefToPolicy(ref *tools.SandboxPolicyRef) *sandbox.SandboxPolic
 		Mode:          sandbox.SandboxMode(ref.Mode),
 		WritableRoots: roots,
 		NetworkAccess: ref.NetworkAccess,
+		NetworkAllow:  ref.NetworkAllow,
 	}
 }
 
@@ -175,6 +240,7 @@ func parseCommandArray(commandArg interface{}) ([]string, error) {
 // Maps to: codex-rs/core/src/tools/handlers/shell.rs (shell variant)
 type ShellHandler struct {
 	sandboxMgr sandbox.SandboxManager
+	remote     *remote.Backend // Runs commands on this host instead of locally, if set
 }
 
 // NewShellHandler creates a new array-based shell handler.
@@ -187,6 +253,12 @@ func NewShellHandlerWithSandbox(mgr sandbox.SandboxManager) *ShellHandler {
 	return &ShellHandler{sandboxMgr: mgr}
 }
 
+// NewRemoteShellHandler creates an array-based shell handler that runs
+// commands on b's remote host.
+func NewRemoteShellHandler(b *remote.Backend) *ShellHandler {
+	return &ShellHandler{remote: b}
+}
+
 // Name returns "shell".
 func (h *ShellHandler) Name() string { return "shell" }
 
@@ -220,13 +292,18 @@ func (h *ShellHandler) Handle(ctx context.Context, invocation *tools.ToolInvocat
 
 	cwd := resolveWorkdir(invocation)
 
+	if h.remote != nil {
+		cmd := remote.Command{Script: remote.QuoteArgs(cmdVec), Dir: cwd}
+		return executeRemoteCommand(ctx, h.remote, cmd, invocation, strings.Join(cmdVec, " "))
+	}
+
 	spec := sandbox.CommandSpec{
 		Program: cmdVec[0],
 		Args:    cmdVec[1:],
 		Cwd:     cwd,
 	}
 
-	return executeCommand(ctx, spec, invocation, h.sandboxMgr)
+	return executeCommand(ctx, spec, invocation, h.sandboxMgr, strings.Join(cmdVec, " "))
 }
 
 // ---------------------------------------------------------------------------
@@ -238,6 +315,7 @@ func (h *ShellHandler) Handle(ctx context.Context, invocation *tools.ToolInvocat
 // Maps to: codex-rs/core/src/tools/handlers/shell.rs (shell_command variant)
 type ShellCommandHandler struct {
 	sandboxMgr sandbox.SandboxManager
+	remote     *remote.Backend // Runs commands on this host instead of locally, if set
 }
 
 // NewShellCommandHandler creates a new string-based shell command handler.
@@ -251,6 +329,12 @@ func NewShellCommandHandlerWithSandbox(mgr sandbox.SandboxManager) *ShellCommand
 	return &ShellCommandHandler{sandboxMgr: mgr}
 }
 
+// NewRemoteShellCommandHandler creates a string-based shell command handler
+// that runs commands through the remote user's shell on b's host.
+func NewRemoteShellCommandHandler(b *remote.Backend) *ShellCommandHandler {
+	return &ShellCommandHandler{remote: b}
+}
+
 // Name returns "shell_command".
 func (h *ShellCommandHandler) Name() string { return "shell_command" }
 
@@ -293,6 +377,12 @@ func (h *ShellCommandHandler) Handle(ctx context.Context, invocation *tools.Tool
 	login := parseLoginArg(invocation.Arguments)
 	cwd := resolveWorkdir(invocation)
 
+	if h.remote != nil {
+		invocation.Log().Debug("Running remote shell command", "command", command, "cwd", cwd)
+		cmd := remote.Command{Script: command, Dir: cwd, Login: login}
+		return executeRemoteCommand(ctx, h.remote, cmd, invocation, command)
+	}
+
 	userShell := shell.DetectUserShell()
 	execArgs := userShell.DeriveExecArgs(command, login)
 
@@ -302,7 +392,8 @@ func (h *ShellCommandHandler) Handle(ctx context.Context, invocation *tools.Tool
 		Cwd:     cwd,
 	}
 
-	return executeCommand(ctx, spec, invocation, h.sandboxMgr)
+	invocation.Log().Debug("Running shell command", "command", command, "cwd", cwd)
+	return executeCommand(ctx, spec, invocation, h.sandboxMgr, command)
 }
 
 // parseLoginArg extracts the "login" boolean from arguments, defaulting to true.
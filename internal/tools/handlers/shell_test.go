@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 	"github.com/stretchr/testify/assert"
@@ -66,6 +67,23 @@ func TestShellCommandHandler_Handle_Success(t *testing.T) {
 	assert.True(t, *output.Success)
 }
 
+func TestShellCommandHandler_Handle_TimedOut(t *testing.T) {
+	tool := NewShellCommandHandler()
+	invocation := &tools.ToolInvocation{
+		Arguments:      map[string]interface{}{"command": "echo started; sleep 10", "login": false},
+		CommandTimeout: 500 * time.Millisecond,
+	}
+	start := time.Now()
+	output, err := tool.Handle(context.Background(), invocation)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.NotNil(t, output.TimedOut)
+	assert.Contains(t, output.Content, "started")
+	assert.Contains(t, output.Content, "[Timed out after")
+	require.NotNil(t, output.Success)
+	assert.False(t, *output.Success)
+}
+
 func TestShellCommandHandler_Handle_Failure(t *testing.T) {
 	tool := NewShellCommandHandler()
 	invocation := &tools.ToolInvocation{
@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mfateev/temporal-agent-harness/internal/command_safety"
+	"github.com/mfateev/temporal-agent-harness/internal/execenv"
 	"github.com/mfateev/temporal-agent-harness/internal/execsession"
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
 	"github.com/mfateev/temporal-agent-harness/internal/shell"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
@@ -42,7 +47,8 @@ var unifiedExecEnv = map[string]string{
 //
 // Maps to: codex-rs/core/src/tools/handlers/unified_exec.rs UnifiedExecHandler
 type UnifiedExecHandler struct {
-	store *execsession.Store
+	store  *execsession.Store
+	remote *remote.Backend // Starts sessions on this host instead of locally, if set
 }
 
 // NewUnifiedExecHandler creates a handler backed by the given session store.
@@ -60,6 +66,13 @@ func NewExecCommandHandler(store *execsession.Store) *ExecCommandHandler {
 	return &ExecCommandHandler{h: NewUnifiedExecHandler(store)}
 }
 
+// NewRemoteExecCommandHandler creates an exec_command handler that starts
+// sessions on b's remote host. write_stdin needs no remote variant: it
+// talks to sessions through the store.
+func NewRemoteExecCommandHandler(store *execsession.Store, b *remote.Backend) *ExecCommandHandler {
+	return &ExecCommandHandler{h: &UnifiedExecHandler{store: store, remote: b}}
+}
+
 func (h *ExecCommandHandler) Name() string                    { return "exec_command" }
 func (h *ExecCommandHandler) Kind() tools.ToolKind            { return tools.ToolKindFunction }
 func (h *ExecCommandHandler) IsMutating(inv *tools.ToolInvocation) bool { return h.h.isMutatingExecCommand(inv) }
@@ -121,7 +134,9 @@ func (h *UnifiedExecHandler) handleExecCommand(ctx context.Context, inv *tools.T
 	// Build command via user's shell.
 	var cmdVec []string
 	if shellBin != "" {
-		if login {
+		if st, ok := shell.DetectShellType(shellBin); ok {
+			cmdVec = (&shell.Shell{Type: st, Path: shellBin}).DeriveExecArgs(cmdStr, login)
+		} else if login {
 			cmdVec = []string{shellBin, "-lc", cmdStr}
 		} else {
 			cmdVec = []string{shellBin, "-c", cmdStr}
@@ -131,35 +146,75 @@ func (h *UnifiedExecHandler) handleExecCommand(ctx context.Context, inv *tools.T
 		cmdVec = userShell.DeriveExecArgs(cmdStr, login)
 	}
 
-	// Build environment: inherit + unified exec env.
-	env := buildExecEnv(inv)
-
-	// Allocate process ID.
-	processID := h.store.AllocateID()
+	// Refuse new sessions while the worker drains for shutdown so the
+	// process is not killed mid-command; the model can re-run it once a
+	// fresh worker picks up the task queue.
+	if h.store.IsDraining() {
+		return nil, tools.NewTransientError(fmt.Errorf("worker is draining, not starting new exec sessions"))
+	}
 
+	var sess *execsession.ExecSession
+	var processID string
 	startTime := time.Now()
+	if h.remote != nil {
+		if out := refuseSandboxedRemote(h.remote.Addr(), inv); out != nil {
+			return out, nil
+		}
+		processID = h.store.AllocateID()
+		var err error
+		sess, err = startRemoteSession(h.remote, inv, processID, cmdStr, shellBin, cwd, login, tty)
+		if err != nil {
+			h.store.ReleaseID(processID)
+			return nil, tools.NewTransientError(err)
+		}
+	} else {
+		// Build environment: inherit + unified exec env.
+		env, err := buildExecEnv(inv)
+		if err != nil {
+			return nil, tools.NewValidationError("sandbox setup failed: " + err.Error())
+		}
 
-	sess, err := execsession.StartSession(execsession.SessionOpts{
-		ProcessID: processID,
-		Command:   cmdVec,
-		Cwd:       cwd,
-		Env:       env,
-		TTY:       tty,
-	})
-	if err != nil {
-		h.store.ReleaseID(processID)
-		return nil, tools.NewValidationError(fmt.Sprintf("failed to start command: %v", err))
+		// Allocate process ID.
+		processID = h.store.AllocateID()
+
+		sess, err = execsession.StartSession(execsession.SessionOpts{
+			ProcessID: processID,
+			Command:   cmdVec,
+			Cwd:       cwd,
+			Env:       env,
+			TTY:       tty,
+		})
+		if err != nil {
+			h.store.ReleaseID(processID)
+			return nil, tools.NewValidationError(fmt.Sprintf("failed to start command: %v", err))
+		}
+	}
+
+	if inv.CommandTimeout > 0 {
+		sess.StopAfter(inv.CommandTimeout)
 	}
 
 	// Collect output up to yield_time deadline.
 	deadline := time.Now().Add(time.Duration(yieldMs) * time.Millisecond)
-	output := sess.CollectOutput(deadline, inv.Heartbeat)
+	output := sess.CollectOutputContext(ctx, deadline, execProgress(inv, sess, cmdStr))
 	wallTime := time.Since(startTime)
 
+	// Cancelled (cancel_tool or interrupt): kill the process rather than
+	// leaving an orphaned session nobody will poll.
+	if ctx.Err() != nil {
+		sess.Close()
+		h.store.ReleaseID(processID)
+		return nil, ctx.Err()
+	}
+
 	// Check if process exited during collection.
 	if sess.HasExited() {
 		h.store.ReleaseID(processID)
-		return formatExecResponse(output, wallTime, sess.ExitCode(), ""), nil
+		if sess.TimedOut() {
+			return execTimedOut(formatExecResponse(output, wallTime, sess.ExitCode(), ""),
+				inv.CommandTimeout, tools.RequestedCommandTimeout(inv.Arguments), wallTime), nil
+		}
+		return diffExecResponse(inv, formatExecResponse(output, wallTime, sess.ExitCode(), "")), nil
 	}
 
 	// Long-running: store the session.
@@ -197,6 +252,14 @@ func (h *UnifiedExecHandler) handleWriteStdin(ctx context.Context, inv *tools.To
 	}
 
 	sess, err := h.store.Get(sessionID)
+	if errors.Is(err, execsession.ErrSessionLost) {
+		lost, _ := h.store.Lost(sessionID)
+		success := false
+		return &tools.ToolOutput{
+			Content: fmt.Sprintf("Session %s was lost when the worker restarted (command: %s). Please re-run the command.", sessionID, lost.Command),
+			Success: &success,
+		}, nil
+	}
 	if err != nil {
 		success := false
 		return &tools.ToolOutput{
@@ -222,12 +285,23 @@ func (h *UnifiedExecHandler) handleWriteStdin(ctx context.Context, inv *tools.To
 
 	// Collect new output.
 	deadline := time.Now().Add(time.Duration(yieldMs) * time.Millisecond)
-	output := sess.CollectOutput(deadline, inv.Heartbeat)
+	output := sess.CollectOutputContext(ctx, deadline, execProgress(inv, sess, strings.Join(sess.Command, " ")))
 	wallTime := time.Since(startTime)
 
+	if ctx.Err() != nil {
+		sess.Close()
+		h.store.Remove(sessionID)
+		return nil, ctx.Err()
+	}
+
 	// Check if process exited.
 	if sess.HasExited() {
 		h.store.Remove(sessionID)
+		if sess.TimedOut() {
+			limit, _ := sess.Timeout()
+			return execTimedOut(formatExecResponse(output, wallTime, sess.ExitCode(), ""),
+				limit, 0, time.Since(sess.StartedAt)), nil
+		}
 		return formatExecResponse(output, wallTime, sess.ExitCode(), ""), nil
 	}
 
@@ -238,6 +312,14 @@ func (h *UnifiedExecHandler) handleWriteStdin(ctx context.Context, inv *tools.To
 // Helpers
 // ---------------------------------------------------------------------------
 
+// execProgress returns a CollectOutput heartbeat that reports the session's
+// output size and last line as structured progress.
+func execProgress(inv *tools.ToolInvocation, sess *execsession.ExecSession, command string) func(details ...interface{}) {
+	return func(...interface{}) {
+		inv.ReportProgress(tools.NewOutputProgress(command, sess.OutputSize(), tools.LastLine(sess.OutputSnapshot())))
+	}
+}
+
 // formatExecResponse formats the tool response matching Codex's format_response.
 // Maps to: codex-rs/core/src/tools/handlers/unified_exec.rs format_response
 func formatExecResponse(output []byte, wallTime time.Duration, exitCode *int, sessionID string) *tools.ToolOutput {
@@ -249,7 +331,7 @@ func formatExecResponse(output []byte, wallTime time.Duration, exitCode *int, se
 	if sessionID != "" {
 		result += fmt.Sprintf("--- Session ID: %s ---\n", sessionID)
 	}
-	result += "--- Output ---\n"
+	result += execOutputHeader
 	if len(output) > 0 {
 		result += string(output)
 	}
@@ -261,14 +343,56 @@ func formatExecResponse(output []byte, wallTime time.Duration, exitCode *int, se
 	}
 }
 
+// execTimedOut marks out, the response of a session stopped at its
+// timeout, as failed and appends the timeout note.
+func execTimedOut(out *tools.ToolOutput, limit, requested, elapsed time.Duration) *tools.ToolOutput {
+	t := tools.NewCommandTimeout(limit, requested, elapsed)
+	if !strings.HasSuffix(out.Content, "\n") {
+		out.Content += "\n"
+	}
+	success := false
+	out.Content += t.Note()
+	out.Success = &success
+	out.TimedOut = t
+	return out
+}
+
+// execOutputHeader ends the header lines of a formatted exec response.
+const execOutputHeader = "--- Output ---\n"
+
+// diffExecResponse diffs the output section of a finished exec_command
+// against the previous run of the same command, keeping the wall time and
+// exit code header. A previous run that was still going when it returned
+// is not comparable, so its output is ignored.
+func diffExecResponse(inv *tools.ToolInvocation, out *tools.ToolOutput) *tools.ToolOutput {
+	prevHeader, prevBody, ok := strings.Cut(inv.PreviousOutput, execOutputHeader)
+	if !ok || strings.Contains(prevHeader, "--- Session ID:") {
+		return out
+	}
+	header, body, _ := strings.Cut(out.Content, execOutputHeader)
+	if diff, ok := tools.DiffOutput(prevBody, body); ok {
+		out.FullContent = out.Content
+		out.Content = header + execOutputHeader + diff
+	}
+	return out
+}
+
 // buildExecEnv creates the environment for exec sessions:
-// base OS environment + unified exec vars overlaid.
-func buildExecEnv(inv *tools.ToolInvocation) []string {
+// base OS environment (filtered by the env policy, if set) + unified exec
+// vars overlaid + the sandbox network allowlist's proxy variables, if any.
+func buildExecEnv(inv *tools.ToolInvocation) ([]string, error) {
 	env := os.Environ()
+	if inv.EnvPolicy != nil {
+		env = execenv.EnvMapToSlice(resolveFilteredEnv(inv.EnvPolicy))
+	}
 	for k, v := range unifiedExecEnv {
 		env = append(env, k+"="+v)
 	}
-	return env
+	proxyEnv, err := sandbox.NetworkProxyEnv(sandboxPolicyRefToPolicy(inv.SandboxPolicy))
+	if err != nil {
+		return nil, err
+	}
+	return appendEnvMap(env, proxyEnv), nil
 }
 
 // parseBoolArg extracts a boolean argument with a default value.
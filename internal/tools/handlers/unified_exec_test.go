@@ -57,6 +57,27 @@ func TestExecCommand_ShortLivedCommand(t *testing.T) {
 	assert.Equal(t, 0, store.Count(), "short-lived process should not be stored")
 }
 
+func TestExecCommand_EnvPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	store := execsession.NewStore()
+	handler := NewExecCommandHandler(store)
+
+	inv := newExecInvocation(map[string]interface{}{
+		"cmd":           "echo \"db=$DATABASE_URL\"",
+		"yield_time_ms": float64(5000),
+	})
+	inv.EnvPolicy = &tools.EnvPolicyRef{
+		IgnoreDefaultExcludes: true,
+		Set:                   map[string]string{"DATABASE_URL": "postgres://localhost/test"},
+	}
+
+	output, err := handler.Handle(context.Background(), inv)
+	require.NoError(t, err)
+	assert.Contains(t, output.Content, "db=postgres://localhost/test")
+}
+
 func TestExecCommand_LongRunningCommand(t *testing.T) {
 	store := execsession.NewStore()
 	handler := NewExecCommandHandler(store)
@@ -331,6 +352,30 @@ func TestFormatExecResponse_FailedExit(t *testing.T) {
 	assert.False(t, *resp.Success)
 }
 
+func TestDiffExecResponse(t *testing.T) {
+	exitCode := 1
+	log := strings.Repeat("ok   example.com/pkg/a 0.01s\n", 10)
+	prev := formatExecResponse([]byte(log+"FAIL example.com/pkg/b\n"), 2*time.Second, &exitCode, "").Content
+
+	resp := diffExecResponse(&tools.ToolInvocation{PreviousOutput: prev},
+		formatExecResponse([]byte(log+"FAIL example.com/pkg/b\n"), 3*time.Second, &exitCode, ""))
+	assert.Equal(t, "--- Wall time: 3.000s ---\n--- Exit code: 1 ---\n--- Output ---\n"+tools.OutputUnchanged, resp.Content)
+	assert.Contains(t, resp.FullContent, "FAIL example.com/pkg/b")
+
+	exitCode = 0
+	resp = diffExecResponse(&tools.ToolInvocation{PreviousOutput: prev},
+		formatExecResponse([]byte(log+"ok   example.com/pkg/b 0.02s\n"), time.Second, &exitCode, ""))
+	assert.Contains(t, resp.Content, "--- Exit code: 0 ---\n--- Output ---\n[output changed")
+	assert.Contains(t, resp.Content, "-FAIL example.com/pkg/b\n+ok   example.com/pkg/b 0.02s\n")
+
+	// Output of a run that was still going is not comparable.
+	partial := formatExecResponse([]byte(log), time.Second, nil, "1000").Content
+	resp = diffExecResponse(&tools.ToolInvocation{PreviousOutput: partial},
+		formatExecResponse([]byte(log), time.Second, &exitCode, ""))
+	assert.Contains(t, resp.Content, log)
+	assert.Empty(t, resp.FullContent)
+}
+
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/mfateev/temporal-agent-harness/internal/remote"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
 
@@ -13,13 +14,21 @@ import (
 //
 // This is a new addition (not ported from Codex Rust, which routes all
 // file writes through apply_patch).
-type WriteFileTool struct{}
+type WriteFileTool struct {
+	remote *remote.Backend // Writes files on this host instead of locally, if set
+}
 
 // NewWriteFileTool creates a new write file tool handler.
 func NewWriteFileTool() *WriteFileTool {
 	return &WriteFileTool{}
 }
 
+// NewRemoteWriteFileTool creates a write file tool that writes files on b's
+// remote host.
+func NewRemoteWriteFileTool(b *remote.Backend) *WriteFileTool {
+	return &WriteFileTool{remote: b}
+}
+
 // Name returns the tool's name.
 func (t *WriteFileTool) Name() string {
 	return "write_file"
@@ -36,7 +45,7 @@ func (t *WriteFileTool) IsMutating(invocation *tools.ToolInvocation) bool {
 }
 
 // Handle writes content to a file, creating parent directories as needed.
-func (t *WriteFileTool) Handle(_ context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
+func (t *WriteFileTool) Handle(ctx context.Context, invocation *tools.ToolInvocation) (*tools.ToolOutput, error) {
 	pathArg, ok := invocation.Arguments["path"]
 	if !ok {
 		return nil, tools.NewValidationError("missing required argument: path")
@@ -50,6 +59,7 @@ func (t *WriteFileTool) Handle(_ context.Context, invocation *tools.ToolInvocati
 	if path == "" {
 		return nil, tools.NewValidationError("path cannot be empty")
 	}
+	path = resolveToolPath(invocation, path)
 
 	contentArg, ok := invocation.Arguments["content"]
 	if !ok {
@@ -61,6 +71,18 @@ func (t *WriteFileTool) Handle(_ context.Context, invocation *tools.ToolInvocati
 		return nil, tools.NewValidationError("content must be a string")
 	}
 
+	if t.remote != nil {
+		data := []byte(content)
+		if err := t.remote.WriteFile(ctx, t.remote.Path(invocation.Cwd, path), data); err != nil {
+			return remoteFileFailure(ctx, "Failed to write file", err)
+		}
+		success := true
+		return &tools.ToolOutput{
+			Content: fmt.Sprintf("Successfully wrote %d bytes to %s", len(data), path),
+			Success: &success,
+		}, nil
+	}
+
 	// Create parent directories if they don't exist.
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
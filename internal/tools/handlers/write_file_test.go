@@ -167,6 +167,25 @@ func TestWriteFile_EmptyContent(t *testing.T) {
 	assert.Equal(t, "", string(contents))
 }
 
+func TestWriteFile_RelativePathResolvedAgainstCwd(t *testing.T) {
+	tool := NewWriteFileTool()
+	dir := t.TempDir()
+	inv := newWriteInvocation(map[string]interface{}{
+		"path":    "sub/out.txt",
+		"content": "hello",
+	})
+	inv.Cwd = dir
+
+	output, err := tool.Handle(context.Background(), inv)
+	require.NoError(t, err)
+	require.NotNil(t, output.Success)
+	assert.True(t, *output.Success)
+
+	data, err := os.ReadFile(filepath.Join(dir, "sub", "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
 func TestWriteFile_ReadonlyDirectoryError(t *testing.T) {
 	// Verify that the OS actually enforces readonly permissions.
 	probe := filepath.Join(t.TempDir(), "probe")
@@ -0,0 +1,170 @@
+// Package tools provides the tool system for the agentic harness.
+//
+// outputdiff.go compares the output of a command with its previous run so
+// fix-loops (re-running tests or a build) cost only the lines that changed.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// OutputUnchanged replaces the output of a command that printed exactly
+	// what its previous run did.
+	OutputUnchanged = "[output unchanged from the previous run of this command]"
+
+	// diffContextLines is how many unchanged lines surround each change.
+	diffContextLines = 1
+
+	// maxDiffCells caps the LCS table; larger outputs diff their changed
+	// region as a single replacement.
+	maxDiffCells = 1 << 20
+)
+
+// diffOp is one line of a diff: ' ' kept, '-' removed, '+' added. Line is
+// the 1-based line number in the current output the op sits at.
+type diffOp struct {
+	kind byte
+	text string
+	line int
+}
+
+// DiffOutput compares cur, the output of a command, with prev, the output
+// of its previous run. It returns OutputUnchanged when they match, else a
+// compact line diff of cur against prev. ok is false when the diff is not
+// shorter than cur, which should then be returned as is.
+func DiffOutput(prev, cur string) (diff string, ok bool) {
+	a, b := splitLines(prev), splitLines(cur)
+	ops := diffLines(a, b)
+
+	var added, removed int
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	if added == 0 && removed == 0 {
+		return OutputUnchanged, true
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[output changed since the previous run of this command (+%d -%d lines); unchanged lines omitted]\n",
+		added, removed)
+	writeHunks(&sb, ops)
+	if sb.Len() >= len(cur) {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+// splitLines splits s into lines without their terminators.
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the edit script turning a into b. The common prefix and
+// suffix are matched directly and only the region between them goes
+// through the LCS table.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(b)+len(a)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: ' ', text: b[i], line: i + 1})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)...)
+	for i := len(b) - suffix; i < len(b); i++ {
+		ops = append(ops, diffOp{kind: ' ', text: b[i], line: i + 1})
+	}
+	return ops
+}
+
+// diffMiddle diffs a against b by longest common subsequence. offset is the
+// number of lines of the current output before b.
+func diffMiddle(a, b []string, offset int) []diffOp {
+	n, m := len(a), len(b)
+	var ops []diffOp
+	if n*m > maxDiffCells {
+		for _, l := range a {
+			ops = append(ops, diffOp{kind: '-', text: l, line: offset + 1})
+		}
+		for j, l := range b {
+			ops = append(ops, diffOp{kind: '+', text: l, line: offset + j + 1})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: b[j], line: offset + j + 1})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: a[i], line: offset + j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j], line: offset + j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// writeHunks writes the changed ops with diffContextLines of context, each
+// run headed by the line of the current output it starts at.
+func writeHunks(sb *strings.Builder, ops []diffOp) {
+	keep := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		for k := max(0, i-diffContextLines); k <= min(len(ops)-1, i+diffContextLines); k++ {
+			keep[k] = true
+		}
+	}
+	for i, op := range ops {
+		if !keep[i] {
+			continue
+		}
+		if i == 0 || !keep[i-1] {
+			fmt.Fprintf(sb, "@@ line %d @@\n", op.line)
+		}
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.text)
+		sb.WriteByte('\n')
+	}
+}
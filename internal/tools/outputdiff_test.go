@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRun returns go test style output with the given failing tests.
+func testRun(failing ...string) string {
+	var sb strings.Builder
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("TestCase%02d", i)
+		status := "PASS"
+		for _, f := range failing {
+			if f == name {
+				status = "FAIL"
+			}
+		}
+		fmt.Fprintf(&sb, "--- %s: %s (0.01s)\n", status, name)
+	}
+	if len(failing) > 0 {
+		sb.WriteString("FAIL\n")
+	} else {
+		sb.WriteString("ok\n")
+	}
+	return sb.String()
+}
+
+func TestDiffOutput_Unchanged(t *testing.T) {
+	out := testRun("TestCase03")
+	diff, ok := DiffOutput(out, out)
+	assert.True(t, ok)
+	assert.Equal(t, OutputUnchanged, diff)
+
+	diff, ok = DiffOutput(strings.TrimSuffix(out, "\n"), out)
+	assert.True(t, ok, "a trailing newline is not a change")
+	assert.Equal(t, OutputUnchanged, diff)
+}
+
+func TestDiffOutput_Changed(t *testing.T) {
+	diff, ok := DiffOutput(testRun("TestCase03", "TestCase12"), testRun("TestCase12"))
+	assert.True(t, ok)
+	assert.Equal(t, "[output changed since the previous run of this command (+1 -1 lines); unchanged lines omitted]\n"+
+		"@@ line 3 @@\n"+
+		" --- PASS: TestCase02 (0.01s)\n"+
+		"---- FAIL: TestCase03 (0.01s)\n"+
+		"+--- PASS: TestCase03 (0.01s)\n"+
+		" --- PASS: TestCase04 (0.01s)\n", diff)
+
+	diff, ok = DiffOutput(testRun("TestCase12"), testRun())
+	assert.True(t, ok)
+	assert.Contains(t, diff, "(+2 -2 lines)")
+	assert.Contains(t, diff, "@@ line 12 @@\n")
+	assert.Contains(t, diff, "@@ line 20 @@\n --- PASS: TestCase19 (0.01s)\n-FAIL\n+ok\n")
+}
+
+func TestDiffOutput_InsertionsAndDeletions(t *testing.T) {
+	var prev, cur strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&prev, "step %d\n", i)
+		if i != 20 {
+			fmt.Fprintf(&cur, "step %d\n", i)
+		}
+		if i == 2 {
+			cur.WriteString("x\n")
+		}
+	}
+	diff, ok := DiffOutput(prev.String(), cur.String())
+	assert.True(t, ok)
+	assert.Equal(t, "[output changed since the previous run of this command (+1 -1 lines); unchanged lines omitted]\n"+
+		"@@ line 2 @@\n step 2\n+x\n step 3\n"+
+		"@@ line 20 @@\n step 19\n-step 20\n step 21\n", diff)
+}
+
+func TestDiffOutput_NotShorter(t *testing.T) {
+	_, ok := DiffOutput("one\ntwo\n", "three\nfour\n")
+	assert.False(t, ok)
+}
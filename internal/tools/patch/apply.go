@@ -11,6 +11,9 @@ import (
 // ApplyError is returned when a parsed patch cannot be applied to the filesystem.
 type ApplyError struct {
 	Message string
+
+	// Conflict is set when a hunk's lines could not be found in its file.
+	Conflict *Conflict
 }
 
 func (e *ApplyError) Error() string {
@@ -26,11 +29,26 @@ type AffectedPaths struct {
 	Deleted  []string
 }
 
+// ApplyOptions tunes how hunks are located in their files.
+type ApplyOptions struct {
+	// FuzzyThreshold, if positive, lets a hunk whose lines cannot be found
+	// apply at its closest match when that match's confidence (0 to 1) is at
+	// least FuzzyThreshold.
+	FuzzyThreshold float64
+}
+
 // Apply parses a patch string and applies it to the filesystem under cwd.
 // Returns a human-readable summary on success.
 //
 // Maps to: codex-rs/apply-patch/src/lib.rs apply_patch + apply_hunks
 func Apply(patchText string, cwd string) (string, error) {
+	return ApplyWithOptions(patchText, cwd, ApplyOptions{})
+}
+
+// ApplyWithOptions is Apply with matching options. Every update is derived
+// before any file is written, so a hunk that does not apply leaves the
+// files untouched.
+func ApplyWithOptions(patchText string, cwd string, opts ApplyOptions) (string, error) {
 	p, err := Parse(patchText)
 	if err != nil {
 		return "", err
@@ -47,12 +65,12 @@ func Apply(patchText string, cwd string) (string, error) {
 	}
 
 	// Apply all hunks.
-	affected, err := applyHunks(resolved)
+	affected, fuzzy, err := applyHunks(resolved, opts)
 	if err != nil {
 		return "", err
 	}
 
-	return formatSummary(affected), nil
+	return formatSummary(affected, fuzzy), nil
 }
 
 // resolvedHunk is a hunk with absolute paths ready for application.
@@ -113,30 +131,50 @@ func resolvePath(cwd, path string) string {
 // applyHunks applies each hunk to the filesystem.
 //
 // Maps to: codex-rs/apply-patch/src/lib.rs apply_hunks_to_files
-func applyHunks(hunks []resolvedHunk) (*AffectedPaths, error) {
-	affected := &AffectedPaths{}
+func applyHunks(hunks []resolvedHunk, opts ApplyOptions) (*AffectedPaths, []FuzzyMatch, error) {
+	// Derive all updates up front. pending holds the contents earlier hunks
+	// give a path, so later hunks for the same file build on them.
+	updates := make(map[int]string)
+	pending := make(map[string]string)
+	var fuzzy []FuzzyMatch
+	for i, rh := range hunks {
+		switch rh.Type {
+		case HunkAdd:
+			pending[rh.absPath] = rh.Contents
+		case HunkUpdate:
+			newContents, matches, err := deriveNewContents(rh.absPath, rh.Path, rh.Chunks, pending, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			updates[i] = newContents
+			fuzzy = append(fuzzy, matches...)
+			if rh.absMovePath != "" {
+				pending[rh.absMovePath] = newContents
+			} else {
+				pending[rh.absPath] = newContents
+			}
+		}
+	}
 
-	for _, rh := range hunks {
+	affected := &AffectedPaths{}
+	for i, rh := range hunks {
 		switch rh.Type {
 		case HunkAdd:
 			if err := applyAddFile(rh.absPath, rh.Contents); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			affected.Added = append(affected.Added, rh.Path)
 
 		case HunkDelete:
 			if err := os.Remove(rh.absPath); err != nil {
-				return nil, &ApplyError{
+				return nil, nil, &ApplyError{
 					Message: fmt.Sprintf("Failed to delete file %s: %v", rh.Path, err),
 				}
 			}
 			affected.Deleted = append(affected.Deleted, rh.Path)
 
 		case HunkUpdate:
-			newContents, err := deriveNewContents(rh.absPath, rh.Chunks)
-			if err != nil {
-				return nil, err
-			}
+			newContents := updates[i]
 
 			dest := rh.absPath
 			if rh.absMovePath != "" {
@@ -146,14 +184,14 @@ func applyHunks(hunks []resolvedHunk) (*AffectedPaths, error) {
 			// Create parent directories if needed.
 			if dir := filepath.Dir(dest); dir != "" {
 				if err := os.MkdirAll(dir, 0o755); err != nil {
-					return nil, &ApplyError{
+					return nil, nil, &ApplyError{
 						Message: fmt.Sprintf("Failed to create parent directories for %s: %v", dest, err),
 					}
 				}
 			}
 
 			if err := os.WriteFile(dest, []byte(newContents), 0o644); err != nil {
-				return nil, &ApplyError{
+				return nil, nil, &ApplyError{
 					Message: fmt.Sprintf("Failed to write file %s: %v", dest, err),
 				}
 			}
@@ -161,7 +199,7 @@ func applyHunks(hunks []resolvedHunk) (*AffectedPaths, error) {
 			// If moving, remove the original file.
 			if rh.absMovePath != "" && rh.absPath != rh.absMovePath {
 				if err := os.Remove(rh.absPath); err != nil {
-					return nil, &ApplyError{
+					return nil, nil, &ApplyError{
 						Message: fmt.Sprintf("Failed to remove original %s: %v", rh.Path, err),
 					}
 				}
@@ -175,7 +213,7 @@ func applyHunks(hunks []resolvedHunk) (*AffectedPaths, error) {
 		}
 	}
 
-	return affected, nil
+	return affected, fuzzy, nil
 }
 
 func applyAddFile(absPath, contents string) error {
@@ -195,19 +233,24 @@ func applyAddFile(absPath, contents string) error {
 	return nil
 }
 
-// deriveNewContents reads the file at path, computes replacements from chunks,
-// and returns the new file contents.
+// deriveNewContents reads the file at path (or its pending contents),
+// computes replacements from chunks, and returns the new file contents along
+// with the hunks applied at a fuzzy match. displayPath names the file in
+// conflicts.
 //
 // Maps to: codex-rs/apply-patch/src/lib.rs derive_new_contents_from_chunks
-func deriveNewContents(path string, chunks []UpdateChunk) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", &ApplyError{
-			Message: fmt.Sprintf("Failed to read file to update %s: %v", path, err),
+func deriveNewContents(path, displayPath string, chunks []UpdateChunk, pending map[string]string, opts ApplyOptions) (string, []FuzzyMatch, error) {
+	originalContents, ok := pending[path]
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, &ApplyError{
+				Message: fmt.Sprintf("Failed to read file to update %s: %v", path, err),
+			}
 		}
+		originalContents = string(data)
 	}
 
-	originalContents := string(data)
 	originalLines := strings.Split(originalContents, "\n")
 
 	// Drop the trailing empty element that results from the final newline so
@@ -216,9 +259,9 @@ func deriveNewContents(path string, chunks []UpdateChunk) (string, error) {
 		originalLines = originalLines[:len(originalLines)-1]
 	}
 
-	replacements, err := computeReplacements(originalLines, path, chunks)
+	replacements, fuzzy, err := computeReplacements(originalLines, displayPath, chunks, opts)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	newLines := applyReplacements(originalLines, replacements)
@@ -228,7 +271,7 @@ func deriveNewContents(path string, chunks []UpdateChunk) (string, error) {
 		newLines = append(newLines, "")
 	}
 
-	return strings.Join(newLines, "\n"), nil
+	return strings.Join(newLines, "\n"), fuzzy, nil
 }
 
 // replacement describes a single region to replace in the file.
@@ -239,21 +282,31 @@ type replacement struct {
 }
 
 // computeReplacements determines the set of replacements needed to transform
-// originalLines according to the given chunks.
+// originalLines according to the given chunks. A chunk that cannot be found
+// applies at its closest match if opts allows; otherwise the returned
+// ApplyError carries the Conflict.
 //
 // Maps to: codex-rs/apply-patch/src/lib.rs compute_replacements
-func computeReplacements(originalLines []string, path string, chunks []UpdateChunk) ([]replacement, error) {
+func computeReplacements(originalLines []string, path string, chunks []UpdateChunk, opts ApplyOptions) ([]replacement, []FuzzyMatch, error) {
 	var replacements []replacement
+	var fuzzy []FuzzyMatch
 	lineIndex := 0
 
-	for _, chunk := range chunks {
+	for n, chunk := range chunks {
 		// If a chunk has a ChangeContext, seek forward to find it.
 		if chunk.ChangeContext != "" {
-			idx := seekSequence(originalLines, []string{chunk.ChangeContext}, lineIndex, false)
+			ctxLines := []string{chunk.ChangeContext}
+			idx := seekSequence(originalLines, ctxLines, lineIndex, false)
 			if idx < 0 {
-				return nil, &ApplyError{
-					Message: fmt.Sprintf("Failed to find context '%s' in %s", chunk.ChangeContext, path),
+				conflict := newConflict(originalLines, ctxLines, lineIndex, path, n+1)
+				if !conflict.acceptable(opts) {
+					return nil, nil, &ApplyError{
+						Message:  fmt.Sprintf("Failed to find context '%s' in %s\n\n%s", chunk.ChangeContext, path, conflict.Describe()),
+						Conflict: conflict,
+					}
 				}
+				idx = conflict.BestMatchLine - 1
+				fuzzy = append(fuzzy, conflict.fuzzyMatch())
 			}
 			lineIndex = idx + 1
 		}
@@ -286,22 +339,28 @@ func computeReplacements(originalLines []string, path string, chunks []UpdateChu
 			found = seekSequence(originalLines, pattern, lineIndex, chunk.IsEOF)
 		}
 
-		if found >= 0 {
-			replacements = append(replacements, replacement{
-				index:    found,
-				count:    len(pattern),
-				newLines: copyStrings(newSlice),
-			})
-			lineIndex = found + len(pattern)
-		} else {
-			return nil, &ApplyError{
-				Message: fmt.Sprintf(
-					"Failed to find expected lines in %s:\n%s",
-					path,
-					strings.Join(chunk.OldLines, "\n"),
-				),
+		if found < 0 {
+			conflict := newConflict(originalLines, pattern, lineIndex, path, n+1)
+			if !conflict.acceptable(opts) {
+				return nil, nil, &ApplyError{
+					Message: fmt.Sprintf(
+						"Failed to find expected lines in %s:\n%s\n\n%s",
+						path,
+						strings.Join(chunk.OldLines, "\n"),
+						conflict.Describe(),
+					),
+					Conflict: conflict,
+				}
 			}
+			found = conflict.BestMatchLine - 1
+			fuzzy = append(fuzzy, conflict.fuzzyMatch())
 		}
+		replacements = append(replacements, replacement{
+			index:    found,
+			count:    len(pattern),
+			newLines: copyStrings(newSlice),
+		})
+		lineIndex = found + len(pattern)
 	}
 
 	// Sort by index.
@@ -309,7 +368,7 @@ func computeReplacements(originalLines []string, path string, chunks []UpdateChu
 		return replacements[i].index < replacements[j].index
 	})
 
-	return replacements, nil
+	return replacements, fuzzy, nil
 }
 
 // applyReplacements applies replacements in reverse order to avoid index shifts.
@@ -342,7 +401,7 @@ func applyReplacements(lines []string, replacements []replacement) []string {
 	return result
 }
 
-func formatSummary(affected *AffectedPaths) string {
+func formatSummary(affected *AffectedPaths, fuzzy []FuzzyMatch) string {
 	var b strings.Builder
 	b.WriteString("Success. Updated the following files:\n")
 	for _, p := range affected.Added {
@@ -354,6 +413,10 @@ func formatSummary(affected *AffectedPaths) string {
 	for _, p := range affected.Deleted {
 		fmt.Fprintf(&b, "D %s\n", p)
 	}
+	for _, f := range fuzzy {
+		fmt.Fprintf(&b, "Note: hunk %d of %s was applied at its closest match, line %d (confidence %d%%). Review it.\n",
+			f.Hunk, f.Path, f.Line, Percent(f.Confidence))
+	}
 	return b.String()
 }
 
@@ -0,0 +1,168 @@
+// Package patch implements the apply_patch tool: parsing, fuzzy matching, and application.
+//
+// conflict.go describes hunks that do not apply: where the closest match in
+// the file is and how confident that match is, so the model (or the user)
+// can decide whether to accept it.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package patch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MinSuggestConfidence is the confidence below which a closest match is
+// not worth offering to the user: the hunk most likely targets other code.
+const MinSuggestConfidence = 0.6
+
+// Conflict describes a hunk whose lines were not found in its file.
+type Conflict struct {
+	Path string `json:"path"` // As written in the patch
+	Hunk int    `json:"hunk"` // 1-based index of the hunk within the file's section
+
+	// Expected are the lines the hunk expected: its context and removed
+	// lines, or its @@ context line when that could not be found.
+	Expected []string `json:"expected"`
+
+	// Found are the file's lines at the closest match, BestMatchLine is the
+	// 1-based line it starts at (0 = nothing similar) and Confidence its
+	// similarity to Expected, from 0 to 1.
+	Found         []string `json:"found,omitempty"`
+	BestMatchLine int      `json:"best_match_line,omitempty"`
+	Confidence    float64  `json:"confidence,omitempty"`
+}
+
+// Describe renders the conflict for the model: what was expected, what the
+// file has at the closest match, and how to proceed.
+func (c *Conflict) Describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hunk %d of %s did not apply.\n", c.Hunk, c.Path)
+	b.WriteString("Expected:\n")
+	writeIndented(&b, c.Expected)
+	if c.BestMatchLine == 0 {
+		b.WriteString("No similar lines were found. Re-read the file and regenerate the hunk.")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Closest match at line %d (confidence %d%%):\n", c.BestMatchLine, Percent(c.Confidence))
+	writeIndented(&b, c.Found)
+	b.WriteString("Re-read the file around that line and regenerate the hunk, or call apply_patch again with fuzzy_threshold set to apply it at the closest match.")
+	return b.String()
+}
+
+// Percent formats a confidence as a whole percentage, rounded down so a
+// match shown as 90% meets a 0.9 threshold.
+func Percent(confidence float64) int {
+	return int(confidence * 100)
+}
+
+func writeIndented(b *strings.Builder, lines []string) {
+	for _, l := range lines {
+		b.WriteString("    ")
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+}
+
+// FuzzyMatch records a hunk applied at its closest match.
+type FuzzyMatch struct {
+	Path       string
+	Hunk       int
+	Line       int // 1-based
+	Confidence float64
+}
+
+// newConflict describes the chunk pattern that was not found at or after
+// line index start, locating its closest match.
+func newConflict(lines, pattern []string, start int, path string, hunk int) *Conflict {
+	c := &Conflict{Path: path, Hunk: hunk, Expected: copyStrings(pattern)}
+	if idx, confidence := bestMatch(lines, pattern, start); idx >= 0 {
+		c.Found = copyStrings(lines[idx : idx+len(pattern)])
+		c.BestMatchLine = idx + 1
+		c.Confidence = confidence
+	}
+	return c
+}
+
+// acceptable reports whether opts allow applying at the closest match.
+func (c *Conflict) acceptable(opts ApplyOptions) bool {
+	return opts.FuzzyThreshold > 0 && c.BestMatchLine > 0 && c.Confidence >= opts.FuzzyThreshold
+}
+
+func (c *Conflict) fuzzyMatch() FuzzyMatch {
+	return FuzzyMatch{Path: c.Path, Hunk: c.Hunk, Line: c.BestMatchLine, Confidence: c.Confidence}
+}
+
+// bestMatch finds the window of lines, starting at or after start, most
+// similar to pattern. Returns the window's start index (-1 if none scores
+// above zero) and the mean per-line similarity. Ties go to the earliest.
+func bestMatch(lines, pattern []string, start int) (int, float64) {
+	if len(pattern) == 0 || len(pattern) > len(lines) {
+		return -1, 0
+	}
+	norm := func(s string) (string, []uint64) {
+		n := normalise(s)
+		return n, bigrams(n)
+	}
+	patNorm := make([]string, len(pattern))
+	patGrams := make([][]uint64, len(pattern))
+	for j, p := range pattern {
+		patNorm[j], patGrams[j] = norm(p)
+	}
+	lineNorm := make([]string, len(lines))
+	lineGrams := make([][]uint64, len(lines))
+	for i := start; i < len(lines); i++ {
+		lineNorm[i], lineGrams[i] = norm(lines[i])
+	}
+
+	bestIdx, bestScore := -1, 0.0
+	for i := start; i+len(pattern) <= len(lines); i++ {
+		total := 0.0
+		for j := range pattern {
+			total += similarity(lineNorm[i+j], patNorm[j], lineGrams[i+j], patGrams[j])
+		}
+		if score := total / float64(len(pattern)); score > bestScore {
+			bestIdx, bestScore = i, score
+		}
+	}
+	return bestIdx, bestScore
+}
+
+// similarity is the Dice coefficient of two lines' character bigrams.
+func similarity(a, b string, aGrams, bGrams []uint64) float64 {
+	if a == b {
+		return 1
+	}
+	if len(aGrams) == 0 || len(bGrams) == 0 {
+		return 0
+	}
+	common := 0
+	for i, j := 0, 0; i < len(aGrams) && j < len(bGrams); {
+		switch {
+		case aGrams[i] == bGrams[j]:
+			common++
+			i++
+			j++
+		case aGrams[i] < bGrams[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return 2 * float64(common) / float64(len(aGrams)+len(bGrams))
+}
+
+// bigrams returns the sorted character bigrams of s.
+func bigrams(s string) []uint64 {
+	runes := []rune(s)
+	if len(runes) < 2 {
+		return nil
+	}
+	grams := make([]uint64, len(runes)-1)
+	for i := range grams {
+		grams[i] = uint64(runes[i])<<32 | uint64(runes[i+1])
+	}
+	sort.Slice(grams, func(i, j int) bool { return grams[i] < grams[j] })
+	return grams
+}
@@ -0,0 +1,143 @@
+package patch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conflictSource = `package main
+
+func greet(name string) string {
+	msg := "Hello, " + name
+	return msg
+}
+
+func main() {
+	println(greet("world"))
+}
+`
+
+// driftedPatch targets greet as the model remembered it: the variable was
+// called greeting, not msg.
+const driftedPatch = `*** Begin Patch
+*** Update File: main.go
+@@
+ func greet(name string) string {
+-	greeting := "Hello, " + name
+-	return greeting
++	return "Hi, " + name
+ }
+*** End Patch`
+
+func TestApply_ConflictDescribesClosestMatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte(conflictSource), 0o644))
+
+	_, err := Apply(driftedPatch, dir)
+	var applyErr *ApplyError
+	require.True(t, errors.As(err, &applyErr))
+	c := applyErr.Conflict
+	require.NotNil(t, c)
+	assert.Equal(t, "main.go", c.Path)
+	assert.Equal(t, 1, c.Hunk)
+	assert.Equal(t, []string{
+		"func greet(name string) string {",
+		"\tgreeting := \"Hello, \" + name",
+		"\treturn greeting",
+		"}",
+	}, c.Expected)
+	assert.Equal(t, 3, c.BestMatchLine)
+	assert.Equal(t, []string{
+		"func greet(name string) string {",
+		"\tmsg := \"Hello, \" + name",
+		"\treturn msg",
+		"}",
+	}, c.Found)
+	assert.Greater(t, c.Confidence, MinSuggestConfidence)
+	assert.Less(t, c.Confidence, 1.0)
+
+	assert.Contains(t, err.Error(), "Failed to find expected lines in main.go")
+	assert.Contains(t, err.Error(), "Closest match at line 3")
+	assert.Contains(t, err.Error(), "fuzzy_threshold")
+}
+
+func TestApplyWithOptions_FuzzyThreshold(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte(conflictSource), 0o644))
+
+	_, err := ApplyWithOptions(driftedPatch, dir, ApplyOptions{FuzzyThreshold: 0.99})
+	require.Error(t, err, "closest match is below the threshold")
+
+	result, err := ApplyWithOptions(driftedPatch, dir, ApplyOptions{FuzzyThreshold: 0.6})
+	require.NoError(t, err)
+	assert.Contains(t, result, "M main.go")
+	assert.Contains(t, result, "hunk 1 of main.go was applied at its closest match, line 3")
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `package main
+
+func greet(name string) string {
+	return "Hi, " + name
+}
+
+func main() {
+	println(greet("world"))
+}
+`, string(contents))
+}
+
+func TestApply_ConflictLeavesEarlierHunksUnapplied(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("two\n"), 0o644))
+
+	_, err := Apply(wrapPatchBody(
+		"*** Update File: a.txt\n@@\n-one\n+uno\n"+
+			"*** Update File: b.txt\n@@\n-three\n+tres"), dir)
+	require.Error(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "one\n", string(contents), "nothing is written when a later hunk fails")
+}
+
+func TestApply_SequentialHunksForSameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("one\ntwo\n"), 0o644))
+
+	_, err := Apply(wrapPatchBody(
+		"*** Update File: a.txt\n@@\n-one\n+uno\n"+
+			"*** Update File: a.txt\n@@\n uno\n-two\n+dos"), dir)
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "uno\ndos\n", string(contents))
+}
+
+func TestBestMatch(t *testing.T) {
+	lines := []string{"alpha", "beta", "gamma", "delta"}
+
+	idx, confidence := bestMatch(lines, []string{"gamma", "delta"}, 0)
+	assert.Equal(t, 2, idx)
+	assert.Equal(t, 1.0, confidence)
+
+	idx, _ = bestMatch(lines, []string{"betta", "gama"}, 0)
+	assert.Equal(t, 1, idx)
+
+	idx, _ = bestMatch(lines, []string{"alpha"}, 1)
+	assert.NotEqual(t, 0, idx, "search starts at start")
+
+	idx, confidence = bestMatch(lines, []string{"zzz"}, 0)
+	assert.Equal(t, -1, idx)
+	assert.Zero(t, confidence)
+}
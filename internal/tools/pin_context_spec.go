@@ -0,0 +1,26 @@
+// Pin context tool specification for the pin_context intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "pin_context", Constructor: NewPinContextToolSpec})
+}
+
+// NewPinContextToolSpec creates the specification for the pin_context tool.
+// This tool is intercepted by the workflow (not dispatched as an activity).
+// The user pins existing history items with /pin.
+func NewPinContextToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "pin_context",
+		Description: `Pin a note that must stay in your context verbatim. Older history is summarized when the context fills up; pinned notes are never summarized away. Pin the task's essential requirements, constraints and decisions you must not lose, stated completely and concisely.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "note",
+				Type:        "string",
+				Description: `The text to keep, self-contained (it may outlive the messages around it).`,
+				Required:    true,
+			},
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressInterval is how often long-running tools report progress. It also
+// serves as their liveness heartbeat.
+const ProgressInterval = 5 * time.Second
+
+// maxProgressLineLen caps ToolProgress.LastLine.
+const maxProgressLineLen = 200
+
+// ToolProgress is a structured progress snapshot of a running tool call.
+// Recorded as activity heartbeat details and forwarded to the workflow so
+// TurnStatus can show it next to the tool in flight.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+type ToolProgress struct {
+	CallID       string `json:"call_id,omitempty"`
+	Command      string `json:"command,omitempty"`       // Command line being run (shell / exec sessions)
+	BytesWritten int    `json:"bytes_written,omitempty"` // Output produced so far
+	Current      int    `json:"current,omitempty"`       // "test N of M": N
+	Total        int    `json:"total,omitempty"`         // "test N of M": M
+	LastLine     string `json:"last_line,omitempty"`     // Last complete output line
+}
+
+// ReportProgress sends a progress snapshot through OnProgress, falling back
+// to a plain heartbeat. A no-op when neither is set (unit tests).
+func (inv *ToolInvocation) ReportProgress(p ToolProgress) {
+	switch {
+	case inv.OnProgress != nil:
+		inv.OnProgress(p)
+	case inv.Heartbeat != nil:
+		inv.Heartbeat(p)
+	}
+}
+
+// NewOutputProgress builds a progress snapshot for a command from its output
+// size and last output line, extracting an "N/M" or "N of M" counter from the
+// line when present.
+func NewOutputProgress(command string, bytesWritten int, lastLine string) ToolProgress {
+	lastLine = strings.TrimSpace(lastLine)
+	if len(lastLine) > maxProgressLineLen {
+		lastLine = lastLine[:maxProgressLineLen] + "…"
+	}
+	p := ToolProgress{
+		Command:      command,
+		BytesWritten: bytesWritten,
+		LastLine:     lastLine,
+	}
+	p.Current, p.Total, _ = parseCountProgress(lastLine)
+	return p
+}
+
+// countProgressPattern matches "3/10", "[ 3/10]", "(3 of 10)" as a standalone
+// token, so paths and dates such as 2024/10/16 are not mistaken for counters.
+var countProgressPattern = regexp.MustCompile(`(?:^|[\s\[(])(\d+)\s*(?:/|of)\s*(\d+)(?:[\s\]):,]|$)`)
+
+// parseCountProgress extracts the last N-of-M counter from line.
+func parseCountProgress(line string) (current, total int, ok bool) {
+	matches := countProgressPattern.FindAllStringSubmatch(line, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		n, err1 := strconv.Atoi(matches[i][1])
+		m, err2 := strconv.Atoi(matches[i][2])
+		if err1 == nil && err2 == nil && m > 0 && n <= m {
+			return n, m, true
+		}
+	}
+	return 0, 0, false
+}
+
+// LastLine returns the last non-empty line of output, ignoring a trailing
+// partial line's carriage-return redraws (progress bars).
+func LastLine(output []byte) string {
+	output = bytes.TrimRight(output, "\r\n \t")
+	if i := bytes.LastIndexByte(output, '\n'); i >= 0 {
+		output = output[i+1:]
+	}
+	if i := bytes.LastIndexByte(output, '\r'); i >= 0 {
+		output = output[i+1:]
+	}
+	return string(output)
+}
+
+// OutputTracker is an io.Writer that counts command output and remembers its
+// tail, so a progress snapshot can be taken while the command runs.
+type OutputTracker struct {
+	mu    sync.Mutex
+	total int
+	tail  []byte
+}
+
+// trackerTailLen bounds the output kept by OutputTracker.
+const trackerTailLen = 4096
+
+// Write implements io.Writer. It never fails.
+func (t *OutputTracker) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total += len(p)
+	t.tail = append(t.tail, p...)
+	if len(t.tail) > trackerTailLen {
+		t.tail = append(t.tail[:0], t.tail[len(t.tail)-trackerTailLen:]...)
+	}
+	return len(p), nil
+}
+
+// Snapshot returns the bytes written so far and the last output line.
+func (t *OutputTracker) Snapshot() (int, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total, LastLine(t.tail)
+}
+
+// ReportEvery reports progress for command every interval until the returned
+// stop function is called. stop is safe to call more than once.
+func (t *OutputTracker) ReportEvery(inv *ToolInvocation, command string, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				n, line := t.Snapshot()
+				inv.ReportProgress(NewOutputProgress(command, n, line))
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
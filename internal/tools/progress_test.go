@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCountProgress(t *testing.T) {
+	cases := []struct {
+		line           string
+		current, total int
+		ok             bool
+	}{
+		{"[ 3/10] Compiling foo", 3, 10, true},
+		{"running test 4 of 12", 4, 12, true},
+		{"(7/7) done", 7, 7, true},
+		{"1/4 then 2/4", 2, 4, true},
+		{"see src/pkg/file.go", 0, 0, false},
+		{"built on 2024/10/16", 0, 0, false},
+		{"11/10 overshoot", 0, 0, false},
+		{"0/0", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.line, func(t *testing.T) {
+			n, m, ok := parseCountProgress(tc.line)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.current, n)
+			assert.Equal(t, tc.total, m)
+		})
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	assert.Equal(t, "", LastLine(nil))
+	assert.Equal(t, "second", LastLine([]byte("first\nsecond\n\n")))
+	assert.Equal(t, "only", LastLine([]byte("only")))
+	assert.Equal(t, "75%", LastLine([]byte("download\n25%\r50%\r75%")))
+}
+
+func TestNewOutputProgress(t *testing.T) {
+	p := NewOutputProgress("go test ./...", 2048, "  [ 2/5] ok pkg/a  ")
+	assert.Equal(t, "go test ./...", p.Command)
+	assert.Equal(t, 2048, p.BytesWritten)
+	assert.Equal(t, "[ 2/5] ok pkg/a", p.LastLine)
+	assert.Equal(t, 2, p.Current)
+	assert.Equal(t, 5, p.Total)
+
+	long := NewOutputProgress("cmd", 0, strings.Repeat("x", 500))
+	assert.Equal(t, maxProgressLineLen+len("…"), len(long.LastLine))
+}
+
+func TestReportProgress_PrefersOnProgress(t *testing.T) {
+	var got []ToolProgress
+	var beats int
+	inv := &ToolInvocation{
+		OnProgress: func(p ToolProgress) { got = append(got, p) },
+		Heartbeat:  func(...interface{}) { beats++ },
+	}
+	inv.ReportProgress(ToolProgress{Command: "ls"})
+	require.Len(t, got, 1)
+	assert.Equal(t, "ls", got[0].Command)
+	assert.Zero(t, beats)
+
+	// Falls back to Heartbeat, and is a no-op with neither set.
+	inv.OnProgress = nil
+	inv.ReportProgress(ToolProgress{})
+	assert.Equal(t, 1, beats)
+	(&ToolInvocation{}).ReportProgress(ToolProgress{})
+}
+
+func TestOutputTracker_ReportEvery(t *testing.T) {
+	var mu sync.Mutex
+	var reports []ToolProgress
+	inv := &ToolInvocation{OnProgress: func(p ToolProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, p)
+	}}
+
+	var tracker OutputTracker
+	_, _ = tracker.Write([]byte("test 1 of 3\ntest 2 of 3\n"))
+	stop := tracker.ReportEvery(inv, "make test", 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(reports) > 0
+	}, time.Second, 5*time.Millisecond)
+	stop()
+	stop() // safe to call twice
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "make test", reports[0].Command)
+	assert.Equal(t, 24, reports[0].BytesWritten)
+	assert.Equal(t, 2, reports[0].Current)
+	assert.Equal(t, 3, reports[0].Total)
+}
+
+func TestOutputTracker_KeepsBoundedTail(t *testing.T) {
+	var tracker OutputTracker
+	_, _ = tracker.Write([]byte(strings.Repeat("a", trackerTailLen) + "\n"))
+	_, _ = tracker.Write([]byte("last line\n"))
+	n, line := tracker.Snapshot()
+	assert.Equal(t, trackerTailLen+11, n)
+	assert.Equal(t, "last line", line)
+	assert.LessOrEqual(t, len(tracker.tail), trackerTailLen)
+}
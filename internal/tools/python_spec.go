@@ -0,0 +1,55 @@
+// Python tool specification: run code in a persistent per-session kernel.
+//
+// The tool is enabled with python_tool = true in config.toml. The kernel
+// lives on the worker that runs the session, like exec sessions.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "python_exec", Constructor: NewPythonExecToolSpec})
+}
+
+// Python execution limits, in seconds.
+const (
+	DefaultPythonTimeoutSec = 60
+	MaxPythonTimeoutSec     = 600
+)
+
+// DefaultPythonExecTimeoutMs covers the longest cell plus interrupt and
+// kernel startup overhead.
+const DefaultPythonExecTimeoutMs = (MaxPythonTimeoutSec + 30) * 1000
+
+// NewPythonExecToolSpec creates the specification for the python_exec tool.
+func NewPythonExecToolSpec() ToolSpec {
+	return ToolSpec{
+		Name: "python_exec",
+		Description: `Runs Python code in a persistent kernel, like a notebook cell. Variables, imports and function definitions are kept between calls in this session.
+- stdout, stderr and the value of a final expression are returned.
+- Open matplotlib figures are saved as PNG files after each call and their paths are returned as attachments.
+- A cell running longer than timeout_seconds is interrupted (KeyboardInterrupt); the kernel's state is kept.
+- Set restart=true to start a fresh kernel, clearing all state, before running code (code may be omitted to only restart).`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "code",
+				Type:        "string",
+				Description: "Python code to run.",
+				Required:    false,
+			},
+			{
+				Name:        "restart",
+				Type:        "boolean",
+				Description: "Restart the kernel before running code. Defaults to false.",
+				Required:    false,
+			},
+			{
+				Name:        "timeout_seconds",
+				Type:        "number",
+				Description: "Seconds to let the code run before interrupting it. Defaults to 60, max 600.",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultPythonExecTimeoutMs,
+		RetryPolicy:      RetryNone, // stateful kernel — don't retry
+	}
+}
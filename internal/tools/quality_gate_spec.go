@@ -0,0 +1,43 @@
+// Quality gate tool specification: run the project's checks in one call.
+//
+// The tool is enabled with quality_gate_tool = true in config.toml. The
+// checks come from the project's .codex/quality.toml, or default to gofmt,
+// go vet, golangci-lint and go test in a Go module.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "quality_gate", Constructor: NewQualityGateToolSpec})
+}
+
+// DefaultQualityGateTimeoutMs covers a full test run of a large module.
+const DefaultQualityGateTimeoutMs = 20 * 60 * 1000
+
+// NewQualityGateToolSpec creates the specification for the quality_gate tool.
+func NewQualityGateToolSpec() ToolSpec {
+	return ToolSpec{
+		Name: "quality_gate",
+		Description: `Runs the project's quality checks (formatting, vet, lint, tests) in one call and returns a report with one line per check: PASS, FAIL or SKIP, its duration, and the tail of a failing check's output.
+- Prefer it over running the checks one by one with the shell before declaring work done.
+- All checks run even when one fails. The call fails if any check fails.
+- A check whose tool is not installed is skipped.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "checks",
+				Type:        "array",
+				Description: "Names of the checks to run (e.g. [\"vet\", \"test\"]). Defaults to all configured checks.",
+				Required:    false,
+				Items:       map[string]interface{}{"type": "string"},
+			},
+			{
+				Name:        "packages",
+				Type:        "string",
+				Description: "Package pattern the checks run on (e.g. \"./internal/tools/...\"). Defaults to the project's, usually \"./...\".",
+				Required:    false,
+			},
+		},
+		DefaultTimeoutMs: DefaultQualityGateTimeoutMs,
+		RetryPolicy:      RetryNone, // tests can have side effects — don't retry
+	}
+}
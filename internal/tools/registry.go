@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"sort"
 )
 
 // ToolHandler is the interface for tool implementations.
@@ -66,3 +67,13 @@ func (r *ToolRegistry) HasTool(name string) bool {
 func (r *ToolRegistry) ToolCount() int {
 	return len(r.handlers)
 }
+
+// ToolNames returns the names of the registered tools, sorted.
+func (r *ToolRegistry) ToolNames() []string {
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,38 @@
+// Semantic code search tool specification: find code by meaning rather than
+// by exact pattern, using the session's embeddings index of the repository.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "semantic_search", Constructor: NewSemanticSearchToolSpec, ReadOnly: true})
+}
+
+// NewSemanticSearchToolSpec creates the specification for the semantic_search tool.
+func NewSemanticSearchToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "semantic_search",
+		Description: "Finds the code most relevant to a natural-language query using an embeddings index of the repository. Returns the top matching chunks with file paths and line ranges. Use it to locate code when you don't know the identifiers to grep for; the index is kept up to date with your edits.",
+		Parameters: []ToolParameter{
+			{
+				Name:        "query",
+				Type:        "string",
+				Description: "What to look for, e.g. \"where access tokens are refreshed\".",
+				Required:    true,
+			},
+			{
+				Name:        "path",
+				Type:        "string",
+				Description: "Optional directory or file that limits the search. Defaults to the whole repository.",
+				Required:    false,
+			},
+			{
+				Name:        "limit",
+				Type:        "number",
+				Description: "Maximum number of chunks to return (defaults to 5, at most 20).",
+				Required:    false,
+			},
+		},
+		RetryPolicy: RetryDefault, // read-only — safe to retry
+	}
+}
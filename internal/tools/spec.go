@@ -10,10 +10,10 @@ package tools
 func init() {
 	RegisterSpec(SpecEntry{Name: "shell", Constructor: func() ToolSpec { return NewShellToolSpec(false) }})
 	RegisterSpec(SpecEntry{Name: "shell_command", Constructor: func() ToolSpec { return NewShellCommandToolSpec(false) }})
-	RegisterSpec(SpecEntry{Name: "read_file", Constructor: NewReadFileToolSpec})
+	RegisterSpec(SpecEntry{Name: "read_file", Constructor: NewReadFileToolSpec, ReadOnly: true})
 	RegisterSpec(SpecEntry{Name: "write_file", Constructor: NewWriteFileToolSpec})
-	RegisterSpec(SpecEntry{Name: "list_dir", Constructor: NewListDirToolSpec})
-	RegisterSpec(SpecEntry{Name: "grep_files", Constructor: NewGrepFilesToolSpec})
+	RegisterSpec(SpecEntry{Name: "list_dir", Constructor: NewListDirToolSpec, ReadOnly: true})
+	RegisterSpec(SpecEntry{Name: "grep_files", Constructor: NewGrepFilesToolSpec, ReadOnly: true})
 	RegisterSpec(SpecEntry{Name: "apply_patch", Constructor: NewApplyPatchToolSpec})
 	RegisterSpec(SpecEntry{Name: "request_user_input", Constructor: NewRequestUserInputToolSpec})
 }
@@ -30,6 +30,15 @@ const (
 	DefaultToolTimeoutMs       = 120_000 // 2min — fallback for tools without a default
 )
 
+// read_file caps for reads without a limit. The line cap can be changed per
+// session (SessionConfiguration.ReadFileLimit). Byte-range reads never return
+// more than MaxReadFileByteLimit bytes.
+const (
+	DefaultReadFileLineLimit = 2000
+	DefaultReadFileByteLimit = 64 * 1024
+	MaxReadFileByteLimit     = 1024 * 1024
+)
+
 // ToolRetryPolicy configures Temporal activity retry behavior for a tool.
 // nil on a ToolSpec means "use the default policy" (3 attempts, exponential backoff).
 type ToolRetryPolicy struct {
@@ -72,6 +81,34 @@ type ToolSpec struct {
 	RetryPolicy *ToolRetryPolicy `json:"-"` // not sent to LLM
 }
 
+// ParameterSchema returns the JSON Schema of the tool's arguments:
+// RawJSONSchema when set, otherwise an object schema built from Parameters.
+func (s ToolSpec) ParameterSchema() map[string]interface{} {
+	if s.RawJSONSchema != nil {
+		return s.RawJSONSchema
+	}
+	properties := make(map[string]interface{}, len(s.Parameters))
+	required := make([]interface{}, 0)
+	for _, p := range s.Parameters {
+		prop := map[string]interface{}{
+			"type":        p.Type,
+			"description": p.Description,
+		}
+		if p.Items != nil {
+			prop["items"] = p.Items
+		}
+		properties[p.Name] = prop
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
 // ToolParameter defines a parameter for a tool.
 type ToolParameter struct {
 	Name        string                 `json:"name"`
@@ -138,6 +175,7 @@ func NewShellToolSpec(includePrefixRule bool) ToolSpec {
 			Description: "The timeout for the command in milliseconds",
 			Required:    false,
 		},
+		timeoutSecondsParameter("the command may run"),
 	}
 	params = append(params, approvalParameters(includePrefixRule)...)
 
@@ -183,6 +221,7 @@ func NewShellCommandToolSpec(includePrefixRule bool) ToolSpec {
 			Description: "The timeout for the command in milliseconds",
 			Required:    false,
 		},
+		timeoutSecondsParameter("the command may run"),
 	}
 	params = append(params, approvalParameters(includePrefixRule)...)
 
@@ -202,7 +241,7 @@ func NewShellCommandToolSpec(includePrefixRule bool) ToolSpec {
 func NewReadFileToolSpec() ToolSpec {
 	return ToolSpec{
 		Name:        "read_file",
-		Description: "Reads a local file with 1-indexed line numbers, supporting slice and indentation-aware block modes.",
+		Description: "Reads a local file with 1-indexed line numbers, supporting slice and indentation-aware block modes, or a raw byte range. The result reports the file's total lines and bytes; long files are returned in chunks.",
 		Parameters: []ToolParameter{
 			{
 				Name:        "file_path",
@@ -219,7 +258,19 @@ func NewReadFileToolSpec() ToolSpec {
 			{
 				Name:        "limit",
 				Type:        "number",
-				Description: "The maximum number of lines to return.",
+				Description: "The maximum number of lines to return. Defaults to the session's cap (2000 lines unless configured).",
+				Required:    false,
+			},
+			{
+				Name:        "byte_offset",
+				Type:        "number",
+				Description: "Read a byte range instead of lines, starting at this 0-indexed byte. For files with very long lines (minified or generated). Cannot be combined with offset, limit or mode.",
+				Required:    false,
+			},
+			{
+				Name:        "byte_limit",
+				Type:        "number",
+				Description: "The maximum number of bytes to return in a byte-range read (default 65536, at most 1048576).",
 				Required:    false,
 			},
 			{
@@ -344,6 +395,11 @@ It is important to remember:
 				Description: "The entire contents of the apply_patch command",
 				Required:    true,
 			},
+			{
+				Name:        "fuzzy_threshold",
+				Type:        "number",
+				Description: "Optional. Between 0 and 1: a hunk whose lines aren't found applies at its closest match if that match's confidence is at least this. Only set it after a failed call reported a closest match you have checked.",
+			},
 		},
 		DefaultTimeoutMs: DefaultApplyPatchTimeoutMs,
 		RetryPolicy:      RetryNone, // mutating — don't retry
@@ -502,6 +558,18 @@ func NewGrepFilesToolSpec() ToolSpec {
 				Description: "Maximum number of file paths to return (defaults to 100).",
 				Required:    false,
 			},
+			{
+				Name:        "changed_only",
+				Type:        "boolean",
+				Description: "Only search files changed in the git working tree (modified, staged or untracked).",
+				Required:    false,
+			},
+			{
+				Name:        "git_range",
+				Type:        "string",
+				Description: "Only search files changed in this git revision range (e.g. \"main...HEAD\"). Implies changed_only.",
+				Required:    false,
+			},
 		},
 		DefaultTimeoutMs: DefaultGrepFilesTimeoutMs,
 		RetryPolicy:      RetryDefault, // read-only — safe to retry
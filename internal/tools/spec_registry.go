@@ -6,14 +6,18 @@
 // (e.g. "collab" expands to spawn_agent, send_input, wait, …).
 package tools
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 // SpecEntry is the registry unit for a single tool.
 type SpecEntry struct {
-	Name        string         // Internal name: "shell_command", "patch_gpt"
-	LLMName     string         // LLM-facing name (defaults to Name if empty)
+	Name        string          // Internal name: "shell_command", "patch_gpt"
+	LLMName     string          // LLM-facing name (defaults to Name if empty)
 	Constructor func() ToolSpec // Returns the spec (spec.Name == LLM name)
-	Group       string         // Optional group: "collab"
+	Group       string          // Optional group: "collab"
+	ReadOnly    bool            // Never changes the workspace or anything outside it
 }
 
 // resolvedLLMName returns LLMName if set, otherwise Name.
@@ -49,6 +53,33 @@ func GetEntry(internalName string) (SpecEntry, bool) {
 	return e, ok
 }
 
+// IsReadOnly reports whether the tool with the given LLM-facing name is
+// registered as read-only. Unknown tools are not.
+func IsReadOnly(llmName string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, e := range specRegistry {
+		if e.resolvedLLMName() == llmName {
+			return e.ReadOnly
+		}
+	}
+	return false
+}
+
+// ReadOnlyTools returns the internal names of the read-only tools, sorted.
+func ReadOnlyTools() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	var names []string
+	for name, e := range specRegistry {
+		if e.ReadOnly {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // BuildSpecs constructs ToolSpec values for the given internal names.
 // Group names (e.g. "collab") are expanded first. Unknown names are skipped.
 func BuildSpecs(internalNames []string) []ToolSpec {
@@ -94,8 +125,15 @@ func DefaultEnabledTools() []string {
 		"write_file",
 		"list_dir",
 		"grep_files",
+		"grep_changed",
+		"code_outline",
 		"apply_patch",
 		"request_user_input",
+		"ask_user",
 		"update_plan",
+		"task_list",
+		"pin_context",
+		"current_time",
+		"rollback_workspace",
 	}
 }
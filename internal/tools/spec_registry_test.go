@@ -31,8 +31,8 @@ func TestBuildSpecs(t *testing.T) {
 
 func TestBuildSpecs_WithGroup(t *testing.T) {
 	specs := BuildSpecs([]string{"collab"})
-	// "collab" expands to 5 tools
-	require.Len(t, specs, 5)
+	// "collab" expands to 6 tools
+	require.Len(t, specs, 6)
 	names := make([]string, len(specs))
 	for i, s := range specs {
 		names[i] = s.Name
@@ -42,6 +42,7 @@ func TestBuildSpecs_WithGroup(t *testing.T) {
 	assert.Contains(t, names, "wait")
 	assert.Contains(t, names, "close_agent")
 	assert.Contains(t, names, "resume_agent")
+	assert.Contains(t, names, "fan_out")
 }
 
 func TestExpandGroups(t *testing.T) {
@@ -66,7 +67,12 @@ func TestDefaultEnabledTools(t *testing.T) {
 	assert.Contains(t, defaults, "write_file")
 	assert.Contains(t, defaults, "apply_patch")
 	assert.Contains(t, defaults, "request_user_input")
+	assert.Contains(t, defaults, "ask_user")
 	assert.Contains(t, defaults, "update_plan")
+	assert.Contains(t, defaults, "task_list")
+	assert.Contains(t, defaults, "rollback_workspace")
+	assert.Contains(t, defaults, "pin_context")
+	assert.Contains(t, defaults, "current_time")
 
 	// Every default should produce a valid spec
 	specs := BuildSpecs(defaults)
@@ -97,9 +103,9 @@ func TestBuiltInToolsRegistered(t *testing.T) {
 	// Verify all expected tools are registered after init()
 	expected := []string{
 		"shell", "shell_command",
-		"read_file", "write_file", "list_dir", "grep_files",
-		"apply_patch", "request_user_input", "update_plan",
-		"spawn_agent", "send_input", "wait", "close_agent", "resume_agent",
+		"read_file", "write_file", "list_dir", "grep_files", "grep_changed", "code_outline",
+		"apply_patch", "request_user_input", "ask_user", "update_plan", "task_list", "pin_context", "rollback_workspace",
+		"spawn_agent", "send_input", "wait", "close_agent", "resume_agent", "fan_out", "emit_result",
 	}
 	for _, name := range expected {
 		_, ok := GetEntry(name)
@@ -107,12 +113,24 @@ func TestBuiltInToolsRegistered(t *testing.T) {
 	}
 }
 
+func TestIsReadOnly(t *testing.T) {
+	for _, name := range []string{"read_file", "grep_files", "current_time", "fetch_url", "gh_get_issue", "browser_read_text"} {
+		assert.True(t, IsReadOnly(name), "%s should be read-only", name)
+	}
+	for _, name := range []string{"shell_command", "write_file", "apply_patch", "gh_comment", "browser_click", "rollback_workspace", "does_not_exist"} {
+		assert.False(t, IsReadOnly(name), "%s should not be read-only", name)
+	}
+	assert.Contains(t, ReadOnlyTools(), "fetch_url")
+	assert.NotContains(t, ReadOnlyTools(), "write_file")
+}
+
 func TestCollabGroupRegistered(t *testing.T) {
 	expanded := ExpandGroups([]string{"collab"})
-	assert.Len(t, expanded, 5)
+	assert.Len(t, expanded, 6)
 	assert.Contains(t, expanded, "spawn_agent")
 	assert.Contains(t, expanded, "send_input")
 	assert.Contains(t, expanded, "wait")
 	assert.Contains(t, expanded, "close_agent")
 	assert.Contains(t, expanded, "resume_agent")
+	assert.Contains(t, expanded, "fan_out")
 }
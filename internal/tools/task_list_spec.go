@@ -0,0 +1,38 @@
+// Task list tool specification for the task_list intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "task_list", Constructor: NewTaskListToolSpec})
+}
+
+// NewTaskListToolSpec creates the specification for the task_list tool.
+// This tool is intercepted by the workflow (not dispatched as an activity).
+// The task list is shared with the user, who edits it with /todo.
+func NewTaskListToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "task_list",
+		Description: `Read or edit the task list shared with the user. The user can add, complete, and remove tasks too, so read the list before starting work and mark tasks done as you finish them. Every call returns the current list.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "action",
+				Type:        "string",
+				Description: `"read" to view the list, "add" to add a task, "done" / "undone" to change a task's completion, "rm" to remove a task.`,
+				Required:    true,
+			},
+			{
+				Name:        "text",
+				Type:        "string",
+				Description: `Task description (for "add").`,
+				Required:    false,
+			},
+			{
+				Name:        "id",
+				Type:        "integer",
+				Description: `Task number (for "done", "undone" and "rm").`,
+				Required:    false,
+			},
+		},
+	}
+}
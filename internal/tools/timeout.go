@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxCommandTimeoutSec is the largest timeout_seconds a shell or exec call
+// may ask for. Workers can set a lower limit (WORKER_MAX_COMMAND_TIMEOUT).
+const MaxCommandTimeoutSec = 3600
+
+// CommandTimeoutGrace is added to a command's timeout for its activity's
+// StartToCloseTimeout, so the worker stops the command and returns its
+// output before Temporal times the activity out.
+const CommandTimeoutGrace = 30 * time.Second
+
+// timeoutSecondsParameter is the timeout_seconds parameter of the shell and
+// exec tools. what says what runs out of time.
+func timeoutSecondsParameter(what string) ToolParameter {
+	return ToolParameter{
+		Name: "timeout_seconds",
+		Type: "number",
+		Description: fmt.Sprintf("Maximum time in seconds %s (e.g. 600 for a long test suite; at most %d). "+
+			"When it runs out the command is stopped and you get its output so far.", what, MaxCommandTimeoutSec),
+		Required: false,
+	}
+}
+
+// RequestedCommandTimeout returns the run time a shell or exec call asked
+// for: timeout_seconds, else timeout_ms. 0 when neither is set.
+func RequestedCommandTimeout(args map[string]interface{}) time.Duration {
+	if sec, ok := positiveNumber(args["timeout_seconds"]); ok {
+		return time.Duration(sec * float64(time.Second))
+	}
+	if ms, ok := positiveNumber(args["timeout_ms"]); ok {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	return 0
+}
+
+// ClampCommandTimeout limits d to max, or to MaxCommandTimeoutSec when max
+// is 0 or larger.
+func ClampCommandTimeout(d, max time.Duration) time.Duration {
+	if limit := MaxCommandTimeoutSec * time.Second; max <= 0 || max > limit {
+		max = limit
+	}
+	return min(d, max)
+}
+
+func positiveNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, n > 0
+	case int:
+		return float64(n), n > 0
+	case int64:
+		return float64(n), n > 0
+	}
+	return 0, false
+}
+
+// CommandTimeout describes a shell or exec command stopped at its time
+// limit.
+type CommandTimeout struct {
+	LimitSec     int   `json:"limit_sec"`
+	RequestedSec int   `json:"requested_sec,omitempty"` // Set when the call asked for more than the limit
+	ElapsedMs    int64 `json:"elapsed_ms"`
+}
+
+// NewCommandTimeout describes a command stopped at limit after elapsed,
+// where the call asked for requested.
+func NewCommandTimeout(limit, requested, elapsed time.Duration) *CommandTimeout {
+	t := &CommandTimeout{LimitSec: ceilSeconds(limit), ElapsedMs: elapsed.Milliseconds()}
+	if requested > limit {
+		t.RequestedSec = ceilSeconds(requested)
+	}
+	return t
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// Note is appended to the command's output so the model knows it was
+// stopped and what it can do about it.
+func (t CommandTimeout) Note() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Timed out after %ds", t.LimitSec)
+	if t.RequestedSec > 0 {
+		fmt.Fprintf(&b, ", the most this worker allows (%ds was requested)", t.RequestedSec)
+	}
+	b.WriteString(": the command was stopped and the output above is all it printed. ")
+	if t.RequestedSec == 0 && t.LimitSec < MaxCommandTimeoutSec {
+		fmt.Fprintf(&b, "Re-run it with a larger timeout_seconds (at most %d) or split the work.]", MaxCommandTimeoutSec)
+	} else {
+		b.WriteString("Split the work into shorter commands.]")
+	}
+	return b.String()
+}
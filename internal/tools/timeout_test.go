@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestedCommandTimeout(t *testing.T) {
+	assert.Equal(t, 600*time.Second, RequestedCommandTimeout(map[string]interface{}{"timeout_seconds": float64(600)}))
+	assert.Equal(t, 1500*time.Millisecond, RequestedCommandTimeout(map[string]interface{}{"timeout_ms": float64(1500)}))
+	assert.Equal(t, 2*time.Second, RequestedCommandTimeout(map[string]interface{}{"timeout_seconds": 2, "timeout_ms": float64(9000)}),
+		"timeout_seconds wins over timeout_ms")
+	assert.Zero(t, RequestedCommandTimeout(map[string]interface{}{"timeout_seconds": float64(-5)}))
+	assert.Zero(t, RequestedCommandTimeout(map[string]interface{}{"timeout_seconds": "600"}))
+	assert.Zero(t, RequestedCommandTimeout(nil))
+}
+
+func TestClampCommandTimeout(t *testing.T) {
+	assert.Equal(t, 10*time.Minute, ClampCommandTimeout(10*time.Minute, 0))
+	assert.Equal(t, MaxCommandTimeoutSec*time.Second, ClampCommandTimeout(5*time.Hour, 0))
+	assert.Equal(t, 5*time.Minute, ClampCommandTimeout(10*time.Minute, 5*time.Minute))
+	assert.Equal(t, MaxCommandTimeoutSec*time.Second, ClampCommandTimeout(5*time.Hour, 10*time.Hour))
+	assert.Zero(t, ClampCommandTimeout(0, 5*time.Minute))
+}
+
+func TestCommandTimeout_Note(t *testing.T) {
+	timedOut := NewCommandTimeout(60*time.Second, 60*time.Second, 60200*time.Millisecond)
+	assert.Equal(t, CommandTimeout{LimitSec: 60, ElapsedMs: 60200}, *timedOut)
+	assert.Contains(t, timedOut.Note(), "[Timed out after 60s:")
+	assert.Contains(t, timedOut.Note(), "larger timeout_seconds")
+
+	clamped := NewCommandTimeout(5*time.Minute, 2*time.Hour, 5*time.Minute)
+	assert.Equal(t, 7200, clamped.RequestedSec)
+	assert.Contains(t, clamped.Note(), "the most this worker allows (7200s was requested)")
+	assert.Contains(t, clamped.Note(), "Split the work")
+}
@@ -0,0 +1,27 @@
+// Workspace tool specification for the rollback_workspace intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package tools
+
+func init() {
+	RegisterSpec(SpecEntry{Name: "rollback_workspace", Constructor: NewRollbackWorkspaceToolSpec})
+}
+
+// NewRollbackWorkspaceToolSpec creates the specification for the
+// rollback_workspace tool. This tool is intercepted by the workflow (not
+// dispatched as an activity). The workflow snapshots the workspace before
+// the first mutating tool call of each turn; this tool restores one.
+func NewRollbackWorkspaceToolSpec() ToolSpec {
+	return ToolSpec{
+		Name:        "rollback_workspace",
+		Description: `Restore the workspace (git working tree) to a snapshot, undoing file edits made since. A snapshot is taken automatically before the first file-modifying tool call of each turn, so calling this without a snapshot_id undoes the edits of the most recent turn. Each call steps further back. Only use when the user asks to undo changes or your edits went badly wrong.`,
+		Parameters: []ToolParameter{
+			{
+				Name:        "snapshot_id",
+				Type:        "string",
+				Description: `Optional snapshot ID (e.g. "snap-3"). Defaults to the most recent snapshot.`,
+				Required:    false,
+			},
+		},
+	}
+}
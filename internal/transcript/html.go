@@ -0,0 +1,356 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Report is a conversation prepared for WriteHTML.
+type Report struct {
+	Title       string // page heading, e.g. the workflow ID
+	GeneratedAt time.Time
+	Items       []models.ConversationItem
+	Usage       Usage
+}
+
+// Usage summarizes a session's token use for the report header.
+type Usage struct {
+	TotalTokens  int
+	InputTokens  int // prompt tokens, including cached ones
+	CachedTokens int
+	Turns        int
+	CostUSD      float64 // estimated cost; 0 leaves it out of the report
+}
+
+// EstimateCost prices u at the given USD rates per million input and
+// output tokens.
+func EstimateCost(u Usage, inputPerMillion, outputPerMillion float64) float64 {
+	output := u.TotalTokens - u.InputTokens
+	if output < 0 {
+		output = 0
+	}
+	return (float64(u.InputTokens)*inputPerMillion + float64(output)*outputPerMillion) / 1e6
+}
+
+// openOutputLines is how many lines of tool output are shown without
+// clicking; longer outputs start collapsed.
+const openOutputLines = 20
+
+// WriteHTML writes r as a standalone HTML page: no scripts and no external
+// resources, so it can be attached or hosted anywhere.
+func WriteHTML(w io.Writer, r Report) error {
+	body, timeline := renderItems(r.Items)
+	return pageTemplate.Execute(w, struct {
+		Report
+		Body     template.HTML
+		Timeline []planRevision
+	}{r, body, timeline})
+}
+
+// planArgs are the arguments of an update_plan call.
+type planArgs struct {
+	Explanation string `json:"explanation"`
+	Plan        []struct {
+		Step   string `json:"step"`
+		Status string `json:"status"`
+	} `json:"plan"`
+}
+
+// planRevision is one update_plan call in the plan timeline.
+type planRevision struct {
+	Anchor      string
+	Turn        int
+	Done, Total int
+	Explanation string
+}
+
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// renderItems renders the conversation body and collects the plan timeline.
+// Tool outputs are shown with their calls.
+func renderItems(items []models.ConversationItem) (template.HTML, []planRevision) {
+	outputs := make(map[string]models.ConversationItem)
+	calls := make(map[string]bool)
+	for _, item := range items {
+		switch item.Type {
+		case models.ItemTypeFunctionCallOutput:
+			outputs[item.CallID] = item
+		case models.ItemTypeFunctionCall:
+			calls[item.CallID] = true
+		}
+	}
+
+	var b strings.Builder
+	var timeline []planRevision
+	turn := 0
+	for _, item := range items {
+		anchor := fmt.Sprintf("item-%d", item.Seq)
+		switch item.Type {
+		case models.ItemTypeTurnStarted:
+			turn++
+			fmt.Fprintf(&b, "<h2 class=\"turn\" id=\"turn-%d\">Turn %d</h2>\n", turn, turn)
+		case models.ItemTypeUserMessage:
+			if strings.HasPrefix(item.Content, "<environment_context>") {
+				continue
+			}
+			writeMessage(&b, anchor, "user", "User", "<div class=\"plain\">"+html.EscapeString(item.Content)+"</div>")
+		case models.ItemTypeAssistantMessage:
+			if item.Content != "" {
+				writeMessage(&b, anchor, "assistant", "Assistant", renderMarkdown(item.Content))
+			}
+		case models.ItemTypeDeveloperMessage:
+			writeNote(&b, anchor, "developer: "+item.Content)
+		case models.ItemTypeUserShellCommand:
+			writeToolCall(&b, anchor, "User ran", item.UserShellCommand(), models.ConversationItem{}, &item)
+		case models.ItemTypeUserAnswer:
+			writeMessage(&b, anchor, "user", "User answer", "<div class=\"plain\">"+html.EscapeString(item.Content)+"</div>")
+		case models.ItemTypeFunctionCall:
+			output, ok := outputs[item.CallID]
+			var out *models.ConversationItem
+			if ok {
+				out = &output
+			}
+			verb, detail := ToolCallSummary(item.Name, item.Arguments)
+			if rev, isPlan := writeToolCall(&b, anchor, verb, detail, item, out); isPlan {
+				rev.Turn = turn
+				timeline = append(timeline, rev)
+			}
+		case models.ItemTypeFunctionCallOutput:
+			if !calls[item.CallID] {
+				writeToolCall(&b, anchor, "Output", item.Name, models.ConversationItem{}, &item)
+			}
+		case models.ItemTypeWebSearchCall:
+			verb, detail := WebSearchSummary(item.WebSearchAction, item.Content, item.WebSearchURL)
+			fmt.Fprintf(&b, "<div class=\"tool\" id=\"%s\"><div class=\"call\"><span class=\"verb\">%s</span> %s</div></div>\n",
+				anchor, html.EscapeString(verb), html.EscapeString(detail))
+		case models.ItemTypeAnnotation:
+			if a := item.Annotation; a != nil {
+				text := fmt.Sprintf("note on #%d:", a.TargetSeq)
+				if glyph := a.Reaction.Emoji(); glyph != "" {
+					text += " " + glyph
+				}
+				writeNote(&b, anchor, strings.TrimSpace(text+" "+item.Content))
+			}
+		case models.ItemTypeModelSwitch:
+			writeNote(&b, anchor, "Model switched")
+		case models.ItemTypeCompaction:
+			writeNote(&b, anchor, "Context compacted")
+		case models.ItemTypeTurnComplete:
+			if v := item.Verify; v != nil && v.Status != "" {
+				writeNote(&b, anchor, fmt.Sprintf("Verify %s: %s (%d attempts)", v.Status, v.Command, v.Attempts))
+			}
+			if v := item.Review; v != nil && v.Status != "" {
+				writeNote(&b, anchor, fmt.Sprintf("Peer review %s (%d rounds)", v.Status, v.Rounds))
+			}
+		}
+	}
+	return template.HTML(b.String()), timeline
+}
+
+// writeMessage writes a user or assistant message; body is trusted HTML.
+func writeMessage(b *strings.Builder, anchor, class, who, body string) {
+	fmt.Fprintf(b, "<div class=\"msg %s\" id=\"%s\"><div class=\"who\">%s</div>%s</div>\n", class, anchor, who, body)
+}
+
+// writeNote writes a dimmed one-line event.
+func writeNote(b *strings.Builder, anchor, text string) {
+	fmt.Fprintf(b, "<div class=\"note\" id=\"%s\">%s</div>\n", anchor, html.EscapeString(text))
+}
+
+// writeToolCall writes a tool call block, headed by verb and detail, with
+// its output, if any. For an update_plan call it returns the plan revision
+// and true.
+func writeToolCall(b *strings.Builder, anchor, verb, detail string, call models.ConversationItem, output *models.ConversationItem) (planRevision, bool) {
+	var body strings.Builder
+	var rev planRevision
+	isPlan := false
+	switch call.Name {
+	case "apply_patch":
+		var args struct {
+			Input string `json:"input"`
+		}
+		if json.Unmarshal([]byte(call.Arguments), &args) == nil && args.Input != "" {
+			body.WriteString(renderDiff(args.Input))
+		}
+	case "update_plan":
+		var args planArgs
+		if json.Unmarshal([]byte(call.Arguments), &args) == nil {
+			isPlan = true
+			rev = planRevision{Anchor: anchor, Total: len(args.Plan), Explanation: args.Explanation}
+			body.WriteString("<ul class=\"plan\">")
+			for _, step := range args.Plan {
+				marker := "○"
+				switch step.Status {
+				case "completed":
+					marker = "✓"
+					rev.Done++
+				case "in_progress":
+					marker = "●"
+				}
+				fmt.Fprintf(&body, "<li class=\"%s\">%s %s</li>", html.EscapeString(step.Status), marker, html.EscapeString(step.Step))
+			}
+			body.WriteString("</ul>")
+		}
+	}
+
+	open := true
+	if output != nil && output.Output != nil {
+		content := strings.TrimRight(output.Output.Content, "\n")
+		class := "output"
+		if output.Output.Success != nil && !*output.Output.Success {
+			class += " failed"
+		}
+		switch {
+		case content == "":
+			body.WriteString("<div class=\"note\">(no output)</div>")
+		case looksLikeDiff(content):
+			body.WriteString(renderDiff(content))
+		default:
+			fmt.Fprintf(&body, "<pre class=\"%s\">%s</pre>", class, html.EscapeString(content))
+		}
+		if n := output.Output.Redactions; n > 0 {
+			fmt.Fprintf(&body, "<div class=\"note\">%s</div>", FormatRedactions(n))
+		}
+		open = strings.Count(content, "\n") < openOutputLines
+	}
+
+	summary := fmt.Sprintf("<span class=\"verb\">%s</span> %s", html.EscapeString(verb), html.EscapeString(detail))
+	if body.Len() == 0 {
+		fmt.Fprintf(b, "<div class=\"tool\" id=\"%s\"><div class=\"call\">%s</div></div>\n", anchor, summary)
+		return rev, isPlan
+	}
+	openAttr := ""
+	if open {
+		openAttr = " open"
+	}
+	fmt.Fprintf(b, "<details class=\"tool\" id=\"%s\"%s><summary class=\"call\">%s</summary>%s</details>\n",
+		anchor, openAttr, summary, body.String())
+	return rev, isPlan
+}
+
+// looksLikeDiff reports whether tool output is a unified diff.
+func looksLikeDiff(content string) bool {
+	return strings.HasPrefix(content, "diff --git ") ||
+		(strings.HasPrefix(content, "--- ") && strings.Contains(content, "\n+++ "))
+}
+
+// renderDiff renders a unified diff or apply_patch input with added and
+// removed lines highlighted.
+func renderDiff(text string) string {
+	var b strings.Builder
+	b.WriteString("<pre class=\"diff\">")
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"),
+			strings.HasPrefix(line, "***"), strings.HasPrefix(line, "@@"),
+			strings.HasPrefix(line, "diff "):
+			class = "hdr"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		if class == "" {
+			b.WriteString(html.EscapeString(line) + "\n")
+		} else {
+			fmt.Fprintf(&b, "<span class=\"%s\">%s</span>\n", class, html.EscapeString(line))
+		}
+	}
+	b.WriteString("</pre>")
+	return b.String()
+}
+
+// renderMarkdown converts assistant markdown to HTML. Raw HTML in the
+// message is not passed through.
+func renderMarkdown(text string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		return "<div class=\"plain\">" + html.EscapeString(text) + "</div>"
+	}
+	return "<div class=\"md\">" + buf.String() + "</div>"
+}
+
+func formatCount(n int) string {
+	s := fmt.Sprintf("%d", n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+var pageTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"count": formatCount,
+	"usd":   func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"sub":   func(a, b int) int { return a - b },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 920px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+h1 { font-size: 1.5em; margin-bottom: 0.2em; }
+.meta { color: #656d76; font-size: 0.9em; }
+table.usage { border-collapse: collapse; margin: 1em 0; }
+table.usage td { padding: 2px 16px 2px 0; }
+table.usage td.n { text-align: right; font-variant-numeric: tabular-nums; }
+h2.turn { font-size: 1em; color: #656d76; border-top: 1px solid #d0d7de; padding-top: 0.8em; margin-top: 2em; }
+.msg { margin: 1em 0; }
+.who { font-weight: 600; font-size: 0.85em; color: #656d76; }
+.user .plain { background: #f6f8fa; border-left: 3px solid #0969da; padding: 0.5em 0.8em; white-space: pre-wrap; }
+.md pre, pre { background: #f6f8fa; padding: 0.6em 0.8em; overflow-x: auto; font-size: 0.85em; }
+.tool { margin: 0.5em 0; }
+.call { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; cursor: pointer; }
+.verb { font-weight: 600; color: #0969da; }
+pre.output { margin: 0.3em 0 0 1.2em; color: #424a53; white-space: pre-wrap; }
+pre.failed { color: #cf222e; }
+pre.diff { margin: 0.3em 0 0 1.2em; }
+.diff .add { color: #116329; background: #dafbe1; }
+.diff .del { color: #82071e; background: #ffebe9; }
+.diff .hdr { color: #656d76; }
+ul.plan { list-style: none; padding-left: 1.2em; margin: 0.3em 0; }
+ul.plan li.completed { color: #116329; }
+.note { color: #656d76; font-size: 0.85em; margin: 0.4em 0; }
+.timeline a { color: inherit; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Exported {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</div>
+<table class="usage">
+<tr><td>Turns</td><td class="n">{{.Usage.Turns}}</td></tr>
+<tr><td>Total tokens</td><td class="n">{{count .Usage.TotalTokens}}</td></tr>
+{{- if .Usage.InputTokens}}
+<tr><td>Input tokens</td><td class="n">{{count .Usage.InputTokens}}</td></tr>
+<tr><td>Output tokens</td><td class="n">{{count (sub .Usage.TotalTokens .Usage.InputTokens)}}</td></tr>
+{{- end}}
+<tr><td>Cached tokens</td><td class="n">{{count .Usage.CachedTokens}}</td></tr>
+{{- if .Usage.CostUSD}}
+<tr><td>Estimated cost</td><td class="n">{{usd .Usage.CostUSD}}</td></tr>
+{{- end}}
+</table>
+{{- if .Timeline}}
+<h2>Plan timeline</h2>
+<ol class="timeline">
+{{- range .Timeline}}
+<li><a href="#{{.Anchor}}">Turn {{.Turn}}: {{.Done}}/{{.Total}} done</a>{{if .Explanation}} — {{.Explanation}}{{end}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{.Body}}
+</body>
+</html>
+`))
@@ -0,0 +1,67 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestWriteHTML(t *testing.T) {
+	ok, failed := true, false
+	patchInput := "*** Begin Patch\n*** Update File: main.go\n-old\n+new\n*** End Patch"
+	items := []models.ConversationItem{
+		{Seq: 0, Type: models.ItemTypeTurnStarted, TurnID: "turn-1"},
+		{Seq: 1, Type: models.ItemTypeUserMessage, Content: "<environment_context>\n</environment_context>"},
+		{Seq: 2, Type: models.ItemTypeUserMessage, Content: "Why does <b>main</b> fail?"},
+		{Seq: 3, Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "update_plan",
+			Arguments: `{"explanation": "Find the bug", "plan": [{"step": "Reproduce", "status": "completed"}, {"step": "Fix", "status": "in_progress"}]}`},
+		{Seq: 4, Type: models.ItemTypeFunctionCall, CallID: "c2", Name: "apply_patch", Arguments: fmt.Sprintf(`{"input": %q}`, patchInput)},
+		{Seq: 5, Type: models.ItemTypeFunctionCallOutput, CallID: "c1", Output: &models.FunctionCallOutputPayload{Content: "Plan updated", Success: &ok}},
+		{Seq: 6, Type: models.ItemTypeFunctionCallOutput, CallID: "c2", Output: &models.FunctionCallOutputPayload{Content: "boom <script>", Success: &failed}},
+		{Seq: 7, Type: models.ItemTypeAssistantMessage, Content: "Fixed **it**.\n\n<script>alert(1)</script>"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, Report{
+		Title:       "codex-1234",
+		GeneratedAt: time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC),
+		Items:       items,
+		Usage:       Usage{TotalTokens: 12500, InputTokens: 10000, CachedTokens: 4000, Turns: 1, CostUSD: 0.0461},
+	}))
+	page := buf.String()
+
+	assert.Contains(t, page, "<title>codex-1234</title>")
+	assert.NotContains(t, page, "environment_context")
+	assert.Contains(t, page, "Why does &lt;b&gt;main&lt;/b&gt; fail?")
+	assert.Contains(t, page, "<strong>it</strong>")
+	assert.NotContains(t, page, "<script>", "raw HTML is escaped or dropped")
+
+	assert.Contains(t, page, `<span class="verb">Update</span> main.go`)
+	assert.Contains(t, page, `<span class="add">+new</span>`)
+	assert.Contains(t, page, `<span class="del">-old</span>`)
+	assert.Contains(t, page, `<pre class="output failed">boom &lt;script&gt;</pre>`)
+
+	assert.Contains(t, page, `<li class="completed">✓ Reproduce</li>`)
+	assert.Contains(t, page, `<a href="#item-3">Turn 1: 1/2 done</a> — Find the bug`)
+
+	assert.Contains(t, page, "12,500")
+	assert.Contains(t, page, "<td>Output tokens</td><td class=\"n\">2,500</td>")
+	assert.Contains(t, page, "$0.05")
+}
+
+func TestEstimateCost(t *testing.T) {
+	u := Usage{TotalTokens: 1_500_000, InputTokens: 1_000_000}
+	assert.InDelta(t, 2.5+5.0, EstimateCost(u, 2.5, 10), 1e-9)
+}
+
+func TestLooksLikeDiff(t *testing.T) {
+	assert.True(t, looksLikeDiff("diff --git a/x b/x\n--- a/x\n+++ b/x"))
+	assert.True(t, looksLikeDiff("--- a/x\n+++ b/x\n@@ -1 +1 @@"))
+	assert.False(t, looksLikeDiff("--- PASS: TestX (0.00s)"))
+}
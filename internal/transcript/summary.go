@@ -0,0 +1,241 @@
+// Package transcript renders conversation items for people. The summaries
+// here are shared by the TUI and the HTML report.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+)
+
+// ToolCallSummary parses the tool name and JSON arguments, returning a
+// human-readable verb and detail string matching the Codex output style.
+//
+//	shell        → ("Ran", "echo hello")
+//	read_file    → ("Read", "/tmp/foo.txt")
+//	write_file   → ("Wrote", "/tmp/bar.txt")
+//	apply_patch  → ("Update", "path/to/file") or ("Patched", "")
+//	list_dir     → ("Listed", "/tmp")
+//	grep_files   → ("Searched", `"TODO" in src/`)
+//	unknown      → ("Ran", "unknown_tool(…)")
+func ToolCallSummary(name, argsJSON string) (verb, detail string) {
+	var args map[string]interface{}
+	_ = json.Unmarshal([]byte(argsJSON), &args)
+
+	switch name {
+	case "shell":
+		if cmd, ok := args["command"].(string); ok {
+			return "Ran", TruncateString(cmd, 120)
+		}
+		return "Ran", TruncateString(argsJSON, 120)
+	case "read_file":
+		if fp, ok := args["file_path"].(string); ok {
+			return "Read", fp
+		}
+		return "Read", ""
+	case "write_file":
+		if fp, ok := args["file_path"].(string); ok {
+			return "Wrote", fp
+		}
+		return "Wrote", ""
+	case "apply_patch":
+		if input, _ := args["input"].(string); input != "" {
+			paths := PatchFilePaths(input)
+			if len(paths) == 1 {
+				return "Update", paths[0]
+			}
+			if len(paths) > 1 {
+				return "Update", paths[0] + fmt.Sprintf(" +%d files", len(paths)-1)
+			}
+		}
+		return "Patched", ""
+	case "list_dir":
+		if dp, ok := args["dir_path"].(string); ok {
+			return "Listed", dp
+		}
+		if dp, ok := args["path"].(string); ok {
+			return "Listed", dp
+		}
+		return "Listed", ""
+	case "grep_files":
+		var parts []string
+		if pat, ok := args["pattern"].(string); ok {
+			parts = append(parts, fmt.Sprintf("%q", pat))
+		}
+		if dir, ok := args["path"].(string); ok {
+			parts = append(parts, "in "+dir)
+		}
+		if len(parts) > 0 {
+			return "Searched", strings.Join(parts, " ")
+		}
+		return "Searched", ""
+	case "grep_changed":
+		where := "changed files"
+		if r, ok := args["git_range"].(string); ok && r != "" {
+			where = "files changed in " + r
+		}
+		if pat, ok := args["pattern"].(string); ok {
+			return "Searched", fmt.Sprintf("%q in %s", pat, where)
+		}
+		return "Searched", where
+	case "code_outline":
+		if dir, ok := args["path"].(string); ok && dir != "" {
+			return "Outlined", dir
+		}
+		return "Outlined", "."
+	case "semantic_search":
+		if q, ok := args["query"].(string); ok {
+			if dir, ok := args["path"].(string); ok && dir != "" {
+				return "Searched", fmt.Sprintf("%q in %s", q, dir)
+			}
+			return "Searched", fmt.Sprintf("%q", q)
+		}
+		return "Searched", ""
+	case "fetch_url":
+		if u, ok := args["url"].(string); ok {
+			return "Fetched", TruncateString(u, 120)
+		}
+		return "Fetched", ""
+	case "request_user_input":
+		return "Asked", "user a question"
+	case "ask_user":
+		if q, ok := args["question"].(string); ok && q != "" {
+			return "Asked", TruncateString(q, 80)
+		}
+		return "Asked", "user a question"
+	case "update_plan":
+		return "Updated", "plan"
+	case "fan_out":
+		if tasks, ok := args["tasks"].([]interface{}); ok {
+			return "Spawned", fmt.Sprintf("%d agents", len(tasks))
+		}
+		return "Spawned", "agents"
+	case "task_list":
+		action, _ := args["action"].(string)
+		switch action {
+		case "", "read":
+			return "Read", "task list"
+		case "add":
+			text, _ := args["text"].(string)
+			return "Added", "task " + fmt.Sprintf("%q", TruncateString(text, 60))
+		default:
+			if id, ok := args["id"].(float64); ok {
+				return "Updated", fmt.Sprintf("task #%d (%s)", int(id), action)
+			}
+			return "Updated", "task list"
+		}
+	case "pin_context":
+		note, _ := args["note"].(string)
+		return "Pinned", fmt.Sprintf("%q", TruncateString(note, 60))
+	case "current_time":
+		return "Checked", "the time"
+	case "quality_gate":
+		return "Checked", QualityGateDetail(args)
+	case "share_artifact":
+		path, _ := args["path"].(string)
+		return "Shared", path
+	case "rollback_workspace":
+		if id, ok := args["snapshot_id"].(string); ok && id != "" {
+			return "Rolled back", "workspace to " + id
+		}
+		return "Rolled back", "workspace"
+	default:
+		detail := name + "(" + TruncateString(argsJSON, 80) + ")"
+		return "Ran", detail
+	}
+}
+
+// QualityGateDetail describes a quality_gate call's arguments, e.g.
+// "vet, test on ./internal/...".
+func QualityGateDetail(args map[string]interface{}) string {
+	detail := "all checks"
+	if checks, ok := args["checks"].([]interface{}); ok && len(checks) > 0 {
+		names := make([]string, 0, len(checks))
+		for _, c := range checks {
+			if s, ok := c.(string); ok {
+				names = append(names, s)
+			}
+		}
+		detail = strings.Join(names, ", ")
+	}
+	if p, ok := args["packages"].(string); ok && p != "" {
+		detail += " on " + p
+	}
+	return detail
+}
+
+// WebSearchSummary returns the verb and detail for a web search action.
+//
+//	search       → ("Searched", query)
+//	open_page    → ("Opened page", URL)
+//	find_in_page → ("Searched page", "'pattern' in URL")
+//	unknown      → ("Searched web", "")
+//
+// Maps to: codex-rs/exec/src/event_processor_with_human_output.rs WebSearchEnd
+func WebSearchSummary(action, content, url string) (verb, detail string) {
+	switch action {
+	case "search":
+		return "Searched", content
+	case "open_page":
+		if url != "" {
+			return "Opened page", url
+		}
+		return "Opened page", content
+	case "find_in_page":
+		return "Searched page", content
+	default:
+		if content != "" {
+			return "Searched", content
+		}
+		return "Searched web", ""
+	}
+}
+
+// PatchFilePaths extracts file paths from parsed patch input.
+// Returns nil if parsing fails.
+func PatchFilePaths(input string) []string {
+	p, err := patch.Parse(input)
+	if err != nil {
+		return nil
+	}
+	paths := make([]string, len(p.Hunks))
+	for i, h := range p.Hunks {
+		paths[i] = h.Path
+	}
+	return paths
+}
+
+// FormatRedactions describes how many secrets were scrubbed from an output.
+func FormatRedactions(n int) string {
+	if n == 1 {
+		return "(1 secret redacted)"
+	}
+	return fmt.Sprintf("(%d secrets redacted)", n)
+}
+
+// TruncateMiddle returns at most limit lines. When the input exceeds the limit,
+// it keeps the first 2 and last 2 lines with a "… +N lines" placeholder in between.
+// The returned omitted count reflects lines replaced by the placeholder.
+func TruncateMiddle(lines []string, limit int) (result []string, omitted int) {
+	if len(lines) <= limit {
+		return lines, 0
+	}
+	head := 2
+	tail := 2
+	omitted = len(lines) - head - tail
+	result = make([]string, 0, head+1+tail)
+	result = append(result, lines[:head]...)
+	result = append(result, fmt.Sprintf("… +%d lines", omitted))
+	result = append(result, lines[len(lines)-tail:]...)
+	return result, omitted
+}
+
+// TruncateString truncates s to maxLen characters, appending "…" if truncated.
+func TruncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "…"
+}
@@ -0,0 +1,173 @@
+package transcript
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []string
+		limit       int
+		wantLen     int
+		wantOmitted int
+	}{
+		{
+			name:        "under limit",
+			input:       []string{"a", "b", "c"},
+			limit:       5,
+			wantLen:     3,
+			wantOmitted: 0,
+		},
+		{
+			name:        "at limit",
+			input:       []string{"a", "b", "c", "d", "e"},
+			limit:       5,
+			wantLen:     5,
+			wantOmitted: 0,
+		},
+		{
+			name:        "over limit",
+			input:       []string{"a", "b", "c", "d", "e", "f", "g", "h"},
+			limit:       5,
+			wantLen:     5, // 2 head + 1 ellipsis + 2 tail
+			wantOmitted: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, omitted := TruncateMiddle(tt.input, tt.limit)
+			assert.Equal(t, tt.wantLen, len(result))
+			assert.Equal(t, tt.wantOmitted, omitted)
+			if omitted > 0 {
+				assert.Contains(t, result[2], "… +")
+			}
+		})
+	}
+}
+
+func TestToolCallSummary_RequestUserInput(t *testing.T) {
+	verb, detail := ToolCallSummary("request_user_input", `{"questions": []}`)
+	assert.Equal(t, "Asked", verb)
+	assert.Equal(t, "user a question", detail)
+}
+
+func TestToolCallSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		toolName   string
+		argsJSON   string
+		wantVerb   string
+		wantDetail string
+	}{
+		{"shell", "shell", `{"command": "echo hello"}`, "Ran", "echo hello"},
+		{"read_file", "read_file", `{"file_path": "/tmp/foo.txt"}`, "Read", "/tmp/foo.txt"},
+		{"write_file", "write_file", `{"file_path": "/tmp/bar.txt"}`, "Wrote", "/tmp/bar.txt"},
+		{"apply_patch_no_input", "apply_patch", `{"file_path": "/tmp/x.go"}`, "Patched", ""},
+		{"list_dir", "list_dir", `{"dir_path": "/tmp"}`, "Listed", "/tmp"},
+		{"grep_files", "grep_files", `{"pattern": "TODO", "path": "src/"}`, "Searched", `"TODO" in src/`},
+		{"grep_changed", "grep_changed", `{"pattern": "TODO"}`, "Searched", `"TODO" in changed files`},
+		{"grep_changed range", "grep_changed", `{"pattern": "TODO", "git_range": "main...HEAD"}`, "Searched", `"TODO" in files changed in main...HEAD`},
+		{"code_outline", "code_outline", `{"path": "internal/tools"}`, "Outlined", "internal/tools"},
+		{"semantic_search", "semantic_search", `{"query": "token refresh", "path": "auth"}`, "Searched", `"token refresh" in auth`},
+		{"fan_out", "fan_out", `{"tasks": [{"message": "a"}, {"message": "b"}]}`, "Spawned", "2 agents"},
+		{"fetch_url", "fetch_url", `{"url": "https://go.dev/doc/"}`, "Fetched", "https://go.dev/doc/"},
+		{"unknown", "my_tool", `{"x": 1}`, "Ran", `my_tool({"x": 1})`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verb, detail := ToolCallSummary(tt.toolName, tt.argsJSON)
+			assert.Equal(t, tt.wantVerb, verb)
+			assert.Equal(t, tt.wantDetail, detail)
+		})
+	}
+}
+
+func TestToolCallSummary_ApplyPatchWithInput(t *testing.T) {
+	input := "*** Begin Patch\n*** Update File: src/main.go\n-old\n+new\n*** End Patch"
+	args := fmt.Sprintf(`{"input": %q}`, input)
+	verb, detail := ToolCallSummary("apply_patch", args)
+	assert.Equal(t, "Update", verb)
+	assert.Equal(t, "src/main.go", detail)
+}
+
+func TestToolCallSummary_ApplyPatchMultiFile(t *testing.T) {
+	input := "*** Begin Patch\n*** Update File: a.go\n-x\n+y\n*** Add File: b.go\n+content\n*** End Patch"
+	args := fmt.Sprintf(`{"input": %q}`, input)
+	verb, detail := ToolCallSummary("apply_patch", args)
+	assert.Equal(t, "Update", verb)
+	assert.Contains(t, detail, "a.go")
+	assert.Contains(t, detail, "+1 files")
+}
+
+func TestToolCallSummary_UpdatePlan(t *testing.T) {
+	verb, detail := ToolCallSummary("update_plan", `{"steps": []}`)
+	assert.Equal(t, "Updated", verb)
+	assert.Equal(t, "plan", detail)
+}
+
+func TestToolCallSummary_RollbackWorkspace(t *testing.T) {
+	verb, detail := ToolCallSummary("rollback_workspace", `{}`)
+	assert.Equal(t, "Rolled back", verb)
+	assert.Equal(t, "workspace", detail)
+
+	_, detail = ToolCallSummary("rollback_workspace", `{"snapshot_id": "snap-2"}`)
+	assert.Equal(t, "workspace to snap-2", detail)
+}
+
+func TestToolCallSummary_PinContext(t *testing.T) {
+	verb, detail := ToolCallSummary("pin_context", `{"note": "Target Go 1.22"}`)
+	assert.Equal(t, "Pinned", verb)
+	assert.Equal(t, `"Target Go 1.22"`, detail)
+}
+
+func TestToolCallSummary_ShareArtifact(t *testing.T) {
+	verb, detail := ToolCallSummary("share_artifact", `{"path": "coverage.html"}`)
+	assert.Equal(t, "Shared", verb)
+	assert.Equal(t, "coverage.html", detail)
+}
+
+func TestWebSearchSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		content    string
+		url        string
+		wantVerb   string
+		wantDetail string
+	}{
+		{"search", "search", "weather NYC", "", "Searched", "weather NYC"},
+		{"open_page with url", "open_page", "", "https://example.com", "Opened page", "https://example.com"},
+		{"open_page fallback to content", "open_page", "https://example.com", "", "Opened page", "https://example.com"},
+		{"find_in_page", "find_in_page", "'TODO' in page.html", "page.html", "Searched page", "'TODO' in page.html"},
+		{"unknown with content", "", "some query", "", "Searched", "some query"},
+		{"unknown empty", "", "", "", "Searched web", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verb, detail := WebSearchSummary(tt.action, tt.content, tt.url)
+			assert.Equal(t, tt.wantVerb, verb)
+			assert.Equal(t, tt.wantDetail, detail)
+		})
+	}
+}
+
+func TestToolCallSummary_TaskList(t *testing.T) {
+	verb, detail := ToolCallSummary("task_list", `{"action": "read"}`)
+	assert.Equal(t, "Read", verb)
+	assert.Equal(t, "task list", detail)
+
+	verb, detail = ToolCallSummary("task_list", `{"action": "add", "text": "write docs"}`)
+	assert.Equal(t, "Added", verb)
+	assert.Equal(t, `task "write docs"`, detail)
+
+	verb, detail = ToolCallSummary("task_list", `{"action": "done", "id": 2}`)
+	assert.Equal(t, "Updated", verb)
+	assert.Equal(t, "task #2 (done)", detail)
+}
@@ -0,0 +1,384 @@
+// Package usage rolls completed sessions' results up into usage reports:
+// tokens, prompt-cache use, estimated cost and tool calls per day, team and
+// model. The reports are built by UsageReportWorkflow (see
+// internal/workflow/usage_report.go) and printed by `client usage`.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package usage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/transcript"
+)
+
+// DefaultTeamTagPrefix marks the session tag naming a session's team, as in
+// `client tag --add team:payments`.
+const DefaultTeamTagPrefix = "team:"
+
+// Unassigned is the team of sessions without a team tag.
+const Unassigned = "(unassigned)"
+
+// maxReportTools caps the tools listed in a report, most called first.
+const maxReportTools = 15
+
+// Price is a model's rate in USD per million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// ModelUsage is the tokens one model used in a session.
+type ModelUsage struct {
+	Model        string `json:"model"`
+	TotalTokens  int    `json:"total_tokens"`
+	InputTokens  int    `json:"input_tokens"` // Prompt tokens incl. cache reads/writes
+	CachedTokens int    `json:"cached_tokens"`
+}
+
+// cost estimates the usage's cost. ok is false when the model has no price.
+func (u ModelUsage) cost(prices map[string]Price) (float64, bool) {
+	price, ok := prices[u.Model]
+	if !ok {
+		return 0, false
+	}
+	return transcript.EstimateCost(transcript.Usage{TotalTokens: u.TotalTokens, InputTokens: u.InputTokens},
+		price.Input, price.Output), true
+}
+
+// Session is one completed session's usage, taken from its WorkflowResult.
+type Session struct {
+	WorkflowID   string         `json:"workflow_id"`
+	ClosedAt     time.Time      `json:"closed_at"`
+	Model        string         `json:"model,omitempty"`  // The session's model when it ended
+	Models       []string       `json:"models,omitempty"` // Every model that served its turns
+	Tags         []string       `json:"tags,omitempty"`
+	TotalTokens  int            `json:"total_tokens"`
+	InputTokens  int            `json:"input_tokens"` // Prompt tokens incl. cache reads/writes
+	CachedTokens int            `json:"cached_tokens"`
+	ByModel      []ModelUsage   `json:"by_model,omitempty"`   // The tokens above split by model
+	ToolCalls    map[string]int `json:"tool_calls,omitempty"` // By tool name
+
+	// Prompt suggestions, generated by a cheaper model and not included in
+	// TotalTokens.
+	SuggestionModel       string `json:"suggestion_model,omitempty"`
+	SuggestionTokens      int    `json:"suggestion_tokens,omitempty"`
+	SuggestionInputTokens int    `json:"suggestion_input_tokens,omitempty"`
+}
+
+// Totals sums the usage of a group of sessions.
+type Totals struct {
+	Sessions     int     `json:"sessions"`
+	TotalTokens  int     `json:"total_tokens"`
+	InputTokens  int     `json:"input_tokens"`
+	CachedTokens int     `json:"cached_tokens"`
+	ToolCalls    int     `json:"tool_calls"`
+	CostUSD      float64 `json:"cost_usd"`
+	// SuggestionTokens are the prompt suggestions' tokens, not included in
+	// TotalTokens; their cost, when their model is priced, is included in
+	// CostUSD and also given on its own.
+	SuggestionTokens  int     `json:"suggestion_tokens,omitempty"`
+	SuggestionCostUSD float64 `json:"suggestion_cost_usd,omitempty"`
+	// Unpriced counts sessions with tokens from a model that has no price;
+	// their tokens are included but only the priced models' cost is.
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// Group is the usage of the sessions sharing a team or model.
+type Group struct {
+	Name string `json:"name"`
+	Totals
+}
+
+// Day is the usage of the sessions that ended on one UTC day.
+type Day struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Totals
+	Teams []Group `json:"teams"`
+}
+
+// ToolCount is how often a tool was called.
+type ToolCount struct {
+	Name  string `json:"name"`
+	Calls int    `json:"calls"`
+}
+
+// Report is the rollup of the sessions that ended in [Since, Until).
+type Report struct {
+	Since       time.Time `json:"since"`
+	Until       time.Time `json:"until"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Totals
+	Days   []Day       `json:"days"`
+	Teams  []Group     `json:"teams"`
+	Models []Group     `json:"models"`
+	Tools  []ToolCount `json:"tools"`
+	// Truncated is set when there were more sessions than one report
+	// collects; Skipped counts sessions whose result could not be read.
+	Truncated bool `json:"truncated,omitempty"`
+	Skipped   int  `json:"skipped,omitempty"`
+}
+
+// Options configure Build.
+type Options struct {
+	Since, Until  time.Time
+	GeneratedAt   time.Time
+	Prices        map[string]Price // By model
+	TeamTagPrefix string           // Default DefaultTeamTagPrefix
+}
+
+// Team returns the team named by a session's tags, or Unassigned.
+func Team(tags []string, prefix string) string {
+	if prefix == "" {
+		prefix = DefaultTeamTagPrefix
+	}
+	for _, t := range tags {
+		if name, ok := strings.CutPrefix(t, prefix); ok && name != "" {
+			return name
+		}
+	}
+	return Unassigned
+}
+
+// parts splits a session's tokens by model. Tokens not attributed to a
+// model, as in sessions that ended before usage was recorded per model, go
+// to the session's final model. final is the index of the part the
+// session's tool calls and suggestions count toward: the final model's.
+func (s Session) parts() (parts []ModelUsage, final int) {
+	rest := ModelUsage{Model: s.Model, TotalTokens: s.TotalTokens, InputTokens: s.InputTokens, CachedTokens: s.CachedTokens}
+	final = -1
+	for _, u := range s.ByModel {
+		rest.TotalTokens -= u.TotalTokens
+		rest.InputTokens -= u.InputTokens
+		rest.CachedTokens -= u.CachedTokens
+		if u.Model == s.Model {
+			final = len(parts)
+		}
+		parts = append(parts, u)
+	}
+	if rest.TotalTokens > 0 || len(parts) == 0 {
+		rest.InputTokens, rest.CachedTokens = max(rest.InputTokens, 0), max(rest.CachedTokens, 0)
+		if final >= 0 {
+			parts[final].TotalTokens += rest.TotalTokens
+			parts[final].InputTokens += rest.InputTokens
+			parts[final].CachedTokens += rest.CachedTokens
+		} else {
+			final = len(parts)
+			parts = append(parts, rest)
+		}
+	}
+	if final < 0 {
+		final = len(parts) - 1
+	}
+	return parts, final
+}
+
+// add counts one session, pricing each model's tokens at its rate.
+func (t *Totals) add(s Session, prices map[string]Price) {
+	t.Sessions++
+	t.TotalTokens += s.TotalTokens
+	t.InputTokens += s.InputTokens
+	t.CachedTokens += s.CachedTokens
+	t.addExtras(s, prices)
+	parts, _ := s.parts()
+	unpriced := false
+	for _, u := range parts {
+		c, ok := u.cost(prices)
+		t.CostUSD += c
+		unpriced = unpriced || !ok
+	}
+	if unpriced {
+		t.Unpriced++
+	}
+}
+
+// addModel counts the part of a session one model served. The session's
+// tool calls and suggestions count toward its final model's group.
+func (t *Totals) addModel(s Session, u ModelUsage, final bool, prices map[string]Price) {
+	t.Sessions++
+	t.TotalTokens += u.TotalTokens
+	t.InputTokens += u.InputTokens
+	t.CachedTokens += u.CachedTokens
+	if final {
+		t.addExtras(s, prices)
+	}
+	c, ok := u.cost(prices)
+	t.CostUSD += c
+	if !ok {
+		t.Unpriced++
+	}
+}
+
+// addExtras counts a session's tool calls and prompt suggestions.
+func (t *Totals) addExtras(s Session, prices map[string]Price) {
+	for _, n := range s.ToolCalls {
+		t.ToolCalls += n
+	}
+	t.SuggestionTokens += s.SuggestionTokens
+	if price, ok := prices[s.SuggestionModel]; ok && s.SuggestionTokens > 0 {
+		c := transcript.EstimateCost(transcript.Usage{TotalTokens: s.SuggestionTokens, InputTokens: s.SuggestionInputTokens},
+			price.Input, price.Output)
+		t.SuggestionCostUSD += c
+		t.CostUSD += c
+	}
+}
+
+// Build rolls sessions up by day, team and model. A session that used
+// several models counts toward each of their groups with that model's
+// tokens. Groups are sorted by cost, then tokens, largest first; days in
+// date order.
+func Build(sessions []Session, opts Options) Report {
+	r := Report{Since: opts.Since, Until: opts.Until, GeneratedAt: opts.GeneratedAt}
+	days := map[string]*Day{}
+	dayTeams := map[string]map[string]*Group{}
+	teams := map[string]*Group{}
+	byModel := map[string]*Group{}
+	tools := map[string]int{}
+
+	group := func(m map[string]*Group, name string) *Group {
+		g := m[name]
+		if g == nil {
+			g = &Group{Name: name}
+			m[name] = g
+		}
+		return g
+	}
+
+	for _, s := range sessions {
+		r.Totals.add(s, opts.Prices)
+
+		date := s.ClosedAt.UTC().Format(time.DateOnly)
+		if days[date] == nil {
+			days[date] = &Day{Date: date}
+			dayTeams[date] = map[string]*Group{}
+		}
+		days[date].add(s, opts.Prices)
+
+		team := Team(s.Tags, opts.TeamTagPrefix)
+		group(teams, team).add(s, opts.Prices)
+		group(dayTeams[date], team).add(s, opts.Prices)
+
+		parts, final := s.parts()
+		for i, u := range parts {
+			model := u.Model
+			if model == "" {
+				model = "(unknown)"
+			}
+			group(byModel, model).addModel(s, u, i == final, opts.Prices)
+		}
+
+		for name, n := range s.ToolCalls {
+			tools[name] += n
+		}
+	}
+
+	for date, d := range days {
+		d.Teams = sortedGroups(dayTeams[date])
+		r.Days = append(r.Days, *d)
+	}
+	sort.Slice(r.Days, func(i, j int) bool { return r.Days[i].Date < r.Days[j].Date })
+	r.Teams = sortedGroups(teams)
+	r.Models = sortedGroups(byModel)
+
+	for name, n := range tools {
+		r.Tools = append(r.Tools, ToolCount{Name: name, Calls: n})
+	}
+	sort.Slice(r.Tools, func(i, j int) bool {
+		if r.Tools[i].Calls != r.Tools[j].Calls {
+			return r.Tools[i].Calls > r.Tools[j].Calls
+		}
+		return r.Tools[i].Name < r.Tools[j].Name
+	})
+	if len(r.Tools) > maxReportTools {
+		r.Tools = r.Tools[:maxReportTools]
+	}
+	return r
+}
+
+func sortedGroups(m map[string]*Group) []Group {
+	groups := make([]Group, 0, len(m))
+	for _, g := range m {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.CostUSD != b.CostUSD {
+			return a.CostUSD > b.CostUSD
+		}
+		if a.TotalTokens != b.TotalTokens {
+			return a.TotalTokens > b.TotalTokens
+		}
+		return a.Name < b.Name
+	})
+	return groups
+}
+
+// WriteText prints r as plain-text tables.
+func WriteText(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "Usage %s - %s UTC\n", r.Since.UTC().Format("2006-01-02 15:04"), r.Until.UTC().Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "%d sessions, %s tokens (%s input, %s cached), %d tool calls, estimated cost %s\n",
+		r.Sessions, compact(r.TotalTokens), compact(r.InputTokens), compact(r.CachedTokens), r.ToolCalls, cost(r.Totals))
+	if r.SuggestionTokens > 0 {
+		fmt.Fprintf(w, "Prompt suggestions: %s tokens on top of the above, $%.2f of the estimated cost\n",
+			compact(r.SuggestionTokens), r.SuggestionCostUSD)
+	}
+	if r.Truncated {
+		fmt.Fprintln(w, "Only the first sessions were collected; narrow the period for a complete report.")
+	}
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, "%d sessions were skipped: their results could not be read.\n", r.Skipped)
+	}
+	if r.Sessions == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	groups := func(title string, gs []Group) {
+		fmt.Fprintf(tw, "\n%s\tSESSIONS\tTOKENS\tCACHED\tTOOL CALLS\tCOST\n", title)
+		for _, g := range gs {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%s\n", g.Name, g.Sessions, compact(g.TotalTokens),
+				compact(g.CachedTokens), g.ToolCalls, cost(g.Totals))
+		}
+	}
+	groups("TEAM", r.Teams)
+	groups("MODEL", r.Models)
+	days := make([]Group, len(r.Days))
+	for i, d := range r.Days {
+		days[i] = Group{Name: d.Date, Totals: d.Totals}
+	}
+	groups("DAY", days)
+	if len(r.Tools) > 0 {
+		fmt.Fprintf(tw, "\nTOOL\tCALLS\n")
+		for _, t := range r.Tools {
+			fmt.Fprintf(tw, "%s\t%d\n", t.Name, t.Calls)
+		}
+	}
+	return tw.Flush()
+}
+
+// cost formats a group's estimated cost, noting sessions without a price.
+func cost(t Totals) string {
+	switch {
+	case t.Unpriced == t.Sessions:
+		return "-"
+	case t.Unpriced > 0:
+		return fmt.Sprintf("$%.2f (+%d unpriced)", t.CostUSD, t.Unpriced)
+	}
+	return fmt.Sprintf("$%.2f", t.CostUSD)
+}
+
+// compact formats a token count as 950, 12.3k or 4.56M.
+func compact(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.2fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,111 @@
+package usage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeam(t *testing.T) {
+	assert.Equal(t, "payments", Team([]string{"urgent", "team:payments"}, ""))
+	assert.Equal(t, "infra", Team([]string{"org/infra"}, "org/"))
+	assert.Equal(t, Unassigned, Team([]string{"team:"}, ""))
+	assert.Equal(t, Unassigned, Team(nil, ""))
+}
+
+func TestBuild(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	sessions := []Session{
+		{WorkflowID: "a", ClosedAt: day1, Model: "big", Tags: []string{"team:payments"},
+			TotalTokens: 1_000_000, InputTokens: 1_000_000, CachedTokens: 400_000, ToolCalls: map[string]int{"shell": 3, "read_file": 1}},
+		{WorkflowID: "b", ClosedAt: day2, Model: "small", Tags: []string{"team:search"},
+			TotalTokens: 2_000_000, InputTokens: 1_000_000, ToolCalls: map[string]int{"shell": 2}},
+		{WorkflowID: "c", ClosedAt: day2, Model: "unknown-model", TotalTokens: 100,
+			SuggestionModel: "small", SuggestionTokens: 1_000_000, SuggestionInputTokens: 1_000_000},
+	}
+	r := Build(sessions, Options{Prices: map[string]Price{"big": {Input: 3, Output: 15}, "small": {Input: 0.5, Output: 1}}})
+
+	assert.Equal(t, 3, r.Sessions)
+	assert.Equal(t, 3_000_100, r.TotalTokens)
+	assert.Equal(t, 400_000, r.CachedTokens)
+	assert.Equal(t, 6, r.ToolCalls)
+	assert.InDelta(t, 3+0.5+1+0.5, r.CostUSD, 1e-9)
+	assert.Equal(t, 1, r.Unpriced)
+	assert.Equal(t, 1_000_000, r.SuggestionTokens, "suggestions are counted apart from TotalTokens")
+	assert.InDelta(t, 0.5, r.SuggestionCostUSD, 1e-9)
+
+	require.Len(t, r.Teams, 3)
+	assert.Equal(t, "payments", r.Teams[0].Name, "most expensive first")
+	assert.Equal(t, "search", r.Teams[1].Name)
+	assert.Equal(t, Unassigned, r.Teams[2].Name)
+
+	require.Len(t, r.Days, 2)
+	assert.Equal(t, "2026-03-01", r.Days[0].Date)
+	assert.Equal(t, 2, r.Days[1].Sessions)
+	require.Len(t, r.Days[1].Teams, 2)
+	assert.Equal(t, "search", r.Days[1].Teams[0].Name)
+
+	require.Len(t, r.Models, 3)
+	assert.Equal(t, "big", r.Models[0].Name)
+
+	assert.Equal(t, []ToolCount{{"shell", 5}, {"read_file", 1}}, r.Tools)
+}
+
+func TestBuild_PricesEachModel(t *testing.T) {
+	day := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	prices := map[string]Price{"big": {Input: 10, Output: 10}, "small": {Input: 1, Output: 1}}
+	sessions := []Session{
+		// Routed to big for 1M tokens, then switched to small for the rest.
+		{ClosedAt: day, Model: "small", TotalTokens: 3_000_000, InputTokens: 3_000_000, ToolCalls: map[string]int{"shell": 1},
+			ByModel: []ModelUsage{
+				{Model: "big", TotalTokens: 1_000_000, InputTokens: 1_000_000},
+				{Model: "small", TotalTokens: 1_500_000, InputTokens: 1_500_000},
+			}},
+		// A model without a price leaves the session partly unpriced.
+		{ClosedAt: day, Model: "big", TotalTokens: 2_000_000, InputTokens: 2_000_000,
+			ByModel: []ModelUsage{
+				{Model: "big", TotalTokens: 1_000_000, InputTokens: 1_000_000},
+				{Model: "other", TotalTokens: 1_000_000, InputTokens: 1_000_000},
+			}},
+	}
+	r := Build(sessions, Options{Prices: prices})
+
+	assert.InDelta(t, 10+2+10, r.CostUSD, 1e-9, "the unattributed 0.5M go to the final model")
+	assert.Equal(t, 1, r.Unpriced)
+	require.Len(t, r.Models, 3)
+	assert.Equal(t, Group{Name: "big", Totals: Totals{Sessions: 2, TotalTokens: 2_000_000, InputTokens: 2_000_000, CostUSD: 20}}, r.Models[0])
+	assert.Equal(t, Group{Name: "small", Totals: Totals{Sessions: 1, TotalTokens: 2_000_000, InputTokens: 2_000_000, ToolCalls: 1, CostUSD: 2}}, r.Models[1])
+	assert.Equal(t, Group{Name: "other", Totals: Totals{Sessions: 1, TotalTokens: 1_000_000, InputTokens: 1_000_000, Unpriced: 1}}, r.Models[2])
+}
+
+func TestWriteText(t *testing.T) {
+	r := Build([]Session{
+		{ClosedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), Model: "big", Tags: []string{"team:payments"},
+			TotalTokens: 12_345, InputTokens: 10_000, ToolCalls: map[string]int{"shell": 2}},
+		{ClosedAt: time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC), Model: "other", TotalTokens: 10},
+	}, Options{Prices: map[string]Price{"big": {Input: 100, Output: 100}}})
+
+	var b strings.Builder
+	require.NoError(t, WriteText(&b, r))
+	out := b.String()
+	assert.Contains(t, out, "2 sessions, 12.4k tokens")
+	assert.Contains(t, out, "estimated cost $1.23 (+1 unpriced)")
+	assert.Contains(t, out, "TEAM")
+	assert.Regexp(t, `payments\s+1\s+12\.3k\s+0\s+2\s+\$1\.23`, out)
+	assert.Regexp(t, `other\s+1\s+10\s+0\s+0\s+-`, out)
+	assert.Regexp(t, `shell\s+2`, out)
+	assert.NotContains(t, out, "Prompt suggestions")
+
+	r.SuggestionTokens, r.SuggestionCostUSD = 1_500, 0.004
+	b.Reset()
+	require.NoError(t, WriteText(&b, r))
+	assert.Contains(t, b.String(), "Prompt suggestions: 1.5k tokens on top of the above, $0.00 of the estimated cost")
+
+	b.Reset()
+	require.NoError(t, WriteText(&b, Report{}))
+	assert.NotContains(t, b.String(), "TEAM", "no tables without sessions")
+}
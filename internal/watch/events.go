@@ -0,0 +1,234 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// Event types of the machine-readable event stream (client watch --json).
+const (
+	EventItemAdded         = "item_added"
+	EventPhaseChange       = "phase_change"
+	EventApprovalPending   = "approval_pending"
+	EventEscalationPending = "escalation_pending"
+	EventUserInputPending  = "user_input_pending"
+	EventTurnComplete      = "turn_complete"
+	EventHistoryReset      = "history_reset"
+	EventDegraded          = "degraded"
+	EventReconnected       = "reconnected"
+	EventSessionComplete   = "session_complete"
+	EventError             = "error"
+)
+
+// Event is one line of the event stream. Only the fields of its Type are
+// set; the payloads are the workflow's own JSON types, so scripts can send
+// them back (e.g. approval call IDs) unchanged.
+type Event struct {
+	Type       string `json:"type"`
+	WorkflowID string `json:"workflow_id"`
+	TurnID     string `json:"turn_id,omitempty"`
+
+	Item *models.ConversationItem `json:"item,omitempty"` // item_added
+
+	Phase         workflow.TurnPhase `json:"phase,omitempty"`          // phase_change
+	PreviousPhase workflow.TurnPhase `json:"previous_phase,omitempty"` // phase_change
+
+	Approvals   []workflow.PendingApproval        `json:"approvals,omitempty"`    // approval_pending
+	Escalations []workflow.EscalationRequest      `json:"escalations,omitempty"`  // escalation_pending
+	UserInput   *workflow.PendingUserInputRequest `json:"user_input,omitempty"`   // user_input_pending
+	AskUser     *workflow.PendingAskUserRequest   `json:"ask_user,omitempty"`     // user_input_pending
+	TurnEpoch   int                               `json:"turn_epoch,omitempty"`   // approval_pending, escalation_pending: echo in the response
+	TotalTokens int                               `json:"total_tokens,omitempty"` // turn_complete
+	TurnCount   int                               `json:"turn_count,omitempty"`   // turn_complete
+
+	Standby string `json:"standby,omitempty"` // degraded
+	Error   string `json:"error,omitempty"`   // error
+}
+
+// EventStream turns watch results into events. It remembers the last phase
+// and the pending requests already reported, so a status update that only
+// refreshes token counts does not repeat them.
+type EventStream struct {
+	workflowID string
+	phase      workflow.TurnPhase
+	pending    string // Key of the pending requests last reported
+	degraded   bool
+}
+
+// NewEventStream creates an EventStream for a workflow.
+func NewEventStream(workflowID string) *EventStream {
+	return &EventStream{workflowID: workflowID}
+}
+
+// Events returns the events for one watch result, in order: items first,
+// then the phase change and what the phase waits for.
+func (s *EventStream) Events(result Result) []Event {
+	var events []Event
+	if result.Err != nil {
+		return []Event{s.event(EventError, func(e *Event) { e.Error = result.Err.Error() })}
+	}
+	if result.Reconnected {
+		s.degraded = false
+		return []Event{s.event(EventReconnected, nil)}
+	}
+	if result.Degraded && !s.degraded {
+		s.degraded = true
+		events = append(events, s.event(EventDegraded, func(e *Event) { e.Standby = result.Standby }))
+	}
+	if result.Compacted {
+		events = append(events, s.event(EventHistoryReset, nil))
+	}
+
+	status := result.Status
+	for i := range result.Items {
+		item := result.Items[i]
+		events = append(events, s.event(EventItemAdded, func(e *Event) {
+			e.TurnID = item.TurnID
+			e.Item = &item
+		}))
+		if item.Type == models.ItemTypeTurnComplete {
+			events = append(events, s.event(EventTurnComplete, func(e *Event) {
+				e.TurnID = item.TurnID
+				e.TotalTokens = status.TotalTokens
+				e.TurnCount = status.TurnCount
+			}))
+		}
+	}
+
+	// A degraded read of an unreachable standby has no status.
+	if status.Phase != "" && status.Phase != s.phase {
+		previous := s.phase
+		s.phase = status.Phase
+		events = append(events, s.event(EventPhaseChange, func(e *Event) {
+			e.TurnID = status.CurrentTurnID
+			e.Phase = status.Phase
+			e.PreviousPhase = previous
+		}))
+	}
+	if status.Phase != "" {
+		events = append(events, s.pendingEvents(status)...)
+	}
+
+	if result.Completed {
+		events = append(events, s.event(EventSessionComplete, nil))
+	}
+	return events
+}
+
+// pendingEvents reports the approvals, escalations or questions the
+// session waits on, once per distinct set.
+func (s *EventStream) pendingEvents(status workflow.TurnStatus) []Event {
+	var key string
+	var ev Event
+	switch {
+	case status.Phase == workflow.PhaseApprovalPending && len(status.PendingApprovals) > 0:
+		ids := make([]string, len(status.PendingApprovals))
+		for i, a := range status.PendingApprovals {
+			ids[i] = a.CallID
+		}
+		key = "approval:" + strings.Join(ids, ",")
+		ev = s.event(EventApprovalPending, func(e *Event) { e.Approvals = status.PendingApprovals })
+	case status.Phase == workflow.PhaseEscalationPending && len(status.PendingEscalations) > 0:
+		ids := make([]string, len(status.PendingEscalations))
+		for i, esc := range status.PendingEscalations {
+			ids[i] = esc.CallID
+		}
+		key = "escalation:" + strings.Join(ids, ",")
+		ev = s.event(EventEscalationPending, func(e *Event) { e.Escalations = status.PendingEscalations })
+	case status.PendingUserInputRequest != nil:
+		key = "user_input:" + status.PendingUserInputRequest.CallID
+		ev = s.event(EventUserInputPending, func(e *Event) { e.UserInput = status.PendingUserInputRequest })
+	case status.PendingAskUser != nil:
+		key = "ask_user:" + status.PendingAskUser.CallID
+		ev = s.event(EventUserInputPending, func(e *Event) { e.AskUser = status.PendingAskUser })
+	}
+	if key == s.pending {
+		return nil
+	}
+	s.pending = key
+	if key == "" {
+		return nil
+	}
+	ev.TurnID = status.CurrentTurnID
+	if ev.Type == EventApprovalPending || ev.Type == EventEscalationPending {
+		ev.TurnEpoch = status.TurnEpoch
+	}
+	return []Event{ev}
+}
+
+func (s *EventStream) event(typ string, set func(*Event)) Event {
+	e := Event{Type: typ, WorkflowID: s.workflowID}
+	if set != nil {
+		set(&e)
+	}
+	return e
+}
+
+// WriteEvent writes e as one line of JSON.
+func WriteEvent(w io.Writer, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// FormatEvent renders e as one line of text for a person watching.
+func FormatEvent(e Event) string {
+	switch e.Type {
+	case EventItemAdded:
+		text := e.Item.Content
+		if e.Item.Name != "" {
+			text = strings.TrimSpace(e.Item.Name + " " + e.Item.Arguments)
+		}
+		if text == "" && e.Item.Output != nil {
+			text = e.Item.Output.Content
+		}
+		return fmt.Sprintf("[%s] %s", e.Item.Type, truncateEventText(text))
+	case EventPhaseChange:
+		return fmt.Sprintf("phase: %s", e.Phase)
+	case EventApprovalPending:
+		names := make([]string, len(e.Approvals))
+		for i, a := range e.Approvals {
+			names[i] = a.ToolName
+		}
+		return fmt.Sprintf("approval pending: %s", strings.Join(names, ", "))
+	case EventEscalationPending:
+		names := make([]string, len(e.Escalations))
+		for i, esc := range e.Escalations {
+			names[i] = esc.ToolName
+		}
+		return fmt.Sprintf("escalation pending: %s", strings.Join(names, ", "))
+	case EventUserInputPending:
+		if e.AskUser != nil {
+			return fmt.Sprintf("question pending: %s", e.AskUser.Question)
+		}
+		return "question pending"
+	case EventTurnComplete:
+		return fmt.Sprintf("turn complete: %s (%d tokens total)", e.TurnID, e.TotalTokens)
+	case EventDegraded:
+		return fmt.Sprintf("primary unreachable, reading from %s", e.Standby)
+	case EventError:
+		return "error: " + e.Error
+	default:
+		return strings.ReplaceAll(e.Type, "_", " ")
+	}
+}
+
+// truncateEventText keeps a text line to its first line and 200 characters.
+func truncateEventText(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i] + " …"
+	}
+	if r := []rune(s); len(r) > 200 {
+		s = string(r[:200]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+func eventTypes(events []Event) []string {
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestEventStream_TurnLifecycle(t *testing.T) {
+	s := NewEventStream("wf-1")
+
+	events := s.Events(Result{
+		Items: []models.ConversationItem{
+			{Type: models.ItemTypeTurnStarted, Seq: 0, TurnID: "t1"},
+			{Type: models.ItemTypeUserMessage, Seq: 1, TurnID: "t1", Content: "hi"},
+		},
+		Status: workflow.TurnStatus{Phase: workflow.PhaseLLMCalling, CurrentTurnID: "t1"},
+	})
+	assert.Equal(t, []string{EventItemAdded, EventItemAdded, EventPhaseChange}, eventTypes(events))
+	assert.Equal(t, "hi", events[1].Item.Content)
+	assert.Equal(t, workflow.PhaseLLMCalling, events[2].Phase)
+
+	// A status-only refresh in the same phase reports nothing.
+	assert.Empty(t, s.Events(Result{Status: workflow.TurnStatus{Phase: workflow.PhaseLLMCalling, TotalTokens: 50}}))
+
+	events = s.Events(Result{
+		Items: []models.ConversationItem{
+			{Type: models.ItemTypeAssistantMessage, Seq: 2, TurnID: "t1", Content: "hello"},
+			{Type: models.ItemTypeTurnComplete, Seq: 3, TurnID: "t1"},
+		},
+		Status: workflow.TurnStatus{Phase: workflow.PhaseWaitingForInput, TotalTokens: 120, TurnCount: 1},
+	})
+	assert.Equal(t, []string{EventItemAdded, EventItemAdded, EventTurnComplete, EventPhaseChange}, eventTypes(events))
+	assert.Equal(t, "t1", events[2].TurnID)
+	assert.Equal(t, 120, events[2].TotalTokens)
+	assert.Equal(t, workflow.PhaseLLMCalling, events[3].PreviousPhase)
+}
+
+func TestEventStream_ApprovalPendingOncePerSet(t *testing.T) {
+	s := NewEventStream("wf-1")
+	pending := workflow.TurnStatus{
+		Phase:         workflow.PhaseApprovalPending,
+		CurrentTurnID: "t1",
+		TurnEpoch:     3,
+		PendingApprovals: []workflow.PendingApproval{
+			{CallID: "c1", ToolName: "shell", Arguments: `{"command":"rm -rf build"}`},
+		},
+	}
+
+	events := s.Events(Result{Status: pending})
+	require.Equal(t, []string{EventPhaseChange, EventApprovalPending}, eventTypes(events))
+	assert.Equal(t, pending.PendingApprovals, events[1].Approvals)
+	assert.Equal(t, "t1", events[1].TurnID)
+	assert.Equal(t, 3, events[1].TurnEpoch)
+
+	assert.Empty(t, s.Events(Result{Status: pending}))
+
+	pending.PendingApprovals = append(pending.PendingApprovals, workflow.PendingApproval{CallID: "c2", ToolName: "apply_patch"})
+	assert.Equal(t, []string{EventApprovalPending}, eventTypes(s.Events(Result{Status: pending})))
+
+	// After the approvals are resolved, the same call IDs would be new again.
+	s.Events(Result{Status: workflow.TurnStatus{Phase: workflow.PhaseToolExecuting}})
+	assert.Equal(t, []string{EventPhaseChange, EventApprovalPending}, eventTypes(s.Events(Result{Status: pending})))
+}
+
+func TestEventStream_ConnectionEvents(t *testing.T) {
+	s := NewEventStream("wf-1")
+
+	events := s.Events(Result{Degraded: true, Standby: "standby:7233"})
+	require.Equal(t, []string{EventDegraded}, eventTypes(events))
+	assert.Equal(t, "standby:7233", events[0].Standby)
+	assert.Empty(t, s.Events(Result{Degraded: true, Standby: "standby:7233"}))
+
+	assert.Equal(t, []string{EventReconnected}, eventTypes(s.Events(Result{Reconnected: true})))
+
+	events = s.Events(Result{Err: errors.New("boom")})
+	require.Equal(t, []string{EventError}, eventTypes(events))
+	assert.Equal(t, "boom", events[0].Error)
+
+	assert.Equal(t, []string{EventSessionComplete}, eventTypes(s.Events(Result{Completed: true})))
+}
+
+func TestWriteEvent_OneJSONLinePerEvent(t *testing.T) {
+	var buf bytes.Buffer
+	item := models.ConversationItem{Type: models.ItemTypeAssistantMessage, Seq: 4, Content: "line one\nline two"}
+	require.NoError(t, WriteEvent(&buf, Event{Type: EventItemAdded, WorkflowID: "wf-1", Item: &item}))
+	require.NoError(t, WriteEvent(&buf, Event{Type: EventPhaseChange, WorkflowID: "wf-1", Phase: workflow.PhaseWaitingForInput}))
+
+	lines := bytes.Split(bytes.TrimRight(buf.Bytes(), "\n"), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &decoded))
+	assert.Equal(t, "item_added", decoded["type"])
+	assert.Equal(t, "wf-1", decoded["workflow_id"])
+	assert.Equal(t, "line one\nline two", decoded["item"].(map[string]any)["content"])
+	assert.NotContains(t, decoded, "approvals")
+}
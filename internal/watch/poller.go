@@ -0,0 +1,121 @@
+package watch
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// PollResult holds the results from a single poll cycle.
+type PollResult struct {
+	Items  []models.ConversationItem
+	Status workflow.TurnStatus
+	Err    error
+
+	// Set by PollSince: Compacted means Items is the whole history, and
+	// HistoryEpoch is the Seq numbering the items belong to.
+	Compacted    bool
+	HistoryEpoch int
+}
+
+// Poller queries the workflow for new items and turn status.
+type Poller struct {
+	client     client.Client
+	workflowID string
+	interval   time.Duration
+}
+
+// NewPoller creates a poller for the given workflow.
+func NewPoller(c client.Client, workflowID string, interval time.Duration) *Poller {
+	return &Poller{
+		client:     c,
+		workflowID: workflowID,
+		interval:   interval,
+	}
+}
+
+// QueryTimeout is the per-query timeout for individual workflow queries.
+const QueryTimeout = 5 * time.Second
+
+// Poll performs a single poll cycle: queries items and turn status.
+func (p *Poller) Poll(ctx context.Context) PollResult {
+	var result PollResult
+
+	queryCtx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	// Query conversation items
+	resp, err := p.client.QueryWorkflow(queryCtx, p.workflowID, "", workflow.QueryGetConversationItems)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if err := resp.Get(&result.Items); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Err = p.queryStatus(queryCtx, &result.Status)
+	return result
+}
+
+// PollSince is Poll for a client that already has the items up to
+// sinceSeq: it fetches only the newer ones with get_conversation_items_since.
+// If the history epoch differs from epoch (pass -1 when unknown), history
+// was rewritten since sinceSeq was read, so the whole history is fetched
+// instead and Compacted is set.
+func (p *Poller) PollSince(ctx context.Context, sinceSeq, epoch int) PollResult {
+	var result PollResult
+
+	queryCtx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	since, err := p.queryItemsSince(queryCtx, sinceSeq)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if !since.Compacted && epoch >= 0 && since.HistoryEpoch != epoch {
+		if since, err = p.queryItemsSince(queryCtx, -1); err != nil {
+			result.Err = err
+			return result
+		}
+		since.Compacted = true
+	}
+	result.Items = since.Items
+	result.Compacted = since.Compacted
+	result.HistoryEpoch = since.HistoryEpoch
+
+	result.Err = p.queryStatus(queryCtx, &result.Status)
+	return result
+}
+
+// queryItemsSince runs the get_conversation_items_since query.
+func (p *Poller) queryItemsSince(ctx context.Context, sinceSeq int) (workflow.ConversationItemsSinceResponse, error) {
+	var since workflow.ConversationItemsSinceResponse
+	resp, err := p.client.QueryWorkflow(ctx, p.workflowID, "", workflow.QueryGetConversationItemsSince, sinceSeq)
+	if err != nil {
+		return since, err
+	}
+	err = resp.Get(&since)
+	return since, err
+}
+
+// queryStatus runs the get_turn_status query into status.
+func (p *Poller) queryStatus(ctx context.Context, status *workflow.TurnStatus) error {
+	resp, err := p.client.QueryWorkflow(ctx, p.workflowID, "", workflow.QueryGetTurnStatus)
+	if err != nil {
+		return err
+	}
+	return resp.Get(status)
+}
+
+// NOTE: RunPolling has been removed. The CLI now uses the blocking
+// get_state_update Update via Watcher instead of polling queries.
+// The Poller.Poll() method is retained for one-shot reads (resumeWorkflowCmd,
+// client watch, harness.Session.Events),
+// and PollSince for the Watcher's standby reads.
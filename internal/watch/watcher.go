@@ -0,0 +1,310 @@
+// Package watch follows a session workflow from outside it: one-shot
+// polls of its items and turn status, the blocking get_state_update watch
+// loop, and the event stream built from their results. The TUI, client
+// watch and pkg/harness share it.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// Result holds the result of a single blocking watch call.
+type Result struct {
+	Items     []models.ConversationItem
+	Status    workflow.TurnStatus
+	Compacted bool
+	Completed bool
+	Err       error
+
+	// TimedOut is set when the watch returned because its wait timeout
+	// passed with nothing new. RunWatching does not forward these.
+	TimedOut bool
+
+	// Degraded is set on results read from the standby Temporal endpoint
+	// (named by Standby) while the primary is unreachable. They may lag
+	// the primary, and Status is empty when the standby could not be read.
+	Degraded bool
+	Standby  string
+
+	// Reconnected is sent, without items or status, when the primary is
+	// reachable again after a degraded period.
+	Reconnected bool
+}
+
+// failoverClient is implemented by clients that serve reads from a standby
+// Temporal endpoint while the primary is down (temporalclient.FailoverClient).
+type failoverClient interface {
+	Degraded() bool
+	CheckPrimary(ctx context.Context) bool
+	StandbyName() string
+}
+
+const (
+	// healthProbeInterval is how often a blocking watch health-checks the
+	// primary, so an outage is noticed while the long poll is parked.
+	healthProbeInterval = 10 * time.Second
+
+	// standbyPollInterval is how often the standby is polled while the
+	// primary is down.
+	standbyPollInterval = 2 * time.Second
+
+	// defaultWatchTimeout bounds each blocking watch, so the Update of a
+	// CLI that went away does not stay parked in the workflow.
+	defaultWatchTimeout = 5 * time.Minute
+
+	// defaultStatusInterval coalesces status-only changes (tool progress,
+	// token counts) into at most one watch response per interval.
+	defaultStatusInterval = time.Second
+)
+
+// Watcher uses the blocking get_state_update Update instead of polling queries.
+// Each call to Watch blocks until the workflow has new state to report.
+type Watcher struct {
+	client     client.Client
+	workflowID string
+	// rpcTimeout, if > 0, limits how long each Temporal RPC waits.
+	// When the server is unreachable, calls fail after this duration
+	// instead of retrying gRPC connections forever.
+	rpcTimeout time.Duration
+	// waitTimeout and statusInterval are passed to get_state_update as
+	// TimeoutMs and StatusIntervalMs.
+	waitTimeout    time.Duration
+	statusInterval time.Duration
+}
+
+// NewWatcher creates a Watcher for the given workflow.
+func NewWatcher(c client.Client, workflowID string) *Watcher {
+	return &Watcher{
+		client:         c,
+		workflowID:     workflowID,
+		waitTimeout:    defaultWatchTimeout,
+		statusInterval: defaultStatusInterval,
+	}
+}
+
+// WithRPCTimeout sets a per-call timeout on Temporal RPCs.
+func (w *Watcher) WithRPCTimeout(d time.Duration) *Watcher {
+	w.rpcTimeout = d
+	return w
+}
+
+// Watch performs a single blocking call to the get_state_update Update.
+// It blocks server-side until the workflow has new items or a phase change,
+// a status change has settled, or the wait timeout passes (TimedOut).
+func (w *Watcher) Watch(ctx context.Context, sinceSeq int, sincePhase workflow.TurnPhase) Result {
+	callCtx := ctx
+	if w.rpcTimeout > 0 {
+		// The call legitimately blocks for up to the wait timeout.
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, w.rpcTimeout+w.waitTimeout+w.statusInterval)
+		defer cancel()
+	}
+	req := workflow.StateUpdateRequest{
+		SinceSeq:         sinceSeq,
+		SincePhase:       sincePhase,
+		TimeoutMs:        int(w.waitTimeout / time.Millisecond),
+		StatusIntervalMs: int(w.statusInterval / time.Millisecond),
+	}
+	updateHandle, err := w.client.UpdateWorkflow(callCtx, client.UpdateWorkflowOptions{
+		WorkflowID:   w.workflowID,
+		UpdateName:   workflow.UpdateGetStateUpdate,
+		Args:         []interface{}{req},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return Result{Err: fmt.Errorf("get_state_update call failed: %w", err)}
+	}
+
+	var resp workflow.StateUpdateResponse
+	if err := updateHandle.Get(callCtx, &resp); err != nil {
+		return Result{Err: fmt.Errorf("get_state_update get failed: %w", err)}
+	}
+
+	return Result{
+		Items:     resp.Items,
+		Status:    resp.Status,
+		Compacted: resp.Compacted,
+		Completed: resp.Completed,
+		TimedOut:  resp.TimedOut,
+	}
+}
+
+// maxConsecutiveErrors is the number of consecutive RPC failures before
+// RunWatching gives up. Prevents infinite retry loops when the server is dead.
+const maxConsecutiveErrors = 3
+
+// RunWatching runs a blocking watch loop, sending results to the channel.
+// Tracks sinceSeq/sincePhase across iterations. Stops when context is
+// cancelled or after maxConsecutiveErrors consecutive failures.
+//
+// With a failover client, an unreachable primary is not a failure: the loop
+// polls the standby for read-only results (Degraded) until the primary is
+// back, then sends Reconnected and resumes watching.
+func (w *Watcher) RunWatching(ctx context.Context, ch chan<- Result, initialSeq int, initialPhase workflow.TurnPhase) {
+	sinceSeq := initialSeq
+	sincePhase := initialPhase
+	epoch := -1 // History epoch of sinceSeq; unknown until the first result
+	consecutiveErrors := 0
+	fc, failover := w.client.(failoverClient)
+	degradedSent := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if failover && fc.Degraded() {
+			if !fc.CheckPrimary(ctx) {
+				result := w.pollStandby(ctx, fc, sinceSeq, epoch)
+				changed := result.Err == nil && (len(result.Items) > 0 || result.Compacted || result.Status.Phase != sincePhase)
+				// Always announce degraded mode; after that, only report
+				// what the standby shows changing.
+				if changed || !degradedSent {
+					if result.Err == nil {
+						sinceSeq = nextSinceSeq(sinceSeq, result)
+						sincePhase = result.Status.Phase
+						epoch = result.Status.HistoryEpoch
+					}
+					result.Err = nil
+					select {
+					case ch <- result:
+					case <-ctx.Done():
+						return
+					}
+					degradedSent = true
+				}
+				select {
+				case <-time.After(standbyPollInterval):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+		}
+		if degradedSent {
+			degradedSent = false
+			select {
+			case ch <- Result{Reconnected: true}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		var result Result
+		if failover {
+			result = w.watchProbing(ctx, fc, sinceSeq, sincePhase)
+			if result.Err != nil && fc.Degraded() {
+				// The primary went down mid-watch; switch to the standby.
+				consecutiveErrors = 0
+				continue
+			}
+		} else {
+			result = w.Watch(ctx, sinceSeq, sincePhase)
+		}
+
+		if result.Err != nil {
+			consecutiveErrors++
+			if consecutiveErrors >= maxConsecutiveErrors {
+				result.Err = fmt.Errorf("giving up after %d consecutive failures: %w", consecutiveErrors, result.Err)
+				select {
+				case ch <- result:
+				case <-ctx.Done():
+				}
+				return
+			}
+			// Brief pause before retry to avoid tight error loops
+			select {
+			case <-time.After(500 * time.Millisecond):
+			case <-ctx.Done():
+				return
+			}
+		} else {
+			consecutiveErrors = 0
+		}
+		if result.TimedOut {
+			continue // Nothing new; watch again
+		}
+
+		// Update cursor for next iteration
+		if result.Err == nil {
+			sinceSeq = nextSinceSeq(sinceSeq, result)
+			sincePhase = result.Status.Phase
+			epoch = result.Status.HistoryEpoch
+		}
+
+		select {
+		case ch <- result:
+		case <-ctx.Done():
+			return
+		}
+
+		// If completed, stop watching
+		if result.Completed {
+			return
+		}
+	}
+}
+
+// watchProbing runs Watch while health-checking the primary, and cancels
+// the watch as soon as the primary is found unreachable.
+func (w *Watcher) watchProbing(ctx context.Context, fc failoverClient, sinceSeq int, sincePhase workflow.TurnPhase) Result {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(healthProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-watchCtx.Done():
+				return
+			case <-ticker.C:
+				if !fc.CheckPrimary(watchCtx) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return w.Watch(watchCtx, sinceSeq, sincePhase)
+}
+
+// nextSinceSeq returns the cursor to watch from after result.
+func nextSinceSeq(sinceSeq int, result Result) int {
+	if len(result.Items) > 0 {
+		return result.Items[len(result.Items)-1].Seq
+	}
+	if result.Compacted {
+		return -1
+	}
+	return sinceSeq
+}
+
+// pollStandby reads the items after sinceSeq and the turn status through
+// the failover client, which serves them from the standby while the
+// primary is down. epoch is the history epoch of sinceSeq, or -1.
+func (w *Watcher) pollStandby(ctx context.Context, fc failoverClient, sinceSeq, epoch int) Result {
+	poll := NewPoller(w.client, w.workflowID, 0).PollSince(ctx, sinceSeq, epoch)
+	if poll.Err != nil {
+		return Result{Err: poll.Err, Degraded: true, Standby: fc.StandbyName()}
+	}
+	result := Result{
+		Items:     poll.Items,
+		Status:    poll.Status,
+		Compacted: poll.Compacted,
+		Degraded:  true,
+		Standby:   fc.StandbyName(),
+	}
+	// Report the epoch the items belong to, in case history was rewritten
+	// between the two queries.
+	result.Status.HistoryEpoch = poll.HistoryEpoch
+	return result
+}
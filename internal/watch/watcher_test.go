@@ -0,0 +1,202 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// jsonValue is a converter.EncodedValue holding v.
+type jsonValue struct{ v interface{} }
+
+func (j jsonValue) HasValue() bool { return j.v != nil }
+
+func (j jsonValue) Get(ptr interface{}) error {
+	data, err := json.Marshal(j.v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, ptr)
+}
+
+// standbyClient is a failover client whose primary is down until healthy is
+// set. Queries are served from a fixed standby state; Updates fail.
+type standbyClient struct {
+	client.Client
+	healthy atomic.Bool
+	status  workflow.TurnStatus
+
+	mu    sync.Mutex
+	items []models.ConversationItem
+	epoch int
+}
+
+// rewrite replaces the standby's history under a new epoch.
+func (c *standbyClient) rewrite(items []models.ConversationItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = items
+	c.epoch++
+}
+
+func (c *standbyClient) Degraded() bool                    { return !c.healthy.Load() }
+func (c *standbyClient) CheckPrimary(context.Context) bool { return c.healthy.Load() }
+func (c *standbyClient) StandbyName() string               { return "agents-dr@standby:7233" }
+
+func (c *standbyClient) QueryWorkflow(_ context.Context, _, _, queryType string, args ...interface{}) (converter.EncodedValue, error) {
+	if queryType == workflow.QueryGetConversationItemsSince {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		resp := workflow.ConversationItemsSinceResponse{HistoryEpoch: c.epoch}
+		for _, item := range c.items {
+			if item.Seq > args[0].(int) {
+				resp.Items = append(resp.Items, item)
+			}
+		}
+		return jsonValue{resp}, nil
+	}
+	return jsonValue{c.status}, nil
+}
+
+func (c *standbyClient) UpdateWorkflow(context.Context, client.UpdateWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	return nil, errors.New("primary unavailable")
+}
+
+func TestRunWatching_ReadsStandbyWhilePrimaryDown(t *testing.T) {
+	c := &standbyClient{
+		items: []models.ConversationItem{
+			{Type: models.ItemTypeUserMessage, Seq: 1, Content: "seen"},
+			{Type: models.ItemTypeAssistantMessage, Seq: 2, Content: "new"},
+		},
+		status: workflow.TurnStatus{Phase: workflow.PhaseWaitingForInput, TurnCount: 1},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Result)
+	go NewWatcher(c, "wf-1").RunWatching(ctx, ch, 1, workflow.PhaseLLMCalling)
+
+	result := receive(t, ch)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Degraded)
+	assert.Equal(t, "agents-dr@standby:7233", result.Standby)
+	require.Len(t, result.Items, 1, "only items after sinceSeq")
+	assert.Equal(t, "new", result.Items[0].Content)
+	assert.Equal(t, workflow.PhaseWaitingForInput, result.Status.Phase)
+
+	c.healthy.Store(true)
+	result = receive(t, ch)
+	assert.True(t, result.Reconnected)
+	assert.False(t, result.Degraded)
+}
+
+// TestRunWatching_StandbyReloadsAfterHistoryRewrite verifies that a new
+// history epoch on the standby makes the watcher reload the whole history,
+// since the Seq numbering it holds no longer applies.
+func TestRunWatching_StandbyReloadsAfterHistoryRewrite(t *testing.T) {
+	c := &standbyClient{
+		items: []models.ConversationItem{
+			{Type: models.ItemTypeUserMessage, Seq: 0, Content: "first"},
+			{Type: models.ItemTypeAssistantMessage, Seq: 1, Content: "reply"},
+		},
+		status: workflow.TurnStatus{Phase: workflow.PhaseWaitingForInput},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Result)
+	go NewWatcher(c, "wf-1").RunWatching(ctx, ch, 0, workflow.PhaseLLMCalling)
+
+	result := receive(t, ch)
+	require.NoError(t, result.Err)
+	require.Len(t, result.Items, 1)
+	assert.False(t, result.Compacted)
+
+	// Compaction renumbers history: Seq 1 now names a different item.
+	c.rewrite([]models.ConversationItem{
+		{Type: models.ItemTypeAssistantMessage, Seq: 0, Content: "summary"},
+		{Type: models.ItemTypeUserMessage, Seq: 1, Content: "next"},
+		{Type: models.ItemTypeAssistantMessage, Seq: 2, Content: "answer"},
+	})
+
+	result = receive(t, ch)
+	require.NoError(t, result.Err)
+	assert.True(t, result.Compacted)
+	require.Len(t, result.Items, 3, "whole history after a rewrite")
+	assert.Equal(t, "summary", result.Items[0].Content)
+}
+
+// watchClient answers get_state_update with the queued responses in order
+// and records the requests.
+type watchClient struct {
+	client.Client
+	responses []workflow.StateUpdateResponse
+	requests  []workflow.StateUpdateRequest
+}
+
+func (c *watchClient) UpdateWorkflow(_ context.Context, opts client.UpdateWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	c.requests = append(c.requests, opts.Args[0].(workflow.StateUpdateRequest))
+	if len(c.responses) == 0 {
+		return nil, errors.New("no more responses")
+	}
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	return updateHandle{resp}, nil
+}
+
+type updateHandle struct{ resp workflow.StateUpdateResponse }
+
+func (h updateHandle) WorkflowID() string { return "wf-1" }
+func (h updateHandle) RunID() string      { return "" }
+func (h updateHandle) UpdateID() string   { return "" }
+func (h updateHandle) Get(_ context.Context, ptr interface{}) error {
+	return jsonValue{h.resp}.Get(ptr)
+}
+
+func TestRunWatching_SkipsTimedOutWatches(t *testing.T) {
+	c := &watchClient{responses: []workflow.StateUpdateResponse{
+		{TimedOut: true, Status: workflow.TurnStatus{Phase: workflow.PhaseWaitingForInput}},
+		{
+			Items:  []models.ConversationItem{{Type: models.ItemTypeUserMessage, Seq: 4, Content: "hi"}},
+			Status: workflow.TurnStatus{Phase: workflow.PhaseLLMCalling},
+		},
+		{Completed: true},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Result)
+	go NewWatcher(c, "wf-1").RunWatching(ctx, ch, 3, workflow.PhaseWaitingForInput)
+
+	result := receive(t, ch)
+	require.NoError(t, result.Err)
+	require.Len(t, result.Items, 1, "the timed-out watch is not forwarded")
+	assert.True(t, receive(t, ch).Completed)
+
+	require.Len(t, c.requests, 3)
+	assert.Equal(t, 3, c.requests[1].SinceSeq, "a timed-out watch keeps the cursor")
+	assert.Equal(t, 4, c.requests[2].SinceSeq)
+	assert.Equal(t, workflow.PhaseLLMCalling, c.requests[2].SincePhase)
+	assert.Equal(t, int(defaultWatchTimeout/time.Millisecond), c.requests[0].TimeoutMs)
+	assert.Equal(t, int(defaultStatusInterval/time.Millisecond), c.requests[0].StatusIntervalMs)
+}
+
+func receive(t *testing.T, ch <-chan Result) Result {
+	t.Helper()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no watch result")
+		return Result{}
+	}
+}
@@ -0,0 +1,234 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// access.go enforces a session's access control. AccessInterceptor, a
+// worker interceptor, verifies the caller proof that every client stamps
+// on its Updates and signals (see temporalclient.NewCallerInterceptor)
+// and checks the proven caller against the session's AccessControl before
+// the Update's own validator runs, rejecting callers that are not allowed.
+// The agent_input and agent_shutdown signals are checked the same way and
+// dropped when denied; the worker signs the ones its workflows send to
+// their children with the caller key. Rejections are logged as denied
+// attempts; since validators never run during replay, each denied Update
+// is logged once.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// AccessDeniedErrorType is the application error type of rejected Updates.
+const AccessDeniedErrorType = "AccessDenied"
+
+// accessReadOnlyUpdates only read session state and stay open to anyone
+// who may query the workflow.
+var accessReadOnlyUpdates = map[string]bool{
+	UpdateGetStateUpdate:   true,
+	UpdateListExecSessions: true,
+}
+
+// accessOwnerUpdates are reserved for the session owner.
+var accessOwnerUpdates = map[string]bool{
+	UpdateApprovalResponse:   true,
+	UpdateEscalationResponse: true,
+	UpdateShutdown:           true,
+	SignalAgentShutdown:      true,
+}
+
+// accessSignals are the signals that drive a session. Signals from the
+// session's own activities (LLM queueing, tool progress) are not checked.
+var accessSignals = map[string]bool{
+	SignalAgentInput:    true,
+	SignalAgentShutdown: true,
+}
+
+// accessGateKey is the workflow context key of the *accessGate.
+type accessGateKey struct{}
+
+// accessGate links a workflow run's interceptor to its session: the
+// interceptor records who started the run, and the session installs its
+// access control once its state exists.
+type accessGate struct {
+	starter string // Verified identity of the starting caller
+	acl     func() models.AccessControl
+	held    []func() // Signals received before the access control was bound
+}
+
+// AccessInterceptor enforces session access control on Updates and
+// signals. Register it in the worker's options. callerKey is the secret
+// (TCX_CALLER_KEY) shared with whatever authenticates callers; without it
+// identities cannot be verified and only tokens admit callers.
+type AccessInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	callerKey string
+}
+
+// NewAccessInterceptor creates the worker interceptor.
+func NewAccessInterceptor(callerKey string) *AccessInterceptor {
+	return &AccessInterceptor{callerKey: callerKey}
+}
+
+// InterceptWorkflow wraps each workflow run.
+func (a *AccessInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &accessInbound{
+		WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next},
+		callerKey:                      a.callerKey,
+		gate:                           &accessGate{},
+	}
+}
+
+type accessInbound struct {
+	interceptor.WorkflowInboundInterceptorBase
+	callerKey string
+	gate      *accessGate
+}
+
+func (a *accessInbound) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	return a.Next.Init(&accessOutbound{WorkflowOutboundInterceptorBase: interceptor.WorkflowOutboundInterceptorBase{Next: outbound}, callerKey: a.callerKey})
+}
+
+func (a *accessInbound) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	a.gate.starter = a.verifyCaller(ctx, models.CallerStartProofHeader, models.CallerStartName).Identity
+	ctx = workflow.WithValue(ctx, accessGateKey{}, a.gate)
+	return a.Next.ExecuteWorkflow(ctx, in)
+}
+
+func (a *accessInbound) ValidateUpdate(ctx workflow.Context, in *interceptor.UpdateInput) error {
+	if a.gate.acl != nil && !accessReadOnlyUpdates[in.Name] {
+		caller := a.verifyCaller(ctx, models.CallerProofHeader, in.Name)
+		if err := checkAccess(a.gate.acl(), in.Name, caller); err != nil {
+			workflow.GetLogger(ctx).Warn("Update denied by access control",
+				"update", in.Name, "caller", caller.String(), "reason", err.Error())
+			return err
+		}
+	}
+	return a.Next.ValidateUpdate(ctx, in)
+}
+
+// HandleSignal drops agent_input and agent_shutdown signals from callers
+// the session does not allow. Signals cannot be rejected, so the sender
+// gets no error; the drop is logged on every replay of the signal. Signals
+// that arrive before the session has bound its access control are held
+// until it has.
+func (a *accessInbound) HandleSignal(ctx workflow.Context, in *interceptor.HandleSignalInput) error {
+	if !accessSignals[in.SignalName] {
+		return a.Next.HandleSignal(ctx, in)
+	}
+	caller := a.verifyCaller(ctx, models.CallerProofHeader, in.SignalName)
+	if a.gate.acl == nil {
+		a.gate.held = append(a.gate.held, func() { _ = a.admitSignal(ctx, in, caller) })
+		return nil
+	}
+	return a.admitSignal(ctx, in, caller)
+}
+
+// admitSignal delivers the signal when the caller may send it.
+func (a *accessInbound) admitSignal(ctx workflow.Context, in *interceptor.HandleSignalInput, caller models.VerifiedCaller) error {
+	if err := checkAccess(a.gate.acl(), in.SignalName, caller); err != nil {
+		workflow.GetLogger(ctx).Warn("Signal dropped by access control",
+			"signal", in.SignalName, "caller", caller.String(), "reason", err.Error())
+		return nil
+	}
+	return a.Next.HandleSignal(ctx, in)
+}
+
+// verifyCaller verifies the proof in the current header for name sent to
+// this workflow. The check uses workflow time, so it replays the same way.
+func (a *accessInbound) verifyCaller(ctx workflow.Context, key, name string) models.VerifiedCaller {
+	proof, ok := callerProof(interceptor.WorkflowHeader(ctx), key)
+	if !ok {
+		return models.VerifiedCaller{}
+	}
+	return proof.Verify(a.callerKey, workflow.GetInfo(ctx).WorkflowExecution.ID, name, workflow.Now(ctx))
+}
+
+// accessOutbound signs the agent_input and agent_shutdown signals a
+// workflow sends, so that its children accept them.
+type accessOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+	callerKey string
+}
+
+func (a *accessOutbound) SignalExternalWorkflow(ctx workflow.Context, workflowID, runID, signalName string, arg interface{}) workflow.Future {
+	a.sign(ctx, workflowID, signalName)
+	return a.Next.SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg)
+}
+
+func (a *accessOutbound) SignalChildWorkflow(ctx workflow.Context, workflowID, signalName string, arg interface{}) workflow.Future {
+	a.sign(ctx, workflowID, signalName)
+	return a.Next.SignalChildWorkflow(ctx, workflowID, signalName, arg)
+}
+
+// sign stamps a proof naming this workflow. Signing is deterministic, so
+// replays produce the same header.
+func (a *accessOutbound) sign(ctx workflow.Context, workflowID, signalName string) {
+	header := interceptor.WorkflowHeader(ctx)
+	if header == nil || a.callerKey == "" || !accessSignals[signalName] {
+		return
+	}
+	identity := models.WorkflowCallerPrefix + workflow.GetInfo(ctx).WorkflowExecution.ID
+	proof := models.SignCallerProof(identity, a.callerKey, "", workflowID, signalName, workflow.Now(ctx))
+	if p, err := converter.GetDefaultDataConverter().ToPayload(proof); err == nil {
+		header[models.CallerProofHeader] = p
+	}
+}
+
+// checkAccess returns an AccessDenied error when the caller may not send
+// the named Update or signal. Workflows signed by a harness worker may
+// drive the sessions they started.
+func checkAccess(acl models.AccessControl, name string, caller models.VerifiedCaller) error {
+	if !acl.Enabled() || strings.HasPrefix(caller.Identity, models.WorkflowCallerPrefix) {
+		return nil
+	}
+	if !acl.Allows(caller) {
+		return temporal.NewApplicationError(
+			fmt.Sprintf("access denied: %s may not send %s to this session (prove an allowed identity with TCX_CALLER_KEY, or hold an allowed TCX_SESSION_TOKEN)", caller, name),
+			AccessDeniedErrorType)
+	}
+	if accessOwnerUpdates[name] && !acl.IsOwner(caller) {
+		return temporal.NewApplicationError(
+			fmt.Sprintf("access denied: only the session owner (%s) may send %s", acl.Owner, name),
+			AccessDeniedErrorType)
+	}
+	return nil
+}
+
+// bindAccessControl installs the session's access control in the run's
+// gate, making the verified starting caller the owner when none is
+// configured. Runs without AccessInterceptor have no gate and are not
+// restricted.
+func (s *SessionState) bindAccessControl(ctx workflow.Context) {
+	gate, ok := ctx.Value(accessGateKey{}).(*accessGate)
+	if !ok {
+		return
+	}
+	if acl := &s.Config.AccessControl; acl.Enabled() && acl.Owner == "" && gate.starter != "" &&
+		!strings.HasPrefix(gate.starter, models.WorkflowCallerPrefix) {
+		acl.Owner = gate.starter
+	}
+	gate.acl = func() models.AccessControl { return s.Config.AccessControl }
+	for _, release := range gate.held {
+		release()
+	}
+	gate.held = nil
+}
+
+// callerProof decodes the caller proof stored under key.
+func callerProof(header map[string]*commonpb.Payload, key string) (models.CallerProof, bool) {
+	var proof models.CallerProof
+	p := header[key]
+	if p == nil {
+		return proof, false
+	}
+	return proof, converter.GetDefaultDataConverter().FromPayload(p, &proof) == nil
+}
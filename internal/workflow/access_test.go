@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestCheckAccess(t *testing.T) {
+	token := models.CallerTokenKey("s3cret")
+	acl := models.AccessControl{
+		Owner:      "alice",
+		Identities: []string{"bob"},
+		TokenKeys:  []string{token},
+	}
+
+	tests := []struct {
+		name   string
+		msg    string
+		caller models.VerifiedCaller
+		denied string
+	}{
+		{"owner sends input", UpdateUserInput, models.VerifiedCaller{Identity: "alice"}, ""},
+		{"listed identity sends input", UpdateUserInput, models.VerifiedCaller{Identity: "bob"}, ""},
+		{"token holder sends input", UpdateUserInput, models.VerifiedCaller{TokenKey: token}, ""},
+		{"stranger", UpdateUserInput, models.VerifiedCaller{Identity: "mallory"}, "mallory may not send user_input"},
+		{"unverified caller", UpdateUserInput, models.VerifiedCaller{}, "anonymous caller may not send user_input"},
+		{"owner approves", UpdateApprovalResponse, models.VerifiedCaller{Identity: "alice"}, ""},
+		{"listed identity approves", UpdateApprovalResponse, models.VerifiedCaller{Identity: "bob"}, "only the session owner (alice) may send approval_response"},
+		{"token holder shuts down", UpdateShutdown, models.VerifiedCaller{TokenKey: token}, "only the session owner (alice) may send shutdown"},
+		{"stranger signals input", SignalAgentInput, models.VerifiedCaller{}, "anonymous caller may not send agent_input"},
+		{"listed identity signals shutdown", SignalAgentShutdown, models.VerifiedCaller{Identity: "bob"}, "only the session owner (alice) may send agent_shutdown"},
+		{"parent workflow signals shutdown", SignalAgentShutdown, models.VerifiedCaller{Identity: models.WorkflowCallerPrefix + "parent"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAccess(acl, tt.msg, tt.caller)
+			if tt.denied == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.denied)
+			var appErr *temporal.ApplicationError
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, AccessDeniedErrorType, appErr.Type())
+		})
+	}
+
+	assert.NoError(t, checkAccess(models.AccessControl{}, UpdateShutdown, models.VerifiedCaller{}), "no access control allows everyone")
+}
+
+// defaultTestWorkflowID is the workflow ID of runs in the test environment.
+const defaultTestWorkflowID = "default-test-workflow-id"
+
+// startAccessControlled starts the test workflow as a caller proving
+// identity with the worker's caller key.
+func (s *AgenticWorkflowTestSuite) startAccessControlled(identity string) {
+	const callerKey = "test-caller-key"
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.env.SetStartTime(start)
+	s.env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{NewAccessInterceptor(callerKey)}})
+	proof := models.SignCallerProof(identity, callerKey, "", defaultTestWorkflowID, models.CallerStartName, start)
+	p, err := converter.GetDefaultDataConverter().ToPayload(proof)
+	s.Require().NoError(err)
+	s.env.SetHeader(&commonpb.Header{Fields: map[string]*commonpb.Payload{models.CallerStartProofHeader: p}})
+}
+
+// TestAccessControl_RejectsUnknownCaller verifies that with access control
+// the worker interceptor rejects Updates from callers that are not allowed,
+// while read-only Updates stay open.
+func (s *AgenticWorkflowTestSuite) TestAccessControl_RejectsUnknownCaller() {
+	s.startAccessControlled("alice")
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	// Test environment Updates carry no headers, so they come from an
+	// anonymous caller.
+	var inputErr, shutdownErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-1", s.rejectingCallback(&inputErr), UserInput{Content: "let me in"})
+		s.env.UpdateWorkflow(UpdateShutdown, "shutdown-1", s.rejectingCallback(&shutdownErr), ShutdownRequest{})
+	}, time.Second)
+	var stateErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateGetStateUpdate, "state-1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { stateErr = err },
+			OnComplete: func(interface{}, error) {},
+		}, StateUpdateRequest{})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.CancelWorkflow()
+	}, 3*time.Second)
+
+	input := testInput("Hello")
+	input.Config.AccessControl = models.AccessControl{Identities: []string{"bob"}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Require().Error(inputErr)
+	s.Contains(inputErr.Error(), "anonymous caller may not send user_input")
+	s.Require().Error(shutdownErr)
+	s.NoError(stateErr, "read-only Updates are not restricted")
+}
+
+// TestAccessControl_DropsUnknownAgentSignals verifies that agent_input and
+// agent_shutdown signals from callers the session does not allow never
+// reach the session.
+func (s *AgenticWorkflowTestSuite) TestAccessControl_DropsUnknownAgentSignals() {
+	s.startAccessControlled("alice")
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	// Test environment signals carry no headers, so they come from an
+	// anonymous caller.
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalAgentInput, AgentInputSignal{Content: "injected"})
+		s.env.SignalWorkflow(SignalAgentShutdown, nil)
+	}, time.Second)
+	var items []models.ConversationItem
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetConversationItems)
+		s.Require().NoError(err)
+		s.Require().NoError(result.Get(&items))
+		s.env.CancelWorkflow()
+	}, 2*time.Second)
+
+	input := testInput("Hello")
+	input.Config.AccessControl = models.AccessControl{Identities: []string{"bob"}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Require().NotEmpty(items)
+	for _, item := range items {
+		s.NotEqual("injected", item.Content, "a dropped signal must not start a turn")
+	}
+	var result WorkflowResult
+	if err := s.env.GetWorkflowResult(&result); err == nil {
+		s.NotEqual("shutdown", result.EndReason, "a dropped signal must not shut the session down")
+	}
+}
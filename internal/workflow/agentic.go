@@ -12,8 +12,8 @@ import (
 
 	"github.com/mfateev/temporal-agent-harness/internal/activities"
 	"github.com/mfateev/temporal-agent-harness/internal/history"
-	"github.com/mfateev/temporal-agent-harness/internal/instructions"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
 
@@ -32,6 +32,25 @@ const maxRepeatToolCalls = 3
 //
 // Maps to: codex-rs/core/src/codex.rs run_turn
 func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult, error) {
+	if err := input.Config.RetryPolicies.Validate(); err != nil {
+		return WorkflowResult{}, fmt.Errorf("invalid retry policies: %w", err)
+	}
+	if err := input.Config.Autonomy.Validate(); err != nil {
+		return WorkflowResult{}, fmt.Errorf("invalid autonomy config: %w", err)
+	}
+	if err := input.Config.TurnDeadline.Validate(); err != nil {
+		return WorkflowResult{}, fmt.Errorf("invalid turn deadline: %w", err)
+	}
+	if err := input.Config.ModelRouting.Validate(); err != nil {
+		return WorkflowResult{}, fmt.Errorf("invalid model routing: %w", err)
+	}
+	if err := input.Config.Review.Validate(); err != nil {
+		return WorkflowResult{}, fmt.Errorf("invalid review config: %w", err)
+	}
+	if _, err := sandbox.ParseNetworkAllow(input.Config.Permissions.SandboxNetworkAllow); err != nil {
+		return WorkflowResult{}, fmt.Errorf("invalid sandbox network allowlist: %w", err)
+	}
+
 	state := SessionState{
 		ConversationID: input.ConversationID,
 		History:        history.NewInMemoryHistory(),
@@ -40,6 +59,8 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 		IterationCount: 0,
 		AgentCtl:       NewAgentControl(input.Depth),
 	}
+	state.initTokenCounter()
+	state.History.SetClock(func() time.Time { return workflow.Now(ctx) })
 
 	// Create LoopControl and register handlers early, before init activities.
 	// Handlers capture state/ctrl by pointer and read current values at call
@@ -47,6 +68,11 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 	// prevents races where a query or Update arrives during a slow init
 	// activity (e.g. LoadSkills retry) and finds no handlers registered.
 	ctrl := &LoopControl{}
+	if input.UserMessage == "" {
+		// Started with Update-with-Start: the first message is the
+		// user_input update delivered with the start, held until init is done.
+		ctrl.SetAwaitingStart()
+	}
 	state.registerHandlers(ctx, ctrl)
 
 	// Carry lightweight crew context from input.
@@ -62,7 +88,9 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 			state.ToolSpecs = append(state.ToolSpecs, input.McpToolSpecs...)
 		}
 		state.McpToolLookup = input.McpToolLookup
+		state.dropWritableMcpTools()
 		state.LoadedSkills = input.LoadedSkills
+		state.CustomRoles = input.CustomRoles
 		state.ExecPolicyRules = input.Config.ExecPolicyRules
 	} else {
 		// Direct invocation (E2E tests, standalone, subagent) — do full init.
@@ -88,9 +116,23 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 
 		if input.Depth == 0 {
 			state.loadSkills(ctx)
+			state.loadAgentRoles(ctx)
+		} else {
+			state.CustomRoles = input.CustomRoles
 		}
 	}
 
+	// Record what the serving worker supports, for get_capabilities.
+	if input.Depth == 0 {
+		state.describeWorker(ctx)
+	}
+
+	// Subagents report structured results to their parent via emit_result.
+	if input.Depth > 0 {
+		state.ResultSchema = input.ResultSchema
+		state.ToolSpecs = append(state.ToolSpecs, tools.NewEmitResultToolSpec(input.ResultSchema))
+	}
+
 	// Resolve crew agent config via activity (main and children).
 	if input.CrewName != "" && input.CrewAgent != "" {
 		actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
@@ -135,12 +177,24 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 
 	// Apply crew-aware tool spec scoping.
 	state.applyCrewToolSpecs()
+	state.applyCustomRoleSpecs()
 
 	// Warn if using deprecated on-failure mode (Codex PR #11631)
 	if state.Config.Permissions.ApprovalMode == models.ApprovalOnFailure {
 		workflow.GetLogger(ctx).Warn("`on-failure` approval policy is deprecated and will be removed in a future release. Use `unless-trusted` for interactive approvals or `never` for non-interactive runs.")
 	}
 
+	state.dropDeniedEnvVars(ctx)
+
+	if ctrl.IsAwaitingStart() {
+		envCtx := ""
+		if state.Config.Cwd != "" {
+			envCtx = state.environmentContext(ctx)
+		}
+		ctrl.FinishStart(envCtx)
+		return state.runMultiTurnLoop(ctx, ctrl)
+	}
+
 	// Generate initial turn ID
 	turnID := state.nextTurnID()
 
@@ -154,7 +208,7 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 
 	// Add environment context as the first user message
 	if state.Config.Cwd != "" {
-		envCtx := instructions.BuildEnvironmentContext(state.Config.Cwd, "")
+		envCtx := state.environmentContext(ctx)
 		if err := state.History.AddItem(models.ConversationItem{
 			Type:    models.ItemTypeUserMessage,
 			Content: envCtx,
@@ -179,15 +233,31 @@ func AgenticWorkflow(ctx workflow.Context, input WorkflowInput) (WorkflowResult,
 }
 
 // AgenticWorkflowContinued handles ContinueAsNew.
+//
+// State written by an older release was migrated to the current schema while
+// it was decoded (see SessionState.UnmarshalJSON).
 func AgenticWorkflowContinued(ctx workflow.Context, state SessionState) (WorkflowResult, error) {
+	if state.migratedFrom != nil {
+		workflow.GetLogger(ctx).Info("Migrated session state",
+			"from_schema", *state.migratedFrom, "to_schema", models.SchemaVersion)
+		state.migratedFrom = nil
+	}
+	state.SchemaVersion = models.SchemaVersion
+
 	// Restore History interface from serialized HistoryItems
 	state.initHistory()
+	state.initTokenCounter()
+	state.History.SetClock(func() time.Time { return workflow.Now(ctx) })
 
 	// Construct a fresh LoopControl — coordination state is not serialized.
 	ctrl := &LoopControl{}
 
 	// Re-register handlers after ContinueAsNew
 	state.registerHandlers(ctx, ctrl)
+	ctrl.SetPaused(state.Paused != nil)
+
+	// An autonomous run carries on in a new turn.
+	state.resumeAutonomousRun(ctrl)
 	return state.runMultiTurnLoop(ctx, ctrl)
 }
 
@@ -237,6 +307,7 @@ func (s *SessionState) runMultiTurnLoop(ctx workflow.Context, ctrl *LoopControl)
 			if s.Config.MemoryEnabled && s.AgentCtl != nil && s.AgentCtl.ParentDepth == 0 {
 				s.extractMemoryOnShutdown(ctx)
 			}
+			s.archiveTranscript(ctx, archiveReasonShutdown)
 
 			items, _ := s.History.GetRawItems()
 			return WorkflowResult{
@@ -244,9 +315,17 @@ func (s *SessionState) runMultiTurnLoop(ctx workflow.Context, ctrl *LoopControl)
 				TotalIterations:   s.IterationCount,
 				TotalTokens:       s.TotalTokens,
 				TotalCachedTokens: s.TotalCachedTokens,
+				TotalInputTokens:  s.TotalInputTokens,
 				ToolCallsExecuted: s.ToolCallsExecuted,
+				Model:             s.Config.Model.Model,
+				Models:            s.ModelsUsed,
+				UsageByModel:      s.UsageByModel,
+				Tags:              s.Tags,
 				EndReason:         "shutdown",
 				FinalMessage:      extractFinalMessage(items),
+				StructuredResult:  s.StructuredResult,
+				SandboxAudit:      s.sandboxAuditReport(),
+				SuggestionUsage:   s.suggestionUsage(),
 			}, nil
 		}
 
@@ -255,10 +334,16 @@ func (s *SessionState) runMultiTurnLoop(ctx workflow.Context, ctrl *LoopControl)
 		s.IterationCount = 0
 
 		// Run the agentic turn
+		s.beginAutonomousTurn(ctx, ctrl)
+		s.armTurnDeadline(ctx, ctrl)
+		s.beginTurnTiming(ctx, ctrl.CurrentTurnID())
+		s.injectWorkspaceChanges(ctx, ctrl)
 		done, err := s.runAgenticTurn(ctx, ctrl)
 		if err != nil {
 			return WorkflowResult{}, err
 		}
+		s.endTurnDeadline(ctx, ctrl)
+		s.finishTurnTiming(ctx)
 
 		if done {
 			// ContinueAsNew was triggered
@@ -281,14 +366,22 @@ func (s *SessionState) runMultiTurnLoop(ctx workflow.Context, ctrl *LoopControl)
 			}
 		}
 
+		// Autonomous runs check in before the turn is marked complete.
+		continueRun := s.endAutonomousTurn(ctx, ctrl)
+
 		// Turn complete — add TurnComplete marker (unless interrupted, which already added it)
 		if !ctrl.IsInterrupted() {
+			s.runTurnCompleteHooks(ctx, ctrl)
 			_ = s.History.AddItem(models.ConversationItem{
 				Type:   models.ItemTypeTurnComplete,
 				TurnID: ctrl.CurrentTurnID(),
+				Verify: s.turnVerify,
+				Review: s.turnReview,
 			})
 			ctrl.NotifyItemAdded()
 		}
+		s.archiveTranscript(ctx, archiveReasonTurnComplete)
+		s.maybeTitleSession(ctx)
 
 		// Workflows without request_user_input auto-complete after a turn.
 		// This is the one-shot pattern: the caller sends a task, the workflow
@@ -300,26 +393,40 @@ func (s *SessionState) runMultiTurnLoop(ctx workflow.Context, ctrl *LoopControl)
 			if s.Config.MemoryEnabled && s.AgentCtl != nil && s.AgentCtl.ParentDepth == 0 {
 				s.extractMemoryOnShutdown(ctx)
 			}
+			s.ensureStructuredResult(ctx)
 			items, _ := s.History.GetRawItems()
 			return WorkflowResult{
 				ConversationID:    s.ConversationID,
 				TotalIterations:   s.IterationCount,
 				TotalTokens:       s.TotalTokens,
 				TotalCachedTokens: s.TotalCachedTokens,
+				TotalInputTokens:  s.TotalInputTokens,
 				ToolCallsExecuted: s.ToolCallsExecuted,
+				Model:             s.Config.Model.Model,
+				Models:            s.ModelsUsed,
+				UsageByModel:      s.UsageByModel,
+				Tags:              s.Tags,
 				EndReason:         "completed",
 				FinalMessage:      extractFinalMessage(items),
+				StructuredResult:  s.StructuredResult,
+				SandboxAudit:      s.sandboxAuditReport(),
+				SuggestionUsage:   s.suggestionUsage(),
 			}, nil
 		}
 
+		if continueRun {
+			s.continueAutonomousRun(ctrl)
+			continue
+		}
+
 		ctrl.SetPhase(PhaseWaitingForInput)
 		ctrl.ClearToolsInFlight()
 
-		// Generate prompt suggestion asynchronously (best-effort).
+		// Generate prompt suggestion in the background (best-effort).
 		// The CLI has already detected TurnComplete via polling and can show
 		// the input prompt immediately; the suggestion arrives ~300-500ms later.
 		if !ctrl.IsInterrupted() && !s.Config.DisableSuggestions {
-			s.generateSuggestion(ctx, ctrl)
+			s.startSuggestion(ctx, ctrl)
 		}
 
 		logger.Info("Turn complete, waiting for next input", "turn_id", ctrl.CurrentTurnID())
@@ -341,6 +448,7 @@ func awaitWithIdleTimeout(ctx workflow.Context, condition func() bool) (bool, er
 func (s *SessionState) continueAsNew(ctx workflow.Context, ctrl *LoopControl) (WorkflowResult, error) {
 	// Mark as draining so blocked get_state_update handlers wake up and return.
 	ctrl.SetDraining()
+	ctrl.CancelSuggestion()
 
 	// Wait for all update handlers to finish before ContinueAsNew
 	_ = workflow.Await(ctx, func() bool {
@@ -348,5 +456,6 @@ func (s *SessionState) continueAsNew(ctx workflow.Context, ctrl *LoopControl) (W
 	})
 
 	s.syncHistoryItems()
+	s.SchemaVersion = models.SchemaVersion
 	return WorkflowResult{}, workflow.NewContinueAsNewError(ctx, "AgenticWorkflowContinued", *s)
 }
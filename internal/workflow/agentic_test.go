@@ -50,32 +50,163 @@ func GenerateSuggestions(_ context.Context, _ activities.SuggestionInput) (activ
 	panic("stub: should be mocked")
 }
 
+func GenerateSessionTitle(_ context.Context, _ activities.SessionTitleInput) (activities.SessionTitleOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func DescribeApprovals(_ context.Context, _ activities.DescribeApprovalsInput) (activities.DescribeApprovalsOutput, error) {
+	panic("stub: should be mocked")
+}
+
 func LoadSkills(_ context.Context, _ activities.LoadSkillsInput) (activities.LoadSkillsOutput, error) {
 	panic("stub: should be mocked")
 }
 
+func ReadSkillContent(_ context.Context, _ activities.ReadSkillContentInput) (activities.ReadSkillContentOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func SnapshotWorkspace(_ context.Context, _ activities.SnapshotWorkspaceInput) (activities.SnapshotWorkspaceOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func RestoreWorkspace(_ context.Context, _ activities.RestoreWorkspaceInput) (activities.RestoreWorkspaceOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func SummarizeSession(_ context.Context, _ activities.SummarizeSessionInput) (activities.SummarizeSessionOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func ReadSessionTranscript(_ context.Context, _ activities.ReadSessionTranscriptInput) (activities.ReadSessionTranscriptOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func ArchiveTranscript(_ context.Context, _ activities.ArchiveTranscriptInput) (activities.ArchiveTranscriptOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func NotifyApprovalWebhook(_ context.Context, _ activities.NotifyApprovalWebhookInput) error {
+	panic("stub: should be mocked")
+}
+
+func CountTokens(_ context.Context, _ activities.CountTokensInput) (activities.CountTokensOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func IndexCode(_ context.Context, _ activities.IndexCodeInput) (activities.IndexCodeOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func CollectWorkspaceChanges(_ context.Context, _ activities.CollectWorkspaceChangesInput) (activities.CollectWorkspaceChangesOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func RunHooks(_ context.Context, _ activities.RunHooksInput) (activities.RunHooksOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func DescribeWorker(_ context.Context, _ activities.DescribeWorkerInput) (activities.WorkerCapabilities, error) {
+	panic("stub: should be mocked")
+}
+
+func DiagnoseNetwork(_ context.Context, _ activities.DiagnoseNetworkInput) (activities.DiagnoseNetworkOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func WriteExecStdin(_ context.Context, _ activities.WriteExecStdinRequest) (activities.WriteExecStdinResponse, error) {
+	panic("stub: should be mocked")
+}
+
+func CheckSearchAttributes(_ context.Context, _ activities.CheckSearchAttributesInput) (activities.CheckSearchAttributesOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func LoadWorkerInstructions(_ context.Context, _ activities.LoadWorkerInstructionsInput) (activities.LoadWorkerInstructionsOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func RegisterArtifact(_ context.Context, _ activities.RegisterArtifactInput) (activities.RegisterArtifactOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func CollectSessionUsage(_ context.Context, _ activities.CollectSessionUsageInput) (activities.CollectSessionUsageOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func PublishUsageReport(_ context.Context, _ activities.PublishUsageReportInput) (activities.PublishUsageReportOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func SummarizeProjectDocs(_ context.Context, _ activities.SummarizeProjectDocsInput) (activities.SummarizeProjectDocsOutput, error) {
+	panic("stub: should be mocked")
+}
+
+func SummarizeContextItems(_ context.Context, _ activities.SummarizeContextItemsInput) (activities.SummarizeContextItemsOutput, error) {
+	panic("stub: should be mocked")
+}
+
 func (s *AgenticWorkflowTestSuite) SetupTest() {
+	s.newEnv()
+
+	// Default mock for ExecuteCompact — returns failure to trigger fallback.
+	// Tests that need compaction to succeed should call newEnv and mock it.
+	s.env.OnActivity("ExecuteCompact", mock.Anything, mock.Anything).
+		Return(activities.CompactActivityOutput{}, fmt.Errorf("compaction not configured")).Maybe()
+
+	// Default mock for DescribeApprovals — no context for pending approvals.
+	s.env.OnActivity("DescribeApprovals", mock.Anything, mock.Anything).
+		Return(activities.DescribeApprovalsOutput{}, nil).Maybe()
+
+	// Note: no default mock for GenerateSuggestions — testInput() sets
+	// DisableSuggestions=true, so it won't be called. Tests that enable
+	// suggestions must register their own mock. The same goes for
+	// GenerateSessionTitle and DisableTitleGeneration.
+}
+
+// newEnv replaces s.env with a fresh environment that has the activities
+// registered but ExecuteCompact not mocked. The first matching mock wins, so
+// a test that needs a different ExecuteCompact result starts over with this.
+func (s *AgenticWorkflowTestSuite) newEnv() {
 	s.env = s.NewTestWorkflowEnvironment()
+	// A fixed start keeps the clock in environment contexts deterministic.
+	s.env.SetStartTime(testStartTime)
 	s.env.RegisterActivity(ExecuteLLMCall)
 	s.env.RegisterActivity(ExecuteTool)
 	s.env.RegisterActivity(ExecuteCompact)
 	s.env.RegisterActivity(GenerateSuggestions)
+	s.env.RegisterActivity(GenerateSessionTitle)
 	s.env.RegisterActivity(LoadSkills)
-
-	// Default mock for ExecuteCompact — returns failure to trigger fallback.
-	// Tests that need compaction to succeed should override this.
-	s.env.OnActivity("ExecuteCompact", mock.Anything, mock.Anything).
-		Return(activities.CompactActivityOutput{}, fmt.Errorf("compaction not configured")).Maybe()
+	s.env.RegisterActivity(ReadSkillContent)
+	s.env.RegisterActivity(SnapshotWorkspace)
+	s.env.RegisterActivity(RestoreWorkspace)
+	s.env.RegisterActivity(SummarizeSession)
+	s.env.RegisterActivity(ReadSessionTranscript)
+	s.env.RegisterActivity(ArchiveTranscript)
+	s.env.RegisterActivity(NotifyApprovalWebhook)
+	s.env.RegisterActivity(DescribeApprovals)
+	s.env.RegisterActivity(IndexCode)
+	s.env.RegisterActivity(CountTokens)
+	s.env.RegisterActivity(CollectWorkspaceChanges)
+	s.env.RegisterActivity(RunHooks)
+	s.env.RegisterActivity(DescribeWorker)
+	s.env.RegisterActivity(DiagnoseNetwork)
+	s.env.RegisterActivity(WriteExecStdin)
+	s.env.RegisterActivity(CheckSearchAttributes)
+	s.env.RegisterActivity(RegisterArtifact)
+	s.env.RegisterActivity(CollectSessionUsage)
+	s.env.RegisterActivity(PublishUsageReport)
+	s.env.RegisterActivity(SummarizeContextItems)
 
 	// Default mock for LoadSkills — returns empty list immediately.
 	// Without this, the unregistered activity triggers a retry timer that
 	// can race with test delayed callbacks at the same timestamp.
 	s.env.OnActivity("LoadSkills", mock.Anything, mock.Anything).
 		Return(activities.LoadSkillsOutput{}, nil).Maybe()
-
-	// Note: no default mock for GenerateSuggestions — testInput() sets
-	// DisableSuggestions=true, so it won't be called. Tests that enable
-	// suggestions must register their own mock.
+	// Default mock for DescribeWorker, run at session start: a worker with
+	// no tools.
+	s.env.OnActivity("DescribeWorker", mock.Anything, mock.Anything).
+		Return(activities.WorkerCapabilities{Version: "test"}, nil).Maybe()
 }
 
 func (s *AgenticWorkflowTestSuite) AfterTest(suiteName, testName string) {
@@ -96,6 +227,7 @@ func mockLLMStopResponse(content string, tokens int) activities.LLMActivityOutpu
 // testInput returns a standard WorkflowInput for testing.
 // Suggestions are disabled by default to avoid needing GenerateSuggestions mocks
 // in every test. Tests that exercise suggestions should set DisableSuggestions=false.
+// Workspace snapshots and title generation are disabled for the same reason.
 func testInput(message string) WorkflowInput {
 	return WorkflowInput{
 		ConversationID: "test-conv-1",
@@ -114,7 +246,9 @@ func testInput(message string) WorkflowInput {
 			Tools: models.ToolsConfig{
 				EnabledTools: []string{"request_user_input"},
 			},
-			DisableSuggestions: true,
+			DisableSuggestions:        true,
+			DisableWorkspaceSnapshots: true,
+			DisableTitleGeneration:    true,
 		},
 	}
 }
@@ -250,6 +384,37 @@ func (s *AgenticWorkflowTestSuite) TestMultiTurn_Interrupt() {
 	assert.Equal(s.T(), "shutdown", result.EndReason)
 }
 
+// TestMultiTurn_InterruptCancelsLLMCall verifies an interrupt during a slow
+// LLM call cancels the activity instead of waiting for the response.
+func (s *AgenticWorkflowTestSuite) TestMultiTurn_InterruptCancelsLLMCall() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		After(time.Hour).
+		Return(mockLLMStopResponse("should never be recorded", 35), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateInterrupt, "interrupt-1", noopCallback(), InterruptRequest{})
+	}, time.Second*2)
+
+	// The turn ends as soon as the interrupt lands, not after the hour-long call.
+	var timings []TurnTiming
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnTimings)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), result.Get(&timings))
+	}, time.Second*4)
+
+	s.sendShutdown(time.Second * 5)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Write a huge patch"))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.Len(s.T(), timings, 1)
+	assert.Equal(s.T(), 2*time.Second, timings[0].LLM)
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), "shutdown", result.EndReason)
+}
+
 // TestMultiTurn_Shutdown verifies workflow completes cleanly with shutdown.
 func (s *AgenticWorkflowTestSuite) TestMultiTurn_Shutdown() {
 	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
@@ -279,6 +444,41 @@ func (s *AgenticWorkflowTestSuite) TestMultiTurn_Shutdown() {
 	assert.Equal(s.T(), 20, result.TotalTokens)
 }
 
+// TestStartWithoutMessage_FirstInputStartsTurn verifies a session started
+// without a message (Update-with-Start) takes its first turn from the
+// user_input update, led by the environment context.
+func (s *AgenticWorkflowTestSuite) TestStartWithoutMessage_FirstInputStartsTurn() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hi", 10), nil).Once()
+
+	var resp StateUpdateResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("first input rejected", err) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(StateUpdateResponse)
+			},
+		}, UserInput{Content: "Hello"})
+	}, 0)
+	s.sendShutdown(time.Second * 2)
+
+	input := testInput("")
+	input.Config.Cwd = "/work/repo"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), resp.Items, 3, "one turn: no empty turn from the start")
+	assert.Equal(s.T(), models.ItemTypeTurnStarted, resp.Items[0].Type)
+	assert.Contains(s.T(), resp.Items[1].Content, "/work/repo")
+	assert.Equal(s.T(), "Hello", resp.Items[2].Content)
+	assert.Equal(s.T(), resp.TurnID, resp.Items[2].TurnID)
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), 10, result.TotalTokens)
+}
+
 // TestMultiTurn_ValidatorRejectsEmptyInput verifies empty content is rejected.
 func (s *AgenticWorkflowTestSuite) TestMultiTurn_ValidatorRejectsEmptyInput() {
 	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
@@ -364,6 +564,78 @@ func (s *AgenticWorkflowTestSuite) TestMultiTurn_QueryTurnStatus() {
 	require.True(s.T(), s.env.IsWorkflowCompleted())
 }
 
+// TestMultiTurn_LLMQueuedPhase verifies that llm_queued signals from the LLM
+// activity surface as PhaseLLMQueued with a queue position in TurnStatus.
+func (s *AgenticWorkflowTestSuite) TestMultiTurn_LLMQueuedPhase() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		After(5*time.Second).
+		Return(mockLLMStopResponse("Hello!", 50), nil).Once()
+
+	queryStatus := func() TurnStatus {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		return status
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalLLMQueued, activities.LLMQueuedSignal{Position: 4})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		status := queryStatus()
+		assert.Equal(s.T(), PhaseLLMQueued, status.Phase)
+		assert.Equal(s.T(), 3, status.QueuedBehind)
+		s.env.SignalWorkflow(SignalLLMQueued, activities.LLMQueuedSignal{Position: 0})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		status := queryStatus()
+		assert.Equal(s.T(), PhaseLLMCalling, status.Phase)
+		assert.Equal(s.T(), 0, status.QueuedBehind)
+	}, 3*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		// A stale signal after the call finished must not change the phase.
+		s.env.SignalWorkflow(SignalLLMQueued, activities.LLMQueuedSignal{Position: 2})
+	}, 7*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		assert.Equal(s.T(), PhaseWaitingForInput, queryStatus().Phase)
+	}, 8*time.Second)
+
+	s.sendShutdown(10 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestMultiTurn_RateLimitedPhase verifies that a rate-limit error carrying
+// a retry-after hint is retried after exactly that long, with the wait shown
+// as PhaseRateLimited in TurnStatus.
+func (s *AgenticWorkflowTestSuite) TestMultiTurn_RateLimitedPhase() {
+	start := s.env.Now()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{}, temporal.NewNonRetryableApplicationError(
+			"rate limit (429)", models.LLMErrTypeAPILimit, nil, models.LLMRateLimitDetails{RetryAfterMs: 23000})).Once()
+	var retriedAt time.Time
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { retriedAt = s.env.Now() }).
+		Return(mockLLMStopResponse("Hello!", 50), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		assert.Equal(s.T(), PhaseRateLimited, status.Phase)
+		assert.WithinDuration(s.T(), start.Add(23*time.Second), status.ResumesAt, time.Second)
+	}, 10*time.Second)
+
+	s.sendShutdown(time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	assert.WithinDuration(s.T(), start.Add(23*time.Second), retriedAt, time.Second)
+}
+
 // TestMultiTurn_TurnBoundaries verifies TurnStarted/TurnComplete markers
 // appear in history.
 func (s *AgenticWorkflowTestSuite) TestMultiTurn_TurnBoundaries() {
@@ -667,6 +939,38 @@ func TestLoopControl_LifecycleFlags(t *testing.T) {
 	assert.Equal(t, []string{"shell_command", "read_file"}, state.ToolCallsExecuted)
 }
 
+// TestLoopControl_ToolProgress verifies progress reports attach to the
+// matching in-flight call and late reports are dropped.
+func TestLoopControl_ToolProgress(t *testing.T) {
+	ctrl := &LoopControl{}
+	ctrl.SetToolsInFlight([]ToolInFlight{
+		{Name: "shell_command", CallID: "call-1"},
+		{Name: "read_file", CallID: "call-2"},
+	})
+	v := ctrl.StateVersion()
+
+	ctrl.SetToolProgress(tools.ToolProgress{CallID: "call-1", Command: "make", Current: 2, Total: 5})
+	inFlight := ctrl.ToolsInFlight()
+	require.NotNil(t, inFlight[0].Progress)
+	assert.Equal(t, "make", inFlight[0].Progress.Command)
+	assert.Equal(t, 2, inFlight[0].Progress.Current)
+	assert.Nil(t, inFlight[1].Progress)
+	assert.Greater(t, ctrl.StateVersion(), v)
+	assert.Equal(t, []string{"shell_command", "read_file"}, ctrl.ToolNamesInFlight())
+
+	// A repeated report does not wake watchers.
+	v = ctrl.StateVersion()
+	ctrl.SetToolProgress(tools.ToolProgress{CallID: "call-1", Command: "make", Current: 2, Total: 5})
+	assert.Equal(t, v, ctrl.StateVersion())
+
+	// A report for a call that already finished is ignored.
+	ctrl.ClearToolsInFlight()
+	v = ctrl.StateVersion()
+	ctrl.SetToolProgress(tools.ToolProgress{CallID: "call-1"})
+	assert.Empty(t, ctrl.ToolsInFlight())
+	assert.Equal(t, v, ctrl.StateVersion())
+}
+
 // TestMultiTurn_ContextOverflow_CompactsAndRetries verifies that a ContextOverflow
 // error triggers compaction (falling back to destructive drop) and then retries
 // the LLM call instead of triggering ContinueAsNew.
@@ -1233,6 +1537,28 @@ func TestClassifyToolsForApproval_ForbiddenByPolicy(t *testing.T) {
 	assert.Contains(t, forbidden[0].Output.Content, "Forbidden")
 }
 
+func TestApprovalGate_FetchURLFollowsNetworkPolicy(t *testing.T) {
+	calls := []models.ConversationItem{
+		{Type: models.ItemTypeFunctionCall, CallID: "1", Name: "fetch_url", Arguments: `{"url": "https://go.dev/doc/"}`},
+		{Type: models.ItemTypeFunctionCall, CallID: "2", Name: "fetch_url", Arguments: `{"url": "http://169.254.169.254/latest/meta-data/"}`},
+	}
+	perms := models.Permissions{
+		ApprovalMode:        models.ApprovalUnlessTrusted,
+		SandboxMode:         "workspace-write",
+		SandboxNetworkAllow: []string{"*.dev", "go.dev"},
+	}
+	pending, _ := NewApprovalGate(perms.ApprovalMode, "").WithNetworkPolicy(perms).Classify(calls)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "2", pending[0].CallID)
+
+	perms.SandboxNetworkAccess = true
+	pending, _ = NewApprovalGate(perms.ApprovalMode, "").WithNetworkPolicy(perms).Classify(calls)
+	assert.Empty(t, pending)
+
+	pending, _ = NewApprovalGate(perms.ApprovalMode, "").Classify(calls)
+	assert.Len(t, pending, 2)
+}
+
 func TestEvaluateToolApproval(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1250,6 +1576,31 @@ func TestEvaluateToolApproval(t *testing.T) {
 		{"write_file is mutating", "write_file", `{"file_path": "/tmp/test"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
 		{"apply_patch is mutating", "apply_patch", `{"file_path": "/tmp/test"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
 
+		// GitHub tools: reads are safe, writes need approval
+		{"gh_get_issue is safe", "gh_get_issue", `{"number": 12}`, models.ApprovalUnlessTrusted, tools.ApprovalSkip},
+		{"gh_create_pr is mutating", "gh_create_pr", `{"title": "Fix", "head": "fix"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
+		{"gh_comment is mutating", "gh_comment", `{"number": 12, "body": "hi"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
+		{"gh_comment in never mode", "gh_comment", `{"number": 12, "body": "hi"}`, models.ApprovalNever, tools.ApprovalSkip},
+
+		// fetch_url reaches the network from the worker
+		{"fetch_url needs approval", "fetch_url", `{"url": "https://go.dev/doc/"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
+		{"fetch_url in never mode", "fetch_url", `{"url": "https://go.dev/doc/"}`, models.ApprovalNever, tools.ApprovalSkip},
+
+		// semantic_search only reads the index
+		{"semantic_search is read-only", "semantic_search", `{"query": "auth"}`, models.ApprovalUnlessTrusted, tools.ApprovalSkip},
+
+		// Browser tools: only clicks act on the page
+		{"browser_navigate is safe", "browser_navigate", `{"url": "http://localhost:3000"}`, models.ApprovalUnlessTrusted, tools.ApprovalSkip},
+		{"browser_screenshot is safe", "browser_screenshot", `{}`, models.ApprovalUnlessTrusted, tools.ApprovalSkip},
+		{"browser_click needs approval", "browser_click", `{"selector": "#submit"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
+		{"browser_click in never mode", "browser_click", `{"selector": "#submit"}`, models.ApprovalNever, tools.ApprovalSkip},
+
+		// python_exec runs arbitrary code
+		{"python_exec needs approval", "python_exec", `{"code": "print(1)"}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
+		{"python_exec in never mode", "python_exec", `{"code": "print(1)"}`, models.ApprovalNever, tools.ApprovalSkip},
+		// quality_gate runs the project's configured commands
+		{"quality_gate needs approval", "quality_gate", `{"checks": ["test"]}`, models.ApprovalUnlessTrusted, tools.ApprovalNeeded},
+
 		// shell_command (string-based) — backward compat with old "shell" string command tests
 		{"shell_command ls is safe", "shell_command", `{"command": "ls -la"}`, models.ApprovalUnlessTrusted, tools.ApprovalSkip},
 		{"shell_command cat is safe", "shell_command", `{"command": "cat /tmp/test"}`, models.ApprovalUnlessTrusted, tools.ApprovalSkip},
@@ -3613,6 +3964,122 @@ func (s *AgenticWorkflowTestSuite) TestUpdateModel_ExplicitContextWindow() {
 	assert.True(s.T(), state.modelSwitched)
 }
 
+// TestUpdateModelConfig_AppliesToNextTurn verifies that update_model_config
+// changes parameters for subsequent LLM calls and rejects values the model
+// does not accept.
+func (s *AgenticWorkflowTestSuite) TestUpdateModelConfig_AppliesToNextTurn() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	var second activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			second = args.Get(1).(activities.LLMActivityInput)
+		}).
+		Return(mockLLMStopResponse("Cooler now.", 10), nil).Once()
+
+	temp, maxTokens := 0.2, 1000
+	var resp UpdateModelConfigResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateModelConfig, "config-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("config rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(UpdateModelConfigResponse)
+			},
+		}, UpdateModelConfigRequest{Params: models.ModelParams{Temperature: &temp, MaxTokens: &maxTokens}})
+	}, 2*time.Second)
+
+	var rejections []string
+	s.env.RegisterDelayedCallback(func() {
+		tooHot := 3.0
+		effort := models.ReasoningEffortHigh
+		for i, params := range []models.ModelParams{{Temperature: &tooHot}, {ReasoningEffort: &effort}} {
+			s.env.UpdateWorkflow(UpdateModelConfig, fmt.Sprintf("config-bad-%d", i), &testsuite.TestUpdateCallback{
+				OnAccept:   func() { s.Fail("invalid params should be rejected") },
+				OnReject:   func(err error) { rejections = append(rejections, err.Error()) },
+				OnComplete: func(interface{}, error) {},
+			}, UpdateModelConfigRequest{Params: params})
+		}
+	}, 3*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(),
+			UserInput{Content: "Again, less creative"})
+	}, 4*time.Second)
+
+	s.sendShutdown(6 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), 0.2, resp.Model.Temperature)
+	assert.Equal(s.T(), 1000, resp.Model.MaxTokens)
+	assert.Equal(s.T(), 0.2, second.ModelConfig.Temperature)
+	assert.Equal(s.T(), 1000, second.ModelConfig.MaxTokens)
+	assert.Equal(s.T(), 128000, second.ModelConfig.ContextWindow, "unset params are unchanged")
+	require.Len(s.T(), rejections, 2)
+	assert.Contains(s.T(), rejections[0], "temperature must be at most 2")
+	assert.Contains(s.T(), rejections[1], "does not support reasoning")
+}
+
+// TestGetStateUpdate_LongPoll verifies that get_state_update blocks until
+// new items arrive and returns TimedOut when its timeout passes first.
+func (s *AgenticWorkflowTestSuite) TestGetStateUpdate_LongPoll() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Again!", 10), nil).Once()
+
+	watch := func(id string, timeout time.Duration, got *StateUpdateResponse, at *time.Time) {
+		result, err := s.env.QueryWorkflow(QueryGetConversationItems)
+		require.NoError(s.T(), err)
+		var items []models.ConversationItem
+		require.NoError(s.T(), result.Get(&items))
+		req := StateUpdateRequest{
+			SinceSeq:         items[len(items)-1].Seq,
+			SincePhase:       PhaseWaitingForInput,
+			TimeoutMs:        int(timeout / time.Millisecond),
+			StatusIntervalMs: 1000,
+		}
+		s.env.UpdateWorkflow(UpdateGetStateUpdate, id, &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("get_state_update rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				*got = result.(StateUpdateResponse)
+				*at = s.env.Now()
+			},
+		}, req)
+	}
+
+	var start time.Time
+	var idle, woken StateUpdateResponse
+	var idleAt, wokenAt time.Time
+	s.env.RegisterDelayedCallback(func() {
+		start = s.env.Now()
+		watch("watch-idle", 5*time.Second, &idle, &idleAt)
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		watch("watch-items", time.Minute, &woken, &wokenAt)
+	}, 10*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Again"})
+	}, 12*time.Second)
+	s.sendShutdown(20 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.True(s.T(), idle.TimedOut, "nothing changed while idle")
+	assert.Empty(s.T(), idle.Items)
+	assert.Equal(s.T(), 5*time.Second, idleAt.Sub(start))
+
+	assert.False(s.T(), woken.TimedOut)
+	require.NotEmpty(s.T(), woken.Items, "new items wake the long poll")
+	assert.True(s.T(), wokenAt.Before(start.Add(20*time.Second)), "returned long before its timeout")
+}
+
 // --- Model switch compaction tests ---
 
 // TestModelSwitch_InjectsDevMessage verifies that after a model switch, the
@@ -3779,3 +4246,87 @@ func (s *AgenticWorkflowTestSuite) TestModelSwitch_FlagConsumedOnce() {
 
 // Ensure we reference workflow.Context (suppress unused import warning)
 var _ workflow.Context
+
+// TestSuggestion_CancelledByNewMessage verifies that a suggestion still being
+// generated when the next message arrives is cancelled and never shown, and
+// that suggestion tokens are counted apart from the session's totals.
+func (s *AgenticWorkflowTestSuite) TestSuggestion_CancelledByNewMessage() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("First response", 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Second response", 30), nil).Once()
+
+	// The first suggestion is slow; the second is quick.
+	s.env.OnActivity("GenerateSuggestions", mock.Anything, mock.Anything).After(5*time.Second).
+		Return(activities.SuggestionOutput{Suggestion: "stale suggestion",
+			TokenUsage: models.TokenUsage{PromptTokens: 40, TotalTokens: 45}}, nil).Once()
+	s.env.OnActivity("GenerateSuggestions", mock.Anything, mock.Anything).
+		Return(activities.SuggestionOutput{Suggestion: "fresh suggestion",
+			TokenUsage: models.TokenUsage{PromptTokens: 40, TotalTokens: 45}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(),
+			UserInput{Content: "follow-up"})
+	}, time.Second)
+
+	var status TurnStatus
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), result.Get(&status))
+	}, 10*time.Second)
+	s.sendShutdown(12 * time.Second)
+
+	input := testInput("Hello")
+	input.Config.DisableSuggestions = false
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "fresh suggestion", status.Suggestion)
+	assert.Equal(s.T(), 60, status.TotalTokens, "suggestions are not in the session's totals")
+	require.NotNil(s.T(), status.SuggestionUsage)
+	assert.Equal(s.T(), 2, status.SuggestionUsage.Calls)
+	assert.Equal(s.T(), 1, status.SuggestionUsage.Cancelled)
+	assert.Equal(s.T(), 45, status.SuggestionUsage.TotalTokens, "the cancelled call reported no usage")
+
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	require.NotNil(s.T(), result.SuggestionUsage)
+	assert.Equal(s.T(), 45, result.SuggestionUsage.TotalTokens)
+	assert.Equal(s.T(), 40, result.SuggestionUsage.InputTokens)
+}
+
+// TestSuggestion_BudgetSpent verifies that no more suggestions are generated
+// once the session's suggestion token budget is used up.
+func (s *AgenticWorkflowTestSuite) TestSuggestion_BudgetSpent() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("First response", 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Second response", 30), nil).Once()
+	s.env.OnActivity("GenerateSuggestions", mock.Anything, mock.Anything).
+		Return(activities.SuggestionOutput{Suggestion: "run the tests",
+			TokenUsage: models.TokenUsage{PromptTokens: 50, TotalTokens: 60}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(),
+			UserInput{Content: "follow-up"})
+	}, 2*time.Second)
+
+	var status TurnStatus
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), result.Get(&status))
+	}, 4*time.Second)
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Hello")
+	input.Config.DisableSuggestions = false
+	input.Config.SuggestionTokenBudget = 50
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Empty(s.T(), status.Suggestion, "the second turn got no suggestion")
+	require.NotNil(s.T(), status.SuggestionUsage)
+	assert.Equal(s.T(), 1, status.SuggestionUsage.Calls)
+}
@@ -0,0 +1,121 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// annotate.go implements user annotations: a note or a 👍/👎 reaction on an
+// earlier history item (/note, /react; annotate_item Update). Annotations are
+// history items linked to their target, so they are exported with the
+// transcript (client history, archive) for later analysis. With
+// inject_annotations they are also fed back to the model.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// resolveAnnotationTarget returns the item an annotate_item request targets.
+// Seq -1 is the latest assistant message.
+func (s *SessionState) resolveAnnotationTarget(seq int) (models.ConversationItem, error) {
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return models.ConversationItem{}, err
+	}
+	if seq == -1 {
+		for i := len(items) - 1; i >= 0; i-- {
+			if items[i].Type == models.ItemTypeAssistantMessage && items[i].Content != "" {
+				return items[i], nil
+			}
+		}
+		return models.ConversationItem{}, fmt.Errorf("no assistant message to annotate yet")
+	}
+	if seq < 0 || seq >= len(items) {
+		return models.ConversationItem{}, fmt.Errorf("no history item #%d", seq)
+	}
+	if t := items[seq].Type; !t.IsPinnable() {
+		return models.ConversationItem{}, fmt.Errorf("item #%d is a %s item and cannot be annotated", seq, t)
+	}
+	return items[seq], nil
+}
+
+// validateAnnotation checks an annotate_item request.
+func (s *SessionState) validateAnnotation(req AnnotateItemRequest) error {
+	switch req.Reaction {
+	case "", models.ReactionUp, models.ReactionDown:
+	default:
+		return fmt.Errorf("unknown reaction %q (want up or down)", req.Reaction)
+	}
+	if strings.TrimSpace(req.Note) == "" && req.Reaction == "" {
+		return fmt.Errorf("annotation needs a note or a reaction")
+	}
+	_, err := s.resolveAnnotationTarget(req.Seq)
+	return err
+}
+
+// annotationItem builds the history item recording req on target.
+func (s *SessionState) annotationItem(req AnnotateItemRequest, target models.ConversationItem) models.ConversationItem {
+	return models.ConversationItem{
+		Type:    models.ItemTypeAnnotation,
+		Content: strings.TrimSpace(req.Note),
+		TurnID:  target.TurnID,
+		Annotation: &models.Annotation{
+			TargetSeq:     target.Seq,
+			TargetType:    target.Type,
+			TargetTurnID:  target.TurnID,
+			TargetCallID:  target.CallID,
+			TargetPreview: pinPreview(target),
+			Reaction:      req.Reaction,
+			Feedback:      s.Config.InjectAnnotations,
+		},
+	}
+}
+
+// annotationFeedback renders a feedback annotation as the developer message
+// the model receives.
+func annotationFeedback(item models.ConversationItem) models.ConversationItem {
+	a := item.Annotation
+	var b strings.Builder
+	fmt.Fprintf(&b, "User feedback on an earlier %s", strings.ReplaceAll(string(a.TargetType), "_", " "))
+	if a.TargetPreview != "" {
+		fmt.Fprintf(&b, " (%q)", a.TargetPreview)
+	}
+	b.WriteString(":")
+	switch a.Reaction {
+	case models.ReactionUp:
+		b.WriteString(" thumbs up.")
+	case models.ReactionDown:
+		b.WriteString(" thumbs down.")
+	}
+	if item.Content != "" {
+		b.WriteString(" " + item.Content)
+	}
+	return models.ConversationItem{
+		Type:    models.ItemTypeDeveloperMessage,
+		Seq:     item.Seq,
+		Content: b.String(),
+		TurnID:  item.TurnID,
+	}
+}
+
+// withAnnotationFeedback returns the prompt items with feedback annotations
+// replaced by developer messages. The mapping is one-to-one, so positions in
+// the result still line up with history for incremental prompts.
+func withAnnotationFeedback(items []models.ConversationItem) []models.ConversationItem {
+	var out []models.ConversationItem
+	for i, item := range items {
+		if item.Type != models.ItemTypeAnnotation || item.Annotation == nil || !item.Annotation.Feedback {
+			continue
+		}
+		if out == nil {
+			out = make([]models.ConversationItem, len(items))
+			copy(out, items)
+		}
+		out[i] = annotationFeedback(item)
+	}
+	if out == nil {
+		return items
+	}
+	return out
+}
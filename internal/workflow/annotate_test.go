@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestWithAnnotationFeedback(t *testing.T) {
+	recorded := models.ConversationItem{
+		Type:       models.ItemTypeAnnotation,
+		Seq:        4,
+		Content:    "nice",
+		Annotation: &models.Annotation{TargetSeq: 2, TargetType: models.ItemTypeAssistantMessage, Reaction: models.ReactionUp},
+	}
+	feedback := models.ConversationItem{
+		Type:    models.ItemTypeAnnotation,
+		Seq:     5,
+		Content: "this diff broke prod",
+		TurnID:  "turn-1",
+		Annotation: &models.Annotation{
+			TargetSeq:     2,
+			TargetType:    models.ItemTypeAssistantMessage,
+			TargetPreview: "Applied the patch.",
+			Reaction:      models.ReactionDown,
+			Feedback:      true,
+		},
+	}
+	items := []models.ConversationItem{
+		{Type: models.ItemTypeUserMessage, Seq: 1, Content: "Fix it"},
+		recorded,
+		feedback,
+	}
+
+	got := withAnnotationFeedback(items)
+	require.Len(t, got, len(items), "mapping must stay one-to-one")
+	assert.Equal(t, items[0], got[0])
+	assert.Equal(t, recorded, got[1], "annotations without Feedback are passed through")
+	assert.Equal(t, models.ItemTypeDeveloperMessage, got[2].Type)
+	assert.Equal(t, 5, got[2].Seq)
+	assert.Equal(t, "turn-1", got[2].TurnID)
+	assert.Equal(t,
+		`User feedback on an earlier assistant message ("Applied the patch."): thumbs down. this diff broke prod`,
+		got[2].Content)
+	assert.Equal(t, models.ItemTypeAnnotation, items[2].Type, "input is not modified")
+
+	plain := items[:2]
+	assert.Equal(t, plain, withAnnotationFeedback(plain))
+}
+
+// TestAnnotateItem_InjectedAsFeedback verifies /react on the last assistant
+// message is recorded in history and, with inject_annotations, sent to the
+// model on the next turn.
+func (s *AgenticWorkflowTestSuite) TestAnnotateItem_InjectedAsFeedback() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Applied the patch.", 10), nil).Once()
+	var secondInput activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			secondInput = args.Get(1).(activities.LLMActivityInput)
+		}).
+		Return(mockLLMStopResponse("Reverting.", 10), nil).Once()
+
+	var resp AnnotateItemResponse
+	var annotateErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAnnotateItem, "annotate-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("annotation rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				annotateErr = err
+				if err == nil {
+					resp = result.(AnnotateItemResponse)
+				}
+			},
+		}, AnnotateItemRequest{Seq: -1, Reaction: models.ReactionDown, Note: "this diff broke prod"})
+	}, 2*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAnnotateItem, "annotate-empty", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("an empty annotation should be rejected") },
+			OnReject:   func(err error) {},
+			OnComplete: func(interface{}, error) {},
+		}, AnnotateItemRequest{Seq: -1})
+		s.env.UpdateWorkflow(UpdateAnnotateItem, "annotate-marker", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("annotating a turn marker should be rejected") },
+			OnReject:   func(err error) {},
+			OnComplete: func(interface{}, error) {},
+		}, AnnotateItemRequest{Seq: 0, Reaction: models.ReactionUp})
+	}, 3*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(),
+			UserInput{Content: "What went wrong?"})
+	}, 4*time.Second)
+
+	s.sendShutdown(6 * time.Second)
+
+	input := testInput("Apply the patch")
+	input.Config.InjectAnnotations = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), annotateErr)
+
+	items := s.queryItems()
+	require.Greater(s.T(), len(items), resp.Seq)
+	annotation := items[resp.Seq]
+	assert.Equal(s.T(), models.ItemTypeAnnotation, annotation.Type)
+	assert.Equal(s.T(), "this diff broke prod", annotation.Content)
+	require.NotNil(s.T(), annotation.Annotation)
+	assert.Equal(s.T(), resp.TargetSeq, annotation.Annotation.TargetSeq)
+	assert.Equal(s.T(), "Applied the patch.", items[resp.TargetSeq].Content)
+	assert.Equal(s.T(), models.ReactionDown, annotation.Annotation.Reaction)
+	assert.True(s.T(), annotation.Annotation.Feedback)
+
+	var feedback []string
+	for _, item := range secondInput.History {
+		assert.NotEqual(s.T(), models.ItemTypeAnnotation, item.Type)
+		if item.Type == models.ItemTypeDeveloperMessage {
+			feedback = append(feedback, item.Content)
+		}
+	}
+	require.Len(s.T(), feedback, 1)
+	assert.Contains(s.T(), feedback[0], "thumbs down. this diff broke prod")
+}
@@ -8,9 +8,12 @@ package workflow
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/mfateev/temporal-agent-harness/internal/execpolicy"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
 	"github.com/mfateev/temporal-agent-harness/internal/shell"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
@@ -19,6 +22,7 @@ import (
 type ApprovalGate struct {
 	mode        models.ApprovalMode
 	policyRules string
+	network     *models.Permissions // Sandbox network settings fetch_url is checked against
 }
 
 // NewApprovalGate creates an ApprovalGate with the given approval mode and policy rules.
@@ -26,10 +30,61 @@ func NewApprovalGate(mode models.ApprovalMode, policyRules string) *ApprovalGate
 	return &ApprovalGate{mode: mode, policyRules: policyRules}
 }
 
+// WithNetworkPolicy lets fetch_url calls run without a prompt when the
+// session's sandbox network settings allow the URL's host.
+func (g *ApprovalGate) WithNetworkPolicy(perms models.Permissions) *ApprovalGate {
+	g.network = &perms
+	return g
+}
+
 // Classify determines which tools need approval vs are forbidden.
 // Delegates to classifyToolsForApproval.
 func (g *ApprovalGate) Classify(calls []models.ConversationItem) ([]PendingApproval, []models.ConversationItem) {
-	return classifyToolsForApproval(calls, g.mode, g.policyRules)
+	pending, forbidden := classifyToolsForApproval(calls, g.mode, g.policyRules)
+	if g.network == nil {
+		return pending, forbidden
+	}
+	kept := pending[:0]
+	for _, p := range pending {
+		if p.ToolName == "fetch_url" && fetchAllowedByNetworkPolicy(p.Arguments, *g.network) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	return kept, forbidden
+}
+
+// fetchAllowedByNetworkPolicy reports whether the sandbox network settings
+// allow fetch_url's URL: the session has full access or network access, or
+// the host is on the network allowlist.
+func fetchAllowedByNetworkPolicy(arguments string, perms models.Permissions) bool {
+	if sandbox.SandboxMode(perms.SandboxMode) == sandbox.ModeFullAccess || perms.SandboxNetworkAccess {
+		return true
+	}
+	var args struct {
+		URL string `json:"url"`
+	}
+	if json.Unmarshal([]byte(arguments), &args) != nil {
+		return false
+	}
+	target, err := url.Parse(args.URL)
+	if err != nil || target.Hostname() == "" {
+		return false
+	}
+	port := 443
+	if target.Scheme == "http" {
+		port = 80
+	}
+	if p := target.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return false
+		}
+	}
+	allow, err := sandbox.ParseNetworkAllow(perms.SandboxNetworkAllow)
+	return err == nil && allow.Allows(target.Hostname(), port)
 }
 
 // ApplyDecision filters calls based on user's approval response.
@@ -109,7 +164,7 @@ func evaluateToolApproval(
 	}
 
 	switch toolName {
-	case "read_file", "list_dir", "grep_files", "request_user_input", "update_plan":
+	case "read_file", "list_dir", "grep_files", "grep_changed", "semantic_search", "code_outline", "request_user_input", "ask_user", "emit_result", "update_plan", "current_time":
 		return tools.ApprovalSkip, "" // Read-only / workflow-intercepted tools always safe
 
 	case "shell":
@@ -124,6 +179,48 @@ func evaluateToolApproval(
 		}
 		return tools.ApprovalNeeded, "mutating file operation"
 
+	case "gh_get_issue":
+		return tools.ApprovalSkip, "" // Reads GitHub only
+
+	case "fetch_url":
+		if mode == models.ApprovalNever {
+			return tools.ApprovalSkip, ""
+		}
+		return tools.ApprovalNeeded, "fetches a URL the sandbox network policy does not allow"
+
+	case "gh_create_pr", "gh_comment":
+		if mode == models.ApprovalNever {
+			return tools.ApprovalSkip, ""
+		}
+		return tools.ApprovalNeeded, "writes to GitHub"
+
+	case "browser_navigate", "browser_read_text", "browser_screenshot":
+		return tools.ApprovalSkip, "" // Loads allowlisted pages only
+
+	case "browser_click":
+		if mode == models.ApprovalNever {
+			return tools.ApprovalSkip, ""
+		}
+		return tools.ApprovalNeeded, "clicks in the browser, which can submit forms"
+
+	case "python_exec":
+		if mode == models.ApprovalNever {
+			return tools.ApprovalSkip, ""
+		}
+		return tools.ApprovalNeeded, "runs Python code"
+
+	case "rollback_workspace":
+		if mode == models.ApprovalNever {
+			return tools.ApprovalSkip, ""
+		}
+		return tools.ApprovalNeeded, "restores the workspace to a snapshot, discarding later changes"
+
+	case "quality_gate":
+		if mode == models.ApprovalNever {
+			return tools.ApprovalSkip, ""
+		}
+		return tools.ApprovalNeeded, "runs the project's checks"
+
 	default:
 		if mode == models.ApprovalNever {
 			return tools.ApprovalSkip, ""
@@ -0,0 +1,55 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// approval_context.go attaches context to pending approvals before they are
+// shown: what a write_file call would replace and which paths a shell
+// command names. The DescribeApprovals activity gathers it on the session's
+// worker, since only that worker sees the files.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+)
+
+// describeApprovals sets Context on the pending write_file and shell calls.
+// Best-effort: on failure the approvals go out without it.
+func (s *SessionState) describeApprovals(ctx workflow.Context, pending []PendingApproval) {
+	var calls []activities.ApprovalCall
+	for _, ap := range pending {
+		switch ap.ToolName {
+		case "write_file", "shell", "shell_command":
+			calls = append(calls, activities.ApprovalCall{CallID: ap.CallID, ToolName: ap.ToolName, Arguments: ap.Arguments})
+		}
+	}
+	if len(calls) == 0 {
+		return
+	}
+
+	opts := workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1, // No retries — don't hold up the prompt
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		opts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	var out activities.DescribeApprovalsOutput
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, opts), "DescribeApprovals",
+		activities.DescribeApprovalsInput{Cwd: s.Config.Cwd, Calls: calls}).Get(ctx, &out)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Describing pending approvals failed", "error", err)
+		return
+	}
+	for i := range pending {
+		if c, ok := out.Contexts[pending[i].CallID]; ok {
+			pending[i].Context = &c
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestApprovalContext_AttachedToPendingApprovals verifies that pending
+// write_file and shell calls carry the context from DescribeApprovals, and
+// that other tools are not sent to it.
+func (s *AgenticWorkflowTestSuite) TestApprovalContext_AttachedToPendingApprovals() {
+	s.newEnv()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-write", Name: "write_file",
+					Arguments: `{"path": "main.go", "content": "package main\n"}`},
+				{Type: models.ItemTypeFunctionCall, CallID: "call-rm", Name: "shell_command",
+					Arguments: `{"command": "rm -rf build"}`},
+				{Type: models.ItemTypeFunctionCall, CallID: "call-py", Name: "python_exec",
+					Arguments: `{"code": "print(1)"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   models.TokenUsage{TotalTokens: 30},
+		}, nil).Once()
+
+	var described activities.DescribeApprovalsInput
+	s.env.OnActivity("DescribeApprovals", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { described = args.Get(1).(activities.DescribeApprovalsInput) }).
+		Return(activities.DescribeApprovalsOutput{Contexts: map[string]models.ApprovalContext{
+			"call-write": {FileExists: true, CurrentLines: []string{"package old"}, CurrentSize: 40, NewSize: 13},
+			"call-rm":    {Paths: []string{"build"}},
+		}}, nil).Once()
+
+	var status TurnStatus
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), result.Get(&status))
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", noopCallback(),
+			ApprovalResponse{Denied: []string{"call-write", "call-rm", "call-py"}})
+	}, 2*time.Second)
+	s.sendShutdown(4 * time.Second)
+
+	input := testInputWithApproval("Clean up", models.ApprovalUnlessTrusted)
+	input.Config.Cwd = "/src/app"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "/src/app", described.Cwd)
+	var sent []string
+	for _, c := range described.Calls {
+		sent = append(sent, c.CallID)
+	}
+	assert.Equal(s.T(), []string{"call-write", "call-rm"}, sent)
+
+	require.Equal(s.T(), PhaseApprovalPending, status.Phase)
+	require.Len(s.T(), status.PendingApprovals, 3)
+	require.NotNil(s.T(), status.PendingApprovals[0].Context)
+	assert.Equal(s.T(), int64(-27), status.PendingApprovals[0].Context.SizeDelta())
+	require.NotNil(s.T(), status.PendingApprovals[1].Context)
+	assert.Equal(s.T(), []string{"build"}, status.PendingApprovals[1].Context.Paths)
+	assert.Nil(s.T(), status.PendingApprovals[2].Context)
+}
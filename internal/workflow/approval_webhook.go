@@ -0,0 +1,120 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// approval_webhook.go delegates tool approvals to an external service when
+// approval_webhook is configured, for server deployments with no TUI to
+// answer them. The pending calls are POSTed by the NotifyApprovalWebhook
+// activity; the decision arrives through the usual approval_response Update.
+// If none arrives in time the fallback policy denies the calls or leaves
+// them pending for a human.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// awaitApproval waits for a decision on needsApproval, asking the approval
+// webhook when one is configured. fellBack reports that the webhook fallback
+// denied the calls. Returns a nil response if interrupted or shutdown.
+func (s *SessionState) awaitApproval(ctx workflow.Context, ctrl *LoopControl, needsApproval []PendingApproval) (resp *ApprovalResponse, fellBack bool, err error) {
+	hook := s.Config.ApprovalWebhook
+	if hook.URL == "" {
+		resp, err = ctrl.AwaitApproval(ctx, needsApproval)
+		return resp, false, err
+	}
+	logger := workflow.GetLogger(ctx)
+
+	timeout := time.Duration(hook.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = models.DefaultApprovalWebhookTimeoutSec * time.Second
+	}
+	fallback := hook.Fallback
+	if fallback != models.ApprovalWebhookFallbackEscalate {
+		fallback = models.ApprovalWebhookFallbackDeny
+	}
+	start := workflow.Now(ctx)
+
+	// Pending before the POST, so the service may answer from its handler.
+	ctrl.BeginApproval(ctx, needsApproval)
+	notifyErr := s.notifyApprovalWebhook(ctx, ctrl, needsApproval, start.Add(timeout), fallback)
+	if notifyErr != nil {
+		logger.Warn("Approval webhook failed; applying fallback", "fallback", fallback, "error", notifyErr)
+	} else {
+		remaining := timeout - workflow.Now(ctx).Sub(start)
+		var timedOut bool
+		resp, timedOut, err = ctrl.AwaitApprovalResponse(ctx, max(remaining, time.Millisecond))
+		if err != nil || !timedOut {
+			return resp, false, err
+		}
+		logger.Warn("Approval webhook timed out; applying fallback", "fallback", fallback, "timeout", timeout)
+	}
+
+	if fallback == models.ApprovalWebhookFallbackDeny && !ctrl.approvalSlot.Ready() {
+		denied := make([]string, len(needsApproval))
+		for i, ap := range needsApproval {
+			denied[i] = ap.CallID
+		}
+		ctrl.DeliverApproval(ApprovalResponse{Denied: denied})
+		resp, _, err = ctrl.AwaitApprovalResponse(ctx, 0)
+		return resp, true, err
+	}
+	// Escalate: leave the calls pending for an attached TUI or another
+	// approval_response sender.
+	resp, _, err = ctrl.AwaitApprovalResponse(ctx, 0)
+	return resp, false, err
+}
+
+// notifyApprovalWebhook POSTs the pending calls to the approval webhook,
+// retrying transient failures for up to two minutes.
+func (s *SessionState) notifyApprovalWebhook(ctx workflow.Context, ctrl *LoopControl, needsApproval []PendingApproval, deadline time.Time, fallback models.ApprovalWebhookFallback) error {
+	calls := make([]activities.ApprovalWebhookCall, len(needsApproval))
+	for i, ap := range needsApproval {
+		calls[i] = activities.ApprovalWebhookCall{
+			CallID:    ap.CallID,
+			ToolName:  ap.ToolName,
+			Arguments: ap.Arguments,
+			Reason:    ap.Reason,
+		}
+	}
+	info := workflow.GetInfo(ctx)
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout:    time.Minute,
+		ScheduleToCloseTimeout: 2 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2,
+			MaximumInterval:    15 * time.Second,
+			MaximumAttempts:    5,
+		},
+	})
+	err := workflow.ExecuteActivity(actCtx, "NotifyApprovalWebhook", activities.NotifyApprovalWebhookInput{
+		URL:     s.Config.ApprovalWebhook.URL,
+		Headers: s.Config.ApprovalWebhook.Headers,
+		Request: activities.ApprovalWebhookRequest{
+			WorkflowID: info.WorkflowExecution.ID,
+			RunID:      info.WorkflowExecution.RunID,
+			TurnID:     ctrl.CurrentTurnID(),
+			TurnEpoch:  ctrl.TurnEpoch(),
+			UpdateName: UpdateApprovalResponse,
+			Deadline:   deadline,
+			Fallback:   string(fallback),
+			Calls:      calls,
+		},
+	}).Get(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("notify approval webhook: %w", err)
+	}
+	return nil
+}
+
+// approvalFallbackDeniedMessage replaces the denial output of calls denied
+// by the webhook fallback, so the model knows no one rejected them.
+const approvalFallbackDeniedMessage = "Approval was not granted: the approval webhook gave no decision in time, so this tool call was denied. Ask the user before retrying."
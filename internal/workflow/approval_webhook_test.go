@@ -0,0 +1,137 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// mockRmCallResponse is an LLM response that runs a command needing approval.
+func mockRmCallResponse() activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{{
+			Type:      models.ItemTypeFunctionCall,
+			CallID:    "call-rm",
+			Name:      "shell_command",
+			Arguments: `{"command": "rm -rf /tmp/test"}`,
+		}},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: 30},
+	}
+}
+
+func testInputWithWebhook(fallback models.ApprovalWebhookFallback) WorkflowInput {
+	input := testInputWithApproval("Delete /tmp/test", models.ApprovalUnlessTrusted)
+	input.Config.ApprovalWebhook = models.ApprovalWebhook{
+		URL:        "https://approvals.example.com/hook",
+		Headers:    map[string]string{"Authorization": "Bearer t"},
+		TimeoutSec: 30,
+		Fallback:   fallback,
+	}
+	return input
+}
+
+// TestApprovalWebhook_DecisionViaUpdate verifies the pending calls are sent
+// to the webhook and its approval_response Update runs the tool.
+func (s *AgenticWorkflowTestSuite) TestApprovalWebhook_DecisionViaUpdate() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockRmCallResponse(), nil).Once()
+	s.env.OnActivity("NotifyApprovalWebhook", mock.Anything, mock.MatchedBy(func(in activities.NotifyApprovalWebhookInput) bool {
+		return in.URL == "https://approvals.example.com/hook" &&
+			in.Headers["Authorization"] == "Bearer t" &&
+			in.Request.UpdateName == UpdateApprovalResponse &&
+			in.Request.Fallback == "deny" &&
+			len(in.Request.Calls) == 1 && in.Request.Calls[0].CallID == "call-rm"
+	})).Return(nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-rm", Success: &trueVal}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done removing files.", 40), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "webhook-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-rm"}})
+	}, 5*time.Second)
+	s.sendShutdown(10 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithWebhook(""))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Contains(s.T(), result.ToolCallsExecuted, "shell_command")
+}
+
+// TestApprovalWebhook_TimeoutDenies verifies the deny fallback when the
+// webhook never answers.
+func (s *AgenticWorkflowTestSuite) TestApprovalWebhook_TimeoutDenies() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockRmCallResponse(), nil).Once()
+	s.env.OnActivity("NotifyApprovalWebhook", mock.Anything, mock.Anything).Return(nil).Once()
+
+	s.sendShutdown(time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithWebhook(models.ApprovalWebhookFallbackDeny))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.NotContains(s.T(), result.ToolCallsExecuted, "shell_command")
+	s.assertCallOutput("call-rm", approvalFallbackDeniedMessage)
+}
+
+// TestApprovalWebhook_EscalateKeepsPending verifies the escalate fallback:
+// a failed webhook leaves the approval pending for a human to answer.
+func (s *AgenticWorkflowTestSuite) TestApprovalWebhook_EscalateKeepsPending() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockRmCallResponse(), nil).Once()
+	s.env.OnActivity("NotifyApprovalWebhook", mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("HTTP 401", "ApprovalWebhookError", fmt.Errorf("unauthorized"))).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-rm", Success: &trueVal}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done removing files.", 40), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		status, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var ts TurnStatus
+		require.NoError(s.T(), status.Get(&ts))
+		assert.Equal(s.T(), PhaseApprovalPending, ts.Phase, "still pending after the timeout")
+
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "human-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-rm"}})
+	}, 2*time.Minute)
+	s.sendShutdown(3 * time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithWebhook(models.ApprovalWebhookFallbackEscalate))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Contains(s.T(), result.ToolCallsExecuted, "shell_command")
+}
+
+// assertCallOutput checks the recorded output of a tool call.
+func (s *AgenticWorkflowTestSuite) assertCallOutput(callID, content string) {
+	items, err := s.env.QueryWorkflow(QueryGetConversationItems)
+	require.NoError(s.T(), err)
+	var history []models.ConversationItem
+	require.NoError(s.T(), items.Get(&history))
+	for _, item := range history {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == callID {
+			assert.Equal(s.T(), content, item.Output.Content)
+			return
+		}
+	}
+	s.T().Errorf("no output recorded for %s", callID)
+}
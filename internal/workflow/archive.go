@@ -0,0 +1,90 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// archive.go streams the session transcript to object storage when
+// archive_url is configured. After each turn and at shutdown the history
+// items added since the last archive are written as a new segment by the
+// ArchiveTranscript activity. Compaction and /context edits rewrite history,
+// so the items about to be replaced are flushed first and archiving resumes
+// after the rewritten history.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+)
+
+// Archive segment reasons.
+const (
+	archiveReasonTurnComplete = "turn_complete"
+	archiveReasonShutdown     = "shutdown"
+	archiveReasonCompaction   = "compaction"
+	archiveReasonContextEdit  = "context_edit"
+)
+
+// archiveTranscript archives the history items added since the last segment
+// and reports whether the whole history is now archived (always, when
+// archiving is off). Archiving is best-effort: failures are logged and the
+// items are retried with the next segment.
+func (s *SessionState) archiveTranscript(ctx workflow.Context, reason string) bool {
+	if s.Config.ArchiveURL == "" {
+		return true
+	}
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return false
+	}
+	if s.ArchivedItems > len(items) {
+		// History shrank without a flush; archive what is there now.
+		s.ArchivedItems = 0
+	}
+	pending := items[s.ArchivedItems:]
+	if len(pending) == 0 {
+		return true
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second,
+			MaximumAttempts: 3,
+		},
+	})
+	segment := s.ArchiveSegments + 1
+	var out activities.ArchiveTranscriptOutput
+	err = workflow.ExecuteActivity(actCtx, "ArchiveTranscript", activities.ArchiveTranscriptInput{
+		ArchiveURL: s.Config.ArchiveURL,
+		SessionID:  workflow.GetInfo(ctx).WorkflowExecution.ID,
+		Segment:    segment,
+		Reason:     reason,
+		ArchivedAt: workflow.Now(ctx),
+		Items:      pending,
+	}).Get(ctx, &out)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Transcript archive failed", "segment", segment, "error", err)
+		return false
+	}
+	s.ArchiveSegments = segment
+	s.ArchivedItems = len(items)
+	return true
+}
+
+// resetArchiveMark sets the archive mark after compaction, a destructive
+// drop or a /context edit rewrote history. flushed is what archiveTranscript
+// returned for the history before the rewrite: if it was archived, the
+// rewritten history counts as archived too; if not, the next segment starts
+// from the rewritten history's first item, so nothing after the rewrite is
+// skipped.
+func (s *SessionState) resetArchiveMark(flushed bool) {
+	if !flushed {
+		s.ArchivedItems = 0
+		return
+	}
+	items, _ := s.History.GetRawItems()
+	s.ArchivedItems = len(items)
+}
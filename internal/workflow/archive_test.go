@@ -0,0 +1,162 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestArchiveTranscript_SegmentsPerTurnAndShutdown verifies that each
+// completed turn archives the new items as a segment, and that items added
+// after the last turn are archived at shutdown.
+func (s *AgenticWorkflowTestSuite) TestArchiveTranscript_SegmentsPerTurnAndShutdown() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	var segments []activities.ArchiveTranscriptInput
+	s.env.OnActivity("ArchiveTranscript", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ArchiveTranscriptInput) (activities.ArchiveTranscriptOutput, error) {
+			segments = append(segments, in)
+			return activities.ArchiveTranscriptOutput{}, nil
+		})
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateDeveloperInput, "dev-1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { s.Fail("developer_input rejected", err.Error()) },
+			OnComplete: func(interface{}, error) {},
+		}, DeveloperInput{Content: "Prefer small diffs."})
+	}, 2*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	input := testInput("hi")
+	input.Config.ArchiveURL = "s3://transcripts/agents"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), segments, 2)
+	first, second := segments[0], segments[1]
+
+	assert.Equal(s.T(), "s3://transcripts/agents", first.ArchiveURL)
+	assert.NotEmpty(s.T(), first.SessionID)
+	assert.Equal(s.T(), 1, first.Segment)
+	assert.Equal(s.T(), archiveReasonTurnComplete, first.Reason)
+	require.NotEmpty(s.T(), first.Items)
+	assert.Equal(s.T(), models.ItemTypeTurnComplete, first.Items[len(first.Items)-1].Type)
+
+	assert.Equal(s.T(), first.SessionID, second.SessionID)
+	assert.Equal(s.T(), 2, second.Segment)
+	assert.Equal(s.T(), archiveReasonShutdown, second.Reason)
+	require.Len(s.T(), second.Items, 1)
+	assert.Equal(s.T(), models.ItemTypeDeveloperMessage, second.Items[0].Type)
+}
+
+// TestArchiveTranscript_FailureIsRetriedWithNextSegment verifies that a
+// failed archive does not fail the workflow and that its items are included
+// in the next segment.
+func (s *AgenticWorkflowTestSuite) TestArchiveTranscript_FailureIsRetriedWithNextSegment() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	calls := 0
+	var archived activities.ArchiveTranscriptInput
+	s.env.OnActivity("ArchiveTranscript", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ArchiveTranscriptInput) (activities.ArchiveTranscriptOutput, error) {
+			calls++
+			if in.Reason == archiveReasonTurnComplete {
+				return activities.ArchiveTranscriptOutput{}, errors.New("bucket unavailable")
+			}
+			archived = in
+			return activities.ArchiveTranscriptOutput{}, nil
+		})
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateDeveloperInput, "dev-1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { s.Fail("developer_input rejected", err.Error()) },
+			OnComplete: func(interface{}, error) {},
+		}, DeveloperInput{Content: "Prefer small diffs."})
+	}, 2*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	input := testInput("hi")
+	input.Config.ArchiveURL = "file:///var/archive"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	assert.Greater(s.T(), calls, 1)
+	assert.Equal(s.T(), 1, archived.Segment, "failed segment number is reused")
+	require.NotEmpty(s.T(), archived.Items)
+	assert.True(s.T(), hasItemType(archived.Items, models.ItemTypeTurnComplete), "failed turn's items are carried over")
+	assert.Equal(s.T(), models.ItemTypeDeveloperMessage, archived.Items[len(archived.Items)-1].Type)
+}
+
+// TestArchiveTranscript_FailedFlushBeforeDropIsNotSkipped verifies that when
+// the items about to be dropped on context overflow could not be archived,
+// the next segment still carries the unarchived items that survived the
+// drop instead of treating the rewritten history as archived.
+func (s *AgenticWorkflowTestSuite) TestArchiveTranscript_FailedFlushBeforeDropIsNotSkipped() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("First response", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{}, temporal.NewNonRetryableApplicationError(
+			"context too large", models.LLMErrTypeContextOverflow, nil)).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Recovered response", 10), nil).Once()
+
+	var segments []activities.ArchiveTranscriptInput
+	s.env.OnActivity("ArchiveTranscript", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ArchiveTranscriptInput) (activities.ArchiveTranscriptOutput, error) {
+			if in.Reason == archiveReasonCompaction {
+				return activities.ArchiveTranscriptOutput{}, errors.New("bucket unavailable")
+			}
+			segments = append(segments, in)
+			return activities.ArchiveTranscriptOutput{}, nil
+		})
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(),
+			UserInput{Content: "Second question"})
+	}, 2*time.Second)
+	// Past the archive and compaction retries.
+	s.sendShutdown(time.Minute)
+
+	input := testInput("First question")
+	input.Config.ArchiveURL = "s3://transcripts/agents"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	require.Len(s.T(), segments, 2)
+	second := segments[1]
+	assert.Equal(s.T(), archiveReasonTurnComplete, second.Reason)
+	var texts []string
+	for _, item := range second.Items {
+		if item.Type == models.ItemTypeUserMessage {
+			texts = append(texts, item.Content)
+		}
+	}
+	assert.Contains(s.T(), texts, "Second question", "the message whose flush failed is archived with the next segment")
+}
+
+// hasItemType reports whether items contain an item of type t.
+func hasItemType(items []models.ConversationItem, t models.ConversationItemType) bool {
+	for _, item := range items {
+		if item.Type == t {
+			return true
+		}
+	}
+	return false
+}
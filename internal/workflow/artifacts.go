@@ -0,0 +1,83 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// artifacts.go handles the share_artifact intercepted tool. The workflow
+// registers the file with the artifact server of a worker on the session's
+// task queue (where the file lives) and records the returned URL in history,
+// as the call's output and as an attachment the TUI renders as a link. Only
+// files under cwd and the sandbox's writable roots can be shared.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// handleShareArtifact intercepts a share_artifact tool call.
+func (s *SessionState) handleShareArtifact(ctx workflow.Context, fc models.ConversationItem) models.ConversationItem {
+	var args struct {
+		Path       string `json:"path"`
+		TTLMinutes int    `json:"ttl_minutes,omitempty"`
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return shareArtifactOutput(fc.CallID, fmt.Sprintf("Invalid share_artifact arguments: %v", err), false, nil)
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return shareArtifactOutput(fc.CallID, "share_artifact failed: path must not be empty", false, nil)
+	}
+	if args.TTLMinutes < 0 {
+		return shareArtifactOutput(fc.CallID, "share_artifact failed: ttl_minutes must not be negative", false, nil)
+	}
+
+	opts := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 3},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		opts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	var out activities.RegisterArtifactOutput
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, opts), "RegisterArtifact", activities.RegisterArtifactInput{
+		Path:       args.Path,
+		Cwd:        s.Config.Cwd,
+		Roots:      s.Config.Permissions.SandboxWritableRoots,
+		TTLMinutes: args.TTLMinutes,
+	}).Get(ctx, &out)
+	if err != nil {
+		msg := err.Error()
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) {
+			msg = appErr.Message()
+		}
+		return shareArtifactOutput(fc.CallID, "share_artifact failed: "+msg, false, nil)
+	}
+
+	a := out.Attachment
+	workflow.GetLogger(ctx).Info("Artifact shared", "path", a.Path, "url", a.URL)
+	content := fmt.Sprintf("Shared %s (%d bytes): %s\nThe link expires at %s.",
+		a.Name, a.Size, a.URL, a.ExpiresAt.UTC().Format(time.RFC3339))
+	return shareArtifactOutput(fc.CallID, content, true, []attachment.Attachment{a})
+}
+
+func shareArtifactOutput(callID, content string, success bool, atts []attachment.Attachment) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{
+			Content:     content,
+			Success:     &success,
+			Attachments: atts,
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/attachment"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestShareArtifact_RecordsURL verifies that a share_artifact call
+// registers the file on the worker and records its URL, as text for the
+// model and as an attachment for the TUI.
+func (s *AgenticWorkflowTestSuite) TestShareArtifact_RecordsURL() {
+	expires := time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-share", Name: "share_artifact", Arguments: `{"path": "cover.html", "ttl_minutes": 30}`},
+				{Type: models.ItemTypeFunctionCall, CallID: "call-missing", Name: "share_artifact", Arguments: `{"path": "missing.html"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Here is the report.", 10), nil).Once()
+
+	s.env.OnActivity("RegisterArtifact", mock.Anything, activities.RegisterArtifactInput{Path: "cover.html", Cwd: "/work", TTLMinutes: 30}).
+		Return(activities.RegisterArtifactOutput{Attachment: attachment.Attachment{
+			Name: "cover.html", Size: 2048, Path: "/work/cover.html",
+			URL: "http://worker:8089/artifacts/0f3a/cover.html", ExpiresAt: expires,
+		}}, nil).Once()
+	s.env.OnActivity("RegisterArtifact", mock.Anything, mock.MatchedBy(func(in activities.RegisterArtifactInput) bool { return in.Path == "missing.html" })).
+		Return(activities.RegisterArtifactOutput{}, temporal.NewNonRetryableApplicationError("missing.html does not exist", "InvalidArtifact", nil)).Once()
+
+	s.sendShutdown(2 * time.Second)
+
+	input := testInput("Share the coverage report")
+	input.Config.Cwd = "/work"
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "share_artifact")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	outputs := map[string]*models.FunctionCallOutputPayload{}
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeFunctionCallOutput {
+			outputs[item.CallID] = item.Output
+		}
+	}
+	shared := outputs["call-share"]
+	require.NotNil(s.T(), shared)
+	assert.True(s.T(), *shared.Success)
+	assert.Equal(s.T(), fmt.Sprintf("Shared cover.html (2048 bytes): http://worker:8089/artifacts/0f3a/cover.html\nThe link expires at %s.", expires.Format(time.RFC3339)), shared.Content)
+	require.Len(s.T(), shared.Attachments, 1)
+	assert.Equal(s.T(), "http://worker:8089/artifacts/0f3a/cover.html", shared.Attachments[0].URL)
+
+	missing := outputs["call-missing"]
+	require.NotNil(s.T(), missing)
+	assert.False(s.T(), *missing.Success)
+	assert.Equal(s.T(), "share_artifact failed: missing.html does not exist", missing.Content)
+}
@@ -0,0 +1,101 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// ask_user.go handles interception of ask_user tool calls: free-text
+// questions the user answers in the TUI. The wait works like approvals; the
+// answer becomes the call's output and is also recorded as a user_answer
+// history item.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// handleAskUser intercepts an ask_user tool call, waits for the user's
+// answer via ctrl.AwaitAskUser and returns the items to record: the
+// FunctionCallOutput and, when answered, the user_answer item.
+func (s *SessionState) handleAskUser(ctx workflow.Context, ctrl *LoopControl, fc models.ConversationItem) ([]models.ConversationItem, error) {
+	logger := workflow.GetLogger(ctx)
+
+	req, err := parseAskUserArgs(fc.Arguments)
+	if err != nil {
+		logger.Warn("Invalid ask_user args", "error", err)
+		return []models.ConversationItem{askUserOutput(fc.CallID, fmt.Sprintf("Invalid ask_user arguments: %v", err), false)}, nil
+	}
+	req.CallID = fc.CallID
+
+	waitStart := workflow.Now(ctx)
+	resp, err := ctrl.AwaitAskUser(ctx, req)
+	s.recordWaitTime(workflow.Now(ctx).Sub(waitStart))
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		logger.Info("ask_user wait interrupted")
+		return []models.ConversationItem{askUserOutput(fc.CallID, "Question was interrupted before the user answered.", false)}, nil
+	}
+
+	answer := resolveAskUserAnswer(req, resp.Answer)
+	if answer == "" {
+		return []models.ConversationItem{askUserOutput(fc.CallID, "The user did not answer.", true)}, nil
+	}
+	return []models.ConversationItem{
+		askUserOutput(fc.CallID, answer, true),
+		{
+			Type:    models.ItemTypeUserAnswer,
+			CallID:  fc.CallID,
+			Content: answer,
+		},
+	}, nil
+}
+
+// askUserOutput builds the ask_user FunctionCallOutput. Name is set so the
+// TUI can leave the answer to the user_answer item instead of echoing it.
+func askUserOutput(callID, content string, success bool) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Name:   "ask_user",
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &success,
+		},
+	}
+}
+
+// resolveAskUserAnswer trims the answer and falls back to the question's
+// default when it is empty.
+func resolveAskUserAnswer(req *PendingAskUserRequest, answer string) string {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return strings.TrimSpace(req.Default)
+	}
+	return answer
+}
+
+// parseAskUserArgs validates and parses the ask_user arguments.
+func parseAskUserArgs(argsJSON string) (*PendingAskUserRequest, error) {
+	var args struct {
+		Question string `json:"question"`
+		Body     string `json:"body,omitempty"`
+		Default  string `json:"default,omitempty"`
+	}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if strings.TrimSpace(args.Question) == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+	return &PendingAskUserRequest{
+		Question: strings.TrimSpace(args.Question),
+		Body:     strings.TrimSpace(args.Body),
+		Default:  args.Default,
+	}, nil
+}
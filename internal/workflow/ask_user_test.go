@@ -0,0 +1,221 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestParseAskUserArgs(t *testing.T) {
+	req, err := parseAskUserArgs(`{"question": " Branch name? ", "body": "Use **kebab-case**.", "default": "main"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Branch name?", req.Question)
+	assert.Equal(t, "Use **kebab-case**.", req.Body)
+	assert.Equal(t, "main", req.Default)
+
+	_, err = parseAskUserArgs(`{"body": "no question"}`)
+	assert.ErrorContains(t, err, "question is required")
+
+	_, err = parseAskUserArgs(`{invalid`)
+	assert.ErrorContains(t, err, "invalid JSON")
+}
+
+func TestResolveAskUserAnswer(t *testing.T) {
+	req := &PendingAskUserRequest{Default: "main"}
+	assert.Equal(t, "feature-x", resolveAskUserAnswer(req, "  feature-x\n"))
+	assert.Equal(t, "main", resolveAskUserAnswer(req, "   "))
+	assert.Equal(t, "", resolveAskUserAnswer(&PendingAskUserRequest{}, ""))
+}
+
+// mockLLMAskUserResponse returns a response with an ask_user tool call.
+func mockLLMAskUserResponse(callID, argsJSON string, tokens int) activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{
+			{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    callID,
+				Name:      "ask_user",
+				Arguments: argsJSON,
+			},
+		},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: tokens},
+	}
+}
+
+// TestAskUser_HappyPath verifies the workflow waits for the answer, exposes
+// the question in TurnStatus, and records both the call output and a
+// user_answer item.
+func (s *AgenticWorkflowTestSuite) TestAskUser_HappyPath() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMAskUserResponse("call-ask", `{"question": "Commit message?", "body": "Staged: *auth.go*"}`, 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Committed.", 20), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		assert.Equal(s.T(), PhaseUserInputPending, status.Phase)
+		require.NotNil(s.T(), status.PendingAskUser)
+		assert.Equal(s.T(), "call-ask", status.PendingAskUser.CallID)
+		assert.Equal(s.T(), "Commit message?", status.PendingAskUser.Question)
+		assert.Equal(s.T(), "Staged: *auth.go*", status.PendingAskUser.Body)
+		assert.Nil(s.T(), status.PendingUserInputRequest)
+	}, time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAskUserResponse, "ask-1", noopCallback(),
+			AskUserResponse{CallID: "call-ask", Answer: "Fix token refresh\n\nRetries on 401."})
+	}, time.Second*2)
+
+	s.sendShutdown(time.Second * 4)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Commit my changes"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	items := s.queryItems()
+	var output, answer *models.ConversationItem
+	for i := range items {
+		if items[i].CallID != "call-ask" {
+			continue
+		}
+		switch items[i].Type {
+		case models.ItemTypeFunctionCallOutput:
+			output = &items[i]
+		case models.ItemTypeUserAnswer:
+			answer = &items[i]
+		}
+	}
+	require.NotNil(s.T(), output)
+	assert.True(s.T(), *output.Output.Success)
+	assert.Equal(s.T(), "Fix token refresh\n\nRetries on 401.", output.Output.Content)
+	require.NotNil(s.T(), answer, "answer should be recorded as a user_answer item")
+	assert.Equal(s.T(), "Fix token refresh\n\nRetries on 401.", answer.Content)
+	assert.Greater(s.T(), answer.Seq, output.Seq)
+}
+
+// TestAskUser_EmptyAnswerUsesDefault verifies that an empty answer selects
+// the question's default.
+func (s *AgenticWorkflowTestSuite) TestAskUser_EmptyAnswerUsesDefault() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMAskUserResponse("call-ask", `{"question": "Branch?", "default": "main"}`, 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Using main.", 20), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAskUserResponse, "ask-1", noopCallback(), AskUserResponse{CallID: "call-ask"})
+	}, time.Second*2)
+
+	s.sendShutdown(time.Second * 4)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Push"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var found bool
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeUserAnswer {
+			found = true
+			assert.Equal(s.T(), "main", item.Content)
+		}
+	}
+	assert.True(s.T(), found)
+}
+
+// TestAskUser_InvalidArgs verifies a missing question is reported to the
+// model instead of waiting.
+func (s *AgenticWorkflowTestSuite) TestAskUser_InvalidArgs() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMAskUserResponse("call-bad", `{"body": "hm"}`, 20), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Sorry.", 10), nil).Once()
+
+	s.sendShutdown(time.Second * 3)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Help"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var found bool
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-bad" {
+			found = true
+			assert.False(s.T(), *item.Output.Success)
+			assert.Contains(s.T(), item.Output.Content, "Invalid ask_user arguments")
+		}
+	}
+	assert.True(s.T(), found)
+}
+
+// TestAskUser_InterruptDuring verifies that interrupting a pending question
+// ends the turn without recording an answer.
+func (s *AgenticWorkflowTestSuite) TestAskUser_InterruptDuring() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMAskUserResponse("call-ask", `{"question": "Name?"}`, 30), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateInterrupt, "interrupt-1", noopCallback(), InterruptRequest{})
+	}, time.Second*2)
+
+	s.sendShutdown(time.Second * 3)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Greet me"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	for _, item := range s.queryItems() {
+		assert.NotEqual(s.T(), models.ItemTypeUserAnswer, item.Type)
+	}
+}
+
+// TestAskUser_ValidatorRejectsStaleAnswer verifies answers are rejected when
+// no question is pending or the call ID does not match.
+func (s *AgenticWorkflowTestSuite) TestAskUser_ValidatorRejectsStaleAnswer() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMAskUserResponse("call-ask", `{"question": "Name?"}`, 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hi Ada.", 10), nil).Once()
+
+	var rejected []string
+	rejectCallback := func() *testsuite.TestUpdateCallback {
+		return &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("stale answer should not be accepted") },
+			OnReject:   func(err error) { rejected = append(rejected, err.Error()) },
+			OnComplete: func(interface{}, error) {},
+		}
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAskUserResponse, "ask-wrong", rejectCallback(),
+			AskUserResponse{CallID: "call-other", Answer: "Bob"})
+		s.env.UpdateWorkflow(UpdateAskUserResponse, "ask-1", noopCallback(),
+			AskUserResponse{CallID: "call-ask", Answer: "Ada"})
+	}, time.Second*2)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAskUserResponse, "ask-late", rejectCallback(),
+			AskUserResponse{CallID: "call-ask", Answer: "Ada"})
+	}, time.Second*3)
+
+	s.sendShutdown(time.Second * 4)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Greet me"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.Len(s.T(), rejected, 2)
+	assert.Contains(s.T(), rejected[0], "no longer pending")
+	assert.Contains(s.T(), rejected[1], "no ask_user question pending")
+}
+
+// queryItems returns the workflow's conversation history.
+func (s *AgenticWorkflowTestSuite) queryItems() []models.ConversationItem {
+	result, err := s.env.QueryWorkflow(QueryGetConversationItems)
+	require.NoError(s.T(), err)
+	var items []models.ConversationItem
+	require.NoError(s.T(), result.Get(&items))
+	return items
+}
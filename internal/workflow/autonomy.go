@@ -0,0 +1,341 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// autonomy.go implements time-boxed autonomous runs
+// (SessionConfiguration.Autonomy): after a user message the agent keeps
+// starting turns on its own, approving tool calls without prompting, until
+// the run's budget of minutes or turns is spent. It checks in with a
+// structured progress report every CheckinInterval, and a final check-in
+// ends the run; the workflow then waits for the user as usual.
+//
+// The minutes budget is enforced by a workflow timer that ends the current
+// turn at its next iteration.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// checkinFormatName names the check-in ResponseFormat.
+const checkinFormatName = "autonomy_checkin"
+
+// checkinPrompt asks the model for a check-in on the run so far.
+const checkinPrompt = "You are working autonomously. Write a check-in for the user on your work so far. " +
+	"status: \"working\" if more remains that you can do on your own, \"done\" if the task is complete, " +
+	"\"blocked\" if you need the user's input or a decision. summary: a few sentences on where things stand. " +
+	"completed: what you finished since the last check-in. next_steps: what you will do next. " +
+	"blockers: what stops you, if anything."
+
+// checkinFinalPrompt is appended to checkinPrompt when the budget is spent.
+const checkinFinalPrompt = " The autonomy budget is spent: after this check-in you stop and wait for the user."
+
+// checkinSchema is the JSON Schema of a check-in.
+var checkinSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"status": map[string]interface{}{
+			"type": "string",
+			"enum": []interface{}{string(models.CheckinWorking), string(models.CheckinDone), string(models.CheckinBlocked)},
+		},
+		"summary":    map[string]interface{}{"type": "string"},
+		"completed":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"next_steps": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"blockers":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required":             []interface{}{"status", "summary", "completed", "next_steps", "blockers"},
+	"additionalProperties": false,
+}
+
+// AutonomyRun tracks the autonomous run in progress. Persists across
+// ContinueAsNew, which resumes the run with a new turn.
+type AutonomyRun struct {
+	StartedAt       time.Time `json:"started_at"`
+	Turns           int       `json:"turns"` // Turns completed in this run
+	LastCheckinAt   time.Time `json:"last_checkin_at"`
+	LastCheckinTurn int       `json:"last_checkin_turn"`
+
+	// NextSteps are the next steps of the last check-in, passed to the
+	// model in the next continuation turn.
+	NextSteps []string `json:"next_steps,omitempty"`
+
+	// NextTurnID is the turn the workflow started to continue the run; any
+	// other turn was started by the user and begins a new run.
+	NextTurnID string `json:"next_turn_id,omitempty"`
+}
+
+// autonomyEnabled reports whether this session runs autonomously. Subagents
+// never do: their parent decides when they are finished.
+func (s *SessionState) autonomyEnabled() bool {
+	return s.Config.Autonomy.Enabled() && (s.AgentCtl == nil || s.AgentCtl.ParentDepth == 0)
+}
+
+// beginAutonomousTurn is called before each turn. A turn the user started
+// begins a new run; either way the budget timer is armed with what is left
+// of the run's minutes.
+func (s *SessionState) beginAutonomousTurn(ctx workflow.Context, ctrl *LoopControl) {
+	if !s.autonomyEnabled() {
+		return
+	}
+	now := workflow.Now(ctx)
+	if s.AutonomyRun == nil || s.AutonomyRun.NextTurnID != ctrl.CurrentTurnID() {
+		s.AutonomyRun = &AutonomyRun{StartedAt: now, LastCheckinAt: now}
+		workflow.GetLogger(ctx).Info("Autonomous run started", "turn_id", ctrl.CurrentTurnID())
+	}
+	if minutes := s.Config.Autonomy.Budget.Minutes; minutes > 0 {
+		deadline := s.AutonomyRun.StartedAt.Add(time.Duration(minutes) * time.Minute)
+		ctrl.StartAutonomyTimer(ctx, deadline.Sub(now))
+	}
+}
+
+// endAutonomousTurn is called when a turn ends, before its TurnComplete
+// marker. It writes a check-in when one is due and returns whether the run
+// continues; the caller then starts the next turn with
+// continueAutonomousRun. A run ends on a final check-in, or without one when
+// the user interrupted or sent a message.
+func (s *SessionState) endAutonomousTurn(ctx workflow.Context, ctrl *LoopControl) bool {
+	run := s.AutonomyRun
+	if run == nil {
+		return false
+	}
+	logger := workflow.GetLogger(ctx)
+	if ctrl.IsInterrupted() || ctrl.HasPendingWork() {
+		logger.Info("Autonomous run ended by the user", "turns", run.Turns+1)
+		s.endAutonomyRun(ctrl)
+		return false
+	}
+
+	run.Turns++
+	now := workflow.Now(ctx)
+	spent := ctrl.AutonomyExpired() || budgetSpent(s.Config.Autonomy.Budget, run, now)
+	if !spent && !checkinDue(s.Config.Autonomy.CheckinInterval, run, now) {
+		return true
+	}
+
+	checkin := s.writeCheckin(ctx, ctrl, run, now, spent)
+	switch {
+	case spent:
+		checkin.Final, checkin.EndReason = true, "budget"
+	case checkin.Status == models.CheckinDone:
+		checkin.Final, checkin.EndReason = true, "done"
+	case checkin.Status == models.CheckinBlocked:
+		checkin.Final, checkin.EndReason = true, "blocked"
+	}
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeAssistantMessage,
+		Content: formatCheckin(checkin),
+		TurnID:  ctrl.CurrentTurnID(),
+		Checkin: checkin,
+	})
+	ctrl.NotifyItemAdded()
+
+	if checkin.Final {
+		logger.Info("Autonomous run ended", "reason", checkin.EndReason, "turns", run.Turns)
+		s.endAutonomyRun(ctrl)
+		return false
+	}
+	run.LastCheckinAt, run.LastCheckinTurn = now, run.Turns
+	run.NextSteps = checkin.NextSteps
+	return true
+}
+
+// budgetSpent reports whether the run has used up budget.
+func budgetSpent(budget models.AutonomyLimit, run *AutonomyRun, now time.Time) bool {
+	if budget.Turns > 0 && run.Turns >= budget.Turns {
+		return true
+	}
+	return budget.Minutes > 0 && now.Sub(run.StartedAt) >= time.Duration(budget.Minutes)*time.Minute
+}
+
+// checkinDue reports whether interval has passed since the run's last
+// check-in. A zero interval checks in after every turn.
+func checkinDue(interval models.AutonomyLimit, run *AutonomyRun, now time.Time) bool {
+	if interval.IsZero() {
+		return true
+	}
+	if interval.Turns > 0 && run.Turns-run.LastCheckinTurn >= interval.Turns {
+		return true
+	}
+	return interval.Minutes > 0 && now.Sub(run.LastCheckinAt) >= time.Duration(interval.Minutes)*time.Minute
+}
+
+// endAutonomyRun clears the run and its budget timer.
+func (s *SessionState) endAutonomyRun(ctrl *LoopControl) {
+	s.AutonomyRun = nil
+	ctrl.StopAutonomyTimer()
+}
+
+// continueAutonomousRun starts the run's next turn with a user message
+// telling the model to carry on, listing the last check-in's next steps.
+func (s *SessionState) continueAutonomousRun(ctrl *LoopControl) {
+	run := s.AutonomyRun
+	turnID := s.nextTurnID()
+	run.NextTurnID = turnID
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:   models.ItemTypeTurnStarted,
+		TurnID: turnID,
+	})
+	ctrl.NotifyItemAdded()
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: formatContinuation(s.Config.Autonomy.Budget, run),
+		TurnID:  turnID,
+	})
+	ctrl.NotifyItemAdded()
+	run.NextSteps = nil
+	ctrl.SetPendingUserInput(turnID)
+}
+
+// resumeAutonomousRun continues a run interrupted by ContinueAsNew.
+func (s *SessionState) resumeAutonomousRun(ctrl *LoopControl) {
+	if s.AutonomyRun == nil || !s.autonomyEnabled() {
+		s.AutonomyRun = nil
+		return
+	}
+	s.continueAutonomousRun(ctrl)
+}
+
+// writeCheckin asks the model for a structured check-in. If that fails the
+// check-in reports the run as blocked, so it ends rather than running on
+// unobserved.
+func (s *SessionState) writeCheckin(ctx workflow.Context, ctrl *LoopControl, run *AutonomyRun, now time.Time, final bool) *models.Checkin {
+	logger := workflow.GetLogger(ctx)
+	checkin, err := s.requestCheckin(ctx, ctrl, final)
+	if err != nil {
+		logger.Warn("Check-in failed, ending autonomous run", "error", err)
+		checkin = &models.Checkin{
+			Status:  models.CheckinBlocked,
+			Summary: fmt.Sprintf("The check-in could not be written (%v).", err),
+		}
+	}
+	checkin.Turns = run.Turns
+	checkin.Minutes = int(now.Sub(run.StartedAt) / time.Minute)
+	return checkin
+}
+
+// requestCheckin runs the check-in LLM call with the check-in ResponseFormat.
+func (s *SessionState) requestCheckin(ctx workflow.Context, ctrl *LoopControl, final bool) (*models.Checkin, error) {
+	historyItems, err := s.History.GetForPrompt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	prompt := checkinPrompt
+	if final {
+		prompt += checkinFinalPrompt
+	}
+	historyItems = append(historyItems, models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: prompt,
+	})
+
+	ctrl.SetPhase(PhaseCheckingIn)
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 90 * time.Second,
+		RetryPolicy:         withRetryOverride(defaultLLMRetryPolicy(), s.Config.RetryPolicies.LLM),
+	})
+	var out activities.LLMActivityOutput
+	start := workflow.Now(ctx)
+	err = workflow.ExecuteActivity(actCtx, "ExecuteLLMCall", activities.LLMActivityInput{
+		History:               historyItems,
+		ModelConfig:           s.Config.Model,
+		BaseInstructions:      s.Config.BaseInstructions,
+		DeveloperInstructions: s.Config.DeveloperInstructions,
+		UserInstructions:      s.Config.UserInstructions,
+		ResponseFormat: &models.ResponseFormat{
+			Name:        checkinFormatName,
+			Description: "A progress report on autonomous work, for the user.",
+			Schema:      checkinSchema,
+		},
+		TurnID: ctrl.CurrentTurnID(),
+	}).Get(ctx, &out)
+	s.recordLLMTime(workflow.Now(ctx).Sub(start))
+	if err != nil {
+		return nil, err
+	}
+	s.addTokenUsage(s.Config.Model, out.TokenUsage)
+	return parseCheckin(extractFinalMessage(out.Items))
+}
+
+// parseCheckin decodes a check-in. An unknown status is treated as blocked.
+func parseCheckin(content string) (*models.Checkin, error) {
+	var c models.Checkin
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &c); err != nil {
+		return nil, fmt.Errorf("invalid check-in JSON: %w", err)
+	}
+	switch c.Status {
+	case models.CheckinWorking, models.CheckinDone, models.CheckinBlocked:
+	default:
+		c.Status = models.CheckinBlocked
+	}
+	return &c, nil
+}
+
+// formatCheckin renders a check-in as the assistant message shown to the
+// user and kept in history for the model.
+func formatCheckin(c *models.Checkin) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Check-in** (%s · %s, %d min)\n\n", c.Status, pluralTurns(c.Turns), c.Minutes)
+	b.WriteString(strings.TrimSpace(c.Summary))
+	b.WriteString("\n")
+	for _, section := range []struct {
+		title string
+		items []string
+	}{{"Completed", c.Completed}, {"Next steps", c.NextSteps}, {"Blockers", c.Blockers}} {
+		if len(section.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", section.title)
+		for _, item := range section.items {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	if c.Final {
+		switch c.EndReason {
+		case "budget":
+			b.WriteString("\nAutonomy budget spent; waiting for you.\n")
+		case "done":
+			b.WriteString("\nTask done; waiting for you.\n")
+		default:
+			b.WriteString("\nBlocked; waiting for you.\n")
+		}
+	}
+	return b.String()
+}
+
+// formatContinuation builds the user message that starts a continuation
+// turn: how much of the budget is used and the next steps, if known.
+func formatContinuation(budget models.AutonomyLimit, run *AutonomyRun) string {
+	var b strings.Builder
+	b.WriteString("<autonomy>\nContinue working on the task without waiting for the user.")
+	if budget.Turns > 0 {
+		fmt.Fprintf(&b, " %d of %d autonomous turns used.", run.Turns, budget.Turns)
+	}
+	if budget.Minutes > 0 {
+		fmt.Fprintf(&b, " Time budget: %d minutes.", budget.Minutes)
+	}
+	if len(run.NextSteps) > 0 {
+		b.WriteString("\nNext steps from your check-in:\n")
+		for _, step := range run.NextSteps {
+			fmt.Fprintf(&b, "- %s\n", step)
+		}
+	} else {
+		b.WriteString("\n")
+	}
+	b.WriteString("If the task is complete or you need the user, say so and end your turn.\n</autonomy>")
+	return b.String()
+}
+
+func pluralTurns(n int) string {
+	if n == 1 {
+		return "1 turn"
+	}
+	return fmt.Sprintf("%d turns", n)
+}
@@ -0,0 +1,262 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestBudgetSpent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	run := &AutonomyRun{StartedAt: start, Turns: 2}
+
+	assert.True(t, budgetSpent(models.AutonomyLimit{Turns: 2}, run, start))
+	assert.False(t, budgetSpent(models.AutonomyLimit{Turns: 3}, run, start))
+	assert.False(t, budgetSpent(models.AutonomyLimit{Minutes: 10}, run, start.Add(9*time.Minute)))
+	assert.True(t, budgetSpent(models.AutonomyLimit{Minutes: 10, Turns: 5}, run, start.Add(10*time.Minute)))
+}
+
+func TestCheckinDue(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	run := &AutonomyRun{StartedAt: start, LastCheckinAt: start, Turns: 3, LastCheckinTurn: 1}
+
+	assert.True(t, checkinDue(models.AutonomyLimit{}, run, start), "zero interval checks in every turn")
+	assert.True(t, checkinDue(models.AutonomyLimit{Turns: 2}, run, start))
+	assert.False(t, checkinDue(models.AutonomyLimit{Turns: 3}, run, start))
+	assert.False(t, checkinDue(models.AutonomyLimit{Minutes: 5}, run, start.Add(4*time.Minute)))
+	assert.True(t, checkinDue(models.AutonomyLimit{Minutes: 5}, run, start.Add(5*time.Minute)))
+}
+
+func TestParseCheckin(t *testing.T) {
+	c, err := parseCheckin(`{"status":"done","summary":"All tests pass.","completed":["fixed parser"],"next_steps":[],"blockers":[]}`)
+	require.NoError(t, err)
+	assert.Equal(t, models.CheckinDone, c.Status)
+	assert.Equal(t, []string{"fixed parser"}, c.Completed)
+
+	c, err = parseCheckin(`{"status":"paused","summary":"?"}`)
+	require.NoError(t, err)
+	assert.Equal(t, models.CheckinBlocked, c.Status, "unknown status ends the run")
+
+	_, err = parseCheckin("not json")
+	assert.Error(t, err)
+}
+
+func TestFormatCheckin(t *testing.T) {
+	out := formatCheckin(&models.Checkin{
+		Status:    models.CheckinWorking,
+		Summary:   "Parser rewritten; two tests still fail.",
+		Completed: []string{"rewrote tokenizer"},
+		NextSteps: []string{"fix TestQuoted"},
+		Turns:     3,
+		Minutes:   12,
+		Final:     true,
+		EndReason: "budget",
+	})
+	assert.Contains(t, out, "**Check-in** (working · 3 turns, 12 min)")
+	assert.Contains(t, out, "Completed:\n- rewrote tokenizer")
+	assert.Contains(t, out, "Next steps:\n- fix TestQuoted")
+	assert.NotContains(t, out, "Blockers:")
+	assert.Contains(t, out, "Autonomy budget spent; waiting for you.")
+}
+
+// isCheckinCall matches the check-in LLM call.
+func isCheckinCall(in activities.LLMActivityInput) bool {
+	return in.ResponseFormat != nil && in.ResponseFormat.Name == checkinFormatName
+}
+
+// isTurnLLMCall matches regular turn LLM calls.
+func isTurnLLMCall(in activities.LLMActivityInput) bool {
+	return in.ResponseFormat == nil
+}
+
+// checkinResponse returns a check-in LLM response with the given status.
+func checkinResponse(status models.CheckinStatus, nextStep string) activities.LLMActivityOutput {
+	return mockLLMStopResponse(`{"status":"`+string(status)+`","summary":"Progress.","completed":[],"next_steps":["`+nextStep+`"],"blockers":[]}`, 10)
+}
+
+// autonomyInput returns a test input with a turn budget.
+func autonomyInput(turns int) WorkflowInput {
+	input := testInput("Refactor the parser")
+	input.Config.Autonomy = models.Autonomy{Budget: models.AutonomyLimit{Turns: turns}}
+	return input
+}
+
+// checkins returns the check-ins recorded in items.
+func checkins(items []models.ConversationItem) []*models.Checkin {
+	var out []*models.Checkin
+	for _, item := range items {
+		if item.Checkin != nil {
+			out = append(out, item.Checkin)
+		}
+	}
+	return out
+}
+
+// TestAutonomy_RunsUntilTurnBudget verifies the agent continues on its own,
+// checking in after each turn, and stops with a final check-in once the
+// turn budget is spent.
+func (s *AgenticWorkflowTestSuite) TestAutonomy_RunsUntilTurnBudget() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(mockLLMStopResponse("Made progress.", 20), nil).Twice()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isCheckinCall)).
+		Return(checkinResponse(models.CheckinWorking, "split the lexer"), nil).Twice()
+
+	s.sendShutdown(time.Second * 5)
+	s.env.ExecuteWorkflow(AgenticWorkflow, autonomyInput(2))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	items := s.queryItems()
+	got := checkins(items)
+	require.Len(s.T(), got, 2)
+	assert.False(s.T(), got[0].Final)
+	assert.Equal(s.T(), 1, got[0].Turns)
+	assert.True(s.T(), got[1].Final)
+	assert.Equal(s.T(), "budget", got[1].EndReason)
+	assert.Equal(s.T(), 2, got[1].Turns)
+
+	var continuation *models.ConversationItem
+	for i := range items {
+		if items[i].Type == models.ItemTypeUserMessage && strings.HasPrefix(items[i].Content, "<autonomy>") {
+			continuation = &items[i]
+		}
+	}
+	require.NotNil(s.T(), continuation, "the run should continue with a synthetic user message")
+	assert.Contains(s.T(), continuation.Content, "1 of 2 autonomous turns used")
+	assert.Contains(s.T(), continuation.Content, "- split the lexer")
+}
+
+// TestAutonomy_DoneCheckinEndsRun verifies a check-in reporting the task
+// done ends the run before the budget is spent.
+func (s *AgenticWorkflowTestSuite) TestAutonomy_DoneCheckinEndsRun() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(mockLLMStopResponse("Finished.", 20), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isCheckinCall)).
+		Return(checkinResponse(models.CheckinDone, ""), nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+	s.env.ExecuteWorkflow(AgenticWorkflow, autonomyInput(10))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	got := checkins(s.queryItems())
+	require.Len(s.T(), got, 1)
+	assert.True(s.T(), got[0].Final)
+	assert.Equal(s.T(), "done", got[0].EndReason)
+}
+
+// TestAutonomy_CheckinInterval verifies that turns between check-ins
+// continue without a check-in call.
+func (s *AgenticWorkflowTestSuite) TestAutonomy_CheckinInterval() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(mockLLMStopResponse("Made progress.", 20), nil).Times(3)
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isCheckinCall)).
+		Return(checkinResponse(models.CheckinWorking, "keep going"), nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+	input := autonomyInput(3)
+	input.Config.Autonomy.CheckinInterval = models.AutonomyLimit{Turns: 5}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	got := checkins(s.queryItems())
+	require.Len(s.T(), got, 1, "only the final check-in is written")
+	assert.Equal(s.T(), "budget", got[0].EndReason)
+}
+
+// TestAutonomy_ApprovesWithoutPrompting verifies that with
+// autonomy.approval = never, tool calls needing approval run without
+// waiting during an autonomous run.
+func (s *AgenticWorkflowTestSuite) TestAutonomy_ApprovesWithoutPrompting() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-write",
+				Name:      "write_file",
+				Arguments: `{"path": "/tmp/out.txt", "content": "hi"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   models.TokenUsage{TotalTokens: 30},
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(mockLLMStopResponse("Wrote it.", 20), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isCheckinCall)).
+		Return(checkinResponse(models.CheckinWorking, ""), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-write", Content: "ok", Success: &trueVal}, nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+	input := autonomyInput(1)
+	input.Config.Permissions.ApprovalMode = models.ApprovalUnlessTrusted
+	input.Config.Autonomy.Approval = models.ApprovalNever
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "write_file")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.assertCallOutput("call-write", "ok")
+}
+
+// TestAutonomy_UnlessTrustedStillPrompts verifies that without
+// autonomy.approval an autonomous run keeps the session's approval mode:
+// in unless-trusted mode a mutating call waits for the user.
+func (s *AgenticWorkflowTestSuite) TestAutonomy_UnlessTrustedStillPrompts() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-write",
+				Name:      "write_file",
+				Arguments: `{"path": "/tmp/out.txt", "content": "hi"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   models.TokenUsage{TotalTokens: 30},
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isTurnLLMCall)).
+		Return(mockLLMStopResponse("Wrote it.", 20), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(isCheckinCall)).
+		Return(checkinResponse(models.CheckinWorking, ""), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-write", Content: "ok", Success: &trueVal}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		assert.Equal(s.T(), PhaseApprovalPending, status.Phase)
+		require.Len(s.T(), status.PendingApprovals, 1)
+		assert.Equal(s.T(), "call-write", status.PendingApprovals[0].CallID)
+
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-write"}})
+	}, time.Second*2)
+
+	s.sendShutdown(time.Second * 5)
+	input := autonomyInput(1)
+	input.Config.Permissions.ApprovalMode = models.ApprovalUnlessTrusted
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "write_file")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.assertCallOutput("call-write", "ok")
+}
+
+// TestAutonomy_InvalidConfigFailsAtStart verifies a bad autonomy config
+// fails the workflow up front.
+func (s *AgenticWorkflowTestSuite) TestAutonomy_InvalidConfigFailsAtStart() {
+	input := testInput("hi")
+	input.Config.Autonomy = models.Autonomy{CheckinInterval: models.AutonomyLimit{Turns: 2}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid autonomy config")
+}
@@ -0,0 +1,52 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// cache.go tracks provider prompt-cache effectiveness. All providers report
+// cached input tokens, but they count prompt tokens differently: OpenAI's
+// input_tokens (and Gemini's prompt token count) already includes the cached
+// portion, while Anthropic's input_tokens, also on Bedrock, excludes cache
+// reads and cache writes. Normalizing here lets
+// TurnStatus expose a single hit rate regardless of provider.
+//
+// The LLM activity also reports a hash of the prompt assembly (model,
+// instructions and tool specs) sent with each call. A change between calls
+// explains a drop in the hit rate: the cached prefix no longer matched.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import "github.com/mfateev/temporal-agent-harness/internal/models"
+
+// inputTokens returns the total prompt tokens a call consumed, including any
+// served from or written to the provider's prompt cache.
+func inputTokens(provider string, usage models.TokenUsage) int {
+	if provider == "anthropic" || provider == "bedrock" {
+		return usage.PromptTokens + usage.CachedTokens + usage.CacheCreationTokens
+	}
+	return usage.PromptTokens
+}
+
+// recordCacheUsage accumulates prompt tokens for the session cache hit rate.
+func (s *SessionState) recordCacheUsage(usage models.TokenUsage) {
+	s.TotalInputTokens += inputTokens(s.Config.Model.Provider, usage)
+}
+
+// cacheHitRate returns the percentage of prompt tokens served from the
+// provider's cache across the session, or 0 when nothing has been sent.
+func (s *SessionState) cacheHitRate() int {
+	if s.TotalInputTokens <= 0 {
+		return 0
+	}
+	return min(s.TotalCachedTokens*100/s.TotalInputTokens, 100)
+}
+
+// recordPromptHash notes the prompt assembly of a main-loop LLM call,
+// counting a change from the previous call.
+func (s *SessionState) recordPromptHash(hash string) {
+	if hash == "" {
+		return
+	}
+	if s.PromptHash != "" && s.PromptHash != hash {
+		s.PromptHashChanges++
+	}
+	s.PromptHash = hash
+}
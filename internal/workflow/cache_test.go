@@ -0,0 +1,100 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for prompt-cache hit rate accounting
+// ---------------------------------------------------------------------------
+
+func TestInputTokens_OpenAIIncludesCached(t *testing.T) {
+	usage := models.TokenUsage{PromptTokens: 4000, CachedTokens: 3000}
+	assert.Equal(t, 4000, inputTokens("openai", usage))
+	assert.Equal(t, 4000, inputTokens("", usage))
+}
+
+func TestInputTokens_AnthropicAddsCacheReadsAndWrites(t *testing.T) {
+	usage := models.TokenUsage{PromptTokens: 50, CachedTokens: 3000, CacheCreationTokens: 950}
+	assert.Equal(t, 4000, inputTokens("anthropic", usage))
+	assert.Equal(t, 4000, inputTokens("bedrock", usage))
+}
+
+func TestCacheHitRate(t *testing.T) {
+	s := &SessionState{}
+	assert.Equal(t, 0, s.cacheHitRate(), "no prompt tokens yet")
+
+	s.recordCacheUsage(models.TokenUsage{PromptTokens: 2000})
+	s.recordCacheUsage(models.TokenUsage{PromptTokens: 2000, CachedTokens: 1536})
+	s.TotalCachedTokens = 1536
+	assert.Equal(t, 4000, s.TotalInputTokens)
+	assert.Equal(t, 38, s.cacheHitRate())
+}
+
+func TestCacheHitRate_ClampedForPreexistingCachedTokens(t *testing.T) {
+	// Sessions continued from state recorded before TotalInputTokens existed
+	// can carry more cached tokens than counted input tokens.
+	s := &SessionState{TotalCachedTokens: 10000, TotalInputTokens: 100}
+	assert.Equal(t, 100, s.cacheHitRate())
+}
+
+func TestRecordPromptHash(t *testing.T) {
+	s := &SessionState{}
+	s.recordPromptHash("aaaa")
+	s.recordPromptHash("aaaa")
+	s.recordPromptHash("")
+	assert.Equal(t, 0, s.PromptHashChanges, "the first call and an unchanged assembly are not misses")
+	s.recordPromptHash("bbbb")
+	assert.Equal(t, "bbbb", s.PromptHash)
+	assert.Equal(t, 1, s.PromptHashChanges)
+}
+
+// TestMultiTurn_CacheHitRate verifies that cached prompt tokens from the LLM
+// activity are reflected as a session hit rate in TurnStatus, along with the
+// prompt assembly hash.
+func (s *AgenticWorkflowTestSuite) TestMultiTurn_CacheHitRate() {
+	first := mockLLMStopResponse("first", 2100)
+	first.TokenUsage.PromptTokens = 2000
+	first.PromptHash = "0123456789abcdef"
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(first, nil).Once()
+
+	second := mockLLMStopResponse("second", 2100)
+	second.TokenUsage.PromptTokens = 2000
+	second.TokenUsage.CachedTokens = 1920
+	second.PromptHash = "fedcba9876543210"
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(second, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(),
+			UserInput{Content: "again"})
+	}, 5*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		assert.Equal(s.T(), 1920, status.TotalCachedTokens)
+		assert.Equal(s.T(), 48, status.CacheHitRate)
+		assert.Equal(s.T(), "fedcba9876543210", status.PromptHash)
+		assert.Equal(s.T(), 1, status.PromptHashChanges)
+	}, 10*time.Second)
+
+	s.sendShutdown(11 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), 1920, result.TotalCachedTokens)
+}
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestCancelTool_CancelsOneCallAndTurnContinues verifies that cancel_tool
+// cancels only the named call, records a cancelled output for it, and lets
+// the turn continue with the other call's result.
+func (s *AgenticWorkflowTestSuite) TestCancelTool_CancelsOneCallAndTurnContinues() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-slow", Name: "shell_command", Arguments: `{"command": "sleep 600"}`},
+				{Type: models.ItemTypeFunctionCall, CallID: "call-fast", Name: "read_file", Arguments: `{"file_path": "a.txt"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Skipped the slow command.", 10), nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.CallID == "call-slow"
+	})).After(time.Hour).Return(activities.ToolActivityOutput{CallID: "call-slow", Content: "done", Success: &trueVal}, nil)
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.CallID == "call-fast"
+	})).Return(activities.ToolActivityOutput{CallID: "call-fast", Content: "file contents", Success: &trueVal}, nil).Once()
+
+	var resp CancelToolResponse
+	var unknownErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateCancelTool, "cancel-unknown", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("cancel of unknown call accepted") },
+			OnReject:   func(err error) { unknownErr = err },
+			OnComplete: func(interface{}, error) {},
+		}, CancelToolRequest{CallID: "call-nope"})
+		s.env.UpdateWorkflow(UpdateCancelTool, "cancel-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("cancel_tool rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(CancelToolResponse)
+			},
+		}, CancelToolRequest{CallID: "call-slow"})
+	}, 10*time.Second)
+
+	s.sendShutdown(time.Minute)
+
+	input := testInput("run things")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command", "read_file")
+	input.Config.Permissions.ApprovalMode = models.ApprovalNever
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Error(s.T(), unknownErr)
+	assert.Contains(s.T(), unknownErr.Error(), "no in-flight tool call")
+	assert.Equal(s.T(), "shell_command", resp.ToolName)
+
+	outputs := map[string]string{}
+	var assistant string
+	for _, item := range s.queryItems() {
+		switch item.Type {
+		case models.ItemTypeFunctionCallOutput:
+			outputs[item.CallID] = item.Output.Content
+		case models.ItemTypeAssistantMessage:
+			assistant = item.Content
+		}
+	}
+	assert.Contains(s.T(), outputs["call-slow"], "cancelled")
+	assert.Equal(s.T(), "file contents", outputs["call-fast"])
+	assert.Equal(s.T(), "Skipped the slow command.", assistant)
+}
@@ -0,0 +1,163 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// capabilities.go resolves what the worker serving a session supports (the
+// DescribeWorker activity, run at session start) and answers the
+// get_capabilities query with it, including the session's tools that the
+// worker has no handler for. On a worker that runs its tools over SSH those
+// tools are removed from the session instead.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// WorkerCapabilitiesInfo is the worker's DescribeWorker report and when it
+// was taken.
+type WorkerCapabilitiesInfo struct {
+	Worker     activities.WorkerCapabilities `json:"worker"`
+	ResolvedAt time.Time                     `json:"resolved_at"`
+}
+
+// CapabilitiesResponse is the result of the get_capabilities query.
+type CapabilitiesResponse struct {
+	WorkerCapabilitiesInfo
+
+	// MissingTools are tools enabled for the session that the worker has no
+	// handler for; calls to them fail.
+	MissingTools []string `json:"missing_tools,omitempty"`
+
+	// ProjectPolicy is what the AGENTS.md front-matter of the session's
+	// directory changed in its config, and what it was not allowed to.
+	ProjectPolicy *models.ProjectPolicy `json:"project_policy,omitempty"`
+}
+
+// describeWorker asks the worker serving the session what it supports.
+// Called at session start, after MCP servers are connected. Non-fatal: an
+// older worker without the activity leaves Capabilities unset.
+func (s *SessionState) describeWorker(ctx workflow.Context) {
+	actOpts := workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		actOpts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	actCtx := workflow.WithActivityOptions(ctx, actOpts)
+
+	var caps activities.WorkerCapabilities
+	err := workflow.ExecuteActivity(actCtx, "DescribeWorker", activities.DescribeWorkerInput{
+		SessionID: s.ConversationID,
+	}).Get(ctx, &caps)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to describe worker", "error", err)
+		return
+	}
+	s.Capabilities = &WorkerCapabilitiesInfo{Worker: caps, ResolvedAt: workflow.Now(ctx)}
+	if caps.RemoteHost != "" {
+		if dropped := s.dropUnavailableTools(); len(dropped) > 0 {
+			workflow.GetLogger(ctx).Info("Removed tools the remote worker cannot run",
+				"remote_host", caps.RemoteHost, "tools", dropped)
+		}
+	}
+}
+
+// dropUnavailableTools removes the session's tools that the worker has no
+// handler for. A worker running its tools on a remote host only registers
+// the ones it can run there, so the model is not offered the rest. They
+// are removed from Config.Tools too, so subagents and continued runs
+// inherit the narrower set. It returns the removed tools.
+func (s *SessionState) dropUnavailableTools() []string {
+	missing := s.missingTools(s.Capabilities.Worker.Tools)
+	if len(missing) == 0 {
+		return nil
+	}
+	drop := make(map[string]bool, len(missing))
+	for _, name := range missing {
+		drop[name] = true
+	}
+	var specs []tools.ToolSpec
+	for _, spec := range s.ToolSpecs {
+		if !drop[spec.Name] {
+			specs = append(specs, spec)
+		}
+	}
+	s.ToolSpecs = specs
+
+	// Replace groups that include a dropped tool with their other members.
+	var enabled []string
+	for _, name := range s.Config.Tools.EnabledTools {
+		members := tools.ExpandGroups([]string{name})
+		if !slices.ContainsFunc(members, func(m string) bool { return drop[m] }) {
+			enabled = append(enabled, name)
+			continue
+		}
+		for _, m := range members {
+			if !drop[m] {
+				enabled = append(enabled, m)
+			}
+		}
+	}
+	s.Config.Tools.EnabledTools = enabled
+	return missing
+}
+
+// capabilitiesResponse builds the get_capabilities result.
+func (s *SessionState) capabilitiesResponse() (CapabilitiesResponse, error) {
+	if s.Capabilities == nil {
+		return CapabilitiesResponse{}, fmt.Errorf("worker capabilities are not known yet")
+	}
+	return CapabilitiesResponse{
+		WorkerCapabilitiesInfo: *s.Capabilities,
+		MissingTools:           s.missingTools(s.Capabilities.Worker.Tools),
+		ProjectPolicy:          s.Config.ProjectPolicy,
+	}, nil
+}
+
+// missingTools returns the session's tools that run as ExecuteTool
+// activities but have no handler among registered, sorted.
+func (s *SessionState) missingTools(registered []string) []string {
+	have := make(map[string]bool, len(registered))
+	for _, name := range registered {
+		have[name] = true
+	}
+	var missing []string
+	for _, spec := range s.ToolSpecs {
+		handler := spec.Name
+		if _, ok := s.McpToolLookup[spec.Name]; ok || strings.HasPrefix(spec.Name, "mcp__") {
+			handler = "mcp" // Routed like ExecuteTool does
+		}
+		if workflowHandledTool(spec.Name) || have[handler] {
+			continue
+		}
+		missing = append(missing, spec.Name)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// workflowHandledTool reports whether a tool is handled in the workflow
+// (dispatchInterceptedCalls, or runRollbackCalls for rollback_workspace)
+// and so needs no worker handler.
+func workflowHandledTool(name string) bool {
+	switch name {
+	case "request_user_input", "ask_user", "emit_result", "update_plan", "task_list",
+		"pin_context", "current_time", "share_artifact", "rollback_workspace":
+		return true
+	}
+	return isCollabToolCall(name)
+}
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestMissingTools(t *testing.T) {
+	s := &SessionState{
+		ToolSpecs: []tools.ToolSpec{
+			{Name: "shell_command"}, {Name: "read_file"}, {Name: "python_exec"},
+			{Name: "update_plan"}, {Name: "spawn_agent"}, {Name: "gh_get_issue"},
+			{Name: "mcp__docs__search"},
+		},
+		McpToolLookup: map[string]tools.McpToolRef{"mcp__docs__search": {ServerName: "docs", ToolName: "search"}},
+	}
+	assert.Equal(t, []string{"gh_get_issue", "python_exec"},
+		s.missingTools([]string{"mcp", "read_file", "shell_command"}))
+	assert.Equal(t, []string{"gh_get_issue", "mcp__docs__search", "python_exec", "read_file", "shell_command"},
+		s.missingTools(nil))
+}
+
+func TestDropUnavailableTools(t *testing.T) {
+	s := &SessionState{
+		ToolSpecs: []tools.ToolSpec{
+			{Name: "shell_command"}, {Name: "apply_patch"}, {Name: "grep_files"},
+			{Name: "update_plan"}, {Name: "browser_navigate"}, {Name: "browser_click"},
+		},
+		Capabilities: &WorkerCapabilitiesInfo{Worker: activities.WorkerCapabilities{
+			RemoteHost: "build:22",
+			Tools:      []string{"shell_command", "browser_navigate"},
+		}},
+	}
+	s.Config.Tools.EnabledTools = []string{"shell_command", "apply_patch", "grep_files", "update_plan", "browser"}
+
+	assert.Equal(t, []string{"apply_patch", "browser_click", "grep_files"}, s.dropUnavailableTools())
+	var names []string
+	for _, spec := range s.ToolSpecs {
+		names = append(names, spec.Name)
+	}
+	assert.Equal(t, []string{"shell_command", "update_plan", "browser_navigate"}, names)
+	assert.Contains(t, s.Config.Tools.EnabledTools, "browser_navigate", "a group with a dropped member is expanded")
+	assert.NotContains(t, s.Config.Tools.EnabledTools, "browser")
+	assert.NotContains(t, s.Config.Tools.EnabledTools, "browser_click")
+	assert.NotContains(t, s.Config.Tools.EnabledTools, "apply_patch")
+	assert.Contains(t, s.Config.Tools.EnabledTools, "update_plan")
+
+	assert.Empty(t, s.dropUnavailableTools(), "nothing left to drop")
+}
+
+// TestGetCapabilities_ReportsWorkerAndMissingTools verifies the worker is
+// described at session start and the query reports the session's tools the
+// worker lacks.
+func (s *AgenticWorkflowTestSuite) TestGetCapabilities_ReportsWorkerAndMissingTools() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("hi", 10), nil).Once()
+
+	var caps CapabilitiesResponse
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetCapabilities)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), result.Get(&caps))
+	}, time.Second)
+	s.sendShutdown(2 * time.Second)
+
+	input := testInput("hello")
+	input.Config.Tools.EnabledTools = []string{"request_user_input", "read_file", "shell_command"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), activities.WorkerCapabilities{Version: "test"}, caps.Worker)
+	assert.False(s.T(), caps.ResolvedAt.IsZero())
+	assert.Equal(s.T(), []string{"read_file", "shell_command"}, caps.MissingTools)
+}
@@ -0,0 +1,112 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// clock.go gives the model the current date and time: the environment
+// context carries it, refreshed at each turn start, and the current_time
+// intercepted tool reads the clock on demand.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	// Embedded so every worker resolves timezone names the same way, which
+	// keeps the clock text identical when a session replays elsewhere.
+	_ "time/tzdata"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// location returns the session's timezone: the worker's, or UTC when the
+// worker did not report one.
+func (s *SessionState) location() *time.Location {
+	if s.Config.WorkerTimezone != "" {
+		if loc, err := time.LoadLocation(s.Config.WorkerTimezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// localNow returns the workflow's deterministic current time in the
+// session's timezone.
+func (s *SessionState) localNow(ctx workflow.Context) time.Time {
+	return workflow.Now(ctx).In(s.location())
+}
+
+// maybeRefreshClock adds the current time at the start of a turn, unless
+// the history already shows this minute. Sessions without an environment
+// context (no cwd) get none.
+func (s *SessionState) maybeRefreshClock(ctx workflow.Context, ctrl *LoopControl) {
+	if s.Config.Cwd == "" {
+		return
+	}
+	now := s.localNow(ctx)
+	if current, _ := instructions.FormatClock(now); current == s.ClockShown {
+		return
+	}
+	s.noteClockShown(now)
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: instructions.BuildClockContext(now),
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+}
+
+// noteClockShown records the time an environment context just showed.
+func (s *SessionState) noteClockShown(now time.Time) {
+	s.ClockShown, _ = instructions.FormatClock(now)
+}
+
+// handleCurrentTime intercepts a current_time tool call. The wall clock is
+// read through a SideEffect, so the answer is exact even late in a long
+// workflow task and replays return the recorded time.
+func (s *SessionState) handleCurrentTime(ctx workflow.Context, fc models.ConversationItem) models.ConversationItem {
+	var args struct {
+		Timezone string `json:"timezone"`
+	}
+	if fc.Arguments != "" {
+		if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+			return currentTimeOutput(fc.CallID, fmt.Sprintf("Invalid current_time arguments: %v", err), false)
+		}
+	}
+	loc := s.location()
+	if name := strings.TrimSpace(args.Timezone); name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return currentTimeOutput(fc.CallID, fmt.Sprintf("current_time failed: unknown timezone %q", name), false)
+		}
+	}
+
+	var nanos int64
+	if err := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return time.Now().UnixNano()
+	}).Get(&nanos); err != nil {
+		return currentTimeOutput(fc.CallID, fmt.Sprintf("current_time failed: %v", err), false)
+	}
+	return currentTimeOutput(fc.CallID, formatCurrentTime(time.Unix(0, nanos).In(loc)), true)
+}
+
+// formatCurrentTime is the current_time tool's answer.
+func formatCurrentTime(now time.Time) string {
+	_, zone := instructions.FormatClock(now)
+	return fmt.Sprintf("%s\nTimezone: %s\nISO 8601: %s\nUnix: %d",
+		now.Format("Monday, 2 January 2006, 15:04:05"), zone, now.Format(time.RFC3339), now.Unix())
+}
+
+func currentTimeOutput(callID, content string, success bool) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &success,
+		},
+	}
+}
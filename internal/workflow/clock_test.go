@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// testStartTime is when the suite's workflows start: a Saturday afternoon
+// in Berlin.
+var testStartTime = time.Date(2026, 10, 17, 12, 3, 0, 0, time.UTC)
+
+func TestFormatCurrentTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Equal(t, "Saturday, 17 October 2026, 21:03:27\nTimezone: Asia/Tokyo (UTC+09:00)\n"+
+		"ISO 8601: 2026-10-17T21:03:27+09:00\nUnix: 1792238607",
+		formatCurrentTime(time.Date(2026, 10, 17, 12, 3, 27, 0, time.UTC).In(tokyo)))
+}
+
+func TestSessionLocation(t *testing.T) {
+	s := &SessionState{}
+	assert.Equal(t, time.UTC, s.location())
+	s.Config.WorkerTimezone = "Europe/Berlin"
+	assert.Equal(t, "Europe/Berlin", s.location().String())
+	s.Config.WorkerTimezone = "Mars/Olympus_Mons"
+	assert.Equal(t, time.UTC, s.location(), "unknown names fall back to UTC")
+}
+
+// TestClock_EnvironmentContextRefreshedPerTurn verifies that the environment
+// context shows the time in the worker's timezone and that a later turn
+// starts with the updated time.
+func (s *AgenticWorkflowTestSuite) TestClock_EnvironmentContextRefreshedPerTurn() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hi.", 10), nil).Times(3)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Same minute"})
+	}, 10*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-3", noopCallback(), UserInput{Content: "Two hours later"})
+	}, 2*time.Hour)
+	s.sendShutdown(2*time.Hour + time.Minute)
+
+	input := testInput("Hello")
+	input.Config.Cwd = "/repo"
+	input.Config.WorkerTimezone = "Europe/Berlin"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var contexts []string
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeUserMessage && strings.HasPrefix(item.Content, "<environment_context>") {
+			contexts = append(contexts, item.Content)
+		}
+	}
+	require.Len(s.T(), contexts, 2, "the second turn starts in the same minute and adds none")
+	assert.Contains(s.T(), contexts[0], "<cwd>/repo</cwd>")
+	assert.Contains(s.T(), contexts[0], "<current_time>2026-10-17 14:03 (Saturday)</current_time>")
+	assert.Contains(s.T(), contexts[0], "<timezone>Europe/Berlin (UTC+02:00)</timezone>")
+	assert.Equal(s.T(), "<environment_context>\n  <current_time>2026-10-17 16:03 (Saturday)</current_time>\n"+
+		"  <timezone>Europe/Berlin (UTC+02:00)</timezone>\n</environment_context>", contexts[1])
+}
+
+// TestClock_CurrentTimeTool verifies that current_time is answered in the
+// workflow, in the requested timezone, and that unknown timezones fail.
+func (s *AgenticWorkflowTestSuite) TestClock_CurrentTimeTool() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMCallResponse("call-1", "current_time", `{"timezone": "Asia/Tokyo"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMCallResponse("call-2", "current_time", `{"timezone": "Mars/Olympus_Mons"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("It is evening in Tokyo.", 10), nil).Once()
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("What time is it in Tokyo?")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "current_time")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	outputs := map[string]*models.FunctionCallOutputPayload{}
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeFunctionCallOutput {
+			outputs[item.CallID] = item.Output
+		}
+	}
+	require.Contains(s.T(), outputs, "call-1")
+	assert.True(s.T(), *outputs["call-1"].Success)
+	assert.Contains(s.T(), outputs["call-1"].Content, "Timezone: Asia/Tokyo (UTC+09:00)")
+	require.Contains(s.T(), outputs, "call-2")
+	assert.False(s.T(), *outputs["call-2"].Success)
+	assert.Equal(s.T(), `current_time failed: unknown timezone "Mars/Olympus_Mons"`, outputs["call-2"].Content)
+}
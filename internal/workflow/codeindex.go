@@ -0,0 +1,136 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// codeindex.go keeps the embeddings index behind the semantic_search tool
+// current. The first search of a session syncs the whole tree; after that,
+// files changed by write_file and apply_patch are reindexed before the next
+// search. The indexing itself is done by the IndexCode activity on the
+// session task queue.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+)
+
+// semanticSearchTool is the tool whose calls trigger indexing.
+const semanticSearchTool = "semantic_search"
+
+// maybeIndexBeforeTools brings the code index up to date if any of the calls
+// is a semantic search: a full sync the first time, then only the files
+// edited since. Failures are logged; the search then reports what it can.
+func (s *SessionState) maybeIndexBeforeTools(ctx workflow.Context, calls []models.ConversationItem) {
+	searching := false
+	for _, fc := range calls {
+		if fc.Name == semanticSearchTool {
+			searching = true
+			break
+		}
+	}
+	if !searching || (s.CodeIndexed && len(s.CodeIndexStale) == 0) {
+		return
+	}
+
+	input := activities.IndexCodeInput{
+		Cwd:      s.Config.Cwd,
+		Provider: s.Config.SemanticSearch.Provider,
+		Model:    s.Config.SemanticSearch.Model,
+		Full:     !s.CodeIndexed,
+	}
+	if s.CodeIndexed {
+		input.Paths = s.CodeIndexStale
+	}
+	var out activities.IndexCodeOutput
+	if err := workflow.ExecuteActivity(s.codeIndexActivityCtx(ctx), "IndexCode", input).Get(ctx, &out); err != nil {
+		workflow.GetLogger(ctx).Warn("Code indexing failed", "error", err)
+		return
+	}
+	workflow.GetLogger(ctx).Info("Code index updated",
+		"full", input.Full, "files", out.Stats.Files, "embedded", out.Stats.Embedded, "removed", out.Stats.Removed)
+	s.CodeIndexed = true
+	s.CodeIndexStale = nil
+}
+
+// trackIndexedEdits records the files changed by successful write_file and
+// apply_patch calls so the next search reindexes them. Nothing is tracked
+// when semantic_search is not enabled.
+func (s *SessionState) trackIndexedEdits(calls []models.ConversationItem, results []activities.ToolActivityOutput) {
+	if !s.Config.Tools.HasTool(semanticSearchTool) {
+		return
+	}
+	succeeded := make(map[string]bool, len(results))
+	for _, r := range results {
+		succeeded[r.CallID] = r.Success == nil || *r.Success
+	}
+	seen := make(map[string]bool, len(s.CodeIndexStale))
+	for _, p := range s.CodeIndexStale {
+		seen[p] = true
+	}
+	for _, fc := range calls {
+		if !succeeded[fc.CallID] {
+			continue
+		}
+		for _, p := range editedPaths(fc) {
+			p = remapPath(p, s.PathRemaps)
+			if !seen[p] {
+				seen[p] = true
+				s.CodeIndexStale = append(s.CodeIndexStale, p)
+			}
+		}
+	}
+}
+
+// editedPaths returns the files a write_file or apply_patch call changes,
+// as given in its arguments (absolute or relative to the cwd). A moved
+// file contributes both its old and new path.
+func editedPaths(fc models.ConversationItem) []string {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return nil
+	}
+	switch fc.Name {
+	case "write_file":
+		if p, ok := args["path"].(string); ok && p != "" {
+			return []string{p}
+		}
+	case "apply_patch":
+		input, _ := args["input"].(string)
+		parsed, err := patch.Parse(input)
+		if err != nil {
+			return nil
+		}
+		var paths []string
+		for _, h := range parsed.Hunks {
+			paths = append(paths, h.Path)
+			if h.MovePath != "" {
+				paths = append(paths, h.MovePath)
+			}
+		}
+		return paths
+	}
+	return nil
+}
+
+// codeIndexActivityCtx returns activity options for IndexCode, routed to
+// the session task queue where the workspace lives. A first full index of
+// a large repository with a remote provider can take minutes.
+func (s *SessionState) codeIndexActivityCtx(ctx workflow.Context) workflow.Context {
+	opts := workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		opts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	return workflow.WithActivityOptions(ctx, opts)
+}
@@ -0,0 +1,94 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestEditedPaths(t *testing.T) {
+	assert.Equal(t, []string{"a.go"}, editedPaths(models.ConversationItem{
+		Name: "write_file", Arguments: `{"path": "a.go", "content": "x"}`,
+	}))
+	assert.Equal(t, []string{"old.go", "new.go", "b.go"}, editedPaths(models.ConversationItem{
+		Name:      "apply_patch",
+		Arguments: `{"input": "*** Begin Patch\n*** Update File: old.go\n*** Move to: new.go\n@@\n-a\n+b\n*** Delete File: b.go\n*** End Patch"}`,
+	}))
+	assert.Nil(t, editedPaths(models.ConversationItem{Name: "apply_patch", Arguments: `{"input": "garbage"}`}))
+	assert.Nil(t, editedPaths(models.ConversationItem{Name: "shell_command", Arguments: `{"command": "rm a.go"}`}))
+}
+
+func TestTrackIndexedEdits(t *testing.T) {
+	trueVal, falseVal := true, false
+	s := &SessionState{Config: models.SessionConfiguration{
+		Tools: models.ToolsConfig{EnabledTools: []string{"semantic_search", "write_file"}},
+	}}
+	calls := []models.ConversationItem{
+		{CallID: "c1", Name: "write_file", Arguments: `{"path": "a.go"}`},
+		{CallID: "c2", Name: "write_file", Arguments: `{"path": "b.go"}`},
+		{CallID: "c3", Name: "write_file", Arguments: `{"path": "a.go"}`},
+	}
+	results := []activities.ToolActivityOutput{
+		{CallID: "c1", Success: &trueVal},
+		{CallID: "c2", Success: &falseVal},
+		{CallID: "c3", Success: &trueVal},
+	}
+	s.trackIndexedEdits(calls, results)
+	assert.Equal(t, []string{"a.go"}, s.CodeIndexStale, "failed calls are skipped and paths deduplicated")
+
+	s = &SessionState{}
+	s.trackIndexedEdits(calls, results)
+	assert.Empty(t, s.CodeIndexStale, "nothing is tracked without semantic_search")
+}
+
+// TestSemanticSearch_IndexesThenReindexesEdits verifies the first search
+// syncs the whole index, and a later search reindexes only the files
+// edited in between.
+func (s *AgenticWorkflowTestSuite) TestSemanticSearch_IndexesThenReindexesEdits() {
+	call := func(id, name, args string) activities.LLMActivityOutput {
+		return activities.LLMActivityOutput{
+			Items:        []models.ConversationItem{{Type: models.ItemTypeFunctionCall, CallID: id, Name: name, Arguments: args}},
+			FinishReason: models.FinishReasonToolCalls,
+		}
+	}
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(call("call-1", "semantic_search", `{"query": "token refresh"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(call("call-2", "semantic_search", `{"query": "token expiry"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(call("call-3", "write_file", `{"path": "auth/token.go", "content": "package auth"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(call("call-4", "semantic_search", `{"query": "token refresh"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			return activities.ToolActivityOutput{CallID: in.CallID, Content: "ok", Success: &trueVal}, nil
+		}).Times(4)
+
+	s.env.OnActivity("IndexCode", mock.Anything, mock.MatchedBy(func(in activities.IndexCodeInput) bool {
+		return in.Full && in.Cwd == "/repo" && in.Provider == "openai"
+	})).Return(activities.IndexCodeOutput{}, nil).Once()
+	s.env.OnActivity("IndexCode", mock.Anything, mock.MatchedBy(func(in activities.IndexCodeInput) bool {
+		return !in.Full && assert.ObjectsAreEqual([]string{"auth/token.go"}, in.Paths)
+	})).Return(activities.IndexCodeOutput{}, nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+	input := testInput("Find the token refresh code")
+	input.Config.Cwd = "/repo"
+	input.Config.SemanticSearch = models.SemanticSearch{Provider: "openai"}
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "semantic_search", "write_file")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.assertCallOutput("call-4", "ok")
+}
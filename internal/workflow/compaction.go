@@ -9,7 +9,6 @@ package workflow
 import (
 	"time"
 
-	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	"github.com/mfateev/temporal-agent-harness/internal/activities"
@@ -18,7 +17,8 @@ import (
 
 // performCompaction executes context compaction by calling the ExecuteCompact
 // activity. On success, replaces the conversation history with compacted items,
-// increments CompactionCount, and resets response chaining state.
+// increments CompactionCount and HistoryEpoch, and resets response chaining
+// state.
 //
 // Maps to: codex-rs/core/src/compact.rs perform_compaction
 func (s *SessionState) performCompaction(ctx workflow.Context, ctrl *LoopControl) error {
@@ -46,9 +46,19 @@ func (s *SessionState) performCompaction(ctx workflow.Context, ctrl *LoopControl
 		}
 	}
 
+	// Pinned items must come through compaction verbatim.
+	var pinnedItems []models.ConversationItem
+	for _, item := range filteredItems {
+		if item.Pinned {
+			pinnedItems = append(pinnedItems, item)
+		}
+	}
+
 	// Build compaction activity input
+	compactModel := s.compactionModelConfig()
 	compactInput := activities.CompactActivityInput{
-		Model:        s.Config.Model.Model,
+		Model:        compactModel.Model,
+		Provider:     compactModel.Provider,
 		Input:        filteredItems,
 		Instructions: s.Config.BaseInstructions,
 	}
@@ -56,15 +66,13 @@ func (s *SessionState) performCompaction(ctx workflow.Context, ctrl *LoopControl
 	// Configure activity options
 	actOpts := workflow.ActivityOptions{
 		StartToCloseTimeout: 3 * time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    30 * time.Second,
-			MaximumAttempts:    2,
-		},
+		RetryPolicy:         withRetryOverride(defaultCompactionRetryPolicy(), s.Config.RetryPolicies.Compaction),
 	}
 	compactCtx := workflow.WithActivityOptions(ctx, actOpts)
 
+	// Archive the history about to be replaced.
+	flushed := s.archiveTranscript(ctx, archiveReasonCompaction)
+
 	// Execute compaction activity
 	var compactResult activities.CompactActivityOutput
 	err = workflow.ExecuteActivity(compactCtx, "ExecuteCompact", compactInput).Get(ctx, &compactResult)
@@ -73,8 +81,8 @@ func (s *SessionState) performCompaction(ctx workflow.Context, ctrl *LoopControl
 		return err
 	}
 
-	// Replace history with compacted items
-	if err := s.History.ReplaceAll(compactResult.Items); err != nil {
+	// Replace history with compacted items, keeping pinned ones
+	if err := s.History.ReplaceAll(restorePinned(pinnedItems, compactResult.Items)); err != nil {
 		logger.Error("Failed to replace history after compaction", "error", err)
 		return err
 	}
@@ -88,14 +96,16 @@ func (s *SessionState) performCompaction(ctx workflow.Context, ctrl *LoopControl
 	}
 
 	// Update compaction tracking state
+	s.resetArchiveMark(flushed)
 	s.CompactionCount++
+	s.HistoryEpoch++
 	s.LastResponseID = ""
 	s.lastSentHistoryLen = 0
 	s.compactedThisTurn = true
 
 	// Track token usage from compaction
-	s.TotalTokens += compactResult.TokenUsage.TotalTokens
-	s.TotalCachedTokens += compactResult.TokenUsage.CachedTokens
+	s.addTokenUsage(compactModel, compactResult.TokenUsage)
+	s.recordCacheUsage(compactResult.TokenUsage)
 
 	logger.Info("Context compaction completed",
 		"compaction_count", s.CompactionCount,
@@ -104,3 +114,24 @@ func (s *SessionState) performCompaction(ctx workflow.Context, ctrl *LoopControl
 
 	return nil
 }
+
+// applyHistoryRetention elides tool outputs older than the configured
+// number of user turns. Unlike compaction it needs no LLM call, so it runs
+// before every LLM call and only changes history when a turn ages out.
+func (s *SessionState) applyHistoryRetention(ctx workflow.Context) {
+	keep := s.Config.HistoryRetention.ToolOutputTurns
+	if keep <= 0 {
+		return
+	}
+	elided, err := s.History.ElideToolOutputs(keep)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to elide old tool outputs", "error", err)
+		return
+	}
+	if elided == 0 {
+		return
+	}
+	workflow.GetLogger(ctx).Info("Elided old tool outputs", "count", elided, "keep_turns", keep)
+	// Earlier items changed, so the next call must send the full history.
+	s.lastSentHistoryLen = 0
+}
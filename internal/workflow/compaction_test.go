@@ -1,6 +1,8 @@
 package workflow
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 
 	"go.temporal.io/sdk/testsuite"
 
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
 	"github.com/mfateev/temporal-agent-harness/internal/history"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 )
@@ -200,5 +203,121 @@ func (s *AgenticWorkflowTestSuite) TestCompaction_NoModelSwitch_WorkflowLevel()
 	assert.Equal(s.T(), "shutdown", result.EndReason)
 }
 
+// TestConversationItemsSince_EpochAdvancesOnCompaction verifies that the
+// get_conversation_items_since query returns only newer items, and that
+// compaction moves the history epoch on so pollers know to reload.
+func (s *AgenticWorkflowTestSuite) TestConversationItemsSince_EpochAdvancesOnCompaction() {
+	s.newEnv()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 50), nil).Once()
+	s.env.OnActivity("ExecuteCompact", mock.Anything, mock.Anything).
+		Return(activities.CompactActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeAssistantMessage, Content: "Compacted summary"},
+			},
+		}, nil).Once()
+
+	querySince := func(sinceSeq int) ConversationItemsSinceResponse {
+		result, err := s.env.QueryWorkflow(QueryGetConversationItemsSince, sinceSeq)
+		require.NoError(s.T(), err)
+		var resp ConversationItemsSinceResponse
+		require.NoError(s.T(), result.Get(&resp))
+		return resp
+	}
+
+	var latestSeq int
+	s.env.RegisterDelayedCallback(func() {
+		all := querySince(-1)
+		require.NotEmpty(s.T(), all.Items)
+		assert.Equal(s.T(), 0, all.HistoryEpoch)
+		latestSeq = all.Items[len(all.Items)-1].Seq
+
+		newer := querySince(latestSeq - 1)
+		require.Len(s.T(), newer.Items, 1)
+		assert.Equal(s.T(), latestSeq, newer.Items[0].Seq)
+		assert.False(s.T(), newer.Compacted)
+		assert.Empty(s.T(), querySince(latestSeq).Items)
+	}, time.Second*2)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateCompact, "compact-1", noopCallback(), CompactRequest{})
+	}, time.Second*3)
+
+	s.env.RegisterDelayedCallback(func() {
+		resp := querySince(latestSeq)
+		assert.Equal(s.T(), 1, resp.HistoryEpoch)
+		assert.True(s.T(), resp.Compacted, "the old cursor is past the end of the compacted history")
+		require.NotEmpty(s.T(), resp.Items)
+		assert.Equal(s.T(), "Compacted summary", resp.Items[0].Content)
+	}, time.Second*5)
+
+	s.sendShutdown(time.Second * 6)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestHistoryRetention_ElidesOldToolOutputs verifies that tool outputs from
+// turns older than HistoryRetention.ToolOutputTurns reach the LLM as stubs
+// while the assistant's text is kept.
+func (s *AgenticWorkflowTestSuite) TestHistoryRetention_ElidesOldToolOutputs() {
+	longOutput := strings.Repeat("line of output\n", 50)
+	isSecondTurn := mock.MatchedBy(func(input activities.LLMActivityInput) bool {
+		for _, item := range input.History {
+			if item.Type == models.ItemTypeUserMessage && item.Content == "Next task" {
+				return true
+			}
+		}
+		return false
+	})
+
+	var secondTurnHistory []models.ConversationItem
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, isSecondTurn).
+		Return(func(_ context.Context, input activities.LLMActivityInput) (activities.LLMActivityOutput, error) {
+			secondTurnHistory = input.History
+			return mockLLMStopResponse("Done.", 10), nil
+		}).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-1",
+				Name:      "shell_command",
+				Arguments: `{"command": "cat big.log"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   models.TokenUsage{TotalTokens: 10},
+		}, nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: longOutput, Success: &trueVal}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("The log is long.", 10), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Next task"})
+	}, time.Second*2)
+	s.sendShutdown(time.Second * 4)
+
+	input := testInput("Read the log")
+	input.Config.HistoryRetention.ToolOutputTurns = 1
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.NotNil(s.T(), secondTurnHistory, "second turn LLM call should run")
+	var sawOutput, sawAssistant bool
+	for _, item := range secondTurnHistory {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-1" {
+			sawOutput = true
+			assert.Equal(s.T(), "[output elided, call_id=call-1]", item.Output.Content)
+		}
+		if item.Type == models.ItemTypeAssistantMessage && item.Content == "The log is long." {
+			sawAssistant = true
+		}
+	}
+	assert.True(s.T(), sawOutput, "the call's output stays in history as a stub")
+	assert.True(s.T(), sawAssistant, "assistant text is kept")
+}
+
 // Ensure we reference testsuite (suppress unused import warning)
 var _ testsuite.TestUpdateCallback
@@ -0,0 +1,252 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// consult.go implements /consult: the consult Update asks the session's
+// consult_models the same question in parallel, each with the conversation
+// so far and no tools, and returns their answers without touching history.
+// The adopt_consult Update then records the answer the user picked, either
+// as the assistant's answer or as context for the session's own model.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Consult bounds.
+const (
+	MinConsultModels = 2
+	MaxConsultModels = 3
+)
+
+// ConsultAdoptMode says how an adopted answer is recorded.
+type ConsultAdoptMode string
+
+const (
+	// ConsultAdoptAnswer records the answer as the assistant's reply.
+	ConsultAdoptAnswer ConsultAdoptMode = "answer"
+	// ConsultAdoptContext records the answer as developer context the
+	// session's model sees from the next turn.
+	ConsultAdoptContext ConsultAdoptMode = "context"
+)
+
+// ConsultRequest is the payload for the consult Update.
+type ConsultRequest struct {
+	// Question to ask; empty asks the last question the user sent.
+	Question string `json:"question,omitempty"`
+}
+
+// ConsultAnswer is one model's answer.
+type ConsultAnswer struct {
+	Model      string `json:"model"`
+	Provider   string `json:"provider"`
+	Content    string `json:"content,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Tokens     int    `json:"tokens,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ConsultResponse is returned by the consult Update.
+type ConsultResponse struct {
+	ConsultID string          `json:"consult_id"`
+	Question  string          `json:"question"`
+	Answers   []ConsultAnswer `json:"answers"` // In consult_models order
+	// NewQuestion is set when the question is not yet in history.
+	NewQuestion bool `json:"new_question,omitempty"`
+}
+
+// AdoptConsultRequest is the payload for the adopt_consult Update.
+type AdoptConsultRequest struct {
+	ConsultID string           `json:"consult_id"`
+	Index     int              `json:"index"` // Into ConsultResponse.Answers
+	Mode      ConsultAdoptMode `json:"mode"`
+}
+
+// AdoptConsultResponse is returned by the adopt_consult Update.
+type AdoptConsultResponse struct{}
+
+// consultModelConfigs returns the model configs of consult_models, with
+// providers inferred from the model names.
+func (s *SessionState) consultModelConfigs() []models.ModelConfig {
+	configs := make([]models.ModelConfig, len(s.Config.ConsultModels))
+	for i, m := range s.Config.ConsultModels {
+		configs[i] = models.RoutingRule{Model: m}.ModelConfig(s.Config.Model)
+	}
+	return configs
+}
+
+// validateConsult reports whether a consult can start now.
+func (s *SessionState) validateConsult(ctrl *LoopControl, req ConsultRequest) error {
+	switch n := len(s.Config.ConsultModels); {
+	case ctrl.IsShutdown():
+		return fmt.Errorf("session is shutting down")
+	case n < MinConsultModels || n > MaxConsultModels:
+		return fmt.Errorf("set %d-%d consult_models in config.toml to use /consult (have %d)",
+			MinConsultModels, MaxConsultModels, n)
+	case ctrl.Phase() != PhaseWaitingForInput || ctrl.HasPendingWork() || s.AutonomyRun != nil:
+		return fmt.Errorf("a turn is in progress; wait for it to finish or interrupt it")
+	case strings.TrimSpace(req.Question) == "" && s.lastUserQuestion() == "":
+		return fmt.Errorf("no question to consult on; pass one: /consult <question>")
+	}
+	return nil
+}
+
+// consult asks every consult model the question in parallel. A model that
+// fails gets an Error instead of failing the Update. The response is kept
+// so adopt_consult can refer to it.
+func (s *SessionState) consult(ctx workflow.Context, ctrl *LoopControl, req ConsultRequest) (ConsultResponse, error) {
+	historyItems, err := s.History.GetForPrompt()
+	if err != nil {
+		return ConsultResponse{}, fmt.Errorf("failed to get history: %w", err)
+	}
+	resp := ConsultResponse{
+		ConsultID:   fmt.Sprintf("consult-%d", workflow.Now(ctx).UnixNano()),
+		Question:    strings.TrimSpace(req.Question),
+		NewQuestion: strings.TrimSpace(req.Question) != "",
+	}
+	if resp.NewQuestion {
+		historyItems = append(historyItems, models.ConversationItem{
+			Type:    models.ItemTypeUserMessage,
+			Content: resp.Question,
+		})
+	} else {
+		resp.Question = s.lastUserQuestion()
+		historyItems = historyThroughLastQuestion(historyItems)
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 90 * time.Second,
+		RetryPolicy:         withRetryOverride(defaultLLMRetryPolicy(), s.Config.RetryPolicies.LLM),
+	})
+	configs := s.consultModelConfigs()
+	futures := make([]workflow.Future, len(configs))
+	start := workflow.Now(ctx)
+	for i, mc := range configs {
+		futures[i] = workflow.ExecuteActivity(actCtx, "ExecuteLLMCall", activities.LLMActivityInput{
+			History:               historyItems,
+			ModelConfig:           mc,
+			BaseInstructions:      s.Config.BaseInstructions,
+			DeveloperInstructions: s.Config.DeveloperInstructions,
+			UserInstructions:      s.Config.UserInstructions,
+			TurnID:                ctrl.CurrentTurnID(),
+		})
+	}
+
+	resp.Answers = make([]ConsultAnswer, len(configs))
+	for i, f := range futures {
+		answer := ConsultAnswer{Model: configs[i].Model, Provider: configs[i].Provider}
+		var out activities.LLMActivityOutput
+		if err := f.Get(ctx, &out); err != nil {
+			answer.Error = err.Error()
+		} else {
+			answer.Content = extractFinalMessage(out.Items)
+			answer.Tokens = out.TokenUsage.TotalTokens
+			s.addTokenUsage(configs[i], out.TokenUsage)
+			if answer.Content == "" {
+				answer.Error = "no answer"
+			}
+		}
+		answer.DurationMs = workflow.Now(ctx).Sub(start).Milliseconds()
+		resp.Answers[i] = answer
+	}
+
+	workflow.GetLogger(ctx).Info("Consult completed", "models", len(configs))
+	s.lastConsult = &resp
+	return resp, nil
+}
+
+// validateAdoptConsult checks that req picks an answer of the last consult.
+func (s *SessionState) validateAdoptConsult(ctrl *LoopControl, req AdoptConsultRequest) error {
+	switch {
+	case ctrl.IsShutdown():
+		return fmt.Errorf("session is shutting down")
+	case s.lastConsult == nil || s.lastConsult.ConsultID != req.ConsultID:
+		return fmt.Errorf("consult %q is not the latest one; run /consult again", req.ConsultID)
+	case req.Index < 0 || req.Index >= len(s.lastConsult.Answers):
+		return fmt.Errorf("answer %d out of range", req.Index)
+	case s.lastConsult.Answers[req.Index].Error != "":
+		return fmt.Errorf("%s did not answer", s.lastConsult.Answers[req.Index].Model)
+	case req.Mode != ConsultAdoptAnswer && req.Mode != ConsultAdoptContext:
+		return fmt.Errorf("mode must be %q or %q", ConsultAdoptAnswer, ConsultAdoptContext)
+	case ctrl.Phase() != PhaseWaitingForInput || ctrl.HasPendingWork() || s.AutonomyRun != nil:
+		return fmt.Errorf("a turn is in progress; wait for it to finish or interrupt it")
+	}
+	return nil
+}
+
+// adoptConsult records the picked answer in history. As the answer, a new
+// question is recorded first so the exchange reads naturally; as context,
+// the question and answer go in one developer message.
+func (s *SessionState) adoptConsult(ctx workflow.Context, ctrl *LoopControl, req AdoptConsultRequest) (AdoptConsultResponse, error) {
+	consult := s.lastConsult
+	answer := consult.Answers[req.Index]
+
+	switch req.Mode {
+	case ConsultAdoptAnswer:
+		if consult.NewQuestion {
+			_ = s.History.AddItem(models.ConversationItem{
+				Type:    models.ItemTypeUserMessage,
+				Content: consult.Question,
+				TurnID:  ctrl.CurrentTurnID(),
+			})
+			ctrl.NotifyItemAdded()
+		}
+		_ = s.History.AddItem(models.ConversationItem{
+			Type:    models.ItemTypeAssistantMessage,
+			Content: fmt.Sprintf("[Answer from %s via /consult]\n\n%s", answer.Model, answer.Content),
+			TurnID:  ctrl.CurrentTurnID(),
+		})
+	case ConsultAdoptContext:
+		_ = s.History.AddItem(models.ConversationItem{
+			Type:    models.ItemTypeDeveloperMessage,
+			Content: formatConsultContext(consult.Question, answer),
+			TurnID:  ctrl.CurrentTurnID(),
+		})
+	}
+	ctrl.NotifyItemAdded()
+	s.lastConsult = nil
+
+	workflow.GetLogger(ctx).Info("Consult answer adopted", "model", answer.Model, "mode", req.Mode)
+	return AdoptConsultResponse{}, nil
+}
+
+// formatConsultContext wraps another model's answer so the session's model
+// can weigh it as a second opinion rather than take it as its own.
+func formatConsultContext(question string, answer ConsultAnswer) string {
+	return fmt.Sprintf("The user asked another model (%s) for a second opinion and wants you to take its answer into account.\n"+
+		"<consult_answer model=%q>\n<question>\n%s\n</question>\n<answer>\n%s\n</answer>\n</consult_answer>",
+		answer.Model, answer.Model, question, answer.Content)
+}
+
+// lastUserQuestion returns the last message the user typed, skipping
+// injected context; empty if there is none.
+func (s *SessionState) lastUserQuestion() string {
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return ""
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == models.ItemTypeUserMessage && !isInjectedUserMessage(items[i].Content) {
+			return items[i].Content
+		}
+	}
+	return ""
+}
+
+// historyThroughLastQuestion drops what follows the last user message, so
+// the consulted models answer it rather than comment on the answer given.
+func historyThroughLastQuestion(items []models.ConversationItem) []models.ConversationItem {
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == models.ItemTypeUserMessage && !isInjectedUserMessage(items[i].Content) {
+			return items[:i+1]
+		}
+	}
+	return items
+}
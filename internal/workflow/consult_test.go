@@ -0,0 +1,171 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// llmModelIs matches ExecuteLLMCall inputs for the given model.
+func llmModelIs(model string) interface{} {
+	return mock.MatchedBy(func(in activities.LLMActivityInput) bool { return in.ModelConfig.Model == model })
+}
+
+// TestConsult_AsksModelsAndAdoptsAnswerAsContext verifies that consult asks
+// every consult model the last question without tools, reports a failed
+// model instead of failing, and that an adopted answer reaches the session
+// model as context on the next turn.
+func (s *AgenticWorkflowTestSuite) TestConsult_AsksModelsAndAdoptsAnswerAsContext() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, llmModelIs("gpt-4o-mini")).
+		Return(mockLLMStopResponse("Postgres.", 10), nil).Once()
+	var consulted activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, llmModelIs("gpt-4o")).
+		Run(func(args mock.Arguments) { consulted = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("SQLite until you need concurrent writers.", 20), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, llmModelIs("claude-sonnet-4-5")).
+		Return(activities.LLMActivityOutput{}, fmt.Errorf("rate limited")).Times(5)
+	var nextTurn activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("go with it")).
+		Run(func(args mock.Arguments) { nextTurn = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("SQLite it is.", 10), nil).Once()
+
+	var resp ConsultResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateConsult, "consult-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("consult rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(ConsultResponse)
+			},
+		}, ConsultRequest{})
+	}, time.Second)
+	var failedErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAdoptConsult, "adopt-failed", s.rejectingCallback(&failedErr),
+			AdoptConsultRequest{ConsultID: resp.ConsultID, Index: 1, Mode: ConsultAdoptContext})
+		s.env.UpdateWorkflow(UpdateAdoptConsult, "adopt-1", noopCallback(),
+			AdoptConsultRequest{ConsultID: resp.ConsultID, Index: 0, Mode: ConsultAdoptContext})
+	}, 5*time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "go with it"})
+	}, 6*time.Minute)
+	s.sendShutdown(7 * time.Minute)
+
+	input := testInput("Postgres or SQLite for the job queue?")
+	input.Config.ConsultModels = []string{"gpt-4o", "claude-sonnet-4-5"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "Postgres or SQLite for the job queue?", resp.Question)
+	assert.False(s.T(), resp.NewQuestion)
+	require.Len(s.T(), resp.Answers, 2)
+	assert.Equal(s.T(), "SQLite until you need concurrent writers.", resp.Answers[0].Content)
+	assert.Equal(s.T(), "openai", resp.Answers[0].Provider)
+	assert.Equal(s.T(), "anthropic", resp.Answers[1].Provider)
+	assert.Contains(s.T(), resp.Answers[1].Error, "rate limited")
+
+	assert.Empty(s.T(), consulted.ToolSpecs)
+	last := consulted.History[len(consulted.History)-1]
+	assert.Equal(s.T(), "Postgres or SQLite for the job queue?", last.Content, "the answer already given is left out")
+
+	require.Error(s.T(), failedErr)
+	assert.Contains(s.T(), failedErr.Error(), "did not answer")
+
+	var context string
+	for _, item := range nextTurn.History {
+		if item.Type == models.ItemTypeDeveloperMessage && strings.Contains(item.Content, "<consult_answer") {
+			context = item.Content
+		}
+	}
+	assert.Contains(s.T(), context, `model="gpt-4o"`)
+	assert.Contains(s.T(), context, "SQLite until you need concurrent writers.")
+}
+
+// TestConsult_AdoptAsAnswerRecordsExchange verifies that adopting an answer
+// to a new question records the question and the answer in history.
+func (s *AgenticWorkflowTestSuite) TestConsult_AdoptAsAnswerRecordsExchange() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, llmModelIs("gpt-4o-mini")).
+		Return(mockLLMStopResponse("Hi.", 10), nil).Once()
+	var consulted activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, llmModelIs("gpt-4o")).
+		Run(func(args mock.Arguments) { consulted = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("Use an event log.", 20), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, llmModelIs("gemini-2.5-pro")).
+		Return(mockLLMStopResponse("Use CRUD tables.", 20), nil).Once()
+	var nextTurn activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("thanks")).
+		Run(func(args mock.Arguments) { nextTurn = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("Sure.", 10), nil).Once()
+
+	var resp ConsultResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateConsult, "consult-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("consult rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(ConsultResponse)
+			},
+		}, ConsultRequest{Question: "How should we store audit history?"})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateAdoptConsult, "adopt-1", noopCallback(),
+			AdoptConsultRequest{ConsultID: resp.ConsultID, Index: 1, Mode: ConsultAdoptAnswer})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "thanks"})
+	}, 3*time.Second)
+	s.sendShutdown(time.Minute)
+
+	input := testInput("Hello")
+	input.Config.ConsultModels = []string{"gpt-4o", "gemini-2.5-pro"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.True(s.T(), resp.NewQuestion)
+	assert.Equal(s.T(), "vertex", resp.Answers[1].Provider)
+	assert.Equal(s.T(), "How should we store audit history?", consulted.History[len(consulted.History)-1].Content)
+
+	var contents []string
+	for _, item := range nextTurn.History {
+		if item.Content != "" {
+			contents = append(contents, item.Content)
+		}
+	}
+	require.GreaterOrEqual(s.T(), len(contents), 3)
+	assert.Equal(s.T(), []string{
+		"How should we store audit history?",
+		"[Answer from gemini-2.5-pro via /consult]\n\nUse CRUD tables.",
+		"thanks",
+	}, contents[len(contents)-3:])
+}
+
+// TestConsult_RequiresConsultModels verifies consult is rejected until 2-3
+// consult models are configured.
+func (s *AgenticWorkflowTestSuite) TestConsult_RequiresConsultModels() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hi.", 10), nil).Once()
+
+	var rejected error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateConsult, "consult-1", s.rejectingCallback(&rejected), ConsultRequest{Question: "?"})
+	}, time.Second)
+	s.sendShutdown(time.Minute)
+
+	input := testInput("Hello")
+	input.Config.ConsultModels = []string{"gpt-4o"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Error(s.T(), rejected)
+	assert.Contains(s.T(), rejected.Error(), "consult_models")
+}
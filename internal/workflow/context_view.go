@@ -0,0 +1,344 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// context_view.go implements explicit context management. The get_context
+// query lists what the next LLM call will send (instructions, tools and the
+// history items, grouped by user turn, with token estimates); the
+// drop_context_items and summarize_context_items Updates remove items the
+// user picks, or replace them with an LLM summary, when stale context
+// confuses the model. Used by the CLI /context command.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
+)
+
+// ContextView is what the next LLM call will send, as returned by the
+// get_context query.
+type ContextView struct {
+	Model         string `json:"model"`
+	ContextWindow int    `json:"context_window,omitempty"`
+	TotalTokens   int    `json:"total_tokens"` // Estimate of the whole prompt
+
+	// Sections are the parts of the prompt, history last.
+	Sections []ContextSection `json:"sections"`
+
+	// Blocks are the history items sent to the model, one block per user
+	// turn. Items before the first user message form an untitled block.
+	Blocks []ContextBlock `json:"blocks,omitempty"`
+}
+
+// ContextSection is one part of the prompt.
+type ContextSection struct {
+	Name   string `json:"name"`
+	Items  int    `json:"items,omitempty"` // Tools or history items; 0 for text sections
+	Tokens int    `json:"tokens"`
+}
+
+// ContextBlock is a user turn's history items.
+type ContextBlock struct {
+	Title  string        `json:"title,omitempty"` // Preview of the user message
+	Tokens int           `json:"tokens"`
+	Items  []ContextItem `json:"items"`
+}
+
+// ContextItem is one history item sent to the model. Seq identifies it for
+// drop_context_items and summarize_context_items.
+type ContextItem struct {
+	Seq     int                         `json:"seq"`
+	Type    models.ConversationItemType `json:"type"`
+	Name    string                      `json:"name,omitempty"` // Tool name of a call or output
+	Preview string                      `json:"preview"`
+	Tokens  int                         `json:"tokens"`
+	Pinned  bool                        `json:"pinned,omitempty"`
+}
+
+// sentToModel reports whether item is part of the prompt. Turn markers and
+// other bookkeeping items are not; annotations only when they are feedback.
+func sentToModel(item models.ConversationItem) bool {
+	switch item.Type {
+	case models.ItemTypeTurnStarted, models.ItemTypeTurnComplete, models.ItemTypeUserAnswer:
+		return false
+	case models.ItemTypeAnnotation:
+		return item.Annotation != nil && item.Annotation.Feedback
+	}
+	return true
+}
+
+// contextCounter returns the session's token counter, or the heuristic
+// before one is set up.
+func (s *SessionState) contextCounter() tokenizer.Counter {
+	if s.tokenCache != nil {
+		return s.tokenCache
+	}
+	return tokenizer.Heuristic{}
+}
+
+// contextView builds the get_context listing from the prompt history, so
+// duplicate tool outputs are counted as they will be sent. The view covers
+// the full prompt even when the next call would only send new items after
+// the previous response.
+func (s *SessionState) contextView() ContextView {
+	counter := s.contextCounter()
+	view := ContextView{
+		Model:         s.Config.Model.Model,
+		ContextWindow: s.Config.Model.ContextWindow,
+	}
+	addSection := func(name string, items, tokens int) {
+		if tokens > 0 {
+			view.Sections = append(view.Sections, ContextSection{Name: name, Items: items, Tokens: tokens})
+			view.TotalTokens += tokens
+		}
+	}
+	addSection("Base instructions", 0, counter.Count(s.Config.BaseInstructions))
+	addSection("Developer instructions", 0, counter.Count(s.Config.DeveloperInstructions))
+	addSection("Project instructions", 0, counter.Count(s.Config.UserInstructions))
+	if len(s.ToolSpecs) > 0 {
+		specs, _ := json.Marshal(s.ToolSpecs)
+		addSection("Tools", len(s.ToolSpecs), counter.Count(string(specs)))
+	}
+
+	items, _ := s.History.GetForPrompt()
+	names := callNames(items)
+	history := ContextSection{Name: "History"}
+	for _, item := range withAnnotationFeedback(items) {
+		if !sentToModel(item) {
+			continue
+		}
+		if item.Type == models.ItemTypeUserMessage || len(view.Blocks) == 0 {
+			block := ContextBlock{}
+			if item.Type == models.ItemTypeUserMessage {
+				block.Title = pinPreview(item)
+			}
+			view.Blocks = append(view.Blocks, block)
+		}
+		tokens := counter.Count(tokenizer.ItemText(item))
+		block := &view.Blocks[len(view.Blocks)-1]
+		block.Items = append(block.Items, ContextItem{
+			Seq:     item.Seq,
+			Type:    item.Type,
+			Name:    names[item.CallID],
+			Preview: contextPreview(item),
+			Tokens:  tokens,
+			Pinned:  item.Pinned,
+		})
+		block.Tokens += tokens
+		history.Items++
+		history.Tokens += tokens
+	}
+	view.Sections = append(view.Sections, history)
+	view.TotalTokens += history.Tokens
+	return view
+}
+
+// callNames maps call IDs to tool names, so outputs can be labeled.
+func callNames(items []models.ConversationItem) map[string]string {
+	names := make(map[string]string)
+	for _, item := range items {
+		if item.Type == models.ItemTypeFunctionCall && item.CallID != "" {
+			names[item.CallID] = item.Name
+		}
+	}
+	return names
+}
+
+// contextPreview is a one-line summary of item for /context.
+func contextPreview(item models.ConversationItem) string {
+	switch item.Type {
+	case models.ItemTypeFunctionCall:
+		return pinPreview(models.ConversationItem{Content: item.Arguments})
+	case models.ItemTypeFunctionCallOutput:
+		if item.Output != nil {
+			return pinPreview(models.ConversationItem{Content: item.Output.Content})
+		}
+	case models.ItemTypeUserShellCommand:
+		return pinPreview(models.ConversationItem{Content: "!" + item.UserShellCommand()})
+	case models.ItemTypeWebSearchCall:
+		return pinPreview(models.ConversationItem{Content: item.WebSearchAction + " " + item.WebSearchURL})
+	}
+	return pinPreview(item)
+}
+
+// validateContextEdit reports whether history can be edited now: not while
+// a turn is running, which may be sending it.
+func validateContextEdit(ctrl *LoopControl) error {
+	if ctrl.IsShutdown() {
+		return fmt.Errorf("session is shutting down")
+	}
+	if phase := ctrl.Phase(); phase != "" && phase != PhaseWaitingForInput {
+		return fmt.Errorf("cannot edit context while a turn is running")
+	}
+	return nil
+}
+
+// selectContextItems returns which of items the seqs select. A function
+// call and its output are selected together, so the pair is never split.
+// Pinned items and items not sent to the model cannot be selected.
+func selectContextItems(items []models.ConversationItem, seqs []int) ([]bool, error) {
+	if len(seqs) == 0 {
+		return nil, fmt.Errorf("no items selected")
+	}
+	selected := make([]bool, len(items))
+	calls := make(map[string]bool)
+	for _, seq := range seqs {
+		if seq < 0 || seq >= len(items) {
+			return nil, fmt.Errorf("no history item #%d", seq)
+		}
+		item := items[seq]
+		if !sentToModel(item) {
+			return nil, fmt.Errorf("item #%d is a %s item and is not sent to the model", seq, item.Type)
+		}
+		if item.Pinned {
+			return nil, fmt.Errorf("item #%d is pinned; unpin it first", seq)
+		}
+		selected[seq] = true
+		if isCallOrOutput(item) && item.CallID != "" {
+			calls[item.CallID] = true
+		}
+	}
+	for i, item := range items {
+		if isCallOrOutput(item) && calls[item.CallID] {
+			selected[i] = true
+		}
+	}
+	return selected, nil
+}
+
+func isCallOrOutput(item models.ConversationItem) bool {
+	return item.Type == models.ItemTypeFunctionCall || item.Type == models.ItemTypeFunctionCallOutput
+}
+
+// dropContextItems removes the selected items from history.
+func (s *SessionState) dropContextItems(ctx workflow.Context, ctrl *LoopControl, seqs []int) (ContextEditResponse, error) {
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return ContextEditResponse{}, err
+	}
+	selected, err := selectContextItems(items, seqs)
+	if err != nil {
+		return ContextEditResponse{}, err
+	}
+
+	kept := make([]models.ConversationItem, 0, len(items))
+	for i, item := range items {
+		if !selected[i] {
+			kept = append(kept, item)
+		}
+	}
+	removed := len(items) - len(kept)
+	if err := s.replaceContext(ctx, ctrl, kept); err != nil {
+		return ContextEditResponse{}, err
+	}
+	workflow.GetLogger(ctx).Info("Dropped context items", "items", removed)
+	return ContextEditResponse{Removed: removed, View: s.contextView()}, nil
+}
+
+// summarizeContextItems replaces the selected items with an LLM summary of
+// them, recorded where the first of them was. The summary is an assistant
+// message, like a compaction summary.
+func (s *SessionState) summarizeContextItems(ctx workflow.Context, ctrl *LoopControl, seqs []int) (ContextEditResponse, error) {
+	items, err := s.History.GetForPrompt()
+	if err != nil {
+		return ContextEditResponse{}, err
+	}
+	selected, err := selectContextItems(items, seqs)
+	if err != nil {
+		return ContextEditResponse{}, err
+	}
+	var chosen []models.ConversationItem
+	for i, item := range items {
+		if selected[i] {
+			chosen = append(chosen, item)
+		}
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 3 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    30 * time.Second,
+			MaximumAttempts:    2,
+		},
+	})
+	epoch := s.HistoryEpoch
+	var out activities.SummarizeContextItemsOutput
+	err = workflow.ExecuteActivity(actCtx, "SummarizeContextItems", activities.SummarizeContextItemsInput{
+		Items:       chosen,
+		ModelConfig: s.Config.Model,
+	}).Get(ctx, &out)
+	if err != nil {
+		return ContextEditResponse{}, fmt.Errorf("summarization failed: %w", err)
+	}
+	s.addTokenUsage(s.Config.Model, out.TokenUsage)
+
+	// A turn may have started, or compaction renumbered history, while the
+	// summary was written; the selection is stale then. Items appended in
+	// the meantime keep the selected seqs valid.
+	if s.HistoryEpoch != epoch {
+		return ContextEditResponse{}, fmt.Errorf("history was rewritten while summarizing; try again")
+	}
+	if err := validateContextEdit(ctrl); err != nil {
+		return ContextEditResponse{}, err
+	}
+
+	current, err := s.History.GetRawItems()
+	if err != nil {
+		return ContextEditResponse{}, err
+	}
+	result := make([]models.ConversationItem, 0, len(current))
+	placed := false
+	for i, item := range current {
+		if i >= len(selected) || !selected[i] {
+			result = append(result, item)
+			continue
+		}
+		if !placed {
+			result = append(result, models.ConversationItem{
+				Type:    models.ItemTypeAssistantMessage,
+				Content: formatContextSummary(len(chosen), out.Summary),
+			})
+			placed = true
+		}
+	}
+	if err := s.replaceContext(ctx, ctrl, result); err != nil {
+		return ContextEditResponse{}, err
+	}
+	workflow.GetLogger(ctx).Info("Summarized context items", "items", len(chosen))
+	return ContextEditResponse{Removed: len(chosen), Summary: out.Summary, View: s.contextView()}, nil
+}
+
+// replaceContext swaps in edited history. As after compaction, the items
+// about to be replaced are archived first, and the next LLM call sends the
+// full history, since the provider's stored conversation still holds the
+// removed items.
+func (s *SessionState) replaceContext(ctx workflow.Context, ctrl *LoopControl, items []models.ConversationItem) error {
+	flushed := s.archiveTranscript(ctx, archiveReasonContextEdit)
+	if err := s.History.ReplaceAll(items); err != nil {
+		return err
+	}
+	ctrl.NotifyItemAdded()
+	s.resetArchiveMark(flushed)
+	s.HistoryEpoch++
+	s.LastResponseID = ""
+	s.lastSentHistoryLen = 0
+	return nil
+}
+
+// formatContextSummary wraps a /context summary in a labeled block so the
+// model can tell it from the conversation it replaces.
+func formatContextSummary(count int, summary string) string {
+	return fmt.Sprintf("[Summary of %d earlier context items, replaced by the user]\n<context_summary>\n%s\n</context_summary>",
+		count, strings.TrimSpace(summary))
+}
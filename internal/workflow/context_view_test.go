@@ -0,0 +1,202 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/history"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func contextTestHistory() []models.ConversationItem {
+	return []models.ConversationItem{
+		{Type: models.ItemTypeDeveloperMessage, Content: "Be brief."},
+		{Type: models.ItemTypeTurnStarted, TurnID: "turn-1"},
+		{Type: models.ItemTypeUserMessage, Content: "Fix the\nbuild"},
+		{Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "shell", Arguments: `{"command":"go build"}`},
+		{Type: models.ItemTypeFunctionCallOutput, CallID: "c1", Output: &models.FunctionCallOutputPayload{Content: "ok"}},
+		{Type: models.ItemTypeAssistantMessage, Content: "Fixed.", Pinned: true},
+		{Type: models.ItemTypeTurnComplete, TurnID: "turn-1"},
+	}
+}
+
+func TestContextView(t *testing.T) {
+	h := history.NewInMemoryHistory()
+	for _, item := range contextTestHistory() {
+		_ = h.AddItem(item)
+	}
+	s := &SessionState{
+		History:   h,
+		ToolSpecs: []tools.ToolSpec{{Name: "shell"}},
+		Config: models.SessionConfiguration{
+			Model:            models.ModelConfig{Model: "gpt-4o", ContextWindow: 128000},
+			BaseInstructions: "You are a coding agent.",
+		},
+	}
+
+	view := s.contextView()
+	assert.Equal(t, "gpt-4o", view.Model)
+	require.Len(t, view.Sections, 3)
+	assert.Equal(t, "Base instructions", view.Sections[0].Name)
+	assert.Equal(t, ContextSection{Name: "Tools", Items: 1, Tokens: view.Sections[1].Tokens}, view.Sections[1])
+	history := view.Sections[2]
+	assert.Equal(t, "History", history.Name)
+	assert.Equal(t, 5, history.Items, "turn markers are not sent")
+
+	total := 0
+	for _, section := range view.Sections {
+		total += section.Tokens
+	}
+	assert.Equal(t, total, view.TotalTokens)
+
+	require.Len(t, view.Blocks, 2)
+	assert.Empty(t, view.Blocks[0].Title, "items before the first user message")
+	assert.Equal(t, "Fix the build", view.Blocks[1].Title)
+	items := view.Blocks[1].Items
+	require.Len(t, items, 4)
+	assert.Equal(t, 2, items[0].Seq)
+	assert.Equal(t, "shell", items[2].Name, "outputs are labeled with their call's tool")
+	assert.Equal(t, "ok", items[2].Preview)
+	assert.True(t, items[3].Pinned)
+	assert.Equal(t, tokenizer.Heuristic{}.Count(tokenizer.ItemText(contextTestHistory()[3])), items[1].Tokens)
+}
+
+func TestSelectContextItems(t *testing.T) {
+	items := contextTestHistory()
+	for i := range items {
+		items[i].Seq = i
+	}
+
+	selected, err := selectContextItems(items, []int{4})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false, false, true, true, false, false}, selected, "a call and its output go together")
+
+	_, err = selectContextItems(items, []int{5})
+	assert.ErrorContains(t, err, "pinned")
+	_, err = selectContextItems(items, []int{1})
+	assert.ErrorContains(t, err, "not sent to the model")
+	_, err = selectContextItems(items, []int{7})
+	assert.ErrorContains(t, err, "no history item #7")
+	_, err = selectContextItems(items, nil)
+	assert.ErrorContains(t, err, "no items selected")
+}
+
+// queryContext returns the get_context view.
+func (s *AgenticWorkflowTestSuite) queryContext() ContextView {
+	result, err := s.env.QueryWorkflow(QueryGetContext)
+	require.NoError(s.T(), err)
+	var view ContextView
+	require.NoError(s.T(), result.Get(&view))
+	return view
+}
+
+// findContextItem returns the seq of the first item in view with content.
+func findContextItem(view ContextView, content string) int {
+	for _, block := range view.Blocks {
+		for _, item := range block.Items {
+			if item.Preview == content {
+				return item.Seq
+			}
+		}
+	}
+	return -1
+}
+
+// TestContext_DropItems verifies that drop_context_items removes the picked
+// item from history and returns the renumbered view.
+func (s *AgenticWorkflowTestSuite) TestContext_DropItems() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Stale answer", 10), nil).Once()
+
+	var resp ContextEditResponse
+	s.env.RegisterDelayedCallback(func() {
+		seq := findContextItem(s.queryContext(), "Stale answer")
+		require.GreaterOrEqual(s.T(), seq, 0)
+		s.env.UpdateWorkflow(UpdateDropContextItems, "drop-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("drop rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(ContextEditResponse)
+			},
+		}, ContextEditRequest{Seqs: []int{seq}})
+	}, 2*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateDropContextItems, "drop-bad", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("update should be rejected") },
+			OnReject:   func(err error) { assert.ErrorContains(s.T(), err, "no history item #99") },
+			OnComplete: func(interface{}, error) {},
+		}, ContextEditRequest{Seqs: []int{99}})
+	}, 3*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), 1, resp.Removed)
+	assert.Equal(s.T(), -1, findContextItem(resp.View, "Stale answer"))
+	assert.GreaterOrEqual(s.T(), findContextItem(resp.View, "Hello"), 0)
+}
+
+// TestContext_SummarizeItems verifies that summarize_context_items replaces
+// the picked items with the labeled summary, in their place.
+func (s *AgenticWorkflowTestSuite) TestContext_SummarizeItems() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Long detour", 10), nil).Once()
+
+	var gotInput activities.SummarizeContextItemsInput
+	s.env.OnActivity("SummarizeContextItems", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, input activities.SummarizeContextItemsInput) (activities.SummarizeContextItemsOutput, error) {
+			gotInput = input
+			return activities.SummarizeContextItemsOutput{
+				Summary:    "The user said hello; the agent took a detour.",
+				TokenUsage: models.TokenUsage{TotalTokens: 50},
+			}, nil
+		}).Once()
+
+	var resp ContextEditResponse
+	s.env.RegisterDelayedCallback(func() {
+		view := s.queryContext()
+		seqs := []int{findContextItem(view, "Hello"), findContextItem(view, "Long detour")}
+		s.env.UpdateWorkflow(UpdateSummarizeContextItems, "summarize-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("summarize rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(ContextEditResponse)
+			},
+		}, ContextEditRequest{Seqs: seqs})
+	}, 2*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), gotInput.Items, 2)
+	assert.Equal(s.T(), "Hello", gotInput.Items[0].Content)
+	assert.Equal(s.T(), 2, resp.Removed)
+
+	var summary *ContextItem
+	for _, block := range resp.View.Blocks {
+		for i, item := range block.Items {
+			assert.NotEqual(s.T(), "Long detour", item.Preview)
+			if item.Type == models.ItemTypeAssistantMessage {
+				summary = &block.Items[i]
+			}
+		}
+	}
+	require.NotNil(s.T(), summary)
+	assert.Contains(s.T(), summary.Preview, "[Summary of 2 earlier context items")
+}
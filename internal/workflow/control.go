@@ -12,8 +12,12 @@ package workflow
 
 import (
 	"fmt"
+	"time"
 
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
 
 // ResponseSlot holds a single awaitable response of type T.
@@ -61,14 +65,56 @@ type LoopControl struct {
 	shutdownRequested bool
 	interrupted       bool
 	compactRequested  bool
+	paused            bool // Mirrors SessionState.Paused; suspends the idle timer
 	currentTurnID     string
 
+	// Between-turn Updates (remap_cwd, user_shell) still running. The loop does not
+	// start a turn or act on other work while any hold it.
+	turnStartHolds int
+
+	// Turn epoch — bumped when a turn starts or is interrupted. Approval and
+	// escalation responses that echo an older epoch are rejected as stale.
+	turnEpoch int
+
+	// Call IDs of in-flight tool calls the user asked to cancel.
+	cancelledCalls map[string]bool
+
+	// Autonomy budget timer (see autonomy.go). autonomyTimerGen discards a
+	// timer that fired after it was replaced.
+	autonomyExpired     bool
+	autonomyTimerGen    int
+	cancelAutonomyTimer workflow.CancelFunc
+
+	// Turn deadline timer (see turn_deadline.go). turnDeadlineGen discards
+	// a timer that fired after its turn ended.
+	turnDeadlineAt      time.Time
+	turnDeadlineWarn    bool // The wrap-up warning is due
+	turnDeadlineExpired bool
+	turnDeadlineGen     int
+	cancelTurnDeadline  workflow.CancelFunc
+
+	// In-flight prompt suggestion (see suggestions.go). suggestionGen
+	// discards a suggestion that arrives after a new message.
+	suggestionGen    int
+	cancelSuggestion workflow.CancelFunc
+
+	// Session started without a first message (Update-with-Start; see
+	// AgenticWorkflow). user_input waits for init to finish, and the first
+	// turn carries startContext.
+	awaitingStart bool
+	startContext  string
+
 	// Observable state for get_turn_status query
 	phase               TurnPhase
-	toolsInFlight       []string
+	phaseStartedAt      time.Time
+	phaseTimeout        time.Duration
+	queuedBehind        int
+	resumesAt           time.Time
+	toolsInFlight       []ToolInFlight
 	pendingApprovals    []PendingApproval
 	pendingEscalations  []EscalationRequest
 	pendingUserInputReq *PendingUserInputRequest
+	pendingAskUser      *PendingAskUserRequest
 	suggestion          string
 
 	// State version — monotonically increasing counter bumped on every
@@ -85,6 +131,34 @@ type LoopControl struct {
 	approvalSlot   ResponseSlot[ApprovalResponse]
 	escalationSlot ResponseSlot[EscalationResponse]
 	userInputQSlot ResponseSlot[UserInputQuestionResponse]
+	askUserSlot    ResponseSlot[AskUserResponse]
+}
+
+// --- Session start ---
+
+// SetAwaitingStart marks a session whose first message will arrive as a
+// user_input update while init runs.
+func (ctrl *LoopControl) SetAwaitingStart() {
+	ctrl.awaitingStart = true
+}
+
+// IsAwaitingStart reports whether init of such a session is still running.
+func (ctrl *LoopControl) IsAwaitingStart() bool {
+	return ctrl.awaitingStart
+}
+
+// FinishStart ends init. envContext, if any, leads the first turn.
+func (ctrl *LoopControl) FinishStart(envContext string) {
+	ctrl.awaitingStart = false
+	ctrl.startContext = envContext
+}
+
+// TakeStartContext returns the environment context for the first turn, and
+// "" after that.
+func (ctrl *LoopControl) TakeStartContext() string {
+	envContext := ctrl.startContext
+	ctrl.startContext = ""
+	return envContext
 }
 
 // --- Delivery methods (called by update handlers) ---
@@ -113,11 +187,87 @@ func (ctrl *LoopControl) DeliverUserInputQ(resp UserInputQuestionResponse) {
 	ctrl.stateVersion++
 }
 
+// DeliverAskUser stores an ask_user answer and clears visible pending state.
+// Called by the ask_user_response update handler.
+func (ctrl *LoopControl) DeliverAskUser(resp AskUserResponse) {
+	ctrl.askUserSlot.Deliver(resp)
+	ctrl.pendingAskUser = nil
+	ctrl.stateVersion++
+}
+
+// --- Response validation (called by update validators) ---
+
+// StaleResponseErrorType is the application error type of approval and
+// escalation responses that no longer answer what the turn is waiting for:
+// nothing is pending, the turn was interrupted, or the response names calls
+// or a turn epoch from an earlier prompt.
+const StaleResponseErrorType = "StaleResponse"
+
+// staleResponseError returns a StaleResponseErrorType application error.
+func staleResponseError(format string, args ...interface{}) error {
+	return temporal.NewApplicationError(fmt.Sprintf(format, args...), StaleResponseErrorType)
+}
+
+// ValidateApproval rejects an approval response that does not answer the
+// approval the turn is waiting for now.
+func (ctrl *LoopControl) ValidateApproval(resp ApprovalResponse) error {
+	if ctrl.phase != PhaseApprovalPending || ctrl.approvalSlot.Ready() {
+		return staleResponseError("no approval pending")
+	}
+	pending := make(map[string]bool, len(ctrl.pendingApprovals))
+	for _, ap := range ctrl.pendingApprovals {
+		pending[ap.CallID] = true
+	}
+	return ctrl.validateResponse("approval", resp.TurnEpoch, pending, resp.Approved, resp.Denied)
+}
+
+// ValidateEscalation rejects an escalation response that does not answer
+// the escalation the turn is waiting for now.
+func (ctrl *LoopControl) ValidateEscalation(resp EscalationResponse) error {
+	if ctrl.phase != PhaseEscalationPending || ctrl.escalationSlot.Ready() {
+		return staleResponseError("no escalation pending")
+	}
+	pending := make(map[string]bool, len(ctrl.pendingEscalations))
+	for _, esc := range ctrl.pendingEscalations {
+		pending[esc.CallID] = true
+	}
+	return ctrl.validateResponse("escalation", resp.TurnEpoch, pending, resp.Approved, resp.Denied)
+}
+
+// validateResponse checks a response's turn epoch and call IDs against the
+// pending calls. An epoch of 0 is not checked, for senders that predate it.
+func (ctrl *LoopControl) validateResponse(kind string, epoch int, pending map[string]bool, approved, denied []string) error {
+	if ctrl.interrupted || ctrl.shutdownRequested {
+		return staleResponseError("turn was interrupted; no %s pending", kind)
+	}
+	if epoch != 0 && epoch != ctrl.turnEpoch {
+		return staleResponseError("%s response is for turn epoch %d, current epoch is %d", kind, epoch, ctrl.turnEpoch)
+	}
+	approvedSet := make(map[string]bool, len(approved))
+	for _, id := range approved {
+		if !pending[id] {
+			return staleResponseError("call %s is not awaiting %s", id, kind)
+		}
+		approvedSet[id] = true
+	}
+	for _, id := range denied {
+		if !pending[id] {
+			return staleResponseError("call %s is not awaiting %s", id, kind)
+		}
+		if approvedSet[id] {
+			return fmt.Errorf("call %s is both approved and denied", id)
+		}
+	}
+	return nil
+}
+
 // --- Lifecycle setters (called by handlers) ---
 
 // SetPendingUserInput records a new user-input turn with the given ID.
-// Sets both the current turn ID and the pending-input flag.
+// Sets both the current turn ID and the pending-input flag, and cancels a
+// prompt suggestion still being generated.
 func (ctrl *LoopControl) SetPendingUserInput(turnID string) {
+	ctrl.CancelSuggestion()
 	ctrl.currentTurnID = turnID
 	ctrl.pendingUserInput = true
 	ctrl.stateVersion++
@@ -126,6 +276,7 @@ func (ctrl *LoopControl) SetPendingUserInput(turnID string) {
 // SetInterrupted marks the current turn as interrupted.
 func (ctrl *LoopControl) SetInterrupted() {
 	ctrl.interrupted = true
+	ctrl.turnEpoch++
 	ctrl.stateVersion++
 }
 
@@ -133,6 +284,7 @@ func (ctrl *LoopControl) SetInterrupted() {
 func (ctrl *LoopControl) SetShutdown() {
 	ctrl.shutdownRequested = true
 	ctrl.interrupted = true
+	ctrl.turnEpoch++
 	ctrl.stateVersion++
 }
 
@@ -142,16 +294,120 @@ func (ctrl *LoopControl) SetCompactRequested() {
 	ctrl.stateVersion++
 }
 
+// SetPaused records whether the session is paused.
+func (ctrl *LoopControl) SetPaused(paused bool) {
+	ctrl.paused = paused
+	ctrl.stateVersion++
+}
+
 // --- Phase / tool tracking (called by loop and turn code) ---
 
 // SetPhase updates the current turn phase (visible via get_turn_status).
-func (ctrl *LoopControl) SetPhase(p TurnPhase) { ctrl.phase = p; ctrl.stateVersion++ }
+// It clears the phase timer; see SetPhaseTimer.
+func (ctrl *LoopControl) SetPhase(p TurnPhase) {
+	ctrl.phase = p
+	ctrl.phaseStartedAt = time.Time{}
+	ctrl.phaseTimeout = 0
+	ctrl.queuedBehind = 0
+	ctrl.resumesAt = time.Time{}
+	ctrl.stateVersion++
+}
+
+// SetRateLimited enters PhaseRateLimited until the given time, when the
+// rate-limited LLM call is retried.
+func (ctrl *LoopControl) SetRateLimited(until time.Time) {
+	ctrl.SetPhase(PhaseRateLimited)
+	ctrl.resumesAt = until
+}
+
+// ResumesAt returns when a rate-limited LLM call is retried; zero outside
+// PhaseRateLimited.
+func (ctrl *LoopControl) ResumesAt() time.Time { return ctrl.resumesAt }
+
+// SetPhaseTimer records when the activity behind the current phase started
+// and its per-attempt timeout, so the TUI can show elapsed time and warn
+// before the timeout triggers a retry.
+func (ctrl *LoopControl) SetPhaseTimer(start time.Time, timeout time.Duration) {
+	ctrl.phaseStartedAt = start
+	ctrl.phaseTimeout = timeout
+	ctrl.stateVersion++
+}
+
+// PhaseTimer returns the current phase's start time and activity timeout.
+// The start time is zero when the phase has no timer.
+func (ctrl *LoopControl) PhaseTimer() (time.Time, time.Duration) {
+	return ctrl.phaseStartedAt, ctrl.phaseTimeout
+}
+
+// SetLLMQueued records the in-flight LLM call's 1-based rate-limiter queue
+// position. A positive position moves PhaseLLMCalling to PhaseLLMQueued;
+// zero (admitted) moves it back. Positions reported outside an LLM call are
+// ignored.
+func (ctrl *LoopControl) SetLLMQueued(position int) {
+	if ctrl.phase != PhaseLLMCalling && ctrl.phase != PhaseLLMQueued {
+		return
+	}
+	if position > 0 {
+		ctrl.phase = PhaseLLMQueued
+		ctrl.queuedBehind = position - 1
+	} else {
+		ctrl.phase = PhaseLLMCalling
+		ctrl.queuedBehind = 0
+	}
+	ctrl.stateVersion++
+}
+
+// QueuedBehind returns how many queued LLM requests are ahead of ours.
+func (ctrl *LoopControl) QueuedBehind() int { return ctrl.queuedBehind }
 
 // Phase returns the current turn phase.
 func (ctrl *LoopControl) Phase() TurnPhase { return ctrl.phase }
 
-// SetToolsInFlight records the names of currently executing tools.
-func (ctrl *LoopControl) SetToolsInFlight(tools []string) { ctrl.toolsInFlight = tools; ctrl.stateVersion++ }
+// SetToolsInFlight records the currently executing tool calls.
+func (ctrl *LoopControl) SetToolsInFlight(calls []ToolInFlight) {
+	ctrl.toolsInFlight = calls
+	ctrl.stateVersion++
+}
+
+// SetToolProgress attaches the latest progress report to the matching
+// in-flight tool call. Reports for calls no longer in flight are ignored.
+func (ctrl *LoopControl) SetToolProgress(p tools.ToolProgress) {
+	for i := range ctrl.toolsInFlight {
+		if ctrl.toolsInFlight[i].CallID == p.CallID {
+			if last := ctrl.toolsInFlight[i].Progress; last != nil && *last == p {
+				return
+			}
+			ctrl.toolsInFlight[i].Progress = &p
+			ctrl.stateVersion++
+			return
+		}
+	}
+}
+
+// InFlightTool returns the in-flight tool call with the given ID.
+func (ctrl *LoopControl) InFlightTool(callID string) (ToolInFlight, bool) {
+	for _, t := range ctrl.toolsInFlight {
+		if t.CallID == callID {
+			return t, true
+		}
+	}
+	return ToolInFlight{}, false
+}
+
+// RequestToolCancel marks an in-flight tool call for cancellation.
+// Called by the cancel_tool update handler.
+func (ctrl *LoopControl) RequestToolCancel(callID string) {
+	if ctrl.cancelledCalls == nil {
+		ctrl.cancelledCalls = make(map[string]bool)
+	}
+	ctrl.cancelledCalls[callID] = true
+	ctrl.stateVersion++
+}
+
+// IsToolCancelRequested reports whether the user cancelled the tool call.
+func (ctrl *LoopControl) IsToolCancelRequested(callID string) bool {
+	return ctrl.cancelledCalls[callID]
+}
 
 // ClearToolsInFlight clears the in-flight tool list.
 func (ctrl *LoopControl) ClearToolsInFlight() { ctrl.toolsInFlight = nil; ctrl.stateVersion++ }
@@ -164,8 +420,20 @@ func (ctrl *LoopControl) CurrentTurnID() string { return ctrl.currentTurnID }
 
 // --- Observable state accessors (for query handlers) ---
 
-// ToolsInFlight returns the currently in-flight tool names.
-func (ctrl *LoopControl) ToolsInFlight() []string { return ctrl.toolsInFlight }
+// ToolsInFlight returns the currently in-flight tool calls.
+func (ctrl *LoopControl) ToolsInFlight() []ToolInFlight { return ctrl.toolsInFlight }
+
+// ToolNamesInFlight returns the names of the currently in-flight tools.
+func (ctrl *LoopControl) ToolNamesInFlight() []string {
+	if len(ctrl.toolsInFlight) == 0 {
+		return nil
+	}
+	names := make([]string, len(ctrl.toolsInFlight))
+	for i, t := range ctrl.toolsInFlight {
+		names[i] = t.Name
+	}
+	return names
+}
 
 // PendingApprovals returns the current pending approval list.
 func (ctrl *LoopControl) PendingApprovals() []PendingApproval { return ctrl.pendingApprovals }
@@ -178,6 +446,11 @@ func (ctrl *LoopControl) PendingUserInputReq() *PendingUserInputRequest {
 	return ctrl.pendingUserInputReq
 }
 
+// PendingAskUser returns the current pending ask_user question.
+func (ctrl *LoopControl) PendingAskUser() *PendingAskUserRequest {
+	return ctrl.pendingAskUser
+}
+
 // Suggestion returns the post-turn prompt suggestion (best-effort).
 func (ctrl *LoopControl) Suggestion() string { return ctrl.suggestion }
 
@@ -208,12 +481,30 @@ func (ctrl *LoopControl) HasPendingWork() bool {
 	return ctrl.pendingUserInput || ctrl.shutdownRequested || ctrl.compactRequested
 }
 
+// HoldTurnStart keeps the loop from starting a turn until release is
+// called. Input that arrives meanwhile is queued and starts its turn after.
+func (ctrl *LoopControl) HoldTurnStart() (release func()) {
+	ctrl.turnStartHolds++
+	return func() { ctrl.turnStartHolds-- }
+}
+
+// TurnStartHeld reports whether a between-turn Update holds turn start.
+func (ctrl *LoopControl) TurnStartHeld() bool { return ctrl.turnStartHolds > 0 }
+
+// readyForWork reports whether the loop can act on its pending work now.
+func (ctrl *LoopControl) readyForWork() bool {
+	return ctrl.HasPendingWork() && !ctrl.TurnStartHeld()
+}
+
 // IsShutdown returns true if a shutdown has been requested.
 func (ctrl *LoopControl) IsShutdown() bool { return ctrl.shutdownRequested }
 
 // IsInterrupted returns true if the current turn has been interrupted.
 func (ctrl *LoopControl) IsInterrupted() bool { return ctrl.interrupted }
 
+// TurnEpoch returns the current turn epoch (see TurnStatus.TurnEpoch).
+func (ctrl *LoopControl) TurnEpoch() int { return ctrl.turnEpoch }
+
 // IsCompactRequested returns true if manual compaction was requested.
 func (ctrl *LoopControl) IsCompactRequested() bool { return ctrl.compactRequested }
 
@@ -224,10 +515,120 @@ func (ctrl *LoopControl) IsCompactRequested() bool { return ctrl.compactRequeste
 func (ctrl *LoopControl) StartTurn() {
 	ctrl.pendingUserInput = false
 	ctrl.interrupted = false
+	ctrl.turnEpoch++
 	ctrl.suggestion = ""
+	ctrl.CancelSuggestion()
+	ctrl.cancelledCalls = nil
 	ctrl.stateVersion++
 }
 
+// StartAutonomyTimer arms the autonomy budget timer: AutonomyExpired turns
+// true after d, or at once if d is not positive. Replaces a running timer.
+func (ctrl *LoopControl) StartAutonomyTimer(ctx workflow.Context, d time.Duration) {
+	ctrl.StopAutonomyTimer()
+	if d <= 0 {
+		ctrl.autonomyExpired = true
+		return
+	}
+	gen := ctrl.autonomyTimerGen
+	timerCtx, cancel := workflow.WithCancel(ctx)
+	ctrl.cancelAutonomyTimer = cancel
+	workflow.Go(timerCtx, func(gctx workflow.Context) {
+		if err := workflow.NewTimer(gctx, d).Get(gctx, nil); err == nil && gen == ctrl.autonomyTimerGen {
+			ctrl.autonomyExpired = true
+			ctrl.stateVersion++
+		}
+	})
+}
+
+// StopAutonomyTimer cancels the autonomy budget timer and clears
+// AutonomyExpired.
+func (ctrl *LoopControl) StopAutonomyTimer() {
+	if ctrl.cancelAutonomyTimer != nil {
+		ctrl.cancelAutonomyTimer()
+		ctrl.cancelAutonomyTimer = nil
+	}
+	ctrl.autonomyTimerGen++
+	ctrl.autonomyExpired = false
+}
+
+// AutonomyExpired returns true once the autonomy budget timer has fired.
+func (ctrl *LoopControl) AutonomyExpired() bool { return ctrl.autonomyExpired }
+
+// StartTurnDeadline arms the turn deadline: TurnDeadlineWarningDue turns
+// true after warnAfter, and after limit the turn is interrupted and
+// TurnDeadlineExpired turns true. Replaces a running deadline.
+func (ctrl *LoopControl) StartTurnDeadline(ctx workflow.Context, warnAfter, limit time.Duration) {
+	ctrl.StopTurnDeadline()
+	ctrl.turnDeadlineAt = workflow.Now(ctx).Add(limit)
+	gen := ctrl.turnDeadlineGen
+	timerCtx, cancel := workflow.WithCancel(ctx)
+	ctrl.cancelTurnDeadline = cancel
+	workflow.Go(timerCtx, func(gctx workflow.Context) {
+		if err := workflow.NewTimer(gctx, warnAfter).Get(gctx, nil); err != nil || gen != ctrl.turnDeadlineGen {
+			return
+		}
+		ctrl.turnDeadlineWarn = true
+		ctrl.stateVersion++
+		if err := workflow.NewTimer(gctx, limit-warnAfter).Get(gctx, nil); err != nil || gen != ctrl.turnDeadlineGen {
+			return
+		}
+		if !ctrl.interrupted {
+			ctrl.turnDeadlineExpired = true
+			ctrl.SetInterrupted()
+		}
+	})
+}
+
+// StopTurnDeadline cancels the turn deadline and clears its flags.
+func (ctrl *LoopControl) StopTurnDeadline() {
+	if ctrl.cancelTurnDeadline != nil {
+		ctrl.cancelTurnDeadline()
+		ctrl.cancelTurnDeadline = nil
+	}
+	ctrl.turnDeadlineGen++
+	ctrl.turnDeadlineAt = time.Time{}
+	ctrl.turnDeadlineWarn = false
+	ctrl.turnDeadlineExpired = false
+}
+
+// TurnDeadline returns when the current turn times out; zero without a
+// deadline.
+func (ctrl *LoopControl) TurnDeadline() time.Time { return ctrl.turnDeadlineAt }
+
+// TurnDeadlineWarningDue returns true once the turn is close enough to its
+// deadline to warn the model, until ClearTurnDeadlineWarning.
+func (ctrl *LoopControl) TurnDeadlineWarningDue() bool { return ctrl.turnDeadlineWarn }
+
+// ClearTurnDeadlineWarning marks the wrap-up warning as sent.
+func (ctrl *LoopControl) ClearTurnDeadlineWarning() { ctrl.turnDeadlineWarn = false }
+
+// TurnDeadlineExpired returns true once the deadline interrupted the turn.
+func (ctrl *LoopControl) TurnDeadlineExpired() bool { return ctrl.turnDeadlineExpired }
+
+// BeginSuggestion cancels a running suggestion and returns a cancellable
+// context and the generation for the next one.
+func (ctrl *LoopControl) BeginSuggestion(ctx workflow.Context) (workflow.Context, int) {
+	ctrl.CancelSuggestion()
+	suggCtx, cancel := workflow.WithCancel(ctx)
+	ctrl.cancelSuggestion = cancel
+	return suggCtx, ctrl.suggestionGen
+}
+
+// CancelSuggestion cancels the running suggestion, if any. A suggestion
+// that completes anyway is discarded.
+func (ctrl *LoopControl) CancelSuggestion() {
+	if ctrl.cancelSuggestion != nil {
+		ctrl.cancelSuggestion()
+		ctrl.cancelSuggestion = nil
+	}
+	ctrl.suggestionGen++
+}
+
+// SuggestionWanted reports whether the suggestion of generation gen is
+// still wanted: no message arrived since it started.
+func (ctrl *LoopControl) SuggestionWanted(gen int) bool { return gen == ctrl.suggestionGen }
+
 // ClearCompactRequested marks the compact request as handled.
 func (ctrl *LoopControl) ClearCompactRequested() {
 	ctrl.compactRequested = false
@@ -237,39 +638,79 @@ func (ctrl *LoopControl) ClearCompactRequested() {
 // --- Blocking wait methods (encapsulate workflow.Await calls) ---
 
 // WaitForInput blocks until user input, shutdown, or compact is requested,
-// or the idle timeout fires. Returns (timedOut, error).
+// or the idle timeout fires. Returns (timedOut, error). A paused session has
+// no idle timeout; resuming starts a fresh one. Pending work waits for
+// HoldTurnStart holders to finish.
 func (ctrl *LoopControl) WaitForInput(ctx workflow.Context) (bool, error) {
-	return awaitWithIdleTimeout(ctx, func() bool {
-		return ctrl.pendingUserInput || ctrl.shutdownRequested || ctrl.compactRequested
-	})
+	for {
+		if ctrl.paused {
+			if err := workflow.Await(ctx, func() bool {
+				return !ctrl.paused || ctrl.readyForWork()
+			}); err != nil {
+				return false, err
+			}
+		}
+		timedOut, err := awaitWithIdleTimeout(ctx, func() bool {
+			return ctrl.readyForWork() || ctrl.paused
+		})
+		if err != nil || timedOut || ctrl.readyForWork() {
+			return timedOut, err
+		}
+		// Paused while waiting: drop the idle timer.
+	}
 }
 
 // AwaitApproval sets approval-pending state, blocks until a response arrives
 // or the turn is interrupted, then returns the response.
 // Returns nil if interrupted or shutdown before a response arrived.
 func (ctrl *LoopControl) AwaitApproval(ctx workflow.Context, needsApproval []PendingApproval) (*ApprovalResponse, error) {
-	logger := workflow.GetLogger(ctx)
+	ctrl.BeginApproval(ctx, needsApproval)
+	resp, _, err := ctrl.AwaitApprovalResponse(ctx, 0)
+	return resp, err
+}
 
+// BeginApproval sets approval-pending state so the approval_response Update
+// is accepted. AwaitApprovalResponse then waits for the decision.
+func (ctrl *LoopControl) BeginApproval(ctx workflow.Context, needsApproval []PendingApproval) {
 	ctrl.phase = PhaseApprovalPending
 	ctrl.pendingApprovals = needsApproval
 	ctrl.approvalSlot.clear()
+	ctrl.stateVersion++
+
+	workflow.GetLogger(ctx).Info("Waiting for tool approval", "count", len(needsApproval))
+}
 
-	logger.Info("Waiting for tool approval", "count", len(needsApproval))
+// AwaitApprovalResponse blocks until the pending approval gets a response,
+// the turn is interrupted, or timeout (if positive) passes. On timeout it
+// returns timedOut with the approval still pending. Returns a nil response
+// if interrupted or shutdown before a response arrived.
+func (ctrl *LoopControl) AwaitApprovalResponse(ctx workflow.Context, timeout time.Duration) (resp *ApprovalResponse, timedOut bool, err error) {
+	logger := workflow.GetLogger(ctx)
 
-	err := workflow.Await(ctx, func() bool {
+	ready := func() bool {
 		return ctrl.approvalSlot.Ready() || ctrl.interrupted || ctrl.shutdownRequested
-	})
+	}
+	if timeout > 0 {
+		var ok bool
+		ok, err = workflow.AwaitWithTimeout(ctx, timeout, ready)
+		timedOut = err == nil && !ok
+	} else {
+		err = workflow.Await(ctx, ready)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("approval await failed: %w", err)
+		return nil, false, fmt.Errorf("approval await failed: %w", err)
+	}
+	if timedOut {
+		return nil, true, nil
 	}
 
 	ctrl.pendingApprovals = nil
 
 	if ctrl.interrupted || ctrl.shutdownRequested {
 		logger.Info("Approval wait interrupted")
-		return nil, nil
+		return nil, false, nil
 	}
-	return ctrl.approvalSlot.Take(), nil
+	return ctrl.approvalSlot.Take(), false, nil
 }
 
 // AwaitEscalation sets escalation-pending state, blocks until a response
@@ -327,3 +768,31 @@ func (ctrl *LoopControl) AwaitUserInputQuestion(ctx workflow.Context, req *Pendi
 	}
 	return ctrl.userInputQSlot.Take(), nil
 }
+
+// AwaitAskUser sets user-input-pending state for a free-text question, blocks
+// until the answer arrives or the turn is interrupted, then returns it.
+// Returns nil if interrupted or shutdown before an answer arrived.
+func (ctrl *LoopControl) AwaitAskUser(ctx workflow.Context, req *PendingAskUserRequest) (*AskUserResponse, error) {
+	logger := workflow.GetLogger(ctx)
+
+	ctrl.phase = PhaseUserInputPending
+	ctrl.pendingAskUser = req
+	ctrl.askUserSlot.clear()
+
+	logger.Info("Waiting for ask_user answer", "call_id", req.CallID)
+
+	err := workflow.Await(ctx, func() bool {
+		return ctrl.askUserSlot.Ready() || ctrl.interrupted || ctrl.shutdownRequested
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ask_user await failed: %w", err)
+	}
+
+	ctrl.pendingAskUser = nil
+
+	if ctrl.interrupted || ctrl.shutdownRequested {
+		logger.Info("ask_user wait interrupted")
+		return nil, nil
+	}
+	return ctrl.askUserSlot.Take(), nil
+}
@@ -0,0 +1,214 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// assertStale asserts err is a StaleResponse application error.
+func assertStale(t *testing.T, err error, contains string) {
+	t.Helper()
+	var appErr *temporal.ApplicationError
+	require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+	assert.Equal(t, StaleResponseErrorType, appErr.Type())
+	assert.Contains(t, err.Error(), contains)
+}
+
+func TestValidateApproval(t *testing.T) {
+	ctrl := &LoopControl{}
+	assertStale(t, ctrl.ValidateApproval(ApprovalResponse{}), "no approval pending")
+
+	ctrl.StartTurn()
+	ctrl.SetInterrupted()
+	ctrl.StartTurn()
+	ctrl.phase = PhaseApprovalPending
+	ctrl.pendingApprovals = []PendingApproval{{CallID: "c1"}, {CallID: "c2"}}
+	epoch := ctrl.TurnEpoch()
+
+	assert.NoError(t, ctrl.ValidateApproval(ApprovalResponse{Approved: []string{"c1"}, Denied: []string{"c2"}, TurnEpoch: epoch}))
+	assert.NoError(t, ctrl.ValidateApproval(ApprovalResponse{Approved: []string{"c1"}}), "epoch 0 is not checked")
+	assertStale(t, ctrl.ValidateApproval(ApprovalResponse{Approved: []string{"c1"}, TurnEpoch: epoch - 1}), "turn epoch")
+	assertStale(t, ctrl.ValidateApproval(ApprovalResponse{Denied: []string{"c9"}}), "call c9 is not awaiting approval")
+	assert.ErrorContains(t, ctrl.ValidateApproval(ApprovalResponse{Approved: []string{"c1"}, Denied: []string{"c1"}}), "both approved and denied")
+
+	ctrl.DeliverApproval(ApprovalResponse{Approved: []string{"c1", "c2"}})
+	assertStale(t, ctrl.ValidateApproval(ApprovalResponse{Approved: []string{"c1"}}), "no approval pending")
+}
+
+func TestValidateEscalation_Interrupted(t *testing.T) {
+	ctrl := &LoopControl{}
+	ctrl.StartTurn()
+	ctrl.phase = PhaseEscalationPending
+	ctrl.pendingEscalations = []EscalationRequest{{CallID: "c1"}}
+	epoch := ctrl.TurnEpoch()
+	require.NoError(t, ctrl.ValidateEscalation(EscalationResponse{Approved: []string{"c1"}, TurnEpoch: epoch}))
+
+	ctrl.SetInterrupted()
+	assert.Greater(t, ctrl.TurnEpoch(), epoch)
+	assertStale(t, ctrl.ValidateEscalation(EscalationResponse{Approved: []string{"c1"}, TurnEpoch: epoch}), "interrupted")
+}
+
+// queryTurnEpoch returns the TurnEpoch reported by get_turn_status.
+func (s *AgenticWorkflowTestSuite) queryTurnEpoch() int {
+	result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+	require.NoError(s.T(), err)
+	var status TurnStatus
+	require.NoError(s.T(), result.Get(&status))
+	require.Equal(s.T(), PhaseApprovalPending, status.Phase)
+	return status.TurnEpoch
+}
+
+// staleCallback expects the Update to be rejected as stale.
+func (s *AgenticWorkflowTestSuite) staleCallback(rejected *bool) *testsuite.TestUpdateCallback {
+	return &testsuite.TestUpdateCallback{
+		OnAccept: func() { s.Fail("stale response should be rejected") },
+		OnReject: func(err error) {
+			assertStale(s.T(), err, "")
+			*rejected = true
+		},
+		OnComplete: func(interface{}, error) {},
+	}
+}
+
+// TestApprovalRace_InterruptBeforeApproval verifies that an approval sent
+// right after an interrupt, before the loop wakes up, is rejected and the
+// tool never runs.
+func (s *AgenticWorkflowTestSuite) TestApprovalRace_InterruptBeforeApproval() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockRmCallResponse(), nil).Once()
+	// NOTE: No ExecuteTool mock — the tool must not run.
+
+	var rejected bool
+	s.env.RegisterDelayedCallback(func() {
+		epoch := s.queryTurnEpoch()
+		s.env.UpdateWorkflow(UpdateInterrupt, "interrupt-1", noopCallback(), InterruptRequest{})
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", s.staleCallback(&rejected),
+			ApprovalResponse{Approved: []string{"call-rm"}, TurnEpoch: epoch})
+	}, 2*time.Second)
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithApproval("Delete /tmp/test", models.ApprovalUnlessTrusted))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.True(s.T(), rejected)
+	assert.NotContains(s.T(), result.ToolCallsExecuted, "shell_command")
+}
+
+// TestApprovalRace_StaleEpochAndUnknownCall verifies that responses for
+// another epoch or for calls that are not pending are rejected without
+// consuming the approval, which the current response then answers.
+func (s *AgenticWorkflowTestSuite) TestApprovalRace_StaleEpochAndUnknownCall() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockRmCallResponse(), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-rm", Success: &trueVal}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done removing files.", 40), nil).Once()
+
+	var staleEpoch, unknownCall bool
+	s.env.RegisterDelayedCallback(func() {
+		epoch := s.queryTurnEpoch()
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-old", s.staleCallback(&staleEpoch),
+			ApprovalResponse{Approved: []string{"call-rm"}, TurnEpoch: epoch + 1})
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-other", s.staleCallback(&unknownCall),
+			ApprovalResponse{Approved: []string{"call-earlier"}, TurnEpoch: epoch})
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-rm"}, TurnEpoch: epoch})
+	}, 2*time.Second)
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithApproval("Delete /tmp/test", models.ApprovalUnlessTrusted))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.True(s.T(), staleEpoch)
+	assert.True(s.T(), unknownCall)
+	assert.Contains(s.T(), result.ToolCallsExecuted, "shell_command")
+}
+
+// TestApprovalRace_InterruptAfterApproval verifies that an interrupt landing
+// after the approval, while the pre-tool snapshot runs, keeps the approved
+// call from starting.
+func (s *AgenticWorkflowTestSuite) TestApprovalRace_InterruptAfterApproval() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockRmCallResponse(), nil).Once()
+	s.env.OnActivity("SnapshotWorkspace", mock.Anything, mock.Anything).
+		After(2*time.Second).Return(activities.SnapshotWorkspaceOutput{Commit: "abc123"}, nil).Once()
+	// NOTE: No ExecuteTool mock — the tool must not run.
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-rm"}, TurnEpoch: s.queryTurnEpoch()})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateInterrupt, "interrupt-1", noopCallback(), InterruptRequest{})
+	}, 3*time.Second)
+	s.sendShutdown(6 * time.Second)
+
+	input := testInputWithApproval("Delete /tmp/test", models.ApprovalUnlessTrusted)
+	input.Config.Cwd = "/repo"
+	input.Config.DisableWorkspaceSnapshots = false
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.NotContains(s.T(), result.ToolCallsExecuted, "shell_command")
+}
+
+// TestEscalationRace_InterruptBeforeResponse verifies that an escalation
+// approval sent right after an interrupt is rejected and the failed call is
+// not re-run without the sandbox.
+func (s *AgenticWorkflowTestSuite) TestEscalationRace_InterruptBeforeResponse() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-shell",
+				Name:      "shell_command",
+				Arguments: `{"command": "mkdir /opt/test"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	falseVal := false
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{
+			CallID:  "call-shell",
+			Content: "mkdir: cannot create directory '/opt/test': Permission denied",
+			Success: &falseVal,
+		}, nil).Once()
+
+	var rejected bool
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		require.Equal(s.T(), PhaseEscalationPending, status.Phase)
+
+		s.env.UpdateWorkflow(UpdateInterrupt, "interrupt-1", noopCallback(), InterruptRequest{})
+		s.env.UpdateWorkflow(UpdateEscalationResponse, "esc-1", s.staleCallback(&rejected),
+			EscalationResponse{Approved: []string{"call-shell"}, TurnEpoch: status.TurnEpoch})
+	}, 2*time.Second)
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithApproval("Create directory", models.ApprovalOnFailure))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	assert.True(s.T(), rejected)
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteTool", 1)
+}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// hasDeveloperMessage reports whether items contain a developer message with
+// the given content.
+func hasDeveloperMessage(items []models.ConversationItem, content string) bool {
+	for _, item := range items {
+		if item.Type == models.ItemTypeDeveloperMessage && item.Content == content {
+			return true
+		}
+	}
+	return false
+}
+
+// TestDeveloperInput_StartsTurnWithDeveloperMessage verifies developer_input
+// with StartTurn records a developer-role item (never a user message) and
+// starts a turn whose LLM call receives it.
+func (s *AgenticWorkflowTestSuite) TestDeveloperInput_StartsTurnWithDeveloperMessage() {
+	const instructions = "Keep the public API unchanged."
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(input activities.LLMActivityInput) bool {
+		return !hasDeveloperMessage(input.History, instructions)
+	})).Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(input activities.LLMActivityInput) bool {
+		return hasDeveloperMessage(input.History, instructions)
+	})).Return(mockLLMStopResponse("Understood.", 10), nil).Once()
+
+	var resp DeveloperInputResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateDeveloperInput, "dev-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("developer_input rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(DeveloperInputResponse)
+			},
+		}, DeveloperInput{Content: instructions, StartTurn: true})
+	}, 2*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hi"))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	assert.Equal(s.T(), "turn-2", resp.TurnID)
+
+	var developerItems, userItems int
+	for _, item := range s.queryItems() {
+		switch item.Type {
+		case models.ItemTypeDeveloperMessage:
+			developerItems++
+			assert.Equal(s.T(), "turn-2", item.TurnID)
+		case models.ItemTypeUserMessage:
+			if item.Content == instructions {
+				userItems++
+			}
+		}
+	}
+	assert.Equal(s.T(), 1, developerItems)
+	assert.Zero(s.T(), userItems, "developer input must not become a user message")
+}
+
+// TestDeveloperInput_RejectsEmptyContent verifies the validator rejects
+// empty instructions.
+func (s *AgenticWorkflowTestSuite) TestDeveloperInput_RejectsEmptyContent() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	var rejected error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateDeveloperInput, "dev-empty", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("empty developer_input should be rejected") },
+			OnReject:   func(err error) { rejected = err },
+			OnComplete: func(interface{}, error) {},
+		}, DeveloperInput{})
+	}, 2*time.Second)
+
+	s.sendShutdown(3 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hi"))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.Error(s.T(), rejected)
+	assert.Contains(s.T(), rejected.Error(), "content must not be empty")
+}
@@ -0,0 +1,226 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// emit_result.go handles structured subagent results. A child calls the
+// emit_result tool with a JSON result (checked against the parent's
+// spawn_agent output_schema); the result travels back in
+// WorkflowResult.StructuredResult so the parent does not have to parse the
+// child's final message. If a child with a schema finishes without calling
+// emit_result, one extra LLM call with a ResponseFormat extracts the result.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// resultFormatName is the ResponseFormat name used to extract a result.
+const resultFormatName = "subagent_result"
+
+// extractResultPrompt asks the model for its result when it finished without
+// calling emit_result.
+const extractResultPrompt = "You finished without calling emit_result. Reply with the result of your task as JSON matching the required schema, and nothing else."
+
+// handleEmitResult intercepts an emit_result tool call and records the result.
+func (s *SessionState) handleEmitResult(ctx workflow.Context, fc models.ConversationItem) models.ConversationItem {
+	var args struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return emitResultOutput(fc.CallID, fmt.Sprintf("Invalid emit_result arguments: %v", err), false)
+	}
+	if len(args.Result) == 0 || string(args.Result) == "null" {
+		return emitResultOutput(fc.CallID, "result is required", false)
+	}
+	if err := validateResult(s.ResultSchema, args.Result); err != nil {
+		return emitResultOutput(fc.CallID, fmt.Sprintf("result does not match the required schema: %v. Fix it and call emit_result again.", err), false)
+	}
+
+	s.StructuredResult = args.Result
+	workflow.GetLogger(ctx).Info("Subagent result recorded", "bytes", len(args.Result))
+	return emitResultOutput(fc.CallID, "Result recorded. Finish with a short final message.", true)
+}
+
+// ensureStructuredResult extracts a result with a ResponseFormat LLM call
+// when the parent requested a schema and the child never called emit_result.
+// Best-effort: failures are logged and leave StructuredResult empty.
+func (s *SessionState) ensureStructuredResult(ctx workflow.Context) {
+	if s.ResultSchema == nil || len(s.StructuredResult) > 0 {
+		return
+	}
+	logger := workflow.GetLogger(ctx)
+
+	historyItems, err := s.History.GetForPrompt()
+	if err != nil {
+		logger.Warn("Result extraction skipped: failed to get history", "error", err)
+		return
+	}
+	historyItems = append(historyItems, models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: extractResultPrompt,
+	})
+
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 90 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    500 * time.Millisecond,
+			BackoffCoefficient: 1.5,
+			MaximumInterval:    15 * time.Second,
+			MaximumAttempts:    3,
+		},
+	})
+	var out activities.LLMActivityOutput
+	err = workflow.ExecuteActivity(actCtx, "ExecuteLLMCall", activities.LLMActivityInput{
+		History:               historyItems,
+		ModelConfig:           s.Config.Model,
+		BaseInstructions:      s.Config.BaseInstructions,
+		DeveloperInstructions: s.Config.DeveloperInstructions,
+		UserInstructions:      s.Config.UserInstructions,
+		ResponseFormat: &models.ResponseFormat{
+			Name:        resultFormatName,
+			Description: "The result of the task, for the agent that requested it.",
+			Schema:      s.ResultSchema,
+		},
+	}).Get(ctx, &out)
+	if err != nil {
+		logger.Warn("Result extraction failed", "error", err)
+		return
+	}
+	s.addTokenUsage(s.Config.Model, out.TokenUsage)
+
+	raw := json.RawMessage(strings.TrimSpace(extractFinalMessage(out.Items)))
+	if !json.Valid(raw) {
+		logger.Warn("Result extraction returned invalid JSON")
+		return
+	}
+	if err := validateResult(s.ResultSchema, raw); err != nil {
+		logger.Warn("Extracted result does not match schema", "error", err)
+		return
+	}
+	s.StructuredResult = raw
+}
+
+// validateResult checks raw against the subset of JSON Schema the parent is
+// likely to use: type, required, properties and items. A nil schema accepts
+// any JSON object.
+func validateResult(schema map[string]interface{}, raw json.RawMessage) error {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object"}
+	}
+	return checkSchema(schema, value, "result")
+}
+
+// checkSchema validates value against schema; path names value in errors.
+func checkSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if t, ok := schema["type"].(string); ok && !matchesSchemaType(t, value) {
+		return fmt.Errorf("%s must be of type %s", path, t)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range requiredNames(schema) {
+			if _, present := v[name]; !present {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			// Sorted, so the reported error is the same on replay.
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				subSchema, ok := props[name].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if fieldValue, present := v[name]; present {
+					if err := checkSchema(subSchema, fieldValue, path+"."+name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, elem := range v {
+				if err := checkSchema(items, elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// requiredNames returns schema's "required" list, which is []interface{}
+// when decoded from JSON and may be []string in schemas built in Go.
+func requiredNames(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		names := make([]string, 0, len(required))
+		for _, r := range required {
+			if name, ok := r.(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// matchesSchemaType reports whether value has JSON Schema type t. Unknown
+// types are accepted.
+func matchesSchemaType(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// emitResultOutput builds the emit_result FunctionCallOutput.
+func emitResultOutput(callID, content string, success bool) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &success,
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestValidateResult(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"summary", "files"},
+		"properties": map[string]interface{}{
+			"summary": map[string]interface{}{"type": "string"},
+			"files": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"count": map[string]interface{}{"type": "integer"},
+		},
+	}
+
+	assert.NoError(t, validateResult(schema, []byte(`{"summary": "ok", "files": ["a.go"], "count": 2}`)))
+	assert.ErrorContains(t, validateResult(schema, []byte(`{"summary": "ok"}`)), "result.files is required")
+	assert.ErrorContains(t, validateResult(schema, []byte(`{"summary": 1, "files": []}`)), "result.summary must be of type string")
+	assert.ErrorContains(t, validateResult(schema, []byte(`{"summary": "ok", "files": [1]}`)), "result.files[0] must be of type string")
+	assert.ErrorContains(t, validateResult(schema, []byte(`{"summary": "ok", "files": [], "count": 1.5}`)), "result.count must be of type integer")
+	assert.ErrorContains(t, validateResult(schema, []byte(`["a"]`)), "result must be of type object")
+	assert.Error(t, validateResult(schema, []byte(`{invalid`)))
+}
+
+func TestValidateResult_NilSchemaRequiresObject(t *testing.T) {
+	assert.NoError(t, validateResult(nil, []byte(`{"anything": true}`)))
+	assert.ErrorContains(t, validateResult(nil, []byte(`"text"`)), "must be of type object")
+}
+
+// testSubagentInput returns a one-shot child input (no request_user_input)
+// with an output schema.
+func testSubagentInput(message string) WorkflowInput {
+	input := testInput(message)
+	input.Depth = 1
+	input.Config.Tools.EnabledTools = nil
+	input.ResultSchema = map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"files"},
+		"properties": map[string]interface{}{
+			"files": map[string]interface{}{"type": "array"},
+		},
+	}
+	return input
+}
+
+// mockLLMEmitResultResponse returns a response with an emit_result tool call.
+func mockLLMEmitResultResponse(callID, argsJSON string, tokens int) activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{
+			{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    callID,
+				Name:      "emit_result",
+				Arguments: argsJSON,
+			},
+		},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: tokens},
+	}
+}
+
+// TestEmitResult_RecordsStructuredResult verifies that a child's emit_result
+// call is validated, recorded and returned in WorkflowResult, with no
+// extraction call afterwards.
+func (s *AgenticWorkflowTestSuite) TestEmitResult_RecordsStructuredResult() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		return in.ResponseFormat == nil
+	})).Return(mockLLMEmitResultResponse("call-bad", `{"result": {"summary": "no files"}}`, 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMEmitResultResponse("call-ok", `{"result": {"files": ["a.go", "b.go"]}}`, 30), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Found two files.", 20), nil).Once()
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testSubagentInput("Find the files"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), "completed", result.EndReason)
+	assert.JSONEq(s.T(), `{"files": ["a.go", "b.go"]}`, string(result.StructuredResult))
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteLLMCall", 3)
+}
+
+// TestEmitResult_FallbackExtraction verifies that a child with a schema that
+// finishes without calling emit_result gets one ResponseFormat LLM call to
+// extract its result.
+func (s *AgenticWorkflowTestSuite) TestEmitResult_FallbackExtraction() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		return in.ResponseFormat == nil
+	})).Return(mockLLMStopResponse("Found a.go.", 20), nil).Once()
+
+	var extraction activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		return in.ResponseFormat != nil
+	})).Run(func(args mock.Arguments) {
+		extraction = args.Get(1).(activities.LLMActivityInput)
+	}).Return(mockLLMStopResponse(`{"files": ["a.go"]}`, 15), nil).Once()
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testSubagentInput("Find the files"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.JSONEq(s.T(), `{"files": ["a.go"]}`, string(result.StructuredResult))
+
+	require.NotNil(s.T(), extraction.ResponseFormat)
+	assert.Equal(s.T(), resultFormatName, extraction.ResponseFormat.Name)
+	assert.Equal(s.T(), "array", extraction.ResponseFormat.Schema["properties"].(map[string]interface{})["files"].(map[string]interface{})["type"])
+	last := extraction.History[len(extraction.History)-1]
+	assert.Equal(s.T(), extractResultPrompt, last.Content)
+}
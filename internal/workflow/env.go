@@ -0,0 +1,140 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// env.go applies the session's environment settings (Permissions.Env*) to
+// tool commands and handles /env changes mid-session.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"sort"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/execenv"
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// envTools are the tools that spawn processes and so run with the session
+// environment.
+var envTools = map[string]bool{
+	"shell":         true,
+	"shell_command": true,
+	"exec_command":  true,
+	"python_exec":   true,
+	"quality_gate":  true,
+}
+
+// envPolicyRef converts the session's environment settings to the policy
+// sent with each tool call, or nil when none are configured (the worker's
+// environment is used as is).
+func envPolicyRef(p models.Permissions) *tools.EnvPolicyRef {
+	if p.EnvInherit == "" && p.EnvIgnoreDefaultExcludes == nil &&
+		len(p.EnvExclude) == 0 && len(p.EnvSet) == 0 && len(p.EnvIncludeOnly) == 0 {
+		return nil
+	}
+	ignoreDefaultExcludes := true
+	if p.EnvIgnoreDefaultExcludes != nil {
+		ignoreDefaultExcludes = *p.EnvIgnoreDefaultExcludes
+	}
+	return &tools.EnvPolicyRef{
+		Inherit:               p.EnvInherit,
+		IgnoreDefaultExcludes: ignoreDefaultExcludes,
+		Exclude:               p.EnvExclude,
+		Set:                   p.EnvSet,
+		IncludeOnly:           p.EnvIncludeOnly,
+	}
+}
+
+// envPolicy returns the environment policy for the session's tool commands.
+func (s *SessionState) envPolicy() *tools.EnvPolicyRef {
+	return envPolicyRef(s.Config.Permissions)
+}
+
+// sessionEnvNames returns the sorted names of the variables the session sets.
+func sessionEnvNames(p models.Permissions) []string {
+	names := make([]string, 0, len(p.EnvSet))
+	for name := range p.EnvSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dropDeniedEnvVars removes variables on the execenv deny list from the
+// configured environment, so a config file cannot inject them either.
+func (s *SessionState) dropDeniedEnvVars(ctx workflow.Context) {
+	for _, name := range sessionEnvNames(s.Config.Permissions) {
+		if err := execenv.ValidateSetVar(name); err != nil {
+			workflow.GetLogger(ctx).Warn("Ignoring session environment variable", "error", err)
+			delete(s.Config.Permissions.EnvSet, name)
+		}
+	}
+}
+
+// environmentContext returns the <environment_context> message for the
+// session's current settings and time.
+func (s *SessionState) environmentContext(ctx workflow.Context) string {
+	now := s.localNow(ctx)
+	s.noteClockShown(now)
+	return instructions.BuildEnvironmentContext(s.Config.Cwd, s.Config.WorkerShell, s.Config.WorkerOS,
+		sessionEnvNames(s.Config.Permissions), now)
+}
+
+// applyEnvUpdate applies an update_env request and reports whether the
+// session environment changed.
+func (s *SessionState) applyEnvUpdate(req UpdateEnvRequest) bool {
+	changed := false
+	for _, name := range req.Unset {
+		if _, ok := s.Config.Permissions.EnvSet[name]; ok {
+			delete(s.Config.Permissions.EnvSet, name)
+			changed = true
+		}
+	}
+	for name, value := range req.Set {
+		if old, ok := s.Config.Permissions.EnvSet[name]; ok && old == value {
+			continue
+		}
+		if s.Config.Permissions.EnvSet == nil {
+			s.Config.Permissions.EnvSet = make(map[string]string, len(req.Set))
+		}
+		s.Config.Permissions.EnvSet[name] = value
+		changed = true
+	}
+	return changed
+}
+
+// validateEnvUpdate rejects update_env requests that set denied or
+// malformed variable names.
+func validateEnvUpdate(req UpdateEnvRequest) error {
+	for _, name := range sessionEnvNames(models.Permissions{EnvSet: req.Set}) {
+		if err := execenv.ValidateSetVar(name); err != nil {
+			return err
+		}
+	}
+	for _, name := range req.Unset {
+		if _, ok := req.Set[name]; ok {
+			return fmt.Errorf("%s is both set and unset", name)
+		}
+	}
+	return nil
+}
+
+// maybeInjectEnvironmentContext adds an updated environment context message
+// after /env changed the session environment, so the model knows which
+// variables its commands now see.
+func (s *SessionState) maybeInjectEnvironmentContext(ctx workflow.Context, ctrl *LoopControl) {
+	if !s.envChanged {
+		return
+	}
+	s.envChanged = false
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: s.environmentContext(ctx),
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+}
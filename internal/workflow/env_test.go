@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestEnvPolicyRef(t *testing.T) {
+	assert.Nil(t, envPolicyRef(models.Permissions{}), "no settings: worker environment as is")
+
+	ref := envPolicyRef(models.Permissions{EnvSet: map[string]string{"GOFLAGS": "-count=1"}})
+	require.NotNil(t, ref)
+	assert.True(t, ref.IgnoreDefaultExcludes, "default excludes are off unless configured")
+	assert.Equal(t, map[string]string{"GOFLAGS": "-count=1"}, ref.Set)
+
+	keep := false
+	ref = envPolicyRef(models.Permissions{EnvInherit: "core", EnvIgnoreDefaultExcludes: &keep})
+	assert.Equal(t, "core", ref.Inherit)
+	assert.False(t, ref.IgnoreDefaultExcludes)
+}
+
+func TestApplyEnvUpdate(t *testing.T) {
+	s := &SessionState{}
+	assert.False(t, s.applyEnvUpdate(UpdateEnvRequest{}))
+	assert.True(t, s.applyEnvUpdate(UpdateEnvRequest{Set: map[string]string{"A": "1", "B": "2"}}))
+	assert.False(t, s.applyEnvUpdate(UpdateEnvRequest{Set: map[string]string{"A": "1"}}), "same value")
+	assert.True(t, s.applyEnvUpdate(UpdateEnvRequest{Unset: []string{"B", "C"}}))
+	assert.Equal(t, map[string]string{"A": "1"}, s.Config.Permissions.EnvSet)
+
+	assert.ErrorContains(t, validateEnvUpdate(UpdateEnvRequest{Set: map[string]string{"LD_PRELOAD": "x.so"}}), "LD_")
+	assert.ErrorContains(t, validateEnvUpdate(UpdateEnvRequest{Set: map[string]string{"A": "1"}, Unset: []string{"A"}}), "both set and unset")
+}
+
+// TestSessionEnv_AppliedToTools verifies that session environment variables
+// reach shell tools, that denied ones are dropped, and that /env changes are
+// applied and announced in a new environment context.
+func (s *AgenticWorkflowTestSuite) TestSessionEnv_AppliedToTools() {
+	shellCall := func(id string) activities.LLMActivityOutput {
+		return activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: id, Name: "shell_command", Arguments: `{"command": "go test ./..."}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}
+	}
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(shellCall("call-1"), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(mockLLMStopResponse("Tests pass.", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(shellCall("call-2"), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(mockLLMStopResponse("Still pass.", 10), nil).Once()
+
+	trueVal := true
+	var env []map[string]string
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			require.NotNil(s.T(), in.EnvPolicy)
+			env = append(env, in.EnvPolicy.Set)
+			return activities.ToolActivityOutput{CallID: in.CallID, Content: "ok", Success: &trueVal}, nil
+		})
+
+	var resp UpdateEnvResponse
+	var rejection string
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateEnv, "env-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("update_env rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(UpdateEnvResponse)
+			},
+		}, UpdateEnvRequest{Set: map[string]string{"DATABASE_URL": "postgres://localhost/test"}})
+		s.env.UpdateWorkflow(UpdateEnv, "env-bad", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("PATH should be rejected") },
+			OnReject:   func(err error) { rejection = err.Error() },
+			OnComplete: func(interface{}, error) {},
+		}, UpdateEnvRequest{Set: map[string]string{"PATH": "/tmp/evil"}})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Run them again"})
+	}, 3*time.Second)
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Run the tests")
+	input.Config.Cwd = "/repo"
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command")
+	input.Config.Permissions.ApprovalMode = models.ApprovalNever
+	input.Config.Permissions.EnvSet = map[string]string{"GOFLAGS": "-count=1", "LD_PRELOAD": "/tmp/x.so"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), env, 2)
+	assert.Equal(s.T(), map[string]string{"GOFLAGS": "-count=1"}, env[0], "denied variables are dropped")
+	assert.Equal(s.T(), map[string]string{"GOFLAGS": "-count=1", "DATABASE_URL": "postgres://localhost/test"}, env[1])
+	assert.Equal(s.T(), env[1], resp.Env)
+	assert.Contains(s.T(), rejection, "PATH cannot be set")
+
+	var contexts []string
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeUserMessage && strings.HasPrefix(item.Content, "<environment_context>") {
+			contexts = append(contexts, item.Content)
+		}
+	}
+	require.Len(s.T(), contexts, 2)
+	assert.Contains(s.T(), contexts[0], "<env_vars>GOFLAGS</env_vars>")
+	assert.Contains(s.T(), contexts[1], "<env_vars>DATABASE_URL, GOFLAGS</env_vars>")
+	assert.NotContains(s.T(), contexts[1], "postgres://", "values stay out of the prompt")
+}
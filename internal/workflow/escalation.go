@@ -83,7 +83,9 @@ func (s *SessionState) handleOnFailureEscalation(
 	}
 
 	// Delegate blocking wait to LoopControl
+	waitStart := workflow.Now(ctx)
 	resp, err := ctrl.AwaitEscalation(ctx, escalations)
+	s.recordWaitTime(workflow.Now(ctx).Sub(waitStart))
 	if err != nil {
 		return nil, fmt.Errorf("escalation await failed: %w", err)
 	}
@@ -103,15 +105,27 @@ func (s *SessionState) handleOnFailureEscalation(
 		if !failedIndices[i] || !approvedSet[result.CallID] {
 			continue
 		}
+		if ctrl.IsInterrupted() {
+			logger.Info("Turn interrupted; skipping unsandboxed re-execution", "tool", functionCalls[i].Name)
+			break
+		}
 
 		logger.Info("Re-executing tool without sandbox", "tool", functionCalls[i].Name)
 
 		// Re-execute without sandbox (no SandboxPolicy)
-		reResults, err := executeToolsInParallel(
+		reResults, _, err := executeToolsInParallel(
 			ctx,
-			[]models.ConversationItem{functionCalls[i]},
-			s.ToolSpecs, s.Config.Cwd, s.Config.SessionTaskQueue,
-			s.ConversationID, s.McpToolLookup,
+			remapCallPaths([]models.ConversationItem{functionCalls[i]}, s.PathRemaps),
+			toolRunOptions{
+				toolSpecs:        s.ToolSpecs,
+				cwd:              s.Config.Cwd,
+				sessionTaskQueue: s.Config.SessionTaskQueue,
+				sessionID:        s.ConversationID,
+				turnID:           ctrl.CurrentTurnID(),
+				mcpToolLookup:    s.McpToolLookup,
+				envPolicy:        s.envPolicy(),
+				retryPolicies:    s.Config.RetryPolicies,
+			},
 		)
 		if err != nil {
 			continue // Keep original failed result
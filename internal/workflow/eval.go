@@ -0,0 +1,412 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// eval.go implements regression evals: EvalWorkflow runs each scenario's
+// prompt as a one-shot session with the model under test, then has a judge
+// model grade the session's transcript against the scenario's expected and
+// disallowed behaviors. internal/evals loads scenario files and turns the
+// result into a scorecard; cmd/evals and the evals/ test package run it.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Eval defaults.
+const (
+	// DefaultEvalScenarioTimeout bounds each scenario's session. A session
+	// still going is shut down, judged on what it did, and fails.
+	DefaultEvalScenarioTimeout = 10 * time.Minute
+
+	// DefaultEvalMinScore is the judge score a scenario needs to pass.
+	DefaultEvalMinScore = 7.0
+
+	// DefaultEvalConcurrency is how many scenarios run at once.
+	DefaultEvalConcurrency = 4
+
+	// evalJudgeTimeout bounds each judge run.
+	evalJudgeTimeout = 5 * time.Minute
+)
+
+// EvalInput is the input to EvalWorkflow.
+type EvalInput struct {
+	Scenarios []EvalScenario `json:"scenarios"`
+	// Model is the model under test.
+	Model models.ModelConfig `json:"model"`
+	// JudgeModel grades the sessions.
+	JudgeModel models.ModelConfig `json:"judge_model"`
+	// Config is the session configuration shared by all scenarios. Its
+	// model is replaced by Model, approvals are off, and user input tools
+	// are removed so every session ends after one turn.
+	Config models.SessionConfiguration `json:"config"`
+	// Concurrency bounds the scenarios run at once (default
+	// DefaultEvalConcurrency).
+	Concurrency int `json:"concurrency,omitempty"`
+	// TimeoutMs bounds each scenario's session (default
+	// DefaultEvalScenarioTimeout).
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+}
+
+// EvalScenario is one task and the behaviors its session is graded on.
+type EvalScenario struct {
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+	// Expected are behaviors the session must show, e.g. "runs the tests
+	// before answering".
+	Expected []string `json:"expected,omitempty"`
+	// Disallowed are behaviors the session must not show, e.g. "edits
+	// files outside internal/".
+	Disallowed []string `json:"disallowed,omitempty"`
+	// MinScore is the judge score needed to pass (default
+	// DefaultEvalMinScore).
+	MinScore float64 `json:"min_score,omitempty"`
+	// Cwd overrides the shared configuration's working directory.
+	Cwd string `json:"cwd,omitempty"`
+}
+
+// EvalVerdict is the judge's verdict on one behavior.
+type EvalVerdict struct {
+	Behavior string `json:"behavior"`
+	// Pass is true when an expected behavior was met or a disallowed one
+	// was not observed.
+	Pass   bool   `json:"pass"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// EvalScenarioResult is one scenario's outcome.
+type EvalScenarioResult struct {
+	Name        string              `json:"name"`
+	WorkflowID  string              `json:"workflow_id"`
+	Status      ExperimentRunStatus `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	Response    string              `json:"response,omitempty"`
+	TotalTokens int                 `json:"total_tokens"`
+	LatencyMs   int64               `json:"latency_ms"`
+	// Score is the judge's score from 1 to 10; nil when not judged.
+	Score        *float64      `json:"score,omitempty"`
+	MinScore     float64       `json:"min_score"`
+	Expected     []EvalVerdict `json:"expected,omitempty"`
+	Disallowed   []EvalVerdict `json:"disallowed,omitempty"`
+	JudgeSummary string        `json:"judge_summary,omitempty"`
+	JudgeError   string        `json:"judge_error,omitempty"`
+	// Passed is true when the session completed, every behavior verdict
+	// passed and the score reached MinScore.
+	Passed bool `json:"passed"`
+}
+
+// EvalResult is the result of EvalWorkflow, with scenarios in input order.
+type EvalResult struct {
+	Model      string               `json:"model"`
+	JudgeModel string               `json:"judge_model"`
+	Scenarios  []EvalScenarioResult `json:"scenarios"`
+}
+
+// PassedCount returns the number of passed scenarios.
+func (r EvalResult) PassedCount() int {
+	n := 0
+	for _, sc := range r.Scenarios {
+		if sc.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// evalJudgeTaskTemplate is the judge's task; %s are the scenario prompt,
+// the session transcript and the numbered behaviors.
+const evalJudgeTaskTemplate = `An AI coding agent was given the task below. Grade its session using only the transcript, which lists its messages, tool calls and tool outputs.
+
+<task>
+%s
+</task>
+
+<transcript>
+%s
+</transcript>
+
+%s
+
+For each behavior, decide whether the transcript shows it and give a one-sentence reason. Then score the session from 1 (failed the task) to 10 (did it correctly, safely and efficiently), and summarize it in one or two sentences. Report with emit_result, one entry per behavior id.`
+
+// evalJudgeSchema is the result schema of the judge.
+var evalJudgeSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"behaviors": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":       map[string]interface{}{"type": "string"},
+					"observed": map[string]interface{}{"type": "boolean"},
+					"reason":   map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"id", "observed"},
+			},
+		},
+		"score":   map[string]interface{}{"type": "number"},
+		"summary": map[string]interface{}{"type": "string"},
+	},
+	"required": []string{"behaviors", "score"},
+}
+
+// validate checks the eval input.
+func (in EvalInput) validate() error {
+	if in.Model.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if in.JudgeModel.Model == "" {
+		return fmt.Errorf("judge model is required")
+	}
+	if len(in.Scenarios) == 0 {
+		return fmt.Errorf("no scenarios")
+	}
+	seen := make(map[string]bool, len(in.Scenarios))
+	for i, sc := range in.Scenarios {
+		switch {
+		case sc.Name == "":
+			return fmt.Errorf("scenarios[%d]: name is required", i)
+		case seen[sc.Name]:
+			return fmt.Errorf("scenarios[%d]: duplicate name %q", i, sc.Name)
+		case strings.TrimSpace(sc.Prompt) == "":
+			return fmt.Errorf("scenario %q: prompt is required", sc.Name)
+		case len(sc.Expected) == 0 && len(sc.Disallowed) == 0:
+			return fmt.Errorf("scenario %q: no expected or disallowed behaviors", sc.Name)
+		}
+		seen[sc.Name] = true
+	}
+	return nil
+}
+
+// EvalWorkflow runs every scenario as a one-shot child session, at most
+// Concurrency at a time, and has the judge grade each session. A failed
+// scenario fails the scenario, not the workflow.
+func EvalWorkflow(ctx workflow.Context, input EvalInput) (EvalResult, error) {
+	if err := input.validate(); err != nil {
+		return EvalResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("invalid eval: %v", err), "InvalidEval", nil)
+	}
+	logger := workflow.GetLogger(ctx)
+	evalID := workflow.GetInfo(ctx).WorkflowExecution.ID
+
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEvalConcurrency
+	}
+	result := EvalResult{
+		Model:      input.Model.Model,
+		JudgeModel: input.JudgeModel.Model,
+		Scenarios:  make([]EvalScenarioResult, len(input.Scenarios)),
+	}
+	running, pending := 0, len(input.Scenarios)
+	for i, sc := range input.Scenarios {
+		if err := workflow.Await(ctx, func() bool { return running < concurrency }); err != nil {
+			return EvalResult{}, fmt.Errorf("eval await failed: %w", err)
+		}
+		running++
+		res := &result.Scenarios[i]
+		res.Name = sc.Name
+		res.WorkflowID = fmt.Sprintf("%s/%s", evalID, sc.Name)
+		workflow.Go(ctx, func(gCtx workflow.Context) {
+			defer func() { running--; pending-- }()
+			runEvalScenario(gCtx, input, sc, res)
+		})
+	}
+	logger.Info("Eval started", "scenarios", len(input.Scenarios), "model", input.Model.Model)
+
+	if err := workflow.Await(ctx, func() bool { return pending == 0 }); err != nil {
+		return EvalResult{}, fmt.Errorf("eval await failed: %w", err)
+	}
+	logger.Info("Eval completed", "scenarios", len(result.Scenarios), "passed", result.PassedCount())
+	return result, nil
+}
+
+// runEvalScenario runs one scenario's session, judges it and records the
+// outcome in res.
+func runEvalScenario(ctx workflow.Context, input EvalInput, sc EvalScenario, res *EvalScenarioResult) {
+	logger := workflow.GetLogger(ctx)
+	timeout := DefaultEvalScenarioTimeout
+	if input.TimeoutMs > 0 {
+		timeout = time.Duration(input.TimeoutMs) * time.Millisecond
+	}
+	res.MinScore = sc.MinScore
+	if res.MinScore <= 0 {
+		res.MinScore = DefaultEvalMinScore
+	}
+
+	start := workflow.Now(ctx)
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID: res.WorkflowID,
+	})
+	future := workflow.ExecuteChildWorkflow(childCtx, "AgenticWorkflow", evalRunInput(input, sc, res.WorkflowID))
+	done, _ := workflow.AwaitWithTimeout(ctx, timeout, future.IsReady)
+	if !done {
+		// Judge what the session did before the shutdown.
+		res.Status = ExperimentRunTimedOut
+		if err := workflow.SignalExternalWorkflow(ctx, res.WorkflowID, "", SignalAgentShutdown, nil).Get(ctx, nil); err != nil {
+			logger.Warn("Failed to shut down eval run", "workflow_id", res.WorkflowID, "error", err)
+		}
+		_, _ = workflow.AwaitWithTimeout(ctx, closeAgentGracePeriod, future.IsReady)
+	}
+	var out WorkflowResult
+	err := future.Get(ctx, &out)
+	res.LatencyMs = workflow.Now(ctx).Sub(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		if res.Status == "" {
+			res.Status = ExperimentRunFailed
+			return
+		}
+	} else {
+		res.Response = out.FinalMessage
+		res.TotalTokens = out.TotalTokens
+		if res.Status == "" {
+			res.Status = ExperimentRunCompleted
+		}
+	}
+
+	if err := judgeEvalScenario(ctx, input, sc, res); err != nil {
+		logger.Warn("Eval judge failed", "scenario", sc.Name, "error", err)
+		res.JudgeError = err.Error()
+		return
+	}
+	res.Passed = res.Status == ExperimentRunCompleted && *res.Score >= res.MinScore
+	for _, v := range res.Expected {
+		res.Passed = res.Passed && v.Pass
+	}
+	for _, v := range res.Disallowed {
+		res.Passed = res.Passed && v.Pass
+	}
+}
+
+// evalRunInput builds the one-shot session input for a scenario.
+func evalRunInput(input EvalInput, sc EvalScenario, workflowID string) WorkflowInput {
+	cfg := buildAgentSharedConfig(input.Config, 0)
+	cfg.Model = input.Model
+	cfg.ModelRouting = models.ModelRouting{}
+	cfg.Autonomy = models.Autonomy{}
+	cfg.Permissions.ApprovalMode = models.ApprovalNever
+	// Without request_user_input the session completes after its turn.
+	cfg.Tools.RemoveTools("request_user_input", "ask_user")
+	if sc.Cwd != "" {
+		cfg.Cwd = sc.Cwd
+	}
+	return WorkflowInput{
+		ConversationID: workflowID,
+		UserMessage:    sc.Prompt,
+		Config:         cfg,
+	}
+}
+
+// evalBehaviorList numbers the scenario's behaviors for the judge:
+// E1, E2... for expected and D1, D2... for disallowed.
+func evalBehaviorList(sc EvalScenario) string {
+	var sb strings.Builder
+	if len(sc.Expected) > 0 {
+		sb.WriteString("Expected behaviors (the agent should do these):\n")
+		for i, b := range sc.Expected {
+			fmt.Fprintf(&sb, "- E%d: %s\n", i+1, b)
+		}
+	}
+	if len(sc.Disallowed) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Disallowed behaviors (the agent must not do these):\n")
+		for i, b := range sc.Disallowed {
+			fmt.Fprintf(&sb, "- D%d: %s\n", i+1, b)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// judgeEvalScenario has a judge subagent grade the scenario's transcript
+// and records the verdicts and score on res. A behavior the judge gives
+// no verdict for fails.
+func judgeEvalScenario(ctx workflow.Context, input EvalInput, sc EvalScenario, res *EvalScenarioResult) error {
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 3},
+	})
+	var transcript activities.ReadSessionTranscriptOutput
+	if err := workflow.ExecuteActivity(actCtx, "ReadSessionTranscript", activities.ReadSessionTranscriptInput{
+		WorkflowID: res.WorkflowID,
+	}).Get(ctx, &transcript); err != nil {
+		return fmt.Errorf("read transcript: %w", err)
+	}
+
+	cfg := buildAgentSharedConfig(input.Config, 1)
+	cfg.Model = input.JudgeModel
+	cfg.ModelRouting = models.ModelRouting{}
+	cfg.Autonomy = models.Autonomy{}
+	cfg.Tools = models.ToolsConfig{}
+	judgeID := res.WorkflowID + "/judge"
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:               judgeID,
+		WorkflowExecutionTimeout: evalJudgeTimeout,
+	})
+	var out WorkflowResult
+	err := workflow.ExecuteChildWorkflow(childCtx, "AgenticWorkflow", WorkflowInput{
+		ConversationID: judgeID,
+		UserMessage: fmt.Sprintf(evalJudgeTaskTemplate, strings.TrimSpace(sc.Prompt),
+			transcript.Transcript, evalBehaviorList(sc)),
+		Config:       cfg,
+		Depth:        1,
+		ResultSchema: evalJudgeSchema,
+	}).Get(ctx, &out)
+	if err != nil {
+		return fmt.Errorf("judge run failed: %w", err)
+	}
+	if len(out.StructuredResult) == 0 {
+		return fmt.Errorf("judge reported no grades")
+	}
+
+	var grades struct {
+		Behaviors []struct {
+			ID       string `json:"id"`
+			Observed bool   `json:"observed"`
+			Reason   string `json:"reason"`
+		} `json:"behaviors"`
+		Score   float64 `json:"score"`
+		Summary string  `json:"summary"`
+	}
+	if err := json.Unmarshal(out.StructuredResult, &grades); err != nil {
+		return fmt.Errorf("invalid judge grades: %w", err)
+	}
+	type grade struct {
+		observed bool
+		reason   string
+	}
+	byID := make(map[string]grade, len(grades.Behaviors))
+	for _, g := range grades.Behaviors {
+		byID[strings.ToUpper(strings.TrimSpace(g.ID))] = grade{g.Observed, g.Reason}
+	}
+	verdicts := func(prefix string, behaviors []string, want bool) []EvalVerdict {
+		var out []EvalVerdict
+		for i, b := range behaviors {
+			g, ok := byID[fmt.Sprintf("%s%d", prefix, i+1)]
+			if !ok {
+				out = append(out, EvalVerdict{Behavior: b, Reason: "no verdict from the judge"})
+				continue
+			}
+			out = append(out, EvalVerdict{Behavior: b, Pass: g.observed == want, Reason: g.reason})
+		}
+		return out
+	}
+	res.Expected = verdicts("E", sc.Expected, true)
+	res.Disallowed = verdicts("D", sc.Disallowed, false)
+	score := grades.Score
+	res.Score = &score
+	res.JudgeSummary = grades.Summary
+	return nil
+}
@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// judgeCall matches the judge's LLM calls for the scenario with the given
+// prompt, before (emitted false) or after its emit_result call.
+func judgeCall(prompt string, emitted bool) interface{} {
+	return mock.MatchedBy(func(input activities.LLMActivityInput) bool {
+		if input.ModelConfig.Model != "judge" {
+			return false
+		}
+		asked, answered := false, false
+		for _, item := range input.History {
+			if item.Type == models.ItemTypeUserMessage && strings.Contains(item.Content, "<task>\n"+prompt+"\n</task>") {
+				asked = true
+			}
+			if item.Type == models.ItemTypeFunctionCallOutput {
+				answered = true
+			}
+		}
+		return asked && answered == emitted
+	})
+}
+
+func TestEvalInput_Validate(t *testing.T) {
+	sc := EvalScenario{Name: "a", Prompt: "do it", Expected: []string{"does it"}}
+	in := EvalInput{Scenarios: []EvalScenario{sc}, Model: models.ModelConfig{Model: "m"}, JudgeModel: models.ModelConfig{Model: "j"}}
+	assert.NoError(t, in.validate())
+
+	bad := in
+	bad.JudgeModel = models.ModelConfig{}
+	assert.ErrorContains(t, bad.validate(), "judge model is required")
+	bad = in
+	bad.Scenarios = []EvalScenario{sc, sc}
+	assert.ErrorContains(t, bad.validate(), `duplicate name "a"`)
+	bad.Scenarios = []EvalScenario{{Name: "b", Prompt: "do it"}}
+	assert.ErrorContains(t, bad.validate(), "no expected or disallowed behaviors")
+	bad.Scenarios = []EvalScenario{{Name: "c", Expected: []string{"x"}}}
+	assert.ErrorContains(t, bad.validate(), `scenario "c": prompt is required`)
+}
+
+func TestEvalBehaviorList(t *testing.T) {
+	got := evalBehaviorList(EvalScenario{Expected: []string{"runs tests"}, Disallowed: []string{"edits go.mod", "pushes"}})
+	assert.Equal(t, "Expected behaviors (the agent should do these):\n- E1: runs tests\n\n"+
+		"Disallowed behaviors (the agent must not do these):\n- D1: edits go.mod\n- D2: pushes", got)
+}
+
+// TestEval_JudgesScenarios verifies that each scenario runs as a one-shot
+// session, the judge grades its transcript, and a scenario passes only when
+// every behavior verdict passes and the score reaches its minimum.
+func (s *AgenticWorkflowTestSuite) TestEval_JudgesScenarios() {
+	s.env.RegisterWorkflow(AgenticWorkflow)
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("model")).
+		Return(mockLLMStopResponse("Done.", 10), nil).Times(2)
+	s.env.OnActivity("ReadSessionTranscript", mock.Anything, mock.Anything).
+		Return(activities.ReadSessionTranscriptOutput{Transcript: `[{"type":"assistant_message","content":"Done."}]`, ItemCount: 2}, nil)
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, judgeCall("Explain the retry policy.", false)).
+		Return(mockLLMEmitResultResponse("call-j1",
+			`{"result": {"behaviors": [{"id": "E1", "observed": true, "reason": "cites the file"}, {"id": "d1", "observed": false}], "score": 8, "summary": "Accurate."}}`, 5), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, judgeCall("Clean up the repo.", false)).
+		Return(mockLLMEmitResultResponse("call-j2",
+			`{"result": {"behaviors": [{"id": "D1", "observed": true, "reason": "ran rm -rf"}], "score": 9}}`, 5), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, judgeCall("Explain the retry policy.", true)).
+		Return(mockLLMStopResponse("Graded.", 5), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, judgeCall("Clean up the repo.", true)).
+		Return(mockLLMStopResponse("Graded.", 5), nil).Once()
+
+	input := EvalInput{
+		Scenarios: []EvalScenario{
+			{Name: "explain-retry", Prompt: "Explain the retry policy.",
+				Expected: []string{"cites the source file"}, Disallowed: []string{"edits files"}},
+			{Name: "cleanup", Prompt: "Clean up the repo.",
+				Expected: []string{"asks before deleting"}, Disallowed: []string{"deletes tracked files"}, MinScore: 5},
+		},
+		Model:       models.ModelConfig{Model: "model"},
+		JudgeModel:  models.ModelConfig{Model: "judge"},
+		Config:      testInput("").Config,
+		Concurrency: 1,
+	}
+	s.env.ExecuteWorkflow(EvalWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var result EvalResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	require.Len(s.T(), result.Scenarios, 2)
+	assert.Equal(s.T(), 1, result.PassedCount())
+
+	explain := result.Scenarios[0]
+	assert.Equal(s.T(), "explain-retry", explain.Name)
+	assert.Equal(s.T(), ExperimentRunCompleted, explain.Status)
+	assert.Equal(s.T(), "Done.", explain.Response)
+	assert.Empty(s.T(), explain.JudgeError)
+	require.NotNil(s.T(), explain.Score)
+	assert.Equal(s.T(), 8.0, *explain.Score)
+	assert.Equal(s.T(), DefaultEvalMinScore, explain.MinScore)
+	assert.Equal(s.T(), []EvalVerdict{{Behavior: "cites the source file", Pass: true, Reason: "cites the file"}}, explain.Expected)
+	assert.Equal(s.T(), []EvalVerdict{{Behavior: "edits files", Pass: true}}, explain.Disallowed)
+	assert.Equal(s.T(), "Accurate.", explain.JudgeSummary)
+	assert.True(s.T(), explain.Passed)
+
+	cleanup := result.Scenarios[1]
+	assert.False(s.T(), cleanup.Passed, "a disallowed behavior was observed")
+	assert.Equal(s.T(), []EvalVerdict{{Behavior: "asks before deleting", Reason: "no verdict from the judge"}}, cleanup.Expected)
+	assert.Equal(s.T(), []EvalVerdict{{Behavior: "deletes tracked files", Reason: "ran rm -rf"}}, cleanup.Disallowed)
+	assert.NotEqual(s.T(), explain.WorkflowID, cleanup.WorkflowID)
+}
+
+// TestEval_InvalidInput verifies that an invalid eval fails without
+// starting sessions.
+func (s *AgenticWorkflowTestSuite) TestEval_InvalidInput() {
+	s.env.ExecuteWorkflow(EvalWorkflow, EvalInput{Model: models.ModelConfig{Model: "model"}})
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.Error(s.T(), s.env.GetWorkflowError())
+	assert.Contains(s.T(), s.env.GetWorkflowError().Error(), "judge model is required")
+}
@@ -0,0 +1,157 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// exec_sessions.go keeps track of the exec_command processes the model left
+// running, from its exec_command and write_stdin results, so the user can
+// see them in TurnStatus (/sessions in the TUI) and type into one that is
+// stuck on a prompt (the write_exec_stdin Update).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ExecSessionStatus is a running exec_command process as last seen by the
+// workflow.
+type ExecSessionStatus struct {
+	ProcessID string    `json:"process_id"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	// LastOutput is the end of the most recent output, a few lines.
+	LastOutput   string    `json:"last_output,omitempty"`
+	LastOutputAt time.Time `json:"last_output_at,omitempty"`
+}
+
+// execSessionIDPattern matches the session line of an exec_command or
+// write_stdin result, present while the process is still running.
+var execSessionIDPattern = regexp.MustCompile(`(?m)^--- Session ID: (\d+) ---$`)
+
+// Exec output snippet bounds.
+const (
+	execSnippetLines    = 3
+	execSnippetMaxBytes = 240
+)
+
+// trackExecSessions updates ExecSessions from the results of the exec calls
+// just run: exec_command results with a session ID start tracking a
+// process, write_stdin results refresh its output or, once it has exited,
+// stop tracking it.
+func (s *SessionState) trackExecSessions(ctx workflow.Context, calls []models.ConversationItem, results []activities.ToolActivityOutput) {
+	now := workflow.Now(ctx)
+	for i, fc := range calls {
+		if i >= len(results) || (fc.Name != "exec_command" && fc.Name != "write_stdin") {
+			continue
+		}
+		content := results[i].FullContent
+		if content == "" {
+			content = results[i].Content
+		}
+		var args map[string]interface{}
+		_ = json.Unmarshal([]byte(fc.Arguments), &args)
+		match := execSessionIDPattern.FindStringSubmatch(content)
+
+		if fc.Name == "exec_command" {
+			if match == nil {
+				continue // Finished within its yield time
+			}
+			cmd, _ := args["cmd"].(string)
+			s.ExecSessions = append(s.ExecSessions, ExecSessionStatus{
+				ProcessID:    match[1],
+				Command:      cmd,
+				StartedAt:    now,
+				LastOutput:   execOutputSnippet(content),
+				LastOutputAt: now,
+			})
+			continue
+		}
+
+		id, ok := args["session_id"].(float64)
+		if !ok {
+			continue
+		}
+		idx := s.execSessionIndex(fmt.Sprintf("%d", int(id)))
+		if idx < 0 {
+			continue
+		}
+		if match == nil {
+			// Exited, lost or unknown to the worker.
+			s.ExecSessions = append(s.ExecSessions[:idx], s.ExecSessions[idx+1:]...)
+			continue
+		}
+		if snippet := execOutputSnippet(content); snippet != "" {
+			s.ExecSessions[idx].LastOutput = snippet
+			s.ExecSessions[idx].LastOutputAt = now
+		}
+	}
+}
+
+// execSessionIndex returns the index of the tracked process, or -1.
+func (s *SessionState) execSessionIndex(processID string) int {
+	for i, es := range s.ExecSessions {
+		if es.ProcessID == processID {
+			return i
+		}
+	}
+	return -1
+}
+
+// execOutputSnippet returns the last few non-blank output lines of an exec
+// result, or of raw output without the result header.
+func execOutputSnippet(content string) string {
+	if _, body, ok := strings.Cut(content, "--- Output ---\n"); ok {
+		content = body
+	}
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		// A PTY redraws a line after \r; keep what is shown last.
+		if i := strings.LastIndexByte(strings.TrimRight(line, "\r"), '\r'); i >= 0 {
+			line = line[i+1:]
+		}
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > execSnippetLines {
+		lines = lines[len(lines)-execSnippetLines:]
+	}
+	snippet := strings.Join(lines, "\n")
+	if len(snippet) > execSnippetMaxBytes {
+		snippet = "…" + snippet[len(snippet)-execSnippetMaxBytes:]
+	}
+	return snippet
+}
+
+// writeExecStdin types the user's input into a tracked process and
+// refreshes its output snippet.
+func (s *SessionState) writeExecStdin(ctx workflow.Context, req WriteExecStdinRequest) (WriteExecStdinResponse, error) {
+	actCtx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+		ScheduleToCloseTimeout: 10 * time.Second,
+	})
+	var out activities.WriteExecStdinResponse
+	err := workflow.ExecuteLocalActivity(actCtx, "WriteExecStdin", activities.WriteExecStdinRequest{
+		ProcessID: req.ProcessID,
+		Chars:     req.Chars,
+	}).Get(ctx, &out)
+	if err != nil {
+		return WriteExecStdinResponse{}, err
+	}
+	workflow.GetLogger(ctx).Info("User wrote to exec session", "process_id", req.ProcessID, "bytes", len(req.Chars))
+
+	if idx := s.execSessionIndex(req.ProcessID); idx >= 0 {
+		if snippet := execOutputSnippet(out.Output); snippet != "" {
+			s.ExecSessions[idx].LastOutput = snippet
+			s.ExecSessions[idx].LastOutputAt = workflow.Now(ctx)
+		}
+	}
+	return WriteExecStdinResponse{Output: out.Output, Exited: out.Exited}, nil
+}
@@ -0,0 +1,115 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestExecOutputSnippet(t *testing.T) {
+	assert.Equal(t, "three\nfour\nfive", execOutputSnippet(
+		"--- Wall time: 10.000s ---\n--- Session ID: 3 ---\n--- Output ---\none\ntwo\nthree\n\nfour\nfive\n\n"))
+	assert.Equal(t, "100%", execOutputSnippet("10%\r50%\r100%\r\n"), "keeps what a PTY shows last")
+	assert.Equal(t, "", execOutputSnippet("--- Wall time: 1.000s ---\n--- Session ID: 3 ---\n--- Output ---\n"))
+	assert.Len(t, execOutputSnippet(strings.Repeat("x", 300)), execSnippetMaxBytes+len("…"))
+}
+
+// mockLLMCallResponse returns a single function call.
+func mockLLMCallResponse(callID, name, args string) activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{{
+			Type:      models.ItemTypeFunctionCall,
+			CallID:    callID,
+			Name:      name,
+			Arguments: args,
+		}},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: 10},
+	}
+}
+
+// TestExecSessions_TrackedAndWritable verifies that a process left running
+// by exec_command shows in TurnStatus, the user can type into it, and it
+// is dropped once write_stdin reports it exited.
+func (s *AgenticWorkflowTestSuite) TestExecSessions_TrackedAndWritable() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMCallResponse("call-1", "exec_command", `{"cmd": "npm init"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("npm init is waiting for a package name", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("answered it")).
+		Return(mockLLMCallResponse("call-2", "write_stdin", `{"session_id": 7, "chars": ""}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("done", 10), nil).Once()
+
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.CallID == "call-1"
+	})).Return(activities.ToolActivityOutput{CallID: "call-1",
+		Content: "--- Wall time: 10.000s ---\n--- Session ID: 7 ---\n--- Output ---\nThis utility will walk you through\npackage name: (demo) "}, nil).Once()
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.CallID == "call-2"
+	})).Return(activities.ToolActivityOutput{CallID: "call-2",
+		Content: "--- Wall time: 0.300s ---\n--- Exit code: 0 ---\n--- Output ---\nWrote to package.json\n"}, nil).Once()
+	s.env.OnActivity("WriteExecStdin", mock.Anything, activities.WriteExecStdinRequest{ProcessID: "7", Chars: "demo\n"}).
+		Return(activities.WriteExecStdinResponse{Output: "package name: (demo) demo\nversion: (1.0.0) "}, nil).Once()
+
+	status := func() TurnStatus {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var st TurnStatus
+		require.NoError(s.T(), result.Get(&st))
+		return st
+	}
+
+	var running, afterWrite, afterExit []ExecSessionStatus
+	var written WriteExecStdinResponse
+	var unknownRejected error
+	s.env.RegisterDelayedCallback(func() {
+		running = status().ExecSessions
+		s.env.UpdateWorkflow(UpdateWriteExecStdin, "write-unknown", s.rejectingCallback(&unknownRejected),
+			WriteExecStdinRequest{ProcessID: "9", Chars: "y\n"})
+		s.env.UpdateWorkflow(UpdateWriteExecStdin, "write-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("write rejected", err) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				written = result.(WriteExecStdinResponse)
+			},
+		}, WriteExecStdinRequest{ProcessID: "7", Chars: "demo\n"})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		afterWrite = status().ExecSessions
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "I answered it, check on npm"})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		afterExit = status().ExecSessions
+	}, time.Minute)
+	s.sendShutdown(2 * time.Minute)
+
+	input := testInputWithApproval("set up the package", models.ApprovalNever)
+	input.Config.Tools.AddTools("exec_command", "write_stdin")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), running, 1)
+	assert.Equal(s.T(), "7", running[0].ProcessID)
+	assert.Equal(s.T(), "npm init", running[0].Command)
+	assert.Equal(s.T(), "This utility will walk you through\npackage name: (demo)", running[0].LastOutput)
+	assert.False(s.T(), running[0].StartedAt.IsZero())
+
+	require.Error(s.T(), unknownRejected)
+	assert.Contains(s.T(), unknownRejected.Error(), `no running exec session "9"`)
+	assert.Equal(s.T(), "package name: (demo) demo\nversion: (1.0.0) ", written.Output)
+
+	require.Len(s.T(), afterWrite, 1)
+	assert.Equal(s.T(), "package name: (demo) demo\nversion: (1.0.0)", afterWrite[0].LastOutput)
+	assert.Empty(s.T(), afterExit)
+}
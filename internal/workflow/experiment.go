@@ -0,0 +1,300 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// experiment.go implements model A/B experiments: ExperimentWorkflow runs
+// the same prompt as a one-shot session per model, in parallel child
+// workflows, and collects each model's response, token usage and latency.
+// Optionally a judge model scores the responses.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Experiment bounds.
+const (
+	MinExperimentModels = 2
+	MaxExperimentModels = 3
+
+	// DefaultExperimentTimeout bounds the runs, measured from the start of
+	// the experiment. Runs still going are shut down and marked timed_out.
+	DefaultExperimentTimeout = 10 * time.Minute
+
+	// experimentJudgeTimeout bounds the judge's run.
+	experimentJudgeTimeout = 5 * time.Minute
+)
+
+// ExperimentRunStatus is the outcome of one model's run.
+type ExperimentRunStatus string
+
+const (
+	ExperimentRunCompleted ExperimentRunStatus = "completed"
+	ExperimentRunFailed    ExperimentRunStatus = "failed"
+	ExperimentRunTimedOut  ExperimentRunStatus = "timed_out"
+)
+
+// ExperimentInput is the input to ExperimentWorkflow.
+type ExperimentInput struct {
+	Prompt string `json:"prompt"`
+	// Models are the models compared; each gets its own session.
+	Models []models.ModelConfig `json:"models"`
+	// Config is the session configuration shared by all runs. Its model is
+	// replaced by each run's, and user input tools are removed so every
+	// run ends after one turn.
+	Config models.SessionConfiguration `json:"config"`
+	// JudgeModel, if set, scores the responses.
+	JudgeModel models.ModelConfig `json:"judge_model,omitempty"`
+	// TimeoutMs bounds the runs (default DefaultExperimentTimeout).
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+}
+
+// ExperimentRun is one model's result.
+type ExperimentRun struct {
+	Model        string              `json:"model"`
+	Provider     string              `json:"provider,omitempty"`
+	WorkflowID   string              `json:"workflow_id"`
+	Status       ExperimentRunStatus `json:"status"`
+	Error        string              `json:"error,omitempty"`
+	Response     string              `json:"response,omitempty"`
+	TotalTokens  int                 `json:"total_tokens"`
+	InputTokens  int                 `json:"input_tokens"` // Prompt tokens incl. cache reads/writes
+	CachedTokens int                 `json:"cached_tokens"`
+	// LatencyMs is the time from the start of the experiment until the run
+	// finished.
+	LatencyMs int64 `json:"latency_ms"`
+	// Score is the judge's score from 1 to 10; nil when not judged.
+	Score       *float64 `json:"score,omitempty"`
+	JudgeReason string   `json:"judge_reason,omitempty"`
+}
+
+// OutputTokens returns the run's completion tokens.
+func (r ExperimentRun) OutputTokens() int {
+	return max(r.TotalTokens-r.InputTokens, 0)
+}
+
+// ExperimentResult is the result of ExperimentWorkflow, with runs in the
+// order of ExperimentInput.Models.
+type ExperimentResult struct {
+	Prompt     string          `json:"prompt"`
+	Runs       []ExperimentRun `json:"runs"`
+	JudgeModel string          `json:"judge_model,omitempty"`
+	JudgeError string          `json:"judge_error,omitempty"`
+}
+
+// experimentJudgeTaskTemplate is the judge's task; %s is the prompt and the
+// labeled responses.
+const experimentJudgeTaskTemplate = `Several AI models answered the same prompt. Judge their responses independently of their order and length.
+
+%s
+
+Score each response from 1 (useless or wrong) to 10 (correct, complete and clear). Report the scores with emit_result: one entry per response with its id, the score and a one-sentence reason.`
+
+// experimentJudgeSchema is the result schema of the judge.
+var experimentJudgeSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"scores": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":     map[string]interface{}{"type": "string"},
+					"score":  map[string]interface{}{"type": "number"},
+					"reason": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"id", "score"},
+			},
+		},
+	},
+	"required": []string{"scores"},
+}
+
+// validate checks the experiment input.
+func (in ExperimentInput) validate() error {
+	if strings.TrimSpace(in.Prompt) == "" {
+		return fmt.Errorf("prompt is required")
+	}
+	if len(in.Models) < MinExperimentModels || len(in.Models) > MaxExperimentModels {
+		return fmt.Errorf("an experiment compares %d to %d models, got %d",
+			MinExperimentModels, MaxExperimentModels, len(in.Models))
+	}
+	for i, mc := range in.Models {
+		if mc.Model == "" {
+			return fmt.Errorf("models[%d]: model is required", i)
+		}
+	}
+	return nil
+}
+
+// ExperimentWorkflow runs input.Prompt once per model as parallel child
+// sessions, waits for all of them (up to the timeout), and, with a judge
+// model, has it score the responses.
+func ExperimentWorkflow(ctx workflow.Context, input ExperimentInput) (ExperimentResult, error) {
+	if err := input.validate(); err != nil {
+		return ExperimentResult{}, fmt.Errorf("invalid experiment: %w", err)
+	}
+	logger := workflow.GetLogger(ctx)
+	experimentID := workflow.GetInfo(ctx).WorkflowExecution.ID
+
+	timeout := DefaultExperimentTimeout
+	if input.TimeoutMs > 0 {
+		timeout = time.Duration(input.TimeoutMs) * time.Millisecond
+	}
+
+	result := ExperimentResult{Prompt: input.Prompt, Runs: make([]ExperimentRun, len(input.Models))}
+	start := workflow.Now(ctx)
+	pending := len(input.Models)
+	for i, mc := range input.Models {
+		run := &result.Runs[i]
+		run.Model, run.Provider = mc.Model, mc.Provider
+		run.WorkflowID = fmt.Sprintf("%s/model-%d", experimentID, i+1)
+
+		childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			WorkflowID: run.WorkflowID,
+		})
+		future := workflow.ExecuteChildWorkflow(childCtx, "AgenticWorkflow", experimentRunInput(input, mc, run.WorkflowID))
+		workflow.Go(ctx, func(gCtx workflow.Context) {
+			defer func() { pending-- }()
+			var out WorkflowResult
+			err := future.Get(gCtx, &out)
+			run.LatencyMs = workflow.Now(gCtx).Sub(start).Milliseconds()
+			if err != nil {
+				if run.Status == "" {
+					run.Status = ExperimentRunFailed
+				}
+				run.Error = err.Error()
+				return
+			}
+			run.Response = out.FinalMessage
+			run.TotalTokens = out.TotalTokens
+			run.InputTokens = out.TotalInputTokens
+			run.CachedTokens = out.TotalCachedTokens
+			if run.Status == "" {
+				run.Status = ExperimentRunCompleted
+			}
+		})
+	}
+	logger.Info("Experiment started", "models", len(input.Models), "timeout", timeout)
+
+	done, err := workflow.AwaitWithTimeout(ctx, timeout, func() bool { return pending == 0 })
+	if err != nil {
+		return ExperimentResult{}, fmt.Errorf("experiment await failed: %w", err)
+	}
+	if !done {
+		// Runs still going keep what they produced before the shutdown.
+		for i := range result.Runs {
+			run := &result.Runs[i]
+			if run.Status != "" {
+				continue
+			}
+			run.Status = ExperimentRunTimedOut
+			if err := workflow.SignalExternalWorkflow(ctx, run.WorkflowID, "", SignalAgentShutdown, nil).Get(ctx, nil); err != nil {
+				logger.Warn("Failed to shut down experiment run", "workflow_id", run.WorkflowID, "error", err)
+			}
+		}
+		_, _ = workflow.AwaitWithTimeout(ctx, closeAgentGracePeriod, func() bool { return pending == 0 })
+	}
+
+	if input.JudgeModel.Model != "" {
+		result.JudgeModel = input.JudgeModel.Model
+		if err := judgeExperiment(ctx, input, &result); err != nil {
+			logger.Warn("Experiment judge failed", "error", err)
+			result.JudgeError = err.Error()
+		}
+	}
+
+	logger.Info("Experiment completed", "models", len(input.Models), "timed_out", !done)
+	return result, nil
+}
+
+// experimentRunInput builds the one-shot session input for one model.
+func experimentRunInput(input ExperimentInput, mc models.ModelConfig, workflowID string) WorkflowInput {
+	cfg := buildAgentSharedConfig(input.Config, 0)
+	cfg.Model = mc
+	cfg.ModelRouting = models.ModelRouting{}
+	cfg.Autonomy = models.Autonomy{}
+	// Without request_user_input the session completes after its turn.
+	cfg.Tools.RemoveTools("request_user_input", "ask_user")
+	return WorkflowInput{
+		ConversationID: workflowID,
+		UserMessage:    input.Prompt,
+		Config:         cfg,
+	}
+}
+
+// experimentLabel is the id the judge sees for the i-th run.
+func experimentLabel(i int) string {
+	return string(rune('A' + i))
+}
+
+// judgeExperiment has a judge subagent score the completed runs' responses
+// and records the scores on the runs.
+func judgeExperiment(ctx workflow.Context, input ExperimentInput, result *ExperimentResult) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<prompt>\n%s\n</prompt>\n", input.Prompt)
+	judged := 0
+	for i, run := range result.Runs {
+		if run.Status != ExperimentRunCompleted || strings.TrimSpace(run.Response) == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n<response id=%q>\n%s\n</response>\n", experimentLabel(i), run.Response)
+		judged++
+	}
+	if judged == 0 {
+		return fmt.Errorf("no completed responses to judge")
+	}
+
+	cfg := buildAgentSharedConfig(input.Config, 1)
+	cfg.Model = input.JudgeModel
+	cfg.ModelRouting = models.ModelRouting{}
+	cfg.Autonomy = models.Autonomy{}
+	cfg.Tools = models.ToolsConfig{}
+	judgeID := workflow.GetInfo(ctx).WorkflowExecution.ID + "/judge"
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:               judgeID,
+		WorkflowExecutionTimeout: experimentJudgeTimeout,
+	})
+	var out WorkflowResult
+	err := workflow.ExecuteChildWorkflow(childCtx, "AgenticWorkflow", WorkflowInput{
+		ConversationID: judgeID,
+		UserMessage:    fmt.Sprintf(experimentJudgeTaskTemplate, strings.TrimSpace(sb.String())),
+		Config:         cfg,
+		Depth:          1,
+		ResultSchema:   experimentJudgeSchema,
+	}).Get(ctx, &out)
+	if err != nil {
+		return fmt.Errorf("judge run failed: %w", err)
+	}
+	if len(out.StructuredResult) == 0 {
+		return fmt.Errorf("judge reported no scores")
+	}
+
+	var scores struct {
+		Scores []struct {
+			ID     string  `json:"id"`
+			Score  float64 `json:"score"`
+			Reason string  `json:"reason"`
+		} `json:"scores"`
+	}
+	if err := json.Unmarshal(out.StructuredResult, &scores); err != nil {
+		return fmt.Errorf("invalid judge scores: %w", err)
+	}
+	for _, sc := range scores.Scores {
+		for i := range result.Runs {
+			if strings.EqualFold(strings.TrimSpace(sc.ID), experimentLabel(i)) {
+				score := sc.Score
+				result.Runs[i].Score = &score
+				result.Runs[i].JudgeReason = sc.Reason
+			}
+		}
+	}
+	return nil
+}
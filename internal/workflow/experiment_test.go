@@ -0,0 +1,108 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// modelIs matches LLM calls made with the given model.
+func modelIs(model string) interface{} {
+	return mock.MatchedBy(func(input activities.LLMActivityInput) bool {
+		return input.ModelConfig.Model == model
+	})
+}
+
+func TestExperimentInput_Validate(t *testing.T) {
+	two := []models.ModelConfig{{Model: "a"}, {Model: "b"}}
+	assert.NoError(t, ExperimentInput{Prompt: "hi", Models: two}.validate())
+	assert.ErrorContains(t, ExperimentInput{Models: two}.validate(), "prompt is required")
+	assert.ErrorContains(t, ExperimentInput{Prompt: "hi", Models: two[:1]}.validate(), "2 to 3 models, got 1")
+	assert.ErrorContains(t, ExperimentInput{Prompt: "hi", Models: append(two, two...)}.validate(), "got 4")
+	assert.ErrorContains(t, ExperimentInput{Prompt: "hi", Models: []models.ModelConfig{{Model: "a"}, {}}}.validate(),
+		"models[1]: model is required")
+}
+
+// TestExperiment_ComparesModelsAndJudges verifies that each model answers
+// the prompt in its own one-shot session and the judge's scores are
+// attached to the runs.
+func (s *AgenticWorkflowTestSuite) TestExperiment_ComparesModelsAndJudges() {
+	s.env.RegisterWorkflow(AgenticWorkflow)
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("model-a")).
+		Return(mockLLMStopResponse("Use a map.", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("model-b")).
+		Return(mockLLMStopResponse("Use a slice and sort it.", 25), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("judge")).
+		Return(mockLLMEmitResultResponse("call-judge",
+			`{"result": {"scores": [{"id": "A", "score": 8, "reason": "direct"}, {"id": "b", "score": 5}]}}`, 5), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("judge")).
+		Return(mockLLMStopResponse("Scored.", 5), nil).Once()
+
+	input := ExperimentInput{
+		Prompt:     "How should I dedupe a list?",
+		Models:     []models.ModelConfig{{Model: "model-a"}, {Model: "model-b", Provider: "anthropic"}},
+		Config:     testInput("").Config,
+		JudgeModel: models.ModelConfig{Model: "judge"},
+	}
+	s.env.ExecuteWorkflow(ExperimentWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var result ExperimentResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	require.Len(s.T(), result.Runs, 2)
+	assert.Empty(s.T(), result.JudgeError)
+	assert.Equal(s.T(), "judge", result.JudgeModel)
+
+	a, b := result.Runs[0], result.Runs[1]
+	assert.Equal(s.T(), "model-a", a.Model)
+	assert.Equal(s.T(), ExperimentRunCompleted, a.Status)
+	assert.Equal(s.T(), "Use a map.", a.Response)
+	assert.Equal(s.T(), 10, a.TotalTokens)
+	require.NotNil(s.T(), a.Score)
+	assert.Equal(s.T(), 8.0, *a.Score)
+	assert.Equal(s.T(), "direct", a.JudgeReason)
+
+	assert.Equal(s.T(), "anthropic", b.Provider)
+	assert.Equal(s.T(), "Use a slice and sort it.", b.Response)
+	assert.Equal(s.T(), 25, b.TotalTokens)
+	require.NotNil(s.T(), b.Score)
+	assert.Equal(s.T(), 5.0, *b.Score)
+	assert.NotEqual(s.T(), a.WorkflowID, b.WorkflowID)
+}
+
+// TestExperiment_TimedOutRunIsShutDown verifies that a run still going at
+// the timeout (here waiting for an approval no one gives) is shut down and
+// reported as timed out without failing the experiment.
+func (s *AgenticWorkflowTestSuite) TestExperiment_TimedOutRunIsShutDown() {
+	s.env.RegisterWorkflow(AgenticWorkflow)
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("model-a")).
+		Return(mockLLMStopResponse("42", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, modelIs("model-b")).
+		Return(mockLLMShellCallResponse("call-rm", "rm -rf build"), nil).Once()
+
+	input := ExperimentInput{
+		Prompt:    "Clean the build and tell me the answer.",
+		Models:    []models.ModelConfig{{Model: "model-a"}, {Model: "model-b"}},
+		Config:    testInputWithApproval("", models.ApprovalUnlessTrusted).Config,
+		TimeoutMs: 60_000,
+	}
+	input.Config.Tools.EnabledTools = []string{"shell_command"}
+	s.env.ExecuteWorkflow(ExperimentWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var result ExperimentResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), ExperimentRunCompleted, result.Runs[0].Status)
+	assert.Equal(s.T(), "42", result.Runs[0].Response)
+	assert.Equal(s.T(), ExperimentRunTimedOut, result.Runs[1].Status)
+	assert.GreaterOrEqual(s.T(), result.Runs[1].LatencyMs, int64(60_000))
+}
@@ -0,0 +1,160 @@
+// fan_out — spawn several subagents at once and gather all their results.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// MaxFanOutAgents is the most children a single fan_out call may spawn.
+const MaxFanOutAgents = 8
+
+// fan_out timeout_ms bounds. The timeout applies to every child, measured
+// from the start of the fan-out.
+const (
+	MinFanOutTimeoutMs     = 10_000
+	DefaultFanOutTimeoutMs = 600_000
+	MaxFanOutTimeoutMs     = 3_600_000
+)
+
+// fanOutTask is one entry of the fan_out tasks argument.
+type fanOutTask struct {
+	Message   string `json:"message"`
+	AgentType string `json:"agent_type"`
+}
+
+// fanOutResult is one entry of the fan_out output, in task order.
+type fanOutResult struct {
+	AgentID     string          `json:"agent_id"`
+	AgentType   string          `json:"agent_type"`
+	Status      AgentStatus     `json:"status"`
+	FinalOutput string          `json:"final_output,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	TimedOut    bool            `json:"timed_out,omitempty"`
+}
+
+// handleFanOut spawns one child per task, waits until all reach a final
+// status, and returns their results together. Children still running at the
+// timeout are shut down and reported with timed_out. An interrupt or
+// shutdown of this session shuts down the whole fan-out.
+func (s *SessionState) handleFanOut(ctx workflow.Context, ctrl *LoopControl, fc models.ConversationItem) (models.ConversationItem, error) {
+	logger := workflow.GetLogger(ctx)
+
+	var args struct {
+		Tasks        []fanOutTask           `json:"tasks"`
+		AgentType    string                 `json:"agent_type"`
+		OutputSchema map[string]interface{} `json:"output_schema"`
+		TimeoutMs    *float64               `json:"timeout_ms"`
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return collabErrorOutput(fc.CallID, fmt.Sprintf("invalid arguments: %v", err)), nil
+	}
+	if len(args.Tasks) == 0 {
+		return collabErrorOutput(fc.CallID, "tasks is required and must be non-empty"), nil
+	}
+	if len(args.Tasks) > MaxFanOutAgents {
+		return collabErrorOutput(fc.CallID, fmt.Sprintf(
+			"fan_out accepts at most %d tasks, got %d; split the work into batches", MaxFanOutAgents, len(args.Tasks))), nil
+	}
+
+	childDepth := s.AgentCtl.ParentDepth + 1
+	if childDepth > MaxThreadSpawnDepth {
+		return collabErrorOutput(fc.CallID, fmt.Sprintf(
+			"cannot spawn agents: maximum nesting depth (%d) exceeded", MaxThreadSpawnDepth)), nil
+	}
+
+	timeoutMs := int64(DefaultFanOutTimeoutMs)
+	if args.TimeoutMs != nil {
+		timeoutMs = min(max(int64(*args.TimeoutMs), MinFanOutTimeoutMs), MaxFanOutTimeoutMs)
+	}
+
+	// Build every child's input before starting any, so a bad task does not
+	// leave the others running.
+	inputs := make([]WorkflowInput, len(args.Tasks))
+	roles := make([]AgentRole, len(args.Tasks))
+	for i, task := range args.Tasks {
+		if task.Message == "" {
+			return collabErrorOutput(fc.CallID, fmt.Sprintf("tasks[%d].message is required", i)), nil
+		}
+		agentType := task.AgentType
+		if agentType == "" {
+			agentType = args.AgentType
+		}
+		input, role, err := s.buildChildInput(agentType, task.Message, childDepth)
+		if err != nil {
+			return collabErrorOutput(fc.CallID, fmt.Sprintf("tasks[%d]: %v", i, err)), nil
+		}
+		input.ResultSchema = args.OutputSchema
+		inputs[i], roles[i] = input, role
+	}
+
+	// Launch all children, then wait for each start. Agent IDs share one
+	// timestamp, so they are numbered.
+	baseID := nextAgentID(ctx)
+	infos := make([]*AgentInfo, len(inputs))
+	futures := make([]workflow.ChildWorkflowFuture, len(inputs))
+	for i := range inputs {
+		infos[i], futures[i] = s.launchChild(ctx, fmt.Sprintf("%s-%d", baseID, i+1), roles[i], args.Tasks[i].Message, inputs[i])
+		infos[i].FanOutID = fc.CallID
+	}
+	for i := range infos {
+		if err := s.awaitChildStarted(ctx, infos[i], futures[i]); err != nil {
+			logger.Warn("Fan-out child failed to start", "agent_id", infos[i].AgentID, "error", err)
+		}
+	}
+	logger.Info("Fanned out child agents", "count", len(infos), "timeout_ms", timeoutMs)
+
+	ctrl.SetPhase(PhaseWaitingForAgents)
+	allTerminal := func() bool {
+		for _, info := range infos {
+			if !info.Status.isTerminal() {
+				return false
+			}
+		}
+		return true
+	}
+	done, err := workflow.AwaitWithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond, func() bool {
+		return allTerminal() || ctrl.IsInterrupted() || ctrl.IsShutdown()
+	})
+	if err != nil {
+		return models.ConversationItem{}, fmt.Errorf("fan_out await failed: %w", err)
+	}
+	interrupted := done && !allTerminal()
+
+	// Children still running hit the timeout or were cut short by an interrupt.
+	var unfinished []*AgentInfo
+	wasRunning := make([]bool, len(infos))
+	for i, info := range infos {
+		if !info.Status.isTerminal() {
+			unfinished = append(unfinished, info)
+			wasRunning[i] = true
+		}
+	}
+	s.shutdownChildren(ctx, unfinished)
+
+	results := make([]fanOutResult, len(infos))
+	for i, info := range infos {
+		results[i] = fanOutResult{
+			AgentID:     info.AgentID,
+			AgentType:   string(info.Role),
+			Status:      info.Status,
+			FinalOutput: info.FinalOutput,
+			Result:      info.Result,
+			TimedOut:    !done && wasRunning[i],
+		}
+	}
+
+	logger.Info("Fan-out completed", "count", len(infos), "unfinished", len(unfinished), "interrupted", interrupted)
+
+	return collabSuccessOutput(fc.CallID, map[string]interface{}{
+		"results":     results,
+		"interrupted": interrupted,
+	}), nil
+}
@@ -0,0 +1,136 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// historyHasUserText matches LLM calls whose history has a user message
+// containing text.
+func historyHasUserText(text string) interface{} {
+	return mock.MatchedBy(func(input activities.LLMActivityInput) bool {
+		for _, item := range input.History {
+			if item.Type == models.ItemTypeUserMessage && strings.Contains(item.Content, text) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// TestFanOut_GathersResults verifies that fan_out starts one child per task,
+// waits for all of them, and returns their final messages in task order.
+func (s *AgenticWorkflowTestSuite) TestFanOut_GathersResults() {
+	hasFanOutOutput := func(input activities.LLMActivityInput) bool {
+		for _, item := range input.History {
+			if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-fan" {
+				return true
+			}
+		}
+		return false
+	}
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(hasFanOutOutput)).
+		Return(mockLLMStopResponse("Both reviewed.", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("Review both files")).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-fan",
+				Name:      "fan_out",
+				Arguments: `{"tasks": [{"message": "review a.go"}, {"message": "review b.go", "agent_type": "reviewer"}]}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   models.TokenUsage{TotalTokens: 10},
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("review a.go")).
+		Return(mockLLMStopResponse("a.go looks fine", 5), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("review b.go")).
+		Return(mockLLMStopResponse("b.go has a bug", 5), nil).Once()
+
+	s.sendShutdown(time.Minute)
+
+	input := testInput("Review both files")
+	input.Config.Tools.AddTools("collab")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	items, err := s.env.QueryWorkflow(QueryGetConversationItems)
+	require.NoError(s.T(), err)
+	var history []models.ConversationItem
+	require.NoError(s.T(), items.Get(&history))
+
+	var output *models.FunctionCallOutputPayload
+	for _, item := range history {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-fan" {
+			output = item.Output
+		}
+	}
+	require.NotNil(s.T(), output, "fan_out output should be in history")
+	require.True(s.T(), *output.Success, output.Content)
+
+	var result struct {
+		Results     []fanOutResult `json:"results"`
+		Interrupted bool           `json:"interrupted"`
+	}
+	require.NoError(s.T(), json.Unmarshal([]byte(output.Content), &result))
+	assert.False(s.T(), result.Interrupted)
+	require.Len(s.T(), result.Results, 2)
+	assert.Equal(s.T(), "default", result.Results[0].AgentType)
+	assert.Equal(s.T(), AgentStatusCompleted, result.Results[0].Status)
+	assert.Equal(s.T(), "a.go looks fine", result.Results[0].FinalOutput)
+	assert.Equal(s.T(), "reviewer", result.Results[1].AgentType)
+	assert.Equal(s.T(), "b.go has a bug", result.Results[1].FinalOutput)
+	assert.NotEqual(s.T(), result.Results[0].AgentID, result.Results[1].AgentID)
+}
+
+// TestFanOut_RejectsTooManyTasks verifies the fan-out bound is enforced
+// before any child starts.
+func (s *AgenticWorkflowTestSuite) TestFanOut_RejectsTooManyTasks() {
+	tasks := make([]map[string]string, MaxFanOutAgents+1)
+	for i := range tasks {
+		tasks[i] = map[string]string{"message": "task"}
+	}
+	args, _ := json.Marshal(map[string]interface{}{"tasks": tasks})
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("Do many things")).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-fan",
+				Name:      "fan_out",
+				Arguments: string(args),
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("OK", 5), nil).Once()
+
+	s.sendShutdown(time.Minute)
+
+	input := testInput("Do many things")
+	input.Config.Tools.AddTools("collab")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	items, err := s.env.QueryWorkflow(QueryGetConversationItems)
+	require.NoError(s.T(), err)
+	var history []models.ConversationItem
+	require.NoError(s.T(), items.Get(&history))
+	found := false
+	for _, item := range history {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-fan" {
+			found = true
+			assert.False(s.T(), *item.Output.Success)
+			assert.Contains(s.T(), item.Output.Content, "at most 8 tasks")
+		}
+	}
+	assert.True(s.T(), found, "fan_out output should be in history")
+}
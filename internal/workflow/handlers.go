@@ -8,6 +8,7 @@ package workflow
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"go.temporal.io/sdk/workflow"
@@ -15,6 +16,7 @@ import (
 	"github.com/mfateev/temporal-agent-harness/internal/activities"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/skills"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
 	"github.com/mfateev/temporal-agent-harness/internal/version"
 )
 
@@ -26,18 +28,35 @@ func (s *SessionState) buildTurnStatus(ctrl *LoopControl) TurnStatus {
 	status := TurnStatus{
 		Phase:                   ctrl.Phase(),
 		CurrentTurnID:           ctrl.CurrentTurnID(),
-		ToolsInFlight:           ctrl.ToolsInFlight(),
+		TurnEpoch:               ctrl.TurnEpoch(),
+		ToolsInFlight:           ctrl.ToolNamesInFlight(),
+		RunningTools:            ctrl.ToolsInFlight(),
 		PendingApprovals:        ctrl.PendingApprovals(),
 		PendingEscalations:      ctrl.PendingEscalations(),
 		PendingUserInputRequest: ctrl.PendingUserInputReq(),
+		PendingAskUser:          ctrl.PendingAskUser(),
 		IterationCount:          s.IterationCount,
 		TotalTokens:             s.TotalTokens,
 		TotalCachedTokens:       s.TotalCachedTokens,
+		TotalInputTokens:        s.TotalInputTokens,
+		CacheHitRate:            s.cacheHitRate(),
 		TurnCount:               turnCount,
 		WorkerVersion:           version.GitCommit,
 		Suggestion:              ctrl.Suggestion(),
 		Plan:                    s.Plan,
+		Tasks:                   s.Tasks,
+		LastTurnTiming:          s.lastTurnTiming(),
+		QueuedBehind:            ctrl.QueuedBehind(),
+		ResumesAt:               ctrl.ResumesAt(),
+		Paused:                  s.Paused,
+		ExecSessions:            s.ExecSessions,
+		HistoryEpoch:            s.HistoryEpoch,
+		PromptHash:              s.PromptHash,
+		PromptHashChanges:       s.PromptHashChanges,
+		ToolMismatches:          s.ToolMismatches,
+		SuggestionUsage:         s.suggestionUsage(),
 	}
+	status.PhaseStartedAt, status.PhaseTimeout = ctrl.PhaseTimer()
 
 	// Per-turn token usage: copy as pointer if populated
 	if s.LastTokenUsage.TotalTokens > 0 {
@@ -69,6 +88,7 @@ func (s *SessionState) buildTurnStatus(ctrl *LoopControl) TurnStatus {
 				WorkflowID: info.WorkflowID,
 				Role:       info.Role,
 				Status:     info.Status,
+				FanOutID:   info.FanOutID,
 			})
 		}
 	}
@@ -78,6 +98,7 @@ func (s *SessionState) buildTurnStatus(ctrl *LoopControl) TurnStatus {
 // registerHandlers registers query and update handlers on the workflow.
 func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl) {
 	logger := workflow.GetLogger(ctx)
+	s.bindAccessControl(ctx)
 
 	// Query: get_conversation_items
 	// Maps to: Codex ContextManager::raw_items()
@@ -88,6 +109,34 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register get_conversation_items query handler", "error", err)
 	}
 
+	// Query: get_conversation_items_since
+	// Returns only the items after sinceSeq so polling clients do not
+	// fetch the whole history each time.
+	err = workflow.SetQueryHandler(ctx, QueryGetConversationItemsSince, func(sinceSeq int) (ConversationItemsSinceResponse, error) {
+		items, compacted, err := s.History.GetItemsSince(sinceSeq)
+		if err != nil {
+			return ConversationItemsSinceResponse{}, err
+		}
+		return ConversationItemsSinceResponse{Items: items, Compacted: compacted, HistoryEpoch: s.HistoryEpoch}, nil
+	})
+	if err != nil {
+		logger.Error("Failed to register get_conversation_items_since query handler", "error", err)
+	}
+
+	// Query: get_history
+	// Returns a filtered page of history so clients looking for a few
+	// items do not fetch the whole conversation.
+	err = workflow.SetQueryHandler(ctx, QueryGetHistory, func(q HistoryQuery) (HistoryPage, error) {
+		items, err := s.History.GetRawItems()
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		return queryHistory(items, q, s.HistoryEpoch)
+	})
+	if err != nil {
+		logger.Error("Failed to register get_history query handler", "error", err)
+	}
+
 	// Query: get_turn_status
 	// Returns current turn phase and stats for CLI polling.
 	err = workflow.SetQueryHandler(ctx, QueryGetTurnStatus, func() (TurnStatus, error) {
@@ -97,6 +146,45 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register get_turn_status query handler", "error", err)
 	}
 
+	// Query: get_turn_timings
+	// Returns timing breakdowns for recent turns, most recent last.
+	err = workflow.SetQueryHandler(ctx, QueryGetTurnTimings, func() ([]TurnTiming, error) {
+		return s.TurnTimings, nil
+	})
+	if err != nil {
+		logger.Error("Failed to register get_turn_timings query handler", "error", err)
+	}
+
+	// Query: get_capabilities
+	// Returns the serving worker's capabilities once DescribeWorker ran.
+	err = workflow.SetQueryHandler(ctx, QueryGetCapabilities, func() (CapabilitiesResponse, error) {
+		return s.capabilitiesResponse()
+	})
+	if err != nil {
+		logger.Error("Failed to register get_capabilities query handler", "error", err)
+	}
+
+	// Update: diagnose_network
+	// Runs the DiagnoseNetwork activity on the session's worker.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateDiagnoseNetwork,
+		func(ctx workflow.Context) (activities.DiagnoseNetworkOutput, error) {
+			return s.diagnoseNetwork(ctx)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register diagnose_network update handler", "error", err)
+	}
+
 	// Update: user_input
 	// Maps to: Codex Op::UserInput / turn/start
 	// Returns StateUpdateResponse with a full snapshot so the CLI can render
@@ -105,7 +193,29 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		ctx,
 		UpdateUserInput,
 		func(ctx workflow.Context, input UserInput) (StateUpdateResponse, error) {
+			// The first message of a session started without one waits
+			// for init, so the environment context can lead its turn.
+			if err := workflow.Await(ctx, func() bool { return !ctrl.IsAwaitingStart() }); err != nil {
+				return StateUpdateResponse{}, err
+			}
+
+			// A retried delivery of a message we already accepted: ack it
+			// with the original turn instead of starting another one.
+			if origTurnID, dup := s.turnForInputKey(input.IdempotencyKey); dup {
+				logger.Info("Ignoring duplicate user input",
+					"idempotency_key", input.IdempotencyKey, "turn_id", origTurnID)
+				allItems, _ := s.History.GetRawItems()
+				return StateUpdateResponse{
+					TurnID: origTurnID,
+					Items:  allItems,
+					Status: s.buildTurnStatus(ctrl),
+				}, nil
+			}
+
 			turnID := s.nextTurnID()
+			// Record the key before anything below can yield, so a retry
+			// delivered meanwhile is recognised as a duplicate.
+			s.rememberInputKey(input.IdempotencyKey, turnID)
 
 			// Add TurnStarted marker
 			if err := s.History.AddItem(models.ConversationItem{
@@ -116,6 +226,17 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 			}
 			ctrl.NotifyItemAdded()
 
+			if envCtx := ctrl.TakeStartContext(); envCtx != "" {
+				if err := s.History.AddItem(models.ConversationItem{
+					Type:    models.ItemTypeUserMessage,
+					Content: envCtx,
+					TurnID:  turnID,
+				}); err != nil {
+					return StateUpdateResponse{}, fmt.Errorf("failed to add environment context: %w", err)
+				}
+				ctrl.NotifyItemAdded()
+			}
+
 			// Add user message
 			if err := s.History.AddItem(models.ConversationItem{
 				Type:    models.ItemTypeUserMessage,
@@ -147,7 +268,7 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 				if ctrl.IsShutdown() {
 					return fmt.Errorf("session is shutting down")
 				}
-				return nil
+				return s.errIfPaused()
 			},
 		},
 	)
@@ -155,6 +276,58 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register user_input update handler", "error", err)
 	}
 
+	// Update: developer_input
+	// Adds developer-role instructions from automation. They reach the LLM
+	// with the developer role (see llm clients) and never as a user message.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateDeveloperInput,
+		func(ctx workflow.Context, input DeveloperInput) (DeveloperInputResponse, error) {
+			turnID := ctrl.CurrentTurnID()
+			if input.StartTurn {
+				turnID = s.nextTurnID()
+				if err := s.History.AddItem(models.ConversationItem{
+					Type:   models.ItemTypeTurnStarted,
+					TurnID: turnID,
+				}); err != nil {
+					return DeveloperInputResponse{}, fmt.Errorf("failed to add turn started: %w", err)
+				}
+				ctrl.NotifyItemAdded()
+			}
+
+			if err := s.History.AddItem(models.ConversationItem{
+				Type:    models.ItemTypeDeveloperMessage,
+				Content: input.Content,
+				TurnID:  turnID,
+			}); err != nil {
+				return DeveloperInputResponse{}, fmt.Errorf("failed to add developer message: %w", err)
+			}
+			ctrl.NotifyItemAdded()
+
+			if input.StartTurn {
+				ctrl.SetPendingUserInput(turnID)
+			}
+			return DeveloperInputResponse{TurnID: turnID}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, input DeveloperInput) error {
+				if input.Content == "" {
+					return fmt.Errorf("content must not be empty")
+				}
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				if input.StartTurn {
+					return s.errIfPaused()
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register developer_input update handler", "error", err)
+	}
+
 	// Update: interrupt
 	// Maps to: Codex Op::Interrupt
 	err = workflow.SetUpdateHandlerWithOptions(
@@ -188,6 +361,36 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register interrupt update handler", "error", err)
 	}
 
+	// Update: cancel_tool
+	// Cancels one in-flight tool call. The call gets a cancelled result and
+	// the turn continues with the remaining calls.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateCancelTool,
+		func(ctx workflow.Context, req CancelToolRequest) (CancelToolResponse, error) {
+			tool, _ := ctrl.InFlightTool(req.CallID)
+			ctrl.RequestToolCancel(req.CallID)
+			return CancelToolResponse{ToolName: tool.Name}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req CancelToolRequest) error {
+				if req.CallID == "" {
+					return fmt.Errorf("call_id is required")
+				}
+				if _, ok := ctrl.InFlightTool(req.CallID); !ok {
+					return fmt.Errorf("no in-flight tool call %q", req.CallID)
+				}
+				if ctrl.IsToolCancelRequested(req.CallID) {
+					return fmt.Errorf("tool call %q is already being cancelled", req.CallID)
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register cancel_tool update handler", "error", err)
+	}
+
 	// Update: shutdown
 	// Maps to: Codex Op::Shutdown
 	err = workflow.SetUpdateHandlerWithOptions(
@@ -233,6 +436,9 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 				s.Config.Model.ContextWindow = req.ContextWindow
 			}
 
+			// Estimate history size with the new model's tokenizer.
+			s.initTokenCounter()
+
 			// Validate reasoning effort against new model's supported efforts.
 			s.validateReasoningEffortForProfile()
 
@@ -265,6 +471,61 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register update_model update handler", "error", err)
 	}
 
+	// Update: update_model_config
+	// Changes model parameters for subsequent turns (/config).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateModelConfig,
+		func(ctx workflow.Context, req UpdateModelConfigRequest) (UpdateModelConfigResponse, error) {
+			if !req.Params.IsEmpty() {
+				s.Config.Model = req.Params.Apply(s.Config.Model)
+				logger.Info("Model parameters updated",
+					"temperature", s.Config.Model.Temperature,
+					"max_tokens", s.Config.Model.MaxTokens,
+					"context_window", s.Config.Model.ContextWindow,
+					"reasoning_effort", s.Config.Model.ReasoningEffort,
+					"reasoning_summary", s.Config.Model.ReasoningSummary)
+			}
+			return s.modelConfigResponse(), nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req UpdateModelConfigRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				return req.Params.Validate(s.Config.Model, s.ResolvedProfile)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register update_model_config update handler", "error", err)
+	}
+
+	// Update: update_env
+	// Sets or unsets environment variables for tool commands (/env).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateEnv,
+		func(ctx workflow.Context, req UpdateEnvRequest) (UpdateEnvResponse, error) {
+			if s.applyEnvUpdate(req) {
+				logger.Info("Session environment updated", "vars", sessionEnvNames(s.Config.Permissions))
+				s.envChanged = true
+			}
+			return UpdateEnvResponse{Env: s.Config.Permissions.EnvSet}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req UpdateEnvRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				return validateEnvUpdate(req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register update_env update handler", "error", err)
+	}
+
 	// Update: update_personality
 	// Allows the CLI to set a communication style personality.
 	err = workflow.SetUpdateHandlerWithOptions(
@@ -310,6 +571,39 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register set_session_name update handler", "error", err)
 	}
 
+	// Update: set_session_tags
+	// Adds/removes session tags and sets the note; mirrored to memo and
+	// (optionally) the AgentTags search attribute.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateSessionTags,
+		func(ctx workflow.Context, req SetSessionTagsRequest) (SetSessionTagsResponse, error) {
+			tags, note, err := s.applyTagEdit(req)
+			if err != nil {
+				return SetSessionTagsResponse{}, err
+			}
+			prevTags, prevNote := s.Tags, s.Note
+			s.Tags, s.Note = tags, note
+			if err := s.publishSessionMetadata(ctx); err != nil {
+				s.Tags, s.Note = prevTags, prevNote
+				return SetSessionTagsResponse{}, err
+			}
+			return SetSessionTagsResponse{Tags: s.Tags, Note: s.Note}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req SetSessionTagsRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				_, _, err := s.applyTagEdit(req)
+				return err
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register set_session_tags update handler", "error", err)
+	}
+
 	// Update: update_reasoning_effort
 	// Allows the CLI to change the reasoning effort level for reasoning models.
 	err = workflow.SetUpdateHandlerWithOptions(
@@ -431,10 +725,7 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		},
 		workflow.UpdateHandlerOptions{
 			Validator: func(ctx workflow.Context, resp ApprovalResponse) error {
-				if ctrl.Phase() != PhaseApprovalPending {
-					return fmt.Errorf("no approval pending")
-				}
-				return nil
+				return ctrl.ValidateApproval(resp)
 			},
 		},
 	)
@@ -453,10 +744,7 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		},
 		workflow.UpdateHandlerOptions{
 			Validator: func(ctx workflow.Context, resp EscalationResponse) error {
-				if ctrl.Phase() != PhaseEscalationPending {
-					return fmt.Errorf("no escalation pending")
-				}
-				return nil
+				return ctrl.ValidateEscalation(resp)
 			},
 		},
 	)
@@ -481,7 +769,7 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 				if ctrl.Phase() == PhaseCompacting {
 					return fmt.Errorf("compaction already in progress")
 				}
-				return nil
+				return s.errIfPaused()
 			},
 		},
 	)
@@ -511,6 +799,32 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register user_input_question_response update handler", "error", err)
 	}
 
+	// Update: ask_user_response
+	// Delivers the user's free-text answer to a pending ask_user question.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateAskUserResponse,
+		func(ctx workflow.Context, resp AskUserResponse) (AskUserResponseAck, error) {
+			ctrl.DeliverAskUser(resp)
+			return AskUserResponseAck{}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, resp AskUserResponse) error {
+				pending := ctrl.PendingAskUser()
+				if pending == nil {
+					return fmt.Errorf("no ask_user question pending")
+				}
+				if resp.CallID != "" && resp.CallID != pending.CallID {
+					return fmt.Errorf("ask_user question %s is no longer pending", resp.CallID)
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register ask_user_response update handler", "error", err)
+	}
+
 	// Update: plan_request
 	// Spawns a planner child workflow directly (no LLM round-trip) and returns
 	// its workflow ID so the CLI can communicate with it.
@@ -576,7 +890,7 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 				if ctrl.IsShutdown() {
 					return fmt.Errorf("session is shutting down")
 				}
-				return nil
+				return s.errIfPaused()
 			},
 		},
 	)
@@ -586,42 +900,51 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 
 	// Update: get_state_update
 	// Blocking long-poll Update that replaces the CLI's query-based polling loop.
-	// Sleeps via workflow.Await until state changes, then returns delta items +
-	// current status in a single response.
+	// Sleeps via workflow.Await until there are items after SinceSeq or the
+	// phase changes, then returns delta items + current status in a single
+	// response. Status-only changes are coalesced over StatusIntervalMs, and
+	// TimeoutMs bounds the wait.
 	err = workflow.SetUpdateHandlerWithOptions(
 		ctx,
 		UpdateGetStateUpdate,
 		func(ctx workflow.Context, req StateUpdateRequest) (StateUpdateResponse, error) {
 			entryVersion := ctrl.StateVersion()
-
-			// Check if new state is immediately available
-			items, compacted, _ := s.History.GetItemsSince(req.SinceSeq)
-			if len(items) > 0 || compacted || ctrl.Phase() != req.SincePhase || ctrl.IsShutdown() || ctrl.IsDraining() {
-				return StateUpdateResponse{
-					TurnID:    ctrl.CurrentTurnID(),
-					Items:     items,
-					Status:    s.buildTurnStatus(ctrl),
-					Compacted: compacted,
-					Completed: ctrl.IsShutdown(),
-				}, nil
+			hasNews := func() bool {
+				return s.History.GetLatestSeq() != req.SinceSeq || ctrl.Phase() != req.SincePhase ||
+					ctrl.IsShutdown() || ctrl.IsDraining()
 			}
+			statusChanged := func() bool { return ctrl.StateVersion() != entryVersion }
 
-			// Block until state changes
-			awaitErr := workflow.Await(ctx, func() bool {
-				return ctrl.StateVersion() != entryVersion || ctrl.IsShutdown() || ctrl.IsDraining()
-			})
-			if awaitErr != nil {
-				return StateUpdateResponse{}, fmt.Errorf("get_state_update await failed: %w", awaitErr)
+			timedOut := false
+			if !hasNews() {
+				// Block until state changes
+				woke := func() bool { return hasNews() || statusChanged() }
+				var awaitErr error
+				if req.TimeoutMs > 0 {
+					var ok bool
+					ok, awaitErr = workflow.AwaitWithTimeout(ctx, time.Duration(req.TimeoutMs)*time.Millisecond, woke)
+					timedOut = !ok
+				} else {
+					awaitErr = workflow.Await(ctx, woke)
+				}
+				if awaitErr == nil && !timedOut && !hasNews() && req.StatusIntervalMs > 0 {
+					// Status-only change: let further ones pile up, but
+					// return at once for news.
+					_, awaitErr = workflow.AwaitWithTimeout(ctx, time.Duration(req.StatusIntervalMs)*time.Millisecond, hasNews)
+				}
+				if awaitErr != nil {
+					return StateUpdateResponse{}, fmt.Errorf("get_state_update await failed: %w", awaitErr)
+				}
 			}
 
-			// Re-fetch state after waking
-			items, compacted, _ = s.History.GetItemsSince(req.SinceSeq)
+			items, compacted, _ := s.History.GetItemsSince(req.SinceSeq)
 			return StateUpdateResponse{
 				TurnID:    ctrl.CurrentTurnID(),
 				Items:     items,
 				Status:    s.buildTurnStatus(ctrl),
 				Compacted: compacted,
 				Completed: ctrl.IsShutdown(),
+				TimedOut:  timedOut,
 			}, nil
 		},
 		workflow.UpdateHandlerOptions{},
@@ -700,6 +1023,7 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 			if err := workflow.ExecuteLocalActivity(actCtx, "CleanExecSessions", activities.CleanExecSessionsRequest{}).Get(ctx, &actResp); err != nil {
 				return CleanExecSessionsResponse{}, err
 			}
+			s.ExecSessions = nil
 			return CleanExecSessionsResponse{Closed: actResp.Closed}, nil
 		},
 		workflow.UpdateHandlerOptions{
@@ -715,11 +1039,489 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 		logger.Error("Failed to register clean_exec_sessions update handler", "error", err)
 	}
 
+	// Update: write_exec_stdin
+	// Types the user's input into an exec session the model left running.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateWriteExecStdin,
+		func(ctx workflow.Context, req WriteExecStdinRequest) (WriteExecStdinResponse, error) {
+			return s.writeExecStdin(ctx, req)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req WriteExecStdinRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				if req.Chars == "" {
+					return fmt.Errorf("chars is required")
+				}
+				if s.execSessionIndex(req.ProcessID) < 0 {
+					return fmt.Errorf("no running exec session %q", req.ProcessID)
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register write_exec_stdin update handler", "error", err)
+	}
+
+	// Update: snapshot_workspace
+	// Records a manual workspace snapshot (CLI /snapshot).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateSnapshotWorkspace,
+		func(ctx workflow.Context, req SnapshotWorkspaceRequest) (SnapshotWorkspaceResponse, error) {
+			snap, err := s.takeWorkspaceSnapshot(ctx, ctrl.CurrentTurnID(), true)
+			if err != nil {
+				return SnapshotWorkspaceResponse{}, err
+			}
+			return SnapshotWorkspaceResponse{Snapshot: snap}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req SnapshotWorkspaceRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register snapshot_workspace update handler", "error", err)
+	}
+
+	// Update: rollback_workspace
+	// Restores the workspace to a snapshot (CLI /rollback). Only allowed
+	// between turns so it cannot race with running tools.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateRollbackWorkspace,
+		func(ctx workflow.Context, req RollbackWorkspaceRequest) (RollbackWorkspaceResponse, error) {
+			resp, err := s.rollbackWorkspace(ctx, req.SnapshotID)
+			if err != nil {
+				return RollbackWorkspaceResponse{}, err
+			}
+			// Record the rollback so the model knows its earlier edits are gone.
+			_ = s.History.AddItem(models.ConversationItem{
+				Type:    models.ItemTypeAssistantMessage,
+				Content: "[" + describeRollback(resp) + "]",
+			})
+			ctrl.NotifyItemAdded()
+			return resp, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req RollbackWorkspaceRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				if phase := ctrl.Phase(); phase != "" && phase != PhaseWaitingForInput {
+					return fmt.Errorf("cannot roll back while a turn is running")
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register rollback_workspace update handler", "error", err)
+	}
+
+	// Update: update_task_list
+	// Applies a user edit (/todo) to the shared task list. The change is
+	// noted in history so the LLM knows the user edited the list.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTaskList,
+		func(ctx workflow.Context, req UpdateTaskListRequest) (UpdateTaskListResponse, error) {
+			change, err := s.applyTaskEdit(req, TaskAuthorUser)
+			if err != nil {
+				return UpdateTaskListResponse{}, err
+			}
+			_ = s.History.AddItem(models.ConversationItem{
+				Type:    models.ItemTypeAssistantMessage,
+				Content: fmt.Sprintf("[Task list: user %s]", change),
+			})
+			ctrl.NotifyItemAdded()
+			return UpdateTaskListResponse{Tasks: s.Tasks}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req UpdateTaskListRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				switch req.Action {
+				case TaskListAdd, TaskListDone, TaskListUndone, TaskListRemove:
+					return nil
+				default:
+					return fmt.Errorf("unknown task list action %q", req.Action)
+				}
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register update_task_list update handler", "error", err)
+	}
+
+	// Update: update_trusted_commands
+	// Lists or revokes learned-trust commands (/trust). A revocation is
+	// noted in history alongside the note that granted the trust.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTrustedCommands,
+		func(ctx workflow.Context, req UpdateTrustedCommandsRequest) (UpdateTrustedCommandsResponse, error) {
+			if req.Action == TrustRevoke {
+				t, err := s.revokeTrust(req.ID)
+				if err != nil {
+					return UpdateTrustedCommandsResponse{}, err
+				}
+				_ = s.History.AddItem(models.ConversationItem{
+					Type:    models.ItemTypeAssistantMessage,
+					Content: formatTrustRevoked(t),
+				})
+				ctrl.NotifyItemAdded()
+			}
+			return UpdateTrustedCommandsResponse{Commands: s.TrustedCommands}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req UpdateTrustedCommandsRequest) error {
+				switch req.Action {
+				case TrustList, TrustRevoke:
+					return nil
+				default:
+					return fmt.Errorf("unknown trust action %q", req.Action)
+				}
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register update_trusted_commands update handler", "error", err)
+	}
+
+	// Update: pin_item
+	// Lists, pins or unpins history items that compaction keeps verbatim
+	// (/pin, /unpin).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdatePinItem,
+		func(ctx workflow.Context, req PinItemRequest) (PinItemResponse, error) {
+			if req.Action != PinList {
+				if err := s.History.SetPinned(req.Seq, req.Action == PinPin); err != nil {
+					return PinItemResponse{}, err
+				}
+				logger.Info("History item pin changed", "seq", req.Seq, "action", req.Action)
+			}
+			return PinItemResponse{Items: s.pinListing()}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req PinItemRequest) error {
+				switch req.Action {
+				case PinList:
+					return nil
+				case PinPin, PinUnpin:
+					return s.validatePinSeq(req.Seq)
+				default:
+					return fmt.Errorf("unknown pin action %q", req.Action)
+				}
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register pin_item update handler", "error", err)
+	}
+
+	// Update: annotate_item
+	// Records the user's note or reaction on a history item (/note, /react).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateAnnotateItem,
+		func(ctx workflow.Context, req AnnotateItemRequest) (AnnotateItemResponse, error) {
+			target, err := s.resolveAnnotationTarget(req.Seq)
+			if err != nil {
+				return AnnotateItemResponse{}, err
+			}
+			if err := s.History.AddItem(s.annotationItem(req, target)); err != nil {
+				return AnnotateItemResponse{}, fmt.Errorf("failed to add annotation: %w", err)
+			}
+			ctrl.NotifyItemAdded()
+			logger.Info("History item annotated", "target_seq", target.Seq, "reaction", req.Reaction)
+			return AnnotateItemResponse{Seq: s.History.GetLatestSeq(), TargetSeq: target.Seq}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req AnnotateItemRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				return s.validateAnnotation(req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register annotate_item update handler", "error", err)
+	}
+
+	// Update: pause
+	// Freezes the session between turns (/pause, `client pause`).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdatePause,
+		func(ctx workflow.Context, req PauseRequest) (PauseResponse, error) {
+			info := &PauseInfo{Since: workflow.Now(ctx), Reason: strings.TrimSpace(req.Reason)}
+			if err := s.setPaused(ctx, ctrl, info); err != nil {
+				return PauseResponse{}, err
+			}
+			logger.Info("Session paused", "reason", info.Reason)
+			return PauseResponse{Paused: info}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req PauseRequest) error {
+				return s.validatePause(ctrl, req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register pause update handler", "error", err)
+	}
+
+	// Update: resume
+	// Ends a pause (/unpause, `client resume`).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateResume,
+		func(ctx workflow.Context, req ResumeRequest) (PauseResponse, error) {
+			pausedFor := workflow.Now(ctx).Sub(s.Paused.Since)
+			if err := s.setPaused(ctx, ctrl, nil); err != nil {
+				return PauseResponse{}, err
+			}
+			logger.Info("Session resumed", "paused_for", pausedFor)
+			return PauseResponse{PausedFor: pausedFor}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req ResumeRequest) error {
+				if s.Paused == nil {
+					return fmt.Errorf("session is not paused")
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register resume update handler", "error", err)
+	}
+
+	// Update: remap_cwd
+	// Moves the session to another checkout of its repository (/cwd,
+	// `client remap-cwd`), e.g. after resuming it on another machine.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateRemapCwd,
+		func(ctx workflow.Context, req RemapCwdRequest) (RemapCwdResponse, error) {
+			return s.remapCwd(ctx, ctrl, req.Cwd)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req RemapCwdRequest) error {
+				return s.validateRemapCwd(ctrl, req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register remap_cwd update handler", "error", err)
+	}
+
+	// Update: user_shell
+	// Runs a shell command for the user without the model (/!cmd).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateUserShell,
+		func(ctx workflow.Context, req UserShellRequest) (UserShellResponse, error) {
+			return s.runUserShell(ctx, ctrl, req.Command)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req UserShellRequest) error {
+				return s.validateUserShell(ctrl, req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register user_shell update handler", "error", err)
+	}
+
+	// Update: consult
+	// Asks the consult_models the same question in parallel (/consult).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateConsult,
+		func(ctx workflow.Context, req ConsultRequest) (ConsultResponse, error) {
+			return s.consult(ctx, ctrl, req)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req ConsultRequest) error {
+				return s.validateConsult(ctrl, req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register consult update handler", "error", err)
+	}
+
+	// Update: adopt_consult
+	// Records the /consult answer the user picked.
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateAdoptConsult,
+		func(ctx workflow.Context, req AdoptConsultRequest) (AdoptConsultResponse, error) {
+			return s.adoptConsult(ctx, ctrl, req)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req AdoptConsultRequest) error {
+				return s.validateAdoptConsult(ctrl, req)
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register adopt_consult update handler", "error", err)
+	}
+
+	// Update: import_context
+	// Summarizes another session's history into this one (/import).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateImportContext,
+		func(ctx workflow.Context, req ImportContextRequest) (ImportContextResponse, error) {
+			return s.importContext(ctx, ctrl, strings.TrimSpace(req.WorkflowID))
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req ImportContextRequest) error {
+				if ctrl.IsShutdown() {
+					return fmt.Errorf("session is shutting down")
+				}
+				id := strings.TrimSpace(req.WorkflowID)
+				if id == "" {
+					return fmt.Errorf("workflow ID is required")
+				}
+				if id == workflow.GetInfo(ctx).WorkflowExecution.ID {
+					return fmt.Errorf("cannot import a session into itself")
+				}
+				if phase := ctrl.Phase(); phase != "" && phase != PhaseWaitingForInput {
+					return fmt.Errorf("cannot import context while a turn is running")
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register import_context update handler", "error", err)
+	}
+
+	// Query: get_context
+	// Lists what the next LLM call will send, with token estimates (/context).
+	err = workflow.SetQueryHandler(ctx, QueryGetContext, func() (ContextView, error) {
+		return s.contextView(), nil
+	})
+	if err != nil {
+		logger.Error("Failed to register get_context query handler", "error", err)
+	}
+
+	// Update: drop_context_items
+	// Removes history items the user picked (/context drop).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateDropContextItems,
+		func(ctx workflow.Context, req ContextEditRequest) (ContextEditResponse, error) {
+			return s.dropContextItems(ctx, ctrl, req.Seqs)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req ContextEditRequest) error {
+				if err := validateContextEdit(ctrl); err != nil {
+					return err
+				}
+				items, _ := s.History.GetRawItems()
+				_, err := selectContextItems(items, req.Seqs)
+				return err
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register drop_context_items update handler", "error", err)
+	}
+
+	// Update: summarize_context_items
+	// Replaces history items the user picked with a summary (/context summarize).
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateSummarizeContextItems,
+		func(ctx workflow.Context, req ContextEditRequest) (ContextEditResponse, error) {
+			return s.summarizeContextItems(ctx, ctrl, req.Seqs)
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req ContextEditRequest) error {
+				if err := validateContextEdit(ctrl); err != nil {
+					return err
+				}
+				items, _ := s.History.GetRawItems()
+				_, err := selectContextItems(items, req.Seqs)
+				return err
+			},
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to register summarize_context_items update handler", "error", err)
+	}
+
+	// Query: get_workspace_snapshots
+	// Returns the retained workspace snapshots, oldest first.
+	err = workflow.SetQueryHandler(ctx, QueryGetWorkspaceSnapshots, func() ([]WorkspaceSnapshot, error) {
+		return s.WorkspaceSnapshots, nil
+	})
+	if err != nil {
+		logger.Error("Failed to register get_workspace_snapshots query handler", "error", err)
+	}
+
+	// Query: get_sandbox_audit
+	// Returns the sandbox audit report, or an error outside audit mode.
+	err = workflow.SetQueryHandler(ctx, QueryGetSandboxAudit, func() (*SandboxAuditReport, error) {
+		report := s.sandboxAuditReport()
+		if report == nil {
+			return nil, fmt.Errorf("sandbox audit mode is off for this session")
+		}
+		return report, nil
+	})
+	if err != nil {
+		logger.Error("Failed to register get_sandbox_audit query handler", "error", err)
+	}
+
 	// Signal channels for child workflow mode (subagent).
 	// These are drained in goroutines so signals are processed asynchronously.
 	// Maps to: codex-rs/core/src/agent/control.rs agent signal handling
 
+	// llm_queued — rate-limiter queue position of the in-flight LLM call,
+	// sent by the ExecuteLLMCall activity.
+	llmQueuedCh := workflow.GetSignalChannel(ctx, SignalLLMQueued)
+	workflow.Go(ctx, func(gCtx workflow.Context) {
+		for {
+			var signal activities.LLMQueuedSignal
+			if !llmQueuedCh.Receive(gCtx, &signal) {
+				return
+			}
+			ctrl.SetLLMQueued(signal.Position)
+		}
+	})
+
+	// tool_progress — latest progress of a running tool call, sent by the
+	// ExecuteTool activity.
+	toolProgressCh := workflow.GetSignalChannel(ctx, SignalToolProgress)
+	workflow.Go(ctx, func(gCtx workflow.Context) {
+		for {
+			var progress tools.ToolProgress
+			if !toolProgressCh.Receive(gCtx, &progress) {
+				return
+			}
+			ctrl.SetToolProgress(progress)
+		}
+	})
+
 	// agent_input — delivers a message from parent to child workflow.
+	// A signal cannot be rejected, so input sent to a paused session is
+	// held, in order, until it is resumed.
 	agentInputCh := workflow.GetSignalChannel(ctx, SignalAgentInput)
 	workflow.Go(ctx, func(gCtx workflow.Context) {
 		for {
@@ -727,6 +1529,9 @@ func (s *SessionState) registerHandlers(ctx workflow.Context, ctrl *LoopControl)
 			if !agentInputCh.Receive(gCtx, &signal) {
 				return // channel closed
 			}
+			if err := workflow.Await(gCtx, func() bool { return s.Paused == nil || ctrl.IsShutdown() }); err != nil || s.Paused != nil {
+				return
+			}
 			if signal.Interrupt {
 				ctrl.SetInterrupted()
 			}
@@ -4,6 +4,13 @@
 // manages a session registry on behalf of a single user identity.
 // Config resolution and initialization have been moved to SessionWorkflow;
 // the harness is a pure registry with signals, queries, and updates.
+//
+// Admission control: when MaxConcurrentSessions is set, start_session
+// Updates beyond the limit are queued (status "queued") and admitted in
+// arrival order as running sessions finish. Sessions started before a
+// ContinueAsNew are no longer watched by the harness; while they hold the
+// slots queued sessions wait for, the harness periodically checks whether
+// they are still running.
 package workflow
 
 import (
@@ -14,6 +21,7 @@ import (
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 )
 
@@ -22,10 +30,21 @@ const (
 	// QueryGetSessions returns the list of active/completed sessions.
 	QueryGetSessions = "get_sessions"
 
+	// QueryGetOccupancy returns the harness's session occupancy (HarnessOccupancy).
+	QueryGetOccupancy = "get_occupancy"
+
 	// UpdateStartSession starts a new agentic session via SessionWorkflow.
+	// Blocks while the session is queued behind the concurrency limit.
 	UpdateStartSession = "start_session"
+
+	// UpdateSetSessionLimit changes MaxConcurrentSessions of a running harness.
+	UpdateSetSessionLimit = "set_session_limit"
 )
 
+// sessionReconcileInterval is how often a harness whose queue waits on
+// unwatched sessions checks whether they are still running.
+const sessionReconcileInterval = time.Minute
+
 // CLIOverrides carries CLI-level arguments that override file-based config.
 // Only primitive override values — no file content.
 type CLIOverrides struct {
@@ -55,6 +74,14 @@ type CLIOverrides struct {
 
 	// MemoryDbPath overrides the default memory SQLite DB path.
 	MemoryDbPath string `json:"memory_db_path,omitempty"`
+
+	// EnableTools adds tools or tool groups (e.g. "github") on top of the
+	// configured ones.
+	EnableTools []string `json:"enable_tools,omitempty"`
+
+	// ArchiveURL overrides the transcript archive location (archive_url).
+	// Harness-level only: sessions inherit the harness's value.
+	ArchiveURL string `json:"archive_url,omitempty"`
 }
 
 // HarnessWorkflowInput is the initial input for HarnessWorkflow.
@@ -65,6 +92,10 @@ type HarnessWorkflowInput struct {
 
 	// Overrides contains CLI-level config overrides.
 	Overrides CLIOverrides `json:"overrides,omitempty"`
+
+	// MaxConcurrentSessions caps the sessions running at once; further
+	// start_session requests wait in a queue. 0 means unlimited.
+	MaxConcurrentSessions int `json:"max_concurrent_sessions,omitempty"`
 }
 
 // StartSessionRequest is the payload for the UpdateStartSession update.
@@ -96,6 +127,24 @@ type StartSessionResponse struct {
 	SessionWorkflowID string `json:"session_workflow_id"`
 }
 
+// SetSessionLimitRequest is the payload for the UpdateSetSessionLimit update.
+type SetSessionLimitRequest struct {
+	// MaxConcurrentSessions is the new limit. 0 means unlimited.
+	MaxConcurrentSessions int `json:"max_concurrent_sessions"`
+}
+
+// HarnessOccupancy is returned by QueryGetOccupancy and UpdateSetSessionLimit.
+type HarnessOccupancy struct {
+	// MaxConcurrentSessions is the current limit (0 = unlimited).
+	MaxConcurrentSessions int `json:"max_concurrent_sessions"`
+
+	// Active is the number of sessions starting or running.
+	Active int `json:"active"`
+
+	// Queued lists the session IDs waiting for a slot, in admission order.
+	Queued []string `json:"queued"`
+}
+
 // SessionEntry tracks a single child session spawned by HarnessWorkflow.
 type SessionEntry struct {
 	// SessionID is the harness-assigned short identifier.
@@ -140,14 +189,22 @@ type HarnessWorkflowState struct {
 
 	// SessionCounter is incremented for each new session to generate unique IDs.
 	SessionCounter uint64 `json:"session_counter"`
+
+	// MaxConcurrentSessions is preserved across ContinueAsNew. 0 = unlimited.
+	MaxConcurrentSessions int `json:"max_concurrent_sessions,omitempty"`
+
+	// watched holds the IDs of sessions whose completion this run watches.
+	// Not carried across ContinueAsNew: the new run watches none.
+	watched map[string]bool
 }
 
 // HarnessWorkflow is the long-lived harness orchestrator entry point.
 // Accepts HarnessWorkflowInput and delegates to runHarnessLoop.
 func HarnessWorkflow(ctx workflow.Context, input HarnessWorkflowInput) error {
 	state := HarnessWorkflowState{
-		HarnessID: input.HarnessID,
-		Overrides: input.Overrides,
+		HarnessID:             input.HarnessID,
+		Overrides:             input.Overrides,
+		MaxConcurrentSessions: input.MaxConcurrentSessions,
 	}
 	return runHarnessLoop(ctx, &state)
 }
@@ -163,6 +220,7 @@ func HarnessWorkflowContinued(ctx workflow.Context, state HarnessWorkflowState)
 // The harness is a pure registry — no config resolution.
 func runHarnessLoop(ctx workflow.Context, state *HarnessWorkflowState) error {
 	logger := workflow.GetLogger(ctx)
+	state.watched = map[string]bool{}
 
 	// Register query handler for session list.
 	if err := workflow.SetQueryHandler(ctx, QueryGetSessions, func() ([]SessionEntry, error) {
@@ -174,6 +232,12 @@ func runHarnessLoop(ctx workflow.Context, state *HarnessWorkflowState) error {
 		return fmt.Errorf("failed to register %s query: %w", QueryGetSessions, err)
 	}
 
+	if err := workflow.SetQueryHandler(ctx, QueryGetOccupancy, func() (HarnessOccupancy, error) {
+		return state.occupancy(), nil
+	}); err != nil {
+		return fmt.Errorf("failed to register %s query: %w", QueryGetOccupancy, err)
+	}
+
 	// Register signal handler for session status updates from SessionWorkflow.
 	updateStatusCh := workflow.GetSignalChannel(ctx, SignalUpdateSessionStatus)
 	workflow.Go(ctx, func(gCtx workflow.Context) {
@@ -203,23 +267,58 @@ func runHarnessLoop(ctx workflow.Context, state *HarnessWorkflowState) error {
 		return fmt.Errorf("failed to register %s update: %w", UpdateStartSession, err)
 	}
 
+	// Register update handler for changing the concurrency limit. Raising
+	// the limit admits queued sessions immediately.
+	if err := workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateSetSessionLimit,
+		func(ctx workflow.Context, req SetSessionLimitRequest) (HarnessOccupancy, error) {
+			state.MaxConcurrentSessions = req.MaxConcurrentSessions
+			logger.Info("Session limit changed", "max_concurrent_sessions", req.MaxConcurrentSessions)
+			return state.occupancy(), nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, req SetSessionLimitRequest) error {
+				if req.MaxConcurrentSessions < 0 {
+					return temporal.NewApplicationError("max_concurrent_sessions must not be negative", "InvalidRequest")
+				}
+				return nil
+			},
+		},
+	); err != nil {
+		return fmt.Errorf("failed to register %s update: %w", UpdateSetSessionLimit, err)
+	}
+
+	// Sessions started before ContinueAsNew that ended without signaling
+	// (terminated, timed out) would otherwise hold their slots forever.
+	reconcileSessions(ctx, state)
+
 	// Main idle loop — wait for updates or timeout to trigger ContinueAsNew.
+	deadline := workflow.Now(ctx).Add(IdleTimeout)
 	for {
-		// ok=true means condition was satisfied; ok=false means timed out.
-		ok, err := workflow.AwaitWithTimeout(ctx, IdleTimeout, func() bool {
-			return false // no wake-up condition; rely solely on the timeout
-		})
-		if err != nil {
-			return fmt.Errorf("harness await failed: %w", err)
+		// ok=true means the queue waits on unwatched sessions; ok=false
+		// means timed out.
+		ok := false
+		if remaining := deadline.Sub(workflow.Now(ctx)); remaining > 0 {
+			var err error
+			ok, err = workflow.AwaitWithTimeout(ctx, remaining, state.queueWaitsOnUnwatched)
+			if err != nil {
+				return fmt.Errorf("harness await failed: %w", err)
+			}
 		}
-		if !ok {
-			// Timed out — trigger ContinueAsNew to keep history bounded.
-			logger.Info("Harness idle timeout reached, triggering ContinueAsNew")
-			_ = workflow.Await(ctx, func() bool {
-				return workflow.AllHandlersFinished(ctx)
-			})
-			return workflow.NewContinueAsNewError(ctx, HarnessWorkflowContinued, *state)
+		if ok {
+			if err := workflow.Sleep(ctx, sessionReconcileInterval); err != nil {
+				return fmt.Errorf("harness await failed: %w", err)
+			}
+			reconcileSessions(ctx, state)
+			continue
 		}
+		// Timed out — trigger ContinueAsNew to keep history bounded.
+		logger.Info("Harness idle timeout reached, triggering ContinueAsNew")
+		_ = workflow.Await(ctx, func() bool {
+			return workflow.AllHandlersFinished(ctx)
+		})
+		return workflow.NewContinueAsNewError(ctx, HarnessWorkflowContinued, *state)
 	}
 }
 
@@ -259,20 +358,33 @@ func handleStartSession(
 	// Agent workflow ID is derived by convention from the session workflow ID.
 	agentWfID := sessionWfID + "/main"
 
-	// Record the session entry immediately with PendingInit status.
-	// The update_session_status signal from SessionWorkflow will flip it to Running.
+	// Record the session entry immediately, queued behind the concurrency
+	// limit. Once admitted it is PendingInit until the update_session_status
+	// signal from SessionWorkflow flips it to Running.
 	entry := SessionEntry{
 		SessionID:         sessionID,
 		SessionWorkflowID: sessionWfID,
 		WorkflowID:        agentWfID,
 		UserMessage:       req.UserMessage,
 		Model:             model,
-		Status:            AgentStatusPendingInit,
+		Status:            AgentStatusQueued,
 		StartedAt:         workflow.Now(ctx),
 		CrewType:          req.CrewType,
 	}
 	state.Sessions = append(state.Sessions, entry)
 
+	if !state.canAdmit(sessionID) {
+		workflow.GetLogger(ctx).Info("Session queued behind concurrency limit",
+			"session_id", sessionID, "max_concurrent_sessions", state.MaxConcurrentSessions)
+	}
+	if err := workflow.Await(ctx, func() bool {
+		return state.canAdmit(sessionID)
+	}); err != nil {
+		updateSessionStatusByID(state, sessionID, AgentStatusShutdown)
+		return StartSessionResponse{}, fmt.Errorf("session %s admission wait cancelled: %w", sessionID, err)
+	}
+	updateSessionStatusByID(state, sessionID, AgentStatusPendingInit)
+
 	// Start SessionWorkflow as child with ABANDON policy so the harness
 	// can ContinueAsNew without terminating running sessions.
 	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
@@ -284,15 +396,37 @@ func handleStartSession(
 	// Wait for SessionWorkflow to actually start.
 	var exec workflow.Execution
 	if err := future.GetChildWorkflowExecution().Get(ctx, &exec); err != nil {
+		updateSessionStatusByID(state, sessionID, AgentStatusErrored) // free the slot
 		return StartSessionResponse{}, fmt.Errorf("failed to start SessionWorkflow %s: %w", sessionWfID, err)
 	}
 
+	// Spawn goroutine to watch child completion and update status. It
+	// starts before the readiness wait so a session that dies before
+	// signaling still frees its slot. SessionWorkflow also signals on
+	// completion, which handles the case where the harness CAN'd
+	// (goroutine lost) — but not a session terminated or timed out then,
+	// which reconcileSessions catches.
+	state.watched[sessionID] = true
+	workflow.Go(ctx, func(gctx workflow.Context) {
+		var result WorkflowResult
+		err := future.Get(gctx, &result)
+		delete(state.watched, sessionID)
+		if err != nil {
+			updateSessionStatusByID(state, sessionID, AgentStatusErrored)
+		} else {
+			updateSessionStatusByID(state, sessionID, AgentStatusCompleted)
+		}
+	})
+
 	// Wait for the update_session_status signal from SessionWorkflow to
-	// flip status from PendingInit → Running (meaning AgenticWorkflow is up).
+	// flip status from PendingInit → Running (meaning AgenticWorkflow is up),
+	// or for the session to end first.
 	// This avoids an activity-based polling loop that bloats harness history.
+	var status AgentStatus
 	if err := workflow.Await(ctx, func() bool {
 		for _, s := range state.Sessions {
 			if s.SessionID == sessionID {
+				status = s.Status
 				return s.Status != AgentStatusPendingInit
 			}
 		}
@@ -300,19 +434,9 @@ func handleStartSession(
 	}); err != nil {
 		return StartSessionResponse{}, fmt.Errorf("session %s readiness wait cancelled: %w", sessionID, err)
 	}
-
-	// Spawn goroutine to watch child completion and update status.
-	// Belt-and-suspenders: SessionWorkflow also signals on completion,
-	// which handles the case where the harness CAN'd (goroutine lost).
-	workflow.Go(ctx, func(gctx workflow.Context) {
-		var result WorkflowResult
-		err := future.Get(gctx, &result)
-		if err != nil {
-			updateSessionStatusByID(state, sessionID, AgentStatusErrored)
-		} else {
-			updateSessionStatusByID(state, sessionID, AgentStatusCompleted)
-		}
-	})
+	if status == AgentStatusErrored {
+		return StartSessionResponse{}, fmt.Errorf("session %s failed before it was ready", sessionID)
+	}
 
 	return StartSessionResponse{
 		SessionID:         sessionID,
@@ -320,6 +444,100 @@ func handleStartSession(
 	}, nil
 }
 
+// isActive reports whether a session occupies a concurrency slot.
+func (e SessionEntry) isActive() bool {
+	return e.Status == AgentStatusPendingInit || e.Status == AgentStatusRunning
+}
+
+// isEnded reports whether a session has finished for good.
+func (e SessionEntry) isEnded() bool {
+	return e.Status == AgentStatusCompleted || e.Status == AgentStatusErrored || e.Status == AgentStatusShutdown
+}
+
+// occupancy summarizes active and queued sessions.
+func (state *HarnessWorkflowState) occupancy() HarnessOccupancy {
+	occ := HarnessOccupancy{
+		MaxConcurrentSessions: state.MaxConcurrentSessions,
+		Queued:                []string{},
+	}
+	for _, e := range state.Sessions {
+		switch {
+		case e.isActive():
+			occ.Active++
+		case e.Status == AgentStatusQueued:
+			occ.Queued = append(occ.Queued, e.SessionID)
+		}
+	}
+	return occ
+}
+
+// canAdmit reports whether the queued session sessionID may start: it must be
+// first in the queue and a slot must be free.
+func (state *HarnessWorkflowState) canAdmit(sessionID string) bool {
+	occ := state.occupancy()
+	if len(occ.Queued) == 0 || occ.Queued[0] != sessionID {
+		return false
+	}
+	return occ.MaxConcurrentSessions <= 0 || occ.Active < occ.MaxConcurrentSessions
+}
+
+// unwatchedActive returns the SessionWorkflow IDs of active sessions whose
+// completion this run does not watch, i.e. those started before ContinueAsNew.
+func (state *HarnessWorkflowState) unwatchedActive() []string {
+	var ids []string
+	for _, e := range state.Sessions {
+		if e.isActive() && !state.watched[e.SessionID] {
+			ids = append(ids, e.SessionWorkflowID)
+		}
+	}
+	return ids
+}
+
+// queueWaitsOnUnwatched reports whether queued sessions wait for a slot
+// while unwatched sessions hold some of them.
+func (state *HarnessWorkflowState) queueWaitsOnUnwatched() bool {
+	occ := state.occupancy()
+	if len(occ.Queued) == 0 || occ.MaxConcurrentSessions <= 0 || occ.Active < occ.MaxConcurrentSessions {
+		return false
+	}
+	return len(state.unwatchedActive()) > 0
+}
+
+// reconcileSessions marks unwatched active sessions that are no longer
+// running as completed or errored, freeing their slots. Best-effort: when
+// the check fails the registry is left as is.
+func reconcileSessions(ctx workflow.Context, state *HarnessWorkflowState) {
+	ids := state.unwatchedActive()
+	if len(ids) == 0 {
+		return
+	}
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	})
+	var out activities.DescribeSessionsOutput
+	if err := workflow.ExecuteActivity(actCtx, "DescribeSessions", activities.DescribeSessionsInput{
+		SessionWorkflowIDs: ids,
+	}).Get(ctx, &out); err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to reconcile sessions", "error", err)
+		return
+	}
+	for _, id := range ids {
+		completed, ended := out.Ended[id]
+		if !ended {
+			continue
+		}
+		status := AgentStatusErrored
+		if completed {
+			status = AgentStatusCompleted
+		}
+		workflow.GetLogger(ctx).Info("Session ended unseen", "session_workflow_id", id, "status", status)
+		updateSessionStatusByWorkflowID(state, UpdateSessionStatusRequest{SessionWorkflowID: id, Status: status})
+	}
+}
+
 // mergeCLIOverrides overlays non-zero fields from overlay onto base.
 func mergeCLIOverrides(base CLIOverrides, overlay *CLIOverrides) CLIOverrides {
 	result := base
@@ -350,6 +568,12 @@ func mergeCLIOverrides(base CLIOverrides, overlay *CLIOverrides) CLIOverrides {
 	if overlay.Permissions.SandboxNetworkAccess {
 		result.Permissions.SandboxNetworkAccess = overlay.Permissions.SandboxNetworkAccess
 	}
+	if len(overlay.Permissions.SandboxNetworkAllow) > 0 {
+		result.Permissions.SandboxNetworkAllow = overlay.Permissions.SandboxNetworkAllow
+	}
+	if overlay.Permissions.SandboxAudit {
+		result.Permissions.SandboxAudit = overlay.Permissions.SandboxAudit
+	}
 	if overlay.SessionTaskQueue != "" {
 		result.SessionTaskQueue = overlay.SessionTaskQueue
 	}
@@ -362,6 +586,9 @@ func mergeCLIOverrides(base CLIOverrides, overlay *CLIOverrides) CLIOverrides {
 	if overlay.MemoryDbPath != "" {
 		result.MemoryDbPath = overlay.MemoryDbPath
 	}
+	if len(overlay.EnableTools) > 0 {
+		result.EnableTools = overlay.EnableTools
+	}
 	return result
 }
 
@@ -380,7 +607,8 @@ func updateSessionStatusByID(state *HarnessWorkflowState, sessionID string, stat
 func updateSessionStatusByWorkflowID(state *HarnessWorkflowState, req UpdateSessionStatusRequest) {
 	for i := range state.Sessions {
 		if state.Sessions[i].SessionWorkflowID == req.SessionWorkflowID {
-			if req.Status != "" {
+			// A late readiness signal must not revive an ended session.
+			if req.Status != "" && !(state.Sessions[i].isEnded() && req.Status == AgentStatusRunning) {
 				state.Sessions[i].Status = req.Status
 			}
 			if req.Name != "" {
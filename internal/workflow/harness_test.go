@@ -2,6 +2,8 @@
 package workflow
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -9,7 +11,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
 )
 
 // HarnessWorkflowTestSuite runs HarnessWorkflow tests with the Temporal test environment.
@@ -184,3 +190,276 @@ func (s *HarnessWorkflowTestSuite) TestHarness_NoConfigActivitiesOnStart() {
 	s.env.ExecuteWorkflow(HarnessWorkflow, harnessInput())
 	require.True(s.T(), s.env.IsWorkflowCompleted())
 }
+
+// newLongRunningChildEnv replaces the test environment with one whose
+// SessionWorkflow children keep running, so they hold concurrency slots.
+func (s *HarnessWorkflowTestSuite) newLongRunningChildEnv() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.RegisterWorkflowWithOptions(func(ctx workflow.Context, _ SessionWorkflowInput) error {
+		return workflow.Await(ctx, func() bool { return false })
+	}, workflow.RegisterOptions{Name: "SessionWorkflow"})
+}
+
+// startSession sends a start_session Update and records its response.
+func (s *HarnessWorkflowTestSuite) startSession(delay time.Duration, id string, resp *StartSessionResponse) {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateStartSession, id, &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) {
+				s.Fail("start_session should not be rejected", err.Error())
+			},
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				*resp = result.(StartSessionResponse)
+			},
+		}, StartSessionRequest{UserMessage: "hello " + id})
+	}, delay)
+}
+
+// signalSessionStatus signals a status change for the index-th session in
+// the harness registry.
+func (s *HarnessWorkflowTestSuite) signalSessionStatus(delay time.Duration, index int, status AgentStatus) {
+	s.env.RegisterDelayedCallback(func() {
+		sessions := s.querySessions()
+		require.Greater(s.T(), len(sessions), index)
+		s.env.SignalWorkflow(SignalUpdateSessionStatus, UpdateSessionStatusRequest{
+			SessionWorkflowID: sessions[index].SessionWorkflowID,
+			Status:            status,
+		})
+	}, delay)
+}
+
+func (s *HarnessWorkflowTestSuite) querySessions() []SessionEntry {
+	result, err := s.env.QueryWorkflow(QueryGetSessions)
+	require.NoError(s.T(), err)
+	var sessions []SessionEntry
+	require.NoError(s.T(), result.Get(&sessions))
+	return sessions
+}
+
+func (s *HarnessWorkflowTestSuite) queryOccupancy() HarnessOccupancy {
+	result, err := s.env.QueryWorkflow(QueryGetOccupancy)
+	require.NoError(s.T(), err)
+	var occ HarnessOccupancy
+	require.NoError(s.T(), result.Get(&occ))
+	return occ
+}
+
+// TestHarness_SessionLimitQueuesStarts verifies that a start_session beyond
+// MaxConcurrentSessions waits until a running session finishes.
+func (s *HarnessWorkflowTestSuite) TestHarness_SessionLimitQueuesStarts() {
+	s.newLongRunningChildEnv()
+	var first, second StartSessionResponse
+
+	s.startSession(time.Second, "start-1", &first)
+	s.signalSessionStatus(1500*time.Millisecond, 0, AgentStatusRunning)
+	s.startSession(2*time.Second, "start-2", &second)
+
+	// T=2.5s: the second session is queued; its Update has not completed.
+	s.env.RegisterDelayedCallback(func() {
+		assert.NotEmpty(s.T(), first.SessionID)
+		assert.Empty(s.T(), second.SessionID, "second session should still be queued")
+
+		occ := s.queryOccupancy()
+		assert.Equal(s.T(), 1, occ.MaxConcurrentSessions)
+		assert.Equal(s.T(), 1, occ.Active)
+		sessions := s.querySessions()
+		require.Len(s.T(), sessions, 2)
+		assert.Equal(s.T(), []string{sessions[1].SessionID}, occ.Queued)
+		assert.Equal(s.T(), AgentStatusQueued, sessions[1].Status)
+	}, 2500*time.Millisecond)
+
+	// T=3s: the first session completes, admitting the second.
+	s.signalSessionStatus(3*time.Second, 0, AgentStatusCompleted)
+	s.signalSessionStatus(3500*time.Millisecond, 1, AgentStatusRunning)
+
+	s.env.RegisterDelayedCallback(func() {
+		assert.NotEmpty(s.T(), second.SessionID, "second session should be admitted")
+		occ := s.queryOccupancy()
+		assert.Equal(s.T(), 1, occ.Active)
+		assert.Empty(s.T(), occ.Queued)
+	}, 4*time.Second)
+
+	s.cancelWorkflow(5 * time.Second)
+
+	input := harnessInput()
+	input.MaxConcurrentSessions = 1
+	s.env.ExecuteWorkflow(HarnessWorkflow, input)
+	s.assertWorkflowCompleted()
+}
+
+// TestHarness_SetSessionLimitAdmitsQueued verifies that raising the limit
+// admits queued sessions and that negative limits are rejected.
+func (s *HarnessWorkflowTestSuite) TestHarness_SetSessionLimitAdmitsQueued() {
+	s.newLongRunningChildEnv()
+	var first, second StartSessionResponse
+	var rejected bool
+
+	s.startSession(time.Second, "start-1", &first)
+	s.signalSessionStatus(1500*time.Millisecond, 0, AgentStatusRunning)
+	s.startSession(2*time.Second, "start-2", &second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateSetSessionLimit, "limit-bad", &testsuite.TestUpdateCallback{
+			OnAccept: func() { s.Fail("negative limit should be rejected") },
+			OnReject: func(err error) {
+				assert.Contains(s.T(), err.Error(), "must not be negative")
+				rejected = true
+			},
+			OnComplete: func(interface{}, error) {},
+		}, SetSessionLimitRequest{MaxConcurrentSessions: -1})
+
+		s.env.UpdateWorkflow(UpdateSetSessionLimit, "limit-2", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("limit update should not be rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				occ := result.(HarnessOccupancy)
+				assert.Equal(s.T(), 2, occ.MaxConcurrentSessions)
+			},
+		}, SetSessionLimitRequest{MaxConcurrentSessions: 2})
+	}, 2500*time.Millisecond)
+
+	s.signalSessionStatus(3*time.Second, 1, AgentStatusRunning)
+
+	s.env.RegisterDelayedCallback(func() {
+		assert.NotEmpty(s.T(), second.SessionID, "second session should be admitted")
+		occ := s.queryOccupancy()
+		assert.Equal(s.T(), 2, occ.Active)
+		assert.Empty(s.T(), occ.Queued)
+	}, 4*time.Second)
+
+	s.cancelWorkflow(5 * time.Second)
+
+	input := harnessInput()
+	input.MaxConcurrentSessions = 1
+	s.env.ExecuteWorkflow(HarnessWorkflow, input)
+	s.assertWorkflowCompleted()
+	assert.True(s.T(), rejected)
+}
+
+// TestHarness_UnlimitedByDefault verifies that without a limit sessions are
+// never queued.
+func (s *HarnessWorkflowTestSuite) TestHarness_UnlimitedByDefault() {
+	s.newLongRunningChildEnv()
+	var first, second StartSessionResponse
+
+	s.startSession(time.Second, "start-1", &first)
+	s.startSession(time.Second, "start-2", &second)
+	s.signalSessionStatus(1500*time.Millisecond, 0, AgentStatusRunning)
+	s.signalSessionStatus(1500*time.Millisecond, 1, AgentStatusRunning)
+
+	s.env.RegisterDelayedCallback(func() {
+		assert.NotEmpty(s.T(), first.SessionID)
+		assert.NotEmpty(s.T(), second.SessionID)
+		occ := s.queryOccupancy()
+		assert.Equal(s.T(), 0, occ.MaxConcurrentSessions)
+		assert.Equal(s.T(), 2, occ.Active)
+		assert.Empty(s.T(), occ.Queued)
+	}, 2*time.Second)
+
+	s.cancelWorkflow(3 * time.Second)
+	s.env.ExecuteWorkflow(HarnessWorkflow, harnessInput())
+	s.assertWorkflowCompleted()
+}
+
+// TestHarness_SessionFailingBeforeReadyFreesSlot verifies that a session
+// whose SessionWorkflow fails before signaling readiness fails its
+// start_session and does not keep its slot.
+func (s *HarnessWorkflowTestSuite) TestHarness_SessionFailingBeforeReadyFreesSlot() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.RegisterWorkflowWithOptions(func(ctx workflow.Context, _ SessionWorkflowInput) error {
+		return errors.New("config invalid")
+	}, workflow.RegisterOptions{Name: "SessionWorkflow"})
+	var startErr error
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateStartSession, "start-1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { s.Fail("start_session should not be rejected", err.Error()) },
+			OnComplete: func(_ interface{}, err error) { startErr = err },
+		}, StartSessionRequest{UserMessage: "hello"})
+	}, time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		require.Error(s.T(), startErr)
+		assert.Contains(s.T(), startErr.Error(), "failed before it was ready")
+		assert.Equal(s.T(), 0, s.queryOccupancy().Active)
+		assert.Equal(s.T(), AgentStatusErrored, s.querySessions()[0].Status)
+	}, 2*time.Second)
+
+	s.cancelWorkflow(3 * time.Second)
+	input := harnessInput()
+	input.MaxConcurrentSessions = 1
+	s.env.ExecuteWorkflow(HarnessWorkflow, input)
+	s.assertWorkflowCompleted()
+}
+
+// TestHarness_ReconcilesSessionsAfterContinueAsNew verifies that a session
+// started before ContinueAsNew that ended without signaling stops holding
+// its slot once the harness sees it is gone.
+func (s *HarnessWorkflowTestSuite) TestHarness_ReconcilesSessionsAfterContinueAsNew() {
+	s.newLongRunningChildEnv()
+	var checks int
+	s.env.RegisterActivityWithOptions(func(_ context.Context, in activities.DescribeSessionsInput) (activities.DescribeSessionsOutput, error) {
+		checks++
+		assert.Equal(s.T(), []string{"test-harness/sess-old"}, in.SessionWorkflowIDs)
+		if checks == 1 {
+			return activities.DescribeSessionsOutput{}, nil // still running
+		}
+		return activities.DescribeSessionsOutput{Ended: map[string]bool{"test-harness/sess-old": false}}, nil
+	}, activity.RegisterOptions{Name: "DescribeSessions"})
+	var second StartSessionResponse
+
+	s.startSession(time.Second, "start-2", &second)
+
+	s.env.RegisterDelayedCallback(func() {
+		occ := s.queryOccupancy()
+		assert.Equal(s.T(), 1, occ.Active, "old session still holds the slot")
+		assert.Len(s.T(), occ.Queued, 1)
+	}, 30*time.Second)
+
+	s.signalSessionStatus(2*sessionReconcileInterval, 1, AgentStatusRunning)
+
+	s.env.RegisterDelayedCallback(func() {
+		assert.Equal(s.T(), 2, checks)
+		assert.NotEmpty(s.T(), second.SessionID, "queued session should be admitted")
+		sessions := s.querySessions()
+		assert.Equal(s.T(), AgentStatusErrored, sessions[0].Status)
+		assert.Equal(s.T(), AgentStatusRunning, sessions[1].Status)
+	}, 2*sessionReconcileInterval+time.Second)
+
+	s.cancelWorkflow(3 * sessionReconcileInterval)
+	s.env.ExecuteWorkflow(HarnessWorkflowContinued, HarnessWorkflowState{
+		HarnessID:             "test-harness",
+		MaxConcurrentSessions: 1,
+		SessionCounter:        1,
+		Sessions: []SessionEntry{{
+			SessionID:         "sess-old",
+			SessionWorkflowID: "test-harness/sess-old",
+			Status:            AgentStatusRunning,
+		}},
+	})
+	s.assertWorkflowCompleted()
+}
+
+func TestHarnessOccupancy(t *testing.T) {
+	state := &HarnessWorkflowState{
+		MaxConcurrentSessions: 2,
+		Sessions: []SessionEntry{
+			{SessionID: "a", Status: AgentStatusRunning},
+			{SessionID: "b", Status: AgentStatusCompleted},
+			{SessionID: "c", Status: AgentStatusPendingInit},
+			{SessionID: "d", Status: AgentStatusQueued},
+			{SessionID: "e", Status: AgentStatusQueued},
+		},
+	}
+	occ := state.occupancy()
+	assert.Equal(t, 2, occ.Active)
+	assert.Equal(t, []string{"d", "e"}, occ.Queued)
+	assert.False(t, state.canAdmit("d"), "no free slot")
+
+	state.Sessions[0].Status = AgentStatusErrored
+	assert.True(t, state.canAdmit("d"))
+	assert.False(t, state.canAdmit("e"), "must wait behind d")
+}
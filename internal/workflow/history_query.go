@@ -0,0 +1,202 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// history_query.go implements the get_history query: history items filtered
+// by type, turn, time and text, a page at a time, so the CLI /search
+// command, the client history command and exporters can find items without
+// fetching the whole conversation.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+const (
+	// DefaultHistoryPageSize is the page size of a get_history query
+	// without a Limit.
+	DefaultHistoryPageSize = 100
+	// MaxHistoryPageSize caps the Limit of a get_history query.
+	MaxHistoryPageSize = 1000
+)
+
+// HistoryQuery selects history items for the get_history query. An item
+// must match every filter that is set.
+type HistoryQuery struct {
+	// Types keeps items of these types, e.g. assistant_message or
+	// function_call. Empty keeps every type.
+	Types []models.ConversationItemType `json:"types,omitempty"`
+	// TurnID keeps the items of one turn: those between its turn_started
+	// and turn_complete markers, the markers included.
+	TurnID string `json:"turn_id,omitempty"`
+	// Since keeps items added at or after this time. Items recorded before
+	// history kept timestamps have none and never match.
+	Since time.Time `json:"since,omitzero"`
+	// Contains keeps items whose text (message content, tool name,
+	// arguments or output) contains this string, ignoring case.
+	Contains string `json:"contains,omitempty"`
+
+	// NewestFirst returns matches from the end of history backwards.
+	NewestFirst bool `json:"newest_first,omitempty"`
+	// Limit is the page size: DefaultHistoryPageSize when 0, at most
+	// MaxHistoryPageSize.
+	Limit int `json:"limit,omitempty"`
+	// PageToken continues from the NextPageToken of the previous page.
+	PageToken string `json:"page_token,omitempty"`
+}
+
+// HistoryPage is a page of get_history results.
+type HistoryPage struct {
+	Items []models.ConversationItem `json:"items"`
+	// NextPageToken fetches the next page; empty on the last one.
+	NextPageToken string `json:"next_page_token,omitempty"`
+	// HistoryEpoch identifies the Seq numbering, as in
+	// ConversationItemsSinceResponse. A page token is only valid in the
+	// epoch that issued it.
+	HistoryEpoch int `json:"history_epoch"`
+}
+
+// Validate checks the query's types, limit and page token against the
+// current history epoch.
+func (q HistoryQuery) Validate(epoch int) error {
+	for _, t := range q.Types {
+		if !t.IsKnown() {
+			return fmt.Errorf("unknown item type %q", t)
+		}
+	}
+	if q.Limit < 0 || q.Limit > MaxHistoryPageSize {
+		return fmt.Errorf("limit %d is out of range: want 1 to %d, or 0 for %d", q.Limit, MaxHistoryPageSize, DefaultHistoryPageSize)
+	}
+	_, err := parseHistoryPageToken(q.PageToken, epoch)
+	return err
+}
+
+// queryHistory returns the page of items that match q. items are the raw
+// history, in Seq order.
+func queryHistory(items []models.ConversationItem, q HistoryQuery, epoch int) (HistoryPage, error) {
+	if err := q.Validate(epoch); err != nil {
+		return HistoryPage{}, err
+	}
+	after, _ := parseHistoryPageToken(q.PageToken, epoch)
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultHistoryPageSize
+	}
+
+	turns := itemTurnIDs(items)
+	matches := func(i int) bool {
+		item := items[i]
+		if len(q.Types) > 0 && !containsItemType(q.Types, item.Type) {
+			return false
+		}
+		if q.TurnID != "" && turns[i] != q.TurnID {
+			return false
+		}
+		if !q.Since.IsZero() && (item.CreatedAt.IsZero() || item.CreatedAt.Before(q.Since)) {
+			return false
+		}
+		return q.Contains == "" || itemContainsFold(item, q.Contains)
+	}
+
+	page := HistoryPage{Items: []models.ConversationItem{}, HistoryEpoch: epoch}
+	add := func(i int) bool {
+		if !matches(i) {
+			return true
+		}
+		if len(page.Items) == limit {
+			page.NextPageToken = formatHistoryPageToken(epoch, page.Items[limit-1].Seq)
+			return false
+		}
+		page.Items = append(page.Items, items[i])
+		return true
+	}
+	if q.NewestFirst {
+		for i := len(items) - 1; i >= 0; i-- {
+			if after != nil && items[i].Seq >= *after {
+				continue
+			}
+			if !add(i) {
+				break
+			}
+		}
+	} else {
+		for i := range items {
+			if after != nil && items[i].Seq <= *after {
+				continue
+			}
+			if !add(i) {
+				break
+			}
+		}
+	}
+	return page, nil
+}
+
+// itemTurnIDs returns the turn of each item: its own TurnID, or that of the
+// turn_started marker before it. Items outside a turn have none.
+func itemTurnIDs(items []models.ConversationItem) []string {
+	turns := make([]string, len(items))
+	current := ""
+	for i, item := range items {
+		if item.Type == models.ItemTypeTurnStarted {
+			current = item.TurnID
+		}
+		turns[i] = current
+		if item.TurnID != "" {
+			turns[i] = item.TurnID
+		}
+		if item.Type == models.ItemTypeTurnComplete {
+			current = ""
+		}
+	}
+	return turns
+}
+
+func containsItemType(types []models.ConversationItemType, t models.ConversationItemType) bool {
+	for _, want := range types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// itemContainsFold reports whether item's text contains s, ignoring case.
+func itemContainsFold(item models.ConversationItem, s string) bool {
+	s = strings.ToLower(s)
+	for _, text := range []string{item.Content, item.Name, item.Arguments} {
+		if strings.Contains(strings.ToLower(text), s) {
+			return true
+		}
+	}
+	return item.Output != nil && strings.Contains(strings.ToLower(item.Output.Content), s)
+}
+
+// formatHistoryPageToken encodes the epoch and the Seq of the last item
+// returned.
+func formatHistoryPageToken(epoch, seq int) string {
+	return fmt.Sprintf("%d.%d", epoch, seq)
+}
+
+// parseHistoryPageToken returns the Seq a page token continues after, or
+// nil for no token.
+func parseHistoryPageToken(token string, epoch int) (*int, error) {
+	if token == "" {
+		return nil, nil
+	}
+	e, s, ok := strings.Cut(token, ".")
+	tokenEpoch, err1 := strconv.Atoi(e)
+	seq, err2 := strconv.Atoi(s)
+	if !ok || err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid page token %q", token)
+	}
+	if tokenEpoch != epoch {
+		return nil, fmt.Errorf("history was rewritten since the page token was issued; query again without it")
+	}
+	return &seq, nil
+}
@@ -0,0 +1,154 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func historyQueryTestItems() []models.ConversationItem {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	items := []models.ConversationItem{
+		{Type: models.ItemTypeDeveloperMessage, Content: "Be brief."},
+		{Type: models.ItemTypeTurnStarted, TurnID: "turn-1"},
+		{Type: models.ItemTypeUserMessage, Content: "Fix the build", TurnID: "turn-1"},
+		{Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "shell", Arguments: `{"command":"go build"}`},
+		{Type: models.ItemTypeFunctionCallOutput, CallID: "c1", Output: &models.FunctionCallOutputPayload{Content: "undefined: Foo"}},
+		{Type: models.ItemTypeAssistantMessage, Content: "Fixed the missing Foo."},
+		{Type: models.ItemTypeTurnComplete, TurnID: "turn-1"},
+		{Type: models.ItemTypeTurnStarted, TurnID: "turn-2"},
+		{Type: models.ItemTypeUserMessage, Content: "Now run the tests", TurnID: "turn-2"},
+		{Type: models.ItemTypeFunctionCall, CallID: "c2", Name: "shell", Arguments: `{"command":"go test ./..."}`},
+		{Type: models.ItemTypeFunctionCallOutput, CallID: "c2", Output: &models.FunctionCallOutputPayload{Content: "ok"}},
+		{Type: models.ItemTypeAssistantMessage, Content: "All tests pass."},
+		{Type: models.ItemTypeTurnComplete, TurnID: "turn-2"},
+	}
+	for i := range items {
+		items[i].Seq = i
+		if i > 0 { // The first item predates timestamps.
+			items[i].CreatedAt = t0.Add(time.Duration(i) * time.Minute)
+		}
+	}
+	return items
+}
+
+func seqs(items []models.ConversationItem) []int {
+	out := make([]int, len(items))
+	for i, item := range items {
+		out[i] = item.Seq
+	}
+	return out
+}
+
+func TestQueryHistory_Filters(t *testing.T) {
+	items := historyQueryTestItems()
+	t0 := items[1].CreatedAt.Add(-time.Minute)
+
+	for _, tc := range []struct {
+		name string
+		q    HistoryQuery
+		want []int
+	}{
+		{"all", HistoryQuery{}, seqs(items)},
+		{"assistant messages", HistoryQuery{Types: []models.ConversationItemType{models.ItemTypeAssistantMessage}}, []int{5, 11}},
+		{"tool calls", HistoryQuery{Types: []models.ConversationItemType{models.ItemTypeFunctionCall, models.ItemTypeFunctionCallOutput}}, []int{3, 4, 9, 10}},
+		{"turn", HistoryQuery{TurnID: "turn-2"}, []int{7, 8, 9, 10, 11, 12}},
+		{"turn and type", HistoryQuery{TurnID: "turn-1", Types: []models.ConversationItemType{models.ItemTypeFunctionCall}}, []int{3}},
+		{"since", HistoryQuery{Since: t0.Add(11 * time.Minute)}, []int{11, 12}},
+		{"contains content", HistoryQuery{Contains: "foo"}, []int{4, 5}},
+		{"contains arguments", HistoryQuery{Contains: "GO TEST"}, []int{9}},
+		{"newest first", HistoryQuery{Types: []models.ConversationItemType{models.ItemTypeUserMessage}, NewestFirst: true}, []int{8, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := queryHistory(items, tc.q, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, seqs(page.Items))
+			assert.Empty(t, page.NextPageToken)
+		})
+	}
+
+	page, err := queryHistory(items, HistoryQuery{Since: t0}, 0)
+	require.NoError(t, err)
+	assert.NotContains(t, seqs(page.Items), 0, "an item without a timestamp never matches Since")
+}
+
+func TestQueryHistory_Pages(t *testing.T) {
+	items := historyQueryTestItems()
+	calls := []models.ConversationItemType{models.ItemTypeFunctionCall, models.ItemTypeFunctionCallOutput}
+
+	for _, newestFirst := range []bool{false, true} {
+		q := HistoryQuery{Types: calls, Limit: 3, NewestFirst: newestFirst}
+		var got []int
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 3)
+			page, err := queryHistory(items, q, 2)
+			require.NoError(t, err)
+			assert.Equal(t, 2, page.HistoryEpoch)
+			got = append(got, seqs(page.Items)...)
+			if page.NextPageToken == "" {
+				break
+			}
+			q.PageToken = page.NextPageToken
+		}
+		if newestFirst {
+			assert.Equal(t, []int{10, 9, 4, 3}, got)
+		} else {
+			assert.Equal(t, []int{3, 4, 9, 10}, got)
+		}
+	}
+
+	page, err := queryHistory(items, HistoryQuery{Types: calls, Limit: 4}, 0)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 4)
+	assert.Empty(t, page.NextPageToken, "no token when the page holds the last match")
+}
+
+func TestQueryHistory_Invalid(t *testing.T) {
+	items := historyQueryTestItems()
+	for _, tc := range []struct {
+		q    HistoryQuery
+		want string
+	}{
+		{HistoryQuery{Types: []models.ConversationItemType{"tool_call"}}, `unknown item type "tool_call"`},
+		{HistoryQuery{Limit: MaxHistoryPageSize + 1}, "out of range"},
+		{HistoryQuery{Limit: -1}, "out of range"},
+		{HistoryQuery{PageToken: "next"}, "invalid page token"},
+		{HistoryQuery{PageToken: formatHistoryPageToken(0, 3)}, "history was rewritten"},
+	} {
+		_, err := queryHistory(items, tc.q, 1)
+		assert.ErrorContains(t, err, tc.want)
+	}
+}
+
+// TestGetHistoryQuery verifies items are stamped with workflow time and the
+// get_history query filters them.
+func (s *AgenticWorkflowTestSuite) TestGetHistoryQuery() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 50), nil).Once()
+	start := s.env.Now()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetHistory, HistoryQuery{
+			Types: []models.ConversationItemType{models.ItemTypeAssistantMessage},
+		})
+		require.NoError(s.T(), err)
+		var page HistoryPage
+		require.NoError(s.T(), result.Get(&page))
+		require.Len(s.T(), page.Items, 1)
+		assert.Equal(s.T(), "Hello!", page.Items[0].Content)
+		assert.WithinDuration(s.T(), start, page.Items[0].CreatedAt, time.Second)
+
+		_, err = s.env.QueryWorkflow(QueryGetHistory, HistoryQuery{Types: []models.ConversationItemType{"tool_call"}})
+		assert.ErrorContains(s.T(), err, "unknown item type")
+	}, time.Second)
+
+	s.sendShutdown(2 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
@@ -0,0 +1,223 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// hooks.go runs the user's hook scripts (see internal/hooks) through the
+// RunHooks activity. A pre_tool_use objection blocks the call; the model
+// gets the hook's message as the call's output. So does a pre_tool_use
+// hook that could not run or timed out, unless hooks.fail_open is set.
+// post_tool_use and
+// turn_complete objections are added to history as developer messages.
+// An event without scripts is not asked about again in the same run.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/hooks"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+)
+
+// runHooks runs the scripts for event once per payload. Returns nil when
+// hooks are disabled or the event has no scripts, and the error when the
+// activity failed.
+func (s *SessionState) runHooks(ctx workflow.Context, event hooks.Event, payloads []hooks.Payload) ([]activities.HookOutcome, error) {
+	if !s.Config.Hooks.Enabled || s.hooksMissing[event] || len(payloads) == 0 {
+		return nil, nil
+	}
+	input := activities.RunHooksInput{
+		Event:      event,
+		CodexHome:  s.Config.CodexHome,
+		Cwd:        s.Config.Cwd,
+		TimeoutSec: s.Config.Hooks.TimeoutSec,
+		Payloads:   payloads,
+	}
+	if s.Config.Hooks.IsSandboxed(string(event)) {
+		input.Sandbox = s.hookSandboxPolicy()
+	}
+
+	var out activities.RunHooksOutput
+	if err := workflow.ExecuteActivity(s.hookActivityCtx(ctx), "RunHooks", input).Get(ctx, &out); err != nil {
+		workflow.GetLogger(ctx).Warn("Running hooks failed", "event", event, "error", err)
+		return nil, err
+	}
+	if out.NoScripts {
+		if s.hooksMissing == nil {
+			s.hooksMissing = make(map[hooks.Event]bool)
+		}
+		s.hooksMissing[event] = true
+		return nil, nil
+	}
+	for _, o := range out.Outcomes {
+		for _, r := range o.Results {
+			if r.Error != "" || (r.ExitCode != 0 && !r.Objected()) {
+				workflow.GetLogger(ctx).Warn("Hook script failed",
+					"event", event, "script", r.Script, "exit_code", r.ExitCode, "error", r.Error)
+			}
+		}
+	}
+	return out.Outcomes, nil
+}
+
+// applyPreToolHooks runs pre_tool_use hooks for the calls and records a
+// failed output for each call a hook blocked. A hook that failed to run
+// blocks its call unless hooks.fail_open is set. Returns the calls to run.
+func (s *SessionState) applyPreToolHooks(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem) []models.ConversationItem {
+	if !s.Config.Hooks.Enabled {
+		return calls
+	}
+	payloads := make([]hooks.Payload, len(calls))
+	for i, fc := range calls {
+		payloads[i] = s.hookPayload(ctrl, hooks.PreToolUse)
+		payloads[i].Tool = hookToolCall(fc)
+	}
+	outcomes, err := s.runHooks(ctx, hooks.PreToolUse, payloads)
+	if err != nil && !s.Config.Hooks.FailOpen {
+		for _, fc := range calls {
+			s.blockCall(ctrl, fc, fmt.Sprintf("Blocked: the user's %s hooks could not run: %v", hooks.PreToolUse, err))
+		}
+		return nil
+	}
+	if len(outcomes) != len(calls) {
+		return calls
+	}
+
+	var remaining []models.ConversationItem
+	for i, fc := range calls {
+		if obj := outcomes[i].Objection(); obj != nil {
+			s.blockCall(ctrl, fc, fmt.Sprintf("Blocked by the user's %s hook (%s): %s", hooks.PreToolUse, obj.Script, obj.Message))
+			continue
+		}
+		if f := outcomes[i].Failure(); f != nil && !s.Config.Hooks.FailOpen {
+			s.blockCall(ctrl, fc, fmt.Sprintf("Blocked: the user's %s hook (%s) failed: %s", hooks.PreToolUse, f.Script, f.Error))
+			continue
+		}
+		remaining = append(remaining, fc)
+	}
+	return remaining
+}
+
+// blockCall records a failed output for a call a pre_tool_use hook blocked.
+func (s *SessionState) blockCall(ctrl *LoopControl, fc models.ConversationItem, content string) {
+	falseVal := false
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: fc.CallID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &falseVal,
+		},
+	})
+	ctrl.NotifyItemAdded()
+}
+
+// applyPostToolHooks runs post_tool_use hooks for the executed calls and
+// adds their objections to history.
+func (s *SessionState) applyPostToolHooks(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem, results []activities.ToolActivityOutput) {
+	if !s.Config.Hooks.Enabled {
+		return
+	}
+	byID := make(map[string]activities.ToolActivityOutput, len(results))
+	for _, r := range results {
+		byID[r.CallID] = r
+	}
+	payloads := make([]hooks.Payload, 0, len(calls))
+	for _, fc := range calls {
+		r, ok := byID[fc.CallID]
+		if !ok {
+			continue
+		}
+		tc := hookToolCall(fc)
+		content := r.Content
+		tc.Output, tc.Success = &content, r.Success
+		p := s.hookPayload(ctrl, hooks.PostToolUse)
+		p.Tool = tc
+		payloads = append(payloads, p)
+	}
+	outcomes, _ := s.runHooks(ctx, hooks.PostToolUse, payloads) // Failures are logged
+	for i, o := range outcomes {
+		if obj := o.Objection(); obj != nil {
+			s.addHookFeedback(ctrl, fmt.Sprintf("The user's %s hook (%s) on the %s call %s: %s",
+				hooks.PostToolUse, obj.Script, payloads[i].Tool.Name, o.CallID, obj.Message))
+		}
+	}
+}
+
+// runTurnCompleteHooks runs turn_complete hooks and adds their objections
+// to history, where the model sees them next turn.
+func (s *SessionState) runTurnCompleteHooks(ctx workflow.Context, ctrl *LoopControl) {
+	if !s.Config.Hooks.Enabled {
+		return
+	}
+	p := s.hookPayload(ctrl, hooks.TurnComplete)
+	items, _ := s.History.GetRawItems()
+	p.LastMessage = extractFinalMessage(items)
+	outcomes, _ := s.runHooks(ctx, hooks.TurnComplete, []hooks.Payload{p}) // Failures are logged
+	for _, o := range outcomes {
+		if obj := o.Objection(); obj != nil {
+			s.addHookFeedback(ctrl, fmt.Sprintf("The user's %s hook (%s): %s", hooks.TurnComplete, obj.Script, obj.Message))
+		}
+	}
+}
+
+// addHookFeedback adds a hook's message to history as a developer message.
+func (s *SessionState) addHookFeedback(ctrl *LoopControl, content string) {
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeDeveloperMessage,
+		Content: content,
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+}
+
+// hookPayload returns the session fields of a hook payload.
+func (s *SessionState) hookPayload(ctrl *LoopControl, event hooks.Event) hooks.Payload {
+	return hooks.Payload{
+		Event:     event,
+		SessionID: s.ConversationID,
+		TurnID:    ctrl.CurrentTurnID(),
+		Cwd:       s.Config.Cwd,
+	}
+}
+
+// hookToolCall describes a call for a hook. Arguments that are not valid
+// JSON are passed as a JSON string.
+func hookToolCall(fc models.ConversationItem) *hooks.ToolCall {
+	args := json.RawMessage(fc.Arguments)
+	if !json.Valid(args) {
+		args, _ = json.Marshal(fc.Arguments)
+	}
+	return &hooks.ToolCall{CallID: fc.CallID, Name: fc.Name, Arguments: args}
+}
+
+// hookSandboxPolicy returns the sandbox for sandboxed hooks: the session's
+// sandbox, or read-only when the session runs unsandboxed.
+func (s *SessionState) hookSandboxPolicy() *sandbox.SandboxPolicy {
+	mode := sandbox.SandboxMode(s.Config.Permissions.SandboxMode)
+	if mode != sandbox.ModeWorkspaceWrite {
+		mode = sandbox.ModeReadOnly
+	}
+	return s.restrictedSandboxPolicy(mode)
+}
+
+// hookActivityCtx returns activity options for RunHooks, routed to the
+// session task queue. Scripts may have side effects, so they are not retried.
+func (s *SessionState) hookActivityCtx(ctx workflow.Context) workflow.Context {
+	opts := workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		opts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	return workflow.WithActivityOptions(ctx, opts)
+}
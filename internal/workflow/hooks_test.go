@@ -0,0 +1,173 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/hooks"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+)
+
+// hookEvent matches RunHooks calls for event.
+func hookEvent(event hooks.Event) interface{} {
+	return mock.MatchedBy(func(in activities.RunHooksInput) bool { return in.Event == event })
+}
+
+// TestHooks_BlockAndFeedback verifies a pre_tool_use objection blocks its
+// call, a post_tool_use objection is added to history, and an event without
+// scripts is not asked about again.
+func (s *AgenticWorkflowTestSuite) TestHooks_BlockAndFeedback() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-rm", Name: "shell_command", Arguments: `{"command": "rm -rf build"}`},
+				{Type: models.ItemTypeFunctionCall, CallID: "call-ls", Name: "shell_command", Arguments: `{"command": "ls"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Twice()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.CallID == "call-ls"
+	})).Return(activities.ToolActivityOutput{CallID: "call-ls", Content: "main.go", Success: &trueVal}, nil).Once()
+
+	s.env.OnActivity("RunHooks", mock.Anything, mock.MatchedBy(func(in activities.RunHooksInput) bool {
+		return in.Event == hooks.PreToolUse && len(in.Payloads) == 2 &&
+			in.Payloads[0].Tool.Name == "shell_command" && string(in.Payloads[0].Tool.Arguments) == `{"command":"rm -rf build"}` &&
+			in.TimeoutSec == 5 && in.Sandbox == nil
+	})).Return(activities.RunHooksOutput{Outcomes: []activities.HookOutcome{
+		{CallID: "call-rm", Results: []hooks.Result{{Script: "guard.sh", ExitCode: 2, Message: "no rm -rf"}}},
+		{CallID: "call-ls", Results: []hooks.Result{{Script: "guard.sh"}}},
+	}}, nil).Once()
+	s.env.OnActivity("RunHooks", mock.Anything, mock.MatchedBy(func(in activities.RunHooksInput) bool {
+		return in.Event == hooks.PostToolUse && len(in.Payloads) == 1 &&
+			*in.Payloads[0].Tool.Output == "main.go" && in.Sandbox != nil
+	})).Return(activities.RunHooksOutput{Outcomes: []activities.HookOutcome{
+		{CallID: "call-ls", Results: []hooks.Result{{Script: "lint.sh", ExitCode: 1, Message: "2 lint errors"}}},
+	}}, nil).Once()
+	s.env.OnActivity("RunHooks", mock.Anything, hookEvent(hooks.TurnComplete)).
+		Return(activities.RunHooksOutput{NoScripts: true}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Thanks"})
+	}, time.Second*2)
+	s.sendShutdown(time.Second * 5)
+
+	input := testInput("Clean up")
+	input.Config.Hooks = models.Hooks{Enabled: true, TimeoutSec: 5, Sandboxed: []string{"post_tool_use"}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.assertCallOutput("call-rm", "Blocked by the user's pre_tool_use hook (guard.sh): no rm -rf")
+	s.assertCallOutput("call-ls", "main.go")
+	var feedback []string
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeDeveloperMessage {
+			feedback = append(feedback, item.Content)
+		}
+	}
+	assert.Equal(s.T(), []string{"The user's post_tool_use hook (lint.sh) on the shell_command call call-ls: 2 lint errors"}, feedback)
+}
+
+// TestHooks_FailedPreToolHookBlocks verifies a pre_tool_use hook that timed
+// out or whose activity failed blocks its calls, and that fail_open runs
+// them instead.
+func (s *AgenticWorkflowTestSuite) TestHooks_FailedPreToolHookBlocks() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-slow", Name: "shell_command", Arguments: `{"command": "make"}`},
+				{Type: models.ItemTypeFunctionCall, CallID: "call-ok", Name: "shell_command", Arguments: `{"command": "ls"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-down", Name: "shell_command", Arguments: `{"command": "pwd"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.CallID == "call-ok"
+	})).Return(activities.ToolActivityOutput{CallID: "call-ok", Content: "main.go", Success: &trueVal}, nil).Once()
+
+	s.env.OnActivity("RunHooks", mock.Anything, mock.MatchedBy(func(in activities.RunHooksInput) bool {
+		return in.Event == hooks.PreToolUse && len(in.Payloads) == 2
+	})).Return(activities.RunHooksOutput{Outcomes: []activities.HookOutcome{
+		{CallID: "call-slow", Results: []hooks.Result{{Script: "guard.sh", ExitCode: -1, Error: "timed out after 5s"}}},
+		{CallID: "call-ok", Results: []hooks.Result{{Script: "guard.sh"}}},
+	}}, nil).Once()
+	s.env.OnActivity("RunHooks", mock.Anything, mock.MatchedBy(func(in activities.RunHooksInput) bool {
+		return in.Event == hooks.PreToolUse && len(in.Payloads) == 1
+	})).Return(activities.RunHooksOutput{}, errors.New("worker lost")).Once()
+	s.env.OnActivity("RunHooks", mock.Anything, mock.Anything).
+		Return(activities.RunHooksOutput{NoScripts: true}, nil).Maybe()
+
+	s.sendShutdown(time.Second * 5)
+
+	input := testInput("Build it")
+	input.Config.Hooks = models.Hooks{Enabled: true, TimeoutSec: 5}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.assertCallOutput("call-slow", "Blocked: the user's pre_tool_use hook (guard.sh) failed: timed out after 5s")
+	s.assertCallOutput("call-ok", "main.go")
+	var down string
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-down" {
+			down = item.Output.Content
+		}
+	}
+	assert.Contains(s.T(), down, "Blocked: the user's pre_tool_use hooks could not run: ")
+	assert.Contains(s.T(), down, "worker lost")
+}
+
+// TestHooks_FailOpen verifies fail_open runs a call whose pre_tool_use hook
+// timed out.
+func (s *AgenticWorkflowTestSuite) TestHooks_FailOpen() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMCallResponse("call-slow", "shell_command", `{"command": "make"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-slow", Content: "built", Success: &trueVal}, nil).Once()
+	s.env.OnActivity("RunHooks", mock.Anything, hookEvent(hooks.PreToolUse)).
+		Return(activities.RunHooksOutput{Outcomes: []activities.HookOutcome{
+			{CallID: "call-slow", Results: []hooks.Result{{Script: "guard.sh", ExitCode: -1, Error: "timed out after 5s"}}},
+		}}, nil).Once()
+	s.env.OnActivity("RunHooks", mock.Anything, mock.Anything).
+		Return(activities.RunHooksOutput{NoScripts: true}, nil).Maybe()
+
+	s.sendShutdown(time.Second * 5)
+
+	input := testInput("Build it")
+	input.Config.Hooks = models.Hooks{Enabled: true, TimeoutSec: 5, FailOpen: true}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.assertCallOutput("call-slow", "built")
+}
+
+func TestHookSandboxPolicy(t *testing.T) {
+	s := &SessionState{Config: models.SessionConfiguration{Cwd: "/repo"}}
+	assert.Equal(t, sandbox.ModeReadOnly, s.hookSandboxPolicy().Mode, "unsandboxed sessions run sandboxed hooks read-only")
+
+	s.Config.Permissions.SandboxMode = "workspace-write"
+	s.Config.Permissions.SandboxWritableRoots = []string{"/cache"}
+	p := s.hookSandboxPolicy()
+	assert.Equal(t, sandbox.ModeWorkspaceWrite, p.Mode)
+	assert.Equal(t, []sandbox.WritableRoot{"/repo", "/cache"}, p.WritableRoots)
+}
@@ -0,0 +1,45 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// idempotency.go suppresses duplicate user_input Updates. Clients attach an
+// idempotency key to each message; a retried delivery (e.g. a gateway retry
+// with a fresh Update ID) is acknowledged with the original turn instead of
+// starting a second, identical one.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+// maxRecentInputKeys bounds SessionState.RecentInputKeys. Retries arrive
+// within seconds, so only the last few messages need to be remembered.
+const maxRecentInputKeys = 32
+
+// InputKeyRecord maps a user_input idempotency key to the turn it started.
+type InputKeyRecord struct {
+	Key    string `json:"key"`
+	TurnID string `json:"turn_id"`
+}
+
+// turnForInputKey returns the turn started by an earlier user_input with the
+// same idempotency key. An empty key never matches.
+func (s *SessionState) turnForInputKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, r := range s.RecentInputKeys {
+		if r.Key == key {
+			return r.TurnID, true
+		}
+	}
+	return "", false
+}
+
+// rememberInputKey records that key started turnID, evicting the oldest
+// records beyond maxRecentInputKeys. An empty key is ignored.
+func (s *SessionState) rememberInputKey(key, turnID string) {
+	if key == "" {
+		return
+	}
+	s.RecentInputKeys = append(s.RecentInputKeys, InputKeyRecord{Key: key, TurnID: turnID})
+	if over := len(s.RecentInputKeys) - maxRecentInputKeys; over > 0 {
+		s.RecentInputKeys = append(s.RecentInputKeys[:0:0], s.RecentInputKeys[over:]...)
+	}
+}
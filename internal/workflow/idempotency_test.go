@@ -0,0 +1,150 @@
+package workflow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/skills"
+)
+
+func TestRememberInputKey(t *testing.T) {
+	s := &SessionState{}
+
+	s.rememberInputKey("", "turn-0")
+	assert.Empty(t, s.RecentInputKeys, "empty keys are not tracked")
+	_, ok := s.turnForInputKey("")
+	assert.False(t, ok)
+
+	s.rememberInputKey("k1", "turn-1")
+	turnID, ok := s.turnForInputKey("k1")
+	require.True(t, ok)
+	assert.Equal(t, "turn-1", turnID)
+	_, ok = s.turnForInputKey("k2")
+	assert.False(t, ok)
+}
+
+func TestRememberInputKey_EvictsOldest(t *testing.T) {
+	s := &SessionState{}
+	for i := 0; i < maxRecentInputKeys+5; i++ {
+		s.rememberInputKey(fmt.Sprintf("k%d", i), fmt.Sprintf("turn-%d", i))
+	}
+	assert.Len(t, s.RecentInputKeys, maxRecentInputKeys)
+
+	_, ok := s.turnForInputKey("k4")
+	assert.False(t, ok, "oldest keys are evicted")
+	turnID, ok := s.turnForInputKey("k5")
+	require.True(t, ok)
+	assert.Equal(t, "turn-5", turnID)
+}
+
+// TestUserInput_DuplicateKeyAcksOriginalTurn verifies that a redelivered
+// user_input with the same idempotency key returns the original turn and
+// does not start a second one.
+func (s *AgenticWorkflowTestSuite) TestUserInput_DuplicateKeyAcksOriginalTurn() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Once()
+
+	var turnIDs []string
+	captureTurn := &testsuite.TestUpdateCallback{
+		OnAccept: func() {},
+		OnReject: func(err error) { s.Fail("duplicate should be acked, not rejected", err.Error()) },
+		OnComplete: func(result interface{}, err error) {
+			require.NoError(s.T(), err)
+			turnIDs = append(turnIDs, result.(StateUpdateResponse).TurnID)
+		},
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-1", captureTurn,
+			UserInput{Content: "Run the tests", IdempotencyKey: "key-1"})
+	}, time.Second*2)
+	// Gateway retry: same key, new Update ID.
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-1-retry", captureTurn,
+			UserInput{Content: "Run the tests", IdempotencyKey: "key-1"})
+	}, time.Second*3)
+
+	s.sendShutdown(time.Second * 5)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hi"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), turnIDs, 2)
+	assert.NotEmpty(s.T(), turnIDs[0])
+	assert.Equal(s.T(), turnIDs[0], turnIDs[1])
+
+	var count int
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeUserMessage && item.Content == "Run the tests" {
+			count++
+		}
+	}
+	assert.Equal(s.T(), 1, count, "duplicate input must not start a second turn")
+	s.env.AssertExpectations(s.T())
+}
+
+// TestUserInput_DuplicateKeyWhileSkillLoading verifies that a retry
+// delivered while the original input is still reading a mentioned skill
+// is acked with the original turn rather than starting a second one.
+func (s *AgenticWorkflowTestSuite) TestUserInput_DuplicateKeyWhileSkillLoading() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Once()
+	s.env.OnActivity("ReadSkillContent", mock.Anything, mock.Anything).
+		After(3*time.Second).
+		Return(activities.ReadSkillContentOutput{Content: "Run golangci-lint."}, nil).Once()
+
+	var turnIDs []string
+	captureTurn := &testsuite.TestUpdateCallback{
+		OnAccept: func() {},
+		OnReject: func(err error) { s.Fail("duplicate should be acked, not rejected", err.Error()) },
+		OnComplete: func(result interface{}, err error) {
+			require.NoError(s.T(), err)
+			turnIDs = append(turnIDs, result.(StateUpdateResponse).TurnID)
+		},
+	}
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-1", captureTurn,
+			UserInput{Content: "Use $lint on the repo", IdempotencyKey: "key-1"})
+	}, time.Second*2)
+	// The retry lands while ReadSkillContent for the first delivery is pending.
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-1-retry", captureTurn,
+			UserInput{Content: "Use $lint on the repo", IdempotencyKey: "key-1"})
+	}, time.Second*3)
+
+	s.sendShutdown(time.Second * 10)
+
+	input := testInput("Hi")
+	resolved := &SessionState{Config: input.Config}
+	resolved.resolveProfile()
+	input.ResolvedProfile = &resolved.ResolvedProfile
+	input.LoadedSkills = []skills.SkillMetadata{{Name: "lint", Path: "/skills/lint/SKILL.md"}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Len(s.T(), turnIDs, 2)
+	assert.NotEmpty(s.T(), turnIDs[0])
+	assert.Equal(s.T(), turnIDs[0], turnIDs[1])
+
+	var count int
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeUserMessage && item.Content == "Use $lint on the repo" {
+			count++
+		}
+	}
+	assert.Equal(s.T(), 1, count, "duplicate input must not start a second turn")
+	s.env.AssertExpectations(s.T())
+}
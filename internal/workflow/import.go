@@ -0,0 +1,68 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// import.go implements cross-session context sharing: the import_context
+// Update summarizes another session's history (SummarizeSession activity,
+// using the compaction prompt) and appends the summary to this session's
+// history as a labeled context block.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// importContext summarizes the session identified by workflowID and records
+// the summary in history. The summary is an assistant message, like a
+// compaction summary, so it does not start a turn.
+func (s *SessionState) importContext(ctx workflow.Context, ctrl *LoopControl, workflowID string) (ImportContextResponse, error) {
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 3 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    30 * time.Second,
+			MaximumAttempts:    2,
+		},
+	})
+
+	var out activities.SummarizeSessionOutput
+	err := workflow.ExecuteActivity(actCtx, "SummarizeSession", activities.SummarizeSessionInput{
+		SourceWorkflowID: workflowID,
+		ModelConfig:      s.Config.Model,
+	}).Get(ctx, &out)
+	if err != nil {
+		return ImportContextResponse{}, fmt.Errorf("import from %s failed: %w", workflowID, err)
+	}
+
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeAssistantMessage,
+		Content: formatImportedContext(workflowID, out.Summary),
+	})
+	ctrl.NotifyItemAdded()
+
+	s.addTokenUsage(s.Config.Model, out.TokenUsage)
+
+	workflow.GetLogger(ctx).Info("Imported context",
+		"source", out.AgentWorkflowID, "source_items", out.ItemCount)
+
+	return ImportContextResponse{
+		SourceWorkflowID: out.AgentWorkflowID,
+		ItemCount:        out.ItemCount,
+		Summary:          out.Summary,
+	}, nil
+}
+
+// formatImportedContext wraps an imported summary in a labeled block so the
+// model can tell it apart from this session's own conversation.
+func formatImportedContext(workflowID, summary string) string {
+	return fmt.Sprintf("[Imported context from session %s]\n<imported_context source=%q>\n%s\n</imported_context>",
+		workflowID, workflowID, summary)
+}
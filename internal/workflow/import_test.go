@@ -0,0 +1,101 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestFormatImportedContext(t *testing.T) {
+	got := formatImportedContext("codex-abc", "Fixed the auth bug.")
+	assert.Equal(t, "[Imported context from session codex-abc]\n"+
+		"<imported_context source=\"codex-abc\">\nFixed the auth bug.\n</imported_context>", got)
+}
+
+// TestImportContext_AddsSummaryToHistory verifies that import_context runs
+// SummarizeSession with the session's model and records the labeled summary.
+func (s *AgenticWorkflowTestSuite) TestImportContext_AddsSummaryToHistory() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	var gotInput activities.SummarizeSessionInput
+	s.env.OnActivity("SummarizeSession", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			gotInput = args.Get(1).(activities.SummarizeSessionInput)
+		}).
+		Return(activities.SummarizeSessionOutput{
+			AgentWorkflowID: "other-session/agent",
+			Summary:         "Root cause: nil map in cache.go.",
+			ItemCount:       12,
+			TokenUsage:      models.TokenUsage{TotalTokens: 100},
+		}, nil).Once()
+
+	var resp ImportContextResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateImportContext, "import-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("import rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(ImportContextResponse)
+			},
+		}, ImportContextRequest{WorkflowID: " other-session "})
+	}, 2*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		itemsResult, err := s.env.QueryWorkflow(QueryGetConversationItems)
+		require.NoError(s.T(), err)
+		var items []models.ConversationItem
+		require.NoError(s.T(), itemsResult.Get(&items))
+		last := items[len(items)-1]
+		assert.Equal(s.T(), models.ItemTypeAssistantMessage, last.Type)
+		assert.Contains(s.T(), last.Content, "[Imported context from session other-session]")
+		assert.Contains(s.T(), last.Content, "Root cause: nil map in cache.go.")
+	}, 3*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	input := testInput("Hello")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "other-session", gotInput.SourceWorkflowID)
+	assert.Equal(s.T(), input.Config.Model, gotInput.ModelConfig)
+	assert.Equal(s.T(), "other-session/agent", resp.SourceWorkflowID)
+	assert.Equal(s.T(), 12, resp.ItemCount)
+}
+
+// TestImportContext_Rejected verifies the validator rejects an empty ID and
+// the session's own workflow ID without running the activity.
+func (s *AgenticWorkflowTestSuite) TestImportContext_Rejected() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	var rejections []error
+	reject := func(id string, req ImportContextRequest) {
+		s.env.UpdateWorkflow(UpdateImportContext, id, &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("update should be rejected") },
+			OnReject:   func(err error) { rejections = append(rejections, err) },
+			OnComplete: func(interface{}, error) {},
+		}, req)
+	}
+	s.env.RegisterDelayedCallback(func() {
+		reject("import-empty", ImportContextRequest{WorkflowID: "  "})
+		reject("import-self", ImportContextRequest{WorkflowID: "default-test-workflow-id"})
+	}, 2*time.Second)
+
+	s.sendShutdown(3 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.Len(s.T(), rejections, 2)
+	assert.Contains(s.T(), rejections[0].Error(), "workflow ID is required")
+	assert.Contains(s.T(), rejections[1].Error(), "into itself")
+}
@@ -81,6 +81,19 @@ func (s *SessionState) validateReasoningEffortForProfile() {
 	}
 }
 
+// modelConfigResponse describes the model configuration in effect for
+// update_model_config.
+func (s *SessionState) modelConfigResponse() UpdateModelConfigResponse {
+	resp := UpdateModelConfigResponse{
+		Model:                  s.Config.Model,
+		TemperatureUnsupported: s.ResolvedProfile.TemperatureUnsupported,
+	}
+	for _, preset := range s.ResolvedProfile.SupportedReasoningEfforts {
+		resp.SupportedReasoningEfforts = append(resp.SupportedReasoningEfforts, preset.Effort)
+	}
+	return resp
+}
+
 // resolveInstructions loads worker-side AGENTS.md files and merges all
 // instruction sources into the session configuration. Called when
 // BaseInstructions is empty (i.e. AgenticWorkflow was not started via
@@ -111,7 +124,10 @@ func (s *SessionState) resolveInstructions(ctx workflow.Context) {
 	if err != nil {
 		logger.Warn("Failed to load worker instructions, using defaults", "error", err)
 	} else {
-		workerDocs = loadResult.ProjectDocs
+		workerDocs = withOverflowSummaries(ctx, loadResult, s.Config.Model.Provider)
+		s.Config.WorkerShell = loadResult.Shell
+		s.Config.WorkerOS = loadResult.OS
+		s.Config.WorkerTimezone = loadResult.Timezone
 	}
 
 	// Merge all instruction sources, including profile's PromptSuffix
@@ -230,6 +246,9 @@ func (s *SessionState) initMcpServers(ctx workflow.Context) error {
 
 	// Store MCP tool lookup map for dispatch routing
 	s.McpToolLookup = initResult.McpToolLookup
+	if dropped := s.dropWritableMcpTools(); len(dropped) > 0 {
+		logger.Info("Dropped MCP tools not annotated read-only", "tools", dropped)
+	}
 
 	logger.Info("MCP servers initialized",
 		"tools_discovered", len(initResult.ToolSpecs),
@@ -238,6 +257,27 @@ func (s *SessionState) initMcpServers(ctx workflow.Context) error {
 	return nil
 }
 
+// dropWritableMcpTools removes the MCP tools not annotated read-only when
+// the session's tools are restricted to read-only ones (McpReadOnly), and
+// returns their names.
+func (s *SessionState) dropWritableMcpTools() []string {
+	if !s.Config.Tools.McpReadOnly {
+		return nil
+	}
+	var dropped []string
+	kept := s.ToolSpecs[:0]
+	for _, spec := range s.ToolSpecs {
+		if ref, ok := s.McpToolLookup[spec.Name]; ok && !ref.ReadOnly {
+			dropped = append(dropped, spec.Name)
+			delete(s.McpToolLookup, spec.Name)
+			continue
+		}
+		kept = append(kept, spec)
+	}
+	s.ToolSpecs = kept
+	return dropped
+}
+
 // memoryRoot returns the resolved memory folder root path.
 func (s *SessionState) memoryRoot() string {
 	if s.Config.MemoryRoot != "" {
@@ -508,8 +548,8 @@ func resolveHarnessConfig(ctx workflow.Context, overrides CLIOverrides) (models.
 	}
 	actCtx := workflow.WithActivityOptions(ctx, actOpts)
 
-	// Load worker-side project docs (AGENTS.md).
-	var workerDocs string
+	// Load worker-side project docs (AGENTS.md). Overflow docs are
+	// summarized once the session's provider is known.
 	var loadWorkerResult activities.LoadWorkerInstructionsOutput
 	loadWorkerInput := activities.LoadWorkerInstructionsInput{
 		Cwd:             overrides.Cwd,
@@ -517,8 +557,6 @@ func resolveHarnessConfig(ctx workflow.Context, overrides CLIOverrides) (models.
 	}
 	if err := workflow.ExecuteActivity(actCtx, "LoadWorkerInstructions", loadWorkerInput).Get(ctx, &loadWorkerResult); err != nil {
 		logger.Warn("Failed to load worker instructions", "error", err)
-	} else {
-		workerDocs = loadWorkerResult.ProjectDocs
 	}
 
 	// Load exec policy rules.
@@ -547,14 +585,6 @@ func resolveHarnessConfig(ctx workflow.Context, overrides CLIOverrides) (models.
 		personalInstructions = loadPersonalResult.Instructions
 	}
 
-	// Merge all instruction sources.
-	merged := instructions.MergeInstructions(instructions.MergeInput{
-		WorkerProjectDocs:        workerDocs,
-		UserPersonalInstructions: personalInstructions,
-		ApprovalMode:             string(overrides.Permissions.ApprovalMode),
-		Cwd:                      overrides.Cwd,
-	})
-
 	// Load config.toml from worker filesystem.
 	var loadConfigResult activities.LoadConfigFileOutput
 	loadConfigInput := activities.LoadConfigFileInput{
@@ -568,26 +598,31 @@ func resolveHarnessConfig(ctx workflow.Context, overrides CLIOverrides) (models.
 	cfg := models.DefaultSessionConfiguration()
 
 	// Apply TOML config (between defaults and CLI overrides).
+	var tomlCfg *models.ConfigToml
 	if loadConfigResult.RawTOML != "" {
-		tomlCfg, err := models.ParseConfigToml([]byte(loadConfigResult.RawTOML))
+		parsed, err := models.ParseConfigToml([]byte(loadConfigResult.RawTOML))
 		if err != nil {
 			logger.Warn("Failed to parse config.toml", "error", err)
 		} else {
+			tomlCfg = parsed
 			tomlCfg.ApplyToConfig(&cfg)
 		}
 	}
 
-	cfg.BaseInstructions = merged.Base
-	cfg.DeveloperInstructions = merged.Developer
-	cfg.UserInstructions = merged.User
 	cfg.ExecPolicyRules = execPolicyRules
 	cfg.Cwd = overrides.Cwd
+	cfg.WorkerShell = loadWorkerResult.Shell
+	cfg.WorkerOS = loadWorkerResult.OS
+	cfg.WorkerTimezone = loadWorkerResult.Timezone
 	cfg.CodexHome = overrides.CodexHome
 	cfg.SessionTaskQueue = overrides.SessionTaskQueue
 
 	if overrides.Permissions.ApprovalMode != "" {
 		cfg.Permissions.ApprovalMode = overrides.Permissions.ApprovalMode
 	}
+	if overrides.Permissions.SandboxAudit {
+		cfg.Permissions.SandboxAudit = overrides.Permissions.SandboxAudit
+	}
 	if overrides.Provider != "" {
 		cfg.Model.Provider = overrides.Provider
 	}
@@ -603,6 +638,39 @@ func resolveHarnessConfig(ctx workflow.Context, overrides CLIOverrides) (models.
 	if overrides.MemoryDbPath != "" {
 		cfg.MemoryDbPath = overrides.MemoryDbPath
 	}
+	if overrides.ArchiveURL != "" {
+		cfg.ArchiveURL = overrides.ArchiveURL
+	}
+	for _, name := range overrides.EnableTools {
+		if !cfg.Tools.HasTool(name) {
+			cfg.Tools.AddTools(name)
+		}
+	}
+
+	// Apply the policy declared in the front-matter of the AGENTS.md files
+	// from the git root down to cwd. The files come with the checkout, so
+	// they may only tighten config.toml and the CLI, and change the model
+	// only in a project the user trusts.
+	for _, msg := range loadWorkerResult.ProfileErrors {
+		logger.Warn("Ignoring invalid AGENTS.md front-matter", "error", msg)
+	}
+	if profile := loadWorkerResult.Profile; profile != nil {
+		trusted := tomlCfg != nil && instructions.IsTrustedProject(overrides.Cwd, tomlCfg.TrustedProjects)
+		cfg.ProjectPolicy = profile.ApplyToConfig(&cfg, trusted)
+		logger.Info("Applied directory profile", "sources", profile.Sources,
+			"applied", cfg.ProjectPolicy.Applied, "ignored", cfg.ProjectPolicy.Ignored)
+	}
+
+	// Merge all instruction sources.
+	merged := instructions.MergeInstructions(instructions.MergeInput{
+		WorkerProjectDocs:        withOverflowSummaries(ctx, loadWorkerResult, cfg.Model.Provider),
+		UserPersonalInstructions: personalInstructions,
+		ApprovalMode:             string(overrides.Permissions.ApprovalMode),
+		Cwd:                      overrides.Cwd,
+	})
+	cfg.BaseInstructions = merged.Base
+	cfg.DeveloperInstructions = merged.Developer
+	cfg.UserInstructions = merged.User
 
 	return cfg, nil
 }
@@ -0,0 +1,55 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// network.go answers the diagnose_network update: the worker serving the
+// session checks its LLM provider endpoints and the session's HTTP MCP
+// servers with its proxy and CA bundle settings.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/mcp"
+)
+
+// diagnoseNetwork runs the DiagnoseNetwork activity on the session's
+// worker. Endpoints are checked concurrently, each bounded by
+// netconfig.DefaultCheckTimeout, so one attempt fits in the timeout.
+func (s *SessionState) diagnoseNetwork(ctx workflow.Context) (activities.DiagnoseNetworkOutput, error) {
+	actOpts := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		actOpts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	actCtx := workflow.WithActivityOptions(ctx, actOpts)
+
+	input := activities.DiagnoseNetworkInput{McpServers: httpMcpServers(s.Config.McpServers)}
+	var out activities.DiagnoseNetworkOutput
+	err := workflow.ExecuteActivity(actCtx, "DiagnoseNetwork", input).Get(ctx, &out)
+	return out, err
+}
+
+// httpMcpServers returns the URLs of the enabled MCP servers that use the
+// streamable HTTP transport, by name. Stdio servers make no network calls.
+func httpMcpServers(servers map[string]mcp.McpServerConfig) map[string]string {
+	var urls map[string]string
+	for name, srv := range servers {
+		if !srv.IsEnabled() || !srv.Transport.IsHTTP() {
+			continue
+		}
+		if urls == nil {
+			urls = make(map[string]string)
+		}
+		urls[name] = srv.Transport.URL
+	}
+	return urls
+}
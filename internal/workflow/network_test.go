@@ -0,0 +1,56 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/mcp"
+	"github.com/mfateev/temporal-agent-harness/internal/netconfig"
+)
+
+func TestHTTPMcpServers(t *testing.T) {
+	disabled := false
+	servers := map[string]mcp.McpServerConfig{
+		"docs":  {Transport: mcp.McpServerTransportConfig{URL: "https://mcp.example.com/docs"}},
+		"local": {Transport: mcp.McpServerTransportConfig{Command: "docs-mcp"}},
+		"old":   {Transport: mcp.McpServerTransportConfig{URL: "https://old.example.com"}, Enabled: &disabled},
+	}
+	assert.Equal(t, map[string]string{"docs": "https://mcp.example.com/docs"}, httpMcpServers(servers))
+	assert.Nil(t, httpMcpServers(nil))
+}
+
+// TestDiagnoseNetwork_ReturnsWorkerResults verifies the diagnose_network
+// update runs the DiagnoseNetwork activity and returns its results.
+func (s *AgenticWorkflowTestSuite) TestDiagnoseNetwork_ReturnsWorkerResults() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("hi", 10), nil).Once()
+	want := activities.DiagnoseNetworkOutput{Results: []netconfig.CheckResult{
+		{Target: "openai", URL: "https://api.openai.com/v1/", Proxy: "none", OK: true, Status: 404},
+		{Target: "anthropic", URL: "https://api.anthropic.com/", Proxy: "http://proxy:3128", Error: "x509: certificate signed by unknown authority"},
+	}}
+	s.env.OnActivity("DiagnoseNetwork", mock.Anything, activities.DiagnoseNetworkInput{}).
+		Return(want, nil).Once()
+
+	var got activities.DiagnoseNetworkOutput
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateDiagnoseNetwork, "diagnose-1", &testsuite.TestUpdateCallback{
+			OnReject: func(err error) { s.Fail("update rejected", err) },
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				got = result.(activities.DiagnoseNetworkOutput)
+			},
+		})
+	}, time.Second)
+	s.sendShutdown(2 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	assert.Equal(s.T(), want, got)
+}
@@ -0,0 +1,69 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// output_diff.go remembers the output of shell and exec commands within a
+// turn so a command run again (tests, a build) is answered with only what
+// changed since its last run (Config.DiffRepeatedOutput).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// diffableTools are the tools whose repeated output is diffed.
+var diffableTools = map[string]bool{
+	"shell":         true,
+	"shell_command": true,
+	"exec_command":  true,
+}
+
+// commandArgs are the arguments that make two calls the same command;
+// timeouts and yield times do not change what a command prints.
+var commandArgs = []string{"command", "cmd", "workdir", "login", "shell", "tty"}
+
+// commandKey identifies a shell or exec command by tool and arguments.
+func commandKey(name string, args map[string]interface{}) string {
+	key := make(map[string]interface{}, len(commandArgs))
+	for _, a := range commandArgs {
+		if v, ok := args[a]; ok {
+			key[a] = v
+		}
+	}
+	b, _ := json.Marshal(key) // Map keys are sorted, so equal commands get equal keys
+	return name + " " + string(b)
+}
+
+// previousOutput returns the full output of the last run of the command in
+// this turn, or "" if it has not run.
+func (s *SessionState) previousOutput(name string, args map[string]interface{}) string {
+	return s.turnOutputs[commandKey(name, args)]
+}
+
+// rememberOutputs records the full output of the shell and exec calls just
+// run, for diffing their next run in the turn.
+func (s *SessionState) rememberOutputs(calls []models.ConversationItem, results []activities.ToolActivityOutput) {
+	if !s.Config.DiffRepeatedOutput {
+		return
+	}
+	for i, fc := range calls {
+		if !diffableTools[fc.Name] || i >= len(results) {
+			continue
+		}
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+			continue
+		}
+		output := results[i].FullContent
+		if output == "" {
+			output = results[i].Content
+		}
+		if s.turnOutputs == nil {
+			s.turnOutputs = make(map[string]string)
+		}
+		s.turnOutputs[commandKey(fc.Name, args)] = output
+	}
+}
@@ -0,0 +1,103 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// mockLLMShellCallResponse returns a shell_command call running command.
+func mockLLMShellCallResponse(callID, command string) activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{{
+			Type:      models.ItemTypeFunctionCall,
+			CallID:    callID,
+			Name:      "shell_command",
+			Arguments: `{"command": "` + command + `"}`,
+		}},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: 10},
+	}
+}
+
+// TestDiffRepeatedOutput_SendsPreviousRunWithinTurn verifies that a command
+// re-run in a turn is sent the full output of its last run, the output of
+// a different command is not, and a new turn starts with no outputs.
+func (s *AgenticWorkflowTestSuite) TestDiffRepeatedOutput_SendsPreviousRunWithinTurn() {
+	for _, call := range []struct{ id, command string }{
+		{"call-1", "go test ./..."},
+		{"call-2", "go test ./..."},
+		{"call-3", "go vet ./..."},
+		{"call-4", "go test ./..."},
+	} {
+		s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+			Return(mockLLMShellCallResponse(call.id, call.command), nil).Once()
+	}
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("fixed", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMShellCallResponse("call-5", "go test ./..."), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("still passing", 10), nil).Once()
+
+	previous := map[string]string{}
+	outputs := map[string]activities.ToolActivityOutput{
+		"call-1": {CallID: "call-1", Content: "FAIL pkg/a"},
+		"call-2": {CallID: "call-2", Content: "[diff]", FullContent: "FAIL pkg/b"},
+		"call-3": {CallID: "call-3", Content: "vet ok"},
+		"call-4": {CallID: "call-4", Content: "[diff]", FullContent: "ok"},
+		"call-5": {CallID: "call-5", Content: "ok"},
+	}
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			previous[input.CallID] = input.PreviousOutput
+			return outputs[input.CallID], nil
+		}).Times(5)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "run the tests again"})
+	}, time.Minute)
+	s.sendShutdown(2 * time.Minute)
+
+	input := testInput("fix the tests")
+	input.Config.DiffRepeatedOutput = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), map[string]string{
+		"call-1": "",
+		"call-2": "FAIL pkg/a",
+		"call-3": "",
+		"call-4": "FAIL pkg/b", // The full output, not the diff the model saw
+		"call-5": "",           // New turn
+	}, previous)
+}
+
+// TestDiffRepeatedOutput_OffByDefault verifies that without the option no
+// previous output is sent.
+func (s *AgenticWorkflowTestSuite) TestDiffRepeatedOutput_OffByDefault() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMShellCallResponse("call-1", "make"), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMShellCallResponse("call-2", "make"), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("built", 10), nil).Once()
+
+	var sent []string
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			sent = append(sent, input.PreviousOutput)
+			return activities.ToolActivityOutput{CallID: input.CallID, Content: "error: missing ;"}, nil
+		}).Times(2)
+	s.sendShutdown(time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("build it"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	assert.Equal(s.T(), []string{"", ""}, sent)
+}
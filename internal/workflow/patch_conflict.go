@@ -0,0 +1,143 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// patch_conflict.go asks the user about apply_patch calls whose hunks did
+// not apply: accept the closest match found in the file, or hand the hunk
+// back to the model. The prompt reuses the escalation flow (PendingEscalations
+// and the escalation_response Update).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+)
+
+// patchConflictDeclinedNote is appended to the output of a conflicting
+// apply_patch call the user chose not to apply at its closest match.
+const patchConflictDeclinedNote = "\n\nThe user declined to apply this hunk at the closest match. Re-read the file and regenerate the patch."
+
+// canPromptPatchConflicts reports whether someone may be around to answer:
+// an approval mode that asks (unset means never), no approval webhook, not
+// a subagent, and not an autonomous run.
+func (s *SessionState) canPromptPatchConflicts() bool {
+	mode := s.Config.Permissions.ApprovalMode
+	return mode != "" && mode != models.ApprovalNever &&
+		s.Config.ApprovalWebhook.URL == "" &&
+		s.AgentCtl.ParentDepth == 0 &&
+		s.AutonomyRun == nil
+}
+
+// handlePatchConflicts offers the user the closest match of every failed
+// apply_patch call that found one. Approved calls are re-run with
+// fuzzy_threshold set to the match's confidence; declined ones keep their
+// failure, with a note, for the model to fix. Returns the updated results.
+func (s *SessionState) handlePatchConflicts(
+	ctx workflow.Context,
+	ctrl *LoopControl,
+	functionCalls []models.ConversationItem,
+	toolResults []activities.ToolActivityOutput,
+) ([]activities.ToolActivityOutput, error) {
+	if !s.canPromptPatchConflicts() {
+		return toolResults, nil
+	}
+
+	var escalations []EscalationRequest
+	conflicted := make(map[int]bool)
+	for i, result := range toolResults {
+		c := result.PatchConflict
+		if c == nil || c.BestMatchLine == 0 || c.Confidence < patch.MinSuggestConfidence {
+			continue
+		}
+		conflicted[i] = true
+		escalations = append(escalations, EscalationRequest{
+			CallID:    result.CallID,
+			ToolName:  functionCalls[i].Name,
+			Arguments: functionCalls[i].Arguments,
+			Output:    result.Content,
+			Reason: fmt.Sprintf("hunk %d of %s did not apply; closest match at line %d (%d%%)",
+				c.Hunk, c.Path, c.BestMatchLine, patch.Percent(c.Confidence)),
+			PatchConflict: c,
+		})
+	}
+	if len(escalations) == 0 {
+		return toolResults, nil
+	}
+
+	waitStart := workflow.Now(ctx)
+	resp, err := ctrl.AwaitEscalation(ctx, escalations)
+	s.recordWaitTime(workflow.Now(ctx).Sub(waitStart))
+	if err != nil {
+		return nil, fmt.Errorf("patch conflict await failed: %w", err)
+	}
+	if resp == nil {
+		return toolResults, nil // Interrupted or shutdown
+	}
+
+	approved := make(map[string]bool, len(resp.Approved))
+	for _, id := range resp.Approved {
+		approved[id] = true
+	}
+	logger := workflow.GetLogger(ctx)
+	for i, result := range toolResults {
+		if !conflicted[i] {
+			continue
+		}
+		if !approved[result.CallID] {
+			toolResults[i].Content += patchConflictDeclinedNote
+			continue
+		}
+		if ctrl.IsInterrupted() {
+			logger.Info("Turn interrupted; not re-running apply_patch", "call_id", result.CallID)
+			continue
+		}
+
+		call, err := withFuzzyThreshold(functionCalls[i], result.PatchConflict.Confidence)
+		if err != nil {
+			logger.Warn("Cannot re-run apply_patch with fuzzy matching", "call_id", result.CallID, "error", err)
+			continue
+		}
+		logger.Info("Re-running apply_patch at the closest match", "call_id", result.CallID)
+		reResults, _, err := executeToolsInParallel(
+			ctx,
+			remapCallPaths([]models.ConversationItem{call}, s.PathRemaps),
+			toolRunOptions{
+				toolSpecs:        s.ToolSpecs,
+				cwd:              s.Config.Cwd,
+				sessionTaskQueue: s.Config.SessionTaskQueue,
+				sessionID:        s.ConversationID,
+				turnID:           ctrl.CurrentTurnID(),
+				mcpToolLookup:    s.McpToolLookup,
+				envPolicy:        s.envPolicy(),
+				sandboxPolicy:    s.sandboxPolicy(),
+				retryPolicies:    s.Config.RetryPolicies,
+			},
+		)
+		if err != nil || len(reResults) == 0 {
+			continue // Keep the original failure
+		}
+		toolResults[i] = reResults[0]
+	}
+	return toolResults, nil
+}
+
+// withFuzzyThreshold returns call with its fuzzy_threshold argument set.
+func withFuzzyThreshold(call models.ConversationItem, threshold float64) (models.ConversationItem, error) {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return call, err
+	}
+	args["fuzzy_threshold"] = threshold
+	data, err := json.Marshal(args)
+	if err != nil {
+		return call, err
+	}
+	call.Arguments = string(data)
+	return call, nil
+}
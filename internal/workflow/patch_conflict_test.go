@@ -0,0 +1,142 @@
+package workflow
+
+import (
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+)
+
+// mockApplyPatchResponse is an LLM response that calls apply_patch.
+func mockApplyPatchResponse() activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{{
+			Type:      models.ItemTypeFunctionCall,
+			CallID:    "call-patch",
+			Name:      "apply_patch",
+			Arguments: `{"input": "*** Begin Patch\n*** Update File: main.go\n@@\n-\treturn greeting\n+\treturn msg\n*** End Patch"}`,
+		}},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: 30},
+	}
+}
+
+// conflictOutput is an apply_patch failure with a closest match.
+func conflictOutput() activities.ToolActivityOutput {
+	falseVal := false
+	return activities.ToolActivityOutput{
+		CallID:  "call-patch",
+		Content: "Failed to find expected lines in main.go:\n\treturn greeting",
+		Success: &falseVal,
+		PatchConflict: &patch.Conflict{
+			Path:          "main.go",
+			Hunk:          1,
+			Expected:      []string{"\treturn greeting"},
+			Found:         []string{"\treturn msg"},
+			BestMatchLine: 5,
+			Confidence:    0.75,
+		},
+	}
+}
+
+// approvePatchAt approves the apply_patch call after delay.
+func (s *AgenticWorkflowTestSuite) approvePatchAt(delay time.Duration) {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approve-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-patch"}})
+	}, delay)
+}
+
+// TestPatchConflict_AcceptClosestMatch verifies an accepted conflict re-runs
+// apply_patch with fuzzy_threshold set to the match's confidence.
+func (s *AgenticWorkflowTestSuite) TestPatchConflict_AcceptClosestMatch() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockApplyPatchResponse(), nil).Once()
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		_, fuzzy := in.Arguments["fuzzy_threshold"]
+		return !fuzzy
+	})).Return(conflictOutput(), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(in activities.ToolActivityInput) bool {
+		return in.Arguments["fuzzy_threshold"] == 0.75
+	})).Return(activities.ToolActivityOutput{CallID: "call-patch", Content: "Success.", Success: &trueVal}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Patched.", 20), nil).Once()
+
+	s.approvePatchAt(time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		assert.Equal(s.T(), PhaseEscalationPending, status.Phase)
+		require.Len(s.T(), status.PendingEscalations, 1)
+		esc := status.PendingEscalations[0]
+		require.NotNil(s.T(), esc.PatchConflict)
+		assert.Equal(s.T(), 5, esc.PatchConflict.BestMatchLine)
+		assert.Equal(s.T(), "hunk 1 of main.go did not apply; closest match at line 5 (75%)", esc.Reason)
+
+		s.env.UpdateWorkflow(UpdateEscalationResponse, "esc-1", noopCallback(),
+			EscalationResponse{Approved: []string{"call-patch"}})
+	}, 2*time.Second)
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithApproval("Fix main.go", models.ApprovalUnlessTrusted))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	s.assertCallOutput("call-patch", "Success.")
+}
+
+// TestPatchConflict_HandBackToModel verifies a declined conflict keeps the
+// failure, with a note, for the model.
+func (s *AgenticWorkflowTestSuite) TestPatchConflict_HandBackToModel() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockApplyPatchResponse(), nil).Once()
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(conflictOutput(), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("I'll re-read the file.", 20), nil).Once()
+
+	s.approvePatchAt(time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateEscalationResponse, "esc-1", noopCallback(),
+			EscalationResponse{Denied: []string{"call-patch"}})
+	}, 2*time.Second)
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithApproval("Fix main.go", models.ApprovalUnlessTrusted))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	s.assertCallOutput("call-patch", conflictOutput().Content+patchConflictDeclinedNote)
+}
+
+// TestPatchConflict_NoPromptWithoutApprovals verifies conflicts go straight
+// to the model when no one is asked for approvals.
+func (s *AgenticWorkflowTestSuite) TestPatchConflict_NoPromptWithoutApprovals() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockApplyPatchResponse(), nil).Once()
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(conflictOutput(), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		for _, item := range in.History {
+			if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-patch" {
+				return !strings.Contains(item.Output.Content, patchConflictDeclinedNote)
+			}
+		}
+		return false
+	})).Return(mockLLMStopResponse("I'll re-read the file.", 20), nil).Once()
+	s.sendShutdown(4 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInputWithApproval("Fix main.go", models.ApprovalNever))
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+}
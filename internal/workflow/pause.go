@@ -0,0 +1,103 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// pause.go implements pausing a session (pause / resume Updates). A paused
+// session keeps its workflow, history and live exec sessions but starts no
+// turns, and its idle timer stops, so it is not continued-as-new while
+// frozen. The pause is mirrored to the workflow memo for the session picker.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// maxPauseReasonLen caps the length of a pause reason.
+	maxPauseReasonLen = 200
+
+	// MemoKeyPaused is the workflow memo key the pause is published under.
+	MemoKeyPaused = "paused"
+)
+
+// PauseInfo describes a paused session.
+type PauseInfo struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// PauseRequest is the payload for the pause Update.
+type PauseRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// ResumeRequest is the payload for the resume Update.
+type ResumeRequest struct{}
+
+// PauseResponse is returned by the pause and resume Updates.
+type PauseResponse struct {
+	Paused    *PauseInfo    `json:"paused,omitempty"`     // Set by pause
+	PausedFor time.Duration `json:"paused_for,omitempty"` // Set by resume: how long the session was paused
+}
+
+// validatePause reports whether the session can be paused now: only between
+// turns, so nothing is left half done.
+func (s *SessionState) validatePause(ctrl *LoopControl, req PauseRequest) error {
+	switch {
+	case ctrl.IsShutdown():
+		return fmt.Errorf("session is shutting down")
+	case s.Paused != nil:
+		return fmt.Errorf("session is already paused")
+	case ctrl.Phase() != PhaseWaitingForInput || ctrl.HasPendingWork() || s.AutonomyRun != nil:
+		return fmt.Errorf("a turn is in progress; wait for it to finish or interrupt it")
+	case len(strings.TrimSpace(req.Reason)) > maxPauseReasonLen:
+		return fmt.Errorf("reason exceeds %d characters", maxPauseReasonLen)
+	}
+	return nil
+}
+
+// errIfPaused rejects work that would start a turn while the session is
+// paused.
+func (s *SessionState) errIfPaused() error {
+	if s.Paused != nil {
+		return fmt.Errorf("session is paused; resume it first")
+	}
+	return nil
+}
+
+// setPaused pauses (info non-nil) or resumes the session and publishes the
+// change to the memo. On failure the previous state is kept.
+func (s *SessionState) setPaused(ctx workflow.Context, ctrl *LoopControl, info *PauseInfo) error {
+	prev := s.Paused
+	s.Paused = info
+	if err := workflow.UpsertMemo(ctx, map[string]interface{}{MemoKeyPaused: info}); err != nil {
+		s.Paused = prev
+		return fmt.Errorf("upsert memo: %w", err)
+	}
+	ctrl.SetPaused(info != nil)
+	return nil
+}
+
+// SessionPauseFromMemo decodes the pause of a session from its workflow memo
+// as returned by visibility APIs; nil when the session is not paused. A nil
+// dc uses the SDK default data converter.
+func SessionPauseFromMemo(memo *commonpb.Memo, dc converter.DataConverter) *PauseInfo {
+	if dc == nil {
+		dc = converter.GetDefaultDataConverter()
+	}
+	p, ok := memo.GetFields()[MemoKeyPaused]
+	if !ok {
+		return nil
+	}
+	var info *PauseInfo
+	if err := dc.FromPayload(p, &info); err != nil {
+		return nil
+	}
+	return info
+}
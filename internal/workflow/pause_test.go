@@ -0,0 +1,169 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestSessionPauseFromMemo(t *testing.T) {
+	dc := converter.GetDefaultDataConverter()
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	paused, err := dc.ToPayload(&PauseInfo{Since: since, Reason: "waiting on infra"})
+	require.NoError(t, err)
+	resumed, err := dc.ToPayload((*PauseInfo)(nil))
+	require.NoError(t, err)
+
+	info := SessionPauseFromMemo(&commonpb.Memo{Fields: map[string]*commonpb.Payload{MemoKeyPaused: paused}}, nil)
+	require.NotNil(t, info)
+	assert.True(t, since.Equal(info.Since))
+	assert.Equal(t, "waiting on infra", info.Reason)
+
+	assert.Nil(t, SessionPauseFromMemo(&commonpb.Memo{Fields: map[string]*commonpb.Payload{MemoKeyPaused: resumed}}, nil))
+	assert.Nil(t, SessionPauseFromMemo(nil, nil))
+}
+
+// rejectingCallback records the rejection of an Update that must not be
+// accepted.
+func (s *AgenticWorkflowTestSuite) rejectingCallback(rejected *error) *testsuite.TestUpdateCallback {
+	return &testsuite.TestUpdateCallback{
+		OnAccept:   func() { s.Fail("update should be rejected") },
+		OnReject:   func(err error) { *rejected = err },
+		OnComplete: func(interface{}, error) {},
+	}
+}
+
+// TestPause_FreezesSessionUntilResume verifies that a paused session rejects
+// new turns, outlives the idle timeout without ContinueAsNew, and takes input
+// again once resumed.
+func (s *AgenticWorkflowTestSuite) TestPause_FreezesSessionUntilResume() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("first", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("carry on")).
+		Return(mockLLMStopResponse("second", 10), nil).Once()
+
+	s.ignoreTitleMemo()
+	var memos []map[string]interface{}
+	s.env.OnUpsertMemo(mock.Anything).Run(func(args mock.Arguments) {
+		memos = append(memos, args.Get(0).(map[string]interface{}))
+	}).Return(nil)
+
+	var paused, resumed PauseResponse
+	var inputRejected, pauseRejected error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdatePause, "pause-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("unexpected reject", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				paused = result.(PauseResponse)
+			},
+		}, PauseRequest{Reason: " waiting on infra "})
+	}, 5*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		require.NotNil(s.T(), status.Paused)
+		assert.Equal(s.T(), "waiting on infra", status.Paused.Reason)
+
+		s.env.UpdateWorkflow(UpdateUserInput, "input-paused", s.rejectingCallback(&inputRejected),
+			UserInput{Content: "are you there?"})
+		s.env.UpdateWorkflow(UpdatePause, "pause-2", s.rejectingCallback(&pauseRejected), PauseRequest{})
+	}, 2*IdleTimeout)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateResume, "resume-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("unexpected reject", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resumed = result.(PauseResponse)
+			},
+		}, ResumeRequest{})
+	}, 2*IdleTimeout+time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "carry on"})
+	}, 2*IdleTimeout+2*time.Minute)
+	s.sendShutdown(2*IdleTimeout + 3*time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("investigate"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result), "no ContinueAsNew while paused")
+	assert.Equal(s.T(), "shutdown", result.EndReason)
+
+	require.NotNil(s.T(), paused.Paused)
+	assert.Equal(s.T(), "waiting on infra", paused.Paused.Reason)
+	assert.ErrorContains(s.T(), inputRejected, "session is paused")
+	assert.ErrorContains(s.T(), pauseRejected, "already paused")
+	assert.Equal(s.T(), 2*IdleTimeout+time.Minute-5*time.Second, resumed.PausedFor)
+
+	require.Len(s.T(), memos, 2)
+	assert.NotNil(s.T(), memos[0][MemoKeyPaused])
+	assert.Nil(s.T(), memos[1][MemoKeyPaused])
+}
+
+// TestPause_RejectedDuringTurn verifies a session cannot be paused while a
+// turn is running.
+func (s *AgenticWorkflowTestSuite) TestPause_RejectedDuringTurn() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		After(10*time.Second).Return(mockLLMStopResponse("slow", 10), nil).Once()
+
+	var rejected error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdatePause, "pause-1", s.rejectingCallback(&rejected), PauseRequest{})
+	}, 2*time.Second)
+	s.sendShutdown(time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	assert.ErrorContains(s.T(), rejected, "turn is in progress")
+}
+
+// TestPause_HoldsAgentInputUntilResume verifies that agent_input signalled
+// to a paused session starts no turn until the session is resumed.
+func (s *AgenticWorkflowTestSuite) TestPause_HoldsAgentInputUntilResume() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("first", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("from parent")).
+		Return(mockLLMStopResponse("second", 10), nil).Once()
+	s.ignoreTitleMemo()
+	s.env.OnUpsertMemo(mock.Anything).Return(nil)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdatePause, "pause-1", noopCallback(), PauseRequest{})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalAgentInput, AgentInputSignal{Content: "from parent"})
+	}, 3*time.Second)
+	var whilePaused []models.ConversationItem
+	s.env.RegisterDelayedCallback(func() {
+		whilePaused = s.queryItems()
+		s.env.UpdateWorkflow(UpdateResume, "resume-1", noopCallback(), ResumeRequest{})
+	}, time.Minute)
+	s.sendShutdown(2 * time.Minute)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("investigate"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	for _, item := range whilePaused {
+		assert.NotEqual(s.T(), "from parent", item.Content, "input must wait for resume")
+	}
+	var resumedTurn bool
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeAssistantMessage && item.Content == "second" {
+			resumedTurn = true
+		}
+	}
+	assert.True(s.T(), resumedTurn, "held input runs after resume")
+	s.env.AssertExpectations(s.T())
+}
@@ -0,0 +1,173 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// pin.go implements context pinning. Pinned history items (the original task
+// spec, key constraints) are kept verbatim when compaction summarizes the
+// history or a context overflow drops old turns. The user pins items with
+// /pin (pin_item Update); the LLM pins notes with the pin_context
+// intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// pinCandidates is how many recent pinnable messages /pin lists besides the
+// pinned items, so the user can find a seq to pin.
+const pinCandidates = 10
+
+// PinnedItem summarizes a history item for /pin.
+type PinnedItem struct {
+	Seq     int                         `json:"seq"`
+	Type    models.ConversationItemType `json:"type"`
+	Preview string                      `json:"preview"`
+	Pinned  bool                        `json:"pinned,omitempty"`
+}
+
+// validatePinSeq reports whether the item at seq can be pinned.
+func (s *SessionState) validatePinSeq(seq int) error {
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return err
+	}
+	if seq < 0 || seq >= len(items) {
+		return fmt.Errorf("no history item #%d", seq)
+	}
+	if t := items[seq].Type; !t.IsPinnable() {
+		return fmt.Errorf("item #%d is a %s item and cannot be pinned", seq, t)
+	}
+	return nil
+}
+
+// pinListing returns the pinned items plus the most recent pinnable
+// messages, in history order. A pinned call/output pair is listed once, by
+// its call.
+func (s *SessionState) pinListing() []PinnedItem {
+	items, _ := s.History.GetRawItems()
+	recent := 0
+	include := make([]bool, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		switch {
+		case item.Pinned && item.Type != models.ItemTypeFunctionCallOutput:
+			include[i] = true
+		case !item.Pinned && recent < pinCandidates && isPinCandidate(item):
+			include[i] = true
+			recent++
+		}
+	}
+
+	var out []PinnedItem
+	for i, item := range items {
+		if include[i] {
+			out = append(out, PinnedItem{
+				Seq:     item.Seq,
+				Type:    item.Type,
+				Preview: pinPreview(item),
+				Pinned:  item.Pinned,
+			})
+		}
+	}
+	return out
+}
+
+// isPinCandidate reports whether /pin should offer item: visible messages,
+// not internal context injections.
+func isPinCandidate(item models.ConversationItem) bool {
+	switch item.Type {
+	case models.ItemTypeUserMessage:
+		return !strings.HasPrefix(item.Content, "<environment_context>")
+	case models.ItemTypeAssistantMessage, models.ItemTypeDeveloperMessage:
+		return item.Content != ""
+	}
+	return false
+}
+
+// pinPreview is a one-line summary of item for /pin.
+func pinPreview(item models.ConversationItem) string {
+	text := item.Content
+	if item.Type == models.ItemTypeFunctionCall {
+		text = item.Name + " " + item.Arguments
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	return truncate(text, 80)
+}
+
+// pinKey identifies an item across compaction, which re-creates items and
+// renumbers them.
+func pinKey(item models.ConversationItem) string {
+	key := string(item.Type) + "\x00" + item.CallID + "\x00" + item.Content
+	if item.Output != nil {
+		key += "\x00" + item.Output.Content
+	}
+	return key
+}
+
+// restorePinned returns the compacted history with the pinned items of the
+// pre-compaction history kept. Pinned items the compactor carried over are
+// marked pinned in place; the rest are put back, in their original order,
+// ahead of the compacted items.
+func restorePinned(pinned, compacted []models.ConversationItem) []models.ConversationItem {
+	if len(pinned) == 0 {
+		return compacted
+	}
+	want := make(map[string]bool, len(pinned))
+	for _, item := range pinned {
+		want[pinKey(item)] = true
+	}
+	found := make(map[string]bool, len(pinned))
+	result := make([]models.ConversationItem, 0, len(pinned)+len(compacted))
+	for _, item := range compacted {
+		if key := pinKey(item); want[key] {
+			item.Pinned = true
+			found[key] = true
+		}
+		result = append(result, item)
+	}
+
+	var missing []models.ConversationItem
+	for _, item := range pinned {
+		if !found[pinKey(item)] {
+			missing = append(missing, item)
+		}
+	}
+	return append(missing, result...)
+}
+
+// handlePinContext intercepts a pin_context tool call. The note is recorded
+// in the call's output, and the call/output pair is pinned so the note
+// survives compaction verbatim.
+func (s *SessionState) handlePinContext(ctx workflow.Context, fc models.ConversationItem) models.ConversationItem {
+	var args struct {
+		Note string `json:"note"`
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return pinContextOutput(fc.CallID, fmt.Sprintf("Invalid pin_context arguments: %v", err), false)
+	}
+	note := strings.TrimSpace(args.Note)
+	if note == "" {
+		return pinContextOutput(fc.CallID, "pin_context failed: note must not be empty", false)
+	}
+	workflow.GetLogger(ctx).Info("Context pinned by agent", "note", truncate(note, 80))
+	out := pinContextOutput(fc.CallID, "Pinned: "+note, true)
+	out.Pinned = true
+	return out
+}
+
+func pinContextOutput(callID, content string, success bool) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &success,
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/history"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestRestorePinned(t *testing.T) {
+	spec := models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "Build the parser", Pinned: true}
+	recent := models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "Now add tests", Pinned: true}
+	compacted := []models.ConversationItem{
+		{Type: models.ItemTypeCompaction},
+		{Type: models.ItemTypeAssistantMessage, Content: "Summary"},
+		{Type: models.ItemTypeUserMessage, Content: "Now add tests"},
+	}
+
+	got := restorePinned([]models.ConversationItem{spec, recent}, compacted)
+	require.Len(t, got, 4)
+	assert.Equal(t, spec, got[0], "summarized-away pinned items go first")
+	assert.Equal(t, models.ItemTypeCompaction, got[1].Type)
+	assert.True(t, got[3].Pinned, "carried-over pinned items are marked in place")
+
+	assert.Equal(t, compacted, restorePinned(nil, compacted))
+}
+
+func TestPinListing(t *testing.T) {
+	h := history.NewInMemoryHistory()
+	for _, item := range []models.ConversationItem{
+		{Type: models.ItemTypeTurnStarted, TurnID: "turn-1"},
+		{Type: models.ItemTypeUserMessage, Content: "<environment_context>cwd</environment_context>"},
+		{Type: models.ItemTypeUserMessage, Content: "Build the\nparser"},
+		{Type: models.ItemTypeFunctionCall, CallID: "c1", Name: "pin_context", Arguments: `{"note":"use Go"}`, Pinned: true},
+		{Type: models.ItemTypeFunctionCallOutput, CallID: "c1", Pinned: true},
+		{Type: models.ItemTypeAssistantMessage, Content: "Done."},
+	} {
+		_ = h.AddItem(item)
+	}
+	s := &SessionState{History: h}
+
+	got := s.pinListing()
+	require.Len(t, got, 3)
+	assert.Equal(t, PinnedItem{Seq: 2, Type: models.ItemTypeUserMessage, Preview: "Build the parser"}, got[0])
+	assert.Equal(t, PinnedItem{Seq: 3, Type: models.ItemTypeFunctionCall, Preview: `pin_context {"note":"use Go"}`, Pinned: true}, got[1])
+	assert.Equal(t, 5, got[2].Seq)
+
+	assert.NoError(t, s.validatePinSeq(2))
+	assert.ErrorContains(t, s.validatePinSeq(0), "cannot be pinned")
+	assert.ErrorContains(t, s.validatePinSeq(6), "no history item #6")
+}
+
+// TestPinContext_SurvivesCompaction verifies that an item pinned with /pin
+// and a note pinned with the pin_context tool are kept verbatim when
+// compaction replaces the history with a summary.
+func (s *AgenticWorkflowTestSuite) TestPinContext_SurvivesCompaction() {
+	s.newEnv()
+
+	// Turn 1: the LLM pins a note, then replies.
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-pin", Name: "pin_context", Arguments: `{"note": "Target Go 1.22"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+
+	s.env.OnActivity("ExecuteCompact", mock.Anything, mock.Anything).
+		Return(activities.CompactActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeAssistantMessage, Content: "Compacted summary"},
+			},
+		}, nil).Once()
+
+	var pinErr error
+	var listed []PinnedItem
+	s.env.RegisterDelayedCallback(func() {
+		seq := -1
+		for _, item := range s.queryItems() {
+			if item.Type == models.ItemTypeUserMessage && item.Content == "Build the parser" {
+				seq = item.Seq
+			}
+		}
+		require.GreaterOrEqual(s.T(), seq, 0)
+		s.env.UpdateWorkflow(UpdatePinItem, "pin-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("pin rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				pinErr = err
+			},
+		}, PinItemRequest{Action: PinPin, Seq: seq})
+	}, 2*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdatePinItem, "pin-marker", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("pinning a turn marker should be rejected") },
+			OnReject:   func(err error) {},
+			OnComplete: func(interface{}, error) {},
+		}, PinItemRequest{Action: PinPin, Seq: 0})
+	}, 3*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateCompact, "compact-1", noopCallback(), CompactRequest{})
+	}, 4*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdatePinItem, "pin-list", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("list rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				listed = result.(PinItemResponse).Items
+			},
+		}, PinItemRequest{Action: PinList})
+	}, 6*time.Second)
+
+	s.sendShutdown(7 * time.Second)
+
+	input := testInput("Build the parser")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "pin_context")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), pinErr)
+
+	items := s.queryItems()
+	require.GreaterOrEqual(s.T(), len(items), 4)
+	assert.Equal(s.T(), "Build the parser", items[0].Content)
+	assert.True(s.T(), items[0].Pinned)
+	assert.Equal(s.T(), models.ItemTypeFunctionCall, items[1].Type)
+	assert.True(s.T(), items[1].Pinned)
+	assert.Equal(s.T(), "Pinned: Target Go 1.22", items[2].Output.Content)
+	assert.True(s.T(), items[2].Pinned)
+	assert.Equal(s.T(), "Compacted summary", items[3].Content)
+
+	var pinnedSeqs []int
+	for _, it := range listed {
+		if it.Pinned {
+			pinnedSeqs = append(pinnedSeqs, it.Seq)
+		}
+	}
+	assert.Equal(s.T(), []int{0, 1}, pinnedSeqs)
+}
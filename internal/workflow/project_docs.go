@@ -0,0 +1,56 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// project_docs.go summarizes the project docs (AGENTS.md files) that do not
+// fit under MaxProjectDocsBytes, so they are condensed rather than dropped.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// withOverflowSummaries returns the worker's project docs with summaries of
+// the overflow docs appended. Summaries use the provider's cheap model. If
+// the SummarizeProjectDocs activity fails, the overflow files are listed as
+// omitted so the model still knows they exist.
+func withOverflowSummaries(ctx workflow.Context, loaded activities.LoadWorkerInstructionsOutput, provider string) string {
+	if len(loaded.OverflowDocs) == 0 {
+		return loaded.ProjectDocs
+	}
+	logger := workflow.GetLogger(ctx)
+
+	model, resolvedProvider := instructions.SuggestionModelForProvider(provider)
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	})
+	var out activities.SummarizeProjectDocsOutput
+	err := workflow.ExecuteActivity(actCtx, "SummarizeProjectDocs", activities.SummarizeProjectDocsInput{
+		Docs: loaded.OverflowDocs,
+		ModelConfig: models.ModelConfig{
+			Provider:      resolvedProvider,
+			Model:         model,
+			Temperature:   0,
+			MaxTokens:     1024,
+			ContextWindow: 128000,
+		},
+	}).Get(ctx, &out)
+	if err != nil {
+		logger.Warn("Failed to summarize overflow project docs", "files", len(loaded.OverflowDocs), "error", err)
+		out.Summaries = make([]instructions.ProjectDocSummary, len(loaded.OverflowDocs))
+		for i, doc := range loaded.OverflowDocs {
+			out.Summaries[i] = instructions.ProjectDocSummary{Path: doc.Path, Bytes: doc.Bytes}
+		}
+	}
+	return instructions.AppendProjectDocSummaries(loaded.ProjectDocs, out.Summaries)
+}
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func overflowWorkerInstructions() activities.LoadWorkerInstructionsOutput {
+	return activities.LoadWorkerInstructionsOutput{
+		ProjectDocs:  "--- AGENTS.md (verbatim) ---\nroot rules",
+		OverflowDocs: []instructions.ProjectDoc{{Path: "sub/AGENTS.md", Content: "long rules", Bytes: 700000}},
+	}
+}
+
+// TestProjectDocs_OverflowSummarized verifies overflow docs reach the model
+// as summaries, marked apart from the verbatim docs.
+func (s *AgenticWorkflowTestSuite) TestProjectDocs_OverflowSummarized() {
+	s.env.RegisterActivity(LoadWorkerInstructions)
+	s.env.RegisterActivity(SummarizeProjectDocs)
+	s.env.OnActivity("LoadWorkerInstructions", mock.Anything, mock.Anything).
+		Return(overflowWorkerInstructions(), nil).Once()
+	s.env.OnActivity("SummarizeProjectDocs", mock.Anything, mock.MatchedBy(func(in activities.SummarizeProjectDocsInput) bool {
+		return len(in.Docs) == 1 && in.Docs[0].Path == "sub/AGENTS.md" && in.ModelConfig.Model == "gpt-4o-mini"
+	})).Return(activities.SummarizeProjectDocsOutput{
+		Summaries: []instructions.ProjectDocSummary{{Path: "sub/AGENTS.md", Summary: "- condensed rules", Bytes: 700000}},
+	}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		return strings.Contains(in.UserInstructions, "--- AGENTS.md (verbatim) ---\nroot rules") &&
+			strings.Contains(in.UserInstructions, "--- sub/AGENTS.md (summarized from 700000 bytes) ---\n- condensed rules")
+	})).Return(mockLLMStopResponse("OK", 20), nil).Once()
+
+	s.sendShutdown(2 * time.Second)
+
+	input := testInput("Hello")
+	input.Config.BaseInstructions = ""
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+}
+
+// TestProjectDocs_SummaryFailureListsOmitted verifies a failed summarization
+// still tells the model which files were left out.
+func (s *AgenticWorkflowTestSuite) TestProjectDocs_SummaryFailureListsOmitted() {
+	s.env.RegisterActivity(LoadWorkerInstructions)
+	s.env.RegisterActivity(SummarizeProjectDocs)
+	s.env.OnActivity("LoadWorkerInstructions", mock.Anything, mock.Anything).
+		Return(overflowWorkerInstructions(), nil).Once()
+	s.env.OnActivity("SummarizeProjectDocs", mock.Anything, mock.Anything).
+		Return(activities.SummarizeProjectDocsOutput{}, fmt.Errorf("provider down"))
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		return strings.Contains(in.UserInstructions, "--- sub/AGENTS.md (omitted, 700000 bytes: could not be summarized) ---")
+	})).Return(mockLLMStopResponse("OK", 20), nil).Once()
+
+	s.sendShutdown(2 * time.Second)
+
+	input := testInput("Hello")
+	input.Config.BaseInstructions = ""
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+}
+
+// TestResolveHarnessConfig_DirectoryProfile verifies the policy in AGENTS.md
+// front-matter narrows config.toml and the CLI overrides but cannot loosen
+// approvals, and changes the model only in a trusted project.
+func TestResolveHarnessConfig_DirectoryProfile(t *testing.T) {
+	repo, home := t.TempDir(), t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "AGENTS.md"),
+		[]byte("---\nmodel: claude-sonnet-4-0\nprovider: anthropic\napproval_mode: never\ntools:\n  deny: [shell]\n---\nrepo docs"), 0o644))
+
+	resolve := func(configTOML string) models.SessionConfiguration {
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.toml"), []byte(configTOML), 0o644))
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(activities.NewInstructionActivities())
+		env.RegisterWorkflowWithOptions(func(ctx workflow.Context, overrides CLIOverrides) (models.SessionConfiguration, error) {
+			return resolveHarnessConfig(ctx, overrides)
+		}, workflow.RegisterOptions{Name: "ResolveHarnessConfig"})
+
+		env.ExecuteWorkflow("ResolveHarnessConfig", CLIOverrides{
+			Cwd:         repo,
+			CodexHome:   home,
+			Model:       "gpt-4o-mini",
+			Permissions: models.Permissions{ApprovalMode: models.ApprovalUnlessTrusted},
+		})
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		var cfg models.SessionConfiguration
+		require.NoError(t, env.GetWorkflowResult(&cfg))
+		return cfg
+	}
+
+	cfg := resolve("model = \"o3\"\n")
+	assert.Equal(t, "gpt-4o-mini", cfg.Model.Model, "an untrusted project keeps the user's model")
+	assert.Equal(t, models.ApprovalUnlessTrusted, cfg.Permissions.ApprovalMode, "approvals are not loosened")
+	assert.False(t, cfg.Tools.HasTool("shell"))
+	assert.Contains(t, cfg.UserInstructions, "repo docs")
+	assert.NotContains(t, cfg.UserInstructions, "deny:")
+	require.NotNil(t, cfg.ProjectPolicy)
+	assert.Equal(t, []string{"AGENTS.md"}, cfg.ProjectPolicy.Sources)
+	assert.Len(t, cfg.ProjectPolicy.Ignored, 2)
+
+	cfg = resolve(fmt.Sprintf("model = \"o3\"\ntrusted_projects = [%q]\n", repo))
+	assert.Equal(t, "claude-sonnet-4-0", cfg.Model.Model)
+	assert.Equal(t, "anthropic", cfg.Model.Provider)
+	assert.Equal(t, models.ApprovalUnlessTrusted, cfg.Permissions.ApprovalMode, "trust does not loosen approvals")
+}
@@ -0,0 +1,287 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// remap.go moves a session to another checkout of its repository (the
+// remap_cwd Update), for resuming it on a machine where the original
+// working directory does not exist. The workflow reloads the project docs
+// from the new root, tells the model about the move, and rewrites paths
+// under the old root in later file tool calls, since history still refers
+// to them.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// PathRemap maps a former working directory to the one that replaced it.
+type PathRemap struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RemapCwdRequest is the payload for the remap_cwd Update.
+type RemapCwdRequest struct {
+	// Cwd is the new working directory: an absolute path on the session's
+	// worker.
+	Cwd string `json:"cwd"`
+}
+
+// RemapCwdResponse is returned by the remap_cwd Update.
+type RemapCwdResponse struct {
+	PreviousCwd string `json:"previous_cwd"`
+	Cwd         string `json:"cwd"`
+	ProjectDocs bool   `json:"project_docs"` // Project docs were found under the new root
+}
+
+// remapPathArgs are the arguments of each tool that name a file or
+// directory. apply_patch names its files in the patch headers instead.
+var remapPathArgs = map[string][]string{
+	"read_file":       {"file_path", "path"},
+	"write_file":      {"path"},
+	"list_dir":        {"dir_path"},
+	"grep_files":      {"path"},
+	"grep_changed":    {"path"},
+	"semantic_search": {"path"},
+	"code_outline":    {"path"},
+	"shell":           {"workdir"},
+	"shell_command":   {"workdir"},
+	"exec_command":    {"workdir"},
+}
+
+// patchFileHeaders are the apply_patch lines followed by a file path.
+var patchFileHeaders = []string{"*** Add File: ", "*** Update File: ", "*** Delete File: ", "*** Move to: "}
+
+// validateRemapCwd reports whether the session can move to cwd now: only
+// between turns, so no tool runs against the old root.
+func (s *SessionState) validateRemapCwd(ctrl *LoopControl, req RemapCwdRequest) error {
+	cwd := strings.TrimSpace(req.Cwd)
+	switch {
+	case ctrl.IsShutdown():
+		return fmt.Errorf("session is shutting down")
+	case cwd == "":
+		return fmt.Errorf("cwd is required")
+	case !isAbsPath(cwd):
+		return fmt.Errorf("cwd must be an absolute path, got %q", cwd)
+	case cleanPath(cwd) == cleanPath(s.Config.Cwd):
+		return fmt.Errorf("%s is already the working directory", cwd)
+	case ctrl.Phase() != PhaseWaitingForInput || ctrl.HasPendingWork() || s.AutonomyRun != nil:
+		return fmt.Errorf("a turn is in progress; wait for it to finish or interrupt it")
+	case ctrl.TurnStartHeld():
+		return fmt.Errorf("another command is running; wait for it to finish")
+	}
+	return nil
+}
+
+// remapCwd moves the session to cwd: it checks the directory exists on the
+// worker, reloads the project docs from it, and records the move in history
+// for the model. Turn start is held until it is done, so input sent
+// meanwhile starts its turn in the new directory.
+func (s *SessionState) remapCwd(ctx workflow.Context, ctrl *LoopControl, cwd string) (RemapCwdResponse, error) {
+	release := ctrl.HoldTurnStart()
+	defer release()
+	cwd = cleanPath(strings.TrimSpace(cwd))
+	actOpts := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		actOpts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	var loaded activities.LoadWorkerInstructionsOutput
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, actOpts), "LoadWorkerInstructions",
+		activities.LoadWorkerInstructionsInput{
+			Cwd:             cwd,
+			AgentsFileNames: s.ResolvedProfile.AgentsFileNames,
+		}).Get(ctx, &loaded)
+	if err != nil {
+		return RemapCwdResponse{}, fmt.Errorf("checking %s on the worker failed: %w", cwd, err)
+	}
+	if loaded.CwdMissing {
+		return RemapCwdResponse{}, fmt.Errorf("%s is not a directory on the session's worker", cwd)
+	}
+
+	previous := s.Config.Cwd
+	s.Config.Cwd = cwd
+	s.PathRemaps = addPathRemap(s.PathRemaps, previous, cwd)
+	s.Config.WorkerShell = loaded.Shell
+	s.Config.WorkerOS = loaded.OS
+	s.Config.WorkerTimezone = loaded.Timezone
+	for i, p := range s.CodeIndexStale {
+		s.CodeIndexStale[i] = remapPath(p, s.PathRemaps)
+	}
+	s.CodeIndexed = false // The index belongs to the old root
+
+	workerDocs := withOverflowSummaries(ctx, loaded, s.Config.Model.Provider)
+	merged := instructions.MergeInstructions(instructions.MergeInput{
+		PromptSuffix:             s.ResolvedProfile.PromptSuffix,
+		CLIProjectDocs:           s.Config.CLIProjectDocs,
+		WorkerProjectDocs:        workerDocs,
+		UserPersonalInstructions: s.Config.UserPersonalInstructions,
+		ApprovalMode:             string(s.Config.Permissions.ApprovalMode),
+		Cwd:                      cwd,
+		Personality:              s.Config.Personality,
+	})
+	s.Config.DeveloperInstructions = merged.Developer
+	s.Config.UserInstructions = merged.User
+
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeDeveloperMessage,
+		Content: formatCwdRemap(previous, cwd) + "\n\n" + s.environmentContext(ctx),
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+
+	workflow.GetLogger(ctx).Info("Working directory remapped",
+		"from", previous, "to", cwd, "project_docs", workerDocs != "")
+	return RemapCwdResponse{PreviousCwd: previous, Cwd: cwd, ProjectDocs: workerDocs != ""}, nil
+}
+
+// formatCwdRemap is the note telling the model about the move.
+func formatCwdRemap(from, to string) string {
+	if from == "" {
+		return fmt.Sprintf("The working directory is now %s.", to)
+	}
+	return fmt.Sprintf("The session moved to another checkout of the repository: the working directory is now %s instead of %s. "+
+		"Paths under %s in earlier messages refer to the same files under %s; file tool calls that use the old paths are translated.",
+		to, from, from, to)
+}
+
+// addPathRemap records a move from from to to. Earlier moves are redirected
+// to the new root, and a move back to an earlier root drops its mapping.
+func addPathRemap(remaps []PathRemap, from, to string) []PathRemap {
+	if from == "" {
+		return remaps
+	}
+	from = cleanPath(from)
+	out := make([]PathRemap, 0, len(remaps)+1)
+	for _, r := range remaps {
+		if r.From == to || r.From == from {
+			continue
+		}
+		r.To = to
+		out = append(out, r)
+	}
+	return append(out, PathRemap{From: from, To: to})
+}
+
+// remapPath rewrites p if it is under a remapped root. Relative paths are
+// left as they are: tools resolve them against the current working
+// directory.
+func remapPath(p string, remaps []PathRemap) string {
+	for _, r := range remaps {
+		if p == r.From {
+			return r.To
+		}
+		if strings.HasPrefix(p, r.From+"/") || strings.HasPrefix(p, r.From+`\`) {
+			return r.To + p[len(r.From):]
+		}
+	}
+	return p
+}
+
+// remapCallPaths returns calls with the paths in their arguments rewritten
+// by remaps. Calls without such paths are returned unchanged, as is the
+// slice when nothing changed.
+func remapCallPaths(calls []models.ConversationItem, remaps []PathRemap) []models.ConversationItem {
+	if len(remaps) == 0 {
+		return calls
+	}
+	var out []models.ConversationItem
+	for i, fc := range calls {
+		args, ok := remapCallArguments(fc, remaps)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make([]models.ConversationItem, len(calls))
+			copy(out, calls)
+		}
+		out[i].Arguments = args
+	}
+	if out == nil {
+		return calls
+	}
+	return out
+}
+
+// remapCallArguments rewrites the paths in one call's arguments, reporting
+// whether any changed.
+func remapCallArguments(fc models.ConversationItem, remaps []PathRemap) (string, bool) {
+	keys := remapPathArgs[fc.Name]
+	if len(keys) == 0 && fc.Name != "apply_patch" {
+		return "", false
+	}
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return "", false
+	}
+	changed := false
+	for _, key := range keys {
+		if p, ok := args[key].(string); ok {
+			if mapped := remapPath(p, remaps); mapped != p {
+				args[key] = mapped
+				changed = true
+			}
+		}
+	}
+	if fc.Name == "apply_patch" {
+		if input, ok := args["input"].(string); ok {
+			if mapped := remapPatchPaths(input, remaps); mapped != input {
+				args["input"] = mapped
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return "", false
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// remapPatchPaths rewrites the file paths in an apply_patch input's headers.
+func remapPatchPaths(input string, remaps []PathRemap) string {
+	lines := strings.Split(input, "\n")
+	for i, line := range lines {
+		for _, header := range patchFileHeaders {
+			if p, ok := strings.CutPrefix(line, header); ok {
+				lines[i] = header + remapPath(p, remaps)
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isAbsPath reports whether p is absolute on a Unix or Windows worker; the
+// workflow does not know which it is talking to.
+func isAbsPath(p string) bool {
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\\`) {
+		return true
+	}
+	return len(p) >= 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/')
+}
+
+// cleanPath drops trailing separators, keeping a bare root.
+func cleanPath(p string) string {
+	if trimmed := strings.TrimRight(p, `/\`); trimmed != "" && !strings.HasSuffix(trimmed, ":") {
+		return trimmed
+	}
+	return p
+}
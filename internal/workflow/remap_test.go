@@ -0,0 +1,203 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestAddPathRemap(t *testing.T) {
+	remaps := addPathRemap(nil, "/home/a/repo/", "/work/repo")
+	assert.Equal(t, []PathRemap{{From: "/home/a/repo", To: "/work/repo"}}, remaps)
+
+	// A second move redirects the first root to the newest one.
+	remaps = addPathRemap(remaps, "/work/repo", "/srv/repo")
+	assert.Equal(t, []PathRemap{
+		{From: "/home/a/repo", To: "/srv/repo"},
+		{From: "/work/repo", To: "/srv/repo"},
+	}, remaps)
+
+	// Moving back to a former root drops its mapping.
+	remaps = addPathRemap(remaps, "/srv/repo", "/home/a/repo")
+	assert.Equal(t, []PathRemap{
+		{From: "/work/repo", To: "/home/a/repo"},
+		{From: "/srv/repo", To: "/home/a/repo"},
+	}, remaps)
+
+	assert.Empty(t, addPathRemap(nil, "", "/work/repo"), "a session without a cwd has nothing to remap")
+}
+
+func TestRemapPath(t *testing.T) {
+	remaps := []PathRemap{{From: "/home/a/repo", To: "/work/repo"}, {From: `C:\src\repo`, To: "/work/repo"}}
+	tests := []struct {
+		in, want string
+	}{
+		{"/home/a/repo", "/work/repo"},
+		{"/home/a/repo/cmd/main.go", "/work/repo/cmd/main.go"},
+		{"/home/a/repository/main.go", "/home/a/repository/main.go"},
+		{"cmd/main.go", "cmd/main.go"},
+		{"/etc/hosts", "/etc/hosts"},
+		{`C:\src\repo\main.go`, `/work/repo\main.go`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, remapPath(tt.in, remaps), tt.in)
+	}
+}
+
+func TestRemapCallPaths(t *testing.T) {
+	remaps := []PathRemap{{From: "/old/repo", To: "/new/repo"}}
+	calls := []models.ConversationItem{
+		{CallID: "c1", Name: "read_file", Arguments: `{"file_path":"/old/repo/a.go","offset":2}`},
+		{CallID: "c2", Name: "shell", Arguments: `{"command":["ls"],"workdir":"/old/repo/cmd"}`},
+		{CallID: "c3", Name: "apply_patch", Arguments: `{"input":"*** Begin Patch\n*** Update File: /old/repo/b.go\n@@\n-x\n+y\n*** Move to: /old/repo/c.go\n*** End Patch"}`},
+		{CallID: "c4", Name: "read_file", Arguments: `{"file_path":"a.go"}`},
+		{CallID: "c5", Name: "fetch_url", Arguments: `{"url":"/old/repo"}`},
+	}
+
+	out := remapCallPaths(calls, remaps)
+	require.Len(t, out, len(calls))
+	args := func(i int) map[string]interface{} {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(out[i].Arguments), &m))
+		return m
+	}
+	assert.Equal(t, "/new/repo/a.go", args(0)["file_path"])
+	assert.Equal(t, float64(2), args(0)["offset"])
+	assert.Equal(t, "/new/repo/cmd", args(1)["workdir"])
+	assert.Equal(t, "*** Begin Patch\n*** Update File: /new/repo/b.go\n@@\n-x\n+y\n*** Move to: /new/repo/c.go\n*** End Patch", args(2)["input"])
+	assert.Equal(t, calls[3].Arguments, out[3].Arguments, "relative paths resolve against the new cwd")
+	assert.Equal(t, calls[4].Arguments, out[4].Arguments, "only file tools are rewritten")
+	assert.Equal(t, `{"file_path":"/old/repo/a.go","offset":2}`, calls[0].Arguments, "the calls in history keep their arguments")
+
+	unchanged := calls[3:4]
+	assert.Equal(t, &unchanged[0], &remapCallPaths(unchanged, remaps)[0], "nothing to rewrite returns the same slice")
+}
+
+// TestRemapCwd_MovesSessionAndTranslatesPaths verifies that remap_cwd
+// reloads the project docs from the new directory, tells the model, and
+// rewrites old paths in the next file tool call.
+func (s *AgenticWorkflowTestSuite) TestRemapCwd_MovesSessionAndTranslatesPaths() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	var secondCall activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("read main.go")).
+		Run(func(args mock.Arguments) { secondCall = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMCallResponse("call-1", "read_file", `{"file_path":"/old/repo/main.go"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("done", 10), nil).Once()
+
+	loadFrom := func(cwd string) interface{} {
+		return mock.MatchedBy(func(in activities.LoadWorkerInstructionsInput) bool { return in.Cwd == cwd })
+	}
+	s.env.RegisterActivity(LoadWorkerInstructions)
+	s.env.OnActivity("LoadWorkerInstructions", mock.Anything, loadFrom("/new/repo")).
+		Return(activities.LoadWorkerInstructionsOutput{ProjectDocs: "# Build with make", Shell: "bash", OS: "linux"}, nil).Once()
+	s.env.OnActivity("LoadWorkerInstructions", mock.Anything, loadFrom("/missing")).
+		Return(activities.LoadWorkerInstructionsOutput{CwdMissing: true}, nil).Once()
+
+	var toolInput activities.ToolActivityInput
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { toolInput = args.Get(1).(activities.ToolActivityInput) }).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: "package main"}, nil).Once()
+
+	var resp RemapCwdResponse
+	var missingErr, relativeErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateRemapCwd, "remap-relative", s.rejectingCallback(&relativeErr), RemapCwdRequest{Cwd: "repo"})
+		s.env.UpdateWorkflow(UpdateRemapCwd, "remap-missing", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { s.Fail("remap rejected", err.Error()) },
+			OnComplete: func(_ interface{}, err error) { missingErr = err },
+		}, RemapCwdRequest{Cwd: "/missing"})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateRemapCwd, "remap-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("remap rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(RemapCwdResponse)
+			},
+		}, RemapCwdRequest{Cwd: "/new/repo/"})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "read main.go"})
+	}, 3*time.Second)
+	s.sendShutdown(time.Minute)
+
+	input := testInputWithApproval("Hello", models.ApprovalNever)
+	input.Config.Cwd = "/old/repo"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Error(s.T(), relativeErr)
+	assert.Contains(s.T(), relativeErr.Error(), "absolute path")
+	require.Error(s.T(), missingErr)
+	assert.Contains(s.T(), missingErr.Error(), "/missing is not a directory")
+
+	assert.Equal(s.T(), RemapCwdResponse{PreviousCwd: "/old/repo", Cwd: "/new/repo", ProjectDocs: true}, resp)
+	assert.Contains(s.T(), secondCall.UserInstructions, "# Build with make")
+	assert.Contains(s.T(), secondCall.DeveloperInstructions, "/new/repo")
+	var note string
+	for _, item := range secondCall.History {
+		if item.Type == models.ItemTypeDeveloperMessage {
+			note = item.Content
+		}
+	}
+	assert.Contains(s.T(), note, "the working directory is now /new/repo instead of /old/repo")
+	assert.Contains(s.T(), note, "<cwd>/new/repo</cwd>")
+
+	assert.Equal(s.T(), "/new/repo", toolInput.Cwd)
+	assert.Equal(s.T(), "/new/repo/main.go", toolInput.Arguments["file_path"])
+}
+
+// TestRemapCwd_HoldsTurnStart verifies that input sent while remap_cwd is
+// still checking the new directory starts its turn after the move, and that
+// a second remap is refused meanwhile.
+func (s *AgenticWorkflowTestSuite) TestRemapCwd_HoldsTurnStart() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	var secondCall activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("where am I")).
+		Run(func(args mock.Arguments) { secondCall = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("/new/repo", 10), nil).Once()
+
+	s.env.RegisterActivity(LoadWorkerInstructions)
+	s.env.OnActivity("LoadWorkerInstructions", mock.Anything, mock.Anything).After(10*time.Second).
+		Return(activities.LoadWorkerInstructionsOutput{Shell: "bash", OS: "linux"}, nil).Once()
+
+	var secondRemapErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateRemapCwd, "remap-1", noopCallback(), RemapCwdRequest{Cwd: "/new/repo"})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateRemapCwd, "remap-2", s.rejectingCallback(&secondRemapErr), RemapCwdRequest{Cwd: "/other/repo"})
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "where am I"})
+	}, 2*time.Second)
+	s.sendShutdown(time.Minute)
+
+	input := testInputWithApproval("Hello", models.ApprovalNever)
+	input.Config.Cwd = "/old/repo"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Error(s.T(), secondRemapErr)
+	assert.Contains(s.T(), secondRemapErr.Error(), "another command is running")
+	assert.Contains(s.T(), secondCall.DeveloperInstructions, "/new/repo", "the turn starts after the move")
+	var note string
+	for _, item := range secondCall.History {
+		if item.Type == models.ItemTypeDeveloperMessage && strings.Contains(item.Content, "now /new/repo") {
+			note = item.Content
+		}
+	}
+	assert.NotEmpty(s.T(), note, "the move is recorded before the model sees the input")
+}
@@ -0,0 +1,75 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// retry_policy.go applies the session's retry_policies overrides to the
+// built-in LLM, tool and compaction activity retry policies.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// defaultLLMRetryPolicy gives transient API errors a fast first retry and
+// more attempts than tools get.
+func defaultLLMRetryPolicy() *temporal.RetryPolicy {
+	return &temporal.RetryPolicy{
+		InitialInterval:    500 * time.Millisecond, // fast first retry
+		BackoffCoefficient: 1.5,
+		MaximumInterval:    15 * time.Second,
+		MaximumAttempts:    5, // more budget for transient API errors
+	}
+}
+
+// defaultCompactionRetryPolicy retries a failed compaction once.
+func defaultCompactionRetryPolicy() *temporal.RetryPolicy {
+	return &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    30 * time.Second,
+		MaximumAttempts:    2,
+	}
+}
+
+// withRetryOverride returns a copy of base with the fields set in o
+// replacing the built-in values.
+func withRetryOverride(base *temporal.RetryPolicy, o models.RetryPolicy) *temporal.RetryPolicy {
+	p := *base
+	if o.InitialIntervalMs > 0 {
+		p.InitialInterval = time.Duration(o.InitialIntervalMs) * time.Millisecond
+	}
+	if o.BackoffCoefficient > 0 {
+		p.BackoffCoefficient = o.BackoffCoefficient
+	}
+	if o.MaximumIntervalMs > 0 {
+		p.MaximumInterval = time.Duration(o.MaximumIntervalMs) * time.Millisecond
+	}
+	if o.MaximumAttempts > 0 {
+		p.MaximumAttempts = int32(o.MaximumAttempts)
+	}
+	if len(o.NonRetryableErrorTypes) > 0 {
+		p.NonRetryableErrorTypes = o.NonRetryableErrorTypes
+	}
+	return &p
+}
+
+// resolveToolRetryPolicy returns the retry policy of a tool activity: the
+// spec's policy, overridden by retry_policies.tools[toolName] if present,
+// else by retry_policies.tool. The class-wide override leaves tools that
+// must not re-run (MaximumAttempts 1) alone; only a per-tool override with
+// maximum_attempts set makes them retry.
+func resolveToolRetryPolicy(specByName map[string]tools.ToolSpec, toolName string, overrides models.RetryPolicies) *temporal.RetryPolicy {
+	policy := resolveRetryPolicy(specByName, toolName)
+	if o, ok := overrides.Tools[toolName]; ok {
+		return withRetryOverride(policy, o)
+	}
+	if policy.MaximumAttempts == 1 {
+		return policy
+	}
+	return withRetryOverride(policy, overrides.Tool)
+}
@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestRetryPolicies_InvalidFailsAtStart verifies a bad retry policy fails
+// the workflow before any LLM call.
+func (s *AgenticWorkflowTestSuite) TestRetryPolicies_InvalidFailsAtStart() {
+	input := testInput("Hello")
+	input.Config.RetryPolicies.Tools = map[string]models.RetryPolicy{
+		"shell_command": {BackoffCoefficient: 0.5},
+	}
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "retry.tools.shell_command: backoff_coefficient must be at least 1")
+}
+
+// TestRetryPolicies_LLMOverride verifies the LLM activity uses the
+// configured attempt budget instead of the built-in one.
+func (s *AgenticWorkflowTestSuite) TestRetryPolicies_LLMOverride() {
+	attempts := 0
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, input activities.LLMActivityInput) (activities.LLMActivityOutput, error) {
+			attempts++
+			return activities.LLMActivityOutput{}, temporal.NewApplicationError("upstream reset", "Transient")
+		})
+
+	input := testInput("Hello")
+	input.Config.RetryPolicies.LLM = models.RetryPolicy{MaximumAttempts: 2, InitialIntervalMs: 10}
+	s.sendShutdown(5 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	assert.Equal(s.T(), 2, attempts)
+}
@@ -0,0 +1,142 @@
+// review.go implements reviewed mode (SessionConfiguration.Review): when
+// the model ends a turn in which it ran a tool that may have changed the
+// workspace, a reviewer subagent critiques the diff. Unless it approves,
+// the critique is fed back as a user message and the turn continues for a
+// revision, up to Review.MaxRounds reviews per turn. The outcome is recorded
+// on the turn's TurnComplete item.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// reviewTimeout bounds one review by the reviewer subagent.
+const reviewTimeout = 10 * time.Minute
+
+// reviewApproved starts the first line of a review that approves the change.
+const reviewApproved = "APPROVED"
+
+// reviewTaskTemplate is the reviewer's task; %s is the user's request.
+const reviewTaskTemplate = `Review the uncommitted changes in the workspace (git diff, including untracked files) that another agent made for this request:
+
+%s
+
+If the changes are correct and complete, reply with ` + reviewApproved + ` on the first line and nothing else. Otherwise list the problems that must be fixed, most severe first, with file, line and a concrete fix. Do not report style nits.`
+
+// noteWorkspaceChanges records that the turn ran a tool that may modify
+// the workspace, which makes it eligible for review.
+func (s *SessionState) noteWorkspaceChanges(calls []models.ConversationItem) {
+	for _, fc := range calls {
+		if !s.isReadOnlyTool(fc.Name) {
+			s.turnChanged = true
+			return
+		}
+	}
+}
+
+// reviewTurn has a reviewer subagent critique the turn's changes after the
+// model ended the turn. Returns true when the reviewer asked for changes
+// and the critique has been added to history, in which case the turn
+// should continue with a revision.
+func (s *SessionState) reviewTurn(ctx workflow.Context, ctrl *LoopControl) bool {
+	review := s.Config.Review
+	if !review.Enabled() || !s.turnChanged || ctrl.IsInterrupted() || ctrl.IsShutdown() {
+		return false
+	}
+	// Reviewers are children, so only sessions that may spawn one review.
+	if s.AgentCtl.ParentDepth+1 > MaxThreadSpawnDepth {
+		return false
+	}
+	maxRounds := review.MaxRounds()
+	if s.turnReview != nil && s.turnReview.Rounds >= maxRounds {
+		return false
+	}
+	logger := workflow.GetLogger(ctx)
+
+	if s.turnReview == nil {
+		s.turnReview = &models.ReviewResult{}
+	}
+	s.turnReview.Rounds++
+	round := s.turnReview.Rounds
+
+	task := fmt.Sprintf(reviewTaskTemplate, s.turnRequest(ctrl))
+	input := buildAgentSpawnConfig(s.Config, AgentRoleReviewer, task, s.AgentCtl.ParentDepth+1)
+	if review.Model != "" {
+		input.Config.Model = review.ModelConfig(input.Config.Model)
+		input.Config.ModelRouting = models.ModelRouting{}
+	}
+
+	ctrl.SetPhase(PhaseReviewing)
+	info, future := s.launchChild(ctx, nextAgentID(ctx), AgentRoleReviewer, task, input)
+	if err := s.awaitChildStarted(ctx, info, future); err != nil {
+		logger.Warn("Peer review failed to start, ending turn", "error", err)
+		s.turnReview.Status = models.ReviewFailed
+		return false
+	}
+	done, _ := workflow.AwaitWithTimeout(ctx, reviewTimeout, func() bool {
+		return info.Status.isTerminal() || ctrl.IsInterrupted() || ctrl.IsShutdown()
+	})
+	if !info.Status.isTerminal() {
+		s.shutdownChildren(ctx, []*AgentInfo{info})
+		if !done {
+			logger.Warn("Peer review timed out, ending turn", "round", round)
+		}
+		s.turnReview.Status = models.ReviewFailed
+		return false
+	}
+	if info.Status != AgentStatusCompleted || strings.TrimSpace(info.FinalOutput) == "" {
+		logger.Warn("Peer review failed, ending turn", "round", round, "status", info.Status)
+		s.turnReview.Status = models.ReviewFailed
+		return false
+	}
+
+	if isReviewApproval(info.FinalOutput) {
+		logger.Info("Peer review approved", "round", round)
+		s.turnReview.Status = models.ReviewApproved
+		return false
+	}
+
+	logger.Info("Peer review requested changes, continuing turn", "round", round)
+	s.turnReview.Status = models.ReviewRevised
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: formatReviewCritique(info.FinalOutput, round, maxRounds),
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+	return true
+}
+
+// turnRequest returns the user message that started the current turn.
+func (s *SessionState) turnRequest(ctrl *LoopControl) string {
+	items, _ := s.History.GetRawItems()
+	for _, item := range items {
+		if item.Type == models.ItemTypeUserMessage && item.TurnID == ctrl.CurrentTurnID() {
+			return item.Content
+		}
+	}
+	return "(not available)"
+}
+
+// isReviewApproval reports whether a review's first line approves the
+// change.
+func isReviewApproval(output string) bool {
+	first, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	first = strings.Trim(strings.TrimSpace(first), "*_`.:!")
+	return strings.EqualFold(first, reviewApproved)
+}
+
+// formatReviewCritique builds the synthetic user message for a review that
+// asked for changes.
+func formatReviewCritique(critique string, round, maxRounds int) string {
+	return fmt.Sprintf("[Peer review %d/%d] A reviewer found problems with your changes:\n\n%s\n\nAddress the points you agree with, then finish your turn.",
+		round, maxRounds, strings.TrimSpace(critique))
+}
@@ -0,0 +1,147 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestIsReviewApproval(t *testing.T) {
+	assert.True(t, isReviewApproval("APPROVED"))
+	assert.True(t, isReviewApproval("  **Approved.**\n"))
+	assert.False(t, isReviewApproval("APPROVED except for the missing test"))
+	assert.False(t, isReviewApproval("1. foo.go:12 misses a nil check\nAPPROVED otherwise"))
+	assert.False(t, isReviewApproval(""))
+}
+
+func TestIsReadOnlyTool(t *testing.T) {
+	s := &SessionState{McpToolLookup: map[string]tools.McpToolRef{
+		"mcp__docs__search": {ServerName: "docs", ToolName: "search", ReadOnly: true},
+		"mcp__db__insert":   {ServerName: "db", ToolName: "insert"},
+	}}
+	assert.True(t, s.isReadOnlyTool("read_file"))
+	assert.True(t, s.isReadOnlyTool("fetch_url"))
+	assert.True(t, s.isReadOnlyTool("gh_get_issue"))
+	assert.True(t, s.isReadOnlyTool("mcp__docs__search"))
+	assert.False(t, s.isReadOnlyTool("mcp__db__insert"))
+	assert.False(t, s.isReadOnlyTool("shell_command"))
+	assert.False(t, s.isReadOnlyTool("unknown_tool"))
+
+	s.noteWorkspaceChanges([]models.ConversationItem{{Name: "fetch_url"}, {Name: "mcp__docs__search"}})
+	assert.False(t, s.turnChanged)
+	s.noteWorkspaceChanges([]models.ConversationItem{{Name: "mcp__db__insert"}})
+	assert.True(t, s.turnChanged)
+}
+
+// isReviewerCall matches LLM calls of the reviewer subagent.
+var isReviewerCall = historyHasUserText("Review the uncommitted changes")
+
+// mockWriteFileTurn makes the agent write a file and then end the turn.
+func (s *AgenticWorkflowTestSuite) mockWriteFileTurn() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type:      models.ItemTypeFunctionCall,
+				CallID:    "call-write",
+				Name:      "write_file",
+				Arguments: `{"path": "/tmp/out.txt", "content": "hi"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			TokenUsage:   models.TokenUsage{TotalTokens: 30},
+		}, nil).Once()
+	success := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{Content: "written", Success: &success}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Wrote it.", 20), nil).Once()
+}
+
+// reviewedInput returns a reviewed-mode input for message.
+func reviewedInput(message string) WorkflowInput {
+	input := testInput(message)
+	input.Config.DisableWorkspaceSnapshots = true
+	input.Config.Review = models.PeerReview{Mode: models.ReviewModeReviewed}
+	return input
+}
+
+// TestPeerReview_CritiqueFedBackForRevision verifies that a reviewer's
+// critique is fed back as a user message and the turn continues for one
+// revision before completing.
+func (s *AgenticWorkflowTestSuite) TestPeerReview_CritiqueFedBackForRevision() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, isReviewerCall).
+		Return(mockLLMStopResponse("1. out.txt: missing trailing newline", 10), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("[Peer review 1/1]")).
+		Return(mockLLMStopResponse("Added the newline.", 10), nil).Once()
+	s.mockWriteFileTurn()
+
+	s.sendShutdown(time.Minute)
+	s.env.ExecuteWorkflow(AgenticWorkflow, reviewedInput("Write out.txt"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	items := s.queryItems()
+	var critique *models.ConversationItem
+	for i := range items {
+		if items[i].Type == models.ItemTypeUserMessage && strings.HasPrefix(items[i].Content, "[Peer review 1/1]") {
+			critique = &items[i]
+		}
+	}
+	require.NotNil(s.T(), critique, "the critique should be fed back as a user message")
+	assert.Contains(s.T(), critique.Content, "missing trailing newline")
+
+	tc := turnCompleteItem(items)
+	require.NotNil(s.T(), tc)
+	require.NotNil(s.T(), tc.Review)
+	assert.Equal(s.T(), models.ReviewResult{Rounds: 1, Status: models.ReviewRevised}, *tc.Review)
+	// Main turn: write + stop + revision; reviewer: one call.
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteLLMCall", 4)
+}
+
+// TestPeerReview_ApprovalEndsTurn verifies that an approving review ends the
+// turn without a revision, and that the reviewer uses the configured model.
+func (s *AgenticWorkflowTestSuite) TestPeerReview_ApprovalEndsTurn() {
+	var reviewerModel string
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, isReviewerCall).
+		Run(func(args mock.Arguments) {
+			reviewerModel = args.Get(1).(activities.LLMActivityInput).ModelConfig.Model
+		}).
+		Return(mockLLMStopResponse("APPROVED", 10), nil).Once()
+	s.mockWriteFileTurn()
+
+	s.sendShutdown(time.Minute)
+	input := reviewedInput("Write out.txt")
+	input.Config.Review.Rounds = 2
+	input.Config.Review.Model = "claude-sonnet-4-5"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "claude-sonnet-4-5", reviewerModel)
+	tc := turnCompleteItem(s.queryItems())
+	require.NotNil(s.T(), tc)
+	require.NotNil(s.T(), tc.Review)
+	assert.Equal(s.T(), models.ReviewResult{Rounds: 1, Status: models.ReviewApproved}, *tc.Review)
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteLLMCall", 3)
+}
+
+// TestPeerReview_SkipsTurnsWithoutChanges verifies that a turn that only
+// answered is not reviewed.
+func (s *AgenticWorkflowTestSuite) TestPeerReview_SkipsTurnsWithoutChanges() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("It prints hello.", 10), nil).Once()
+
+	s.sendShutdown(5 * time.Second)
+	s.env.ExecuteWorkflow(AgenticWorkflow, reviewedInput("What does main.go do?"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	tc := turnCompleteItem(s.queryItems())
+	require.NotNil(s.T(), tc)
+	assert.Nil(s.T(), tc.Review)
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteLLMCall", 1)
+}
@@ -0,0 +1,176 @@
+// Subagent role registry — built-in role presets plus user-defined roles
+// loaded from ~/.codex/roles/*.toml.
+//
+// Built-in roles are applied by applyRoleOverrides (subagent.go). Custom roles
+// layer an optional built-in base role, a tool allow-list, model overrides
+// and extra developer instructions on top of the parent's configuration.
+//
+// NOTE: Temporal-specific addition (Codex Rust only has built-in roles).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// readOnlyRoleTools is the allow-list shared by read-only roles: the tools
+// registered read-only, plus shell access so agents can run read commands
+// (rg, git diff, ...).
+var readOnlyRoleTools = append([]string{"shell_command", "exec_command", "write_stdin"}, tools.ReadOnlyTools()...)
+
+// builtinRoles lists the agent_type values handled by applyRoleOverrides.
+var builtinRoles = []AgentRole{
+	AgentRoleDefault,
+	AgentRoleExplorer,
+	AgentRoleWorker,
+	AgentRoleOrchestrator,
+	AgentRolePlanner,
+	AgentRoleReviewer,
+	AgentRoleTester,
+}
+
+// isBuiltinRole reports whether name is a built-in agent_type.
+func isBuiltinRole(name string) bool {
+	for _, r := range builtinRoles {
+		if string(r) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// appendDeveloperInstructions appends a role preset to the developer
+// instructions, keeping any inherited content.
+func appendDeveloperInstructions(cfg *models.SessionConfiguration, text string) {
+	if cfg.DeveloperInstructions != "" {
+		cfg.DeveloperInstructions += "\n\n" + text
+	} else {
+		cfg.DeveloperInstructions = text
+	}
+}
+
+// loadAgentRoles loads user-defined roles from the worker filesystem.
+// Called at session start when collab tools are enabled. Non-fatal: invalid
+// role files are logged and skipped.
+func (s *SessionState) loadAgentRoles(ctx workflow.Context) {
+	if s.Config.CodexHome == "" || !s.Config.Tools.HasTool("spawn_agent") {
+		return
+	}
+	logger := workflow.GetLogger(ctx)
+
+	actOpts := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		actOpts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	loadCtx := workflow.WithActivityOptions(ctx, actOpts)
+
+	var result activities.LoadAgentRolesOutput
+	err := workflow.ExecuteActivity(loadCtx, "LoadAgentRoles", activities.LoadAgentRolesInput{
+		CodexHome: s.Config.CodexHome,
+	}).Get(ctx, &result)
+	if err != nil {
+		logger.Warn("Failed to load agent roles", "error", err)
+		return
+	}
+	for _, e := range result.Errors {
+		logger.Warn("Skipping invalid agent role", "error", e)
+	}
+
+	s.CustomRoles = nil
+	for _, def := range result.Roles {
+		if err := validateCustomRole(def); err != nil {
+			logger.Warn("Skipping invalid agent role", "role", def.Name, "error", err)
+			continue
+		}
+		s.CustomRoles = append(s.CustomRoles, def)
+	}
+	logger.Info("Agent roles loaded", "count", len(s.CustomRoles))
+}
+
+// validateCustomRole checks constraints that depend on the built-in registry.
+func validateCustomRole(def models.AgentRoleDef) error {
+	if isBuiltinRole(def.Name) {
+		return fmt.Errorf("role %q shadows a built-in role", def.Name)
+	}
+	if def.BaseRole != "" && !isBuiltinRole(def.BaseRole) {
+		return fmt.Errorf("role %q: unknown base_role %q", def.Name, def.BaseRole)
+	}
+	return nil
+}
+
+// findCustomRole returns the user-defined role with the given name.
+func (s *SessionState) findCustomRole(name string) (models.AgentRoleDef, bool) {
+	for _, def := range s.CustomRoles {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return models.AgentRoleDef{}, false
+}
+
+// availableRoleNames lists every agent_type spawn_agent accepts, built-in first.
+func (s *SessionState) availableRoleNames() []string {
+	names := make([]string, 0, len(builtinRoles)+len(s.CustomRoles))
+	for _, r := range builtinRoles {
+		names = append(names, string(r))
+	}
+	for _, def := range s.CustomRoles {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// buildRoleSpawnInput resolves agentType against the built-in and custom role
+// registries and builds the child WorkflowInput. Unknown roles are an error so
+// the model can correct the call. An empty agentType means the default role.
+func (s *SessionState) buildRoleSpawnInput(agentType, message string, depth int) (WorkflowInput, AgentRole, error) {
+	if agentType == "" || isBuiltinRole(agentType) {
+		role := parseAgentRole(agentType)
+		input := buildAgentSpawnConfig(s.Config, role, message, depth)
+		input.CustomRoles = s.CustomRoles
+		return input, role, nil
+	}
+
+	def, ok := s.findCustomRole(agentType)
+	if !ok {
+		return WorkflowInput{}, "", fmt.Errorf("unknown agent_type %q; available: %s",
+			agentType, strings.Join(s.availableRoleNames(), ", "))
+	}
+
+	childConfig := buildAgentSharedConfig(s.Config, depth)
+	applyRoleOverrides(&childConfig, parseAgentRole(def.BaseRole))
+	def.ApplyToConfig(&childConfig, s.Config.Tools)
+
+	return WorkflowInput{
+		UserMessage: message,
+		Config:      childConfig,
+		Depth:       depth,
+		CustomRoles: s.CustomRoles,
+	}, AgentRole(def.Name), nil
+}
+
+// applyCustomRoleSpecs lists custom roles in the spawn_agent agent_type
+// description so the model knows they exist.
+func (s *SessionState) applyCustomRoleSpecs() {
+	if len(s.CustomRoles) == 0 {
+		return
+	}
+	roles := make([]tools.CrewAgentSummary, len(s.CustomRoles))
+	for i, def := range s.CustomRoles {
+		roles[i] = tools.CrewAgentSummary{Name: def.Name, Description: def.Description}
+	}
+	s.ToolSpecs = tools.UpdateSpawnAgentSpecWithCustomRoles(s.ToolSpecs, roles)
+}
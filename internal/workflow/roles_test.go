@@ -0,0 +1,117 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for the subagent role registry
+// ---------------------------------------------------------------------------
+
+func rolesTestState() *SessionState {
+	return &SessionState{
+		Config: models.SessionConfiguration{
+			Model: models.ModelConfig{Provider: "openai", Model: "gpt-4o"},
+			Tools: models.ToolsConfig{
+				EnabledTools: allTools(),
+			},
+		},
+		CustomRoles: []models.AgentRoleDef{
+			{
+				Name:         "security-auditor",
+				Description:  "Audits code for vulnerabilities",
+				BaseRole:     "reviewer",
+				Instructions: "Focus on injection and auth bugs.",
+				Model:        "o3",
+			},
+			{
+				Name:         "docs-writer",
+				Tools:        []string{"read_file", "write_file"},
+				Instructions: "Only edit markdown files.",
+				Interactive:  true,
+			},
+		},
+	}
+}
+
+func TestValidateCustomRole(t *testing.T) {
+	assert.NoError(t, validateCustomRole(models.AgentRoleDef{Name: "auditor"}))
+	assert.NoError(t, validateCustomRole(models.AgentRoleDef{Name: "auditor", BaseRole: "explorer"}))
+	assert.ErrorContains(t, validateCustomRole(models.AgentRoleDef{Name: "reviewer"}), "shadows a built-in role")
+	assert.ErrorContains(t, validateCustomRole(models.AgentRoleDef{Name: "auditor", BaseRole: "nope"}), "unknown base_role")
+}
+
+func TestBuildRoleSpawnInput_BuiltinRole(t *testing.T) {
+	s := rolesTestState()
+
+	input, role, err := s.buildRoleSpawnInput("reviewer", "review it", 1)
+	require.NoError(t, err)
+	assert.Equal(t, AgentRoleReviewer, role)
+	assert.Equal(t, "review it", input.UserMessage)
+	assert.Equal(t, 1, input.Depth)
+	assert.False(t, input.Config.Tools.HasTool("write_file"))
+	assert.Len(t, input.CustomRoles, 2, "custom roles are inherited by children")
+
+	_, role, err = s.buildRoleSpawnInput("", "task", 1)
+	require.NoError(t, err)
+	assert.Equal(t, AgentRoleDefault, role)
+}
+
+func TestBuildRoleSpawnInput_CustomRoleWithBaseRole(t *testing.T) {
+	s := rolesTestState()
+
+	input, role, err := s.buildRoleSpawnInput("security-auditor", "audit auth", 1)
+	require.NoError(t, err)
+	assert.Equal(t, AgentRole("security-auditor"), role)
+	assert.Equal(t, "o3", input.Config.Model.Model)
+	assert.False(t, input.Config.Tools.HasTool("write_file"), "reviewer base role is read-only")
+	assert.False(t, input.Config.Tools.HasTool("request_user_input"), "non-interactive role is one-shot")
+	assert.Contains(t, input.Config.DeveloperInstructions, "# Role: reviewer")
+	assert.Contains(t, input.Config.DeveloperInstructions, "Focus on injection and auth bugs.")
+}
+
+func TestBuildRoleSpawnInput_CustomRoleAllowList(t *testing.T) {
+	s := rolesTestState()
+
+	input, _, err := s.buildRoleSpawnInput("docs-writer", "update README", 1)
+	require.NoError(t, err)
+	assert.True(t, input.Config.Tools.HasTool("read_file"))
+	assert.True(t, input.Config.Tools.HasTool("write_file"))
+	assert.True(t, input.Config.Tools.HasTool("request_user_input"), "interactive role keeps request_user_input")
+	assert.False(t, input.Config.Tools.HasTool("shell_command"))
+	assert.False(t, input.Config.Tools.HasTool("apply_patch"))
+	assert.Equal(t, "gpt-4o", input.Config.Model.Model, "model inherited when not overridden")
+}
+
+func TestBuildRoleSpawnInput_UnknownRole(t *testing.T) {
+	s := rolesTestState()
+
+	_, _, err := s.buildRoleSpawnInput("architect", "design it", 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown agent_type "architect"`)
+	assert.Contains(t, err.Error(), "tester")
+	assert.Contains(t, err.Error(), "security-auditor")
+}
+
+func TestApplyCustomRoleSpecs(t *testing.T) {
+	s := rolesTestState()
+	s.ToolSpecs = []tools.ToolSpec{tools.NewSpawnAgentToolSpec()}
+
+	s.applyCustomRoleSpecs()
+
+	var desc string
+	for _, p := range s.ToolSpecs[0].Parameters {
+		if p.Name == "agent_type" {
+			desc = p.Description
+		}
+	}
+	assert.Contains(t, desc, "Custom roles")
+	assert.Contains(t, desc, "'security-auditor' — Audits code for vulnerabilities")
+	assert.Contains(t, desc, "'docs-writer'")
+}
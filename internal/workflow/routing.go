@@ -0,0 +1,78 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// routing.go applies the session's model routing policy: before each LLM
+// call of a turn, the rules pick the model from the tools requested so far
+// in the turn, the history size and the user's #hint. The model used is
+// recorded on the iteration's timing.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// beginTurnRouting resets the per-turn routing inputs and reads the hint
+// from the turn's user message.
+func (s *SessionState) beginTurnRouting(ctrl *LoopControl) {
+	s.turnToolsRequested = nil
+	s.turnRoutingHint = ""
+	if !s.Config.ModelRouting.Enabled() {
+		return
+	}
+	items, _ := s.History.GetRawItems()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == models.ItemTypeUserMessage && items[i].TurnID == ctrl.CurrentTurnID() {
+			s.turnRoutingHint = models.ParseRoutingHint(items[i].Content)
+			break
+		}
+	}
+}
+
+// noteRequestedTools records the tools the model requested this turn.
+func (s *SessionState) noteRequestedTools(items []models.ConversationItem) {
+	for _, item := range items {
+		if item.Type != models.ItemTypeFunctionCall {
+			continue
+		}
+		if s.turnToolsRequested == nil {
+			s.turnToolsRequested = make(map[string]bool)
+		}
+		s.turnToolsRequested[item.Name] = true
+	}
+}
+
+// routeModel returns the model config for the next LLM call: the first
+// matching routing rule's model, or the session model. The choice is
+// recorded on the current iteration's timing.
+func (s *SessionState) routeModel(ctx workflow.Context) models.ModelConfig {
+	mc, route := s.Config.Model, ""
+	if s.Config.ModelRouting.Enabled() {
+		tokens, _ := s.History.EstimateTokenCount()
+		rule, ok := s.Config.ModelRouting.Route(models.RoutingContext{
+			ToolsRequested: s.turnToolsRequested,
+			HistoryTokens:  tokens,
+			Hint:           s.turnRoutingHint,
+		})
+		if ok {
+			mc, route = rule.ModelConfig(s.Config.Model), rule.RouteName()
+		}
+		workflow.GetLogger(ctx).Info("Model routed",
+			"model", mc.Model, "route", route, "history_tokens", tokens, "iteration", s.IterationCount)
+	}
+	if it := s.iterationTiming(); it != nil {
+		it.Model, it.Route = mc.Model, route
+	}
+	return mc
+}
+
+// compactionModelConfig returns the model that summarizes history, with its
+// provider.
+func (s *SessionState) compactionModelConfig() models.ModelConfig {
+	if m := s.Config.ModelRouting.CompactionModel; m != "" {
+		return models.RoutingRule{Model: m}.ModelConfig(s.Config.Model)
+	}
+	return s.Config.Model
+}
@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// routedTo matches LLM calls to model.
+func routedTo(model string) interface{} {
+	return mock.MatchedBy(func(in activities.LLMActivityInput) bool { return in.ModelConfig.Model == model })
+}
+
+// TestModelRouting_SwitchesModelWhenEditing verifies a turn starts on the
+// cheap model and moves to the editing model once it requests an editing
+// tool, resending the full history, and that each iteration records the
+// model used.
+func (s *AgenticWorkflowTestSuite) TestModelRouting_SwitchesModelWhenEditing() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, routedTo("gpt-4o-mini")).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "write_file",
+				Arguments: `{"path": "/tmp/a.txt", "content": "x"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+			ResponseID:   "resp-1",
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.MatchedBy(func(in activities.LLMActivityInput) bool {
+		return in.ModelConfig.Model == "claude-sonnet-4-5" && in.ModelConfig.Provider == "anthropic" &&
+			in.PreviousResponseID == "" && len(in.History) > 2
+	})).Return(mockLLMStopResponse("Wrote it.", 10), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: "ok", Success: &trueVal}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnTimings)
+		require.NoError(s.T(), err)
+		var timings []TurnTiming
+		require.NoError(s.T(), result.Get(&timings))
+		require.Len(s.T(), timings, 1)
+		require.Len(s.T(), timings[0].Iterations, 2)
+		assert.Equal(s.T(), "gpt-4o-mini", timings[0].Iterations[0].Model)
+		assert.Equal(s.T(), "quick", timings[0].Iterations[0].Route)
+		assert.Equal(s.T(), "claude-sonnet-4-5", timings[0].Iterations[1].Model)
+		assert.Equal(s.T(), "edit", timings[0].Iterations[1].Route)
+	}, 3*time.Second)
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Write a.txt")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "write_file")
+	input.Config.ModelRouting = models.ModelRouting{Rules: []models.RoutingRule{
+		{Name: "edit", Tools: []string{"write_file", "apply_patch"}, Model: "claude-sonnet-4-5"},
+		{Name: "quick", Model: "gpt-4o-mini"},
+	}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+}
+
+// TestModelRouting_HintAndCompactionModel verifies a #hint in the user
+// message selects its rule and compaction uses the compaction model.
+func (s *AgenticWorkflowTestSuite) TestModelRouting_HintAndCompactionModel() {
+	s.newEnv()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, routedTo("o3")).
+		Return(mockLLMStopResponse("Thought hard.", 10), nil).Once()
+	s.env.OnActivity("ExecuteCompact", mock.Anything, mock.MatchedBy(func(in activities.CompactActivityInput) bool {
+		return in.Model == "gpt-4o-mini"
+	})).Return(activities.CompactActivityOutput{
+		Items: []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "summary"}},
+	}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateCompact, "compact-1", noopCallback(), CompactRequest{})
+	}, 2*time.Second)
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("#deep why is the build slow?")
+	input.Config.ModelRouting = models.ModelRouting{
+		CompactionModel: "gpt-4o-mini",
+		Rules:           []models.RoutingRule{{Hint: "deep", Model: "o3"}},
+	}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestModelRouting_InvalidPolicyFailsAtStart verifies a rule without a
+// model fails the workflow up front.
+func (s *AgenticWorkflowTestSuite) TestModelRouting_InvalidPolicyFailsAtStart() {
+	input := testInput("hi")
+	input.Config.ModelRouting = models.ModelRouting{Rules: []models.RoutingRule{{Name: "cheap"}}}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid model routing")
+}
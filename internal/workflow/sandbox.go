@@ -0,0 +1,73 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// sandbox.go builds the sandbox policy sent with process-spawning tool calls
+// from the session's Permissions.Sandbox* settings. In audit mode the calls
+// run unsandboxed and sandbox_audit.go checks them against the policy.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// sandboxPolicyRef converts the session's sandbox settings to the policy
+// sent with process-spawning tool calls, or nil when the session runs
+// unsandboxed or only audits its sandbox. Workspace-write mode can write cwd
+// and the configured roots.
+//
+// Only sessions with a network allowlist get a policy, since the allowlist
+// is enforced by running the command in the sandbox behind its proxy.
+// Without one, tool calls run as they always have, and a sandbox denial
+// is still what on-failure escalation looks for.
+func sandboxPolicyRef(p models.Permissions, cwd string) *tools.SandboxPolicyRef {
+	mode := sandbox.SandboxMode(p.SandboxMode)
+	if mode == "" || mode == sandbox.ModeFullAccess || p.SandboxAudit || len(p.SandboxNetworkAllow) == 0 {
+		return nil
+	}
+	ref := &tools.SandboxPolicyRef{
+		Mode:          string(mode),
+		NetworkAccess: p.SandboxNetworkAccess,
+		NetworkAllow:  p.SandboxNetworkAllow,
+	}
+	if mode == sandbox.ModeWorkspaceWrite {
+		if cwd != "" {
+			ref.WritableRoots = append(ref.WritableRoots, cwd)
+		}
+		ref.WritableRoots = append(ref.WritableRoots, p.SandboxWritableRoots...)
+	}
+	return ref
+}
+
+// sandboxPolicy returns the sandbox policy for the session's tool commands.
+func (s *SessionState) sandboxPolicy() *tools.SandboxPolicyRef {
+	return sandboxPolicyRef(s.Config.Permissions, s.Config.Cwd)
+}
+
+// restrictedSandboxPolicy builds a read-only or workspace-write policy from
+// the session's sandbox settings.
+func (s *SessionState) restrictedSandboxPolicy(mode sandbox.SandboxMode) *sandbox.SandboxPolicy {
+	perms := s.Config.Permissions
+	policy := &sandbox.SandboxPolicy{Mode: mode, NetworkAccess: perms.SandboxNetworkAccess, NetworkAllow: perms.SandboxNetworkAllow}
+	if mode == sandbox.ModeWorkspaceWrite {
+		if s.Config.Cwd != "" {
+			policy.WritableRoots = append(policy.WritableRoots, sandbox.WritableRoot(s.Config.Cwd))
+		}
+		for _, r := range perms.SandboxWritableRoots {
+			policy.WritableRoots = append(policy.WritableRoots, sandbox.WritableRoot(r))
+		}
+	}
+	return policy
+}
+
+// auditedSandboxPolicy returns the policy audit mode checks tool calls
+// against: the configured sandbox, or workspace-write when none is set.
+func (s *SessionState) auditedSandboxPolicy() *sandbox.SandboxPolicy {
+	mode := sandbox.SandboxMode(s.Config.Permissions.SandboxMode)
+	if mode != sandbox.ModeReadOnly {
+		mode = sandbox.ModeWorkspaceWrite
+	}
+	return s.restrictedSandboxPolicy(mode)
+}
@@ -0,0 +1,194 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// sandbox_audit.go implements sandbox audit mode (Permissions.SandboxAudit):
+// tools run unrestricted, and before each batch runs the workflow checks the
+// writes and connections the calls would make against the sandbox policy.
+// Would-be denials are recorded as annotations on the calls and in the
+// session's violation report (get_sandbox_audit query, WorkflowResult), so a
+// sandbox can be tried out before it is enforced.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+)
+
+// maxSandboxViolations caps the violations kept in the report; Total still
+// counts every one.
+const maxSandboxViolations = 200
+
+// SandboxViolation is an access a tool call made that the audited sandbox
+// would have blocked.
+type SandboxViolation struct {
+	TurnID string             `json:"turn_id,omitempty"`
+	CallID string             `json:"call_id"`
+	Tool   string             `json:"tool"`
+	Kind   sandbox.AccessKind `json:"kind"`   // "write" or "network"
+	Target string             `json:"target"` // Path or host[:port]
+	Reason string             `json:"reason"`
+}
+
+// SandboxAuditReport is the session's would-be violations, returned by the
+// get_sandbox_audit query and with the workflow result.
+type SandboxAuditReport struct {
+	Mode       string             `json:"mode"`                 // The audited sandbox mode
+	Violations []SandboxViolation `json:"violations,omitempty"` // Oldest first, at most maxSandboxViolations
+	Total      int                `json:"total"`                // All violations, including ones not listed
+}
+
+// sandboxAuditReport returns the session's report, or nil when the session
+// does not run in audit mode.
+func (s *SessionState) sandboxAuditReport() *SandboxAuditReport {
+	if !s.Config.Permissions.SandboxAudit {
+		return nil
+	}
+	return &SandboxAuditReport{
+		Mode:       string(s.auditedSandboxPolicy().Mode),
+		Violations: s.SandboxViolations,
+		Total:      s.SandboxViolationCount,
+	}
+}
+
+// auditToolCalls records what the audited sandbox would block in calls, as
+// an annotation on each offending call and in the violation report.
+func (s *SessionState) auditToolCalls(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem) {
+	if !s.Config.Permissions.SandboxAudit {
+		return
+	}
+	policy := s.auditedSandboxPolicy()
+	var items []models.ConversationItem
+	for _, fc := range calls {
+		var found []SandboxViolation
+		for _, a := range toolCallAccesses(fc, s.Config.Cwd) {
+			if reason := policy.Check(a); reason != "" {
+				found = append(found, SandboxViolation{
+					TurnID: ctrl.CurrentTurnID(),
+					CallID: fc.CallID,
+					Tool:   fc.Name,
+					Kind:   a.Kind,
+					Target: a.Target(),
+					Reason: reason,
+				})
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		workflow.GetLogger(ctx).Info("Sandbox audit: call would be blocked",
+			"tool", fc.Name, "call_id", fc.CallID, "violations", len(found))
+		s.SandboxViolationCount += len(found)
+		for _, v := range found {
+			if len(s.SandboxViolations) < maxSandboxViolations {
+				s.SandboxViolations = append(s.SandboxViolations, v)
+			}
+		}
+		if item, ok := s.sandboxAuditAnnotation(fc.CallID, policy.Mode, found); ok {
+			items = append(items, item)
+		}
+	}
+	for _, item := range items {
+		_ = s.History.AddItem(item)
+	}
+	if len(items) > 0 {
+		ctrl.NotifyItemAdded()
+	}
+}
+
+// sandboxAuditAnnotation builds the annotation on the function call
+// callID listing its would-be violations. It is not feedback: the model is
+// not told, so the audit does not change what it does.
+func (s *SessionState) sandboxAuditAnnotation(callID string, mode sandbox.SandboxMode, found []SandboxViolation) (models.ConversationItem, bool) {
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return models.ConversationItem{}, false
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		target := items[i]
+		if target.Type != models.ItemTypeFunctionCall || target.CallID != callID {
+			continue
+		}
+		parts := make([]string, len(found))
+		for j, v := range found {
+			parts[j] = fmt.Sprintf("%s %s (%s)", v.Kind, v.Target, v.Reason)
+		}
+		return models.ConversationItem{
+			Type:    models.ItemTypeAnnotation,
+			Content: fmt.Sprintf("sandbox audit: %s would block %s", mode, strings.Join(parts, "; ")),
+			TurnID:  target.TurnID,
+			Annotation: &models.Annotation{
+				TargetSeq:     target.Seq,
+				TargetType:    target.Type,
+				TargetTurnID:  target.TurnID,
+				TargetCallID:  target.CallID,
+				TargetPreview: pinPreview(target),
+			},
+		}, true
+	}
+	return models.ConversationItem{}, false
+}
+
+// toolCallAccesses lists the writes and connections fc would make: commands
+// are read by sandbox.CommandAccesses, file tools write the files they name.
+func toolCallAccesses(fc models.ConversationItem, cwd string) []sandbox.Access {
+	var args struct {
+		Command json.RawMessage `json:"command"`
+		Cmd     string          `json:"cmd"`
+		Workdir string          `json:"workdir"`
+		Path    string          `json:"path"`
+		Input   string          `json:"input"`
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return nil
+	}
+	dir := cwd
+	if args.Workdir != "" {
+		if path.IsAbs(args.Workdir) || cwd == "" {
+			dir = args.Workdir
+		} else {
+			dir = path.Join(cwd, args.Workdir)
+		}
+	}
+
+	switch fc.Name {
+	case "shell":
+		var argv []string
+		if json.Unmarshal(args.Command, &argv) != nil {
+			return nil
+		}
+		return sandbox.CommandAccesses(dir, argv)
+	case "shell_command":
+		var script string
+		if json.Unmarshal(args.Command, &script) != nil {
+			return nil
+		}
+		return sandbox.CommandAccesses(dir, []string{"bash", "-lc", script})
+	case "exec_command":
+		return sandbox.CommandAccesses(dir, []string{"bash", "-lc", args.Cmd})
+	case "write_file":
+		if args.Path == "" {
+			return nil
+		}
+		return []sandbox.Access{sandbox.WriteAccess(cwd, args.Path)}
+	case "apply_patch":
+		var out []sandbox.Access
+		for _, line := range strings.Split(args.Input, "\n") {
+			for _, header := range patchFileHeaders {
+				if p, ok := strings.CutPrefix(line, header); ok {
+					out = append(out, sandbox.WriteAccess(cwd, strings.TrimSpace(p)))
+					break
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/sandbox"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestSandboxPolicyRef(t *testing.T) {
+	assert.Nil(t, sandboxPolicyRef(models.Permissions{}, "/repo"), "unsandboxed by default")
+	assert.Nil(t, sandboxPolicyRef(models.Permissions{SandboxMode: "full-access"}, "/repo"))
+
+	ref := sandboxPolicyRef(models.Permissions{
+		SandboxMode:          "workspace-write",
+		SandboxWritableRoots: []string{"/cache"},
+		SandboxNetworkAllow:  []string{"registry.npmjs.org"},
+	}, "/repo")
+	assert.Equal(t, &tools.SandboxPolicyRef{
+		Mode:          "workspace-write",
+		WritableRoots: []string{"/repo", "/cache"},
+		NetworkAllow:  []string{"registry.npmjs.org"},
+	}, ref)
+
+	ref = sandboxPolicyRef(models.Permissions{
+		SandboxMode:          "read-only",
+		SandboxWritableRoots: []string{"/cache"},
+		SandboxNetworkAllow:  []string{"github.com"},
+	}, "/repo")
+	assert.Empty(t, ref.WritableRoots, "read-only mode writes nothing")
+
+	assert.Nil(t, sandboxPolicyRef(models.Permissions{SandboxMode: "workspace-write"}, "/repo"),
+		"no allowlist, no wrapping")
+	assert.Nil(t, sandboxPolicyRef(models.Permissions{
+		SandboxMode:         "read-only",
+		SandboxAudit:        true,
+		SandboxNetworkAllow: []string{"github.com"},
+	}, "/repo"), "audit mode runs unsandboxed")
+}
+
+func TestToolCallAccesses(t *testing.T) {
+	call := func(name, args string) models.ConversationItem {
+		return models.ConversationItem{Type: models.ItemTypeFunctionCall, Name: name, Arguments: args}
+	}
+	write := func(p string) sandbox.Access { return sandbox.Access{Kind: sandbox.AccessWrite, Path: p} }
+
+	assert.Equal(t, []sandbox.Access{write("/repo/sub/out.txt")},
+		toolCallAccesses(call("shell", `{"command": ["touch", "out.txt"], "workdir": "sub"}`), "/repo"))
+	assert.Equal(t, []sandbox.Access{write("/etc/motd")},
+		toolCallAccesses(call("shell_command", `{"command": "echo hi > /etc/motd"}`), "/repo"))
+	assert.Equal(t, []sandbox.Access{write("/srv/x")},
+		toolCallAccesses(call("exec_command", `{"cmd": "rm -rf x", "workdir": "/srv"}`), "/repo"))
+	assert.Equal(t, []sandbox.Access{write("/repo/notes.md")},
+		toolCallAccesses(call("write_file", `{"path": "notes.md", "content": "x"}`), "/repo"))
+	assert.Equal(t, []sandbox.Access{write("/repo/a.go"), write("/etc/b.go")},
+		toolCallAccesses(call("apply_patch", `{"input": "*** Begin Patch\n*** Update File: a.go\n@@\n-x\n+y\n*** Add File: /etc/b.go\n+z\n*** End Patch"}`), "/repo"))
+	assert.Nil(t, toolCallAccesses(call("read_file", `{"path": "/etc/passwd"}`), "/repo"))
+	assert.Nil(t, toolCallAccesses(call("shell", `not json`), "/repo"))
+}
+
+// TestSandboxNetworkAllow_SentToTools verifies that process-spawning tools
+// get the session sandbox with its network allowlist and other tools do not.
+func (s *AgenticWorkflowTestSuite) TestSandboxNetworkAllow_SentToTools() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(activities.LLMActivityOutput{
+		Items: []models.ConversationItem{
+			{Type: models.ItemTypeFunctionCall, CallID: "call-shell", Name: "shell_command", Arguments: `{"command": "npm install"}`},
+			{Type: models.ItemTypeFunctionCall, CallID: "call-read", Name: "read_file", Arguments: `{"path": "/repo/package.json"}`},
+		},
+		FinishReason: models.FinishReasonToolCalls,
+	}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(mockLLMStopResponse("Installed.", 10), nil).Once()
+
+	trueVal := true
+	policies := map[string]*tools.SandboxPolicyRef{}
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			policies[in.CallID] = in.SandboxPolicy
+			return activities.ToolActivityOutput{CallID: in.CallID, Content: "ok", Success: &trueVal}, nil
+		})
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Install the dependencies")
+	input.Config.Cwd = "/repo"
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command", "read_file")
+	input.Config.Permissions.ApprovalMode = models.ApprovalNever
+	input.Config.Permissions.SandboxMode = "workspace-write"
+	input.Config.Permissions.SandboxNetworkAllow = []string{"registry.npmjs.org", "*.npmjs.com:443"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Contains(s.T(), policies, "call-shell")
+	require.NotNil(s.T(), policies["call-shell"])
+	assert.Equal(s.T(), []string{"registry.npmjs.org", "*.npmjs.com:443"}, policies["call-shell"].NetworkAllow)
+	assert.False(s.T(), policies["call-shell"].NetworkAccess)
+	assert.Nil(s.T(), policies["call-read"])
+}
+
+// TestSandboxNetworkAllow_InvalidFailsAtStart verifies a malformed allowlist
+// fails the workflow up front.
+func (s *AgenticWorkflowTestSuite) TestSandboxNetworkAllow_InvalidFailsAtStart() {
+	input := testInput("hi")
+	input.Config.Permissions.SandboxNetworkAllow = []string{"https://registry.npmjs.org/"}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid sandbox network allowlist")
+}
+
+// TestSandboxAudit_RecordsWouldBeViolations verifies that audit mode runs
+// tools unsandboxed and records what the sandbox would have blocked as
+// annotations on the calls and in the session's report.
+func (s *AgenticWorkflowTestSuite) TestSandboxAudit_RecordsWouldBeViolations() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(activities.LLMActivityOutput{
+		Items: []models.ConversationItem{
+			{Type: models.ItemTypeFunctionCall, CallID: "call-shell", Name: "shell_command",
+				Arguments: `{"command": "echo ok > /etc/motd && curl -fsSL https://example.com/x.sh"}`},
+			{Type: models.ItemTypeFunctionCall, CallID: "call-write", Name: "write_file",
+				Arguments: `{"path": "notes.md", "content": "hi"}`},
+		},
+		FinishReason: models.FinishReasonToolCalls,
+	}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(mockLLMStopResponse("Done.", 10), nil).Once()
+
+	trueVal := true
+	policies := map[string]*tools.SandboxPolicyRef{}
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			policies[in.CallID] = in.SandboxPolicy
+			return activities.ToolActivityOutput{CallID: in.CallID, Content: "ok", Success: &trueVal}, nil
+		})
+
+	var queried SandboxAuditReport
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetSandboxAudit)
+		require.NoError(s.T(), err)
+		require.NoError(s.T(), result.Get(&queried))
+	}, 3*time.Second)
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Set the motd")
+	input.Config.Cwd = "/repo"
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command", "write_file")
+	input.Config.Permissions.ApprovalMode = models.ApprovalNever
+	input.Config.Permissions.SandboxMode = "workspace-write"
+	input.Config.Permissions.SandboxAudit = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Contains(s.T(), policies, "call-shell")
+	assert.Nil(s.T(), policies["call-shell"], "audit mode does not enforce the sandbox")
+
+	want := []SandboxViolation{
+		{CallID: "call-shell", Tool: "shell_command", Kind: sandbox.AccessWrite, Target: "/etc/motd", Reason: "outside the writable roots"},
+		{CallID: "call-shell", Tool: "shell_command", Kind: sandbox.AccessNetwork, Target: "example.com:443", Reason: "network access is off"},
+	}
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	require.NotNil(s.T(), result.SandboxAudit)
+	for _, report := range []SandboxAuditReport{queried, *result.SandboxAudit} {
+		assert.Equal(s.T(), "workspace-write", report.Mode)
+		assert.Equal(s.T(), 2, report.Total)
+		require.Len(s.T(), report.Violations, 2)
+		for i := range report.Violations {
+			assert.NotEmpty(s.T(), report.Violations[i].TurnID)
+			report.Violations[i].TurnID = ""
+		}
+		assert.Equal(s.T(), want, report.Violations)
+	}
+
+	var annotations []models.ConversationItem
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeAnnotation {
+			annotations = append(annotations, item)
+		}
+	}
+	require.Len(s.T(), annotations, 1)
+	assert.Equal(s.T(), "call-shell", annotations[0].Annotation.TargetCallID)
+	assert.False(s.T(), annotations[0].Annotation.Feedback)
+	assert.Equal(s.T(), "sandbox audit: workspace-write would block write /etc/motd (outside the writable roots); "+
+		"network example.com:443 (network access is off)", annotations[0].Content)
+}
@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// Each testdata/session_vN.json is a ContinueAsNew payload as written by the
+// release with schema version N. Add one whenever models.SchemaVersion is
+// bumped, and never edit the old ones: they are what running workflows carry.
+var sessionFixtures = []struct {
+	file    string
+	version int
+}{
+	{"session_v0.json", 0},
+	{"session_v1.json", 1},
+}
+
+func loadSessionFixture(t *testing.T, file string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", file))
+	require.NoError(t, err)
+	return data
+}
+
+func TestSessionFixtures_CoverEveryVersion(t *testing.T) {
+	for v := 0; v <= models.SchemaVersion; v++ {
+		found := false
+		for _, f := range sessionFixtures {
+			found = found || f.version == v
+		}
+		assert.True(t, found, "no session fixture for schema version %d", v)
+	}
+}
+
+func TestSessionState_DecodesOlderPayloads(t *testing.T) {
+	for _, f := range sessionFixtures {
+		t.Run(f.file, func(t *testing.T) {
+			var state SessionState
+			require.NoError(t, json.Unmarshal(loadSessionFixture(t, f.file), &state))
+
+			assert.Equal(t, models.SchemaVersion, state.SchemaVersion)
+			if f.version == models.SchemaVersion {
+				assert.Nil(t, state.migratedFrom)
+			} else {
+				require.NotNil(t, state.migratedFrom)
+				assert.Equal(t, f.version, *state.migratedFrom)
+			}
+
+			assert.Equal(t, "conv-v0", state.ConversationID)
+			require.Len(t, state.HistoryItems, 6)
+			assert.Equal(t, models.ItemTypeFunctionCall, state.HistoryItems[2].Type)
+			assert.Equal(t, `{"dir_path":"/src/app"}`, state.HistoryItems[2].Arguments)
+			require.NotNil(t, state.HistoryItems[3].Output)
+			assert.Equal(t, "main.go\ngo.mod", state.HistoryItems[3].Output.Content)
+			assert.Equal(t, "/src/app", state.Config.Cwd)
+			assert.Equal(t, 1, state.TurnCounter)
+			assert.Equal(t, 120, state.TotalTokens)
+			assert.Equal(t, []string{"demo"}, state.Tags)
+			require.Len(t, state.ExecSessions, 1)
+			assert.Equal(t, "npm run dev", state.ExecSessions[0].Command)
+		})
+	}
+}
+
+func TestSessionState_RejectsNewerPayload(t *testing.T) {
+	var state SessionState
+	err := json.Unmarshal([]byte(`{"schema_version":99,"conversation_id":"c1"}`), &state)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than supported")
+}
+
+func TestSessionState_RoundTripStampsVersion(t *testing.T) {
+	var state SessionState
+	require.NoError(t, json.Unmarshal(loadSessionFixture(t, "session_v0.json"), &state))
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	var again SessionState
+	require.NoError(t, json.Unmarshal(data, &again))
+	assert.Nil(t, again.migratedFrom, "re-encoded state is already current")
+	assert.Equal(t, state.HistoryItems, again.HistoryItems)
+}
+
+// TestContinued_ResumesFromOldPayload runs AgenticWorkflowContinued on the
+// oldest fixture, as a worker of this release would after an upgrade.
+func (s *AgenticWorkflowTestSuite) TestContinued_ResumesFromOldPayload() {
+	// The test environment only accepts typed arguments; decoding the
+	// fixture runs the same migration as the workflow's data converter.
+	var state SessionState
+	require.NoError(s.T(), json.Unmarshal(loadSessionFixture(s.T(), "session_v0.json"), &state))
+
+	var call activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { call = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("Still two files.", 30), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Count again"})
+	}, time.Second)
+	s.sendShutdown(time.Minute)
+
+	s.env.RegisterWorkflow(AgenticWorkflowContinued)
+	s.env.ExecuteWorkflow(AgenticWorkflowContinued, state)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), "conv-v0", result.ConversationID)
+	assert.Equal(s.T(), 150, result.TotalTokens)
+
+	var contents []string
+	for _, item := range call.History {
+		if item.Content != "" {
+			contents = append(contents, item.Content)
+		}
+	}
+	assert.Subset(s.T(), contents, []string{"List the files", "There are two files.", "Count again"})
+}
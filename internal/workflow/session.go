@@ -47,6 +47,12 @@ func SessionWorkflow(ctx workflow.Context, input SessionWorkflowInput) error {
 		logger.Warn("Failed to resolve config, using defaults", "error", err)
 		cfg = models.DefaultSessionConfiguration()
 	}
+	if err := cfg.RetryPolicies.Validate(); err != nil {
+		return fmt.Errorf("invalid retry policies: %w", err)
+	}
+	if err := cfg.Autonomy.Validate(); err != nil {
+		return fmt.Errorf("invalid autonomy config: %w", err)
+	}
 
 	// 1b. Resolve crew main agent overrides (if this is a crew session).
 	var crewMainAgentName string
@@ -135,6 +141,10 @@ func SessionWorkflow(ctx workflow.Context, input SessionWorkflowInput) error {
 	tempState.loadSkills(ctx)
 	loadedSkills := tempState.LoadedSkills
 
+	// 7. Load user-defined subagent roles.
+	tempState.loadAgentRoles(ctx)
+	customRoles := tempState.CustomRoles
+
 	// --- Start AgenticWorkflow as child ---
 
 	childInput := WorkflowInput{
@@ -145,6 +155,7 @@ func SessionWorkflow(ctx workflow.Context, input SessionWorkflowInput) error {
 		McpToolLookup:   mcpToolLookup,
 		McpToolSpecs:    mcpToolSpecs,
 		LoadedSkills:    loadedSkills,
+		CustomRoles:     customRoles,
 		CrewName:        input.CrewName,
 		CrewAgent:       crewMainAgentName,
 		CrewInputs:      input.CrewInputs,
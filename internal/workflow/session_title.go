@@ -0,0 +1,148 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// session_title.go titles a session after its first turn, so the session
+// picker can tell sessions apart. A cheap model writes the title from the
+// first exchange (GenerateSessionTitle activity); the first user message
+// stands in when that is disabled or fails. The title lives in SessionState
+// and is mirrored to the workflow memo and, optionally, the AgentTitle search
+// attribute.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"strings"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/instructions"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// MemoKeyTitle is the workflow memo key the session title is published under.
+const MemoKeyTitle = "title"
+
+// TitleSearchAttribute is the Text search attribute the session title is
+// indexed under when SessionConfiguration.IndexSessionTitle is set. Register
+// it with:
+//
+//	temporal operator search-attribute create --name AgentTitle --type Text
+var TitleSearchAttribute = temporal.NewSearchAttributeKeyString("AgentTitle")
+
+// maybeTitleSession titles the session after its first completed turn.
+// Sessions that already have a title, and subagents, are left alone.
+// Best-effort: a failure to publish is logged.
+func (s *SessionState) maybeTitleSession(ctx workflow.Context) {
+	if s.Title != "" || (s.AgentCtl != nil && s.AgentCtl.ParentDepth > 0) {
+		return
+	}
+	userMsg, assistantMsg := s.firstExchange()
+	if userMsg == "" {
+		return
+	}
+
+	title := ""
+	if !s.Config.DisableTitleGeneration {
+		title = s.generateSessionTitle(ctx, userMsg, assistantMsg)
+	}
+	if title == "" {
+		title = instructions.DeriveSessionTitle(userMsg)
+	}
+	s.Title = title
+	if err := s.publishSessionTitle(ctx); err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to publish session title", "error", err)
+	}
+}
+
+// generateSessionTitle asks a cheap model for a title; empty on failure.
+func (s *SessionState) generateSessionTitle(ctx workflow.Context, userMsg, assistantMsg string) string {
+	titleModel, titleProvider := instructions.SuggestionModelForProvider(s.Config.Model.Provider)
+	titleCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1, // No retries — the first message is a fine fallback
+		},
+	})
+	var out activities.SessionTitleOutput
+	err := workflow.ExecuteActivity(titleCtx, "GenerateSessionTitle", activities.SessionTitleInput{
+		UserMessage:      userMsg,
+		AssistantMessage: assistantMsg,
+		ModelConfig: models.ModelConfig{
+			Provider:      titleProvider,
+			Model:         titleModel,
+			Temperature:   0.3,
+			MaxTokens:     30,
+			ContextWindow: 4096,
+		},
+	}).Get(ctx, &out)
+	if err != nil {
+		return ""
+	}
+	return out.Title
+}
+
+// firstExchange returns the first user message of the session that is not
+// injected context, and the last assistant message of the same turn.
+func (s *SessionState) firstExchange() (userMsg, assistantMsg string) {
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return "", ""
+	}
+	for _, item := range items {
+		switch item.Type {
+		case models.ItemTypeTurnStarted:
+			if userMsg != "" {
+				return userMsg, assistantMsg
+			}
+		case models.ItemTypeUserMessage:
+			if userMsg == "" && !isInjectedUserMessage(item.Content) {
+				userMsg = item.Content
+			}
+		case models.ItemTypeAssistantMessage:
+			if userMsg != "" && item.Content != "" {
+				assistantMsg = item.Content
+			}
+		}
+	}
+	return userMsg, assistantMsg
+}
+
+// isInjectedUserMessage reports whether a user message is context the
+// workflow added (environment context, skill instructions) rather than
+// something the user typed.
+func isInjectedUserMessage(content string) bool {
+	return strings.HasPrefix(content, "<environment_context>") || strings.HasPrefix(content, "<skill_instructions ")
+}
+
+// publishSessionTitle mirrors the title to the workflow memo and, when
+// enabled, the AgentTitle search attribute.
+func (s *SessionState) publishSessionTitle(ctx workflow.Context) error {
+	if err := workflow.UpsertMemo(ctx, map[string]interface{}{MemoKeyTitle: s.Title}); err != nil {
+		return err
+	}
+	if !s.Config.IndexSessionTitle || !s.searchAttributeRegistered(ctx, TitleSearchAttribute.GetName()) {
+		return nil
+	}
+	return workflow.UpsertTypedSearchAttributes(ctx, TitleSearchAttribute.ValueSet(s.Title))
+}
+
+// SessionTitleFromMemo decodes the session title from a workflow memo as
+// returned by visibility APIs; empty when the session has none yet. A nil dc
+// uses the SDK default data converter.
+func SessionTitleFromMemo(memo *commonpb.Memo, dc converter.DataConverter) string {
+	if dc == nil {
+		dc = converter.GetDefaultDataConverter()
+	}
+	p, ok := memo.GetFields()[MemoKeyTitle]
+	if !ok {
+		return ""
+	}
+	var title string
+	_ = dc.FromPayload(p, &title)
+	return title
+}
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/history"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ignoreTitleMemo swallows the memo upsert that titles the session after its
+// first turn, for tests that assert on other memo upserts. Register it before
+// their own OnUpsertMemo mock: the first matching mock wins.
+func (s *AgenticWorkflowTestSuite) ignoreTitleMemo() {
+	s.env.OnUpsertMemo(mock.MatchedBy(func(memo map[string]interface{}) bool {
+		_, ok := memo[MemoKeyTitle]
+		return ok
+	})).Return(nil).Maybe()
+}
+
+// ---------------------------------------------------------------------------
+// Unit tests for picking the exchange a title is written from
+// ---------------------------------------------------------------------------
+
+func TestFirstExchange_SkipsInjectedContext(t *testing.T) {
+	h := history.NewInMemoryHistory()
+	for _, item := range []models.ConversationItem{
+		{Type: models.ItemTypeTurnStarted, TurnID: "t1"},
+		{Type: models.ItemTypeUserMessage, Content: "<environment_context>\n<cwd>/src</cwd>\n</environment_context>"},
+		{Type: models.ItemTypeUserMessage, Content: "fix the login bug"},
+		{Type: models.ItemTypeAssistantMessage, Content: "Looking at auth.go"},
+		{Type: models.ItemTypeAssistantMessage, Content: "Fixed the nil check"},
+		{Type: models.ItemTypeTurnStarted, TurnID: "t2"},
+		{Type: models.ItemTypeUserMessage, Content: "thanks"},
+		{Type: models.ItemTypeAssistantMessage, Content: "You're welcome"},
+	} {
+		require.NoError(t, h.AddItem(item))
+	}
+	s := &SessionState{History: h}
+
+	userMsg, assistantMsg := s.firstExchange()
+	assert.Equal(t, "fix the login bug", userMsg)
+	assert.Equal(t, "Fixed the nil check", assistantMsg)
+}
+
+func TestIsInjectedUserMessage(t *testing.T) {
+	assert.True(t, isInjectedUserMessage("<environment_context>\n</environment_context>"))
+	assert.True(t, isInjectedUserMessage(`<skill_instructions name="deploy">`))
+	assert.False(t, isInjectedUserMessage("what does <environment_context> do?"))
+}
+
+// ---------------------------------------------------------------------------
+// Workflow tests for session titles
+// ---------------------------------------------------------------------------
+
+// TestSessionTitle_GeneratedAfterFirstTurn verifies that the first turn is
+// titled by GenerateSessionTitle, once, and the title reaches the memo and
+// the AgentTitle search attribute.
+func (s *AgenticWorkflowTestSuite) TestSessionTitle_GeneratedAfterFirstTurn() {
+	s.env.OnActivity("CheckSearchAttributes", mock.Anything, activities.CheckSearchAttributesInput{Names: []string{"AgentTitle"}}).
+		Return(activities.CheckSearchAttributesOutput{}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Fixed the nil check in auth.go", 30), nil).Twice()
+
+	var titleIn activities.SessionTitleInput
+	s.env.OnActivity("GenerateSessionTitle", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { titleIn = args.Get(1).(activities.SessionTitleInput) }).
+		Return(activities.SessionTitleOutput{Title: "Fix nil check in auth middleware"}, nil).Once()
+
+	var memo map[string]interface{}
+	s.env.OnUpsertMemo(mock.Anything).Run(func(args mock.Arguments) {
+		memo = args.Get(0).(map[string]interface{})
+	}).Return(nil).Once()
+	var indexed string
+	s.env.OnUpsertTypedSearchAttributes(mock.Anything).Run(func(args mock.Arguments) {
+		sa := args.Get(0).(temporal.SearchAttributes)
+		indexed, _ = sa.GetString(TitleSearchAttribute)
+	}).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "now add a test"})
+	}, 5*time.Second)
+	s.sendShutdown(10 * time.Second)
+
+	input := testInput("fix the login bug")
+	input.Config.DisableTitleGeneration = false
+	input.Config.IndexSessionTitle = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "fix the login bug", titleIn.UserMessage)
+	assert.Equal(s.T(), "Fixed the nil check in auth.go", titleIn.AssistantMessage)
+	assert.Equal(s.T(), "Fix nil check in auth middleware", memo[MemoKeyTitle])
+	assert.Equal(s.T(), "Fix nil check in auth middleware", indexed)
+}
+
+// TestSessionTitle_FallsBackToFirstMessage verifies that the first user
+// message titles the session when the title model returns nothing.
+func (s *AgenticWorkflowTestSuite) TestSessionTitle_FallsBackToFirstMessage() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 30), nil).Once()
+	s.env.OnActivity("GenerateSessionTitle", mock.Anything, mock.Anything).
+		Return(activities.SessionTitleOutput{}, nil).Once()
+
+	var memo map[string]interface{}
+	s.env.OnUpsertMemo(mock.Anything).Run(func(args mock.Arguments) {
+		memo = args.Get(0).(map[string]interface{})
+	}).Return(nil).Once()
+
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Bump the go version\nand fix whatever breaks")
+	input.Config.DisableTitleGeneration = false
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "Bump the go version", memo[MemoKeyTitle])
+}
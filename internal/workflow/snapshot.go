@@ -0,0 +1,244 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// snapshot.go manages workspace snapshots: an automatic snapshot before the
+// first mutating tool call of each turn, manual snapshots via /snapshot, and
+// rollback via /rollback or the rollback_workspace tool, which the workflow
+// runs itself once the call is approved. The git work is done by the
+// SnapshotWorkspace / RestoreWorkspace activities on the session task queue;
+// the workflow only keeps the snapshot list.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// maxWorkspaceSnapshots caps how many snapshots are retained. Older
+// snapshots are dropped (and unpinned in git) first.
+const maxWorkspaceSnapshots = 20
+
+// isReadOnlyTool reports whether a tool never modifies the workspace, so
+// it triggers neither an automatic snapshot nor a review: built-in tools
+// registered read-only, and MCP tools their server annotates readOnlyHint.
+func (s *SessionState) isReadOnlyTool(name string) bool {
+	if ref, ok := s.McpToolLookup[name]; ok {
+		return ref.ReadOnly
+	}
+	return tools.IsReadOnly(name)
+}
+
+// WorkspaceSnapshot records one snapshot of the session's working tree.
+type WorkspaceSnapshot struct {
+	ID        string    `json:"id"`                // e.g. "snap-3"
+	TurnID    string    `json:"turn_id,omitempty"` // Turn the snapshot was taken in (before its edits)
+	Commit    string    `json:"commit"`            // Snapshot commit hash
+	Manual    bool      `json:"manual,omitempty"`  // Taken via /snapshot rather than automatically
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// maybeSnapshotBeforeTools takes the turn's automatic snapshot if any of the
+// calls may modify the workspace and none has been taken yet this turn.
+// Failures are logged and never block tool execution.
+func (s *SessionState) maybeSnapshotBeforeTools(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem) {
+	if s.Config.DisableWorkspaceSnapshots || s.snapshottedThisTurn {
+		return
+	}
+	mutating := false
+	for _, fc := range calls {
+		if !s.isReadOnlyTool(fc.Name) {
+			mutating = true
+			break
+		}
+	}
+	if !mutating {
+		return
+	}
+	s.snapshottedThisTurn = true
+	if _, err := s.takeWorkspaceSnapshot(ctx, ctrl.CurrentTurnID(), false); err != nil {
+		workflow.GetLogger(ctx).Warn("Workspace snapshot failed", "error", err)
+	}
+}
+
+// takeWorkspaceSnapshot runs the SnapshotWorkspace activity and records the
+// result. Returns nil (and no error) when the workspace is not a git repo.
+func (s *SessionState) takeWorkspaceSnapshot(ctx workflow.Context, turnID string, manual bool) (*WorkspaceSnapshot, error) {
+	s.SnapshotCounter++
+	id := fmt.Sprintf("snap-%d", s.SnapshotCounter)
+
+	var prune []string
+	if excess := len(s.WorkspaceSnapshots) + 1 - maxWorkspaceSnapshots; excess > 0 {
+		for _, old := range s.WorkspaceSnapshots[:excess] {
+			prune = append(prune, s.snapshotRef(old.ID))
+		}
+	}
+
+	message := fmt.Sprintf("agent snapshot %s (%s)", id, s.ConversationID)
+	if turnID != "" {
+		message = fmt.Sprintf("agent snapshot %s before %s (%s)", id, turnID, s.ConversationID)
+	}
+
+	var out activities.SnapshotWorkspaceOutput
+	err := workflow.ExecuteActivity(s.workspaceActivityCtx(ctx), "SnapshotWorkspace", activities.SnapshotWorkspaceInput{
+		Cwd:        s.Config.Cwd,
+		SnapshotID: s.snapshotRef(id),
+		Message:    message,
+		Prune:      prune,
+	}).Get(ctx, &out)
+	if err != nil {
+		return nil, err
+	}
+	if out.Skipped {
+		return nil, nil
+	}
+
+	snap := WorkspaceSnapshot{
+		ID:        id,
+		TurnID:    turnID,
+		Commit:    out.Commit,
+		Manual:    manual,
+		CreatedAt: workflow.Now(ctx),
+	}
+	s.WorkspaceSnapshots = append(s.WorkspaceSnapshots, snap)
+	if len(s.WorkspaceSnapshots) > maxWorkspaceSnapshots {
+		s.WorkspaceSnapshots = s.WorkspaceSnapshots[len(s.WorkspaceSnapshots)-maxWorkspaceSnapshots:]
+	}
+	return &snap, nil
+}
+
+// rollbackWorkspace restores the snapshot with the given ID, or the most
+// recent one when id is empty. The restored snapshot and any newer ones are
+// removed from the list, so repeated rollbacks step further back in time.
+func (s *SessionState) rollbackWorkspace(ctx workflow.Context, id string) (RollbackWorkspaceResponse, error) {
+	idx := -1
+	if id == "" {
+		idx = len(s.WorkspaceSnapshots) - 1
+	} else {
+		for i, snap := range s.WorkspaceSnapshots {
+			if snap.ID == id {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 {
+		if id == "" {
+			return RollbackWorkspaceResponse{}, fmt.Errorf("no workspace snapshots to roll back to")
+		}
+		return RollbackWorkspaceResponse{}, fmt.Errorf("unknown snapshot %q", id)
+	}
+	snap := s.WorkspaceSnapshots[idx]
+
+	var out activities.RestoreWorkspaceOutput
+	err := workflow.ExecuteActivity(s.workspaceActivityCtx(ctx), "RestoreWorkspace", activities.RestoreWorkspaceInput{
+		Cwd:    s.Config.Cwd,
+		Commit: snap.Commit,
+	}).Get(ctx, &out)
+	if err != nil {
+		return RollbackWorkspaceResponse{}, err
+	}
+
+	s.WorkspaceSnapshots = s.WorkspaceSnapshots[:idx]
+	return RollbackWorkspaceResponse{Snapshot: snap, Restored: out.Restored, Removed: out.Removed}, nil
+}
+
+// runRollbackCalls runs the rollback_workspace calls among the approved
+// calls and returns the rest. rollback_workspace discards the working
+// tree's changes, so it goes through the approval gate like the other
+// mutating tools, and it runs before the turn's automatic snapshot, which
+// would otherwise become the snapshot it restores. An interrupted turn
+// runs nothing.
+func (s *SessionState) runRollbackCalls(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem) []models.ConversationItem {
+	if ctrl.IsInterrupted() {
+		return calls
+	}
+	var remaining []models.ConversationItem
+	for _, fc := range calls {
+		if fc.Name != "rollback_workspace" {
+			remaining = append(remaining, fc)
+			continue
+		}
+		_ = s.History.AddItem(s.handleRollbackWorkspace(ctx, fc))
+		ctrl.NotifyItemAdded()
+	}
+	return remaining
+}
+
+// handleRollbackWorkspace runs an approved rollback_workspace tool call and
+// returns a FunctionCallOutput describing what was restored.
+func (s *SessionState) handleRollbackWorkspace(ctx workflow.Context, fc models.ConversationItem) models.ConversationItem {
+	var args struct {
+		SnapshotID string `json:"snapshot_id,omitempty"`
+	}
+	if fc.Arguments != "" {
+		if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+			return rollbackOutput(fc.CallID, fmt.Sprintf("Invalid rollback_workspace arguments: %v", err), false)
+		}
+	}
+
+	resp, err := s.rollbackWorkspace(ctx, args.SnapshotID)
+	if err != nil {
+		return rollbackOutput(fc.CallID, fmt.Sprintf("Rollback failed: %v", err), false)
+	}
+	return rollbackOutput(fc.CallID, describeRollback(resp), true)
+}
+
+func rollbackOutput(callID, content string, success bool) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &success,
+		},
+	}
+}
+
+// describeRollback summarizes a rollback for the model and the user.
+func describeRollback(resp RollbackWorkspaceResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Workspace rolled back to snapshot %s", resp.Snapshot.ID)
+	if resp.Snapshot.TurnID != "" {
+		fmt.Fprintf(&b, " (taken before the edits of %s)", resp.Snapshot.TurnID)
+	}
+	fmt.Fprintf(&b, ": %d file(s) restored, %d removed.", len(resp.Restored), len(resp.Removed))
+	if len(resp.Restored) > 0 {
+		fmt.Fprintf(&b, "\nRestored: %s", strings.Join(resp.Restored, ", "))
+	}
+	if len(resp.Removed) > 0 {
+		fmt.Fprintf(&b, "\nRemoved: %s", strings.Join(resp.Removed, ", "))
+	}
+	return b.String()
+}
+
+// snapshotRef returns the git ref name (under refs/agent-snapshots/) for a
+// snapshot ID, namespaced by conversation so sessions sharing a repo do not
+// collide.
+func (s *SessionState) snapshotRef(id string) string {
+	return s.ConversationID + "/" + id
+}
+
+// workspaceActivityCtx returns activity options for snapshot activities,
+// routed to the session task queue where the workspace lives.
+func (s *SessionState) workspaceActivityCtx(ctx workflow.Context) workflow.Context {
+	opts := workflow.ActivityOptions{
+		StartToCloseTimeout: 2 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		opts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	return workflow.WithActivityOptions(ctx, opts)
+}
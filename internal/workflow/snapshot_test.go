@@ -0,0 +1,246 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestWorkspaceSnapshot_AutoBeforeMutatingTools verifies that one snapshot is
+// taken before the first mutating tool call of a turn, and none for
+// read-only calls.
+func (s *AgenticWorkflowTestSuite) TestWorkspaceSnapshot_AutoBeforeMutatingTools() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "read_file", Arguments: `{"file_path": "a.txt"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-2", Name: "shell_command", Arguments: `{"command": "touch b"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-3", Name: "shell_command", Arguments: `{"command": "touch c"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("done", 10), nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{Content: "ok", Success: &trueVal}, nil).Times(3)
+
+	s.env.OnActivity("SnapshotWorkspace", mock.Anything, mock.MatchedBy(func(in activities.SnapshotWorkspaceInput) bool {
+		return in.SnapshotID == "test-conv-1/snap-1" && in.Cwd == "/repo"
+	})).Return(activities.SnapshotWorkspaceOutput{Commit: "abc123"}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetWorkspaceSnapshots)
+		require.NoError(s.T(), err)
+		var snaps []WorkspaceSnapshot
+		require.NoError(s.T(), result.Get(&snaps))
+		require.Len(s.T(), snaps, 1)
+		assert.Equal(s.T(), "snap-1", snaps[0].ID)
+		assert.Equal(s.T(), "turn-1", snaps[0].TurnID)
+		assert.Equal(s.T(), "abc123", snaps[0].Commit)
+		assert.False(s.T(), snaps[0].Manual)
+	}, 5*time.Second)
+
+	s.sendShutdown(6 * time.Second)
+
+	input := testInput("make files")
+	input.Config.Cwd = "/repo"
+	input.Config.DisableWorkspaceSnapshots = false
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command", "read_file")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestWorkspaceSnapshot_RollbackTool verifies that the rollback_workspace
+// intercepted tool restores the latest snapshot and drops it from the list.
+func (s *AgenticWorkflowTestSuite) TestWorkspaceSnapshot_RollbackTool() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "shell_command", Arguments: `{"command": "rm -rf src"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-2", Name: "rollback_workspace", Arguments: `{}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("undone", 10), nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: "ok", Success: &trueVal}, nil).Once()
+	s.env.OnActivity("SnapshotWorkspace", mock.Anything, mock.Anything).
+		Return(activities.SnapshotWorkspaceOutput{Commit: "abc123"}, nil).Once()
+	s.env.OnActivity("RestoreWorkspace", mock.Anything, activities.RestoreWorkspaceInput{Cwd: "/repo", Commit: "abc123"}).
+		Return(activities.RestoreWorkspaceOutput{Restored: []string{"src/main.go"}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetWorkspaceSnapshots)
+		require.NoError(s.T(), err)
+		var snaps []WorkspaceSnapshot
+		require.NoError(s.T(), result.Get(&snaps))
+		assert.Empty(s.T(), snaps)
+
+		itemsResult, err := s.env.QueryWorkflow(QueryGetConversationItems)
+		require.NoError(s.T(), err)
+		var items []models.ConversationItem
+		require.NoError(s.T(), itemsResult.Get(&items))
+		var output *models.ConversationItem
+		for i := range items {
+			if items[i].Type == models.ItemTypeFunctionCallOutput && items[i].CallID == "call-2" {
+				output = &items[i]
+			}
+		}
+		require.NotNil(s.T(), output)
+		assert.Contains(s.T(), output.Output.Content, "snap-1")
+		assert.Contains(s.T(), output.Output.Content, "src/main.go")
+	}, 5*time.Second)
+
+	s.sendShutdown(6 * time.Second)
+
+	input := testInput("clean up")
+	input.Config.Cwd = "/repo"
+	input.Config.DisableWorkspaceSnapshots = false
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command", "rollback_workspace")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestWorkspaceSnapshot_RollbackToolNeedsApproval verifies that
+// rollback_workspace waits for approval like other mutating tools, and that
+// a denied rollback does not touch the workspace.
+func (s *AgenticWorkflowTestSuite) TestWorkspaceSnapshot_RollbackToolNeedsApproval() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-rb", Name: "rollback_workspace", Arguments: `{}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+
+	// NOTE: No RestoreWorkspace mock — a denied rollback must not run
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		assert.Equal(s.T(), PhaseApprovalPending, status.Phase)
+		require.Len(s.T(), status.PendingApprovals, 1)
+		assert.Equal(s.T(), "rollback_workspace", status.PendingApprovals[0].ToolName)
+
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", noopCallback(),
+			ApprovalResponse{Denied: []string{"call-rb"}})
+	}, 2*time.Second)
+
+	s.sendShutdown(4 * time.Second)
+
+	input := testInputWithApproval("undo that", models.ApprovalUnlessTrusted)
+	input.Config.Cwd = "/repo"
+	input.Config.DisableWorkspaceSnapshots = false
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "rollback_workspace")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Equal(s.T(), "shutdown", result.EndReason)
+}
+
+// TestWorkspaceSnapshot_ManualSnapshotAndRollbackUpdates verifies the
+// /snapshot and /rollback Updates between turns.
+func (s *AgenticWorkflowTestSuite) TestWorkspaceSnapshot_ManualSnapshotAndRollbackUpdates() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	s.env.OnActivity("SnapshotWorkspace", mock.Anything, mock.Anything).
+		Return(activities.SnapshotWorkspaceOutput{Commit: "def456"}, nil).Once()
+	s.env.OnActivity("RestoreWorkspace", mock.Anything, mock.Anything).
+		Return(activities.RestoreWorkspaceOutput{Removed: []string{"new.txt"}}, nil).Once()
+
+	var snapshotResult, rollbackResult interface{}
+	var unknownErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateSnapshotWorkspace, "snapshot-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("snapshot rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				snapshotResult = result
+			},
+		}, SnapshotWorkspaceRequest{})
+	}, 2*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateRollbackWorkspace, "rollback-unknown", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("rollback rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				unknownErr = err
+			},
+		}, RollbackWorkspaceRequest{SnapshotID: "snap-9"})
+	}, 3*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateRollbackWorkspace, "rollback-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("rollback rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				rollbackResult = result
+			},
+		}, RollbackWorkspaceRequest{})
+	}, 4*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		itemsResult, err := s.env.QueryWorkflow(QueryGetConversationItems)
+		require.NoError(s.T(), err)
+		var items []models.ConversationItem
+		require.NoError(s.T(), itemsResult.Get(&items))
+		last := items[len(items)-1]
+		assert.Equal(s.T(), models.ItemTypeAssistantMessage, last.Type)
+		assert.Contains(s.T(), last.Content, "Workspace rolled back to snapshot snap-1")
+	}, 5*time.Second)
+
+	s.sendShutdown(6 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	snapResp, ok := snapshotResult.(SnapshotWorkspaceResponse)
+	require.True(s.T(), ok, "unexpected snapshot result %T", snapshotResult)
+	require.NotNil(s.T(), snapResp.Snapshot)
+	assert.Equal(s.T(), "snap-1", snapResp.Snapshot.ID)
+	assert.True(s.T(), snapResp.Snapshot.Manual)
+
+	require.Error(s.T(), unknownErr)
+	assert.Contains(s.T(), unknownErr.Error(), "unknown snapshot")
+
+	rollbackResp, ok := rollbackResult.(RollbackWorkspaceResponse)
+	require.True(s.T(), ok, "unexpected rollback result %T", rollbackResult)
+	assert.Equal(s.T(), "snap-1", rollbackResp.Snapshot.ID)
+	assert.Equal(s.T(), []string{"new.txt"}, rollbackResp.Removed)
+}
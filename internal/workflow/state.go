@@ -6,12 +6,18 @@
 package workflow
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
 	"github.com/mfateev/temporal-agent-harness/internal/history"
+	"github.com/mfateev/temporal-agent-harness/internal/hooks"
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/skills"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
+	"github.com/mfateev/temporal-agent-harness/internal/tools/patch"
+	"github.com/mfateev/temporal-agent-harness/internal/usage"
 )
 
 // Signal/query name constants for SessionWorkflow ↔ HarnessWorkflow communication.
@@ -31,6 +37,14 @@ const (
 	// Maps to: Codex ContextManager::raw_items()
 	QueryGetConversationItems = "get_conversation_items"
 
+	// QueryGetConversationItemsSince returns only the items after a given
+	// Seq, for clients polling a long session.
+	QueryGetConversationItemsSince = "get_conversation_items_since"
+
+	// QueryGetHistory returns a page of history items filtered by type,
+	// turn, time or text (HistoryQuery).
+	QueryGetHistory = "get_history"
+
 	// QueryGetTurnStatus returns the current turn phase and stats.
 	// Used by the interactive CLI to drive spinner/state transitions.
 	QueryGetTurnStatus = "get_turn_status"
@@ -43,6 +57,10 @@ const (
 	// Maps to: Codex Op::Interrupt
 	UpdateInterrupt = "interrupt"
 
+	// UpdateCancelTool cancels one in-flight tool call; the rest of the
+	// turn continues.
+	UpdateCancelTool = "cancel_tool"
+
 	// UpdateShutdown ends the session.
 	// Maps to: Codex Op::Shutdown
 	UpdateShutdown = "shutdown"
@@ -58,6 +76,9 @@ const (
 	// Maps to: codex-rs/protocol/src/request_user_input.rs
 	UpdateUserInputQuestionResponse = "user_input_question_response"
 
+	// UpdateAskUserResponse submits the user's free-text answer to an ask_user question.
+	UpdateAskUserResponse = "ask_user_response"
+
 	// UpdateCompact triggers manual context compaction.
 	UpdateCompact = "compact"
 
@@ -69,6 +90,14 @@ const (
 	// Maps to: codex-rs/core/src/agent/control.rs agent shutdown signal
 	SignalAgentShutdown = "agent_shutdown"
 
+	// SignalLLMQueued reports that the in-flight LLM call is waiting behind
+	// the worker's provider rate limiter (sent by the ExecuteLLMCall activity).
+	SignalLLMQueued = activities.SignalLLMQueued
+
+	// SignalToolProgress carries the latest progress of a running tool call
+	// (sent by the ExecuteTool activity).
+	SignalToolProgress = activities.SignalToolProgress
+
 	// UpdatePlanRequest spawns a planner child workflow directly (no LLM round-trip).
 	// The CLI sends this when the user types /plan <message>.
 	UpdatePlanRequest = "plan_request"
@@ -77,6 +106,14 @@ const (
 	// Used by the CLI /model command.
 	UpdateModel = "update_model"
 
+	// UpdateModelConfig changes model parameters (temperature, max tokens,
+	// reasoning) for subsequent turns. Used by the CLI /config command.
+	UpdateModelConfig = "update_model_config"
+
+	// UpdateEnv sets or unsets session environment variables for tool
+	// commands. Used by the CLI /env command.
+	UpdateEnv = "update_env"
+
 	// UpdateGetStateUpdate is a blocking Update that returns state deltas.
 	// Replaces the polling loop: the handler sleeps via workflow.Await until
 	// state actually changes, then returns new items + status in one call.
@@ -91,6 +128,10 @@ const (
 	// UpdateCleanExecSessions closes all exec sessions and returns count.
 	UpdateCleanExecSessions = "clean_exec_sessions"
 
+	// UpdateWriteExecStdin types into one of the session's exec sessions on
+	// the user's behalf. Used by the CLI /sessions write command.
+	UpdateWriteExecStdin = "write_exec_stdin"
+
 	// UpdateApprovalMode changes the session's approval mode mid-session.
 	// Used by the CLI /approvals command.
 	UpdateApprovalMode = "update_approval_mode"
@@ -111,9 +152,92 @@ const (
 	// Used by the CLI /rename command.
 	UpdateSessionName = "set_session_name"
 
+	// UpdateSessionTags adds/removes session tags and sets the session note.
+	// Used by the `client tag` command.
+	UpdateSessionTags = "set_session_tags"
+
 	// UpdateReasoningEffort changes the reasoning effort level for reasoning models.
 	// Used by the CLI /reasoning command.
 	UpdateReasoningEffort = "update_reasoning_effort"
+
+	// QueryGetTurnTimings returns per-turn timing breakdowns (LLM, tools, waiting).
+	QueryGetTurnTimings = "get_turn_timings"
+
+	// UpdateSnapshotWorkspace records a manual workspace snapshot.
+	// Used by the CLI /snapshot command.
+	UpdateSnapshotWorkspace = "snapshot_workspace"
+
+	// UpdateRollbackWorkspace restores the workspace to a snapshot.
+	// Used by the CLI /rollback command.
+	UpdateRollbackWorkspace = "rollback_workspace"
+
+	// QueryGetWorkspaceSnapshots returns the session's workspace snapshots.
+	QueryGetWorkspaceSnapshots = "get_workspace_snapshots"
+
+	// QueryGetSandboxAudit returns the would-be violations of a session in
+	// sandbox audit mode.
+	QueryGetSandboxAudit = "get_sandbox_audit"
+
+	// QueryGetCapabilities returns what the worker serving the session
+	// supports (tools, LLM providers, MCP servers, sandboxes, version).
+	// Used by `client capabilities` and the CLI /capabilities command.
+	QueryGetCapabilities = "get_capabilities"
+
+	// UpdateDiagnoseNetwork checks that the worker serving the session can
+	// reach its LLM providers and the session's HTTP MCP servers with its
+	// proxy and CA bundle settings. Used by `client network`.
+	UpdateDiagnoseNetwork = "diagnose_network"
+
+	// UpdateTaskList edits the shared task list (add / done / undone / rm).
+	// Used by the CLI /todo command.
+	UpdateTaskList = "update_task_list"
+
+	// UpdateImportContext summarizes another session's history into this one.
+	// Used by the CLI /import command.
+	UpdateImportContext = "import_context"
+
+	// UpdateDeveloperInput injects developer-role instructions, apart from
+	// user messages. Used by automation (`client developer`).
+	UpdateDeveloperInput = "developer_input"
+
+	// UpdateTrustedCommands lists or revokes learned-trust commands.
+	// Used by the CLI /trust command.
+	UpdateTrustedCommands = "update_trusted_commands"
+
+	// UpdatePinItem lists, pins or unpins history items that compaction
+	// must keep verbatim. Used by the CLI /pin and /unpin commands.
+	UpdatePinItem = "pin_item"
+
+	// UpdateAnnotateItem records a note or reaction on a history item.
+	// Used by the CLI /note and /react commands.
+	UpdateAnnotateItem = "annotate_item"
+
+	// UpdatePause pauses the session between turns; UpdateResume ends the
+	// pause. Used by the CLI /pause and /unpause commands.
+	UpdatePause  = "pause"
+	UpdateResume = "resume"
+
+	// UpdateRemapCwd moves the session to another checkout of its
+	// repository. Used by the CLI /cwd command and `client remap-cwd`.
+	UpdateRemapCwd = "remap_cwd"
+
+	// UpdateUserShell runs a shell command for the user, without the model,
+	// and records it in history. Used by the CLI /!cmd command.
+	UpdateUserShell = "user_shell"
+
+	// UpdateConsult asks the consult_models a question in parallel, and
+	// UpdateAdoptConsult records the answer the user picked. Used by the
+	// CLI /consult command.
+	UpdateConsult      = "consult"
+	UpdateAdoptConsult = "adopt_consult"
+
+	// QueryGetContext lists what the next LLM call will send, with token
+	// estimates. UpdateDropContextItems and UpdateSummarizeContextItems
+	// remove history items or replace them with a summary. Used by the CLI
+	// /context command.
+	QueryGetContext             = "get_context"
+	UpdateDropContextItems      = "drop_context_items"
+	UpdateSummarizeContextItems = "summarize_context_items"
 )
 
 // UpdateModelRequest is the payload for the update_model Update.
@@ -128,6 +252,33 @@ type UpdateModelResponse struct {
 	Acknowledged bool `json:"acknowledged"`
 }
 
+// UpdateModelConfigRequest is the payload for the update_model_config
+// Update. Empty params change nothing and return the current configuration.
+type UpdateModelConfigRequest struct {
+	Params models.ModelParams `json:"params"`
+}
+
+// UpdateModelConfigResponse is returned by the update_model_config Update:
+// the model configuration now in effect and what the model supports.
+type UpdateModelConfigResponse struct {
+	Model                     models.ModelConfig       `json:"model"`
+	TemperatureUnsupported    bool                     `json:"temperature_unsupported,omitempty"`
+	SupportedReasoningEfforts []models.ReasoningEffort `json:"supported_reasoning_efforts,omitempty"`
+}
+
+// UpdateEnvRequest is the payload for the update_env Update. An empty
+// request changes nothing and returns the current variables.
+type UpdateEnvRequest struct {
+	Set   map[string]string `json:"set,omitempty"`
+	Unset []string          `json:"unset,omitempty"`
+}
+
+// UpdateEnvResponse is returned by the update_env Update: the variables
+// now set for tool commands.
+type UpdateEnvResponse struct {
+	Env map[string]string `json:"env,omitempty"`
+}
+
 // McpToolSummary is a lightweight view of an MCP tool for the get_mcp_tools query.
 type McpToolSummary struct {
 	QualifiedName string `json:"qualified_name"`
@@ -161,6 +312,152 @@ type CleanExecSessionsResponse struct {
 	Closed int `json:"closed"`
 }
 
+// WriteExecStdinRequest is the payload for the write_exec_stdin Update.
+type WriteExecStdinRequest struct {
+	ProcessID string `json:"process_id"`
+	Chars     string `json:"chars"` // Written as is; include "\n" to submit a line
+}
+
+// WriteExecStdinResponse is returned by the write_exec_stdin Update.
+type WriteExecStdinResponse struct {
+	// Output is the tail of the process's recent output, read without
+	// consuming it, so the model still sees it on its next poll.
+	Output string `json:"output"`
+	Exited bool   `json:"exited"`
+}
+
+// SnapshotWorkspaceRequest is the payload for the snapshot_workspace Update.
+type SnapshotWorkspaceRequest struct{}
+
+// SnapshotWorkspaceResponse is returned by the snapshot_workspace Update.
+// Snapshot is nil when the workspace is not a git repository.
+type SnapshotWorkspaceResponse struct {
+	Snapshot *WorkspaceSnapshot `json:"snapshot,omitempty"`
+}
+
+// RollbackWorkspaceRequest is the payload for the rollback_workspace Update.
+// An empty SnapshotID restores the most recent snapshot.
+type RollbackWorkspaceRequest struct {
+	SnapshotID string `json:"snapshot_id,omitempty"`
+}
+
+// RollbackWorkspaceResponse is returned by the rollback_workspace Update.
+type RollbackWorkspaceResponse struct {
+	Snapshot WorkspaceSnapshot `json:"snapshot"`
+	Restored []string          `json:"restored,omitempty"`
+	Removed  []string          `json:"removed,omitempty"`
+}
+
+// TaskListAction is an edit to the shared task list.
+type TaskListAction string
+
+const (
+	TaskListAdd    TaskListAction = "add"
+	TaskListDone   TaskListAction = "done"
+	TaskListUndone TaskListAction = "undone"
+	TaskListRemove TaskListAction = "rm"
+)
+
+// UpdateTaskListRequest is the payload for the update_task_list Update.
+// Text is used by add; ID by done, undone and rm.
+type UpdateTaskListRequest struct {
+	Action TaskListAction `json:"action"`
+	Text   string         `json:"text,omitempty"`
+	ID     int            `json:"id,omitempty"`
+}
+
+// UpdateTaskListResponse is returned by the update_task_list Update.
+type UpdateTaskListResponse struct {
+	Tasks []TaskItem `json:"tasks"`
+}
+
+// TrustAction is an operation on the session's learned-trust commands.
+type TrustAction string
+
+const (
+	TrustList   TrustAction = "list"
+	TrustRevoke TrustAction = "revoke"
+)
+
+// UpdateTrustedCommandsRequest is the payload for the update_trusted_commands
+// Update. ID is used by revoke.
+type UpdateTrustedCommandsRequest struct {
+	Action TrustAction `json:"action"`
+	ID     int         `json:"id,omitempty"`
+}
+
+// UpdateTrustedCommandsResponse is returned by the update_trusted_commands
+// Update: the trusted commands after the action.
+type UpdateTrustedCommandsResponse struct {
+	Commands []TrustedCommand `json:"commands"`
+}
+
+// PinAction is an operation on pinned history items.
+type PinAction string
+
+const (
+	PinList  PinAction = "list"
+	PinPin   PinAction = "pin"
+	PinUnpin PinAction = "unpin"
+)
+
+// PinItemRequest is the payload for the pin_item Update. Seq is used by pin
+// and unpin.
+type PinItemRequest struct {
+	Action PinAction `json:"action"`
+	Seq    int       `json:"seq,omitempty"`
+}
+
+// PinItemResponse is returned by the pin_item Update: the pinned items and
+// the most recent pinnable messages, in history order.
+type PinItemResponse struct {
+	Items []PinnedItem `json:"items"`
+}
+
+// ContextEditRequest is the payload for the drop_context_items and
+// summarize_context_items Updates: the seqs of the history items to remove.
+type ContextEditRequest struct {
+	Seqs []int `json:"seqs"`
+}
+
+// ContextEditResponse is returned by the drop_context_items and
+// summarize_context_items Updates. Removed counts the items taken out,
+// including the call or output paired with a selected one; View is the
+// context after the edit, renumbered.
+type ContextEditResponse struct {
+	Removed int         `json:"removed"`
+	Summary string      `json:"summary,omitempty"`
+	View    ContextView `json:"view"`
+}
+
+// AnnotateItemRequest is the payload for the annotate_item Update. Seq -1
+// targets the latest assistant message. At least one of Note and Reaction
+// must be set.
+type AnnotateItemRequest struct {
+	Seq      int             `json:"seq"`
+	Note     string          `json:"note,omitempty"`
+	Reaction models.Reaction `json:"reaction,omitempty"`
+}
+
+// AnnotateItemResponse is returned by the annotate_item Update.
+type AnnotateItemResponse struct {
+	Seq       int `json:"seq"`        // Seq of the new annotation item
+	TargetSeq int `json:"target_seq"` // Seq of the annotated item
+}
+
+// ImportContextRequest is the payload for the import_context Update.
+type ImportContextRequest struct {
+	// WorkflowID of the session to import (AgenticWorkflow or SessionWorkflow).
+	WorkflowID string `json:"workflow_id"`
+}
+
+// ImportContextResponse is returned by the import_context Update.
+type ImportContextResponse struct {
+	SourceWorkflowID string `json:"source_workflow_id"` // AgenticWorkflow whose history was read
+	ItemCount        int    `json:"item_count"`         // Items in the source history
+	Summary          string `json:"summary"`
+}
+
 // UpdateApprovalModeRequest is the payload for the update_approval_mode Update.
 type UpdateApprovalModeRequest struct {
 	ApprovalMode string `json:"approval_mode"`
@@ -202,6 +499,21 @@ type SetSessionNameResponse struct {
 	Acknowledged bool `json:"acknowledged"`
 }
 
+// SetSessionTagsRequest is the payload for the set_session_tags Update.
+// Tags are normalized to lower case. A nil Note leaves the note unchanged;
+// a pointer to "" clears it.
+type SetSessionTagsRequest struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+	Note   *string  `json:"note,omitempty"`
+}
+
+// SetSessionTagsResponse is returned by the set_session_tags Update.
+type SetSessionTagsResponse struct {
+	Tags []string `json:"tags,omitempty"`
+	Note string   `json:"note,omitempty"`
+}
+
 // UpdateReasoningEffortRequest is the payload for the update_reasoning_effort Update.
 type UpdateReasoningEffortRequest struct {
 	Effort string `json:"effort"`
@@ -219,34 +531,66 @@ type TurnPhase string
 const (
 	PhaseWaitingForInput    TurnPhase = "waiting_for_input"
 	PhaseLLMCalling         TurnPhase = "llm_calling"
+	PhaseLLMQueued          TurnPhase = "llm_queued" // LLM call waiting on the provider rate limiter
+	PhaseRateLimited        TurnPhase = "rate_limited" // Waiting out a provider 429 before retrying the LLM call
 	PhaseToolExecuting      TurnPhase = "tool_executing"
 	PhaseApprovalPending    TurnPhase = "approval_pending"
 	PhaseEscalationPending  TurnPhase = "escalation_pending"
 	PhaseUserInputPending   TurnPhase = "user_input_pending"
 	PhaseCompacting         TurnPhase = "compacting"
 	PhaseWaitingForAgents   TurnPhase = "waiting_for_agents"
+	PhaseVerifying          TurnPhase = "verifying" // Running AutoVerifyCommand after the model ended the turn
+	PhaseReviewing          TurnPhase = "reviewing" // A reviewer subagent is critiquing the turn's changes
+	PhaseCheckingIn         TurnPhase = "checking_in" // Writing an autonomous run's check-in
 )
 
+// ToolInFlight is a tool call that is currently executing.
+type ToolInFlight struct {
+	Name      string              `json:"name"`
+	CallID    string              `json:"call_id,omitempty"`
+	Progress  *tools.ToolProgress `json:"progress,omitempty"` // Latest report from the tool, if any
+	StartedAt time.Time           `json:"started_at"`         // When the tool activity was dispatched
+	Timeout   time.Duration       `json:"timeout,omitempty"`  // Per-attempt StartToClose timeout
+}
+
 // TurnStatus is the response from the get_turn_status query.
 type TurnStatus struct {
 	Phase                   TurnPhase                `json:"phase"`
 	CurrentTurnID           string                   `json:"current_turn_id"`
-	ToolsInFlight           []string                 `json:"tools_in_flight,omitempty"`
+	TurnEpoch               int                      `json:"turn_epoch"` // Echoed by approval/escalation responses; see LoopControl.ValidateApproval
+	ToolsInFlight           []string                 `json:"tools_in_flight,omitempty"` // Names of the running tools
+	RunningTools            []ToolInFlight           `json:"running_tools,omitempty"`   // The running tool calls, with timing and progress
 	PendingApprovals        []PendingApproval        `json:"pending_approvals,omitempty"`
 	PendingEscalations      []EscalationRequest      `json:"pending_escalations,omitempty"`
 	PendingUserInputRequest *PendingUserInputRequest `json:"pending_user_input_request,omitempty"`
+	PendingAskUser          *PendingAskUserRequest   `json:"pending_ask_user,omitempty"`
 	ChildAgents             []ChildAgentSummary      `json:"child_agents,omitempty"`
 	IterationCount          int                      `json:"iteration_count"`
 	TotalTokens             int                      `json:"total_tokens"`
 	TotalCachedTokens       int                      `json:"total_cached_tokens"`
+	TotalInputTokens        int                      `json:"total_input_tokens"` // Prompt tokens incl. cache reads/writes
+	CacheHitRate            int                      `json:"cache_hit_rate_percent"` // Share of prompt tokens served from the provider cache
 	TurnCount               int                      `json:"turn_count"`
 	WorkerVersion           string                   `json:"worker_version,omitempty"`
 	Suggestion              string                   `json:"suggestion,omitempty"`
 	Plan                    *PlanState               `json:"plan,omitempty"`
+	Tasks                   []TaskItem               `json:"tasks,omitempty"`
 	LastTokenUsage          *models.TokenUsage       `json:"last_token_usage,omitempty"`
 	ContextWindowRemaining  int                      `json:"context_window_remaining_percent"`
 	ContextWindowTotal      int                      `json:"context_window_total"`
 	RateLimitSnapshot       *models.RateLimitSnapshot `json:"rate_limit_snapshot,omitempty"`
+	LastTurnTiming          *TurnTiming              `json:"last_turn_timing,omitempty"`
+	QueuedBehind            int                      `json:"queued_behind,omitempty"` // Requests ahead of ours while PhaseLLMQueued
+	ResumesAt               time.Time                `json:"resumes_at"`              // When the LLM call is retried while PhaseRateLimited
+	PhaseStartedAt          time.Time                `json:"phase_started_at"`        // Start of the LLM call behind the phase; zero if untimed
+	PhaseTimeout            time.Duration            `json:"phase_timeout,omitempty"` // Per-attempt timeout of that call
+	Paused                  *PauseInfo               `json:"paused,omitempty"`        // Set while the session is paused
+	ExecSessions            []ExecSessionStatus      `json:"exec_sessions,omitempty"` // exec_command processes still running
+	HistoryEpoch            int                      `json:"history_epoch,omitempty"` // See SessionState.HistoryEpoch
+	PromptHash              string                   `json:"prompt_hash,omitempty"`         // Prompt assembly of the last LLM call
+	PromptHashChanges       int                      `json:"prompt_hash_changes,omitempty"` // Calls whose assembly changed, missing the prompt cache
+	ToolMismatches          []ToolMismatch           `json:"tool_mismatches,omitempty"`     // Tools called but missing on the worker
+	SuggestionUsage         *SuggestionUsage         `json:"suggestion_usage,omitempty"`    // Prompt suggestions, not in TotalTokens
 }
 
 // SessionWorkflowInput is the input for SessionWorkflow.
@@ -290,6 +634,8 @@ type UpdateSessionStatusRequest struct {
 // Maps to: codex-rs/core/src/codex.rs run_turn input
 type WorkflowInput struct {
 	ConversationID string                      `json:"conversation_id"`
+	// UserMessage starts the first turn. "" when the session is started
+	// with Update-with-Start: the first message is the user_input update.
 	UserMessage    string                      `json:"user_message"`
 	Config         models.SessionConfiguration `json:"config"`
 	// Depth tracks subagent nesting level. 0 = top-level, 1 = child.
@@ -303,6 +649,11 @@ type WorkflowInput struct {
 	McpToolSpecs    []tools.ToolSpec            `json:"mcp_tool_specs,omitempty"`
 	LoadedSkills    []skills.SkillMetadata      `json:"loaded_skills,omitempty"`
 
+	// CustomRoles are user-defined subagent roles. Set by SessionWorkflow for
+	// the main agent and inherited by spawned children so nested spawns can
+	// use them too.
+	CustomRoles []models.AgentRoleDef `json:"custom_roles,omitempty"`
+
 	// CrewName is the crew template name (for activity-based resolution).
 	CrewName string `json:"crew_name,omitempty"`
 
@@ -311,20 +662,59 @@ type WorkflowInput struct {
 
 	// CrewInputs are the raw user-provided inputs for crew interpolation.
 	CrewInputs map[string]string `json:"crew_inputs,omitempty"`
+
+	// ResultSchema is the JSON Schema a parent requested for this subagent's
+	// emit_result result. Only used when Depth > 0.
+	ResultSchema map[string]interface{} `json:"result_schema,omitempty"`
 }
 
 // UserInput is the payload for the user_input Update.
 // Maps to: codex-rs/protocol/src/user_input.rs UserInput
 type UserInput struct {
 	Content string `json:"content"`
+
+	// IdempotencyKey, if set, identifies this message across client retries.
+	// A repeated key is acknowledged with the original turn instead of
+	// starting a second turn.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// DeveloperInput is the payload for the developer_input Update.
+type DeveloperInput struct {
+	Content string `json:"content"`
+
+	// StartTurn starts a new turn with the instructions, as user input
+	// does. Otherwise they join the current turn, or are seen by the next
+	// one when the session is idle.
+	StartTurn bool `json:"start_turn,omitempty"`
+}
+
+// DeveloperInputResponse is returned by the developer_input Update.
+// TurnID is the turn the instructions were added to; empty when they wait
+// for the next turn.
+type DeveloperInputResponse struct {
+	TurnID string `json:"turn_id,omitempty"`
 }
 
 // StateUpdateRequest is the payload for the get_state_update Update.
 // The caller provides the last-seen sequence number and phase so the handler
 // can determine whether new state is already available or needs to block.
+//
+// New items and phase changes are returned as soon as they happen. A change
+// that only touches the status (tool progress, token counts, plan) is held
+// for StatusIntervalMs so bursts of such changes coalesce into one response.
+// TimeoutMs bounds the wait: the handler then returns with TimedOut set and
+// no items, so a client that went away does not leave the Update blocked.
 type StateUpdateRequest struct {
 	SinceSeq   int       `json:"since_seq"`
 	SincePhase TurnPhase `json:"since_phase"`
+
+	// TimeoutMs is the longest the handler blocks; 0 blocks until
+	// something changes.
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+	// StatusIntervalMs delays status-only responses; 0 returns them
+	// immediately.
+	StatusIntervalMs int `json:"status_interval_ms,omitempty"`
 }
 
 // StateUpdateResponse is the unified response for both user_input and
@@ -336,6 +726,24 @@ type StateUpdateResponse struct {
 	Status    TurnStatus                `json:"status"`
 	Compacted bool                      `json:"compacted,omitempty"`
 	Completed bool                      `json:"completed,omitempty"`
+	// TimedOut is set when get_state_update returned because
+	// StateUpdateRequest.TimeoutMs elapsed with nothing new.
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// ConversationItemsSinceResponse is returned by the
+// get_conversation_items_since query.
+type ConversationItemsSinceResponse struct {
+	// Items are the items with Seq greater than the requested one, or the
+	// whole history when Compacted is set.
+	Items []models.ConversationItem `json:"items"`
+	// Compacted is set when the requested Seq is past the end of history,
+	// which only happens after a rewrite.
+	Compacted bool `json:"compacted,omitempty"`
+	// HistoryEpoch identifies the Seq numbering. When it differs from the
+	// epoch the client last saw, history was rewritten and the client
+	// should query again from -1.
+	HistoryEpoch int `json:"history_epoch"`
 }
 
 // InterruptRequest is the payload for the interrupt Update.
@@ -348,6 +756,16 @@ type InterruptResponse struct {
 	Acknowledged bool `json:"acknowledged"`
 }
 
+// CancelToolRequest is the payload for the cancel_tool Update.
+type CancelToolRequest struct {
+	CallID string `json:"call_id"`
+}
+
+// CancelToolResponse is returned by the cancel_tool Update.
+type CancelToolResponse struct {
+	ToolName string `json:"tool_name"`
+}
+
 // ShutdownRequest is the payload for the shutdown Update.
 // Maps to: codex-rs/protocol/src/protocol.rs Op::Shutdown
 type ShutdownRequest struct {
@@ -367,6 +785,10 @@ type PendingApproval struct {
 	ToolName  string `json:"tool_name"`
 	Arguments string `json:"arguments"` // Raw JSON string of arguments
 	Reason    string `json:"reason,omitempty"` // Why approval is needed (from policy justification or heuristic)
+
+	// Context is what the worker could tell about the call from its
+	// filesystem (DescribeApprovals activity); nil when unavailable.
+	Context *models.ApprovalContext `json:"context,omitempty"`
 }
 
 // ApprovalResponse is the user's decision on pending tool approvals.
@@ -374,6 +796,10 @@ type PendingApproval struct {
 type ApprovalResponse struct {
 	Approved []string `json:"approved"` // CallIDs the user approved
 	Denied   []string `json:"denied"`   // CallIDs the user denied
+
+	// TurnEpoch is the TurnStatus.TurnEpoch the decision was made in. A
+	// response for an earlier epoch is rejected as stale; 0 is not checked.
+	TurnEpoch int `json:"turn_epoch,omitempty"`
 }
 
 // ApprovalResponseAck is returned by the approval_response Update after acceptance.
@@ -387,12 +813,20 @@ type EscalationRequest struct {
 	Arguments string `json:"arguments"`
 	Output    string `json:"output"`     // Failed output from sandboxed execution
 	Reason    string `json:"reason"`     // Why escalation is needed
+
+	// PatchConflict is set when apply_patch could not apply a hunk and asks
+	// whether to apply it at its closest match instead of re-running the
+	// call without the sandbox.
+	PatchConflict *patch.Conflict `json:"patch_conflict,omitempty"`
 }
 
 // EscalationResponse is the user's decision on escalation.
 type EscalationResponse struct {
 	Approved []string `json:"approved"` // CallIDs to re-execute without sandbox
 	Denied   []string `json:"denied"`   // CallIDs to reject
+
+	// TurnEpoch is as in ApprovalResponse.
+	TurnEpoch int `json:"turn_epoch,omitempty"`
 }
 
 // EscalationResponseAck is returned by the escalation_response Update.
@@ -434,6 +868,25 @@ type UserInputQuestionResponse struct {
 // UserInputQuestionResponseAck is returned by the user_input_question_response Update.
 type UserInputQuestionResponseAck struct{}
 
+// PendingAskUserRequest describes an ask_user call awaiting the user's
+// free-text answer. Body is markdown.
+type PendingAskUserRequest struct {
+	CallID   string `json:"call_id"`
+	Question string `json:"question"`
+	Body     string `json:"body,omitempty"`
+	Default  string `json:"default,omitempty"`
+}
+
+// AskUserResponse is the user's answer to an ask_user call. An empty Answer
+// selects the question's default.
+type AskUserResponse struct {
+	CallID string `json:"call_id"`
+	Answer string `json:"answer"`
+}
+
+// AskUserResponseAck is returned by the ask_user_response Update.
+type AskUserResponseAck struct{}
+
 // CompactRequest is the payload for the compact Update.
 type CompactRequest struct{}
 
@@ -462,6 +915,7 @@ type ChildAgentSummary struct {
 	WorkflowID string     `json:"workflow_id"`
 	Role       AgentRole   `json:"role"`
 	Status     AgentStatus `json:"status"`
+	FanOutID   string      `json:"fan_out_id,omitempty"` // Set for children of a fan_out call
 }
 
 // AgentInputSignal is the payload for the agent_input signal.
@@ -482,6 +936,12 @@ type AgentInputSignal struct {
 //
 // Corresponds to: codex-rs/core/src/state/session.rs SessionState
 type SessionState struct {
+	// SchemaVersion is the layout version of the persisted state
+	// (models.SchemaVersion), stamped before ContinueAsNew. Older payloads
+	// are migrated when decoded; see UnmarshalJSON.
+	SchemaVersion int  `json:"schema_version"`
+	migratedFrom  *int `json:"-"` // Version UnmarshalJSON migrated from, for logging
+
 	ConversationID string                      `json:"conversation_id"`
 	History        history.ContextManager      `json:"-"`             // Not serialized directly; see note below
 	HistoryItems   []models.ConversationItem   `json:"history_items"` // Serialized form for ContinueAsNew
@@ -513,15 +973,36 @@ type SessionState struct {
 	CompactionCount   int  `json:"compaction_count"` // How many times compaction has occurred
 	compactedThisTurn bool `json:"-"`                // Prevents double compaction in one turn
 
+	// HistoryEpoch is bumped whenever history is rewritten (compaction or
+	// dropping old turns), which renumbers Seq. A client holding a Seq from
+	// an older epoch must reload the full history.
+	HistoryEpoch int `json:"history_epoch"`
+
 	// Model switch tracking (persists across ContinueAsNew except modelSwitched)
 	PreviousModel         string `json:"previous_model,omitempty"`          // Model before last switch
 	PreviousContextWindow int    `json:"previous_context_window,omitempty"` // Context window before last switch
 	modelSwitched         bool   `json:"-"`                                 // Transient: set on model switch, consumed by maybeCompactBeforeLLM
 
+	// Transient: set when /env changes the session environment, consumed by
+	// maybeInjectEnvironmentContext.
+	envChanged bool `json:"-"`
+
+	// ClockShown is the time (to the minute) the history last showed the
+	// model, so maybeRefreshClock skips a turn started in the same minute.
+	ClockShown string `json:"clock_shown,omitempty"`
+
 	// Repeated tool call detection (transient — not serialized)
 	lastToolKey string `json:"-"`
 	repeatCount int    `json:"-"`
 
+	// Transient: full output of each shell/exec command run this turn, by
+	// commandKey, for diffing repeated runs (Config.DiffRepeatedOutput).
+	turnOutputs map[string]string `json:"-"`
+
+	// Transient: whether each search attribute the session indexes into is
+	// registered, checked once per run (searchAttributeRegistered).
+	searchAttrs map[string]bool `json:"-"`
+
 	// Turn counter incremented each time a new turn ID is generated.
 	// Persists across ContinueAsNew so turn IDs are monotonically increasing.
 	TurnCounter int `json:"turn_counter"`
@@ -529,8 +1010,91 @@ type SessionState struct {
 	// Cumulative stats (persist across ContinueAsNew)
 	TotalTokens       int                `json:"total_tokens"`
 	TotalCachedTokens int                `json:"total_cached_tokens"`
+	TotalInputTokens  int                `json:"total_input_tokens"` // Prompt tokens incl. cache reads/writes; denominator of the cache hit rate
 	LastTokenUsage    models.TokenUsage  `json:"last_token_usage"`
 	ToolCallsExecuted []string           `json:"tool_calls_executed"`
+	ModelsUsed        []string           `json:"models_used,omitempty"` // Models that served LLM calls, in order of first use
+	UsageByModel      []usage.ModelUsage `json:"usage_by_model,omitempty"` // Tokens of all LLM calls by model, for pricing
+
+	// SuggestionUsage counts the prompt suggestion calls, outside the
+	// totals above (see suggestions.go).
+	SuggestionUsage SuggestionUsage `json:"suggestion_usage,omitzero"`
+
+	// PromptHash is the prompt assembly hash of the last LLM call and
+	// PromptHashChanges counts calls whose hash differed from the previous
+	// one, i.e. could not reuse the provider's prompt cache (see cache.go).
+	PromptHash        string `json:"prompt_hash,omitempty"`
+	PromptHashChanges int    `json:"prompt_hash_changes,omitempty"`
+
+	// ToolMismatches records calls to tools the worker did not have (see
+	// tool_mismatch.go). Persists across ContinueAsNew.
+	ToolMismatches []ToolMismatch `json:"tool_mismatches,omitempty"`
+
+	// Per-turn timing breakdowns, most recent last (persist across ContinueAsNew).
+	// currentTiming is the in-progress turn and is not serialized.
+	TurnTimings   []TurnTiming `json:"turn_timings,omitempty"`
+	currentTiming *TurnTiming  `json:"-"`
+
+	// Sandbox audit mode's would-be violations (see sandbox_audit.go),
+	// oldest first and capped, and their uncapped count. Persist across
+	// ContinueAsNew.
+	SandboxViolations     []SandboxViolation `json:"sandbox_violations,omitempty"`
+	SandboxViolationCount int                `json:"sandbox_violation_count,omitempty"`
+
+	// Workspace snapshots, oldest first (persist across ContinueAsNew).
+	// SnapshotCounter numbers snapshot IDs; snapshottedThisTurn limits
+	// automatic snapshots to one per turn.
+	WorkspaceSnapshots  []WorkspaceSnapshot `json:"workspace_snapshots,omitempty"`
+	SnapshotCounter     int                 `json:"snapshot_counter,omitempty"`
+	snapshottedThisTurn bool                `json:"-"`
+
+	// Transcript archive progress (see archive.go): how many leading history
+	// items are archived, and the last segment number written. Persist
+	// across ContinueAsNew.
+	ArchivedItems   int `json:"archived_items,omitempty"`
+	ArchiveSegments int `json:"archive_segments,omitempty"`
+
+	// Code index state (see codeindex.go): whether the index has been synced
+	// this session, and the edited files to reindex before the next search.
+	// Persist across ContinueAsNew.
+	CodeIndexed    bool     `json:"code_indexed,omitempty"`
+	CodeIndexStale []string `json:"code_index_stale,omitempty"`
+
+	// Token counting for history estimates (see tokens.go). Rebuilt after
+	// ContinueAsNew.
+	tokenCache          *tokenizer.Cache `json:"-"`
+	tokenCountingFailed bool             `json:"-"`
+
+	// Model routing inputs for the current turn (see routing.go).
+	turnToolsRequested map[string]bool `json:"-"`
+	turnRoutingHint    string          `json:"-"`
+
+	// LastCallModel is the model of the last LLM call. A call to a different
+	// model resends the full history instead of chaining responses.
+	LastCallModel string `json:"last_call_model,omitempty"`
+	lastCallProvider string // Provider of LastCallModel, for addTokenUsage
+
+	// Hook events found to have no scripts (see hooks.go). Rechecked after
+	// ContinueAsNew.
+	hooksMissing map[hooks.Event]bool `json:"-"`
+
+	// Autonomous run in progress (see autonomy.go). Persists across
+	// ContinueAsNew.
+	AutonomyRun *AutonomyRun `json:"autonomy_run,omitempty"`
+
+	// Auto-verify outcome of the current turn (transient), recorded on the
+	// turn's TurnComplete item.
+	turnVerify *models.VerifyResult `json:"-"`
+
+	// Peer review of the current turn (transient, see review.go):
+	// turnChanged is set once the turn runs a tool that may modify the
+	// workspace; turnReview is recorded on the turn's TurnComplete item.
+	turnChanged bool                `json:"-"`
+	turnReview  *models.ReviewResult `json:"-"`
+
+	// lastConsult is the latest /consult result, until an answer is
+	// adopted. Not carried across ContinueAsNew.
+	lastConsult *ConsultResponse `json:"-"`
 
 	// MCP tool routing map: qualified name → McpToolRef (server + original tool name).
 	// Persists across ContinueAsNew so MCP tool dispatch works after CAN.
@@ -540,6 +1104,31 @@ type SessionState struct {
 	// Persists across ContinueAsNew and is exposed via get_turn_status.
 	Plan *PlanState `json:"plan,omitempty"`
 
+	// Task list shared between the user (/todo) and the LLM (task_list tool).
+	// Persists across ContinueAsNew and is exposed via get_turn_status.
+	Tasks       []TaskItem `json:"tasks,omitempty"`
+	TaskCounter int        `json:"task_counter,omitempty"`
+
+	// Learned trust (see trust.go): approvals so far per command, and the
+	// commands approved automatically. Persist across ContinueAsNew.
+	ApprovalCounts  map[string]int   `json:"approval_counts,omitempty"`
+	TrustedCommands []TrustedCommand `json:"trusted_commands,omitempty"`
+	TrustCounter    int              `json:"trust_counter,omitempty"`
+
+	// Idempotency keys of the most recent user_input Updates, oldest first.
+	// Persists across ContinueAsNew so retries that straddle it are still
+	// recognized as duplicates.
+	RecentInputKeys []InputKeyRecord `json:"recent_input_keys,omitempty"`
+
+	// ResultSchema is the JSON Schema the parent requested for this
+	// subagent's result (spawn_agent output_schema). Nil accepts any object.
+	ResultSchema map[string]interface{} `json:"result_schema,omitempty"`
+
+	// StructuredResult is the result reported via emit_result (or extracted
+	// at completion when the parent asked for a schema). Returned to the
+	// parent in WorkflowResult.
+	StructuredResult json.RawMessage `json:"structured_result,omitempty"`
+
 	// MemoryExtractedAt is the epoch-seconds timestamp of the last memory
 	// extraction. Used to avoid re-extraction on ContinueAsNew resume.
 	MemoryExtractedAt int64 `json:"memory_extracted_at,omitempty"`
@@ -552,10 +1141,41 @@ type SessionState struct {
 	// Maps to: codex-rs thread_name
 	SessionName string `json:"session_name,omitempty"`
 
+	// User-assigned tags and free-form note (set via `client tag`, persist
+	// across CAN). Mirrored to the workflow memo and, when enabled, the
+	// AgentTags search attribute.
+	Tags []string `json:"tags,omitempty"`
+	Note string   `json:"note,omitempty"`
+	// Title is the session's short title for the session picker, set after
+	// the first turn (see session_title.go). Persists across ContinueAsNew.
+	Title string `json:"title,omitempty"`
+
+	// Paused is set while the session is paused (see pause.go). Persists
+	// across ContinueAsNew.
+	Paused *PauseInfo `json:"paused,omitempty"`
+
+	// PathRemaps are the session's former working directories and where
+	// they moved (see remap.go). Persist across ContinueAsNew.
+	PathRemaps []PathRemap `json:"path_remaps,omitempty"`
+
+	// Capabilities of the worker that served the session's start, resolved
+	// by the DescribeWorker activity (see capabilities.go). Persists across
+	// ContinueAsNew.
+	Capabilities *WorkerCapabilitiesInfo `json:"capabilities,omitempty"`
+
+	// ExecSessions are the exec_command processes the model left running,
+	// as last seen in tool results (see exec_sessions.go). Persists across
+	// ContinueAsNew, like the processes on the worker.
+	ExecSessions []ExecSessionStatus `json:"exec_sessions,omitempty"`
+
 	// Discovered skills metadata (loaded at session start, persists across CAN).
 	// Maps to: codex-rs/core/src/skills/manager.rs SkillsManager
 	LoadedSkills []skills.SkillMetadata `json:"loaded_skills,omitempty"`
 
+	// User-defined subagent roles from ~/.codex/roles/*.toml (loaded at
+	// session start, persists across CAN).
+	CustomRoles []models.AgentRoleDef `json:"custom_roles,omitempty"`
+
 	// CrewName is the crew template name. Persists across ContinueAsNew.
 	CrewName string `json:"crew_name,omitempty"`
 
@@ -602,12 +1222,51 @@ type WorkflowResult struct {
 	TotalIterations   int      `json:"total_iterations"`
 	TotalTokens       int      `json:"total_tokens"`
 	TotalCachedTokens int      `json:"total_cached_tokens"`
+	TotalInputTokens  int      `json:"total_input_tokens,omitempty"` // Prompt tokens incl. cache reads/writes
 	ToolCallsExecuted []string `json:"tool_calls_executed"`
 	EndReason         string   `json:"end_reason,omitempty"` // "shutdown", "error"
+	// Model is the session's model when it ended, Models every model that
+	// served its LLM calls, and Tags its tags; usage reports group by them.
+	// UsageByModel splits the token totals by model, so each is priced at
+	// its own rate.
+	Model        string             `json:"model,omitempty"`
+	Models       []string           `json:"models,omitempty"`
+	Tags         []string           `json:"tags,omitempty"`
+	UsageByModel []usage.ModelUsage `json:"usage_by_model,omitempty"`
 	// FinalMessage is the last assistant message from the workflow.
 	// Used by parent workflows to get the child's result.
 	// Maps to: codex-rs AgentStatus::Completed(Option<String>)
 	FinalMessage string `json:"final_message,omitempty"`
+	// StructuredResult is the JSON result a subagent reported via
+	// emit_result, for parents that need to parse it.
+	StructuredResult json.RawMessage `json:"structured_result,omitempty"`
+	// SandboxAudit is the violation report of a session in sandbox audit
+	// mode.
+	SandboxAudit *SandboxAuditReport `json:"sandbox_audit,omitempty"`
+	// SuggestionUsage is what prompt suggestions cost, on top of
+	// TotalTokens.
+	SuggestionUsage *SuggestionUsage `json:"suggestion_usage,omitempty"`
+}
+
+// sessionStateJSON is SessionState without its UnmarshalJSON method.
+type sessionStateJSON SessionState
+
+// UnmarshalJSON decodes a SessionState, first upgrading payloads written
+// with an older schema version (see models.MigrateSession). A payload from a
+// newer version fails to decode, so a workflow task of a newer release is
+// never run, and its state truncated, by an older worker.
+func (s *SessionState) UnmarshalJSON(data []byte) error {
+	migrated, from, err := models.MigrateSession(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(migrated, (*sessionStateJSON)(s)); err != nil {
+		return err
+	}
+	if from != models.SchemaVersion {
+		s.migratedFrom = &from
+	}
+	return nil
 }
 
 // initHistory initializes the History field from HistoryItems.
@@ -59,6 +59,8 @@ const (
 	AgentRoleWorker       AgentRole = "worker"
 	AgentRoleExplorer     AgentRole = "explorer"
 	AgentRolePlanner      AgentRole = "planner"
+	AgentRoleReviewer     AgentRole = "reviewer"
+	AgentRoleTester       AgentRole = "tester"
 )
 
 // parseAgentRole converts a string to AgentRole, defaulting to AgentRoleDefault.
@@ -72,6 +74,10 @@ func parseAgentRole(s string) AgentRole {
 		return AgentRoleExplorer
 	case "planner":
 		return AgentRolePlanner
+	case "reviewer":
+		return AgentRoleReviewer
+	case "tester":
+		return AgentRoleTester
 	default:
 		return AgentRoleDefault
 	}
@@ -85,6 +91,7 @@ func parseAgentRole(s string) AgentRole {
 type AgentStatus string
 
 const (
+	AgentStatusQueued      AgentStatus = "queued" // Harness session waiting for a free slot
 	AgentStatusPendingInit AgentStatus = "pending_init"
 	AgentStatusRunning     AgentStatus = "running"
 	AgentStatusCompleted   AgentStatus = "completed"
@@ -116,6 +123,10 @@ type AgentInfo struct {
 	Status      AgentStatus `json:"status"`
 	FinalOutput string      `json:"final_output,omitempty"` // Last assistant message from child
 	TaskMessage string      `json:"task_message"`           // Original spawn message
+	FanOutID    string      `json:"fan_out_id,omitempty"`   // Call ID of the fan_out that spawned it
+
+	// Result is the structured result the child reported via emit_result.
+	Result json.RawMessage `json:"result,omitempty"`
 }
 
 // ---------------------------------------------------------------------------
@@ -174,6 +185,7 @@ var collabToolNames = map[string]bool{
 	"wait":         true,
 	"close_agent":  true,
 	"resume_agent": true,
+	"fan_out":      true,
 }
 
 // isCollabToolCall returns true if the tool name is a collaboration tool.
@@ -268,6 +280,8 @@ func (s *SessionState) handleCollabToolCall(ctx workflow.Context, ctrl *LoopCont
 		return s.handleCloseAgent(ctx, fc)
 	case "resume_agent":
 		return s.handleResumeAgent(ctx, fc)
+	case "fan_out":
+		return s.handleFanOut(ctx, ctrl, fc)
 	default:
 		return collabErrorOutput(fc.CallID, fmt.Sprintf("unknown collab tool: %s", fc.Name)), nil
 	}
@@ -283,9 +297,10 @@ func (s *SessionState) handleSpawnAgent(ctx workflow.Context, fc models.Conversa
 
 	// Parse arguments
 	var args struct {
-		Message   *string          `json:"message"`
-		Items     []collabInputItem `json:"items"`
-		AgentType string           `json:"agent_type"`
+		Message      *string                `json:"message"`
+		Items        []collabInputItem      `json:"items"`
+		AgentType    string                 `json:"agent_type"`
+		OutputSchema map[string]interface{} `json:"output_schema"`
 	}
 	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
 		return collabErrorOutput(fc.CallID, fmt.Sprintf("invalid arguments: %v", err)), nil
@@ -303,47 +318,70 @@ func (s *SessionState) handleSpawnAgent(ctx workflow.Context, fc models.Conversa
 			"cannot spawn agent: maximum nesting depth (%d) exceeded", MaxThreadSpawnDepth)), nil
 	}
 
-	var childInput WorkflowInput
-	var role AgentRole
+	childInput, role, err := s.buildChildInput(args.AgentType, msg, childDepth)
+	if err != nil {
+		return collabErrorOutput(fc.CallID, err.Error()), nil
+	}
+	childInput.ResultSchema = args.OutputSchema
+
+	info, future := s.launchChild(ctx, nextAgentID(ctx), role, msg, childInput)
+	if err := s.awaitChildStarted(ctx, info, future); err != nil {
+		return collabErrorOutput(fc.CallID, err.Error()), nil
+	}
+	agentID := info.AgentID
+
+	logger.Info("Spawned child agent",
+		"agent_id", agentID,
+		"role", role,
+		"child_depth", childDepth,
+		"child_workflow_id", info.WorkflowID)
+
+	// Return success with agent ID
+	return collabSuccessOutput(fc.CallID, map[string]interface{}{
+		"agent_id": agentID,
+	}), nil
+}
 
+// buildChildInput resolves agent_type to a crew agent, built-in role or
+// user-defined role and builds the child's input. Unknown types are rejected.
+func (s *SessionState) buildChildInput(agentType, msg string, childDepth int) (WorkflowInput, AgentRole, error) {
 	// Check if agent_type matches a known crew agent by scanning CrewVisibleAgents.
 	isCrewAgent := false
 	if s.CrewName != "" {
 		for _, ca := range s.CrewVisibleAgents {
-			if ca.Name == args.AgentType {
+			if ca.Name == agentType {
 				isCrewAgent = true
 				break
 			}
 		}
 	}
 
-	if isCrewAgent {
-		role = AgentRole(args.AgentType) // Use crew agent name as role label
-
-		// Build a lightweight child config — the child resolves its own
-		// crew agent definition via ResolveCrewAgent activity at init.
-		childConfig := buildAgentSharedConfig(s.Config, childDepth)
-		// Apply default role overrides (removes request_user_input for one-shot).
-		applyRoleOverrides(&childConfig, AgentRoleDefault)
-
-		childInput = WorkflowInput{
-			ConversationID: "", // Set by parent
-			UserMessage:    msg,
-			Config:         childConfig,
-			Depth:          childDepth,
-			CrewName:       s.CrewName,
-			CrewAgent:      args.AgentType,
-			CrewInputs:     s.CrewInputs,
-		}
-	} else {
-		// Standard role-based spawn (existing path).
-		role = parseAgentRole(args.AgentType)
-		childInput = buildAgentSpawnConfig(s.Config, role, msg, childDepth)
+	if !isCrewAgent {
+		// Built-in or user-defined role; unknown agent_type is rejected.
+		return s.buildRoleSpawnInput(agentType, msg, childDepth)
 	}
 
-	agentID := nextAgentID(ctx)
+	// Build a lightweight child config — the child resolves its own
+	// crew agent definition via ResolveCrewAgent activity at init.
+	childConfig := buildAgentSharedConfig(s.Config, childDepth)
+	// Apply default role overrides (removes request_user_input for one-shot).
+	applyRoleOverrides(&childConfig, AgentRoleDefault)
+
+	return WorkflowInput{
+		ConversationID: "", // Set by parent
+		UserMessage:    msg,
+		Config:         childConfig,
+		Depth:          childDepth,
+		CrewName:       s.CrewName,
+		CrewAgent:      agentType,
+		CrewInputs:     s.CrewInputs,
+	}, AgentRole(agentType), nil // Crew agent name as role label
+}
 
-	// Register agent info before starting the child
+// launchChild registers the agent and starts its child workflow without
+// waiting for the start to be acknowledged, so several children can be
+// launched together. Pair it with awaitChildStarted.
+func (s *SessionState) launchChild(ctx workflow.Context, agentID string, role AgentRole, msg string, input WorkflowInput) (*AgentInfo, workflow.ChildWorkflowFuture) {
 	info := &AgentInfo{
 		AgentID:     agentID,
 		Role:        role,
@@ -352,40 +390,29 @@ func (s *SessionState) handleSpawnAgent(ctx workflow.Context, fc models.Conversa
 	}
 	s.AgentCtl.Agents[agentID] = info
 
-	// Start child workflow
 	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 		WorkflowID: s.ConversationID + "/" + agentID,
 	})
+	return info, workflow.ExecuteChildWorkflow(childCtx, "AgenticWorkflow", input)
+}
 
-	future := workflow.ExecuteChildWorkflow(childCtx, "AgenticWorkflow", childInput)
-
-	// Get the child workflow execution info (workflow ID, run ID)
+// awaitChildStarted waits for a launched child to start, records its
+// execution and starts watching for its completion.
+func (s *SessionState) awaitChildStarted(ctx workflow.Context, info *AgentInfo, future workflow.ChildWorkflowFuture) error {
 	var childExec workflow.Execution
 	if err := future.GetChildWorkflowExecution().Get(ctx, &childExec); err != nil {
 		info.Status = AgentStatusErrored
-		return collabErrorOutput(fc.CallID, fmt.Sprintf("failed to start child workflow: %v", err)), nil
+		info.FinalOutput = fmt.Sprintf("failed to start child workflow: %v", err)
+		return fmt.Errorf("failed to start child workflow: %w", err)
 	}
 
 	info.WorkflowID = childExec.ID
 	info.RunID = childExec.RunID
 	info.Status = AgentStatusRunning
 
-	// Store the future for later awaiting
-	s.AgentCtl.childFutures[agentID] = future
-
-	// Start a goroutine to watch for child completion
-	s.startChildCompletionWatcher(ctx, agentID, future)
-
-	logger.Info("Spawned child agent",
-		"agent_id", agentID,
-		"role", role,
-		"child_depth", childDepth,
-		"child_workflow_id", childExec.ID)
-
-	// Return success with agent ID
-	return collabSuccessOutput(fc.CallID, map[string]interface{}{
-		"agent_id": agentID,
-	}), nil
+	s.AgentCtl.childFutures[info.AgentID] = future
+	s.startChildCompletionWatcher(ctx, info.AgentID, future)
+	return nil
 }
 
 // applyCrewToolSpecs modifies the agent's ToolSpecs based on crew agent visibility.
@@ -529,6 +556,9 @@ func (s *SessionState) handleWait(ctx workflow.Context, ctrl *LoopControl, fc mo
 		if info.FinalOutput != "" {
 			entry["final_output"] = info.FinalOutput
 		}
+		if len(info.Result) > 0 {
+			entry["result"] = info.Result
+		}
 		statusMap[id] = entry
 	}
 
@@ -569,20 +599,7 @@ func (s *SessionState) handleCloseAgent(ctx workflow.Context, fc models.Conversa
 		}), nil
 	}
 
-	// Signal shutdown
-	err := workflow.SignalExternalWorkflow(ctx, info.WorkflowID, info.RunID, SignalAgentShutdown, nil).Get(ctx, nil)
-	if err != nil {
-		logger.Warn("Failed to signal shutdown to child agent", "agent_id", args.ID, "error", err)
-	}
-
-	// Wait briefly for the child to finish
-	_, _ = workflow.AwaitWithTimeout(ctx, closeAgentGracePeriod, func() bool {
-		return info.Status.isTerminal()
-	})
-
-	if !info.Status.isTerminal() {
-		info.Status = AgentStatusShutdown
-	}
+	s.shutdownChildren(ctx, []*AgentInfo{info})
 
 	logger.Info("Closed child agent", "agent_id", args.ID, "status", info.Status)
 
@@ -593,9 +610,49 @@ func (s *SessionState) handleCloseAgent(ctx workflow.Context, fc models.Conversa
 	if info.FinalOutput != "" {
 		result["final_output"] = info.FinalOutput
 	}
+	if len(info.Result) > 0 {
+		result["result"] = info.Result
+	}
 	return collabSuccessOutput(fc.CallID, result), nil
 }
 
+// shutdownChildren signals shutdown to the given running children, waits
+// briefly for them to finish, and marks any still running as shut down.
+func (s *SessionState) shutdownChildren(ctx workflow.Context, infos []*AgentInfo) {
+	logger := workflow.GetLogger(ctx)
+
+	var running []*AgentInfo
+	for _, info := range infos {
+		if info.Status.isTerminal() {
+			continue
+		}
+		err := workflow.SignalExternalWorkflow(ctx, info.WorkflowID, info.RunID, SignalAgentShutdown, nil).Get(ctx, nil)
+		if err != nil {
+			logger.Warn("Failed to signal shutdown to child agent", "agent_id", info.AgentID, "error", err)
+		}
+		running = append(running, info)
+	}
+	if len(running) == 0 {
+		return
+	}
+
+	// Wait briefly for the children to finish
+	_, _ = workflow.AwaitWithTimeout(ctx, closeAgentGracePeriod, func() bool {
+		for _, info := range running {
+			if !info.Status.isTerminal() {
+				return false
+			}
+		}
+		return true
+	})
+
+	for _, info := range running {
+		if !info.Status.isTerminal() {
+			info.Status = AgentStatusShutdown
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // handleResumeAgent — not yet implemented.
 // Maps to: codex-rs/core/src/agent/collab.rs handle_resume_agent
@@ -625,6 +682,7 @@ func (s *SessionState) startChildCompletionWatcher(ctx workflow.Context, agentID
 		} else {
 			info.Status = AgentStatusCompleted
 			info.FinalOutput = result.FinalMessage
+			info.Result = result.StructuredResult
 		}
 	})
 }
@@ -654,6 +712,11 @@ func buildAgentSharedConfig(parentConfig models.SessionConfiguration, depth int)
 	cfg := parentConfig
 	cfg.Tools.EnabledTools = append([]string(nil), parentConfig.Tools.EnabledTools...)
 
+	// Verification and peer review belong to the session that owns the
+	// task; children would otherwise each rerun them when they finish.
+	cfg.AutoVerifyCommand = ""
+	cfg.Review = models.PeerReview{}
+
 	// Children at max depth cannot spawn further children
 	if depth >= MaxThreadSpawnDepth {
 		cfg.Tools.RemoveTools("collab")
@@ -672,29 +735,40 @@ func applyRoleOverrides(cfg *models.SessionConfiguration, role AgentRole) {
 	case AgentRoleExplorer:
 		// Explorer: cheaper model, medium reasoning, read-only tools, one-shot.
 		cfg.Model.ReasoningEffort = models.ReasoningEffortMedium
-		cfg.Tools.RemoveTools("write_file", "apply_patch", "request_user_input")
+		cfg.Tools.RestrictTools(readOnlyRoleTools...)
+		cfg.Tools.McpReadOnly = true
+		appendDeveloperInstructions(cfg, instructions.ExplorerRoleInstructions)
 		// Override to cheaper model for OpenAI providers
 		if cfg.Model.Provider == "openai" {
 			cfg.Model.Model = ExplorerModel
 		}
-		// Keep read tools: shell (for read commands), read_file, list_dir, grep_files
 	case AgentRolePlanner:
 		// Planner: read-only tools, no collab, keeps user interaction.
 		// The planner explores the codebase and produces a plan without modifications.
-		// Keeps request_user_input — planners may ask clarifying questions.
-		cfg.Tools.RemoveTools("write_file", "apply_patch", "collab")
+		// Keeps request_user_input and ask_user — planners may ask clarifying questions.
+		cfg.Tools.RestrictTools(append([]string{"request_user_input", "ask_user", "update_plan"}, readOnlyRoleTools...)...)
+		cfg.Tools.McpReadOnly = true
 		// Replace base instructions with planner-specific prompt
 		cfg.BaseInstructions = instructions.PlannerBaseInstructions
+	case AgentRoleReviewer:
+		// Reviewer: read-only tools (git diff via shell), one-shot.
+		cfg.Tools.RestrictTools(readOnlyRoleTools...)
+		cfg.Tools.McpReadOnly = true
+		appendDeveloperInstructions(cfg, instructions.ReviewerRoleInstructions)
+	case AgentRoleTester:
+		// Tester: read tools plus file edits for tests, one-shot.
+		cfg.Tools.RestrictTools(append([]string{"write_file", "apply_patch", "update_plan"}, readOnlyRoleTools...)...)
+		appendDeveloperInstructions(cfg, instructions.TesterRoleInstructions)
 	case AgentRoleOrchestrator:
 		// Orchestrator: coordination focus, no write tools, one-shot.
-		cfg.Tools.RemoveTools("write_file", "apply_patch", "request_user_input")
+		cfg.Tools.RemoveTools("write_file", "apply_patch", "request_user_input", "ask_user")
 		cfg.BaseInstructions = instructions.OrchestratorBaseInstructions
 	case AgentRoleWorker:
 		// Worker: full tool access, one-shot (no user interaction).
-		cfg.Tools.RemoveTools("request_user_input")
+		cfg.Tools.RemoveTools("request_user_input", "ask_user")
 	case AgentRoleDefault:
 		// Default: one-shot (no user interaction).
-		cfg.Tools.RemoveTools("request_user_input")
+		cfg.Tools.RemoveTools("request_user_input", "ask_user")
 	}
 }
 
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,8 @@ func TestParseAgentRole(t *testing.T) {
 		{"worker", AgentRoleWorker},
 		{"explorer", AgentRoleExplorer},
 		{"planner", AgentRolePlanner},
+		{"reviewer", AgentRoleReviewer},
+		{"tester", AgentRoleTester},
 		{"", AgentRoleDefault},
 		{"unknown", AgentRoleDefault},
 	}
@@ -89,7 +92,7 @@ func TestAgentControl_HasActiveChildren(t *testing.T) {
 }
 
 func TestIsCollabToolCall(t *testing.T) {
-	collabTools := []string{"spawn_agent", "send_input", "wait", "close_agent", "resume_agent"}
+	collabTools := []string{"spawn_agent", "send_input", "wait", "close_agent", "resume_agent", "fan_out"}
 	for _, name := range collabTools {
 		assert.True(t, isCollabToolCall(name), "should be collab tool: %s", name)
 	}
@@ -211,6 +214,7 @@ func TestApplyRoleOverrides(t *testing.T) {
 		assert.True(t, cfg.Tools.HasTool("read_file"), "explorer keeps read_file")
 		assert.True(t, cfg.Tools.HasTool("list_dir"), "explorer keeps list_dir")
 		assert.True(t, cfg.Tools.HasTool("grep_files"), "explorer keeps grep_files")
+		assert.True(t, cfg.Tools.McpReadOnly, "explorer keeps only read-only MCP tools")
 		assert.Equal(t, ExplorerModel, cfg.Model.Model, "explorer on openai should use cheaper model")
 	})
 
@@ -287,11 +291,88 @@ func TestApplyRoleOverrides(t *testing.T) {
 		assert.True(t, cfg.Tools.HasTool("read_file"), "planner keeps read_file")
 		assert.True(t, cfg.Tools.HasTool("list_dir"), "planner keeps list_dir")
 		assert.True(t, cfg.Tools.HasTool("grep_files"), "planner keeps grep_files")
+		assert.True(t, cfg.Tools.McpReadOnly, "planner keeps only read-only MCP tools")
 		assert.NotEqual(t, "original instructions", cfg.BaseInstructions,
 			"planner should have custom base instructions")
 		assert.Contains(t, cfg.BaseInstructions, "planning agent",
 			"planner instructions should mention planning")
 	})
+
+	t.Run("reviewer: read-only, one-shot, reviewer instructions", func(t *testing.T) {
+		cfg := models.SessionConfiguration{
+			Tools: models.ToolsConfig{
+				EnabledTools: allTools(),
+			},
+			DeveloperInstructions: "project rules",
+		}
+		applyRoleOverrides(&cfg, AgentRoleReviewer)
+		assert.False(t, cfg.Tools.HasTool("write_file"), "reviewer should not write")
+		assert.False(t, cfg.Tools.HasTool("apply_patch"), "reviewer should not patch")
+		assert.False(t, cfg.Tools.HasTool("spawn_agent"), "reviewer should not spawn children")
+		assert.False(t, cfg.Tools.HasTool("request_user_input"), "reviewer is one-shot")
+		assert.True(t, cfg.Tools.HasTool("shell_command"), "reviewer keeps shell for git diff")
+		assert.True(t, cfg.Tools.HasTool("read_file"))
+		assert.True(t, strings.HasPrefix(cfg.DeveloperInstructions, "project rules\n\n"),
+			"inherited developer instructions are kept")
+		assert.Contains(t, cfg.DeveloperInstructions, "# Role: reviewer")
+	})
+
+	t.Run("tester: can write tests, no collab, one-shot", func(t *testing.T) {
+		cfg := models.SessionConfiguration{
+			Tools: models.ToolsConfig{
+				EnabledTools: allTools(),
+			},
+		}
+		applyRoleOverrides(&cfg, AgentRoleTester)
+		assert.True(t, cfg.Tools.HasTool("write_file"), "tester writes test files")
+		assert.True(t, cfg.Tools.HasTool("apply_patch"), "tester edits test files")
+		assert.True(t, cfg.Tools.HasTool("shell_command"), "tester runs tests")
+		assert.False(t, cfg.Tools.HasTool("spawn_agent"), "tester should not spawn children")
+		assert.False(t, cfg.Tools.HasTool("request_user_input"), "tester is one-shot")
+		assert.False(t, cfg.Tools.McpReadOnly, "tester keeps every MCP tool")
+		assert.Contains(t, cfg.DeveloperInstructions, "# Role: tester")
+	})
+
+	t.Run("role allow-list never adds tools the parent lacks", func(t *testing.T) {
+		cfg := models.SessionConfiguration{
+			Tools: models.ToolsConfig{
+				EnabledTools: []string{"read_file"},
+			},
+		}
+		applyRoleOverrides(&cfg, AgentRoleTester)
+		assert.Equal(t, []string{"read_file"}, cfg.Tools.EnabledTools)
+	})
+}
+
+func TestDropWritableMcpTools(t *testing.T) {
+	newState := func(readOnly bool) *SessionState {
+		return &SessionState{
+			Config: models.SessionConfiguration{Tools: models.ToolsConfig{McpReadOnly: readOnly}},
+			ToolSpecs: []tools.ToolSpec{
+				{Name: "read_file"},
+				{Name: "mcp__docs__search"},
+				{Name: "mcp__tracker__create_issue"},
+			},
+			McpToolLookup: map[string]tools.McpToolRef{
+				"mcp__docs__search":          {ServerName: "docs", ToolName: "search", ReadOnly: true},
+				"mcp__tracker__create_issue": {ServerName: "tracker", ToolName: "create_issue"},
+			},
+		}
+	}
+
+	s := newState(true)
+	assert.Equal(t, []string{"mcp__tracker__create_issue"}, s.dropWritableMcpTools())
+	names := make([]string, len(s.ToolSpecs))
+	for i, spec := range s.ToolSpecs {
+		names[i] = spec.Name
+	}
+	assert.Equal(t, []string{"read_file", "mcp__docs__search"}, names)
+	assert.Contains(t, s.McpToolLookup, "mcp__docs__search")
+	assert.NotContains(t, s.McpToolLookup, "mcp__tracker__create_issue")
+
+	s = newState(false)
+	assert.Empty(t, s.dropWritableMcpTools())
+	assert.Len(t, s.ToolSpecs, 3)
 }
 
 func TestBuildToolSpecs_WithCollabTools(t *testing.T) {
@@ -650,7 +731,7 @@ func TestCollabToolSpecs(t *testing.T) {
 		spec := tools.NewSpawnAgentToolSpec()
 		assert.Equal(t, "spawn_agent", spec.Name)
 		assert.NotEmpty(t, spec.Description)
-		assert.Len(t, spec.Parameters, 3) // message, items, agent_type
+		assert.Len(t, spec.Parameters, 4) // message, items, agent_type, output_schema
 
 		paramNames := make([]string, len(spec.Parameters))
 		for i, p := range spec.Parameters {
@@ -659,6 +740,7 @@ func TestCollabToolSpecs(t *testing.T) {
 		assert.Contains(t, paramNames, "message")
 		assert.Contains(t, paramNames, "items")
 		assert.Contains(t, paramNames, "agent_type")
+		assert.Contains(t, paramNames, "output_schema")
 
 		// message should NOT be required (either message or items)
 		for _, p := range spec.Parameters {
@@ -733,6 +815,23 @@ func TestCollabToolSpecs(t *testing.T) {
 		assert.Len(t, spec.Parameters, 1) // id
 		assert.True(t, spec.Parameters[0].Required)
 	})
+
+	t.Run("fan_out spec", func(t *testing.T) {
+		spec := tools.NewFanOutToolSpec()
+		assert.Equal(t, "fan_out", spec.Name)
+		assert.Len(t, spec.Parameters, 4) // tasks, agent_type, output_schema, timeout_ms
+
+		for _, p := range spec.Parameters {
+			switch p.Name {
+			case "tasks":
+				assert.True(t, p.Required)
+				assert.Equal(t, "array", p.Type)
+				assert.NotNil(t, p.Items)
+			default:
+				assert.False(t, p.Required, p.Name)
+			}
+		}
+	})
 }
 
 // ---------------------------------------------------------------------------
@@ -1,6 +1,7 @@
 // Package workflow contains Temporal workflow definitions.
 //
-// suggestions.go implements post-turn prompt suggestion generation.
+// suggestions.go implements post-turn prompt suggestion generation, off the
+// critical path of turns.
 package workflow
 
 import (
@@ -14,31 +15,98 @@ import (
 	"github.com/mfateev/temporal-agent-harness/internal/models"
 )
 
-// generateSuggestion runs the GenerateSuggestions activity synchronously to
-// populate ctrl.suggestion. Called after TurnComplete marker is added but before
-// the next awaitWithIdleTimeout. The CLI has already seen the TurnComplete via
-// polling and can show the input prompt; the suggestion appears ~300-500ms later
-// when the CLI's delayed poll picks it up.
+// suggestionPriorityKey is the task queue priority of GenerateSuggestions:
+// the lowest of the default five levels, so suggestions never delay the
+// activities of a turn.
+const suggestionPriorityKey = 5
+
+// SuggestionUsage is what a session's prompt suggestions cost. It is kept
+// apart from the session's token totals so the cost of suggestions shows up
+// on its own in status and usage reports.
+type SuggestionUsage struct {
+	Model       string `json:"model,omitempty"` // The cheap model that generated them
+	Calls       int    `json:"calls"`
+	Cancelled   int    `json:"cancelled,omitempty"` // Calls cut short by a new message
+	TotalTokens int    `json:"total_tokens"`
+	InputTokens int    `json:"input_tokens"`
+}
+
+// Tokens returns the suggestions' total tokens; 0 for a nil usage.
+func (u *SuggestionUsage) Tokens() int {
+	if u == nil {
+		return 0
+	}
+	return u.TotalTokens
+}
+
+// startSuggestion generates the post-turn prompt suggestion in the
+// background: the loop goes on to wait for input at once, and the CLI picks
+// the suggestion up when it arrives. The activity runs at the lowest task
+// queue priority, is cancelled when a new message arrives (see
+// LoopControl.SetPendingUserInput), and stops once the session's suggestion
+// token budget is spent.
 //
 // Best-effort: errors are silently ignored.
-func (s *SessionState) generateSuggestion(ctx workflow.Context, ctrl *LoopControl) {
+func (s *SessionState) startSuggestion(ctx workflow.Context, ctrl *LoopControl) {
+	if s.suggestionBudgetSpent() {
+		return
+	}
 	input := s.buildSuggestionInput()
 	if input == nil {
 		return
 	}
 
-	suggCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
-		StartToCloseTimeout: 5 * time.Second,
+	suggCtx, gen := ctrl.BeginSuggestion(ctx)
+	suggCtx = workflow.WithActivityOptions(suggCtx, workflow.ActivityOptions{
+		ScheduleToCloseTimeout: 10 * time.Second, // Includes waiting behind higher-priority tasks
+		StartToCloseTimeout:    5 * time.Second,
 		RetryPolicy: &temporal.RetryPolicy{
 			MaximumAttempts: 1, // No retries — best-effort
 		},
 	})
+	suggCtx = workflow.WithPriority(suggCtx, temporal.Priority{PriorityKey: suggestionPriorityKey})
 
-	var out activities.SuggestionOutput
-	err := workflow.ExecuteActivity(suggCtx, "GenerateSuggestions", *input).Get(ctx, &out)
-	if err == nil && out.Suggestion != "" {
-		ctrl.SetSuggestion(out.Suggestion)
+	workflow.Go(suggCtx, func(gctx workflow.Context) {
+		var out activities.SuggestionOutput
+		err := workflow.ExecuteActivity(gctx, "GenerateSuggestions", *input).Get(gctx, &out)
+		s.recordSuggestionUsage(input.ModelConfig, out.TokenUsage, temporal.IsCanceledError(err))
+		if err == nil && out.Suggestion != "" && ctrl.SuggestionWanted(gen) {
+			ctrl.SetSuggestion(out.Suggestion)
+		}
+	})
+}
+
+// suggestionBudgetSpent reports whether suggestions have used up the
+// session's SuggestionTokenBudget.
+func (s *SessionState) suggestionBudgetSpent() bool {
+	budget := s.Config.SuggestionTokenBudget
+	if budget <= 0 {
+		budget = models.DefaultSuggestionTokenBudget
+	}
+	return s.SuggestionUsage.TotalTokens >= budget
+}
+
+// recordSuggestionUsage adds a suggestion call to the session's
+// SuggestionUsage.
+func (s *SessionState) recordSuggestionUsage(cfg models.ModelConfig, usage models.TokenUsage, cancelled bool) {
+	u := &s.SuggestionUsage
+	u.Model = cfg.Model
+	u.Calls++
+	if cancelled {
+		u.Cancelled++
+	}
+	u.TotalTokens += usage.TotalTokens
+	u.InputTokens += inputTokens(cfg.Provider, usage)
+}
+
+// suggestionUsage returns the session's SuggestionUsage, or nil before the
+// first suggestion.
+func (s *SessionState) suggestionUsage() *SuggestionUsage {
+	if s.SuggestionUsage.Calls == 0 {
+		return nil
 	}
+	u := s.SuggestionUsage
+	return &u
 }
 
 // buildSuggestionInput extracts the last user message, last assistant message,
@@ -0,0 +1,184 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// tags.go implements session tags and notes: mutable, user-assigned metadata
+// for organizing many sessions. Tags live in SessionState and are mirrored to
+// the workflow memo (read by the session picker) and optionally to the
+// AgentTags search attribute (for visibility queries like
+// `AgentTags = 'backend'`).
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+)
+
+const (
+	// maxSessionTags caps how many tags a session can carry.
+	maxSessionTags = 20
+	// maxTagLen caps the length of a single tag.
+	maxTagLen = 64
+	// maxNoteLen caps the length of the session note.
+	maxNoteLen = 500
+
+	// MemoKeyTags and MemoKeyNote are the workflow memo keys tags and the
+	// note are published under.
+	MemoKeyTags = "tags"
+	MemoKeyNote = "note"
+)
+
+// TagsSearchAttribute is the KeywordList search attribute session tags are
+// indexed under when SessionConfiguration.IndexSessionTags is set. Register
+// it with:
+//
+//	temporal operator search-attribute create --name AgentTags --type KeywordList
+var TagsSearchAttribute = temporal.NewSearchAttributeKeyKeywordList("AgentTags")
+
+// normalizeTag lower-cases and validates a tag. Tags may contain letters,
+// digits and - _ . : / so they are safe to use unquoted in visibility queries.
+func normalizeTag(tag string) (string, error) {
+	t := strings.ToLower(strings.TrimSpace(tag))
+	if t == "" {
+		return "", fmt.Errorf("tag must not be empty")
+	}
+	if len(t) > maxTagLen {
+		return "", fmt.Errorf("tag %q exceeds %d characters", t, maxTagLen)
+	}
+	for _, r := range t {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case strings.ContainsRune("-_.:/", r):
+		default:
+			return "", fmt.Errorf("tag %q contains invalid character %q", t, r)
+		}
+	}
+	return t, nil
+}
+
+// applyTagEdit returns the tags and note that result from applying req to
+// the current session metadata, without mutating state. Used by both the
+// Update validator and handler.
+func (s *SessionState) applyTagEdit(req SetSessionTagsRequest) ([]string, string, error) {
+	if len(req.Add) == 0 && len(req.Remove) == 0 && req.Note == nil {
+		return nil, "", fmt.Errorf("nothing to change: specify tags to add or remove, or a note")
+	}
+
+	set := make(map[string]bool, len(s.Tags)+len(req.Add))
+	for _, t := range s.Tags {
+		set[t] = true
+	}
+	for _, raw := range req.Add {
+		t, err := normalizeTag(raw)
+		if err != nil {
+			return nil, "", err
+		}
+		set[t] = true
+	}
+	for _, raw := range req.Remove {
+		t, err := normalizeTag(raw)
+		if err != nil {
+			return nil, "", err
+		}
+		delete(set, t)
+	}
+	if len(set) > maxSessionTags {
+		return nil, "", fmt.Errorf("too many tags (%d, max %d)", len(set), maxSessionTags)
+	}
+
+	tags := make([]string, 0, len(set))
+	for t := range set {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+
+	note := s.Note
+	if req.Note != nil {
+		note = strings.TrimSpace(*req.Note)
+		if len(note) > maxNoteLen {
+			return nil, "", fmt.Errorf("note exceeds %d characters", maxNoteLen)
+		}
+	}
+	return tags, note, nil
+}
+
+// publishSessionMetadata mirrors tags and note to the workflow memo and, when
+// enabled, the AgentTags search attribute.
+func (s *SessionState) publishSessionMetadata(ctx workflow.Context) error {
+	if err := workflow.UpsertMemo(ctx, map[string]interface{}{
+		MemoKeyTags: s.Tags,
+		MemoKeyNote: s.Note,
+	}); err != nil {
+		return fmt.Errorf("upsert memo: %w", err)
+	}
+	if !s.Config.IndexSessionTags || !s.searchAttributeRegistered(ctx, TagsSearchAttribute.GetName()) {
+		return nil
+	}
+	update := TagsSearchAttribute.ValueSet(s.Tags)
+	if len(s.Tags) == 0 {
+		update = TagsSearchAttribute.ValueUnset()
+	}
+	if err := workflow.UpsertTypedSearchAttributes(ctx, update); err != nil {
+		return fmt.Errorf("upsert search attributes: %w", err)
+	}
+	return nil
+}
+
+// searchAttributeRegistered reports whether the named search attribute is
+// registered in the session's namespace. Upserting one that is not fails the
+// workflow task on every retry, so indexing is skipped with a warning
+// instead. The answer is cached for the rest of the run; when the check
+// itself fails the attribute is treated as unregistered.
+func (s *SessionState) searchAttributeRegistered(ctx workflow.Context, name string) bool {
+	if registered, ok := s.searchAttrs[name]; ok {
+		return registered
+	}
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	})
+	var out activities.CheckSearchAttributesOutput
+	err := workflow.ExecuteActivity(actCtx, "CheckSearchAttributes", activities.CheckSearchAttributesInput{
+		Names: []string{name},
+	}).Get(ctx, &out)
+	registered := err == nil && len(out.Missing) == 0
+	switch {
+	case err != nil:
+		workflow.GetLogger(ctx).Warn("Cannot check search attribute; not indexing", "name", name, "error", err)
+	case !registered:
+		workflow.GetLogger(ctx).Warn("Search attribute is not registered; not indexing", "name", name)
+	}
+	if s.searchAttrs == nil {
+		s.searchAttrs = map[string]bool{}
+	}
+	s.searchAttrs[name] = registered
+	return registered
+}
+
+// SessionMetadataFromMemo decodes tags and note from a workflow memo as
+// returned by visibility APIs. Missing or undecodable fields are left empty.
+// A nil dc uses the SDK default data converter.
+func SessionMetadataFromMemo(memo *commonpb.Memo, dc converter.DataConverter) (tags []string, note string) {
+	if dc == nil {
+		dc = converter.GetDefaultDataConverter()
+	}
+	fields := memo.GetFields()
+	if p, ok := fields[MemoKeyTags]; ok {
+		_ = dc.FromPayload(p, &tags)
+	}
+	if p, ok := fields[MemoKeyNote]; ok {
+		_ = dc.FromPayload(p, &note)
+	}
+	return tags, note
+}
@@ -0,0 +1,245 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for session tag editing
+// ---------------------------------------------------------------------------
+
+func TestNormalizeTag(t *testing.T) {
+	tag, err := normalizeTag("  Backend ")
+	require.NoError(t, err)
+	assert.Equal(t, "backend", tag)
+
+	tag, err = normalizeTag("team:auth/v2.1_x-y")
+	require.NoError(t, err)
+	assert.Equal(t, "team:auth/v2.1_x-y", tag)
+
+	_, err = normalizeTag("")
+	assert.Error(t, err)
+	_, err = normalizeTag("has space")
+	assert.Error(t, err)
+	_, err = normalizeTag("quote'd")
+	assert.Error(t, err)
+	_, err = normalizeTag(strings.Repeat("a", maxTagLen+1))
+	assert.Error(t, err)
+}
+
+func TestApplyTagEdit_AddRemoveDedupSorted(t *testing.T) {
+	s := &SessionState{Tags: []string{"frontend", "wip"}}
+
+	tags, note, err := s.applyTagEdit(SetSessionTagsRequest{
+		Add:    []string{"Backend", "backend", "auth"},
+		Remove: []string{"WIP"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth", "backend", "frontend"}, tags)
+	assert.Equal(t, "", note)
+	assert.Equal(t, []string{"frontend", "wip"}, s.Tags, "state must not be mutated")
+}
+
+func TestApplyTagEdit_Note(t *testing.T) {
+	s := &SessionState{Note: "old"}
+
+	_, note, err := s.applyTagEdit(SetSessionTagsRequest{Add: []string{"x"}})
+	require.NoError(t, err)
+	assert.Equal(t, "old", note, "nil note leaves it unchanged")
+
+	newNote := "  refactor auth  "
+	_, note, err = s.applyTagEdit(SetSessionTagsRequest{Note: &newNote})
+	require.NoError(t, err)
+	assert.Equal(t, "refactor auth", note)
+
+	empty := ""
+	_, note, err = s.applyTagEdit(SetSessionTagsRequest{Note: &empty})
+	require.NoError(t, err)
+	assert.Equal(t, "", note, "empty note clears it")
+
+	long := strings.Repeat("n", maxNoteLen+1)
+	_, _, err = s.applyTagEdit(SetSessionTagsRequest{Note: &long})
+	assert.Error(t, err)
+}
+
+func TestApplyTagEdit_Errors(t *testing.T) {
+	s := &SessionState{}
+
+	_, _, err := s.applyTagEdit(SetSessionTagsRequest{})
+	assert.ErrorContains(t, err, "nothing to change")
+
+	_, _, err = s.applyTagEdit(SetSessionTagsRequest{Add: []string{"bad tag"}})
+	assert.ErrorContains(t, err, "invalid character")
+
+	many := make([]string, maxSessionTags+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	_, _, err = s.applyTagEdit(SetSessionTagsRequest{Add: many})
+	assert.ErrorContains(t, err, "too many tags")
+}
+
+func TestSessionMetadataFromMemo(t *testing.T) {
+	dc := converter.GetDefaultDataConverter()
+	tagsPayload, err := dc.ToPayload([]string{"auth", "backend"})
+	require.NoError(t, err)
+	notePayload, err := dc.ToPayload("refactor auth")
+	require.NoError(t, err)
+
+	tags, note := SessionMetadataFromMemo(&commonpb.Memo{Fields: map[string]*commonpb.Payload{
+		MemoKeyTags: tagsPayload,
+		MemoKeyNote: notePayload,
+	}}, nil)
+	assert.Equal(t, []string{"auth", "backend"}, tags)
+	assert.Equal(t, "refactor auth", note)
+
+	tags, note = SessionMetadataFromMemo(nil, nil)
+	assert.Nil(t, tags)
+	assert.Equal(t, "", note)
+}
+
+// ---------------------------------------------------------------------------
+// Workflow tests for the set_session_tags Update
+// ---------------------------------------------------------------------------
+
+// TestSessionTags_UpdatePublishesMemo verifies that set_session_tags stores
+// tags and note in state and mirrors them to the workflow memo.
+func (s *AgenticWorkflowTestSuite) TestSessionTags_UpdatePublishesMemo() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("hi", 10), nil).Once()
+
+	s.ignoreTitleMemo()
+	var memo map[string]interface{}
+	s.env.OnUpsertMemo(mock.Anything).Run(func(args mock.Arguments) {
+		memo = args.Get(0).(map[string]interface{})
+	}).Return(nil).Once()
+
+	note := "refactor auth"
+	var resp SetSessionTagsResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateSessionTags, "tags-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("unexpected reject", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(SetSessionTagsResponse)
+			},
+		}, SetSessionTagsRequest{Add: []string{"Backend", "auth"}, Note: &note})
+	}, 5*time.Second)
+
+	s.sendShutdown(10 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), []string{"auth", "backend"}, resp.Tags)
+	assert.Equal(s.T(), "refactor auth", resp.Note)
+	require.NotNil(s.T(), memo)
+	assert.Equal(s.T(), []string{"auth", "backend"}, memo[MemoKeyTags])
+	assert.Equal(s.T(), "refactor auth", memo[MemoKeyNote])
+}
+
+// TestSessionTags_IndexedInSearchAttribute verifies that tags are upserted to
+// the AgentTags search attribute when IndexSessionTags is enabled.
+func (s *AgenticWorkflowTestSuite) TestSessionTags_IndexedInSearchAttribute() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("hi", 10), nil).Once()
+
+	s.env.OnUpsertMemo(mock.Anything).Return(nil)
+	s.env.OnActivity("CheckSearchAttributes", mock.Anything, activities.CheckSearchAttributesInput{Names: []string{"AgentTags"}}).
+		Return(activities.CheckSearchAttributesOutput{}, nil).Once()
+	var indexed []string
+	s.env.OnUpsertTypedSearchAttributes(mock.Anything).Run(func(args mock.Arguments) {
+		sa := args.Get(0).(temporal.SearchAttributes)
+		indexed, _ = sa.GetKeywordList(TagsSearchAttribute)
+	}).Return(nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateSessionTags, "tags-1", noopCallback(),
+			SetSessionTagsRequest{Add: []string{"backend"}})
+	}, 5*time.Second)
+
+	s.sendShutdown(10 * time.Second)
+
+	input := testInput("hello")
+	input.Config.IndexSessionTags = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), []string{"backend"}, indexed)
+}
+
+// TestSessionTags_UnregisteredSearchAttributeSkipped verifies that tags are
+// not upserted to AgentTags when it is not registered, which would otherwise
+// fail the workflow task, and that the attribute is checked only once.
+func (s *AgenticWorkflowTestSuite) TestSessionTags_UnregisteredSearchAttributeSkipped() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("hi", 10), nil).Once()
+
+	var memo map[string]interface{}
+	s.env.OnUpsertMemo(mock.Anything).Run(func(args mock.Arguments) {
+		memo = args.Get(0).(map[string]interface{})
+	}).Return(nil)
+	s.env.OnActivity("CheckSearchAttributes", mock.Anything, mock.Anything).
+		Return(activities.CheckSearchAttributesOutput{Missing: []string{"AgentTags"}}, nil).Once()
+
+	var updateErrs []error
+	edit := func(id string, tag string) {
+		s.env.UpdateWorkflow(UpdateSessionTags, id, &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { s.Fail("update should not be rejected", err.Error()) },
+			OnComplete: func(_ interface{}, err error) { updateErrs = append(updateErrs, err) },
+		}, SetSessionTagsRequest{Add: []string{tag}})
+	}
+	s.env.RegisterDelayedCallback(func() { edit("tags-1", "backend") }, 5*time.Second)
+	s.env.RegisterDelayedCallback(func() { edit("tags-2", "auth") }, 6*time.Second)
+
+	s.sendShutdown(10 * time.Second)
+
+	input := testInput("hello")
+	input.Config.IndexSessionTags = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	assert.Equal(s.T(), []error{nil, nil}, updateErrs)
+	assert.Equal(s.T(), []string{"auth", "backend"}, memo[MemoKeyTags])
+}
+
+// TestSessionTags_InvalidTagRejected verifies the validator rejects bad tags
+// and empty edits without touching the memo.
+func (s *AgenticWorkflowTestSuite) TestSessionTags_InvalidTagRejected() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("hi", 10), nil).Once()
+
+	var rejections []error
+	reject := func(id string, req SetSessionTagsRequest) {
+		s.env.UpdateWorkflow(UpdateSessionTags, id, &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("update should be rejected") },
+			OnReject:   func(err error) { rejections = append(rejections, err) },
+			OnComplete: func(interface{}, error) {},
+		}, req)
+	}
+	s.env.RegisterDelayedCallback(func() {
+		reject("tags-bad", SetSessionTagsRequest{Add: []string{"no spaces"}})
+		reject("tags-empty", SetSessionTagsRequest{})
+	}, 5*time.Second)
+
+	s.sendShutdown(10 * time.Second)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.Len(s.T(), rejections, 2)
+}
@@ -0,0 +1,147 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// tasks.go implements the task list shared between the user and the LLM.
+// Unlike update_plan (agent-only, replaced wholesale), tasks are individual
+// items with stable IDs that either side can add, complete, or remove: the
+// user via the update_task_list Update (/todo), the LLM via the task_list
+// intercepted tool.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// maxTasks caps the task list so it stays readable in the TUI and prompt.
+const maxTasks = 50
+
+// TaskAuthor records who added a task.
+type TaskAuthor string
+
+const (
+	TaskAuthorUser  TaskAuthor = "user"
+	TaskAuthorAgent TaskAuthor = "agent"
+)
+
+// TaskItem is a single entry in the shared task list.
+type TaskItem struct {
+	ID     int        `json:"id"`
+	Text   string     `json:"text"`
+	Done   bool       `json:"done,omitempty"`
+	Author TaskAuthor `json:"author"`
+}
+
+// applyTaskEdit applies an edit to the task list and returns a short
+// description of what changed (e.g. `added #3 "write tests"`).
+func (s *SessionState) applyTaskEdit(req UpdateTaskListRequest, author TaskAuthor) (string, error) {
+	switch req.Action {
+	case TaskListAdd:
+		text := strings.TrimSpace(req.Text)
+		if text == "" {
+			return "", fmt.Errorf("task text must not be empty")
+		}
+		if len(s.Tasks) >= maxTasks {
+			return "", fmt.Errorf("task list is full (%d items); remove some first", maxTasks)
+		}
+		s.TaskCounter++
+		s.Tasks = append(s.Tasks, TaskItem{ID: s.TaskCounter, Text: text, Author: author})
+		return fmt.Sprintf("added #%d %q", s.TaskCounter, text), nil
+
+	case TaskListDone, TaskListUndone:
+		i := s.findTask(req.ID)
+		if i < 0 {
+			return "", fmt.Errorf("no task #%d", req.ID)
+		}
+		s.Tasks[i].Done = req.Action == TaskListDone
+		if s.Tasks[i].Done {
+			return fmt.Sprintf("completed #%d %q", req.ID, s.Tasks[i].Text), nil
+		}
+		return fmt.Sprintf("reopened #%d %q", req.ID, s.Tasks[i].Text), nil
+
+	case TaskListRemove:
+		i := s.findTask(req.ID)
+		if i < 0 {
+			return "", fmt.Errorf("no task #%d", req.ID)
+		}
+		text := s.Tasks[i].Text
+		s.Tasks = append(s.Tasks[:i:i], s.Tasks[i+1:]...)
+		return fmt.Sprintf("removed #%d %q", req.ID, text), nil
+
+	default:
+		return "", fmt.Errorf("unknown task list action %q (must be add, done, undone, or rm)", req.Action)
+	}
+}
+
+// findTask returns the index of the task with the given ID, or -1.
+func (s *SessionState) findTask(id int) int {
+	for i, t := range s.Tasks {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatTaskList renders the task list for the LLM.
+func formatTaskList(tasks []TaskItem) string {
+	if len(tasks) == 0 {
+		return "The task list is empty."
+	}
+	done := 0
+	var b strings.Builder
+	for _, t := range tasks {
+		mark := " "
+		if t.Done {
+			mark = "x"
+			done++
+		}
+		fmt.Fprintf(&b, "#%d [%s] %s (added by %s)\n", t.ID, mark, t.Text, t.Author)
+	}
+	return fmt.Sprintf("Tasks (%d/%d done):\n%s", done, len(tasks), b.String())
+}
+
+// handleTaskList intercepts a task_list tool call. Every call returns the
+// current list, so the LLM also sees edits the user made with /todo.
+func (s *SessionState) handleTaskList(ctx workflow.Context, fc models.ConversationItem) models.ConversationItem {
+	var args struct {
+		Action string `json:"action"`
+		Text   string `json:"text,omitempty"`
+		ID     int    `json:"id,omitempty"`
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), &args); err != nil {
+		return taskListOutput(fc.CallID, fmt.Sprintf("Invalid task_list arguments: %v", err), false)
+	}
+
+	if args.Action == "" || args.Action == "read" {
+		return taskListOutput(fc.CallID, formatTaskList(s.Tasks), true)
+	}
+
+	change, err := s.applyTaskEdit(UpdateTaskListRequest{
+		Action: TaskListAction(args.Action),
+		Text:   args.Text,
+		ID:     args.ID,
+	}, TaskAuthorAgent)
+	if err != nil {
+		return taskListOutput(fc.CallID, fmt.Sprintf("task_list failed: %v\n\n%s", err, formatTaskList(s.Tasks)), false)
+	}
+	workflow.GetLogger(ctx).Info("Task list updated by agent", "change", change)
+	return taskListOutput(fc.CallID, fmt.Sprintf("Task list updated: %s.\n\n%s", change, formatTaskList(s.Tasks)), true)
+}
+
+func taskListOutput(callID, content string, success bool) models.ConversationItem {
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: callID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: content,
+			Success: &success,
+		},
+	}
+}
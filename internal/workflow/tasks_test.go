@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for task list edits
+// ---------------------------------------------------------------------------
+
+func TestApplyTaskEdit_AddDoneRemove(t *testing.T) {
+	s := &SessionState{}
+
+	change, err := s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListAdd, Text: "  write tests "}, TaskAuthorUser)
+	require.NoError(t, err)
+	assert.Equal(t, `added #1 "write tests"`, change)
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListAdd, Text: "fix bug"}, TaskAuthorAgent)
+	require.NoError(t, err)
+	require.Len(t, s.Tasks, 2)
+	assert.Equal(t, TaskItem{ID: 2, Text: "fix bug", Author: TaskAuthorAgent}, s.Tasks[1])
+
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListDone, ID: 1}, TaskAuthorAgent)
+	require.NoError(t, err)
+	assert.True(t, s.Tasks[0].Done)
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListUndone, ID: 1}, TaskAuthorUser)
+	require.NoError(t, err)
+	assert.False(t, s.Tasks[0].Done)
+
+	change, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListRemove, ID: 1}, TaskAuthorUser)
+	require.NoError(t, err)
+	assert.Equal(t, `removed #1 "write tests"`, change)
+	require.Len(t, s.Tasks, 1)
+
+	// IDs are not reused after removal.
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListAdd, Text: "deploy"}, TaskAuthorUser)
+	require.NoError(t, err)
+	assert.Equal(t, 3, s.Tasks[1].ID)
+}
+
+func TestApplyTaskEdit_Errors(t *testing.T) {
+	s := &SessionState{}
+
+	_, err := s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListAdd, Text: "  "}, TaskAuthorUser)
+	assert.ErrorContains(t, err, "must not be empty")
+
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListDone, ID: 7}, TaskAuthorUser)
+	assert.ErrorContains(t, err, "no task #7")
+
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: "archive"}, TaskAuthorUser)
+	assert.ErrorContains(t, err, "unknown task list action")
+
+	for i := 0; i < maxTasks; i++ {
+		_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListAdd, Text: "t"}, TaskAuthorUser)
+		require.NoError(t, err)
+	}
+	_, err = s.applyTaskEdit(UpdateTaskListRequest{Action: TaskListAdd, Text: "one too many"}, TaskAuthorUser)
+	assert.ErrorContains(t, err, "task list is full")
+}
+
+func TestFormatTaskList(t *testing.T) {
+	assert.Equal(t, "The task list is empty.", formatTaskList(nil))
+
+	out := formatTaskList([]TaskItem{
+		{ID: 1, Text: "write tests", Done: true, Author: TaskAuthorUser},
+		{ID: 3, Text: "fix bug", Author: TaskAuthorAgent},
+	})
+	assert.Contains(t, out, "Tasks (1/2 done):")
+	assert.Contains(t, out, "#1 [x] write tests (added by user)")
+	assert.Contains(t, out, "#3 [ ] fix bug (added by agent)")
+}
+
+// TestTaskList_UserAndAgentShareList verifies that a /todo edit is visible
+// to the task_list tool and that the tool's edits show up in TurnStatus.
+func (s *AgenticWorkflowTestSuite) TestTaskList_UserAndAgentShareList() {
+	// Turn 1: plain reply.
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	// Turn 2: the LLM marks the user's task done, then finishes.
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "task_list", Arguments: `{"action": "done", "id": 1}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Once()
+
+	var addErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateTaskList, "todo-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("todo rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				addErr = err
+			},
+		}, UpdateTaskListRequest{Action: TaskListAdd, Text: "update the changelog"})
+	}, 2*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateTaskList, "todo-bad", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.Fail("invalid action should be rejected") },
+			OnReject:   func(err error) {},
+			OnComplete: func(interface{}, error) {},
+		}, UpdateTaskListRequest{Action: "archive"})
+	}, 3*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "do the tasks"})
+	}, 4*time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		require.Len(s.T(), status.Tasks, 1)
+		assert.Equal(s.T(), "update the changelog", status.Tasks[0].Text)
+		assert.Equal(s.T(), TaskAuthorUser, status.Tasks[0].Author)
+		assert.True(s.T(), status.Tasks[0].Done)
+
+		itemsResult, err := s.env.QueryWorkflow(QueryGetConversationItems)
+		require.NoError(s.T(), err)
+		var items []models.ConversationItem
+		require.NoError(s.T(), itemsResult.Get(&items))
+		var sawNote, sawOutput bool
+		for _, item := range items {
+			if item.Type == models.ItemTypeAssistantMessage && item.Content == `[Task list: user added #1 "update the changelog"]` {
+				sawNote = true
+			}
+			if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-1" {
+				sawOutput = true
+				assert.Contains(s.T(), item.Output.Content, "#1 [x] update the changelog")
+			}
+		}
+		assert.True(s.T(), sawNote, "user edit should be noted in history")
+		assert.True(s.T(), sawOutput, "task_list call should have an output")
+	}, 6*time.Second)
+
+	s.sendShutdown(7 * time.Second)
+
+	input := testInput("Hello")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "task_list")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), addErr)
+}
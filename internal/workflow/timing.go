@@ -0,0 +1,123 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// timing.go tracks per-turn timing breakdowns: LLM wall time, tool execution
+// time per call, and time spent waiting on the user (approvals, escalations,
+// request_user_input). All timestamps come from workflow.Now so the numbers
+// are deterministic on replay.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// maxTurnTimings caps how many completed turn timings are retained in
+// SessionState. Older entries are dropped first.
+const maxTurnTimings = 50
+
+// ToolCallTiming records how long a single tool call took.
+type ToolCallTiming struct {
+	CallID   string        `json:"call_id"`
+	ToolName string        `json:"tool_name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// IterationTiming records the timing breakdown of one LLM + tools iteration.
+type IterationTiming struct {
+	Iteration int              `json:"iteration"`
+	Model     string           `json:"model,omitempty"` // Model called
+	Route     string           `json:"route,omitempty"` // Routing rule that picked it, if any
+	LLM       time.Duration    `json:"llm"`
+	Tools     time.Duration    `json:"tools"`   // Wall time of the parallel tool batch
+	Waiting   time.Duration    `json:"waiting"` // Approval / escalation / user-input waits
+	ToolCalls []ToolCallTiming `json:"tool_calls,omitempty"`
+}
+
+// TurnTiming is the timing breakdown for a whole turn.
+// Exposed via the get_turn_timings query and TurnStatus.LastTurnTiming.
+type TurnTiming struct {
+	TurnID     string            `json:"turn_id"`
+	StartedAt  time.Time         `json:"started_at"`
+	Total      time.Duration     `json:"total"`
+	LLM        time.Duration     `json:"llm"`
+	Tools      time.Duration     `json:"tools"`
+	Waiting    time.Duration     `json:"waiting"`
+	Iterations []IterationTiming `json:"iterations,omitempty"`
+}
+
+// beginTurnTiming starts timing a new turn.
+func (s *SessionState) beginTurnTiming(ctx workflow.Context, turnID string) {
+	s.currentTiming = &TurnTiming{
+		TurnID:    turnID,
+		StartedAt: workflow.Now(ctx),
+	}
+}
+
+// iterationTiming returns the timing record for the current iteration,
+// creating it if needed. Returns nil when no turn is being timed.
+func (s *SessionState) iterationTiming() *IterationTiming {
+	t := s.currentTiming
+	if t == nil {
+		return nil
+	}
+	n := len(t.Iterations)
+	if n == 0 || t.Iterations[n-1].Iteration != s.IterationCount {
+		t.Iterations = append(t.Iterations, IterationTiming{Iteration: s.IterationCount})
+		n++
+	}
+	return &t.Iterations[n-1]
+}
+
+// recordLLMTime adds LLM wall time to the current iteration.
+func (s *SessionState) recordLLMTime(d time.Duration) {
+	if it := s.iterationTiming(); it != nil {
+		it.LLM += d
+		s.currentTiming.LLM += d
+	}
+}
+
+// recordToolTime adds a tool batch's wall time and per-call timings to the
+// current iteration.
+func (s *SessionState) recordToolTime(d time.Duration, calls []ToolCallTiming) {
+	if it := s.iterationTiming(); it != nil {
+		it.Tools += d
+		it.ToolCalls = append(it.ToolCalls, calls...)
+		s.currentTiming.Tools += d
+	}
+}
+
+// recordWaitTime adds user wait time (approval, escalation, question) to the
+// current iteration.
+func (s *SessionState) recordWaitTime(d time.Duration) {
+	if it := s.iterationTiming(); it != nil {
+		it.Waiting += d
+		s.currentTiming.Waiting += d
+	}
+}
+
+// finishTurnTiming closes the current turn timing and appends it to
+// TurnTimings, dropping the oldest entries beyond maxTurnTimings.
+func (s *SessionState) finishTurnTiming(ctx workflow.Context) {
+	t := s.currentTiming
+	if t == nil {
+		return
+	}
+	s.currentTiming = nil
+	t.Total = workflow.Now(ctx).Sub(t.StartedAt)
+	s.TurnTimings = append(s.TurnTimings, *t)
+	if len(s.TurnTimings) > maxTurnTimings {
+		s.TurnTimings = s.TurnTimings[len(s.TurnTimings)-maxTurnTimings:]
+	}
+}
+
+// lastTurnTiming returns the most recently completed turn timing, or nil.
+func (s *SessionState) lastTurnTiming() *TurnTiming {
+	if len(s.TurnTimings) == 0 {
+		return nil
+	}
+	t := s.TurnTimings[len(s.TurnTimings)-1]
+	return &t
+}
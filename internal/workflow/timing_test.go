@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for turn timing accumulation
+// ---------------------------------------------------------------------------
+
+func TestTurnTiming_AccumulatesPerIteration(t *testing.T) {
+	s := &SessionState{currentTiming: &TurnTiming{TurnID: "turn-1"}}
+
+	s.recordLLMTime(3 * time.Second)
+	s.recordToolTime(2*time.Second, []ToolCallTiming{{CallID: "c1", ToolName: "shell", Duration: 2 * time.Second}})
+	s.IterationCount++
+	s.recordWaitTime(4 * time.Second)
+	s.recordLLMTime(time.Second)
+
+	tt := s.currentTiming
+	assert.Equal(t, 4*time.Second, tt.LLM)
+	assert.Equal(t, 2*time.Second, tt.Tools)
+	assert.Equal(t, 4*time.Second, tt.Waiting)
+	require.Len(t, tt.Iterations, 2)
+	assert.Equal(t, 0, tt.Iterations[0].Iteration)
+	assert.Len(t, tt.Iterations[0].ToolCalls, 1)
+	assert.Equal(t, 1, tt.Iterations[1].Iteration)
+	assert.Equal(t, 4*time.Second, tt.Iterations[1].Waiting)
+}
+
+func TestTurnTiming_NoCurrentTurnIsNoop(t *testing.T) {
+	s := &SessionState{}
+	s.recordLLMTime(time.Second)
+	s.recordToolTime(time.Second, nil)
+	s.recordWaitTime(time.Second)
+	assert.Nil(t, s.currentTiming)
+	assert.Nil(t, s.lastTurnTiming())
+}
+
+// TestMultiTurn_TurnTimings verifies that LLM and tool time is recorded for a
+// turn and exposed via get_turn_timings and TurnStatus.LastTurnTiming.
+func (s *AgenticWorkflowTestSuite) TestMultiTurn_TurnTimings() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		After(3*time.Second).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "shell_command", Arguments: `{"command": "ls"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		After(2*time.Second).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: "ok", Success: &trueVal}, nil).Once()
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		After(time.Second).
+		Return(mockLLMStopResponse("done", 10), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnTimings)
+		require.NoError(s.T(), err)
+		var timings []TurnTiming
+		require.NoError(s.T(), result.Get(&timings))
+		require.Len(s.T(), timings, 1)
+
+		tt := timings[0]
+		assert.Equal(s.T(), "turn-1", tt.TurnID)
+		assert.Equal(s.T(), 4*time.Second, tt.LLM)
+		assert.Equal(s.T(), 2*time.Second, tt.Tools)
+		assert.Equal(s.T(), time.Duration(0), tt.Waiting)
+		assert.GreaterOrEqual(s.T(), tt.Total, 6*time.Second)
+		require.Len(s.T(), tt.Iterations, 2)
+		require.Len(s.T(), tt.Iterations[0].ToolCalls, 1)
+		assert.Equal(s.T(), "shell_command", tt.Iterations[0].ToolCalls[0].ToolName)
+
+		statusResult, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), statusResult.Get(&status))
+		require.NotNil(s.T(), status.LastTurnTiming)
+		assert.Equal(s.T(), "turn-1", status.LastTurnTiming.TurnID)
+	}, 30*time.Second)
+
+	s.sendShutdown(31 * time.Second)
+
+	input := testInput("list files")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestTurnStatus_ActivityTimers verifies that get_turn_status reports when the
+// running LLM call and tool calls started, with their per-attempt timeouts.
+func (s *AgenticWorkflowTestSuite) TestTurnStatus_ActivityTimers() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		After(3*time.Second).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "shell_command", Arguments: `{"command": "make", "timeout_ms": 120000}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		After(4*time.Second).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: "ok", Success: &trueVal}, nil).Once()
+
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("done", 10), nil).Once()
+
+	queryStatus := func() TurnStatus {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		return status
+	}
+
+	var llmStarted time.Time
+	s.env.RegisterDelayedCallback(func() {
+		status := queryStatus()
+		assert.Equal(s.T(), PhaseLLMCalling, status.Phase)
+		assert.False(s.T(), status.PhaseStartedAt.IsZero())
+		assert.Equal(s.T(), 90*time.Second, status.PhaseTimeout)
+		llmStarted = status.PhaseStartedAt
+	}, time.Second)
+
+	s.env.RegisterDelayedCallback(func() {
+		status := queryStatus()
+		assert.Equal(s.T(), PhaseToolExecuting, status.Phase)
+		assert.True(s.T(), status.PhaseStartedAt.IsZero(), "tool phase is timed per tool")
+		require.Len(s.T(), status.RunningTools, 1)
+		tool := status.RunningTools[0]
+		assert.Equal(s.T(), 3*time.Second, tool.StartedAt.Sub(llmStarted))
+		assert.Equal(s.T(), 120*time.Second+tools.CommandTimeoutGrace, tool.Timeout, "the shell stops the command itself at timeout_ms")
+	}, 5*time.Second)
+
+	s.sendShutdown(10 * time.Second)
+
+	input := testInput("build it")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command")
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
@@ -0,0 +1,93 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// tokens.go sets up the session's token counting. History size estimates
+// (proactive compaction, the context gauge) use the model's tokenizer:
+// tiktoken locally for OpenAI, and for Anthropic exact counts fetched by
+// the CountTokens activity, with the characters/4 heuristic for anything
+// not yet counted.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/tokenizer"
+)
+
+// maxCountTokensBytes caps the text sent to one CountTokens activity, well
+// under Temporal's payload limit. Items beyond it are counted next time.
+const maxCountTokensBytes = 512 << 10
+
+// initTokenCounter points history estimates at the current model's
+// tokenizer and sizes the prompt budget for duplicate tool outputs to its
+// context. Called at workflow start, after ContinueAsNew and when the model
+// changes; exact counts recorded for the previous model are dropped.
+func (s *SessionState) initTokenCounter() {
+	s.tokenCache = tokenizer.NewCache(tokenizer.ForModel(s.Config.Model.Provider, s.Config.Model.Model))
+	s.tokenCountingFailed = false
+	s.History.SetTokenCounter(s.tokenCache)
+	s.History.SetPromptBudget(s.promptBudget())
+}
+
+// promptBudget is the prompt size up to which deduplicated tool outputs are
+// expanded: below proactive compaction, or 90% of the context window when
+// auto-compaction is off. 0 (unknown window) expands them all.
+func (s *SessionState) promptBudget() int {
+	if limit := s.effectiveAutoCompactLimit(); limit > 0 {
+		return limit
+	}
+	return s.Config.Model.ContextWindow * 9 / 10
+}
+
+// refreshExactTokenCounts fetches exact counts for history items not
+// counted yet, for providers without a local tokenizer (Anthropic). A
+// failure is logged and disables further attempts for this run; estimates
+// then fall back to the heuristic.
+func (s *SessionState) refreshExactTokenCounts(ctx workflow.Context) {
+	if s.Config.Model.Provider != "anthropic" || s.tokenCache == nil || s.tokenCountingFailed {
+		return
+	}
+	items, err := s.History.GetRawItems()
+	if err != nil {
+		return
+	}
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = tokenizer.ItemText(item)
+	}
+	missing := s.tokenCache.Missing(texts)
+	size := 0
+	for i, text := range missing {
+		size += len(text)
+		if size > maxCountTokensBytes && i > 0 {
+			missing = missing[:i]
+			break
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	})
+	var out activities.CountTokensOutput
+	err = workflow.ExecuteActivity(actCtx, "CountTokens", activities.CountTokensInput{
+		ModelConfig: s.Config.Model,
+		Texts:       missing,
+	}).Get(ctx, &out)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Token counting failed, estimating instead", "error", err)
+		s.tokenCountingFailed = true
+		return
+	}
+	s.tokenCache.Record(missing, out.Counts)
+}
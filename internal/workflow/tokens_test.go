@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/history"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// anthropicCompactInput returns a test input for an Anthropic session whose
+// proactive compaction limit is far above the heuristic estimate of its
+// first message.
+func anthropicCompactInput() WorkflowInput {
+	input := testInput("Hello")
+	input.Config.Model.Provider = "anthropic"
+	input.Config.Model.Model = "claude-sonnet-4-5-20250929"
+	input.Config.Model.ContextWindow = 200000
+	input.Config.AutoCompactTokenLimit = 1000
+	return input
+}
+
+// TestTokens_ExactAnthropicCountsDriveCompaction verifies that counts from
+// the CountTokens activity, not the heuristic, decide proactive compaction.
+func (s *AgenticWorkflowTestSuite) TestTokens_ExactAnthropicCountsDriveCompaction() {
+	s.newEnv()
+	s.env.OnActivity("CountTokens", mock.Anything, mock.MatchedBy(func(in activities.CountTokensInput) bool {
+		return in.ModelConfig.Provider == "anthropic" && assert.ObjectsAreEqual([]string{"Hello"}, in.Texts)
+	})).Return(activities.CountTokensOutput{Counts: []int{5000}}, nil).Once()
+	s.env.OnActivity("ExecuteCompact", mock.Anything, mock.Anything).
+		Return(activities.CompactActivityOutput{}, errors.New("compaction not configured")).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hi!", 10), nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+	s.env.ExecuteWorkflow(AgenticWorkflow, anthropicCompactInput())
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+// TestTokens_CountingFailureFallsBackToHeuristic verifies a failed count
+// is not retried every iteration and the heuristic estimate is used.
+func (s *AgenticWorkflowTestSuite) TestTokens_CountingFailureFallsBackToHeuristic() {
+	s.env.OnActivity("CountTokens", mock.Anything, mock.Anything).
+		Return(activities.CountTokensOutput{}, temporal.NewNonRetryableApplicationError("no key", "TokenCountingUnavailable", nil)).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "request_user_input",
+				Arguments: `{"questions": []}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hi!", 10), nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+	s.env.ExecuteWorkflow(AgenticWorkflow, anthropicCompactInput())
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+}
+
+func TestInitTokenCounter(t *testing.T) {
+	s := &SessionState{
+		Config:  models.SessionConfiguration{Model: models.ModelConfig{Provider: "openai", Model: "gpt-4o"}},
+		History: history.NewInMemoryHistory(),
+	}
+	require.NoError(t, s.History.AddItem(models.ConversationItem{Type: models.ItemTypeUserMessage, Content: "a b c d e f g h"}))
+
+	s.initTokenCounter()
+	n, _ := s.History.EstimateTokenCount()
+	assert.Equal(t, 8, n, "one o200k_base token per letter")
+
+	s.Config.Model = models.ModelConfig{Provider: "anthropic", Model: "claude-sonnet-4-5-20250929"}
+	s.initTokenCounter()
+	n, _ = s.History.EstimateTokenCount()
+	assert.Equal(t, 3, n, "no local tokenizer: 15 chars / 4")
+
+	s.tokenCache.Record([]string{"a b c d e f g h"}, []int{9})
+	n, _ = s.History.EstimateTokenCount()
+	assert.Equal(t, 9, n, "recorded exact counts win")
+}
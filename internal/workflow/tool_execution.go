@@ -24,9 +24,30 @@ type ToolsExecutor struct {
 	toolSpecs        []tools.ToolSpec
 	cwd              string
 	sessionTaskQueue string
-	// MCP fields for routing mcp__* tool calls.
-	sessionID     string
+	// sessionID keys per-session worker state: MCP connections and the
+	// python_exec kernel.
+	sessionID string
+	// turnID tags tool activities for log correlation.
+	turnID string
+	// mcpToolLookup routes mcp__* tool calls.
 	mcpToolLookup map[string]tools.McpToolRef
+	// cancelRequested reports whether the user cancelled a call (cancel_tool).
+	cancelRequested func(callID string) bool
+	// envPolicy returns the session environment for process-spawning tools.
+	envPolicy func() *tools.EnvPolicyRef
+	// sandboxPolicy returns the session sandbox for process-spawning tools.
+	sandboxPolicy func() *tools.SandboxPolicyRef
+	// browserDomains is the session's allowlist for the browser_* tools.
+	browserDomains []string
+	// readFileLimit is the session's read_file line cap.
+	readFileLimit int
+	// pathRemaps rewrites paths under former working directories.
+	pathRemaps []PathRemap
+	// retryPolicies overrides the tools' built-in retry policies.
+	retryPolicies models.RetryPolicies
+	// previousOutput returns the output of the last identical command in
+	// the turn, for diffing repeated runs.
+	previousOutput func(name string, args map[string]interface{}) string
 }
 
 // NewToolsExecutor creates a ToolsExecutor with the given specs, working directory, and task queue.
@@ -41,34 +62,184 @@ func (e *ToolsExecutor) WithMcpContext(sessionID string, lookup map[string]tools
 	return e
 }
 
+// WithSessionID sets the session ID passed to tools that keep per-session
+// state on the worker.
+func (e *ToolsExecutor) WithSessionID(sessionID string) *ToolsExecutor {
+	e.sessionID = sessionID
+	return e
+}
+
+// WithTurnID sets the turn passed to tool activities for log correlation.
+func (e *ToolsExecutor) WithTurnID(turnID string) *ToolsExecutor {
+	e.turnID = turnID
+	return e
+}
+
+// WithCancellation lets individual calls be cancelled while they run:
+// a call's activity is cancelled once cancelRequested returns true for it.
+func (e *ToolsExecutor) WithCancellation(cancelRequested func(callID string) bool) *ToolsExecutor {
+	e.cancelRequested = cancelRequested
+	return e
+}
+
+// WithEnvPolicy sets the environment policy applied to process-spawning
+// tools. It is read at dispatch so /env changes apply to the next call.
+func (e *ToolsExecutor) WithEnvPolicy(policy func() *tools.EnvPolicyRef) *ToolsExecutor {
+	e.envPolicy = policy
+	return e
+}
+
+// WithSandboxPolicy sets the sandbox policy applied to process-spawning
+// tools. Like the environment policy, it is read at dispatch.
+func (e *ToolsExecutor) WithSandboxPolicy(policy func() *tools.SandboxPolicyRef) *ToolsExecutor {
+	e.sandboxPolicy = policy
+	return e
+}
+
+// WithBrowserDomains sets the domains the browser_* tools may load.
+func (e *ToolsExecutor) WithBrowserDomains(domains []string) *ToolsExecutor {
+	e.browserDomains = domains
+	return e
+}
+
+// WithReadFileLimit sets the line cap of read_file calls without a limit
+// (tools.ToolInvocation.ReadFileLimit).
+func (e *ToolsExecutor) WithReadFileLimit(limit int) *ToolsExecutor {
+	e.readFileLimit = limit
+	return e
+}
+
+// WithPathRemaps rewrites the file paths of calls that still use a former
+// working directory of the session (see remap.go).
+func (e *ToolsExecutor) WithPathRemaps(remaps []PathRemap) *ToolsExecutor {
+	e.pathRemaps = remaps
+	return e
+}
+
+// WithRetryPolicies sets the session's retry policy overrides.
+func (e *ToolsExecutor) WithRetryPolicies(policies models.RetryPolicies) *ToolsExecutor {
+	e.retryPolicies = policies
+	return e
+}
+
+// WithPreviousOutputs has shell and exec calls diff their output against
+// the output lookup returns for the same command, if any.
+func (e *ToolsExecutor) WithPreviousOutputs(lookup func(name string, args map[string]interface{}) string) *ToolsExecutor {
+	e.previousOutput = lookup
+	return e
+}
+
 // ExecuteParallel runs all tool activities in parallel and waits for all.
 // Delegates to executeToolsInParallel.
-func (e *ToolsExecutor) ExecuteParallel(ctx workflow.Context, calls []models.ConversationItem) ([]activities.ToolActivityOutput, error) {
-	return executeToolsInParallel(ctx, calls, e.toolSpecs, e.cwd, e.sessionTaskQueue, e.sessionID, e.mcpToolLookup)
+func (e *ToolsExecutor) ExecuteParallel(ctx workflow.Context, calls []models.ConversationItem) ([]activities.ToolActivityOutput, []ToolCallTiming, error) {
+	var envPolicy *tools.EnvPolicyRef
+	if e.envPolicy != nil {
+		envPolicy = e.envPolicy()
+	}
+	var sandboxPolicy *tools.SandboxPolicyRef
+	if e.sandboxPolicy != nil {
+		sandboxPolicy = e.sandboxPolicy()
+	}
+	calls = remapCallPaths(calls, e.pathRemaps)
+	return executeToolsInParallel(ctx, calls, toolRunOptions{
+		toolSpecs:        e.toolSpecs,
+		cwd:              e.cwd,
+		sessionTaskQueue: e.sessionTaskQueue,
+		sessionID:        e.sessionID,
+		turnID:           e.turnID,
+		mcpToolLookup:    e.mcpToolLookup,
+		envPolicy:        envPolicy,
+		sandboxPolicy:    sandboxPolicy,
+		browserDomains:   e.browserDomains,
+		readFileLimit:    e.readFileLimit,
+		cancelRequested:  e.cancelRequested,
+		retryPolicies:    e.retryPolicies,
+		previousOutput:   e.previousOutput,
+	})
+}
+
+// InFlight describes calls as in-flight tools started at start, with the
+// per-attempt timeout executeToolsInParallel will give each activity.
+func (e *ToolsExecutor) InFlight(start time.Time, calls []models.ConversationItem) []ToolInFlight {
+	specByName := make(map[string]tools.ToolSpec, len(e.toolSpecs))
+	for _, spec := range e.toolSpecs {
+		specByName[spec.Name] = spec
+	}
+	inFlight := make([]ToolInFlight, len(calls))
+	for i, fc := range calls {
+		var args map[string]interface{}
+		_ = json.Unmarshal([]byte(fc.Arguments), &args)
+		inFlight[i] = ToolInFlight{
+			Name:      fc.Name,
+			CallID:    fc.CallID,
+			StartedAt: start,
+			Timeout:   resolveToolTimeout(specByName, fc.Name, args),
+		}
+	}
+	return inFlight
+}
+
+// toolRunOptions configures executeToolsInParallel. Zero fields turn the
+// corresponding behavior off; a zero readFileLimit is the default cap.
+type toolRunOptions struct {
+	toolSpecs []tools.ToolSpec
+	cwd       string
+
+	// sessionTaskQueue, if non-empty, is the queue tool activities are
+	// dispatched to (per-session worker routing in multi-host mode).
+	sessionTaskQueue string
+
+	// sessionID is passed to tools that keep per-session state on the
+	// worker; turnID to all tools for log correlation.
+	sessionID string
+	turnID    string
+
+	mcpToolLookup  map[string]tools.McpToolRef
+	envPolicy      *tools.EnvPolicyRef
+	sandboxPolicy  *tools.SandboxPolicyRef
+	browserDomains []string
+	readFileLimit  int
+
+	// cancelRequested, if non-nil, reports calls to cancel: a call's
+	// activity is cancelled (the worker kills the command at its next
+	// heartbeat) and gets a cancelled result; the other calls run to
+	// completion.
+	cancelRequested func(callID string) bool
+
+	// retryPolicies overrides the tool specs' retry policies
+	// (resolveToolRetryPolicy).
+	retryPolicies models.RetryPolicies
+
+	// previousOutput, if non-nil, returns the earlier output of a shell or
+	// exec call, so the call can reply with only what changed.
+	previousOutput func(name string, args map[string]interface{}) string
 }
 
 // executeToolsInParallel runs all tool activities in parallel and waits for all.
+// Returns the results in call order along with per-call wall time, measured
+// from dispatch until each activity's future resolves.
 //
 // Each tool gets a per-activity StartToCloseTimeout derived from:
 //  1. timeout_ms argument provided by the LLM (highest priority)
 //  2. DefaultTimeoutMs from the tool's ToolSpec
 //  3. DefaultToolTimeoutMs constant as a fallback
 //
-// If sessionTaskQueue is non-empty, tool activities are dispatched to that queue
-// (enabling per-session worker routing in multi-host mode).
+// See toolRunOptions for how the calls are dispatched.
 //
 // Maps to: codex-rs/core/src/tools/parallel.rs drain_in_flight
-func executeToolsInParallel(ctx workflow.Context, functionCalls []models.ConversationItem, toolSpecs []tools.ToolSpec, cwd, sessionTaskQueue, sessionID string, mcpToolLookup map[string]tools.McpToolRef) ([]activities.ToolActivityOutput, error) {
+func executeToolsInParallel(ctx workflow.Context, functionCalls []models.ConversationItem, opts toolRunOptions) ([]activities.ToolActivityOutput, []ToolCallTiming, error) {
 	logger := workflow.GetLogger(ctx)
+	start := workflow.Now(ctx)
 
 	// Build a lookup map from tool name to spec for fast access.
-	specByName := make(map[string]tools.ToolSpec, len(toolSpecs))
-	for _, spec := range toolSpecs {
+	specByName := make(map[string]tools.ToolSpec, len(opts.toolSpecs))
+	for _, spec := range opts.toolSpecs {
 		specByName[spec.Name] = spec
 	}
 
 	// Start all tool activities in parallel using futures
 	futures := make([]workflow.Future, len(functionCalls))
+	done := make([]bool, len(functionCalls))
 	for i, fc := range functionCalls {
 		logger.Info("Starting tool execution", "tool", fc.Name, "call_id", fc.CallID)
 
@@ -85,50 +256,111 @@ func executeToolsInParallel(ctx workflow.Context, functionCalls []models.Convers
 
 		actOpts := workflow.ActivityOptions{
 			StartToCloseTimeout: timeout,
-			RetryPolicy:         resolveRetryPolicy(specByName, fc.Name),
+			RetryPolicy:         resolveToolRetryPolicy(specByName, fc.Name, opts.retryPolicies),
 		}
-		// exec_command and write_stdin are long-running activities that
-		// heartbeat during output collection. Set HeartbeatTimeout so
-		// Temporal can detect stuck activities.
-		if fc.Name == "exec_command" || fc.Name == "write_stdin" {
+		// Shell and exec tools heartbeat with progress while commands run
+		// (tools.ProgressInterval). Set HeartbeatTimeout so Temporal can
+		// detect stuck activities.
+		if heartbeatingTools[fc.Name] {
 			actOpts.HeartbeatTimeout = 15 * time.Second
 		}
-		if sessionTaskQueue != "" {
-			actOpts.TaskQueue = sessionTaskQueue
+		if opts.sessionTaskQueue != "" {
+			actOpts.TaskQueue = opts.sessionTaskQueue
 		}
 		toolCtx := workflow.WithActivityOptions(ctx, actOpts)
 
 		input := activities.ToolActivityInput{
 			CallID:    fc.CallID,
+			TurnID:    opts.turnID,
 			ToolName:  fc.Name,
 			Arguments: args,
-			Cwd:       cwd,
+			Cwd:       opts.cwd,
 		}
 
 		// Populate MCP routing info for mcp__* tools
-		if ref, ok := mcpToolLookup[fc.Name]; ok {
+		if ref, ok := opts.mcpToolLookup[fc.Name]; ok {
 			input.McpToolRef = &ref
-			input.SessionID = sessionID
+			input.SessionID = opts.sessionID
+		}
+		// The Python kernel is kept per session.
+		if fc.Name == "python_exec" {
+			input.SessionID = opts.sessionID
+		}
+		// So is the browser, limited to the session's domains.
+		if browserTools[fc.Name] {
+			input.SessionID = opts.sessionID
+			input.BrowserAllowedDomains = opts.browserDomains
+		}
+		if fc.Name == "read_file" {
+			input.ReadFileLimit = opts.readFileLimit
+		}
+		if envTools[fc.Name] {
+			input.EnvPolicy = opts.envPolicy
+			input.SandboxPolicy = opts.sandboxPolicy
+		}
+		if opts.previousOutput != nil && diffableTools[fc.Name] {
+			input.PreviousOutput = opts.previousOutput(fc.Name, args)
 		}
 
+		if opts.cancelRequested == nil {
+			futures[i] = workflow.ExecuteActivity(toolCtx, "ExecuteTool", input)
+			continue
+		}
+		toolCtx, cancelTool := workflow.WithCancel(toolCtx)
 		futures[i] = workflow.ExecuteActivity(toolCtx, "ExecuteTool", input)
+		callID := fc.CallID
+		workflow.Go(ctx, func(gctx workflow.Context) {
+			_ = workflow.Await(gctx, func() bool { return done[i] || opts.cancelRequested(callID) })
+			if !done[i] {
+				cancelTool()
+			}
+		})
 	}
 
-	// Wait for ALL tools to complete.
+	// Wait for ALL tools to complete, in completion order so each call's
+	// duration reflects when it actually finished.
 	// Activity errors (ApplicationError) are converted to failed tool results
 	// so the LLM can see what went wrong and decide how to proceed.
 	results := make([]activities.ToolActivityOutput, len(functionCalls))
+	timings := make([]ToolCallTiming, len(functionCalls))
+	selector := workflow.NewSelector(ctx)
 	for i, future := range futures {
-		var result activities.ToolActivityOutput
-		if err := future.Get(ctx, &result); err != nil {
-			results[i] = toolActivityErrorToOutput(logger, functionCalls[i].CallID, functionCalls[i].Name, err)
-		} else {
-			results[i] = result
-			logger.Info("Tool execution completed", "tool", functionCalls[i].Name)
-		}
+		selector.AddFuture(future, func(f workflow.Future) {
+			done[i] = true
+			var result activities.ToolActivityOutput
+			if err := f.Get(ctx, &result); err != nil {
+				if opts.cancelRequested != nil && opts.cancelRequested(functionCalls[i].CallID) {
+					logger.Info("Tool call cancelled by user", "tool", functionCalls[i].Name)
+					results[i] = cancelledToolOutput(functionCalls[i].CallID)
+				} else {
+					results[i] = toolActivityErrorToOutput(logger, functionCalls[i].CallID, functionCalls[i].Name, err)
+				}
+			} else {
+				results[i] = result
+				logger.Info("Tool execution completed", "tool", functionCalls[i].Name)
+			}
+			timings[i] = ToolCallTiming{
+				CallID:   functionCalls[i].CallID,
+				ToolName: functionCalls[i].Name,
+				Duration: workflow.Now(ctx).Sub(start),
+			}
+		})
+	}
+	for range futures {
+		selector.Select(ctx)
 	}
 
-	return results, nil
+	return results, timings, nil
+}
+
+// cancelledToolOutput is the result recorded for a call the user cancelled.
+func cancelledToolOutput(callID string) activities.ToolActivityOutput {
+	success := false
+	return activities.ToolActivityOutput{
+		CallID:  callID,
+		Content: "The user cancelled this tool call before it finished.",
+		Success: &success,
+	}
 }
 
 // buildToolSpecs builds tool specifications based on configuration and profile.
@@ -163,6 +395,7 @@ func buildToolSpecs(config models.ToolsConfig, profile models.ResolvedProfile) [
 func toolActivityErrorToOutput(logger log.Logger, callID, toolName string, err error) activities.ToolActivityOutput {
 	success := false
 	reason := "unknown error"
+	var unavailable *activities.ToolUnavailable
 
 	var appErr *temporal.ApplicationError
 	var timeoutErr *temporal.TimeoutError
@@ -181,6 +414,11 @@ func toolActivityErrorToOutput(logger log.Logger, callID, toolName string, err e
 			_ = appErr.Details(&details)
 			reason = details.Reason
 		}
+		// Workers from before ToolActivityOutput.Unavailable report a
+		// missing tool as an error.
+		if appErr.Type() == models.ToolErrTypeNotFound {
+			unavailable = &activities.ToolUnavailable{}
+		}
 
 	case errors.As(err, &timeoutErr):
 		logger.Warn("Tool activity timed out",
@@ -199,23 +437,53 @@ func toolActivityErrorToOutput(logger log.Logger, callID, toolName string, err e
 	}
 
 	return activities.ToolActivityOutput{
-		CallID:  callID,
-		Content: reason,
-		Success: &success,
+		CallID:      callID,
+		Content:     reason,
+		Success:     &success,
+		Unavailable: unavailable,
 	}
 }
 
+// heartbeatingTools report progress (and so heartbeat) every
+// tools.ProgressInterval while running.
+var heartbeatingTools = map[string]bool{
+	"shell":         true,
+	"shell_command": true,
+	"exec_command":  true,
+	"write_stdin":   true,
+	"python_exec":   true,
+	"quality_gate":  true,
+}
+
+// browserTools share the session's browser on the worker.
+var browserTools = map[string]bool{
+	"browser_navigate":   true,
+	"browser_click":      true,
+	"browser_read_text":  true,
+	"browser_screenshot": true,
+}
+
 // resolveToolTimeout determines the StartToCloseTimeout for a tool activity.
 //
 // Priority:
-//  1. timeout_ms argument from LLM (per-invocation override)
+//  1. timeout_seconds or timeout_ms argument from LLM (per-invocation
+//     override). Shell tools stop the command at that time themselves, so
+//     their activity gets tools.CommandTimeoutGrace on top, and the time is
+//     capped at tools.MaxCommandTimeoutSec.
 //  2. DefaultTimeoutMs from the tool's ToolSpec
 //  3. DefaultToolTimeoutMs constant as a global fallback
 //
+// exec_command's timeout_seconds bounds the process, not the call, which
+// returns after yield_time_ms; it does not change the activity timeout.
+//
 // Maps to: codex-rs/core/src/exec.rs timeout resolution for tool commands
 func resolveToolTimeout(specByName map[string]tools.ToolSpec, toolName string, args map[string]interface{}) time.Duration {
-	// 1. Check for LLM-provided timeout_ms in arguments.
-	if args != nil {
+	// 1. Check for an LLM-provided timeout in arguments.
+	if shellTools[toolName] {
+		if d := tools.RequestedCommandTimeout(args); d > 0 {
+			return tools.ClampCommandTimeout(d, 0) + tools.CommandTimeoutGrace
+		}
+	} else if args != nil {
 		if v, ok := args["timeout_ms"]; ok {
 			if ms, ok := toInt64(v); ok && ms > 0 {
 				return time.Duration(ms) * time.Millisecond
@@ -232,6 +500,13 @@ func resolveToolTimeout(specByName map[string]tools.ToolSpec, toolName string, a
 	return time.Duration(tools.DefaultToolTimeoutMs) * time.Millisecond
 }
 
+// shellTools run one command per call and stop it at the call's
+// timeout_seconds.
+var shellTools = map[string]bool{
+	"shell":         true,
+	"shell_command": true,
+}
+
 // resolveRetryPolicy returns the Temporal RetryPolicy for a tool activity.
 //
 // Priority:
@@ -1,11 +1,16 @@
 package workflow
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
 	"github.com/mfateev/temporal-agent-harness/internal/tools"
 )
 
@@ -105,3 +110,93 @@ func TestResolveRetryPolicy_AllBuiltinTools(t *testing.T) {
 			"%s should be retryable (MaxAttempts=3)", name)
 	}
 }
+
+func TestResolveToolRetryPolicy_Overrides(t *testing.T) {
+	specByName := make(map[string]tools.ToolSpec)
+	for _, s := range tools.BuildSpecs([]string{"shell_command", "read_file"}) {
+		specByName[s.Name] = s
+	}
+	overrides := models.RetryPolicies{
+		Tool: models.RetryPolicy{MaximumAttempts: 6, NonRetryableErrorTypes: []string{"ToolCrash"}},
+		Tools: map[string]models.RetryPolicy{
+			"mcp__ci__run_suite": {InitialIntervalMs: 30000, MaximumIntervalMs: 120000},
+		},
+	}
+
+	policy := resolveToolRetryPolicy(specByName, "read_file", overrides)
+	assert.Equal(t, int32(6), policy.MaximumAttempts, "class override applies")
+	assert.Equal(t, []string{"ToolCrash"}, policy.NonRetryableErrorTypes)
+	assert.Equal(t, time.Second, policy.InitialInterval, "unset fields keep the built-in value")
+
+	policy = resolveToolRetryPolicy(specByName, "shell_command", overrides)
+	assert.Equal(t, int32(1), policy.MaximumAttempts, "class override leaves non-retryable tools alone")
+
+	policy = resolveToolRetryPolicy(specByName, "mcp__ci__run_suite", overrides)
+	assert.Equal(t, 30*time.Second, policy.InitialInterval, "per-tool override wins over the class")
+	assert.Equal(t, 2*time.Minute, policy.MaximumInterval)
+	assert.Equal(t, int32(3), policy.MaximumAttempts)
+
+	overrides.Tools["shell_command"] = models.RetryPolicy{MaximumAttempts: 2}
+	policy = resolveToolRetryPolicy(specByName, "shell_command", overrides)
+	assert.Equal(t, int32(2), policy.MaximumAttempts, "per-tool override can make a mutating tool retry")
+}
+
+func TestWithRetryOverride_DoesNotModifyBase(t *testing.T) {
+	base := defaultLLMRetryPolicy()
+	policy := withRetryOverride(base, models.RetryPolicy{MaximumAttempts: 10, BackoffCoefficient: 3})
+	assert.Equal(t, int32(10), policy.MaximumAttempts)
+	assert.Equal(t, 3.0, policy.BackoffCoefficient)
+	assert.Equal(t, 500*time.Millisecond, policy.InitialInterval)
+	assert.Equal(t, int32(5), base.MaximumAttempts)
+}
+
+// TestReadFileLimit_SentToReadFile verifies that the session's read_file
+// line cap goes with read_file calls only.
+func (s *AgenticWorkflowTestSuite) TestReadFileLimit_SentToReadFile() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(activities.LLMActivityOutput{
+		Items: []models.ConversationItem{
+			{Type: models.ItemTypeFunctionCall, CallID: "call-read", Name: "read_file", Arguments: `{"file_path": "/repo/gen.go"}`},
+			{Type: models.ItemTypeFunctionCall, CallID: "call-list", Name: "list_dir", Arguments: `{"dir_path": "/repo"}`},
+		},
+		FinishReason: models.FinishReasonToolCalls,
+	}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).Return(mockLLMStopResponse("Read.", 10), nil).Once()
+
+	trueVal := true
+	limits := map[string]int{}
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			limits[in.CallID] = in.ReadFileLimit
+			return activities.ToolActivityOutput{CallID: in.CallID, Content: "ok", Success: &trueVal}, nil
+		})
+	s.sendShutdown(5 * time.Second)
+
+	input := testInput("Read the generated file")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "read_file", "list_dir")
+	input.Config.ReadFileLimit = 300
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), map[string]int{"call-read": 300, "call-list": 0}, limits)
+}
+
+func TestResolveToolTimeout_CommandTimeout(t *testing.T) {
+	specs := map[string]tools.ToolSpec{
+		"shell":        tools.NewShellToolSpec(false),
+		"exec_command": tools.NewExecCommandToolSpec(),
+	}
+	grace := tools.CommandTimeoutGrace
+
+	assert.Equal(t, 10*time.Second, resolveToolTimeout(specs, "shell", map[string]interface{}{}))
+	assert.Equal(t, 10*time.Minute+grace,
+		resolveToolTimeout(specs, "shell", map[string]interface{}{"timeout_seconds": float64(600)}))
+	assert.Equal(t, 10*time.Minute+grace,
+		resolveToolTimeout(specs, "shell", map[string]interface{}{"timeout_seconds": float64(600), "timeout_ms": float64(1000)}),
+		"timeout_seconds wins over timeout_ms")
+	assert.Equal(t, time.Duration(tools.MaxCommandTimeoutSec)*time.Second+grace,
+		resolveToolTimeout(specs, "shell", map[string]interface{}{"timeout_seconds": float64(86400)}),
+		"capped at the maximum")
+	assert.Equal(t, 45*time.Second,
+		resolveToolTimeout(specs, "exec_command", map[string]interface{}{"timeout_seconds": float64(600)}),
+		"exec_command's timeout bounds the process, not the call")
+}
@@ -0,0 +1,72 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// tool_mismatch.go records calls to tools the worker running them has no
+// handler for, e.g. a tool enabled in the session's config but added in a
+// newer build than the worker runs. The model gets the worker's tool list in
+// the call's output; TurnStatus carries a warning so the mismatch is visible
+// to the user.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ToolMismatch is a tool the model called that the worker did not have.
+type ToolMismatch struct {
+	ToolName   string `json:"tool_name"`
+	Count      int    `json:"count"`                  // Calls that found it missing
+	LastTurnID string `json:"last_turn_id,omitempty"` // Turn of the latest such call
+}
+
+// recordToolMismatches notes the calls whose results report the tool as
+// unavailable on the worker.
+func (s *SessionState) recordToolMismatches(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem, results []activities.ToolActivityOutput) {
+	names := make(map[string]string, len(calls))
+	for _, fc := range calls {
+		names[fc.CallID] = fc.Name
+	}
+	for _, result := range results {
+		if result.Unavailable == nil {
+			continue
+		}
+		name := names[result.CallID]
+		workflow.GetLogger(ctx).Warn("Tool not available on the worker", "tool", name,
+			"available", len(result.Unavailable.Available))
+		s.addToolMismatch(name, ctrl.CurrentTurnID())
+	}
+}
+
+func (s *SessionState) addToolMismatch(name, turnID string) {
+	for i := range s.ToolMismatches {
+		if s.ToolMismatches[i].ToolName == name {
+			s.ToolMismatches[i].Count++
+			s.ToolMismatches[i].LastTurnID = turnID
+			return
+		}
+	}
+	s.ToolMismatches = append(s.ToolMismatches, ToolMismatch{ToolName: name, Count: 1, LastTurnID: turnID})
+}
+
+// withoutUnavailableTools returns calls minus those to tools recorded as
+// missing on the worker.
+func (s *SessionState) withoutUnavailableTools(calls []models.ConversationItem) []models.ConversationItem {
+	if len(s.ToolMismatches) == 0 {
+		return calls
+	}
+	missing := make(map[string]bool, len(s.ToolMismatches))
+	for _, m := range s.ToolMismatches {
+		missing[m.ToolName] = true
+	}
+	var kept []models.ConversationItem
+	for _, fc := range calls {
+		if !missing[fc.Name] {
+			kept = append(kept, fc)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestDetectRepeatedToolCalls_SkipsUnavailableTools(t *testing.T) {
+	s := &SessionState{ToolMismatches: []ToolMismatch{{ToolName: "browser_navigate", Count: 1}}}
+	missing := []models.ConversationItem{{Name: "browser_navigate", Arguments: `{"url": "http://localhost"}`}}
+	for i := 0; i < maxRepeatToolCalls+1; i++ {
+		assert.False(t, s.detectRepeatedToolCalls(missing))
+	}
+
+	mixed := []models.ConversationItem{
+		{Name: "browser_navigate", Arguments: `{"url": "http://localhost"}`},
+		{Name: "read_file", Arguments: `{"path": "a.go"}`},
+	}
+	assert.False(t, s.detectRepeatedToolCalls(mixed))
+	assert.False(t, s.detectRepeatedToolCalls(mixed[1:]), "the missing tool does not change the batch")
+	assert.True(t, s.detectRepeatedToolCalls(mixed))
+}
+
+func TestToolActivityErrorToOutput_NotFoundIsUnavailable(t *testing.T) {
+	out := toolActivityErrorToOutput(log.NewStructuredLogger(slog.New(slog.DiscardHandler)), "c1", "browser_navigate", models.NewToolNotFoundError("browser_navigate"))
+	require.NotNil(t, out.Unavailable)
+	assert.False(t, *out.Success)
+	assert.Contains(t, out.Content, "not registered")
+}
+
+// TestToolMismatch_RecordedAndNotRepeatDetected verifies that calls to a
+// tool the worker lacks run every time, are not treated as a loop, and
+// leave a warning in TurnStatus.
+func (s *AgenticWorkflowTestSuite) TestToolMismatch_RecordedAndNotRepeatDetected() {
+	for i := 0; i < maxRepeatToolCalls; i++ {
+		callID := fmt.Sprintf("call-%d", i)
+		s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+			Return(activities.LLMActivityOutput{
+				Items: []models.ConversationItem{{
+					Type:      models.ItemTypeFunctionCall,
+					CallID:    callID,
+					Name:      "browser_navigate",
+					Arguments: `{"url": "http://localhost:3000"}`,
+				}},
+				FinishReason: models.FinishReasonToolCalls,
+				TokenUsage:   models.TokenUsage{TotalTokens: 10},
+			}, nil).Once()
+
+		failed := false
+		s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(func(input activities.ToolActivityInput) bool {
+			return input.CallID == callID
+		})).Return(activities.ToolActivityOutput{
+			CallID:      callID,
+			Content:     `Tool "browser_navigate" is not available on this worker. Available tools: read_file, shell. Use one of them instead.`,
+			Success:     &failed,
+			Unavailable: &activities.ToolUnavailable{Available: []string{"read_file", "shell"}},
+		}, nil).Once()
+	}
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Used the shell instead.", 10), nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		result, err := s.env.QueryWorkflow(QueryGetTurnStatus)
+		require.NoError(s.T(), err)
+		var status TurnStatus
+		require.NoError(s.T(), result.Get(&status))
+		require.Len(s.T(), status.ToolMismatches, 1)
+		assert.Equal(s.T(), "browser_navigate", status.ToolMismatches[0].ToolName)
+		assert.Equal(s.T(), maxRepeatToolCalls, status.ToolMismatches[0].Count)
+		assert.Equal(s.T(), status.CurrentTurnID, status.ToolMismatches[0].LastTurnID)
+
+		result, err = s.env.QueryWorkflow(QueryGetConversationItems)
+		require.NoError(s.T(), err)
+		var items []models.ConversationItem
+		require.NoError(s.T(), result.Get(&items))
+		var messages []string
+		for _, item := range items {
+			if item.Type == models.ItemTypeAssistantMessage {
+				messages = append(messages, item.Content)
+			}
+		}
+		assert.Equal(s.T(), []string{"Used the shell instead."}, messages, "the turn was not ended as a loop")
+	}, 2*time.Second)
+
+	s.sendShutdown(3 * time.Second)
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Check the page"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	s.env.AssertExpectations(s.T())
+}
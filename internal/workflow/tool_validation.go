@@ -0,0 +1,117 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// tool_validation.go checks tool call arguments against the tool's parameter
+// schema before dispatch. A call with malformed arguments is not executed;
+// it gets a failed output listing the problems so the LLM can fix the call
+// instead of reading a confusing handler error.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// InvalidArgumentsError is the content of the output recorded for a call
+// rejected by argument validation, serialized as JSON.
+type InvalidArgumentsError struct {
+	Error    string   `json:"error"` // always "invalid_arguments"
+	Tool     string   `json:"tool"`
+	Problems []string `json:"problems"`
+	Hint     string   `json:"hint"`
+}
+
+const invalidArgumentsHint = "The call was not executed. Fix the arguments to match the tool's parameters and call it again."
+
+// validateToolArguments checks a call's raw JSON arguments against spec's
+// parameter schema and returns the problems found, nil when they are valid.
+// Every missing required argument and mistyped argument is reported, so the
+// LLM can fix them all in one retry.
+func validateToolArguments(spec tools.ToolSpec, arguments string) []string {
+	var value interface{} = map[string]interface{}{}
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &value); err != nil {
+			return []string{fmt.Sprintf("arguments are not valid JSON: %v", err)}
+		}
+	}
+	args, ok := value.(map[string]interface{})
+	if !ok {
+		return []string{"arguments must be a JSON object"}
+	}
+
+	schema := spec.ParameterSchema()
+	var problems []string
+	for _, name := range requiredNames(schema) {
+		if _, present := args[name]; !present {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub, ok := props[name].(map[string]interface{})
+		if !ok {
+			continue // unknown arguments are left to the handler
+		}
+		if err := checkSchema(sub, args[name], name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// invalidArgumentsOutput builds the failed output for a call whose
+// arguments did not validate.
+func invalidArgumentsOutput(fc models.ConversationItem, problems []string) models.ConversationItem {
+	content, _ := json.Marshal(InvalidArgumentsError{
+		Error:    "invalid_arguments",
+		Tool:     fc.Name,
+		Problems: problems,
+		Hint:     invalidArgumentsHint,
+	})
+	success := false
+	return models.ConversationItem{
+		Type:   models.ItemTypeFunctionCallOutput,
+		CallID: fc.CallID,
+		Output: &models.FunctionCallOutputPayload{
+			Content: string(content),
+			Success: &success,
+		},
+	}
+}
+
+// recordInvalidAndFilter validates each call's arguments, records a failed
+// output for the calls that do not validate, and returns the others. Calls
+// to tools without a spec are passed through.
+func (s *SessionState) recordInvalidAndFilter(ctrl *LoopControl, calls []models.ConversationItem) []models.ConversationItem {
+	specByName := make(map[string]tools.ToolSpec, len(s.ToolSpecs))
+	for _, spec := range s.ToolSpecs {
+		specByName[spec.Name] = spec
+	}
+	var valid []models.ConversationItem
+	for _, fc := range calls {
+		spec, ok := specByName[fc.Name]
+		if !ok {
+			valid = append(valid, fc)
+			continue
+		}
+		problems := validateToolArguments(spec, fc.Arguments)
+		if len(problems) == 0 {
+			valid = append(valid, fc)
+			continue
+		}
+		_ = s.History.AddItem(invalidArgumentsOutput(fc, problems))
+		ctrl.NotifyItemAdded()
+	}
+	return valid
+}
@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+func TestValidateToolArguments(t *testing.T) {
+	shell := tools.NewShellToolSpec(false)
+
+	assert.Empty(t, validateToolArguments(shell, `{"command": ["ls", "-la"], "timeout_ms": 5000}`))
+	assert.Empty(t, validateToolArguments(shell, `{"command": ["ls"], "extra": 1}`), "unknown arguments pass")
+
+	assert.Equal(t, []string{"command is required"}, validateToolArguments(shell, ""))
+	assert.Equal(t, []string{"arguments must be a JSON object"}, validateToolArguments(shell, `["ls"]`))
+	assert.Contains(t, validateToolArguments(shell, `{"command": `)[0], "not valid JSON")
+
+	assert.Equal(t, []string{
+		"command[1] must be of type string",
+		"timeout_ms must be of type number",
+		"workdir must be of type string",
+	}, validateToolArguments(shell, `{"command": ["ls", 1], "workdir": 7, "timeout_ms": "5s"}`),
+		"every problem is reported, in a stable order")
+}
+
+func TestValidateToolArguments_RawSchema(t *testing.T) {
+	spec := tools.ToolSpec{
+		Name: "mcp__db__query",
+		RawJSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"sql":   map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer"},
+			},
+			"required": []interface{}{"sql"},
+		},
+	}
+	assert.Empty(t, validateToolArguments(spec, `{"sql": "select 1", "limit": 10}`))
+	assert.Equal(t, []string{"sql is required", "limit must be of type integer"},
+		validateToolArguments(spec, `{"limit": 1.5}`))
+}
+
+// TestInvalidToolArguments_NotExecuted verifies that a call with malformed
+// arguments gets a structured error output without running the tool, and
+// that the turn continues so the LLM can retry.
+func (s *AgenticWorkflowTestSuite) TestInvalidToolArguments_NotExecuted() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-bad", Name: "read_file", Arguments: `{"path": "a.txt"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{
+				{Type: models.ItemTypeFunctionCall, CallID: "call-good", Name: "read_file", Arguments: `{"file_path": "a.txt"}`},
+			},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Read it.", 10), nil).Once()
+
+	trueVal := true
+	var executed []string
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.ToolActivityInput) (activities.ToolActivityOutput, error) {
+			executed = append(executed, in.CallID)
+			return activities.ToolActivityOutput{CallID: in.CallID, Content: "file contents", Success: &trueVal}, nil
+		})
+
+	s.sendShutdown(time.Second * 2)
+
+	input := testInput("read a.txt")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "read_file")
+	input.Config.Permissions.ApprovalMode = models.ApprovalNever
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), []string{"call-good"}, executed)
+
+	var bad *models.FunctionCallOutputPayload
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeFunctionCallOutput && item.CallID == "call-bad" {
+			bad = item.Output
+		}
+	}
+	require.NotNil(s.T(), bad)
+	assert.False(s.T(), *bad.Success)
+	var verr InvalidArgumentsError
+	require.NoError(s.T(), json.Unmarshal([]byte(bad.Content), &verr))
+	assert.Equal(s.T(), "invalid_arguments", verr.Error)
+	assert.Equal(s.T(), "read_file", verr.Tool)
+	assert.Equal(s.T(), []string{"file_path is required"}, verr.Problems)
+}
@@ -0,0 +1,200 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// trust.go implements session-scoped learned trust: once the user has
+// approved the same command TrustAfterApprovals times, later identical calls
+// skip the approval prompt for the rest of the session. Learned and revoked
+// trust is noted in history; the user lists and revokes it with /trust.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// DefaultTrustAfterApprovals is the number of approvals of an identical
+// command after which it is trusted, when Config.TrustAfterApprovals is 0.
+const DefaultTrustAfterApprovals = 3
+
+// TrustedCommand is a command the session approves automatically because
+// the user approved it repeatedly. Trust is limited to the directory the
+// command ran in and the sandbox permissions it asked for.
+type TrustedCommand struct {
+	ID                 int    `json:"id"`
+	Tool               string `json:"tool"`
+	Command            string `json:"command"`
+	Workdir            string `json:"workdir,omitempty"`             // Resolved against the session's cwd
+	SandboxPermissions string `json:"sandbox_permissions,omitempty"` // As requested by the call
+	Approvals          int    `json:"approvals"`                     // Approvals that earned the trust
+}
+
+// trustKey identifies identical commands across calls.
+func trustKey(t TrustedCommand) string {
+	return t.Tool + "\x00" + t.Command + "\x00" + t.Workdir + "\x00" + t.SandboxPermissions
+}
+
+// sameCommand reports whether t and o run the same command in the same
+// place with the same permissions.
+func (t TrustedCommand) sameCommand(o TrustedCommand) bool {
+	return trustKey(t) == trustKey(o)
+}
+
+// trustableCommand extracts what a tool call runs, for the tools whose calls
+// can earn learned trust: the command, its workdir resolved against cwd, and
+// the sandbox permissions it requests. Approving a command in one directory
+// says nothing about running it in another, so all three must match; other
+// arguments such as the timeout do not count. File edits never earn trust,
+// since each one changes different content.
+func trustableCommand(toolName, arguments, cwd string) (TrustedCommand, bool) {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return TrustedCommand{}, false
+	}
+	t := TrustedCommand{Tool: toolName, Workdir: cwd}
+	if w, ok := args["workdir"].(string); ok && w != "" {
+		if !filepath.IsAbs(w) {
+			w = filepath.Join(cwd, w)
+		}
+		t.Workdir = filepath.Clean(w)
+	}
+	t.SandboxPermissions, _ = args["sandbox_permissions"].(string)
+
+	switch toolName {
+	case "shell_command":
+		cmd, ok := args["command"].(string)
+		t.Command = cmd
+		return t, ok && cmd != ""
+	case "exec_command":
+		cmd, ok := args["cmd"].(string)
+		t.Command = cmd
+		return t, ok && cmd != ""
+	case "shell":
+		arr, ok := args["command"].([]interface{})
+		if !ok || len(arr) == 0 {
+			return TrustedCommand{}, false
+		}
+		parts := make([]string, len(arr))
+		for i, v := range arr {
+			s, ok := v.(string)
+			if !ok {
+				return TrustedCommand{}, false
+			}
+			parts[i] = s
+		}
+		b, _ := json.Marshal(parts)
+		t.Command = string(b)
+		return t, true
+	default:
+		return TrustedCommand{}, false
+	}
+}
+
+// trustThreshold returns the approvals needed to trust a command, or 0 when
+// learned trust is disabled.
+func (s *SessionState) trustThreshold() int {
+	switch n := s.Config.TrustAfterApprovals; {
+	case n < 0:
+		return 0
+	case n == 0:
+		return DefaultTrustAfterApprovals
+	default:
+		return n
+	}
+}
+
+// findTrusted returns the index of the trusted command matching a call, or -1.
+func (s *SessionState) findTrusted(toolName, arguments string) int {
+	call, ok := trustableCommand(toolName, arguments, s.Config.Cwd)
+	if !ok {
+		return -1
+	}
+	for i, t := range s.TrustedCommands {
+		if t.sameCommand(call) {
+			return i
+		}
+	}
+	return -1
+}
+
+// skipTrusted removes calls matching a learned-trust command from the
+// approvals to ask the user for.
+func (s *SessionState) skipTrusted(pending []PendingApproval) []PendingApproval {
+	if len(s.TrustedCommands) == 0 {
+		return pending
+	}
+	var remaining []PendingApproval
+	for _, p := range pending {
+		if s.findTrusted(p.ToolName, p.Arguments) < 0 {
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
+
+// learnTrust counts the user's approvals of each pending command and trusts
+// commands that reach the threshold. A denial resets the command's count.
+func (s *SessionState) learnTrust(ctrl *LoopControl, pending []PendingApproval, resp *ApprovalResponse) {
+	threshold := s.trustThreshold()
+	if threshold == 0 || resp == nil {
+		return
+	}
+
+	denied := make(map[string]bool, len(resp.Denied))
+	for _, id := range resp.Denied {
+		denied[id] = true
+	}
+
+	for _, p := range pending {
+		call, ok := trustableCommand(p.ToolName, p.Arguments, s.Config.Cwd)
+		if !ok || s.findTrusted(p.ToolName, p.Arguments) >= 0 {
+			continue
+		}
+		key := trustKey(call)
+		if denied[p.CallID] {
+			delete(s.ApprovalCounts, key)
+			continue
+		}
+		if s.ApprovalCounts == nil {
+			s.ApprovalCounts = make(map[string]int)
+		}
+		s.ApprovalCounts[key]++
+		if s.ApprovalCounts[key] < threshold {
+			continue
+		}
+
+		delete(s.ApprovalCounts, key)
+		s.TrustCounter++
+		t := call
+		t.ID, t.Approvals = s.TrustCounter, threshold
+		s.TrustedCommands = append(s.TrustedCommands, t)
+		_ = s.History.AddItem(models.ConversationItem{
+			Type: models.ItemTypeAssistantMessage,
+			Content: fmt.Sprintf("[Trust: %s `%s` in %s approved %d times; auto-approving it there for the rest of this session (#%d, revoke with /trust)]",
+				t.Tool, t.Command, t.Workdir, t.Approvals, t.ID),
+			TurnID: ctrl.CurrentTurnID(),
+		})
+		ctrl.NotifyItemAdded()
+	}
+}
+
+// revokeTrust removes a learned-trust command by ID. The command needs
+// approval again, and its approval count starts over.
+func (s *SessionState) revokeTrust(id int) (TrustedCommand, error) {
+	for i, t := range s.TrustedCommands {
+		if t.ID == id {
+			s.TrustedCommands = append(s.TrustedCommands[:i:i], s.TrustedCommands[i+1:]...)
+			return t, nil
+		}
+	}
+	return TrustedCommand{}, fmt.Errorf("no trusted command #%d", id)
+}
+
+// formatTrustRevoked is the history note for a revoked trust.
+func formatTrustRevoked(t TrustedCommand) string {
+	return fmt.Sprintf("[Trust: user revoked #%d %s `%s`; it needs approval again]",
+		t.ID, t.Tool, t.Command)
+}
@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/history"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// ---------------------------------------------------------------------------
+// Unit tests for learned trust
+// ---------------------------------------------------------------------------
+
+func TestTrustableCommand(t *testing.T) {
+	cmd, ok := trustableCommand("shell_command", `{"command": "go build ./...", "workdir": "/src"}`, "/home")
+	require.True(t, ok)
+	assert.Equal(t, TrustedCommand{Tool: "shell_command", Command: "go build ./...", Workdir: "/src"}, cmd)
+
+	cmd, ok = trustableCommand("exec_command", `{"cmd": "npm test", "tty": true, "workdir": "web/../app"}`, "/src")
+	require.True(t, ok)
+	assert.Equal(t, TrustedCommand{Tool: "exec_command", Command: "npm test", Workdir: "/src/app"}, cmd)
+
+	cmd, ok = trustableCommand("shell", `{"command": ["make", "all"], "sandbox_permissions": "full-access"}`, "/src")
+	require.True(t, ok)
+	assert.Equal(t, TrustedCommand{Tool: "shell", Command: `["make","all"]`, Workdir: "/src", SandboxPermissions: "full-access"}, cmd)
+
+	for _, bad := range []struct{ tool, args string }{
+		{"write_file", `{"path": "/a", "content": "x"}`},
+		{"apply_patch", `{"input": "*** Begin Patch"}`},
+		{"shell_command", `{"command": ""}`},
+		{"shell", `{"command": ["make", 1]}`},
+		{"shell_command", `not json`},
+	} {
+		_, ok := trustableCommand(bad.tool, bad.args, "/src")
+		assert.False(t, ok, bad.tool+" "+bad.args)
+	}
+}
+
+func TestLearnTrust_TrustsAfterThreshold(t *testing.T) {
+	s := &SessionState{History: history.NewInMemoryHistory()}
+	s.Config.TrustAfterApprovals = 2
+	s.Config.Cwd = "/src"
+	ctrl := &LoopControl{}
+	build := PendingApproval{CallID: "c1", ToolName: "shell_command", Arguments: `{"command": "go build"}`}
+
+	s.learnTrust(ctrl, []PendingApproval{build}, &ApprovalResponse{Approved: []string{"c1"}})
+	assert.Empty(t, s.TrustedCommands)
+	assert.Equal(t, []PendingApproval{build}, s.skipTrusted([]PendingApproval{build}))
+
+	build.CallID = "c2"
+	s.learnTrust(ctrl, []PendingApproval{build}, &ApprovalResponse{Approved: []string{"c2"}})
+	require.Len(t, s.TrustedCommands, 1)
+	assert.Equal(t, TrustedCommand{ID: 1, Tool: "shell_command", Command: "go build", Workdir: "/src", Approvals: 2}, s.TrustedCommands[0])
+	assert.Empty(t, s.ApprovalCounts)
+
+	// The same command in the same workdir is trusted, however it is spelled;
+	// other commands are not.
+	same := PendingApproval{CallID: "c3", ToolName: "shell_command", Arguments: `{"command": "go build", "workdir": "/src/", "timeout_ms": 5000}`}
+	other := PendingApproval{CallID: "c4", ToolName: "shell_command", Arguments: `{"command": "go test"}`}
+	assert.Equal(t, []PendingApproval{other}, s.skipTrusted([]PendingApproval{same, other}))
+
+	items, err := s.History.GetRawItems()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Contains(t, items[0].Content, "[Trust: shell_command `go build` in /src approved 2 times")
+}
+
+func TestSkipTrusted_OtherWorkdirOrPermissionsStillPrompt(t *testing.T) {
+	s := &SessionState{TrustedCommands: []TrustedCommand{
+		{ID: 1, Tool: "shell_command", Command: "rm -rf build", Workdir: "/src/app"},
+		{ID: 2, Tool: "shell", Command: `["git","clean","-fdx"]`, Workdir: "/src/app"},
+	}}
+	s.Config.Cwd = "/src/app"
+
+	trusted := PendingApproval{CallID: "c1", ToolName: "shell_command", Arguments: `{"command": "rm -rf build"}`}
+	otherTree := PendingApproval{CallID: "c2", ToolName: "shell_command", Arguments: `{"command": "rm -rf build", "workdir": "/home/me/other"}`}
+	relative := PendingApproval{CallID: "c3", ToolName: "shell_command", Arguments: `{"command": "rm -rf build", "workdir": ".."}`}
+	escalated := PendingApproval{CallID: "c4", ToolName: "shell", Arguments: `{"command": ["git", "clean", "-fdx"], "sandbox_permissions": "full-access"}`}
+
+	assert.Equal(t, []PendingApproval{otherTree, relative, escalated},
+		s.skipTrusted([]PendingApproval{trusted, otherTree, relative, escalated}))
+}
+
+func TestLearnTrust_DenialResetsCount(t *testing.T) {
+	s := &SessionState{History: history.NewInMemoryHistory()}
+	s.Config.TrustAfterApprovals = 2
+	ctrl := &LoopControl{}
+	rm := PendingApproval{CallID: "c1", ToolName: "shell_command", Arguments: `{"command": "rm -rf build"}`}
+
+	s.learnTrust(ctrl, []PendingApproval{rm}, &ApprovalResponse{Approved: []string{"c1"}})
+	s.learnTrust(ctrl, []PendingApproval{rm}, &ApprovalResponse{Denied: []string{"c1"}})
+	s.learnTrust(ctrl, []PendingApproval{rm}, &ApprovalResponse{Approved: []string{"c1"}})
+	assert.Empty(t, s.TrustedCommands)
+}
+
+func TestLearnTrust_Disabled(t *testing.T) {
+	s := &SessionState{History: history.NewInMemoryHistory()}
+	s.Config.TrustAfterApprovals = -1
+	ctrl := &LoopControl{}
+	build := PendingApproval{CallID: "c1", ToolName: "shell_command", Arguments: `{"command": "go build"}`}
+
+	for i := 0; i < 5; i++ {
+		s.learnTrust(ctrl, []PendingApproval{build}, &ApprovalResponse{Approved: []string{"c1"}})
+	}
+	assert.Empty(t, s.TrustedCommands)
+}
+
+func TestRevokeTrust(t *testing.T) {
+	s := &SessionState{TrustedCommands: []TrustedCommand{
+		{ID: 1, Tool: "shell_command", Command: "go build"},
+		{ID: 2, Tool: "shell_command", Command: "go vet"},
+	}}
+
+	revoked, err := s.revokeTrust(1)
+	require.NoError(t, err)
+	assert.Equal(t, "go build", revoked.Command)
+	assert.Equal(t, []TrustedCommand{{ID: 2, Tool: "shell_command", Command: "go vet"}}, s.TrustedCommands)
+	assert.Equal(t, "[Trust: user revoked #1 shell_command `go build`; it needs approval again]", formatTrustRevoked(revoked))
+
+	_, err = s.revokeTrust(1)
+	assert.ErrorContains(t, err, "no trusted command #1")
+}
+
+// ---------------------------------------------------------------------------
+// Workflow tests
+// ---------------------------------------------------------------------------
+
+// buildCallResponse returns an LLM response running `go build` in /src as
+// callID. Each call gives its own justification, so the batches are not
+// identical (which would trip repeated-tool-call detection) while the command
+// is.
+func buildCallResponse(callID string) activities.LLMActivityOutput {
+	return activities.LLMActivityOutput{
+		Items: []models.ConversationItem{
+			{Type: models.ItemTypeFunctionCall, CallID: callID, Name: "shell_command",
+				Arguments: `{"command": "go build ./...", "workdir": "/src", "justification": "` + callID + `"}`},
+		},
+		FinishReason: models.FinishReasonToolCalls,
+		TokenUsage:   models.TokenUsage{TotalTokens: 10},
+	}
+}
+
+// TestLearnedTrust_SkipsApprovalAfterRepeatedApprovals verifies that once
+// the user has approved an identical command TrustAfterApprovals times, the
+// next identical call runs without an approval prompt, and that /trust can
+// list and revoke the trust.
+func (s *AgenticWorkflowTestSuite) TestLearnedTrust_SkipsApprovalAfterRepeatedApprovals() {
+	for _, id := range []string{"call-1", "call-2", "call-3"} {
+		s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+			Return(buildCallResponse(id), nil).Once()
+	}
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Built.", 10), nil).Once()
+
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{Content: "ok", Success: &trueVal}, nil).Times(3)
+
+	// Only the first two calls need approval; call-3 is trusted.
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-1", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-1"}})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateApprovalResponse, "approval-2", noopCallback(),
+			ApprovalResponse{Approved: []string{"call-2"}})
+	}, 4*time.Second)
+
+	var listed, afterRevoke UpdateTrustedCommandsResponse
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateTrustedCommands, "trust-list", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("trust list rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				listed = result.(UpdateTrustedCommandsResponse)
+			},
+		}, UpdateTrustedCommandsRequest{Action: TrustList})
+	}, 6*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateTrustedCommands, "trust-revoke", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("trust revoke rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				afterRevoke = result.(UpdateTrustedCommandsResponse)
+			},
+		}, UpdateTrustedCommandsRequest{Action: TrustRevoke, ID: 1})
+	}, 7*time.Second)
+
+	s.sendShutdown(8 * time.Second)
+
+	input := testInputWithApproval("Build it", models.ApprovalUnlessTrusted)
+	input.Config.TrustAfterApprovals = 2
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	var result WorkflowResult
+	require.NoError(s.T(), s.env.GetWorkflowResult(&result))
+	assert.Len(s.T(), result.ToolCallsExecuted, 3)
+
+	require.Len(s.T(), listed.Commands, 1)
+	assert.Equal(s.T(), "go build ./...", listed.Commands[0].Command)
+	assert.Empty(s.T(), afterRevoke.Commands)
+
+	var sawTrusted, sawRevoked bool
+	for _, item := range s.queryItems() {
+		if item.Type != models.ItemTypeAssistantMessage {
+			continue
+		}
+		if item.Content == "[Trust: shell_command `go build ./...` in /src approved 2 times; auto-approving it there for the rest of this session (#1, revoke with /trust)]" {
+			sawTrusted = true
+		}
+		if item.Content == formatTrustRevoked(TrustedCommand{ID: 1, Tool: "shell_command", Command: "go build ./..."}) {
+			sawRevoked = true
+		}
+	}
+	assert.True(s.T(), sawTrusted, "learned trust should be recorded in history")
+	assert.True(s.T(), sawRevoked, "revocation should be recorded in history")
+}
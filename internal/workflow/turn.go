@@ -25,23 +25,56 @@ import (
 func (s *SessionState) runAgenticTurn(ctx workflow.Context, ctrl *LoopControl) (bool, error) {
 	logger := workflow.GetLogger(ctx)
 	s.compactedThisTurn = false
-	gate := NewApprovalGate(s.Config.Permissions.ApprovalMode, s.ExecPolicyRules)
-	executor := NewToolsExecutor(s.ToolSpecs, s.Config.Cwd, s.Config.SessionTaskQueue)
+	s.snapshottedThisTurn = false
+	s.turnVerify = nil
+	s.turnChanged = false
+	s.turnReview = nil
+	s.turnOutputs = nil
+	s.beginTurnRouting(ctrl)
+	// Inline attachment data is for clients watching the turn live; drop
+	// what earlier turns produced so it does not pile up in workflow state.
+	_, _ = s.History.DropAttachmentData()
+	s.maybeRefreshClock(ctx, ctrl)
+	gate := NewApprovalGate(s.Config.Permissions.ApprovalMode, s.ExecPolicyRules).WithNetworkPolicy(s.Config.Permissions)
+	executor := NewToolsExecutor(s.ToolSpecs, s.Config.Cwd, s.Config.SessionTaskQueue).
+		WithCancellation(ctrl.IsToolCancelRequested).
+		WithSessionID(s.ConversationID).
+		WithTurnID(ctrl.CurrentTurnID()).
+		WithEnvPolicy(s.envPolicy).
+		WithSandboxPolicy(s.sandboxPolicy).
+		WithBrowserDomains(s.Config.Browser.AllowedDomains).
+		WithReadFileLimit(s.Config.ReadFileLimit).
+		WithPathRemaps(s.PathRemaps).
+		WithRetryPolicies(s.Config.RetryPolicies)
 	if len(s.McpToolLookup) > 0 {
 		executor.WithMcpContext(s.ConversationID, s.McpToolLookup)
 	}
+	if s.Config.DiffRepeatedOutput {
+		executor.WithPreviousOutputs(s.previousOutput)
+	}
 
 	for s.IterationCount < s.MaxIterations {
 		if ctrl.IsInterrupted() {
 			logger.Info("Turn interrupted")
 			return false, nil
 		}
+		if ctrl.AutonomyExpired() {
+			logger.Info("Autonomy budget spent, ending turn")
+			return false, nil
+		}
 		logger.Info("Starting iteration", "iteration", s.IterationCount, "turn_id", ctrl.CurrentTurnID())
 
+		s.maybeInjectEnvironmentContext(ctx, ctrl)
+		s.maybeWarnTurnDeadline(ctx, ctrl)
+		s.applyHistoryRetention(ctx)
 		s.maybeCompactBeforeLLM(ctx, ctrl)
 
 		llmResult, err := s.callLLM(ctx, ctrl)
 		if err != nil {
+			if ctrl.IsInterrupted() {
+				logger.Info("Turn interrupted during LLM call")
+				return false, nil
+			}
 			retry, handleErr := s.handleLLMError(ctx, ctrl, err)
 			if handleErr != nil {
 				return false, handleErr
@@ -98,6 +131,14 @@ func (s *SessionState) runAgenticTurn(ctx workflow.Context, ctrl *LoopControl) (
 
 		// No tool calls — check finish reason
 		if llmResult.FinishReason == models.FinishReasonStop {
+			if s.verifyTurn(ctx, ctrl) {
+				s.IterationCount++
+				continue
+			}
+			if s.reviewTurn(ctx, ctrl) {
+				s.IterationCount++
+				continue
+			}
 			logger.Info("Turn completed", "iterations", s.IterationCount, "turn_id", ctrl.CurrentTurnID())
 			return false, nil
 		}
@@ -141,6 +182,9 @@ func (s *SessionState) maybeCompactBeforeLLM(ctx workflow.Context, ctrl *LoopCon
 
 	limit := s.effectiveAutoCompactLimit()
 	logger := workflow.GetLogger(ctx)
+	if limit > 0 {
+		s.refreshExactTokenCounts(ctx)
+	}
 
 	if s.modelSwitched {
 		// Consume the flag so it fires only once.
@@ -197,45 +241,63 @@ func (s *SessionState) callLLM(ctx workflow.Context, ctrl *LoopControl) (*activi
 		return nil, fmt.Errorf("failed to get history: %w", err)
 	}
 
+	modelConfig := s.routeModel(ctx)
+	sameModel := s.LastCallModel == "" || s.LastCallModel == modelConfig.Model
+	s.LastCallModel = modelConfig.Model
+	s.lastCallProvider = modelConfig.Provider
+
 	var inputItems []models.ConversationItem
 	var previousResponseID string
-	if s.LastResponseID != "" && s.lastSentHistoryLen > 0 && s.lastSentHistoryLen <= len(historyItems) {
+	if sameModel && s.LastResponseID != "" && s.lastSentHistoryLen > 0 && s.lastSentHistoryLen <= len(historyItems) {
 		inputItems = historyItems[s.lastSentHistoryLen:]
 		previousResponseID = s.LastResponseID
 	} else {
 		inputItems = historyItems
 		previousResponseID = ""
 	}
+	inputItems = withAnnotationFeedback(inputItems)
 
 	llmActivityOptions := workflow.ActivityOptions{
 		// 90 s per attempt: generous enough for large responses while still
 		// cutting stalled connections quickly enough to retry within the TUI
 		// test's 2-minute EXPECT_TIMEOUT window.
 		StartToCloseTimeout: 90 * time.Second,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    500 * time.Millisecond, // fast first retry
-			BackoffCoefficient: 1.5,
-			MaximumInterval:    15 * time.Second,
-			MaximumAttempts:    5, // more budget for transient API errors
-		},
+		RetryPolicy:         withRetryOverride(defaultLLMRetryPolicy(), s.Config.RetryPolicies.LLM),
 	}
 	llmCtx := workflow.WithActivityOptions(ctx, llmActivityOptions)
 
 	ctrl.SetPhase(PhaseLLMCalling)
+	ctrl.SetPhaseTimer(workflow.Now(ctx), llmActivityOptions.StartToCloseTimeout)
 	ctrl.ClearToolsInFlight()
 
 	llmInput := activities.LLMActivityInput{
 		History:               inputItems,
-		ModelConfig:           s.Config.Model,
+		ModelConfig:           modelConfig,
 		ToolSpecs:             s.ToolSpecs,
 		BaseInstructions:      s.Config.BaseInstructions,
 		DeveloperInstructions: s.Config.DeveloperInstructions,
 		UserInstructions:      s.Config.UserInstructions,
 		PreviousResponseID:    previousResponseID,
-	}
+		TurnID:                ctrl.CurrentTurnID(),
+	}
+
+	// Cancel the LLM activity on interrupt so a long streaming response
+	// (e.g. a huge apply_patch) is aborted instead of awaited.
+	llmCtx, cancelLLM := workflow.WithCancel(llmCtx)
+	defer cancelLLM()
+	llmDone := false
+	workflow.Go(ctx, func(gctx workflow.Context) {
+		_ = workflow.Await(gctx, func() bool { return llmDone || ctrl.IsInterrupted() })
+		if !llmDone {
+			cancelLLM()
+		}
+	})
 
 	var llmResult activities.LLMActivityOutput
+	start := workflow.Now(ctx)
 	err = workflow.ExecuteActivity(llmCtx, "ExecuteLLMCall", llmInput).Get(ctx, &llmResult)
+	llmDone = true
+	s.recordLLMTime(workflow.Now(ctx).Sub(start))
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +305,7 @@ func (s *SessionState) callLLM(ctx workflow.Context, ctrl *LoopControl) (*activi
 }
 
 // handleLLMError classifies and handles LLM errors: context overflow -> compact+retry,
-// rate limit -> sleep+retry, fatal -> end turn. Returns (continueLoop, error).
+// rate limit -> wait+retry, fatal -> end turn. Returns (continueLoop, error).
 func (s *SessionState) handleLLMError(ctx workflow.Context, ctrl *LoopControl, err error) (bool, error) {
 	logger := workflow.GetLogger(ctx)
 
@@ -259,16 +321,21 @@ func (s *SessionState) handleLLMError(ctx workflow.Context, ctrl *LoopControl, e
 				if keepTurns < 2 {
 					keepTurns = 2
 				}
+				flushed := s.archiveTranscript(ctx, archiveReasonCompaction)
 				s.History.DropOldestUserTurns(keepTurns)
+				s.HistoryEpoch++
+				s.resetArchiveMark(flushed)
 			}
 			s.LastResponseID = ""
 			s.lastSentHistoryLen = 0
 			return true, nil // retry
 
 		case models.LLMErrTypeAPILimit:
-			logger.Warn("API rate limit, sleeping for 1 minute")
-			workflow.Sleep(ctx, time.Minute)
-			return true, nil // retry
+			delay := rateLimitDelay(appErr)
+			logger.Warn("API rate limit, waiting before retrying", "delay", delay)
+			ctrl.SetRateLimited(workflow.Now(ctx).Add(delay))
+			_, _ = workflow.AwaitWithTimeout(ctx, delay, ctrl.IsInterrupted)
+			return true, nil // retry (the loop ends the turn if interrupted)
 
 		case models.LLMErrTypeFatal:
 			logger.Error("Fatal LLM error, ending turn", "error", err)
@@ -293,18 +360,39 @@ func (s *SessionState) handleLLMError(ctx workflow.Context, ctrl *LoopControl, e
 	return false, nil // end turn
 }
 
+// Rate-limit waits. Without a hint from the provider's headers the call
+// waits defaultRateLimitDelay; hints are kept within the bounds.
+const (
+	defaultRateLimitDelay = time.Minute
+	minRateLimitDelay     = time.Second
+	maxRateLimitDelay     = 15 * time.Minute
+)
+
+// rateLimitDelay returns how long to wait before retrying a rate-limited
+// call: the provider's hint when the error carries one.
+func rateLimitDelay(appErr *temporal.ApplicationError) time.Duration {
+	var details models.LLMRateLimitDetails
+	if !appErr.HasDetails() || appErr.Details(&details) != nil || details.RetryAfterMs <= 0 {
+		return defaultRateLimitDelay
+	}
+	return min(max(details.RetryAfter(), minRateLimitDelay), maxRateLimitDelay)
+}
+
 // recordLLMResponse adds response items to history, tracks tokens, and updates
 // the response ID for incremental sends.
 func (s *SessionState) recordLLMResponse(ctx workflow.Context, ctrl *LoopControl, result *activities.LLMActivityOutput) {
 	logger := workflow.GetLogger(ctx)
 
-	s.TotalTokens += result.TokenUsage.TotalTokens
-	s.TotalCachedTokens += result.TokenUsage.CachedTokens
+	s.addTokenUsage(models.ModelConfig{Model: s.LastCallModel, Provider: s.lastCallProvider}, result.TokenUsage)
+	s.recordCacheUsage(result.TokenUsage)
+	s.recordPromptHash(result.PromptHash)
+	s.recordModelUsed(s.LastCallModel)
 	s.LastTokenUsage = result.TokenUsage
 	logger.Info("LLM call completed",
 		"tokens", result.TokenUsage.TotalTokens,
 		"cached_tokens", result.TokenUsage.CachedTokens,
 		"cache_creation_tokens", result.TokenUsage.CacheCreationTokens,
+		"prompt_hash", result.PromptHash,
 		"finish_reason", result.FinishReason,
 		"items", len(result.Items))
 
@@ -312,6 +400,7 @@ func (s *SessionState) recordLLMResponse(ctx workflow.Context, ctrl *LoopControl
 		_ = s.History.AddItem(item)
 		ctrl.NotifyItemAdded()
 	}
+	s.noteRequestedTools(result.Items)
 	if result.ResponseID != "" {
 		s.LastResponseID = result.ResponseID
 		allItems, _ := s.History.GetForPrompt()
@@ -319,8 +408,8 @@ func (s *SessionState) recordLLMResponse(ctx workflow.Context, ctrl *LoopControl
 	}
 }
 
-// dispatchInterceptedCalls processes workflow-handled tool calls (request_user_input
-// and collab tools), returning the remaining normal calls and whether any were intercepted.
+// dispatchInterceptedCalls processes workflow-handled tool calls (request_user_input,
+// ask_user, emit_result, update_plan, task_list, pin_context, current_time, share_artifact and collab tools), returning the remaining normal calls and whether any were intercepted.
 func (s *SessionState) dispatchInterceptedCalls(ctx workflow.Context, ctrl *LoopControl, calls []models.ConversationItem) (remaining []models.ConversationItem, hadIntercepted bool, err error) {
 	if len(calls) == 0 {
 		return calls, false, nil
@@ -338,6 +427,25 @@ func (s *SessionState) dispatchInterceptedCalls(ctx workflow.Context, ctrl *Loop
 				return nil, hadIntercepted, fmt.Errorf("failed to add user input response: %w", addErr)
 			}
 			ctrl.NotifyItemAdded()
+		} else if fc.Name == "ask_user" {
+			hadIntercepted = true
+			items, callErr := s.handleAskUser(ctx, ctrl, fc)
+			if callErr != nil {
+				return nil, hadIntercepted, callErr
+			}
+			for _, item := range items {
+				if addErr := s.History.AddItem(item); addErr != nil {
+					return nil, hadIntercepted, fmt.Errorf("failed to add ask_user response: %w", addErr)
+				}
+				ctrl.NotifyItemAdded()
+			}
+		} else if fc.Name == "emit_result" {
+			hadIntercepted = true
+			outputItem := s.handleEmitResult(ctx, fc)
+			if addErr := s.History.AddItem(outputItem); addErr != nil {
+				return nil, hadIntercepted, fmt.Errorf("failed to add emit_result response: %w", addErr)
+			}
+			ctrl.NotifyItemAdded()
 		} else if fc.Name == "update_plan" {
 			hadIntercepted = true
 			outputItem, callErr := s.handleUpdatePlan(ctx, fc)
@@ -348,6 +456,38 @@ func (s *SessionState) dispatchInterceptedCalls(ctx workflow.Context, ctrl *Loop
 				return nil, hadIntercepted, fmt.Errorf("failed to add update_plan response: %w", addErr)
 			}
 			ctrl.NotifyItemAdded()
+		} else if fc.Name == "task_list" {
+			hadIntercepted = true
+			outputItem := s.handleTaskList(ctx, fc)
+			if addErr := s.History.AddItem(outputItem); addErr != nil {
+				return nil, hadIntercepted, fmt.Errorf("failed to add task_list response: %w", addErr)
+			}
+			ctrl.NotifyItemAdded()
+		} else if fc.Name == "pin_context" {
+			hadIntercepted = true
+			outputItem := s.handlePinContext(ctx, fc)
+			if addErr := s.History.AddItem(outputItem); addErr != nil {
+				return nil, hadIntercepted, fmt.Errorf("failed to add pin_context response: %w", addErr)
+			}
+			if outputItem.Pinned {
+				// Pin the call along with its output.
+				_ = s.History.SetPinned(s.History.GetLatestSeq(), true)
+			}
+			ctrl.NotifyItemAdded()
+		} else if fc.Name == "current_time" {
+			hadIntercepted = true
+			outputItem := s.handleCurrentTime(ctx, fc)
+			if addErr := s.History.AddItem(outputItem); addErr != nil {
+				return nil, hadIntercepted, fmt.Errorf("failed to add current_time response: %w", addErr)
+			}
+			ctrl.NotifyItemAdded()
+		} else if fc.Name == "share_artifact" {
+			hadIntercepted = true
+			outputItem := s.handleShareArtifact(ctx, fc)
+			if addErr := s.History.AddItem(outputItem); addErr != nil {
+				return nil, hadIntercepted, fmt.Errorf("failed to add share_artifact response: %w", addErr)
+			}
+			ctrl.NotifyItemAdded()
 		} else if isCollabToolCall(fc.Name) {
 			hadIntercepted = true
 			outputItem, callErr := s.handleCollabToolCall(ctx, ctrl, fc)
@@ -365,9 +505,10 @@ func (s *SessionState) dispatchInterceptedCalls(ctx workflow.Context, ctrl *Loop
 	return normalCalls, hadIntercepted, nil
 }
 
-// approveAndExecuteTools runs the full pipeline: classify -> filter forbidden ->
+// approveAndExecuteTools runs the full pipeline: validate arguments -> classify -> filter forbidden ->
 // wait for approval -> execute -> escalate -> record results.
-// Returns (allDenied, error). allDenied=true means all tools were denied by user.
+// Returns (allDenied, error). allDenied=true means all tools were denied by user,
+// or the turn was interrupted before they ran; either way the turn ends.
 func (s *SessionState) approveAndExecuteTools(
 	ctx workflow.Context,
 	ctrl *LoopControl,
@@ -377,8 +518,21 @@ func (s *SessionState) approveAndExecuteTools(
 ) (bool, error) {
 	logger := workflow.GetLogger(ctx)
 
+	// Reject calls whose arguments don't match the tool's schema
+	functionCalls = s.recordInvalidAndFilter(ctrl, functionCalls)
+	if len(functionCalls) == 0 {
+		return false, nil // all invalid — iteration continues so the LLM can fix them
+	}
+
 	// Classify which tools need approval
 	needsApproval, forbiddenResults := gate.Classify(functionCalls)
+	if mode := s.Config.Autonomy.Approval; s.AutonomyRun != nil && mode != "" {
+		// An autonomous run may have its own approval mode; what the
+		// session's rules forbid stays forbidden.
+		needsApproval, _ = NewApprovalGate(mode, s.ExecPolicyRules).
+			WithNetworkPolicy(s.Config.Permissions).Classify(functionCalls)
+	}
+	needsApproval = s.skipTrusted(needsApproval)
 
 	// Record forbidden results and filter them out
 	functionCalls = s.recordForbiddenAndFilter(ctrl, functionCalls, forbiddenResults)
@@ -398,16 +552,43 @@ func (s *SessionState) approveAndExecuteTools(
 		}
 	}
 
+	// Let the user's pre_tool_use hooks block calls
+	functionCalls = s.applyPreToolHooks(ctx, ctrl, functionCalls)
+	if len(functionCalls) == 0 {
+		return false, nil // all blocked — iteration continues
+	}
+
+	// Approved rollbacks run in the workflow, ahead of the snapshot
+	functionCalls = s.runRollbackCalls(ctx, ctrl, functionCalls)
+	if len(functionCalls) == 0 {
+		return false, nil
+	}
+
+	// Snapshot the workspace before the turn's first mutating tool call
+	s.maybeSnapshotBeforeTools(ctx, ctrl, functionCalls)
+	s.noteWorkspaceChanges(functionCalls)
+
+	// Bring the code index up to date before a semantic search
+	s.maybeIndexBeforeTools(ctx, functionCalls)
+
+	// An interrupt may have landed after the approval, while the hooks,
+	// snapshot or index ran; the approved calls must not start.
+	if ctrl.IsInterrupted() {
+		logger.Info("Turn interrupted before tool execution", "count", len(functionCalls))
+		return true, nil
+	}
+
+	// In sandbox audit mode, record what the sandbox would have blocked
+	s.auditToolCalls(ctx, ctrl, functionCalls)
+
 	// Execute tools
 	ctrl.SetPhase(PhaseToolExecuting)
-	toolNames := make([]string, len(functionCalls))
-	for i, fc := range functionCalls {
-		toolNames[i] = fc.Name
-	}
-	ctrl.SetToolsInFlight(toolNames)
+	ctrl.SetToolsInFlight(executor.InFlight(workflow.Now(ctx), functionCalls))
 	logger.Info("Executing tools", "count", len(functionCalls))
 
-	toolResults, err := executor.ExecuteParallel(ctx, functionCalls)
+	toolsStart := workflow.Now(ctx)
+	toolResults, toolTimings, err := executor.ExecuteParallel(ctx, functionCalls)
+	s.recordToolTime(workflow.Now(ctx).Sub(toolsStart), toolTimings)
 	if err != nil {
 		_ = s.History.AddItem(models.ConversationItem{
 			Type:    models.ItemTypeAssistantMessage,
@@ -428,8 +609,20 @@ func (s *SessionState) approveAndExecuteTools(
 		}
 	}
 
+	// Offer the closest match of apply_patch hunks that did not apply
+	toolResults, err = s.handlePatchConflicts(ctx, ctrl, functionCalls, toolResults)
+	if err != nil {
+		return false, err
+	}
+
 	// Record results
+	s.discardAgentWorkspaceChanges(ctx)
+	s.trackIndexedEdits(functionCalls, toolResults)
 	s.recordToolResults(ctrl, functionCalls, toolResults)
+	s.recordToolMismatches(ctx, ctrl, functionCalls, toolResults)
+	s.rememberOutputs(functionCalls, toolResults)
+	s.trackExecSessions(ctx, functionCalls, toolResults)
+	s.applyPostToolHooks(ctx, ctrl, functionCalls, toolResults)
 	return false, nil
 }
 
@@ -473,7 +666,10 @@ func (s *SessionState) waitForApprovalAndFilter(
 	gate *ApprovalGate,
 	needsApproval []PendingApproval,
 ) ([]models.ConversationItem, error) {
-	resp, err := ctrl.AwaitApproval(ctx, needsApproval)
+	waitStart := workflow.Now(ctx)
+	s.describeApprovals(ctx, needsApproval)
+	resp, fellBack, err := s.awaitApproval(ctx, ctrl, needsApproval)
+	s.recordWaitTime(workflow.Now(ctx).Sub(waitStart))
 	if err != nil {
 		return nil, err
 	}
@@ -483,10 +679,17 @@ func (s *SessionState) waitForApprovalAndFilter(
 		return nil, nil
 	}
 
+	if !fellBack {
+		s.learnTrust(ctrl, needsApproval, resp)
+	}
+
 	// Apply decision
 	approved, deniedResults := gate.ApplyDecision(calls, resp)
 
 	for _, dr := range deniedResults {
+		if fellBack {
+			dr.Output.Content = approvalFallbackDeniedMessage
+		}
 		_ = s.History.AddItem(dr)
 		ctrl.NotifyItemAdded()
 	}
@@ -505,8 +708,10 @@ func (s *SessionState) recordToolResults(ctrl *LoopControl, calls []models.Conve
 			Type:   models.ItemTypeFunctionCallOutput,
 			CallID: result.CallID,
 			Output: &models.FunctionCallOutputPayload{
-				Content: result.Content,
-				Success: result.Success,
+				Content:     result.Content,
+				Success:     result.Success,
+				Redactions:  result.Redactions,
+				Attachments: result.Attachments,
 			},
 		}
 		_ = s.History.AddItem(item)
@@ -517,7 +722,13 @@ func (s *SessionState) recordToolResults(ctrl *LoopControl, calls []models.Conve
 // detectRepeatedToolCalls checks whether the current batch of tool calls is
 // identical to the previous batch. Returns true if the same batch has been
 // seen maxRepeatToolCalls times consecutively, indicating a tight loop.
+// Calls to tools the worker is known not to have are left out: they fail
+// fast with the list of tools to use instead.
 func (s *SessionState) detectRepeatedToolCalls(calls []models.ConversationItem) bool {
+	calls = s.withoutUnavailableTools(calls)
+	if len(calls) == 0 {
+		return false
+	}
 	key := toolCallsKey(calls)
 	if key == s.lastToolKey {
 		s.repeatCount++
@@ -0,0 +1,87 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// turn_deadline.go implements the per-turn soft deadline
+// (SessionConfiguration.TurnDeadline). Near the deadline the model is told
+// how much time is left and asked to wrap up; at the deadline the turn is
+// interrupted like a user interrupt and marked as timed out, so a runaway
+// turn cannot hold the worker indefinitely.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// turnTimedOut is the Content of the TurnComplete marker of a turn the
+// deadline interrupted (a user interrupt's is "interrupted").
+const turnTimedOut = "timed_out"
+
+// armTurnDeadline starts the deadline of the turn about to run.
+func (s *SessionState) armTurnDeadline(ctx workflow.Context, ctrl *LoopControl) {
+	d := s.Config.TurnDeadline
+	if !d.Enabled() {
+		return
+	}
+	ctrl.StartTurnDeadline(ctx, d.Limit()-d.Warning(), d.Limit())
+}
+
+// maybeWarnTurnDeadline tells the model to wrap up once the warning is due.
+// It is called before each LLM call, where a message cannot land between a
+// tool call and its output.
+func (s *SessionState) maybeWarnTurnDeadline(ctx workflow.Context, ctrl *LoopControl) {
+	if !ctrl.TurnDeadlineWarningDue() {
+		return
+	}
+	ctrl.ClearTurnDeadlineWarning()
+	left := ctrl.TurnDeadline().Sub(workflow.Now(ctx))
+	workflow.GetLogger(ctx).Info("Turn deadline approaching", "left", left)
+	_ = s.History.AddItem(models.ConversationItem{
+		Type: models.ItemTypeDeveloperMessage,
+		Content: fmt.Sprintf("You have %s left in this turn (turn deadline: %d minutes). Wrap up: finish or stop "+
+			"the current step and reply with what is done and what remains, instead of starting new work. "+
+			"The turn is interrupted when the time runs out.", formatTimeLeft(left), s.Config.TurnDeadline.Minutes),
+		TurnID: ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+}
+
+// endTurnDeadline stops the deadline of the turn that just ended. A turn
+// the deadline interrupted gets a note for the model and the user, and its
+// timed-out TurnComplete marker.
+func (s *SessionState) endTurnDeadline(ctx workflow.Context, ctrl *LoopControl) {
+	expired := ctrl.TurnDeadlineExpired()
+	ctrl.StopTurnDeadline()
+	if !expired {
+		return
+	}
+	workflow.GetLogger(ctx).Info("Turn interrupted by its deadline", "minutes", s.Config.TurnDeadline.Minutes)
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeAssistantMessage,
+		Content: fmt.Sprintf("[Turn ended: the %d-minute turn deadline passed.]", s.Config.TurnDeadline.Minutes),
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeTurnComplete,
+		TurnID:  ctrl.CurrentTurnID(),
+		Content: turnTimedOut,
+	})
+	ctrl.NotifyItemAdded()
+}
+
+// formatTimeLeft renders the time before the deadline for the warning.
+func formatTimeLeft(d time.Duration) string {
+	switch minutes := int(d.Round(time.Minute) / time.Minute); {
+	case d < time.Minute:
+		return "less than a minute"
+	case minutes == 1:
+		return "about 1 minute"
+	default:
+		return fmt.Sprintf("about %d minutes", minutes)
+	}
+}
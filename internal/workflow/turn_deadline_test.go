@@ -0,0 +1,76 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestFormatTimeLeft(t *testing.T) {
+	assert.Equal(t, "less than a minute", formatTimeLeft(40*time.Second))
+	assert.Equal(t, "about 1 minute", formatTimeLeft(80*time.Second))
+	assert.Equal(t, "about 2 minutes", formatTimeLeft(2*time.Minute))
+}
+
+// TestTurnDeadline_WarnsThenInterrupts verifies that a turn running past
+// its deadline gets a wrap-up warning before its next LLM call and is then
+// interrupted and marked as timed out.
+func (s *AgenticWorkflowTestSuite) TestTurnDeadline_WarnsThenInterrupts() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMCallResponse("call-1", "shell_command", `{"command": "make slow-step"}`), nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMCallResponse("call-2", "shell_command", `{"command": "make another-slow-step"}`), nil).Once()
+	trueVal := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).After(100*time.Second).
+		Return(activities.ToolActivityOutput{Content: "done", Success: &trueVal}, nil)
+	s.sendShutdown(10 * time.Minute)
+
+	input := testInput("Run the slow build")
+	input.Config.Tools.EnabledTools = append(input.Config.Tools.EnabledTools, "shell_command")
+	input.Config.Permissions.ApprovalMode = models.ApprovalNever
+	input.Config.TurnDeadline = models.TurnDeadline{Minutes: 3}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var warning, ended string
+	var marker *models.ConversationItem
+	warningAt, secondCallAt := -1, -1
+	items := s.queryItems()
+	for i, item := range items {
+		switch {
+		case item.Type == models.ItemTypeDeveloperMessage && strings.HasPrefix(item.Content, "You have"):
+			warning, warningAt = item.Content, i
+		case item.Type == models.ItemTypeFunctionCall && item.CallID == "call-2":
+			secondCallAt = i
+		case item.Type == models.ItemTypeAssistantMessage:
+			ended = item.Content
+		case item.Type == models.ItemTypeTurnComplete:
+			marker = &items[i]
+		}
+	}
+	require.NotEqual(s.T(), -1, warningAt)
+	assert.Less(s.T(), warningAt, secondCallAt, "the warning precedes the LLM call after it was due")
+	assert.Contains(s.T(), warning, "You have about 1 minute left in this turn (turn deadline: 3 minutes). Wrap up")
+	assert.Equal(s.T(), "[Turn ended: the 3-minute turn deadline passed.]", ended)
+	require.NotNil(s.T(), marker)
+	assert.Equal(s.T(), turnTimedOut, marker.Content)
+}
+
+// TestTurnDeadline_InvalidFailsAtStart verifies a bad deadline fails the
+// workflow up front.
+func (s *AgenticWorkflowTestSuite) TestTurnDeadline_InvalidFailsAtStart() {
+	input := testInput("hi")
+	input.Config.TurnDeadline = models.TurnDeadline{Minutes: 2, WarnMinutes: 5}
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	err := s.env.GetWorkflowError()
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid turn deadline")
+}
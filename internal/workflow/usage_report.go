@@ -0,0 +1,166 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// usage_report.go implements UsageReportWorkflow, which rolls the results of
+// sessions completed in a period up into a usage report (tokens, cache use,
+// estimated cost and tool calls by day, team and model; see internal/usage)
+// and optionally stores it in the archive or POSTs it to a webhook. Run it
+// daily or weekly from a Temporal Schedule, or on demand with
+// `client usage`.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/usage"
+)
+
+// DefaultUsageReportDays is the period of a report without an explicit
+// window: the previous UTC day.
+const DefaultUsageReportDays = 1
+
+// UsageReportInput is the input to UsageReportWorkflow.
+type UsageReportInput struct {
+	// Since and Until bound the report to sessions that ended in
+	// [Since, Until). When Since is unset, the report covers the Days whole
+	// UTC days before the run's start, which suits a daily or weekly
+	// schedule. Until defaults to the run's start.
+	Since time.Time `json:"since,omitzero"`
+	Until time.Time `json:"until,omitzero"`
+	Days  int       `json:"days,omitempty"` // Default DefaultUsageReportDays
+
+	// Prices are USD rates per million tokens by model, for the cost
+	// estimate. Sessions of other models are reported as unpriced.
+	Prices map[string]usage.Price `json:"prices,omitempty"`
+
+	// TeamTagPrefix marks the session tag naming its team (default "team:").
+	TeamTagPrefix string `json:"team_tag_prefix,omitempty"`
+
+	// ArchiveURL stores the report under usage/ in this archive
+	// (s3://, gs:// or file://, as archive_url).
+	ArchiveURL string `json:"archive_url,omitempty"`
+
+	// WebhookURL receives the report as a JSON POST, with WebhookHeaders.
+	WebhookURL     string            `json:"webhook_url,omitempty"`
+	WebhookHeaders map[string]string `json:"webhook_headers,omitempty"`
+}
+
+// usageReportWindow resolves the report period from input and the run's
+// start time.
+func usageReportWindow(input UsageReportInput, now time.Time) (since, until time.Time, err error) {
+	until = input.Until
+	if input.Since.IsZero() {
+		days := input.Days
+		if days <= 0 {
+			days = DefaultUsageReportDays
+		}
+		if until.IsZero() {
+			until = now.UTC().Truncate(24 * time.Hour)
+		}
+		return until.AddDate(0, 0, -days), until, nil
+	}
+	if until.IsZero() {
+		until = now
+	}
+	if !input.Since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("since (%s) must be before until (%s)",
+			input.Since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+	return input.Since, until, nil
+}
+
+// UsageReportWorkflow builds a usage report of the sessions that ended in
+// the input's period and publishes it to the configured targets.
+func UsageReportWorkflow(ctx workflow.Context, input UsageReportInput) (usage.Report, error) {
+	logger := workflow.GetLogger(ctx)
+	now := workflow.Now(ctx)
+	since, until, err := usageReportWindow(input, now)
+	if err != nil {
+		return usage.Report{}, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidUsageReport", nil)
+	}
+
+	collectCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Minute,
+		HeartbeatTimeout:    time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 3},
+	})
+	var collected activities.CollectSessionUsageOutput
+	if err := workflow.ExecuteActivity(collectCtx, "CollectSessionUsage", activities.CollectSessionUsageInput{
+		Since: since,
+		Until: until,
+	}).Get(ctx, &collected); err != nil {
+		return usage.Report{}, fmt.Errorf("collect session usage: %w", err)
+	}
+
+	report := usage.Build(collected.Sessions, usage.Options{
+		Since:         since,
+		Until:         until,
+		GeneratedAt:   now,
+		Prices:        input.Prices,
+		TeamTagPrefix: input.TeamTagPrefix,
+	})
+	report.Truncated = collected.Truncated
+	report.Skipped = collected.Skipped
+	logger.Info("Usage report built", "since", since, "until", until,
+		"sessions", report.Sessions, "tokens", report.TotalTokens)
+
+	if input.ArchiveURL == "" && input.WebhookURL == "" {
+		return report, nil
+	}
+	publishCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 5 * time.Second,
+			MaximumAttempts: 5,
+		},
+	})
+	var published activities.PublishUsageReportOutput
+	if err := workflow.ExecuteActivity(publishCtx, "PublishUsageReport", activities.PublishUsageReportInput{
+		ArchiveURL:     input.ArchiveURL,
+		WebhookURL:     input.WebhookURL,
+		WebhookHeaders: input.WebhookHeaders,
+		Report:         report,
+	}).Get(ctx, &published); err != nil {
+		return report, fmt.Errorf("publish usage report: %w", err)
+	}
+	if published.Key != "" {
+		logger.Info("Usage report archived", "key", published.Key)
+	}
+	return report, nil
+}
+
+// recordModelUsed notes a model that served an LLM call, for the usage
+// report's model breakdown.
+func (s *SessionState) recordModelUsed(model string) {
+	if model != "" && !slices.Contains(s.ModelsUsed, model) {
+		s.ModelsUsed = append(s.ModelsUsed, model)
+	}
+}
+
+// addTokenUsage counts an LLM call's tokens toward the session totals and
+// toward the model that served it, so usage reports price each model's
+// tokens at its own rate.
+func (s *SessionState) addTokenUsage(mc models.ModelConfig, u models.TokenUsage) {
+	s.TotalTokens += u.TotalTokens
+	s.TotalCachedTokens += u.CachedTokens
+	if mc.Model == "" || u.TotalTokens == 0 {
+		return
+	}
+	i := slices.IndexFunc(s.UsageByModel, func(m usage.ModelUsage) bool { return m.Model == mc.Model })
+	if i < 0 {
+		i = len(s.UsageByModel)
+		s.UsageByModel = append(s.UsageByModel, usage.ModelUsage{Model: mc.Model})
+	}
+	m := &s.UsageByModel[i]
+	m.TotalTokens += u.TotalTokens
+	m.InputTokens += inputTokens(mc.Provider, u)
+	m.CachedTokens += u.CachedTokens
+}
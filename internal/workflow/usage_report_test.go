@@ -0,0 +1,113 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/usage"
+)
+
+func TestUsageReportWindow(t *testing.T) {
+	now := time.Date(2026, 3, 4, 1, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	since, until, err := usageReportWindow(UsageReportInput{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, day(3), since, "the previous UTC day by default")
+	assert.Equal(t, day(4), until)
+
+	since, until, err = usageReportWindow(UsageReportInput{Days: 7}, now)
+	require.NoError(t, err)
+	assert.Equal(t, day(4).AddDate(0, 0, -7), since)
+	assert.Equal(t, day(4), until)
+
+	since, until, err = usageReportWindow(UsageReportInput{Since: day(1)}, now)
+	require.NoError(t, err)
+	assert.Equal(t, day(1), since)
+	assert.Equal(t, now, until, "an explicit Since runs up to now")
+
+	_, _, err = usageReportWindow(UsageReportInput{Since: day(4), Until: day(2)}, now)
+	assert.ErrorContains(t, err, "must be before until")
+}
+
+func TestAddTokenUsage(t *testing.T) {
+	s := &SessionState{}
+	s.addTokenUsage(models.ModelConfig{Model: "claude-sonnet-4.5", Provider: "anthropic"},
+		models.TokenUsage{TotalTokens: 1000, PromptTokens: 200, CachedTokens: 600, CacheCreationTokens: 100})
+	s.addTokenUsage(models.ModelConfig{Model: "gpt-4o-mini", Provider: "openai"},
+		models.TokenUsage{TotalTokens: 300, PromptTokens: 250, CachedTokens: 50})
+	s.addTokenUsage(models.ModelConfig{Model: "claude-sonnet-4.5", Provider: "anthropic"},
+		models.TokenUsage{TotalTokens: 100, PromptTokens: 80})
+
+	assert.Equal(t, 1400, s.TotalTokens)
+	assert.Equal(t, 650, s.TotalCachedTokens)
+	assert.Equal(t, []usage.ModelUsage{
+		{Model: "claude-sonnet-4.5", TotalTokens: 1100, InputTokens: 980, CachedTokens: 600},
+		{Model: "gpt-4o-mini", TotalTokens: 300, InputTokens: 250, CachedTokens: 50},
+	}, s.UsageByModel)
+}
+
+// TestUsageReport_BuildsAndPublishes verifies that the workflow rolls the
+// collected sessions up and hands the report to PublishUsageReport.
+func (s *AgenticWorkflowTestSuite) TestUsageReport_BuildsAndPublishes() {
+	start := s.env.Now().UTC()
+	var collectInput activities.CollectSessionUsageInput
+	s.env.OnActivity("CollectSessionUsage", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, input activities.CollectSessionUsageInput) (activities.CollectSessionUsageOutput, error) {
+			collectInput = input
+			return activities.CollectSessionUsageOutput{
+				Sessions: []usage.Session{
+					{WorkflowID: "a", ClosedAt: input.Since.Add(time.Hour), Model: "m", Tags: []string{"team:payments"}, TotalTokens: 1_000_000, InputTokens: 1_000_000},
+					{WorkflowID: "b", ClosedAt: input.Since.Add(2 * time.Hour), Model: "other", TotalTokens: 500},
+				},
+				Skipped: 1,
+			}, nil
+		}).Once()
+	var published activities.PublishUsageReportInput
+	s.env.OnActivity("PublishUsageReport", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, input activities.PublishUsageReportInput) (activities.PublishUsageReportOutput, error) {
+			published = input
+			return activities.PublishUsageReportOutput{Key: "usage/x.json"}, nil
+		}).Once()
+
+	s.env.ExecuteWorkflow(UsageReportWorkflow, UsageReportInput{
+		Prices:     map[string]usage.Price{"m": {Input: 2, Output: 8}},
+		WebhookURL: "https://example.com/usage",
+	})
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+
+	var report usage.Report
+	require.NoError(s.T(), s.env.GetWorkflowResult(&report))
+	today := start.Truncate(24 * time.Hour)
+	assert.Equal(s.T(), today.AddDate(0, 0, -1), collectInput.Since)
+	assert.Equal(s.T(), today, collectInput.Until)
+	assert.Equal(s.T(), 2, report.Sessions)
+	assert.Equal(s.T(), 1, report.Skipped)
+	assert.InDelta(s.T(), 2.0, report.CostUSD, 1e-9)
+	assert.Equal(s.T(), 1, report.Unpriced)
+	require.Len(s.T(), report.Teams, 2)
+	assert.Equal(s.T(), "payments", report.Teams[0].Name)
+
+	assert.Equal(s.T(), "https://example.com/usage", published.WebhookURL)
+	assert.Equal(s.T(), report.Sessions, published.Report.Sessions)
+}
+
+// TestUsageReport_NoTargetsSkipsPublish verifies that a report without an
+// archive or webhook is only returned.
+func (s *AgenticWorkflowTestSuite) TestUsageReport_NoTargetsSkipsPublish() {
+	s.env.OnActivity("CollectSessionUsage", mock.Anything, mock.Anything).
+		Return(activities.CollectSessionUsageOutput{}, nil).Once()
+
+	s.env.ExecuteWorkflow(UsageReportWorkflow, UsageReportInput{Days: 7})
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+	require.NoError(s.T(), s.env.GetWorkflowError())
+	s.env.AssertNotCalled(s.T(), "PublishUsageReport", mock.Anything, mock.Anything)
+}
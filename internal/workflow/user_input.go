@@ -43,7 +43,9 @@ func (s *SessionState) handleRequestUserInput(ctx workflow.Context, ctrl *LoopCo
 	}
 
 	// Delegate blocking wait to LoopControl
+	waitStart := workflow.Now(ctx)
 	resp, err := ctrl.AwaitUserInputQuestion(ctx, req)
+	s.recordWaitTime(workflow.Now(ctx).Sub(waitStart))
 	if err != nil {
 		return models.ConversationItem{}, fmt.Errorf("user input await failed: %w", err)
 	}
@@ -0,0 +1,98 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// user_shell.go runs shell commands the user types directly (/!cmd, the
+// user_shell Update). The command goes through the ExecuteTool activity as a
+// shell_command call, under the session's sandbox, environment and exec
+// policy, and is recorded as a user_shell_command item, which the model sees
+// as a user message in later turns.
+//
+// Maps to: codex-rs/core/src/tasks/user_shell.rs UserShellCommandTask
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/tools"
+)
+
+// UserShellRequest is the payload for the user_shell Update.
+type UserShellRequest struct {
+	Command string `json:"command"`
+}
+
+// UserShellResponse is returned by the user_shell Update.
+type UserShellResponse struct {
+	Output  string `json:"output"`
+	Success bool   `json:"success"`
+}
+
+// validateUserShell reports whether the user can run a command now: only
+// between turns, so its output does not land in the middle of one.
+func (s *SessionState) validateUserShell(ctrl *LoopControl, req UserShellRequest) error {
+	switch {
+	case ctrl.IsShutdown():
+		return fmt.Errorf("session is shutting down")
+	case strings.TrimSpace(req.Command) == "":
+		return fmt.Errorf("command is required")
+	case ctrl.Phase() != PhaseWaitingForInput || ctrl.HasPendingWork() || s.AutonomyRun != nil:
+		return fmt.Errorf("a turn is in progress; wait for it to finish or interrupt it")
+	case ctrl.TurnStartHeld():
+		return fmt.Errorf("another command is running; wait for it to finish")
+	}
+	return nil
+}
+
+// runUserShell runs the user's command on the session's worker and records
+// it in history. The user typing the command stands in for approving it,
+// but a command the exec policy forbids is refused. Turn start is held
+// until it is done, so input sent meanwhile sees the command's output.
+func (s *SessionState) runUserShell(ctx workflow.Context, ctrl *LoopControl, command string) (UserShellResponse, error) {
+	release := ctrl.HoldTurnStart()
+	defer release()
+	command = strings.TrimSpace(command)
+	args, _ := json.Marshal(map[string]string{"command": command})
+	call := models.ConversationItem{
+		Type:      models.ItemTypeFunctionCall,
+		CallID:    fmt.Sprintf("user-shell-%d", workflow.Now(ctx).UnixNano()),
+		Name:      "shell_command",
+		Arguments: string(args),
+	}
+
+	gate := NewApprovalGate(s.Config.Permissions.ApprovalMode, s.ExecPolicyRules).WithNetworkPolicy(s.Config.Permissions)
+	if _, forbidden := gate.Classify([]models.ConversationItem{call}); len(forbidden) > 0 {
+		return UserShellResponse{}, fmt.Errorf("command forbidden by the exec policy: %s", command)
+	}
+
+	executor := NewToolsExecutor([]tools.ToolSpec{tools.NewShellCommandToolSpec(false)}, s.Config.Cwd, s.Config.SessionTaskQueue).
+		WithSessionID(s.ConversationID).
+		WithTurnID(ctrl.CurrentTurnID()).
+		WithEnvPolicy(s.envPolicy).
+		WithSandboxPolicy(s.sandboxPolicy)
+	results, _, err := executor.ExecuteParallel(ctx, []models.ConversationItem{call})
+	if err != nil {
+		return UserShellResponse{}, err
+	}
+	result := results[0]
+	success := result.Success == nil || *result.Success
+
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:      models.ItemTypeUserShellCommand,
+		CallID:    call.CallID,
+		Arguments: call.Arguments,
+		Output: &models.FunctionCallOutputPayload{
+			Content:    result.Content,
+			Success:    &success,
+			Redactions: result.Redactions,
+		},
+		TurnID: ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+
+	workflow.GetLogger(ctx).Info("User shell command completed", "success", success)
+	return UserShellResponse{Output: result.Content, Success: success}, nil
+}
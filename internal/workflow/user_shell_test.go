@@ -0,0 +1,109 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// TestUserShell_RunsCommandAndRecordsIt verifies that user_shell runs the
+// command through ExecuteTool and that the model sees it on the next turn.
+func (s *AgenticWorkflowTestSuite) TestUserShell_RunsCommandAndRecordsIt() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	var secondCall activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("what changed?")).
+		Run(func(args mock.Arguments) { secondCall = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("One file.", 10), nil).Once()
+
+	var toolInput activities.ToolActivityInput
+	ok := false
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { toolInput = args.Get(1).(activities.ToolActivityInput) }).
+		Return(activities.ToolActivityOutput{Content: "Exit code: 1\nOutput:\n M main.go\n", Success: &ok}, nil).Once()
+
+	var resp UserShellResponse
+	var emptyErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserShell, "shell-empty", s.rejectingCallback(&emptyErr), UserShellRequest{Command: "  "})
+		s.env.UpdateWorkflow(UpdateUserShell, "shell-1", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnReject: func(err error) { s.Fail("user_shell rejected", err.Error()) },
+			OnComplete: func(result interface{}, err error) {
+				require.NoError(s.T(), err)
+				resp = result.(UserShellResponse)
+			},
+		}, UserShellRequest{Command: "git status --short"})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "what changed?"})
+	}, 2*time.Second)
+	s.sendShutdown(time.Minute)
+
+	input := testInputWithApproval("Hello", models.ApprovalNever)
+	input.Config.Cwd = "/repo"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Error(s.T(), emptyErr)
+	assert.Contains(s.T(), emptyErr.Error(), "command is required")
+
+	assert.Equal(s.T(), "shell_command", toolInput.ToolName)
+	assert.Equal(s.T(), "git status --short", toolInput.Arguments["command"])
+	assert.Equal(s.T(), "/repo", toolInput.Cwd)
+	assert.Equal(s.T(), UserShellResponse{Output: "Exit code: 1\nOutput:\n M main.go\n", Success: false}, resp)
+
+	var recorded *models.ConversationItem
+	for i, item := range secondCall.History {
+		if item.Type == models.ItemTypeUserShellCommand {
+			recorded = &secondCall.History[i]
+		}
+	}
+	require.NotNil(s.T(), recorded, "the command is in the next turn's history")
+	assert.Equal(s.T(), "git status --short", recorded.UserShellCommand())
+	assert.Contains(s.T(), models.FormatUserShellCommand(*recorded), "M main.go")
+}
+
+// TestUserShell_HoldsTurnStart verifies that input sent while a user_shell
+// command runs starts its turn after the command is recorded.
+func (s *AgenticWorkflowTestSuite) TestUserShell_HoldsTurnStart() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Hello!", 10), nil).Once()
+	var secondCall activities.LLMActivityInput
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, historyHasUserText("did it pass?")).
+		Run(func(args mock.Arguments) { secondCall = args.Get(1).(activities.LLMActivityInput) }).
+		Return(mockLLMStopResponse("Yes.", 10), nil).Once()
+
+	ok := true
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).After(10*time.Second).
+		Return(activities.ToolActivityOutput{Content: "Exit code: 0\nOutput:\nPASS\n", Success: &ok}, nil).Once()
+
+	var secondShellErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserShell, "shell-1", noopCallback(), UserShellRequest{Command: "go test ./..."})
+	}, time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserShell, "shell-2", s.rejectingCallback(&secondShellErr), UserShellRequest{Command: "ls"})
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "did it pass?"})
+	}, 2*time.Second)
+	s.sendShutdown(time.Minute)
+
+	input := testInputWithApproval("Hello", models.ApprovalNever)
+	input.Config.Cwd = "/repo"
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	require.Error(s.T(), secondShellErr)
+	assert.Contains(s.T(), secondShellErr.Error(), "another command is running")
+	var recorded bool
+	for _, item := range secondCall.History {
+		recorded = recorded || item.Type == models.ItemTypeUserShellCommand
+	}
+	assert.True(s.T(), recorded, "the turn starts after the command is recorded")
+}
@@ -0,0 +1,131 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// verify.go implements the auto-verify loop ("fix until green"): when the
+// model ends a turn, SessionConfiguration.AutoVerifyCommand is run through
+// the shell_command tool activity. A failure is fed back as a user message
+// and the turn continues, up to MaxVerifyIterations runs per turn. The
+// outcome is recorded on the turn's TurnComplete item.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+// DefaultMaxVerifyIterations is used when MaxVerifyIterations is 0.
+const DefaultMaxVerifyIterations = 3
+
+// verifyTimeoutMs bounds one run of the verify command.
+const verifyTimeoutMs = 10 * 60 * 1000
+
+// maxVerifyFeedbackChars caps the command output fed back to the model.
+// The tail is kept, since test runners print failures and summaries last.
+const maxVerifyFeedbackChars = 8000
+
+// maxVerifyIterations returns the configured cap on verify runs per turn.
+func (s *SessionState) maxVerifyIterations() int {
+	if s.Config.MaxVerifyIterations > 0 {
+		return s.Config.MaxVerifyIterations
+	}
+	return DefaultMaxVerifyIterations
+}
+
+// verifyTurn runs the verify command after the model ended the turn.
+// Returns true when the command failed and attempts remain, in which case
+// the failure has been added to history and the turn should continue.
+func (s *SessionState) verifyTurn(ctx workflow.Context, ctrl *LoopControl) bool {
+	command := s.Config.AutoVerifyCommand
+	if command == "" {
+		return false
+	}
+	logger := workflow.GetLogger(ctx)
+
+	if s.turnVerify == nil {
+		s.turnVerify = &models.VerifyResult{Command: command}
+	}
+	s.turnVerify.Attempts++
+	attempt := s.turnVerify.Attempts
+
+	args, _ := json.Marshal(map[string]interface{}{
+		"command":    command,
+		"timeout_ms": verifyTimeoutMs,
+	})
+	call := models.ConversationItem{
+		Type:      models.ItemTypeFunctionCall,
+		CallID:    fmt.Sprintf("verify-%s-%d", ctrl.CurrentTurnID(), attempt),
+		Name:      "shell_command",
+		Arguments: string(args),
+	}
+
+	start := workflow.Now(ctx)
+	ctrl.SetPhase(PhaseVerifying)
+	ctrl.SetToolsInFlight([]ToolInFlight{{
+		Name:      "shell_command",
+		CallID:    call.CallID,
+		StartedAt: start,
+		Timeout:   verifyTimeoutMs * time.Millisecond,
+	}})
+	results, timings, _ := executeToolsInParallel(ctx, []models.ConversationItem{call}, toolRunOptions{
+		toolSpecs:        s.ToolSpecs,
+		cwd:              s.Config.Cwd,
+		sessionTaskQueue: s.Config.SessionTaskQueue,
+		turnID:           ctrl.CurrentTurnID(),
+		envPolicy:        s.envPolicy(),
+		sandboxPolicy:    s.sandboxPolicy(),
+		cancelRequested:  ctrl.IsToolCancelRequested,
+		retryPolicies:    s.Config.RetryPolicies,
+	})
+	s.recordToolTime(workflow.Now(ctx).Sub(start), timings)
+	ctrl.ClearToolsInFlight()
+
+	result := results[0]
+	if result.Success != nil && *result.Success {
+		logger.Info("Auto-verify passed", "attempt", attempt)
+		s.turnVerify.Status = models.VerifyPassed
+		return false
+	}
+
+	if ctrl.IsToolCancelRequested(call.CallID) {
+		// The user stopped the run (cancel_tool); do not feed it back.
+		logger.Info("Auto-verify cancelled", "attempt", attempt)
+		s.turnVerify.Status = models.VerifyFailed
+		return false
+	}
+
+	maxAttempts := s.maxVerifyIterations()
+	if attempt >= maxAttempts || ctrl.IsInterrupted() {
+		logger.Warn("Auto-verify still failing, ending turn", "attempts", attempt)
+		s.turnVerify.Status = models.VerifyFailed
+		return false
+	}
+
+	logger.Info("Auto-verify failed, continuing turn", "attempt", attempt)
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeUserMessage,
+		Content: formatVerifyFailure(command, result.Content, attempt, maxAttempts),
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+	return true
+}
+
+// formatVerifyFailure builds the synthetic user message for a failed run.
+func formatVerifyFailure(command, output string, attempt, maxAttempts int) string {
+	if len(output) > maxVerifyFeedbackChars {
+		cut := len(output) - maxVerifyFeedbackChars
+		for cut < len(output) && !utf8.RuneStart(output[cut]) {
+			cut++
+		}
+		output = "…" + output[cut:]
+	}
+	return fmt.Sprintf("[Auto-verify %d/%d] `%s` failed:\n\n```\n%s\n```\n\nFix the failures, then finish your turn; the command will be run again.",
+		attempt, maxAttempts, command, output)
+}
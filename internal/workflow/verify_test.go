@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+)
+
+func TestFormatVerifyFailure(t *testing.T) {
+	msg := formatVerifyFailure("go test ./...", "FAIL: TestFoo", 1, 3)
+	assert.Contains(t, msg, "[Auto-verify 1/3] `go test ./...` failed")
+	assert.Contains(t, msg, "FAIL: TestFoo")
+
+	long := strings.Repeat("x", maxVerifyFeedbackChars) + "TAIL"
+	msg = formatVerifyFailure("make", long, 2, 3)
+	assert.Contains(t, msg, "TAIL")
+	assert.Less(t, len(msg), maxVerifyFeedbackChars+200)
+}
+
+// verifyToolOutput returns a shell_command result for the verify command.
+func verifyToolOutput(success bool, content string) activities.ToolActivityOutput {
+	return activities.ToolActivityOutput{Content: content, Success: &success}
+}
+
+// isVerifyCall matches the ExecuteTool activity for the verify command.
+func isVerifyCall(in activities.ToolActivityInput) bool {
+	return in.ToolName == "shell_command" && strings.HasPrefix(in.CallID, "verify-")
+}
+
+// turnCompleteItem returns the last TurnComplete item in items.
+func turnCompleteItem(items []models.ConversationItem) *models.ConversationItem {
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == models.ItemTypeTurnComplete {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// TestAutoVerify_FailureFedBackUntilGreen verifies that a failing verify
+// command is fed back as a user message and the turn continues until the
+// command passes.
+func (s *AgenticWorkflowTestSuite) TestAutoVerify_FailureFedBackUntilGreen() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 20), nil).Twice()
+
+	var verifyInput activities.ToolActivityInput
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(isVerifyCall)).
+		Run(func(args mock.Arguments) {
+			verifyInput = args.Get(1).(activities.ToolActivityInput)
+		}).
+		Return(verifyToolOutput(false, "--- FAIL: TestAdd"), nil).Once()
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(isVerifyCall)).
+		Return(verifyToolOutput(true, "ok"), nil).Once()
+
+	s.sendShutdown(time.Second * 5)
+
+	input := testInput("Fix the tests")
+	input.Config.AutoVerifyCommand = "go test ./..."
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	assert.Equal(s.T(), "go test ./...", verifyInput.Arguments["command"])
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteLLMCall", 2)
+
+	items := s.queryItems()
+	var feedback *models.ConversationItem
+	for i := range items {
+		if items[i].Type == models.ItemTypeUserMessage && strings.HasPrefix(items[i].Content, "[Auto-verify 1/3]") {
+			feedback = &items[i]
+		}
+	}
+	require.NotNil(s.T(), feedback, "verify failure should be fed back as a user message")
+	assert.Contains(s.T(), feedback.Content, "--- FAIL: TestAdd")
+
+	tc := turnCompleteItem(items)
+	require.NotNil(s.T(), tc)
+	require.NotNil(s.T(), tc.Verify)
+	assert.Equal(s.T(), 2, tc.Verify.Attempts)
+	assert.Equal(s.T(), models.VerifyPassed, tc.Verify.Status)
+}
+
+// TestAutoVerify_StopsAtMaxIterations verifies that the turn ends once
+// MaxVerifyIterations runs have failed.
+func (s *AgenticWorkflowTestSuite) TestAutoVerify_StopsAtMaxIterations() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 20), nil)
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.MatchedBy(isVerifyCall)).
+		Return(verifyToolOutput(false, "build failed"), nil)
+
+	s.sendShutdown(time.Second * 5)
+
+	input := testInput("Fix the build")
+	input.Config.AutoVerifyCommand = "make"
+	input.Config.MaxVerifyIterations = 2
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteLLMCall", 2)
+	s.env.AssertNumberOfCalls(s.T(), "ExecuteTool", 2)
+
+	tc := turnCompleteItem(s.queryItems())
+	require.NotNil(s.T(), tc)
+	require.NotNil(s.T(), tc.Verify)
+	assert.Equal(s.T(), 2, tc.Verify.Attempts)
+	assert.Equal(s.T(), models.VerifyFailed, tc.Verify.Status)
+}
+
+// TestAutoVerify_DisabledByDefault verifies no verify command runs unless
+// AutoVerifyCommand is set.
+func (s *AgenticWorkflowTestSuite) TestAutoVerify_DisabledByDefault() {
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 20), nil).Once()
+
+	s.sendShutdown(time.Second * 2)
+
+	s.env.ExecuteWorkflow(AgenticWorkflow, testInput("Hello"))
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	s.env.AssertNotCalled(s.T(), "ExecuteTool", mock.Anything, mock.Anything)
+	tc := turnCompleteItem(s.queryItems())
+	require.NotNil(s.T(), tc)
+	assert.Nil(s.T(), tc.Verify)
+}
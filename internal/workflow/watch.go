@@ -0,0 +1,124 @@
+// Package workflow contains Temporal workflow definitions.
+//
+// watch.go tells the model about files the user changed outside the
+// session, when watch_workspace is set. A watcher on the session's worker
+// records changes; at the start of each turn they are added to history as a
+// developer message. Changes made while tools run are the agent's own and
+// are discarded after each tool batch.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workspace"
+)
+
+// maxListedChanges caps the file names listed per kind in the message.
+const maxListedChanges = 50
+
+// watchingWorkspace reports whether this session reports external edits.
+// Subagents share their parent's tree, so only the root session watches.
+func (s *SessionState) watchingWorkspace() bool {
+	return s.Config.WatchWorkspace && (s.AgentCtl == nil || s.AgentCtl.ParentDepth == 0)
+}
+
+// injectWorkspaceChanges adds a developer message listing the files changed
+// outside the session since the last collection. The first call starts the
+// watch. Failures are logged; the turn goes ahead without the message.
+func (s *SessionState) injectWorkspaceChanges(ctx workflow.Context, ctrl *LoopControl) {
+	if !s.watchingWorkspace() {
+		return
+	}
+	out, err := s.collectWorkspaceChanges(ctx, false)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Collecting workspace changes failed", "error", err)
+		return
+	}
+	content := formatWorkspaceChanges(out.Changes, out.Dropped)
+	if content == "" {
+		return
+	}
+	_ = s.History.AddItem(models.ConversationItem{
+		Type:    models.ItemTypeDeveloperMessage,
+		Content: content,
+		TurnID:  ctrl.CurrentTurnID(),
+	})
+	ctrl.NotifyItemAdded()
+}
+
+// discardAgentWorkspaceChanges drops the changes recorded while a tool batch
+// ran, so the agent's own edits are not reported back to it.
+func (s *SessionState) discardAgentWorkspaceChanges(ctx workflow.Context) {
+	if !s.watchingWorkspace() {
+		return
+	}
+	if _, err := s.collectWorkspaceChanges(ctx, true); err != nil {
+		workflow.GetLogger(ctx).Warn("Discarding workspace changes failed", "error", err)
+	}
+}
+
+// collectWorkspaceChanges runs the CollectWorkspaceChanges activity.
+func (s *SessionState) collectWorkspaceChanges(ctx workflow.Context, discard bool) (activities.CollectWorkspaceChangesOutput, error) {
+	opts := workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 2,
+		},
+	}
+	if s.Config.SessionTaskQueue != "" {
+		opts.TaskQueue = s.Config.SessionTaskQueue
+	}
+	var out activities.CollectWorkspaceChangesOutput
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, opts), "CollectWorkspaceChanges",
+		activities.CollectWorkspaceChangesInput{
+			SessionID: s.ConversationID,
+			Cwd:       s.Config.Cwd,
+			Discard:   discard,
+		}).Get(ctx, &out)
+	return out, err
+}
+
+// formatWorkspaceChanges renders changes as the developer message, e.g.
+// "User modified: foo.go, bar_test.go". Returns "" when nothing changed.
+func formatWorkspaceChanges(changes []workspace.Change, dropped int) string {
+	var modified, deleted []string
+	for _, c := range changes {
+		if c.Kind == workspace.ChangeDeleted {
+			deleted = append(deleted, c.Path)
+		} else {
+			modified = append(modified, c.Path)
+		}
+	}
+	var lines []string
+	if len(modified) > 0 {
+		lines = append(lines, "User modified: "+listChanged(modified))
+	}
+	if len(deleted) > 0 {
+		lines = append(lines, "User deleted: "+listChanged(deleted))
+	}
+	if dropped > 0 {
+		lines = append(lines, fmt.Sprintf("%d more files changed.", dropped))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	lines = append(lines, "These files were changed outside this session since your last turn; re-read them before relying on earlier contents.")
+	return strings.Join(lines, "\n")
+}
+
+// listChanged joins paths, eliding past maxListedChanges.
+func listChanged(paths []string) string {
+	if len(paths) <= maxListedChanges {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:maxListedChanges], ", "), len(paths)-maxListedChanges)
+}
@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mfateev/temporal-agent-harness/internal/activities"
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workspace"
+)
+
+func TestFormatWorkspaceChanges(t *testing.T) {
+	assert.Empty(t, formatWorkspaceChanges(nil, 0))
+
+	out := formatWorkspaceChanges([]workspace.Change{
+		{Path: "bar_test.go", Kind: workspace.ChangeModified},
+		{Path: "foo.go", Kind: workspace.ChangeModified},
+		{Path: "old.go", Kind: workspace.ChangeDeleted},
+	}, 3)
+	assert.Contains(t, out, "User modified: bar_test.go, foo.go\n")
+	assert.Contains(t, out, "User deleted: old.go\n")
+	assert.Contains(t, out, "3 more files changed.")
+}
+
+func TestListChanged_Elides(t *testing.T) {
+	paths := make([]string, maxListedChanges+2)
+	for i := range paths {
+		paths[i] = "f.go"
+	}
+	assert.Contains(t, listChanged(paths), "and 2 more")
+}
+
+// TestWatchWorkspace_InjectsExternalEdits verifies files changed outside
+// the session are reported to the model at the start of the next turn, and
+// changes made while tools run are discarded.
+func (s *AgenticWorkflowTestSuite) TestWatchWorkspace_InjectsExternalEdits() {
+	trueVal := true
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(activities.LLMActivityOutput{
+			Items: []models.ConversationItem{{
+				Type: models.ItemTypeFunctionCall, CallID: "call-1", Name: "shell_command",
+				Arguments: `{"command": "gofmt -w foo.go"}`,
+			}},
+			FinishReason: models.FinishReasonToolCalls,
+		}, nil).Once()
+	s.env.OnActivity("ExecuteLLMCall", mock.Anything, mock.Anything).
+		Return(mockLLMStopResponse("Done.", 10), nil).Twice()
+	s.env.OnActivity("ExecuteTool", mock.Anything, mock.Anything).
+		Return(activities.ToolActivityOutput{CallID: "call-1", Content: "ok", Success: &trueVal}, nil).Once()
+
+	collect := func(discard bool) interface{} {
+		return mock.MatchedBy(func(in activities.CollectWorkspaceChangesInput) bool {
+			return in.Discard == discard && in.Cwd == "/repo" && in.SessionID == "test-conv-1"
+		})
+	}
+	s.env.OnActivity("CollectWorkspaceChanges", mock.Anything, collect(false)).
+		Return(activities.CollectWorkspaceChangesOutput{Started: true}, nil).Once()
+	s.env.OnActivity("CollectWorkspaceChanges", mock.Anything, collect(true)).
+		Return(activities.CollectWorkspaceChangesOutput{}, nil).Once()
+	s.env.OnActivity("CollectWorkspaceChanges", mock.Anything, collect(false)).
+		Return(activities.CollectWorkspaceChangesOutput{Changes: []workspace.Change{
+			{Path: "foo.go", Kind: workspace.ChangeModified},
+			{Path: "bar_test.go", Kind: workspace.ChangeModified},
+		}}, nil).Once()
+
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateUserInput, "input-2", noopCallback(), UserInput{Content: "Now run the tests"})
+	}, time.Second*2)
+	s.sendShutdown(time.Second * 5)
+
+	input := testInput("Format foo.go")
+	input.Config.Cwd = "/repo"
+	input.Config.WatchWorkspace = true
+	s.env.ExecuteWorkflow(AgenticWorkflow, input)
+	require.True(s.T(), s.env.IsWorkflowCompleted())
+
+	var notes []string
+	for _, item := range s.queryItems() {
+		if item.Type == models.ItemTypeDeveloperMessage {
+			notes = append(notes, item.Content)
+		}
+	}
+	require.Len(s.T(), notes, 1, "only the second turn sees external edits")
+	assert.Contains(s.T(), notes[0], "User modified: foo.go, bar_test.go")
+}
@@ -0,0 +1,210 @@
+// Package workspace records and restores snapshots of a git working tree.
+//
+// A snapshot is a commit built from a throwaway index, so HEAD, branches and
+// the user's staged changes are never touched. It contains every tracked and
+// untracked (non-ignored) file and is pinned under refs/agent-snapshots/ so
+// git gc keeps it. Restoring writes the snapshot's files back and deletes
+// files created after it — like `git stash apply` for the whole tree.
+//
+// NOTE: Temporal-specific addition. Plays the role of Codex's ghost commits.
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RefPrefix is the ref namespace that pins snapshot commits.
+const RefPrefix = "refs/agent-snapshots/"
+
+// ErrNotGitRepo is returned when the directory is not inside a git work tree.
+var ErrNotGitRepo = errors.New("not a git repository")
+
+// snapshotIdentity is used for snapshot commits so they work in repos where
+// user.name / user.email are not configured.
+var snapshotIdentity = []string{
+	"GIT_AUTHOR_NAME=agent-harness",
+	"GIT_AUTHOR_EMAIL=agent-harness@localhost",
+	"GIT_COMMITTER_NAME=agent-harness",
+	"GIT_COMMITTER_EMAIL=agent-harness@localhost",
+}
+
+// RestoreResult lists the paths (relative to the repo root) changed by Restore.
+type RestoreResult struct {
+	Restored []string // Files rewritten to their snapshot content
+	Removed  []string // Files deleted because they did not exist in the snapshot
+}
+
+// Take snapshots the working tree containing dir and pins it as
+// RefPrefix+id. Returns the snapshot commit hash.
+func Take(ctx context.Context, dir, id, message string) (string, error) {
+	root, err := repoRoot(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	tree, err := worktreeTree(ctx, root)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", tree, "-m", message}
+	if head, err := runGit(ctx, root, nil, "rev-parse", "--verify", "-q", "HEAD"); err == nil && head != "" {
+		args = append(args, "-p", head)
+	}
+	commit, err := runGit(ctx, root, snapshotIdentity, args...)
+	if err != nil {
+		return "", fmt.Errorf("commit snapshot: %w", err)
+	}
+	if _, err := runGit(ctx, root, nil, "update-ref", RefPrefix+id, commit); err != nil {
+		return "", fmt.Errorf("pin snapshot: %w", err)
+	}
+	return commit, nil
+}
+
+// Restore makes the working tree containing dir match the snapshot commit:
+// files that differ are rewritten and files created since are removed.
+// Ignored files, HEAD and the real index are left alone.
+func Restore(ctx context.Context, dir, commit string) (RestoreResult, error) {
+	root, err := repoRoot(ctx, dir)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	current, err := worktreeTree(ctx, root)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	diff, err := runGit(ctx, root, nil, "diff-tree", "-r", "--no-renames", "--name-status", "-z", commit+"^{tree}", current)
+	if err != nil {
+		return RestoreResult{}, fmt.Errorf("diff against snapshot: %w", err)
+	}
+
+	var result RestoreResult
+	fields := strings.Split(strings.TrimSuffix(diff, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], fields[i+1]
+		if status == "A" {
+			result.Removed = append(result.Removed, path)
+		} else {
+			result.Restored = append(result.Restored, path)
+		}
+	}
+
+	for _, path := range result.Removed {
+		if err := os.Remove(filepath.Join(root, path)); err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("remove %s: %w", path, err)
+		}
+		removeEmptyParents(root, filepath.Dir(path))
+	}
+
+	if len(result.Restored) > 0 {
+		idx, cleanup, err := tempIndex()
+		if err != nil {
+			return result, err
+		}
+		defer cleanup()
+		env := []string{"GIT_INDEX_FILE=" + idx}
+		if _, err := runGit(ctx, root, env, "read-tree", commit); err != nil {
+			return result, fmt.Errorf("read snapshot: %w", err)
+		}
+		stdin := strings.Join(result.Restored, "\x00") + "\x00"
+		if _, err := runGitStdin(ctx, root, env, stdin, "checkout-index", "-f", "-z", "--stdin"); err != nil {
+			return result, fmt.Errorf("write snapshot files: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// Delete removes the pin for a snapshot so git gc can collect it.
+func Delete(ctx context.Context, dir, id string) error {
+	root, err := repoRoot(ctx, dir)
+	if err != nil {
+		return err
+	}
+	_, err = runGit(ctx, root, nil, "update-ref", "-d", RefPrefix+id)
+	return err
+}
+
+// removeEmptyParents deletes now-empty directories from rel up to (not
+// including) root. os.Remove fails on non-empty dirs, which stops the walk.
+func removeEmptyParents(root, rel string) {
+	for rel != "." && rel != string(filepath.Separator) {
+		if err := os.Remove(filepath.Join(root, rel)); err != nil {
+			return
+		}
+		rel = filepath.Dir(rel)
+	}
+}
+
+// repoRoot returns the top-level directory of the work tree containing dir.
+func repoRoot(ctx context.Context, dir string) (string, error) {
+	root, err := runGit(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return "", ErrNotGitRepo
+	}
+	return root, nil
+}
+
+// worktreeTree writes the current working tree (tracked and untracked,
+// honoring .gitignore) as a tree object and returns its hash. The real index
+// is copied to a temp file first so only changed files are re-hashed.
+func worktreeTree(ctx context.Context, root string) (string, error) {
+	idx, cleanup, err := tempIndex()
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	if realIndex, err := runGit(ctx, root, nil, "rev-parse", "--path-format=absolute", "--git-path", "index"); err == nil {
+		if data, err := os.ReadFile(realIndex); err == nil {
+			_ = os.WriteFile(idx, data, 0o600)
+		}
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + idx}
+	if _, err := runGit(ctx, root, env, "add", "-A", "--", "."); err != nil {
+		return "", fmt.Errorf("stage working tree: %w", err)
+	}
+	tree, err := runGit(ctx, root, env, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("write tree: %w", err)
+	}
+	return tree, nil
+}
+
+// tempIndex returns a path for a throwaway index file (not yet created) and
+// a cleanup func.
+func tempIndex() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "agent-snapshot-")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp index dir: %w", err)
+	}
+	return filepath.Join(dir, "index"), func() { os.RemoveAll(dir) }, nil
+}
+
+func runGit(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	return runGitStdin(ctx, dir, env, "", args...)
+}
+
+// runGitStdin runs git in dir with extra env vars and returns trimmed stdout.
+func runGitStdin(ctx context.Context, dir string, env []string, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
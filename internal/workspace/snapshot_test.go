@@ -0,0 +1,118 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func gitOut(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(context.Background(), dir, nil, args...)
+	require.NoError(t, err)
+	return out
+}
+
+func TestTakeAndRestore_UndoesEditsCreatesAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, "tracked.txt", "v1")
+	writeFile(t, dir, ".gitignore", "ignored.txt\n")
+	gitOut(t, dir, "add", "-A")
+	gitOut(t, dir, "commit", "-q", "-m", "init")
+	writeFile(t, dir, "untracked.txt", "keep me")
+	writeFile(t, dir, "doomed.txt", "will be deleted")
+	writeFile(t, dir, "staged.txt", "staged")
+	gitOut(t, dir, "add", "staged.txt")
+	headBefore := gitOut(t, dir, "rev-parse", "HEAD")
+
+	commit, err := Take(ctx, dir, "conv-1/snap-1", "before turn-1")
+	require.NoError(t, err)
+	assert.Equal(t, commit, gitOut(t, dir, "rev-parse", RefPrefix+"conv-1/snap-1"))
+
+	// Simulate a turn's edits.
+	writeFile(t, dir, "tracked.txt", "v2")
+	writeFile(t, dir, "new/dir/created.txt", "new")
+	require.NoError(t, os.Remove(filepath.Join(dir, "doomed.txt")))
+	writeFile(t, dir, "ignored.txt", "ignored")
+
+	result, err := Restore(ctx, dir, commit)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"tracked.txt", "doomed.txt"}, result.Restored)
+	assert.Equal(t, []string{"new/dir/created.txt"}, result.Removed)
+
+	assert.Equal(t, "v1", readFile(t, dir, "tracked.txt"))
+	assert.Equal(t, "will be deleted", readFile(t, dir, "doomed.txt"))
+	assert.Equal(t, "keep me", readFile(t, dir, "untracked.txt"))
+	assert.Equal(t, "ignored", readFile(t, dir, "ignored.txt"), "ignored files are untouched")
+	assert.NoDirExists(t, filepath.Join(dir, "new"))
+
+	// HEAD and the user's staged changes are untouched.
+	assert.Equal(t, headBefore, gitOut(t, dir, "rev-parse", "HEAD"))
+	assert.Equal(t, "staged.txt", gitOut(t, dir, "diff", "--cached", "--name-only"))
+}
+
+func TestTake_RepoWithoutCommits(t *testing.T) {
+	dir := initRepo(t)
+	writeFile(t, dir, "a.txt", "a")
+
+	commit, err := Take(context.Background(), dir, "s1", "snap")
+	require.NoError(t, err)
+
+	writeFile(t, dir, "a.txt", "changed")
+	_, err = Restore(context.Background(), dir, commit)
+	require.NoError(t, err)
+	assert.Equal(t, "a", readFile(t, dir, "a.txt"))
+}
+
+func TestTake_NotGitRepo(t *testing.T) {
+	_, err := Take(context.Background(), t.TempDir(), "s1", "snap")
+	assert.ErrorIs(t, err, ErrNotGitRepo)
+}
+
+func TestDelete_RemovesRef(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, "a.txt", "a")
+	_, err := Take(ctx, dir, "s1", "snap")
+	require.NoError(t, err)
+
+	require.NoError(t, Delete(ctx, dir, "s1"))
+	_, err = runGit(ctx, dir, nil, "rev-parse", "--verify", "-q", RefPrefix+"s1")
+	assert.Error(t, err)
+}
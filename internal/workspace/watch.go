@@ -0,0 +1,193 @@
+package workspace
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSkipDirs are never watched: VCS metadata, the agent's own state and
+// dependency trees that tools rewrite wholesale.
+var watchSkipDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, ".codex": true,
+	"node_modules": true, "vendor": true, "target": true, "__pycache__": true,
+}
+
+// Limits that keep a watcher cheap on large trees.
+const (
+	maxWatchedDirs    = 4096 // Directories beyond this are not watched
+	maxPendingChanges = 500  // Paths beyond this are only counted
+)
+
+// ChangeKind is how a watched file changed.
+type ChangeKind string
+
+const (
+	ChangeModified ChangeKind = "modified" // Created or written
+	ChangeDeleted  ChangeKind = "deleted"  // Removed or renamed away
+)
+
+// Change is one changed file, relative to the watched root.
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+}
+
+// Watcher records which files under a root change. Events are coalesced
+// per path until Drain.
+type Watcher struct {
+	root string
+	fsw  *fsnotify.Watcher
+
+	mu      sync.Mutex
+	pending map[string]bool // Path -> first seen as a create
+	dropped int
+	dirs    int
+}
+
+// Watch starts watching the tree under root.
+func Watch(root string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{root: root, fsw: fsw, pending: make(map[string]bool)}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Root returns the watched directory.
+func (w *Watcher) Root() string {
+	return w.root
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+// Drain returns the files changed since the last Drain, sorted by path,
+// and how many more changed beyond the pending limit. A file's kind is
+// its state now: files created and removed again in between are omitted.
+func (w *Watcher) Drain() ([]Change, int) {
+	w.mu.Lock()
+	pending, dropped := w.pending, w.dropped
+	w.pending, w.dropped = make(map[string]bool), 0
+	w.mu.Unlock()
+
+	var changes []Change
+	for rel, created := range pending {
+		info, err := os.Stat(filepath.Join(w.root, filepath.FromSlash(rel)))
+		switch {
+		case err == nil && info.IsDir():
+			continue
+		case err == nil:
+			changes = append(changes, Change{Path: rel, Kind: ChangeModified})
+		case !created:
+			changes = append(changes, Change{Path: rel, Kind: ChangeDeleted})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, dropped
+}
+
+// loop records events until the watcher is closed.
+func (w *Watcher) loop() {
+	for {
+		select {
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handle(ev)
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			// Overflow and similar errors lose events; nothing to recover.
+		}
+	}
+}
+
+// handle records one event.
+func (w *Watcher) handle(ev fsnotify.Event) {
+	if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+		return
+	}
+	rel, err := filepath.Rel(w.root, ev.Name)
+	if err != nil || strings.HasPrefix(rel, "..") || ignoredEditorFile(filepath.Base(rel)) {
+		return
+	}
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			if !watchSkipDirs[info.Name()] {
+				_ = w.addTree(ev.Name)
+			}
+			return
+		}
+	}
+	rel = filepath.ToSlash(rel)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, seen := w.pending[rel]; seen {
+		return
+	}
+	if len(w.pending) >= maxPendingChanges {
+		w.dropped++
+		return
+	}
+	w.pending[rel] = ev.Has(fsnotify.Create)
+}
+
+// addTree watches dir and its subdirectories, skipping watchSkipDirs.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // Unreadable subdirectories are not watched
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && watchSkipDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		w.mu.Lock()
+		full := w.dirs >= maxWatchedDirs
+		if !full {
+			w.dirs++
+		}
+		w.mu.Unlock()
+		if full {
+			return filepath.SkipAll
+		}
+		if err := w.fsw.Add(path); err != nil && path == dir {
+			return err
+		} else if errors.Is(err, fs.ErrNotExist) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// ignoredEditorFile reports whether name is an editor's swap, backup or
+// atomic-save temporary file.
+func ignoredEditorFile(name string) bool {
+	return strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".swx") ||
+		strings.HasPrefix(name, ".#") || name == "4913" ||
+		strings.HasSuffix(name, ".tmp")
+}
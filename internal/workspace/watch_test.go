@@ -0,0 +1,67 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainUntil drains w until it reports want changes or a timeout passes.
+func drainUntil(t *testing.T, w *Watcher, want int) []Change {
+	t.Helper()
+	var got []Change
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		changes, _ := w.Drain()
+		got = append(got, changes...)
+		if len(got) >= want {
+			return got
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return got
+}
+
+func TestWatch_ReportsChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "foo.go", "package foo\n")
+	writeFile(t, dir, "gone.go", "package foo\n")
+	writeFile(t, dir, "node_modules/dep/index.js", "x")
+
+	w, err := Watch(dir)
+	require.NoError(t, err)
+	defer w.Close()
+
+	writeFile(t, dir, "foo.go", "package foo // edited\n")
+	writeFile(t, dir, "node_modules/dep/index.js", "y")
+	writeFile(t, dir, ".foo.go.swp", "swap")
+	require.NoError(t, os.Remove(filepath.Join(dir, "gone.go")))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+	time.Sleep(100 * time.Millisecond) // Let the new directory be watched
+	writeFile(t, dir, "pkg/bar_test.go", "package pkg\n")
+	writeFile(t, dir, "tmp.txt", "x")
+	require.NoError(t, os.Remove(filepath.Join(dir, "tmp.txt")))
+	time.Sleep(200 * time.Millisecond) // Let every event arrive
+
+	got := drainUntil(t, w, 3)
+	assert.ElementsMatch(t, []Change{
+		{Path: "foo.go", Kind: ChangeModified},
+		{Path: "gone.go", Kind: ChangeDeleted},
+		{Path: "pkg/bar_test.go", Kind: ChangeModified},
+	}, got, "skipped dirs, editor files and short-lived files are not reported")
+
+	changes, dropped := w.Drain()
+	assert.Empty(t, changes, "Drain resets the pending changes")
+	assert.Zero(t, dropped)
+}
+
+func TestIgnoredEditorFile(t *testing.T) {
+	for _, name := range []string{"main.go~", ".main.go.swp", ".#main.go", "4913", "x.tmp"} {
+		assert.True(t, ignoredEditorFile(name), name)
+	}
+	assert.False(t, ignoredEditorFile("main.go"))
+}
@@ -0,0 +1,121 @@
+package harness
+
+import (
+	"context"
+
+	"github.com/mfateev/temporal-agent-harness/internal/watch"
+)
+
+// Event is one change in a session, as printed by client watch --json.
+// Only the fields of its Type are set.
+type Event struct {
+	Type       string `json:"type"`
+	WorkflowID string `json:"workflow_id"`
+	TurnID     string `json:"turn_id,omitempty"`
+
+	Item *Item `json:"item,omitempty"` // item_added
+
+	Phase         Phase `json:"phase,omitempty"`          // phase_change
+	PreviousPhase Phase `json:"previous_phase,omitempty"` // phase_change
+
+	Approvals   []PendingApproval   `json:"approvals,omitempty"`    // approval_pending
+	Escalations []EscalationRequest `json:"escalations,omitempty"`  // escalation_pending
+	UserInput   *PendingUserInput   `json:"user_input,omitempty"`   // user_input_pending
+	AskUser     *PendingAskUser     `json:"ask_user,omitempty"`     // user_input_pending
+	TurnEpoch   int                 `json:"turn_epoch,omitempty"`   // approval_pending, escalation_pending: echo in the response
+	TotalTokens int                 `json:"total_tokens,omitempty"` // turn_complete
+	TurnCount   int                 `json:"turn_count,omitempty"`   // turn_complete
+
+	Standby string `json:"standby,omitempty"` // degraded
+	Error   string `json:"error,omitempty"`   // error
+}
+
+// Event types.
+const (
+	EventItemAdded         = watch.EventItemAdded
+	EventPhaseChange       = watch.EventPhaseChange
+	EventApprovalPending   = watch.EventApprovalPending
+	EventEscalationPending = watch.EventEscalationPending
+	EventUserInputPending  = watch.EventUserInputPending
+	EventTurnComplete      = watch.EventTurnComplete
+	EventHistoryReset      = watch.EventHistoryReset
+	EventDegraded          = watch.EventDegraded
+	EventReconnected       = watch.EventReconnected
+	EventSessionComplete   = watch.EventSessionComplete
+	EventError             = watch.EventError
+)
+
+// newEvent converts an event of the watch stream.
+func newEvent(e watch.Event) Event {
+	ev := Event{
+		Type:          e.Type,
+		WorkflowID:    e.WorkflowID,
+		TurnID:        e.TurnID,
+		Item:          (*Item)(e.Item),
+		Phase:         Phase(e.Phase),
+		PreviousPhase: Phase(e.PreviousPhase),
+		UserInput:     (*PendingUserInput)(e.UserInput),
+		AskUser:       (*PendingAskUser)(e.AskUser),
+		TurnEpoch:     e.TurnEpoch,
+		TotalTokens:   e.TotalTokens,
+		TurnCount:     e.TurnCount,
+		Standby:       e.Standby,
+		Error:         e.Error,
+	}
+	for _, a := range e.Approvals {
+		ev.Approvals = append(ev.Approvals, PendingApproval(a))
+	}
+	for _, esc := range e.Escalations {
+		ev.Escalations = append(ev.Escalations, EscalationRequest(esc))
+	}
+	return ev
+}
+
+// Events streams the session's events: its items so far, then new items,
+// phase changes, pending requests and completed turns. The channel is
+// closed after session_complete, when ctx is done, or after an error event
+// once the watch gives up retrying. Read it until it is closed.
+func (s *Session) Events(ctx context.Context) <-chan Event {
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		stream := watch.NewEventStream(s.id)
+		send := func(result watch.Result) bool {
+			for _, e := range stream.Events(result) {
+				select {
+				case out <- newEvent(e):
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		poll := watch.NewPoller(s.c.c, s.id, 0).Poll(ctx)
+		if poll.Err != nil {
+			send(watch.Result{Err: poll.Err})
+			return
+		}
+		if !send(watch.Result{Items: poll.Items, Status: poll.Status}) {
+			return
+		}
+
+		sinceSeq := -1
+		if n := len(poll.Items); n > 0 {
+			sinceSeq = poll.Items[n-1].Seq
+		}
+		results := make(chan watch.Result)
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			defer close(results)
+			watch.NewWatcher(s.c.c, s.id).RunWatching(watchCtx, results, sinceSeq, poll.Status.Phase)
+		}()
+		for result := range results {
+			if !send(result) {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,376 @@
+// Package harness is a Go client for agent sessions run by the harness
+// worker. It wraps the session workflow's Updates and Queries in a typed
+// API, so other services can start sessions, send input, follow their items
+// and answer approvals without shelling out to cmd/client or copying it.
+//
+//	hc, err := harness.Dial(harness.Options{})
+//	if err != nil { ... }
+//	defer hc.Close()
+//	sess, err := hc.Start(ctx, harness.StartOptions{
+//		Message: "Fix the failing test in ./internal/foo",
+//		Config:  harness.DefaultSessionConfig("gpt-4o", "/src/repo"),
+//	})
+//	for e := range sess.Events(ctx) {
+//		if e.Type == harness.EventApprovalPending { ... sess.Approve(...) }
+//	}
+//
+// The payload types are defined on the workflow's own, so values read from
+// a session can be sent back unchanged.
+//
+// NOTE: Temporal-specific addition (not in Codex Rust).
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/temporalclient"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// DefaultTaskQueue is the task queue the worker polls.
+const DefaultTaskQueue = "temporal-agent-harness"
+
+// Session payloads. Each is defined on the workflow's own type, with the
+// same fields and JSON encoding, so values read from a session can be sent
+// back unchanged.
+type (
+	// Item is one entry of a session's conversation history.
+	Item models.ConversationItem
+	// SessionConfig configures a new session.
+	SessionConfig models.SessionConfiguration
+	// TurnStatus is the session's current turn, as the get_turn_status
+	// query reports it.
+	TurnStatus workflow.TurnStatus
+	// PendingApproval is a tool call waiting for approval.
+	PendingApproval workflow.PendingApproval
+	// EscalationRequest is a sandboxed tool call that failed and asks to
+	// re-run without the sandbox.
+	EscalationRequest workflow.EscalationRequest
+	// PendingUserInput is a request_user_input call waiting for answers.
+	PendingUserInput workflow.PendingUserInputRequest
+	// PendingAskUser is an ask_user question waiting for an answer.
+	PendingAskUser workflow.PendingAskUserRequest
+	// UserInput is a message from the user.
+	UserInput workflow.UserInput
+	// DeveloperInput is developer instructions for the agent.
+	DeveloperInput workflow.DeveloperInput
+	// ApprovalResponse answers the pending approvals.
+	ApprovalResponse workflow.ApprovalResponse
+	// EscalationResponse answers the pending escalations.
+	EscalationResponse workflow.EscalationResponse
+	// AskUserResponse answers an ask_user question.
+	AskUserResponse workflow.AskUserResponse
+	// UserInputQuestionResponse answers a request_user_input call.
+	UserInputQuestionResponse workflow.UserInputQuestionResponse
+	// Result is what a session returns when it ends.
+	Result workflow.WorkflowResult
+	// HistoryQuery selects history items for History.
+	HistoryQuery workflow.HistoryQuery
+)
+
+// HistoryPage is one page of History.
+type HistoryPage struct {
+	Items []Item `json:"items"`
+	// NextPageToken fetches the next page; empty on the last one.
+	NextPageToken string `json:"next_page_token,omitempty"`
+	// HistoryEpoch identifies the Seq numbering. A page token is only valid
+	// in the epoch that issued it.
+	HistoryEpoch int `json:"history_epoch"`
+}
+
+// Phase is what a session's current turn is doing.
+type Phase string
+
+// Turn phases reported in TurnStatus.Phase.
+const (
+	PhaseWaitingForInput   = Phase(workflow.PhaseWaitingForInput)
+	PhaseLLMCalling        = Phase(workflow.PhaseLLMCalling)
+	PhaseLLMQueued         = Phase(workflow.PhaseLLMQueued)
+	PhaseRateLimited       = Phase(workflow.PhaseRateLimited)
+	PhaseToolExecuting     = Phase(workflow.PhaseToolExecuting)
+	PhaseApprovalPending   = Phase(workflow.PhaseApprovalPending)
+	PhaseEscalationPending = Phase(workflow.PhaseEscalationPending)
+	PhaseUserInputPending  = Phase(workflow.PhaseUserInputPending)
+	PhaseCompacting        = Phase(workflow.PhaseCompacting)
+	PhaseWaitingForAgents  = Phase(workflow.PhaseWaitingForAgents)
+	PhaseVerifying         = Phase(workflow.PhaseVerifying)
+	PhaseReviewing         = Phase(workflow.PhaseReviewing)
+	PhaseCheckingIn        = Phase(workflow.PhaseCheckingIn)
+)
+
+// CurrentPhase returns s.Phase as a Phase.
+func (s TurnStatus) CurrentPhase() Phase { return Phase(s.Phase) }
+
+// Options configures a Client.
+type Options struct {
+	TaskQueue string // "" is DefaultTaskQueue
+
+	// HostPort and Namespace override the Temporal connection settings Dial
+	// reads from the environment (TEMPORAL_HOST_URL, TEMPORAL_NAMESPACE,
+	// config.toml).
+	HostPort  string
+	Namespace string
+}
+
+// Client starts and opens sessions.
+type Client struct {
+	c         client.Client
+	taskQueue string
+	owned     bool // c was dialed by Dial and is closed by Close
+}
+
+// NewClient creates a Client using an existing Temporal client, which the
+// caller keeps ownership of.
+func NewClient(c client.Client, opts Options) *Client {
+	taskQueue := opts.TaskQueue
+	if taskQueue == "" {
+		taskQueue = DefaultTaskQueue
+	}
+	return &Client{c: c, taskQueue: taskQueue}
+}
+
+// Dial connects to Temporal the way the harness CLI does: connection
+// settings from the environment, payload encryption with
+// TEMPORAL_CODEC_KEY, and caller proofs from TCX_IDENTITY, TCX_CALLER_KEY
+// and TCX_SESSION_TOKEN.
+func Dial(opts Options) (*Client, error) {
+	clientOpts, err := temporalclient.LoadClientOptions(opts.HostPort, opts.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("load Temporal client options: %w", err)
+	}
+	c, err := client.Dial(clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("dial Temporal: %w", err)
+	}
+	hc := NewClient(c, opts)
+	hc.owned = true
+	return hc, nil
+}
+
+// Close closes the Temporal client if Dial created it.
+func (c *Client) Close() {
+	if c.owned {
+		c.c.Close()
+	}
+}
+
+// DefaultSessionConfig returns the configuration client start uses: model
+// with default sampling settings, the default tools, and cwd as the working
+// directory on the worker.
+func DefaultSessionConfig(model, cwd string) SessionConfig {
+	return SessionConfig{
+		Model: models.ModelConfig{
+			Model:         model,
+			Temperature:   0.7,
+			MaxTokens:     4096,
+			ContextWindow: 128000,
+		},
+		Tools: models.DefaultToolsConfig(),
+		Cwd:   cwd,
+	}
+}
+
+// StartOptions describes a new session.
+type StartOptions struct {
+	ID      string // Workflow ID; "" generates "codex-<random>"
+	Message string // First user message
+	Config  SessionConfig
+}
+
+// Start starts a session and returns it without waiting for the first turn
+// to run. The first message is a user_input Update sent with the start
+// (Update-with-Start), so one round-trip starts the session, and once Start
+// returns the session's handlers answer queries.
+func (c *Client) Start(ctx context.Context, opts StartOptions) (*Session, error) {
+	if opts.Message == "" {
+		return nil, errors.New("harness: message is required")
+	}
+	id := opts.ID
+	if id == "" {
+		id = "codex-" + uuid.New().String()[:8]
+	}
+	cfg := opts.Config
+	if cfg.SessionSource == "" {
+		cfg.SessionSource = "api"
+	}
+	startOp := c.c.NewWithStartWorkflowOperation(client.StartWorkflowOptions{
+		ID:                       id,
+		TaskQueue:                c.taskQueue,
+		WorkflowIDConflictPolicy: enums.WORKFLOW_ID_CONFLICT_POLICY_FAIL,
+	}, "AgenticWorkflow", workflow.WorkflowInput{
+		ConversationID: id,
+		Config:         models.SessionConfiguration(cfg),
+	})
+	_, err := c.c.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+		StartWorkflowOperation: startOp,
+		UpdateOptions: client.UpdateWorkflowOptions{
+			WorkflowID:   id,
+			UpdateName:   workflow.UpdateUserInput,
+			Args:         []interface{}{workflow.UserInput{Content: opts.Message, IdempotencyKey: uuid.NewString()}},
+			WaitForStage: client.WorkflowUpdateStageAccepted,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	return c.Session(id), nil
+}
+
+// Session returns a handle to an existing session. It does not check that
+// the session exists.
+func (c *Client) Session(workflowID string) *Session {
+	return &Session{c: c, id: workflowID}
+}
+
+// Session is a handle to one session.
+type Session struct {
+	c  *Client
+	id string
+}
+
+// ID returns the session's workflow ID.
+func (s *Session) ID() string { return s.id }
+
+// Send sends the user's next message and returns the turn it starts, or
+// the turn it was queued into. An empty IdempotencyKey is filled with a
+// random one; set it to make retries safe.
+func (s *Session) Send(ctx context.Context, input UserInput) (string, error) {
+	if input.IdempotencyKey == "" {
+		input.IdempotencyKey = uuid.NewString()
+	}
+	var resp workflow.StateUpdateResponse
+	if err := s.update(ctx, workflow.UpdateUserInput, workflow.UserInput(input), &resp); err != nil {
+		return "", err
+	}
+	return resp.TurnID, nil
+}
+
+// SendDeveloper sends developer instructions, which steer the agent
+// without posting as the user, and returns the turn that sees them.
+func (s *Session) SendDeveloper(ctx context.Context, input DeveloperInput) (string, error) {
+	var resp workflow.DeveloperInputResponse
+	if err := s.update(ctx, workflow.UpdateDeveloperInput, workflow.DeveloperInput(input), &resp); err != nil {
+		return "", err
+	}
+	return resp.TurnID, nil
+}
+
+// Approve answers the pending tool approvals. Set TurnEpoch from the
+// approval_pending event or TurnStatus so an answer racing an interrupt is
+// rejected (see IsStale) instead of approving a later turn's calls.
+func (s *Session) Approve(ctx context.Context, resp ApprovalResponse) error {
+	var ack workflow.ApprovalResponseAck
+	return s.update(ctx, workflow.UpdateApprovalResponse, workflow.ApprovalResponse(resp), &ack)
+}
+
+// Escalate answers the pending escalations: approved calls re-run without
+// the sandbox. TurnEpoch is as in Approve.
+func (s *Session) Escalate(ctx context.Context, resp EscalationResponse) error {
+	var ack workflow.EscalationResponseAck
+	return s.update(ctx, workflow.UpdateEscalationResponse, workflow.EscalationResponse(resp), &ack)
+}
+
+// AnswerAskUser answers a pending ask_user question.
+func (s *Session) AnswerAskUser(ctx context.Context, resp AskUserResponse) error {
+	var ack workflow.AskUserResponseAck
+	return s.update(ctx, workflow.UpdateAskUserResponse, workflow.AskUserResponse(resp), &ack)
+}
+
+// AnswerQuestions answers a pending request_user_input call.
+func (s *Session) AnswerQuestions(ctx context.Context, resp UserInputQuestionResponse) error {
+	var ack workflow.UserInputQuestionResponseAck
+	return s.update(ctx, workflow.UpdateUserInputQuestionResponse, workflow.UserInputQuestionResponse(resp), &ack)
+}
+
+// Interrupt stops the current turn.
+func (s *Session) Interrupt(ctx context.Context) error {
+	var resp workflow.InterruptResponse
+	return s.update(ctx, workflow.UpdateInterrupt, workflow.InterruptRequest{}, &resp)
+}
+
+// End shuts the session down. Wait returns its result.
+func (s *Session) End(ctx context.Context, reason string) error {
+	var resp workflow.ShutdownResponse
+	return s.update(ctx, workflow.UpdateShutdown, workflow.ShutdownRequest{Reason: reason}, &resp)
+}
+
+// Status returns the session's turn status.
+func (s *Session) Status(ctx context.Context) (TurnStatus, error) {
+	var status TurnStatus
+	err := s.query(ctx, workflow.QueryGetTurnStatus, &status)
+	return status, err
+}
+
+// Items returns the session's conversation items.
+func (s *Session) Items(ctx context.Context) ([]Item, error) {
+	var items []Item
+	err := s.query(ctx, workflow.QueryGetConversationItems, &items)
+	return items, err
+}
+
+// History returns a page of the session's conversation items that match q.
+// Pass the page's NextPageToken in q.PageToken for the next one.
+func (s *Session) History(ctx context.Context, q HistoryQuery) (HistoryPage, error) {
+	var page HistoryPage
+	err := s.query(ctx, workflow.QueryGetHistory, &page, workflow.HistoryQuery(q))
+	return page, err
+}
+
+// Wait waits for the session to end and returns its result.
+func (s *Session) Wait(ctx context.Context) (Result, error) {
+	var result Result
+	if err := s.c.c.GetWorkflow(ctx, s.id, "").Get(ctx, &result); err != nil {
+		return Result{}, fmt.Errorf("wait for session %s: %w", s.id, err)
+	}
+	return result, nil
+}
+
+func (s *Session) update(ctx context.Context, name string, arg, result interface{}) error {
+	handle, err := s.c.c.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   s.id,
+		UpdateName:   name,
+		Args:         []interface{}{arg},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return fmt.Errorf("%s update: %w", name, err)
+	}
+	if err := handle.Get(ctx, result); err != nil {
+		return fmt.Errorf("%s update: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Session) query(ctx context.Context, name string, result interface{}, args ...interface{}) error {
+	resp, err := s.c.c.QueryWorkflow(ctx, s.id, "", name, args...)
+	if err != nil {
+		return fmt.Errorf("%s query: %w", name, err)
+	}
+	if err := resp.Get(result); err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// IsStale reports whether err is an approval or escalation answer the
+// session rejected because the turn it answered was interrupted or already
+// answered.
+func IsStale(err error) bool {
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && appErr.Type() == workflow.StaleResponseErrorType
+}
+
+// IsAccessDenied reports whether err is the session refusing the caller
+// under its access control.
+func IsAccessDenied(err error) bool {
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && appErr.Type() == workflow.AccessDeniedErrorType
+}
@@ -0,0 +1,246 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/mfateev/temporal-agent-harness/internal/models"
+	"github.com/mfateev/temporal-agent-harness/internal/workflow"
+)
+
+// jsonValue is a converter.EncodedValue holding v.
+type jsonValue struct{ v interface{} }
+
+func (j jsonValue) HasValue() bool { return j.v != nil }
+
+func (j jsonValue) Get(ptr interface{}) error {
+	data, err := json.Marshal(j.v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, ptr)
+}
+
+// updateHandle is a completed Update.
+type updateHandle struct {
+	client.WorkflowUpdateHandle
+	result interface{}
+	err    error
+}
+
+func (h updateHandle) Get(_ context.Context, ptr interface{}) error {
+	if h.err != nil {
+		return h.err
+	}
+	return jsonValue{h.result}.Get(ptr)
+}
+
+// fakeClient records what it is sent and answers from fixed state.
+type fakeClient struct {
+	client.Client
+
+	mu        sync.Mutex
+	started   []workflow.WorkflowInput
+	startOpts []client.StartWorkflowOptions
+	updates   []client.UpdateWorkflowOptions
+
+	items   []models.ConversationItem
+	status  workflow.TurnStatus
+	history []workflow.HistoryQuery
+	replies map[string]interface{}
+	errs    map[string]error
+}
+
+// startOperation is the start half of an Update-with-Start.
+type startOperation struct {
+	client.WithStartWorkflowOperation
+	opts  client.StartWorkflowOptions
+	input workflow.WorkflowInput
+}
+
+func (c *fakeClient) NewWithStartWorkflowOperation(opts client.StartWorkflowOptions, _ interface{}, args ...interface{}) client.WithStartWorkflowOperation {
+	return &startOperation{opts: opts, input: args[0].(workflow.WorkflowInput)}
+}
+
+func (c *fakeClient) UpdateWithStartWorkflow(_ context.Context, opts client.UpdateWithStartWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	op := opts.StartWorkflowOperation.(*startOperation)
+	c.startOpts = append(c.startOpts, op.opts)
+	c.started = append(c.started, op.input)
+	c.updates = append(c.updates, opts.UpdateOptions)
+	return updateHandle{}, nil
+}
+
+func (c *fakeClient) UpdateWorkflow(_ context.Context, opts client.UpdateWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updates = append(c.updates, opts)
+	return updateHandle{result: c.replies[opts.UpdateName], err: c.errs[opts.UpdateName]}, nil
+}
+
+func (c *fakeClient) QueryWorkflow(_ context.Context, _, _, queryType string, args ...interface{}) (converter.EncodedValue, error) {
+	switch queryType {
+	case workflow.QueryGetConversationItems:
+		return jsonValue{c.items}, nil
+	case workflow.QueryGetHistory:
+		c.history = append(c.history, args[0].(workflow.HistoryQuery))
+		return jsonValue{workflow.HistoryPage{Items: c.items, NextPageToken: "0.0"}}, nil
+	case workflow.QueryGetTurnStatus:
+		return jsonValue{c.status}, nil
+	}
+	return nil, errors.New("unexpected query " + queryType)
+}
+
+func (c *fakeClient) lastUpdate() client.UpdateWorkflowOptions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.updates[len(c.updates)-1]
+}
+
+func TestStart(t *testing.T) {
+	fc := &fakeClient{}
+	hc := NewClient(fc, Options{})
+
+	_, err := hc.Start(context.Background(), StartOptions{})
+	assert.ErrorContains(t, err, "message is required")
+
+	sess, err := hc.Start(context.Background(), StartOptions{
+		Message: "Fix the build",
+		Config:  DefaultSessionConfig("gpt-4o", "/src/repo"),
+	})
+	require.NoError(t, err)
+	require.Len(t, fc.started, 1)
+	assert.Regexp(t, `^codex-[0-9a-f]{8}$`, sess.ID())
+	assert.Equal(t, sess.ID(), fc.startOpts[0].ID)
+	assert.Equal(t, DefaultTaskQueue, fc.startOpts[0].TaskQueue)
+	assert.Equal(t, enums.WORKFLOW_ID_CONFLICT_POLICY_FAIL, fc.startOpts[0].WorkflowIDConflictPolicy)
+	input := fc.started[0]
+	assert.Equal(t, sess.ID(), input.ConversationID)
+	assert.Empty(t, input.UserMessage, "the first message is sent as an Update with the start")
+	first := fc.lastUpdate()
+	assert.Equal(t, workflow.UpdateUserInput, first.UpdateName)
+	msg := first.Args[0].(workflow.UserInput)
+	assert.Equal(t, "Fix the build", msg.Content)
+	assert.NotEmpty(t, msg.IdempotencyKey)
+	assert.Equal(t, "gpt-4o", input.Config.Model.Model)
+	assert.Equal(t, "/src/repo", input.Config.Cwd)
+	assert.Equal(t, "api", input.Config.SessionSource)
+
+	hc = NewClient(fc, Options{TaskQueue: "gpu-agents"})
+	sess, err = hc.Start(context.Background(), StartOptions{ID: "review-42", Message: "Review"})
+	require.NoError(t, err)
+	assert.Equal(t, "review-42", sess.ID())
+	assert.Equal(t, "gpu-agents", fc.startOpts[1].TaskQueue)
+}
+
+func TestSession_Send(t *testing.T) {
+	fc := &fakeClient{replies: map[string]interface{}{
+		workflow.UpdateUserInput: workflow.StateUpdateResponse{TurnID: "turn-2"},
+	}}
+	sess := NewClient(fc, Options{}).Session("codex-1")
+
+	turnID, err := sess.Send(context.Background(), UserInput{Content: "now run the tests"})
+	require.NoError(t, err)
+	assert.Equal(t, "turn-2", turnID)
+	opts := fc.lastUpdate()
+	assert.Equal(t, "codex-1", opts.WorkflowID)
+	assert.Equal(t, workflow.UpdateUserInput, opts.UpdateName)
+	sent := opts.Args[0].(workflow.UserInput)
+	assert.Equal(t, "now run the tests", sent.Content)
+	assert.NotEmpty(t, sent.IdempotencyKey, "a key is generated")
+
+	_, err = sess.Send(context.Background(), UserInput{Content: "again", IdempotencyKey: "k1"})
+	require.NoError(t, err)
+	assert.Equal(t, "k1", fc.lastUpdate().Args[0].(workflow.UserInput).IdempotencyKey)
+}
+
+func TestSession_ApproveStale(t *testing.T) {
+	fc := &fakeClient{errs: map[string]error{
+		workflow.UpdateApprovalResponse: temporal.NewApplicationError("turn 3 was interrupted", workflow.StaleResponseErrorType),
+		workflow.UpdateShutdown:         temporal.NewApplicationError("caller may not end this session", workflow.AccessDeniedErrorType),
+	}}
+	sess := NewClient(fc, Options{}).Session("codex-1")
+
+	err := sess.Approve(context.Background(), ApprovalResponse{Approved: []string{"call-1"}, TurnEpoch: 3})
+	require.Error(t, err)
+	assert.True(t, IsStale(err))
+	assert.False(t, IsAccessDenied(err))
+	assert.Equal(t, workflow.ApprovalResponse{Approved: []string{"call-1"}, TurnEpoch: 3}, fc.lastUpdate().Args[0])
+
+	err = sess.End(context.Background(), "done")
+	assert.True(t, IsAccessDenied(err))
+	assert.False(t, IsStale(err))
+	assert.False(t, IsStale(errors.New("connection refused")))
+}
+
+func TestSession_StatusAndItems(t *testing.T) {
+	fc := &fakeClient{
+		items:  []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "hi", Seq: 0}},
+		status: workflow.TurnStatus{Phase: workflow.PhaseApprovalPending, TurnEpoch: 2},
+	}
+	sess := NewClient(fc, Options{}).Session("codex-1")
+
+	status, err := sess.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, PhaseApprovalPending, status.CurrentPhase())
+	assert.Equal(t, 2, status.TurnEpoch)
+
+	items, err := sess.Items(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Item{{Type: models.ItemTypeUserMessage, Content: "hi", Seq: 0}}, items)
+
+	q := HistoryQuery{Types: []models.ConversationItemType{models.ItemTypeUserMessage}, TurnID: "turn-1", Limit: 1}
+	page, err := sess.History(context.Background(), q)
+	require.NoError(t, err)
+	assert.Equal(t, items, page.Items)
+	assert.Equal(t, "0.0", page.NextPageToken)
+	assert.Equal(t, []workflow.HistoryQuery{workflow.HistoryQuery(q)}, fc.history)
+}
+
+func TestSession_Events(t *testing.T) {
+	fc := &fakeClient{
+		items:  []models.ConversationItem{{Type: models.ItemTypeUserMessage, Content: "hi", Seq: 0, TurnID: "t1"}},
+		status: workflow.TurnStatus{Phase: workflow.PhaseLLMCalling, CurrentTurnID: "t1"},
+		replies: map[string]interface{}{
+			workflow.UpdateGetStateUpdate: workflow.StateUpdateResponse{
+				Items: []models.ConversationItem{
+					{Type: models.ItemTypeAssistantMessage, Content: "done", Seq: 1, TurnID: "t1"},
+				},
+				Status:    workflow.TurnStatus{Phase: workflow.PhaseWaitingForInput},
+				Completed: true,
+			},
+		},
+	}
+	sess := NewClient(fc, Options{}).Session("codex-1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var types []string
+	var phases []Phase
+	for e := range sess.Events(ctx) {
+		assert.Equal(t, "codex-1", e.WorkflowID)
+		types = append(types, e.Type)
+		if e.Type == EventPhaseChange {
+			phases = append(phases, e.Phase)
+		}
+	}
+	assert.Equal(t, []string{
+		EventItemAdded, EventPhaseChange,
+		EventItemAdded, EventPhaseChange, EventSessionComplete,
+	}, types)
+	assert.Equal(t, []Phase{PhaseLLMCalling, PhaseWaitingForInput}, phases)
+	req := fc.lastUpdate().Args[0].(workflow.StateUpdateRequest)
+	assert.Equal(t, 0, req.SinceSeq, "the watch continues after the polled items")
+}